@@ -0,0 +1,6 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+// Package parsecache contains benchmarks of the configuration loading with
+// and without the on-disk parse cache.
+package parsecache
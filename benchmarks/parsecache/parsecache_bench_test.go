@@ -0,0 +1,90 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package parsecache_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/madlambda/spells/assert"
+	"github.com/rs/zerolog"
+	"github.com/terramate-io/terramate/config"
+	"github.com/terramate-io/terramate/hcl"
+	"github.com/terramate-io/terramate/test/sandbox"
+)
+
+const (
+	nstacks           = 100
+	nglobalsPerStack  = 50
+	benchCacheVersion = "bench"
+)
+
+func BenchmarkLoadRootCold(b *testing.B) {
+	b.StopTimer()
+	rootdir := largeFixture(b)
+	hcl.DisableParseCache()
+
+	b.StartTimer()
+	for i := 0; i < b.N; i++ {
+		root, err := config.LoadRoot(rootdir)
+		assert.NoError(b, err)
+		assert.EqualInts(b, nstacks, len(root.Stacks()))
+	}
+}
+
+func BenchmarkLoadRootWarm(b *testing.B) {
+	b.StopTimer()
+	rootdir := largeFixture(b)
+	hcl.EnableParseCache(benchCacheVersion)
+	defer hcl.DisableParseCache()
+
+	// populates the cache.
+	_, err := config.LoadRoot(rootdir)
+	assert.NoError(b, err)
+
+	b.StartTimer()
+	for i := 0; i < b.N; i++ {
+		root, err := config.LoadRoot(rootdir)
+		assert.NoError(b, err)
+		assert.EqualInts(b, nstacks, len(root.Stacks()))
+	}
+}
+
+func largeFixture(b *testing.B) string {
+	s := sandbox.NoGit(b, true)
+	layout := []string{}
+	for i := 0; i < nstacks; i++ {
+		layout = append(layout, fmt.Sprintf("s:stacks/stack-%d", i))
+	}
+	s.BuildTree(layout)
+
+	for i := 0; i < nstacks; i++ {
+		var globals strings.Builder
+		globals.WriteString("globals {\n")
+		for j := 0; j < nglobalsPerStack; j++ {
+			fmt.Fprintf(&globals, "  str_%d = \"stack-%d-${tm_upper(\"value\")}-%d\"\n", j, i, j)
+			fmt.Fprintf(&globals, "  list_%d = [for v in tm_range(%d) : v * 2 if v %% 2 == 0]\n", j, j)
+			fmt.Fprintf(&globals, "  obj_%d = { a = global.str_%d, b = [1, 2, 3], c = { d = true } }\n", j, j)
+		}
+		globals.WriteString("}\n")
+		stack := s.DirEntry(fmt.Sprintf("stacks/stack-%d", i))
+		stack.CreateFile("globals.tm", globals.String())
+		stack.CreateFile("generate.tm", `
+generate_hcl "main.tf" {
+  content {
+    locals {
+      value = global.str_0
+      items = global.list_10
+    }
+  }
+}
+`)
+	}
+	return s.RootDir()
+}
+
+func init() {
+	zerolog.SetGlobalLevel(zerolog.Disabled)
+}
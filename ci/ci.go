@@ -79,6 +79,26 @@ func DetectPlatformFromEnv() PlatformType {
 	return typ
 }
 
+// IsScheduledEvent tells if the pipeline running in the platform was triggered
+// by a schedule, e.g. a GitHub `schedule` event or a GitLab pipeline schedule.
+// It's always false for the platforms that don't expose the trigger event.
+func IsScheduledEvent(platform PlatformType) bool {
+	switch platform {
+	case PlatformGithub:
+		return os.Getenv("GITHUB_EVENT_NAME") == "schedule"
+	case PlatformGitlab:
+		return os.Getenv("CI_PIPELINE_SOURCE") == "schedule"
+	case PlatformAzureDevops:
+		return os.Getenv("BUILD_REASON") == "Schedule"
+	case PlatformBuildKite:
+		return os.Getenv("BUILDKITE_SOURCE") == "schedule"
+	case PlatformTravis:
+		return os.Getenv("TRAVIS_EVENT_TYPE") == "cron"
+	default:
+		return false
+	}
+}
+
 func (plat PlatformType) String() string {
 	switch plat {
 	case PlatformLocal:
@@ -33,3 +33,51 @@ func TestDetectPlatformFromEnv(t *testing.T) {
 		})
 	}
 }
+
+func TestIsScheduledEvent(t *testing.T) {
+	type testcase struct {
+		platform ci.PlatformType
+		env      map[string]string
+		want     bool
+	}
+
+	for name, tc := range map[string]testcase{
+		"github schedule": {
+			platform: ci.PlatformGithub,
+			env:      map[string]string{"GITHUB_EVENT_NAME": "schedule"},
+			want:     true,
+		},
+		"github push": {
+			platform: ci.PlatformGithub,
+			env:      map[string]string{"GITHUB_EVENT_NAME": "push"},
+		},
+		"gitlab schedule": {
+			platform: ci.PlatformGitlab,
+			env:      map[string]string{"CI_PIPELINE_SOURCE": "schedule"},
+			want:     true,
+		},
+		"gitlab merge request": {
+			platform: ci.PlatformGitlab,
+			env:      map[string]string{"CI_PIPELINE_SOURCE": "merge_request_event"},
+		},
+		"event of another platform": {
+			platform: ci.PlatformGitlab,
+			env:      map[string]string{"GITHUB_EVENT_NAME": "schedule"},
+		},
+		"local": {
+			platform: ci.PlatformLocal,
+			env:      map[string]string{"GITHUB_EVENT_NAME": "schedule"},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			for _, k := range []string{"GITHUB_EVENT_NAME", "CI_PIPELINE_SOURCE"} {
+				t.Setenv(k, "")
+			}
+			for k, v := range tc.env {
+				t.Setenv(k, v)
+			}
+			assert.IsTrue(t, ci.IsScheduledEvent(tc.platform) == tc.want,
+				"IsScheduledEvent() must be %t", tc.want)
+		})
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"math/rand"
 	"mime"
 	"net/http"
 	"net/http/httputil"
@@ -17,6 +18,7 @@ import (
 	"os"
 	"path"
 	"strings"
+	"time"
 
 	hversion "github.com/apparentlymart/go-versions/versions"
 	"github.com/rs/zerolog"
@@ -37,6 +39,10 @@ const BaseURL = "https://" + Host
 
 const defaultPageSize = 50
 
+// MaxChangeReasonSize is the maximum size in bytes of the stack change reason
+// synchronized with the deployments, drifts and previews.
+const MaxChangeReasonSize = 1024
+
 const (
 	// WellKnownCLIPath is the well-known base path.
 	WellKnownCLIPath = "/.well-known/cli.json"
@@ -77,6 +83,11 @@ type (
 		// if not set, a new instance of http.Client is created on the first request.
 		HTTPClient *http.Client
 
+		// MaxAttempts is the maximum number of attempts of idempotent requests
+		// (GET and PUT) failing with a transient error (connection errors and
+		// 5xx responses). If not set, it defaults to [DefaultMaxAttempts].
+		MaxAttempts int
+
 		Logger *zerolog.Logger
 		noauth bool
 	}
@@ -92,9 +103,18 @@ type (
 	}
 )
 
+// DefaultMaxAttempts is the default maximum number of attempts of idempotent
+// requests.
+const DefaultMaxAttempts = 3
+
 var (
 	pageSize         int64 = defaultPageSize
 	debugAPIRequests bool
+
+	// retryWaitMin and retryWaitMax bound the exponential backoff between
+	// attempts of a request.
+	retryWaitMin = 200 * time.Millisecond
+	retryWaitMax = 5 * time.Second
 )
 
 func init() {
@@ -232,6 +252,17 @@ func (c *Client) StackLastDrift(ctx context.Context, orgUUID UUID, stackID int64
 	return Get[DriftsStackPayloadResponse](ctx, c, c.URL(path, query))
 }
 
+// ArchiveStack archives the stack record with the given stackID.
+func (c *Client) ArchiveStack(ctx context.Context, orgUUID UUID, stackID int64) error {
+	_, err := Post[EmptyResponse](
+		ctx,
+		c,
+		nil,
+		c.URL(path.Join(StacksPath, string(orgUUID), strconv.Itoa64(stackID), "archive")),
+	)
+	return err
+}
+
 // DriftDetails retrieves details of the given driftID.
 func (c *Client) DriftDetails(ctx context.Context, orgUUID UUID, stackID int64, driftID int64) (Drift, error) {
 	path := path.Join(DriftsPath, string(orgUUID), strconv.Itoa64(stackID), strconv.Itoa64(driftID))
@@ -271,6 +302,48 @@ func (c *Client) UpdateDeploymentStacks(ctx context.Context, orgUUID UUID, deplo
 	return err
 }
 
+// ListDeployments returns the most recent deployments of the given repository,
+// newest first. If stackID is not nil, only the deployments of that stack are
+// returned. It paginates as needed until limit deployments are fetched.
+func (c *Client) ListDeployments(ctx context.Context, orgUUID UUID, repository string, stackID *int64, limit int) ([]Deployment, error) {
+	query := url.Values{}
+	query.Set("repository", repository)
+	if stackID != nil {
+		query.Set("stack_id", strconv.Itoa64(*stackID))
+	}
+	perPage := pageSize
+	if int64(limit) < perPage {
+		perPage = int64(limit)
+	}
+	query.Set("per_page", strconv.Itoa64(perPage))
+	url := c.URL(path.Join(DeploymentsPath, string(orgUUID)))
+	lastPage := int64(1)
+	var deployments []Deployment
+	for len(deployments) < limit {
+		query.Set("page", strconv.Itoa64(lastPage))
+		url.RawQuery = query.Encode()
+		resp, err := Get[DeploymentsResponse](ctx, c, url)
+		if err != nil {
+			return nil, err
+		}
+		deployments = append(deployments, resp.Deployments...)
+		if int64(len(resp.Deployments)) < perPage {
+			break
+		}
+		lastPage++
+	}
+	if len(deployments) > limit {
+		deployments = deployments[:limit]
+	}
+	return deployments, nil
+}
+
+// GetDeployment retrieves the details of the deployment with the given UUID.
+// It returns an error of kind [ErrNotFound] if the deployment does not exist.
+func (c *Client) GetDeployment(ctx context.Context, orgUUID UUID, deploymentUUID UUID) (Deployment, error) {
+	return Get[Deployment](ctx, c, c.URL(path.Join(DeploymentsPath, string(orgUUID), string(deploymentUUID))))
+}
+
 // CreateStackDrift pushes a new drift status for the given stack.
 func (c *Client) CreateStackDrift(
 	ctx context.Context,
@@ -370,14 +443,67 @@ func Put[T Resource](ctx context.Context, client *Client, payload interface{}, u
 
 // Request makes a request to the Terramate Cloud using client.
 // The instantiated type gets decoded and return as the entity T,
+// Idempotent requests (GET and PUT) failing with a transient error are retried
+// with exponential backoff, up to [Client.MaxAttempts] times.
 func Request[T Resource](ctx context.Context, c *Client, method string, url url.URL, postBody io.Reader) (entity T, err error) {
 	if !c.noauth && c.Credential == nil {
 		return entity, errors.E("no credential provided to %s endpoint", url)
 	}
 
-	req, err := c.newRequest(ctx, method, url, postBody)
+	var body []byte
+	if postBody != nil {
+		body, err = io.ReadAll(postBody)
+		if err != nil {
+			return entity, errors.E(err, "reading request payload")
+		}
+	}
+
+	maxAttempts := 1
+	if method == http.MethodGet || method == http.MethodPut {
+		maxAttempts = c.MaxAttempts
+		if maxAttempts <= 0 {
+			maxAttempts = DefaultMaxAttempts
+		}
+	}
+
+	for attempt := 1; ; attempt++ {
+		var retry bool
+		entity, retry, err = request[T](ctx, c, method, url, body)
+		if err == nil || !retry || attempt == maxAttempts {
+			return entity, err
+		}
+
+		wait := retryWait(attempt)
+		if c.Logger != nil {
+			c.Logger.Debug().
+				Err(err).
+				Str("method", method).
+				Str("url", url.String()).
+				Int("attempt", attempt).
+				Dur("wait", wait).
+				Msg("retrying request")
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return entity, err
+		case <-timer.C:
+		}
+	}
+}
+
+// request makes a single request attempt. The returned boolean tells if the
+// error is transient and the request can be retried.
+func request[T Resource](ctx context.Context, c *Client, method string, url url.URL, body []byte) (entity T, retry bool, err error) {
+	var reqBody io.Reader
+	if body != nil {
+		reqBody = bytes.NewReader(body)
+	}
+	req, err := c.newRequest(ctx, method, url, reqBody)
 	if err != nil {
-		return entity, err
+		return entity, false, err
 	}
 
 	if debugAPIRequests {
@@ -388,7 +514,7 @@ func Request[T Resource](ctx context.Context, c *Client, method string, url url.
 	client := c.httpClient()
 	resp, err := client.Do(req)
 	if err != nil {
-		return entity, err
+		return entity, ctx.Err() == nil, err
 	}
 
 	if debugAPIRequests {
@@ -402,35 +528,49 @@ func Request[T Resource](ctx context.Context, c *Client, method string, url url.
 
 	data, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return entity, err
+		return entity, true, err
 	}
 
 	if resp.StatusCode == http.StatusNotFound {
-		return entity, errors.E(ErrNotFound, "%s %s", method, url.String())
+		return entity, false, errors.E(ErrNotFound, "%s %s", method, url.String())
 	}
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return entity, errors.E(ErrUnexpectedStatus, "%s: status: %s, content: %s", url.String(), resp.Status, data)
+		retry := resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests
+		return entity, retry, errors.E(ErrUnexpectedStatus, "%s: status: %s, content: %s", url.String(), resp.Status, data)
 	}
 
 	if resp.StatusCode == http.StatusNoContent {
-		return entity, nil
+		return entity, false, nil
 	}
 
 	if ctype, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); ctype != contentType {
-		return entity, errors.E(ErrUnexpectedResponseBody, "client expects the Content-Type: %s but got %s", contentType, ctype)
+		return entity, false, errors.E(ErrUnexpectedResponseBody, "client expects the Content-Type: %s but got %s", contentType, ctype)
 	}
 
 	var resource T
 	err = json.Unmarshal(data, &resource)
 	if err != nil {
-		return entity, errors.E(ErrUnexpectedResponseBody, err, "status: %d, data: %s", resp.StatusCode, data)
+		return entity, false, errors.E(ErrUnexpectedResponseBody, err, "status: %d, data: %s", resp.StatusCode, data)
 	}
 	err = resource.Validate()
 	if err != nil {
-		return entity, errors.E(ErrUnexpectedResponseBody, err)
+		return entity, false, errors.E(ErrUnexpectedResponseBody, err)
 	}
-	return resource, nil
+	return resource, false, nil
+}
+
+// retryWait returns the backoff before the next attempt, which doubles after
+// each attempt and has a random jitter of up to half of its value.
+func retryWait(attempt int) time.Duration {
+	wait := retryWaitMax
+	if attempt < 16 {
+		wait = retryWaitMin << (attempt - 1)
+		if wait > retryWaitMax {
+			wait = retryWaitMax
+		}
+	}
+	return wait/2 + time.Duration(rand.Int63n(int64(wait/2)+1))
 }
 
 func (c *Client) newRequest(ctx context.Context, method string, url url.URL, body io.Reader) (*http.Request, error) {
@@ -30,6 +30,7 @@ type RunContext struct {
 type CreatePreviewOpts struct {
 	Runs            []RunContext
 	AffectedStacks  map[string]*config.Stack
+	ChangeReasons   map[string]string
 	OrgUUID         UUID
 	PushedAt        int64
 	CommitSHA       string
@@ -113,6 +114,7 @@ func (c *Client) CreatePreview(ctx context.Context, opts CreatePreviewOpts) (*Cr
 				MetaDescription: affectedStack.Description,
 				MetaTags:        affectedStack.Tags,
 				DefaultBranch:   opts.DefaultBranch,
+				ChangeReason:    TruncateChangeReason(opts.ChangeReasons[affectedStack.ID]),
 			},
 		}
 
@@ -9,14 +9,19 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"net/http/httptrace"
+	"strings"
 	"testing"
 	"time"
+	"unicode/utf8"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/julienschmidt/httprouter"
 	"github.com/madlambda/spells/assert"
 	"github.com/terramate-io/terramate/cloud"
 	"github.com/terramate-io/terramate/cloud/stack"
+	"github.com/terramate-io/terramate/cloud/testserver"
+	"github.com/terramate-io/terramate/cloud/testserver/cloudstore"
 	"github.com/terramate-io/terramate/errors"
 	errtest "github.com/terramate-io/terramate/test/errors"
 )
@@ -564,3 +569,167 @@ func (*mockCred) ApplyCredentials(req *http.Request) error {
 func (*mockCred) RedactCredentials(req *http.Request) {
 	req.Header.Set("Authorization", "REDACTED")
 }
+
+func TestCloudRetries(t *testing.T) {
+	t.Parallel()
+
+	type testcase struct {
+		name        string
+		method      string
+		failures    int
+		status      int
+		maxAttempts int
+		wantCalls   int
+		wantErr     error
+	}
+
+	for _, tc := range []testcase{
+		{
+			name:      "GET succeeds after transient failures",
+			method:    "GET",
+			failures:  2,
+			status:    http.StatusBadGateway,
+			wantCalls: 3,
+		},
+		{
+			name:      "GET fails after the default max attempts",
+			method:    "GET",
+			failures:  -1,
+			status:    http.StatusServiceUnavailable,
+			wantCalls: cloud.DefaultMaxAttempts,
+			wantErr:   errors.E(cloud.ErrUnexpectedStatus),
+		},
+		{
+			name:        "GET with custom max attempts",
+			method:      "GET",
+			failures:    -1,
+			status:      http.StatusInternalServerError,
+			maxAttempts: 5,
+			wantCalls:   5,
+			wantErr:     errors.E(cloud.ErrUnexpectedStatus),
+		},
+		{
+			name:        "GET is not retried with a single attempt",
+			method:      "GET",
+			failures:    1,
+			status:      http.StatusBadGateway,
+			maxAttempts: 1,
+			wantCalls:   1,
+			wantErr:     errors.E(cloud.ErrUnexpectedStatus),
+		},
+		{
+			name:      "GET is not retried on client errors",
+			method:    "GET",
+			failures:  1,
+			status:    http.StatusBadRequest,
+			wantCalls: 1,
+			wantErr:   errors.E(cloud.ErrUnexpectedStatus),
+		},
+		{
+			name:      "PUT succeeds after transient failures",
+			method:    "PUT",
+			failures:  1,
+			status:    http.StatusGatewayTimeout,
+			wantCalls: 2,
+		},
+		{
+			name:      "POST is not retried",
+			method:    "POST",
+			failures:  1,
+			status:    http.StatusBadGateway,
+			wantCalls: 1,
+			wantErr:   errors.E(cloud.ErrUnexpectedStatus),
+		},
+		{
+			name:      "PATCH is not retried",
+			method:    "PATCH",
+			failures:  1,
+			status:    http.StatusBadGateway,
+			wantCalls: 1,
+			wantErr:   errors.E(cloud.ErrUnexpectedStatus),
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			const path = "/v1/retries"
+			faulty := &testserver.Faulty{
+				Failures: tc.failures,
+				Status:   tc.status,
+				Handler: func(_ *cloudstore.Data, w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+					// the payload must be sent again in each attempt.
+					if r.Method != "GET" {
+						body, _ := io.ReadAll(r.Body)
+						if string(body) != `{"name":"test"}` {
+							w.WriteHeader(http.StatusBadRequest)
+							return
+						}
+					}
+					w.WriteHeader(http.StatusNoContent)
+				},
+			}
+			store := &cloudstore.Data{}
+			router := testserver.RouterWith(store, map[string]bool{})
+			testserver.RouterAddCustoms(router, store, testserver.Custom{
+				Routes: map[string]testserver.Route{
+					tc.method: {
+						Path:    path,
+						Handler: faulty.Handle,
+					},
+				},
+			})
+			s := httptest.NewServer(router)
+			defer s.Close()
+
+			client := &cloud.Client{
+				BaseURL:     s.URL,
+				HTTPClient:  s.Client(),
+				Credential:  credential(),
+				MaxAttempts: tc.maxAttempts,
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+
+			payload := map[string]string{"name": "test"}
+			var err error
+			switch tc.method {
+			case "GET":
+				_, err = cloud.Get[cloud.EmptyResponse](ctx, client, client.URL(path))
+			case "PUT":
+				_, err = cloud.Put[cloud.EmptyResponse](ctx, client, payload, client.URL(path))
+			case "POST":
+				_, err = cloud.Post[cloud.EmptyResponse](ctx, client, payload, client.URL(path))
+			case "PATCH":
+				_, err = cloud.Patch[cloud.EmptyResponse](ctx, client, payload, client.URL(path))
+			}
+			errtest.Assert(t, err, tc.wantErr)
+			assert.EqualInts(t, tc.wantCalls, faulty.Calls())
+		})
+	}
+}
+
+func TestCloudTruncateChangeReason(t *testing.T) {
+	t.Parallel()
+
+	short := "stack has unmerged changes"
+	assert.EqualStrings(t, short, cloud.TruncateChangeReason(short))
+
+	long := strings.Repeat("a", cloud.MaxChangeReasonSize-1) + "ção"
+	got := cloud.TruncateChangeReason(long)
+	assert.IsTrue(t, len(got) <= cloud.MaxChangeReasonSize, "truncated reason has %d bytes", len(got))
+	assert.IsTrue(t, utf8.ValidString(got), "truncated reason is not valid utf-8")
+	assert.IsTrue(t, strings.HasSuffix(got, "..."), "truncated reason must end with ellipsis")
+
+	st := cloud.Stack{
+		Repository:    "github.com/terramate-io/terramate",
+		DefaultBranch: "main",
+		Path:          "/stack",
+		MetaID:        "stack",
+		ChangeReason:  long,
+	}
+	assert.Error(t, st.Validate())
+	st.ChangeReason = got
+	assert.NoError(t, st.Validate())
+}
@@ -0,0 +1,30 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package drift
+
+import "github.com/terramate-io/terramate/errors"
+
+// ErrInvalidContext represents an invalid drift context error.
+const ErrInvalidContext errors.Kind = "invalid drift context"
+
+// Context tells where a drift run originated from.
+type Context string
+
+const (
+	// ContextScheduled is used for drift runs started by a schedule, e.g. a
+	// cron triggered CI/CD pipeline.
+	ContextScheduled Context = "scheduled"
+	// ContextManual is used for drift runs started in any other way.
+	ContextManual Context = "manual"
+)
+
+// Validate the context.
+func (c Context) Validate() error {
+	switch c {
+	case ContextScheduled, ContextManual:
+		return nil
+	default:
+		return errors.E(ErrInvalidContext, "%q: expected %q or %q", string(c), ContextScheduled, ContextManual)
+	}
+}
@@ -0,0 +1,96 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package cloud
+
+import (
+	"context"
+	"regexp"
+)
+
+type (
+	// LogTail keeps the last lines of a command output, bounded by the total
+	// size of the messages. It's used as a Syncer by a LogSyncer when the logs
+	// are uploaded only after the command finishes.
+	LogTail struct {
+		logs     CommandLogs
+		size     int
+		maxBytes int
+	}
+)
+
+// DefaultLogTailSize is the default maximum size of a LogTail, in bytes.
+const DefaultLogTailSize = 64 * 1024
+
+// RedactedLogMessage is the message replacing redacted log lines.
+const RedactedLogMessage = "[REDACTED]"
+
+// NewLogTail creates a new log tail keeping at most maxBytes of messages.
+func NewLogTail(maxBytes int) *LogTail {
+	return &LogTail{maxBytes: maxBytes}
+}
+
+// Append appends the logs to the tail, discarding the oldest lines when the
+// maximum size is exceeded. A single line bigger than the maximum size is
+// truncated, keeping its end.
+// It must not be called concurrently.
+func (t *LogTail) Append(logs CommandLogs) {
+	for _, l := range logs {
+		if len(l.Message) > t.maxBytes {
+			cp := *l
+			cp.Message = cp.Message[len(cp.Message)-t.maxBytes:]
+			l = &cp
+		}
+		t.logs = append(t.logs, l)
+		t.size += len(l.Message)
+	}
+	for t.size > t.maxBytes && len(t.logs) > 0 {
+		t.size -= len(t.logs[0].Message)
+		t.logs = t.logs[1:]
+	}
+}
+
+// Logs returns the logs kept by the tail.
+func (t *LogTail) Logs() CommandLogs {
+	return t.logs
+}
+
+// Redact returns a Syncer which replaces the messages matching any of the
+// patterns with RedactedLogMessage before calling syncfn.
+// If there are no patterns, syncfn is returned unchanged.
+func Redact(patterns []*regexp.Regexp, syncfn Syncer) Syncer {
+	if len(patterns) == 0 {
+		return syncfn
+	}
+	return func(logs CommandLogs) {
+		for _, l := range logs {
+			for _, pattern := range patterns {
+				if pattern.MatchString(l.Message) {
+					l.Message = RedactedLogMessage
+					break
+				}
+			}
+		}
+		syncfn(logs)
+	}
+}
+
+// UploadDeploymentLogs uploads the full command logs of a deployment stack to
+// Terramate Cloud, sending them in batches of DefaultLogBatchSize lines.
+func (c *Client) UploadDeploymentLogs(
+	ctx context.Context,
+	orgUUID UUID,
+	stackID int64,
+	deploymentUUID UUID,
+	logs CommandLogs,
+) error {
+	for len(logs) > 0 {
+		n := min(DefaultLogBatchSize, len(logs))
+		err := c.SyncCommandLogs(ctx, orgUUID, stackID, deploymentUUID, logs[:n], "")
+		if err != nil {
+			return err
+		}
+		logs = logs[n:]
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package cloud_test
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/terramate-io/terramate/cloud"
+)
+
+func TestCloudLogTail(t *testing.T) {
+	t.Parallel()
+
+	type testcase struct {
+		name     string
+		maxBytes int
+		appends  []cloud.CommandLogs
+		want     []string
+	}
+
+	logs := func(msgs ...string) cloud.CommandLogs {
+		var res cloud.CommandLogs
+		for i, msg := range msgs {
+			res = append(res, &cloud.CommandLog{
+				Channel: cloud.StdoutLogChannel,
+				Line:    int64(i + 1),
+				Message: msg,
+			})
+		}
+		return res
+	}
+
+	for _, tc := range []testcase{
+		{
+			name:     "no logs",
+			maxBytes: 10,
+		},
+		{
+			name:     "logs fitting the tail are kept",
+			maxBytes: 10,
+			appends:  []cloud.CommandLogs{logs("abc", "def"), logs("ghij")},
+			want:     []string{"abc", "def", "ghij"},
+		},
+		{
+			name:     "oldest lines are discarded",
+			maxBytes: 10,
+			appends:  []cloud.CommandLogs{logs("abc", "def"), logs("ghij", "k")},
+			want:     []string{"def", "ghij", "k"},
+		},
+		{
+			name:     "huge line keeps its end",
+			maxBytes: 4,
+			appends:  []cloud.CommandLogs{logs("abc", strings.Repeat("x", 10)+"end")},
+			want:     []string{"xend"},
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			tail := cloud.NewLogTail(tc.maxBytes)
+			for _, l := range tc.appends {
+				tail.Append(l)
+			}
+			var got []string
+			for _, l := range tail.Logs() {
+				got = append(got, l.Message)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Fatalf("unexpected tail: %s", diff)
+			}
+		})
+	}
+}
+
+func TestCloudLogRedact(t *testing.T) {
+	t.Parallel()
+
+	var got cloud.CommandLogs
+	syncer := cloud.Redact([]*regexp.Regexp{
+		regexp.MustCompile(`(?i)password`),
+		regexp.MustCompile(`^token=`),
+	}, func(l cloud.CommandLogs) {
+		got = append(got, l...)
+	})
+
+	syncer(cloud.CommandLogs{
+		{Channel: cloud.StdoutLogChannel, Line: 1, Message: "applying changes"},
+		{Channel: cloud.StdoutLogChannel, Line: 2, Message: "db PASSWORD is hunter2"},
+		{Channel: cloud.StderrLogChannel, Line: 1, Message: "token=abc"},
+		{Channel: cloud.StderrLogChannel, Line: 2, Message: "the token=abc is kept"},
+	})
+
+	want := cloud.CommandLogs{
+		{Channel: cloud.StdoutLogChannel, Line: 1, Message: "applying changes"},
+		{Channel: cloud.StdoutLogChannel, Line: 2, Message: cloud.RedactedLogMessage},
+		{Channel: cloud.StderrLogChannel, Line: 1, Message: cloud.RedactedLogMessage},
+		{Channel: cloud.StderrLogChannel, Line: 2, Message: "the token=abc is kept"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("unexpected redacted logs: %s", diff)
+	}
+}
@@ -99,6 +99,7 @@ type (
 		CreatedAt        *time.Time        `json:"created_at,omitempty"`
 		UpdatedAt        *time.Time        `json:"updated_at,omitempty"`
 		SeenAt           *time.Time        `json:"seen_at,omitempty"`
+		ArchivedAt       *time.Time        `json:"archived_at,omitempty"`
 	}
 	// Deployment model.
 	Deployment struct {
@@ -111,6 +112,8 @@ type (
 		Metadata      *cloud.DeploymentMetadata `json:"metadata"`
 		ReviewRequest *cloud.ReviewRequest      `json:"review_request"`
 		State         DeploymentState           `json:"state"`
+		CreatedAt     *time.Time                `json:"created_at,omitempty"`
+		FinishedAt    *time.Time                `json:"finished_at,omitempty"`
 	}
 	// DeploymentState is the state of a deployment.
 	DeploymentState struct {
@@ -124,6 +127,7 @@ type (
 		StackMetaID string                    `json:"stack_meta_id"`
 		StackTarget string                    `json:"stack_target"`
 		Status      drift.Status              `json:"status"`
+		Context     drift.Context             `json:"context,omitempty"`
 		Details     *cloud.ChangesetDetails   `json:"details"`
 		Metadata    *cloud.DeploymentMetadata `json:"metadata"`
 		Command     []string                  `json:"command"`
@@ -334,6 +338,26 @@ func (d *Data) UpsertStack(orguuid cloud.UUID, st Stack) (int64, error) {
 	return int64(len(org.Stacks) - 1), nil
 }
 
+// ArchiveStack marks the given stack as archived.
+func (d *Data) ArchiveStack(orguuid cloud.UUID, stackID int64) error {
+	org, found := d.GetOrg(orguuid)
+	if !found {
+		return errors.E(ErrNotExists, "org uuid %s", orguuid)
+	}
+	if _, found := d.GetStack(org, stackID); !found {
+		return errors.E(ErrNotExists, "stack id %d", stackID)
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	t := time.Now().UTC()
+	org.Stacks[stackID].State.ArchivedAt = &t
+	org.Stacks[stackID].State.UpdatedAt = &t
+	d.Orgs[org.Name] = org
+	return nil
+}
+
 // AppendPreviewLogs appends logs to the given stack preview.
 func (d *Data) AppendPreviewLogs(org Org, stackPreviewID string, logs cloud.CommandLogs) error {
 	d.mu.Lock()
@@ -496,6 +520,10 @@ func (d *Data) InsertDeployment(orgID cloud.UUID, deploy Deployment) error {
 	for _, stackID := range deploy.Stacks {
 		deploy.State.StackStatusEvents[stackID] = append(deploy.State.StackStatusEvents[stackID], deployment.Pending)
 	}
+	if deploy.CreatedAt == nil {
+		now := time.Now().UTC()
+		deploy.CreatedAt = &now
+	}
 	if org.Deployments == nil {
 		org.Deployments = make(map[cloud.UUID]*Deployment)
 	}
@@ -543,9 +571,35 @@ func (d *Data) SetDeploymentStatus(org Org, deploymentID cloud.UUID, stackID int
 	}
 	deployment.State.StackStatus[stackID] = status
 	deployment.State.StackStatusEvents[stackID] = append(deployment.State.StackStatusEvents[stackID], status)
+	for _, id := range deployment.Stacks {
+		if !deployment.State.StackStatus[id].IsFinalState() {
+			return nil
+		}
+	}
+	now := time.Now().UTC()
+	deployment.FinishedAt = &now
 	return nil
 }
 
+// ListDeployments returns a copy of the deployments of the given organization,
+// newest first.
+func (d *Data) ListDeployments(org Org) []Deployment {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	deployments := make([]Deployment, 0, len(org.Deployments))
+	for _, deploy := range org.Deployments {
+		deployments = append(deployments, *deploy)
+	}
+	sort.SliceStable(deployments, func(i, j int) bool {
+		ti, tj := deployments[i].CreatedAt, deployments[j].CreatedAt
+		if ti == nil || tj == nil || ti.Equal(*tj) {
+			return deployments[i].UUID < deployments[j].UUID
+		}
+		return ti.After(*tj)
+	})
+	return deployments
+}
+
 // GetDeploymentEvents returns the events of the given deployment.
 func (d *Data) GetDeploymentEvents(orgID, deploymentID cloud.UUID) (map[string][]deployment.Status, error) {
 	org, found := d.GetOrg(orgID)
@@ -7,6 +7,7 @@ import (
 	"encoding/json"
 	"io"
 	"net/http"
+	"slices"
 
 	"github.com/julienschmidt/httprouter"
 	"github.com/terramate-io/terramate/cloud"
@@ -14,6 +15,7 @@ import (
 	"github.com/terramate-io/terramate/cloud/stack"
 	"github.com/terramate-io/terramate/cloud/testserver/cloudstore"
 	"github.com/terramate-io/terramate/errors"
+	"github.com/terramate-io/terramate/strconv"
 )
 
 // GetDeployments is the GET /deployments handler.
@@ -36,6 +38,157 @@ func GetDeployments(store *cloudstore.Data, w http.ResponseWriter, _ *http.Reque
 	marshalWrite(w, deploymentInfo)
 }
 
+// ListDeployments is the GET /deployments/:orguuid handler.
+func ListDeployments(store *cloudstore.Data, w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	orguuid := cloud.UUID(p.ByName("orguuid"))
+	repository := r.FormValue("repository")
+
+	org, found := store.GetOrg(orguuid)
+	if !found {
+		w.WriteHeader(http.StatusNotFound)
+		writeString(w, "organization not found")
+		return
+	}
+
+	var (
+		err     error
+		stackID int64 = -1
+	)
+	if str := r.FormValue("stack_id"); str != "" {
+		stackID, err = strconv.Atoi64(str)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			writeErr(w, errors.E(err, "invalid stack_id parameter"))
+			return
+		}
+	}
+
+	page, perPage := int64(1), int64(10)
+	if str := r.FormValue("page"); str != "" {
+		page, err = strconv.Atoi64(str)
+		if err != nil || page < 1 {
+			w.WriteHeader(http.StatusBadRequest)
+			writeErr(w, errors.E(err, "invalid page parameter"))
+			return
+		}
+	}
+	if str := r.FormValue("per_page"); str != "" {
+		perPage, err = strconv.Atoi64(str)
+		if err != nil || perPage < 1 {
+			w.WriteHeader(http.StatusBadRequest)
+			writeErr(w, errors.E(err, "invalid per_page parameter"))
+			return
+		}
+	}
+
+	var deployments []cloud.Deployment
+	for _, deploy := range store.ListDeployments(org) {
+		if stackID != -1 && !slices.Contains(deploy.Stacks, stackID) {
+			continue
+		}
+		resp := newDeploymentResponse(store, org, deploy)
+		if repository != "" && resp.Repository != repository {
+			continue
+		}
+		deployments = append(deployments, resp)
+	}
+
+	res := cloud.DeploymentsResponse{
+		Pagination: cloud.PaginatedResult{
+			Total:   int64(len(deployments)),
+			Page:    page,
+			PerPage: perPage,
+		},
+	}
+	start := (page - 1) * perPage
+	if start < int64(len(deployments)) {
+		end := start + perPage
+		if end > int64(len(deployments)) {
+			end = int64(len(deployments))
+		}
+		res.Deployments = deployments[start:end]
+	}
+	w.Header().Add("Content-Type", "application/json")
+	marshalWrite(w, res)
+}
+
+// GetDeployment is the GET /deployments/:orguuid/:deployuuid handler.
+func GetDeployment(store *cloudstore.Data, w http.ResponseWriter, _ *http.Request, p httprouter.Params) {
+	orguuid := cloud.UUID(p.ByName("orguuid"))
+	deployuuid := cloud.UUID(p.ByName("deployuuid"))
+
+	org, found := store.GetOrg(orguuid)
+	if !found {
+		w.WriteHeader(http.StatusNotFound)
+		writeString(w, "organization not found")
+		return
+	}
+	for _, deploy := range store.ListDeployments(org) {
+		if deploy.UUID == deployuuid {
+			w.Header().Add("Content-Type", "application/json")
+			marshalWrite(w, newDeploymentResponse(store, org, deploy))
+			return
+		}
+	}
+	w.WriteHeader(http.StatusNotFound)
+	writeString(w, "deployment not found")
+}
+
+func newDeploymentResponse(store *cloudstore.Data, org cloudstore.Org, deploy cloudstore.Deployment) cloud.Deployment {
+	res := cloud.Deployment{
+		UUID:       deploy.UUID,
+		Workdir:    deploy.Workdir,
+		CreatedAt:  deploy.CreatedAt,
+		FinishedAt: deploy.FinishedAt,
+		Stacks:     []cloud.DeploymentStack{},
+	}
+	if deploy.Metadata != nil {
+		res.CommitSHA = deploy.Metadata.GitCommitSHA
+		res.CommitTitle = deploy.Metadata.GitCommitTitle
+		res.AuthorName = deploy.Metadata.GitCommitAuthorName
+	}
+
+	finished := true
+	var running, failed, canceled bool
+	for _, stackID := range deploy.Stacks {
+		st, ok := store.GetStack(org, stackID)
+		if !ok {
+			continue
+		}
+		status, ok := deploy.State.StackStatus[stackID]
+		if !ok {
+			status = deployment.Pending
+		}
+		res.Repository = st.Repository
+		res.Stacks = append(res.Stacks, cloud.DeploymentStack{
+			StackID:           stackID,
+			MetaID:            st.MetaID,
+			Path:              st.Path,
+			Target:            st.Target,
+			Status:            status,
+			DeploymentCommand: deploy.StackCommands[st.MetaID],
+		})
+		finished = finished && status.IsFinalState()
+		running = running || status == deployment.Running
+		failed = failed || status == deployment.Failed
+		canceled = canceled || status == deployment.Canceled
+	}
+
+	switch {
+	case !finished && running:
+		res.Status = deployment.Running
+	case !finished:
+		res.Status = deployment.Pending
+	case failed:
+		res.Status = deployment.Failed
+	case canceled:
+		res.Status = deployment.Canceled
+	default:
+		res.Status = deployment.OK
+	}
+	return res
+}
+
 // PostDeployment is the POST /deployments handler.
 func PostDeployment(store *cloudstore.Data, w http.ResponseWriter, r *http.Request, p httprouter.Params) {
 	data, err := io.ReadAll(r.Body)
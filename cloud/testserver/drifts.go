@@ -47,6 +47,7 @@ func GetDrift(store *cloudstore.Data, w http.ResponseWriter, _ *http.Request, pa
 			marshalWrite(w, cloud.Drift{
 				ID:       drift.ID,
 				Status:   drift.Status,
+				Context:  drift.Context,
 				Details:  drift.Details,
 				Metadata: drift.Metadata,
 			})
@@ -104,6 +105,9 @@ func PostDrift(store *cloudstore.Data, w http.ResponseWriter, r *http.Request, p
 			Stack: payload.Stack,
 			State: cloudstore.NewState(),
 		}
+	} else {
+		st.Stack.CustomMetadata = payload.Stack.CustomMetadata
+		st.Stack.ChangeReason = payload.Stack.ChangeReason
 	}
 	_, err = store.InsertDrift(cloud.UUID(orguuid), cloudstore.Drift{
 		StackMetaID: payload.Stack.MetaID,
@@ -111,6 +115,7 @@ func PostDrift(store *cloudstore.Data, w http.ResponseWriter, r *http.Request, p
 		Metadata:    payload.Metadata,
 		Details:     payload.Details,
 		Status:      payload.Status,
+		Context:     payload.Context,
 		Command:     payload.Command,
 		StartedAt:   payload.StartedAt,
 		FinishedAt:  payload.FinishedAt,
@@ -159,6 +164,7 @@ func GetDrifts(store *cloudstore.Data, w http.ResponseWriter, _ *http.Request, p
 		res = append(res, cloud.DriftStackPayloadRequest{
 			Stack:      st.Stack,
 			Status:     drift.Status,
+			Context:    drift.Context,
 			Metadata:   drift.Metadata,
 			Details:    drift.Details,
 			Command:    drift.Command,
@@ -0,0 +1,47 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package testserver
+
+import (
+	"net/http"
+	"sync"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/terramate-io/terramate/cloud/testserver/cloudstore"
+)
+
+// Faulty wraps a handler to inject transient failures in the fake server.
+// The first Failures requests are responded with the Status code and the
+// subsequent ones are handled by Handler.
+// A negative Failures makes all requests fail.
+type Faulty struct {
+	Failures int
+	Status   int
+	Handler  Handler
+
+	mu    sync.Mutex
+	calls int
+}
+
+// Handle is the [Handler] of the faulty endpoint.
+func (f *Faulty) Handle(store *cloudstore.Data, w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	f.mu.Lock()
+	f.calls++
+	fail := f.Failures < 0 || f.calls <= f.Failures
+	f.mu.Unlock()
+
+	if fail {
+		w.WriteHeader(f.Status)
+		writeString(w, http.StatusText(f.Status))
+		return
+	}
+	f.Handler(store, w, r, p)
+}
+
+// Calls returns the number of requests received by the faulty endpoint.
+func (f *Faulty) Calls() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.calls
+}
@@ -10,7 +10,9 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/google/go-cmp/cmp"
 	"github.com/madlambda/spells/assert"
+	"github.com/terramate-io/terramate/cloud"
 	"github.com/terramate-io/terramate/cloud/testserver/cloudstore"
 )
 
@@ -117,6 +119,107 @@ func TestPostPreviews(t *testing.T) {
 	// t.Logf(string(respGetPreview))
 }
 
+func TestPostPreviewsGitlab(t *testing.T) {
+	orguuid := "deadbeef-dead-dead-dead-deaddeadbeef"
+	const testserverJSONFile = "../../testdata/testserver/cloud.data.json"
+
+	type testcase struct {
+		name          string
+		reviewRequest string
+		want          cloud.ReviewRequest
+	}
+
+	for _, tc := range []testcase{
+		{
+			name: "merge request pipeline",
+			reviewRequest: `{
+				"platform": "gitlab",
+				"repository": "gitlab.com/terramate-io/terramate",
+				"commit_sha": "somecommitsha",
+				"number": 42,
+				"title": "Amazing new feature",
+				"description": "Please merge these awesome changes in!",
+				"url": "https://gitlab.com/terramate-io/terramate/-/merge_requests/42",
+				"labels": [{"name": "bug"}],
+				"author": {"login": "octocat", "id": "1"},
+				"status": "opened",
+				"draft": true,
+				"branch": "new-topic",
+				"base_branch": "main"
+			}`,
+			want: cloud.ReviewRequest{
+				Platform:    "gitlab",
+				Repository:  "gitlab.com/terramate-io/terramate",
+				CommitSHA:   "somecommitsha",
+				Number:      42,
+				Title:       "Amazing new feature",
+				Description: "Please merge these awesome changes in!",
+				URL:         "https://gitlab.com/terramate-io/terramate/-/merge_requests/42",
+				Labels:      []cloud.Label{{Name: "bug"}},
+				Author:      cloud.Author{Login: "octocat", ID: "1"},
+				Status:      "opened",
+				Draft:       true,
+				Branch:      "new-topic",
+				BaseBranch:  "main",
+			},
+		},
+		{
+			name: "branch pipeline",
+			reviewRequest: `{
+				"platform": "gitlab",
+				"repository": "gitlab.com/terramate-io/terramate",
+				"commit_sha": "somecommitsha",
+				"branch": "main"
+			}`,
+			want: cloud.ReviewRequest{
+				Platform:   "gitlab",
+				Repository: "gitlab.com/terramate-io/terramate",
+				CommitSHA:  "somecommitsha",
+				Branch:     "main",
+			},
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			store, err := cloudstore.LoadDatastore(testserverJSONFile)
+			assert.NoError(t, err)
+			router := Router(store)
+
+			w := doRequest(t, router, "POST", "/v1/previews/"+orguuid,
+				`{
+					"stacks": [
+						{
+						  "preview_status": "pending",
+						  "cmd": ["terraform",  "plan"],
+						  "repository": "gitlab.com/terramate-io/terramate",
+						  "name": "teststack",
+						  "path": "teststack",
+						  "meta_id": "teststack",
+						  "default_branch": "main"
+						}
+					],
+					"review_request": `+tc.reviewRequest+`,
+					"pushed_at": 1709644546,
+					"commit_sha": "somecommitsha",
+					"technology": "terraform",
+					"technology_layer": "default"
+				}`,
+			)
+			assert.EqualInts(t, http.StatusOK, w.Code, w.Body.String())
+
+			wGetPreview := doRequest(t, router, "GET", "/v1/previews/"+orguuid+"/1", "")
+			assert.EqualInts(t, http.StatusOK, wGetPreview.Code, wGetPreview.Body.String())
+
+			var preview cloudstore.Preview
+			assert.NoError(t, json.Unmarshal(wGetPreview.Body.Bytes(), &preview))
+			assert.IsTrue(t, preview.ReviewRequest != nil, "missing review request")
+			if diff := cmp.Diff(tc.want, *preview.ReviewRequest); diff != "" {
+				t.Fatalf("unexpected review request (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
 func doRequest(t *testing.T, router http.Handler, method, path, body string) *httptest.ResponseRecorder {
 	req, err := http.NewRequest(method, path, strings.NewReader(body))
 	if err != nil {
@@ -83,6 +83,7 @@ func RouterAdd(store *cloudstore.Data, router *httprouter.Router, enabled map[st
 		router.GET(cloud.StacksPath+"/:orguuid/:stackid/deployments/:deployment_uuid/logs/events", handler(store, GetDeploymentLogsEvents))
 
 		router.GET(cloud.StacksPath+"/:orguuid/:stackid/drifts", handler(store, GetStackDrifts))
+		router.POST(cloud.StacksPath+"/:orguuid/:stackid/archive", handler(store, ArchiveStack))
 
 		// not a real TMC handler, only used by tests to populate the stacks state.
 		router.PUT(cloud.StacksPath+"/:orguuid/:stackuuid", handler(store, PutStack))
@@ -93,6 +94,8 @@ func RouterAdd(store *cloudstore.Data, router *httprouter.Router, enabled map[st
 	}
 
 	if enabled[cloud.DeploymentsPath] {
+		router.GET(cloud.DeploymentsPath+"/:orguuid", handler(store, ListDeployments))
+		router.GET(cloud.DeploymentsPath+"/:orguuid/:deployuuid", handler(store, GetDeployment))
 		router.GET(cloud.DeploymentsPath+"/:orguuid/:deployuuid/stacks", handler(store, GetDeployments))
 		router.POST(cloud.DeploymentsPath+"/:orguuid/:deployuuid/stacks", handler(store, PostDeployment))
 		router.PATCH(cloud.DeploymentsPath+"/:orguuid/:deployuuid/stacks", handler(store, PatchDeployment))
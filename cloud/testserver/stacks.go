@@ -182,9 +182,31 @@ func GetStacks(store *cloudstore.Data, w http.ResponseWriter, r *http.Request, p
 		}
 	}
 
-	start := (page - 1) * perPage
+	// filters are applied before paginating, so every page but the last one
+	// is full, as in the real API.
+	var stacks []cloud.StackObject
+	for id, st := range org.Stacks {
+		if !validateStackStatus(st) {
+			w.WriteHeader(http.StatusInternalServerError)
+			writeErr(w, invalidStackStateError(st))
+			return
+		}
 
-	stacks := org.Stacks
+		if filter(st) {
+			stacks = append(stacks, cloud.StackObject{
+				ID:               int64(id),
+				Stack:            st.Stack,
+				Status:           st.State.Status,
+				DeploymentStatus: st.State.DeploymentStatus,
+				DriftStatus:      st.State.DriftStatus,
+				CreatedAt:        st.State.CreatedAt,
+				UpdatedAt:        st.State.UpdatedAt,
+				SeenAt:           st.State.SeenAt,
+			})
+		}
+	}
+
+	start := (page - 1) * perPage
 	if start >= int64(len(stacks)) {
 		w.Header().Add("Content-Type", "application/json")
 		marshalWrite(w, cloud.StacksResponse{
@@ -203,29 +225,7 @@ func GetStacks(store *cloudstore.Data, w http.ResponseWriter, r *http.Request, p
 	}
 
 	var resp cloud.StacksResponse
-	for id, st := range stacks[start:end] {
-		if !validateStackStatus(st) {
-			w.WriteHeader(http.StatusInternalServerError)
-			writeErr(w, invalidStackStateError(st))
-			return
-		}
-
-		if filter(st) {
-			resp.Stacks = append(resp.Stacks, cloud.StackObject{
-				ID:               int64(id),
-				Stack:            st.Stack,
-				Status:           st.State.Status,
-				DeploymentStatus: st.State.DeploymentStatus,
-				DriftStatus:      st.State.DriftStatus,
-				CreatedAt:        st.State.CreatedAt,
-				UpdatedAt:        st.State.UpdatedAt,
-				SeenAt:           st.State.SeenAt,
-			})
-		}
-	}
-	sort.Slice(resp.Stacks, func(i, j int) bool {
-		return resp.Stacks[i].ID < resp.Stacks[j].ID
-	})
+	resp.Stacks = stacks[start:end]
 	resp.Pagination.Page = page
 	resp.Pagination.PerPage = int64(len(resp.Stacks))
 	resp.Pagination.Total = int64(len(stacks))
@@ -269,6 +269,30 @@ func PutStack(store *cloudstore.Data, w http.ResponseWriter, r *http.Request, p
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// ArchiveStack is the POST /stacks/:orguuid/:stackid/archive handler.
+func ArchiveStack(store *cloudstore.Data, w http.ResponseWriter, _ *http.Request, p httprouter.Params) {
+	orguuid := cloud.UUID(p.ByName("orguuid"))
+	stackid, err := strconv.Atoi64(p.ByName("stackid"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		writeErr(w, errors.E(err, "invalid stackid"))
+		return
+	}
+
+	err = store.ArchiveStack(orguuid, stackid)
+	if errors.IsKind(err, cloudstore.ErrNotExists) {
+		w.WriteHeader(http.StatusNotFound)
+		writeErr(w, err)
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		writeErr(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
 // GetDeploymentLogs is the GET /deployments/.../logs handler.
 func GetDeploymentLogs(store *cloudstore.Data, w http.ResponseWriter, _ *http.Request, p httprouter.Params) {
 	stackIDStr := p.ByName("stackid")
@@ -489,6 +513,7 @@ func GetStackDrifts(store *cloudstore.Data, w http.ResponseWriter, r *http.Reque
 		res.Drifts = append(res.Drifts, cloud.Drift{
 			ID:       drift.ID,
 			Status:   drift.Status,
+			Context:  drift.Context,
 			Details:  drift.Details,
 			Metadata: drift.Metadata,
 		})
@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/terramate-io/terramate/cloud/deployment"
 	"github.com/terramate-io/terramate/cloud/drift"
@@ -74,6 +75,14 @@ type (
 		MetaName        string   `json:"meta_name,omitempty"`
 		MetaDescription string   `json:"meta_description,omitempty"`
 		MetaTags        []string `json:"meta_tags,omitempty"`
+
+		// CustomMetadata is the user defined metadata of the stack, configured
+		// in the `terramate.config.cloud.metadata` block.
+		CustomMetadata map[string]string `json:"custom_metadata,omitempty"`
+
+		// ChangeReason is the reason why the stack was selected by the change
+		// detection, eg.: a changed file, a watched file or a changed module.
+		ChangeReason string `json:"change_reason,omitempty"`
 	}
 
 	// ChangesetDetails represents the details of a changeset (e.g. the terraform plan).
@@ -129,6 +138,7 @@ type (
 	Drift struct {
 		ID       int64               `json:"id"`
 		Status   drift.Status        `json:"status"`
+		Context  drift.Context       `json:"drift_context,omitempty"`
 		Details  *ChangesetDetails   `json:"drift_details,omitempty"`
 		Metadata *DeploymentMetadata `json:"metadata,omitempty"`
 	}
@@ -146,6 +156,7 @@ type (
 	DriftStackPayloadRequest struct {
 		Stack      Stack               `json:"stack"`
 		Status     drift.Status        `json:"drift_status"`
+		Context    drift.Context       `json:"drift_context,omitempty"`
 		Details    *ChangesetDetails   `json:"drift_details,omitempty"`
 		Metadata   *DeploymentMetadata `json:"metadata,omitempty"`
 		StartedAt  *time.Time          `json:"started_at,omitempty"`
@@ -354,6 +365,38 @@ type (
 	// Reviewers is a list of reviewers.
 	Reviewers []Reviewer
 
+	// DeploymentsResponse represents the deployments object response.
+	DeploymentsResponse struct {
+		Deployments []Deployment    `json:"deployments"`
+		Pagination  PaginatedResult `json:"paginated_result"`
+	}
+
+	// Deployment represents a deployment in the Terramate Cloud.
+	Deployment struct {
+		UUID        UUID              `json:"deployment_uuid"`
+		Repository  string            `json:"repository"`
+		Status      deployment.Status `json:"status"`
+		CommitSHA   string            `json:"commit_sha,omitempty"`
+		CommitTitle string            `json:"commit_title,omitempty"`
+		AuthorName  string            `json:"author_name,omitempty"`
+		Workdir     string            `json:"workdir,omitempty"`
+		Stacks      []DeploymentStack `json:"stacks"`
+
+		// readonly fields
+		CreatedAt  *time.Time `json:"created_at,omitempty"`
+		FinishedAt *time.Time `json:"finished_at,omitempty"`
+	}
+
+	// DeploymentStack represents a stack of a deployment in the Terramate Cloud.
+	DeploymentStack struct {
+		StackID           int64             `json:"stack_id"`
+		MetaID            string            `json:"meta_id"`
+		Path              string            `json:"path"`
+		Target            string            `json:"target,omitempty"`
+		Status            deployment.Status `json:"status"`
+		DeploymentCommand string            `json:"deployment_cmd,omitempty"`
+	}
+
 	// UpdateDeploymentStack is the request payload item for updating the deployment status.
 	UpdateDeploymentStack struct {
 		StackID int64             `json:"stack_id"`
@@ -436,6 +479,9 @@ var (
 	_ = Resource(DeploymentStackResponse{})
 	_ = Resource(DeploymentStacksResponse{})
 	_ = Resource(UpdateDeploymentStack{})
+	_ = Resource(Deployment{})
+	_ = Resource(DeploymentStack{})
+	_ = Resource(DeploymentsResponse{})
 	_ = Resource(UpdateDeploymentStacks{})
 	_ = Resource(ReviewRequest{})
 	_ = Resource(Reviewer{})
@@ -572,14 +618,36 @@ func (s Stack) Validate() error {
 	if strings.ToLower(s.MetaID) != s.MetaID {
 		return errors.E(`"meta_id" requires a lowercase string but %s provided`, s.MetaID)
 	}
+	if len(s.ChangeReason) > MaxChangeReasonSize {
+		return errors.E(`"change_reason" exceeds the maximum size of %d bytes`, MaxChangeReasonSize)
+	}
 	return nil
 }
 
+// TruncateChangeReason truncates the change reason to at most MaxChangeReasonSize
+// bytes, without breaking multi-byte characters.
+func TruncateChangeReason(reason string) string {
+	if len(reason) <= MaxChangeReasonSize {
+		return reason
+	}
+	const ellipsis = "..."
+	n := MaxChangeReasonSize - len(ellipsis)
+	for n > 0 && !utf8.RuneStart(reason[n]) {
+		n--
+	}
+	return reason[:n] + ellipsis
+}
+
 // Validate a drift.
 func (d Drift) Validate() error {
 	if err := d.Status.Validate(); err != nil {
 		return err
 	}
+	if d.Context != "" {
+		if err := d.Context.Validate(); err != nil {
+			return err
+		}
+	}
 	if d.Details != nil {
 		return d.Details.Validate()
 	}
@@ -599,6 +667,11 @@ func (d DriftStackPayloadRequest) Validate() error {
 	if err := d.Status.Validate(); err != nil {
 		return err
 	}
+	if d.Context != "" {
+		if err := d.Context.Validate(); err != nil {
+			return err
+		}
+	}
 	if d.Details != nil {
 		return d.Details.Validate()
 	}
@@ -678,6 +751,45 @@ func (d DeploymentStackResponse) Validate() error {
 	return d.Status.Validate()
 }
 
+// Validate the deployment entity.
+func (d Deployment) Validate() error {
+	if d.UUID == "" {
+		return errors.E(`missing "deployment_uuid" field`)
+	}
+	if err := d.Status.Validate(); err != nil {
+		return err
+	}
+	return validateResourceList(d.Stacks...)
+}
+
+// Validate the deployment stack entity.
+func (d DeploymentStack) Validate() error {
+	if d.MetaID == "" {
+		return errors.E(`missing "meta_id" field`)
+	}
+	return d.Status.Validate()
+}
+
+// Validate the DeploymentsResponse object.
+func (d DeploymentsResponse) Validate() error {
+	if err := validateResourceList(d.Deployments...); err != nil {
+		return err
+	}
+	return d.Pagination.Validate()
+}
+
+// Duration returns the duration of the deployment, until now if it's not
+// finished yet, or zero if the start time is unknown.
+func (d Deployment) Duration() time.Duration {
+	if d.CreatedAt == nil {
+		return 0
+	}
+	if d.FinishedAt == nil {
+		return time.Since(*d.CreatedAt)
+	}
+	return d.FinishedAt.Sub(*d.CreatedAt)
+}
+
 // Validate the DeploymentReviewRequest object.
 func (rr ReviewRequest) Validate() error {
 	if rr.Repository == "" {
@@ -0,0 +1,36 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package cli
+
+import (
+	"github.com/terramate-io/terramate/config"
+	"github.com/terramate-io/terramate/errors"
+	"github.com/terramate-io/terramate/run"
+)
+
+// selectChunk returns the stacks of the chunk given by the --chunk spec, or
+// all the stacks if the spec is empty. With --verbose, the chunk of each
+// selected stack is printed to stderr.
+func selectChunk[S ~[]E, E any](c *cli, spec string, items S, getStack func(E) *config.Stack) S {
+	if spec == "" {
+		return items
+	}
+
+	index, total, err := run.ParseChunk(spec)
+	if err != nil {
+		fatalWithDetailf(err, "Invalid args")
+	}
+
+	chunks, reason, err := run.Chunks(c.cfg(), items, getStack, total)
+	if err != nil {
+		fatalWithDetailf(errors.E(err, reason), "Invalid stack configuration")
+	}
+
+	for i, chunk := range chunks {
+		for _, item := range chunk {
+			c.output.MsgStdErrV("stack %s is in chunk %d/%d", getStack(item).Dir, i, total)
+		}
+	}
+	return chunks[index]
+}
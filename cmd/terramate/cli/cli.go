@@ -39,8 +39,10 @@ import (
 	"github.com/terramate-io/terramate/hcl/eval"
 	"github.com/terramate-io/terramate/hcl/fmt"
 	"github.com/terramate-io/terramate/hcl/info"
+	"github.com/terramate-io/terramate/modvendor"
 	"github.com/terramate-io/terramate/modvendor/download"
 	"github.com/terramate-io/terramate/printer"
+	"github.com/terramate-io/terramate/progress"
 	"github.com/terramate-io/terramate/safeguard"
 	"github.com/terramate-io/terramate/tg"
 	"github.com/terramate-io/terramate/versions"
@@ -52,13 +54,13 @@ import (
 
 	prj "github.com/terramate-io/terramate/project"
 	"github.com/terramate-io/terramate/run"
-	"github.com/terramate-io/terramate/run/dag"
+	"github.com/terramate-io/terramate/run/state"
 	"github.com/terramate-io/terramate/tf"
 	"github.com/zclconf/go-cty/cty"
 	"github.com/zclconf/go-cty/cty/json"
 
 	"github.com/alecthomas/kong"
-	"github.com/emicklei/dot"
+	"github.com/fatih/color"
 
 	_ "embed"
 
@@ -77,6 +79,9 @@ const (
 	ErrCurrentHeadIsOutOfDate errors.Kind = "current HEAD is out-of-date with the remote base branch"
 	// ErrOutdatedGenCodeDetected indicates outdated generated code detected.
 	ErrOutdatedGenCodeDetected errors.Kind = "outdated generated code detected"
+	// ErrNotDefaultBranch indicates a deployment is synchronized from a branch
+	// other than the default branch.
+	ErrNotDefaultBranch errors.Kind = "current branch is not the default branch"
 )
 
 const (
@@ -93,6 +98,8 @@ const (
 
 const defaultVendorDir = "/modules"
 
+const terragruntConfigFilename = "terragrunt.hcl"
+
 const terramateUserConfigDir = ".terramate.d"
 
 const (
@@ -110,9 +117,10 @@ type cliSpec struct {
 
 	deprecatedGlobalSafeguardsCliSpec
 
-	DisableCheckpoint          bool `hidden:"true" optional:"true" default:"false" help:"Disable checkpoint checks for updates."`
-	DisableCheckpointSignature bool `hidden:"true" optional:"true" default:"false" help:"Disable checkpoint signature."`
-	CPUProfiling               bool `hidden:"true" optional:"true" default:"false" help:"Create a CPU profile file when running"`
+	DisableCheckpoint          bool   `hidden:"true" optional:"true" default:"false" help:"Disable checkpoint checks for updates."`
+	DisableCheckpointSignature bool   `hidden:"true" optional:"true" default:"false" help:"Disable checkpoint signature."`
+	CPUProfiling               bool   `hidden:"true" optional:"true" default:"false" help:"Create a CPU profile file when running"`
+	CloudProfile               string `name:"profile" env:"TM_CLOUD_PROFILE" default:"default" help:"Set the profile of the Terramate Cloud user credential."`
 
 	Create struct {
 		Path        string   `arg:"" optional:"" name:"path" predictor:"file" help:"Path of the new stack."`
@@ -130,21 +138,53 @@ type cliSpec struct {
 		AllTerraform   bool     `help:"Import existing Terraform Root Modules as stacks."`
 		AllTerragrunt  bool     `help:"Import existing Terragrunt Modules as stacks."`
 		EnsureStackIDs bool     `name:"ensure-stack-ids" help:"Set the ID of existing stacks that do not set an ID to a new UUIDv4."`
+		FixDuplicates  bool     `help:"With --ensure-stack-ids, set a new UUIDv4 to the stacks sharing the ID of another stack, keeping it on the first stack by path."`
 		NoGenerate     bool     `help:"Do not run code generation after creating the new stack."`
+		Terragrunt     bool     `help:"Create a Terragrunt module in the new stack with a terragrunt.hcl file using the module given by --source."`
+		Source         string   `help:"Set the Terraform module source of the terragrunt.hcl created by --terragrunt."`
 	} `cmd:"" help:"Create or import stacks."`
 
+	Stack struct {
+		Delete struct {
+			Path         string `arg:"" name:"path" predictor:"file" help:"Path of the stack to delete."`
+			Yes          bool   `help:"Delete the stack without asking for confirmation."`
+			KeepDir      bool   `help:"Remove only the stack block, turning the stack into a plain directory."`
+			CloudArchive bool   `help:"Archive the stack in Terramate Cloud."`
+			Target       string `help:"Set the deployment target of the stack archived in Terramate Cloud."`
+		} `cmd:"" help:"Delete a stack."`
+	} `cmd:"" help:"Manage stacks."`
+
 	Fmt struct {
 		Files            []string `arg:"" optional:"true" predictor:"file" help:"List of files to be formatted."`
 		Check            bool     `hidden:"" help:"Lists unformatted files but do not change them. (Exits with 0 if all is formatted, 1 otherwise)"`
 		DetailedExitCode bool     `help:"Return a detailed exit code: 0 nothing changed, 1 an error happened, 2 changes were made."`
+		StdinFilename    string   `help:"Path of the file being formatted from stdin, used in diagnostics and check results."`
+		Exclude          []string `help:"Glob pattern of directories or files, relative to the working directory, to skip when formatting the tree."`
+		Recursive        bool     `default:"true" negatable:"" help:"Format the files of the sub directories of the working directory. Use --no-recursive to format only the files of the working directory."`
 	} `cmd:"" help:"Format configuration files."`
 
+	Validate struct {
+		JSON bool `help:"Show the diagnostics as JSON."`
+	} `cmd:"" help:"Validate the configuration files without generating code or accessing git."`
+
 	List struct {
 		Why bool `help:"Shows the reason why the stack has changed."`
 
 		cloudFilterFlags
+		pathFilterFlags
+		stackIDFilterFlags
 		Target   string `help:"Select the deployment target of the filtered stacks."`
 		RunOrder bool   `default:"false" help:"Sort listed stacks by order of execution"`
+		Group    bool   `default:"false" help:"Group the stacks sorted by --run-order into levels that can run concurrently"`
+		Shuffle  bool   `default:"false" help:"Shuffle the order of execution sorted by --run-order, as done by 'terramate run --shuffle'"`
+		Seed     int64  `default:"0" help:"Set the seed of --shuffle to reproduce a previous order. A random seed is used if not set or set to 0"`
+		Overlay  string `predictor:"file" help:"Directory of Terramate files layered over the project configuration."`
+
+		ErrorOnEmpty bool   `default:"false" help:"Exit with status 3 when no stacks are listed."`
+		Chunk        string `default:"" help:"List only the chunk <index>/<total> of the selected stacks, as selected by 'terramate run --chunk'."`
+
+		Format string `default:"text" enum:"text,json,github-matrix" help:"Output format: 'text', 'json' or 'github-matrix'. The 'github-matrix' format prints a GitHub Actions matrix, suitable for fromJSON()."`
+		Max    int    `default:"256" help:"Fail if --format github-matrix lists more than the given number of stacks. Defaults to the job limit of GitHub Actions."`
 
 		changeDetectionFlags
 	} `cmd:"" help:"List stacks."`
@@ -153,15 +193,27 @@ type cliSpec struct {
 		runCommandFlags `envprefix:"TM_ARG_RUN_"`
 		runSafeguardsCliSpec
 		outputsSharingFlags
+		pathFilterFlags
+		stackIDFilterFlags
 	} `cmd:"" help:"Run command in the stacks"`
 
 	Generate struct {
-		Parallel         int  `env:"TM_ARG_GENERATE_PARALLEL" short:"j" optional:"true" help:"Set the parallelism of code generation"`
-		DetailedExitCode bool `default:"false" help:"Return a detailed exit code: 0 nothing changed, 1 an error happened, 2 changes were made."`
+		Parallel         int           `env:"TM_ARG_GENERATE_PARALLEL" short:"j" optional:"true" help:"Set the parallelism of code generation"`
+		DetailedExitCode bool          `default:"false" help:"Return a detailed exit code: 0 nothing changed, 1 an error happened, 2 changes were made."`
+		Metrics          bool          `default:"false" help:"Show timing metrics of the code generation."`
+		AllowDelete      bool          `default:"false" help:"Delete generated files that are not generated anymore."`
+		Context          string        `default:"all" enum:"all,stack,root" help:"Generate only the blocks of the given context: 'all', 'stack' or 'root'."`
+		GlobalsFile      string        `predictor:"file" help:"Read global overrides from a file of HCL attribute assignments, applied to the stacks of the working directory."`
+		Watch            bool          `default:"false" help:"Watch the project for changes and generate the code of the affected stacks again."`
+		WatchInterval    time.Duration `default:"500ms" help:"Interval between the scans of the project for changes in --watch mode."`
+
+		changeDetectionFlags
 	} `cmd:"" help:"Run Code Generation in stacks."`
 
 	Script struct {
-		List struct{} `cmd:"" help:"List scripts."`
+		List struct {
+			Stack string `predictor:"file" help:"Show the effective scripts of the stack at the given path."`
+		} `cmd:"" help:"List scripts."`
 		Tree struct{} `cmd:"" help:"Dump a tree of scripts."`
 		Info struct {
 			Cmds []string `arg:"" optional:"true" passthrough:"" help:"Script to show info for."`
@@ -170,16 +222,29 @@ type cliSpec struct {
 			runScriptFlags `envprefix:"TM_ARG_RUN_"`
 			runSafeguardsCliSpec
 			outputsSharingFlags
+			pathFilterFlags
+			stackIDFilterFlags
 		} `cmd:"" help:"Run a Terramate Script in stacks."`
 	} `cmd:"" help:"Use Terramate Scripts"`
 
 	Debug struct {
 		Show struct {
-			Metadata        struct{} `cmd:"" help:"Show metadata available in stacks."`
-			Globals         struct{} `cmd:"" help:"Show globals available in stacks."`
+			Metadata struct{} `cmd:"" help:"Show metadata available in stacks."`
+			Globals  struct {
+				ShowSensitive bool   `help:"Show the values of sensitive globals."`
+				Overlay       string `predictor:"file" help:"Directory of Terramate files layered over the project configuration."`
+			} `cmd:"" help:"Show globals available in stacks."`
 			GenerateOrigins struct {
 			} `cmd:"" help:"Show details about generated code in stacks."`
 			RuntimeEnv struct{} `cmd:"" help:"Show available run-time environment variables (ENV) in stacks."`
+			RunConfig  struct{} `cmd:"" help:"Show the effective run configuration of stacks and where it is defined."`
+			Scope      struct {
+				Path          string `arg:"" optional:"true" name:"path" predictor:"file" help:"Directory to show the scope of (default: working directory)."`
+				Values        bool   `help:"Show the expressions of globals, env variables and config settings."`
+				ShowSensitive bool   `help:"Show the expressions of sensitive globals."`
+				Format        string `default:"text" enum:"text,json" help:"Output format: 'text' or 'json'."`
+			} `cmd:"" help:"Show the configuration affecting a directory, level by level from the root."`
+			Config struct{} `cmd:"" help:"Show the effective telemetry and checkpoint settings and where they are defined."`
 		} `cmd:"" help:"Show configuration details of stacks."`
 	} `cmd:"" help:"Debug Terramate configuration."`
 
@@ -188,12 +253,26 @@ type cliSpec struct {
 			Google bool `optional:"true" help:"authenticate with google credentials"`
 			Github bool `optional:"true" help:"authenticate with github credentials"`
 		} `cmd:"" help:"Sign in to Terramate Cloud."`
-		Info  struct{} `cmd:"" help:"Show your current Terramate Cloud login status."`
+		Logout struct{} `cmd:"" help:"Sign out from Terramate Cloud, removing the stored credential."`
+		Info   struct {
+			AsJSON bool `name:"json" help:"Outputs the login status, organization, targets and entitlements as JSON."`
+		} `cmd:"" help:"Show your current Terramate Cloud login status."`
 		Drift struct {
 			Show struct {
 				Target string `help:"Show stacks from the given deployment target."`
 			} `cmd:"" help:"Show the current drift of a stack."`
 		} `cmd:"" help:"Interact with Terramate Cloud Drift Detection."`
+		Deployment struct {
+			List struct {
+				Stack  string `predictor:"file" help:"List only the deployments of the stack at the given path."`
+				Target string `help:"Use the stack from the given deployment target."`
+				Limit  int    `default:"10" help:"Maximum number of deployments to list."`
+			} `cmd:"" help:"List the most recent deployments of the repository."`
+			Show struct {
+				ID     string `required:"" help:"UUID of the deployment."`
+				AsJSON bool   `name:"json" help:"Outputs the deployment as JSON."`
+			} `cmd:"" help:"Show the details of a deployment."`
+		} `cmd:"" help:"Inspect Terramate Cloud deployments."`
 	} `cmd:"" help:"Interact with Terramate Cloud"`
 
 	Trigger struct {
@@ -203,6 +282,12 @@ type cliSpec struct {
 		IgnoreChange bool   `default:"false" help:"Trigger stacks to be ignored by change detection"`
 		Reason       string `default:"" name:"reason" help:"Set a reason for triggering the stack."`
 		cloudFilterFlags
+		stackIDFilterFlags
+		Priority int  `default:"0" help:"Set the run order priority of the triggered stacks. Higher priorities run first when the order constraints allow it."`
+		List     bool `default:"false" help:"List the existing triggers, only of the given stack path if set."`
+		Clear    bool `default:"false" help:"Remove the existing triggers of the given stack path."`
+		All      bool `default:"false" help:"Remove the triggers of all the stacks, used together with --clear."`
+		AsJSON   bool `name:"json" help:"Outputs the listed triggers as JSON."`
 	} `cmd:"" help:"Mark a stack as changed so it will be triggered in Change Detection."`
 
 	Experimental struct {
@@ -220,51 +305,88 @@ type cliSpec struct {
 			IgnoreChange bool   `default:"false" help:"Trigger stacks to be ignored by change detection"`
 			Reason       string `default:"" name:"reason" help:"Set a reason for triggering the stack."`
 			cloudFilterFlags
+			stackIDFilterFlags
+			Priority int  `default:"0" help:"Set the run order priority of the triggered stacks. Higher priorities run first when the order constraints allow it."`
+			List     bool `default:"false" help:"List the existing triggers, only of the given stack path if set."`
+			Clear    bool `default:"false" help:"Remove the existing triggers of the given stack path."`
+			All      bool `default:"false" help:"Remove the triggers of all the stacks, used together with --clear."`
+			AsJSON   bool `name:"json" help:"Outputs the listed triggers as JSON."`
 		} `cmd:"" hidden:"" help:"Mark a stack as changed so it will be triggered in Change Detection. (DEPRECATED)"`
 
 		RunGraph struct {
-			Outfile string `short:"o" predictor:"file" default:"" help:"Output .dot file"`
+			Outfile string `short:"o" predictor:"file" default:"" help:"Output file of the graph"`
 			Label   string `short:"l" default:"stack.name" help:"Label used in graph nodes (it could be either \"stack.name\" or \"stack.dir\""`
+			Format  string `default:"dot" enum:"dot,mermaid" help:"Format of the graph: 'dot' (Graphviz) or 'mermaid'."`
 		} `cmd:"" help:"Generate a graph of the execution order"`
 
+		Dependencies struct {
+			Stack  string `arg:"" optional:"true" name:"stack" predictor:"file" help:"Path of the stack, defaults to the current directory."`
+			AsJSON bool   `name:"json" help:"Outputs the dependencies as JSON."`
+		} `cmd:"" help:"Show the resolved dependencies of a stack"`
+
 		Vendor struct {
 			Download struct {
 				Dir       string `short:"d" predictor:"file" default:"" help:"dir to vendor downloaded project"`
 				Source    string `arg:"" name:"source" help:"Terraform module source URL, must be Git/Github and should not contain a reference"`
 				Reference string `arg:"" name:"ref" help:"Reference of the Terraform module to vendor"`
 			} `cmd:"" help:"Downloads a Terraform module and stores it on the project vendor dir"`
+
+			Update struct {
+				Dir      string `short:"d" predictor:"file" default:"" help:"dir to vendor downloaded project"`
+				Parallel int    `short:"j" optional:"true" help:"Set the parallelism of module downloads"`
+				Prune    bool   `default:"false" help:"Remove vendored directories no longer referenced by tm_vendor calls"`
+			} `cmd:"" help:"Downloads again all modules referenced by tm_vendor calls at their pinned references"`
 		} `cmd:"" help:"Manages vendored Terraform modules"`
 
 		Eval struct {
-			Global map[string]string `short:"g" help:"set/override globals. eg.: --global name=<expr>"`
-			AsJSON bool              `help:"Outputs the result as a JSON value"`
-			Exprs  []string          `arg:"" help:"expressions to be evaluated" name:"expr" passthrough:""`
+			Global        map[string]string `short:"g" help:"set/override globals. eg.: --global name=<expr>"`
+			GlobalsFile   string            `predictor:"file" help:"Read global overrides from a file of HCL attribute assignments. The --global flags have precedence over the file."`
+			AsJSON        bool              `help:"Outputs the result as a JSON value"`
+			ShowSensitive bool              `help:"Show the values of sensitive globals."`
+			Overlay       string            `predictor:"file" help:"Directory of Terramate files layered over the project configuration."`
+			Stack         string            `predictor:"file" help:"Evaluate in the context of the stack at the given path, absolute to the project root or relative to the working directory."`
+			Exprs         []string          `arg:"" help:"expressions to be evaluated" name:"expr" passthrough:""`
 		} `cmd:"" help:"Eval expression"`
 
 		PartialEval struct {
-			Global map[string]string `short:"g" help:"set/override globals. eg.: --global name=<expr>"`
-			Exprs  []string          `arg:"" help:"expressions to be partially evaluated" name:"expr" passthrough:""`
+			Global        map[string]string `short:"g" help:"set/override globals. eg.: --global name=<expr>"`
+			GlobalsFile   string            `predictor:"file" help:"Read global overrides from a file of HCL attribute assignments. The --global flags have precedence over the file."`
+			ShowSensitive bool              `help:"Show the values of sensitive globals."`
+			Stack         string            `predictor:"file" help:"Evaluate in the context of the stack at the given path, absolute to the project root or relative to the working directory."`
+			Exprs         []string          `arg:"" help:"expressions to be partially evaluated" name:"expr" passthrough:""`
 		} `cmd:"" help:"Partial evaluate the expressions"`
 
 		GetConfigValue struct {
-			Global map[string]string `short:"g" help:"set/override globals. eg.: --global name=<expr>"`
-			AsJSON bool              `help:"Outputs the result as a JSON value"`
-			Vars   []string          `arg:"" help:"variable to be retrieved" name:"var" passthrough:""`
+			Global        map[string]string `short:"g" help:"set/override globals. eg.: --global name=<expr>"`
+			GlobalsFile   string            `predictor:"file" help:"Read global overrides from a file of HCL attribute assignments. The --global flags have precedence over the file."`
+			AsJSON        bool              `help:"Outputs the result as a JSON value"`
+			ShowSensitive bool              `help:"Show the values of sensitive globals."`
+			Stack         string            `predictor:"file" help:"Evaluate in the context of the stack at the given path, absolute to the project root or relative to the working directory."`
+			Vars          []string          `arg:"" help:"variable to be retrieved" name:"var" passthrough:""`
 		} `cmd:"" help:"Get configuration value"`
 
 		Cloud struct {
-			Login struct{} `cmd:"" help:"login for cloud.terramate.io  (DEPRECATED)"`
-			Info  struct{} `cmd:"" help:"cloud information status (DEPRECATED)"`
+			Login struct{} `cmd:"" hidden:"" help:"login for cloud.terramate.io  (DEPRECATED)"`
+			Info  struct{} `cmd:"" hidden:"" help:"cloud information status (DEPRECATED)"`
 			Drift struct {
 				Show struct {
 				} `cmd:"" help:"show drifts  (DEPRECATED)"`
-			} `cmd:"" help:"manage cloud drifts  (DEPRECATED)"`
-		} `cmd:"" hidden:"" help:"Terramate Cloud commands (DEPRECATED)"`
+			} `cmd:"" hidden:"" help:"manage cloud drifts  (DEPRECATED)"`
+			Stacks struct {
+				List struct {
+					Target string `help:"List the stacks of the given deployment target."`
+					AsJSON bool   `name:"json" help:"Outputs the matched, cloud-only and local-only stacks as JSON."`
+				} `cmd:"" help:"List the Terramate Cloud stacks of the repository mapped to the local stacks."`
+			} `cmd:"" help:"Browse Terramate Cloud stacks."`
+		} `cmd:"" help:"Experimental Terramate Cloud commands."`
 	} `cmd:"" help:"Use experimental features."`
 
 	InstallCompletions kongplete.InstallCompletions `cmd:"" help:"Install shell completions."`
 
-	Version struct{} `cmd:"" help:"Show Terramate version"`
+	Version struct {
+		JSON  bool `help:"Show the version and update information as JSON."`
+		Check bool `help:"Exit with status 1 if an update is available, without printing the version."`
+	} `cmd:"" help:"Show Terramate version"`
 }
 
 type globalCliFlags struct {
@@ -272,6 +394,7 @@ type globalCliFlags struct {
 	Chdir          string   `env:"CHDIR" short:"C" optional:"true" predictor:"file" help:"Set working directory."`
 	GitChangeBase  string   `env:"GIT_CHANGE_BASE" short:"B" optional:"true" help:"Set git base reference for computing changes."`
 	Changed        bool     `env:"CHANGED" short:"c" optional:"true" help:"Filter stacks based on changes made in git."`
+	DiffAgainst    string   `env:"DIFF_AGAINST" optional:"true" predictor:"file" help:"Compute changes by comparing the project with a snapshot directory instead of git. Requires --changed."`
 	Tags           []string `env:"TAGS" optional:"true" sep:"none" help:"Filter stacks by tags."`
 	NoTags         []string `env:"NO_TAGS" optional:"true" sep:"," help:"Filter stacks by tags not being set."`
 	LogLevel       string   `env:"LOG_LEVEL" optional:"true" default:"warn" enum:"disabled,trace,debug,info,warn,error,fatal" help:"Log level to use: 'disabled', 'trace', 'debug', 'info', 'warn', 'error', or 'fatal'."`
@@ -279,12 +402,15 @@ type globalCliFlags struct {
 	LogDestination string   `env:"LOG_DESTINATION" optional:"true" default:"stderr" enum:"stderr,stdout" help:"Destination channel of log messages: 'stderr' or 'stdout'."`
 	Quiet          bool     `env:"QUIET" optional:"false" help:"Disable outputs."`
 	Verbose        int      `env:"VERBOSE" short:"v" optional:"true" default:"0" type:"counter" help:"Increase verboseness of output"`
+	NoParseCache   bool     `env:"NO_PARSE_CACHE" optional:"true" help:"Disable the on-disk cache of parsed configuration files."`
+	NoCache        bool     `env:"NO_CACHE" optional:"true" help:"Disable all the on-disk caches, including the cache of parsed configuration files and of the outdated code detection."`
+	ProgressFormat string   `env:"PROGRESS_FORMAT" optional:"true" default:"none" enum:"none,ndjson" help:"Format of the progress events of long-running commands: 'none' or 'ndjson'. The 'ndjson' format emits one JSON event per line on stderr."`
 }
 
 type runSafeguardsCliSpec struct {
 	// Note: The `name` and `short` are being used to define the -X flag without longer version.
 	DisableSafeguardsAll            bool               `default:"false" name:"disable-safeguards=all" short:"X" help:"Disable all safeguards."`
-	DisableSafeguards               safeguard.Keywords `env:"TM_DISABLE_SAFEGUARDS" enum:"git,all,none,git-untracked,git-uncommitted,outdated-code,git-out-of-sync" help:"Disable specific safeguards: 'all', 'none', 'git', 'git-untracked', 'git-uncommitted', 'git-out-of-sync', and/or 'outdated-code'."`
+	DisableSafeguards               safeguard.Keywords `env:"TM_DISABLE_SAFEGUARDS" enum:"git,all,none,git-untracked,git-uncommitted,outdated-code,git-out-of-sync,git-branch-protection" help:"Disable specific safeguards: 'all', 'none', 'git', 'git-untracked', 'git-uncommitted', 'git-out-of-sync', 'git-branch-protection', and/or 'outdated-code'."`
 	DeprecatedDisableCheckGenCode   bool               `hidden:"" default:"false" name:"disable-check-gen-code" env:"TM_DISABLE_CHECK_GEN_CODE" help:"Disable outdated generated code check (DEPRECATED)."`
 	DeprecatedDisableCheckGitRemote bool               `hidden:"" default:"false" name:"disable-check-git-remote" env:"TM_DISABLE_CHECK_GIT_REMOTE" help:"Disable checking if local default branch is updated with remote (DEPRECATED)."`
 }
@@ -298,6 +424,7 @@ type safeguards struct {
 	DisableCheckGitUntracked          bool
 	DisableCheckGitUncommitted        bool
 	DisableCheckGitRemote             bool
+	DisableCheckGitBranchProtection   bool
 	DisableCheckGenerateOutdatedCheck bool
 
 	reEnabled bool
@@ -321,18 +448,31 @@ type outputsSharingFlags struct {
 	OnlyOutputDependencies    bool `help:"Only include stacks that are dependencies of the selected stacks. (requires outputs-sharing experiment enabled)"`
 }
 
+type stackIDFilterFlags struct {
+	StackID          []string `name:"stack-id" sep:"none" help:"Select only the stacks with the given ID (case insensitive). Can be given multiple times."`
+	IgnoreMissingIDs bool     `name:"ignore-missing-ids" help:"Do not fail when a --stack-id matches no stack of the project."`
+}
+
+type pathFilterFlags struct {
+	Include []string `sep:"none" help:"Select only stacks matching the path or glob pattern, absolute to the project root or relative to the working directory."`
+	Exclude []string `sep:"none" help:"Skip stacks matching the path or glob pattern, absolute to the project root or relative to the working directory."`
+}
+
 type cloudTargetFlags struct {
 	Target     string `env:"TARGET" help:"Set the deployment target for stacks synchronized to Terramate Cloud."`
 	FromTarget string `env:"FROM_TARGET" help:"Migrate stacks from given deployment target."`
 }
 
 type cloudSyncFlags struct {
-	CloudSyncDeployment  bool `hidden:""`
-	SyncDeployment       bool `env:"SYNC_DEPLOYMENT" default:"false" help:"Synchronize the command as a new deployment to Terramate Cloud."`
-	CloudSyncDriftStatus bool `hidden:""`
-	SyncDriftStatus      bool `env:"SYNC_DRIFT_STATUS" default:"false" help:"Synchronize the command as a new drift run to Terramate Cloud."`
-	CloudSyncPreview     bool `hidden:""`
-	SyncPreview          bool `env:"SYNC_PREVIEW" default:"false" help:"Synchronize the command as a new preview to Terramate Cloud."`
+	CloudSyncDeployment  bool   `hidden:""`
+	SyncDeployment       bool   `env:"SYNC_DEPLOYMENT" default:"false" help:"Synchronize the command as a new deployment to Terramate Cloud."`
+	CloudSyncDriftStatus bool   `hidden:""`
+	SyncDriftStatus      bool   `env:"SYNC_DRIFT_STATUS" default:"false" help:"Synchronize the command as a new drift run to Terramate Cloud."`
+	DriftContext         string `env:"DRIFT_CONTEXT" help:"Set the context of the drift run synchronized to Terramate Cloud: scheduled or manual. Inferred from the CI/CD event if not set."`
+	CloudSyncPreview     bool   `hidden:""`
+	SyncPreview          bool   `env:"SYNC_PREVIEW" default:"false" help:"Synchronize the command as a new preview to Terramate Cloud."`
+
+	FailOnCloudError bool `env:"FAIL_ON_CLOUD_ERROR" default:"false" help:"Fail the command when the status of any stack cannot be synchronized to Terramate Cloud."`
 
 	CloudSyncLayer             preview.Layer `hidden:""`
 	Layer                      preview.Layer `env:"LAYER" default:"" help:"Set a customer layer for synchronizing a preview to Terramate Cloud."`
@@ -344,6 +484,12 @@ type commonRunFlags struct {
 	ContinueOnError bool `env:"CONTINUE_ON_ERROR" default:"false" help:"Continue executing next stacks when a command returns an error."`
 	DryRun          bool `env:"DRY_RUN" default:"false" help:"Plan the execution but do not execute it."`
 	Reverse         bool `env:"REVERSE" default:"false" help:"Reverse the order of execution."`
+	ErrorOnEmpty    bool `env:"ERROR_ON_EMPTY" default:"false" help:"Exit with status 3 when no stacks are selected."`
+
+	Chunk string `env:"CHUNK" default:"" help:"Select only the chunk <index>/<total> of the selected stacks, for splitting a run across CI jobs. Stacks with ordering constraints between them are always in the same chunk."`
+
+	Summary     string `env:"SUMMARY" default:"none" enum:"none,text,json" help:"Show a summary of the run: 'text' prints the counts and the slowest stacks to stderr, 'json' writes the result of each stack."`
+	SummaryFile string `env:"SUMMARY_FILE" default:"" predictor:"file" help:"Write the summary of --summary json to the given file instead of stdout."`
 
 	// Note: 0 is not the real default value here, this is just a workaround.
 	// Kong doesn't support having 0 as the default value in case the flag isn't set, but K in case it's set without a value.
@@ -359,17 +505,32 @@ type runCommandFlags struct {
 	EnableSharing bool `env:"ENABLE_SHARING" help:"Enable sharing of stack outputs as stack inputs."`
 	MockOnFail    bool `env:"MOCK_ON_FAIL" help:"Mock the output values if command fails."`
 
+	IsolateDataDir bool `env:"ISOLATE_DATA_DIR" help:"Set TF_DATA_DIR to a data directory per stack, at .terramate/data/<stack-id>."`
+
 	cloudSyncFlags
 
+	SyncLogs syncLogsMode `env:"SYNC_LOGS" help:"Upload the last 64KB of the output of the failed stacks of --sync-deployment to Terramate Cloud after they finish, instead of streaming it. Use --sync-logs=all to upload the output of all stacks."`
+
 	TerraformPlanFile string `env:"TERRAFORM_PLAN_FILE" default:"" help:"Add details of the Terraform Plan file to the synchronization to Terramate Cloud."`
 	TofuPlanFile      string `env:"TOFU_PLAN_FILE" default:"" help:"Add details of the OpenTofu Plan file to the synchronization to Terramate Cloud."`
 	DebugPreviewURL   string `hidden:"true" default:"" help:"Create a debug preview URL to Terramate Cloud details."`
 
+	OnlyPlanChangedResources bool `env:"ONLY_PLAN_CHANGED_RESOURCES" default:"false" help:"(experimental) Add -target options for the resources changed in the last drift of the stacks in Terramate Cloud. Stacks without drift details are skipped."`
+	MaxTargets               int  `env:"MAX_TARGETS" default:"20" help:"Maximum number of -target options added by --only-plan-changed-resources. Stacks with more changed resources run the full command."`
+
+	StreamSelection bool `env:"STREAM_SELECTION" default:"false" help:"(experimental) Start running the changed stacks while the change detection is in progress. Requires --changed."`
+
+	Resume bool `default:"false" help:"Resume the last run, or the run with the ID given before the command, executing only the stacks which failed or were not started."`
+
+	Shuffle bool  `env:"SHUFFLE" default:"false" help:"Pseudo-randomly shuffle the order of execution of the stacks, still honoring their ordering constraints."`
+	Seed    int64 `env:"SEED" default:"0" help:"Set the seed of --shuffle to reproduce a previous order. A random seed is used if not set or set to 0."`
+
 	commonRunFlags
 
-	Eval       bool     `env:"EVAL" default:"false" help:"Evaluate command arguments as HCL strings interpolating Globals, Functions and Metadata."`
-	Terragrunt bool     `env:"TERRAGRUNT" default:"false" help:"Use terragrunt when generating planfile for Terramate Cloud sync."`
-	Command    []string `arg:"" name:"cmd" predictor:"file" passthrough:"" help:"Command to execute"`
+	Eval        bool     `env:"EVAL" default:"false" help:"Evaluate command arguments as HCL strings interpolating Globals, Functions and Metadata."`
+	GlobalsFile string   `env:"GLOBALS_FILE" predictor:"file" help:"Read global overrides from a file of HCL attribute assignments, used by --eval."`
+	Terragrunt  bool     `env:"TERRAGRUNT" default:"false" help:"Use terragrunt when generating planfile for Terramate Cloud sync."`
+	Command     []string `arg:"" optional:"true" name:"cmd" predictor:"file" passthrough:"" help:"Command to execute"`
 }
 
 type runScriptFlags struct {
@@ -378,7 +539,9 @@ type runScriptFlags struct {
 	cloudTargetFlags
 	commonRunFlags
 
-	Cmds []string `arg:"" optional:"true" passthrough:"" help:"Script to execute."`
+	FailOnCloudError bool `env:"FAIL_ON_CLOUD_ERROR" default:"false" help:"Fail the script when the status of any stack cannot be synchronized to Terramate Cloud."`
+
+	Cmds []string `arg:"" optional:"true" passthrough:"" help:"Script to execute, optionally followed by -- and the arguments exposed as script.args."`
 }
 
 // Exec will execute terramate with the provided flags defined on args.
@@ -428,7 +591,31 @@ type cli struct {
 
 	tags filter.TagClause
 
+	// pathFilter is set by the commands supporting --include and --exclude.
+	pathFilter filter.PathFilter
+
+	// stackIDs is set by the commands supporting --stack-id, with the lower
+	// cased IDs of the stacks to select.
+	stackIDs map[string]struct{}
+
 	changeDetection changeDetection
+
+	// progress is set when --progress-format=ndjson is used.
+	progress *progress.Writer
+
+	// generateStacks is set by the generate command when --changed or a tags
+	// filter restricts the stacks to generate, nil means all the stacks.
+	generateStacks prj.Paths
+
+	// lastGenerateReport is the report of the last code generation done by
+	// the generate command.
+	lastGenerateReport *generate.Report
+
+	// runState is set by the run command when the state of the run is saved.
+	runState *state.File
+
+	// runSummary is set by the run commands when --summary is used.
+	runSummary *runSummary
 }
 
 type changeDetection struct {
@@ -499,6 +686,19 @@ func newCLI(version string, args []string, stdin io.Reader, stdout, stderr io.Wr
 	// profiler is only started if Terramate is built with -tags profiler
 	startProfiler(&parsedArgs)
 
+	var progressWriter *progress.Writer
+	if parsedArgs.ProgressFormat == progress.FormatNDJSON {
+		// human-readable output on stderr is turned into log events, so
+		// every line of stderr can be decoded.
+		progressWriter = progress.NewWriter(stderr)
+		stderr = progressWriter.LogWriter("")
+		printer.Stderr = printer.NewPrinter(stderr)
+		color.NoColor = true
+		if parsedArgs.LogFmt == "console" {
+			parsedArgs.LogFmt = "text"
+		}
+	}
+
 	configureLogging(parsedArgs.LogLevel, parsedArgs.LogFmt,
 		parsedArgs.LogDestination, stdout, stderr)
 	// If we don't re-create the logger after configuring we get some
@@ -543,45 +743,34 @@ func newCLI(version string, args []string, stdin io.Reader, stdout, stderr io.Wr
 		clicfg.UserTerramateDir = homeTmDir
 	}
 
-	checkpointResults := make(chan *checkpoint.CheckResponse, 1)
-	go runCheckpoint(
-		version,
-		clicfg,
-		checkpointResults,
-	)
+	if err := validateCloudProfile(parsedArgs.CloudProfile); err != nil {
+		fatal(err)
+	}
 
 	switch ctx.Command() {
 	case "version":
 		logger.Debug().Msg("Get terramate version with version subcommand.")
-		stdfmt.Println(version)
-
-		info := <-checkpointResults
 
-		if info != nil {
-			if info.Outdated {
-				releaseDate := time.Unix(int64(info.CurrentReleaseDate), 0).UTC()
-				output.MsgStdOut("\nYour version of Terramate is out of date! The latest version\n"+
-					"is %s (released on %s).\nYou can update by downloading from %s",
-					info.CurrentVersion, releaseDate.Format(time.UnixDate),
-					info.CurrentDownloadURL)
-			}
-
-			if len(info.Alerts) > 0 {
-				plural := ""
-				if len(info.Alerts) > 1 {
-					plural = "s"
-				}
+		// the project is not loaded but checkpoint can be disabled in its
+		// root configuration.
+		wd := parsedArgs.Chdir
+		if wd == "" {
+			wd, _ = os.Getwd()
+		}
+		cp := checkpointSetting(parsedArgs.DisableCheckpoint, clicfg, lookupRootConfig(wd))
+		results := startCheckpoint(version, clicfg, cp)
 
-				output.MsgStdOut("\nYour version of Terramate has %d alert%s:\n", len(info.Alerts), plural)
+		if !parsedArgs.Version.JSON && !parsedArgs.Version.Check {
+			printVersion(output, version, results)
+			return &cli{exit: true}
+		}
 
-				for _, alert := range info.Alerts {
-					urlDesc := ""
-					if alert.URL != "" {
-						urlDesc = stdfmt.Sprintf(" (more information at %s)", alert.URL)
-					}
-					output.MsgStdOut("\t- [%s] %s%s", alert.Level, alert.Message, urlDesc)
-				}
-			}
+		info := waitCheckpoint(results)
+		if parsedArgs.Version.JSON {
+			printVersionJSON(version, info)
+		}
+		if parsedArgs.Version.Check && info != nil && info.Outdated {
+			os.Exit(1)
 		}
 
 		return &cli{exit: true}
@@ -598,9 +787,9 @@ func newCLI(version string, args []string, stdin io.Reader, stdout, stderr io.Wr
 	case "cloud login":
 		var err error
 		if parsedArgs.Cloud.Login.Github {
-			err = githubLogin(output, cloudBaseURL(), idpkey(), clicfg)
+			err = githubLogin(output, cloudBaseURL(), idpkey(), clicfg, parsedArgs.CloudProfile)
 		} else {
-			err = googleLogin(output, idpkey(), clicfg)
+			err = googleLogin(output, idpkey(), clicfg, parsedArgs.CloudProfile)
 		}
 		if err != nil {
 			printer.Stderr.Error(err)
@@ -608,6 +797,12 @@ func newCLI(version string, args []string, stdin io.Reader, stdout, stderr io.Wr
 		}
 		output.MsgStdOut("authenticated successfully")
 		return &cli{exit: true}
+	case "cloud logout":
+		if err := cloudLogout(output, clicfg, parsedArgs.CloudProfile); err != nil {
+			printer.Stderr.Error(err)
+			os.Exit(1)
+		}
+		return &cli{exit: true}
 	}
 
 	wd, err := os.Getwd()
@@ -639,7 +834,31 @@ func newCLI(version string, args []string, stdin io.Reader, stdout, stderr io.Wr
 		fatalWithDetailf(err, "evaluating symlinks on working dir: %s", wd)
 	}
 
-	prj, foundRoot, err := lookupProject(wd)
+	if !parsedArgs.NoParseCache && !parsedArgs.NoCache {
+		hcl.EnableParseCache(version)
+	}
+	if !parsedArgs.NoCache {
+		generate.EnableOutdatedCache(version)
+	}
+
+	if ctx.Command() == "validate" {
+		// the configuration is validated before it's loaded, so all the
+		// problems are reported instead of failing on the first one.
+		validateProject(wd, parsedArgs.Validate.JSON)
+		return &cli{exit: true}
+	}
+
+	var overlay string
+	switch ctx.Command() {
+	case "list":
+		overlay = parsedArgs.List.Overlay
+	case "debug show globals":
+		overlay = parsedArgs.Debug.Show.Globals.Overlay
+	case "experimental eval <expr>":
+		overlay = parsedArgs.Experimental.Eval.Overlay
+	}
+
+	prj, foundRoot, err := lookupProject(wd, overlay)
 	if err != nil {
 		fatalWithDetailf(err, "unable to parse configuration")
 	}
@@ -663,12 +882,27 @@ Please see https://terramate.io/docs/cli/configuration/project-setup for details
 		fatalWithDetailf(err, "setting configuration")
 	}
 
-	if parsedArgs.Changed && !prj.isRepo {
-		fatal("flag --changed provided but no git repository found")
-	}
+	if parsedArgs.DiffAgainst != "" {
+		if !parsedArgs.Changed {
+			fatal("flag --diff-against requires --changed")
+		}
+		snapshotdir := parsedArgs.DiffAgainst
+		if !filepath.IsAbs(snapshotdir) {
+			snapshotdir = filepath.Join(wd, snapshotdir)
+		}
+		var gw *git.Git
+		if prj.isRepo {
+			gw = prj.git.wrapper
+		}
+		prj.stackManager = stack.NewDiffAwareManager(prj.root, gw, stack.NewDirDiffer(prj.rootdir, snapshotdir))
+	} else {
+		if parsedArgs.Changed && !prj.isRepo {
+			fatal("flag --changed provided but no git repository found")
+		}
 
-	if parsedArgs.Changed && !prj.hasCommits() {
-		fatal("flag --changed requires a repository with at least two commits")
+		if parsedArgs.Changed && !prj.hasCommits() {
+			fatal("flag --changed requires a repository with at least two commits")
+		}
 	}
 
 	uimode := HumanMode
@@ -676,6 +910,8 @@ Please see https://terramate.io/docs/cli/configuration/project-setup for details
 		uimode = AutomationMode
 	}
 
+	checkpoint := checkpointSetting(parsedArgs.DisableCheckpoint, clicfg, prj.root.Tree().Node)
+
 	return &cli{
 		version:    version,
 		stdin:      stdin,
@@ -692,7 +928,11 @@ Please see https://terramate.io/docs/cli/configuration/project-setup for details
 		// http.Client in all requests, for most hosts.
 		// The transport can be tuned here, if needed.
 		httpClient:        http.Client{},
-		checkpointResults: make(chan *checkpoint.CheckResponse, 1),
+		checkpointResults: startCheckpoint(version, clicfg, checkpoint),
+		progress:          progressWriter,
+		cloud: cloudConfig{
+			syncFailures: &cloudSyncFailures{},
+		},
 	}
 }
 
@@ -717,11 +957,14 @@ func (c *cli) run() {
 	case "fmt", "fmt <files>":
 		c.initAnalytics("fmt",
 			tel.BoolFlag("detailed-exit-code", c.parsedArgs.Fmt.DetailedExitCode),
+			tel.BoolFlag("no-recursive", !c.parsedArgs.Fmt.Recursive),
 		)
 		c.format()
 		c.sendAndWaitForAnalytics()
 	case "create <path>":
-		c.initAnalytics("create")
+		c.initAnalytics("create",
+			tel.BoolFlag("terragrunt", c.parsedArgs.Create.Terragrunt),
+		)
 		c.createStack()
 		c.sendAndWaitForAnalytics()
 	case "create":
@@ -740,13 +983,26 @@ func (c *cli) run() {
 			tel.StringFlag("filter-deployment-status", c.parsedArgs.List.DeploymentStatus),
 			tel.StringFlag("filter-target", c.parsedArgs.List.Target),
 			tel.BoolFlag("run-order", c.parsedArgs.List.RunOrder),
+			tel.BoolFlag("group", c.parsedArgs.List.Group),
+			tel.BoolFlag("shuffle", c.parsedArgs.List.Shuffle),
+			tel.BoolFlag("filter-paths", !c.parsedArgs.List.pathFilterFlags.isEmpty()),
+			tel.BoolFlag("filter-stack-ids", len(c.parsedArgs.List.StackID) != 0),
+			tel.BoolFlag("overlay", c.parsedArgs.List.Overlay != ""),
+			tel.BoolFlag("error-on-empty", c.parsedArgs.List.ErrorOnEmpty),
+			tel.StringFlag("format", c.parsedArgs.List.Format),
+			tel.BoolFlag("chunk", c.parsedArgs.List.Chunk != ""),
 		)
+		c.setupFilterPaths(c.parsedArgs.List.pathFilterFlags)
+		c.setupFilterStackIDs(c.parsedArgs.List.stackIDFilterFlags)
 		c.setupGit()
 		c.setupChangeDetection(c.parsedArgs.List.EnableChangeDetection, c.parsedArgs.List.DisableChangeDetection)
 		c.printStacks()
 		c.sendAndWaitForAnalytics()
 	case "run":
-		fatal("no command specified")
+		if !c.parsedArgs.Run.Resume {
+			fatal("no command specified")
+		}
+		fallthrough
 	case "run <cmd>":
 		c.initAnalytics("run",
 			tel.BoolFlag("filter-changed", c.parsedArgs.Changed),
@@ -757,7 +1013,9 @@ func (c *cli) run() {
 			tel.StringFlag("target", c.parsedArgs.Run.Target),
 			tel.BoolFlag("sync-deployment", c.parsedArgs.Run.SyncDeployment),
 			tel.BoolFlag("sync-drift", c.parsedArgs.Run.SyncDriftStatus),
+			tel.StringFlag("drift-context", c.parsedArgs.Run.DriftContext),
 			tel.BoolFlag("sync-preview", c.parsedArgs.Run.SyncPreview),
+			tel.StringFlag("sync-logs", string(c.parsedArgs.Run.SyncLogs)),
 			tel.StringFlag("terraform-planfile", c.parsedArgs.Run.TerraformPlanFile),
 			tel.StringFlag("tofu-planfile", c.parsedArgs.Run.TofuPlanFile),
 			tel.StringFlag("layer", string(c.parsedArgs.Run.Layer)),
@@ -766,7 +1024,17 @@ func (c *cli) run() {
 			tel.BoolFlag("parallel", c.parsedArgs.Run.Parallel > 0),
 			tel.BoolFlag("output-sharing", c.parsedArgs.Run.EnableSharing),
 			tel.BoolFlag("output-mocks", c.parsedArgs.Run.MockOnFail),
+			tel.BoolFlag("filter-paths", !c.parsedArgs.Run.pathFilterFlags.isEmpty()),
+			tel.BoolFlag("filter-stack-ids", len(c.parsedArgs.Run.StackID) != 0),
+			tel.BoolFlag("only-plan-changed-resources", c.parsedArgs.Run.OnlyPlanChangedResources),
+			tel.BoolFlag("error-on-empty", c.parsedArgs.Run.ErrorOnEmpty),
+			tel.BoolFlag("stream-selection", c.parsedArgs.Run.StreamSelection),
+			tel.BoolFlag("resume", c.parsedArgs.Run.Resume),
+			tel.BoolFlag("shuffle", c.parsedArgs.Run.Shuffle),
+			tel.BoolFlag("chunk", c.parsedArgs.Run.Chunk != ""),
 		)
+		c.setupFilterPaths(c.parsedArgs.Run.pathFilterFlags)
+		c.setupFilterStackIDs(c.parsedArgs.Run.stackIDFilterFlags)
 		c.setupGit()
 		c.setupChangeDetection(c.parsedArgs.Run.EnableChangeDetection, c.parsedArgs.Run.DisableChangeDetection)
 		c.setupSafeguards(c.parsedArgs.Run.runSafeguardsCliSpec)
@@ -776,11 +1044,37 @@ func (c *cli) run() {
 		c.initAnalytics("generate",
 			tel.BoolFlag("detailed-exit-code", c.parsedArgs.Generate.DetailedExitCode),
 			tel.BoolFlag("parallel", c.parsedArgs.Generate.Parallel > 0),
+			tel.BoolFlag("metrics", c.parsedArgs.Generate.Metrics),
+			tel.BoolFlag("allow-delete", c.parsedArgs.Generate.AllowDelete),
+			tel.BoolFlag("filter-changed", c.parsedArgs.Changed),
+			tel.BoolFlag("filter-tags", len(c.parsedArgs.Tags) != 0 || len(c.parsedArgs.NoTags) != 0),
+			tel.BoolFlag("globals-file", c.parsedArgs.Generate.GlobalsFile != ""),
+			tel.BoolFlag("watch", c.parsedArgs.Generate.Watch),
 		)
-		exitCode := c.generate()
+		c.setupGit()
+		c.setupChangeDetection(c.parsedArgs.Generate.EnableChangeDetection, c.parsedArgs.Generate.DisableChangeDetection)
+		if stacks, ok := c.filteredStackPaths(); ok {
+			c.generateStacks = stacks
+		}
+		if overrides := c.loadGlobalsFile(c.parsedArgs.Generate.GlobalsFile); len(overrides) > 0 {
+			c.cfg().SetGlobalOverrides(overrides)
+		}
+		var exitCode int
+		if c.parsedArgs.Generate.Watch {
+			exitCode = c.generateWatch()
+		} else {
+			exitCode = c.generate()
+		}
 		stopProfiler(c.parsedArgs)
 		c.sendAndWaitForAnalytics()
 		os.Exit(exitCode)
+	case "stack delete <path>":
+		c.initAnalytics("stack-delete",
+			tel.BoolFlag("keep-dir", c.parsedArgs.Stack.Delete.KeepDir),
+			tel.BoolFlag("cloud-archive", c.parsedArgs.Stack.Delete.CloudArchive),
+		)
+		c.deleteStack()
+		c.sendAndWaitForAnalytics()
 	case "experimental clone <srcdir> <destdir>":
 		c.initAnalytics("clone")
 		c.cloneStack()
@@ -789,8 +1083,17 @@ func (c *cli) run() {
 		c.parsedArgs.Trigger = c.parsedArgs.Experimental.Trigger
 		fallthrough
 	case "trigger":
-		c.initAnalytics("trigger")
-		c.triggerStackByFilter()
+		c.initAnalytics("trigger",
+			tel.BoolFlag("list", c.parsedArgs.Trigger.List),
+			tel.BoolFlag("clear", c.parsedArgs.Trigger.Clear),
+			tel.BoolFlag("filter-stack-ids", len(c.parsedArgs.Trigger.StackID) != 0),
+		)
+		if c.managingTriggers() {
+			c.manageTriggers("")
+		} else {
+			c.setupFilterStackIDs(c.parsedArgs.Trigger.stackIDFilterFlags)
+			c.triggerStackByFilter()
+		}
 		c.sendAndWaitForAnalytics()
 	case "experimental trigger <stack>": // Deprecated
 		c.parsedArgs.Trigger = c.parsedArgs.Experimental.Trigger
@@ -800,13 +1103,35 @@ func (c *cli) run() {
 			tel.StringFlag("stack", c.parsedArgs.Trigger.Stack),
 			tel.BoolFlag("change", c.parsedArgs.Trigger.Change),
 			tel.BoolFlag("ignore-change", c.parsedArgs.Trigger.IgnoreChange),
+			tel.BoolFlag("list", c.parsedArgs.Trigger.List),
+			tel.BoolFlag("clear", c.parsedArgs.Trigger.Clear),
 		)
-		c.triggerStack(c.parsedArgs.Trigger.Stack)
+		if len(c.parsedArgs.Trigger.StackID) > 0 {
+			fatalWithDetailf(errors.E("--stack-id conflicts with the stack path argument"), "Invalid args")
+		}
+		if c.managingTriggers() {
+			c.manageTriggers(c.parsedArgs.Trigger.Stack)
+		} else {
+			c.triggerStack(c.parsedArgs.Trigger.Stack)
+		}
+		c.sendAndWaitForAnalytics()
+	case "experimental dependencies", "experimental dependencies <stack>":
+		c.initAnalytics("dependencies",
+			tel.BoolFlag("json", c.parsedArgs.Experimental.Dependencies.AsJSON),
+		)
+		c.printStackDependencies()
 		c.sendAndWaitForAnalytics()
 	case "experimental vendor download <source> <ref>":
 		c.initAnalytics("vendor-download")
 		c.vendorDownload()
 		c.sendAndWaitForAnalytics()
+	case "experimental vendor update":
+		c.initAnalytics("vendor-update",
+			tel.BoolFlag("parallel", c.parsedArgs.Experimental.Vendor.Update.Parallel > 0),
+			tel.BoolFlag("prune", c.parsedArgs.Experimental.Vendor.Update.Prune),
+		)
+		c.vendorUpdate()
+		c.sendAndWaitForAnalytics()
 	case "debug show globals":
 		c.setupGit()
 		c.printStacksGlobals()
@@ -824,6 +1149,14 @@ func (c *cli) run() {
 	case "debug show runtime-env":
 		c.setupGit()
 		c.printRuntimeEnv()
+	case "debug show run-config":
+		c.setupGit()
+		c.printRunConfig()
+	case "debug show scope", "debug show scope <path>":
+		c.setupGit()
+		c.printScope()
+	case "debug show config":
+		c.printConfig()
 	case "experimental eval":
 		fatal("no expression specified")
 	case "experimental eval <expr>":
@@ -848,8 +1181,25 @@ func (c *cli) run() {
 		c.initAnalytics("cloud-drift-show")
 		c.cloudDriftShow()
 		c.sendAndWaitForAnalytics()
+	case "experimental cloud stacks list":
+		c.initAnalytics("experimental-cloud-stacks-list",
+			tel.BoolFlag("json", c.parsedArgs.Experimental.Cloud.Stacks.List.AsJSON),
+			tel.StringFlag("target", c.parsedArgs.Experimental.Cloud.Stacks.List.Target),
+		)
+		c.cloudStacksList()
+		c.sendAndWaitForAnalytics()
+	case "cloud deployment list":
+		c.initAnalytics("cloud-deployment-list")
+		c.cloudDeploymentList()
+		c.sendAndWaitForAnalytics()
+	case "cloud deployment show":
+		c.initAnalytics("cloud-deployment-show")
+		c.cloudDeploymentShow()
+		c.sendAndWaitForAnalytics()
 	case "script list":
-		c.initAnalytics("script-list")
+		c.initAnalytics("script-list",
+			tel.BoolFlag("stack", c.parsedArgs.Script.List.Stack != ""),
+		)
 		c.checkScriptEnabled()
 		c.printScriptList()
 		c.sendAndWaitForAnalytics()
@@ -879,8 +1229,14 @@ func (c *cli) run() {
 			tel.StringFlag("target", c.parsedArgs.Script.Run.Target),
 			tel.BoolFlag("reverse", c.parsedArgs.Script.Run.Reverse),
 			tel.BoolFlag("parallel", c.parsedArgs.Script.Run.Parallel > 0),
+			tel.BoolFlag("filter-paths", !c.parsedArgs.Script.Run.pathFilterFlags.isEmpty()),
+			tel.BoolFlag("filter-stack-ids", len(c.parsedArgs.Script.Run.StackID) != 0),
+			tel.BoolFlag("error-on-empty", c.parsedArgs.Script.Run.ErrorOnEmpty),
+			tel.BoolFlag("chunk", c.parsedArgs.Script.Run.Chunk != ""),
 		)
 		c.checkScriptEnabled()
+		c.setupFilterPaths(c.parsedArgs.Script.Run.pathFilterFlags)
+		c.setupFilterStackIDs(c.parsedArgs.Script.Run.stackIDFilterFlags)
 		c.setupGit()
 		c.setupChangeDetection(c.parsedArgs.Script.Run.EnableChangeDetection, c.parsedArgs.Script.Run.DisableChangeDetection)
 		c.setupSafeguards(c.parsedArgs.Script.Run.runSafeguardsCliSpec)
@@ -931,18 +1287,7 @@ func (c *cli) sendAndWaitForAnalytics() {
 }
 
 func (c *cli) isTelemetryEnabled() bool {
-	if c.clicfg.DisableTelemetry {
-		return false
-	}
-
-	cfg := c.rootNode()
-	if cfg.Terramate == nil ||
-		cfg.Terramate.Config == nil ||
-		cfg.Terramate.Config.Telemetry == nil ||
-		cfg.Terramate.Config.Telemetry.Enabled == nil {
-		return true
-	}
-	return *cfg.Terramate.Config.Telemetry.Enabled
+	return telemetrySetting(c.clicfg, c.rootNode()).enabled
 }
 
 func (c *cli) setupSafeguards(run runSafeguardsCliSpec) {
@@ -976,6 +1321,7 @@ func (c *cli) setupSafeguards(run runSafeguardsCliSpec) {
 	c.safeguards.DisableCheckGitUncommitted = run.DisableSafeguards.Has(safeguard.GitUncommitted, safeguard.All, safeguard.Git)
 	c.safeguards.DisableCheckGitUntracked = run.DisableSafeguards.Has(safeguard.GitUntracked, safeguard.All, safeguard.Git)
 	c.safeguards.DisableCheckGitRemote = run.DisableSafeguards.Has(safeguard.GitOutOfSync, safeguard.All, safeguard.Git)
+	c.safeguards.DisableCheckGitBranchProtection = run.DisableSafeguards.Has(safeguard.GitBranchProtection, safeguard.All, safeguard.Git)
 	c.safeguards.DisableCheckGenerateOutdatedCheck = run.DisableSafeguards.Has(safeguard.Outdated, safeguard.All)
 	if run.DisableSafeguards.Has("none") {
 		c.safeguards = safeguards{}
@@ -984,10 +1330,14 @@ func (c *cli) setupSafeguards(run runSafeguardsCliSpec) {
 }
 
 func (c *cli) setupGit() {
-	if !c.parsedArgs.Changed || !c.prj.isGitFeaturesEnabled() {
+	if !c.parsedArgs.Changed || c.parsedArgs.DiffAgainst != "" || !c.prj.isGitFeaturesEnabled() {
 		return
 	}
 
+	if err := c.prj.resolveGitDefaults(); err != nil {
+		fatalWithDetailf(err, "detecting git default remote and branch")
+	}
+
 	remoteCheckFailed := false
 
 	if err := c.prj.checkDefaultRemote(); err != nil {
@@ -1028,6 +1378,8 @@ func (c *cli) vendorDownload() {
 	}
 	parsedSource.Ref = ref
 
+	startedAt := c.emitOperationStart("vendor download", 0)
+
 	eventsStream := download.NewEventStream()
 	eventsHandled := c.handleVendorProgressEvents(eventsStream)
 
@@ -1052,7 +1404,76 @@ func (c *cli) vendorDownload() {
 		}
 	}
 
+	c.emitOperationEnd("vendor download", startedAt, 0, report.Error)
+
+	if c.progress == nil {
+		c.output.MsgStdOut(report.String())
+	}
+}
+
+func (c *cli) vendorUpdate() {
+	logger := log.With().
+		Str("workingDir", c.wd()).
+		Str("rootdir", c.rootdir()).
+		Str("action", "cli.vendorUpdate()").
+		Logger()
+
+	vendorDir := c.vendorDir()
+
+	requests, err := generate.LoadVendorRequests(c.cfg(), vendorDir)
+	if err != nil {
+		fatalWithDetailf(err, "loading tm_vendor calls")
+	}
+
+	sources := make([]tf.Source, len(requests))
+	for i, req := range requests {
+		sources[i] = req.Source
+	}
+
+	// the modules recorded in the manifest are updated too, even if they
+	// are not referenced by the tm_vendor calls of the project anymore.
+	manifest, err := modvendor.LoadManifest(c.rootdir(), vendorDir)
+	if err != nil {
+		fatalWithDetailf(err, "loading vendor manifest")
+	}
+	manifestSources, err := manifest.Sources()
+	if err != nil {
+		fatalWithDetailf(err, "loading vendor manifest")
+	}
+	sources = append(sources, manifestSources...)
+
+	eventsStream := download.NewEventStream()
+	eventsHandled := c.handleVendorProgressEvents(eventsStream)
+
+	logger.Debug().Int("modules", len(sources)).Msg("updating vendored modules")
+
+	report := download.UpdateAll(c.rootdir(), vendorDir, sources,
+		c.parsedArgs.Experimental.Vendor.Update.Parallel, eventsStream)
+
+	close(eventsStream)
+	<-eventsHandled
+
+	if c.parsedArgs.Experimental.Vendor.Update.Prune {
+		modules := make([]prj.Path, len(report.Results))
+		for i, res := range report.Results {
+			modules[i] = res.Dir
+		}
+		pruned, err := download.Prune(c.rootdir(), vendorDir, modules)
+		if err != nil {
+			fatalWithDetailf(err, "pruning vendor dir")
+		}
+		report.Pruned = pruned
+	}
+
 	c.output.MsgStdOut(report.String())
+	c.output.MsgStdOut("\n%d updated, %d up-to-date, %d failed",
+		report.Count(download.Updated),
+		report.Count(download.UpToDate),
+		report.Count(download.UpdateFailed))
+
+	if report.HasFailures() {
+		os.Exit(1)
+	}
 }
 
 func (c *cli) handleVendorProgressEvents(eventsStream download.ProgressEventStream) <-chan struct{} {
@@ -1060,8 +1481,17 @@ func (c *cli) handleVendorProgressEvents(eventsStream download.ProgressEventStre
 
 	go func() {
 		for event := range eventsStream {
-			c.output.MsgStdOut("vendor: %s %s at %s",
-				event.Message, event.Module.Raw, event.TargetDir)
+			if c.progress != nil {
+				c.emitProgress(progress.Event{
+					Type:    progress.VendorProgress,
+					Message: event.Message,
+					Module:  event.Module.Raw,
+					Dir:     event.TargetDir.String(),
+				})
+			} else {
+				c.output.MsgStdOut("vendor: %s %s at %s",
+					event.Message, event.Module.Raw, event.TargetDir)
+			}
 			log.Info().
 				Str("module", event.Module.Raw).
 				Stringer("vendorDir", event.TargetDir).
@@ -1074,9 +1504,11 @@ func (c *cli) handleVendorProgressEvents(eventsStream download.ProgressEventStre
 }
 
 func (c *cli) vendorDir() prj.Path {
-	if c.parsedArgs.Experimental.Vendor.Download.Dir != "" {
-
-		dir := c.parsedArgs.Experimental.Vendor.Download.Dir
+	dir := c.parsedArgs.Experimental.Vendor.Download.Dir
+	if dir == "" {
+		dir = c.parsedArgs.Experimental.Vendor.Update.Dir
+	}
+	if dir != "" {
 		if !path.IsAbs(dir) {
 			dir = prj.PrjAbsPath(c.rootdir(), c.wd()).Join(dir).String()
 		}
@@ -1167,8 +1599,8 @@ func (c *cli) triggerStackByFilter() {
 		statusStr = cloudStatus
 	}
 
-	if statusStr == "" {
-		fatal("trigger command expects either a stack path or the --status flag")
+	if statusStr == "" && len(c.stackIDs) == 0 {
+		fatal("trigger command expects either a stack path or the --status or --stack-id flags")
 	}
 	statusFilter := parseStatusFilter(statusStr)
 	if statusFilter != cloudstack.NoFilter && c.parsedArgs.Trigger.Recursive {
@@ -1182,7 +1614,7 @@ func (c *cli) triggerStackByFilter() {
 		fatalWithDetailf(err, "unable to list stacks")
 	}
 
-	for _, st := range c.filterStacksByWorkingDir(stacksReport.Stacks) {
+	for _, st := range c.filterStacksByIDs(c.filterStacksByWorkingDir(stacksReport.Stacks)) {
 		c.triggerStack(st.Stack.Dir.String())
 	}
 }
@@ -1259,7 +1691,7 @@ func (c *cli) triggerStack(basePath string) {
 		}
 	}
 	for _, st := range stacks {
-		if err := trigger.Create(c.cfg(), st.Dir(), kind, reason); err != nil {
+		if err := trigger.Create(c.cfg(), st.Dir(), kind, reason, c.parsedArgs.Trigger.Priority); err != nil {
 			fatalWithDetailf(err, "unable to create trigger")
 		}
 		c.output.MsgStdOut("Created %s trigger for stack %q", kindName, st.Dir())
@@ -1291,20 +1723,33 @@ func (c *cli) cloneStack() {
 }
 
 func (c *cli) generate() int {
-	report, vendorReport := c.gencodeWithVendor()
+	startedAt := c.emitOperationStart("generate", 0)
 
-	c.output.MsgStdOut(report.Full())
+	report, vendorReport := c.gencodeWithVendor()
+	c.lastGenerateReport = report
 
 	vendorReport.RemoveIgnoredByKind(download.ErrAlreadyVendored)
 
-	exitCode := 0
+	if c.progress != nil {
+		c.emitGenerateReport(report)
+	} else {
+		c.output.MsgStdOut(report.Full())
 
-	if !vendorReport.IsEmpty() {
-		c.output.MsgStdOut(vendorReport.String())
+		if c.parsedArgs.Generate.Metrics {
+			c.output.MsgStdOut("\n%s", report.Metrics)
+		} else {
+			c.output.MsgStdOutV("\n%s", report.Metrics)
+		}
+
+		if !vendorReport.IsEmpty() {
+			c.output.MsgStdOut(vendorReport.String())
+		}
 	}
 
+	exitCode := 0
+
 	if c.parsedArgs.Generate.DetailedExitCode {
-		if len(report.Successes) > 0 || !vendorReport.IsEmpty() {
+		if report.HasChanges() || !vendorReport.IsEmpty() {
 			exitCode = 2
 		}
 	}
@@ -1312,9 +1757,56 @@ func (c *cli) generate() int {
 	if report.HasFailures() || vendorReport.HasFailures() {
 		exitCode = 1
 	}
+
+	var err error
+	if report.BootstrapErr != nil {
+		err = report.BootstrapErr
+	} else if report.CleanupErr != nil {
+		err = report.CleanupErr
+	} else if vendorReport.Error != nil {
+		err = vendorReport.Error
+	}
+	c.emitOperationEnd("generate", startedAt, exitCode, err)
 	return exitCode
 }
 
+// filteredStackPaths returns the stacks selected by --changed and the tags
+// filters, scoped to the working directory. The boolean result is false if
+// none of these filters is used, meaning all the stacks are selected.
+func (c *cli) filteredStackPaths() (prj.Paths, bool) {
+	if !c.parsedArgs.Changed && c.tags.IsEmpty() {
+		return nil, false
+	}
+
+	var (
+		report *stack.Report
+		err    error
+	)
+	mgr := c.stackManager()
+	if c.parsedArgs.Changed {
+		// the stacks inheriting changed globals or generate blocks are only
+		// changed for the code generation, as only their generated code is
+		// affected.
+		report, err = mgr.ListChanged(stack.ChangeConfig{
+			BaseRef:            c.baseRef(),
+			UntrackedChanges:   c.changeDetection.untracked,
+			UncommittedChanges: c.changeDetection.uncommitted,
+			GenerateConfig:     true,
+		})
+	} else {
+		report, err = mgr.List(false)
+	}
+	if err != nil {
+		fatalWithDetailf(err, "selecting stacks")
+	}
+
+	paths := prj.Paths{}
+	for _, entry := range c.filterStacks(report.Stacks) {
+		paths = append(paths, entry.Stack.Dir)
+	}
+	return paths, true
+}
+
 // gencodeWithVendor will generate code for the whole project providing automatic
 // vendoring of all tm_vendor calls.
 func (c *cli) gencodeWithVendor() (*generate.Report, download.Report) {
@@ -1332,8 +1824,26 @@ func (c *cli) gencodeWithVendor() (*generate.Report, download.Report) {
 
 	log.Trace().Msg("generating code")
 
+	// the context is only set by the generate command, the other commands
+	// generating code always generate all the contexts.
+	context := c.parsedArgs.Generate.Context
+	if context == "" {
+		context = generate.ContextAll
+	}
+
 	cwd := prj.PrjAbsPath(c.cfg().HostDir(), c.wd())
-	report := generate.Do(c.cfg(), cwd, c.parsedArgs.Generate.Parallel, c.vendorDir(), vendorRequestEvents)
+	var report *generate.Report
+	if c.generateStacks != nil {
+		report = generate.DoStacks(
+			c.cfg(), cwd, c.generateStacks, c.parsedArgs.Generate.Parallel, c.vendorDir(),
+			vendorRequestEvents, c.parsedArgs.Generate.AllowDelete, context,
+		)
+	} else {
+		report = generate.Do(
+			c.cfg(), cwd, c.parsedArgs.Generate.Parallel, c.vendorDir(), vendorRequestEvents,
+			c.parsedArgs.Generate.AllowDelete, context,
+		)
+	}
 
 	log.Trace().Msg("code generation finished, waiting for vendor requests to be handled")
 
@@ -1436,6 +1946,36 @@ func (c *cli) gitSafeguardDefaultBranchIsReachable() {
 	}
 }
 
+func (c *cli) gitSafeguardBranchProtection() {
+	logger := log.With().
+		Bool("is_repository", c.prj.isRepo).
+		Bool("is_enabled", c.gitSafeguardBranchProtectionEnabled()).
+		Logger()
+
+	if !c.gitSafeguardBranchProtectionEnabled() {
+		logger.Debug().Msg("Safeguard git-branch-protection is disabled.")
+		return
+	}
+
+	if c.prj.isDefaultBranch() {
+		return
+	}
+
+	defaultBranch := c.prj.gitcfg().DefaultBranch
+	branch, err := c.prj.git.wrapper.CurrentBranch()
+	if err != nil {
+		branch = "HEAD " + c.prj.headCommit()
+	}
+	fatalWithDetailf(
+		errors.E(ErrNotDefaultBranch,
+			"%s is not the default branch %s. Deployments can only be synchronized from the default branch "+
+				"(use --disable-safeguards=git-branch-protection to override).",
+			branch, defaultBranch,
+		),
+		"refusing to synchronize the deployment",
+	)
+}
+
 func (c *cli) checkChangeDetectionFlagConflicts(enable []string, disable []string) {
 	for _, enableOpt := range enable {
 		if slices.Contains(disable, enableOpt) {
@@ -1538,6 +2078,12 @@ func (c *cli) listStacks(isChanged bool, target string, stackFilters cloud.Statu
 }
 
 func (c *cli) scanCreate() {
+	if c.parsedArgs.Create.FixDuplicates && !c.parsedArgs.Create.EnsureStackIDs {
+		fatalWithDetailf(
+			errors.E("--fix-duplicates requires --ensure-stack-ids"),
+			"Invalid args")
+	}
+
 	scanFlags := 0
 	if c.parsedArgs.Create.AllTerraform {
 		scanFlags++
@@ -1583,7 +2129,9 @@ func (c *cli) scanCreate() {
 		len(c.parsedArgs.Create.Wants) != 0 ||
 		len(c.parsedArgs.Create.WantedBy) != 0 ||
 		len(c.parsedArgs.Create.Watch) != 0 ||
-		len(c.parsedArgs.Create.Import) != 0 {
+		len(c.parsedArgs.Create.Import) != 0 ||
+		c.parsedArgs.Create.Terragrunt ||
+		c.parsedArgs.Create.Source != "" {
 
 		fatalWithDetailf(
 			errors.E(
@@ -1595,6 +2143,8 @@ func (c *cli) scanCreate() {
 					"--before, "+
 					"--watch, "+
 					"--import, "+
+					"--terragrunt, "+
+					"--source, "+
 					" --ignore-existing",
 				flagname,
 			),
@@ -1660,6 +2210,7 @@ func (c *cli) initTerragrunt() {
 			Description: dirBasename,
 			Tags:        tags,
 			After:       after,
+			Watch:       terragruntWatchPaths(c.rootdir(), mod),
 		}
 
 		err = stack.Create(c.cfg(), stackSpec)
@@ -1676,6 +2227,49 @@ func (c *cli) initTerragrunt() {
 	}
 }
 
+// createTerragruntConfig creates a minimal terragrunt.hcl in dir, using the given
+// Terraform module source and no inputs. The file is detected as a Terragrunt
+// module by tg.ScanModules, so its dependencies are tracked as usual once the
+// user adds dependency blocks to it.
+func createTerragruntConfig(dir string, source string) error {
+	file := hclwrite.NewEmptyFile()
+	body := file.Body()
+	body.AppendNewBlock("terraform", nil).Body().SetAttributeValue("source", cty.StringVal(source))
+	body.AppendNewline()
+	body.SetAttributeValue("inputs", cty.EmptyObjectVal)
+
+	// never overwrite an existing Terragrunt configuration.
+	f, err := os.OpenFile(filepath.Join(dir, terragruntConfigFilename), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return errors.E(err, "creating %s", terragruntConfigFilename)
+	}
+	_, err = f.Write(file.Bytes())
+	if err != nil {
+		_ = f.Close()
+		return errors.E(err, "writing %s", terragruntConfigFilename)
+	}
+	return f.Close()
+}
+
+// terragruntWatchPaths returns the files outside of the module directory that the
+// module configuration depends on, like included files and the ones read with
+// read_terragrunt_config(), so changing them also marks the stack as changed.
+// Directories, like the dependency modules, are not watched.
+func terragruntWatchPaths(rootdir string, mod *tg.Module) prj.Paths {
+	var watch prj.Paths
+	for _, dep := range mod.DependsOn {
+		if mod.Path.String() == "/" || dep.HasPrefix(mod.Path.String()+"/") {
+			continue
+		}
+		st, err := os.Stat(dep.HostPath(rootdir))
+		if err != nil || !st.Mode().IsRegular() {
+			continue
+		}
+		watch = append(watch, dep)
+	}
+	return watch
+}
+
 func (c *cli) initTerraform() {
 	err := c.initTerraformDir(c.wd())
 	if err != nil {
@@ -1746,7 +2340,7 @@ func (c *cli) initTerraformDir(baseDir string) error {
 			continue
 		}
 
-		if filepath.Ext(f.Name()) != ".tf" {
+		if !tf.IsTerraformFile(f.Name()) {
 			continue
 		}
 
@@ -1788,13 +2382,37 @@ func (c *cli) initTerraformDir(baseDir string) error {
 }
 
 func (c *cli) createStack() {
-	if c.parsedArgs.Create.AllTerraform || c.parsedArgs.Create.EnsureStackIDs || c.parsedArgs.Create.AllTerragrunt {
+	if c.parsedArgs.Create.AllTerraform || c.parsedArgs.Create.EnsureStackIDs ||
+		c.parsedArgs.Create.AllTerragrunt || c.parsedArgs.Create.FixDuplicates {
 		c.scanCreate()
 		return
 	}
 
+	if c.parsedArgs.Create.Terragrunt && c.parsedArgs.Create.Source == "" {
+		fatalWithDetailf(errors.E("--terragrunt requires --source"), "Invalid args")
+	}
+	if c.parsedArgs.Create.Source != "" && !c.parsedArgs.Create.Terragrunt {
+		fatalWithDetailf(errors.E("--source requires --terragrunt"), "Invalid args")
+	}
+
 	stackHostDir := filepath.Join(c.wd(), c.parsedArgs.Create.Path)
 
+	// an existing Terragrunt configuration is kept with --ignore-existing,
+	// otherwise the stack is not created.
+	createTerragrunt := c.parsedArgs.Create.Terragrunt
+	if createTerragrunt {
+		_, err := os.Stat(filepath.Join(stackHostDir, terragruntConfigFilename))
+		if err == nil {
+			if !c.parsedArgs.Create.IgnoreExisting {
+				fatalWithDetailf(
+					errors.E("%s already exists in %s", terragruntConfigFilename, c.parsedArgs.Create.Path),
+					"Cannot create stack")
+			}
+			log.Debug().Msgf("%s already exists, ignoring", terragruntConfigFilename)
+			createTerragrunt = false
+		}
+	}
+
 	stackID := c.parsedArgs.Create.ID
 	if stackID == "" {
 
@@ -1853,7 +2471,7 @@ func (c *cli) createStack() {
 
 		if errors.IsKind(err, stack.ErrStackDefaultCfgFound) {
 			logger = logger.With().
-				Str("file", stack.DefaultFilename).
+				Str("file", stack.Filename(c.cfg())).
 				Logger()
 		}
 
@@ -1862,6 +2480,13 @@ func (c *cli) createStack() {
 
 	printer.Stdout.Success("Created stack " + stackSpec.Dir.String())
 
+	if createTerragrunt {
+		err := createTerragruntConfig(stackHostDir, c.parsedArgs.Create.Source)
+		if err != nil {
+			fatalWithDetailf(err, "Cannot create Terragrunt module")
+		}
+	}
+
 	if c.parsedArgs.Create.NoGenerate {
 		log.Debug().Msg("code generation on stack creation disabled")
 		return
@@ -1894,29 +2519,53 @@ func (c *cli) format() {
 		fatalWithDetailf(errors.E("--check conflicts with --detailed-exit-code"), "Invalid args")
 	}
 
+	isStdin := len(c.parsedArgs.Fmt.Files) == 1 && c.parsedArgs.Fmt.Files[0] == "-"
+	if c.parsedArgs.Fmt.StdinFilename != "" && !isStdin {
+		fatalWithDetailf(errors.E("--stdin-filename requires formatting from stdin (-)"), "Invalid args")
+	}
+	if len(c.parsedArgs.Fmt.Exclude) > 0 && len(c.parsedArgs.Fmt.Files) > 0 {
+		fatalWithDetailf(errors.E("--exclude cannot be used when formatting explicit files"), "Invalid args")
+	}
+	if !c.parsedArgs.Fmt.Recursive && len(c.parsedArgs.Fmt.Files) > 0 {
+		fatalWithDetailf(errors.E("--no-recursive cannot be used when formatting explicit files"), "Invalid args")
+	}
+
+	wd := prj.PrjAbsPath(c.rootdir(), c.wd())
+
 	var results []fmt.FormatResult
 	switch len(c.parsedArgs.Fmt.Files) {
 	case 0:
 		var err error
-		results, err = fmt.FormatTree(c.wd())
+		if c.parsedArgs.Fmt.Recursive {
+			results, err = fmt.FormatTree(c.rootdir(), wd, c.parsedArgs.Fmt.Exclude...)
+		} else {
+			results, err = fmt.FormatDir(c.rootdir(), wd, c.parsedArgs.Fmt.Exclude...)
+		}
 		if err != nil {
 			fatalWithDetailf(err, "formatting directory %s", c.wd())
 		}
 	case 1:
-		if c.parsedArgs.Fmt.Files[0] == "-" {
+		if isStdin {
+			filename := "<stdin>"
+			if c.parsedArgs.Fmt.StdinFilename != "" {
+				filename = c.parsedArgs.Fmt.StdinFilename
+			}
 			content, err := io.ReadAll(os.Stdin)
 			if err != nil {
 				fatalWithDetailf(err, "reading stdin")
 			}
 			original := string(content)
-			formatted, err := fmt.Format(original, "<stdin>")
+			formatted, err := fmt.Format(original, filename)
 			if err != nil {
-				fatalWithDetailf(err, "formatting stdin")
+				fatalWithDetailf(err, "formatting %s", filename)
 			}
 
 			if c.parsedArgs.Fmt.Check {
 				var status int
 				if formatted != original {
+					if c.parsedArgs.Fmt.StdinFilename != "" {
+						c.output.MsgStdOut("%s", filename)
+					}
 					status = 1
 				}
 				os.Exit(status)
@@ -1937,6 +2586,11 @@ func (c *cli) format() {
 
 	for _, res := range results {
 		path := strings.TrimPrefix(res.Path(), c.wd()+string(filepath.Separator))
+		if len(c.parsedArgs.Fmt.Files) == 0 {
+			// the tree results are always inside the working directory.
+			relpath, _ := prj.FriendlyFmtDir(c.rootdir(), c.wd(), res.PrjPath().String())
+			path = filepath.FromSlash(relpath)
+		}
 		c.output.MsgStdOut(path)
 	}
 
@@ -1965,6 +2619,28 @@ func (c *cli) printStacks() {
 		fatalWithDetailf(errors.E("the --why flag must be used together with --changed"), "Invalid args")
 	}
 
+	if c.parsedArgs.List.Group && !c.parsedArgs.List.RunOrder {
+		fatalWithDetailf(errors.E("the --group flag must be used together with --run-order"), "Invalid args")
+	}
+
+	if c.parsedArgs.List.Shuffle && !c.parsedArgs.List.RunOrder {
+		fatalWithDetailf(errors.E("the --shuffle flag must be used together with --run-order"), "Invalid args")
+	}
+
+	if c.parsedArgs.List.Shuffle && c.parsedArgs.List.Group {
+		fatalWithDetailf(errors.E("the --shuffle flag cannot be used together with --group"), "Invalid args")
+	}
+
+	checkSeedFlag(c.parsedArgs.List.Seed, c.parsedArgs.List.Shuffle)
+
+	if c.parsedArgs.List.Group && c.parsedArgs.List.Format != listFormatText {
+		fatalWithDetailf(errors.E("the --group flag cannot be used together with --format %s", c.parsedArgs.List.Format), "Invalid args")
+	}
+
+	if c.parsedArgs.List.Max <= 0 {
+		fatalWithDetailf(errors.E("the --max flag must be a positive number"), "Invalid args")
+	}
+
 	expStatus := c.parsedArgs.List.ExperimentalStatus
 	cloudStatus := c.parsedArgs.List.Status
 	if expStatus != "" && cloudStatus != "" {
@@ -2000,12 +2676,22 @@ func (c *cli) printStacks() {
 		fatal(err)
 	}
 
-	c.printStacksList(report.Stacks, c.parsedArgs.List.Why, c.parsedArgs.List.RunOrder)
-}
+	stacks := selectChunk(c, c.parsedArgs.List.Chunk, c.filterStacks(report.Stacks),
+		func(e stack.Entry) *config.Stack { return e.Stack })
 
-func (c *cli) printStacksList(allStacks []stack.Entry, why bool, runOrder bool) {
-	filteredStacks := c.filterStacks(allStacks)
+	if c.parsedArgs.List.ErrorOnEmpty && len(stacks) == 0 {
+		c.exitOnEmptySelection(selectionFilters{
+			cloudFilterFlags:   c.parsedArgs.List.cloudFilterFlags,
+			pathFilterFlags:    c.parsedArgs.List.pathFilterFlags,
+			stackIDFilterFlags: c.parsedArgs.List.stackIDFilterFlags,
+			Target:             c.parsedArgs.List.Target,
+		})
+	}
+
+	c.printStacksList(stacks, c.parsedArgs.List.Why, c.parsedArgs.List.RunOrder, c.parsedArgs.List.Group)
+}
 
+func (c *cli) printStacksList(filteredStacks []stack.Entry, why bool, runOrder bool, group bool) {
 	reasons := map[string]string{}
 	stacks := make(config.List[*config.SortableStack], len(filteredStacks))
 	for i, entry := range filteredStacks {
@@ -2013,31 +2699,60 @@ func (c *cli) printStacksList(allStacks []stack.Entry, why bool, runOrder bool)
 		reasons[entry.Stack.ID] = entry.Reason
 	}
 
-	if runOrder {
-		var failReason string
-		var err error
-		failReason, err = run.Sort(c.cfg(), stacks,
-			func(s *config.SortableStack) *config.Stack { return s.Stack })
-		if err != nil {
-			fatalWithDetailf(errors.E(err, failReason), "Invalid stack configuration")
-		}
-	}
-
-	for _, s := range stacks {
+	printStack := func(s *config.SortableStack, indent string) {
 		dir := s.Dir().String()
 		friendlyDir, ok := c.friendlyFmtDir(dir)
 		if !ok {
 			printer.Stderr.Error(stdfmt.Sprintf("Unable to format stack dir %s", dir))
-			printer.Stdout.Println(dir)
-			continue
+			printer.Stdout.Println(indent + dir)
+			return
 		}
 
 		if why {
-			printer.Stdout.Println(stdfmt.Sprintf("%s - %s", friendlyDir, reasons[s.ID]))
+			printer.Stdout.Println(stdfmt.Sprintf("%s%s - %s", indent, friendlyDir, reasons[s.ID]))
+		} else {
+			printer.Stdout.Println(indent + friendlyDir)
+		}
+	}
+
+	if runOrder && group {
+		levels, failReason, err := run.Levels(c.cfg(), stacks,
+			func(s *config.SortableStack) *config.Stack { return s.Stack })
+		if err != nil {
+			fatalWithDetailf(errors.E(err, failReason), "Invalid stack configuration")
+		}
+
+		for i, level := range levels {
+			printer.Stdout.Println(stdfmt.Sprintf("Group %d:", i))
+			for _, s := range level {
+				printStack(s, "  ")
+			}
+		}
+		return
+	}
+
+	if runOrder {
+		var failReason string
+		var err error
+		getStack := func(s *config.SortableStack) *config.Stack { return s.Stack }
+		if c.parsedArgs.List.Shuffle {
+			failReason, err = run.Shuffle(c.cfg(), stacks, getStack, shuffleSeed(c.parsedArgs.List.Seed))
 		} else {
-			printer.Stdout.Println(friendlyDir)
+			failReason, err = run.Sort(c.cfg(), stacks, getStack)
+		}
+		if err != nil {
+			fatalWithDetailf(errors.E(err, failReason), "Invalid stack configuration")
 		}
 	}
+
+	if format := c.parsedArgs.List.Format; format != listFormatText {
+		c.printStacksListJSON(format, stacks, reasons, why)
+		return
+	}
+
+	for _, s := range stacks {
+		printStack(s, "")
+	}
 }
 
 func parseStatusFilter(filterStr string) cloudstack.FilterStatus {
@@ -2080,7 +2795,7 @@ func (c *cli) printRuntimeEnv() {
 	}
 
 	for _, stackEntry := range c.filterStacks(report.Stacks) {
-		envVars, err := run.LoadEnv(c.cfg(), stackEntry.Stack)
+		envVars, err := run.LoadEnvWithOrigins(c.cfg(), stackEntry.Stack, c.isolateDataDir())
 		if err != nil {
 			fatalWithDetailf(err, "loading stack run environment")
 		}
@@ -2088,133 +2803,28 @@ func (c *cli) printRuntimeEnv() {
 		c.output.MsgStdOut("\nstack %q:", stackEntry.Stack.Dir)
 
 		for _, envVar := range envVars {
-			c.output.MsgStdOut("\t%s", envVar)
+			c.output.MsgStdOut("\t%s=%s  (%s)", envVar.Name, envVar.Value, envVar.Origin)
 		}
 	}
 }
 
-func (c *cli) generateGraph() {
-	var getLabel func(s *config.Stack) string
-
-	logger := log.With().
-		Str("action", "generateGraph()").
-		Str("workingDir", c.wd()).
-		Logger()
-
-	switch c.parsedArgs.Experimental.RunGraph.Label {
-	case "stack.name":
-		logger.Debug().Msg("Set label to stack name.")
-
-		getLabel = func(s *config.Stack) string { return s.Name }
-	case "stack.dir":
-		logger.Debug().Msg("Set label stack directory.")
-
-		getLabel = func(s *config.Stack) string { return s.Dir.String() }
-	default:
-		fatal(`-label expects the values "stack.name" or "stack.dir"`)
-	}
-
-	entries, err := stack.List(c.cfg(), c.cfg().Tree())
-	if err != nil {
-		fatalWithDetailf(err, "listing stacks to build graph")
-	}
-
-	logger.Debug().Msg("Create new graph.")
-
-	dotGraph := dot.NewGraph(dot.Directed)
-	graph := dag.New[*config.Stack]()
-
-	visited := dag.Visited{}
-	for _, e := range c.filterStacksByWorkingDir(entries) {
-		if _, ok := visited[dag.ID(e.Stack.Dir.String())]; ok {
-			continue
-		}
-
-		if err := run.BuildDAG(
-			graph,
-			c.cfg(),
-			e.Stack,
-			"before",
-			func(s config.Stack) []string { return s.Before },
-			"after",
-			func(s config.Stack) []string { return s.After },
-			visited,
-		); err != nil {
-			fatalWithDetailf(err, "building order tree")
-		}
-	}
-
-	for _, id := range graph.IDs() {
-		val, err := graph.Node(id)
-		if err != nil {
-			fatalWithDetailf(err, "generating graph")
-		}
-
-		generateDot(dotGraph, graph, id, val, getLabel)
-	}
-
-	logger.Debug().
-		Msg("Set output of graph.")
-	outFile := c.parsedArgs.Experimental.RunGraph.Outfile
-	var out io.Writer
-	if outFile == "" {
-
-		out = c.stdout
-	} else {
-
-		f, err := os.Create(outFile)
-		if err != nil {
-			fatalWithDetailf(err, "opening file %s", outFile)
-		}
-
-		defer func() {
-			if err := f.Close(); err != nil {
-				fatalWithDetailf(err, "closing output graph file")
-			}
-		}()
-
-		out = f
-	}
-
-	logger.Debug().
-		Msg("Write graph to output.")
-	_, err = out.Write([]byte(dotGraph.String()))
+func (c *cli) printRunConfig() {
+	report, err := c.listStacks(c.parsedArgs.Changed, cloudstack.AnyTarget, cloud.NoStatusFilters(), false)
 	if err != nil {
-		fatalWithDetailf(err, "writing output %s", outFile)
+		fatalWithDetailf(err, "listing stacks")
 	}
-}
 
-func generateDot(
-	dotGraph *dot.Graph,
-	graph *dag.DAG[*config.Stack],
-	id dag.ID,
-	stackval *config.Stack,
-	getLabel func(s *config.Stack) string,
-) {
-	descendant := dotGraph.Node(getLabel(stackval))
-	for _, ancestor := range graph.AncestorsOf(id) {
-		s, err := graph.Node(ancestor)
-		if err != nil {
-			fatalWithDetailf(err, "generating dot file")
-		}
-		ancestorNode := dotGraph.Node(getLabel(s))
-
-		// we invert the graph here.
-
-		edges := dotGraph.FindEdges(ancestorNode, descendant)
-		if len(edges) == 0 {
-			edge := dotGraph.Edge(ancestorNode, descendant)
-			if graph.HasCycle(ancestor) {
-				edge.Attr("color", "red")
-				continue
-			}
-		}
+	for _, stackEntry := range c.filterStacks(report.Stacks) {
+		runCfg := run.LoadStackRunConfig(c.cfg(), stackEntry.Stack)
 
-		if graph.HasCycle(ancestor) {
-			continue
+		timeout := "none"
+		if runCfg.Timeout > 0 {
+			timeout = runCfg.Timeout.String()
 		}
 
-		generateDot(dotGraph, graph, ancestor, s, getLabel)
+		c.output.MsgStdOut("\nstack %q:", stackEntry.Stack.Dir)
+		c.output.MsgStdOut("\ttimeout  = %s (%s)", timeout, runCfg.TimeoutOrigin)
+		c.output.MsgStdOut("\tpriority = %d (%s)", runCfg.Priority, runCfg.PriorityOrigin)
 	}
 }
 
@@ -2271,14 +2881,16 @@ func (c *cli) printStacksGlobals() {
 		fatalWithDetailf(err, "listing stacks globals: listing stacks")
 	}
 
+	sensitive := c.sensitiveGlobals(c.parsedArgs.Debug.Show.Globals.ShowSensitive)
 	for _, stackEntry := range c.filterStacks(report.Stacks) {
 		stack := stackEntry.Stack
 		report := globals.ForStack(c.cfg(), stack)
 		if err := report.AsError(); err != nil {
+			err = sensitive.RedactError(report.Globals.AsValueMap(), err)
 			fatalWithDetailf(err, "listing stacks globals: loading stack at %s", stack.Dir)
 		}
 
-		globalsStrRepr := report.Globals.String()
+		globalsStrRepr := fmt.FormatAttributes(sensitive.Redact(report.Globals.AsValueMap()))
 		if globalsStrRepr == "" {
 			continue
 		}
@@ -2353,7 +2965,44 @@ func (c *cli) checkGenCode() bool {
 
 }
 
+func (c *cli) checkTerraformVersion() bool {
+	cfg := c.rootNode()
+	return cfg.Terramate != nil &&
+		cfg.Terramate.Config != nil &&
+		cfg.Terramate.Config.Run != nil &&
+		cfg.Terramate.Config.Run.CheckTerraformVersion
+}
+
+// isolateDataDir tells if the commands must run with a per-stack TF_DATA_DIR,
+// enabled by --isolate-data-dir or terramate.config.run.isolate_data_dir.
+func (c *cli) isolateDataDir() bool {
+	if c.parsedArgs.Run.IsolateDataDir {
+		return true
+	}
+	cfg := c.rootNode()
+	return cfg.Terramate != nil &&
+		cfg.Terramate.Config != nil &&
+		cfg.Terramate.Config.Run != nil &&
+		cfg.Terramate.Config.Run.IsolateDataDir
+}
+
+// runLocking tells if the stacks must be locked while running, enabled by
+// terramate.config.run.locking. It also returns the configured lock TTL.
+func (c *cli) runLocking() (bool, time.Duration) {
+	cfg := c.rootNode()
+	if cfg.Terramate == nil ||
+		cfg.Terramate.Config == nil ||
+		cfg.Terramate.Config.Run == nil {
+		return false, 0
+	}
+	return cfg.Terramate.Config.Run.Locking, cfg.Terramate.Config.Run.LockTTL
+}
+
 func (c *cli) ensureStackID() {
+	if c.parsedArgs.Create.FixDuplicates {
+		c.fixDuplicatedStackIDs()
+	}
+
 	report, err := c.listStacks(false, cloudstack.AnyTarget, cloud.NoStatusFilters(), false)
 	if err != nil {
 		fatalWithDetailf(err, "listing stacks")
@@ -2374,7 +3023,9 @@ func (c *cli) ensureStackID() {
 }
 
 func (c *cli) eval() {
-	ctx := c.detectEvalContext(c.parsedArgs.Experimental.Eval.Global)
+	ctx := c.detectEvalContext(c.parsedArgs.Experimental.Eval.Stack, c.parsedArgs.Experimental.Eval.GlobalsFile, c.parsedArgs.Experimental.Eval.Global)
+	sensitive := c.sensitiveGlobals(c.parsedArgs.Experimental.Eval.ShowSensitive)
+	globalVals := evalContextGlobals(ctx)
 	for _, exprStr := range c.parsedArgs.Experimental.Eval.Exprs {
 		expr, err := ast.ParseExpression(exprStr, "<cmdline>")
 		if err != nil {
@@ -2382,14 +3033,19 @@ func (c *cli) eval() {
 		}
 		val, err := ctx.Eval(expr)
 		if err != nil {
-			fatalWithDetailf(err, "eval %q", exprStr)
+			fatalWithDetailf(sensitive.RedactError(globalVals, err), "eval %q", exprStr)
 		}
-		c.outputEvalResult(val, c.parsedArgs.Experimental.Eval.AsJSON)
+		if sensitive.MatchExpr(expr) {
+			val = cty.StringVal(globals.SensitiveValue)
+		}
+		c.outputEvalResult(val, c.parsedArgs.Experimental.Eval.AsJSON, sensitive, globalVals)
 	}
 }
 
 func (c *cli) partialEval() {
-	ctx := c.detectEvalContext(c.parsedArgs.Experimental.PartialEval.Global)
+	ctx := c.detectEvalContext(c.parsedArgs.Experimental.PartialEval.Stack, c.parsedArgs.Experimental.PartialEval.GlobalsFile, c.parsedArgs.Experimental.PartialEval.Global)
+	sensitive := c.sensitiveGlobals(c.parsedArgs.Experimental.PartialEval.ShowSensitive)
+	globalVals := evalContextGlobals(ctx)
 	for _, exprStr := range c.parsedArgs.Experimental.PartialEval.Exprs {
 		expr, err := ast.ParseExpression(exprStr, "<cmdline>")
 		if err != nil {
@@ -2397,36 +3053,50 @@ func (c *cli) partialEval() {
 		}
 		newexpr, _, err := ctx.PartialEval(expr)
 		if err != nil {
-			fatalWithDetailf(err, "partial eval %q", exprStr)
+			fatalWithDetailf(sensitive.RedactError(globalVals, err), "partial eval %q", exprStr)
+		}
+		if sensitive.MatchExpr(expr) {
+			c.output.MsgStdOut("%s", globals.SensitiveValue)
+			continue
 		}
-		c.output.MsgStdOut("%s", string(hclwrite.Format(ast.TokensForExpression(newexpr).Bytes())))
+		result := string(hclwrite.Format(ast.TokensForExpression(newexpr).Bytes()))
+		c.output.MsgStdOut("%s", sensitive.RedactString(globalVals, result))
 	}
 }
 
-func (c *cli) evalRunArgs(st *config.Stack, cmd []string) ([]string, error) {
-	ctx := c.setupEvalContext(st, map[string]string{})
-	var newargs []string
-	for _, arg := range cmd {
-		exprStr := `"` + arg + `"`
-		expr, err := ast.ParseExpression(exprStr, "<cmd arg>")
-		if err != nil {
-			return nil, errors.E(err, "parsing %s", exprStr)
-		}
-		val, err := ctx.Eval(expr)
-		if err != nil {
-			return nil, errors.E(err, "eval %s", exprStr)
-		}
-		if !val.Type().Equals(cty.String) {
-			return nil, errors.E("cmd line evaluates to type %s but only string is permitted", val.Type().FriendlyName())
-		}
+func (c *cli) evalRunArgs(st *config.Stack, overrides []config.GlobalOverride, cmd []string) ([]string, error) {
+	ctx := c.setupEvalContext(st, overrides, map[string]string{})
+	setTerraformDir := func(tfdir prj.Path) {
+		tmVal, _ := ctx.GetNamespace("terramate")
+		runtime := tmVal.AsValueMap()
+		runtime["stack"] = st.RuntimeValuesWithTerraformDir(c.cfg(), tfdir)["stack"]
+		ctx.SetNamespace("terramate", runtime)
+	}
 
-		newargs = append(newargs, val.AsString())
+	setTerraformDir(st.Dir)
+	newargs, err := run.EvalArgs(ctx, st, cmd)
+	if err != nil {
+		return nil, c.sensitiveGlobals(false).RedactError(evalContextGlobals(ctx), err)
+	}
+
+	// The terramate.stack.terraform_dir depends on the evaluated -chdir, then
+	// the arguments are evaluated again if it's set.
+	tfdir, ok, err := run.TerraformDir(c.cfg(), st, newargs)
+	if !ok || err != nil || tfdir == st.Dir {
+		return newargs, nil
+	}
+	setTerraformDir(tfdir)
+	newargs, err = run.EvalArgs(ctx, st, cmd)
+	if err != nil {
+		return nil, c.sensitiveGlobals(false).RedactError(evalContextGlobals(ctx), err)
 	}
 	return newargs, nil
 }
 
 func (c *cli) getConfigValue() {
-	ctx := c.detectEvalContext(c.parsedArgs.Experimental.GetConfigValue.Global)
+	ctx := c.detectEvalContext(c.parsedArgs.Experimental.GetConfigValue.Stack, c.parsedArgs.Experimental.GetConfigValue.GlobalsFile, c.parsedArgs.Experimental.GetConfigValue.Global)
+	sensitive := c.sensitiveGlobals(c.parsedArgs.Experimental.GetConfigValue.ShowSensitive)
+	globalVals := evalContextGlobals(ctx)
 	for _, exprStr := range c.parsedArgs.Experimental.GetConfigValue.Vars {
 		expr, err := ast.ParseExpression(exprStr, "<cmdline>")
 		if err != nil {
@@ -2445,14 +3115,55 @@ func (c *cli) getConfigValue() {
 
 		val, err := ctx.Eval(expr)
 		if err != nil {
-			fatalWithDetailf(err, "evaluating expression: %s", exprStr)
+			fatalWithDetailf(sensitive.RedactError(globalVals, err), "evaluating expression: %s", exprStr)
+		}
+		if sensitive.MatchExpr(expr) {
+			val = cty.StringVal(globals.SensitiveValue)
 		}
 
-		c.outputEvalResult(val, c.parsedArgs.Experimental.GetConfigValue.AsJSON)
+		c.outputEvalResult(val, c.parsedArgs.Experimental.GetConfigValue.AsJSON, sensitive, globalVals)
+	}
+}
+
+// loadGlobalsFile loads the global overrides of the given globals file, which
+// is relative to the working directory. The overrides apply to the working
+// directory and its subdirectories. It returns no overrides if path is empty.
+func (c *cli) loadGlobalsFile(path string) []config.GlobalOverride {
+	if path == "" {
+		return nil
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(c.wd(), path)
+	}
+	overrides, err := globals.LoadOverridesFile(c.rootdir(), prj.PrjAbsPath(c.rootdir(), c.wd()), path)
+	if err != nil {
+		fatalWithDetailf(err, "loading globals file")
 	}
+	return overrides
 }
 
-func (c *cli) outputEvalResult(val cty.Value, asJSON bool) {
+// sensitiveGlobals returns the matcher for the globals configured as sensitive
+// in the project. If show is true, no global is considered sensitive.
+func (c *cli) sensitiveGlobals(show bool) globals.Sensitive {
+	if show {
+		return globals.Sensitive{}
+	}
+	sensitive, err := globals.LoadSensitive(c.cfg())
+	if err != nil {
+		fatalWithDetailf(err, "loading terramate.config.sensitive_globals")
+	}
+	return sensitive
+}
+
+func evalContextGlobals(ctx *eval.Context) map[string]cty.Value {
+	val, ok := ctx.GetNamespace("global")
+	if !ok || val.LengthInt() == 0 {
+		return map[string]cty.Value{}
+	}
+	return val.AsValueMap()
+}
+
+func (c *cli) outputEvalResult(val cty.Value, asJSON bool, sensitive globals.Sensitive, globalVals map[string]cty.Value) {
 	var data []byte
 	if asJSON {
 		var err error
@@ -2469,10 +3180,18 @@ func (c *cli) outputEvalResult(val cty.Value, asJSON bool) {
 		}
 	}
 
-	c.output.MsgStdOut("%s", string(data))
+	c.output.MsgStdOut("%s", sensitive.RedactString(globalVals, string(data)))
 }
 
-func (c *cli) detectEvalContext(overrideGlobals map[string]string) *eval.Context {
+// detectEvalContext returns the evaluation context of the given stack path or,
+// if empty, of the working directory, which is a stack context if it's a stack.
+// The globals are overridden by the globals file, if set, and then by the
+// given override globals.
+func (c *cli) detectEvalContext(stackPath string, globalsFile string, overrideGlobals map[string]string) *eval.Context {
+	overrides := c.loadGlobalsFile(globalsFile)
+	if stackPath != "" {
+		return c.setupEvalContext(c.loadEvalStack(stackPath), overrides, overrideGlobals)
+	}
 	var st *config.Stack
 	if config.IsStack(c.cfg(), c.wd()) {
 		var err error
@@ -2481,10 +3200,56 @@ func (c *cli) detectEvalContext(overrideGlobals map[string]string) *eval.Context
 			fatalWithDetailf(err, "setup eval context: loading stack config")
 		}
 	}
-	return c.setupEvalContext(st, overrideGlobals)
+	return c.setupEvalContext(st, overrides, overrideGlobals)
+}
+
+// loadEvalStack loads the stack at the given path, which is absolute to the
+// project root or relative to the working directory. It fails suggesting the
+// nearest stack if the path is not a stack.
+func (c *cli) loadEvalStack(stackPath string) *config.Stack {
+	hostpath := filepath.FromSlash(stackPath)
+	if path.IsAbs(stackPath) {
+		hostpath = filepath.Join(c.rootdir(), hostpath)
+	}
+	dir := c.projectPath(hostpath)
+	st, found, err := config.TryLoadStack(c.cfg(), dir)
+	if err != nil {
+		fatalWithDetailf(err, "loading stack at %s", dir)
+	}
+	if found {
+		return st
+	}
+	err = errors.E("%s is not a stack", dir)
+	if nearest, ok := c.nearestStack(dir); ok {
+		fatalWithDetailf(err, "--stack must be a stack directory, did you mean %s?", nearest)
+	}
+	fatalWithDetailf(err, "--stack must be a stack directory")
+	return nil
+}
+
+// nearestStack returns the closest parent stack of dir or, if there's none,
+// its first child stack.
+func (c *cli) nearestStack(dir prj.Path) (prj.Path, bool) {
+	for p := dir; ; p = p.Dir() {
+		if tree, ok := c.cfg().Lookup(p); ok && tree.IsStack() {
+			return p, true
+		}
+		if p.String() == "/" {
+			break
+		}
+	}
+	tree, ok := c.cfg().Lookup(dir)
+	if !ok {
+		return prj.Path{}, false
+	}
+	stacks := tree.Stacks()
+	if len(stacks) == 0 {
+		return prj.Path{}, false
+	}
+	return stacks[0].Dir(), true
 }
 
-func (c *cli) setupEvalContext(st *config.Stack, overrideGlobals map[string]string) *eval.Context {
+func (c *cli) setupEvalContext(st *config.Stack, overrides []config.GlobalOverride, overrideGlobals map[string]string) *eval.Context {
 	runtime := c.cfg().Runtime()
 
 	if c.cloud.run.target != "" {
@@ -2512,6 +3277,7 @@ func (c *cli) setupEvalContext(st *config.Stack, overrideGlobals map[string]stri
 		fatalWithDetailf(err, "loading globals expressions")
 	}
 
+	exprs.SetOverrides(wdPath, overrides)
 	for name, exprStr := range overrideGlobals {
 		expr, err := ast.ParseExpression(exprStr, "<cmdline>")
 		if err != nil {
@@ -2556,7 +3322,18 @@ func (c *cli) checkOutdatedGeneratedCode() {
 		return
 	}
 
-	outdatedFiles, err := generate.DetectOutdated(c.cfg(), targetTree, c.vendorDir())
+	// when the stacks are filtered only the code generated for them is
+	// checked, as 'terramate generate' with the same filters only generates
+	// these stacks.
+	var (
+		outdatedFiles []string
+		err           error
+	)
+	if stacks, ok := c.filteredStackPaths(); ok {
+		outdatedFiles, err = generate.DetectOutdatedStacks(c.cfg(), targetTree, stacks, c.vendorDir())
+	} else {
+		outdatedFiles, err = generate.DetectOutdated(c.cfg(), targetTree, c.vendorDir())
+	}
 	if err != nil {
 		fatalWithDetailf(err, "failed to check outdated code on project")
 	}
@@ -2576,6 +3353,15 @@ func (c *cli) checkOutdatedGeneratedCode() {
 	}
 }
 
+// checkStackAsserts fails if any assert block applying to the selected stacks
+// has a false assertion. The failures of all stacks are reported together.
+func (c *cli) checkStackAsserts(stacks config.List[*config.SortableStack]) {
+	err := generate.CheckAsserts(c.cfg(), stacks)
+	if err != nil {
+		fatalWithDetailf(err, "assertions failed in the selected stacks")
+	}
+}
+
 func (c *cli) gitSafeguardRemoteEnabled() bool {
 	if !c.prj.isGitFeaturesEnabled() || c.safeguards.DisableCheckGitRemote {
 		return false
@@ -2602,6 +3388,23 @@ func (c *cli) gitSafeguardRemoteEnabled() bool {
 	return hasRemotes
 }
 
+func (c *cli) gitSafeguardBranchProtectionEnabled() bool {
+	if !c.prj.isGitFeaturesEnabled() || c.safeguards.DisableCheckGitBranchProtection {
+		return false
+	}
+
+	// the safeguard is disabled by default and must be enabled in the config.
+	cfg := c.rootNode()
+	if cfg.Terramate == nil || cfg.Terramate.Config == nil {
+		return false
+	}
+	if c.safeguards.reEnabled {
+		git := cfg.Terramate.Config.Git
+		return git != nil && git.BranchProtection
+	}
+	return !cfg.Terramate.Config.HasSafeguardDisabled(safeguard.GitBranchProtection)
+}
+
 func (c *cli) wd() string                   { return c.prj.wd }
 func (c *cli) rootdir() string              { return c.prj.rootdir }
 func (c *cli) cfg() *config.Root            { return c.prj.root }
@@ -2661,7 +3464,7 @@ func (c *cli) addOutputDependencies(outputFlags outputsSharingFlags, stacks conf
 	depIDs := map[string]struct{}{}
 	depOrigins := map[string][]string{} // id -> stack paths
 	for _, st := range stacks {
-		evalctx := c.setupEvalContext(st.Stack, map[string]string{})
+		evalctx := c.setupEvalContext(st.Stack, nil, map[string]string{})
 		cfg, _ := rootcfg.Lookup(st.Stack.Dir)
 		for _, inputcfg := range cfg.Node.Inputs {
 			fromStackID, err := config.EvalInputFromStackID(evalctx, inputcfg)
@@ -2725,7 +3528,7 @@ func (c *cli) addOutputDependencies(outputFlags outputsSharingFlags, stacks conf
 }
 
 func (c *cli) filterStacks(stacks []stack.Entry) []stack.Entry {
-	return c.filterStacksByTags(c.filterStacksByWorkingDir(stacks))
+	return c.filterStacksByIDs(c.filterStacksByPaths(c.filterStacksByTags(c.filterStacksByWorkingDir(stacks))))
 }
 
 func (c *cli) filterStacksByBasePath(basePath prj.Path, stacks []stack.Entry) []stack.Entry {
@@ -2763,6 +3566,32 @@ func (c *cli) filterStacksByTags(entries []stack.Entry) []stack.Entry {
 	return filtered
 }
 
+func (c *cli) filterStacksByPaths(entries []stack.Entry) []stack.Entry {
+	if c.pathFilter.IsEmpty() {
+		return entries
+	}
+	filtered := []stack.Entry{}
+	for _, entry := range entries {
+		if c.pathFilter.Match(entry.Stack.Dir) {
+			filtered = append(filtered, entry)
+		}
+	}
+	return filtered
+}
+
+func (c *cli) filterStacksByIDs(entries []stack.Entry) []stack.Entry {
+	if len(c.stackIDs) == 0 {
+		return entries
+	}
+	filtered := []stack.Entry{}
+	for _, entry := range entries {
+		if _, ok := c.stackIDs[strings.ToLower(entry.Stack.ID)]; ok && entry.Stack.ID != "" {
+			filtered = append(filtered, entry)
+		}
+	}
+	return filtered
+}
+
 func (c cli) checkVersion() {
 	logger := log.With().
 		Str("action", "cli.checkVersion()").
@@ -2790,11 +3619,6 @@ func (c cli) checkVersion() {
 }
 
 func runCheckpoint(version string, clicfg cliconfig.Config, result chan *checkpoint.CheckResponse) {
-	if clicfg.DisableCheckpoint {
-		result <- nil
-		return
-	}
-
 	logger := log.With().
 		Str("action", "runCheckpoint()").
 		Logger()
@@ -2822,6 +3646,59 @@ func runCheckpoint(version string, clicfg cliconfig.Config, result chan *checkpo
 	result <- resp
 }
 
+func (f pathFilterFlags) isEmpty() bool {
+	return len(f.Include) == 0 && len(f.Exclude) == 0
+}
+
+func (c *cli) setupFilterPaths(flags pathFilterFlags) {
+	pathFilter, err := filter.ParsePathFilter(
+		prj.PrjAbsPath(c.rootdir(), c.wd()), flags.Include, flags.Exclude,
+	)
+	if err != nil {
+		fatalWithDetailf(err, "unable to parse --include/--exclude patterns")
+	}
+	c.pathFilter = pathFilter
+}
+
+// setupFilterStackIDs sets the IDs of the stacks selected by --stack-id.
+// The IDs are matched case insensitively and each of them must match a stack
+// of the project, unless --ignore-missing-ids is set.
+func (c *cli) setupFilterStackIDs(flags stackIDFilterFlags) {
+	if len(flags.StackID) == 0 {
+		if flags.IgnoreMissingIDs {
+			fatalWithDetailf(errors.E("--ignore-missing-ids requires --stack-id"), "Invalid args")
+		}
+		return
+	}
+
+	stacks, err := config.LoadAllStacks(c.cfg(), c.cfg().Tree())
+	if err != nil {
+		fatalWithDetailf(err, "loading stacks")
+	}
+	projectIDs := map[string]struct{}{}
+	for _, st := range stacks {
+		if st.Stack.ID != "" {
+			projectIDs[strings.ToLower(st.Stack.ID)] = struct{}{}
+		}
+	}
+
+	c.stackIDs = map[string]struct{}{}
+	var missing []string
+	for _, id := range flags.StackID {
+		lowerID := strings.ToLower(id)
+		if _, ok := projectIDs[lowerID]; !ok {
+			missing = append(missing, id)
+		}
+		c.stackIDs[lowerID] = struct{}{}
+	}
+	if len(missing) > 0 && !flags.IgnoreMissingIDs {
+		fatalWithDetailf(
+			errors.E("--stack-id %s does not match any stack of the project", strings.Join(missing, ", ")),
+			"Use --ignore-missing-ids to ignore the IDs not found",
+		)
+	}
+}
+
 func (c *cli) setupFilterTags() {
 	clauses, found, err := filter.ParseTagClauses(c.parsedArgs.Tags...)
 	if err != nil {
@@ -2887,7 +3764,9 @@ func newGit(basedir string) (*git.Git, error) {
 	return g, nil
 }
 
-func lookupProject(wd string) (prj *project, found bool, err error) {
+// lookupProject looks up the project from wd. If overlay is not empty, the
+// Terramate files of the overlay directory are layered over the project ones.
+func lookupProject(wd string, overlay string) (prj *project, found bool, err error) {
 	prj = &project{
 		wd: wd,
 	}
@@ -2908,7 +3787,7 @@ func lookupProject(wd string) (prj *project, found bool, err error) {
 			return nil, false, errors.E(err, "failed evaluating symlinks of %q", gitabs)
 		}
 
-		cfg, err := config.LoadRoot(rootdir)
+		cfg, err := loadRoot(rootdir, overlay)
 		if err != nil {
 			return nil, false, err
 		}
@@ -2933,12 +3812,25 @@ func lookupProject(wd string) (prj *project, found bool, err error) {
 	if !rootfound {
 		return nil, false, nil
 	}
+	if overlay != "" {
+		rootcfg, err = loadRoot(rootcfgpath, overlay)
+		if err != nil {
+			return nil, false, err
+		}
+	}
 	prj.rootdir = rootcfgpath
 	prj.root = rootcfg
 	prj.stackManager = stack.NewManager(prj.root)
 	return prj, true, nil
 }
 
+func loadRoot(rootdir string, overlay string) (*config.Root, error) {
+	if overlay != "" {
+		return config.LoadRootWithOverlay(rootdir, overlay)
+	}
+	return config.LoadRoot(rootdir)
+}
+
 func configureLogging(logLevel, logFmt, logdest string, stdout, stderr io.Writer) {
 	var output io.Writer
 
@@ -15,7 +15,9 @@ import (
 	"github.com/zclconf/go-cty/cty"
 )
 
-const configPathEnv = "TM_CLI_CONFIG_FILE"
+// PathEnv is the environment variable used to define the path of the CLI
+// configuration file.
+const PathEnv = "TM_CLI_CONFIG_FILE"
 
 const (
 	// ErrInvalidAttributeType indicates the attribute has an invalid type.
@@ -31,11 +33,14 @@ type Config struct {
 	DisableCheckpointSignature bool
 	DisableTelemetry           bool
 	UserTerramateDir           string
+
+	// Filename is the path of the loaded configuration file, if any.
+	Filename string
 }
 
 // Load loads (parses and evaluates) all CLI configuration files.
 func Load() (cfg Config, err error) {
-	fname := os.Getenv(configPathEnv)
+	fname := os.Getenv(PathEnv)
 	if fname == "" {
 		var found bool
 		fname, found = configAbsPath()
@@ -59,7 +64,7 @@ func LoadFrom(fname string) (Config, error) {
 		return Config{}, errors.E(hcl.ErrHCLSyntax, diags, "failed to parse %s", fname)
 	}
 
-	var cfg Config
+	cfg := Config{Filename: fname}
 	body := hclfile.Body.(*hclsyntax.Body)
 	for name, attr := range body.Attributes {
 		val, diags := attr.Expr.Value(nil)
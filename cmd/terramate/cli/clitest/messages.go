@@ -17,6 +17,10 @@ const (
 	// CloudSyncDriftFailedMessage is the message displayed when a drift sync fails.
 	CloudSyncDriftFailedMessage = "failed to sync the drift status"
 
+	// CloudSyncStacksFailedMessage is the message displayed in the summary of stacks
+	// whose status could not be synchronized.
+	CloudSyncStacksFailedMessage = "failed to sync the status of some stacks to Terramate Cloud"
+
 	// CloudSkippingTerraformPlanSync is the message displayed when a terraform plan sync is skipped.
 	CloudSkippingTerraformPlanSync = "skipping the sync of Terraform plan details"
 
@@ -51,6 +55,10 @@ const (
 	// ErrCloudInvalidTerraformPlanFilePath indicates the plan file is not valid.
 	ErrCloudInvalidTerraformPlanFilePath errors.Kind = "invalid plan file path"
 
+	// ErrCloudSyncFailed indicates the status of some stacks could not be synchronized
+	// and --fail-on-cloud-error is set.
+	ErrCloudSyncFailed errors.Kind = "failed to synchronize with Terramate Cloud"
+
 	// ErrSafeguardKeywordValidation indicates the safeguard keywords validation failed.
 	ErrSafeguardKeywordValidation errors.Kind = "failed to validate safeguard keywords"
 )
@@ -41,6 +41,7 @@ import (
 	"github.com/terramate-io/terramate/git"
 	"github.com/terramate-io/terramate/printer"
 	prj "github.com/terramate-io/terramate/project"
+	runutil "github.com/terramate-io/terramate/run"
 )
 
 const (
@@ -56,6 +57,8 @@ const (
 	cloudFeatSyncDeployment  = "'--sync-deployment' is a Terramate Cloud feature to synchronize deployment details to Terramate Cloud."
 	cloudFeatSyncDriftStatus = "'--sync-drift-status' is a Terramate Cloud feature to synchronize drift and health check results to Terramate Cloud."
 	cloudFeatSyncPreview     = "'--sync-preview' is a Terramate Cloud feature to synchronize deployment previews to Terramate Cloud."
+
+	cloudFeatOnlyPlanChangedResources = "'--only-plan-changed-resources' is a Terramate Cloud feature to target the resources changed in the last drift of the stacks."
 )
 
 const githubDomain = "github.com"
@@ -136,6 +139,13 @@ type cloudRunState struct {
 		commitSHA string
 	}
 	metadata *cloud.DeploymentMetadata
+
+	// stackMeta2Metadata is a map of stack.ID to the stack custom metadata.
+	stackMeta2Metadata map[string]map[string]string
+
+	// stackMeta2ChangeReason is a map of stack.ID to the reason why the stack
+	// was selected by the change detection.
+	stackMeta2ChangeReason map[string]string
 }
 
 type cloudConfig struct {
@@ -143,7 +153,12 @@ type cloudConfig struct {
 	client   *cloud.Client
 	output   out.O
 
-	run cloudRunState
+	run          cloudRunState
+	syncFailures *cloudSyncFailures
+
+	// syncGate defers the synchronization of the stack runs when the stack
+	// selection is streamed.
+	syncGate *cloudSyncGate
 }
 
 type credential interface {
@@ -189,12 +204,34 @@ func (rs cloudRunState) cloudPreviewID(metaID string) (string, bool) {
 	return id, ok
 }
 
+func (rs *cloudRunState) setMeta2Metadata(metaID string, metadata map[string]string) {
+	if rs.stackMeta2Metadata == nil {
+		rs.stackMeta2Metadata = make(map[string]map[string]string)
+	}
+	rs.stackMeta2Metadata[strings.ToLower(metaID)] = metadata
+}
+
+func (rs cloudRunState) stackMetadata(metaID string) map[string]string {
+	return rs.stackMeta2Metadata[strings.ToLower(metaID)]
+}
+
+func (rs *cloudRunState) setMeta2ChangeReason(metaID string, reason string) {
+	if rs.stackMeta2ChangeReason == nil {
+		rs.stackMeta2ChangeReason = make(map[string]string)
+	}
+	rs.stackMeta2ChangeReason[strings.ToLower(metaID)] = cloud.TruncateChangeReason(reason)
+}
+
+func (rs cloudRunState) stackChangeReason(metaID string) string {
+	return rs.stackMeta2ChangeReason[strings.ToLower(metaID)]
+}
+
 func (c *cli) credentialPrecedence(output out.O) []credential {
 	return []credential{
 		newAPIKey(output, c.cloud.client),
 		newGithubOIDC(output, c.cloud.client),
 		newGitlabOIDC(output, c.cloud.client),
-		newGoogleCredential(output, c.cloud.client.IDPKey, c.clicfg, c.cloud.client),
+		newGoogleCredential(output, c.cloud.client.IDPKey, c.clicfg, c.parsedArgs.CloudProfile, c.cloud.client),
 	}
 }
 
@@ -239,7 +276,45 @@ func isDeploymentTask(t stackRunTask) bool { return t.CloudSyncDeployment }
 func isDriftTask(t stackRunTask) bool      { return t.CloudSyncDriftStatus }
 func isPreviewTask(t stackRunTask) bool    { return t.CloudSyncPreview }
 
+func isDeploymentOrDriftTask(t stackRunTask) bool {
+	return t.CloudSyncDeployment || t.CloudSyncDriftStatus
+}
+
+// loadCloudStacksMetadata evaluates the terramate.config.cloud.metadata of all
+// stacks beforehand, then no command is executed if the metadata of any of
+// them is invalid. The change reasons of the selected stacks are also kept,
+// so they can be synchronized together with the stacks.
+func (c *cli) loadCloudStacksMetadata(runs []stackCloudRun) {
+	for _, entry := range c.affectedStacks {
+		if entry.Reason != "" {
+			c.cloud.run.setMeta2ChangeReason(entry.Stack.ID, entry.Reason)
+		}
+	}
+
+	errs := errors.L()
+	for _, run := range runs {
+		metadata, err := runutil.LoadCloudMetadata(c.cfg(), run.Stack)
+		if err != nil {
+			errs.Append(err)
+			continue
+		}
+		c.cloud.run.setMeta2Metadata(run.Stack.ID, metadata)
+	}
+	if err := errs.AsError(); err != nil {
+		fatalWithDetailf(err, "unable to evaluate cloud metadata")
+	}
+}
+
 func (c *cli) checkCloudSync() {
+	if c.parsedArgs.Run.DriftContext != "" {
+		if !c.parsedArgs.Run.SyncDriftStatus {
+			fatal("--drift-context requires --sync-drift-status")
+		}
+		if err := drift.Context(c.parsedArgs.Run.DriftContext).Validate(); err != nil {
+			fatalWithDetailf(err, "invalid --drift-context")
+		}
+	}
+
 	if !c.parsedArgs.Run.SyncDeployment && !c.parsedArgs.Run.SyncDriftStatus && !c.parsedArgs.Run.SyncPreview {
 		return
 	}
@@ -285,6 +360,19 @@ func (c *cli) cloudOrgName() string {
 	return ""
 }
 
+// cloudMaxAttempts returns the maximum number of attempts of idempotent cloud
+// requests set by terramate.config.cloud.retries, or zero if not set.
+func (c *cli) cloudMaxAttempts() int {
+	cfg := c.rootNode()
+	if cfg.Terramate != nil &&
+		cfg.Terramate.Config != nil &&
+		cfg.Terramate.Config.Cloud != nil &&
+		cfg.Terramate.Config.Cloud.Retries != nil {
+		return *cfg.Terramate.Config.Cloud.Retries + 1
+	}
+	return 0
+}
+
 func (c *cli) setupCloudConfig(requestedFeatures []string) error {
 	err := c.loadCredential()
 	if err != nil {
@@ -387,6 +475,10 @@ func (c *cli) cloudSyncBefore(run stackCloudRun) {
 		return
 	}
 
+	if c.cloud.syncGate.deferred(func() { c.cloudSyncBefore(run) }) {
+		return
+	}
+
 	if run.Task.CloudSyncDeployment {
 		c.doCloudSyncDeployment(run, deployment.Running)
 	}
@@ -397,6 +489,8 @@ func (c *cli) cloudSyncBefore(run stackCloudRun) {
 }
 
 func (c *cli) cloudSyncAfter(run stackCloudRun, res runResult, err error) {
+	c.cloud.syncGate.wait()
+
 	if !c.cloudEnabled() {
 		return
 	}
@@ -430,6 +524,7 @@ func (c *cli) doPreviewBefore(run stackCloudRun) {
 			ChangesetDetails: nil,
 		}); err != nil {
 		printer.Stderr.ErrorWithDetails("failed to update stack preview", err)
+		c.addCloudSyncFailure(run, err)
 		return
 	}
 	log.Debug().
@@ -483,6 +578,7 @@ func (c *cli) doPreviewAfter(run stackCloudRun, res runResult) {
 			ChangesetDetails: previewChangeset,
 		}); err != nil {
 		printer.Stderr.ErrorWithDetails("failed to create stack preview", err)
+		c.addCloudSyncFailure(run, err)
 		return
 	}
 
@@ -498,11 +594,20 @@ func (c *cli) doPreviewAfter(run stackCloudRun, res runResult) {
 }
 
 func (c *cli) cloudInfo() {
+	asJSON := c.parsedArgs.Cloud.Info.AsJSON
 	err := c.loadCredential()
 	if err != nil {
+		if asJSON && errors.IsKind(err, ErrLoginRequired) {
+			c.printCloudInfoJSON(cloudInfoJSON{Status: cloudInfoSignedOut})
+			os.Exit(ExitStatusLoginRequired)
+		}
 		// TODO: Better error message.
 		fatalWithDetailf(err, "failed to load credentials")
 	}
+	if asJSON {
+		c.printCloudInfoJSON(c.newCloudInfoJSON())
+		return
+	}
 	c.cred().info(c.cloudOrgName())
 
 	// verbose info
@@ -584,6 +689,9 @@ func (c *cli) cloudDriftShow() {
 		fatalf("Stack %s is drifted, but no details are available.", st.Dir.String())
 	}
 	c.output.MsgStdOutV("drift provisioner: %s", driftData.Details.Provisioner)
+	if driftData.Context != "" {
+		c.output.MsgStdOut("drift context: %s", driftData.Context)
+	}
 	c.output.MsgStdOut(driftData.Details.ChangesetASCII)
 }
 
@@ -826,11 +934,15 @@ func (c *cli) detectGitlabMetadata(group string, projectName string) {
 	mr, found, err := client.MRForCommit(ctx, headCommit)
 	if err != nil {
 		logger.Warn().Err(err).Msg("failed to retrieve Merge Requests associated with commit")
-		return
 	}
 	if !found {
-		logger.Warn().Msg("No merge request associated with commit")
-		return
+		// merge request pipelines provide the MR details in the environment.
+		mr, found = gitlab.MRFromCIEnv()
+		if !found {
+			logger.Warn().Msg("No merge request associated with commit")
+			return
+		}
+		logger.Debug().Msg("using the merge request of the CI/CD pipeline environment")
 	}
 	md.GitlabMergeRequestAuthorID = mr.Author.ID
 	md.GitlabMergeRequestAuthorName = mr.Author.Name
@@ -854,7 +966,7 @@ func (c *cli) detectGitlabMetadata(group string, projectName string) {
 	}
 
 	pushedAt, err := time.Parse(time.RFC3339, md.GitlabCICDJobStartedAt)
-	if err != nil {
+	if err != nil && mr.UpdatedAt != "" {
 		printer.Stderr.WarnWithDetails("failed to parse job `started_at` field: fallback to MR `updated_at` field", err)
 		pushedAt, err = time.Parse(time.RFC3339, mr.UpdatedAt)
 		if err != nil {
@@ -865,6 +977,10 @@ func (c *cli) detectGitlabMetadata(group string, projectName string) {
 		pushedAtInt := pushedAt.Unix()
 		c.cloud.run.rrEvent.pushedAt = &pushedAtInt
 	}
+	if c.cloud.run.rrEvent.pushedAt == nil {
+		printer.Stderr.Warn("unable to detect the push time of the merge request: skipping review request")
+		return
+	}
 
 	c.cloud.run.rrEvent.commitSHA = mr.SHA
 	c.cloud.run.reviewRequest = c.newGitlabReviewRequest(mr)
@@ -1485,15 +1601,21 @@ func (c *cli) newGitlabReviewRequest(mr gitlab.MR) *cloud.ReviewRequest {
 	if c.cloud.run.rrEvent.pushedAt == nil {
 		panic(errors.E(errors.ErrInternal, "CI pushed_at is nil"))
 	}
-	mrUpdatedAt, err := time.Parse(time.RFC3339, mr.UpdatedAt)
-	if err != nil {
-		printer.Stderr.WarnWithDetails("failed to parse MR.updated_at field", err)
+	// the times are not available for merge requests of the CI environment.
+	var mrUpdatedAt, mrCreatedAt *time.Time
+	if mr.UpdatedAt != "" {
+		if mrUpdatedAtVal, err := time.Parse(time.RFC3339, mr.UpdatedAt); err != nil {
+			printer.Stderr.WarnWithDetails("failed to parse MR.updated_at field", err)
+		} else {
+			mrUpdatedAt = &mrUpdatedAtVal
+		}
 	}
-	var mrCreatedAt *time.Time
-	if mrCreatedAtVal, err := time.Parse(time.RFC3339, mr.CreatedAt); err != nil {
-		printer.Stderr.WarnWithDetails("failed to parse MR.created_at field", err)
-	} else {
-		mrCreatedAt = &mrCreatedAtVal
+	if mr.CreatedAt != "" {
+		if mrCreatedAtVal, err := time.Parse(time.RFC3339, mr.CreatedAt); err != nil {
+			printer.Stderr.WarnWithDetails("failed to parse MR.created_at field", err)
+		} else {
+			mrCreatedAt = &mrCreatedAtVal
+		}
 	}
 	rr := &cloud.ReviewRequest{
 		Platform:    "gitlab",
@@ -1505,7 +1627,7 @@ func (c *cli) newGitlabReviewRequest(mr gitlab.MR) *cloud.ReviewRequest {
 		CommitSHA:   mr.SHA,
 		Draft:       mr.Draft,
 		CreatedAt:   mrCreatedAt,
-		UpdatedAt:   &mrUpdatedAt,
+		UpdatedAt:   mrUpdatedAt,
 		Status:      mr.State,
 		Author: cloud.Author{
 			ID:        strconv.Itoa64(int64(mr.Author.ID)),
@@ -1528,6 +1650,38 @@ func (c *cli) newGitlabReviewRequest(mr gitlab.MR) *cloud.ReviewRequest {
 	return rr
 }
 
+// newGitlabBranchReviewRequest returns the review request of a Gitlab CI/CD
+// pipeline not running for a merge request, so the preview is associated only
+// with the pipeline branch and commit.
+func (c *cli) newGitlabBranchReviewRequest() *cloud.ReviewRequest {
+	branch := os.Getenv("CI_COMMIT_BRANCH")
+	if branch == "" {
+		branch = os.Getenv("CI_COMMIT_REF_NAME")
+	}
+	commitSHA := os.Getenv("CI_COMMIT_SHA")
+	if commitSHA == "" {
+		commitSHA = c.prj.headCommit()
+	}
+	c.cloud.run.rrEvent.commitSHA = commitSHA
+	return &cloud.ReviewRequest{
+		Platform:   "gitlab",
+		Repository: c.prj.prettyRepo(),
+		CommitSHA:  commitSHA,
+		Branch:     branch,
+	}
+}
+
+// syncPreviewSupported tells if --sync-preview is supported in the CI/CD
+// platform Terramate is running on.
+func (c *cli) syncPreviewSupported() bool {
+	switch c.prj.ciPlatform() {
+	case ci.PlatformGithub, ci.PlatformGitlab, ci.PlatformBitBucket:
+		return true
+	default:
+		return false
+	}
+}
+
 func (c *cli) loadCredential() error {
 	cloudURL := cloudBaseURL()
 	clientLogger := log.With().
@@ -1535,10 +1689,11 @@ func (c *cli) loadCredential() error {
 		Logger()
 
 	c.cloud.client = &cloud.Client{
-		BaseURL:    cloudURL,
-		IDPKey:     idpkey(),
-		HTTPClient: &c.httpClient,
-		Logger:     &clientLogger,
+		BaseURL:     cloudURL,
+		IDPKey:      idpkey(),
+		HTTPClient:  &c.httpClient,
+		Logger:      &clientLogger,
+		MaxAttempts: c.cloudMaxAttempts(),
 	}
 	c.cloud.output = c.output
 
@@ -19,7 +19,7 @@ import (
 
 const defaultGitHubClientID = "08e1f8d6f599c7ec48c5"
 
-func githubLogin(output out.O, tmcBaseURL string, idpKey string, clicfg cliconfig.Config) error {
+func githubLogin(output out.O, tmcBaseURL string, idpKey string, clicfg cliconfig.Config, profile string) error {
 	token, err := githubAuth()
 	if err != nil {
 		return err
@@ -46,7 +46,7 @@ func githubLogin(output out.O, tmcBaseURL string, idpKey string, clicfg cliconfi
 	output.MsgStdOutV("Token: %s", cred.IDToken)
 	expire, _ := strconv.Atoi(cred.ExpiresIn)
 	output.MsgStdOutV("Expire at: %s", time.Now().Add(time.Second*time.Duration(expire)).Format(time.RFC822Z))
-	return saveCredential(output, cred, clicfg)
+	return saveCredential(output, cred, clicfg, profile)
 }
 
 func githubAuth() (string, error) {
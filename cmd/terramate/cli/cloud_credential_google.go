@@ -15,7 +15,6 @@ import (
 	"net/http"
 	"net/url"
 	"os"
-	"path/filepath"
 	"strconv"
 	"sync"
 	"time"
@@ -56,9 +55,10 @@ type (
 
 		provider string
 
-		output out.O
-		clicfg cliconfig.Config
-		client *cloud.Client
+		output  out.O
+		clicfg  cliconfig.Config
+		profile string
+		client  *cloud.Client
 	}
 
 	createAuthURIResponse struct {
@@ -94,7 +94,7 @@ type (
 	}
 )
 
-func googleLogin(output out.O, idpKey string, clicfg cliconfig.Config) error {
+func googleLogin(output out.O, idpKey string, clicfg cliconfig.Config, profile string) error {
 	h := &tokenHandler{
 		credentialChan: make(chan credentialInfo),
 		errChan:        make(chan tokenError),
@@ -136,7 +136,7 @@ func googleLogin(output out.O, idpKey string, clicfg cliconfig.Config) error {
 		output.MsgStdOutV("Token: %s", cred.IDToken)
 		expire, _ := strconv.Atoi(cred.ExpiresIn)
 		output.MsgStdOutV("Expire at: %s", time.Now().Add(time.Second*time.Duration(expire)).Format(time.RFC822Z))
-		return saveCredential(output, cred, clicfg)
+		return saveCredential(output, cred, clicfg, profile)
 	case err := <-h.errChan:
 		return err.err
 	}
@@ -390,7 +390,7 @@ func (h *tokenHandler) handleErr(w http.ResponseWriter) {
 	_, _ = w.Write([]byte(errMessage))
 }
 
-func saveCredential(output out.O, cred credentialInfo, clicfg cliconfig.Config) error {
+func saveCredential(output out.O, cred credentialInfo, clicfg cliconfig.Config, profile string) error {
 	cachePayload := cachedCredential{
 		Provider:     cred.ProviderID.String(),
 		IDToken:      cred.IDToken,
@@ -402,7 +402,7 @@ func saveCredential(output out.O, cred credentialInfo, clicfg cliconfig.Config)
 		return errors.E(err, "failed to JSON marshal the credentials")
 	}
 
-	credfile := filepath.Join(clicfg.UserTerramateDir, credfile)
+	credfile := credentialFile(clicfg, profile)
 	err = os.WriteFile(credfile, data, 0600)
 	if err != nil {
 		return errors.E(err, "failed to cache credentials")
@@ -412,8 +412,8 @@ func saveCredential(output out.O, cred credentialInfo, clicfg cliconfig.Config)
 	return nil
 }
 
-func loadCredential(output out.O, clicfg cliconfig.Config) (cachedCredential, bool, error) {
-	credFile := filepath.Join(clicfg.UserTerramateDir, credfile)
+func loadCredential(output out.O, clicfg cliconfig.Config, profile string) (cachedCredential, bool, error) {
+	credFile := credentialFile(clicfg, profile)
 	_, err := os.Lstat(credFile)
 	if err != nil {
 		return cachedCredential{}, false, nil
@@ -447,18 +447,20 @@ func newGoogleCredential(
 	output out.O,
 	idpKey string,
 	clicfg cliconfig.Config,
+	profile string,
 	client *cloud.Client,
 ) *googleCredential {
 	return &googleCredential{
-		output: output,
-		clicfg: clicfg,
-		idpKey: idpKey,
-		client: client,
+		output:  output,
+		clicfg:  clicfg,
+		profile: profile,
+		idpKey:  idpKey,
+		client:  client,
 	}
 }
 
 func (g *googleCredential) Load() (bool, error) {
-	credinfo, found, err := loadCredential(g.output, g.clicfg)
+	credinfo, found, err := loadCredential(g.output, g.clicfg, g.profile)
 	if err != nil {
 		return false, err
 	}
@@ -572,7 +574,7 @@ func (g *googleCredential) Refresh() (err error) {
 	return saveCredential(g.output, credentialInfo{
 		IDToken:      g.token,
 		RefreshToken: g.refreshToken,
-	}, g.clicfg)
+	}, g.clicfg, g.profile)
 }
 
 func (g *googleCredential) HasExpiration() bool {
@@ -655,6 +657,9 @@ func (g *googleCredential) fetchDetails() error {
 func (g *googleCredential) info(selectedOrgName string) {
 	printer.Stdout.Println("status: signed in")
 	printer.Stdout.Println(fmt.Sprintf("provider: %s", g.Name()))
+	if g.profile != defaultCloudProfile {
+		printer.Stdout.Println(fmt.Sprintf("profile: %s", g.profile))
+	}
 
 	if g.user.DisplayName != "" {
 		printer.Stdout.Println(fmt.Sprintf("user: %s", g.user.DisplayName))
@@ -686,6 +691,11 @@ func (g *googleCredential) organizations() cloud.MemberOrganizations {
 	return g.orgs
 }
 
+// cloudUser returns the Terramate Cloud user of the credential.
+func (g *googleCredential) cloudUser() cloud.User {
+	return g.user
+}
+
 func (g *googleCredential) update(idToken, refreshToken string) (err error) {
 	g.mu.Lock()
 	defer g.mu.Unlock()
@@ -0,0 +1,174 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package cli
+
+import (
+	"context"
+	stdjson "encoding/json"
+	"time"
+
+	"github.com/terramate-io/terramate/cloud"
+	"github.com/terramate-io/terramate/config"
+	"github.com/terramate-io/terramate/errors"
+)
+
+func (c *cli) cloudDeploymentList() {
+	args := c.parsedArgs.Cloud.Deployment.List
+	if args.Limit < 1 {
+		fatal("--limit must be greater than zero")
+	}
+	if !c.prj.isRepo {
+		fatal("Listing deployments requires the project to be a git repository.")
+	}
+
+	err := c.setupCloudConfig(nil)
+	if err != nil {
+		fatalWithDetailf(err, "unable to setup cloud configuration")
+	}
+
+	var stackID *int64
+	if args.Stack != "" {
+		id := c.cloudStackID(args.Stack, args.Target)
+		stackID = &id
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultCloudTimeout)
+	defer cancel()
+
+	deployments, err := c.cloud.client.ListDeployments(ctx, c.cloud.run.orgUUID, c.prj.prettyRepo(), stackID, args.Limit)
+	if err != nil {
+		fatalWithDetailf(err, "unable to list deployments")
+	}
+	if len(deployments) == 0 {
+		c.output.MsgStdOut("No deployments found.")
+		return
+	}
+	for _, d := range deployments {
+		c.output.MsgStdOut("%s %s %s %s %s", d.UUID, d.Status, shortCommitSHA(d.CommitSHA),
+			valueOrDash(d.AuthorName), formatDeploymentDuration(d))
+		for _, st := range d.Stacks {
+			c.output.MsgStdOut("\t%s %s", st.Path, st.Status)
+		}
+	}
+}
+
+func (c *cli) cloudDeploymentShow() {
+	args := c.parsedArgs.Cloud.Deployment.Show
+
+	err := c.setupCloudConfig(nil)
+	if err != nil {
+		fatalWithDetailf(err, "unable to setup cloud configuration")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultCloudTimeout)
+	defer cancel()
+
+	d, err := c.cloud.client.GetDeployment(ctx, c.cloud.run.orgUUID, cloud.UUID(args.ID))
+	if err != nil {
+		if errors.IsKind(err, cloud.ErrNotFound) {
+			fatalf("Deployment %s not found in Terramate Cloud.", args.ID)
+		}
+		fatalWithDetailf(err, "unable to fetch deployment")
+	}
+
+	if args.AsJSON {
+		data, err := stdjson.MarshalIndent(d, "", "  ")
+		if err != nil {
+			fatalWithDetailf(err, "encoding deployment %s", d.UUID)
+		}
+		c.output.MsgStdOut("%s", data)
+		return
+	}
+
+	c.output.MsgStdOut("Deployment %s", d.UUID)
+	c.output.MsgStdOut("Status: %s", d.Status)
+	commit := valueOrDash(d.CommitSHA)
+	if d.CommitTitle != "" {
+		commit += " (" + d.CommitTitle + ")"
+	}
+	c.output.MsgStdOut("Commit: %s", commit)
+	c.output.MsgStdOut("Author: %s", valueOrDash(d.AuthorName))
+	if d.CreatedAt != nil {
+		c.output.MsgStdOut("Started at: %s", d.CreatedAt.Format(time.RFC3339))
+	}
+	c.output.MsgStdOut("Duration: %s", formatDeploymentDuration(d))
+	if d.Workdir != "" {
+		c.output.MsgStdOut("Working directory: %s", d.Workdir)
+	}
+	c.output.MsgStdOut("Stacks:")
+	for _, st := range d.Stacks {
+		c.output.MsgStdOut("\t%s: %s", st.Path, st.Status)
+		c.output.MsgStdOutV("\t\tcommand: %s", st.DeploymentCommand)
+	}
+}
+
+// cloudStackID returns the Terramate Cloud ID of the stack at the given path.
+func (c *cli) cloudStackID(path, target string) int64 {
+	st, found, err := config.TryLoadStack(c.cfg(), c.projectPath(path))
+	if err != nil {
+		fatalWithDetailf(err, "loading stack %s", path)
+	}
+	if !found {
+		fatalf("No stack found at %s.", path)
+	}
+	if st.ID == "" {
+		fatal("The stack must have an ID for using TMC features")
+	}
+
+	isTargetConfigEnabled := false
+	c.checkTargetsConfiguration(target, "", func(isTargetEnabled bool) {
+		if !isTargetEnabled {
+			fatal("--target must be set when terramate.config.cloud.targets.enabled is true")
+		}
+		isTargetConfigEnabled = isTargetEnabled
+	})
+	if target == "" {
+		target = "default"
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultCloudTimeout)
+	defer cancel()
+
+	stackResp, found, err := c.cloud.client.GetStack(ctx, c.cloud.run.orgUUID, c.prj.prettyRepo(), target, st.ID)
+	if err != nil {
+		fatalWithDetailf(err, "unable to fetch stack")
+	}
+	if !found {
+		if isTargetConfigEnabled {
+			fatalf("Stack %s was not yet synced for target %s with the Terramate Cloud.", st.Dir.String(), target)
+		}
+		fatalf("Stack %s was not yet synced with the Terramate Cloud.", st.Dir.String())
+	}
+	return stackResp.ID
+}
+
+// printCloudDeployment prints the UUID of the deployment synchronized to
+// Terramate Cloud, so it can be used with `terramate cloud deployment show`.
+func (c *cli) printCloudDeployment() {
+	if c.cloud.run.runUUID == "" || !c.cloudEnabled() || c.quiet() {
+		return
+	}
+	c.output.MsgStdErr("Terramate Cloud deployment: %s", c.cloud.run.runUUID)
+}
+
+func formatDeploymentDuration(d cloud.Deployment) string {
+	if d.CreatedAt == nil {
+		return "-"
+	}
+	return d.Duration().Round(time.Second).String()
+}
+
+func shortCommitSHA(sha string) string {
+	if len(sha) > 8 {
+		return sha[:8]
+	}
+	return valueOrDash(sha)
+}
+
+func valueOrDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
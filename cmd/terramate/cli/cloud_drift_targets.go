@@ -0,0 +1,145 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package cli
+
+import (
+	"context"
+
+	"github.com/rs/zerolog/log"
+	"github.com/terramate-io/terramate/cloud/drift"
+	"github.com/terramate-io/terramate/errors"
+	"github.com/terramate-io/terramate/printer"
+	runutil "github.com/terramate-io/terramate/run"
+)
+
+const onlyPlanChangedResourcesWarning = "--only-plan-changed-resources is experimental and uses -target, " +
+	"which can leave the infrastructure partially applied. " +
+	"Run the full command once the drifted resources are reconciled."
+
+// targetChangedResources returns the runs with -target options added to their
+// terraform or tofu commands for the resources changed in the last drift of
+// the stack synchronized to Terramate Cloud. Stacks without drift details are
+// removed from the runs and stacks with more changed resources than
+// --max-targets run the full command.
+func (c *cli) targetChangedResources(runs []stackRun) []stackRun {
+	errs := errors.L()
+	for _, run := range runs {
+		for _, task := range run.Tasks {
+			if err := runutil.CheckTargetable(task.Cmd); err != nil {
+				errs.Append(errors.E(err, "stack %s", run.Stack.Dir))
+			}
+		}
+	}
+	if err := errs.AsError(); err != nil {
+		fatalWithDetailf(err, "--only-plan-changed-resources requires terraform or tofu plan or apply commands")
+	}
+
+	if c.parsedArgs.Run.MaxTargets <= 0 {
+		fatal("--max-targets must be greater than zero")
+	}
+
+	if !c.prj.isRepo {
+		fatal("--only-plan-changed-resources requires a git repository")
+	}
+
+	err := c.setupCloudConfig([]string{cloudFeatOnlyPlanChangedResources})
+	if err != nil {
+		fatalWithDetailf(err, "unable to setup cloud configuration")
+	}
+
+	printer.Stderr.Warn(onlyPlanChangedResourcesWarning)
+
+	target := c.parsedArgs.Run.Target
+	if target == "" {
+		target = "default"
+	}
+
+	var targetedRuns []stackRun
+	for _, run := range runs {
+		if run.Stack.ID == "" {
+			fatalf("stack %s must have an ID for using --only-plan-changed-resources", run.Stack.Dir)
+		}
+
+		addresses, found, err := c.stackDriftedResources(run, target)
+		if err != nil {
+			fatalWithDetailf(err, "unable to fetch the drift details of stack %s", run.Stack.Dir)
+		}
+		if !found || len(addresses) == 0 {
+			c.output.MsgStdErr("Skipping stack %s: no drift details available", run.Stack.Dir)
+			continue
+		}
+
+		if len(addresses) > c.parsedArgs.Run.MaxTargets {
+			printer.Stderr.Warnf("stack %s has %d changed resources, more than --max-targets=%d: running the full command",
+				run.Stack.Dir, len(addresses), c.parsedArgs.Run.MaxTargets)
+
+			targetedRuns = append(targetedRuns, run)
+			continue
+		}
+
+		tasks := make([]stackRunTask, len(run.Tasks))
+		for i, task := range run.Tasks {
+			// the commands were checked above.
+			task.Cmd, _ = runutil.WithTargets(task.Cmd, addresses)
+			task.DisplayCmd = nil
+			tasks[i] = task
+		}
+		run.Tasks = tasks
+		targetedRuns = append(targetedRuns, run)
+	}
+	return targetedRuns
+}
+
+// stackDriftedResources returns the addresses of the resources changed in the
+// last drift of the stack. It returns false if the stack is not drifted or if
+// the drift has no JSON plan details.
+func (c *cli) stackDriftedResources(run stackRun, target string) ([]string, bool, error) {
+	logger := log.With().
+		Str("action", "cli.stackDriftedResources").
+		Stringer("stack", run.Stack.Dir).
+		Str("target", target).
+		Logger()
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultCloudTimeout)
+	defer cancel()
+
+	stackResp, found, err := c.cloud.client.GetStack(ctx, c.cloud.run.orgUUID, c.prj.prettyRepo(), target, run.Stack.ID)
+	if err != nil {
+		return nil, false, err
+	}
+	if !found || stackResp.DriftStatus != drift.Drifted {
+		logger.Debug().Msg("stack is not drifted")
+		return nil, false, nil
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), defaultCloudTimeout)
+	defer cancel()
+
+	driftsResp, err := c.cloud.client.StackLastDrift(ctx, c.cloud.run.orgUUID, stackResp.ID)
+	if err != nil {
+		return nil, false, err
+	}
+	if len(driftsResp.Drifts) == 0 {
+		logger.Debug().Msg("stack has no drifts")
+		return nil, false, nil
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), defaultCloudTimeout)
+	defer cancel()
+
+	driftData, err := c.cloud.client.DriftDetails(ctx, c.cloud.run.orgUUID, stackResp.ID, driftsResp.Drifts[0].ID)
+	if err != nil {
+		return nil, false, err
+	}
+	if driftData.Status != drift.Drifted || driftData.Details == nil || driftData.Details.ChangesetJSON == "" {
+		logger.Debug().Msg("drift has no JSON plan details")
+		return nil, false, nil
+	}
+
+	addresses, err := runutil.ChangedResources(driftData.Details.ChangesetJSON)
+	if err != nil {
+		return nil, false, err
+	}
+	return addresses, true, nil
+}
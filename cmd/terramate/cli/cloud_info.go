@@ -0,0 +1,159 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package cli
+
+import (
+	stdjson "encoding/json"
+	"strings"
+
+	"github.com/terramate-io/terramate/cloud"
+)
+
+// ExitStatusLoginRequired is the exit status of `terramate cloud info --json`
+// when there's no Terramate Cloud credential.
+const ExitStatusLoginRequired = 3
+
+const (
+	cloudInfoSignedIn  = "signed in"
+	cloudInfoSignedOut = "signed out"
+)
+
+type cloudInfoJSON struct {
+	Status        string                     `json:"status"`
+	Provider      string                     `json:"provider,omitempty"`
+	Profile       string                     `json:"profile,omitempty"`
+	User          *cloudInfoUserJSON         `json:"user,omitempty"`
+	Claims        map[string]string          `json:"claims,omitempty"`
+	Organizations []cloudInfoOrgJSON         `json:"organizations"`
+	Organization  *cloudInfoOrgJSON          `json:"organization"`
+	Targets       *cloudInfoTargetsJSON      `json:"targets,omitempty"`
+	Entitlements  *cloudInfoEntitlementsJSON `json:"entitlements"`
+}
+
+type cloudInfoUserJSON struct {
+	DisplayName string     `json:"display_name"`
+	Email       string     `json:"email"`
+	UUID        cloud.UUID `json:"uuid"`
+}
+
+type cloudInfoOrgJSON struct {
+	Name        string     `json:"name"`
+	DisplayName string     `json:"display_name"`
+	UUID        cloud.UUID `json:"uuid"`
+	Role        string     `json:"role,omitempty"`
+	Status      string     `json:"status"`
+}
+
+type cloudInfoTargetsJSON struct {
+	Enabled bool `json:"enabled"`
+}
+
+type cloudInfoEntitlementsJSON struct {
+	Role string `json:"role"`
+	Sync bool   `json:"sync"`
+}
+
+// cloudUserCredential is implemented by the credentials of Terramate Cloud
+// users, as opposed to the credentials of machines (API keys and OIDC).
+type cloudUserCredential interface {
+	cloudUser() cloud.User
+}
+
+// claimsCredential is implemented by the credentials with claims shown to the
+// user in `terramate cloud info`.
+type claimsCredential interface {
+	DisplayClaims() []keyValue
+}
+
+func (c *cli) newCloudInfoJSON() cloudInfoJSON {
+	cred := c.cred()
+	info := cloudInfoJSON{
+		Status:        cloudInfoSignedIn,
+		Provider:      cred.Name(),
+		Organizations: []cloudInfoOrgJSON{},
+		Targets: &cloudInfoTargetsJSON{
+			Enabled: c.cfg().IsTargetsEnabled(),
+		},
+	}
+
+	if userCred, ok := cred.(cloudUserCredential); ok {
+		user := userCred.cloudUser()
+		info.User = &cloudInfoUserJSON{
+			DisplayName: user.DisplayName,
+			Email:       user.Email,
+			UUID:        user.UUID,
+		}
+		if profile := c.parsedArgs.CloudProfile; profile != defaultCloudProfile {
+			info.Profile = profile
+		}
+	}
+	if claimsCred, ok := cred.(claimsCredential); ok {
+		info.Claims = map[string]string{}
+		for _, kv := range claimsCred.DisplayClaims() {
+			info.Claims[kv.key] = kv.value
+		}
+	}
+
+	orgs := cred.organizations()
+	for _, org := range orgs {
+		info.Organizations = append(info.Organizations, newCloudInfoOrgJSON(org))
+	}
+
+	if org, ok := selectCloudOrg(orgs, c.cloudOrgName()); ok {
+		orgInfo := newCloudInfoOrgJSON(org)
+		info.Organization = &orgInfo
+		info.Entitlements = &cloudInfoEntitlementsJSON{
+			Role: org.Role,
+			Sync: isActiveMembership(org),
+		}
+	}
+	return info
+}
+
+func (c *cli) printCloudInfoJSON(info cloudInfoJSON) {
+	data, err := stdjson.MarshalIndent(info, "", "  ")
+	if err != nil {
+		fatalWithDetailf(err, "encoding cloud info")
+	}
+	c.output.MsgStdOut("%s", data)
+}
+
+func newCloudInfoOrgJSON(org cloud.MemberOrganization) cloudInfoOrgJSON {
+	return cloudInfoOrgJSON{
+		Name:        org.Name,
+		DisplayName: org.DisplayName,
+		UUID:        org.UUID,
+		Role:        org.Role,
+		Status:      org.Status,
+	}
+}
+
+// selectCloudOrg returns the organization used by the cloud commands, which
+// is the one named by orgName or the only active membership if orgName is
+// empty.
+func selectCloudOrg(orgs cloud.MemberOrganizations, orgName string) (cloud.MemberOrganization, bool) {
+	if orgName != "" {
+		for _, org := range orgs {
+			if strings.EqualFold(org.Name, orgName) {
+				return org, true
+			}
+		}
+		return cloud.MemberOrganization{}, false
+	}
+
+	var activeOrgs cloud.MemberOrganizations
+	for _, org := range orgs {
+		if isActiveMembership(org) {
+			activeOrgs = append(activeOrgs, org)
+		}
+	}
+	if len(activeOrgs) != 1 {
+		return cloud.MemberOrganization{}, false
+	}
+	return activeOrgs[0], true
+}
+
+func isActiveMembership(org cloud.MemberOrganization) bool {
+	return org.Status == "active" || org.Status == "trusted"
+}
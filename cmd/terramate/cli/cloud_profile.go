@@ -0,0 +1,53 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"github.com/terramate-io/terramate/cmd/terramate/cli/cliconfig"
+	"github.com/terramate-io/terramate/cmd/terramate/cli/out"
+	"github.com/terramate-io/terramate/errors"
+)
+
+// defaultCloudProfile is the profile of the credential stored in the
+// credentials file used before profiles were supported.
+const defaultCloudProfile = "default"
+
+var cloudProfileRx = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
+func validateCloudProfile(profile string) error {
+	if !cloudProfileRx.MatchString(profile) {
+		return errors.E("invalid profile name %q: it must only contain letters, digits, '-' or '_'", profile)
+	}
+	return nil
+}
+
+// credentialFile returns the path of the credentials file of the given profile.
+// The default profile keeps the credentials file of the single credential
+// layout, so existing logins keep working.
+func credentialFile(clicfg cliconfig.Config, profile string) string {
+	if profile == defaultCloudProfile {
+		return filepath.Join(clicfg.UserTerramateDir, credfile)
+	}
+	return filepath.Join(clicfg.UserTerramateDir, "credentials."+profile+".tmrc.json")
+}
+
+// cloudLogout removes the stored credential of the given profile.
+func cloudLogout(output out.O, clicfg cliconfig.Config, profile string) error {
+	credfile := credentialFile(clicfg, profile)
+	err := os.Remove(credfile)
+	if os.IsNotExist(err) {
+		output.MsgStdOut("not logged in with profile %s", profile)
+		return nil
+	}
+	if err != nil {
+		return errors.E(err, "failed to remove the credentials of profile %s", profile)
+	}
+	output.MsgStdOutV("removed credentials at %s", credfile)
+	output.MsgStdOut("logged out from profile %s", profile)
+	return nil
+}
@@ -0,0 +1,169 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package cli
+
+import (
+	"context"
+	stdjson "encoding/json"
+	"sort"
+	"strings"
+
+	"github.com/terramate-io/terramate/cloud"
+	"github.com/terramate-io/terramate/config"
+)
+
+type (
+	// cloudStackMapping is the result of joining the Terramate Cloud stacks
+	// with the local stacks by their meta ID.
+	cloudStackMapping struct {
+		Matched   []cloudStackEntry `json:"matched"`
+		CloudOnly []cloudStackEntry `json:"cloud_only"`
+		LocalOnly []cloudStackEntry `json:"local_only"`
+	}
+
+	cloudStackEntry struct {
+		MetaID           string `json:"meta_id"`
+		Path             string `json:"path"`
+		CloudPath        string `json:"cloud_path,omitempty"`
+		Status           string `json:"status,omitempty"`
+		DeploymentStatus string `json:"deployment_status,omitempty"`
+		DriftStatus      string `json:"drift_status,omitempty"`
+	}
+)
+
+func (c *cli) cloudStacksList() {
+	args := c.parsedArgs.Experimental.Cloud.Stacks.List
+	if !c.prj.isRepo {
+		fatal("Listing cloud stacks requires the project to be a git repository.")
+	}
+
+	err := c.setupCloudConfig(nil)
+	if err != nil {
+		fatalWithDetailf(err, "unable to setup cloud configuration")
+	}
+
+	target := args.Target
+	c.checkTargetsConfiguration(target, "", func(isTargetEnabled bool) {
+		if !isTargetEnabled {
+			fatal("--target must be set when terramate.config.cloud.targets.enabled is true")
+		}
+	})
+	if target == "" {
+		target = "default"
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultCloudTimeout)
+	defer cancel()
+
+	cloudStacks, err := c.cloud.client.StacksByStatus(ctx, c.cloud.run.orgUUID, c.prj.prettyRepo(), target, cloud.NoStatusFilters())
+	if err != nil {
+		fatalWithDetailf(err, "unable to list cloud stacks")
+	}
+
+	localStacks, err := config.LoadAllStacks(c.cfg(), c.cfg().Tree())
+	if err != nil {
+		fatalWithDetailf(err, "loading local stacks")
+	}
+
+	mapping := cloudStackMapping{
+		Matched:   []cloudStackEntry{},
+		CloudOnly: []cloudStackEntry{},
+		LocalOnly: []cloudStackEntry{},
+	}
+
+	cloudStacksByID := map[string]cloud.StackObject{}
+	for _, st := range cloudStacks {
+		cloudStacksByID[strings.ToLower(st.MetaID)] = st
+	}
+
+	seen := map[string]struct{}{}
+	for _, elem := range localStacks {
+		st := elem.Stack
+		if st.ID == "" {
+			// stacks without ID cannot be synced to Terramate Cloud.
+			continue
+		}
+		id := strings.ToLower(st.ID)
+		cloudStack, ok := cloudStacksByID[id]
+		if !ok {
+			mapping.LocalOnly = append(mapping.LocalOnly, cloudStackEntry{
+				MetaID: st.ID,
+				Path:   st.Dir.String(),
+			})
+			continue
+		}
+		seen[id] = struct{}{}
+		entry := newCloudStackEntry(cloudStack)
+		entry.Path = st.Dir.String()
+		if cloudStack.Path != entry.Path {
+			entry.CloudPath = cloudStack.Path
+		}
+		mapping.Matched = append(mapping.Matched, entry)
+	}
+
+	for _, st := range cloudStacks {
+		if _, ok := seen[strings.ToLower(st.MetaID)]; ok {
+			continue
+		}
+		mapping.CloudOnly = append(mapping.CloudOnly, newCloudStackEntry(st))
+	}
+
+	for _, entries := range [][]cloudStackEntry{mapping.Matched, mapping.CloudOnly, mapping.LocalOnly} {
+		sort.Slice(entries, func(i, j int) bool {
+			return entries[i].Path < entries[j].Path
+		})
+	}
+
+	if args.AsJSON {
+		data, err := stdjson.MarshalIndent(mapping, "", "  ")
+		if err != nil {
+			fatalWithDetailf(err, "encoding cloud stacks")
+		}
+		c.output.MsgStdOut("%s", data)
+		return
+	}
+
+	if len(mapping.Matched) == 0 && len(mapping.CloudOnly) == 0 && len(mapping.LocalOnly) == 0 {
+		c.output.MsgStdOut("No stacks found.")
+		return
+	}
+
+	if len(mapping.Matched) > 0 {
+		c.output.MsgStdOut("Matched stacks:")
+		for _, entry := range mapping.Matched {
+			line := entry.String()
+			if entry.CloudPath != "" {
+				line += " cloud_path=" + entry.CloudPath
+			}
+			c.output.MsgStdOut("\t%s", line)
+		}
+	}
+	if len(mapping.CloudOnly) > 0 {
+		c.output.MsgStdOut("Cloud-only stacks:")
+		for _, entry := range mapping.CloudOnly {
+			c.output.MsgStdOut("\t%s", entry)
+		}
+	}
+	if len(mapping.LocalOnly) > 0 {
+		c.output.MsgStdOut("Local-only stacks (never synced):")
+		for _, entry := range mapping.LocalOnly {
+			c.output.MsgStdOut("\t%s (id: %s)", entry.Path, entry.MetaID)
+		}
+	}
+}
+
+func newCloudStackEntry(st cloud.StackObject) cloudStackEntry {
+	return cloudStackEntry{
+		MetaID:           st.MetaID,
+		Path:             st.Path,
+		Status:           st.Status.String(),
+		DeploymentStatus: st.DeploymentStatus.String(),
+		DriftStatus:      st.DriftStatus.String(),
+	}
+}
+
+func (e cloudStackEntry) String() string {
+	return e.Path + " (id: " + e.MetaID + ") status=" + e.Status +
+		" deployment=" + e.DeploymentStatus + " drift=" + e.DriftStatus
+}
@@ -90,6 +90,8 @@ func (c *cli) createCloudDeployment(deployRuns []stackCloudRun) {
 				FromTarget:      run.Task.CloudFromTarget,
 				DefaultBranch:   c.prj.gitcfg().DefaultBranch,
 				Path:            run.Stack.Dir.String(),
+				CustomMetadata:  c.cloud.run.stackMetadata(run.Stack.ID),
+				ChangeReason:    c.cloud.run.stackChangeReason(run.Stack.ID),
 			},
 			CommitSHA:         deploymentCommitSHA,
 			DeploymentCommand: strings.Join(run.Task.Cmd, " "),
@@ -102,15 +104,17 @@ func (c *cli) createCloudDeployment(deployRuns []stackCloudRun) {
 			Err(err).
 			Msg("failed to create cloud deployment")
 
+		c.addCloudSyncFailures(deployRuns, err)
 		c.disableCloudFeatures(cloudError())
 		return
 	}
 
 	if len(res) != len(deployRuns) {
-		logger.Error().
-			Msgf("the backend respond with an invalid number of stacks in the deployment: %d instead of %d",
-				len(res), len(deployRuns))
+		err := errors.E("the backend respond with an invalid number of stacks in the deployment: %d instead of %d",
+			len(res), len(deployRuns))
+		logger.Error().Msg(err.Error())
 
+		c.addCloudSyncFailures(deployRuns, err)
 		c.disableCloudFeatures(cloudError())
 		return
 	}
@@ -121,6 +125,7 @@ func (c *cli) createCloudDeployment(deployRuns []stackCloudRun) {
 			logger.Error().
 				Msg("backend returned empty meta_id")
 
+			c.addCloudSyncFailures(deployRuns, errors.E("backend returned empty meta_id"))
 			c.disableCloudFeatures(cloudError())
 			return
 		}
@@ -154,6 +159,7 @@ func (c *cli) doCloudSyncDeployment(run stackCloudRun, status deployment.Status)
 	stackID, ok := c.cloud.run.stackCloudID(run.Stack.ID)
 	if !ok {
 		logger.Error().Msg("unable to update deployment status due to invalid API response")
+		c.addCloudSyncFailure(run, errors.E("stack is missing in the deployment"))
 		return
 	}
 
@@ -184,6 +190,7 @@ func (c *cli) doCloudSyncDeployment(run stackCloudRun, status deployment.Status)
 	err := c.cloud.client.UpdateDeploymentStacks(ctx, c.cloud.run.orgUUID, c.cloud.run.runUUID, payload)
 	if err != nil {
 		logger.Err(err).Str("stack_id", run.Stack.ID).Msg("failed to update deployment status for each")
+		c.addCloudSyncFailure(run, err)
 	} else {
 		logger.Debug().Msg("deployment status synced successfully")
 	}
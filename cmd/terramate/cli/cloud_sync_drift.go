@@ -8,6 +8,7 @@ import (
 	"strings"
 
 	"github.com/rs/zerolog/log"
+	"github.com/terramate-io/terramate/ci"
 	"github.com/terramate-io/terramate/cloud"
 	"github.com/terramate-io/terramate/cloud/drift"
 	"github.com/terramate-io/terramate/cmd/terramate/cli/clitest"
@@ -67,8 +68,11 @@ func (c *cli) cloudSyncDriftStatus(run stackCloudRun, res runResult, err error)
 			MetaName:        st.Name,
 			MetaDescription: st.Description,
 			MetaTags:        st.Tags,
+			CustomMetadata:  c.cloud.run.stackMetadata(st.ID),
+			ChangeReason:    c.cloud.run.stackChangeReason(st.ID),
 		},
 		Status:     status,
+		Context:    c.driftContext(),
 		Details:    driftDetails,
 		Metadata:   c.cloud.run.metadata,
 		StartedAt:  res.StartedAt,
@@ -78,7 +82,21 @@ func (c *cli) cloudSyncDriftStatus(run stackCloudRun, res runResult, err error)
 
 	if err != nil {
 		logger.Error().Err(err).Msg(clitest.CloudSyncDriftFailedMessage)
+		c.addCloudSyncFailure(run, err)
 	} else {
 		logger.Debug().Msg("synced drift_status successfully")
 	}
 }
+
+// driftContext returns the context of the drift runs synchronized to Terramate
+// Cloud: the one set with --drift-context or, if not set, scheduled if the CI/CD
+// pipeline was triggered by a schedule and manual otherwise.
+func (c *cli) driftContext() drift.Context {
+	if c.parsedArgs.Run.DriftContext != "" {
+		return drift.Context(c.parsedArgs.Run.DriftContext)
+	}
+	if ci.IsScheduledEvent(c.prj.ciPlatform()) {
+		return drift.ContextScheduled
+	}
+	return drift.ContextManual
+}
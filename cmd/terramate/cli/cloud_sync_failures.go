@@ -0,0 +1,74 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package cli
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/terramate-io/terramate/cmd/terramate/cli/clitest"
+	"github.com/terramate-io/terramate/errors"
+	"github.com/terramate-io/terramate/printer"
+)
+
+// cloudSyncFailures records the stacks whose status could not be synchronized
+// to Terramate Cloud. It's safe to use from concurrent stack runs.
+type cloudSyncFailures struct {
+	mu     sync.Mutex
+	stacks map[string]error
+}
+
+// add records the failure of the stack. Only the first failure of each stack is
+// kept, as it's usually the cause of the subsequent ones.
+func (f *cloudSyncFailures) add(stack string, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.stacks == nil {
+		f.stacks = make(map[string]error)
+	}
+	if _, ok := f.stacks[stack]; !ok {
+		f.stacks[stack] = err
+	}
+}
+
+// list returns the failures sorted by the stack path.
+func (f *cloudSyncFailures) list() []error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	stacks := make([]string, 0, len(f.stacks))
+	for stack := range f.stacks {
+		stacks = append(stacks, stack)
+	}
+	sort.Strings(stacks)
+	errs := make([]error, 0, len(stacks))
+	for _, stack := range stacks {
+		errs = append(errs, errors.E(f.stacks[stack], "stack %s", stack))
+	}
+	return errs
+}
+
+func (c *cli) addCloudSyncFailure(run stackCloudRun, err error) {
+	c.cloud.syncFailures.add(run.Stack.Dir.String(), err)
+}
+
+// reportCloudSyncFailures warns about the stacks whose status could not be
+// synchronized to Terramate Cloud. The failures don't change the exit code of
+// the command unless failOnCloudError is set, in which case an error is returned.
+func (c *cli) reportCloudSyncFailures(failOnCloudError bool) error {
+	errs := c.cloud.syncFailures.list()
+	if len(errs) == 0 {
+		return nil
+	}
+	printer.Stderr.WarnWithDetails(clitest.CloudSyncStacksFailedMessage, errors.L(errs...))
+	if !failOnCloudError {
+		return nil
+	}
+	return errors.E(clitest.ErrCloudSyncFailed, "%d stack(s) could not be synchronized", len(errs))
+}
+
+func (c *cli) addCloudSyncFailures(runs []stackCloudRun, err error) {
+	for _, run := range runs {
+		c.addCloudSyncFailure(run, err)
+	}
+}
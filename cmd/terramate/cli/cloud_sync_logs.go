@@ -0,0 +1,139 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package cli
+
+import (
+	"context"
+	"io"
+	"regexp"
+
+	"github.com/alecthomas/kong"
+	"github.com/rs/zerolog"
+	"github.com/terramate-io/terramate/cloud"
+	"github.com/terramate-io/terramate/errors"
+	"github.com/terramate-io/terramate/hcl"
+)
+
+// syncLogsMode is the value of the --sync-logs flag. The flag can be given
+// without a value, which uploads the logs of the failed stacks, or as
+// --sync-logs=all to upload the logs of all stacks.
+type syncLogsMode string
+
+// Decode implements the kong.MapperValue interface.
+func (m *syncLogsMode) Decode(ctx *kong.DecodeContext) error {
+	*m = hcl.CloudLogsSyncFailed
+	token := ctx.Scan.Peek()
+	if token.Type != kong.FlagValueToken {
+		return nil
+	}
+	ctx.Scan.Pop()
+	value, ok := token.Value.(string)
+	if !ok {
+		return errors.E("--sync-logs expects a string value but got %v", token.Value)
+	}
+	switch value {
+	case hcl.CloudLogsSyncFailed, hcl.CloudLogsSyncAll:
+		*m = syncLogsMode(value)
+	default:
+		return errors.E("--sync-logs must be %q or %q but given %q",
+			hcl.CloudLogsSyncFailed, hcl.CloudLogsSyncAll, value)
+	}
+	return nil
+}
+
+// IsBool implements the kong.BoolMapper interface, so the flag can be given
+// without a value.
+func (m *syncLogsMode) IsBool() bool { return true }
+
+// syncLogsMode returns which deployment stacks have their logs uploaded after
+// the command finishes, given by the --sync-logs flag or, if not set, by the
+// terramate.config.cloud.logs.sync option. It returns an empty string if the
+// logs are streamed while the command runs.
+func (c *cli) syncLogsMode(flag syncLogsMode) string {
+	if flag != "" {
+		return string(flag)
+	}
+	if cfg := c.cfg().CloudLogsConfig(); cfg != nil {
+		return cfg.Sync
+	}
+	return ""
+}
+
+// cloudLogRedactPatterns returns the compiled terramate.config.cloud.logs.redact
+// patterns.
+func (c *cli) cloudLogRedactPatterns() []*regexp.Regexp {
+	cfg := c.cfg().CloudLogsConfig()
+	if cfg == nil {
+		return nil
+	}
+	patterns := make([]*regexp.Regexp, 0, len(cfg.Redact))
+	for _, pattern := range cfg.Redact {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			fatalWithDetailf(errors.E(err, "compiling terramate.config.cloud.logs.redact pattern %q", pattern),
+				"Invalid configuration")
+		}
+		patterns = append(patterns, re)
+	}
+	return patterns
+}
+
+// newStackLogSyncer wraps the stdout and stderr of the command of the run with
+// a log syncer. The logs are streamed to Terramate Cloud while the command
+// runs, unless the task is a deployment and opts.SyncLogs is set, then the
+// last cloud.DefaultLogTailSize bytes of the output are kept and uploaded when
+// the returned wait function is called, for failed stacks or for all the stacks
+// if the mode is hcl.CloudLogsSyncAll. The lines matching the redact patterns
+// are never synchronized.
+func (c *cli) newStackLogSyncer(
+	logger *zerolog.Logger,
+	run stackRun,
+	task stackRunTask,
+	opts runAllOptions,
+	stdout, stderr io.Writer,
+) (io.Writer, io.Writer, func(failed bool)) {
+	if !task.CloudSyncDeployment || opts.SyncLogs == "" {
+		logSyncer := cloud.NewLogSyncer(cloud.Redact(opts.LogRedact, func(logs cloud.CommandLogs) {
+			c.syncLogs(logger, run, logs)
+		}))
+		stdout = logSyncer.NewBuffer(cloud.StdoutLogChannel, stdout)
+		stderr = logSyncer.NewBuffer(cloud.StderrLogChannel, stderr)
+		return stdout, stderr, func(bool) { logSyncer.Wait() }
+	}
+
+	tail := cloud.NewLogTail(cloud.DefaultLogTailSize)
+	logSyncer := cloud.NewLogSyncer(cloud.Redact(opts.LogRedact, tail.Append))
+	stdout = logSyncer.NewBuffer(cloud.StdoutLogChannel, stdout)
+	stderr = logSyncer.NewBuffer(cloud.StderrLogChannel, stderr)
+	return stdout, stderr, func(failed bool) {
+		logSyncer.Wait()
+		if failed || opts.SyncLogs == hcl.CloudLogsSyncAll {
+			c.uploadDeploymentLogs(logger, run, tail.Logs())
+		}
+	}
+}
+
+func (c *cli) uploadDeploymentLogs(logger *zerolog.Logger, run stackRun, logs cloud.CommandLogs) {
+	if len(logs) == 0 {
+		return
+	}
+
+	c.cloud.syncGate.wait()
+
+	stackID, ok := c.cloud.run.stackCloudID(run.Stack.ID)
+	if !ok {
+		logger.Warn().Msg("failed to upload logs: stack not synchronized with Terramate Cloud")
+		return
+	}
+
+	logger.Debug().Int("lines", len(logs)).Msg("uploading deployment logs")
+	ctx, cancel := context.WithTimeout(context.Background(), defaultCloudTimeout)
+	defer cancel()
+	err := c.cloud.client.UploadDeploymentLogs(
+		ctx, c.cloud.run.orgUUID, stackID, c.cloud.run.runUUID, logs,
+	)
+	if err != nil {
+		logger.Warn().Err(err).Msg("failed to upload logs")
+	}
+}
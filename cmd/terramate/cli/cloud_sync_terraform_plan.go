@@ -50,6 +50,10 @@ func (c *cli) getTerraformChangeset(run stackCloudRun) (*cloud.ChangesetDetails,
 	// Terragrunt writes the plan to a temporary directory, so we cannot check for its existence.
 	if !run.Task.UseTerragrunt {
 		_, err := os.Lstat(absPlanFilePath)
+		if os.IsNotExist(err) && run.Task.CloudPlanFileHint != "" {
+			return nil, errors.E(clitest.ErrCloudTerraformPlanFile,
+				"plan file %s not found in stack %s: %s", planfile, run.Stack.Dir, run.Task.CloudPlanFileHint)
+		}
 		if err != nil {
 			return nil, errors.E(err, "checking plan file")
 		}
@@ -0,0 +1,122 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/terramate-io/go-checkpoint"
+	"github.com/terramate-io/terramate/cmd/terramate/cli/cliconfig"
+	"github.com/terramate-io/terramate/hcl"
+)
+
+// checkpointDisableEnv disables checkpoint when set to any value.
+const checkpointDisableEnv = "CHECKPOINT_DISABLE"
+
+// setting is the effective value of a CLI setting and the source defining it.
+type setting struct {
+	name    string
+	enabled bool
+	source  string
+}
+
+// telemetrySetting returns the effective telemetry setting. Disabling it in
+// the user CLI configuration takes precedence over the project configuration.
+func telemetrySetting(clicfg cliconfig.Config, rootcfg hcl.Config) setting {
+	s := setting{name: "telemetry.enabled"}
+	if clicfg.DisableTelemetry {
+		s.source = userConfigSource(clicfg, "disable_telemetry")
+		return s
+	}
+	if cfg := rootcfg.Terramate; cfg != nil && cfg.Config != nil &&
+		cfg.Config.Telemetry != nil && cfg.Config.Telemetry.Enabled != nil {
+		s.enabled = *cfg.Config.Telemetry.Enabled
+		s.source = "project config terramate.config.telemetry.enabled"
+		return s
+	}
+	s.enabled = true
+	s.source = "default"
+	return s
+}
+
+// checkpointSetting returns the effective checkpoint setting. The command
+// line flag, the environment and the user CLI configuration can only disable
+// checkpoint, taking precedence over the project configuration.
+func checkpointSetting(disableFlag bool, clicfg cliconfig.Config, rootcfg hcl.Config) setting {
+	s := setting{name: "checkpoint.enabled"}
+	if disableFlag {
+		s.source = "flag --disable-checkpoint"
+		return s
+	}
+	if os.Getenv(checkpointDisableEnv) != "" {
+		s.source = "env " + checkpointDisableEnv
+		return s
+	}
+	if clicfg.DisableCheckpoint {
+		s.source = userConfigSource(clicfg, "disable_checkpoint")
+		return s
+	}
+	if cfg := rootcfg.Terramate; cfg != nil && cfg.Config != nil &&
+		cfg.Config.Checkpoint != nil && cfg.Config.Checkpoint.Enabled != nil {
+		s.enabled = *cfg.Config.Checkpoint.Enabled
+		s.source = "project config terramate.config.checkpoint.enabled"
+		return s
+	}
+	s.enabled = true
+	s.source = "default"
+	return s
+}
+
+func userConfigSource(clicfg cliconfig.Config, attr string) string {
+	return fmt.Sprintf("user config %s (%s)", clicfg.Filename, attr)
+}
+
+// startCheckpoint checks for new versions in the background. When checkpoint
+// is disabled no goroutine is started, so no network call is attempted, and
+// the returned channel holds a nil response.
+func startCheckpoint(version string, clicfg cliconfig.Config, cp setting) chan *checkpoint.CheckResponse {
+	results := make(chan *checkpoint.CheckResponse, 1)
+	if !cp.enabled {
+		results <- nil
+		return results
+	}
+	go runCheckpoint(version, clicfg, results)
+	return results
+}
+
+// lookupRootConfig parses the configuration of the root directory of the
+// project containing wd, for commands which don't load the project. An empty
+// configuration is returned if there is no project or it can't be parsed.
+func lookupRootConfig(wd string) hcl.Config {
+	rootdir, found := lookupProjectRoot(wd)
+	if !found {
+		return hcl.Config{}
+	}
+	cfg, err := hcl.ParseDir(rootdir, rootdir)
+	if err != nil {
+		return hcl.Config{}
+	}
+	return cfg
+}
+
+func (c *cli) printConfig() {
+	userConfig := "none"
+	if c.clicfg.Filename != "" {
+		userConfig = c.clicfg.Filename
+	}
+	if os.Getenv(cliconfig.PathEnv) != "" {
+		userConfig += " (env " + cliconfig.PathEnv + ")"
+	}
+	c.output.MsgStdOut("user config: %s", userConfig)
+	c.output.MsgStdOut("project root: %s", filepath.ToSlash(c.rootdir()))
+
+	for _, s := range []setting{
+		telemetrySetting(c.clicfg, c.rootNode()),
+		checkpointSetting(c.parsedArgs.DisableCheckpoint, c.clicfg, c.rootNode()),
+	} {
+		c.output.MsgStdOut("%s = %t (%s)", s.name, s.enabled, s.source)
+	}
+}
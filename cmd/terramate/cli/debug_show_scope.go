@@ -0,0 +1,139 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package cli
+
+import (
+	stdjson "encoding/json"
+	"strings"
+
+	"github.com/terramate-io/terramate/config"
+	"github.com/terramate-io/terramate/globals"
+	"github.com/terramate-io/terramate/hcl/ast"
+)
+
+type scopeLevelJSON struct {
+	Dir      string             `json:"dir"`
+	Files    []string           `json:"files"`
+	Imports  []scopeElementJSON `json:"imports"`
+	Globals  []scopeElementJSON `json:"globals"`
+	Env      []scopeElementJSON `json:"env"`
+	Scripts  []scopeElementJSON `json:"scripts"`
+	Generate []scopeElementJSON `json:"generate"`
+	Config   []scopeElementJSON `json:"config"`
+}
+
+type scopeElementJSON struct {
+	Name       string `json:"name"`
+	Value      string `json:"value,omitempty"`
+	Origin     string `json:"origin"`
+	Overridden bool   `json:"overridden,omitempty"`
+}
+
+func (c *cli) printScope() {
+	args := c.parsedArgs.Debug.Show.Scope
+
+	path := args.Path
+	if path == "" {
+		path = c.wd()
+	}
+	dir := c.projectPath(path)
+
+	levels, err := config.Scope(c.cfg(), dir)
+	if err != nil {
+		fatalWithDetailf(err, "loading the scope of %s", dir)
+	}
+
+	sensitive := c.sensitiveGlobals(args.ShowSensitive)
+
+	// value returns the expression of the element, if requested. Imports are
+	// always shown because their source is what identifies them.
+	value := func(elem config.ScopeElement, always bool, global bool) string {
+		if elem.Expr == nil || (!args.Values && !always) {
+			return ""
+		}
+		if global && sensitive.Match(strings.Split(strings.TrimPrefix(elem.Name, "global."), ".")) {
+			return globals.SensitiveValue
+		}
+		if sensitive.MatchExpr(elem.Expr) {
+			return globals.SensitiveValue
+		}
+		return string(ast.TokensForExpression(elem.Expr).Bytes())
+	}
+	elements := func(elems []config.ScopeElement, always bool, global bool) []scopeElementJSON {
+		res := []scopeElementJSON{}
+		for _, elem := range elems {
+			res = append(res, scopeElementJSON{
+				Name:       elem.Name,
+				Value:      value(elem, always, global),
+				Origin:     elem.Origin.String(),
+				Overridden: elem.Overridden,
+			})
+		}
+		return res
+	}
+
+	var res []scopeLevelJSON
+	for _, level := range levels {
+		files := []string{}
+		for _, file := range level.Files {
+			files = append(files, file.String())
+		}
+		res = append(res, scopeLevelJSON{
+			Dir:      level.Dir.String(),
+			Files:    files,
+			Imports:  elements(level.Imports, true, false),
+			Globals:  elements(level.Globals, false, true),
+			Env:      elements(level.Env, false, false),
+			Scripts:  elements(level.Scripts, false, false),
+			Generate: elements(level.Generate, false, false),
+			Config:   elements(level.Config, false, false),
+		})
+	}
+
+	if args.Format == "json" {
+		data, err := stdjson.MarshalIndent(res, "", "  ")
+		if err != nil {
+			fatalWithDetailf(err, "encoding the scope of %s", dir)
+		}
+		c.output.MsgStdOut("%s", data)
+		return
+	}
+
+	printSection := func(title string, elems []scopeElementJSON) {
+		if len(elems) == 0 {
+			return
+		}
+		c.output.MsgStdOut("\t%s:", title)
+		for _, elem := range elems {
+			line := elem.Name
+			if elem.Value != "" {
+				line += " = " + elem.Value
+			}
+			line += " (" + elem.Origin + ")"
+			if elem.Overridden {
+				line += " [overridden]"
+			}
+			c.output.MsgStdOut("\t\t%s", line)
+		}
+	}
+
+	for i, level := range res {
+		if i > 0 {
+			c.output.MsgStdOut("")
+		}
+		c.output.MsgStdOut("scope %q:", level.Dir)
+		if len(level.Files) > 0 {
+			c.output.MsgStdOut("\tfiles:")
+			for _, file := range level.Files {
+				c.output.MsgStdOut("\t\t%s", file)
+			}
+		}
+		printSection("imports", level.Imports)
+		printSection("globals", level.Globals)
+		printSection("env", level.Env)
+		printSection("scripts", level.Scripts)
+		printSection("generate", level.Generate)
+		printSection("config", level.Config)
+	}
+}
@@ -0,0 +1,87 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package cli
+
+import (
+	"os"
+	"strings"
+
+	"github.com/terramate-io/terramate/errors"
+	"github.com/terramate-io/terramate/errors/verbosity"
+	"github.com/terramate-io/terramate/printer"
+	prj "github.com/terramate-io/terramate/project"
+)
+
+// ExitStatusNoStacksSelected is the exit status of `terramate run`,
+// `terramate script run` and `terramate list` when --error-on-empty is set
+// and no stacks are selected, or the script is disabled in all of them.
+const ExitStatusNoStacksSelected = 3
+
+// selectionFilters are the filters which selected the stacks of a command.
+type selectionFilters struct {
+	cloudFilterFlags
+	pathFilterFlags
+	stackIDFilterFlags
+	Target string
+}
+
+// exitOnEmptySelection prints a summary of the filters which selected no
+// stacks and exits with ExitStatusNoStacksSelected.
+func (c *cli) exitOnEmptySelection(filters selectionFilters) {
+	derr := errors.D("no stacks selected")
+	for _, detail := range c.selectionSummary(filters) {
+		derr = derr.WithDetail(verbosity.V0, detail)
+	}
+	printer.Stderr.Error(derr)
+	os.Exit(ExitStatusNoStacksSelected)
+}
+
+// exitOnDisabledScripts reports that the script is disabled in all the
+// selected stacks, so nothing runs, and exits with ExitStatusNoStacksSelected.
+func (c *cli) exitOnDisabledScripts(labels []string, stacks int) {
+	printer.Stderr.Error(errors.D("script %q is disabled in all the %d selected stack(s)",
+		strings.Join(labels, " "), stacks))
+	os.Exit(ExitStatusNoStacksSelected)
+}
+
+func (c *cli) selectionSummary(filters selectionFilters) []string {
+	summary := []string{
+		"working directory: " + prj.PrjAbsPath(c.rootdir(), c.wd()).String(),
+	}
+	if c.parsedArgs.Changed {
+		summary = append(summary, "--changed against "+c.baseRef())
+	}
+	for _, tags := range c.parsedArgs.Tags {
+		summary = append(summary, "--tags "+tags)
+	}
+	if len(c.parsedArgs.NoTags) > 0 {
+		summary = append(summary, "--no-tags "+strings.Join(c.parsedArgs.NoTags, ","))
+	}
+	for _, include := range filters.Include {
+		summary = append(summary, "--include "+include)
+	}
+	for _, exclude := range filters.Exclude {
+		summary = append(summary, "--exclude "+exclude)
+	}
+	for _, id := range filters.StackID {
+		summary = append(summary, "--stack-id "+id)
+	}
+	status := filters.Status
+	if status == "" {
+		status = filters.ExperimentalStatus
+	}
+	if status != "" {
+		summary = append(summary, "--status "+status)
+	}
+	if filters.DeploymentStatus != "" {
+		summary = append(summary, "--deployment-status "+filters.DeploymentStatus)
+	}
+	if filters.DriftStatus != "" {
+		summary = append(summary, "--drift-status "+filters.DriftStatus)
+	}
+	if filters.Target != "" {
+		summary = append(summary, "--target "+filters.Target)
+	}
+	return summary
+}
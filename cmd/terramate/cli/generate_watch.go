@@ -0,0 +1,90 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package cli
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/terramate-io/terramate/fs/watch"
+	"github.com/terramate-io/terramate/generate"
+	prj "github.com/terramate-io/terramate/project"
+)
+
+const watchDebounceDelay = 300 * time.Millisecond
+
+// generateWatch generates the code like the generate command and then watches
+// the project for changes, generating the code of the affected stacks again
+// until interrupted.
+func (c *cli) generateWatch() int {
+	interval := c.parsedArgs.Generate.WatchInterval
+	if interval <= 0 {
+		fatal("--watch-interval must be a positive duration")
+	}
+
+	poller, err := watch.NewPoller(c.cfg().HostDir(), nil)
+	if err != nil {
+		fatalWithDetailf(err, "watching the project")
+	}
+
+	watcher := generate.NewWatcher(c.cfg())
+	filter := c.generateStacks
+
+	c.generate()
+	watcher.Track(c.lastGenerateReport)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	errs := make(chan error, 1)
+	batches := watch.Debounce(ctx, watch.Events(ctx, poller, interval, errs), watchDebounceDelay)
+
+	c.output.MsgStdOut("\nWatching for changes, press Ctrl-C to stop.")
+
+	for batch := range batches {
+		affected, relevant, err := watcher.Affected(batch)
+		if err != nil {
+			c.output.MsgStdErr("Error: reloading the configuration: %v", err)
+			continue
+		}
+		if !relevant {
+			continue
+		}
+
+		c.output.MsgStdOut("\nChanges detected, generating the code of %d affected stack(s)\n", len(affected))
+
+		c.generateStacks = selectStacks(affected, filter)
+		c.generate()
+		watcher.Track(c.lastGenerateReport)
+	}
+
+	select {
+	case err := <-errs:
+		fatalWithDetailf(err, "watching the project")
+	default:
+	}
+
+	log.Debug().Msg("watch mode interrupted")
+	return 0
+}
+
+// selectStacks returns the stacks also selected by the filter, if any.
+func selectStacks(stacks prj.Paths, filter prj.Paths) prj.Paths {
+	if filter == nil {
+		return stacks
+	}
+	selected := prj.Paths{}
+	for _, stack := range stacks {
+		for _, dir := range filter {
+			if stack == dir {
+				selected = append(selected, stack)
+				break
+			}
+		}
+	}
+	return selected
+}
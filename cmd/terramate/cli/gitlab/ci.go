@@ -0,0 +1,53 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package gitlab
+
+import (
+	"os"
+	"strconv"
+	"strings"
+)
+
+// MRFromCIEnv returns the Merge Request of the Gitlab CI/CD merge request
+// pipeline, built from the predefined CI_MERGE_REQUEST_* variables.
+// It returns false if the pipeline is not a merge request pipeline.
+//
+// The variables don't provide the creation and update times of the
+// Merge Request, so they are left empty.
+func MRFromCIEnv() (MR, bool) {
+	iid, err := strconv.Atoi(os.Getenv("CI_MERGE_REQUEST_IID"))
+	if err != nil {
+		return MR{}, false
+	}
+
+	mr := MR{
+		IID:          iid,
+		Title:        os.Getenv("CI_MERGE_REQUEST_TITLE"),
+		Description:  os.Getenv("CI_MERGE_REQUEST_DESCRIPTION"),
+		State:        "opened",
+		SourceBranch: os.Getenv("CI_MERGE_REQUEST_SOURCE_BRANCH_NAME"),
+		TargetBranch: os.Getenv("CI_MERGE_REQUEST_TARGET_BRANCH_NAME"),
+		Draft:        os.Getenv("CI_MERGE_REQUEST_DRAFT") == "true",
+		// in merged results pipelines CI_COMMIT_SHA is the merge commit.
+		SHA: os.Getenv("CI_MERGE_REQUEST_SOURCE_BRANCH_SHA"),
+		Author: User{
+			Username: os.Getenv("GITLAB_USER_LOGIN"),
+			Name:     os.Getenv("GITLAB_USER_NAME"),
+		},
+	}
+	if mr.SHA == "" {
+		mr.SHA = os.Getenv("CI_COMMIT_SHA")
+	}
+	mr.ID, _ = strconv.Atoi(os.Getenv("CI_MERGE_REQUEST_ID"))
+	mr.ProjectID, _ = strconv.Atoi(os.Getenv("CI_MERGE_REQUEST_PROJECT_ID"))
+	mr.Author.ID, _ = strconv.Atoi(os.Getenv("GITLAB_USER_ID"))
+
+	if projectURL := os.Getenv("CI_MERGE_REQUEST_PROJECT_URL"); projectURL != "" {
+		mr.WebURL = strings.TrimSuffix(projectURL, "/") + "/-/merge_requests/" + strconv.Itoa(iid)
+	}
+	if labels := os.Getenv("CI_MERGE_REQUEST_LABELS"); labels != "" {
+		mr.Labels = strings.Split(labels, ",")
+	}
+	return mr, true
+}
@@ -0,0 +1,121 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package gitlab_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/terramate-io/terramate/cmd/terramate/cli/gitlab"
+)
+
+func TestMRFromCIEnv(t *testing.T) {
+	type testcase struct {
+		name  string
+		env   map[string]string
+		want  gitlab.MR
+		found bool
+	}
+
+	for _, tc := range []testcase{
+		{
+			name: "not a merge request pipeline",
+			env: map[string]string{
+				"CI_COMMIT_BRANCH": "main",
+				"CI_COMMIT_SHA":    "deadbeef",
+			},
+		},
+		{
+			name: "merge request pipeline",
+			env: map[string]string{
+				"CI_MERGE_REQUEST_ID":                 "1000",
+				"CI_MERGE_REQUEST_IID":                "42",
+				"CI_MERGE_REQUEST_PROJECT_ID":         "7",
+				"CI_MERGE_REQUEST_PROJECT_URL":        "https://gitlab.com/terramate-io/terramate",
+				"CI_MERGE_REQUEST_TITLE":              "Amazing new feature",
+				"CI_MERGE_REQUEST_DESCRIPTION":        "Please merge these awesome changes in!",
+				"CI_MERGE_REQUEST_SOURCE_BRANCH_NAME": "new-topic",
+				"CI_MERGE_REQUEST_TARGET_BRANCH_NAME": "main",
+				"CI_MERGE_REQUEST_SOURCE_BRANCH_SHA":  "cafebabe",
+				"CI_MERGE_REQUEST_LABELS":             "bug,enhancement",
+				"CI_MERGE_REQUEST_DRAFT":              "true",
+				"CI_COMMIT_SHA":                       "deadbeef",
+				"GITLAB_USER_ID":                      "1",
+				"GITLAB_USER_LOGIN":                   "octocat",
+				"GITLAB_USER_NAME":                    "Octo Cat",
+			},
+			want: gitlab.MR{
+				ID:           1000,
+				IID:          42,
+				ProjectID:    7,
+				Title:        "Amazing new feature",
+				Description:  "Please merge these awesome changes in!",
+				State:        "opened",
+				SourceBranch: "new-topic",
+				TargetBranch: "main",
+				Labels:       []string{"bug", "enhancement"},
+				Draft:        true,
+				SHA:          "cafebabe",
+				WebURL:       "https://gitlab.com/terramate-io/terramate/-/merge_requests/42",
+				Author: gitlab.User{
+					ID:       1,
+					Username: "octocat",
+					Name:     "Octo Cat",
+				},
+			},
+			found: true,
+		},
+		{
+			name: "commit sha fallback",
+			env: map[string]string{
+				"CI_MERGE_REQUEST_IID":                "42",
+				"CI_MERGE_REQUEST_SOURCE_BRANCH_NAME": "new-topic",
+				"CI_MERGE_REQUEST_TARGET_BRANCH_NAME": "main",
+				"CI_COMMIT_SHA":                       "deadbeef",
+			},
+			want: gitlab.MR{
+				IID:          42,
+				State:        "opened",
+				SourceBranch: "new-topic",
+				TargetBranch: "main",
+				SHA:          "deadbeef",
+			},
+			found: true,
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			for _, name := range []string{
+				"CI_MERGE_REQUEST_ID",
+				"CI_MERGE_REQUEST_IID",
+				"CI_MERGE_REQUEST_PROJECT_ID",
+				"CI_MERGE_REQUEST_PROJECT_URL",
+				"CI_MERGE_REQUEST_TITLE",
+				"CI_MERGE_REQUEST_DESCRIPTION",
+				"CI_MERGE_REQUEST_SOURCE_BRANCH_NAME",
+				"CI_MERGE_REQUEST_TARGET_BRANCH_NAME",
+				"CI_MERGE_REQUEST_SOURCE_BRANCH_SHA",
+				"CI_MERGE_REQUEST_LABELS",
+				"CI_MERGE_REQUEST_DRAFT",
+				"CI_COMMIT_SHA",
+				"GITLAB_USER_ID",
+				"GITLAB_USER_LOGIN",
+				"GITLAB_USER_NAME",
+			} {
+				t.Setenv(name, "")
+			}
+			for name, value := range tc.env {
+				t.Setenv(name, value)
+			}
+
+			got, found := gitlab.MRFromCIEnv()
+			if found != tc.found {
+				t.Fatalf("found = %t, want %t", found, tc.found)
+			}
+			if diff := cmp.Diff(got, tc.want); diff != "" {
+				t.Fatalf("unexpected MR (-got +want):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package cli
+
+import (
+	stdjson "encoding/json"
+
+	"github.com/terramate-io/terramate/config"
+	"github.com/terramate-io/terramate/errors"
+)
+
+// Formats of the list command.
+const (
+	listFormatText         = "text"
+	listFormatJSON         = "json"
+	listFormatGitHubMatrix = "github-matrix"
+)
+
+// defaultTarget is the deployment target of the stacks when the targets
+// feature is not enabled.
+const defaultTarget = "default"
+
+// stackListEntry is the JSON representation of a listed stack.
+type stackListEntry struct {
+	Stack  string `json:"stack"`
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Target string `json:"target"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// githubMatrix is a GitHub Actions matrix with a job for each stack.
+type githubMatrix struct {
+	Include []stackListEntry `json:"include"`
+}
+
+// printStacksListJSON prints the stacks with the given JSON based format.
+// The json format prints the stacks as an indented array and the
+// github-matrix format prints the matrix in a single line, so it can be
+// written as a step output.
+func (c *cli) printStacksListJSON(format string, stacks config.List[*config.SortableStack], reasons map[string]string, why bool) {
+	target := c.parsedArgs.List.Target
+	if target == "" {
+		target = defaultTarget
+	}
+
+	entries := make([]stackListEntry, len(stacks))
+	for i, s := range stacks {
+		entries[i] = stackListEntry{
+			Stack:  s.Dir().String(),
+			ID:     s.ID,
+			Name:   s.Name,
+			Target: target,
+		}
+		if why {
+			entries[i].Reason = reasons[s.ID]
+		}
+	}
+
+	var (
+		data []byte
+		err  error
+	)
+	switch format {
+	case listFormatJSON:
+		data, err = stdjson.MarshalIndent(entries, "", "  ")
+	case listFormatGitHubMatrix:
+		if limit := c.parsedArgs.List.Max; len(entries) > limit {
+			fatalWithDetailf(
+				errors.E("the matrix has %d stacks but the maximum is %d", len(entries), limit),
+				"Invalid GitHub Actions matrix",
+			)
+		}
+		data, err = stdjson.Marshal(githubMatrix{Include: entries})
+	default:
+		panic(errors.E(errors.ErrInternal, "unexpected list format %s", format))
+	}
+	if err != nil {
+		fatalWithDetailf(err, "encoding the list of stacks")
+	}
+	_, _ = c.stdout.Write(append(data, '\n'))
+}
@@ -0,0 +1,87 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package cli
+
+import (
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/terramate-io/terramate/generate"
+	"github.com/terramate-io/terramate/progress"
+)
+
+// emitProgress emits the event when --progress-format=ndjson is used.
+func (c *cli) emitProgress(ev progress.Event) {
+	if c.progress == nil {
+		return
+	}
+	if err := c.progress.Emit(ev); err != nil {
+		log.Debug().Err(err).Msg("failed to emit progress event")
+	}
+}
+
+// quiet tells if the human-readable messages of long-running commands must be
+// suppressed, which happens with --quiet or when emitting progress events.
+func (c *cli) quiet() bool {
+	return c.parsedArgs.Quiet || c.progress != nil
+}
+
+func (c *cli) emitOperationStart(operation string, stacks int) time.Time {
+	c.emitProgress(progress.Event{
+		Type:      progress.OperationStart,
+		Operation: operation,
+		Stacks:    stacks,
+	})
+	return time.Now()
+}
+
+func (c *cli) emitOperationEnd(operation string, startedAt time.Time, exitCode int, err error) {
+	ev := progress.Event{
+		Type:      progress.OperationEnd,
+		Operation: operation,
+	}
+	if err != nil {
+		ev.Error = err.Error()
+	}
+	c.emitProgress(ev.WithExitCode(exitCode).WithDuration(time.Since(startedAt)))
+}
+
+func (c *cli) emitGenerateReport(report *generate.Report) {
+	emitWarnings := func(res generate.Result) {
+		for _, warning := range res.Warnings {
+			c.emitProgress(progress.Event{
+				Type:    progress.GenerateWarning,
+				Dir:     res.Dir.String(),
+				Message: warning,
+			})
+		}
+	}
+	for _, res := range report.Successes {
+		emitWarnings(res)
+		for _, files := range []struct {
+			typ   progress.Type
+			names []string
+		}{
+			{progress.FileCreated, res.Created},
+			{progress.FileChanged, res.Changed},
+			{progress.FileDeleted, res.Deleted},
+		} {
+			for _, name := range files.names {
+				c.emitProgress(progress.Event{
+					Type: files.typ,
+					Dir:  res.Dir.String(),
+					File: name,
+				})
+			}
+		}
+	}
+	for _, res := range report.Failures {
+		c.emitProgress(progress.Event{
+			Type:  progress.GenerateFailure,
+			Dir:   res.Dir.String(),
+			Error: res.Error.Error(),
+		})
+		emitWarnings(res.Result)
+	}
+}
@@ -5,6 +5,9 @@ package cli
 
 import (
 	"fmt"
+	"slices"
+	"strconv"
+	"strings"
 
 	"github.com/rs/zerolog/log"
 	"github.com/terramate-io/terramate/ci"
@@ -32,15 +35,57 @@ type project struct {
 		localDefaultBranchCommit  string
 		remoteDefaultBranchCommit string
 
+		// remoteDefaultBranchFetched tells if the remote was already queried
+		// for the default branch commit. The remote is queried at most once
+		// per process and the failure, if any, is kept in remoteDefaultBranchErr.
+		remoteDefaultBranchFetched bool
+		remoteDefaultBranchErr     error
+
 		remoteConfigured bool
 		branchConfigured bool
 
+		// cfg is the git configuration with the detected defaults, set on
+		// the first use, and detectErr is the error detecting the default
+		// remote and branch not set in the configuration.
+		cfg       *hcl.GitConfig
+		detectErr error
+
 		repoChecks stack.RepoChecks
 	}
 }
 
-func (p project) gitcfg() *hcl.GitConfig {
-	return p.root.Tree().Node.Terramate.Config.Git
+// gitcfg returns the git configuration of the project, with the default
+// remote and branch resolved, see [project.resolveGitDefaults].
+func (p *project) gitcfg() *hcl.GitConfig {
+	_ = p.resolveGitDefaults()
+	return p.git.cfg
+}
+
+// resolveGitDefaults sets the default remote and branch which are not set in
+// terramate.config.git, detecting them from the repository. The detection runs
+// git, so it's only done on the first use and its result is reused.
+// It returns the detection error, if any, in which case the undetected values
+// are set to the defaults.
+func (p *project) resolveGitDefaults() error {
+	if p.git.cfg != nil {
+		return p.git.detectErr
+	}
+
+	gitOpt := p.root.Tree().Node.Terramate.Config.Git
+	if p.isRepo {
+		p.git.detectErr = p.detectGitDefaults(gitOpt)
+	}
+
+	if gitOpt.DefaultBranch == "" {
+		gitOpt.DefaultBranch = defaultBranch
+	}
+
+	if gitOpt.DefaultRemote == "" {
+		gitOpt.DefaultRemote = defaultRemote
+	}
+
+	p.git.cfg = gitOpt
+	return p.git.detectErr
 }
 
 func (p *project) repo() (*git.Repository, error) {
@@ -123,24 +168,28 @@ func (p *project) headCommit() string {
 	return val
 }
 
-func (p *project) remoteDefaultCommit() string {
-	if p.git.remoteDefaultBranchCommit != "" {
-		return p.git.remoteDefaultBranchCommit
+// remoteDefaultCommit returns the commit of the default branch in the default
+// remote. This is the only project operation making use of the network, so it
+// is done lazily and its result is memoized.
+func (p *project) remoteDefaultCommit() (string, error) {
+	if p.git.remoteDefaultBranchFetched {
+		return p.git.remoteDefaultBranchCommit, p.git.remoteDefaultBranchErr
 	}
 
+	p.git.remoteDefaultBranchFetched = true
+
 	gitcfg := p.gitcfg()
 	remoteRef, err := p.git.wrapper.FetchRemoteRev(gitcfg.DefaultRemote, gitcfg.DefaultBranch)
 	if err != nil {
-		fatalWithDetailf(
-			fmt.Errorf("fetching remote commit of %s/%s: %v",
-				gitcfg.DefaultRemote, gitcfg.DefaultBranch,
-				err,
-			),
-			"unable to fetch remote commit")
+		p.git.remoteDefaultBranchErr = fmt.Errorf("fetching remote commit of %s/%s: %v",
+			gitcfg.DefaultRemote, gitcfg.DefaultBranch,
+			err,
+		)
+		return "", p.git.remoteDefaultBranchErr
 	}
 
 	p.git.remoteDefaultBranchCommit = remoteRef.CommitID
-	return p.git.remoteDefaultBranchCommit
+	return p.git.remoteDefaultBranchCommit, nil
 }
 
 func (p *project) isDefaultBranch() bool {
@@ -159,11 +208,16 @@ func (p *project) isDefaultBranch() bool {
 
 // defaultBaseRef returns the baseRef for the current git environment.
 func (p *project) defaultBaseRef() string {
-	if p.isDefaultBranch() &&
-		p.remoteDefaultCommit() == p.headCommit() {
-		_, err := p.git.wrapper.RevParse(defaultBranchBaseRef)
-		if err == nil {
-			return defaultBranchBaseRef
+	if p.isDefaultBranch() {
+		remoteCommit, err := p.remoteDefaultCommit()
+		if err != nil {
+			fatalWithDetailf(err, "unable to fetch remote commit")
+		}
+		if remoteCommit == p.headCommit() {
+			_, err := p.git.wrapper.RevParse(defaultBranchBaseRef)
+			if err == nil {
+				return defaultBranchBaseRef
+			}
 		}
 	}
 	return p.defaultBranchRef()
@@ -181,7 +235,7 @@ func (p *project) defaultLocalBaseRef() string {
 	return git.DefaultBranch
 }
 
-func (p project) defaultBranchRef() string {
+func (p *project) defaultBranchRef() string {
 	git := p.gitcfg()
 	return git.DefaultRemote + "/" + git.DefaultBranch
 }
@@ -216,19 +270,99 @@ func (p *project) setDefaults() error {
 	gitOpt := cfg.Terramate.Config.Git
 
 	p.git.branchConfigured = gitOpt.DefaultBranch != ""
-	if !p.git.branchConfigured {
-		gitOpt.DefaultBranch = defaultBranch
+	p.git.remoteConfigured = gitOpt.DefaultRemote != ""
+	return nil
+}
+
+// detectGitDefaults detects from the repository the default remote and branch
+// which are not set in terramate.config.git.
+//
+// The default remote is the only remote of the repository, the "origin"
+// remote if there are many, or else the remote tracked by the current branch.
+// The default branch is the branch of refs/remotes/<remote>/HEAD, as set by
+// git clone, or else the "main" or "master" branch fetched from the remote.
+//
+// The values which cannot be detected are kept unset, so the defaults are
+// used, and an error is returned if the detection is ambiguous.
+func (p *project) detectGitDefaults(gitOpt *hcl.GitConfig) error {
+	gw := p.git.wrapper
+
+	if gitOpt.DefaultRemote == "" {
+		remotes, err := gw.RemoteNames()
+		if err != nil || len(remotes) == 0 {
+			return nil
+		}
+
+		switch {
+		case len(remotes) == 1:
+			gitOpt.DefaultRemote = remotes[0]
+		case slices.Contains(remotes, defaultRemote):
+			gitOpt.DefaultRemote = defaultRemote
+		default:
+			upstream, _, err := gw.Upstream()
+			if err != nil {
+				return errors.E(
+					"unable to detect the default remote: the repository has the remotes %s "+
+						"and the current branch has no upstream. "+
+						"Please set terramate.config.git.default_remote and terramate.config.git.default_branch",
+					quotedList(remotes),
+				)
+			}
+			gitOpt.DefaultRemote = upstream
+		}
 	}
 
-	p.git.remoteConfigured = gitOpt.DefaultRemote != ""
-	if !p.git.remoteConfigured {
-		gitOpt.DefaultRemote = defaultRemote
+	if gitOpt.DefaultBranch != "" {
+		return nil
 	}
 
-	return nil
+	if branch, err := gw.RemoteHEAD(gitOpt.DefaultRemote); err == nil {
+		gitOpt.DefaultBranch = branch
+		return nil
+	}
+
+	remotes, err := gw.Remotes()
+	if err != nil {
+		return nil
+	}
+
+	var candidates []string
+	for _, remote := range remotes {
+		if remote.Name != gitOpt.DefaultRemote {
+			continue
+		}
+		for _, branch := range []string{defaultBranch, "master"} {
+			if slices.Contains(remote.Branches, branch) {
+				candidates = append(candidates, branch)
+			}
+		}
+	}
+
+	switch len(candidates) {
+	case 0:
+		return nil
+	case 1:
+		gitOpt.DefaultBranch = candidates[0]
+		return nil
+	default:
+		return errors.E(
+			"unable to detect the default branch: the remote %q has the branches %s "+
+				"but refs/remotes/%s/HEAD is not set. "+
+				"Please set terramate.config.git.default_branch or run 'git remote set-head %s --auto'",
+			gitOpt.DefaultRemote, quotedList(candidates), gitOpt.DefaultRemote, gitOpt.DefaultRemote,
+		)
+	}
 }
 
-func (p project) checkDefaultRemote() error {
+func quotedList(values []string) string {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = strconv.Quote(v)
+	}
+	return strings.Join(quoted, ", ")
+}
+
+func (p *project) checkDefaultRemote() error {
 	remotes, err := p.git.wrapper.Remotes()
 	if err != nil {
 		return fmt.Errorf("checking if remote %q exists: %v", defaultRemote, err)
@@ -270,10 +404,15 @@ func (p *project) checkRemoteDefaultBranchIsReachable() error {
 
 	remoteDesc := fmt.Sprintf("remote(%s/%s)", gitcfg.DefaultRemote, gitcfg.DefaultBranch)
 
+	remoteCommit, err := p.remoteDefaultCommit()
+	if err != nil {
+		return err
+	}
+
 	logger := log.With().
 		Str("head_hash", p.headCommit()).
 		Str("default_branch", remoteDesc).
-		Str("default_hash", p.remoteDefaultCommit()).
+		Str("default_hash", remoteCommit).
 		Logger()
 
 	outOfDateErr := errors.E(
@@ -281,14 +420,14 @@ func (p *project) checkRemoteDefaultBranchIsReachable() error {
 		"Please update the current branch with the latest changes from the default branch.",
 	)
 
-	mergeBaseCommitID, err := p.git.wrapper.MergeBase(p.headCommit(), p.remoteDefaultCommit())
+	mergeBaseCommitID, err := p.git.wrapper.MergeBase(p.headCommit(), remoteCommit)
 	if err != nil {
 		logger.Debug().
 			Msg("A common merge-base can not be determined between HEAD and default branch")
 		return outOfDateErr
 	}
 
-	if mergeBaseCommitID != p.remoteDefaultCommit() {
+	if mergeBaseCommitID != remoteCommit {
 		logger.Debug().
 			Str("merge_base_hash", mergeBaseCommitID).
 			Msg("The default branch is not equal to the common merge-base of HEAD")
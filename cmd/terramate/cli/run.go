@@ -10,6 +10,8 @@ import (
 	"regexp"
 
 	stdfmt "fmt"
+	"io"
+	"math/rand"
 	"os"
 	"os/exec"
 	"os/signal"
@@ -19,16 +21,19 @@ import (
 
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
+	"github.com/terramate-io/terramate/ci"
 	"github.com/terramate-io/terramate/cloud"
 	"github.com/terramate-io/terramate/cloud/preview"
 	"github.com/terramate-io/terramate/config"
 	"github.com/terramate-io/terramate/errors"
 	"github.com/terramate-io/terramate/hcl/ast"
 	"github.com/terramate-io/terramate/printer"
+	"github.com/terramate-io/terramate/progress"
 	prj "github.com/terramate-io/terramate/project"
 	"github.com/terramate-io/terramate/run"
 	runutil "github.com/terramate-io/terramate/run"
 	"github.com/terramate-io/terramate/run/dag"
+	"github.com/terramate-io/terramate/run/state"
 	"github.com/terramate-io/terramate/scheduler"
 	"github.com/terramate-io/terramate/scheduler/resource"
 	"github.com/terramate-io/terramate/stack"
@@ -46,6 +51,9 @@ const (
 	// ErrRunCommandNotExecuted represents the error when the command was not executed for whatever reason.
 	ErrRunCommandNotExecuted errors.Kind = "command not found"
 
+	// ErrRunTimeout represents the error when the command was killed for exceeding the stack timeout.
+	ErrRunTimeout errors.Kind = "execution timed out"
+
 	cloudSyncPreviewCICDWarning = "--sync-preview is only supported in GitHub Actions workflows, Gitlab CICD pipelines or Bitbucket Cloud Pipelines"
 )
 
@@ -54,6 +62,37 @@ type stackRun struct {
 	Stack         *config.Stack
 	Tasks         []stackRunTask
 	SyncTaskIndex int // index of the task with sync options
+
+	// jobs is the state shared by the runs of each script job of the stack,
+	// when the jobs are ordered by job. See [cli.runJobByJob].
+	jobs *stackJobsState
+}
+
+// stackJobsState is the state of a stack shared by the runs of its script
+// jobs when each job runs in all the stacks before the next job.
+type stackJobsState struct {
+	outputs  map[string]cty.Value
+	failed   bool
+	canceled bool
+}
+
+// jobRun returns the run of the tasks of the given script job.
+func (run stackRun) jobRun(jobIdx int) stackRun {
+	jobRun := stackRun{
+		Stack:         run.Stack,
+		SyncTaskIndex: -1,
+		jobs:          run.jobs,
+	}
+	for taskIndex, task := range run.Tasks {
+		if task.ScriptJobIdx != jobIdx {
+			continue
+		}
+		if taskIndex == run.SyncTaskIndex {
+			jobRun.SyncTaskIndex = len(jobRun.Tasks)
+		}
+		jobRun.Tasks = append(jobRun.Tasks, task)
+	}
+	return jobRun
 }
 
 // stackCloudRun is a stackRun, but with a single task, because the cloud API only supports
@@ -84,9 +123,28 @@ type stackRunTask struct {
 	CloudPlanFile        string
 	CloudPlanProvisioner string
 
+	// CloudPlanFileHint tells which job of the script is expected to create
+	// the CloudPlanFile, and it is reported when the file is not found.
+	CloudPlanFileHint string
+
 	UseTerragrunt bool
 	EnableSharing bool
 	MockOnFail    bool
+
+	// DisplayCmd is the command shown to the user, when it differs from Cmd.
+	DisplayCmd []string
+
+	// CaptureOutput is the name of the job_output value storing the stdout
+	// of the task.
+	CaptureOutput string
+
+	// evalDeferred evaluates the tasks replacing this one, when the commands
+	// depend on the outputs captured by previous tasks of the stack.
+	evalDeferred func(outputs map[string]cty.Value) ([]stackRunTask, error)
+
+	// evalOptions evaluates the command options again right before the task
+	// is executed, then they observe the files created by the previous tasks.
+	evalOptions func(task stackRunTask) (stackRunTask, error)
 }
 
 // runResult contains exit code and duration of a completed run.
@@ -96,6 +154,13 @@ type runResult struct {
 	FinishedAt *time.Time
 }
 
+func (t stackRunTask) displayCmd() []string {
+	if t.DisplayCmd != nil {
+		return t.DisplayCmd
+	}
+	return t.Cmd
+}
+
 func (t stackRunTask) isSuccessExit(exitCode int) bool {
 	if exitCode == 0 {
 		return true
@@ -118,6 +183,11 @@ func selectPlanFile(terraformPlan, tofuPlan string) (planfile, provisioner strin
 }
 
 func (c *cli) runOnStacks() {
+	var resumed *state.File
+	if c.parsedArgs.Run.Resume {
+		resumed = c.loadResumedRun()
+	}
+
 	c.gitSafeguardDefaultBranchIsReachable()
 
 	if len(c.parsedArgs.Run.Command) == 0 {
@@ -125,10 +195,36 @@ func (c *cli) runOnStacks() {
 	}
 
 	c.checkOutdatedGeneratedCode()
+	if c.parsedArgs.Run.SyncDeployment {
+		c.gitSafeguardBranchProtection()
+	}
 	c.checkCloudSync()
 
+	streamed := false
+	if c.parsedArgs.Run.StreamSelection {
+		if reason := c.streamSelectionUnsupported(); reason != "" {
+			printer.Stderr.Warnf("--stream-selection is ignored because %s", reason)
+		} else {
+			streamed = true
+		}
+	}
+
 	var stacks config.List[*config.SortableStack]
-	if c.parsedArgs.Run.NoRecursive {
+	if streamed {
+		// the stacks are selected while running.
+	} else if resumed != nil {
+		stacks = c.resumedStacks(resumed)
+		if len(stacks) == 0 {
+			if !c.parsedArgs.Run.DryRun {
+				c.runState = resumed
+				c.finishRunState()
+			}
+			if !c.quiet() {
+				printer.Stderr.Println("terramate: nothing to resume, all the stacks of the run succeeded")
+			}
+			return
+		}
+	} else if c.parsedArgs.Run.NoRecursive {
 		st, found, err := config.TryLoadStack(c.cfg(), prj.PrjAbsPath(c.rootdir(), c.wd()))
 		if err != nil {
 			fatalWithDetailf(err, "loading stack in current directory")
@@ -154,10 +250,22 @@ func (c *cli) runOnStacks() {
 		}
 	}
 
+	if !streamed {
+		stacks = selectChunk(c, c.parsedArgs.Run.Chunk, stacks,
+			func(s *config.SortableStack) *config.Stack { return s.Stack })
+		c.checkStackAsserts(stacks)
+	}
+
 	if c.parsedArgs.Run.SyncDeployment && c.parsedArgs.Run.SyncDriftStatus {
 		fatal("--sync-deployment conflicts with --sync-drift-status")
 	}
 
+	checkSeedFlag(c.parsedArgs.Run.Seed, c.parsedArgs.Run.Shuffle)
+
+	if c.parsedArgs.Run.SyncLogs != "" && !c.parsedArgs.Run.SyncDeployment {
+		fatal("--sync-logs requires --sync-deployment")
+	}
+
 	if c.parsedArgs.Run.SyncPreview && (c.parsedArgs.Run.SyncDeployment || c.parsedArgs.Run.SyncDriftStatus) {
 		fatal("cannot use --sync-preview with --sync-deployment or --sync-drift-status")
 	}
@@ -182,10 +290,10 @@ func (c *cli) runOnStacks() {
 
 	c.checkTargetsConfiguration(c.parsedArgs.Run.Target, c.parsedArgs.Run.FromTarget, func(isTargetSet bool) {
 		isStatusSet := c.parsedArgs.Run.Status != ""
-		isUsingCloudFeat := cloudSyncEnabled || isStatusSet
+		isUsingCloudFeat := cloudSyncEnabled || isStatusSet || c.parsedArgs.Run.OnlyPlanChangedResources
 
 		if isTargetSet && !isUsingCloudFeat {
-			fatal("--target must be used together with --sync-deployment, --sync-drift-status, --sync-preview, or --status")
+			fatal("--target must be used together with --sync-deployment, --sync-drift-status, --sync-preview, --only-plan-changed-resources, or --status")
 		} else if !isTargetSet && isUsingCloudFeat {
 			fatal("--sync-*/--status flags require --target when terramate.config.cloud.targets.enabled is true")
 		}
@@ -195,26 +303,39 @@ func (c *cli) runOnStacks() {
 		fatal("--from-target must be used together with --sync-deployment, --sync-drift-status, or --sync-preview")
 	}
 
+	if c.parsedArgs.Run.ErrorOnEmpty && !streamed && len(stacks) == 0 {
+		c.exitOnEmptySelection(selectionFilters{
+			cloudFilterFlags:   c.parsedArgs.Run.cloudFilterFlags,
+			pathFilterFlags:    c.parsedArgs.Run.pathFilterFlags,
+			stackIDFilterFlags: c.parsedArgs.Run.stackIDFilterFlags,
+			Target:             c.parsedArgs.Run.Target,
+		})
+	}
+
 	if cloudSyncEnabled {
 		if !c.prj.isRepo {
 			fatal("cloud features requires a git repository")
 		}
-		c.ensureAllStackHaveIDs(stacks)
+		if !streamed {
+			c.ensureAllStackHaveIDs(stacks)
+		}
 		c.detectCloudMetadata()
 	}
 
-	isCICD := os.Getenv("GITHUB_ACTIONS") != "" || os.Getenv("GITLAB_CI") != "" || os.Getenv("BITBUCKET_BUILD_NUMBER") != ""
-	if c.parsedArgs.Run.SyncPreview && !isCICD {
+	if c.parsedArgs.Run.SyncPreview && !c.syncPreviewSupported() {
 		printer.Stderr.Warn(cloudSyncPreviewCICDWarning)
 		c.disableCloudFeatures(errors.E(cloudSyncPreviewCICDWarning))
 	}
 
-	var runs []stackRun
-	var err error
-	for _, st := range stacks {
+	if c.parsedArgs.Run.GlobalsFile != "" && !c.parsedArgs.Run.Eval {
+		fatal("--globals-file requires --eval")
+	}
+	globalsOverrides := c.loadGlobalsFile(c.parsedArgs.Run.GlobalsFile)
+
+	newRun := func(st *config.Stack) (stackRun, error) {
 		run := stackRun{
 			SyncTaskIndex: -1,
-			Stack:         st.Stack,
+			Stack:         st,
 			Tasks: []stackRunTask{
 				{
 					Cmd:                  c.parsedArgs.Run.Command,
@@ -233,40 +354,90 @@ func (c *cli) runOnStacks() {
 			},
 		}
 		if c.parsedArgs.Run.Eval {
-			run.Tasks[0].Cmd, err = c.evalRunArgs(run.Stack, run.Tasks[0].Cmd)
+			var err error
+			run.Tasks[0].Cmd, err = c.evalRunArgs(run.Stack, globalsOverrides, run.Tasks[0].Cmd)
 			if err != nil {
-				fatalWithDetailf(err, "unable to evaluate command")
+				return stackRun{}, err
 			}
 		}
-		runs = append(runs, run)
+		return run, nil
 	}
 
-	if c.parsedArgs.Run.SyncDeployment {
-		// This will just select all runs, since the CloudSyncDeployment was set just above.
-		// Still, it's convenient to re-use this function here.
-		deployRuns := selectCloudStackTasks(runs, isDeploymentTask)
-		c.createCloudDeployment(deployRuns)
-	}
+	syncSetup := func(runs []stackRun) {
+		c.loadCloudStacksMetadata(selectCloudStackTasks(runs, isDeploymentOrDriftTask))
 
-	if c.parsedArgs.Run.SyncPreview && c.cloudEnabled() {
-		// See comment above.
-		previewRuns := selectCloudStackTasks(runs, isPreviewTask)
-		for metaID, previewID := range c.createCloudPreview(previewRuns, c.parsedArgs.Run.Target, c.parsedArgs.Run.FromTarget) {
-			c.cloud.run.setMeta2PreviewID(metaID, previewID)
+		if c.parsedArgs.Run.SyncDeployment {
+			// This will just select all runs, since the CloudSyncDeployment was set just above.
+			// Still, it's convenient to re-use this function here.
+			deployRuns := selectCloudStackTasks(runs, isDeploymentTask)
+			c.createCloudDeployment(deployRuns)
+		}
+
+		if c.parsedArgs.Run.SyncPreview && c.cloudEnabled() {
+			// See comment above.
+			previewRuns := selectCloudStackTasks(runs, isPreviewTask)
+			for metaID, previewID := range c.createCloudPreview(previewRuns, c.parsedArgs.Run.Target, c.parsedArgs.Run.FromTarget) {
+				c.cloud.run.setMeta2PreviewID(metaID, previewID)
+			}
 		}
 	}
 
-	err = c.runAll(runs, runAllOptions{
-		Quiet:           c.parsedArgs.Quiet,
+	runOpts := runAllOptions{
+		Quiet:           c.quiet(),
 		DryRun:          c.parsedArgs.Run.DryRun,
 		Reverse:         c.parsedArgs.Run.Reverse,
 		ScriptRun:       false,
 		ContinueOnError: c.parsedArgs.Run.ContinueOnError,
 		Parallel:        c.parsedArgs.Run.Parallel,
-	})
+		Shuffle:         c.parsedArgs.Run.Shuffle,
+		SyncLogs:        c.syncLogsMode(c.parsedArgs.Run.SyncLogs),
+		LogRedact:       c.cloudLogRedactPatterns(),
+	}
+	if runOpts.Shuffle {
+		runOpts.Seed = shuffleSeed(c.parsedArgs.Run.Seed)
+	}
+
+	if !c.parsedArgs.Run.DryRun {
+		c.setupRunState(resumed, stacks, !streamed)
+	}
+	c.setupRunSummary(c.parsedArgs.Run.Summary, c.parsedArgs.Run.SummaryFile, c.parsedArgs.Run.DryRun)
+
+	var err error
+	if streamed {
+		if !cloudSyncEnabled {
+			syncSetup = nil
+		}
+		err = c.runStreamed(newRun, syncSetup, runOpts)
+	} else {
+		var runs []stackRun
+		for _, st := range stacks {
+			run, err := newRun(st.Stack)
+			if err != nil {
+				fatalWithDetailf(err, "unable to evaluate command")
+			}
+			runs = append(runs, run)
+		}
+
+		if c.parsedArgs.Run.OnlyPlanChangedResources {
+			runs = c.targetChangedResources(runs)
+		}
+
+		if cloudSyncEnabled {
+			syncSetup(runs)
+		}
+
+		err = c.runAll(runs, runOpts)
+	}
+	c.finishRunState()
+	c.printRunSummary()
+	c.printCloudDeployment()
+	syncErr := c.reportCloudSyncFailures(c.parsedArgs.Run.FailOnCloudError)
 	if err != nil {
 		fatalWithDetailf(err, "one or more commands failed")
 	}
+	if syncErr != nil {
+		fatal(syncErr)
+	}
 }
 
 // runAllOptions define named flags for runAll
@@ -277,6 +448,22 @@ type runAllOptions struct {
 	ScriptRun       bool
 	ContinueOnError bool
 	Parallel        int
+
+	// OrderByJob runs each script job in all the stacks before running the
+	// next job, see [cli.runJobByJob].
+	OrderByJob bool
+
+	// Shuffle the order of execution with the given Seed, see [dag.DAG.Shuffle].
+	Shuffle bool
+	Seed    int64
+
+	// SyncLogs tells which deployment stacks have their logs uploaded after
+	// the command finishes, see [cli.newStackLogSyncer].
+	SyncLogs string
+
+	// LogRedact is the list of patterns of the lines which are never
+	// synchronized to Terramate Cloud.
+	LogRedact []*regexp.Regexp
 }
 
 // runAll will execute the list of RunStack definitions. A RunStack defines the
@@ -293,7 +480,44 @@ type runAllOptions struct {
 func (c *cli) runAll(
 	runs []stackRun,
 	opts runAllOptions,
-) error {
+) (err error) {
+	operation := "run"
+	if opts.ScriptRun {
+		operation = "script run"
+	}
+	operationStartedAt := c.emitOperationStart(operation, len(runs))
+	defer func() {
+		exitCode := 0
+		if err != nil {
+			exitCode = 1
+		}
+		c.emitOperationEnd(operation, operationStartedAt, exitCode, err)
+	}()
+
+	sched := c.scheduleRuns(runs, opts)
+
+	// we load/check the env of all stacks beforehand then no stack is executed
+	// if the environment is not correct for all of them.
+	stackEnvs, err := c.loadAllStackEnvs(runs)
+	if err != nil {
+		return err
+	}
+
+	// the same for the directories of terraform -chdir commands.
+	if err := c.checkAllTerraformDirs(runs); err != nil {
+		return err
+	}
+
+	stackEnv := func(dir prj.Path) runutil.EnvVars { return stackEnvs[dir] }
+	if opts.OrderByJob {
+		return c.runJobByJob(runs, stackEnv, opts)
+	}
+	return c.runScheduled(sched, stackEnv, opts)
+}
+
+// scheduleRuns returns the scheduler of the runs, ordered by the DAG of the
+// implicit and explicit dependencies between their stacks.
+func (c *cli) scheduleRuns(runs []stackRun, opts runAllOptions) scheduler.S[stackRun] {
 	// Construct a DAG from the list of stackRuns, based on the implicit and
 	// explicit dependencies between stacks.
 	d, reason, err := runutil.BuildDAGFromStacks(c.cfg(), runs,
@@ -305,7 +529,150 @@ func (c *cli) runAll(
 			fatalWithDetailf(err, "failed to plan execution")
 		}
 	}
+	if opts.Shuffle {
+		d.Shuffle(opts.Seed)
+	}
 
+	// Select a scheduling strategy for the DAG nodes.
+	if opts.Parallel > 1 {
+		parallel := scheduler.NewParallel(d, opts.Reverse)
+		parallel.SetLimit(opts.Parallel)
+		if opts.DryRun && !opts.Quiet && !opts.ScriptRun {
+			printStartOrder(d, parallel.Order(), opts.Parallel)
+		}
+		return parallel
+	}
+	return scheduler.NewSequential(d, opts.Reverse)
+}
+
+// runJobByJob executes the script runs job by job: each job runs in all the
+// stacks, in the order given by their dependencies and the parallelism,
+// before the next job starts. The stacks which failed a job are skipped by
+// the next jobs and, if the execution is aborted, the remaining jobs are
+// canceled.
+func (c *cli) runJobByJob(
+	runs []stackRun,
+	stackEnv func(dir prj.Path) runutil.EnvVars,
+	opts runAllOptions,
+) error {
+	jobsCount := 0
+	for i := range runs {
+		runs[i].jobs = &stackJobsState{outputs: map[string]cty.Value{}}
+		for _, task := range runs[i].Tasks {
+			jobsCount = max(jobsCount, task.ScriptJobIdx+1)
+		}
+	}
+
+	errs := errors.L()
+	aborted := false
+	for jobIdx := 0; jobIdx < jobsCount; jobIdx++ {
+		var jobRuns []stackRun
+		for _, run := range runs {
+			jobRun := run.jobRun(jobIdx)
+			switch {
+			case len(jobRun.Tasks) == 0:
+			case aborted || run.jobs.canceled:
+				c.cancelJobRun(jobRun)
+			case run.jobs.failed:
+				c.skipJobRun(jobRun, opts)
+			default:
+				jobRuns = append(jobRuns, jobRun)
+			}
+		}
+		if len(jobRuns) == 0 {
+			continue
+		}
+
+		err := c.runScheduled(c.scheduleRuns(jobRuns, opts), stackEnv, opts)
+		errs.Append(err)
+		for _, run := range jobRuns {
+			if run.jobs.canceled {
+				aborted = true
+			}
+		}
+		if err != nil && !opts.ContinueOnError {
+			aborted = true
+		}
+	}
+	return errs.AsError()
+}
+
+// cancelJobRun reports the run of a script job as canceled because the
+// execution was aborted by a previous job.
+func (c *cli) cancelJobRun(run stackRun) {
+	for _, task := range run.Tasks {
+		cloudRun := stackCloudRun{Stack: run.Stack, Task: task}
+		c.cloudSyncAfter(cloudRun, runResult{ExitCode: -1}, errors.E(ErrRunCanceled))
+	}
+	c.emitProgress(progress.Event{
+		Type:  progress.StackCanceled,
+		Stack: run.Stack.Dir.String(),
+	})
+	c.setRunState(run.Stack.Dir, state.Canceled)
+	c.runSummary.record(run.Stack.Dir, stackSummaryCanceled, nil, 0)
+}
+
+// skipJobRun reports the run of a script job as skipped because a previous
+// job of the stack failed.
+func (c *cli) skipJobRun(run stackRun, opts runAllOptions) {
+	if run.SyncTaskIndex != -1 {
+		cloudRun := stackCloudRun{
+			Stack: run.Stack,
+			Task:  run.Tasks[run.SyncTaskIndex],
+		}
+		c.cloudSyncAfter(cloudRun, runResult{ExitCode: 1}, errors.E(ErrRunFailed))
+	}
+	if !opts.Quiet {
+		printScriptJobSkipped(c.stderr, run.Stack, run.Tasks[0])
+	}
+	c.emitProgress(progress.Event{
+		Type:  progress.StackSkipped,
+		Stack: run.Stack.Dir.String(),
+	})
+	c.runSummary.record(run.Stack.Dir, stackSummarySkipped, nil, 0)
+}
+
+// checkSeedFlag fails if the --seed flag is set without --shuffle. The zero
+// seed is the same as not setting the flag.
+func checkSeedFlag(seed int64, shuffle bool) {
+	if seed != 0 && !shuffle {
+		fatalWithDetailf(errors.E("the --seed flag must be used together with --shuffle"), "Invalid args")
+	}
+}
+
+// shuffleSeed returns the seed used to shuffle the order of execution. If no
+// seed is given then a random one is used and printed, so the same order can
+// be reproduced later with --seed.
+func shuffleSeed(seed int64) int64 {
+	if seed != 0 {
+		return seed
+	}
+	for seed == 0 {
+		seed = rand.Int63()
+	}
+	printer.Stderr.Println(stdfmt.Sprintf("terramate: shuffling the order of execution with --seed=%d", seed))
+	return seed
+}
+
+// printStartOrder prints the order in which the stacks are preferred to start
+// when more of them are ready than the parallelism allows.
+func printStartOrder(d *dag.DAG[stackRun], order []dag.ID, parallel int) {
+	printer.Stderr.Println(stdfmt.Sprintf("terramate: (dry-run) Start order with --parallel=%d:", parallel))
+	for i, id := range order {
+		run, _ := d.Node(id)
+		printer.Stderr.Println(stdfmt.Sprintf("terramate: (dry-run)   %d. %s (priority %d)",
+			i+1, run.Stack.Dir, d.Priority(id)))
+	}
+}
+
+// runScheduled executes the stack runs in the order given by the scheduler.
+// The stackEnv function returns the environment of each stack, which must be
+// already loaded and checked. See [cli.runAll] for the signal handling.
+func (c *cli) runScheduled(
+	sched scheduler.S[stackRun],
+	stackEnv func(dir prj.Path) runutil.EnvVars,
+	opts runAllOptions,
+) error {
 	// This context is used to cancel execution mid-progress and skip pending runs.
 	// It will not abort any already started runs.
 	cancelCtx, cancel := context.WithCancel(context.Background())
@@ -315,27 +682,19 @@ func (c *cli) runAll(
 	killCtx, kill := context.WithCancel(context.Background())
 	defer kill()
 
-	// Select a scheduling strategy for the DAG nodes.
-	var sched scheduler.S[stackRun]
 	acquireResource := func() {}
 	releaseResource := func() {}
 
 	if opts.Parallel > 1 {
-		sched = scheduler.NewParallel(d, opts.Reverse)
-
 		rg := resource.NewBounded(opts.Parallel)
 		// Acquire can fail, but not with context.Background().
 		acquireResource = func() { _ = rg.Acquire(context.Background()) }
 		releaseResource = func() { rg.Release() }
-	} else {
-		sched = scheduler.NewSequential(d, opts.Reverse)
 	}
 
-	// we load/check the env of all stacks beforehand then no stack is executed
-	// if the environment is not correct for all of them.
-	stackEnvs, err := c.loadAllStackEnvs(runs)
-	if err != nil {
-		return err
+	var tfVersions *runutil.TerraformVersionChecker
+	if c.checkTerraformVersion() {
+		tfVersions = runutil.NewTerraformVersionChecker()
 	}
 
 	const signalsBufferSize = 10
@@ -345,6 +704,9 @@ func (c *cli) runAll(
 
 	continueOnError := opts.ContinueOnError
 
+	locking, lockTTL := c.runLocking()
+	locking = locking && !opts.DryRun
+
 	printPrefix := "terramate:"
 	if !opts.ScriptRun && opts.DryRun {
 		printPrefix = stdfmt.Sprintf("%s (dry-run)", printPrefix)
@@ -383,13 +745,40 @@ func (c *cli) runAll(
 	// map of stackName -> map of backendName -> outputs
 	allOutputs := run.NewOnceMap[string, *run.OnceMap[string, cty.Value]]()
 
-	err = sched.Run(func(run stackRun) error {
+	return sched.Run(func(run stackRun) error {
 		errs := errors.L()
 
 		failedTaskIndex := -1
+		timeout := runutil.LoadStackRunConfig(c.cfg(), run.Stack).Timeout
+
+		// state used by the progress events of the stack.
+		stackStartedAt := time.Now()
+		started, canceled := false, false
+		exitCode, failedExitCode := 0, -1
+		var lastCmd []string
+
+		// outputs captured by the script jobs of the stack.
+		jobOutputs := map[string]cty.Value{}
+		if run.jobs != nil {
+			jobOutputs = run.jobs.outputs
+		}
+		captures := map[string]*captureBuffer{}
+
+		var lock *runutil.Lock
+		defer func() {
+			if lock == nil {
+				return
+			}
+			if err := lock.Release(); err != nil {
+				printer.Stderr.WarnWithDetails(
+					stdfmt.Sprintf("failed to release the lock of stack %s", run.Stack.Dir), err,
+				)
+			}
+		}()
 
 	tasksLoop:
-		for taskIndex, task := range run.Tasks {
+		for taskIndex := 0; taskIndex < len(run.Tasks); taskIndex++ {
+			task := run.Tasks[taskIndex]
 			acquireResource()
 
 			// For cloud sync, we always assume that there's a single task per stack.
@@ -399,10 +788,85 @@ func (c *cli) runAll(
 			case <-cancelCtx.Done():
 				c.cloudSyncAfter(cloudRun, runResult{ExitCode: -1}, errors.E(ErrRunCanceled))
 				releaseResource()
+				canceled = true
 				continue tasksLoop
 			default:
 			}
 
+			if !started {
+				started = true
+				stackStartedAt = time.Now()
+				c.emitProgress(progress.Event{
+					Type:  progress.StackStart,
+					Stack: run.Stack.Dir.String(),
+				})
+				c.setRunState(run.Stack.Dir, state.Running)
+
+				if locking {
+					var err error
+					lock, err = runutil.AcquireLock(c.cfg(), run.Stack, lockTTL)
+					if err != nil {
+						c.cloudSyncAfter(cloudRun, runResult{ExitCode: -1}, errors.E(ErrRunCommandNotExecuted, err))
+						errs.Append(err)
+						releaseResource()
+						failedTaskIndex = taskIndex
+						if !continueOnError {
+							cancel()
+						}
+						break tasksLoop
+					}
+					if stolen := lock.Stolen; stolen != nil {
+						printer.Stderr.Warnf("stack %s: stole the stale lock of pid %d on host %s created at %s",
+							run.Stack.Dir, stolen.PID, stolen.Hostname, stolen.CreatedAt.Format(time.RFC3339))
+					}
+				}
+			}
+
+			if task.evalDeferred != nil {
+				tasks, err := task.evalDeferred(jobOutputs)
+				if err != nil {
+					errs.Append(errors.E(err, "evaluating job %d of script %d", task.ScriptJobIdx, task.ScriptIdx))
+					releaseResource()
+					failedTaskIndex = taskIndex
+					if !continueOnError {
+						cancel()
+					}
+					break tasksLoop
+				}
+
+				// the stackRun is a copy but Tasks shares the backing array
+				// with the other stacks of the script.
+				expanded := make([]stackRunTask, 0, len(run.Tasks)+len(tasks)-1)
+				expanded = append(expanded, run.Tasks[:taskIndex]...)
+				expanded = append(expanded, tasks...)
+				expanded = append(expanded, run.Tasks[taskIndex+1:]...)
+				run.Tasks = expanded
+				if run.SyncTaskIndex > taskIndex {
+					run.SyncTaskIndex += len(tasks) - 1
+				}
+
+				task = run.Tasks[taskIndex]
+				cloudRun.Task = task
+			}
+
+			if task.evalOptions != nil {
+				evaluated, err := task.evalOptions(task)
+				if err != nil {
+					err = errors.E(err, "evaluating the options of job %d of script %d", task.ScriptJobIdx, task.ScriptIdx)
+					errs.Append(err)
+					c.cloudSyncAfter(cloudRun, runResult{ExitCode: -1}, errors.E(ErrRunCommandNotExecuted, err))
+					releaseResource()
+					failedTaskIndex = taskIndex
+					if !continueOnError {
+						cancel()
+					}
+					break tasksLoop
+				}
+				task = evaluated
+				cloudRun.Task = task
+			}
+			lastCmd = task.displayCmd()
+
 			if !opts.Quiet && !opts.ScriptRun {
 				printer.Stderr.Println(printPrefix + " Entering stack in " + run.Stack.String())
 			}
@@ -411,6 +875,11 @@ func (c *cli) runAll(
 				printScriptCommand(c.stderr, run.Stack, task)
 			}
 
+			if tfdir, ok, _ := runutil.TerraformDir(c.cfg(), run.Stack, task.Cmd); ok && !opts.Quiet {
+				printer.Stderr.Println(stdfmt.Sprintf("%s Detected -chdir, %s runs in %s",
+					printPrefix, task.Cmd[0], tfdir))
+			}
+
 			logger := log.With().
 				Stringer("stack", run.Stack).
 				Bool("enable_sharing", task.EnableSharing).
@@ -418,10 +887,10 @@ func (c *cli) runAll(
 				Logger()
 
 			cfg, _ := c.cfg().Lookup(run.Stack.Dir)
-			environ := newEnvironFrom(stackEnvs[run.Stack.Dir])
+			environ := newEnvironFrom(stackEnv(run.Stack.Dir))
 			if task.EnableSharing {
 				for _, in := range cfg.Node.Inputs {
-					evalctx := c.setupEvalContext(run.Stack, map[string]string{})
+					evalctx := c.setupEvalContext(run.Stack, nil, map[string]string{})
 					input, err := config.EvalInput(evalctx, in)
 					if err != nil {
 						errs.Append(errors.E(err, "failed to evaluate input block"))
@@ -585,6 +1054,23 @@ func (c *cli) runAll(
 				break tasksLoop
 			}
 
+			if tfVersions != nil {
+				err := tfVersions.Check(c.cfg(), run.Stack, task.Cmd, cmdPath, environ)
+				if err != nil {
+					c.cloudSyncAfter(cloudRun, runResult{ExitCode: -1}, errors.E(ErrRunCommandNotExecuted, err))
+					errs.Append(err)
+					releaseResource()
+					failedTaskIndex = taskIndex
+					if !continueOnError {
+						cancel()
+					}
+					break tasksLoop
+				}
+			}
+
+			// all the tasks of the stack, including every job of a script,
+			// run in the stack directory, then files created by a job (eg.:
+			// a plan file) are available to the next jobs.
 			cmd := exec.Command(cmdPath, task.Cmd[1:]...)
 			cmd.Dir = run.Stack.HostDir(c.cfg())
 			cmd.Env = environ
@@ -592,15 +1078,34 @@ func (c *cli) runAll(
 			stdout := c.stdout
 			stderr := c.stderr
 
-			logSyncWait := func() {}
+			var stackLogs *progress.LogWriter
+			if c.progress != nil {
+				// the stderr of the command is emitted as log events of the stack.
+				stackLogs = c.progress.LogWriter(run.Stack.Dir.String())
+				stderr = stackLogs
+			}
+
+			logSyncWait := func(bool) {}
 			if c.cloudEnabled() && (task.CloudSyncDeployment || task.CloudSyncPreview) {
-				logSyncer := cloud.NewLogSyncer(func(logs cloud.CommandLogs) {
-					c.syncLogs(&logger, run, logs)
-				})
-				stdout = logSyncer.NewBuffer(cloud.StdoutLogChannel, c.stdout)
-				stderr = logSyncer.NewBuffer(cloud.StderrLogChannel, c.stderr)
+				stdout, stderr, logSyncWait = c.newStackLogSyncer(&logger, run, task, opts, stdout, stderr)
+			}
+
+			var capture *captureBuffer
+			if task.CaptureOutput != "" {
+				capture = captures[task.CaptureOutput]
+				if capture == nil {
+					capture = newCaptureBuffer(config.MaxScriptJobOutputBytes)
+					captures[task.CaptureOutput] = capture
+				}
+				stdout = io.MultiWriter(stdout, capture)
+			}
 
-				logSyncWait = logSyncer.Wait
+			if stackLogs != nil {
+				syncWait := logSyncWait
+				logSyncWait = func(failed bool) {
+					syncWait(failed)
+					_ = stackLogs.Flush()
+				}
 			}
 
 			cmd.Stdin = c.stdin
@@ -614,6 +1119,9 @@ func (c *cli) runAll(
 			}
 
 			if opts.DryRun {
+				if task.CaptureOutput != "" {
+					jobOutputs[task.CaptureOutput] = cty.StringVal("")
+				}
 				releaseResource()
 				continue tasksLoop
 			}
@@ -623,7 +1131,7 @@ func (c *cli) runAll(
 			if err := cmd.Start(); err != nil {
 				endTime := time.Now().UTC()
 
-				logSyncWait()
+				logSyncWait(true)
 
 				res := runResult{
 					ExitCode:   -1,
@@ -642,16 +1150,18 @@ func (c *cli) runAll(
 			}
 
 			resultc := makeResultChannel(cmd)
+			timeoutc, stopTimeout := makeTimeoutChannel(timeout)
 
 			select {
 			case <-killCtx.Done():
+				stopTimeout()
 				if err := cmd.Process.Kill(); err != nil {
 					logger.Debug().Err(err).Msg("unable to send kill signal to child process")
 				}
 
 				endTime := time.Now().UTC()
 
-				logSyncWait()
+				logSyncWait(true)
 
 				res := runResult{
 					ExitCode:   -1,
@@ -667,11 +1177,38 @@ func (c *cli) runAll(
 				}
 				break tasksLoop
 
+			case <-timeoutc:
+				if err := cmd.Process.Kill(); err != nil {
+					logger.Debug().Err(err).Msg("unable to send kill signal to child process")
+				}
+
+				result := <-resultc
+
+				logSyncWait(true)
+
+				res := runResult{
+					ExitCode:   -1,
+					StartedAt:  &startTime,
+					FinishedAt: result.finishedAt,
+				}
+				err := errors.E(ErrRunTimeout, "running %s (in %s) exceeded the timeout of %s",
+					result.cmd, run.Stack.Dir, timeout)
+				c.cloudSyncAfter(cloudRun, res, err)
+				errs.Append(err)
+				releaseResource()
+				failedTaskIndex = taskIndex
+				if !continueOnError {
+					cancel()
+				}
+				break tasksLoop
+
 			case result := <-resultc:
-				logSyncWait()
+				stopTimeout()
+				failed := !task.isSuccessExit(result.cmd.ProcessState.ExitCode())
+				logSyncWait(failed)
 
 				var err error
-				if !task.isSuccessExit(result.cmd.ProcessState.ExitCode()) {
+				if failed {
 					err = errors.E(result.err, ErrRunFailed, "running %s (in %s)", result.cmd, run.Stack.Dir)
 					errs.Append(err)
 				}
@@ -682,6 +1219,11 @@ func (c *cli) runAll(
 					FinishedAt: result.finishedAt,
 				}
 
+				exitCode = res.ExitCode
+				if err != nil {
+					failedExitCode = res.ExitCode
+				}
+
 				logMsg := logger.Debug().Int("exit_code", res.ExitCode)
 				if res.StartedAt != nil && res.FinishedAt != nil {
 					logMsg = logMsg.
@@ -700,6 +1242,15 @@ func (c *cli) runAll(
 					}
 					break tasksLoop
 				}
+
+				if capture != nil {
+					if capture.truncated && !capture.reported {
+						capture.reported = true
+						printer.Stderr.Warnf("stack %s: output captured in job_output.%s truncated to %d bytes",
+							run.Stack.Dir, task.CaptureOutput, config.MaxScriptJobOutputBytes)
+					}
+					jobOutputs[task.CaptureOutput] = cty.StringVal(strings.TrimSpace(capture.String()))
+				}
 			}
 		}
 
@@ -711,13 +1262,45 @@ func (c *cli) runAll(
 			c.cloudSyncAfter(cloudRun, runResult{ExitCode: 1}, errors.E(ErrRunFailed))
 		}
 
-		return errs.AsError()
-	})
+		err := errs.AsError()
+
+		ev := progress.Event{
+			Stack: run.Stack.Dir.String(),
+			Cmd:   lastCmd,
+		}
+		if run.jobs != nil {
+			run.jobs.canceled = canceled
+			run.jobs.failed = err != nil
+		}
 
-	return err
+		stackDuration := time.Since(stackStartedAt)
+		switch {
+		case canceled:
+			ev.Type = progress.StackCanceled
+			c.emitProgress(ev)
+			c.setRunState(run.Stack.Dir, state.Canceled)
+			c.runSummary.record(run.Stack.Dir, stackSummaryCanceled, nil, 0)
+		case !started:
+		case err != nil:
+			ev.Type = progress.StackFailure
+			ev.Error = err.Error()
+			c.emitProgress(ev.WithExitCode(failedExitCode).WithDuration(stackDuration))
+			c.setRunState(run.Stack.Dir, state.Failed)
+			c.runSummary.record(run.Stack.Dir, stackSummaryFailed, &failedExitCode, stackDuration)
+		default:
+			ev.Type = progress.StackSuccess
+			c.emitProgress(ev.WithExitCode(exitCode).WithDuration(stackDuration))
+			c.setRunState(run.Stack.Dir, state.Success)
+			c.runSummary.record(run.Stack.Dir, stackSummarySuccess, &exitCode, stackDuration)
+		}
+
+		return err
+	})
 }
 
 func (c *cli) syncLogs(logger *zerolog.Logger, run stackRun, logs cloud.CommandLogs) {
+	c.cloud.syncGate.wait()
+
 	data, _ := stdjson.Marshal(logs)
 	logger.Debug().RawJSON("logs", data).Msg("synchronizing logs")
 	ctx, cancel := context.WithTimeout(context.Background(), defaultCloudTimeout)
@@ -754,6 +1337,16 @@ func makeResultChannel(cmd *exec.Cmd) <-chan cmdResult {
 	return resultc
 }
 
+// makeTimeoutChannel returns a channel which receives when the given timeout
+// expires and a function to stop the timer. A zero timeout never expires.
+func makeTimeoutChannel(timeout time.Duration) (<-chan time.Time, func()) {
+	if timeout <= 0 {
+		return nil, func() {}
+	}
+	timer := time.NewTimer(timeout)
+	return timer.C, func() { timer.Stop() }
+}
+
 func newEnvironFrom(stackEnviron []string) []string {
 	environ := make([]string, len(os.Environ()))
 	copy(environ, os.Environ())
@@ -765,7 +1358,7 @@ func (c *cli) loadAllStackEnvs(runs []stackRun) (map[prj.Path]runutil.EnvVars, e
 	errs := errors.L()
 	stackEnvs := map[prj.Path]runutil.EnvVars{}
 	for _, run := range runs {
-		env, err := runutil.LoadEnv(c.cfg(), run.Stack)
+		env, err := runutil.LoadEnv(c.cfg(), run.Stack, c.isolateDataDir())
 		errs.Append(err)
 		stackEnvs[run.Stack.Dir] = env
 	}
@@ -776,6 +1369,17 @@ func (c *cli) loadAllStackEnvs(runs []stackRun) (map[prj.Path]runutil.EnvVars, e
 	return stackEnvs, nil
 }
 
+func (c *cli) checkAllTerraformDirs(runs []stackRun) error {
+	errs := errors.L()
+	for _, run := range runs {
+		for _, task := range run.Tasks {
+			_, _, err := runutil.TerraformDir(c.cfg(), run.Stack, task.Cmd)
+			errs.Append(err)
+		}
+	}
+	return errs.AsError()
+}
+
 func (c *cli) createCloudPreview(runs []stackCloudRun, target, fromTarget string) map[string]string {
 	previewRuns := make([]cloud.RunContext, len(runs))
 	for i, run := range runs {
@@ -786,8 +1390,15 @@ func (c *cli) createCloudPreview(runs []stackCloudRun, target, fromTarget string
 	}
 
 	affectedStacksMap := map[string]*config.Stack{}
+	changeReasons := map[string]string{}
 	for _, st := range c.getAffectedStacks() {
 		affectedStacksMap[st.Stack.ID] = st.Stack
+		changeReasons[st.Stack.ID] = st.Reason
+	}
+
+	if c.cloud.run.reviewRequest == nil && c.cloud.run.metadata != nil && c.prj.ciPlatform() == ci.PlatformGitlab {
+		// not a merge request pipeline.
+		c.cloud.run.reviewRequest = c.newGitlabBranchReviewRequest()
 	}
 
 	if c.cloud.run.reviewRequest == nil || c.cloud.run.rrEvent.pushedAt == nil {
@@ -824,6 +1435,7 @@ func (c *cli) createCloudPreview(runs []stackCloudRun, target, fromTarget string
 		cloud.CreatePreviewOpts{
 			Runs:            previewRuns,
 			AffectedStacks:  affectedStacksMap,
+			ChangeReasons:   changeReasons,
 			OrgUUID:         c.cloud.run.orgUUID,
 			PushedAt:        *c.cloud.run.rrEvent.pushedAt,
 			CommitSHA:       c.cloud.run.rrEvent.commitSHA,
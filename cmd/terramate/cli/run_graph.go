@@ -0,0 +1,236 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/emicklei/dot"
+	"github.com/rs/zerolog/log"
+	"github.com/terramate-io/terramate/config"
+	"github.com/terramate-io/terramate/errors"
+	"github.com/terramate-io/terramate/printer"
+	"github.com/terramate-io/terramate/run"
+	"github.com/terramate-io/terramate/run/dag"
+	"github.com/terramate-io/terramate/stack"
+)
+
+// graphRenderer renders the run graph in a specific output format.
+type graphRenderer interface {
+	// addNode adds the node with the given label, if not added yet.
+	addNode(label string)
+	// addEdge adds an edge between the nodes with the given labels.
+	// The edges which are part of a cycle are highlighted.
+	addEdge(from, to string, cycle bool)
+	// render returns the rendered graph.
+	render() string
+}
+
+func (c *cli) generateGraph() {
+	var getLabel func(s *config.Stack) string
+
+	logger := log.With().
+		Str("action", "generateGraph()").
+		Str("workingDir", c.wd()).
+		Logger()
+
+	switch c.parsedArgs.Experimental.RunGraph.Label {
+	case "stack.name":
+		logger.Debug().Msg("Set label to stack name.")
+
+		getLabel = func(s *config.Stack) string { return s.Name }
+	case "stack.dir":
+		logger.Debug().Msg("Set label stack directory.")
+
+		getLabel = func(s *config.Stack) string { return s.Dir.String() }
+	default:
+		fatal(`-label expects the values "stack.name" or "stack.dir"`)
+	}
+
+	var renderer graphRenderer
+	switch c.parsedArgs.Experimental.RunGraph.Format {
+	case "dot":
+		renderer = &dotRenderer{graph: dot.NewGraph(dot.Directed)}
+	case "mermaid":
+		renderer = &mermaidRenderer{ids: map[string]string{}}
+	}
+
+	entries, err := stack.List(c.cfg(), c.cfg().Tree())
+	if err != nil {
+		fatalWithDetailf(err, "listing stacks to build graph")
+	}
+
+	logger.Debug().Msg("Create new graph.")
+
+	graph := dag.New[*config.Stack]()
+
+	visited := dag.Visited{}
+	for _, e := range c.filterStacksByWorkingDir(entries) {
+		if _, ok := visited[dag.ID(e.Stack.Dir.String())]; ok {
+			continue
+		}
+
+		if err := run.BuildDAG(
+			graph,
+			c.cfg(),
+			e.Stack,
+			"before",
+			func(s config.Stack) []string { return s.Before },
+			"after",
+			func(s config.Stack) []string { return s.After },
+			visited,
+		); err != nil {
+			fatalWithDetailf(err, "building order tree")
+		}
+	}
+
+	edges := map[[2]string]bool{}
+	for _, id := range graph.IDs() {
+		val, err := graph.Node(id)
+		if err != nil {
+			fatalWithDetailf(err, "generating graph")
+		}
+
+		renderGraphNode(renderer, graph, id, val, getLabel, edges)
+	}
+
+	if cycles := graph.Cycles(); len(cycles) > 0 {
+		errs := errors.L()
+		for _, cycle := range cycles {
+			chain := make([]string, len(cycle))
+			for i, id := range cycle {
+				chain[i] = string(id)
+			}
+			errs.Append(errors.E(strings.Join(chain, " -> ")))
+		}
+		printer.Stderr.WarnWithDetails("cycles detected in the execution order", errs)
+	}
+
+	logger.Debug().
+		Msg("Set output of graph.")
+	outFile := c.parsedArgs.Experimental.RunGraph.Outfile
+	var out io.Writer
+	if outFile == "" {
+
+		out = c.stdout
+	} else {
+
+		f, err := os.Create(outFile)
+		if err != nil {
+			fatalWithDetailf(err, "opening file %s", outFile)
+		}
+
+		defer func() {
+			if err := f.Close(); err != nil {
+				fatalWithDetailf(err, "closing output graph file")
+			}
+		}()
+
+		out = f
+	}
+
+	logger.Debug().
+		Msg("Write graph to output.")
+	_, err = out.Write([]byte(renderer.render()))
+	if err != nil {
+		fatalWithDetailf(err, "writing output %s", outFile)
+	}
+}
+
+// renderGraphNode adds the node of the given id and the edges from its
+// ancestors to the renderer, recursively. The edges already rendered are
+// tracked by the edges map.
+func renderGraphNode(
+	renderer graphRenderer,
+	graph *dag.DAG[*config.Stack],
+	id dag.ID,
+	stackval *config.Stack,
+	getLabel func(s *config.Stack) string,
+	edges map[[2]string]bool,
+) {
+	descendant := getLabel(stackval)
+	renderer.addNode(descendant)
+	for _, ancestor := range graph.AncestorsOf(id) {
+		s, err := graph.Node(ancestor)
+		if err != nil {
+			fatalWithDetailf(err, "generating graph")
+		}
+		ancestorLabel := getLabel(s)
+		renderer.addNode(ancestorLabel)
+
+		// we invert the graph here.
+
+		cycle := graph.HasCycle(ancestor)
+		edge := [2]string{ancestorLabel, descendant}
+		if !edges[edge] {
+			edges[edge] = true
+			renderer.addEdge(ancestorLabel, descendant, cycle)
+		}
+
+		if cycle {
+			continue
+		}
+
+		renderGraphNode(renderer, graph, ancestor, s, getLabel, edges)
+	}
+}
+
+// dotRenderer renders the graph in the Graphviz dot format.
+type dotRenderer struct {
+	graph *dot.Graph
+}
+
+func (r *dotRenderer) addNode(label string) {
+	r.graph.Node(label)
+}
+
+func (r *dotRenderer) addEdge(from, to string, cycle bool) {
+	edge := r.graph.Edge(r.graph.Node(from), r.graph.Node(to))
+	if cycle {
+		edge.Attr("color", "red")
+	}
+}
+
+func (r *dotRenderer) render() string {
+	return r.graph.String()
+}
+
+// mermaidRenderer renders the graph as a Mermaid flowchart.
+type mermaidRenderer struct {
+	ids        map[string]string
+	nodes      []string
+	edges      []string
+	cycleEdges []string
+}
+
+func (r *mermaidRenderer) addNode(label string) {
+	if _, ok := r.ids[label]; ok {
+		return
+	}
+	id := fmt.Sprintf("n%d", len(r.ids)+1)
+	r.ids[label] = id
+	r.nodes = append(r.nodes, fmt.Sprintf("%s[\"%s\"]", id, strings.ReplaceAll(label, `"`, "#quot;")))
+}
+
+func (r *mermaidRenderer) addEdge(from, to string, cycle bool) {
+	if cycle {
+		r.cycleEdges = append(r.cycleEdges, fmt.Sprint(len(r.edges)))
+	}
+	r.edges = append(r.edges, fmt.Sprintf("%s --> %s", r.ids[from], r.ids[to]))
+}
+
+func (r *mermaidRenderer) render() string {
+	var b strings.Builder
+	b.WriteString("graph TD\n")
+	for _, line := range append(r.nodes, r.edges...) {
+		fmt.Fprintf(&b, "    %s\n", line)
+	}
+	if len(r.cycleEdges) > 0 {
+		fmt.Fprintf(&b, "    linkStyle %s stroke:red\n", strings.Join(r.cycleEdges, ","))
+	}
+	return b.String()
+}
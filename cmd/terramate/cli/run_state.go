@@ -0,0 +1,156 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package cli
+
+import (
+	stdjson "encoding/json"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"github.com/terramate-io/terramate/config"
+	"github.com/terramate-io/terramate/printer"
+	prj "github.com/terramate-io/terramate/project"
+	"github.com/terramate-io/terramate/run/state"
+)
+
+// loadResumedRun loads the state of the run given by --resume and restores
+// its flags, so the command runs with the same options. The run ID is given
+// as the first argument of the command, if not given the last run is resumed.
+// The command itself is not saved in the state of the run, so it must be given
+// again.
+func (c *cli) loadResumedRun() *state.File {
+	args := c.parsedArgs.Run.Command
+	var runID string
+	if len(args) > 0 {
+		if _, err := uuid.Parse(args[0]); err == nil {
+			runID = args[0]
+			args = args[1:]
+		}
+	}
+	if len(args) > 0 && args[0] == "--" {
+		args = args[1:]
+	}
+	if len(args) == 0 {
+		fatal("--resume expects the command to run, it is not saved in the state of the run")
+	}
+
+	var (
+		f   *state.File
+		err error
+	)
+	if runID != "" {
+		f, err = state.Load(c.rootdir(), runID)
+	} else {
+		f, err = state.Latest(c.rootdir())
+	}
+	if err != nil {
+		fatalWithDetailf(err, "loading the run to resume")
+	}
+
+	run := f.Run()
+	if !run.SelectionComplete {
+		fatalf("the stacks of run %s were not all selected, it must be run again without --resume", run.ID)
+	}
+
+	dryRun := c.parsedArgs.Run.DryRun
+	if err := stdjson.Unmarshal(run.Args, &c.parsedArgs.Run); err != nil {
+		fatalWithDetailf(err, "loading the arguments of run %s", run.ID)
+	}
+	c.parsedArgs.Run.Command = args
+	c.parsedArgs.Run.Resume = true
+	c.parsedArgs.Run.DryRun = c.parsedArgs.Run.DryRun || dryRun
+	// the stacks of the resumed run are already known.
+	c.parsedArgs.Run.StreamSelection = false
+
+	if !c.quiet() {
+		printer.Stderr.Println("terramate: resuming run " + run.ID)
+	}
+	return f
+}
+
+// resumedStacks returns the stacks of the resumed run which failed or were not
+// completed, in the order they were selected.
+func (c *cli) resumedStacks(f *state.File) config.List[*config.SortableStack] {
+	var stacks config.List[*config.SortableStack]
+	for _, path := range f.Unfinished() {
+		st, err := config.LoadStack(c.cfg(), prj.NewPath(path))
+		if err != nil {
+			fatalWithDetailf(err, "loading stack %s of run %s", path, f.Run().ID)
+		}
+		stacks = append(stacks, st.Sortable())
+	}
+	return stacks
+}
+
+// setupRunState saves the state of the run with the given stacks. The stacks of
+// a resumed run are set back to pending. A failure to save the state does not
+// stop the run, but then it cannot be resumed.
+//
+// Only the stacks and the flags of the run are saved. The command is left out
+// because it may contain secrets, like `-var password=...`.
+func (c *cli) setupRunState(resumed *state.File, stacks config.List[*config.SortableStack], selectionComplete bool) {
+	if resumed != nil {
+		for _, st := range stacks {
+			if err := resumed.Set(st.Dir().String(), state.Pending); err != nil {
+				printer.Stderr.WarnWithDetails("unable to save the state of the run, it cannot be resumed", err)
+				return
+			}
+		}
+		c.runState = resumed
+		return
+	}
+
+	id, err := uuid.NewRandom()
+	if err != nil {
+		printer.Stderr.WarnWithDetails("unable to create the run ID, the run cannot be resumed", err)
+		return
+	}
+
+	paths := make([]string, len(stacks))
+	for i, st := range stacks {
+		paths[i] = st.Dir().String()
+	}
+	args := c.parsedArgs.Run
+	args.Command = nil
+	f, err := state.New(c.rootdir(), id.String(), args, paths, selectionComplete)
+	if err != nil {
+		printer.Stderr.WarnWithDetails("unable to save the state of the run, it cannot be resumed", err)
+		return
+	}
+	c.runState = f
+}
+
+// setRunState saves the status of the stack in the state of the run, if any.
+func (c *cli) setRunState(dir prj.Path, status state.Status) {
+	if err := c.runState.Set(dir.String(), status); err != nil {
+		log.Warn().Err(err).Stringer("stack", dir).Msg("saving the state of the run")
+	}
+}
+
+// completeRunSelection marks the selection of the stacks of the run as
+// complete, if the state of the run is saved.
+func (c *cli) completeRunSelection() {
+	if err := c.runState.CompleteSelection(); err != nil {
+		log.Warn().Err(err).Msg("saving the state of the run")
+	}
+}
+
+// finishRunState removes the state of the run if all the stacks succeeded,
+// otherwise it shows how to resume the run.
+func (c *cli) finishRunState() {
+	if c.runState == nil {
+		return
+	}
+	run := c.runState.Run()
+	if len(c.runState.Unfinished()) == 0 && run.SelectionComplete {
+		if err := c.runState.Remove(); err != nil {
+			log.Warn().Err(err).Msg("removing the state of the run")
+		}
+		return
+	}
+	if !c.quiet() && run.SelectionComplete {
+		printer.Stderr.Println("terramate: run `terramate run --resume " + run.ID +
+			" -- <cmd>` to execute only the stacks which failed or were not started")
+	}
+}
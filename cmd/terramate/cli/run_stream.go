@@ -0,0 +1,340 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package cli
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	"github.com/rs/zerolog/log"
+	"github.com/terramate-io/terramate/cmd/terramate/cli/clitest"
+	"github.com/terramate-io/terramate/config"
+	"github.com/terramate-io/terramate/errors"
+	"github.com/terramate-io/terramate/generate"
+	prj "github.com/terramate-io/terramate/project"
+	runutil "github.com/terramate-io/terramate/run"
+	"github.com/terramate-io/terramate/run/dag"
+	"github.com/terramate-io/terramate/run/state"
+	"github.com/terramate-io/terramate/scheduler"
+	"github.com/terramate-io/terramate/stack"
+)
+
+// streamSelectionUnsupported returns the reason why the stack selection of
+// the run command cannot be streamed, or an empty string if it can.
+// The selection can only be streamed when the change of each stack is known
+// without computing the change of the other stacks.
+func (c *cli) streamSelectionUnsupported() string {
+	// the stacks must be listed before checking the Terragrunt config.
+	report, err := c.stackManager().List(false)
+	if err != nil {
+		fatalWithDetailf(err, "listing stacks")
+	}
+
+	args := c.parsedArgs.Run
+	switch {
+	case !c.parsedArgs.Changed:
+		return "it requires --changed"
+	case args.NoRecursive:
+		return "--no-recursive selects a single stack"
+	case args.Status != "" || args.DeploymentStatus != "" || args.DriftStatus != "":
+		return "the Terramate Cloud status filters need the full selection"
+	case args.IncludeOutputDependencies || args.OnlyOutputDependencies:
+		return "the output dependencies need the full selection"
+	case args.OnlyPlanChangedResources:
+		return "--only-plan-changed-resources needs the full selection"
+	case args.ErrorOnEmpty:
+		return "--error-on-empty needs the full selection"
+	case args.Chunk != "":
+		return "--chunk needs the full selection"
+	case c.cfg().IsTerragruntChangeDetectionEnabled():
+		return "the Terragrunt change detection needs the full selection"
+	case c.cfg().IsOutputsPropagationEnabled():
+		return "the propagation of changes to output dependents needs the full selection"
+	}
+
+	for _, e := range report.Stacks {
+		if len(e.Stack.Wants) > 0 || len(e.Stack.WantedBy) > 0 {
+			return "the stacks with wants or wanted_by need the full selection"
+		}
+	}
+	return ""
+}
+
+// runStreamed runs the commands in the changed stacks while the change
+// detection is in progress. The stacks are resolved in the execution order and
+// each changed stack is started as soon as all the stacks which may run before
+// it are resolved and done, so the execution order is the same as if all the
+// changed stacks were selected beforehand.
+// The checks done for all stacks before running any of them by [cli.runAll]
+// are done for each stack when it's resolved, and any failure stops starting
+// new stacks. The syncSetup function is called with the final list of runs,
+// before any stack run is synchronized with Terramate Cloud.
+func (c *cli) runStreamed(
+	newRun func(st *config.Stack) (stackRun, error),
+	syncSetup func(runs []stackRun),
+	opts runAllOptions,
+) (err error) {
+	const operation = "run"
+
+	// the number of stacks is unknown at this point.
+	operationStartedAt := c.emitOperationStart(operation, 0)
+	defer func() {
+		exitCode := 0
+		if err != nil {
+			exitCode = 1
+		}
+		c.emitOperationEnd(operation, operationStartedAt, exitCode, err)
+	}()
+
+	mgr := c.stackManager()
+	detector, err := mgr.NewChangeDetector(stack.ChangeConfig{
+		BaseRef:            c.baseRef(),
+		UntrackedChanges:   c.changeDetection.untracked,
+		UncommittedChanges: c.changeDetection.uncommitted,
+	})
+	if err != nil {
+		fatalWithDetailf(err, "computing selected stacks")
+	}
+
+	c.prj.git.repoChecks = detector.Checks()
+	c.gitFileSafeguards(true)
+
+	report, err := mgr.List(false)
+	if err != nil {
+		fatalWithDetailf(err, "computing selected stacks")
+	}
+
+	// The DAG has all the stacks which may be selected, so the ordering
+	// constraints of every changed stack are known beforehand.
+	var candidates []*config.Stack
+	for _, e := range c.filterStacks(report.Stacks) {
+		candidates = append(candidates, e.Stack)
+	}
+	d, reason, err := runutil.BuildDAGFromStacks(c.cfg(), candidates,
+		func(st *config.Stack) *config.Stack { return st })
+	if err != nil {
+		if errors.IsKind(err, dag.ErrCycleDetected) {
+			fatalWithDetailf(err, "cycle detected: %s", reason)
+		} else {
+			fatalWithDetailf(err, "failed to plan execution")
+		}
+	}
+	if opts.Shuffle {
+		d.Shuffle(opts.Seed)
+	}
+
+	runs := &streamedRuns{
+		d:     d,
+		sched: scheduler.NewStreaming(d, opts.Reverse, opts.Parallel > 1),
+		runs:  map[prj.Path]stackRun{},
+		envs:  map[prj.Path]runutil.EnvVars{},
+	}
+
+	if syncSetup != nil {
+		c.cloud.syncGate = newCloudSyncGate()
+	}
+
+	var resolveErr error
+	resolved := make(chan struct{})
+	go func() {
+		defer close(resolved)
+		defer c.cloud.syncGate.open()
+
+		resolveErr = c.resolveStreamedRuns(detector, runs, newRun, syncSetup != nil)
+		if resolveErr != nil {
+			runs.abort()
+			if syncSetup != nil {
+				c.disableCloudFeatures(resolveErr)
+			}
+			return
+		}
+		if syncSetup != nil {
+			syncSetup(runs.selected())
+		}
+	}()
+
+	err = c.runScheduled(runs, runs.env, opts)
+	<-resolved
+
+	if resolveErr != nil {
+		return errors.L(errors.E(resolveErr, "computing selected stacks"), err).AsError()
+	}
+	return err
+}
+
+// resolveStreamedRuns resolves the stacks of the DAG in the scheduler order,
+// selecting the changed ones.
+func (c *cli) resolveStreamedRuns(
+	detector *stack.ChangeDetector,
+	runs *streamedRuns,
+	newRun func(st *config.Stack) (stackRun, error),
+	cloudSyncEnabled bool,
+) error {
+	var affected []stack.Entry
+	defer func() {
+		sort.Slice(affected, func(i, j int) bool {
+			return affected[i].Stack.Dir.String() < affected[j].Stack.Dir.String()
+		})
+		c.affectedStacks = affected
+	}()
+
+	for _, id := range runs.sched.Order() {
+		st, _ := runs.d.Node(id)
+
+		entry, changed, err := detector.Changed(st)
+		if err != nil {
+			return err
+		}
+		if !changed {
+			runs.sched.Skip(id)
+			continue
+		}
+
+		log.Debug().
+			Stringer("stack", st.Dir).
+			Str("reason", entry.Reason).
+			Msg("stack selected")
+
+		if err := generate.CheckAsserts(c.cfg(), config.List[*config.SortableStack]{st.Sortable()}); err != nil {
+			return errors.E(err, "assertions failed in the selected stacks")
+		}
+
+		if cloudSyncEnabled && st.ID == "" {
+			log.Error().Stringer("stack", st.Dir).Msg("stack is missing the ID field")
+			return errors.E(clitest.ErrCloudStacksWithoutID)
+		}
+
+		run, err := newRun(st)
+		if err != nil {
+			return errors.E(err, "unable to evaluate command")
+		}
+
+		env, err := runutil.LoadEnv(c.cfg(), st, c.isolateDataDir())
+		if err != nil {
+			return err
+		}
+		if err := c.checkAllTerraformDirs([]stackRun{run}); err != nil {
+			return err
+		}
+
+		affected = append(affected, stack.Entry{Stack: st, Reason: entry.Reason})
+		runs.add(run, env)
+		c.setRunState(st.Dir, state.Pending)
+		runs.sched.Select(id)
+	}
+	c.completeRunSelection()
+	return nil
+}
+
+// streamedRuns implements the scheduler.S interface for the stack runs
+// created while the stacks are resolved by the streaming scheduler.
+type streamedRuns struct {
+	d       *dag.DAG[*config.Stack]
+	sched   *scheduler.Streaming[*config.Stack]
+	aborted atomic.Bool
+
+	mu    sync.Mutex
+	runs  map[prj.Path]stackRun
+	envs  map[prj.Path]runutil.EnvVars
+	order []prj.Path
+}
+
+func (s *streamedRuns) Run(f scheduler.Func[stackRun]) error {
+	return s.sched.Run(func(st *config.Stack) error {
+		// stacks already selected but not started are skipped after the
+		// selection fails.
+		if s.aborted.Load() {
+			return nil
+		}
+		s.mu.Lock()
+		run := s.runs[st.Dir]
+		s.mu.Unlock()
+		return f(run)
+	})
+}
+
+func (s *streamedRuns) add(run stackRun, env runutil.EnvVars) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.runs[run.Stack.Dir] = run
+	s.envs[run.Stack.Dir] = env
+	s.order = append(s.order, run.Stack.Dir)
+}
+
+func (s *streamedRuns) env(dir prj.Path) runutil.EnvVars {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.envs[dir]
+}
+
+// selected returns the runs of the selected stacks, in the order they were
+// selected.
+func (s *streamedRuns) selected() []stackRun {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	runs := make([]stackRun, 0, len(s.order))
+	for _, dir := range s.order {
+		runs = append(runs, s.runs[dir])
+	}
+	return runs
+}
+
+func (s *streamedRuns) abort() {
+	s.aborted.Store(true)
+	s.sched.Close()
+}
+
+// cloudSyncGate defers the synchronization of the stack runs with Terramate
+// Cloud until it's open, when the final set of stacks is known and the cloud
+// deployment or preview is created. A nil gate is always open.
+type cloudSyncGate struct {
+	mu      sync.Mutex
+	isOpen  bool
+	pending []func()
+	openc   chan struct{}
+}
+
+func newCloudSyncGate() *cloudSyncGate {
+	return &cloudSyncGate{openc: make(chan struct{})}
+}
+
+// deferred queues fn to be called when the gate opens and returns true, or
+// returns false if the gate is already open.
+func (g *cloudSyncGate) deferred(fn func()) bool {
+	if g == nil {
+		return false
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.isOpen {
+		return false
+	}
+	g.pending = append(g.pending, fn)
+	return true
+}
+
+// wait blocks until the gate is open and all the deferred calls are done.
+func (g *cloudSyncGate) wait() {
+	if g == nil {
+		return
+	}
+	<-g.openc
+}
+
+func (g *cloudSyncGate) open() {
+	if g == nil {
+		return
+	}
+	g.mu.Lock()
+	g.isOpen = true
+	pending := g.pending
+	g.pending = nil
+	g.mu.Unlock()
+
+	for _, fn := range pending {
+		fn()
+	}
+	close(g.openc)
+}
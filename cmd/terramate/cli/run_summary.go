@@ -0,0 +1,238 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package cli
+
+import (
+	"bytes"
+	stdjson "encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/terramate-io/terramate/errors"
+	"github.com/terramate-io/terramate/printer"
+	prj "github.com/terramate-io/terramate/project"
+)
+
+// Formats of the --summary flag.
+const (
+	runSummaryText = "text"
+	runSummaryJSON = "json"
+)
+
+// runSummarySlowest is the number of slowest stacks shown by the text summary.
+const runSummarySlowest = 5
+
+// Status of the stacks in the run summary.
+const (
+	stackSummarySuccess  = "success"
+	stackSummaryFailed   = "failed"
+	stackSummarySkipped  = "skipped"
+	stackSummaryCanceled = "canceled"
+	stackSummarySelected = "selected"
+)
+
+// runSummary collects the result of each stack of a run, which is shown at the
+// end of the run with --summary. It is safe for concurrent use and a nil
+// summary records nothing.
+type runSummary struct {
+	format    string
+	file      string
+	dryRun    bool
+	startedAt time.Time
+
+	mu     sync.Mutex
+	stacks map[prj.Path]*stackSummary
+}
+
+// stackSummary is the result of a stack in the run summary. The script jobs
+// of a stack running job by job are summarized as a single result, with the
+// total duration of the jobs.
+type stackSummary struct {
+	Stack      string `json:"stack"`
+	Status     string `json:"status"`
+	ExitCode   *int   `json:"exit_code,omitempty"`
+	DurationMS int64  `json:"duration_ms"`
+
+	duration time.Duration
+}
+
+// runSummaryDoc is the document written by --summary json.
+type runSummaryDoc struct {
+	DryRun     bool            `json:"dry_run,omitempty"`
+	Total      int             `json:"total"`
+	Succeeded  int             `json:"succeeded"`
+	Failed     int             `json:"failed"`
+	Skipped    int             `json:"skipped"`
+	Canceled   int             `json:"canceled"`
+	DurationMS int64           `json:"duration_ms"`
+	Stacks     []*stackSummary `json:"stacks"`
+}
+
+// setupRunSummary creates the summary of the run if --summary is set to text
+// or json.
+func (c *cli) setupRunSummary(format, file string, dryRun bool) {
+	if format != runSummaryText && format != runSummaryJSON {
+		if file != "" {
+			fatal("--summary-file requires --summary json")
+		}
+		return
+	}
+	if file != "" && format != runSummaryJSON {
+		fatal("--summary-file requires --summary json")
+	}
+	c.runSummary = &runSummary{
+		format:    format,
+		file:      file,
+		dryRun:    dryRun,
+		startedAt: time.Now(),
+		stacks:    map[prj.Path]*stackSummary{},
+	}
+}
+
+// record saves the result of the execution of the stack. If the stack already
+// has a result, from a previous script job, the durations are added and the
+// worst status is kept.
+func (s *runSummary) record(dir prj.Path, status string, exitCode *int, duration time.Duration) {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	res, ok := s.stacks[dir]
+	if !ok {
+		res = &stackSummary{Stack: dir.String()}
+		s.stacks[dir] = res
+	}
+	res.duration += duration
+	res.DurationMS = res.duration.Milliseconds()
+	if !ok || stackSummaryPriority(status) > stackSummaryPriority(res.Status) {
+		res.Status = status
+		res.ExitCode = exitCode
+	}
+}
+
+func stackSummaryPriority(status string) int {
+	switch status {
+	case stackSummaryFailed:
+		return 3
+	case stackSummaryCanceled:
+		return 2
+	case stackSummarySkipped:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// doc returns the summary document with the stacks sorted by path.
+func (s *runSummary) doc() runSummaryDoc {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	doc := runSummaryDoc{
+		DryRun:     s.dryRun,
+		DurationMS: time.Since(s.startedAt).Milliseconds(),
+		Stacks:     []*stackSummary{},
+	}
+	for _, res := range s.stacks {
+		if s.dryRun {
+			// a dry run only selects the stacks.
+			res = &stackSummary{Stack: res.Stack, Status: stackSummarySelected}
+		}
+		doc.Stacks = append(doc.Stacks, res)
+		switch res.Status {
+		case stackSummarySuccess:
+			doc.Succeeded++
+		case stackSummaryFailed:
+			doc.Failed++
+		case stackSummarySkipped:
+			doc.Skipped++
+		case stackSummaryCanceled:
+			doc.Canceled++
+		}
+	}
+	doc.Total = len(doc.Stacks)
+	sort.Slice(doc.Stacks, func(i, j int) bool {
+		return doc.Stacks[i].Stack < doc.Stacks[j].Stack
+	})
+	if s.dryRun {
+		doc.DurationMS = 0
+	}
+	return doc
+}
+
+// printRunSummary shows the summary of the run, if --summary is set. The text
+// summary is printed to stderr so the stdout only has the output of the
+// commands, the JSON summary is written to --summary-file or to stdout.
+func (c *cli) printRunSummary() {
+	s := c.runSummary
+	if s == nil {
+		return
+	}
+
+	doc := s.doc()
+	if s.format == runSummaryJSON {
+		data, err := stdjson.MarshalIndent(doc, "", "  ")
+		if err != nil {
+			fatalWithDetailf(err, "encoding the run summary")
+		}
+		data = append(data, '\n')
+		if s.file == "" {
+			_, _ = c.stdout.Write(data)
+			return
+		}
+		if err := os.WriteFile(s.file, data, 0644); err != nil {
+			fatalWithDetailf(errors.E(err), "writing the run summary")
+		}
+		return
+	}
+
+	if s.dryRun {
+		printer.Stderr.Println(fmt.Sprintf("terramate: run summary (dry-run): %d stack(s) selected", doc.Total))
+		return
+	}
+
+	printer.Stderr.Println(fmt.Sprintf(
+		"terramate: run summary: %d stack(s), %d succeeded, %d failed, %d skipped, %d canceled in %s",
+		doc.Total, doc.Succeeded, doc.Failed, doc.Skipped, doc.Canceled,
+		(time.Duration(doc.DurationMS) * time.Millisecond).String(),
+	))
+
+	var executed []*stackSummary
+	for _, res := range doc.Stacks {
+		if res.ExitCode != nil {
+			executed = append(executed, res)
+		}
+	}
+	if len(executed) == 0 {
+		return
+	}
+	sort.SliceStable(executed, func(i, j int) bool {
+		return executed[i].duration > executed[j].duration
+	})
+	if len(executed) > runSummarySlowest {
+		executed = executed[:runSummarySlowest]
+	}
+
+	var table bytes.Buffer
+	w := tabwriter.NewWriter(&table, 0, 0, 2, ' ', 0)
+	_, _ = w.Write([]byte("STACK\tDURATION\tEXIT CODE\n"))
+	for _, res := range executed {
+		_, _ = w.Write([]byte(res.Stack + "\t" +
+			res.duration.Round(time.Millisecond).String() + "\t" +
+			strconv.Itoa(*res.ExitCode) + "\n"))
+	}
+	_ = w.Flush()
+
+	printer.Stderr.Println("\nSlowest stacks:")
+	printer.Stderr.Println(strings.TrimSuffix(table.String(), "\n"))
+}
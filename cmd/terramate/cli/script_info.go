@@ -23,7 +23,7 @@ import (
 )
 
 func (c *cli) printScriptInfo() {
-	labels := c.parsedArgs.Script.Info.Cmds
+	labels, _ := splitScriptArgs(c.parsedArgs.Script.Info.Cmds)
 
 	stacks, err := c.computeSelectedStacks(false, outputsSharingFlags{}, cloudstack.AnyTarget, cloud.NoStatusFilters())
 	if err != nil {
@@ -35,7 +35,7 @@ func (c *cli) printScriptInfo() {
 
 	if len(m.Results) == 0 {
 		c.output.MsgStdErr(color.RedString("script not found: ") +
-			strings.Join(c.parsedArgs.Script.Info.Cmds, " "))
+			strings.Join(labels, " "))
 		os.Exit(1)
 	}
 
@@ -47,6 +47,16 @@ func (c *cli) printScriptInfo() {
 		if x.ScriptCfg.Description != nil {
 			c.output.MsgStdOut("Description: %s", descTruncation(exprString(x.ScriptCfg.Description.Expr), "script.description"))
 		}
+		if x.ScriptCfg.Enabled != nil {
+			c.output.MsgStdOut("Enabled: %s", exprString(x.ScriptCfg.Enabled.Expr))
+		}
+		if usesArgs, required := config.ScriptUsesArgs(*x.ScriptCfg); usesArgs {
+			if required > 0 {
+				c.output.MsgStdOut("Arguments: consumed via script.args (at least %d required)", required)
+			} else {
+				c.output.MsgStdOut("Arguments: consumed via script.args")
+			}
+		}
 		if len(x.Stacks) > 0 {
 			c.output.MsgStdOut("Stacks:")
 			for _, st := range x.Stacks {
@@ -9,6 +9,11 @@ import (
 	prj "github.com/terramate-io/terramate/project"
 )
 
+type stackScriptEntry struct {
+	ScriptCfg *hcl.Script
+	Enabled   bool
+}
+
 type scriptListEntry struct {
 	ScriptCfg *hcl.Script
 	Dir       string
@@ -18,6 +23,11 @@ type scriptListEntry struct {
 type scriptListMap map[string]*scriptListEntry
 
 func (c *cli) printScriptList() {
+	if c.parsedArgs.Script.List.Stack != "" {
+		c.printStackScriptList(c.parsedArgs.Script.List.Stack)
+		return
+	}
+
 	srcpath := prj.PrjAbsPath(c.rootdir(), c.wd())
 
 	cfg, found := c.cfg().Lookup(srcpath)
@@ -50,6 +60,62 @@ func (c *cli) printScriptList() {
 	}
 }
 
+// printStackScriptList prints the effective scripts of the stack at path.
+// A script defined in a directory shadows the script with the same name
+// defined in any parent directory.
+func (c *cli) printStackScriptList(path string) {
+	dir := c.projectPath(path)
+	st, found, err := config.TryLoadStack(c.cfg(), dir)
+	if err != nil {
+		fatalWithDetailf(err, "loading stack %s", dir)
+	}
+	if !found {
+		fatalf("%s is not a stack", dir)
+	}
+
+	ectx, err := scriptEvalContext(c.cfg(), st, "", nil)
+	if err != nil {
+		fatalWithDetailf(err, "failed to get context")
+	}
+
+	cfg, _ := c.cfg().Lookup(dir)
+	entries := map[string]*stackScriptEntry{}
+	for ; cfg != nil; cfg = cfg.Parent {
+		for _, sc := range cfg.Node.Scripts {
+			scriptname := sc.AccessorName()
+			if _, ok := entries[scriptname]; ok {
+				continue
+			}
+			enabled, err := config.EvalScriptEnabled(ectx, *sc)
+			if err != nil {
+				fatalWithDetailf(err, "failed to eval script")
+			}
+			entries[scriptname] = &stackScriptEntry{
+				ScriptCfg: sc,
+				Enabled:   enabled,
+			}
+		}
+	}
+
+	for _, name := range sortedKeys(entries) {
+		entry := entries[name]
+
+		if entry.Enabled {
+			c.output.MsgStdOut("%v", name)
+		} else {
+			c.output.MsgStdOut("%v (disabled)", name)
+		}
+		if entry.ScriptCfg.Name != nil {
+			c.output.MsgStdOut("  Name: %v", nameTruncation(exprString(entry.ScriptCfg.Name.Expr), "script.name"))
+		}
+		if entry.ScriptCfg.Description != nil {
+			c.output.MsgStdOut("  Description: %v", exprString(entry.ScriptCfg.Description.Expr))
+		}
+		c.output.MsgStdOut("  Defined at %v:%d", entry.ScriptCfg.Range.Path(), entry.ScriptCfg.Range.Start().Line())
+		c.output.MsgStdOut("")
+	}
+}
+
 func addParentScriptListEntries(cfg *config.Tree, entries scriptListMap) {
 	for _, sc := range cfg.Node.Scripts {
 		scriptname := sc.AccessorName()
@@ -4,9 +4,12 @@
 package cli
 
 import (
+	"bytes"
 	"fmt"
 	"io"
 	"os"
+	"path"
+	"path/filepath"
 	"strings"
 
 	"github.com/fatih/color"
@@ -16,9 +19,11 @@ import (
 	"github.com/terramate-io/terramate/config"
 	"github.com/terramate-io/terramate/errors"
 	"github.com/terramate-io/terramate/globals"
+	"github.com/terramate-io/terramate/hcl"
 	"github.com/terramate-io/terramate/hcl/eval"
 	"github.com/terramate-io/terramate/printer"
 	prj "github.com/terramate-io/terramate/project"
+	runutil "github.com/terramate-io/terramate/run"
 	"github.com/terramate-io/terramate/stdlib"
 	"github.com/zclconf/go-cty/cty"
 )
@@ -68,13 +73,19 @@ func (c *cli) runScript() {
 		}
 	}
 
+	stacks = selectChunk(c, c.parsedArgs.Script.Run.Chunk, stacks,
+		func(s *config.SortableStack) *config.Stack { return s.Stack })
+
+	c.checkStackAsserts(stacks)
+
 	// search for the script and prepare a list of script/stack entries
-	m := newScriptsMatcher(c.parsedArgs.Script.Run.Cmds)
+	labels, args := splitScriptArgs(c.parsedArgs.Script.Run.Cmds)
+	m := newScriptsMatcher(labels)
 	m.Search(c.cfg(), stacks)
 
 	if len(m.Results) == 0 {
 		c.output.MsgStdErr(color.RedString("script not found: ") +
-			strings.Join(c.parsedArgs.Script.Run.Cmds, " "))
+			strings.Join(labels, " "))
 		os.Exit(1)
 	}
 
@@ -84,12 +95,18 @@ func (c *cli) runScript() {
 
 	var runs []stackRun
 
+	// stacks where the script is found but disabled.
+	disabled := map[prj.Path]struct{}{}
+
+	// all the scripts run together, then they must agree on the job ordering.
+	orderByJob := false
+
 	for scriptIdx, result := range m.Results {
 		if len(result.Stacks) == 0 {
 			continue
 		}
 
-		if !c.parsedArgs.Quiet {
+		if !c.quiet() {
 			c.output.MsgStdErr("Script %s at %s having %s job(s)",
 				color.GreenString(fmt.Sprintf("%d", scriptIdx)),
 				color.BlueString(result.ScriptCfg.Range.String()),
@@ -98,55 +115,66 @@ func (c *cli) runScript() {
 		}
 
 		for _, st := range result.Stacks {
-			run := stackRun{Stack: st.Stack}
+			run := stackRun{Stack: st.Stack, SyncTaskIndex: -1}
 
-			ectx, err := scriptEvalContext(c.cfg(), st.Stack, c.parsedArgs.Script.Run.Target)
+			ectx, err := scriptEvalContext(c.cfg(), st.Stack, c.parsedArgs.Script.Run.Target, args)
 			if err != nil {
 				fatalWithDetailf(err, "failed to get context")
 			}
 
+			enabled, err := config.EvalScriptEnabled(ectx, *result.ScriptCfg)
+			if err != nil {
+				fatalWithDetailf(err, "failed to eval script")
+			}
+			if !enabled {
+				disabled[st.Dir()] = struct{}{}
+				if !c.quiet() {
+					c.output.MsgStdErr("Script %s is disabled in stack %s, skipping",
+						color.GreenString(fmt.Sprintf("%d", scriptIdx)),
+						color.BlueString(st.Dir().String()),
+					)
+				}
+				continue
+			}
+
 			evalScript, err := config.EvalScript(ectx, *result.ScriptCfg)
 			if err != nil {
 				fatalWithDetailf(err, "failed to eval script")
 			}
 
+			if len(runs) > 0 && evalScript.OrderedByJob() != orderByJob {
+				fatal(fmt.Sprintf("script %d in stack %s has a different job_ordering than the previous scripts: "+
+					"all the scripts run together must have the same job_ordering", scriptIdx, st.Dir()))
+			}
+			orderByJob = evalScript.OrderedByJob()
+
+			// files created by the -out flag of the commands, mapped to the
+			// job creating them, used to report the job expected to create
+			// the plan file synchronized to the cloud.
+			planFiles := map[string]int{}
+
 			for jobIdx, job := range evalScript.Jobs {
+				if job.Deferred {
+					// the commands of the job depend on the output of previous
+					// jobs, then they are evaluated when the job is executed.
+					run.Tasks = append(run.Tasks, stackRunTask{
+						ScriptIdx:     scriptIdx,
+						ScriptJobIdx:  jobIdx,
+						CaptureOutput: job.Capture,
+						evalDeferred: c.deferredScriptJobEvaluator(
+							ectx, st.Stack, *result.ScriptCfg, scriptIdx, jobIdx, job.Capture),
+					})
+					continue
+				}
+
 				for cmdIdx, cmd := range job.Commands() {
-					task := stackRunTask{
-						Cmd:             cmd.Args,
-						CloudTarget:     c.parsedArgs.Script.Run.Target,
-						CloudFromTarget: c.parsedArgs.Script.Run.FromTarget,
-						ScriptIdx:       scriptIdx,
-						ScriptJobIdx:    jobIdx,
-						ScriptCmdIdx:    cmdIdx,
-					}
+					addPlanFiles(planFiles, jobIdx, cmd.Args)
 
-					if cmd.Options != nil {
-						planFile, planProvisioner := selectPlanFile(
-							cmd.Options.CloudTerraformPlanFile,
-							cmd.Options.CloudTofuPlanFile)
-
-						task.CloudSyncDeployment = cmd.Options.CloudSyncDeployment
-						task.CloudSyncDriftStatus = cmd.Options.CloudSyncDriftStatus
-						task.CloudSyncPreview = cmd.Options.CloudSyncPreview
-						task.CloudSyncLayer = cmd.Options.CloudSyncLayer
-						task.CloudPlanFile = planFile
-						task.CloudPlanProvisioner = planProvisioner
-						task.UseTerragrunt = cmd.Options.UseTerragrunt
-						task.EnableSharing = cmd.Options.EnableSharing
-						task.MockOnFail = cmd.Options.MockOnFail
-
-						tel.DefaultRecord.Set(
-							tel.BoolFlag("sync-deployment", cmd.Options.CloudSyncDeployment),
-							tel.BoolFlag("sync-drift", cmd.Options.CloudSyncDriftStatus),
-							tel.BoolFlag("sync-preview", cmd.Options.CloudSyncPreview),
-							tel.StringFlag("terraform-planfile", cmd.Options.CloudTerraformPlanFile),
-							tel.StringFlag("tofu-planfile", cmd.Options.CloudTofuPlanFile),
-							tel.StringFlag("layer", string(cmd.Options.CloudSyncLayer)),
-							tel.BoolFlag("terragrunt", cmd.Options.UseTerragrunt),
-							tel.BoolFlag("output-sharing", cmd.Options.EnableSharing),
-							tel.BoolFlag("output-mocks", cmd.Options.MockOnFail),
-						)
+					task := c.newScriptTask(scriptIdx, jobIdx, cmdIdx, cmd)
+					task.CaptureOutput = job.Capture
+					if task.CloudSyncDeployment || task.CloudSyncDriftStatus || task.CloudSyncPreview {
+						task.CloudPlanFileHint = planFileHint(planFiles, jobIdx, task.CloudPlanFile)
+						task.evalOptions = scriptCmdOptionsEvaluator(ectx, *result.ScriptCfg, planFiles)
 					}
 					run.Tasks = append(run.Tasks, task)
 					if task.CloudSyncDeployment || task.CloudSyncDriftStatus || task.CloudSyncPreview {
@@ -159,19 +187,176 @@ func (c *cli) runScript() {
 		}
 	}
 
+	if c.parsedArgs.Script.Run.ErrorOnEmpty && len(runs) == 0 && len(disabled) > 0 {
+		c.exitOnDisabledScripts(labels, len(disabled))
+	}
+	if c.parsedArgs.Script.Run.ErrorOnEmpty && len(runs) == 0 {
+		c.exitOnEmptySelection(selectionFilters{
+			cloudFilterFlags:   c.parsedArgs.Script.Run.cloudFilterFlags,
+			pathFilterFlags:    c.parsedArgs.Script.Run.pathFilterFlags,
+			stackIDFilterFlags: c.parsedArgs.Script.Run.stackIDFilterFlags,
+			Target:             c.parsedArgs.Script.Run.Target,
+		})
+	}
+
 	c.prepareScriptForCloudSync(runs)
+	c.setupRunSummary(c.parsedArgs.Script.Run.Summary, c.parsedArgs.Script.Run.SummaryFile, c.parsedArgs.Script.Run.DryRun)
 
 	err := c.runAll(runs, runAllOptions{
-		Quiet:           c.parsedArgs.Quiet,
+		Quiet:           c.quiet(),
 		DryRun:          c.parsedArgs.Script.Run.DryRun,
 		Reverse:         c.parsedArgs.Script.Run.Reverse,
 		ScriptRun:       true,
 		ContinueOnError: c.parsedArgs.Script.Run.ContinueOnError,
 		Parallel:        c.parsedArgs.Script.Run.Parallel,
+		OrderByJob:      orderByJob,
+		SyncLogs:        c.syncLogsMode(""),
+		LogRedact:       c.cloudLogRedactPatterns(),
 	})
+	c.printRunSummary()
+	c.printCloudDeployment()
+	syncErr := c.reportCloudSyncFailures(c.parsedArgs.Script.Run.FailOnCloudError)
 	if err != nil {
 		fatalWithDetailf(err, "one or more commands failed")
 	}
+	if syncErr != nil {
+		fatal(syncErr)
+	}
+}
+
+func (c *cli) newScriptTask(scriptIdx, jobIdx, cmdIdx int, cmd *config.ScriptCmd) stackRunTask {
+	task := stackRunTask{
+		Cmd:             cmd.Args,
+		CloudTarget:     c.parsedArgs.Script.Run.Target,
+		CloudFromTarget: c.parsedArgs.Script.Run.FromTarget,
+		ScriptIdx:       scriptIdx,
+		ScriptJobIdx:    jobIdx,
+		ScriptCmdIdx:    cmdIdx,
+	}
+
+	if cmd.Options != nil {
+		planFile, planProvisioner := selectPlanFile(
+			cmd.Options.CloudTerraformPlanFile,
+			cmd.Options.CloudTofuPlanFile)
+
+		task.CloudSyncDeployment = cmd.Options.CloudSyncDeployment
+		task.CloudSyncDriftStatus = cmd.Options.CloudSyncDriftStatus
+		task.CloudSyncPreview = cmd.Options.CloudSyncPreview
+		task.CloudSyncLayer = cmd.Options.CloudSyncLayer
+		task.CloudPlanFile = planFile
+		task.CloudPlanProvisioner = planProvisioner
+		task.UseTerragrunt = cmd.Options.UseTerragrunt
+		task.EnableSharing = cmd.Options.EnableSharing
+		task.MockOnFail = cmd.Options.MockOnFail
+
+		tel.DefaultRecord.Set(
+			tel.BoolFlag("sync-deployment", cmd.Options.CloudSyncDeployment),
+			tel.BoolFlag("sync-drift", cmd.Options.CloudSyncDriftStatus),
+			tel.BoolFlag("sync-preview", cmd.Options.CloudSyncPreview),
+			tel.StringFlag("terraform-planfile", cmd.Options.CloudTerraformPlanFile),
+			tel.StringFlag("tofu-planfile", cmd.Options.CloudTofuPlanFile),
+			tel.StringFlag("layer", string(cmd.Options.CloudSyncLayer)),
+			tel.BoolFlag("terragrunt", cmd.Options.UseTerragrunt),
+			tel.BoolFlag("output-sharing", cmd.Options.EnableSharing),
+			tel.BoolFlag("output-mocks", cmd.Options.MockOnFail),
+		)
+	}
+	return task
+}
+
+// scriptCmdOptionsEvaluator returns the function which evaluates the options
+// of a script command right before the command is executed, then the options
+// observe the files created by the previous jobs of the script.
+func scriptCmdOptionsEvaluator(ectx *eval.Context, script hcl.Script, planFiles map[string]int) func(stackRunTask) (stackRunTask, error) {
+	return func(task stackRunTask) (stackRunTask, error) {
+		opts, err := config.EvalScriptCmdOptions(ectx, script, task.ScriptJobIdx, task.ScriptCmdIdx)
+		if err != nil {
+			return stackRunTask{}, err
+		}
+		if opts == nil {
+			opts = &config.ScriptCmdOptions{}
+		}
+
+		// the cloud sync flags were already used to prepare the cloud sync
+		// of the run, then only the details of the sync are updated.
+		task.CloudPlanFile, task.CloudPlanProvisioner = selectPlanFile(
+			opts.CloudTerraformPlanFile,
+			opts.CloudTofuPlanFile)
+		task.CloudSyncLayer = opts.CloudSyncLayer
+		task.UseTerragrunt = opts.UseTerragrunt
+		task.CloudPlanFileHint = planFileHint(planFiles, task.ScriptJobIdx, task.CloudPlanFile)
+		return task, nil
+	}
+}
+
+// addPlanFiles records the files created by the -out flag of the command
+// arguments (eg.: terraform plan -out=out.tfplan) as created by the job.
+func addPlanFiles(planFiles map[string]int, jobIdx int, args []string) {
+	for i, arg := range args {
+		var file string
+		switch {
+		case strings.HasPrefix(arg, "-out="):
+			file = strings.TrimPrefix(arg, "-out=")
+		case arg == "-out" && i+1 < len(args):
+			file = args[i+1]
+		default:
+			continue
+		}
+		file = path.Clean(filepath.ToSlash(file))
+		if _, ok := planFiles[file]; !ok {
+			planFiles[file] = jobIdx
+		}
+	}
+}
+
+// planFileHint returns a hint about the job expected to create the plan file
+// used by the job at index jobIdx.
+func planFileHint(planFiles map[string]int, jobIdx int, planfile string) string {
+	if planfile == "" {
+		return ""
+	}
+	if producer, ok := planFiles[path.Clean(filepath.ToSlash(planfile))]; ok && producer <= jobIdx {
+		return fmt.Sprintf("it is expected to be created by job %d of the script", producer)
+	}
+	return fmt.Sprintf("no job of the script up to job %d creates it, eg.: terraform plan -out=%s", jobIdx, planfile)
+}
+
+// deferredScriptJobEvaluator returns the function which evaluates the tasks of
+// a job referencing the job_output namespace, given the outputs captured by
+// the previous jobs executed in the stack.
+func (c *cli) deferredScriptJobEvaluator(
+	ectx *eval.Context,
+	st *config.Stack,
+	script hcl.Script,
+	scriptIdx, jobIdx int,
+	capture string,
+) func(outputs map[string]cty.Value) ([]stackRunTask, error) {
+	return func(outputs map[string]cty.Value) ([]stackRunTask, error) {
+		job, err := config.EvalScriptJob(ectx, script, jobIdx, outputs)
+		if err != nil {
+			return nil, err
+		}
+
+		// the captured outputs are printed as part of the commands, then
+		// values of sensitive globals are masked.
+		sensitive := c.sensitiveGlobals(false)
+		globalVals := evalContextGlobals(ectx)
+
+		var tasks []stackRunTask
+		for cmdIdx, cmd := range job.Commands() {
+			if _, _, err := runutil.TerraformDir(c.cfg(), st, cmd.Args); err != nil {
+				return nil, err
+			}
+			task := c.newScriptTask(scriptIdx, jobIdx, cmdIdx, cmd)
+			task.CaptureOutput = capture
+			task.DisplayCmd = make([]string, len(cmd.Args))
+			for i, arg := range cmd.Args {
+				task.DisplayCmd[i] = sensitive.RedactString(globalVals, arg)
+			}
+			tasks = append(tasks, task)
+		}
+		return tasks, nil
+	}
 }
 
 func (c *cli) prepareScriptForCloudSync(runs []stackRun) {
@@ -188,6 +373,7 @@ func (c *cli) prepareScriptForCloudSync(runs []stackRun) {
 
 	var feats []string
 	if len(deployRuns) > 0 {
+		c.gitSafeguardBranchProtection()
 		feats = append(feats, cloudFeatScriptSyncDeployment)
 	}
 	if len(driftRuns) > 0 {
@@ -197,8 +383,7 @@ func (c *cli) prepareScriptForCloudSync(runs []stackRun) {
 		feats = append(feats, cloudFeatScriptSyncPreview)
 	}
 
-	isCI := os.Getenv("GITHUB_ACTIONS") != "" || os.Getenv("GITLAB_CI") != "" || os.Getenv("BITBUCKET_BUILD_NUMBER") != ""
-	if len(previewRuns) > 0 && !isCI {
+	if len(previewRuns) > 0 && !c.syncPreviewSupported() {
 		printer.Stderr.Warn(cloudSyncPreviewCICDWarning)
 		c.disableCloudFeatures(errors.E(cloudSyncPreviewCICDWarning))
 		return
@@ -217,6 +402,7 @@ func (c *cli) prepareScriptForCloudSync(runs []stackRun) {
 	}
 
 	c.detectCloudMetadata()
+	c.loadCloudStacksMetadata(selectCloudStackTasks(runs, isDeploymentOrDriftTask))
 
 	if len(deployRuns) > 0 {
 		uuid, err := uuid.GenerateUUID()
@@ -254,10 +440,56 @@ func printScriptCommand(w io.Writer, stack *config.Stack, run stackRunTask) {
 	prompt := color.GreenString(fmt.Sprintf("%s (script:%d job:%d.%d)>",
 		stack.Dir.String(),
 		run.ScriptIdx, run.ScriptJobIdx, run.ScriptCmdIdx))
-	fprintln(w, prompt, color.YellowString(strings.Join(run.Cmd, " ")))
+	fprintln(w, prompt, color.YellowString(strings.Join(run.displayCmd(), " ")))
+}
+
+func printScriptJobSkipped(w io.Writer, stack *config.Stack, run stackRunTask) {
+	prompt := color.GreenString(fmt.Sprintf("%s (script:%d job:%d)>",
+		stack.Dir.String(), run.ScriptIdx, run.ScriptJobIdx))
+	fprintln(w, prompt, color.YellowString("skipped because a previous job failed"))
+}
+
+// captureBuffer stores the output of the commands of a job with the capture
+// attribute, up to limit bytes.
+type captureBuffer struct {
+	buf       bytes.Buffer
+	limit     int
+	truncated bool
+	reported  bool
+}
+
+func newCaptureBuffer(limit int) *captureBuffer {
+	return &captureBuffer{limit: limit}
+}
+
+// Write never fails, the data exceeding the limit is discarded.
+func (b *captureBuffer) Write(p []byte) (int, error) {
+	if room := b.limit - b.buf.Len(); len(p) > room {
+		b.truncated = true
+		if room > 0 {
+			b.buf.Write(p[:room])
+		}
+		return len(p), nil
+	}
+	return b.buf.Write(p)
+}
+
+func (b *captureBuffer) String() string {
+	return b.buf.String()
+}
+
+// splitScriptArgs splits the labels of the script from the arguments given
+// after "--", which are kept as is by the passthrough parsing of the script name.
+func splitScriptArgs(cmds []string) (labels []string, args []string) {
+	for i, cmd := range cmds {
+		if cmd == "--" {
+			return cmds[:i], cmds[i+1:]
+		}
+	}
+	return cmds, nil
 }
 
-func scriptEvalContext(root *config.Root, st *config.Stack, target string) (*eval.Context, error) {
+func scriptEvalContext(root *config.Root, st *config.Stack, target string, args []string) (*eval.Context, error) {
 	globalsReport := globals.ForStack(root, st)
 	if err := globalsReport.AsError(); err != nil {
 		return nil, err
@@ -273,6 +505,7 @@ func scriptEvalContext(root *config.Root, st *config.Stack, target string) (*eva
 
 	evalctx.SetNamespace("terramate", runtime)
 	evalctx.SetNamespace("global", globalsReport.Globals.AsValueMap())
+	config.SetScriptArgs(evalctx, args)
 	evalctx.SetEnv(os.Environ())
 
 	return evalctx, nil
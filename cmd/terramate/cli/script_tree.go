@@ -83,6 +83,9 @@ func (node *scriptsTreeNode) format(w io.Writer, prefix string, parentScripts []
 			desc := exprString(sc.Description.Expr)
 			fprintln(w, blockPrefix+"  "+scriptColor("  Description: "+desc))
 		}
+		if sc.Enabled != nil {
+			fprintln(w, blockPrefix+"  "+scriptColor("  Enabled: "+exprString(sc.Enabled.Expr)))
+		}
 	}
 
 	if node.IsStack {
@@ -0,0 +1,165 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package cli
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/terramate-io/terramate/config"
+	"github.com/terramate-io/terramate/generate"
+	"github.com/terramate-io/terramate/printer"
+	prj "github.com/terramate-io/terramate/project"
+	"github.com/terramate-io/terramate/stack"
+)
+
+func (c *cli) deleteStack() {
+	args := c.parsedArgs.Stack.Delete
+
+	dir := c.projectPath(args.Path)
+	absdir := dir.HostPath(c.rootdir())
+
+	plan, err := stack.PlanDelete(c.cfg(), dir, args.KeepDir)
+	if err != nil {
+		fatalWithDetailf(err, "unable to delete stack %s", dir)
+	}
+
+	if args.KeepDir {
+		genfiles, err := generate.ListStackGenFiles(c.cfg(), absdir)
+		if err != nil {
+			fatalWithDetailf(err, "listing generated files of stack %s", dir)
+		}
+		for _, file := range genfiles {
+			plan.GeneratedFiles = append(plan.GeneratedFiles, dir.Join(file))
+		}
+		plan.Files = append(plan.Files, plan.GeneratedFiles...)
+	}
+
+	st, err := config.LoadStack(c.cfg(), dir)
+	if err != nil {
+		fatalWithDetailf(err, "loading stack %s", dir)
+	}
+
+	c.printDeletePlan(plan)
+
+	if !args.Yes && !c.confirm("Delete stack %s?", dir) {
+		fatal("stack deletion aborted, use --yes to delete without confirmation")
+	}
+
+	// WHY: the cloud stack is only archived after the local deletion
+	// succeeds, so a failed deletion never leaves a live stack archived.
+	// The cloud lookup is done before, failing early on configuration errors.
+	var cloudStackID int64
+	var archive bool
+	if args.CloudArchive {
+		cloudStackID, archive = c.lookupCloudStack(st)
+	}
+
+	if err := stack.Delete(c.cfg(), plan); err != nil {
+		fatalWithDetailf(err, "deleting stack %s", dir)
+	}
+
+	if args.KeepDir {
+		c.output.MsgStdOut("Stack %s removed, the directory was kept", dir)
+	} else {
+		c.output.MsgStdOut("Stack %s deleted", dir)
+	}
+
+	if archive {
+		c.archiveCloudStack(st, cloudStackID)
+	}
+}
+
+func (c *cli) printDeletePlan(plan stack.DeletePlan) {
+	if plan.KeepDir {
+		c.output.MsgStdOut("The stack block will be removed from %s", plan.StackFile)
+	}
+	if len(plan.Files) > 0 {
+		c.output.MsgStdOut("Files to be removed:")
+		for _, file := range plan.Files {
+			c.output.MsgStdOut("\t%s", file)
+		}
+	}
+	if len(plan.Triggers) > 0 {
+		c.output.MsgStdOut("Triggers to be removed:")
+		for _, file := range plan.Triggers {
+			c.output.MsgStdOut("\t%s", file)
+		}
+	}
+	for _, ref := range plan.References {
+		printer.Stderr.Warnf("stack %s references %s in the %q attribute at %s",
+			ref.Stack, ref.Path, ref.Attribute, ref.Range)
+	}
+}
+
+// projectPath returns the project path of the given path, which is relative
+// to the working directory if not absolute.
+func (c *cli) projectPath(p string) prj.Path {
+	absdir := p
+	if !filepath.IsAbs(absdir) {
+		absdir = filepath.Join(c.wd(), absdir)
+	}
+	absdir = filepath.Clean(absdir)
+	if absdir != c.rootdir() && !strings.HasPrefix(absdir, c.rootdir()+string(filepath.Separator)) {
+		fatalf("path %s is outside the project", p)
+	}
+	return prj.PrjAbsPath(c.rootdir(), absdir)
+}
+
+// confirm asks a yes/no question in the standard input, defaulting to no.
+func (c *cli) confirm(format string, args ...any) bool {
+	fmt.Fprintf(c.stdout, format+" [y/N] ", args...)
+	answer, _ := bufio.NewReader(c.stdin).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+// lookupCloudStack returns the Terramate Cloud ID of the given stack and if
+// it was synced with Terramate Cloud.
+func (c *cli) lookupCloudStack(st *config.Stack) (int64, bool) {
+	if st.ID == "" {
+		fatalf("stack %s must have an ID to be archived in Terramate Cloud", st.Dir)
+	}
+
+	err := c.setupCloudConfig(nil)
+	if err != nil {
+		fatalWithDetailf(err, "unable to setup cloud configuration")
+	}
+
+	target := c.parsedArgs.Stack.Delete.Target
+	c.checkTargetsConfiguration(target, "", func(isTargetEnabled bool) {
+		if !isTargetEnabled {
+			fatal("--target must be set when terramate.config.cloud.targets.enabled is true")
+		}
+	})
+	if target == "" {
+		target = "default"
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultCloudTimeout)
+	defer cancel()
+
+	stackResp, found, err := c.cloud.client.GetStack(ctx, c.cloud.run.orgUUID, c.prj.prettyRepo(), target, st.ID)
+	if err != nil {
+		fatalWithDetailf(err, "unable to fetch stack")
+	}
+	if !found {
+		printer.Stderr.Warnf("stack %s was not synced with Terramate Cloud, nothing to archive", st.Dir)
+		return 0, false
+	}
+	return stackResp.ID, true
+}
+
+func (c *cli) archiveCloudStack(st *config.Stack, cloudStackID int64) {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultCloudTimeout)
+	defer cancel()
+
+	if err := c.cloud.client.ArchiveStack(ctx, c.cloud.run.orgUUID, cloudStackID); err != nil {
+		fatalWithDetailf(err, "unable to archive stack %s in Terramate Cloud", st.Dir)
+	}
+	c.output.MsgStdOut("Stack %s archived in Terramate Cloud", st.Dir)
+}
@@ -0,0 +1,81 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package cli
+
+import (
+	stdjson "encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/terramate-io/terramate/stack"
+)
+
+func (c *cli) printStackDependencies() {
+	args := c.parsedArgs.Experimental.Dependencies
+
+	path := args.Stack
+	if path == "" {
+		path = c.wd()
+	}
+	dir := c.projectPath(path)
+
+	deps, err := c.stackManager().Dependencies(dir)
+	if err != nil {
+		fatalWithDetailf(err, "computing dependencies of stack %s", dir)
+	}
+
+	if args.AsJSON {
+		data, err := stdjson.MarshalIndent(deps, "", "  ")
+		if err != nil {
+			fatalWithDetailf(err, "encoding dependencies of stack %s", dir)
+		}
+		c.output.MsgStdOut("%s", data)
+		return
+	}
+
+	c.output.MsgStdOut("Stack %s", deps.Stack)
+	printSection := func(title string, lines []string) {
+		if len(lines) == 0 {
+			return
+		}
+		c.output.MsgStdOut("%s:", title)
+		for _, line := range lines {
+			c.output.MsgStdOut("\t%s", line)
+		}
+	}
+	paths := func(paths []string) string {
+		if len(paths) == 0 {
+			return "(no stacks)"
+		}
+		return strings.Join(paths, ", ")
+	}
+
+	printSection("Parent stacks", deps.Parents.Strings())
+	for _, order := range []struct {
+		title string
+		deps  []stack.OrderDependency
+	}{
+		{"After", deps.After},
+		{"Before", deps.Before},
+	} {
+		var lines []string
+		for _, dep := range order.deps {
+			lines = append(lines, dep.Entry+": "+paths(dep.Stacks.Strings()))
+		}
+		printSection(order.title, lines)
+	}
+	printSection("Wants", deps.Wants.Strings())
+	printSection("Wanted by", deps.WantedBy.Strings())
+	printSection("Watch", deps.Watch.Strings())
+
+	var modules []string
+	for _, mod := range deps.TerraformModules {
+		modules = append(modules, fmt.Sprintf("%s (source %q called by %s)", mod.Path, mod.Source, mod.CalledBy))
+	}
+	printSection("Terraform modules", modules)
+
+	if deps.Terragrunt != nil {
+		printSection("Terragrunt dependencies", deps.Terragrunt.DependsOn.Strings())
+	}
+}
@@ -0,0 +1,55 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/terramate-io/terramate/config"
+	"github.com/terramate-io/terramate/stack"
+)
+
+// fixDuplicatedStackIDs sets a new ID to the stacks sharing the ID of another
+// stack of the project. The first stack, by path, keeps the ID. The project
+// configuration is reloaded afterwards.
+func (c *cli) fixDuplicatedStackIDs() {
+	dups, err := config.FindDuplicatedStackIDs(c.cfg().Tree())
+	if err != nil {
+		fatalWithDetailf(err, "looking for duplicated stack IDs")
+	}
+	if len(dups) == 0 {
+		c.output.MsgStdOut("No duplicated stack IDs found")
+		return
+	}
+
+	var table bytes.Buffer
+	w := tabwriter.NewWriter(&table, 0, 0, 2, ' ', 0)
+	_, _ = w.Write([]byte("STACK\tOLD ID\tNEW ID\n"))
+	for _, dup := range dups {
+		for _, dir := range dup.Stacks[1:] {
+			id, err := stack.UpdateStackID(c.cfg(), dir.HostPath(c.rootdir()))
+			if err != nil {
+				fatalWithDetailf(err, "failed to update stack.id of stack %s", dir)
+			}
+			_, _ = w.Write([]byte(dir.String() + "\t" + dup.ID + "\t" + id + "\n"))
+		}
+	}
+	_ = w.Flush()
+
+	c.output.MsgStdOut("Regenerated duplicated stack IDs:\n")
+	c.output.MsgStdOut("%s", strings.TrimSuffix(table.String(), "\n"))
+
+	root, err := config.LoadRoot(c.rootdir())
+	if err != nil {
+		fatalWithDetailf(err, "reloading the configuration")
+	}
+	c.prj.root = root
+	if c.prj.isRepo {
+		c.prj.stackManager = stack.NewGitAwareManager(root, c.prj.git.wrapper)
+	} else {
+		c.prj.stackManager = stack.NewManager(root)
+	}
+}
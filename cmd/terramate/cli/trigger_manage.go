@@ -0,0 +1,172 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package cli
+
+import (
+	stdjson "encoding/json"
+	"path"
+	"path/filepath"
+	"time"
+
+	prj "github.com/terramate-io/terramate/project"
+	"github.com/terramate-io/terramate/stack/trigger"
+)
+
+type triggerJSON struct {
+	Stack     string `json:"stack"`
+	File      string `json:"file"`
+	Kind      string `json:"kind"`
+	Reason    string `json:"reason"`
+	Ctime     int64  `json:"ctime"`
+	CreatedAt string `json:"created_at"`
+	Priority  int    `json:"priority,omitempty"`
+	Author    string `json:"author,omitempty"`
+}
+
+// managingTriggers tells if the trigger command lists or removes triggers
+// instead of creating them.
+func (c *cli) managingTriggers() bool {
+	args := c.parsedArgs.Trigger
+	return args.List || args.Clear || args.All || args.AsJSON
+}
+
+// manageTriggers handles the --list and --clear flags of the trigger command.
+// The stackPath is absolute to the project root or relative to the working
+// directory, and it may be empty.
+func (c *cli) manageTriggers(stackPath string) {
+	args := c.parsedArgs.Trigger
+	switch {
+	case args.List && args.Clear:
+		fatal("flags --list and --clear are conflicting")
+	case args.All && !args.Clear:
+		fatal("--all must be used together with --clear")
+	case args.AsJSON && !args.List:
+		fatal("--json must be used together with --list")
+	case args.Change || args.IgnoreChange || args.Reason != "" || args.Priority != 0:
+		fatal("--change, --ignore-change, --reason and --priority cannot be used with --list or --clear")
+	case args.Status != "" || args.ExperimentalStatus != "":
+		fatal("cloud filters such as --status are incompatible with --list and --clear")
+	case len(args.StackID) > 0:
+		fatal("--stack-id is incompatible with --list and --clear")
+	}
+
+	if args.List {
+		c.listTriggers(stackPath)
+		return
+	}
+
+	switch {
+	case stackPath != "" && args.All:
+		fatal("--clear expects either a stack path or --all")
+	case stackPath == "" && !args.All:
+		fatal("--clear expects a stack path or --all")
+	}
+
+	dir, recursive := prj.NewPath("/"), true
+	if !args.All {
+		dir, recursive = c.triggerPath(stackPath), args.Recursive
+	}
+	removed, err := trigger.Clear(c.cfg(), dir, recursive)
+	for _, entry := range removed {
+		c.output.MsgStdOut("Removed %s trigger of stack %q", triggerKindName(entry.Info.Type), entry.Info.StackPath)
+	}
+	if err != nil {
+		fatalWithDetailf(err, "unable to remove triggers")
+	}
+	c.output.MsgStdOut("Removed %d trigger(s)", len(removed))
+}
+
+func (c *cli) listTriggers(stackPath string) {
+	entries, err := trigger.List(c.cfg())
+	if err != nil {
+		fatalWithDetailf(err, "unable to list triggers")
+	}
+
+	if stackPath != "" {
+		dir := c.triggerPath(stackPath)
+		var filtered []trigger.Entry
+		for _, entry := range entries {
+			stackdir := entry.Info.StackPath
+			if stackdir == dir || (c.parsedArgs.Trigger.Recursive && stackdir.HasDirPrefix(dir.String())) {
+				filtered = append(filtered, entry)
+			}
+		}
+		entries = filtered
+	}
+
+	list := make([]triggerJSON, 0, len(entries))
+	for _, entry := range entries {
+		list = append(list, triggerJSON{
+			Stack:     entry.Info.StackPath.String(),
+			File:      entry.Path.String(),
+			Kind:      string(entry.Info.Type),
+			Reason:    entry.Info.Reason,
+			Ctime:     entry.Info.Ctime,
+			CreatedAt: time.Unix(entry.Info.Ctime, 0).UTC().Format(time.RFC3339),
+			Priority:  entry.Info.Priority,
+			Author:    c.triggerAuthor(entry.Path),
+		})
+	}
+
+	if c.parsedArgs.Trigger.AsJSON {
+		data, err := stdjson.MarshalIndent(list, "", "  ")
+		if err != nil {
+			fatalWithDetailf(err, "encoding triggers as JSON")
+		}
+		c.output.MsgStdOut("%s", data)
+		return
+	}
+
+	for _, tr := range list {
+		c.output.MsgStdOut("%s", tr.Stack)
+		c.output.MsgStdOut("  Kind: %s", tr.Kind)
+		c.output.MsgStdOut("  Reason: %s", tr.Reason)
+		c.output.MsgStdOut("  Created: %s", tr.CreatedAt)
+		if tr.Author != "" {
+			c.output.MsgStdOut("  Author: %s", tr.Author)
+		}
+		if tr.Priority != 0 {
+			c.output.MsgStdOut("  Priority: %d", tr.Priority)
+		}
+		c.output.MsgStdOut("  File: %s", tr.File)
+		c.output.MsgStdOut("")
+	}
+}
+
+// triggerAuthor returns the author of the commit which added the trigger
+// file, or an empty string if the file is not committed.
+func (c *cli) triggerAuthor(file prj.Path) string {
+	if !c.prj.isRepo {
+		return ""
+	}
+	commit, err := c.prj.git.wrapper.FileCreationCommit(file.HostPath(c.rootdir()))
+	if err != nil || commit == "" {
+		return ""
+	}
+	metadata, err := c.prj.git.wrapper.ShowCommitMetadata(commit)
+	if err != nil {
+		return ""
+	}
+	return metadata.Author + " <" + metadata.Email + ">"
+}
+
+// triggerPath returns the project path of the stack path given to the
+// trigger command. The stack does not need to exist anymore, so stale
+// triggers can still be managed.
+func (c *cli) triggerPath(stackPath string) prj.Path {
+	if path.IsAbs(stackPath) {
+		return prj.NewPath(path.Clean(stackPath))
+	}
+	return c.projectPath(filepath.Join(c.wd(), filepath.FromSlash(stackPath)))
+}
+
+func triggerKindName(kind trigger.Kind) string {
+	switch kind {
+	case trigger.Ignored:
+		return "ignore"
+	case trigger.Changed:
+		return "change"
+	}
+	return "invalid"
+}
@@ -0,0 +1,86 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package cli
+
+import (
+	stdjson "encoding/json"
+	stdfmt "fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/terramate-io/terramate/config/validate"
+	"github.com/terramate-io/terramate/hcl"
+)
+
+type validateOutput struct {
+	Valid       bool                 `json:"valid"`
+	Diagnostics validate.Diagnostics `json:"diagnostics"`
+}
+
+// validateProject validates the configuration of the project containing wd and
+// exits with status 1 if any error is found.
+func validateProject(wd string, jsonOutput bool) {
+	rootdir, found := lookupProjectRoot(wd)
+	if !found {
+		fatal("unable to detect a project root: run inside a git repository or a directory with a root Terramate config")
+	}
+
+	diags := validate.Project(rootdir)
+	if jsonOutput {
+		out := validateOutput{
+			Valid:       !diags.HasErrors(),
+			Diagnostics: diags,
+		}
+		if out.Diagnostics == nil {
+			out.Diagnostics = validate.Diagnostics{}
+		}
+		data, err := stdjson.MarshalIndent(out, "", "  ")
+		if err != nil {
+			fatalWithDetailf(err, "encoding the diagnostics")
+		}
+		stdfmt.Println(string(data))
+	} else {
+		for _, diag := range diags {
+			stdfmt.Println(diag.String())
+		}
+	}
+
+	if diags.HasErrors() {
+		os.Exit(1)
+	}
+}
+
+// lookupProjectRoot finds the root directory of the project containing wd
+// without loading the configuration, so it works with invalid configuration.
+// The root is the closest git repository or, outside a repository, the closest
+// directory with a root config. A directory whose files cannot be parsed is
+// only used as root if no other is found.
+func lookupProjectRoot(wd string) (string, bool) {
+	var cfgroot, invalidroot string
+	dir := wd
+	for {
+		if _, err := os.Lstat(filepath.Join(dir, ".git")); err == nil {
+			return dir, true
+		}
+		if cfgroot == "" {
+			isRoot, err := hcl.IsRootConfig(dir)
+			if err != nil && invalidroot == "" {
+				invalidroot = dir
+			}
+			if isRoot {
+				cfgroot = dir
+			}
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+	if cfgroot != "" {
+		return cfgroot, true
+	}
+	return invalidroot, invalidroot != ""
+}
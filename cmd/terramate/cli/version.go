@@ -0,0 +1,141 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package cli
+
+import (
+	stdjson "encoding/json"
+	stdfmt "fmt"
+	"time"
+
+	"github.com/terramate-io/go-checkpoint"
+	"github.com/terramate-io/terramate/cmd/terramate/cli/out"
+)
+
+// checkpointTimeout is the maximum time `version --json` and `version --check`
+// wait for the checkpoint response. It bounds the wait even if the checkpoint client is
+// configured without a timeout (eg.: CHECKPOINT_TIMEOUT=0).
+const checkpointTimeout = 3 * time.Second
+
+const (
+	versionStatusUnknown  = "unknown"
+	versionStatusOutdated = "outdated"
+	versionStatusUpToDate = "up-to-date"
+)
+
+// versionInfo is the output of `terramate version --json`. The checkpoint
+// fields are null when checkpoint is disabled or unreachable.
+type versionInfo struct {
+	Version       string         `json:"version"`
+	Status        string         `json:"status"`
+	LatestVersion *string        `json:"latest_version"`
+	ReleaseDate   *string        `json:"release_date"`
+	DownloadURL   *string        `json:"download_url"`
+	Alerts        []versionAlert `json:"alerts"`
+}
+
+type versionAlert struct {
+	Level   string `json:"level"`
+	Message string `json:"message"`
+	URL     string `json:"url,omitempty"`
+}
+
+// waitCheckpoint waits for the checkpoint response, returning nil if it
+// fails or takes longer than [checkpointTimeout].
+func waitCheckpoint(results <-chan *checkpoint.CheckResponse) *checkpoint.CheckResponse {
+	timer := time.NewTimer(checkpointTimeout)
+	defer timer.Stop()
+
+	select {
+	case info := <-results:
+		return info
+	case <-timer.C:
+		return nil
+	}
+}
+
+func newVersionInfo(version string, info *checkpoint.CheckResponse) versionInfo {
+	v := versionInfo{
+		Version: version,
+		Status:  versionStatusUnknown,
+	}
+	// an empty response is returned when checkpoint is disabled by the
+	// CHECKPOINT_DISABLE environment variable.
+	if info == nil || info.CurrentVersion == "" {
+		return v
+	}
+
+	v.Status = versionStatusUpToDate
+	if info.Outdated {
+		v.Status = versionStatusOutdated
+	}
+
+	releaseDate := time.Unix(int64(info.CurrentReleaseDate), 0).UTC().Format(time.RFC3339)
+	v.LatestVersion = &info.CurrentVersion
+	v.ReleaseDate = &releaseDate
+	v.DownloadURL = &info.CurrentDownloadURL
+	v.Alerts = []versionAlert{}
+	for _, alert := range info.Alerts {
+		v.Alerts = append(v.Alerts, versionAlert{
+			Level:   alert.Level,
+			Message: alert.Message,
+			URL:     alert.URL,
+		})
+	}
+	return v
+}
+
+func printVersionJSON(version string, info *checkpoint.CheckResponse) {
+	data, err := stdjson.MarshalIndent(newVersionInfo(version, info), "", "  ")
+	if err != nil {
+		fatalWithDetailf(err, "encoding version information")
+	}
+	stdfmt.Println(string(data))
+}
+
+// pollCheckpoint returns the checkpoint response if it's already available,
+// without waiting for it.
+func pollCheckpoint(results <-chan *checkpoint.CheckResponse) *checkpoint.CheckResponse {
+	select {
+	case info := <-results:
+		return info
+	default:
+		return nil
+	}
+}
+
+// printVersion prints the version right away. The update notice is only
+// appended if the checkpoint has already returned, so it never waits for it.
+func printVersion(output out.O, version string, results <-chan *checkpoint.CheckResponse) {
+	stdfmt.Println(version)
+
+	info := pollCheckpoint(results)
+	if info == nil {
+		return
+	}
+
+	if info.Outdated {
+		releaseDate := time.Unix(int64(info.CurrentReleaseDate), 0).UTC()
+		output.MsgStdOut("\nYour version of Terramate is out of date! The latest version\n"+
+			"is %s (released on %s).\nYou can update by downloading from %s",
+			info.CurrentVersion, releaseDate.Format(time.UnixDate),
+			info.CurrentDownloadURL)
+	}
+
+	if len(info.Alerts) > 0 {
+		plural := ""
+		if len(info.Alerts) > 1 {
+			plural = "s"
+		}
+
+		output.MsgStdOut("\nYour version of Terramate has %d alert%s:\n", len(info.Alerts), plural)
+
+		for _, alert := range info.Alerts {
+			urlDesc := ""
+			if alert.URL != "" {
+				urlDesc = stdfmt.Sprintf(" (more information at %s)", alert.URL)
+			}
+			output.MsgStdOut("\t- [%s] %s%s", alert.Level, alert.Message, urlDesc)
+		}
+	}
+}
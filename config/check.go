@@ -0,0 +1,78 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package config
+
+import (
+	"github.com/terramate-io/terramate/errors"
+	"github.com/terramate-io/terramate/hcl"
+	"github.com/terramate-io/terramate/hcl/eval"
+
+	hhcl "github.com/terramate-io/hcl/v2"
+)
+
+// Severity levels of the check blocks.
+const (
+	CheckSeverityError = "error"
+	CheckSeverityWarn  = "warn"
+)
+
+// Check represents evaluated check block configuration.
+type Check struct {
+	Name      string
+	Assertion bool
+	Severity  string
+	Message   string
+	Range     hhcl.Range
+}
+
+// Warning tells if a failure of the check is reported as a warning instead
+// of an error.
+func (c Check) Warning() bool {
+	return c.Severity == CheckSeverityWarn
+}
+
+// EvalCheck evaluates a given check configuration and returns its
+// evaluated form. The severity defaults to [CheckSeverityError].
+func EvalCheck(evalctx *eval.Context, cfg hcl.CheckConfig) (Check, error) {
+	res := Check{
+		Name:     cfg.Name,
+		Severity: CheckSeverityError,
+	}
+	errs := errors.L()
+
+	assertion, err := evalBool(evalctx, cfg.Assertion, "check.assertion")
+	if err != nil {
+		errs.Append(err)
+	} else {
+		res.Assertion = assertion
+		res.Range = cfg.Assertion.Range()
+	}
+
+	message, err := evalString(evalctx, cfg.Message, "check.message")
+	if err != nil {
+		errs.Append(err)
+	} else {
+		res.Message = message
+	}
+
+	if cfg.Severity != nil {
+		severity, err := evalString(evalctx, cfg.Severity, "check.severity")
+		switch {
+		case err != nil:
+			errs.Append(err)
+		case severity != CheckSeverityError && severity != CheckSeverityWarn:
+			errs.Append(errors.E(ErrSchema, cfg.Severity.Range(),
+				"check.severity must be %q or %q, got %q",
+				CheckSeverityError, CheckSeverityWarn, severity))
+		default:
+			res.Severity = severity
+		}
+	}
+
+	if err := errs.AsError(); err != nil {
+		return Check{}, err
+	}
+
+	return res, nil
+}
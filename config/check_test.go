@@ -0,0 +1,133 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package config_test
+
+import (
+	"testing"
+
+	"github.com/madlambda/spells/assert"
+	hhcl "github.com/terramate-io/hcl/v2"
+	"github.com/terramate-io/terramate/config"
+	"github.com/terramate-io/terramate/errors"
+	"github.com/terramate-io/terramate/hcl"
+	"github.com/terramate-io/terramate/hcl/eval"
+	"github.com/terramate-io/terramate/stdlib"
+	"github.com/terramate-io/terramate/test"
+)
+
+func TestCheckConfigEval(t *testing.T) {
+	t.Parallel()
+	type testcase struct {
+		name       string
+		check      hcl.CheckConfig
+		namespaces namespaces
+		want       config.Check
+		wantErr    error
+	}
+
+	expr := func(s string) hhcl.Expression {
+		return test.NewExpr(t, s)
+	}
+
+	tcases := []testcase{
+		{
+			name: "severity defaults to error",
+			check: hcl.CheckConfig{
+				Name:      "backend",
+				Assertion: expr(`"a" == "terramate"`),
+				Message:   expr(`"something"`),
+			},
+			want: config.Check{
+				Name:      "backend",
+				Assertion: false,
+				Severity:  config.CheckSeverityError,
+				Message:   "something",
+			},
+		},
+		{
+			name: "accessing namespace values",
+			namespaces: namespaces{
+				"ns": nsvalues{
+					"a":        "terramate",
+					"severity": "warn",
+				},
+			},
+			check: hcl.CheckConfig{
+				Name:      "tags",
+				Assertion: expr(`ns.a == "terramate"`),
+				Message:   expr(`"${ns.a} message"`),
+				Severity:  expr(`ns.severity`),
+			},
+			want: config.Check{
+				Name:      "tags",
+				Assertion: true,
+				Severity:  config.CheckSeverityWarn,
+				Message:   "terramate message",
+			},
+		},
+		{
+			name: "assertion undefined fails",
+			check: hcl.CheckConfig{
+				Message: expr(`"something"`),
+			},
+			wantErr: errors.E(config.ErrSchema),
+		},
+		{
+			name: "assertion is not boolean fails",
+			check: hcl.CheckConfig{
+				Assertion: expr(`[]`),
+				Message:   expr(`"something"`),
+			},
+			wantErr: errors.E(config.ErrSchema),
+		},
+		{
+			name: "message is not string fails",
+			check: hcl.CheckConfig{
+				Assertion: expr(`true`),
+				Message:   expr(`false`),
+			},
+			wantErr: errors.E(config.ErrSchema),
+		},
+		{
+			name: "unknown severity fails",
+			check: hcl.CheckConfig{
+				Assertion: expr(`true`),
+				Message:   expr(`"msg"`),
+				Severity:  expr(`"fatal"`),
+			},
+			wantErr: errors.E(config.ErrSchema),
+		},
+		{
+			name: "multiple errors",
+			check: hcl.CheckConfig{
+				Assertion: expr(`unknown.val`),
+				Message:   expr(`false`),
+				Severity:  expr(`access.severity`),
+			},
+			wantErr: errors.L(
+				errors.E(eval.ErrEval),
+				errors.E(config.ErrSchema),
+				errors.E(eval.ErrEval),
+			),
+		},
+	}
+
+	for _, tcase := range tcases {
+		tcase := tcase
+		t.Run(tcase.name, func(t *testing.T) {
+			t.Parallel()
+			hclctx := eval.NewContext(stdlib.Functions(test.TempDir(t), []string{}))
+
+			for k, v := range tcase.namespaces {
+				hclctx.SetNamespace(k, v.asCtyMap())
+			}
+
+			got, err := config.EvalCheck(hclctx, tcase.check)
+			assert.IsError(t, err, tcase.wantErr)
+			// ranges of the expressions built by the test are not checked.
+			got.Range = hhcl.Range{}
+			test.AssertDiff(t, got, tcase.want)
+		})
+	}
+}
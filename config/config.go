@@ -47,6 +47,15 @@ type Root struct {
 	hasTerragruntStacks *bool
 
 	runtime project.Runtime
+
+	// importedBy maps the imported files to the directories importing them.
+	// It's computed on demand and dropped when the configuration is reloaded.
+	importedBy map[string]project.Paths
+
+	reloadHooks []ReloadHook
+
+	// globalOverrides are the globals set from outside of the configuration.
+	globalOverrides []GlobalOverride
 }
 
 // Tree is the configuration tree.
@@ -62,6 +71,10 @@ type Tree struct {
 	OtherFiles     []string
 	TmGenFiles     []string
 
+	// ImportedFiles are the host paths of the files imported by the
+	// configuration of this node, directly or by other imported files.
+	ImportedFiles []string
+
 	// Children is a map of configuration dir names to tree nodes.
 	Children map[string]*Tree
 
@@ -72,6 +85,9 @@ type Tree struct {
 	root  *Root
 	stack *Stack
 
+	// overlay is the overlay directory, only set if Parent == nil.
+	overlay string
+
 	dir string
 
 	// used for caching the loaded stack.
@@ -100,17 +116,19 @@ func TryLoadConfig(fromdir string) (tree *Root, configpath string, found bool, e
 		}
 
 		if ok {
-			cfg, err := hcl.ParseDir(fromdir, fromdir)
+			rootTree, err := parseRootTree(fromdir, "")
 			if err != nil {
 				return nil, fromdir, true, err
 			}
-			rootTree := NewTree(fromdir)
-			rootTree.Node = cfg
 			_, err = loadTree(rootTree, fromdir, nil)
 			if err != nil {
 				return nil, fromdir, true, err
 			}
-			return NewRoot(rootTree), fromdir, true, err
+			root := NewRoot(rootTree)
+			if err := checkPathCase(root); err != nil {
+				return nil, fromdir, true, err
+			}
+			return root, fromdir, true, nil
 		}
 
 		parent, ok := parentDir(fromdir)
@@ -134,17 +152,52 @@ func NewRoot(tree *Tree) *Root {
 
 // LoadRoot loads the root configuration tree.
 func LoadRoot(rootdir string) (*Root, error) {
-	rootcfg, err := hcl.ParseDir(rootdir, rootdir)
+	return loadRoot(rootdir, "")
+}
+
+// loadRoot loads the root configuration tree, with the files of the overlay
+// directory layered over the project files if overlaydir is not empty.
+func loadRoot(rootdir, overlaydir string) (*Root, error) {
+	root, err := parseRootTree(rootdir, overlaydir)
 	if err != nil {
 		return nil, err
 	}
-	root := NewTree(rootdir)
-	root.Node = rootcfg
 	cfgtree, err := loadTree(root, rootdir, nil)
 	if err != nil {
 		return nil, err
 	}
-	return NewRoot(cfgtree), nil
+	r := NewRoot(cfgtree)
+	if err := checkPathCase(r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// parseRootTree parses the configuration of the root directory into a new
+// tree node, without loading its child directories. The overlay directory
+// is optional, see [LoadRootWithOverlay].
+func parseRootTree(rootdir, overlaydir string) (*Tree, error) {
+	p, err := hcl.NewTerramateParser(rootdir, rootdir)
+	if err != nil {
+		return nil, err
+	}
+	root := NewTree(rootdir)
+	root.overlay = overlaydir
+
+	filesResult, err := fs.ListTerramateFiles(rootdir)
+	if err != nil {
+		return nil, errors.E("adding files to parser", err)
+	}
+	if _, err := root.addTmFiles(p, rootdir, filesResult.TmFiles); err != nil {
+		return nil, errors.E("adding files to parser", err)
+	}
+	cfg, err := p.ParseConfig()
+	if err != nil {
+		return nil, err
+	}
+	root.Node = cfg
+	root.ImportedFiles = p.ImportedFiles()
+	return root, nil
 }
 
 // Tree returns the root configuration tree.
@@ -229,7 +282,14 @@ func (root *Root) StackByID(id string) (*Stack, bool, error) {
 		return nil, false, nil
 	}
 	if len(stacks) > 1 {
-		printer.Stderr.Warnf("multiple stacks with the same ID %q found.", id)
+		dup := DuplicatedStackID{ID: id}
+		for _, st := range stacks {
+			dup.Stacks = append(dup.Stacks, st.Dir())
+		}
+		sort.Slice(dup.Stacks, func(i, j int) bool {
+			return dup.Stacks[i].String() < dup.Stacks[j].String()
+		})
+		return nil, true, errors.E(ErrStackDuplicatedID, dup.String())
 	}
 	stack, err := stacks[0].Stack()
 	if err != nil {
@@ -265,7 +325,7 @@ func (root *Root) LoadSubTree(cfgdir project.Path) error {
 	nextComponent := components[0]
 	subtreeDir := filepath.Join(rootdir, parent.String(), nextComponent)
 
-	node, err := loadTree(root.Tree(), subtreeDir, nil)
+	node, err := loadTree(parentNode, subtreeDir, nil)
 	if err != nil {
 		return errors.E(err, "failed to load config from %s", subtreeDir)
 	}
@@ -412,6 +472,42 @@ func (tree *Tree) stacks(cond func(*Tree) bool) List[*Tree] {
 	return stacks
 }
 
+// IsLeafStack tells if the tree is a stack without any stack below it.
+func (tree *Tree) IsLeafStack() bool {
+	if !tree.IsStack() {
+		return false
+	}
+	var hasChildStacks func(*Tree) bool
+	hasChildStacks = func(node *Tree) bool {
+		for _, child := range node.Children {
+			if child.IsStack() || hasChildStacks(child) {
+				return true
+			}
+		}
+		return false
+	}
+	return !hasChildStacks(tree)
+}
+
+// childStacks returns the closest stacks below the tree, without the stacks
+// nested inside them, sorted by path.
+func (tree *Tree) childStacks() List[*Tree] {
+	var stacks List[*Tree]
+	var walk func(*Tree)
+	walk = func(node *Tree) {
+		for _, child := range node.Children {
+			if child.IsStack() {
+				stacks = append(stacks, child)
+				continue
+			}
+			walk(child)
+		}
+	}
+	walk(tree)
+	sort.Sort(stacks)
+	return stacks
+}
+
 // Lookup a node from the tree using a filesystem query path.
 // The abspath is relative to the current tree node.
 func (tree *Tree) lookup(abspath project.Path) (node *Tree, skipped bool, found bool) {
@@ -485,17 +581,9 @@ func loadTree(parentTree *Tree, cfgdir string, rootcfg *hcl.Config) (_ *Tree, er
 		if err != nil {
 			return nil, err
 		}
-		for _, filename := range filesResult.TmFiles {
-			path := filepath.Join(cfgdir, filename)
-
-			data, err := os.ReadFile(path)
-			if err != nil {
-				return nil, errors.E(err, "reading config file %q", path)
-			}
-
-			if err := p.AddFileContent(path, data); err != nil {
-				return nil, err
-			}
+		tmfiles, err := parentTree.addTmFiles(p, cfgdir, filesResult.TmFiles)
+		if err != nil {
+			return nil, err
 		}
 		cfg, err := p.ParseConfig()
 		if err != nil {
@@ -507,7 +595,8 @@ func loadTree(parentTree *Tree, cfgdir string, rootcfg *hcl.Config) (_ *Tree, er
 		}
 
 		tree.Node = cfg
-		tree.TerramateFiles = filesResult.TmFiles
+		tree.ImportedFiles = p.ImportedFiles()
+		tree.TerramateFiles = tmfiles
 		tree.OtherFiles = filesResult.OtherFiles
 		tree.TmGenFiles = filesResult.TmGenFiles
 		tree.Parent = parentTree
@@ -521,6 +610,10 @@ func loadTree(parentTree *Tree, cfgdir string, rootcfg *hcl.Config) (_ *Tree, er
 		return nil, err
 	}
 
+	if err := checkDirsCase(cfgdir, filesResult.Dirs); err != nil {
+		return nil, err
+	}
+
 	for _, fname := range filesResult.Dirs {
 		if Skip(fname) {
 			continue
@@ -565,6 +658,7 @@ func processTmGenFiles(rootTree *Tree, cfg *hcl.Config, cfgdir string, files []s
 		if diags.HasErrors() {
 			return errors.E(diags, "failed to parse .tmgen file")
 		}
+		hcl.FixFlushHeredocs(hclFile, content)
 
 		lines := bytes.Split(content, []byte{'\n'})
 		nLines := len(lines)
@@ -713,6 +807,20 @@ func (root *Root) IsTerragruntChangeDetectionEnabled() (ret bool) {
 	}
 }
 
+// IsOutputsPropagationEnabled returns true if the stacks with inputs from a
+// changed stack must also be considered changed, which is configured by the
+// `terramate.config.change_detection.propagate_outputs` option and requires
+// the outputs-sharing experiment.
+func (root *Root) IsOutputsPropagationEnabled() bool {
+	if !root.HasExperiment(hcl.SharingIsCaringExperimentName) {
+		return false
+	}
+	return root.tree.Node.Terramate != nil &&
+		root.tree.Node.Terramate.Config != nil &&
+		root.tree.Node.Terramate.Config.ChangeDetection != nil &&
+		root.tree.Node.Terramate.Config.ChangeDetection.PropagateOutputs
+}
+
 // IsTargetsEnabled returns the configured `terramate.config.cloud.targets.enabled` option.
 func (root *Root) IsTargetsEnabled() bool {
 	if root.tree.Node.Terramate != nil &&
@@ -724,6 +832,38 @@ func (root *Root) IsTargetsEnabled() bool {
 	return false
 }
 
+// CloudLogsConfig returns the configured `terramate.config.cloud.logs` block
+// or nil if not set.
+func (root *Root) CloudLogsConfig() *hcl.CloudLogsConfig {
+	if root.tree.Node.Terramate != nil &&
+		root.tree.Node.Terramate.Config != nil &&
+		root.tree.Node.Terramate.Config.Cloud != nil {
+		return root.tree.Node.Terramate.Config.Cloud.Logs
+	}
+	return nil
+}
+
+// StackFilename returns the file name configured in
+// `terramate.config.stack.filename` or an empty string if not set.
+func (root *Root) StackFilename() string {
+	if root.tree.Node.Terramate != nil &&
+		root.tree.Node.Terramate.Config != nil &&
+		root.tree.Node.Terramate.Config.Stack != nil {
+		return root.tree.Node.Terramate.Config.Stack.Filename
+	}
+	return ""
+}
+
+// IsOrderingCheckStrict tells if invalid stack ordering entries must fail
+// instead of being reported as warnings, which is configured by the
+// `terramate.config.run.check_ordering` option.
+func (root *Root) IsOrderingCheckStrict() bool {
+	return root.tree.Node.Terramate != nil &&
+		root.tree.Node.Terramate.Config != nil &&
+		root.tree.Node.Terramate.Config.Run != nil &&
+		root.tree.Node.Terramate.Config.Run.CheckOrdering == hcl.RunCheckOrderingError
+}
+
 // Skip returns true if the given file/dir name should be ignored by Terramate.
 func Skip(name string) bool {
 	// assumes filename length > 0
@@ -120,6 +120,32 @@ func TestConfigLookup(t *testing.T) {
 	assert.EqualStrings(t, "/stacks/child/non-stack/stack", stacks[2].Dir().String())
 }
 
+func TestConfigLoadSubTree(t *testing.T) {
+	t.Parallel()
+	s := sandbox.NoGit(t, true)
+	s.BuildTree([]string{
+		"s:/stacks/a",
+	})
+
+	root := s.Config()
+	s.BuildTree([]string{
+		"s:/stacks/b",
+	})
+	assert.NoError(t, root.LoadSubTree(project.NewPath("/stacks/b")))
+
+	node, found := root.Lookup(project.NewPath("/stacks/b"))
+	assert.IsTrue(t, found && node.IsStack())
+	assert.EqualStrings(t, "/stacks", node.Parent.Dir().String())
+
+	_, found = root.Tree().Children["b"]
+	assert.IsTrue(t, !found, "subtree must not be added to the root")
+
+	stacks := root.Tree().Stacks()
+	assert.EqualInts(t, 2, len(stacks))
+	assert.EqualStrings(t, "/stacks/a", stacks[0].Dir().String())
+	assert.EqualStrings(t, "/stacks/b", stacks[1].Dir().String())
+}
+
 func TestConfigStacksByPaths(t *testing.T) {
 	t.Parallel()
 	type testcase struct {
@@ -0,0 +1,128 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package filter
+
+import (
+	"path"
+	"strings"
+
+	"github.com/gobwas/glob"
+	"github.com/terramate-io/terramate/errors"
+	"github.com/terramate-io/terramate/project"
+)
+
+// ErrInvalidPathPattern indicates that a path filter pattern is invalid.
+const ErrInvalidPathPattern errors.Kind = "invalid path filter pattern"
+
+// PathFilter selects directories by include and exclude patterns.
+// A pattern is either a path, which matches the directory and all of its
+// subdirectories, or a glob (eg.: /stacks/*/prod/**), which matches the
+// directories it describes. In globs, "*" matches a single directory level and
+// "**" matches any number of levels.
+type PathFilter struct {
+	include []pathPattern
+	exclude []pathPattern
+}
+
+type pathPattern struct {
+	pattern string
+
+	// globs is set if the pattern is a glob. A trailing "/**" also matches
+	// the directory itself, which is matched by a second glob without it.
+	globs []glob.Glob
+}
+
+// ParsePathFilter parses the include and exclude patterns. Patterns not
+// starting with "/" are relative to the given base directory.
+func ParsePathFilter(base project.Path, include, exclude []string) (PathFilter, error) {
+	var filter PathFilter
+	var err error
+	filter.include, err = parsePathPatterns(base, include)
+	if err != nil {
+		return PathFilter{}, err
+	}
+	filter.exclude, err = parsePathPatterns(base, exclude)
+	if err != nil {
+		return PathFilter{}, err
+	}
+	return filter, nil
+}
+
+// IsEmpty tells if the filter has no patterns, which matches everything.
+func (f PathFilter) IsEmpty() bool {
+	return len(f.include) == 0 && len(f.exclude) == 0
+}
+
+// Match tells if the given directory matches any include pattern (or there's
+// no include pattern) and doesn't match any exclude pattern.
+func (f PathFilter) Match(dir project.Path) bool {
+	if len(f.include) > 0 && !matchAny(f.include, dir) {
+		return false
+	}
+	return !matchAny(f.exclude, dir)
+}
+
+func matchAny(patterns []pathPattern, dir project.Path) bool {
+	for _, p := range patterns {
+		if p.match(dir) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p pathPattern) match(dir project.Path) bool {
+	if p.globs != nil {
+		for _, g := range p.globs {
+			if g.Match(dir.String()) {
+				return true
+			}
+		}
+		return false
+	}
+	return dir.HasDirPrefix(p.pattern)
+}
+
+func parsePathPatterns(base project.Path, patterns []string) ([]pathPattern, error) {
+	parsed := make([]pathPattern, 0, len(patterns))
+	for _, pattern := range patterns {
+		if pattern == "" {
+			return nil, errors.E(ErrInvalidPathPattern, "empty pattern")
+		}
+		abspattern := pattern
+		if !path.IsAbs(abspattern) {
+			abspattern = path.Join(base.String(), abspattern)
+		}
+		abspattern = path.Clean(abspattern)
+		p := pathPattern{pattern: abspattern}
+		if strings.ContainsAny(abspattern, "*?[{\\") {
+			globs, err := compilePathGlobs(abspattern)
+			if err != nil {
+				return nil, errors.E(ErrInvalidPathPattern, err, "%q is not a valid glob pattern", pattern)
+			}
+			p.globs = globs
+		}
+		parsed = append(parsed, p)
+	}
+	return parsed, nil
+}
+
+func compilePathGlobs(pattern string) ([]glob.Glob, error) {
+	patterns := []string{pattern}
+	if prefix, ok := strings.CutSuffix(pattern, "/**"); ok {
+		if prefix == "" {
+			prefix = "/"
+		}
+		patterns = append(patterns, prefix)
+	}
+	globs := make([]glob.Glob, 0, len(patterns))
+	for _, p := range patterns {
+		g, err := glob.Compile(p, '/')
+		if err != nil {
+			return nil, err
+		}
+		globs = append(globs, g)
+	}
+	return globs, nil
+}
@@ -0,0 +1,145 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package filter
+
+import (
+	"testing"
+
+	"github.com/madlambda/spells/assert"
+	"github.com/terramate-io/terramate/errors"
+	"github.com/terramate-io/terramate/project"
+	errtest "github.com/terramate-io/terramate/test/errors"
+)
+
+func TestPathFilter(t *testing.T) {
+	t.Parallel()
+
+	type testcase struct {
+		name    string
+		base    string
+		include []string
+		exclude []string
+		match   []string
+		noMatch []string
+		err     error
+	}
+
+	for _, tc := range []testcase{
+		{
+			name:  "empty filter matches everything",
+			match: []string{"/", "/stack", "/a/b/c"},
+		},
+		{
+			name:    "include path matches the dir and its subdirs",
+			include: []string{"/stacks/prod"},
+			match:   []string{"/stacks/prod", "/stacks/prod/a", "/stacks/prod/a/b"},
+			noMatch: []string{"/stacks", "/stacks/production", "/stacks/dev/a"},
+		},
+		{
+			name:    "include root path matches everything",
+			include: []string{"/"},
+			match:   []string{"/", "/stack", "/a/b/c"},
+		},
+		{
+			name:    "exclude path from included path",
+			include: []string{"/stacks/prod"},
+			exclude: []string{"/stacks/prod/legacy"},
+			match:   []string{"/stacks/prod", "/stacks/prod/a", "/stacks/prod/legacy-new"},
+			noMatch: []string{"/stacks/prod/legacy", "/stacks/prod/legacy/a", "/stacks/dev"},
+		},
+		{
+			name:    "multiple includes are or'ed",
+			include: []string{"/a", "/b"},
+			match:   []string{"/a", "/b/c"},
+			noMatch: []string{"/c"},
+		},
+		{
+			name:    "single star glob matches a single dir level",
+			include: []string{"/stacks/*"},
+			match:   []string{"/stacks/a", "/stacks/b"},
+			noMatch: []string{"/stacks", "/stacks/a/b"},
+		},
+		{
+			name:    "trailing double star glob matches the dir and all subdirs",
+			include: []string{"/stacks/**"},
+			match:   []string{"/stacks", "/stacks/a", "/stacks/a/b/c"},
+			noMatch: []string{"/other", "/stacksa"},
+		},
+		{
+			name:    "double star in the middle",
+			include: []string{"/**/prod"},
+			match:   []string{"/prod", "/stacks/prod", "/a/b/prod"},
+			noMatch: []string{"/stacks/prod/a", "/stacks/production"},
+		},
+		{
+			name:    "double star only matches everything",
+			include: []string{"/**"},
+			match:   []string{"/", "/stack", "/a/b/c"},
+		},
+		{
+			name:    "leading double star matches zero dirs",
+			include: []string{"/**/legacy"},
+			match:   []string{"/legacy", "/stacks/legacy", "/a/b/legacy"},
+			noMatch: []string{"/legacy/a", "/stacks/legacy-new"},
+		},
+		{
+			name:    "exclude glob",
+			exclude: []string{"/**/legacy/**"},
+			match:   []string{"/", "/stacks/prod"},
+			noMatch: []string{"/legacy", "/stacks/legacy", "/stacks/legacy/a"},
+		},
+		{
+			name:    "alternatives and character classes",
+			include: []string{"/{dev,prod}/stack-[0-9]"},
+			match:   []string{"/dev/stack-1", "/prod/stack-9"},
+			noMatch: []string{"/stage/stack-1", "/dev/stack-a"},
+		},
+		{
+			name:    "relative patterns are joined with the base dir",
+			base:    "/stacks",
+			include: []string{"prod", "./dev/*"},
+			exclude: []string{"../stacks/prod/legacy"},
+			match:   []string{"/stacks/prod", "/stacks/prod/a", "/stacks/dev/a"},
+			noMatch: []string{"/prod", "/stacks/dev", "/stacks/prod/legacy"},
+		},
+		{
+			name:    "trailing slash is ignored",
+			include: []string{"/stacks/prod/"},
+			match:   []string{"/stacks/prod", "/stacks/prod/a"},
+			noMatch: []string{"/stacks"},
+		},
+		{
+			name:    "empty pattern fails",
+			include: []string{""},
+			err:     errors.E(ErrInvalidPathPattern),
+		},
+		{
+			name:    "invalid glob fails",
+			exclude: []string{"/stacks/[a"},
+			err:     errors.E(ErrInvalidPathPattern),
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			base := tc.base
+			if base == "" {
+				base = "/"
+			}
+			filter, err := ParsePathFilter(project.NewPath(base), tc.include, tc.exclude)
+			errtest.Assert(t, err, tc.err)
+			if tc.err != nil {
+				return
+			}
+			assert.IsTrue(t, filter.IsEmpty() == (len(tc.include) == 0 && len(tc.exclude) == 0))
+			for _, dir := range tc.match {
+				assert.IsTrue(t, filter.Match(project.NewPath(dir)), "%s must match", dir)
+			}
+			for _, dir := range tc.noMatch {
+				assert.IsTrue(t, !filter.Match(project.NewPath(dir)), "%s must not match", dir)
+			}
+		})
+	}
+}
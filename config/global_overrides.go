@@ -0,0 +1,50 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package config
+
+import (
+	hhcl "github.com/terramate-io/hcl/v2"
+	"github.com/terramate-io/terramate/hcl/info"
+	"github.com/terramate-io/terramate/project"
+)
+
+// GlobalOverride is a global set from outside of the configuration, like from
+// a globals file given in the command line. It has precedence over the globals
+// defined in the configuration of the directories in its scope.
+type GlobalOverride struct {
+	// Scope is the directory where the override applies, including its
+	// subdirectories.
+	Scope project.Path
+
+	// Labels are the labels of the global object, if any.
+	Labels []string
+
+	// Name is the name of the global attribute.
+	Name string
+
+	// Expr is the unevaluated expression of the global.
+	Expr hhcl.Expression
+
+	// Origin is the range where the override is defined.
+	Origin info.Range
+}
+
+// SetGlobalOverrides sets the globals overriding the configuration when
+// evaluating the globals of a directory. The overrides are kept when the
+// configuration is reloaded.
+func (root *Root) SetGlobalOverrides(overrides []GlobalOverride) {
+	root.globalOverrides = overrides
+}
+
+// GlobalOverrides returns the global overrides applying to the given
+// directory, in the order they were set.
+func (root *Root) GlobalOverrides(dir project.Path) []GlobalOverride {
+	var overrides []GlobalOverride
+	for _, o := range root.globalOverrides {
+		if dir.HasDirPrefix(o.Scope.String()) {
+			overrides = append(overrides, o)
+		}
+	}
+	return overrides
+}
@@ -0,0 +1,109 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/terramate-io/terramate/errors"
+	"github.com/terramate-io/terramate/fs"
+	"github.com/terramate-io/terramate/hcl"
+)
+
+// OverlayWhiteoutPrefix is the filename prefix of the overlay whiteout markers.
+// An overlay file named ".wh.<name>" removes the project file <name> from the
+// same directory.
+const OverlayWhiteoutPrefix = ".wh."
+
+// ErrOverlay indicates that the overlay directory is invalid.
+const ErrOverlay errors.Kind = "invalid overlay directory"
+
+// LoadRootWithOverlay loads the root configuration tree with the Terramate
+// files of the overlay directory layered over the project files.
+// The overlay mirrors the project layout: an overlay file replaces the project
+// file with the same path (or adds it if absent) and a whiteout marker
+// (see [OverlayWhiteoutPrefix]) removes it. Directories only present in the
+// overlay and files imported by the configuration are not overlaid.
+func LoadRootWithOverlay(rootdir string, overlaydir string) (*Root, error) {
+	overlaydir, err := filepath.Abs(overlaydir)
+	if err != nil {
+		return nil, errors.E(ErrOverlay, err)
+	}
+	st, err := os.Stat(overlaydir)
+	if err != nil {
+		return nil, errors.E(ErrOverlay, err)
+	}
+	if !st.IsDir() {
+		return nil, errors.E(ErrOverlay, "%s is not a directory", overlaydir)
+	}
+	return loadRoot(rootdir, overlaydir)
+}
+
+// addTmFiles adds the Terramate files of cfgdir to the parser, applying the
+// overlay of the tree, if any. It returns the resulting list of filenames.
+func (tree *Tree) addTmFiles(p *hcl.TerramateParser, cfgdir string, filenames []string) ([]string, error) {
+	files := make(map[string]string, len(filenames))
+	for _, fname := range filenames {
+		files[fname] = filepath.Join(cfgdir, fname)
+	}
+
+	if overlay := tree.RootTree().overlay; overlay != "" {
+		if err := applyOverlay(files, overlay, tree.RootDir(), cfgdir); err != nil {
+			return nil, err
+		}
+	}
+
+	names := make([]string, 0, len(files))
+	for fname := range files {
+		names = append(names, fname)
+	}
+	sort.Strings(names)
+
+	for _, fname := range names {
+		data, err := os.ReadFile(files[fname])
+		if err != nil {
+			return nil, errors.E(err, "reading config file %q", files[fname])
+		}
+		// the files are always named after the project path, so errors and
+		// metadata refer to the original location.
+		if err := p.AddFileContent(filepath.Join(cfgdir, fname), data); err != nil {
+			return nil, err
+		}
+	}
+	return names, nil
+}
+
+// applyOverlay updates the files of cfgdir, a map of filenames to the host
+// path of their content, with the files of the corresponding overlay dir.
+func applyOverlay(files map[string]string, overlay, rootdir, cfgdir string) error {
+	reldir, err := filepath.Rel(rootdir, cfgdir)
+	if err != nil {
+		return errors.E(errors.ErrInternal, err)
+	}
+	overlaydir := filepath.Join(overlay, reldir)
+	entries, err := os.ReadDir(overlaydir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return errors.E(ErrOverlay, err, "reading overlay dir %s", overlaydir)
+	}
+	for _, entry := range entries {
+		fname := entry.Name()
+		if entry.IsDir() {
+			continue
+		}
+		if strings.HasPrefix(fname, OverlayWhiteoutPrefix) {
+			delete(files, strings.TrimPrefix(fname, OverlayWhiteoutPrefix))
+			continue
+		}
+		if fs.IsTerramateFile(fname) {
+			files[fname] = filepath.Join(overlaydir, fname)
+		}
+	}
+	return nil
+}
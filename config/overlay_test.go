@@ -0,0 +1,120 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package config_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/madlambda/spells/assert"
+	"github.com/terramate-io/terramate/config"
+	"github.com/terramate-io/terramate/errors"
+	"github.com/terramate-io/terramate/hcl/ast"
+	"github.com/terramate-io/terramate/project"
+	"github.com/terramate-io/terramate/test"
+	errtest "github.com/terramate-io/terramate/test/errors"
+	"github.com/terramate-io/terramate/test/sandbox"
+)
+
+func TestLoadRootWithOverlay(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.NoGit(t, true)
+	s.BuildTree([]string{
+		`f:stacks/a/stack.tm:stack {
+		  description = "original"
+		}`,
+		`f:stacks/a/globals.tm:globals {
+		  a = 1
+		}`,
+		`f:stacks/b/stack.tm:stack {}`,
+	})
+
+	overlay := test.TempDir(t)
+	test.WriteFile(t, filepath.Join(overlay, "stacks/a"), "stack.tm", `stack {
+	  description = "overlaid"
+	}`)
+	test.WriteFile(t, filepath.Join(overlay, "stacks/a"), ".wh.globals.tm", "")
+	test.WriteFile(t, filepath.Join(overlay, "stacks/b"), "globals.tm", `globals {
+	  b = 1
+	}`)
+	test.WriteFile(t, filepath.Join(overlay, "stacks/b"), "README.md", "ignored")
+	test.WriteFile(t, filepath.Join(overlay, "stacks/new"), "stack.tm", "stack {}")
+
+	root, err := config.LoadRootWithOverlay(s.RootDir(), overlay)
+	assert.NoError(t, err)
+
+	a, ok := root.Lookup(project.NewPath("/stacks/a"))
+	assert.IsTrue(t, ok)
+	assert.EqualStrings(t, "overlaid", a.Node.Stack.Description)
+	assert.EqualInts(t, 1, len(a.TerramateFiles))
+	assert.EqualStrings(t, "stack.tm", a.TerramateFiles[0])
+	assert.IsTrue(t, !a.Node.HasGlobals(), "whiteout file must be removed")
+
+	b, ok := root.Lookup(project.NewPath("/stacks/b"))
+	assert.IsTrue(t, ok)
+	assert.EqualInts(t, 2, len(b.TerramateFiles))
+	assert.EqualStrings(t, "globals.tm", b.TerramateFiles[0])
+	assert.EqualStrings(t, "stack.tm", b.TerramateFiles[1])
+	assert.IsTrue(t, b.Node.HasGlobals(), "overlay file must be added")
+
+	_, ok = root.Lookup(project.NewPath("/stacks/new"))
+	assert.IsTrue(t, !ok, "dirs only present in the overlay must be ignored")
+
+	// the project files are untouched.
+	a, ok = s.Config().Lookup(project.NewPath("/stacks/a"))
+	assert.IsTrue(t, ok)
+	assert.EqualStrings(t, "original", a.Node.Stack.Description)
+	assert.IsTrue(t, a.Node.HasGlobals())
+}
+
+func TestLoadRootWithOverlayRootFiles(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.NoGit(t, true)
+	s.BuildTree([]string{
+		`f:globals.tm:globals {
+		  a = "original"
+		}`,
+		`f:modules/imported.tm:globals {
+		  b = "imported"
+		}`,
+		`f:stack/stack.tm:stack {}`,
+	})
+
+	overlay := test.TempDir(t)
+	test.WriteFile(t, overlay, "globals.tm", `globals {
+	  a = "overlaid"
+	}
+
+	import {
+	  source = "/modules/imported.tm"
+	}`)
+
+	root, err := config.LoadRootWithOverlay(s.RootDir(), overlay)
+	assert.NoError(t, err)
+
+	assert.EqualInts(t, 1, len(root.Tree().ImportedFiles))
+	assert.EqualStrings(t, filepath.Join(s.RootDir(), "modules/imported.tm"), root.Tree().ImportedFiles[0])
+
+	globals := root.Tree().Node.Globals[ast.NewEmptyLabelBlockType("globals")]
+	assert.EqualInts(t, 2, len(globals.Attributes))
+	val, diags := globals.Attributes["a"].Expr.Value(nil)
+	assert.IsTrue(t, !diags.HasErrors())
+	assert.EqualStrings(t, "overlaid", val.AsString())
+}
+
+func TestLoadRootWithOverlayFailsIfNotDir(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.NoGit(t, true)
+	overlay := filepath.Join(test.TempDir(t), "file.tm")
+	test.WriteFile(t, filepath.Dir(overlay), "file.tm", "")
+
+	_, err := config.LoadRootWithOverlay(s.RootDir(), overlay)
+	errtest.Assert(t, err, errors.E(config.ErrOverlay))
+
+	_, err = config.LoadRootWithOverlay(s.RootDir(), filepath.Join(overlay, "missing"))
+	errtest.Assert(t, err, errors.E(config.ErrOverlay))
+}
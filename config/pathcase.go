@@ -0,0 +1,96 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package config
+
+import (
+	"path"
+	"strings"
+
+	"github.com/terramate-io/terramate/errors"
+	"github.com/terramate-io/terramate/printer"
+	"github.com/terramate-io/terramate/project"
+)
+
+// ErrPathCaseConflict indicates that paths of the project differ only by case,
+// which resolve to the same directory in case-insensitive filesystems.
+const ErrPathCaseConflict errors.Kind = "path case conflict"
+
+// checkDirsCase checks that the directories of cfgdir don't differ only by case.
+func checkDirsCase(cfgdir string, dirs []string) error {
+	seen := map[string]string{}
+	for _, dir := range dirs {
+		if Skip(dir) {
+			continue
+		}
+		key := strings.ToLower(dir)
+		if other, ok := seen[key]; ok {
+			return errors.E(ErrPathCaseConflict,
+				"directories %q and %q in %s differ only by case", other, dir, cfgdir)
+		}
+		seen[key] = dir
+	}
+	return nil
+}
+
+// checkPathCase checks the stack references of the after, before, wants and
+// wanted_by attributes. A reference to a path which is not in the project but
+// matches a project directory ignoring the case is an error, as it resolves
+// differently depending on the filesystem. If terramate.config.normalize_path_case
+// is enabled, the reference is normalized to the project directory instead.
+func checkPathCase(root *Root) error {
+	dirs := map[string]project.Path{}
+	for _, tree := range root.Tree().AsList() {
+		if !tree.Skipped {
+			dirs[strings.ToLower(tree.Dir().String())] = tree.Dir()
+		}
+	}
+
+	normalize := root.normalizePathCase()
+	errs := errors.L()
+	for _, tree := range root.Tree().Stacks() {
+		st := tree.Node.Stack
+		for _, attr := range []struct {
+			name  string
+			paths []string
+		}{
+			{"after", st.After},
+			{"before", st.Before},
+			{"wants", st.Wants},
+			{"wanted_by", st.WantedBy},
+		} {
+			for i, pathstr := range attr.paths {
+				if strings.HasPrefix(pathstr, "tag:") {
+					continue
+				}
+				target := pathstr
+				if !path.IsAbs(target) {
+					target = path.Join(tree.Dir().String(), target)
+				}
+				target = path.Clean(target)
+				if _, _, found := root.Lookup2(project.NewPath(target)); found {
+					continue
+				}
+				dir, ok := dirs[strings.ToLower(target)]
+				if !ok {
+					continue
+				}
+				if !normalize {
+					errs.Append(errors.E(ErrPathCaseConflict,
+						"stack %s references %s in the %q attribute, which differs only by case from %s",
+						tree.Dir(), pathstr, attr.name, dir))
+					continue
+				}
+				printer.Stderr.Warnf("stack %s references %s in the %q attribute, normalized to %s",
+					tree.Dir(), pathstr, attr.name, dir)
+				attr.paths[i] = dir.String()
+			}
+		}
+	}
+	return errs.AsError()
+}
+
+func (root *Root) normalizePathCase() bool {
+	cfg := root.tree.Node.Terramate
+	return cfg != nil && cfg.Config != nil && cfg.Config.NormalizePathCase
+}
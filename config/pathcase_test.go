@@ -0,0 +1,110 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package config_test
+
+import (
+	"testing"
+
+	"github.com/madlambda/spells/assert"
+	"github.com/terramate-io/terramate/config"
+	"github.com/terramate-io/terramate/errors"
+	"github.com/terramate-io/terramate/project"
+	errtest "github.com/terramate-io/terramate/test/errors"
+	"github.com/terramate-io/terramate/test/sandbox"
+)
+
+func TestLoadRootPathCaseConflict(t *testing.T) {
+	t.Parallel()
+
+	for _, attr := range []string{"after", "before", "wants", "wanted_by"} {
+		attr := attr
+		t.Run(attr, func(t *testing.T) {
+			t.Parallel()
+
+			s := sandbox.NoGit(t, true)
+			s.BuildTree([]string{
+				"s:stacks/app",
+				`f:stacks/db/stack.tm:stack {
+				  ` + attr + ` = ["/Stacks/App"]
+				}`,
+			})
+
+			_, err := config.LoadRoot(s.RootDir())
+			errtest.Assert(t, err, errors.E(config.ErrPathCaseConflict))
+
+			_, _, _, err = config.TryLoadConfig(s.RootDir())
+			errtest.Assert(t, err, errors.E(config.ErrPathCaseConflict))
+		})
+	}
+}
+
+func TestLoadRootPathCaseRelativeReference(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.NoGit(t, true)
+	s.BuildTree([]string{
+		"s:stacks/app",
+		`f:stacks/db/stack.tm:stack {
+		  after = ["../APP"]
+		}`,
+	})
+
+	_, err := config.LoadRoot(s.RootDir())
+	errtest.Assert(t, err, errors.E(config.ErrPathCaseConflict))
+}
+
+func TestLoadRootPathCaseIgnoresValidReferences(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.NoGit(t, true)
+	s.BuildTree([]string{
+		"s:stacks/app",
+		`f:stacks/db/stack.tm:stack {
+		  after  = ["/stacks/app", "tag:app"]
+		  before = ["/not-found"]
+		}`,
+	})
+
+	root, err := config.LoadRoot(s.RootDir())
+	assert.NoError(t, err)
+
+	st, err := config.LoadStack(root, project.NewPath("/stacks/db"))
+	assert.NoError(t, err)
+	assertStrings(t, []string{"/stacks/app", "tag:app"}, st.After)
+	assertStrings(t, []string{"/not-found"}, st.Before)
+}
+
+func TestLoadRootPathCaseNormalize(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.NoGit(t, true)
+	s.BuildTree([]string{
+		`f:terramate.tm:terramate {
+		  config {
+		    normalize_path_case = true
+		  }
+		}`,
+		"s:stacks/app",
+		`f:stacks/db/stack.tm:stack {
+		  after = ["/Stacks/App", "../app"]
+		  wants = ["../APP"]
+		}`,
+	})
+
+	root, err := config.LoadRoot(s.RootDir())
+	assert.NoError(t, err)
+
+	st, err := config.LoadStack(root, project.NewPath("/stacks/db"))
+	assert.NoError(t, err)
+	assertStrings(t, []string{"/stacks/app", "../app"}, st.After)
+	assertStrings(t, []string{"/stacks/app"}, st.Wants)
+}
+
+func assertStrings(t *testing.T, want, got []string) {
+	t.Helper()
+	assert.EqualInts(t, len(want), len(got), "got %v", got)
+	for i := range want {
+		assert.EqualStrings(t, want[i], got[i])
+	}
+}
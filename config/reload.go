@@ -0,0 +1,180 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package config
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/terramate-io/terramate/errors"
+	"github.com/terramate-io/terramate/project"
+)
+
+// ReloadHook is called by [Root.ReloadPath] with the directories reloaded.
+// The child directories of each reloaded directory are reloaded as well, then
+// caches keyed by the scope directory (eg.: the evaluated globals) must drop
+// the entries of the reloaded directories and their children.
+// See [ScopeReloaded].
+type ReloadHook func(dirs project.Paths)
+
+// OnReload registers a hook called after every successful [Root.ReloadPath].
+func (root *Root) OnReload(hook ReloadHook) {
+	root.reloadHooks = append(root.reloadHooks, hook)
+}
+
+// ScopeReloaded tells if the scope directory is one of the reloaded dirs or
+// a child of them, which means values computed for the scope are stale.
+func ScopeReloaded(dirs project.Paths, scope project.Path) bool {
+	for _, dir := range dirs {
+		if scope.HasDirPrefix(dir.String()) {
+			return true
+		}
+	}
+	return false
+}
+
+// ReloadPath reloads the configuration affected by a change of the file or
+// directory at the given path, instead of loading the whole project again.
+// The closest directory of the path present in the configuration is reloaded,
+// including its child directories, together with the directories importing
+// the changed files. A change of the root directory reloads the whole project.
+// If reloading fails, the configuration is left unchanged.
+func (root *Root) ReloadPath(changed project.Path) error {
+	dirs := root.reloadDirs(changed)
+	if len(dirs) == 1 && dirs[0].String() == "/" {
+		return root.reloadAll()
+	}
+
+	type replaced struct {
+		parent *Tree
+		name   string
+		old    *Tree
+	}
+	var undo []replaced
+	rollback := func() {
+		for i := len(undo) - 1; i >= 0; i-- {
+			undo[i].parent.Children[undo[i].name] = undo[i].old
+		}
+	}
+
+	for _, dir := range dirs {
+		node, _ := root.Lookup(dir)
+		parent := node.Parent
+		name := path.Base(dir.String())
+		undo = append(undo, replaced{parent: parent, name: name, old: node})
+
+		if _, err := os.Stat(node.HostDir()); errors.Is(err, os.ErrNotExist) {
+			delete(parent.Children, name)
+			continue
+		}
+
+		newNode, err := loadTree(parent, node.HostDir(), nil)
+		if err != nil {
+			rollback()
+			return errors.E(err, "reloading %s", dir)
+		}
+		newNode.Parent = parent
+		parent.Children[name] = newNode
+	}
+
+	if err := checkPathCase(root); err != nil {
+		rollback()
+		return err
+	}
+
+	root.resetCaches()
+	for _, hook := range root.reloadHooks {
+		hook(dirs)
+	}
+	return nil
+}
+
+// reloadAll reloads the whole configuration, keeping the reload hooks and the
+// global overrides.
+func (root *Root) reloadAll() error {
+	var (
+		newRoot *Root
+		err     error
+	)
+	if overlay := root.tree.overlay; overlay != "" {
+		newRoot, err = LoadRootWithOverlay(root.HostDir(), overlay)
+	} else {
+		newRoot, err = LoadRoot(root.HostDir())
+	}
+	if err != nil {
+		return err
+	}
+
+	hooks := root.reloadHooks
+	overrides := root.globalOverrides
+	*root = *newRoot
+	root.tree.root = root
+	root.reloadHooks = hooks
+	root.globalOverrides = overrides
+	for _, hook := range root.reloadHooks {
+		hook(project.Paths{project.NewPath("/")})
+	}
+	return nil
+}
+
+// reloadDirs returns the directories to reload when the given path changes,
+// sorted and without the directories already reloaded by a parent directory.
+func (root *Root) reloadDirs(changed project.Path) project.Paths {
+	dir := changed
+	if _, found := root.Lookup(dir); !found {
+		// a file or a new directory.
+		dir = dir.Dir()
+	}
+	for {
+		if _, found := root.Lookup(dir); found || dir.String() == "/" {
+			break
+		}
+		dir = dir.Dir()
+	}
+
+	dirs := project.Paths{dir}
+	hostpath := changed.HostPath(root.HostDir())
+	for file, importers := range root.importers() {
+		if file == hostpath || strings.HasPrefix(file, hostpath+string(filepath.Separator)) {
+			dirs = append(dirs, importers...)
+		}
+	}
+
+	sort.Slice(dirs, func(i, j int) bool {
+		return dirs[i].String() < dirs[j].String()
+	})
+
+	var reduced project.Paths
+	for _, dir := range dirs {
+		if !ScopeReloaded(reduced, dir) {
+			reduced = append(reduced, dir)
+		}
+	}
+	return reduced
+}
+
+// importers returns the reverse import edges of the configuration, mapping
+// each imported file to the directories importing it.
+func (root *Root) importers() map[string]project.Paths {
+	if root.importedBy != nil {
+		return root.importedBy
+	}
+	root.importedBy = map[string]project.Paths{}
+	for _, tree := range root.tree.AsList() {
+		for _, file := range tree.ImportedFiles {
+			root.importedBy[file] = append(root.importedBy[file], tree.Dir())
+		}
+	}
+	return root.importedBy
+}
+
+// resetCaches drops the values computed from the previous configuration.
+func (root *Root) resetCaches() {
+	root.importedBy = nil
+	root.hasTerragruntStacks = nil
+	root.initRuntime()
+}
@@ -0,0 +1,59 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package config_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/madlambda/spells/assert"
+	"github.com/terramate-io/terramate/config"
+	"github.com/terramate-io/terramate/project"
+	"github.com/terramate-io/terramate/test/sandbox"
+)
+
+// setupReloadBench creates a project of 1000 directories: 10 directories
+// having 9 directories each, with 10 stacks inside, and globals at every level.
+func setupReloadBench(b *testing.B) sandbox.S {
+	s := sandbox.NoGit(b, true)
+	layout := []string{
+		"f:globals.tm:globals {\n  level = 0\n}\n",
+	}
+	for i := 0; i < 10; i++ {
+		for j := 0; j < 9; j++ {
+			dir := fmt.Sprintf("dir-%d/dir-%d", i, j)
+			layout = append(layout, fmt.Sprintf("f:%s/globals.tm:globals {\n  level = 2\n}\n", dir))
+			for k := 0; k < 10; k++ {
+				layout = append(layout, fmt.Sprintf("s:%s/stack-%d", dir, k))
+			}
+		}
+		layout = append(layout, fmt.Sprintf("f:dir-%d/globals.tm:globals {\n  level = 1\n}\n", i))
+	}
+	s.BuildTree(layout)
+	return s
+}
+
+func BenchmarkLoadRoot(b *testing.B) {
+	s := setupReloadBench(b)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, err := config.LoadRoot(s.RootDir())
+		assert.NoError(b, err)
+	}
+}
+
+func BenchmarkReloadPath(b *testing.B) {
+	s := setupReloadBench(b)
+	root, err := config.LoadRoot(s.RootDir())
+	assert.NoError(b, err)
+	assert.EqualInts(b, 1000, len(root.Tree().AsList())-1)
+
+	changed := project.NewPath("/dir-5/dir-5/stack-5/stack.tm.hcl")
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		assert.NoError(b, root.ReloadPath(changed))
+	}
+}
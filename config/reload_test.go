@@ -0,0 +1,138 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/madlambda/spells/assert"
+	"github.com/terramate-io/terramate/config"
+	"github.com/terramate-io/terramate/project"
+	"github.com/terramate-io/terramate/test"
+	"github.com/terramate-io/terramate/test/sandbox"
+)
+
+func TestReloadPath(t *testing.T) {
+	t.Parallel()
+
+	setup := func(t *testing.T) (sandbox.S, *config.Root, *[]project.Paths) {
+		s := sandbox.NoGit(t, true)
+		s.BuildTree([]string{
+			"s:stacks/a",
+			"s:stacks/b",
+			"d:modules",
+			"f:modules/globals.tm:globals {\n  module = \"v1\"\n}\n",
+			"f:stacks/b/import.tm:import {\n  source = \"/modules/globals.tm\"\n}\n",
+		})
+		root, err := config.LoadRoot(s.RootDir())
+		assert.NoError(t, err)
+
+		var reloads []project.Paths
+		root.OnReload(func(dirs project.Paths) {
+			reloads = append(reloads, dirs)
+		})
+		return s, root, &reloads
+	}
+
+	lookup := func(t *testing.T, root *config.Root, dir string) *config.Tree {
+		t.Helper()
+		node, ok := root.Lookup(project.NewPath(dir))
+		assert.IsTrue(t, ok, "%s not found", dir)
+		return node
+	}
+
+	t.Run("changed file reloads only its directory", func(t *testing.T) {
+		t.Parallel()
+		s, root, reloads := setup(t)
+		stackB := lookup(t, root, "/stacks/b")
+
+		s.DirEntry("stacks/a").CreateFile("stack.tm.hcl", "stack {\n  name = \"renamed\"\n}\n")
+		assert.NoError(t, root.ReloadPath(project.NewPath("/stacks/a/stack.tm.hcl")))
+
+		st, err := lookup(t, root, "/stacks/a").Stack()
+		assert.NoError(t, err)
+		assert.EqualStrings(t, "renamed", st.Name)
+		assert.IsTrue(t, lookup(t, root, "/stacks/b") == stackB, "stack b must not be reloaded")
+		test.AssertDiff(t, *reloads, []project.Paths{{project.NewPath("/stacks/a")}})
+	})
+
+	t.Run("changed imported file reloads the importing directories", func(t *testing.T) {
+		t.Parallel()
+		s, root, reloads := setup(t)
+
+		s.DirEntry("modules").CreateFile("globals.tm", "globals {\n  module = \"v2\"\n}\n")
+		assert.NoError(t, root.ReloadPath(project.NewPath("/modules/globals.tm")))
+
+		test.AssertDiff(t, *reloads, []project.Paths{{
+			project.NewPath("/modules"),
+			project.NewPath("/stacks/b"),
+		}})
+		node := lookup(t, root, "/stacks/b")
+		test.AssertDiff(t, node.ImportedFiles, []string{filepath.Join(s.RootDir(), "modules", "globals.tm")})
+	})
+
+	t.Run("new and removed stacks update the stacks list", func(t *testing.T) {
+		t.Parallel()
+		s, root, _ := setup(t)
+
+		s.BuildTree([]string{"s:stacks/c/nested"})
+		assert.NoError(t, root.ReloadPath(project.NewPath("/stacks/c/nested/stack.tm.hcl")))
+		test.AssertDiff(t, root.Stacks().Strings(), []string{"/stacks/a", "/stacks/b", "/stacks/c/nested"})
+
+		assert.NoError(t, os.RemoveAll(filepath.Join(s.RootDir(), "stacks", "a")))
+		assert.NoError(t, root.ReloadPath(project.NewPath("/stacks/a")))
+		test.AssertDiff(t, root.Stacks().Strings(), []string{"/stacks/b", "/stacks/c/nested"})
+		_, found := root.Lookup(project.NewPath("/stacks/a"))
+		assert.IsTrue(t, !found, "/stacks/a must be removed")
+	})
+
+	t.Run("changed root file reloads everything", func(t *testing.T) {
+		t.Parallel()
+		s, root, reloads := setup(t)
+		tree := root.Tree()
+
+		s.RootEntry().CreateFile("globals.tm", "globals {\n  root = true\n}\n")
+		assert.NoError(t, root.ReloadPath(project.NewPath("/globals.tm")))
+
+		assert.IsTrue(t, root.Tree() != tree, "root must be reloaded")
+		assert.IsTrue(t, root.Tree().Root() == root, "tree must point to the reloaded root")
+		assert.IsTrue(t, root.Tree().Node.HasGlobals(), "root globals not loaded")
+		test.AssertDiff(t, *reloads, []project.Paths{{project.NewPath("/")}})
+	})
+
+	t.Run("failed reload keeps the configuration", func(t *testing.T) {
+		t.Parallel()
+		s, root, reloads := setup(t)
+		stackA := lookup(t, root, "/stacks/a")
+
+		s.DirEntry("stacks/a").CreateFile("invalid.tm", "globals {")
+		assert.Error(t, root.ReloadPath(project.NewPath("/stacks/a/invalid.tm")))
+		assert.IsTrue(t, lookup(t, root, "/stacks/a") == stackA, "stack a must be kept")
+		assert.EqualInts(t, 0, len(*reloads))
+	})
+}
+
+func TestScopeReloaded(t *testing.T) {
+	t.Parallel()
+
+	dirs := project.Paths{project.NewPath("/stacks/a"), project.NewPath("/modules")}
+	for _, tc := range []struct {
+		scope string
+		want  bool
+	}{
+		{"/stacks/a", true},
+		{"/stacks/a/child", true},
+		{"/modules", true},
+		{"/stacks/ab", false},
+		{"/stacks", false},
+		{"/", false},
+	} {
+		got := config.ScopeReloaded(dirs, project.NewPath(tc.scope))
+		assert.IsTrue(t, got == tc.want, "ScopeReloaded(%s) = %t", tc.scope, got)
+	}
+	assert.IsTrue(t, config.ScopeReloaded(project.Paths{project.NewPath("/")}, project.NewPath("/stacks")),
+		"reloading / reloads every scope")
+}
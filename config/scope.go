@@ -0,0 +1,250 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package config
+
+import (
+	"sort"
+	"strings"
+
+	hhcl "github.com/terramate-io/hcl/v2"
+	"github.com/terramate-io/terramate/errors"
+	"github.com/terramate-io/terramate/fs"
+	"github.com/terramate-io/terramate/hcl"
+	"github.com/terramate-io/terramate/hcl/ast"
+	"github.com/terramate-io/terramate/hcl/info"
+	"github.com/terramate-io/terramate/project"
+)
+
+// ScopeLevel is the configuration defined in a single directory of the scope
+// chain of a directory.
+type ScopeLevel struct {
+	// Dir is the directory of this level.
+	Dir project.Path
+
+	// Files are the Terramate files of the directory.
+	Files []project.Path
+
+	// Imports are the import blocks of the directory.
+	Imports []ScopeElement
+
+	// Globals are the globals defined in the directory, including imported ones.
+	Globals []ScopeElement
+
+	// Env are the terramate.config.run.env variables defined in the directory.
+	Env []ScopeElement
+
+	// Config are the remaining terramate.config attributes defined in the directory.
+	Config []ScopeElement
+
+	// Scripts are the scripts defined in the directory.
+	Scripts []ScopeElement
+
+	// Generate are the generate_file and generate_hcl blocks defined in the directory.
+	Generate []ScopeElement
+}
+
+// ScopeElement is a single configuration element of a [ScopeLevel].
+type ScopeElement struct {
+	// Name identifies the element, eg.: global.a.b or terramate.config.git.default_branch.
+	Name string
+
+	// Expr is the expression defining the element, if any.
+	Expr hhcl.Expression
+
+	// Origin is where the element is defined.
+	Origin info.Range
+
+	// Overridden tells if the element is redefined by a level closer to the
+	// scope directory.
+	Overridden bool
+
+	// extends tells if the element only extends a global object, in which
+	// case it never overrides elements of parent levels.
+	extends bool
+}
+
+// Scope returns the scope chain of dir, ordered from the root directory to dir.
+// Each level contains the configuration elements defined in that directory and
+// their origin.
+func Scope(root *Root, dir project.Path) ([]ScopeLevel, error) {
+	tree, found := root.Lookup(dir)
+	if !found {
+		return nil, errors.E("directory %s not found in the project", dir)
+	}
+
+	var chain []*Tree
+	for node := tree; node != nil; node = node.Parent {
+		chain = append(chain, node)
+	}
+
+	levels := make([]ScopeLevel, 0, len(chain))
+	for i := len(chain) - 1; i >= 0; i-- {
+		level, err := scopeLevel(root, chain[i])
+		if err != nil {
+			return nil, errors.E(err, "loading scope of %s", chain[i].Dir())
+		}
+		levels = append(levels, level)
+	}
+
+	markOverridden(levels, func(l *ScopeLevel) []ScopeElement { return l.Globals }, true)
+	markOverridden(levels, func(l *ScopeLevel) []ScopeElement { return l.Env }, false)
+	markOverridden(levels, func(l *ScopeLevel) []ScopeElement { return l.Config }, false)
+	return levels, nil
+}
+
+func scopeLevel(root *Root, tree *Tree) (ScopeLevel, error) {
+	level := ScopeLevel{
+		Dir: tree.Dir(),
+	}
+
+	rootdir := root.HostDir()
+	hostdir := tree.HostDir()
+
+	filesResult, err := fs.ListTerramateFiles(hostdir)
+	if err != nil {
+		return ScopeLevel{}, err
+	}
+	p, err := hcl.NewTerramateParser(rootdir, hostdir, root.Tree().Node.Experiments()...)
+	if err != nil {
+		return ScopeLevel{}, err
+	}
+	files, err := tree.addTmFiles(p, hostdir, filesResult.TmFiles)
+	if err != nil {
+		return ScopeLevel{}, err
+	}
+	for _, fname := range files {
+		level.Files = append(level.Files, tree.Dir().Join(fname))
+	}
+	if err := p.Parse(); err != nil {
+		return ScopeLevel{}, err
+	}
+
+	imports, err := p.Imports()
+	if err != nil {
+		return ScopeLevel{}, err
+	}
+	for _, importBlock := range imports {
+		src := importBlock.Attributes["source"]
+		level.Imports = append(level.Imports, ScopeElement{
+			Name:   "import",
+			Expr:   src.Expr,
+			Origin: importBlock.Range,
+		})
+	}
+
+	raw := p.Imported.Copy()
+	if err := raw.Merge(p.Config); err != nil {
+		return ScopeLevel{}, err
+	}
+	if tmblock, ok := raw.MergedBlocks["terramate"]; ok {
+		for _, cfgblock := range tmblock.Blocks {
+			if cfgblock.Type == "config" {
+				level.scopeConfig("terramate.config", cfgblock)
+			}
+		}
+	}
+
+	level.scopeGlobals(tree.Node.Globals)
+
+	for _, script := range tree.Node.Scripts {
+		level.Scripts = append(level.Scripts, ScopeElement{
+			Name:   "script." + strings.Join(script.Labels, "."),
+			Origin: script.Range,
+		})
+	}
+	for _, gen := range tree.Node.Generate.Files {
+		level.Generate = append(level.Generate, ScopeElement{
+			Name:   "generate_file." + gen.Label,
+			Origin: gen.Range,
+		})
+	}
+	for _, gen := range tree.Node.Generate.HCLs {
+		level.Generate = append(level.Generate, ScopeElement{
+			Name:   "generate_hcl." + gen.Label,
+			Origin: gen.Range,
+		})
+	}
+
+	sortElements(level.Globals)
+	sortElements(level.Env)
+	sortElements(level.Config)
+	sortElements(level.Scripts)
+	sortElements(level.Generate)
+	return level, nil
+}
+
+func (level *ScopeLevel) scopeConfig(prefix string, block *ast.MergedBlock) {
+	for _, attr := range block.Attributes.SortedList() {
+		elem := ScopeElement{
+			Name:   prefix + "." + attr.Name,
+			Expr:   attr.Expr,
+			Origin: attr.Range,
+		}
+		if prefix == "terramate.config.run.env" {
+			elem.Name = "env." + attr.Name
+			level.Env = append(level.Env, elem)
+			continue
+		}
+		level.Config = append(level.Config, elem)
+	}
+	for _, subblock := range block.Blocks {
+		level.scopeConfig(prefix+"."+string(subblock.Type), subblock)
+	}
+}
+
+func (level *ScopeLevel) scopeGlobals(globals ast.MergedLabelBlocks) {
+	for _, block := range globals.AsList() {
+		prefix := strings.Join(append([]string{"global"}, block.Labels...), ".")
+
+		if len(block.Labels) > 0 && len(block.Attributes) == 0 {
+			level.Globals = append(level.Globals, ScopeElement{
+				Name:    prefix,
+				Origin:  block.RawOrigins[0].Range,
+				extends: true,
+			})
+		}
+		for _, mapBlock := range block.Blocks {
+			level.Globals = append(level.Globals, ScopeElement{
+				Name:   prefix + "." + mapBlock.Labels[0],
+				Origin: mapBlock.RawOrigins[0].Range,
+			})
+		}
+		for _, attr := range block.Attributes.SortedList() {
+			level.Globals = append(level.Globals, ScopeElement{
+				Name:   prefix + "." + attr.Name,
+				Expr:   attr.Expr,
+				Origin: attr.Range,
+			})
+		}
+	}
+}
+
+// markOverridden marks the elements redefined by a deeper level. If nested is
+// true, the elements are also overridden by deeper definitions of any of their
+// parent objects, like globals.
+func markOverridden(levels []ScopeLevel, elems func(*ScopeLevel) []ScopeElement, nested bool) {
+	for i := range levels {
+		for j := range elems(&levels[i]) {
+			elem := &elems(&levels[i])[j]
+			for k := i + 1; k < len(levels) && !elem.Overridden; k++ {
+				for _, deeper := range elems(&levels[k]) {
+					if deeper.extends {
+						continue
+					}
+					if deeper.Name == elem.Name ||
+						(nested && strings.HasPrefix(elem.Name, deeper.Name+".")) {
+						elem.Overridden = true
+						break
+					}
+				}
+			}
+		}
+	}
+}
+
+func sortElements(elems []ScopeElement) {
+	sort.SliceStable(elems, func(i, j int) bool {
+		return elems[i].Name < elems[j].Name
+	})
+}
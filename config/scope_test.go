@@ -0,0 +1,174 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package config_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/madlambda/spells/assert"
+	"github.com/terramate-io/terramate/config"
+	"github.com/terramate-io/terramate/project"
+	"github.com/terramate-io/terramate/test/sandbox"
+)
+
+func TestScope(t *testing.T) {
+	t.Parallel()
+
+	type element struct {
+		Name       string
+		Origin     string
+		Overridden bool
+	}
+	type level struct {
+		Dir      string
+		Files    []string
+		Imports  []element
+		Globals  []element
+		Env      []element
+		Config   []element
+		Scripts  []element
+		Generate []element
+	}
+
+	s := sandbox.NoGit(t, true)
+	s.BuildTree([]string{
+		`f:imports/common.tm.hcl:globals {
+		  team = "platform"
+		}`,
+		`f:terramate.tm.hcl:terramate {
+		  config {
+		    experiments = ["scripts"]
+		    git {
+		      default_branch = "main"
+		    }
+		    run {
+		      env {
+		        TF_PLUGIN_CACHE_DIR = "/cache"
+		      }
+		    }
+		  }
+		}
+
+		import {
+		  source = "/imports/common.tm.hcl"
+		}`,
+		`f:globals.tm:globals {
+		  env = "dev"
+		  region = "us-east-1"
+		}
+
+		globals "obj" {
+		  a = 1
+		}`,
+		`f:stacks/globals.tm:globals {
+		  env = "prd"
+		}
+
+		globals "obj" {
+		}
+
+		generate_file "file.txt" {
+		  content = global.env
+		}`,
+		`f:stacks/a/stack.tm:stack {}
+
+		globals {
+		  obj = {}
+		}
+
+		script "deploy" {
+		  description = "deploy"
+		  job {
+		    command = ["echo"]
+		  }
+		}`,
+	})
+
+	root, err := config.LoadRoot(s.RootDir())
+	assert.NoError(t, err)
+
+	levels, err := config.Scope(root, project.NewPath("/stacks/a"))
+	assert.NoError(t, err)
+
+	elements := func(elems []config.ScopeElement) []element {
+		var res []element
+		for _, elem := range elems {
+			res = append(res, element{
+				Name:       elem.Name,
+				Origin:     elem.Origin.Path().String(),
+				Overridden: elem.Overridden,
+			})
+		}
+		return res
+	}
+
+	var got []level
+	for _, l := range levels {
+		var files []string
+		for _, f := range l.Files {
+			files = append(files, f.String())
+		}
+		got = append(got, level{
+			Dir:      l.Dir.String(),
+			Files:    files,
+			Imports:  elements(l.Imports),
+			Globals:  elements(l.Globals),
+			Env:      elements(l.Env),
+			Config:   elements(l.Config),
+			Scripts:  elements(l.Scripts),
+			Generate: elements(l.Generate),
+		})
+	}
+
+	want := []level{
+		{
+			Dir:   "/",
+			Files: []string{"/globals.tm", "/root.config.tm", "/terramate.tm.hcl"},
+			Imports: []element{
+				{Name: "import", Origin: "/terramate.tm.hcl"},
+			},
+			Globals: []element{
+				{Name: "global.env", Origin: "/globals.tm", Overridden: true},
+				{Name: "global.obj.a", Origin: "/globals.tm", Overridden: true},
+				{Name: "global.region", Origin: "/globals.tm"},
+				{Name: "global.team", Origin: "/imports/common.tm.hcl"},
+			},
+			Env: []element{
+				{Name: "env.TF_PLUGIN_CACHE_DIR", Origin: "/terramate.tm.hcl"},
+			},
+			Config: []element{
+				{Name: "terramate.config.experiments", Origin: "/terramate.tm.hcl"},
+				{Name: "terramate.config.git.default_branch", Origin: "/terramate.tm.hcl"},
+			},
+		},
+		{
+			Dir:   "/stacks",
+			Files: []string{"/stacks/globals.tm"},
+			Globals: []element{
+				{Name: "global.env", Origin: "/stacks/globals.tm"},
+				{Name: "global.obj", Origin: "/stacks/globals.tm", Overridden: true},
+			},
+			Generate: []element{
+				{Name: "generate_file.file.txt", Origin: "/stacks/globals.tm"},
+			},
+		},
+		{
+			Dir:   "/stacks/a",
+			Files: []string{"/stacks/a/stack.tm"},
+			Globals: []element{
+				{Name: "global.obj", Origin: "/stacks/a/stack.tm"},
+			},
+			Scripts: []element{
+				{Name: "script.deploy", Origin: "/stacks/a/stack.tm"},
+			},
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("-(want) +(got):\n%s", diff)
+	}
+
+	_, err = config.Scope(root, project.NewPath("/not-found"))
+	assert.Error(t, err)
+}
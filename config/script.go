@@ -5,9 +5,11 @@ package config
 
 import (
 	"fmt"
+	"math/big"
 	"strings"
 
 	hhcl "github.com/terramate-io/hcl/v2"
+	"github.com/terramate-io/hcl/v2/hclsyntax"
 	"github.com/terramate-io/terramate/cloud/preview"
 	"github.com/terramate-io/terramate/errors"
 	"github.com/terramate-io/terramate/hcl"
@@ -26,6 +28,9 @@ const (
 	ErrScriptInvalidTypeCommands errors.Kind = "invalid type for script.job.commands"
 	ErrScriptEmptyCmds           errors.Kind = "job command or commands evaluated to empty list"
 	ErrScriptInvalidCmdOptions   errors.Kind = "invalid options for script command"
+	ErrScriptInvalidCapture      errors.Kind = "invalid script.job.capture"
+	ErrScriptUndefinedJobOutput  errors.Kind = "undefined script job output"
+	ErrScriptMissingArg          errors.Kind = "missing script argument"
 )
 
 // MaxScriptNameRunes defines the maximum number of runes allowed for a script name.
@@ -34,6 +39,20 @@ const MaxScriptNameRunes = 128
 // MaxScriptDescRunes defines the maximum number of runes allowed for a script description.
 const MaxScriptDescRunes = 1000
 
+// Script job orderings.
+const (
+	// ScriptJobOrderingStack runs all the jobs of the script in a stack
+	// before running them in the next stack.
+	ScriptJobOrderingStack = "stack"
+	// ScriptJobOrderingJob runs each job of the script in all the stacks
+	// before running the next job.
+	ScriptJobOrderingJob = "job"
+)
+
+// MaxScriptJobOutputBytes defines the maximum number of bytes of the stdout
+// captured by a job with the `capture` attribute.
+const MaxScriptJobOutputBytes = 16 * 1024
+
 // ScriptCmdOptions represents optional parameters for a script command
 type ScriptCmdOptions struct {
 	CloudSyncDeployment    bool
@@ -59,6 +78,14 @@ type ScriptJob struct {
 	Description string
 	Cmd         *ScriptCmd
 	Cmds        []*ScriptCmd
+
+	// Capture is the name of the job_output value storing the stdout of the job.
+	Capture string
+
+	// Deferred tells that the commands reference job_output values, so they
+	// are not evaluated by EvalScript but by EvalScriptJob, after the
+	// previous jobs of the script were executed.
+	Deferred bool
 }
 
 // Script represents an evaluated script block
@@ -68,6 +95,16 @@ type Script struct {
 	Name        string
 	Description string
 	Jobs        []ScriptJob
+
+	// JobOrdering is the value of the job_ordering attribute, which is empty
+	// if the attribute is not set, meaning the default ScriptJobOrderingStack.
+	JobOrdering string
+}
+
+// OrderedByJob tells if each job of the script runs in all the stacks before
+// the next job runs.
+func (es Script) OrderedByJob() bool {
+	return es.JobOrdering == ScriptJobOrderingJob
 }
 
 // Commands is a convenience method for callers who don't specifically
@@ -81,6 +118,20 @@ func (es ScriptJob) Commands() []*ScriptCmd {
 	return es.Cmds
 }
 
+// EvalScriptEnabled evaluates the enabled attribute of the script using the
+// provided evaluation context of the stack. A script without the attribute is
+// enabled. The script lets are not available to the attribute.
+func EvalScriptEnabled(evalctx *eval.Context, script hcl.Script) (bool, error) {
+	if script.Enabled == nil {
+		return true, nil
+	}
+	enabled, err := evalBool(evalctx, script.Enabled.Expr, "script.enabled")
+	if err != nil {
+		return false, errors.E(ErrScriptInvalidType, script.Enabled.Expr.Range(), err)
+	}
+	return enabled, nil
+}
+
 // EvalScript evaluates a script block using the provided evaluation context
 func EvalScript(evalctx *eval.Context, script hcl.Script) (Script, error) {
 	evaluatedScript := Script{
@@ -88,7 +139,16 @@ func EvalScript(evalctx *eval.Context, script hcl.Script) (Script, error) {
 		Labels: script.Labels,
 	}
 
+	if len(script.Jobs) == 0 {
+		return Script{}, errors.E(hcl.ErrScriptMissingOrInvalidJob, script.Range,
+			"the script only disables an inherited script but it is enabled")
+	}
+
 	errs := errors.L()
+	errs.Append(checkScriptArgs(evalctx, script))
+	if err := errs.AsError(); err != nil {
+		return Script{}, err
+	}
 
 	localctx := evalctx.ChildContext()
 	localctx.SetNamespace("let", map[string]cty.Value{})
@@ -124,6 +184,21 @@ func EvalScript(evalctx *eval.Context, script hcl.Script) (Script, error) {
 		evaluatedScript.Description = desc
 	}
 
+	if script.JobOrdering != nil {
+		ordering, err := evalScriptStringField(localctx, script.JobOrdering.Expr, "script.job_ordering")
+		switch {
+		case err != nil:
+			errs.Append(err)
+		case ordering != ScriptJobOrderingStack && ordering != ScriptJobOrderingJob:
+			errs.Append(errors.E(ErrScriptSchema, script.JobOrdering.Expr.Range(),
+				"script.job_ordering must be %q or %q but got %q",
+				ScriptJobOrderingStack, ScriptJobOrderingJob, ordering))
+		default:
+			evaluatedScript.JobOrdering = ordering
+		}
+	}
+
+	captured := map[string]bool{}
 	for _, job := range script.Jobs {
 		evaluatedJob := ScriptJob{}
 
@@ -154,36 +229,40 @@ func EvalScript(evalctx *eval.Context, script hcl.Script) (Script, error) {
 			evaluatedJob.Description = desc
 		}
 
-		if job.Command != nil {
-			expr := job.Command.Expr
-			v, err := localctx.Eval(expr)
-			if err != nil {
-				errs.Append(errors.E(ErrScriptSchema, expr.Range(), err, "evaluating command"))
-				continue
+		refs, deferred := jobOutputRefs(job)
+		for _, ref := range refs {
+			if !captured[ref.name] {
+				errs.Append(errors.E(ErrScriptUndefinedJobOutput, ref.rng,
+					"job_output.%s is not captured by a previous job of the script", ref.name))
 			}
+		}
 
-			command, err := unmarshalScriptJobCommand(v, expr)
-			if err != nil {
+		if job.Capture != nil {
+			capture, err := evalScriptStringField(localctx, job.Capture.Expr, "script.job.capture")
+			switch {
+			case err != nil:
 				errs.Append(err)
-				continue
+			case !hclsyntax.ValidIdentifier(capture):
+				errs.Append(errors.E(ErrScriptInvalidCapture, job.Capture.Expr.Range(),
+					"%q is not a valid identifier", capture))
+			case captured[capture]:
+				errs.Append(errors.E(ErrScriptInvalidCapture, job.Capture.Expr.Range(),
+					"job_output.%s is already captured by a previous job", capture))
+			default:
+				captured[capture] = true
 			}
-			evaluatedJob.Cmd = command
+			evaluatedJob.Capture = capture
 		}
 
-		if job.Commands != nil {
-			expr := job.Commands.Expr
-			v, err := localctx.Eval(expr)
-			if err != nil {
-				errs.Append(errors.E(ErrScriptSchema, expr.Range(), err, "evaluating commands"))
-				continue
-			}
+		if deferred {
+			evaluatedJob.Deferred = true
+			evaluatedScript.Jobs = append(evaluatedScript.Jobs, evaluatedJob)
+			continue
+		}
 
-			commands, err := unmarshalScriptJobCommands(v, expr)
-			if err != nil {
-				errs.Append(err)
-				continue
-			}
-			evaluatedJob.Cmds = commands
+		if err := evalScriptJobCommands(localctx, job, &evaluatedJob); err != nil {
+			errs.Append(err)
+			continue
 		}
 
 		evaluatedScript.Jobs = append(evaluatedScript.Jobs, evaluatedJob)
@@ -216,7 +295,7 @@ func EvalScript(evalctx *eval.Context, script hcl.Script) (Script, error) {
 	if len(cmdsWithCloudSyncPreview) > 1 {
 		errs.Append(errors.E(ErrScriptInvalidCmdOptions,
 			"only a single command per script may have 'sync_preview' enabled, but was enabled by: %v",
-			strings.Join(cmdsWithCloudSyncDeployment, " "),
+			strings.Join(cmdsWithCloudSyncPreview, " "),
 		))
 	}
 
@@ -227,6 +306,279 @@ func EvalScript(evalctx *eval.Context, script hcl.Script) (Script, error) {
 	return evaluatedScript, nil
 }
 
+// EvalScriptJob evaluates the commands of the job at index jobIdx of the script,
+// with the job_output namespace set to the outputs captured by the previous
+// jobs. It must be used for the jobs marked as [ScriptJob.Deferred] by EvalScript.
+func EvalScriptJob(evalctx *eval.Context, script hcl.Script, jobIdx int, outputs map[string]cty.Value) (ScriptJob, error) {
+	job := script.Jobs[jobIdx]
+
+	refs, _ := jobOutputRefs(job)
+	errs := errors.L()
+	for _, ref := range refs {
+		if _, ok := outputs[ref.name]; !ok {
+			errs.Append(errors.E(ErrScriptUndefinedJobOutput, ref.rng,
+				"job_output.%s is undefined because the job capturing it did not succeed", ref.name))
+		}
+	}
+	if err := errs.AsError(); err != nil {
+		return ScriptJob{}, err
+	}
+
+	localctx := evalctx.ChildContext()
+	localctx.SetNamespace("let", map[string]cty.Value{})
+	if err := lets.Load(script.Lets, localctx); err != nil {
+		return ScriptJob{}, err
+	}
+	localctx.SetNamespace("job_output", outputs)
+
+	evaluatedJob := ScriptJob{}
+	if err := evalScriptJobCommands(localctx, job, &evaluatedJob); err != nil {
+		return ScriptJob{}, err
+	}
+
+	for _, cmd := range evaluatedJob.Commands() {
+		if cmd.Options != nil &&
+			(cmd.Options.CloudSyncDeployment || cmd.Options.CloudSyncDriftStatus || cmd.Options.CloudSyncPreview) {
+			return ScriptJob{}, errors.E(ErrScriptInvalidCmdOptions,
+				"commands referencing job_output cannot enable 'sync_deployment', 'sync_drift_status' or 'sync_preview'")
+		}
+	}
+	return evaluatedJob, nil
+}
+
+// EvalScriptCmdOptions evaluates again the options of the command at index
+// cmdIdx of the job at index jobIdx of the script. It is used right before the
+// command is executed, then the options observe the files created by the
+// previous jobs of the script, eg.: the plan file of terraform_plan_file.
+// It must not be used for the jobs marked as [ScriptJob.Deferred].
+func EvalScriptCmdOptions(evalctx *eval.Context, script hcl.Script, jobIdx, cmdIdx int) (*ScriptCmdOptions, error) {
+	localctx := evalctx.ChildContext()
+	localctx.SetNamespace("let", map[string]cty.Value{})
+	if err := lets.Load(script.Lets, localctx); err != nil {
+		return nil, err
+	}
+
+	evaluatedJob := ScriptJob{}
+	if err := evalScriptJobCommands(localctx, script.Jobs[jobIdx], &evaluatedJob); err != nil {
+		return nil, err
+	}
+
+	cmds := evaluatedJob.Commands()
+	if cmdIdx >= len(cmds) {
+		return nil, errors.E(ErrScriptSchema,
+			"job %d of the script has %d command(s) but command %d was requested", jobIdx, len(cmds), cmdIdx)
+	}
+	return cmds[cmdIdx].Options, nil
+}
+
+func evalScriptJobCommands(evalctx *eval.Context, job *hcl.ScriptJob, evaluatedJob *ScriptJob) error {
+	if job.Command != nil {
+		expr := job.Command.Expr
+		v, err := evalctx.Eval(expr)
+		if err != nil {
+			return errors.E(ErrScriptSchema, expr.Range(), err, "evaluating command")
+		}
+
+		command, err := unmarshalScriptJobCommand(v, expr)
+		if err != nil {
+			return err
+		}
+		evaluatedJob.Cmd = command
+	}
+
+	if job.Commands != nil {
+		expr := job.Commands.Expr
+		v, err := evalctx.Eval(expr)
+		if err != nil {
+			return errors.E(ErrScriptSchema, expr.Range(), err, "evaluating commands")
+		}
+
+		commands, err := unmarshalScriptJobCommands(v, expr)
+		if err != nil {
+			return err
+		}
+		evaluatedJob.Cmds = commands
+	}
+	return nil
+}
+
+type jobOutputRef struct {
+	name string
+	rng  hhcl.Range
+}
+
+// jobOutputRefs returns the job_output values referenced by the commands of
+// the job and if the job references the job_output namespace at all.
+func jobOutputRefs(job *hcl.ScriptJob) ([]jobOutputRef, bool) {
+	var exprs []hhcl.Expression
+	if job.Command != nil {
+		exprs = append(exprs, job.Command.Expr)
+	}
+	if job.Commands != nil {
+		exprs = append(exprs, job.Commands.Expr)
+	}
+
+	var refs []jobOutputRef
+	found := false
+	for _, expr := range exprs {
+		for _, traversal := range expr.Variables() {
+			if traversal.RootName() != "job_output" {
+				continue
+			}
+			found = true
+			if len(traversal) < 2 {
+				continue
+			}
+			var name string
+			switch step := traversal[1].(type) {
+			case hhcl.TraverseAttr:
+				name = step.Name
+			case hhcl.TraverseIndex:
+				if step.Key.Type() == cty.String && step.Key.IsKnown() {
+					name = step.Key.AsString()
+				}
+			}
+			if name != "" {
+				refs = append(refs, jobOutputRef{name: name, rng: traversal.SourceRange()})
+			}
+		}
+	}
+	return refs, found
+}
+
+// SetScriptArgs sets the script namespace of the evaluation context, exposing
+// the arguments given to the script as script.args.
+func SetScriptArgs(evalctx *eval.Context, args []string) {
+	argsVal := cty.ListValEmpty(cty.String)
+	if len(args) > 0 {
+		vals := make([]cty.Value, len(args))
+		for i, arg := range args {
+			vals[i] = cty.StringVal(arg)
+		}
+		argsVal = cty.ListVal(vals)
+	}
+	evalctx.SetNamespace("script", map[string]cty.Value{
+		"args": argsVal,
+	})
+}
+
+// ScriptUsesArgs tells if the script references script.args and the number of
+// arguments required by the script.args indexes it references.
+func ScriptUsesArgs(script hcl.Script) (bool, int) {
+	refs, found := scriptArgRefs(scriptLetsExprs(script)...)
+	for _, job := range script.Jobs {
+		jobRefs, jobFound := scriptArgRefs(scriptJobExprs(job)...)
+		refs = append(refs, jobRefs...)
+		found = found || jobFound
+	}
+	required := 0
+	for _, ref := range refs {
+		if ref.index >= required {
+			required = ref.index + 1
+		}
+	}
+	return found, required
+}
+
+// checkScriptArgs checks that the script.args indexes referenced by the script
+// are within the arguments set by SetScriptArgs, so a missing argument reports
+// the script and job using it instead of a generic evaluation error.
+func checkScriptArgs(evalctx *eval.Context, script hcl.Script) error {
+	nargs := 0
+	if ns, ok := evalctx.GetNamespace("script"); ok {
+		if args := ns.GetAttr("args"); args.CanIterateElements() {
+			nargs = args.LengthInt()
+		}
+	}
+
+	name := strings.Join(script.Labels, " ")
+	errs := errors.L()
+	refs, _ := scriptArgRefs(scriptLetsExprs(script)...)
+	for _, ref := range refs {
+		if ref.index >= nargs {
+			errs.Append(errors.E(ErrScriptMissingArg, ref.rng,
+				"lets of script %q reference script.args[%d] but %d argument(s) were given",
+				name, ref.index, nargs))
+		}
+	}
+	for jobIdx, job := range script.Jobs {
+		refs, _ := scriptArgRefs(scriptJobExprs(job)...)
+		for _, ref := range refs {
+			if ref.index >= nargs {
+				errs.Append(errors.E(ErrScriptMissingArg, ref.rng,
+					"job %d of script %q references script.args[%d] but %d argument(s) were given",
+					jobIdx, name, ref.index, nargs))
+			}
+		}
+	}
+	return errs.AsError()
+}
+
+type scriptArgRef struct {
+	index int
+	rng   hhcl.Range
+}
+
+// scriptArgRefs returns the script.args indexes referenced by the expressions
+// and if the expressions reference script.args at all. Only constant indexes
+// are returned.
+func scriptArgRefs(exprs ...hhcl.Expression) ([]scriptArgRef, bool) {
+	var refs []scriptArgRef
+	found := false
+	for _, expr := range exprs {
+		for _, traversal := range expr.Variables() {
+			if traversal.RootName() != "script" || len(traversal) < 2 {
+				continue
+			}
+			if attr, ok := traversal[1].(hhcl.TraverseAttr); !ok || attr.Name != "args" {
+				continue
+			}
+			found = true
+			if len(traversal) < 3 {
+				continue
+			}
+			step, ok := traversal[2].(hhcl.TraverseIndex)
+			if !ok || !step.Key.IsKnown() || step.Key.Type() != cty.Number {
+				continue
+			}
+			index, accuracy := step.Key.AsBigFloat().Int64()
+			if accuracy != big.Exact || index < 0 {
+				continue
+			}
+			refs = append(refs, scriptArgRef{index: int(index), rng: traversal.SourceRange()})
+		}
+	}
+	return refs, found
+}
+
+func scriptLetsExprs(script hcl.Script) []hhcl.Expression {
+	if script.Lets == nil {
+		return nil
+	}
+	var exprs []hhcl.Expression
+	for _, attr := range script.Lets.Attributes.SortedList() {
+		exprs = append(exprs, attr.Expr)
+	}
+	return exprs
+}
+
+func scriptJobExprs(job *hcl.ScriptJob) []hhcl.Expression {
+	var exprs []hhcl.Expression
+	if job.Name != nil {
+		exprs = append(exprs, job.Name.Expr)
+	}
+	if job.Description != nil {
+		exprs = append(exprs, job.Description.Expr)
+	}
+	if job.Command != nil {
+		exprs = append(exprs, job.Command.Expr)
+	}
+	if job.Commands != nil {
+		exprs = append(exprs, job.Commands.Expr)
+	}
+	return exprs
+}
+
 func evalScriptStringField(evalctx *eval.Context, expr hhcl.Expression, name string) (string, error) {
 	f, err := evalString(evalctx, expr, name)
 	if err != nil {
@@ -415,6 +767,10 @@ func unmarshalScriptCommandOptions(obj cty.Value, expr hhcl.Expression) (*Script
 			r.CloudTerraformPlanFile = v.AsString()
 
 		case "tofu_plan_file":
+			fallthrough
+		case "sync_tofu_plan_file":
+			fallthrough
+		case "cloud_sync_tofu_plan_file":
 			if v.Type() != cty.String {
 				errs.Append(errors.E(ErrScriptInvalidCmdOptions, expr.Range(),
 					"command option '%s' must be a string, but has type %s",
@@ -453,16 +809,21 @@ func unmarshalScriptCommandOptions(obj cty.Value, expr hhcl.Expression) (*Script
 		default:
 			errs.Append(errors.E(ErrScriptInvalidCmdOptions, expr.Range(), "unknown command option: %s", ks))
 		}
+	}
 
-		if r.CloudSyncDeployment && r.CloudSyncDriftStatus {
-			errs.Append(errors.E(ErrScriptInvalidCmdOptions, expr.Range(),
-				"'sync_deployment' and 'sync_drift_status' are conflicting options in the same command"))
-		}
+	if r.CloudSyncDeployment && r.CloudSyncDriftStatus {
+		errs.Append(errors.E(ErrScriptInvalidCmdOptions, expr.Range(),
+			"'sync_deployment' and 'sync_drift_status' are conflicting options in the same command"))
+	}
 
-		if r.CloudTerraformPlanFile != "" && r.CloudTofuPlanFile != "" {
-			errs.Append(errors.E(ErrScriptInvalidCmdOptions, expr.Range(),
-				"'terraform_plan_file' and 'tofu_plan_file' are conflicting options in the same command"))
-		}
+	if r.CloudTerraformPlanFile != "" && r.CloudTofuPlanFile != "" {
+		errs.Append(errors.E(ErrScriptInvalidCmdOptions, expr.Range(),
+			"'terraform_plan_file' and 'tofu_plan_file' are conflicting options in the same command"))
+	}
+
+	if r.CloudSyncPreview && r.CloudTerraformPlanFile == "" && r.CloudTofuPlanFile == "" {
+		errs.Append(errors.E(ErrScriptInvalidCmdOptions, expr.Range(),
+			"'sync_preview' requires 'terraform_plan_file' or 'tofu_plan_file'"))
 	}
 
 	if err := errs.AsError(); err != nil {
@@ -10,8 +10,10 @@ import (
 	"github.com/google/go-cmp/cmp/cmpopts"
 	"github.com/madlambda/spells/assert"
 	"github.com/terramate-io/terramate/config"
+	"github.com/terramate-io/terramate/errors"
 	"github.com/terramate-io/terramate/hcl/eval"
 	"github.com/terramate-io/terramate/hcl/info"
+	"github.com/terramate-io/terramate/lets"
 	"github.com/terramate-io/terramate/project"
 	"github.com/terramate-io/terramate/stdlib"
 	"github.com/terramate-io/terramate/test"
@@ -324,6 +326,129 @@ func TestScriptLetsEval(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "lets map block",
+			globals: map[string]cty.Value{
+				"regions": cty.ListVal([]cty.Value{
+					cty.StringVal("us-east-1"),
+					cty.StringVal("eu-west-1"),
+				}),
+			},
+			config: Script(
+				Labels(labels...),
+				Lets(
+					Map(
+						Labels("zones"),
+						Expr("for_each", `global.regions`),
+						Expr("key", `element.new`),
+						Expr("value", `"${element.new}a"`),
+					),
+				),
+				Block("job",
+					Expr("command", `["echo", let.zones["us-east-1"], let.zones["eu-west-1"]]`),
+				),
+			),
+			want: config.Script{
+				Labels: labels,
+				Jobs: []config.ScriptJob{
+					{
+						Cmd: &config.ScriptCmd{
+							Args: []string{"echo", "us-east-1a", "eu-west-1a"},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "lets map block with iterator referencing other lets",
+			config: Script(
+				Labels(labels...),
+				Lets(
+					Expr("names", `["a", "b", "a"]`),
+					Map(
+						Labels("counts"),
+						Expr("for_each", `let.names`),
+						Expr("iterator", `name`),
+						Expr("key", `name.new`),
+						Expr("value", `tm_try(name.old, 0) + 1`),
+					),
+				),
+				Block("job",
+					Expr("command", `["echo", tm_jsonencode(let.counts)]`),
+				),
+			),
+			want: config.Script{
+				Labels: labels,
+				Jobs: []config.ScriptJob{
+					{
+						Cmd: &config.ScriptCmd{
+							Args: []string{"echo", `{"a":2,"b":1}`},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "lets map block with nested value blocks and recursion",
+			config: Script(
+				Labels(labels...),
+				Lets(
+					Map(
+						Labels("teams"),
+						Expr("for_each", `[
+							{team = "dev", members = ["alice", "bob"]},
+							{team = "ops", members = ["carol"]},
+						]`),
+						Expr("key", `element.new.team`),
+						Value(
+							Map(
+								Labels("members"),
+								Expr("for_each", `element.new.members`),
+								Expr("iterator", `member`),
+								Expr("key", `member.new`),
+								Expr("value", `true`),
+							),
+							Expr("name", `tm_upper(element.new.team)`),
+						),
+					),
+				),
+				Block("job",
+					Expr("command", `["echo", tm_jsonencode(let.teams)]`),
+				),
+			),
+			want: config.Script{
+				Labels: labels,
+				Jobs: []config.ScriptJob{
+					{
+						Cmd: &config.ScriptCmd{
+							Args: []string{
+								"echo",
+								`{"dev":{"members":{"alice":true,"bob":true},"name":"DEV"},"ops":{"members":{"carol":true},"name":"OPS"}}`,
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "lets map label conflicting with attribute fails",
+			config: Script(
+				Labels(labels...),
+				Lets(
+					Str("zones", "conflict"),
+					Map(
+						Labels("zones"),
+						Expr("for_each", `["a"]`),
+						Expr("key", `element.new`),
+						Expr("value", `element.new`),
+					),
+				),
+				Block("job",
+					Expr("command", `["echo", let.zones]`),
+				),
+			),
+			wantErr: errors.E(lets.ErrRedefined),
+		},
 	} {
 		tc := tc
 		t.Run(tc.name, func(t *testing.T) {
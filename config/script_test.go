@@ -554,6 +554,77 @@ func TestScriptEval(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "command options with sync_preview without planfile",
+			config: Script(
+				Labels(labels...),
+				Str("description", "some description"),
+				Block("job",
+					Expr("commands", `[
+								["echo", "hello", {
+									sync_preview = true
+								}],
+							  ]
+							`),
+				),
+			),
+			wantErr: errors.E(config.ErrScriptInvalidCmdOptions),
+		},
+		{
+			name: "command options with terraform and tofu planfiles",
+			config: Script(
+				Labels(labels...),
+				Str("description", "some description"),
+				Block("job",
+					Expr("commands", `[
+								["echo", "hello", {
+									sync_preview = true
+									terraform_plan_file = "plan_a"
+									tofu_plan_file = "plan_b"
+								}],
+							  ]
+							`),
+				),
+			),
+			wantErr: errors.E(config.ErrScriptInvalidCmdOptions),
+		},
+		{
+			name: "command options with sync_preview + tofu planfile + terragrunt",
+			config: Script(
+				Labels(labels...),
+				Str("description", "some description"),
+				Block("job",
+					Expr("commands", `[
+								["echo", "hello", {
+									cloud_sync_preview = true
+									cloud_sync_tofu_plan_file = "plan_a"
+									cloud_sync_layer = "staging"
+									terragrunt = true
+								}],
+							  ]
+							`),
+				),
+			),
+			want: config.Script{
+				Labels:      labels,
+				Description: "some description",
+				Jobs: []config.ScriptJob{
+					{
+						Cmds: []*config.ScriptCmd{
+							{
+								Args: []string{"echo", "hello"},
+								Options: &config.ScriptCmdOptions{
+									CloudSyncPreview:  true,
+									CloudSyncLayer:    "staging",
+									CloudTofuPlanFile: "plan_a",
+									UseTerragrunt:     true,
+								},
+							},
+						},
+					},
+				},
+			},
+		},
 		{
 			name: "command options",
 			config: Script(
@@ -705,6 +776,115 @@ func TestScriptEval(t *testing.T) {
 			),
 			wantErr: errors.E(config.ErrScriptInvalidCmdOptions),
 		},
+		{
+			name: "job capture and deferred job referencing job_output",
+			config: Script(
+				Labels(labels...),
+				Block("job",
+					Expr("command", `["echo", "arn"]`),
+					Str("capture", "bootstrap"),
+				),
+				Block("job",
+					Expr("command", `["echo", job_output.bootstrap]`),
+				),
+			),
+			want: config.Script{
+				Labels: labels,
+				Jobs: []config.ScriptJob{
+					{
+						Cmd:     &config.ScriptCmd{Args: []string{"echo", "arn"}},
+						Capture: "bootstrap",
+					},
+					{
+						Deferred: true,
+					},
+				},
+			},
+		},
+		{
+			name: "job_output referenced by the job capturing it",
+			config: Script(
+				Labels(labels...),
+				Block("job",
+					Expr("command", `["echo", job_output.bootstrap]`),
+					Str("capture", "bootstrap"),
+				),
+			),
+			wantErr: errors.E(config.ErrScriptUndefinedJobOutput),
+		},
+		{
+			name: "job_output not captured by any job",
+			config: Script(
+				Labels(labels...),
+				Block("job",
+					Expr("command", `["echo", "arn"]`),
+					Str("capture", "bootstrap"),
+				),
+				Block("job",
+					Expr("commands", `[["echo", job_output.other]]`),
+				),
+			),
+			wantErr: errors.E(config.ErrScriptUndefinedJobOutput),
+		},
+		{
+			name: "job capture is not a valid identifier",
+			config: Script(
+				Labels(labels...),
+				Block("job",
+					Expr("command", `["echo", "arn"]`),
+					Str("capture", "not valid"),
+				),
+			),
+			wantErr: errors.E(config.ErrScriptInvalidCapture),
+		},
+		{
+			name: "job_ordering attribute",
+			config: Script(
+				Labels(labels...),
+				Str("job_ordering", "job"),
+				Block("job", Command("echo", "hello")),
+			),
+			want: config.Script{
+				Labels:      labels,
+				JobOrdering: config.ScriptJobOrderingJob,
+				Jobs: []config.ScriptJob{
+					{Cmd: &config.ScriptCmd{Args: []string{"echo", "hello"}}},
+				},
+			},
+		},
+		{
+			name: "job_ordering attribute with invalid value",
+			config: Script(
+				Labels(labels...),
+				Str("job_ordering", "parallel"),
+				Block("job", Command("echo", "hello")),
+			),
+			wantErr: errors.E(config.ErrScriptSchema),
+		},
+		{
+			name: "job_ordering attribute wrong type",
+			config: Script(
+				Labels(labels...),
+				Number("job_ordering", 1),
+				Block("job", Command("echo", "hello")),
+			),
+			wantErr: errors.E(config.ErrScriptInvalidType),
+		},
+		{
+			name: "job capture is duplicated",
+			config: Script(
+				Labels(labels...),
+				Block("job",
+					Expr("command", `["echo", "a"]`),
+					Str("capture", "out"),
+				),
+				Block("job",
+					Expr("command", `["echo", "b"]`),
+					Str("capture", "out"),
+				),
+			),
+			wantErr: errors.E(config.ErrScriptInvalidCapture),
+		},
 	}
 
 	for _, tcase := range tcases {
@@ -716,6 +896,283 @@ func TestScriptEval(t *testing.T) {
 	}
 }
 
+func TestScriptEvalDeferredJob(t *testing.T) {
+	t.Parallel()
+
+	tempdir := test.TempDir(t)
+	test.AppendFile(t, tempdir, "stack.tm", Block("stack").String())
+	test.AppendFile(t, tempdir, "script.tm", Script(
+		Labels("deploy"),
+		Block("lets",
+			Str("prefix", "--arn="),
+		),
+		Block("job",
+			Expr("command", `["echo", "arn"]`),
+			Str("capture", "bootstrap"),
+		),
+		Block("job",
+			Expr("commands", `[
+			  ["echo", "${let.prefix}${job_output.bootstrap}"],
+			  ["echo", "done"],
+			]`),
+		),
+		Block("job",
+			Expr("command", `["echo", job_output.bootstrap, {
+			  sync_deployment = true
+			}]`),
+		),
+	).String())
+	test.AppendFile(t, tempdir, "terramate.tm", Terramate(
+		Config(
+			Expr("experiments", `["scripts"]`),
+		),
+	).String())
+
+	cfg, err := config.LoadRoot(tempdir)
+	assert.NoError(t, err)
+	rootTree, _ := cfg.Lookup(project.NewPath("/"))
+	script := *rootTree.Node.Scripts[0]
+	hclctx := eval.NewContext(stdlib.Functions(tempdir, []string{}))
+
+	_, err = config.EvalScriptJob(hclctx, script, 1, map[string]cty.Value{})
+	assert.IsError(t, err, errors.E(config.ErrScriptUndefinedJobOutput))
+
+	got, err := config.EvalScriptJob(hclctx, script, 1, map[string]cty.Value{
+		"bootstrap": cty.StringVal("arn:aws:iam::123"),
+	})
+	assert.NoError(t, err)
+	want := config.ScriptJob{
+		Cmds: []*config.ScriptCmd{
+			{Args: []string{"echo", "--arn=arn:aws:iam::123"}},
+			{Args: []string{"echo", "done"}},
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("unexpected result\n%s", diff)
+	}
+
+	_, err = config.EvalScriptJob(hclctx, script, 2, map[string]cty.Value{
+		"bootstrap": cty.StringVal("arn:aws:iam::123"),
+	})
+	assert.IsError(t, err, errors.E(config.ErrScriptInvalidCmdOptions))
+}
+
+func TestScriptEvalCmdOptions(t *testing.T) {
+	t.Parallel()
+
+	tempdir := test.TempDir(t)
+	test.AppendFile(t, tempdir, "stack.tm", Block("stack").String())
+	test.AppendFile(t, tempdir, "script.tm", Script(
+		Labels("drift"),
+		Block("lets",
+			Str("planfile", "drift.tfplan"),
+		),
+		Block("job",
+			Expr("command", `["terraform", "plan", "-out=${let.planfile}"]`),
+		),
+		Block("job",
+			Expr("commands", `[
+			  ["echo", "detect"],
+			  ["terraform", "plan", {
+			    sync_drift_status   = true
+			    terraform_plan_file = tm_fileexists(let.planfile) ? let.planfile : "other.tfplan"
+			  }],
+			]`),
+		),
+	).String())
+	test.AppendFile(t, tempdir, "terramate.tm", Terramate(
+		Config(
+			Expr("experiments", `["scripts"]`),
+		),
+	).String())
+
+	cfg, err := config.LoadRoot(tempdir)
+	assert.NoError(t, err)
+	rootTree, _ := cfg.Lookup(project.NewPath("/"))
+	script := *rootTree.Node.Scripts[0]
+	hclctx := eval.NewContext(stdlib.Functions(tempdir, []string{}))
+
+	opts, err := config.EvalScriptCmdOptions(hclctx, script, 0, 0)
+	assert.NoError(t, err)
+	assert.IsTrue(t, opts == nil, "unexpected options: %+v", opts)
+
+	opts, err = config.EvalScriptCmdOptions(hclctx, script, 1, 1)
+	assert.NoError(t, err)
+	assert.EqualStrings(t, "other.tfplan", opts.CloudTerraformPlanFile)
+
+	// the options observe the files created after the script was evaluated.
+	test.WriteFile(t, tempdir, "drift.tfplan", "")
+	opts, err = config.EvalScriptCmdOptions(hclctx, script, 1, 1)
+	assert.NoError(t, err)
+	assert.IsTrue(t, opts.CloudSyncDriftStatus, "sync_drift_status is not set")
+	assert.EqualStrings(t, "drift.tfplan", opts.CloudTerraformPlanFile)
+
+	_, err = config.EvalScriptCmdOptions(hclctx, script, 1, 2)
+	assert.IsError(t, err, errors.E(config.ErrScriptSchema))
+}
+
+func TestScriptEvalArgs(t *testing.T) {
+	t.Parallel()
+
+	tempdir := test.TempDir(t)
+	test.AppendFile(t, tempdir, "stack.tm", Block("stack").String())
+	test.AppendFile(t, tempdir, "script.tm", Script(
+		Labels("deploy"),
+		Block("lets",
+			Expr("env", "script.args[0]"),
+		),
+		Block("job",
+			Expr("command", `["echo", let.env]`),
+		),
+		Block("job",
+			Expr("command", `["echo", script.args[2]]`),
+		),
+	).String())
+	test.AppendFile(t, tempdir, "terramate.tm", Terramate(
+		Config(
+			Expr("experiments", `["scripts"]`),
+		),
+	).String())
+
+	cfg, err := config.LoadRoot(tempdir)
+	assert.NoError(t, err)
+	rootTree, _ := cfg.Lookup(project.NewPath("/"))
+	script := *rootTree.Node.Scripts[0]
+
+	usesArgs, required := config.ScriptUsesArgs(script)
+	assert.IsTrue(t, usesArgs, "script uses script.args")
+	assert.EqualInts(t, 3, required)
+
+	hclctx := eval.NewContext(stdlib.Functions(tempdir, []string{}))
+	_, err = config.EvalScript(hclctx, script)
+	assert.IsError(t, err, errors.E(config.ErrScriptMissingArg))
+
+	config.SetScriptArgs(hclctx, []string{"prod", "a"})
+	_, err = config.EvalScript(hclctx, script)
+	assert.IsError(t, err, errors.E(config.ErrScriptMissingArg))
+
+	config.SetScriptArgs(hclctx, []string{"prod", "a", "b"})
+	got, err := config.EvalScript(hclctx, script)
+	assert.NoError(t, err)
+	want := config.Script{
+		Labels: []string{"deploy"},
+		Jobs: []config.ScriptJob{
+			{Cmd: &config.ScriptCmd{Args: []string{"echo", "prod"}}},
+			{Cmd: &config.ScriptCmd{Args: []string{"echo", "b"}}},
+		},
+	}
+	if diff := cmp.Diff(want, got, cmpopts.IgnoreUnexported(info.Range{})); diff != "" {
+		t.Fatalf("unexpected result\n%s", diff)
+	}
+}
+
+func TestScriptEvalEnabled(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name    string
+		script  fmt.Stringer
+		want    bool
+		wantErr error
+	}{
+		{
+			name: "enabled by default",
+			script: Script(
+				Labels("deploy"),
+				Block("job", Command("echo", "hello")),
+			),
+			want: true,
+		},
+		{
+			name: "enabled from globals",
+			script: Script(
+				Labels("deploy"),
+				Expr("enabled", "global.enabled"),
+				Block("job", Command("echo", "hello")),
+			),
+			want: true,
+		},
+		{
+			name: "disabled from expression",
+			script: Script(
+				Labels("deploy"),
+				Expr("enabled", "!global.enabled"),
+				Block("job", Command("echo", "hello")),
+			),
+			want: false,
+		},
+		{
+			name: "disabled without jobs",
+			script: Script(
+				Labels("deploy"),
+				Expr("enabled", "false"),
+			),
+			want: false,
+		},
+		{
+			name: "invalid type",
+			script: Script(
+				Labels("deploy"),
+				Str("enabled", "yes"),
+				Block("job", Command("echo", "hello")),
+			),
+			wantErr: errors.E(config.ErrScriptInvalidType),
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			tempdir := test.TempDir(t)
+			test.AppendFile(t, tempdir, "script.tm", tc.script.String())
+			test.AppendFile(t, tempdir, "terramate.tm", Terramate(
+				Config(
+					Expr("experiments", `["scripts"]`),
+				),
+			).String())
+
+			cfg, err := config.LoadRoot(tempdir)
+			assert.NoError(t, err)
+			rootTree, _ := cfg.Lookup(project.NewPath("/"))
+			script := *rootTree.Node.Scripts[0]
+
+			hclctx := eval.NewContext(stdlib.Functions(tempdir, []string{}))
+			hclctx.SetNamespace("global", map[string]cty.Value{
+				"enabled": cty.True,
+			})
+			got, err := config.EvalScriptEnabled(hclctx, script)
+			assert.IsError(t, err, tc.wantErr)
+			if tc.wantErr != nil {
+				return
+			}
+			assert.IsTrue(t, got == tc.want, "got enabled=%t, want %t", got, tc.want)
+		})
+	}
+}
+
+func TestScriptEvalFailsIfEnabledWithoutJobs(t *testing.T) {
+	t.Parallel()
+
+	tempdir := test.TempDir(t)
+	test.AppendFile(t, tempdir, "script.tm", Script(
+		Labels("deploy"),
+		Expr("enabled", "true"),
+	).String())
+	test.AppendFile(t, tempdir, "terramate.tm", Terramate(
+		Config(
+			Expr("experiments", `["scripts"]`),
+		),
+	).String())
+
+	cfg, err := config.LoadRoot(tempdir)
+	assert.NoError(t, err)
+	rootTree, _ := cfg.Lookup(project.NewPath("/"))
+	script := *rootTree.Node.Scripts[0]
+
+	hclctx := eval.NewContext(stdlib.Functions(tempdir, []string{}))
+	_, err = config.EvalScript(hclctx, script)
+	assert.IsError(t, err, errors.E(hcl.ErrScriptMissingOrInvalidJob))
+}
+
 func testScriptEval(t *testing.T, tcase scriptTestcase) {
 	t.Helper()
 	tempdir := test.TempDir(t)
@@ -4,10 +4,14 @@
 package config
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
 	"os"
 	"path"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
 
 	"github.com/terramate-io/terramate/config/tag"
@@ -53,8 +57,16 @@ type (
 		// Watch is the list of files to be watched for changes.
 		Watch project.Paths
 
+		// IgnoreOnChange is the list of glob patterns, relative to the stack
+		// directory, of the files whose changes do not mark the stack as changed.
+		IgnoreOnChange []string
+
 		// IsChanged tells if this is a changed stack.
 		IsChanged bool
+
+		// TriggerPriority is the highest run order priority of the change
+		// triggers of the stack present in the change set, or zero if none.
+		TriggerPriority int
 	}
 
 	// SortableStack is a wrapper for the Stack which implements the [DirElem] type.
@@ -63,6 +75,14 @@ type (
 	}
 )
 
+// StackDataDirs is the directory, relative to the project root, with the
+// isolated data directories of the stacks.
+const StackDataDirs = ".terramate/data"
+
+// StackLockDirs is the directory, relative to the project root, with the
+// run lock files of the stacks.
+const StackLockDirs = ".terramate/locks"
+
 const (
 	// ErrStackValidation indicates an error when validating the stack fields.
 	ErrStackValidation errors.Kind = "validating stack fields"
@@ -106,6 +126,8 @@ func NewStackFromHCL(root string, cfg hcl.Config) (*Stack, error) {
 		WantedBy:    cfg.Stack.WantedBy,
 		Watch:       watchFiles,
 		Dir:         project.PrjAbsPath(root, cfg.AbsDir()),
+
+		IgnoreOnChange: cfg.Stack.IgnoreOnChange,
 	}
 	err = stack.Validate()
 	if err != nil {
@@ -219,6 +241,40 @@ func (s *Stack) HostDir(root *Root) string {
 	return project.AbsPath(root.HostDir(), s.Dir.String())
 }
 
+// DataDir returns the project path of the isolated data directory of the stack,
+// which is named after the stack ID. It returns false if the stack has no ID.
+func (s *Stack) DataDir() (project.Path, bool) {
+	if s.ID == "" {
+		return project.Path{}, false
+	}
+	return project.NewPath(path.Join("/", StackDataDirs, s.ID)), true
+}
+
+// LockFile returns the project path of the run lock file of the stack, which
+// is named after the stack ID. Stacks without an ID use a name derived from
+// the stack path instead, so moving them changes their lock.
+func (s *Stack) LockFile() project.Path {
+	name := s.ID
+	if name == "" {
+		sum := sha256.Sum256([]byte(s.Dir.String()))
+		name = "path-" + hex.EncodeToString(sum[:])
+	}
+	return project.NewPath(path.Join("/", StackLockDirs, name+".lock"))
+}
+
+// RuntimeValuesWithTerraformDir returns the runtime "terramate" namespace for
+// the stack when Terraform runs in the tfdir directory, as set by the
+// `terraform -chdir=<dir>` option. It's the namespace of [Stack.RuntimeValues]
+// plus terramate.stack.terraform_dir, which is only known when running a
+// command in the stack, so it's only available in `run --eval`.
+func (s *Stack) RuntimeValuesWithTerraformDir(root *Root, tfdir project.Path) map[string]cty.Value {
+	runtime := s.RuntimeValues(root)
+	stackVals := runtime["stack"].AsValueMap()
+	stackVals["terraform_dir"] = cty.StringVal(tfdir.String())
+	runtime["stack"] = cty.ObjectVal(stackVals)
+	return runtime
+}
+
 // RuntimeValues returns the runtime "terramate" namespace for the stack.
 func (s *Stack) RuntimeValues(root *Root) map[string]cty.Value {
 	stackpath := cty.ObjectVal(map[string]cty.Value{
@@ -228,29 +284,38 @@ func (s *Stack) RuntimeValues(root *Root) map[string]cty.Value {
 		"to_root":  cty.StringVal(s.RelPathToRoot(root)),
 	})
 	stackMapVals := map[string]cty.Value{
-		"name":        cty.StringVal(s.Name),
-		"description": cty.StringVal(s.Description),
-		"tags":        toCtyStringList(s.Tags),
-		"path":        stackpath,
+		"name":          cty.StringVal(s.Name),
+		"description":   cty.StringVal(s.Description),
+		"tags":          toCtyStringList(s.Tags),
+		"path":          stackpath,
 	}
 	if s.ID != "" {
 		stackMapVals["id"] = cty.StringVal(s.ID)
 	}
-	cfg, _ := root.Lookup(s.Dir)
+	if datadir, ok := s.DataDir(); ok {
+		stackMapVals["data_dir"] = cty.StringVal(datadir.String())
+	}
+	stackTree, _ := root.Lookup(s.Dir)
 	var parentStack *Tree
 
-	for cfg.Parent != nil {
+	for cfg := stackTree; cfg.Parent != nil; {
 		cfg = cfg.Parent
 		if cfg.IsStack() {
 			parentStack = cfg
 			break
 		}
 	}
+	// parent.path is null for top-level stacks, so templates can test for it.
+	parentVals := map[string]cty.Value{
+		"path": cty.NullVal(cty.String),
+	}
 	if parentStack != nil {
-		stackMapVals["parent"] = cty.ObjectVal(map[string]cty.Value{
-			"id": cty.StringVal(parentStack.Node.Stack.ID),
-		})
+		parentVals["id"] = cty.StringVal(parentStack.Node.Stack.ID)
+		parentVals["path"] = cty.StringVal(parentStack.Dir().String())
 	}
+	stackMapVals["parent"] = cty.ObjectVal(parentVals)
+	stackMapVals["children"] = toCtyStringList(stackTree.childStacks().Paths().Strings())
+
 	stack := cty.ObjectVal(stackMapVals)
 	return map[string]cty.Value{
 		"name":        cty.StringVal(s.Name),         // DEPRECATED
@@ -316,7 +381,7 @@ func LoadAllStacks(root *Root, cfg *Tree) (List[*SortableStack], error) {
 	falsy := false
 	root.hasTerragruntStacks = &falsy
 	stacks := List[*SortableStack]{}
-	stacksIDs := map[string]*Stack{}
+	var withID []*Stack
 
 	for _, stackNode := range cfg.Stacks() {
 		stack, err := stackNode.Stack()
@@ -336,21 +401,87 @@ func LoadAllStacks(root *Root, cfg *Tree) (List[*SortableStack], error) {
 		}
 
 		if stack.ID != "" {
-			if otherStack, ok := stacksIDs[strings.ToLower(stack.ID)]; ok {
-				return List[*SortableStack]{}, errors.E(ErrStackDuplicatedID,
-					"stack %q and %q have same ID %q",
-					stack.Dir,
-					otherStack.Dir,
-					stack.ID,
-				)
-			}
-			stacksIDs[strings.ToLower(stack.ID)] = stack
+			withID = append(withID, stack)
 		}
 	}
 
+	// all the duplicated IDs are reported in a single error, so none is
+	// elided when showing the error.
+	var dups []string
+	for _, dup := range duplicatedStackIDs(withID) {
+		dups = append(dups, dup.String())
+	}
+	if len(dups) > 0 {
+		return List[*SortableStack]{}, errors.E(ErrStackDuplicatedID, strings.Join(dups, "; "))
+	}
 	return stacks, nil
 }
 
+// DuplicatedStackID is a stack ID defined by more than one stack. IDs are
+// compared case-insensitively.
+type DuplicatedStackID struct {
+	// ID is the ID, as defined by the first stack.
+	ID string
+	// Stacks are the paths of the stacks defining the ID, sorted
+	// lexicographically.
+	Stacks []project.Path
+}
+
+// FindDuplicatedStackIDs returns the stack IDs defined by more than one stack
+// inside the given tree, ordered by the path of their first stack.
+func FindDuplicatedStackIDs(cfg *Tree) ([]DuplicatedStackID, error) {
+	var withID []*Stack
+	for _, stackNode := range cfg.Stacks() {
+		stack, err := stackNode.Stack()
+		if err != nil {
+			return nil, err
+		}
+		if stack.ID != "" {
+			withID = append(withID, stack)
+		}
+	}
+	return duplicatedStackIDs(withID), nil
+}
+
+func duplicatedStackIDs(stacks []*Stack) []DuplicatedStackID {
+	sorted := make([]*Stack, len(stacks))
+	copy(sorted, stacks)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Dir.String() < sorted[j].Dir.String()
+	})
+
+	byID := map[string]*DuplicatedStackID{}
+	var ids []string
+	for _, stack := range sorted {
+		id := strings.ToLower(stack.ID)
+		dup, ok := byID[id]
+		if !ok {
+			dup = &DuplicatedStackID{ID: stack.ID}
+			byID[id] = dup
+			ids = append(ids, id)
+		}
+		dup.Stacks = append(dup.Stacks, stack.Dir)
+	}
+
+	var dups []DuplicatedStackID
+	for _, id := range ids {
+		if dup := byID[id]; len(dup.Stacks) > 1 {
+			dups = append(dups, *dup)
+		}
+	}
+	return dups
+}
+
+// String describes the duplicated ID and the stacks defining it.
+func (dup DuplicatedStackID) String() string {
+	paths := make([]string, len(dup.Stacks))
+	for i, dir := range dup.Stacks {
+		paths[i] = dir.String()
+	}
+	return fmt.Sprintf("stack ID %q is defined by the stacks %s",
+		dup.ID, strings.Join(paths, ", "))
+}
+
 // LoadStack a single stack from dir.
 func LoadStack(root *Root, dir project.Path) (*Stack, error) {
 	node, ok := root.Lookup(dir)
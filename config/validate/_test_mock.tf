@@ -0,0 +1,20 @@
+// TERRAMATE: GENERATED AUTOMATICALLY DO NOT EDIT
+
+resource "local_file" "validate" {
+  content = <<-EOT
+package validate // import "github.com/terramate-io/terramate/config/validate"
+
+Package validate checks the Terramate configuration of a project without
+generating code, evaluating globals or accessing git.
+
+type Diagnostic struct{ ... }
+type Diagnostics []Diagnostic
+    func Project(rootdir string) Diagnostics
+type Pos struct{ ... }
+type Range struct{ ... }
+type Severity string
+    const SeverityError Severity = "error" ...
+EOT
+
+  filename = "${path.module}/mock-validate.ignore"
+}
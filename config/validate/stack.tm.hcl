@@ -0,0 +1,9 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+stack {
+  name        = "package validate // import \"github.com/terramate-io/terramate/config/validate\""
+  description = "package validate // import \"github.com/terramate-io/terramate/config/validate\"\n\nPackage validate checks the Terramate configuration of a project without\ngenerating code, evaluating globals or accessing git.\n\ntype Diagnostic struct{ ... }\ntype Diagnostics []Diagnostic\n    func Project(rootdir string) Diagnostics\ntype Pos struct{ ... }\ntype Range struct{ ... }\ntype Severity string\n    const SeverityError Severity = \"error\" ..."
+  tags        = ["config", "golang", "validate"]
+  id          = "780673e2-6784-43cc-92f9-5e111ac041ae"
+}
@@ -0,0 +1,231 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+// Package validate checks the Terramate configuration of a project without
+// generating code, evaluating globals or accessing git.
+package validate
+
+import (
+	stderrors "errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	hhcl "github.com/terramate-io/hcl/v2"
+	"github.com/terramate-io/terramate"
+	"github.com/terramate-io/terramate/config"
+	"github.com/terramate-io/terramate/errors"
+	"github.com/terramate-io/terramate/fs"
+	"github.com/terramate-io/terramate/hcl"
+	"github.com/terramate-io/terramate/project"
+)
+
+// Severity of a diagnostic.
+type Severity string
+
+// Available severities.
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+// Pos is a position inside a file. Line and column start at 1.
+type Pos struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// Range of a diagnostic inside a file. The filename is relative to the
+// project root and uses forward slashes.
+type Range struct {
+	Filename string `json:"filename"`
+	Start    Pos    `json:"start"`
+	End      Pos    `json:"end"`
+}
+
+// Diagnostic is a problem found in the configuration.
+type Diagnostic struct {
+	Severity Severity `json:"severity"`
+	Summary  string   `json:"summary"`
+
+	// Range is nil if the problem is not related to a specific file.
+	Range *Range `json:"range,omitempty"`
+}
+
+// Diagnostics is a list of diagnostics.
+type Diagnostics []Diagnostic
+
+// String formats the diagnostic as <file>:<line>:<col>: <severity>: <summary>.
+func (d Diagnostic) String() string {
+	if d.Range == nil {
+		return fmt.Sprintf("%s: %s", d.Severity, d.Summary)
+	}
+	return fmt.Sprintf("%s:%d:%d: %s: %s",
+		d.Range.Filename, d.Range.Start.Line, d.Range.Start.Column, d.Severity, d.Summary)
+}
+
+// HasErrors tells if any of the diagnostics is an error.
+func (diags Diagnostics) HasErrors() bool {
+	for _, d := range diags {
+		if d.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+type validator struct {
+	rootdir     string
+	experiments []string
+	stackIDs    map[string]project.Path
+	diags       Diagnostics
+}
+
+// Project validates the configuration of all the directories of the project at
+// rootdir, reporting every problem found instead of stopping at the first one.
+// The diagnostics are sorted by file and position.
+func Project(rootdir string) Diagnostics {
+	v := &validator{
+		rootdir:  rootdir,
+		stackIDs: map[string]project.Path{},
+	}
+	v.validateDir(rootdir)
+
+	sort.SliceStable(v.diags, func(i, j int) bool {
+		a, b := v.diags[i].Range, v.diags[j].Range
+		switch {
+		case a == nil || b == nil:
+			return a == nil && b != nil
+		case a.Filename != b.Filename:
+			return a.Filename < b.Filename
+		case a.Start.Line != b.Start.Line:
+			return a.Start.Line < b.Start.Line
+		default:
+			return a.Start.Column < b.Start.Column
+		}
+	})
+	return v.diags
+}
+
+func (v *validator) validateDir(dir string) {
+	res, err := fs.ListTerramateFiles(dir)
+	if err != nil {
+		v.add(SeverityError, err)
+		return
+	}
+	for _, fname := range res.OtherFiles {
+		if fname == terramate.SkipFilename {
+			return
+		}
+	}
+
+	v.validateConfig(dir)
+
+	for _, fname := range res.Dirs {
+		if config.Skip(fname) {
+			continue
+		}
+		v.validateDir(filepath.Join(dir, fname))
+	}
+}
+
+func (v *validator) validateConfig(dir string) {
+	p, err := hcl.NewTerramateParser(v.rootdir, dir, v.experiments...)
+	if err != nil {
+		v.add(SeverityError, err)
+		return
+	}
+	p.CollectWarnings()
+	if err := p.AddDir(dir); err != nil {
+		v.add(SeverityError, err)
+		return
+	}
+
+	cfg, err := p.ParseConfig()
+	for _, warn := range p.Warnings() {
+		v.add(SeverityWarning, warn)
+	}
+	if err != nil {
+		v.add(SeverityError, err)
+		return
+	}
+
+	cfgdir := project.PrjAbsPath(v.rootdir, dir)
+	if dir == v.rootdir {
+		// the experiments of the root change how the other directories are parsed.
+		v.experiments = cfg.Experiments()
+	} else if cfg.IsRootConfig() {
+		v.add(SeverityWarning, errors.E("root config found outside root dir: %s", cfgdir))
+	}
+
+	if cfg.Stack == nil {
+		return
+	}
+
+	stackRange := stackBlockRange(p)
+	st, err := config.NewStackFromHCL(v.rootdir, cfg)
+	if err != nil {
+		v.add(SeverityError, errors.E(stackRange, err))
+		return
+	}
+	if st.ID == "" {
+		return
+	}
+	id := strings.ToLower(st.ID)
+	if other, ok := v.stackIDs[id]; ok {
+		v.add(SeverityError, errors.E(config.ErrStackDuplicatedID, stackRange,
+			"stack %q and %q have same ID %q", other, cfgdir, st.ID))
+		return
+	}
+	v.stackIDs[id] = cfgdir
+}
+
+func (v *validator) add(severity Severity, err error) {
+	for _, err := range errors.L(err).Errors() {
+		d := Diagnostic{
+			Severity: severity,
+			Summary:  err.Error(),
+		}
+		var e *errors.Error
+		if stderrors.As(err, &e) {
+			d.Summary = e.Message()
+			if !e.FileRange.Empty() {
+				d.Range = v.newRange(e.FileRange)
+			}
+		}
+		v.diags = append(v.diags, d)
+	}
+}
+
+func (v *validator) newRange(r hhcl.Range) *Range {
+	filename := r.Filename
+	if rel, err := filepath.Rel(v.rootdir, filename); err == nil && !strings.HasPrefix(rel, "..") {
+		filename = rel
+	}
+	return &Range{
+		Filename: filepath.ToSlash(filename),
+		Start:    Pos{Line: r.Start.Line, Column: r.Start.Column},
+		End:      Pos{Line: r.End.Line, Column: r.End.Column},
+	}
+}
+
+// stackBlockRange returns the range of the stack block parsed by p. The stack
+// validation errors have no range, so they are reported at the block.
+func stackBlockRange(p *hcl.TerramateParser) hhcl.Range {
+	bodies := p.ParsedBodies()
+	filenames := make([]string, 0, len(bodies))
+	for fname := range bodies {
+		filenames = append(filenames, fname)
+	}
+	sort.Strings(filenames)
+
+	for _, fname := range filenames {
+		for _, block := range bodies[fname].Blocks {
+			if block.Type == hcl.StackBlockType {
+				return block.DefRange()
+			}
+		}
+	}
+	return hhcl.Range{}
+}
@@ -0,0 +1,134 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package validate_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/madlambda/spells/assert"
+	"github.com/terramate-io/terramate/config/validate"
+	"github.com/terramate-io/terramate/test/sandbox"
+)
+
+func TestValidateProject(t *testing.T) {
+	t.Parallel()
+
+	type testcase struct {
+		name   string
+		layout []string
+		want   []string
+	}
+
+	for _, tc := range []testcase{
+		{
+			name: "valid project",
+			layout: []string{
+				`s:a:id=a`,
+				`s:a/b:id=b`,
+				`f:globals.tm:globals {
+				  a = tm_upper("not evaluated")
+				}`,
+			},
+		},
+		{
+			name: "all the errors are reported",
+			layout: []string{
+				`f:a/syntax.tm:stack {`,
+				`f:b/unknown.tm:unknown {}`,
+				`f:c/stack.tm:stack {
+				  name = 1
+				}`,
+			},
+			want: []string{
+				"a/syntax.tm:1:7: error: HCL syntax error",
+				`b/unknown.tm:1:1: error: terramate schema error: unrecognized block "unknown"`,
+				"c/stack.tm:2:14: error: terramate schema error: field stack.name must be a string",
+			},
+		},
+		{
+			name: "duplicated globals",
+			layout: []string{
+				`f:a/g1.tm:globals {
+				  a = 1
+				}`,
+				`f:a/g2.tm:globals {
+				  a = 2
+				}`,
+			},
+			want: []string{
+				`a/g2.tm:2:7: error: terramate schema error: attribute "a" redeclared`,
+			},
+		},
+		{
+			name: "invalid stack attributes",
+			layout: []string{
+				`f:a/stack.tm:stack {
+				  id   = "not valid"
+				  tags = ["A"]
+				}`,
+			},
+			want: []string{
+				`a/stack.tm:1:1: error: validating stack fields: "stack.id" "not valid" doesn't match`,
+				`a/stack.tm:1:1: error: validating stack fields: invalid stack.tags entry`,
+			},
+		},
+		{
+			name: "duplicated stack IDs",
+			layout: []string{
+				`s:a:id=same`,
+				`s:b:id=SAME`,
+			},
+			want: []string{
+				`b/terramate.tm.hcl:1:1: error: duplicated ID found on stacks: stack "/a" and "/b" have same ID "SAME"`,
+			},
+		},
+		{
+			name: "root config outside root is a warning",
+			layout: []string{
+				`f:a/config.tm:terramate {
+				  config {
+				    git {
+				      default_branch = "main"
+				    }
+				  }
+				}`,
+			},
+			want: []string{
+				"a/config.tm:3:9: warning: terramate schema error: block terramate.config.git can only be declared at the project root directory",
+			},
+		},
+		{
+			name: "skipped directories are ignored",
+			layout: []string{
+				`f:a/.tmskip:`,
+				`f:a/invalid.tm:invalid {}`,
+			},
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			s := sandbox.NoGit(t, true)
+			s.BuildTree(tc.layout)
+
+			diags := validate.Project(s.RootDir())
+			assert.EqualInts(t, len(tc.want), len(diags), "diagnostics: %v", diags)
+			for i, want := range tc.want {
+				got := diags[i].String()
+				assert.IsTrue(t, strings.HasPrefix(got, want),
+					"diagnostic %d: want prefix %q, got %q", i, want, got)
+			}
+
+			hasErrors := false
+			for _, want := range tc.want {
+				if !strings.Contains(want, ": warning: ") {
+					hasErrors = true
+				}
+			}
+			assert.IsTrue(t, hasErrors == diags.HasErrors(), "HasErrors() = %t", diags.HasErrors())
+		})
+	}
+}
@@ -0,0 +1,118 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package cloud_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/madlambda/spells/assert"
+	"github.com/terramate-io/terramate/cloud"
+	"github.com/terramate-io/terramate/cloud/deployment"
+	"github.com/terramate-io/terramate/cloud/testserver/cloudstore"
+	. "github.com/terramate-io/terramate/e2etests/internal/runner"
+	"github.com/terramate-io/terramate/test/sandbox"
+)
+
+func TestCloudDeploymentListAndShow(t *testing.T) {
+	t.Parallel()
+
+	cloudData, err := cloudstore.LoadDatastore(testserverJSONFile)
+	assert.NoError(t, err)
+	addr := startFakeTMCServer(t, cloudData)
+
+	s := sandbox.New(t)
+	s.BuildTree([]string{
+		"s:s1:id=s1",
+		"s:s2:id=s2",
+	})
+	s.Git().CommitAll("all stacks committed")
+	s.Git().SetRemoteURL("origin", testRemoteRepoURL)
+
+	env := RemoveEnv(os.Environ(), "CI", "GITHUB_ACTIONS")
+	env = append(env, "TMC_API_URL=http://"+addr)
+	tmcli := NewCLI(t, s.RootDir(), env...)
+
+	uuidRegex := regexp.MustCompile(`Terramate Cloud deployment: ([0-9a-f-]{36})`)
+	syncDeployment := func(cli CLI) string {
+		t.Helper()
+		res := cli.Run("run", "--disable-safeguards=git-out-of-sync", "--sync-deployment",
+			"--", HelperPath, "echo", "ok")
+		AssertRunResult(t, res, RunExpected{
+			IgnoreStdout: true,
+			StderrRegex:  uuidRegex.String(),
+		})
+		return uuidRegex.FindStringSubmatch(res.Stderr)[1]
+	}
+
+	first := syncDeployment(tmcli)
+	second := syncDeployment(NewCLI(t, filepath.Join(s.RootDir(), "s2"), env...))
+
+	AssertRunResult(t, tmcli.Run("cloud", "deployment", "list"), RunExpected{
+		StdoutRegex: "(?s)^" + second + " ok .*\t/s2 ok\n" + first + " ok .*\t/s1 ok\n\t/s2 ok\n$",
+	})
+	AssertRunResult(t, tmcli.Run("cloud", "deployment", "list", "--limit", "1"), RunExpected{
+		StdoutRegex: "(?s)^" + second + " ok [^\n]*\n\t/s2 ok\n$",
+	})
+	AssertRunResult(t, tmcli.Run("cloud", "deployment", "list", "--stack", "s1"), RunExpected{
+		StdoutRegex: "(?s)^" + first + " ok .*\t/s1 ok\n\t/s2 ok\n$",
+	})
+	AssertRunResult(t, tmcli.Run("cloud", "deployment", "list", "--stack", "s1", "--limit", "0"), RunExpected{
+		Status:      1,
+		StderrRegex: "--limit must be greater than zero",
+	})
+
+	res := tmcli.Run("cloud", "deployment", "show", "--id", first)
+	AssertRunResult(t, res, RunExpected{
+		StdoutRegex: "(?s)^Deployment " + first + "\nStatus: ok\nCommit: " + s.Git().RevParse("HEAD") +
+			".*\nStacks:\n\t/s1: ok\n\t/s2: ok\n$",
+	})
+
+	res = tmcli.Run("cloud", "deployment", "show", "--id", second, "--json")
+	AssertRunResult(t, res, RunExpected{IgnoreStdout: true})
+	var got cloud.Deployment
+	assert.NoError(t, json.Unmarshal([]byte(res.Stdout), &got))
+	assert.EqualStrings(t, second, string(got.UUID))
+	assert.IsTrue(t, got.Status == deployment.OK, "unexpected status %s", got.Status)
+	assert.EqualStrings(t, s.Git().RevParse("HEAD"), got.CommitSHA)
+	assert.EqualInts(t, 1, len(got.Stacks))
+	assert.EqualStrings(t, "/s2", got.Stacks[0].Path)
+	assert.IsTrue(t, strings.HasSuffix(got.Stacks[0].DeploymentCommand, "echo ok"),
+		"unexpected command %q", got.Stacks[0].DeploymentCommand)
+	assert.IsTrue(t, got.CreatedAt != nil && got.FinishedAt != nil, "deployment must be finished")
+
+	AssertRunResult(t, tmcli.Run("cloud", "deployment", "show", "--id", "a2b1b6a2-7b3c-4c63-9d4b-1f1b0c5b6d7e"), RunExpected{
+		Status:      1,
+		StderrRegex: "Deployment a2b1b6a2-7b3c-4c63-9d4b-1f1b0c5b6d7e not found in Terramate Cloud",
+	})
+}
+
+func TestCloudDeploymentListUnsyncedStack(t *testing.T) {
+	t.Parallel()
+
+	cloudData, err := cloudstore.LoadDatastore(testserverJSONFile)
+	assert.NoError(t, err)
+	addr := startFakeTMCServer(t, cloudData)
+
+	s := sandbox.New(t)
+	s.BuildTree([]string{"s:s1:id=s1"})
+	s.Git().CommitAll("all stacks committed")
+	s.Git().SetRemoteURL("origin", testRemoteRepoURL)
+
+	env := RemoveEnv(os.Environ(), "CI", "GITHUB_ACTIONS")
+	env = append(env, "TMC_API_URL=http://"+addr)
+	tmcli := NewCLI(t, s.RootDir(), env...)
+
+	AssertRunResult(t, tmcli.Run("cloud", "deployment", "list"), RunExpected{
+		Stdout: "No deployments found.\n",
+	})
+	AssertRunResult(t, tmcli.Run("cloud", "deployment", "list", "--stack", "s1"), RunExpected{
+		Status:      1,
+		StderrRegex: "Stack /s1 was not yet synced with the Terramate Cloud",
+	})
+}
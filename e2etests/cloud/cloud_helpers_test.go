@@ -16,11 +16,15 @@ import (
 )
 
 func startFakeTMCServer(t *testing.T, store *cloudstore.Data) string {
+	return startFakeTMCServerWith(t, testserver.Router(store))
+}
+
+func startFakeTMCServerWith(t *testing.T, handler http.Handler) string {
 	l, err := net.Listen("tcp", ":0")
 	assert.NoError(t, err)
 
 	fakeserver := &http.Server{
-		Handler: testserver.Router(store),
+		Handler: handler,
 		Addr:    l.Addr().String(),
 	}
 
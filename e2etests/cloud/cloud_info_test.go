@@ -0,0 +1,219 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package cloud_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/madlambda/spells/assert"
+	"github.com/terramate-io/terramate/cloud/testserver/cloudstore"
+	"github.com/terramate-io/terramate/cmd/terramate/cli"
+	. "github.com/terramate-io/terramate/e2etests/internal/runner"
+	"github.com/terramate-io/terramate/test"
+	"github.com/terramate-io/terramate/test/sandbox"
+)
+
+const multiOrgJSONFile = "testdata/cloud.multiorg.data.json"
+
+type cloudInfoOrg struct {
+	Name        string `json:"name"`
+	DisplayName string `json:"display_name"`
+	UUID        string `json:"uuid"`
+	Role        string `json:"role"`
+	Status      string `json:"status"`
+}
+
+type cloudInfo struct {
+	Status   string `json:"status"`
+	Provider string `json:"provider"`
+	User     *struct {
+		DisplayName string `json:"display_name"`
+		Email       string `json:"email"`
+		UUID        string `json:"uuid"`
+	} `json:"user"`
+	Organizations []cloudInfoOrg `json:"organizations"`
+	Organization  *cloudInfoOrg  `json:"organization"`
+	Targets       *struct {
+		Enabled bool `json:"enabled"`
+	} `json:"targets"`
+	Entitlements *struct {
+		Role string `json:"role"`
+		Sync bool   `json:"sync"`
+	} `json:"entitlements"`
+}
+
+var (
+	terramateOrg = cloudInfoOrg{
+		Name:        "terramate",
+		DisplayName: "Terramate",
+		UUID:        "deadbeef-dead-dead-dead-deaddeadbeef",
+		Role:        "member",
+		Status:      "active",
+	}
+	mineirosOrg = cloudInfoOrg{
+		Name:        "mineiros",
+		DisplayName: "Mineiros",
+		UUID:        "0000beef-dead-dead-dead-deaddeadbeef",
+		Role:        "admin",
+		Status:      "active",
+	}
+	acmeOrg = cloudInfoOrg{
+		Name:        "acme",
+		DisplayName: "ACME",
+		UUID:        "1111beef-dead-dead-dead-deaddeadbeef",
+		Role:        "member",
+		Status:      "invited",
+	}
+)
+
+func TestCloudInfoJSON(t *testing.T) {
+	t.Parallel()
+
+	type testcase struct {
+		name      string
+		datafile  string
+		config    string
+		wantOrgs  []cloudInfoOrg
+		wantOrg   *cloudInfoOrg
+		wantSync  bool
+		wantTargs bool
+	}
+
+	for _, tc := range []testcase{
+		{
+			name:     "single organization is selected",
+			datafile: testserverJSONFile,
+			wantOrgs: []cloudInfoOrg{terramateOrg},
+			wantOrg:  &terramateOrg,
+			wantSync: true,
+		},
+		{
+			name:     "multiple organizations without selection",
+			datafile: multiOrgJSONFile,
+			wantOrgs: []cloudInfoOrg{acmeOrg, mineirosOrg, terramateOrg},
+		},
+		{
+			name:     "multiple organizations selected by config",
+			datafile: multiOrgJSONFile,
+			config: `terramate {
+			  config {
+			    cloud {
+			      organization = "mineiros"
+			    }
+			  }
+			}`,
+			wantOrgs: []cloudInfoOrg{acmeOrg, mineirosOrg, terramateOrg},
+			wantOrg:  &mineirosOrg,
+			wantSync: true,
+		},
+		{
+			name:     "selected organization with pending invitation",
+			datafile: multiOrgJSONFile,
+			config: `terramate {
+			  config {
+			    cloud {
+			      organization = "acme"
+			    }
+			  }
+			}`,
+			wantOrgs: []cloudInfoOrg{acmeOrg, mineirosOrg, terramateOrg},
+			wantOrg:  &acmeOrg,
+		},
+		{
+			name:     "targets enabled",
+			datafile: testserverJSONFile,
+			config: `terramate {
+			  config {
+			    experiments = ["targets"]
+			    cloud {
+			      targets {
+			        enabled = true
+			      }
+			    }
+			  }
+			}`,
+			wantOrgs:  []cloudInfoOrg{terramateOrg},
+			wantOrg:   &terramateOrg,
+			wantSync:  true,
+			wantTargs: true,
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			store, err := cloudstore.LoadDatastore(tc.datafile)
+			assert.NoError(t, err)
+			addr := startFakeTMCServer(t, store)
+
+			s := sandbox.NoGit(t, true)
+			if tc.config != "" {
+				s.RootEntry().CreateFile("terramate.tm.hcl", tc.config)
+			}
+
+			env := RemoveEnv(os.Environ(), "CI", "GITHUB_ACTIONS", "GITLAB_CI")
+			env = append(env, "TMC_API_URL=http://"+addr)
+			tmcli := NewCLI(t, s.RootDir(), env...)
+
+			res := tmcli.Run("cloud", "info", "--json")
+			AssertRunResult(t, res, RunExpected{IgnoreStdout: true})
+
+			var got cloudInfo
+			assert.NoError(t, json.Unmarshal([]byte(res.Stdout), &got))
+
+			assert.EqualStrings(t, "signed in", got.Status)
+			assert.EqualStrings(t, "Google", got.Provider)
+			assert.IsTrue(t, got.User != nil, "user must be set")
+			assert.EqualStrings(t, "Batman", got.User.DisplayName)
+			assert.EqualStrings(t, "batman@terramate.io", got.User.Email)
+
+			if diff := cmp.Diff(tc.wantOrgs, got.Organizations); diff != "" {
+				t.Fatalf("organizations: -(want) +(got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tc.wantOrg, got.Organization); diff != "" {
+				t.Fatalf("organization: -(want) +(got):\n%s", diff)
+			}
+			if tc.wantOrg == nil {
+				assert.IsTrue(t, got.Entitlements == nil, "entitlements must be null")
+			} else {
+				assert.IsTrue(t, got.Entitlements != nil, "entitlements must be set")
+				assert.EqualStrings(t, tc.wantOrg.Role, got.Entitlements.Role)
+				assert.IsTrue(t, got.Entitlements.Sync == tc.wantSync,
+					"sync entitlement: want %t got %t", tc.wantSync, got.Entitlements.Sync)
+			}
+			assert.IsTrue(t, got.Targets != nil && got.Targets.Enabled == tc.wantTargs,
+				"unexpected targets state")
+		})
+	}
+}
+
+func TestCloudInfoJSONNotLoggedIn(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.NoGit(t, true)
+
+	userDir := test.TempDir(t)
+	rcfile := test.WriteFile(t, userDir, "terramate.rc",
+		fmt.Sprintf("user_terramate_dir = %q\n", filepath.ToSlash(userDir)))
+
+	env := RemoveEnv(os.Environ(), "CI", "GITHUB_ACTIONS", "GITLAB_CI")
+	tmcli := NewCLI(t, s.RootDir(), env...)
+	tmcli.AppendEnv = []string{"TM_CLI_CONFIG_FILE=" + rcfile}
+
+	res := tmcli.Run("cloud", "info", "--json")
+	AssertRunResult(t, res, RunExpected{
+		Status:       cli.ExitStatusLoginRequired,
+		IgnoreStdout: true,
+	})
+
+	var got cloudInfo
+	assert.NoError(t, json.Unmarshal([]byte(res.Stdout), &got))
+	assert.EqualStrings(t, "signed out", got.Status)
+	assert.IsTrue(t, got.Organization == nil, "organization must be null")
+}
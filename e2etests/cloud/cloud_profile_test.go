@@ -0,0 +1,87 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package cloud_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/madlambda/spells/assert"
+	"github.com/terramate-io/terramate/cloud/testserver/cloudstore"
+	"github.com/terramate-io/terramate/cmd/terramate/cli"
+	. "github.com/terramate-io/terramate/e2etests/internal/runner"
+	"github.com/terramate-io/terramate/test/sandbox"
+)
+
+const profilesJSONFile = "testdata/cloud.profiles.data.json"
+
+func TestCloudProfiles(t *testing.T) {
+	t.Parallel()
+
+	store, err := cloudstore.LoadDatastore(profilesJSONFile)
+	assert.NoError(t, err)
+	addr := startFakeTMCServer(t, store)
+
+	s := sandbox.NoGit(t, true)
+	env := RemoveEnv(os.Environ(), "CI", "GITHUB_ACTIONS", "GITLAB_CI", "TM_CLOUD_PROFILE")
+	env = append(env, "TMC_API_URL=http://"+addr)
+	tmcli := NewCLI(t, s.RootDir(), env...)
+	tmcli.WriteCredential("service", "robin@terramate.io")
+
+	const (
+		batmanInfo = "status: signed in\nprovider: Google\nuser: Batman\nemail: batman@terramate.io\norganizations: Terramate (terramate)\n"
+		robinInfo  = "status: signed in\nprovider: Google\nprofile: service\nuser: Robin\nemail: robin@terramate.io\norganizations: Mineiros (mineiros)\n"
+	)
+
+	AssertRunResult(t, tmcli.Run("cloud", "info"), RunExpected{Stdout: batmanInfo})
+	AssertRunResult(t, tmcli.Run("--profile", "service", "cloud", "info"), RunExpected{Stdout: robinInfo})
+
+	envcli := tmcli
+	envcli.AppendEnv = append(envcli.AppendEnv, "TM_CLOUD_PROFILE=service")
+	res := envcli.Run("cloud", "info", "--json")
+	AssertRunResult(t, res, RunExpected{IgnoreStdout: true})
+	var got struct {
+		Status  string `json:"status"`
+		Profile string `json:"profile"`
+		User    struct {
+			Email string `json:"email"`
+		} `json:"user"`
+	}
+	assert.NoError(t, json.Unmarshal([]byte(res.Stdout), &got))
+	assert.EqualStrings(t, "signed in", got.Status)
+	assert.EqualStrings(t, "service", got.Profile)
+	assert.EqualStrings(t, "robin@terramate.io", got.User.Email)
+
+	// the flag takes precedence over the environment.
+	AssertRunResult(t, envcli.Run("--profile", "default", "cloud", "info"), RunExpected{Stdout: batmanInfo})
+
+	AssertRunResult(t, tmcli.Run("--profile", "service", "cloud", "logout"), RunExpected{
+		Stdout: "logged out from profile service\n",
+	})
+	_, err = os.Stat(filepath.Join(tmcli.UserDir(), "credentials.service.tmrc.json"))
+	assert.IsTrue(t, os.IsNotExist(err), "credential of the profile must be removed: %v", err)
+
+	AssertRunResult(t, tmcli.Run("--profile", "service", "cloud", "info", "--json"), RunExpected{
+		Status:      cli.ExitStatusLoginRequired,
+		StdoutRegex: `"status": "signed out"`,
+	})
+	AssertRunResult(t, tmcli.Run("--profile", "service", "cloud", "logout"), RunExpected{
+		Stdout: "not logged in with profile service\n",
+	})
+
+	// the default profile is kept.
+	AssertRunResult(t, tmcli.Run("cloud", "info"), RunExpected{Stdout: batmanInfo})
+	AssertRunResult(t, tmcli.Run("cloud", "logout"), RunExpected{
+		Stdout: "logged out from profile default\n",
+	})
+	_, err = os.Stat(filepath.Join(tmcli.UserDir(), "credentials.tmrc.json"))
+	assert.IsTrue(t, os.IsNotExist(err), "default credential must be removed: %v", err)
+
+	AssertRunResult(t, tmcli.Run("--profile", "../service", "cloud", "info"), RunExpected{
+		Status:      1,
+		StderrRegex: "invalid profile name",
+	})
+}
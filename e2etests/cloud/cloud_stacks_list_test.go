@@ -0,0 +1,217 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package cloud_test
+
+import (
+	"os"
+	"strconv"
+	"testing"
+
+	"github.com/madlambda/spells/assert"
+	"github.com/terramate-io/terramate/cloud"
+	"github.com/terramate-io/terramate/cloud/deployment"
+	"github.com/terramate-io/terramate/cloud/drift"
+	cloudstack "github.com/terramate-io/terramate/cloud/stack"
+	"github.com/terramate-io/terramate/cloud/testserver/cloudstore"
+	. "github.com/terramate-io/terramate/e2etests/internal/runner"
+	"github.com/terramate-io/terramate/test/sandbox"
+)
+
+func TestCloudStacksList(t *testing.T) {
+	t.Parallel()
+
+	const targetsConfig = `f:cfg.tm.hcl:terramate {
+	  config {
+	    experiments = ["targets"]
+	    cloud {
+	      targets {
+	        enabled = true
+	      }
+	    }
+	  }
+	}`
+
+	type testcase struct {
+		name     string
+		layout   []string
+		args     []string
+		pageSize int
+		want     RunExpected
+	}
+
+	for _, tc := range []testcase{
+		{
+			name: "matched, cloud-only and local-only stacks",
+			args: []string{"experimental", "cloud", "stacks", "list"},
+			want: RunExpected{
+				Stdout: nljoin(
+					"Matched stacks:",
+					"\t/stacks/a (id: stack-a) status=ok deployment=ok drift=ok",
+					"\t/stacks/moved (id: stack-b) status=drifted deployment=ok drift=drifted cloud_path=/stacks/b",
+					"Cloud-only stacks:",
+					"\t/stacks/deleted (id: stack-deleted) status=failed deployment=failed drift=ok",
+					"Local-only stacks (never synced):",
+					"\t/stacks/new (id: stack-new)",
+				),
+			},
+		},
+		{
+			name:     "pagination",
+			args:     []string{"experimental", "cloud", "stacks", "list"},
+			pageSize: 1,
+			want: RunExpected{
+				Stdout: nljoin(
+					"Matched stacks:",
+					"\t/stacks/a (id: stack-a) status=ok deployment=ok drift=ok",
+					"\t/stacks/moved (id: stack-b) status=drifted deployment=ok drift=drifted cloud_path=/stacks/b",
+					"Cloud-only stacks:",
+					"\t/stacks/deleted (id: stack-deleted) status=failed deployment=failed drift=ok",
+					"Local-only stacks (never synced):",
+					"\t/stacks/new (id: stack-new)",
+				),
+			},
+		},
+		{
+			name: "json",
+			args: []string{"experimental", "cloud", "stacks", "list", "--json"},
+			want: RunExpected{
+				Stdout: `{
+  "matched": [
+    {
+      "meta_id": "stack-a",
+      "path": "/stacks/a",
+      "status": "ok",
+      "deployment_status": "ok",
+      "drift_status": "ok"
+    },
+    {
+      "meta_id": "stack-b",
+      "path": "/stacks/moved",
+      "cloud_path": "/stacks/b",
+      "status": "drifted",
+      "deployment_status": "ok",
+      "drift_status": "drifted"
+    }
+  ],
+  "cloud_only": [
+    {
+      "meta_id": "stack-deleted",
+      "path": "/stacks/deleted",
+      "status": "failed",
+      "deployment_status": "failed",
+      "drift_status": "ok"
+    }
+  ],
+  "local_only": [
+    {
+      "meta_id": "stack-new",
+      "path": "/stacks/new"
+    }
+  ]
+}
+`,
+			},
+		},
+		{
+			name:   "target",
+			layout: []string{targetsConfig},
+			args:   []string{"experimental", "cloud", "stacks", "list", "--target", "prod"},
+			want: RunExpected{
+				Stdout: nljoin(
+					"Matched stacks:",
+					"\t/stacks/a (id: stack-a) status=failed deployment=failed drift=ok",
+					"Local-only stacks (never synced):",
+					"\t/stacks/moved (id: stack-b)",
+					"\t/stacks/new (id: stack-new)",
+				),
+			},
+		},
+		{
+			name:   "target is required when targets are enabled",
+			layout: []string{targetsConfig},
+			args:   []string{"experimental", "cloud", "stacks", "list"},
+			want: RunExpected{
+				Status:      1,
+				StderrRegex: "--target must be set when terramate.config.cloud.targets.enabled is true",
+			},
+		},
+		{
+			name: "target requires the targets feature",
+			args: []string{"experimental", "cloud", "stacks", "list", "--target", "prod"},
+			want: RunExpected{
+				Status:      1,
+				StderrRegex: `The "targets" feature is not enabled`,
+			},
+		},
+		{
+			name:   "invalid target",
+			layout: []string{targetsConfig},
+			args:   []string{"experimental", "cloud", "stacks", "list", "--target", "invalid target"},
+			want: RunExpected{
+				Status:      1,
+				StderrRegex: "--target value has invalid format",
+			},
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			store, err := cloudstore.LoadDatastore(testserverJSONFile)
+			assert.NoError(t, err)
+			addr := startFakeTMCServer(t, store)
+
+			s := sandbox.New(t)
+			s.BuildTree(append([]string{
+				"s:stacks/a:id=stack-a",
+				"s:stacks/moved:id=stack-b",
+				"s:stacks/new:id=stack-new",
+				"s:stacks/no-id",
+			}, tc.layout...))
+			s.Git().SetRemoteURL("origin", "git@github.com:terramate-io/terramate.git")
+			s.Git().CommitAll("create stacks")
+
+			org := store.MustOrgByName("terramate")
+			for _, st := range []cloudstore.Stack{
+				newCloudStack("stack-a", "/stacks/a", "default", cloudstack.OK, deployment.OK, drift.OK),
+				newCloudStack("stack-b", "/stacks/b", "default", cloudstack.Drifted, deployment.OK, drift.Drifted),
+				newCloudStack("stack-deleted", "/stacks/deleted", "default", cloudstack.Failed, deployment.Failed, drift.OK),
+				newCloudStack("stack-a", "/stacks/a", "prod", cloudstack.Failed, deployment.Failed, drift.OK),
+			} {
+				_, err := store.UpsertStack(org.UUID, st)
+				assert.NoError(t, err)
+			}
+
+			// stacks of other repositories must be ignored.
+			other := newCloudStack("stack-new", "/stacks/new", "default", cloudstack.OK, deployment.OK, drift.OK)
+			other.Stack.Repository = "github.com/terramate-io/other"
+			_, err = store.UpsertStack(org.UUID, other)
+			assert.NoError(t, err)
+
+			env := RemoveEnv(os.Environ(), "CI")
+			env = append(env, "TMC_API_URL=http://"+addr, "CI=")
+			if tc.pageSize != 0 {
+				env = append(env, "TMC_API_PAGESIZE="+strconv.Itoa(tc.pageSize))
+			}
+			tmcli := NewCLI(t, s.RootDir(), env...)
+			AssertRunResult(t, tmcli.Run(tc.args...), tc.want)
+		})
+	}
+}
+
+func newCloudStack(metaID, path, target string, status cloudstack.Status, deployStatus deployment.Status, driftStatus drift.Status) cloudstore.Stack {
+	return cloudstore.Stack{
+		Stack: cloud.Stack{
+			MetaID:     metaID,
+			Path:       path,
+			Repository: "github.com/terramate-io/terramate",
+			Target:     target,
+		},
+		State: cloudstore.StackState{
+			Status:           status,
+			DeploymentStatus: deployStatus,
+			DriftStatus:      driftStatus,
+		},
+	}
+}
@@ -115,7 +115,7 @@ func TestCloudTriggerUnhealthy(t *testing.T) {
 			want: want{
 				trigger: RunExpected{
 					Status:      1,
-					StderrRegex: "trigger command expects either a stack path or the --status flag",
+					StderrRegex: "trigger command expects either a stack path or the --status or --stack-id flags",
 				},
 			},
 		},
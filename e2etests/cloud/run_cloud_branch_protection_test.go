@@ -0,0 +1,149 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package cloud_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/madlambda/spells/assert"
+	"github.com/terramate-io/terramate/cloud/testserver/cloudstore"
+	"github.com/terramate-io/terramate/cmd/terramate/cli"
+	. "github.com/terramate-io/terramate/e2etests/internal/runner"
+	"github.com/terramate-io/terramate/test/sandbox"
+)
+
+func TestCloudSyncDeploymentBranchProtection(t *testing.T) {
+	t.Parallel()
+
+	type testcase struct {
+		name             string
+		branch           string
+		branchProtection bool
+		disableConfig    string
+		flags            []string
+		wantFail         bool
+	}
+
+	for _, tc := range []testcase{
+		{
+			name:             "enabled and deploying from the default branch",
+			branch:           "main",
+			branchProtection: true,
+		},
+		{
+			name:             "enabled and deploying from a feature branch",
+			branch:           "feature",
+			branchProtection: true,
+			wantFail:         true,
+		},
+		{
+			name:   "not enabled and deploying from a feature branch",
+			branch: "feature",
+		},
+		{
+			name:             "disabled with --disable-safeguards=git-branch-protection",
+			branch:           "feature",
+			branchProtection: true,
+			flags:            []string{"--disable-safeguards=git-branch-protection"},
+		},
+		{
+			name:             "disabled with --disable-safeguards=git",
+			branch:           "feature",
+			branchProtection: true,
+			flags:            []string{"--disable-safeguards=git"},
+		},
+		{
+			name:             "disabled with terramate.config.disable_safeguards",
+			branch:           "feature",
+			branchProtection: true,
+			disableConfig:    `disable_safeguards = ["git-branch-protection", "git-out-of-sync"]`,
+		},
+	} {
+		tc := tc
+		for _, isScript := range []bool{false, true} {
+			isScript := isScript
+			name := tc.name
+			if isScript {
+				name += "/script"
+			}
+			t.Run(name, func(t *testing.T) {
+				t.Parallel()
+
+				cloudData, err := cloudstore.LoadDatastore(testserverJSONFile)
+				assert.NoError(t, err)
+				addr := startFakeTMCServer(t, cloudData)
+
+				s := sandbox.New(t)
+				s.BuildTree([]string{
+					"s:stack:id=stack",
+					fmt.Sprintf(`f:terramate.tm:
+						terramate {
+						  config {
+						    experiments = ["scripts"]
+						    %s
+						    git {
+						      branch_protection = %t
+						    }
+						  }
+						}
+
+						script "deploy" {
+						  description = "deploy"
+						  job {
+						    command = ["helper", "echo", "${terramate.stack.name}", {
+						      sync_deployment = true
+						    }]
+						  }
+						}`, tc.disableConfig, tc.branchProtection),
+				})
+				s.Git().CommitAll("all stacks committed")
+				if tc.branch != "main" {
+					s.Git().CheckoutNew(tc.branch)
+				}
+				s.Git().SetRemoteURL("origin", testRemoteRepoURL)
+
+				env := RemoveEnv(os.Environ(), "CI", "GITHUB_ACTIONS")
+				env = append(env, "TMC_API_URL=http://"+addr)
+				tmcli := NewCLI(t, s.RootDir(), env...)
+				tmcli.PrependToPath(filepath.Dir(HelperPath))
+
+				args := []string{"--quiet"}
+				if tc.disableConfig == "" {
+					args = append(args, "--disable-safeguards=git-out-of-sync")
+				}
+				args = append(args, tc.flags...)
+
+				var result RunResult
+				if isScript {
+					args = append(args, "deploy")
+					result = tmcli.RunScript(args...)
+				} else {
+					args = append([]string{"run", "--sync-deployment"}, args...)
+					args = append(args, "--", "helper", "echo", "stack")
+					result = tmcli.Run(args...)
+				}
+
+				if tc.wantFail {
+					AssertRunResult(t, result, RunExpected{
+						Status:      1,
+						StderrRegex: string(cli.ErrNotDefaultBranch),
+					})
+					assertRunEvents(t, cloudData, s.Git().RevParse("HEAD"), nil)
+					return
+				}
+
+				AssertRunResult(t, result, RunExpected{
+					Stdout:       "stack\n",
+					IgnoreStderr: true,
+				})
+				assertRunEvents(t, cloudData, s.Git().RevParse("HEAD"), eventsResponse{
+					"stack": []string{"pending", "running", "ok"},
+				})
+			})
+		}
+	}
+}
@@ -0,0 +1,87 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package cloud_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/madlambda/spells/assert"
+	"github.com/terramate-io/terramate/cloud/testserver/cloudstore"
+	. "github.com/terramate-io/terramate/e2etests/internal/runner"
+	"github.com/terramate-io/terramate/test/sandbox"
+)
+
+func TestCLIRunWithCloudSyncDeploymentChangeReason(t *testing.T) {
+	t.Parallel()
+
+	for _, streamed := range []bool{false, true} {
+		streamed := streamed
+		name := "sorted-selection"
+		if streamed {
+			name = "stream-selection"
+		}
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			cloudData, err := cloudstore.LoadDatastore(testserverJSONFile)
+			assert.NoError(t, err)
+			addr := startFakeTMCServer(t, cloudData)
+
+			s := sandbox.New(t)
+			s.BuildTree([]string{
+				`s:s1:id=s1;watch=["/external/file.txt"]`,
+				"s:s2:id=s2",
+				"s:s3:id=s3",
+				"f:external/file.txt:anything",
+				"f:s2/main.tf:# s2",
+				"f:s3/main.tf:# s3",
+			})
+			git := s.Git()
+			git.CommitAll("all stacks committed")
+			git.Push("main")
+			git.CheckoutNew("change-stacks")
+			s.RootEntry().CreateFile("external/file.txt", "changed")
+			s.RootEntry().CreateFile("s2/main.tf", "# changed")
+			git.CommitAll("stacks changed")
+			git.SetRemoteURL("origin", testRemoteRepoURL)
+
+			env := RemoveEnv(os.Environ(), "CI", "GITHUB_ACTIONS")
+			env = append(env, "TMC_API_URL=http://"+addr)
+			tmcli := NewCLI(t, s.RootDir(), env...)
+
+			args := []string{"run", "--changed", "--quiet",
+				"--disable-safeguards=git-out-of-sync", "--sync-deployment"}
+			if streamed {
+				args = append(args, "--stream-selection")
+			}
+			args = append(args, "--", HelperPath, "echo", "ok")
+			AssertRunResult(t, tmcli.Run(args...), RunExpected{
+				Stdout: "ok\nok\n",
+			})
+
+			assertStacksChangeReason(t, cloudData, map[string]string{
+				"s1": `stack changed because watched file "/external/file.txt" changed`,
+				"s2": "stack has unmerged changes",
+			})
+
+			org := cloudData.MustOrgByName("terramate")
+			_, _, found := cloudData.GetStackByMetaID(org, "s3", "default")
+			assert.IsTrue(t, !found, "unchanged stack s3 must not be synced")
+		})
+	}
+}
+
+func assertStacksChangeReason(t *testing.T, cloudData *cloudstore.Data, want map[string]string) {
+	t.Helper()
+
+	org := cloudData.MustOrgByName("terramate")
+	for metaID, wantReason := range want {
+		st, _, found := cloudData.GetStackByMetaID(org, metaID, "default")
+		if !found {
+			t.Fatalf("stack %s not found", metaID)
+		}
+		assert.EqualStrings(t, wantReason, st.ChangeReason, "stack %s change reason mismatch", metaID)
+	}
+}
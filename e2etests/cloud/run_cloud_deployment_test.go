@@ -50,6 +50,9 @@ func TestCLIRunWithCloudSyncDeployment(t *testing.T) {
 	type want struct {
 		run    RunExpected
 		events eventsResponse
+
+		// metadata is a map of stack.ID to the expected custom metadata.
+		metadata map[string]map[string]string
 	}
 	type testcase struct {
 		name       string
@@ -121,6 +124,77 @@ func TestCLIRunWithCloudSyncDeployment(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "stacks with custom metadata",
+			layout: []string{
+				"s:s1:id=s1",
+				"s:s2:id=s2",
+				`f:cloud.tm:terramate {
+					config {
+						cloud {
+							metadata {
+								owner        = "platform"
+								service_tier = global.tier
+							}
+						}
+					}
+				}
+				globals {
+					tier = "gold"
+				}`,
+				`f:s2/cloud.tm:terramate {
+					config {
+						cloud {
+							metadata {
+								service_tier = "silver"
+							}
+						}
+					}
+				}`,
+			},
+			cmd: []string{HelperPath, "echo", "ok"},
+			want: want{
+				run: RunExpected{
+					Stdout: "ok\nok\n",
+				},
+				events: eventsResponse{
+					"s1": []string{"pending", "running", "ok"},
+					"s2": []string{"pending", "running", "ok"},
+				},
+				metadata: map[string]map[string]string{
+					"s1": {
+						"owner":        "platform",
+						"service_tier": "gold",
+					},
+					"s2": {
+						"owner":        "platform",
+						"service_tier": "silver",
+					},
+				},
+			},
+		},
+		{
+			name: "stacks with non-string custom metadata fails",
+			layout: []string{
+				"s:s1:id=s1",
+				`f:cloud.tm:terramate {
+					config {
+						cloud {
+							metadata {
+								owner = ["platform"]
+							}
+						}
+					}
+				}`,
+			},
+			cmd: []string{HelperPath, "echo", "ok"},
+			want: want{
+				run: RunExpected{
+					Status:      1,
+					StderrRegex: `stack /s1: metadata key "owner" has type tuple but must be string`,
+				},
+			},
+		},
 		{
 			name:     "both failed stacks and continueOnError",
 			layout:   []string{"s:s1:id=s1", "s:s2:id=s2"},
@@ -863,6 +937,7 @@ func TestCLIRunWithCloudSyncDeployment(t *testing.T) {
 				result := cli.Run(runflags...)
 				AssertRunResult(t, result, tc.want.run)
 				assertRunEvents(t, cloudData, s.Git().RevParse("HEAD"), tc.want.events)
+				assertStacksCustomMetadata(t, cloudData, tc.want.metadata)
 			})
 		}
 	}
@@ -908,6 +983,21 @@ func assertRunEvents(t *testing.T, cloudData *cloudstore.Data, commitSHA string,
 	}
 }
 
+func assertStacksCustomMetadata(t *testing.T, cloudData *cloudstore.Data, want map[string]map[string]string) {
+	t.Helper()
+
+	org := cloudData.MustOrgByName("terramate")
+	for metaID, wantMetadata := range want {
+		st, _, found := cloudData.GetStackByMetaID(org, metaID, "default")
+		if !found {
+			t.Fatalf("stack %s not found", metaID)
+		}
+		if diff := cmp.Diff(st.CustomMetadata, wantMetadata); diff != "" {
+			t.Fatalf("stack %s custom metadata mismatch: %s", metaID, diff)
+		}
+	}
+}
+
 func TestRunGithubTokenDetection(t *testing.T) {
 	t.Parallel()
 	s := sandbox.New(t)
@@ -0,0 +1,185 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package cloud_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/madlambda/spells/assert"
+	"github.com/terramate-io/terramate/cloud"
+	"github.com/terramate-io/terramate/cloud/drift"
+	"github.com/terramate-io/terramate/cloud/testserver/cloudstore"
+	. "github.com/terramate-io/terramate/e2etests/internal/runner"
+	"github.com/terramate-io/terramate/test"
+	"github.com/terramate-io/terramate/test/sandbox"
+)
+
+const driftedPlanJSON = `{
+  "format_version": "1.2",
+  "resource_changes": [
+    {
+      "address": "null_resource.b",
+      "mode": "managed",
+      "type": "null_resource",
+      "name": "b",
+      "change": {"actions": ["delete", "create"]}
+    },
+    {
+      "address": "module.m.null_resource.a[0]",
+      "mode": "managed",
+      "type": "null_resource",
+      "name": "a",
+      "change": {"actions": ["update"]}
+    },
+    {
+      "address": "null_resource.unchanged",
+      "mode": "managed",
+      "type": "null_resource",
+      "name": "unchanged",
+      "change": {"actions": ["no-op"]}
+    },
+    {
+      "address": "data.null_data_source.d",
+      "mode": "data",
+      "type": "null_data_source",
+      "name": "d",
+      "change": {"actions": ["read"]}
+    }
+  ]
+}`
+
+func TestRunOnlyPlanChangedResources(t *testing.T) {
+	t.Parallel()
+
+	type testcase struct {
+		name  string
+		flags []string
+		cmd   []string
+		want  RunExpected
+	}
+
+	for _, tc := range []testcase{
+		{
+			name: "drifted resources are targeted",
+			cmd:  []string{"terraform", "plan", "-no-color"},
+			want: RunExpected{
+				StderrRegexes: []string{
+					`--only-plan-changed-resources is experimental and uses -target`,
+					`Executing command "terraform plan -target=module.m.null_resource.a\[0\] -target=null_resource.b -no-color"`,
+					`Skipping stack /healthy: no drift details available`,
+					`Skipping stack /no-details: no drift details available`,
+				},
+				NoStderrRegexes: []string{
+					`null_resource.unchanged`,
+					`data.null_data_source.d`,
+				},
+			},
+		},
+		{
+			name: "global options are kept before the subcommand",
+			cmd:  []string{"terraform", "-chdir=.", "apply", "-auto-approve"},
+			want: RunExpected{
+				StderrRegex: `Executing command "terraform -chdir=. apply -target=module.m.null_resource.a\[0\] -target=null_resource.b -auto-approve"`,
+			},
+		},
+		{
+			name:  "stacks exceeding max targets run the full command",
+			flags: []string{"--max-targets", "1"},
+			cmd:   []string{"terraform", "plan"},
+			want: RunExpected{
+				StderrRegexes: []string{
+					`stack /drifted has 2 changed resources, more than --max-targets=1: running the full command`,
+					`Executing command "terraform plan"`,
+				},
+				NoStderrRegex: `-target=`,
+			},
+		},
+		{
+			name: "fails for terraform commands other than plan or apply",
+			cmd:  []string{"terraform", "init"},
+			want: RunExpected{
+				Status:      1,
+				StderrRegex: `must be a plan or apply, got init`,
+			},
+		},
+		{
+			name: "fails for non terraform commands",
+			cmd:  []string{HelperPath, "echo", "plan"},
+			want: RunExpected{
+				Status:      1,
+				StderrRegex: `is not a terraform or tofu command`,
+			},
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			store, err := cloudstore.LoadDatastore(testserverJSONFile)
+			assert.NoError(t, err)
+			addr := startFakeTMCServer(t, store)
+
+			s := sandbox.New(t)
+			s.BuildTree([]string{
+				"s:drifted:id=drifted",
+				"s:healthy:id=healthy",
+				"s:no-details:id=no-details",
+			})
+			s.Git().SetRemoteURL("origin", "git@github.com:terramate-io/terramate.git")
+			s.Git().CommitAll("create stacks")
+
+			org := store.MustOrgByName("terramate")
+			for _, st := range []struct {
+				metaID string
+				status drift.Status
+			}{
+				{"drifted", drift.Drifted},
+				{"healthy", drift.OK},
+				{"no-details", drift.Drifted},
+			} {
+				_, err := store.UpsertStack(org.UUID, cloudstore.Stack{
+					Stack: cloud.Stack{
+						MetaID:     st.metaID,
+						Repository: "github.com/terramate-io/terramate",
+						Target:     "default",
+					},
+					State: cloudstore.StackState{
+						DriftStatus: st.status,
+					},
+				})
+				assert.NoError(t, err)
+			}
+			_, err = store.InsertDrift(org.UUID, cloudstore.Drift{
+				StackMetaID: "drifted",
+				StackTarget: "default",
+				Status:      drift.Drifted,
+				Details: &cloud.ChangesetDetails{
+					Provisioner:    "terraform",
+					ChangesetASCII: "drifted",
+					ChangesetJSON:  driftedPlanJSON,
+				},
+			})
+			assert.NoError(t, err)
+			_, err = store.InsertDrift(org.UUID, cloudstore.Drift{
+				StackMetaID: "no-details",
+				StackTarget: "default",
+				Status:      drift.Drifted,
+			})
+			assert.NoError(t, err)
+
+			env := RemoveEnv(os.Environ(), "CI")
+			env, _ = test.PrependToPath(env, filepath.Dir(TerraformTestPath))
+			env = append(env, "TMC_API_URL=http://"+addr, "CI=")
+			tmcli := NewCLI(t, s.RootDir(), env...)
+
+			args := []string{"run", "--disable-safeguards=git-out-of-sync", "--dry-run", "--only-plan-changed-resources"}
+			args = append(args, tc.flags...)
+			args = append(args, "--")
+			args = append(args, tc.cmd...)
+			AssertRunResult(t, tmcli.Run(args...), tc.want)
+		})
+	}
+}
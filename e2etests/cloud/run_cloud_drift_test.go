@@ -256,6 +256,47 @@ func TestCLIRunWithCloudSyncDriftStatus(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:     "drift sync with scheduled context",
+			layout:   []string{"s:stack:id=stack"},
+			runflags: []string{"--drift-context=scheduled"},
+			cmd: []string{
+				HelperPath, "exit", "2",
+			},
+			want: want{
+				drifts: expectedDriftStackPayloadRequests{
+					{
+						DriftStackPayloadRequest: cloud.DriftStackPayloadRequest{
+							Stack: cloud.Stack{
+								Repository:    normalizedTestRemoteRepo,
+								DefaultBranch: "main",
+								Path:          "/stack",
+								MetaName:      "stack",
+								MetaID:        "stack",
+								Target:        "default",
+							},
+							Status:   drift.Drifted,
+							Context:  drift.ContextScheduled,
+							Metadata: expectedMetadata,
+						},
+					},
+				},
+			},
+		},
+		{
+			name:     "drift sync with invalid context fails",
+			layout:   []string{"s:stack:id=stack"},
+			runflags: []string{"--drift-context=cron"},
+			cmd: []string{
+				HelperPath, "exit", "2",
+			},
+			want: want{
+				run: RunExpected{
+					Status:      1,
+					StderrRegex: string(drift.ErrInvalidContext),
+				},
+			},
+		},
 		{
 			name:   "basic drift sync with uppercase stack id",
 			layout: []string{"s:stack:id=STACK"},
@@ -281,6 +322,74 @@ func TestCLIRunWithCloudSyncDriftStatus(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "drift sync with custom metadata",
+			layout: []string{
+				"s:stack:id=stack",
+				`f:cloud.tm:terramate {
+					config {
+						cloud {
+							metadata {
+								owner        = "team-${terramate.stack.name}"
+								service_tier = global.tier
+							}
+						}
+					}
+				}
+				globals {
+					tier = "gold"
+				}`,
+			},
+			cmd: []string{
+				HelperPath, "exit", "2",
+			},
+			want: want{
+				drifts: expectedDriftStackPayloadRequests{
+					{
+						DriftStackPayloadRequest: cloud.DriftStackPayloadRequest{
+							Stack: cloud.Stack{
+								Repository:    normalizedTestRemoteRepo,
+								DefaultBranch: "main",
+								Path:          "/stack",
+								MetaName:      "stack",
+								MetaID:        "stack",
+								Target:        "default",
+								CustomMetadata: map[string]string{
+									"owner":        "team-stack",
+									"service_tier": "gold",
+								},
+							},
+							Status:   drift.Drifted,
+							Metadata: expectedMetadata,
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "drift sync with non-string custom metadata fails",
+			layout: []string{
+				"s:stack:id=stack",
+				`f:cloud.tm:terramate {
+					config {
+						cloud {
+							metadata {
+								service_tier = 1
+							}
+						}
+					}
+				}`,
+			},
+			cmd: []string{
+				HelperPath, "exit", "2",
+			},
+			want: want{
+				run: RunExpected{
+					Status:      1,
+					StderrRegex: `stack /stack: metadata key "service_tier" has type number but must be string`,
+				},
+			},
+		},
 		{
 			name: "only stacks inside working dir are synced",
 			layout: []string{
@@ -748,6 +857,7 @@ func TestCLIRunWithCloudSyncDriftStatus(t *testing.T) {
 							AssertRunResult(t, res, RunExpected{
 								Status: 0,
 								StdoutRegexes: []string{
+									"drift context: " + string(expectedDriftContext(wantDrift.DriftStackPayloadRequest)),
 									s + " used the selected providers to generate the following execution",
 									`local_file.foo will be created`,
 								},
@@ -824,6 +934,15 @@ func TestSyncPlanSerial(t *testing.T) {
 	assertPlanSerial(t, got.Details.Serial, makeSerial(1))
 }
 
+// expectedDriftContext returns the context expected for the drift, which is
+// manual if not set because the tests don't run in a scheduled CI/CD event.
+func expectedDriftContext(d cloud.DriftStackPayloadRequest) drift.Context {
+	if d.Context == "" {
+		return drift.ContextManual
+	}
+	return d.Context
+}
+
 func assertRunDrifts(t *testing.T, cloudData *cloudstore.Data, tmcAddr string, expectedDrifts expectedDriftStackPayloadRequests, minStartTime, maxEndTime time.Time) {
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
@@ -841,7 +960,9 @@ func assertRunDrifts(t *testing.T, cloudData *cloudstore.Data, tmcAddr string, e
 
 	for i, expected := range expectedDrifts {
 		got := res[i]
-		if diff := cmp.Diff(got, expected.DriftStackPayloadRequest,
+		want := expected.DriftStackPayloadRequest
+		want.Context = expectedDriftContext(want)
+		if diff := cmp.Diff(got, want,
 			// Ignore hard to predict fields
 			// They are validated (for existence) in the testserver anyway.
 			cmpopts.IgnoreFields(cloud.GitMetadata{}, "GitCommitSHA", "GitCommitAuthorTime"),
@@ -967,3 +1088,17 @@ func loadJSONPlan(t *testing.T, fname string) string {
 func makeSerial(serial int64) *int64 {
 	return &serial
 }
+
+func TestCLIRunDriftContextRequiresSyncDriftStatus(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.New(t)
+	s.BuildTree([]string{"s:stack:id=stack"})
+	s.Git().CommitAll("all stacks committed")
+
+	cli := NewCLI(t, s.RootDir(), RemoveEnv(os.Environ(), "CI", "GITHUB_ACTIONS")...)
+	AssertRunResult(t, cli.Run("run", "--drift-context=scheduled", "--", HelperPath, "true"), RunExpected{
+		Status:      1,
+		StderrRegex: "--drift-context requires --sync-drift-status",
+	})
+}
@@ -0,0 +1,76 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package cloud_test
+
+import (
+	"encoding/json"
+	"os"
+	"regexp"
+	"sort"
+	"testing"
+
+	"github.com/madlambda/spells/assert"
+	"github.com/terramate-io/terramate/cloud"
+	"github.com/terramate-io/terramate/cloud/deployment"
+	"github.com/terramate-io/terramate/cloud/testserver/cloudstore"
+	. "github.com/terramate-io/terramate/e2etests/internal/runner"
+	"github.com/terramate-io/terramate/test/sandbox"
+)
+
+func TestRunStreamSelectionSyncDeployment(t *testing.T) {
+	t.Parallel()
+
+	cloudData, err := cloudstore.LoadDatastore(testserverJSONFile)
+	assert.NoError(t, err)
+	addr := startFakeTMCServer(t, cloudData)
+
+	s := sandbox.New(t)
+	s.BuildTree([]string{
+		"s:s1:id=s1",
+		`s:s2:id=s2;after=["/s1"]`,
+		"s:s3:id=s3",
+		"f:s1/main.tf:# s1",
+		"f:s2/main.tf:# s2",
+		"f:s3/main.tf:# s3",
+	})
+	git := s.Git()
+	git.CommitAll("all stacks committed")
+	git.Push("main")
+	git.CheckoutNew("change-stacks")
+	s.RootEntry().CreateFile("s1/main.tf", "# changed")
+	s.RootEntry().CreateFile("s2/main.tf", "# changed")
+	git.CommitAll("stacks changed")
+	git.SetRemoteURL("origin", testRemoteRepoURL)
+
+	env := RemoveEnv(os.Environ(), "CI", "GITHUB_ACTIONS")
+	env = append(env, "TMC_API_URL=http://"+addr)
+	tmcli := NewCLI(t, s.RootDir(), env...)
+
+	uuidRegex := regexp.MustCompile(`Terramate Cloud deployment: ([0-9a-f-]{36})`)
+	res := tmcli.Run("run", "--changed", "--stream-selection", "--parallel=2",
+		"--disable-safeguards=git-out-of-sync", "--sync-deployment",
+		"--", HelperPath, "echo", "ok")
+	AssertRunResult(t, res, RunExpected{
+		IgnoreStdout: true,
+		StderrRegex:  uuidRegex.String(),
+	})
+	deploymentUUID := uuidRegex.FindStringSubmatch(res.Stderr)[1]
+
+	res = tmcli.Run("cloud", "deployment", "show", "--id", deploymentUUID, "--json")
+	AssertRunResult(t, res, RunExpected{IgnoreStdout: true})
+
+	var got cloud.Deployment
+	assert.NoError(t, json.Unmarshal([]byte(res.Stdout), &got))
+	assert.IsTrue(t, got.Status == deployment.OK, "unexpected status %s", got.Status)
+
+	var paths []string
+	for _, st := range got.Stacks {
+		paths = append(paths, st.Path)
+		assert.IsTrue(t, st.Status == deployment.OK, "stack %s: unexpected status %s", st.Path, st.Status)
+	}
+	sort.Strings(paths)
+	assert.EqualInts(t, 2, len(paths))
+	assert.EqualStrings(t, "/s1", paths[0])
+	assert.EqualStrings(t, "/s2", paths[1])
+}
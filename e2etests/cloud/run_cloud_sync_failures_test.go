@@ -0,0 +1,189 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package cloud_test
+
+import (
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/madlambda/spells/assert"
+	"github.com/terramate-io/terramate/cloud"
+	"github.com/terramate-io/terramate/cloud/testserver"
+	"github.com/terramate-io/terramate/cloud/testserver/cloudstore"
+	"github.com/terramate-io/terramate/cmd/terramate/cli/clitest"
+	. "github.com/terramate-io/terramate/e2etests/internal/runner"
+	"github.com/terramate-io/terramate/test/sandbox"
+)
+
+func TestCLIRunWithCloudSyncDeploymentFailures(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name             string
+		failOnCloudError bool
+		want             RunExpected
+	}{
+		{
+			name: "sync failures are warnings",
+			want: RunExpected{
+				StderrRegexes: []string{
+					clitest.CloudSyncStacksFailedMessage,
+					`stack /s1: .*502 Bad Gateway`,
+					`stack /s2: .*502 Bad Gateway`,
+				},
+			},
+		},
+		{
+			name:             "sync failures fail with --fail-on-cloud-error",
+			failOnCloudError: true,
+			want: RunExpected{
+				Status: 1,
+				StderrRegexes: []string{
+					clitest.CloudSyncStacksFailedMessage,
+					`stack /s1: .*502 Bad Gateway`,
+					`stack /s2: .*502 Bad Gateway`,
+					string(clitest.ErrCloudSyncFailed),
+				},
+			},
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			store, err := cloudstore.LoadDatastore(testserverJSONFile)
+			assert.NoError(t, err)
+
+			endpoints := testserver.EnableAllConfig()
+			delete(endpoints, cloud.DeploymentsPath)
+			router := testserver.RouterWith(store, endpoints)
+
+			patchDeployment := &testserver.Faulty{
+				Failures: -1,
+				Status:   http.StatusBadGateway,
+				Handler:  testserver.PatchDeployment,
+			}
+			testserver.RouterAddCustoms(router, store, testserver.Custom{
+				Routes: map[string]testserver.Route{
+					"POST": {
+						Path:    cloud.DeploymentsPath + "/:orguuid/:deployuuid/stacks",
+						Handler: testserver.PostDeployment,
+					},
+					"PATCH": {
+						Path:    cloud.DeploymentsPath + "/:orguuid/:deployuuid/stacks",
+						Handler: patchDeployment.Handle,
+					},
+				},
+			})
+			addr := startFakeTMCServerWith(t, router)
+
+			s := sandbox.New(t)
+			s.BuildTree([]string{
+				"s:s1:id=s1",
+				"s:s2:id=s2",
+			})
+			s.Git().CommitAll("all stacks committed")
+			s.Git().SetRemoteURL("origin", testRemoteRepoURL)
+
+			env := RemoveEnv(os.Environ(), "CI", "GITHUB_ACTIONS")
+			env = append(env, "TMC_API_URL=http://"+addr)
+			tmcli := NewCLI(t, s.RootDir(), env...)
+
+			args := []string{"run", "--quiet", "--disable-safeguards=git-out-of-sync", "--sync-deployment"}
+			if tc.failOnCloudError {
+				args = append(args, "--fail-on-cloud-error")
+			}
+			args = append(args, "--", HelperPath, "true")
+			AssertRunResult(t, tmcli.Run(args...), tc.want)
+
+			// the deployment updates are not idempotent, hence not retried.
+			assert.EqualInts(t, 4, patchDeployment.Calls())
+		})
+	}
+}
+
+func TestCLIRunWithCloudRetries(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name      string
+		retries   string
+		want      RunExpected
+		wantCalls int
+	}{
+		{
+			name:      "transient failures are retried by default",
+			wantCalls: 3,
+		},
+		{
+			name:      "retries set in the config",
+			retries:   "1",
+			want:      RunExpected{Status: 1, StderrRegex: "503 Service Unavailable"},
+			wantCalls: 2,
+		},
+		{
+			name:      "retries disabled in the config",
+			retries:   "0",
+			want:      RunExpected{Status: 1, StderrRegex: "503 Service Unavailable"},
+			wantCalls: 1,
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			store, err := cloudstore.LoadDatastore(testserverJSONFile)
+			assert.NoError(t, err)
+
+			endpoints := testserver.EnableAllConfig()
+			delete(endpoints, cloud.MembershipsPath)
+			router := testserver.RouterWith(store, endpoints)
+
+			memberships := &testserver.Faulty{
+				Failures: 2,
+				Status:   http.StatusServiceUnavailable,
+				Handler:  testserver.GetMemberships,
+			}
+			testserver.RouterAddCustoms(router, store, testserver.Custom{
+				Routes: map[string]testserver.Route{
+					"GET": {
+						Path:    cloud.MembershipsPath,
+						Handler: memberships.Handle,
+					},
+				},
+			})
+			addr := startFakeTMCServerWith(t, router)
+
+			s := sandbox.New(t)
+			s.BuildTree([]string{
+				"s:s1:id=s1",
+			})
+			if tc.retries != "" {
+				s.RootEntry().CreateFile("cloud.tm", `
+					terramate {
+					  config {
+					    cloud {
+					      retries = `+tc.retries+`
+					    }
+					  }
+					}
+				`)
+			}
+			s.Git().CommitAll("all stacks committed")
+			s.Git().SetRemoteURL("origin", testRemoteRepoURL)
+
+			env := RemoveEnv(os.Environ(), "CI", "GITHUB_ACTIONS")
+			env = append(env, "TMC_API_URL=http://"+addr)
+			tmcli := NewCLI(t, s.RootDir(), env...)
+
+			want := tc.want
+			want.IgnoreStdout = true
+			want.IgnoreStderr = want.StderrRegex == ""
+			AssertRunResult(t, tmcli.Run("run", "--quiet", "--disable-safeguards=git-out-of-sync",
+				"--sync-deployment", "--", HelperPath, "true"), want)
+			assert.EqualInts(t, tc.wantCalls, memberships.Calls())
+		})
+	}
+}
@@ -0,0 +1,169 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package cloud_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/madlambda/spells/assert"
+	"github.com/terramate-io/terramate/cloud"
+	"github.com/terramate-io/terramate/cloud/testserver/cloudstore"
+	. "github.com/terramate-io/terramate/e2etests/internal/runner"
+	"github.com/terramate-io/terramate/test/sandbox"
+)
+
+func TestCLIRunWithCloudSyncLogs(t *testing.T) {
+	t.Parallel()
+
+	type testcase struct {
+		name     string
+		config   string
+		runflags []string
+		want     RunExpected
+
+		// logs is a map of stack.ID to the expected uploaded log messages.
+		logs map[string][]string
+	}
+
+	const redactConfig = `terramate {
+		config {
+			cloud {
+				logs {
+					redact = ["^password="]
+				}
+			}
+		}
+	}`
+
+	for _, tc := range []testcase{
+		{
+			name:     "sync-logs uploads the output of failed stacks",
+			config:   redactConfig,
+			runflags: []string{"--sync-deployment", "--sync-logs"},
+			want: RunExpected{
+				Status:       1,
+				IgnoreStdout: true,
+				IgnoreStderr: true,
+			},
+			logs: map[string][]string{
+				"s1": nil,
+				"s2": {"open out.txt: no such file or directory"},
+			},
+		},
+		{
+			name:     "sync-logs=all uploads the redacted output of all stacks",
+			config:   redactConfig,
+			runflags: []string{"--sync-deployment", "--sync-logs=all"},
+			want: RunExpected{
+				Status:       1,
+				IgnoreStdout: true,
+				IgnoreStderr: true,
+			},
+			logs: map[string][]string{
+				"s1": {"applying s1", cloud.RedactedLogMessage, "done"},
+				"s2": {"open out.txt: no such file or directory"},
+			},
+		},
+		{
+			name: "sync mode from config",
+			config: `terramate {
+				config {
+					cloud {
+						logs {
+							sync   = "all"
+							redact = ["^password="]
+						}
+					}
+				}
+			}`,
+			runflags: []string{"--sync-deployment"},
+			want: RunExpected{
+				Status:       1,
+				IgnoreStdout: true,
+				IgnoreStderr: true,
+			},
+			logs: map[string][]string{
+				"s1": {"applying s1", cloud.RedactedLogMessage, "done"},
+				"s2": {"open out.txt: no such file or directory"},
+			},
+		},
+		{
+			name:     "sync-logs requires sync-deployment",
+			runflags: []string{"--sync-logs"},
+			want: RunExpected{
+				Status:      1,
+				StderrRegex: "--sync-logs requires --sync-deployment",
+			},
+		},
+		{
+			name:     "invalid sync-logs value",
+			runflags: []string{"--sync-deployment", "--sync-logs=always"},
+			want: RunExpected{
+				Status:      1,
+				StderrRegex: `--sync-logs must be "failed" or "all"`,
+			},
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			cloudData, err := cloudstore.LoadDatastore(testserverJSONFile)
+			assert.NoError(t, err)
+			addr := startFakeTMCServer(t, cloudData)
+
+			s := sandbox.New(t)
+			layout := []string{
+				"s:s1:id=s1",
+				"s:s2:id=s2",
+				"f:s1/out.txt:applying s1\npassword=hunter2\ndone\n",
+			}
+			if tc.config != "" {
+				layout = append(layout, "f:terramate.tm.hcl:"+tc.config)
+			}
+			s.BuildTree(layout)
+			s.Git().CommitAll("all stacks committed")
+			s.Git().SetRemoteURL("origin", testRemoteRepoURL)
+
+			env := RemoveEnv(os.Environ(), "CI", "GITHUB_ACTIONS")
+			env = append(env, "TMC_API_URL=http://"+addr)
+			cli := NewCLI(t, s.RootDir(), env...)
+
+			runflags := []string{
+				"run",
+				"--disable-safeguards=git-out-of-sync",
+				"--quiet",
+				"--continue-on-error",
+			}
+			runflags = append(runflags, tc.runflags...)
+			runflags = append(runflags, "--", HelperPath, "cat", "out.txt")
+			AssertRunResult(t, cli.Run(runflags...), tc.want)
+
+			if tc.logs == nil {
+				return
+			}
+
+			org := cloudData.MustOrgByName("terramate")
+			deployment, ok := cloudData.FindDeploymentForCommit(org.UUID, s.Git().RevParse("HEAD"))
+			if !ok {
+				t.Fatal("deployment not found")
+			}
+			for metaID, want := range tc.logs {
+				var got []string
+				logs, err := cloudData.GetDeploymentLogs(org.UUID, metaID, "default", deployment.UUID, 0)
+				if err == nil {
+					for _, l := range logs {
+						got = append(got, l.Message)
+					}
+				} else if len(want) > 0 {
+					t.Fatalf("getting logs of stack %s: %v", metaID, err)
+				}
+				if diff := cmp.Diff(want, got); diff != "" {
+					t.Errorf("stack %s logs mismatch: %s", metaID, diff)
+				}
+			}
+		})
+	}
+}
@@ -443,3 +443,118 @@ func toTime(t *testing.T, s string) *time.Time {
 	assert.NoError(t, err)
 	return &tm
 }
+
+func TestCLIRunWithCloudSyncPreviewGitlab(t *testing.T) {
+	t.Parallel()
+
+	const (
+		remoteRepoURL = "git@gitlab.com:terramate-io/dummy-repo.git"
+		commitSHA     = "ea61b5bd72dec0878ae388b04d76a988439d1e28"
+	)
+
+	r, err := git.NormalizeGitURI(remoteRepoURL)
+	assert.NoError(t, err)
+	normalizedRepo := r.Repo
+
+	pipelineCreatedAt := toTime(t, "2024-02-09T12:38:30Z").Unix()
+
+	type testcase struct {
+		name string
+		env  []string
+		want cloud.ReviewRequest
+	}
+
+	for _, tc := range []testcase{
+		{
+			name: "merge request pipeline",
+			env: []string{
+				"CI_MERGE_REQUEST_IID=42",
+				"CI_MERGE_REQUEST_PROJECT_URL=https://gitlab.com/terramate-io/dummy-repo",
+				"CI_MERGE_REQUEST_TITLE=Amazing new feature",
+				"CI_MERGE_REQUEST_SOURCE_BRANCH_NAME=new-topic",
+				"CI_MERGE_REQUEST_TARGET_BRANCH_NAME=main",
+				"GITLAB_USER_LOGIN=octocat",
+			},
+			want: cloud.ReviewRequest{
+				Platform:   "gitlab",
+				Repository: normalizedRepo,
+				CommitSHA:  commitSHA,
+				Number:     42,
+				Title:      "Amazing new feature",
+				URL:        "https://gitlab.com/terramate-io/dummy-repo/-/merge_requests/42",
+				Status:     "opened",
+				Author:     cloud.Author{ID: "0", Login: "octocat"},
+				PushedAt:   &pipelineCreatedAt,
+				Branch:     "new-topic",
+				BaseBranch: "main",
+			},
+		},
+		{
+			name: "branch pipeline falls back to branch-only preview",
+			env: []string{
+				"CI_COMMIT_BRANCH=main",
+			},
+			want: cloud.ReviewRequest{
+				Platform:   "gitlab",
+				Repository: normalizedRepo,
+				CommitSHA:  commitSHA,
+				PushedAt:   &pipelineCreatedAt,
+				Branch:     "main",
+			},
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			cloudData, err := cloudstore.LoadDatastore(testserverJSONFile)
+			assert.NoError(t, err)
+			addr := startFakeTMCServer(t, cloudData)
+
+			s := sandbox.New(t)
+			s.BuildTree([]string{"s:stack:id=stack"})
+			s.Git().CommitAll("all stacks committed")
+			s.Git().SetRemoteURL("origin", remoteRepoURL)
+
+			env := RemoveEnv(os.Environ(), "CI")
+			env = RemoveEnv(env, "GITHUB_ACTIONS")
+			env = append(env,
+				"TMC_API_URL=http://"+addr,
+				// the testserver has no Gitlab API, so the MR is detected from the environment.
+				"TM_GITLAB_API_URL=http://"+addr+"/gitlab",
+				"GITLAB_TOKEN=fake_token",
+				"GITLAB_CI=true",
+				"CI_PIPELINE_CREATED_AT=2024-02-09T12:38:30Z",
+				"CI_COMMIT_SHA="+commitSHA,
+			)
+			env = append(env, tc.env...)
+			cli := NewCLI(t, s.RootDir(), env...)
+
+			result := cli.Run("run", "--disable-safeguards=all", "--sync-preview",
+				"--terraform-plan-file=out.tfplan", "--", HelperPath, "true")
+			AssertRunResult(t, result, RunExpected{
+				IgnoreStdout:  true,
+				IgnoreStderr:  true,
+				StderrRegexes: []string{"Preview created"},
+			})
+
+			orguuid := string(cloudData.MustOrgByName("terramate").UUID)
+			req, err := http.NewRequest("GET", "http://"+addr+"/v1/previews/"+orguuid+"/1", nil)
+			assert.NoError(t, err)
+			req.Header.Set("User-Agent", "terramate/0.0.0-test")
+			httpResp, err := (&http.Client{}).Do(req)
+			assert.NoError(t, err)
+			defer func() { _ = httpResp.Body.Close() }()
+			assert.EqualInts(t, http.StatusOK, httpResp.StatusCode)
+
+			var preview cloudstore.Preview
+			assert.NoError(t, json.NewDecoder(httpResp.Body).Decode(&preview))
+			assert.EqualStrings(t, commitSHA, preview.CommitSHA)
+			assert.EqualInts(t, int(pipelineCreatedAt), int(preview.PushedAt))
+			assert.IsTrue(t, preview.ReviewRequest != nil, "missing review request")
+			if diff := cmp.Diff(tc.want, *preview.ReviewRequest); diff != "" {
+				t.Errorf("unexpected review request (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -17,6 +17,7 @@ import (
 	"github.com/terramate-io/terramate/cmd/terramate/cli/clitest"
 	. "github.com/terramate-io/terramate/e2etests/internal/runner"
 	"github.com/terramate-io/terramate/test"
+	"github.com/terramate-io/terramate/test/hclwrite"
 	. "github.com/terramate-io/terramate/test/hclwrite/hclutils"
 	"github.com/terramate-io/terramate/test/sandbox"
 )
@@ -586,3 +587,195 @@ func TestScriptRunDriftStatus(t *testing.T) {
 		}
 	}
 }
+
+func TestScriptRunDriftStatusWithPlanFileFromPreviousJob(t *testing.T) {
+	t.Parallel()
+
+	// the helper binary acts as a fake terraform when named terraform.
+	fakeTerraformDir := test.TempDir(t)
+	helper, err := os.ReadFile(HelperPath)
+	assert.NoError(t, err)
+	fakeTerraform := filepath.Join(fakeTerraformDir, "terraform"+filepath.Ext(HelperPath))
+	assert.NoError(t, os.WriteFile(fakeTerraform, helper, 0755))
+
+	// the JSON plan printed by the fake terraform.
+	const fakeJSONPlan = `{"format_version":"1.2","planned_values":{"root_module":{}},"configuration":{"root_module":{}}}`
+
+	type testcase struct {
+		name   string
+		jobs   []*hclwrite.Block
+		run    RunExpected
+		drifts expectedDriftStackPayloadRequests
+	}
+
+	expectedStack := cloud.Stack{
+		Repository:    normalizedTestRemoteRepo,
+		DefaultBranch: "main",
+		Path:          "/stack",
+		MetaName:      "stack",
+		MetaID:        "stack",
+		Target:        "default",
+	}
+
+	for _, tc := range []testcase{
+		{
+			name: "plan file created by a previous job",
+			jobs: []*hclwrite.Block{
+				Block("job",
+					Expr("command", `["terraform", "plan", "-out=drift.tfplan"]`),
+				),
+				Block("job",
+					Expr("command", fmt.Sprintf(`["%s", "exit", "2", {
+						sync_drift_status   = true
+						terraform_plan_file = "drift.tfplan"
+					}]`, HelperPathAsHCL)),
+				),
+			},
+			drifts: expectedDriftStackPayloadRequests{
+				{
+					DriftStackPayloadRequest: cloud.DriftStackPayloadRequest{
+						Stack:    expectedStack,
+						Status:   drift.Drifted,
+						Metadata: expectedMetadata,
+						Details: &cloud.ChangesetDetails{
+							Provisioner:   "terraform",
+							ChangesetJSON: fakeJSONPlan,
+						},
+					},
+					ChangesetASCIIRegexes: []string{"Plan: 1 to add"},
+				},
+			},
+		},
+		{
+			name: "plan file option is evaluated right before the job",
+			jobs: []*hclwrite.Block{
+				Block("job",
+					Expr("command", `["terraform", "plan", "-out", "drift.tfplan"]`),
+				),
+				Block("job",
+					Expr("command", fmt.Sprintf(`["%s", "exit", "2", {
+						sync_drift_status   = true
+						terraform_plan_file = tm_fileexists("drift.tfplan") ? "drift.tfplan" : "missing.tfplan"
+					}]`, HelperPathAsHCL)),
+				),
+			},
+			drifts: expectedDriftStackPayloadRequests{
+				{
+					DriftStackPayloadRequest: cloud.DriftStackPayloadRequest{
+						Stack:    expectedStack,
+						Status:   drift.Drifted,
+						Metadata: expectedMetadata,
+						Details: &cloud.ChangesetDetails{
+							Provisioner:   "terraform",
+							ChangesetJSON: fakeJSONPlan,
+						},
+					},
+					ChangesetASCIIRegexes: []string{"Plan: 1 to add"},
+				},
+			},
+		},
+		{
+			name: "plan file not created by the expected job",
+			jobs: []*hclwrite.Block{
+				Block("job",
+					Expr("command", fmt.Sprintf(`["%s", "echo", "-out=drift.tfplan"]`, HelperPathAsHCL)),
+				),
+				Block("job",
+					Expr("command", fmt.Sprintf(`["%s", "exit", "2", {
+						sync_drift_status   = true
+						terraform_plan_file = "drift.tfplan"
+					}]`, HelperPathAsHCL)),
+				),
+			},
+			run: RunExpected{
+				IgnoreStdout: true,
+				StderrRegexes: []string{
+					clitest.CloudSkippingTerraformPlanSync,
+					`plan file drift.tfplan not found in stack /stack`,
+					`it is expected to be created by job 0 of the script`,
+				},
+			},
+			drifts: expectedDriftStackPayloadRequests{
+				{
+					DriftStackPayloadRequest: cloud.DriftStackPayloadRequest{
+						Stack:    expectedStack,
+						Status:   drift.Drifted,
+						Metadata: expectedMetadata,
+					},
+				},
+			},
+		},
+		{
+			name: "plan file not created by any job",
+			jobs: []*hclwrite.Block{
+				Block("job",
+					Expr("command", `["terraform", "plan", "-out=other.tfplan"]`),
+				),
+				Block("job",
+					Expr("command", fmt.Sprintf(`["%s", "exit", "2", {
+						sync_drift_status   = true
+						terraform_plan_file = "drift.tfplan"
+					}]`, HelperPathAsHCL)),
+				),
+			},
+			run: RunExpected{
+				StderrRegexes: []string{
+					`plan file drift.tfplan not found in stack /stack`,
+					`no job of the script up to job 1 creates it`,
+				},
+			},
+			drifts: expectedDriftStackPayloadRequests{
+				{
+					DriftStackPayloadRequest: cloud.DriftStackPayloadRequest{
+						Stack:    expectedStack,
+						Status:   drift.Drifted,
+						Metadata: expectedMetadata,
+					},
+				},
+			},
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			cloudData, err := cloudstore.LoadDatastore(testserverJSONFile)
+			assert.NoError(t, err)
+			addr := startFakeTMCServer(t, cloudData)
+
+			s := sandbox.NewWithGitConfig(t, sandbox.GitConfig{
+				LocalBranchName:         "main",
+				DefaultRemoteName:       "origin",
+				DefaultRemoteBranchName: "main",
+			})
+			s.Env, _ = test.PrependToPath(os.Environ(), fakeTerraformDir)
+
+			script := Block("script",
+				Labels("drift"),
+				Str("description", "test"),
+			)
+			for _, job := range tc.jobs {
+				script.AddBlock(job)
+			}
+			s.BuildTree([]string{
+				"s:stack:id=stack",
+				"f:script.tm:" + script.String(),
+				"f:terramate.tm:" + Block("terramate",
+					Block("config",
+						Expr("experiments", `["scripts"]`))).String(),
+			})
+			s.Git().CommitAll("all stacks committed")
+
+			env := RemoveEnv(s.Env, "CI", "GITHUB_ACTIONS")
+			env = append(env, "TMC_API_URL=http://"+addr)
+			cli := NewCLI(t, s.RootDir(), env...)
+			s.Git().SetRemoteURL("origin", testRemoteRepoURL)
+
+			minStartTime := time.Now().UTC()
+			result := cli.Run("script", "run", "--disable-safeguards=git-out-of-sync", "--quiet", "--", "drift")
+			maxEndTime := time.Now().UTC()
+			AssertRunResult(t, result, tc.run)
+			assertRunDrifts(t, cloudData, addr, tc.drifts, minStartTime, maxEndTime)
+		})
+	}
+}
@@ -224,6 +224,147 @@ func TestScriptRunWithCloudSyncPreview(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "sync with tofu plan file and custom layer",
+			layout: []string{
+				"s:stack:id=someid",
+				`f:terramate.tm:
+				  terramate {
+					config {
+						experiments = ["scripts"]
+					}
+				  }
+				`,
+				`f:stack/preview.tm:
+
+				  script "preview" {
+					description = "sync a preview"
+					job {
+					  commands = [
+						["tofu", "plan", "-out=out.tfplan", "-no-color", "-detailed-exitcode", {
+						  sync_preview   = true,
+						  tofu_plan_file = "out.tfplan",
+						  layer          = "staging",
+						}],
+					  ]
+					}
+				  }
+				  `,
+				`f:stack/main.tf:
+				  resource "local_file" "foo" {
+					content  = "test content"
+					filename = "${path.module}/foo.bar"
+				  }`,
+				"run:stack:tofu init",
+			},
+			cmd: []string{"script", "run", "-X", "preview"},
+			env: []string{
+				"GITHUB_ACTIONS=1",
+			},
+			githubEventPath: datapath(t, "interop/testdata/event_pull_request.json"),
+			want: want{
+				run: RunExpected{
+					Status: 0,
+					StdoutRegexes: []string{
+						"Plan: 1 to add, 0 to change, 0 to destroy.",
+					},
+					StderrRegexes: []string{
+						"Preview created",
+					},
+				},
+				preview: &cloudstore.Preview{
+					PreviewID:       "1",
+					Technology:      "opentofu",
+					TechnologyLayer: "custom:staging",
+					PushedAt:        1707482310,                                 // pushed_at from the pull request event (not from API)
+					CommitSHA:       "ea61b5bd72dec0878ae388b04d76a988439d1e28", // commit_sha from the pull request event (not from API)
+					StackPreviews: []*cloudstore.StackPreview{
+						{
+							ID:     "1",
+							Status: "changed",
+							Cmd:    []string{"tofu", "plan", "-out=out.tfplan", "-no-color", "-detailed-exitcode"},
+						},
+					},
+					ReviewRequest: &cloud.ReviewRequest{
+						Platform:    "github",
+						Repository:  normalizedPreviewTestRemoteRepo,
+						Number:      1347,
+						CommitSHA:   "aaa",
+						Title:       "Amazing new feature",
+						Description: "Please pull these awesome changes in!",
+						URL:         "https://github.com/octocat/Hello-World/pull/1347",
+						Labels:      []cloud.Label{{Name: "bug", Color: "f29513", Description: "Something isn't working"}},
+						Status:      "open",
+						CreatedAt:   createdAt,
+						UpdatedAt:   updatedAt,
+						PushedAt:    &pushedAt,
+						Author: cloud.Author{
+							ID:        "1",
+							Login:     "octocat",
+							AvatarURL: "https://github.com/images/error/octocat_happy.gif",
+						},
+						Branch:     "new-topic",
+						BaseBranch: "master",
+					},
+				},
+				stackPreviewChangesets: []changesetDetails{
+					{
+						Provisioner: "opentofu",
+						ChangesetASCIIRegexes: []string{
+							`OpenTofu will perform the following actions:`,
+							`# local_file.foo will be created`,
+							`Plan: 1 to add, 0 to change, 0 to destroy`,
+						},
+					},
+				},
+				ignoreTypes: []cmp.Option{
+					cmpopts.IgnoreTypes(
+						cloud.CommandLogs{},
+						&cloud.ChangesetDetails{},
+						cloudstore.Stack{},
+						&cloud.DeploymentMetadata{},
+					),
+					cmpopts.IgnoreFields(cloud.ReviewRequest{}, "CommitSHA"),
+				},
+			},
+		},
+		{
+			name: "sync_preview without plan file fails",
+			layout: []string{
+				"s:stack:id=someid",
+				`f:terramate.tm:
+				  terramate {
+					config {
+						experiments = ["scripts"]
+					}
+				  }
+				`,
+				`f:stack/preview.tm:
+
+				  script "preview" {
+					description = "sync a preview"
+					job {
+					  commands = [
+						["terraform", "plan", "-no-color", {
+						  sync_preview = true,
+						}],
+					  ]
+					}
+				  }
+				  `,
+			},
+			cmd: []string{"script", "run", "-X", "preview"},
+			env: []string{
+				"GITHUB_ACTIONS=1",
+			},
+			githubEventPath: datapath(t, "interop/testdata/event_pull_request.json"),
+			want: want{
+				run: RunExpected{
+					Status:      1,
+					StderrRegex: `'sync_preview' requires 'terraform_plan_file' or 'tofu_plan_file'`,
+				},
+			},
+		},
 		{
 			name: "failed command without sync, still sync if any other command has sync enabled",
 			layout: []string{
@@ -0,0 +1,96 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package cloud_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/madlambda/spells/assert"
+	"github.com/terramate-io/terramate/cloud"
+	"github.com/terramate-io/terramate/cloud/testserver/cloudstore"
+	. "github.com/terramate-io/terramate/e2etests/internal/runner"
+	"github.com/terramate-io/terramate/test/sandbox"
+)
+
+func TestStackDeleteCloudArchive(t *testing.T) {
+	t.Parallel()
+
+	store, err := cloudstore.LoadDatastore(testserverJSONFile)
+	assert.NoError(t, err)
+	addr := startFakeTMCServer(t, store)
+
+	s := sandbox.New(t)
+	s.BuildTree([]string{
+		"s:stack:id=stack-id",
+		"s:other:id=other-id",
+	})
+	s.Git().SetRemoteURL("origin", "git@github.com:terramate-io/terramate.git")
+	s.Git().CommitAll("create stacks")
+
+	org := store.MustOrgByName("terramate")
+	var ids []int64
+	for _, metaID := range []string{"stack-id", "other-id"} {
+		id, err := store.UpsertStack(org.UUID, cloudstore.Stack{
+			Stack: cloud.Stack{
+				MetaID:     metaID,
+				Repository: "github.com/terramate-io/terramate",
+				Target:     "default",
+			},
+		})
+		assert.NoError(t, err)
+		ids = append(ids, id)
+	}
+
+	env := RemoveEnv(os.Environ(), "CI")
+	env = append(env, "TMC_API_URL=http://"+addr, "CI=")
+	tmcli := NewCLI(t, s.RootDir(), env...)
+
+	AssertRunResult(t, tmcli.Run("stack", "delete", "--yes", "--cloud-archive", "stack"), RunExpected{
+		Stdout: nljoin(
+			"Files to be removed:",
+			"\t/stack/terramate.tm.hcl",
+			"Stack /stack deleted",
+			"Stack /stack archived in Terramate Cloud",
+		),
+	})
+
+	_, err = os.Stat(filepath.Join(s.RootDir(), "stack"))
+	assert.IsTrue(t, os.IsNotExist(err), "stack dir must be removed")
+
+	org = store.MustOrgByName("terramate")
+	st, ok := store.GetStack(org, ids[0])
+	assert.IsTrue(t, ok)
+	assert.IsTrue(t, st.State.ArchivedAt != nil, "stack must be archived")
+
+	other, ok := store.GetStack(org, ids[1])
+	assert.IsTrue(t, ok)
+	assert.IsTrue(t, other.State.ArchivedAt == nil, "other stacks must not be archived")
+}
+
+func TestStackDeleteCloudArchiveRequiresID(t *testing.T) {
+	t.Parallel()
+
+	store, err := cloudstore.LoadDatastore(testserverJSONFile)
+	assert.NoError(t, err)
+	addr := startFakeTMCServer(t, store)
+
+	s := sandbox.New(t)
+	s.BuildTree([]string{"s:stack"})
+	s.Git().CommitAll("create stack")
+
+	env := RemoveEnv(os.Environ(), "CI")
+	env = append(env, "TMC_API_URL=http://"+addr, "CI=")
+	tmcli := NewCLI(t, s.RootDir(), env...)
+
+	AssertRunResult(t, tmcli.Run("stack", "delete", "--yes", "--cloud-archive", "stack"), RunExpected{
+		Status:       1,
+		IgnoreStdout: true,
+		StderrRegex:  "must have an ID to be archived",
+	})
+
+	_, err = os.Stat(filepath.Join(s.RootDir(), "stack"))
+	assert.NoError(t, err)
+}
@@ -32,6 +32,12 @@ func main() {
 	// could be confused with a *detected drift* (see: run --sync-drift-status)
 	// then avoid panics here and do proper os.Exit(1) in case of errors.
 
+	// a copy of the helper named terraform acts as a fake terraform.
+	if name := filepath.Base(os.Args[0]); strings.TrimSuffix(name, filepath.Ext(name)) == "terraform" {
+		fakeTerraform(os.Args[1:])
+		return
+	}
+
 	switch os.Args[1] {
 	case "echo":
 		args := os.Args[2:]
@@ -225,6 +231,51 @@ func tfPlanSanitize(fname string) {
 	fmt.Print(string(newPlanData))
 }
 
+// fakeTerraform implements the plan and show commands of terraform, enough
+// for synchronizing plan files to Terramate Cloud. The plan command writes the
+// file given by -out and exits with 2 (changes present) if -detailed-exitcode
+// is set. The show command prints the plan file, or an empty JSON plan if
+// -json is set.
+func fakeTerraform(args []string) {
+	switch args[0] {
+	case "version":
+		fmt.Println("Terraform v1.5.7")
+	case "plan":
+		var planfile string
+		detailed := false
+		for i, arg := range args[1:] {
+			switch {
+			case arg == "-detailed-exitcode":
+				detailed = true
+			case strings.HasPrefix(arg, "-out="):
+				planfile = strings.TrimPrefix(arg, "-out=")
+			case arg == "-out" && i+2 < len(args):
+				planfile = args[i+2]
+			}
+		}
+		if planfile != "" {
+			checkerr(os.WriteFile(planfile, []byte("Plan: 1 to add, 0 to change, 0 to destroy.\n"), 0644))
+		}
+		if detailed {
+			os.Exit(2)
+		}
+	case "show":
+		planfile := args[len(args)-1]
+		data, err := os.ReadFile(planfile)
+		checkerr(err)
+		for _, arg := range args[1:] {
+			if arg == "-json" {
+				fmt.Print(`{"format_version":"1.2","terraform_version":"1.5.7",` +
+					`"planned_values":{"root_module":{}},"configuration":{"root_module":{}}}`)
+				return
+			}
+		}
+		fmt.Print(string(data))
+	default:
+		log.Fatalf("unknown terraform command %s", args[0])
+	}
+}
+
 func gitnorm(rawURL string) {
 	repo, err := git.NormalizeGitURI(rawURL)
 	if err != nil {
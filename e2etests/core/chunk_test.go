@@ -0,0 +1,144 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package core_test
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/madlambda/spells/assert"
+	. "github.com/terramate-io/terramate/e2etests/internal/runner"
+	"github.com/terramate-io/terramate/test/sandbox"
+)
+
+func TestChunk(t *testing.T) {
+	t.Parallel()
+
+	layout := []string{
+		`s:infra/network:tags=["infra"]`,
+		`s:infra/dns:tags=["infra"]`,
+		`s:apps/a:after=["tag:infra"]`,
+		`s:apps/b:after=["tag:infra"]`,
+		`s:docs`,
+		`s:docs/child`,
+		`s:tools`,
+		`s:web`,
+		`s:worker`,
+		`f:terramate.tm:
+		terramate {
+		  config {
+		    experiments = ["scripts"]
+		  }
+		}`,
+		`f:script.tm:
+		script "deploy" {
+		  job {
+		    command = ["` + HelperPath + `", "stack-rel-path", "${terramate.root.path.fs.absolute}"]
+		  }
+		}`,
+	}
+
+	lines := func(out string) []string {
+		return strings.Split(strings.TrimSpace(out), "\n")
+	}
+
+	t.Run("the chunks partition the selection", func(t *testing.T) {
+		t.Parallel()
+		s := sandbox.NoGit(t, true)
+		s.BuildTree(layout)
+		cli := NewCLI(t, s.RootDir())
+
+		res := cli.ListStacks()
+		AssertRunResult(t, res, RunExpected{IgnoreStdout: true})
+		all := lines(res.Stdout)
+
+		for _, total := range []int{1, 2, 3, 12} {
+			var union []string
+			chunkOf := map[string]string{}
+			for i := 0; i < total; i++ {
+				spec := fmt.Sprintf("%d/%d", i, total)
+				res := cli.Run("list", "--chunk", spec)
+				AssertRunResult(t, res, RunExpected{IgnoreStdout: true})
+				if res.Stdout == "" {
+					continue
+				}
+				for _, st := range lines(res.Stdout) {
+					if other, ok := chunkOf[st]; ok {
+						t.Fatalf("stack %s is in chunk %s and %s", st, other, spec)
+					}
+					chunkOf[st] = spec
+					union = append(union, st)
+				}
+			}
+			slices.Sort(union)
+			assert.EqualStrings(t, strings.Join(all, " "), strings.Join(union, " "), "total %d", total)
+
+			// the stacks ordered between them are always in the same chunk.
+			for _, group := range [][]string{
+				{"apps/a", "apps/b", "infra/dns", "infra/network"},
+				{"docs", "docs/child"},
+			} {
+				for _, st := range group {
+					assert.EqualStrings(t, chunkOf[group[0]], chunkOf[st], "total %d", total)
+				}
+			}
+		}
+	})
+
+	t.Run("run and script run execute the listed chunk", func(t *testing.T) {
+		t.Parallel()
+		s := sandbox.NoGit(t, true)
+		s.BuildTree(layout)
+		cli := NewCLI(t, s.RootDir())
+
+		for _, spec := range []string{"0/2", "1/2"} {
+			res := cli.Run("list", "--chunk", spec, "--run-order")
+			AssertRunResult(t, res, RunExpected{IgnoreStdout: true})
+			want := RunExpected{Stdout: res.Stdout}
+			AssertRunResult(t, cli.Run("run", "--quiet", "--chunk", spec, "--",
+				HelperPath, "stack-rel-path", s.RootDir()), want)
+			AssertRunResult(t, cli.Run("script", "run", "--quiet", "--chunk", spec, "deploy"), want)
+		}
+	})
+
+	t.Run("verbose shows the chunk of each stack", func(t *testing.T) {
+		t.Parallel()
+		s := sandbox.NoGit(t, true)
+		s.BuildTree(layout)
+		cli := NewCLI(t, s.RootDir())
+		AssertRunResult(t, cli.Run("list", "--chunk", "1/2", "-v"), RunExpected{
+			IgnoreStdout: true,
+			StderrRegexes: []string{
+				`stack /apps/a is in chunk 0/2`,
+				`stack /docs/child is in chunk 1/2`,
+			},
+		})
+	})
+
+	t.Run("invalid chunks fail", func(t *testing.T) {
+		t.Parallel()
+		s := sandbox.NoGit(t, true)
+		s.BuildTree(layout)
+		cli := NewCLI(t, s.RootDir())
+		for _, tc := range []struct {
+			spec string
+			want string
+		}{
+			{spec: "2/2", want: `the index must be between 0 and 1`},
+			{spec: "0/0", want: `the total must be greater than 0`},
+			{spec: "1", want: `expected <index>/<total>`},
+		} {
+			AssertRunResult(t, cli.Run("list", "--chunk", tc.spec), RunExpected{
+				Status:      1,
+				StderrRegex: tc.want,
+			})
+			AssertRunResult(t, cli.Run("run", "--chunk", tc.spec, "--", HelperPath, "true"), RunExpected{
+				Status:      1,
+				StderrRegex: tc.want,
+			})
+		}
+	})
+}
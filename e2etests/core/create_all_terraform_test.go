@@ -176,3 +176,43 @@ func TestCreateWithAllTerraformDetectModulesInsideStacks(t *testing.T) {
 		},
 	)
 }
+
+func TestCreateWithAllTerraformDetectsOpenTofuFiles(t *testing.T) {
+	s := sandbox.NoGit(t, true)
+	backendContent := Block("terraform",
+		Block("backend",
+			Labels("remote"),
+			Str("attr", "value"),
+		)).String()
+	providerContent := Block("provider",
+		Labels("aws"),
+		Str("attr", "1"),
+	).String()
+
+	s.BuildTree([]string{
+		`f:tofu-only/main.tofu:` + backendContent,
+		`f:mixed/main.tf:` + backendContent,
+		`f:mixed/providers.tofu:` + providerContent,
+		`f:module/main.tofu:# not a root module`,
+		`f:README.md:# My module`,
+	})
+	tm := NewCLI(t, s.RootDir())
+	AssertRunResult(t,
+		tm.Run("create", "--all-terraform"),
+		RunExpected{
+			Stdout: nljoin(
+				"Created stack /mixed",
+				"Created stack /tofu-only",
+			),
+		},
+	)
+	AssertRunResult(t,
+		tm.Run("list"),
+		RunExpected{
+			Stdout: nljoin(
+				"mixed",
+				"tofu-only",
+			),
+		},
+	)
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/madlambda/spells/assert"
 	. "github.com/terramate-io/terramate/e2etests/internal/runner"
 	"github.com/terramate-io/terramate/project"
+	"github.com/terramate-io/terramate/test"
 	"github.com/terramate-io/terramate/test/hclwrite"
 	. "github.com/terramate-io/terramate/test/hclwrite/hclutils"
 	"github.com/terramate-io/terramate/test/sandbox"
@@ -578,3 +579,235 @@ func TestCreateAllTerragrunt(t *testing.T) {
 		})
 	}
 }
+
+func TestCreateAllTerragruntWatchesConfigFiles(t *testing.T) {
+	t.Parallel()
+
+	hclfile := func(name string, content *hclwrite.Block) string {
+		return "f:" + name + ":" + content.String()
+	}
+	envLocals := func(name string) string {
+		return Locals(Str("environment", name)).String()
+	}
+	unit := func(envcommon string) string {
+		return Doc(
+			Block("include", Labels("root"),
+				Expr("path", `find_in_parent_folders()`),
+			),
+			Block("include", Labels("envcommon"),
+				Expr("path", `"${dirname(find_in_parent_folders())}/_envcommon/`+envcommon+`"`),
+				Bool("expose", true),
+			),
+			Block("terraform",
+				Expr("source", `"${include.envcommon.locals.base_source_url}?ref=v0.8.0"`),
+			),
+		).String()
+	}
+
+	// mirrors the terragrunt-infrastructure-live-example layout.
+	s := sandbox.New(t)
+	s.BuildTree([]string{
+		hclfile("terragrunt.hcl", Locals(
+			Expr("account_vars", `read_terragrunt_config(find_in_parent_folders("account.hcl"))`),
+			Expr("region_vars", `read_terragrunt_config(find_in_parent_folders("region.hcl"))`),
+			Expr("env_vars", `read_terragrunt_config(find_in_parent_folders("env.hcl"))`),
+		)),
+		hclfile("_envcommon/mysql.hcl", Locals(
+			Expr("env_vars", `read_terragrunt_config(find_in_parent_folders("env.hcl"))`),
+			Str("base_source_url", "git::git@github.com:acme/infrastructure-modules.git//mysql"),
+		)),
+		hclfile("non-prod/account.hcl", Locals(Str("account_name", "non-prod"))),
+		hclfile("non-prod/common.hcl", Locals(
+			Expr("account", `read_terragrunt_config("account.hcl")`),
+		)),
+		hclfile("non-prod/us-east-1/region.hcl", Locals(Str("aws_region", "us-east-1"))),
+		"f:non-prod/us-east-1/qa/env.hcl:" + envLocals("qa"),
+		"f:non-prod/us-east-1/qa/mysql/terragrunt.hcl:" + unit("mysql.hcl"),
+		hclfile("non-prod/us-east-1/qa/webserver/terragrunt.hcl", Doc(
+			Block("include", Labels("root"),
+				Expr("path", `find_in_parent_folders()`),
+			),
+			Locals(
+				Expr("common", `read_terragrunt_config(find_in_parent_folders("common.hcl"))`),
+			),
+			Block("terraform",
+				Str("source", "../../../../modules/webserver"),
+			),
+		)),
+		"f:modules/webserver/main.tf:# empty",
+		hclfile("prod/account.hcl", Locals(Str("account_name", "prod"))),
+		hclfile("prod/us-east-1/region.hcl", Locals(Str("aws_region", "us-east-1"))),
+		"f:prod/us-east-1/prod/env.hcl:" + envLocals("prod"),
+		"f:prod/us-east-1/prod/mysql/terragrunt.hcl:" + unit("mysql.hcl"),
+	})
+
+	tm := NewCLI(t, s.RootDir())
+	AssertRunResult(t, tm.Run("create", "--all-terragrunt"), RunExpected{
+		Stdout: nljoin(
+			"Created stack /non-prod/us-east-1/qa/mysql",
+			"Created stack /non-prod/us-east-1/qa/webserver",
+			"Created stack /prod/us-east-1/prod/mysql",
+		),
+	})
+
+	want := map[string]project.Paths{
+		"/non-prod/us-east-1/qa/mysql": {
+			project.NewPath("/_envcommon/mysql.hcl"),
+			project.NewPath("/non-prod/account.hcl"),
+			project.NewPath("/non-prod/us-east-1/qa/env.hcl"),
+			project.NewPath("/non-prod/us-east-1/region.hcl"),
+			project.NewPath("/terragrunt.hcl"),
+		},
+		"/non-prod/us-east-1/qa/webserver": {
+			project.NewPath("/non-prod/account.hcl"),
+			project.NewPath("/non-prod/common.hcl"),
+			project.NewPath("/non-prod/us-east-1/qa/env.hcl"),
+			project.NewPath("/non-prod/us-east-1/region.hcl"),
+			project.NewPath("/terragrunt.hcl"),
+		},
+		"/prod/us-east-1/prod/mysql": {
+			project.NewPath("/_envcommon/mysql.hcl"),
+			project.NewPath("/prod/account.hcl"),
+			project.NewPath("/prod/us-east-1/prod/env.hcl"),
+			project.NewPath("/prod/us-east-1/region.hcl"),
+			project.NewPath("/terragrunt.hcl"),
+		},
+	}
+
+	root := s.ReloadConfig()
+	stacks := root.Tree().Stacks()
+	assert.EqualInts(t, len(want), len(stacks))
+	for _, tree := range stacks {
+		st, err := tree.Stack()
+		assert.NoError(t, err)
+		test.AssertDiff(t, st.Watch, want[st.Dir.String()], "stack %s has unexpected watch paths", st.Dir)
+	}
+
+	s.Git().CommitAll("create stacks")
+	s.Git().Push("main")
+	s.Git().CheckoutNew("change-env")
+
+	s.RootEntry().CreateFile("non-prod/us-east-1/qa/env.hcl", envLocals("staging"))
+	s.Git().CommitAll("change qa env")
+	AssertRunResult(t, tm.Run("list", "--changed"), RunExpected{
+		Stdout: nljoin(
+			"non-prod/us-east-1/qa/mysql",
+			"non-prod/us-east-1/qa/webserver",
+		),
+	})
+
+	s.RootEntry().CreateFile("_envcommon/mysql.hcl", Locals(
+		Str("base_source_url", "git::git@github.com:acme/infrastructure-modules.git//mysql"),
+	).String())
+	s.Git().CommitAll("change mysql envcommon")
+	AssertRunResult(t, tm.Run("list", "--changed"), RunExpected{
+		Stdout: nljoin(
+			"non-prod/us-east-1/qa/mysql",
+			"non-prod/us-east-1/qa/webserver",
+			"prod/us-east-1/prod/mysql",
+		),
+	})
+}
+
+func TestCreateTerragrunt(t *testing.T) {
+	t.Parallel()
+
+	const source = "git::git@github.com:acme/infrastructure-modules.git//vpc?ref=v0.1.0"
+
+	s := sandbox.New(t)
+	tm := NewCLI(t, s.RootDir())
+
+	AssertRunResult(t, tm.Run("create", "--terragrunt", "live/app"), RunExpected{
+		Status:      1,
+		StderrRegex: "--terragrunt requires --source",
+	})
+	AssertRunResult(t, tm.Run("create", "live/app", "--source", source), RunExpected{
+		Status:      1,
+		StderrRegex: "--source requires --terragrunt",
+	})
+	AssertRunResult(t, tm.Run("create", "--all-terragrunt", "--terragrunt", "--source", source), RunExpected{
+		Status:      1,
+		StderrRegex: "--all-terragrunt is incompatible",
+	})
+
+	AssertRunResult(t, tm.Run("create", "--terragrunt", "live/vpc", "--source", source), RunExpected{
+		Stdout: nljoin("Created stack /live/vpc"),
+	})
+	AssertRunResult(t, tm.Run("create", "--terragrunt", "live/app", "--source", source), RunExpected{
+		Stdout: nljoin("Created stack /live/app"),
+	})
+	AssertRunResult(t, tm.Run("create", "--terragrunt", "live/app", "--source", source), RunExpected{
+		Status:      1,
+		StderrRegex: "terragrunt.hcl already exists",
+	})
+
+	const existingConfig = "# keep me\n"
+	s.RootEntry().CreateFile("live/existing/terragrunt.hcl", existingConfig)
+	AssertRunResult(t, tm.Run("create", "--terragrunt", "live/existing", "--source", source, "--ignore-existing"), RunExpected{
+		Stdout: nljoin("Created stack /live/existing"),
+	})
+	assert.EqualStrings(t, existingConfig, string(s.DirEntry("live/existing").ReadFile("terragrunt.hcl")))
+	s.DirEntry("live/existing").RemoveFile("terragrunt.hcl")
+	s.DirEntry("live/existing").RemoveFile("stack.tm.hcl")
+
+	wantConfig := "terraform {\n" +
+		"  source = \"" + source + "\"\n" +
+		"}\n" +
+		"\n" +
+		"inputs = {}\n"
+	for _, dir := range []string{"live/vpc", "live/app"} {
+		assert.EqualStrings(t, wantConfig, string(s.DirEntry(dir).ReadFile("terragrunt.hcl")))
+	}
+
+	AssertRunResult(t, tm.Run("list"), RunExpected{
+		Stdout: nljoin("live/app", "live/vpc"),
+	})
+	s.Git().CommitAll("create stacks")
+	AssertRunResult(t, tm.Run("run", "--quiet", "--", HelperPath, "cat", "terragrunt.hcl"), RunExpected{
+		Stdout: wantConfig + wantConfig,
+	})
+
+	// the created stacks are already detected as Terragrunt modules.
+	AssertRunResult(t, tm.Run("create", "--all-terragrunt"), RunExpected{})
+
+	t.Run("dependency blocks added later", func(t *testing.T) {
+		appCfg := s.DirEntry("live/app").ReadFile("terragrunt.hcl")
+		s.DirEntry("live/app").CreateFile("terragrunt.hcl", string(appCfg)+Block("dependency",
+			Labels("vpc"),
+			Str("config_path", "../vpc"),
+		).String())
+		s.RootEntry().CreateFile("live/db/terragrunt.hcl", Doc(
+			Block("terraform",
+				Str("source", source),
+			),
+			Block("dependency",
+				Labels("app"),
+				Str("config_path", "../app"),
+			),
+		).String())
+
+		modules, err := tg.ScanModules(s.RootDir(), project.NewPath("/live"), true)
+		assert.NoError(t, err)
+		assert.EqualInts(t, 3, len(modules))
+
+		wantAfter := map[string]project.Paths{
+			"/live/app": {project.NewPath("/live/vpc")},
+			"/live/db":  {project.NewPath("/live/app")},
+			"/live/vpc": nil,
+		}
+		for _, mod := range modules {
+			test.AssertDiff(t, mod.After, wantAfter[mod.Path.String()], "module %s has unexpected after", mod.Path)
+		}
+
+		AssertRunResult(t, tm.Run("create", "--all-terragrunt"), RunExpected{
+			Stdout: nljoin("Created stack /live/db"),
+		})
+
+		root := s.ReloadConfig()
+		tree, ok := root.Lookup(project.NewPath("/live/db"))
+		assert.IsTrue(t, ok)
+		st, err := tree.Stack()
+		assert.NoError(t, err)
+		test.AssertDiff(t, st.After, []string{"/live/app"})
+	})
+}
@@ -247,6 +247,53 @@ func TestCreateStackDefaults(t *testing.T) {
 	test.AssertStackImports(t, s.RootDir(), got.HostDir(s.Config()), []string{})
 }
 
+func TestCreateStackWithConfiguredFilename(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.NoGit(t, true)
+	s.BuildTree([]string{
+		`f:config.tm:terramate {
+		  config {
+		    stack {
+		      filename = "terramate.tm.hcl"
+		    }
+		  }
+		}`,
+		"f:stacks/a/terramate.tm.hcl:# the globals of the stack\nglobals {\n  env = \"prod\"\n}\n",
+		"f:stacks/b/terramate.tm.hcl:globals {\n}\n",
+		"f:stacks/b/stack.tm:stack {\n}\n",
+		"f:modules/tf/main.tf:terraform {\n  backend \"local\" {}\n}\n",
+	})
+	cli := NewCLI(t, s.RootDir())
+	AssertRunResult(t, cli.Run("create", "stacks/a", "--id", "stack-a"), RunExpected{
+		Stdout: "Created stack /stacks/a\n",
+	})
+	AssertRunResult(t, cli.Run("create", "stacks/new"), RunExpected{
+		Stdout: "Created stack /stacks/new\n",
+	})
+	AssertRunResult(t, cli.Run("create", "stacks/b"), RunExpected{
+		Status:      1,
+		StderrRegex: "stack already exists",
+	})
+	AssertRunResult(t, cli.Run("create", "--all-terraform"), RunExpected{
+		Stdout: "Created stack /modules/tf\n",
+	})
+
+	got := test.ReadFile(t, filepath.Join(s.RootDir(), "stacks", "a"), "terramate.tm.hcl")
+	assert.EqualStrings(t, "# the globals of the stack\nglobals {\n  env = \"prod\"\n}\n"+
+		"\nstack {\n"+
+		"  name        = \"a\"\n"+
+		"  description = \"a\"\n"+
+		"  id          = \"stack-a\"\n"+
+		"}\n", string(got))
+
+	for _, dir := range []string{"stacks/a", "stacks/new", "modules/tf"} {
+		test.DoesNotExist(t, filepath.Join(s.RootDir(), dir), "stack.tm.hcl")
+		st := s.LoadStack(project.NewPath("/" + dir))
+		assert.EqualStrings(t, path.Base(dir), st.Name)
+	}
+}
+
 func TestCreateStackIgnoreExistingOnDefaultStackCfgFound(t *testing.T) {
 	t.Parallel()
 
@@ -373,3 +420,92 @@ func newStackID(t *testing.T) string {
 	}
 	return id.String()
 }
+
+func TestCreateEnsureStackIDFixDuplicates(t *testing.T) {
+	t.Parallel()
+
+	layout := []string{
+		`s:stacks/c:id=dup`,
+		`s:stacks/a:id=dup`,
+		`s:stacks/b:id=DUP`,
+		`s:stacks/d:id=other`,
+		`s:stacks/e:id=other`,
+		`s:stacks/f:id=unique`,
+		`s:stacks/g`,
+	}
+
+	t.Run("commands fail listing all duplicated stacks", func(t *testing.T) {
+		t.Parallel()
+		s := sandbox.NoGit(t, true)
+		s.BuildTree(layout)
+		tm := NewCLI(t, s.RootDir())
+		AssertRunResult(t, tm.Run("list"), RunExpected{
+			Status: 1,
+			StderrRegexes: []string{
+				`stack ID "dup" is defined by the stacks /stacks/a, /stacks/b, /stacks/c`,
+				`stack ID "other" is defined by the stacks /stacks/d, /stacks/e`,
+			},
+		})
+	})
+
+	t.Run("--ensure-stack-ids alone refuses to run", func(t *testing.T) {
+		t.Parallel()
+		s := sandbox.NoGit(t, true)
+		s.BuildTree(layout)
+		tm := NewCLI(t, s.RootDir())
+		AssertRunResult(t, tm.Run("create", "--ensure-stack-ids"), RunExpected{
+			Status:      1,
+			StderrRegex: `stack ID "dup" is defined by the stacks /stacks/a, /stacks/b, /stacks/c`,
+		})
+		s.ReloadConfig()
+		stackG := s.StackEntry("stacks/g")
+		assert.IsTrue(t, !strings.Contains(stackG.ReadFile("stack.tm.hcl"), "id"),
+			"stack without ID must not be changed")
+	})
+
+	t.Run("--fix-duplicates requires --ensure-stack-ids", func(t *testing.T) {
+		t.Parallel()
+		s := sandbox.NoGit(t, true)
+		s.BuildTree(layout)
+		tm := NewCLI(t, s.RootDir())
+		AssertRunResult(t, tm.Run("create", "--fix-duplicates"), RunExpected{
+			Status:      1,
+			StderrRegex: "--fix-duplicates requires --ensure-stack-ids",
+		})
+	})
+
+	t.Run("--fix-duplicates keeps the first stack ID and regenerates the others", func(t *testing.T) {
+		t.Parallel()
+		s := sandbox.NoGit(t, true)
+		s.BuildTree(layout)
+		tm := NewCLI(t, s.RootDir())
+		res := tm.Run("create", "--ensure-stack-ids", "--fix-duplicates")
+		AssertRunResult(t, res, RunExpected{
+			StdoutRegexes: []string{
+				`STACK\s+OLD ID\s+NEW ID`,
+				`/stacks/b\s+dup\s+[0-9a-f-]{36}`,
+				`/stacks/c\s+dup\s+[0-9a-f-]{36}`,
+				`/stacks/e\s+other\s+[0-9a-f-]{36}`,
+				`Generated ID [0-9a-f-]{36} for stack /stacks/g`,
+			},
+			NoStdoutRegex: `/stacks/(a|d|f)\s`,
+		})
+
+		s.ReloadConfig()
+		ids := map[string]string{}
+		for _, st := range s.LoadStacks() {
+			assert.IsTrue(t, st.ID != "", "stack %s has no ID", st.Dir())
+			if other, ok := ids[strings.ToLower(st.ID)]; ok {
+				t.Fatalf("stacks %s and %s have the same ID %s", other, st.Dir(), st.ID)
+			}
+			ids[strings.ToLower(st.ID)] = st.Dir().String()
+		}
+		assert.EqualStrings(t, "/stacks/a", ids["dup"])
+		assert.EqualStrings(t, "/stacks/d", ids["other"])
+		assert.EqualStrings(t, "/stacks/f", ids["unique"])
+
+		AssertRunResult(t, tm.Run("create", "--ensure-stack-ids", "--fix-duplicates"), RunExpected{
+			Stdout: "No duplicated stack IDs found\n",
+		})
+	})
+}
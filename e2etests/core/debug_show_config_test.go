@@ -0,0 +1,93 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package core_test
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	. "github.com/terramate-io/terramate/e2etests/internal/runner"
+	"github.com/terramate-io/terramate/test"
+	. "github.com/terramate-io/terramate/test/hclwrite/hclutils"
+	"github.com/terramate-io/terramate/test/sandbox"
+)
+
+func TestDebugShowConfig(t *testing.T) {
+	t.Parallel()
+
+	t.Run("defaults and env override", func(t *testing.T) {
+		t.Parallel()
+
+		s := sandbox.NoGit(t, true)
+		tmcli := NewCLI(t, s.RootDir())
+		AssertRunResult(t, tmcli.Run("debug", "show", "config"), RunExpected{
+			StdoutRegexes: []string{
+				`user config: .*terramate\.rc \(env TM_CLI_CONFIG_FILE\)`,
+				regexp.QuoteMeta("project root: " + filepath.ToSlash(s.RootDir())),
+				regexp.QuoteMeta("telemetry.enabled = true (default)"),
+				regexp.QuoteMeta("checkpoint.enabled = false (env CHECKPOINT_DISABLE)"),
+			},
+		})
+	})
+
+	t.Run("project config", func(t *testing.T) {
+		t.Parallel()
+
+		s := sandbox.NoGit(t, true)
+		s.RootEntry().CreateFile("telemetry.tm", Terramate(
+			Config(
+				Block("telemetry",
+					Bool("enabled", false),
+				),
+				Block("checkpoint",
+					Bool("enabled", false),
+				),
+			),
+		).String())
+		tmcli := NewCLI(t, s.RootDir())
+		tmcli.AppendEnv = append(tmcli.AppendEnv, "CHECKPOINT_DISABLE=")
+		AssertRunResult(t, tmcli.Run("debug", "show", "config"), RunExpected{
+			StdoutRegexes: []string{
+				regexp.QuoteMeta("telemetry.enabled = false (project config terramate.config.telemetry.enabled)"),
+				regexp.QuoteMeta("checkpoint.enabled = false (project config terramate.config.checkpoint.enabled)"),
+			},
+		})
+
+		// the command line flag takes precedence.
+		AssertRunResult(t, tmcli.Run("--disable-checkpoint", "debug", "show", "config"), RunExpected{
+			StdoutRegex: regexp.QuoteMeta("checkpoint.enabled = false (flag --disable-checkpoint)"),
+		})
+	})
+
+	t.Run("user config takes precedence over project config", func(t *testing.T) {
+		t.Parallel()
+
+		s := sandbox.NoGit(t, true)
+		s.RootEntry().CreateFile("telemetry.tm", Terramate(
+			Config(
+				Block("telemetry",
+					Bool("enabled", true),
+				),
+			),
+		).String())
+
+		userDir := test.TempDir(t)
+		rcfile := filepath.Clean(test.WriteFile(t, userDir, "terramate.rc", fmt.Sprintf(
+			"user_terramate_dir = \"%s\"\ndisable_telemetry = true\n",
+			strings.ReplaceAll(userDir, "\\", "\\\\"),
+		)))
+
+		tmcli := NewCLI(t, s.RootDir())
+		tmcli.AppendEnv = append(tmcli.AppendEnv, "TM_CLI_CONFIG_FILE="+rcfile)
+		AssertRunResult(t, tmcli.Run("debug", "show", "config"), RunExpected{
+			StdoutRegexes: []string{
+				regexp.QuoteMeta("user config: " + rcfile + " (env TM_CLI_CONFIG_FILE)"),
+				regexp.QuoteMeta("telemetry.enabled = false (user config " + rcfile + " (disable_telemetry))"),
+			},
+		})
+	})
+}
@@ -258,3 +258,78 @@ stack "/stacks/stack-name":
 		})
 	}
 }
+
+func TestStacksGlobalsSensitive(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.NoGit(t, true)
+	s.BuildTree([]string{"s:stack"})
+	s.RootEntry().CreateFile("terramate.tm", Terramate(
+		Config(
+			Expr("sensitive_globals", `["db_password", "api.*"]`),
+		),
+	).String())
+	s.DirEntry("stack").CreateFile("globals.tm", Globals(
+		Str("db_password", "hunter2"),
+		Str("db_user", "admin"),
+		Expr("api", `{
+			key = "secret-key"
+			url = "https://example.com"
+		}`),
+	).String())
+
+	tm := NewCLI(t, s.RootDir())
+	AssertRunResult(t, tm.Run("debug", "show", "globals"), RunExpected{
+		Stdout: `
+stack "/stack":
+	api = {
+	  key = "(sensitive)"
+	  url = "(sensitive)"
+	}
+	db_password = "(sensitive)"
+	db_user     = "admin"
+`,
+	})
+
+	AssertRunResult(t, tm.Run("debug", "show", "globals", "--show-sensitive"), RunExpected{
+		Stdout: `
+stack "/stack":
+	api = {
+	  key = "secret-key"
+	  url = "https://example.com"
+	}
+	db_password = "hunter2"
+	db_user     = "admin"
+`,
+	})
+
+	s.DirEntry("stack").CreateFile("error.tm", Globals(
+		Expr("port", `tm_tonumber(global.db_password)`),
+	).String())
+
+	AssertRunResult(t, tm.Run("debug", "show", "globals"), RunExpected{
+		Status:        1,
+		StderrRegex:   `\(sensitive\)`,
+		NoStderrRegex: `hunter2`,
+	})
+
+	AssertRunResult(t, tm.Run("debug", "show", "globals", "--show-sensitive"), RunExpected{
+		Status:      1,
+		StderrRegex: `hunter2`,
+	})
+
+	AssertRunResult(t, tm.Run("generate"), RunExpected{
+		Status:        1,
+		StdoutRegex:   `\(sensitive\)`,
+		NoStdoutRegex: `hunter2`,
+	})
+
+	s.DirEntry("stack").RemoveFile("error.tm")
+
+	AssertRunResult(t, tm.Run("run", "--quiet", "--eval", "--",
+		HelperPath, "echo", "${tm_tonumber(global.db_password)}"), RunExpected{
+		Status:        1,
+		StderrRegex:   `\(sensitive\)`,
+		NoStderrRegex: `hunter2`,
+	})
+}
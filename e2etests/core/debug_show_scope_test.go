@@ -0,0 +1,170 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package core_test
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/madlambda/spells/assert"
+	. "github.com/terramate-io/terramate/e2etests/internal/runner"
+	"github.com/terramate-io/terramate/test/sandbox"
+)
+
+func TestDebugShowScope(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.NoGit(t, true)
+	s.BuildTree([]string{
+		`f:imports/common.tm.hcl:globals {
+		  team = "platform"
+		}`,
+		`f:terramate.tm.hcl:terramate {
+		  config {
+		    sensitive_globals = ["token"]
+		    run {
+		      env {
+		        TOKEN = global.token
+		      }
+		    }
+		  }
+		}
+
+		import {
+		  source = "/imports/common.tm.hcl"
+		}
+
+		globals {
+		  env   = "dev"
+		  token = "secret"
+		}`,
+		`f:stacks/globals.tm:globals {
+		  env = "prd"
+		}`,
+		`f:stacks/a/stack.tm:stack {}
+
+		generate_file "file.txt" {
+		  content = global.env
+		}`,
+	})
+
+	// the sandbox root.config.tm is empty.
+	wantText := `scope "/":
+	files:
+		/root.config.tm
+		/terramate.tm.hcl
+	imports:
+		import = "/imports/common.tm.hcl" (/terramate.tm.hcl:12,3-14,4)
+	globals:
+		global.env (/terramate.tm.hcl:17,5-18) [overridden]
+		global.team (/imports/common.tm.hcl:2,5-22)
+		global.token (/terramate.tm.hcl:18,5-21)
+	env:
+		env.TOKEN (/terramate.tm.hcl:6,11-31)
+	config:
+		terramate.config.sensitive_globals (/terramate.tm.hcl:3,7-36)
+
+scope "/stacks":
+	files:
+		/stacks/globals.tm
+	globals:
+		global.env (/stacks/globals.tm:2,5-16)
+
+scope "/stacks/a":
+	files:
+		/stacks/a/stack.tm
+	generate:
+		generate_file.file.txt (/stacks/a/stack.tm:3,3-5,4)
+`
+
+	tmcli := NewCLI(t, filepath.Join(s.RootDir(), "stacks/a"))
+	AssertRunResult(t, tmcli.Run("debug", "show", "scope"), RunExpected{
+		Stdout: wantText,
+	})
+
+	tmcli = NewCLI(t, s.RootDir())
+	AssertRunResult(t, tmcli.Run("debug", "show", "scope", "stacks/a"), RunExpected{
+		Stdout: wantText,
+	})
+
+	AssertRunResult(t, tmcli.Run("debug", "show", "scope", "--values", "stacks"), RunExpected{
+		Stdout: `scope "/":
+	files:
+		/root.config.tm
+		/terramate.tm.hcl
+	imports:
+		import = "/imports/common.tm.hcl" (/terramate.tm.hcl:12,3-14,4)
+	globals:
+		global.env = "dev" (/terramate.tm.hcl:17,5-18) [overridden]
+		global.team = "platform" (/imports/common.tm.hcl:2,5-22)
+		global.token = (sensitive) (/terramate.tm.hcl:18,5-21)
+	env:
+		env.TOKEN = (sensitive) (/terramate.tm.hcl:6,11-31)
+	config:
+		terramate.config.sensitive_globals = ["token"] (/terramate.tm.hcl:3,7-36)
+
+scope "/stacks":
+	files:
+		/stacks/globals.tm
+	globals:
+		global.env = "prd" (/stacks/globals.tm:2,5-16)
+`,
+	})
+
+	res := tmcli.Run("debug", "show", "scope", "--values", "--show-sensitive", "--format", "json", "stacks")
+	AssertRunResult(t, res, RunExpected{IgnoreStdout: true})
+
+	type element struct {
+		Name       string `json:"name"`
+		Value      string `json:"value"`
+		Origin     string `json:"origin"`
+		Overridden bool   `json:"overridden"`
+	}
+	type level struct {
+		Dir     string    `json:"dir"`
+		Files   []string  `json:"files"`
+		Imports []element `json:"imports"`
+		Globals []element `json:"globals"`
+		Env     []element `json:"env"`
+	}
+	var got []level
+	assert.NoError(t, json.Unmarshal([]byte(res.Stdout), &got))
+
+	want := []level{
+		{
+			Dir:   "/",
+			Files: []string{"/root.config.tm", "/terramate.tm.hcl"},
+			Imports: []element{
+				{Name: "import", Value: `"/imports/common.tm.hcl"`, Origin: "/terramate.tm.hcl:12,3-14,4"},
+			},
+			Globals: []element{
+				{Name: "global.env", Value: `"dev"`, Origin: "/terramate.tm.hcl:17,5-18", Overridden: true},
+				{Name: "global.team", Value: `"platform"`, Origin: "/imports/common.tm.hcl:2,5-22"},
+				{Name: "global.token", Value: `"secret"`, Origin: "/terramate.tm.hcl:18,5-21"},
+			},
+			Env: []element{
+				{Name: "env.TOKEN", Value: "global.token", Origin: "/terramate.tm.hcl:6,11-31"},
+			},
+		},
+		{
+			Dir:     "/stacks",
+			Files:   []string{"/stacks/globals.tm"},
+			Imports: []element{},
+			Globals: []element{
+				{Name: "global.env", Value: `"prd"`, Origin: "/stacks/globals.tm:2,5-16"},
+			},
+			Env: []element{},
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("-(want) +(got):\n%s", diff)
+	}
+
+	AssertRunResult(t, tmcli.Run("debug", "show", "scope", "not-found"), RunExpected{
+		Status:      1,
+		StderrRegex: `directory /not-found not found in the project`,
+	})
+}
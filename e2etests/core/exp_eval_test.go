@@ -6,6 +6,7 @@ package core_test
 import (
 	"fmt"
 	"path/filepath"
+	"regexp"
 	"testing"
 
 	. "github.com/terramate-io/terramate/e2etests/internal/runner"
@@ -452,4 +453,113 @@ func TestGetConfigValue(t *testing.T) {
 	}
 }
 
+func TestExpEvalSensitiveGlobals(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.NoGit(t, true)
+	s.RootEntry().CreateFile("terramate.tm", Terramate(
+		Config(
+			Expr("sensitive_globals", `["db_password"]`),
+		),
+	).String())
+	s.RootEntry().CreateFile("globals.tm", Globals(
+		Str("db_password", "hunter2"),
+		Str("db_user", "admin"),
+	).String())
+
+	ts := NewCLI(t, s.RootDir())
+	AssertRunResult(t, ts.Run("experimental", "eval", "global.db_password"), RunExpected{
+		Stdout: addnl(`(sensitive)`),
+	})
+	AssertRunResult(t, ts.Run("experimental", "eval", "global.db_user"), RunExpected{
+		Stdout: addnl(`admin`),
+	})
+	AssertRunResult(t, ts.Run("experimental", "eval", `"${global.db_user}:${global.db_password}"`), RunExpected{
+		Stdout: addnl(`(sensitive)`),
+	})
+	AssertRunResult(t, ts.Run("experimental", "partial-eval", `"${global.db_user}:${global.db_password}"`), RunExpected{
+		Stdout: addnl(`(sensitive)`),
+	})
+	AssertRunResult(t, ts.Run("experimental", "get-config-value", "global.db_password"), RunExpected{
+		Stdout: addnl(`(sensitive)`),
+	})
+	AssertRunResult(t, ts.Run("experimental", "eval", "--show-sensitive", "global.db_password"), RunExpected{
+		Stdout: addnl(`hunter2`),
+	})
+}
+
+func TestExpEvalWithStackFlag(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.NoGit(t, true)
+	s.BuildTree([]string{
+		"s:stacks/a:id=stack-a",
+		"s:stacks/b",
+		"d:stacks/a/modules",
+		"d:other",
+	})
+	s.RootEntry().CreateFile("globals.tm", Globals(
+		Str("env", "prod"),
+		Expr("name", `"${global.env}-${terramate.stack.name}"`),
+	).String())
+	s.RootEntry().CreateFile("stacks/a/globals.tm", Globals(
+		Str("env", "dev"),
+	).String())
+
+	cmds := [][]string{
+		{"experimental", "eval", "global.name"},
+		{"experimental", "eval", "--as-json", "terramate.stack"},
+		{"experimental", "partial-eval", `"${global.name}:${terramate.stack.path.relative}"`},
+		{"experimental", "get-config-value", "terramate.stack.path.absolute"},
+		{"experimental", "eval", "--global", `env="test"`, "global.name"},
+	}
+
+	withStack := func(args []string, stack string) []string {
+		return append([]string{args[0], args[1], "--stack", stack}, args[2:]...)
+	}
+
+	for _, args := range cmds {
+		for _, stack := range []string{"stacks/a", "stacks/b"} {
+			inStack := NewCLI(t, filepath.Join(s.RootDir(), stack))
+			want := inStack.Run(args...)
+			AssertRunResult(t, want, RunExpected{IgnoreStdout: true})
+
+			fromRoot := NewCLI(t, s.RootDir())
+			AssertRunResult(t, fromRoot.Run(withStack(args, stack)...), RunExpected{
+				Stdout: want.Stdout,
+			})
+			AssertRunResult(t, fromRoot.Run(withStack(args, "/"+stack)...), RunExpected{
+				Stdout: want.Stdout,
+			})
+
+			fromOther := NewCLI(t, filepath.Join(s.RootDir(), "other"))
+			AssertRunResult(t, fromOther.Run(withStack(args, "../"+stack)...), RunExpected{
+				Stdout: want.Stdout,
+			})
+		}
+	}
+
+	tm := NewCLI(t, s.RootDir())
+	AssertRunResult(t, tm.Run("experimental", "eval", "--stack", "stacks/a", "global.name"), RunExpected{
+		Stdout: addnl("dev-a"),
+	})
+	AssertRunResult(t, tm.Run("experimental", "eval", "--stack", "stacks/a/modules", "global.name"), RunExpected{
+		Status:      1,
+		StderrRegex: regexp.QuoteMeta("did you mean /stacks/a?"),
+	})
+	AssertRunResult(t, tm.Run("experimental", "get-config-value", "--stack", "/stacks", "global.env"), RunExpected{
+		Status:      1,
+		StderrRegex: regexp.QuoteMeta("did you mean /stacks/a?"),
+	})
+	AssertRunResult(t, tm.Run("experimental", "partial-eval", "--stack", "other", "global.env"), RunExpected{
+		Status:        1,
+		StderrRegex:   "/other is not a stack",
+		NoStderrRegex: "did you mean",
+	})
+	AssertRunResult(t, tm.Run("experimental", "eval", "--stack", "../outside", "global.env"), RunExpected{
+		Status:      1,
+		StderrRegex: "outside the project",
+	})
+}
+
 func addnl(s string) string { return s + "\n" }
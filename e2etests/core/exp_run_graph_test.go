@@ -4,8 +4,13 @@
 package core_test
 
 import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
 	"testing"
 
+	"github.com/madlambda/spells/assert"
 	. "github.com/terramate-io/terramate/e2etests/internal/runner"
 	"github.com/terramate-io/terramate/test/sandbox"
 )
@@ -162,6 +167,10 @@ func TestOrderGraphAfter(t *testing.T) {
 					n1->n1[color="red"];
 				}`,
 				FlattenStdout: true,
+				StderrRegexes: []string{
+					"cycles detected in the execution order",
+					"/stack-a -> /stack-a",
+				},
 			},
 		},
 		{
@@ -178,6 +187,10 @@ func TestOrderGraphAfter(t *testing.T) {
 					n2->n1;
 				}`,
 				FlattenStdout: true,
+				StderrRegexes: []string{
+					"cycles detected in the execution order",
+					"/stack-a -> /stack-b -> /stack-a",
+				},
 			},
 		},
 		{
@@ -199,6 +212,11 @@ func TestOrderGraphAfter(t *testing.T) {
 					n3->n1;
 				}`,
 				FlattenStdout: true,
+				StderrRegexes: []string{
+					"cycles detected in the execution order",
+					"/stack-a -> /stack-b -> /stack-a",
+					"/stack-a -> /stack-c -> /stack-a",
+				},
 			},
 		},
 		{
@@ -325,7 +343,124 @@ func TestOrderGraphAfter(t *testing.T) {
 			s := sandbox.NoGit(t, true)
 			s.BuildTree(tc.layout)
 			cli := NewCLI(t, s.RootDir())
-			AssertRunResult(t, cli.StacksRunGraph(), tc.want)
+			dotRes := cli.StacksRunGraph()
+			AssertRunResult(t, dotRes, tc.want)
+
+			// the mermaid output has the same edges.
+			mermaidRes := cli.StacksRunGraph("--format", "mermaid")
+			AssertRunResult(t, mermaidRes, RunExpected{
+				IgnoreStdout:  true,
+				StderrRegexes: tc.want.StderrRegexes,
+			})
+			dotEdges, dotCycleEdges := parseDotEdges(t, dotRes.Stdout)
+			mermaidEdges, mermaidCycleEdges := parseMermaidEdges(t, mermaidRes.Stdout)
+			assert.EqualStrings(t, strings.Join(dotEdges, "\n"), strings.Join(mermaidEdges, "\n"))
+			assert.EqualStrings(t, strings.Join(dotCycleEdges, "\n"), strings.Join(mermaidCycleEdges, "\n"))
 		})
 	}
 }
+
+func TestRunGraphMermaid(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.NoGit(t, true)
+	s.BuildTree([]string{
+		`s:stack-a:after=["/stack-b", "/stack-c"]`,
+		`s:stack-b:after=["/stack-c"]`,
+		`s:stack-c`,
+		`f:stack-d/stack.tm:stack {
+		  name  = "deploy \"d\""
+		  after = ["/stack-e"]
+		}`,
+		`s:stack-e:after=["/stack-d"]`,
+		`s:stack-f`,
+	})
+	cli := NewCLI(t, s.RootDir())
+
+	res := cli.StacksRunGraph("--format", "mermaid")
+	AssertRunResult(t, res, RunExpected{
+		IgnoreStdout: true,
+		StderrRegexes: []string{
+			"cycles detected in the execution order",
+			"/stack-d -> /stack-e -> /stack-d",
+		},
+	})
+	assert.EqualStrings(t, "graph TD", strings.Split(res.Stdout, "\n")[0])
+
+	edges, cycleEdges := parseMermaidEdges(t, res.Stdout)
+	assert.EqualStrings(t, strings.Join([]string{
+		`deploy "d" -> stack-e`,
+		`stack-b -> stack-a`,
+		`stack-c -> stack-a`,
+		`stack-c -> stack-b`,
+		`stack-e -> deploy "d"`,
+	}, "\n"), strings.Join(edges, "\n"))
+	assert.EqualStrings(t, `deploy "d" -> stack-e`, strings.Join(cycleEdges, "\n"))
+	assert.IsTrue(t, strings.Contains(res.Stdout, `["stack-f"]`), "missing node stack-f")
+
+	res = cli.StacksRunGraph("--format", "mermaid", "--label", "stack.dir")
+	AssertRunResult(t, res, RunExpected{IgnoreStdout: true, IgnoreStderr: true})
+	edges, _ = parseMermaidEdges(t, res.Stdout)
+	assert.EqualStrings(t, strings.Join([]string{
+		`/stack-b -> /stack-a`,
+		`/stack-c -> /stack-a`,
+		`/stack-c -> /stack-b`,
+		`/stack-d -> /stack-e`,
+		`/stack-e -> /stack-d`,
+	}, "\n"), strings.Join(edges, "\n"))
+}
+
+// parseDotEdges returns the sorted edges of the dot graph, as "from -> to"
+// labels, and the edges highlighted as part of cycles.
+func parseDotEdges(t *testing.T, out string) (edges, cycleEdges []string) {
+	t.Helper()
+	nodeRe := regexp.MustCompile(`(n\d+)\[label="((?:[^"\\]|\\.)*)"\]`)
+	edgeRe := regexp.MustCompile(`(n\d+)->(n\d+)(\[color="red"\])?`)
+	labels := map[string]string{}
+	for _, m := range nodeRe.FindAllStringSubmatch(out, -1) {
+		labels[m[1]] = strings.ReplaceAll(m[2], `\"`, `"`)
+	}
+	for _, m := range edgeRe.FindAllStringSubmatch(out, -1) {
+		edge := labels[m[1]] + " -> " + labels[m[2]]
+		edges = append(edges, edge)
+		if m[3] != "" {
+			cycleEdges = append(cycleEdges, edge)
+		}
+	}
+	sort.Strings(edges)
+	sort.Strings(cycleEdges)
+	return edges, cycleEdges
+}
+
+// parseMermaidEdges returns the sorted edges of the mermaid graph, as
+// "from -> to" labels, and the edges highlighted as part of cycles.
+func parseMermaidEdges(t *testing.T, out string) (edges, cycleEdges []string) {
+	t.Helper()
+	nodeRe := regexp.MustCompile(`^(n\d+)\["(.*)"\]$`)
+	edgeRe := regexp.MustCompile(`^(n\d+) --> (n\d+)$`)
+	styleRe := regexp.MustCompile(`^linkStyle ([\d,]+) stroke:red$`)
+	labels := map[string]string{}
+	var ordered []string
+	var cycleIndexes []string
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n")[1:] {
+		line = strings.TrimSpace(line)
+		if m := nodeRe.FindStringSubmatch(line); m != nil {
+			labels[m[1]] = strings.ReplaceAll(m[2], "#quot;", `"`)
+		} else if m := edgeRe.FindStringSubmatch(line); m != nil {
+			ordered = append(ordered, labels[m[1]]+" -> "+labels[m[2]])
+		} else if m := styleRe.FindStringSubmatch(line); m != nil {
+			cycleIndexes = strings.Split(m[1], ",")
+		} else {
+			t.Fatalf("unexpected mermaid line: %q", line)
+		}
+	}
+	for _, index := range cycleIndexes {
+		i, err := strconv.Atoi(index)
+		assert.NoError(t, err)
+		cycleEdges = append(cycleEdges, ordered[i])
+	}
+	edges = append(edges, ordered...)
+	sort.Strings(edges)
+	sort.Strings(cycleEdges)
+	return edges, cycleEdges
+}
@@ -0,0 +1,136 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package core_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/madlambda/spells/assert"
+	. "github.com/terramate-io/terramate/e2etests/internal/runner"
+	"github.com/terramate-io/terramate/modvendor"
+	"github.com/terramate-io/terramate/project"
+	"github.com/terramate-io/terramate/test"
+	. "github.com/terramate-io/terramate/test/hclwrite/hclutils"
+	"github.com/terramate-io/terramate/test/sandbox"
+)
+
+func TestVendorUpdate(t *testing.T) {
+	t.Parallel()
+
+	const filename = "test.txt"
+
+	repoSandbox := sandbox.New(t)
+	repoSandbox.RootEntry().CreateFile(filename, "v1")
+	repoGit := repoSandbox.Git()
+	repoGit.CommitAll("add file")
+	gitSource := newLocalSource(repoSandbox.RootDir())
+	modsrc := test.ParseSource(t, gitSource+"?ref=main")
+
+	s := sandbox.NoGit(t, true)
+	s.CreateStack("stack")
+	s.RootEntry().CreateFile("config.tm", Doc(
+		GenerateFile(
+			Labels("file.txt"),
+			Expr("content", fmt.Sprintf(`tm_vendor("%s?ref=main")`, gitSource)),
+		),
+	).String())
+
+	vendorDir := project.NewPath("/modules")
+	clonedir := modvendor.AbsVendorDir(s.RootDir(), vendorDir, modsrc)
+
+	tmcli := NewCLI(t, s.RootDir())
+	AssertRunResult(t, tmcli.Run("experimental", "vendor", "update"), RunExpected{
+		StdoutRegexes: []string{
+			`\[\+\] ` + modsrc.URL,
+			"1 updated, 0 up-to-date, 0 failed",
+		},
+	})
+	assert.EqualStrings(t, "v1", string(test.ReadFile(t, clonedir, filename)))
+
+	AssertRunResult(t, tmcli.Run("experimental", "vendor", "update"), RunExpected{
+		StdoutRegexes: []string{
+			`\[=\] ` + modsrc.URL,
+			"0 updated, 1 up-to-date, 0 failed",
+		},
+	})
+
+	repoSandbox.RootEntry().CreateFile(filename, "v2")
+	repoGit.CommitAll("update file")
+
+	stale := filepath.Join(s.RootDir(), "modules", "stale", "v1")
+	test.WriteFile(t, stale, "main.tf", "")
+
+	AssertRunResult(t, tmcli.Run("experimental", "vendor", "update", "--prune", "-j", "2"), RunExpected{
+		StdoutRegexes: []string{
+			`\[\+\] ` + modsrc.URL,
+			`\[-\] /modules/stale`,
+			"1 updated, 0 up-to-date, 0 failed",
+		},
+	})
+	assert.EqualStrings(t, "v2", string(test.ReadFile(t, clonedir, filename)))
+
+	_, err := os.Stat(stale)
+	assert.IsTrue(t, os.IsNotExist(err), "stale vendored dir must be pruned")
+
+	// a module that can't be downloaded fails the command but the
+	// previously vendored module is kept.
+	assert.NoError(t, os.RemoveAll(repoSandbox.RootDir()))
+
+	AssertRunResult(t, tmcli.Run("experimental", "vendor", "update"), RunExpected{
+		Status: 1,
+		StdoutRegexes: []string{
+			`\[!\] ` + modsrc.URL,
+			"0 updated, 0 up-to-date, 1 failed",
+		},
+	})
+	assert.EqualStrings(t, "v2", string(test.ReadFile(t, clonedir, filename)))
+}
+
+func TestVendorUpdateManifestAndRootContext(t *testing.T) {
+	t.Parallel()
+
+	const filename = "test.txt"
+
+	manifestRepo := sandbox.New(t)
+	manifestRepo.RootEntry().CreateFile(filename, "manifest")
+	manifestRepo.Git().CommitAll("add file")
+	_, err := manifestRepo.Git().Unwrap().Exec("tag", "v1.0.0")
+	assert.NoError(t, err)
+	manifestSrc := test.ParseSource(t, newLocalSource(manifestRepo.RootDir())+"?ref=v1.0.0")
+
+	rootRepo := sandbox.New(t)
+	rootRepo.RootEntry().CreateFile(filename, "root")
+	rootRepo.Git().CommitAll("add file")
+	rootSrc := test.ParseSource(t, newLocalSource(rootRepo.RootDir())+"?ref=main")
+
+	s := sandbox.NoGit(t, true)
+	s.CreateStack("stack")
+	s.RootEntry().CreateFile("config.tm", Doc(
+		GenerateFile(
+			Labels("/docs/modules.txt"),
+			Expr("context", "root"),
+			Expr("content", fmt.Sprintf(`tm_vendor("%s")`, rootSrc.Raw)),
+		),
+	).String())
+
+	vendorDir := project.NewPath("/modules")
+	assert.NoError(t, modvendor.RecordVersion(s.RootDir(), vendorDir, manifestSrc, "~> 1.0"))
+
+	tmcli := NewCLI(t, s.RootDir())
+	AssertRunResult(t, tmcli.Run("experimental", "vendor", "update", "--prune"), RunExpected{
+		StdoutRegexes: []string{
+			`\[\+\] ` + manifestSrc.URL,
+			`\[\+\] ` + rootSrc.URL,
+			"2 updated, 0 up-to-date, 0 failed",
+		},
+	})
+
+	manifestDir := modvendor.AbsVendorDir(s.RootDir(), vendorDir, manifestSrc)
+	rootDir := modvendor.AbsVendorDir(s.RootDir(), vendorDir, rootSrc)
+	assert.EqualStrings(t, "manifest", string(test.ReadFile(t, manifestDir, filename)))
+	assert.EqualStrings(t, "root", string(test.ReadFile(t, rootDir, filename)))
+}
@@ -154,6 +154,91 @@ block {
 		assertWantedFilesContents(t, unformattedTmFile, unformattedTmGenFile)
 	})
 
+	t.Run("checking with --exclude ignores excluded dirs and files", func(t *testing.T) {
+		writeUnformattedFiles()
+		AssertRunResult(t, cli.Run("fmt", "--check",
+			"--exclude", "another-stacks",
+			"--exclude", "stacks/stack-*",
+			"--exclude", "**.tmgen",
+		), RunExpected{
+			Status: 1,
+			Stdout: filesListOutput([]string{
+				"globals.tm",
+				"stacks/globals.tm",
+			}, nil),
+		})
+		assertWantedFilesContents(t, unformattedTmFile, unformattedTmGenFile)
+	})
+
+	t.Run("checking succeeds when all unformatted files are excluded", func(t *testing.T) {
+		writeUnformattedFiles()
+		AssertRunResult(t, cli.Run("fmt", "--check", "--exclude", "**"), RunExpected{})
+		assertWantedFilesContents(t, unformattedTmFile, unformattedTmGenFile)
+	})
+
+	t.Run("--exclude is relative to the working directory", func(t *testing.T) {
+		writeUnformattedFiles()
+		subdir := filepath.Join(s.RootDir(), "another-stacks")
+		cli := NewCLI(t, subdir)
+		AssertRunResult(t, cli.Run("fmt", "--check", "--exclude", "stack-1"), RunExpected{
+			Status: 1,
+			Stdout: filesListOutput([]string{
+				"globals.tm.hcl",
+				"stack-2/globals.tm.hcl",
+			}, nil),
+		})
+	})
+
+	t.Run("checking with --no-recursive only checks the working directory", func(t *testing.T) {
+		writeUnformattedFiles()
+		AssertRunResult(t, cli.Run("fmt", "--check", "--no-recursive"), RunExpected{
+			Status: 1,
+			Stdout: filesListOutput([]string{"globals.tm"}, []string{"test.hcl.tmgen"}),
+		})
+
+		stacks := filepath.Join(s.RootDir(), "stacks")
+		cli := NewCLI(t, stacks)
+		AssertRunResult(t, cli.Run("fmt", "--check", "--recursive=false"), RunExpected{
+			Status: 1,
+			Stdout: filesListOutput([]string{"globals.tm"}, []string{"test.hcl.tmgen"}),
+		})
+		AssertRunResult(t, cli.Run("fmt", "--check", "--no-recursive", "--exclude", "*.tmgen"), RunExpected{
+			Status: 1,
+			Stdout: filesListOutput([]string{"globals.tm"}, nil),
+		})
+		assertWantedFilesContents(t, unformattedTmFile, unformattedTmGenFile)
+	})
+
+	t.Run("--no-recursive with --detailed-exit-code only formats the working directory", func(t *testing.T) {
+		writeUnformattedFiles()
+		anotherStacks := filepath.Join(s.RootDir(), "another-stacks")
+		cli := NewCLI(t, anotherStacks)
+		AssertRunResult(t, cli.Run("fmt", "--no-recursive", "--detailed-exit-code"), RunExpected{
+			Status: 2,
+			Stdout: filesListOutput([]string{"globals.tm.hcl"}, nil),
+		})
+		assertFileContents(t, "another-stacks/globals.tm.hcl", formattedTmContent)
+		assertFileContents(t, "another-stacks/stack-1/globals.tm.hcl", unformattedTmFile)
+		assertFileContents(t, "another-stacks/stack-2/globals.tm.hcl", unformattedTmFile)
+
+		AssertRunResult(t, cli.Run("fmt", "--no-recursive", "--detailed-exit-code"), RunExpected{})
+		AssertRunResult(t, cli.Run("fmt", "--no-recursive", "--check"), RunExpected{})
+	})
+
+	t.Run("--no-recursive cannot be used with files", func(t *testing.T) {
+		AssertRunResult(t, cli.Run("fmt", "--no-recursive", "globals.tm"), RunExpected{
+			Status:      1,
+			StderrRegex: "--no-recursive cannot be used when formatting explicit files",
+		})
+	})
+
+	t.Run("--exclude fails with invalid pattern", func(t *testing.T) {
+		AssertRunResult(t, cli.Run("fmt", "--check", "--exclude", "[invalid"), RunExpected{
+			Status:      1,
+			StderrRegex: string(fmt.ErrInvalidExclude),
+		})
+	})
+
 	t.Run("update unformatted files in place", func(t *testing.T) {
 		writeUnformattedFiles()
 		AssertRunResult(t, cli.Run("fmt"), RunExpected{
@@ -243,13 +328,14 @@ func TestFmtFiles(t *testing.T) {
 		res    RunExpected
 	}
 	type testcase struct {
-		name     string
-		layout   []string
-		files    []string
-		check    bool
-		stdin    string
-		absPaths bool
-		want     want
+		name          string
+		layout        []string
+		files         []string
+		check         bool
+		stdin         string
+		stdinFilename string
+		absPaths      bool
+		want          want
 	}
 
 	for _, tc := range []testcase{
@@ -420,6 +506,55 @@ name="name"
 				},
 			},
 		},
+		{
+			name:          "format stdin with --check and --stdin-filename",
+			files:         []string{"-"},
+			stdinFilename: "stacks/stack.tm",
+			stdin: `stack {
+name="name"
+  description = "desc"
+			}`,
+			check: true,
+			want: want{
+				res: RunExpected{
+					Stdout: nljoin("stacks/stack.tm"),
+					Status: 1,
+				},
+			},
+		},
+		{
+			name:          "format formatted stdin with --check and --stdin-filename",
+			files:         []string{"-"},
+			stdinFilename: "stacks/stack.tm",
+			stdin: `stack {
+  name = "name"
+}
+`,
+			check: true,
+		},
+		{
+			name:          "format invalid stdin with --stdin-filename",
+			files:         []string{"-"},
+			stdinFilename: "stacks/stack.tm",
+			stdin:         `stack {`,
+			want: want{
+				res: RunExpected{
+					StderrRegex: `stacks/stack.tm:1`,
+					Status:      1,
+				},
+			},
+		},
+		{
+			name:          "--stdin-filename without stdin",
+			files:         []string{"example.tm"},
+			stdinFilename: "example.tm",
+			want: want{
+				res: RunExpected{
+					StderrRegex: "--stdin-filename requires formatting from stdin",
+					Status:      1,
+				},
+			},
+		},
 	} {
 		tc := tc
 		t.Run(tc.name, func(t *testing.T) {
@@ -437,6 +572,9 @@ name="name"
 			if tc.check {
 				args = append(args, "--check")
 			}
+			if tc.stdinFilename != "" {
+				args = append(args, "--stdin-filename", tc.stdinFilename)
+			}
 			args = append(args, files...)
 			var result RunResult
 			if len(files) == 1 && files[0] == "-" {
@@ -522,7 +522,7 @@ func TestRemoteSafeguardDefaults(t *testing.T) {
 	// List still works
 	AssertRun(t, cli.ListChangedStacks())
 
-	// Run fails because of safeguard
+	// Run fails because of safeguard, the only remote is the default remote.
 	AssertRunResult(t,
 		cli.Run(
 			"run",
@@ -533,7 +533,7 @@ func TestRemoteSafeguardDefaults(t *testing.T) {
 		),
 		RunExpected{
 			Status:      1,
-			StderrRegex: `'origin' does not appear to be a git repository`,
+			StderrRegex: `'anyurl' does not appear to be a git repository`,
 		},
 	)
 }
@@ -0,0 +1,121 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package core_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/madlambda/spells/assert"
+	. "github.com/terramate-io/terramate/e2etests/internal/runner"
+	"github.com/terramate-io/terramate/test/sandbox"
+)
+
+func TestGenerateLabelRenamedOnlyByCase(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.New(t)
+	s.BuildTree([]string{
+		"s:stack",
+		`f:stack/generate.tm:generate_hcl "main.hcl" {
+		  content {
+		    a = 1
+		  }
+		}`,
+	})
+	tmcli := NewCLI(t, s.RootDir())
+	AssertRunResult(t, tmcli.Run("generate"), RunExpected{IgnoreStdout: true})
+	s.Git().CommitAll("generate code")
+
+	s.RootEntry().CreateFile("stack/generate.tm", `generate_hcl "Main.hcl" {
+	  content {
+	    a = 1
+	  }
+	}`)
+	s.Git().CommitAll("rename label")
+
+	AssertRunResult(t, tmcli.Run("generate"), RunExpected{
+		Status:      1,
+		StdoutRegex: `file "Main.hcl" differs only by case from the existing file "main.hcl"`,
+	})
+	assertDirEntries(t, filepath.Join(s.RootDir(), "stack"), "generate.tm", "main.hcl", "stack.tm.hcl")
+
+	AssertRunResult(t, tmcli.Run("generate", "--allow-delete"), RunExpected{
+		StdoutRegex: `\[\+\] Main.hcl\n\t\[-\] main.hcl\n`,
+	})
+	assertDirEntries(t, filepath.Join(s.RootDir(), "stack"), "Main.hcl", "generate.tm", "stack.tm.hcl")
+
+	s.Git().CommitAll("generate code")
+	AssertRunResult(t, tmcli.Run("generate"), RunExpected{
+		Stdout: "Nothing to do, generated code is up to date\n",
+	})
+}
+
+func TestGenerateLabelsDifferingOnlyByCaseConflict(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.NoGit(t, true)
+	s.BuildTree([]string{
+		"s:stack",
+		`f:stack/generate.tm:generate_hcl "main.hcl" {
+		  content {
+		    a = 1
+		  }
+		}
+
+		generate_hcl "MAIN.hcl" {
+		  content {
+		    a = 2
+		  }
+		}`,
+	})
+	tmcli := NewCLI(t, s.RootDir())
+	AssertRunResult(t, tmcli.Run("generate"), RunExpected{
+		Status:      1,
+		StdoutRegex: `generate files "main.hcl" and "MAIN.hcl" differing only by case`,
+	})
+	assertDirEntries(t, filepath.Join(s.RootDir(), "stack"), "generate.tm", "stack.tm.hcl")
+}
+
+func TestStackReferenceDifferingOnlyByCase(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.NoGit(t, true)
+	s.BuildTree([]string{
+		"s:stacks/app",
+		`f:stacks/db/stack.tm:stack {
+		  before = ["/Stacks/App"]
+		}`,
+	})
+	tmcli := NewCLI(t, s.RootDir())
+	AssertRunResult(t, tmcli.Run("list"), RunExpected{
+		Status:      1,
+		StderrRegex: `stack /stacks/db references /Stacks/App in the "before" attribute, which differs only by case from /stacks/app`,
+	})
+
+	s.RootEntry().CreateFile("terramate.tm", `terramate {
+	  config {
+	    normalize_path_case = true
+	  }
+	}`)
+	AssertRunResult(t, tmcli.Run("list", "--run-order"), RunExpected{
+		Stdout:      nljoin("stacks/db", "stacks/app"),
+		StderrRegex: `normalized to /stacks/app`,
+	})
+}
+
+func assertDirEntries(t *testing.T, dir string, want ...string) {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	var got []string
+	for _, entry := range entries {
+		got = append(got, entry.Name())
+	}
+	assert.EqualInts(t, len(want), len(got), "entries: %v", got)
+	for i, name := range want {
+		assert.EqualStrings(t, name, got[i])
+	}
+}
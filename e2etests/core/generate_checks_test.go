@@ -0,0 +1,100 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package core_test
+
+import (
+	"testing"
+
+	. "github.com/terramate-io/terramate/e2etests/internal/runner"
+	"github.com/terramate-io/terramate/test/sandbox"
+)
+
+func TestE2EGenerateChecks(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.NoGit(t, true)
+	s.BuildTree([]string{
+		`f:checks.tm:check "backend" {
+  assertion = tm_contains(generated.files, "backend.tf")
+  message   = "${terramate.stack.path.absolute} must generate backend.tf"
+}
+
+check "tags" {
+  assertion = tm_length(tm_try(global.tags, [])) > 0
+  message   = "global.tags must be set"
+  severity  = "warn"
+}`,
+		`f:stacks/backend.tm:generate_file "backend.tf" {
+  condition = tm_try(global.backend, true)
+  content   = "backend"
+}`,
+		"s:stacks/ok",
+		`f:stacks/ok/globals.tm:globals {
+  tags = ["ok"]
+}`,
+		"s:stacks/missing",
+		`f:stacks/missing/globals.tm:globals {
+  backend = false
+  tags    = ["missing"]
+}`,
+		"s:stacks/legacy",
+		`f:stacks/legacy/checks.tm:globals {
+  backend = false
+}
+
+check "backend" {
+  assertion = tm_contains(generated.files, "backend.tf")
+  message   = "legacy stacks should migrate to backend.tf"
+  severity  = "warn"
+}
+
+check "tags" {
+  assertion = true
+  message   = "legacy stacks have no tags"
+}`,
+	})
+
+	tmcli := NewCLI(t, s.RootDir())
+	AssertRunResult(t, tmcli.Run("generate"), RunExpected{
+		Status: 1,
+		Stdout: `Code generation report
+
+Successes:
+
+- /stacks/legacy (context=stack)
+	warning: /stacks/legacy/checks.tm:6,15-57: check "backend": legacy stacks should migrate to backend.tf
+
+- /stacks/ok (context=stack)
+	[+] backend.tf
+
+Failures:
+
+- /stacks/missing (context=stack)
+	error: check failed: /checks.tm:2,15-57: check "backend": /stacks/missing must generate backend.tf
+
+Hint: '+', '~' and '-' mean the file was created, changed and deleted, respectively.
+`,
+	})
+
+	s.DirEntry("stacks/missing").CreateFile("checks.tm", `check "backend" {
+  assertion = false
+  message   = "backend.tf is managed elsewhere"
+  severity  = "warn"
+}`)
+
+	AssertRunResult(t, tmcli.Run("generate"), RunExpected{
+		Stdout: `Code generation report
+
+Successes:
+
+- /stacks/legacy (context=stack)
+	warning: /stacks/legacy/checks.tm:6,15-57: check "backend": legacy stacks should migrate to backend.tf
+
+- /stacks/missing (context=stack)
+	warning: /stacks/missing/checks.tm:2,15-20: check "backend": backend.tf is managed elsewhere
+
+Hint: '+', '~' and '-' mean the file was created, changed and deleted, respectively.
+`,
+	})
+}
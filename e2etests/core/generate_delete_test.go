@@ -0,0 +1,127 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package core_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/madlambda/spells/assert"
+	"github.com/terramate-io/terramate/cmd/terramate/cli"
+	. "github.com/terramate-io/terramate/e2etests/internal/runner"
+	"github.com/terramate-io/terramate/test/sandbox"
+)
+
+func TestGenerateDeletionSafeguard(t *testing.T) {
+	t.Parallel()
+
+	const generateBlock = `f:stack/generate.tm:generate_hcl "file.hcl" {
+	  content {
+	    a = 1
+	  }
+	}`
+
+	setup := func(t *testing.T, layout ...string) (sandbox.S, CLI) {
+		s := sandbox.New(t)
+		s.BuildTree(append([]string{"s:stack", generateBlock}, layout...))
+		tmcli := NewCLI(t, s.RootDir())
+		AssertRunResult(t, tmcli.Run("generate"), RunExpected{
+			IgnoreStdout: true,
+		})
+		s.Git().CommitAll("generate code")
+
+		// the block label was renamed.
+		s.RootEntry().CreateFile("stack/generate.tm", `generate_hcl "renamed.hcl" {
+		  content {
+		    a = 1
+		  }
+		}`)
+		s.Git().CommitAll("rename label")
+		return s, tmcli
+	}
+
+	fileExists := func(t *testing.T, s sandbox.S, name string) bool {
+		t.Helper()
+		_, err := os.Stat(filepath.Join(s.RootDir(), name))
+		if err != nil && !os.IsNotExist(err) {
+			t.Fatal(err)
+		}
+		return err == nil
+	}
+
+	t.Run("deletion is pending by default", func(t *testing.T) {
+		t.Parallel()
+
+		s, tmcli := setup(t)
+		AssertRunResult(t, tmcli.Run("generate", "--detailed-exit-code"), RunExpected{
+			Stdout: `Code generation report
+
+Successes:
+
+- /stack (context=stack)
+	[+] renamed.hcl
+	[!] file.hcl (pending deletion)
+
+Hint: '+', '~' and '-' mean the file was created, changed and deleted, respectively.
+Hint: '!' means the file is not generated anymore but it was not deleted.
+Run with --allow-delete or set terramate.config.generate.allow_deletion = true to delete it.
+`,
+			Status: 2,
+		})
+		assert.IsTrue(t, fileExists(t, s, "stack/file.hcl"), "file must not be deleted")
+
+		s.Git().CommitAll("generate code")
+
+		// the pending deletion is still reported and treated as outdated code.
+		AssertRunResult(t, tmcli.Run("generate", "--detailed-exit-code"), RunExpected{
+			StdoutRegex: `\[!\] file.hcl \(pending deletion\)`,
+			Status:      2,
+		})
+		AssertRunResult(t, tmcli.Run("run", "--quiet", "--", HelperPath, "true"), RunExpected{
+			StderrRegex: string(cli.ErrOutdatedGenCodeDetected),
+			Status:      1,
+		})
+	})
+
+	t.Run("deletion allowed by flag", func(t *testing.T) {
+		t.Parallel()
+
+		s, tmcli := setup(t)
+		AssertRunResult(t, tmcli.Run("generate", "--allow-delete"), RunExpected{
+			Stdout: `Code generation report
+
+Successes:
+
+- /stack (context=stack)
+	[+] renamed.hcl
+	[-] file.hcl
+
+Hint: '+', '~' and '-' mean the file was created, changed and deleted, respectively.
+`,
+		})
+		assert.IsTrue(t, !fileExists(t, s, "stack/file.hcl"), "file must be deleted")
+
+		s.Git().CommitAll("generate code")
+		AssertRunResult(t, tmcli.Run("run", "--quiet", "--", HelperPath, "echo", "ok"), RunExpected{
+			Stdout: nljoin("ok"),
+		})
+	})
+
+	t.Run("deletion allowed by config", func(t *testing.T) {
+		t.Parallel()
+
+		s, tmcli := setup(t, `f:terramate.tm:terramate {
+		  config {
+		    generate {
+		      allow_deletion = true
+		    }
+		  }
+		}`)
+		AssertRunResult(t, tmcli.Run("generate"), RunExpected{
+			StdoutRegex: `\[-\] file.hcl`,
+		})
+		assert.IsTrue(t, !fileExists(t, s, "stack/file.hcl"), "file must be deleted")
+	})
+}
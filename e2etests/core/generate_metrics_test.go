@@ -0,0 +1,47 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package core_test
+
+import (
+	"testing"
+
+	. "github.com/terramate-io/terramate/e2etests/internal/runner"
+	"github.com/terramate-io/terramate/test/sandbox"
+)
+
+func TestGenerateMetrics(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.NoGit(t, true)
+	s.BuildTree([]string{
+		"s:stack-a",
+		"s:stack-b",
+		`f:generate.tm:generate_file "file.txt" {
+			content = "data"
+		}`,
+	})
+
+	cli := NewCLI(t, s.RootDir())
+
+	AssertRunResult(t, cli.Run("generate", "--metrics"), RunExpected{
+		StdoutRegexes: []string{
+			`Code generation metrics`,
+			`Total time: `,
+			`Stacks: 2`,
+			`- /stack-a: `,
+			`- /stack-b: `,
+		},
+	})
+
+	AssertRunResult(t, cli.Run("generate"), RunExpected{
+		Stdout: "Nothing to do, generated code is up to date\n",
+	})
+
+	AssertRunResult(t, cli.Run("generate", "-v"), RunExpected{
+		StdoutRegexes: []string{
+			`Code generation metrics`,
+			`Stacks: 2`,
+		},
+	})
+}
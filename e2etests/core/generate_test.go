@@ -5,10 +5,15 @@ package core_test
 
 import (
 	"fmt"
+	"os"
 	"path/filepath"
+	"regexp"
+	"strings"
 	"testing"
 
+	"github.com/madlambda/spells/assert"
 	"github.com/terramate-io/terramate"
+	"github.com/terramate-io/terramate/cmd/terramate/cli"
 	"github.com/terramate-io/terramate/generate"
 	"github.com/terramate-io/terramate/modvendor"
 	"github.com/terramate-io/terramate/project"
@@ -141,7 +146,7 @@ func TestE2EGenerate(t *testing.T) {
 
 Successes:
 
-- /stack
+- /stack (context=stack)
 	[+] file.hcl
 	[+] file.txt
 
@@ -263,7 +268,7 @@ Code generation report
 
 Successes:
 
-- /stack
+- /stack (context=stack)
 	[+] file.hcl
 	[+] file.txt
 
@@ -349,10 +354,10 @@ Vendor report:
 
 Successes:
 
-- /stack1
+- /stack1 (context=stack)
 	[+] file.hcl
 
-- /stack2
+- /stack2 (context=stack)
 	[+] file.hcl
 
 Hint: '+', '~' and '-' mean the file was created, changed and deleted, respectively.
@@ -409,10 +414,10 @@ Hint: '+', '~' and '-' mean the file was created, changed and deleted, respectiv
 
 Successes:
 
-- /stack1
+- /stack1 (context=stack)
 	[+] example.hcl
 
-- /stack2
+- /stack2 (context=stack)
 	[+] example.hcl
 
 Hint: '+', '~' and '-' mean the file was created, changed and deleted, respectively.
@@ -472,7 +477,7 @@ Hint: '+', '~' and '-' mean the file was created, changed and deleted, respectiv
 
 Successes:
 
-- /stack1
+- /stack1 (context=stack)
 	[+] main.tfvars
 
 Hint: '+', '~' and '-' mean the file was created, changed and deleted, respectively.
@@ -574,8 +579,11 @@ func TestE2EGenerateRespectsWorkingDirectory(t *testing.T) {
 
 			gencli := NewCLI(t, filepath.Join(s.RootDir(), generateWd))
 			res := gencli.Run("generate")
+			// the context of the results is not part of the wanted report,
+			// the root directory files are the ones from context=root.
 			expected := RunExpected{
-				Stdout: nljoin(wantGenerate.Full()),
+				Stdout: nljoin(strings.Replace(wantGenerate.Full(),
+					"- / (context=stack)", "- / (context=root)", 1)),
 			}
 			AssertRunResult(t, res, expected)
 
@@ -679,3 +687,272 @@ type str string
 func (s str) String() string {
 	return string(s)
 }
+
+func TestE2EGenerateStackParentAndChildren(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.NoGit(t, true)
+	s.BuildTree([]string{
+		"s:infra",
+		"s:infra/b",
+		"s:infra/a",
+		"s:infra/a/nested",
+		"s:infra/dir/c",
+	})
+	s.RootEntry().CreateFile(
+		terramate.DefaultFilename,
+		Doc(
+			GenerateFile(
+				Labels("family.txt"),
+				Expr("content", `"${terramate.stack.parent.path == null ? "-" : terramate.stack.parent.path} ${tm_join(",", terramate.stack.children)}"`),
+			),
+		).String(),
+	)
+
+	tmcli := NewCLI(t, s.RootDir())
+	AssertRunResult(t, tmcli.Run("generate"), RunExpected{IgnoreStdout: true})
+
+	for path, want := range map[string]string{
+		"/infra":          "- /infra/a,/infra/b,/infra/dir/c",
+		"/infra/a":        "/infra /infra/a/nested",
+		"/infra/a/nested": "/infra/a ",
+		"/infra/b":        "/infra ",
+		"/infra/dir/c":    "/infra ",
+	} {
+		got := test.ReadFile(t, s.RootDir(), path+"/family.txt")
+		assert.EqualStrings(t, want, string(got), "stack %s", path)
+	}
+}
+
+func TestE2EGenerateUndefinedGlobalsTrace(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.NoGit(t, true)
+	s.BuildTree([]string{
+		`f:imports/globals.tm.hcl:globals {
+  name = "root"
+}`,
+		`f:terramate.tm.hcl:import {
+  source = "/imports/globals.tm.hcl"
+}`,
+		"s:stack",
+		`f:stack/globals.tm.hcl:globals {
+  name = unset
+}`,
+		`f:stack/gen.tm.hcl:generate_hcl "out.tf" {
+  content {
+    name = global.name
+  }
+}`,
+		"s:other",
+		`f:other/gen.tm.hcl:generate_hcl "out.tf" {
+  content {
+    other = global.other
+  }
+}`,
+	})
+
+	tmcli := NewCLI(t, s.RootDir())
+	AssertRunResult(t, tmcli.Run("generate"), RunExpected{
+		Status: 1,
+		StdoutRegexes: []string{
+			`imports/globals.tm.hcl:2,3-\d+: global.name is defined, imported by /`,
+			`stack/globals.tm.hcl:2,3-\d+: global.name is unset`,
+			`global.other is not defined in /other or its parent directories`,
+		},
+	})
+}
+
+func TestE2EGenerateSelectedContext(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.NoGit(t, true)
+	s.BuildTree([]string{
+		"s:stack",
+		`f:gen.tm.hcl:generate_file "/root.txt" {
+  context = root
+  content = "root"
+}
+
+generate_file "stack.txt" {
+  content = "stack"
+}`,
+	})
+
+	tmcli := NewCLI(t, s.RootDir())
+	AssertRunResult(t, tmcli.Run("generate", "--context", "root"), RunExpected{
+		Stdout: `Code generation report
+
+Successes:
+
+- / (context=root)
+	[+] root.txt
+
+Hint: '+', '~' and '-' mean the file was created, changed and deleted, respectively.
+`,
+	})
+	test.AssertFileContentEquals(t, filepath.Join(s.RootDir(), "root.txt"), "root")
+	_, err := os.Stat(filepath.Join(s.RootDir(), "stack", "stack.txt"))
+	assert.IsTrue(t, os.IsNotExist(err), "stack.txt must not be generated")
+
+	// the outdated code safeguard considers all the contexts.
+	AssertRunResult(t, tmcli.Run("run", HelperPath, "true"), RunExpected{
+		Status:      1,
+		StderrRegex: string(cli.ErrOutdatedGenCodeDetected),
+	})
+
+	AssertRunResult(t, tmcli.Run("generate", "--context", "stack"), RunExpected{
+		Stdout: `Code generation report
+
+Successes:
+
+- /stack (context=stack)
+	[+] stack.txt
+
+Hint: '+', '~' and '-' mean the file was created, changed and deleted, respectively.
+`,
+	})
+	test.AssertFileContentEquals(t, filepath.Join(s.RootDir(), "stack", "stack.txt"), "stack")
+
+	AssertRunResult(t, tmcli.Run("generate", "--context", "invalid"), RunExpected{
+		Status:      1,
+		StderrRegex: "--context must be one of",
+	})
+	AssertRunResult(t, tmcli.Run("generate"), RunExpected{
+		Stdout: "Nothing to do, generated code is up to date\n",
+	})
+}
+
+func TestE2EGenerateChanged(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.New(t)
+	s.BuildTree([]string{
+		"s:parent/stack-a",
+		"s:parent/stack-b",
+		"s:other/stack-c",
+		`f:parent/globals.tm:globals {
+  env = "dev"
+}`,
+		`f:other/globals.tm:globals {
+  env = "dev"
+}`,
+		`f:gen.tm:generate_file "env.txt" {
+  content = global.env
+}`,
+	})
+
+	tmcli := NewCLI(t, s.RootDir())
+	AssertRunResult(t, tmcli.Run("generate"), RunExpected{IgnoreStdout: true})
+
+	git := s.Git()
+	git.CommitAll("first commit")
+
+	// the code of other/stack-c is outdated but the stack is not changed.
+	s.RootEntry().CreateFile("other/globals.tm", `globals {
+  env = "stg"
+}`)
+	git.CommitAll("outdated stack-c")
+	git.Push("main")
+
+	// the parent scope change makes all the stacks of the scope changed for
+	// the code generation only.
+	git.CheckoutNew("change-parent")
+	s.RootEntry().CreateFile("parent/globals.tm", `globals {
+  env = "prd"
+}`)
+	git.CommitAll("parent globals changed")
+
+	AssertRunResult(t, tmcli.ListChangedStacks(), RunExpected{})
+	AssertRunResult(t, tmcli.Run("generate", "--changed"), RunExpected{
+		Stdout: `Code generation report
+
+Successes:
+
+- /parent/stack-a (context=stack)
+	[~] env.txt
+
+- /parent/stack-b (context=stack)
+	[~] env.txt
+
+Hint: '+', '~' and '-' mean the file was created, changed and deleted, respectively.
+`,
+	})
+	test.AssertFileContentEquals(t, filepath.Join(s.RootDir(), "parent/stack-a/env.txt"), "prd")
+	test.AssertFileContentEquals(t, filepath.Join(s.RootDir(), "other/stack-c/env.txt"), "dev")
+	git.CommitAll("generate changed stacks")
+
+	// the unchanged stack is not regenerated nor checked by the outdated code
+	// safeguard of the changed stacks.
+	AssertRunResult(t, tmcli.Run("run", "--quiet", "--changed", HelperPath, "echo", "ok"), RunExpected{
+		Stdout: "ok\nok\n",
+	})
+	AssertRunResult(t, tmcli.Run("run", "--quiet", HelperPath, "echo", "ok"), RunExpected{
+		Status:      1,
+		StderrRegex: string(cli.ErrOutdatedGenCodeDetected),
+	})
+
+	AssertRunResult(t, tmcli.Run("generate", "--changed"), RunExpected{
+		Stdout: "Nothing to do, generated code is up to date\n",
+	})
+}
+
+func TestE2EGenerateVendorWithVersionConstraint(t *testing.T) {
+	t.Parallel()
+
+	repo := sandbox.New(t)
+	repo.RootEntry().CreateFile("main.tf", "# v1")
+	repogit := repo.Git()
+	repogit.CommitAll("v1")
+	for _, tag := range []string{"v1.0.0", "v1.1.0"} {
+		_, err := repogit.Unwrap().Exec("tag", tag)
+		assert.NoError(t, err)
+	}
+	source := newLocalSource(repo.RootDir())
+
+	s := sandbox.NoGit(t, true)
+	s.BuildTree([]string{
+		"s:stack",
+		`f:gen.tm.hcl:generate_file "vendor.txt" {
+  content = tm_vendor("` + source + `", "~> 1.0")
+}`,
+	})
+
+	vendordir := project.NewPath("/modules")
+	resolved := test.ParseSource(t, source+"?ref=v1.1.0")
+	targetdir := modvendor.TargetDir(vendordir, resolved)
+
+	tmcli := NewCLI(t, s.RootDir())
+	AssertRunResult(t, tmcli.Run("generate"), RunExpected{IgnoreStdout: true})
+	test.AssertFileContentEquals(t,
+		filepath.Join(s.RootDir(), "stack", "vendor.txt"), ".."+targetdir.String())
+	test.IsFile(t, filepath.Join(s.RootDir(), filepath.FromSlash(targetdir.String())), "main.tf")
+
+	manifest, err := modvendor.LoadManifest(s.RootDir(), vendordir)
+	assert.NoError(t, err)
+	version, ok := manifest.Lookup(source, "~> 1.0")
+	assert.IsTrue(t, ok, "resolved version not recorded: %+v", manifest)
+	assert.EqualStrings(t, "v1.1.0", version)
+
+	// newer tags don't change the version recorded in the manifest.
+	repo.RootEntry().CreateFile("main.tf", "# v1.2")
+	repogit.CommitAll("v1.2")
+	_, err = repogit.Unwrap().Exec("tag", "v1.2.0")
+	assert.NoError(t, err)
+
+	AssertRunResult(t, tmcli.Run("generate"), RunExpected{
+		Stdout: "Nothing to do, generated code is up to date\n",
+	})
+
+	s.RootEntry().CreateFile("gen.tm.hcl", `generate_file "vendor.txt" {
+  content = tm_vendor("`+source+`", "~> 2.0")
+}`)
+
+	AssertRunResult(t, tmcli.Run("generate"), RunExpected{
+		Status: 1,
+		StdoutRegexes: []string{
+			`\[!\] ` + regexp.QuoteMeta(source),
+			`no tag of .* matches the version constraint "~> 2.0"`,
+		},
+	})
+}
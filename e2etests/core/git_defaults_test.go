@@ -0,0 +1,126 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package core_test
+
+import (
+	"testing"
+
+	"github.com/madlambda/spells/assert"
+	. "github.com/terramate-io/terramate/e2etests/internal/runner"
+	"github.com/terramate-io/terramate/test/sandbox"
+)
+
+func TestChangedDetectsGitDefaults(t *testing.T) {
+	t.Parallel()
+
+	// setup creates a repository with the stacks a and b on the default
+	// branch of the remote, and a feature branch changing the stack b.
+	setup := func(t *testing.T, remote, branch string) sandbox.S {
+		s := sandbox.NewWithGitConfig(t, sandbox.GitConfig{
+			LocalBranchName:         branch,
+			DefaultRemoteName:       remote,
+			DefaultRemoteBranchName: branch,
+		})
+		s.BuildTree([]string{"s:a", "s:b"})
+		git := s.Git()
+		git.CommitAll("create stacks")
+		git.Push(branch)
+		git.CheckoutNew("feature")
+		s.RootEntry().CreateFile("b/main.tf", "# changed")
+		git.CommitAll("change b")
+		return s
+	}
+
+	exec := func(t *testing.T, s sandbox.S, args ...string) {
+		t.Helper()
+		_, err := s.Git().Unwrap().Exec(args[0], args[1:]...)
+		assert.NoError(t, err)
+	}
+
+	t.Run("master branch of origin", func(t *testing.T) {
+		t.Parallel()
+		s := setup(t, "origin", "master")
+		cli := NewCLI(t, s.RootDir())
+		AssertRunResult(t, cli.ListChangedStacks(), RunExpected{Stdout: nljoin("b")})
+	})
+
+	t.Run("only remote of the repository", func(t *testing.T) {
+		t.Parallel()
+		s := setup(t, "upstream", "master")
+		cli := NewCLI(t, s.RootDir())
+		AssertRunResult(t, cli.ListChangedStacks(), RunExpected{Stdout: nljoin("b")})
+	})
+
+	t.Run("HEAD of the remote", func(t *testing.T) {
+		t.Parallel()
+		s := setup(t, "origin", "trunk")
+		cli := NewCLI(t, s.RootDir())
+
+		// the feature branch is pushed as main, which is used as the
+		// default branch until trunk is set as the HEAD of the remote.
+		exec(t, s, "push", "origin", "feature:main")
+		exec(t, s, "fetch", "origin")
+		AssertRunResult(t, cli.ListChangedStacks(), RunExpected{})
+
+		exec(t, s, "remote", "set-head", "origin", "trunk")
+		AssertRunResult(t, cli.ListChangedStacks(), RunExpected{Stdout: nljoin("b")})
+	})
+
+	t.Run("fails if main and master exist and the remote has no HEAD", func(t *testing.T) {
+		t.Parallel()
+		s := setup(t, "origin", "master")
+		exec(t, s, "push", "origin", "master:main")
+		exec(t, s, "fetch", "origin")
+
+		cli := NewCLI(t, s.RootDir())
+		AssertRunResult(t, cli.ListChangedStacks(), RunExpected{
+			Status: 1,
+			StderrRegexes: []string{
+				`the remote "origin" has the branches "main", "master"`,
+				`terramate.config.git.default_branch`,
+			},
+		})
+
+		// the other commands don't depend on the default branch.
+		AssertRunResult(t, cli.ListStacks(), RunExpected{Stdout: nljoin("a", "b")})
+
+		exec(t, s, "remote", "set-head", "origin", "master")
+		AssertRunResult(t, cli.ListChangedStacks(), RunExpected{Stdout: nljoin("b")})
+	})
+
+	t.Run("fails if there are many remotes and no upstream", func(t *testing.T) {
+		t.Parallel()
+		s := setup(t, "upstream", "master")
+		s.Git().SetupRemote("fork", "master", "master")
+
+		cli := NewCLI(t, s.RootDir())
+		AssertRunResult(t, cli.ListChangedStacks(), RunExpected{
+			Status: 1,
+			StderrRegexes: []string{
+				`the repository has the remotes "fork", "upstream"`,
+				`terramate.config.git.default_remote`,
+			},
+		})
+
+		exec(t, s, "branch", "--set-upstream-to=upstream/master")
+		AssertRunResult(t, cli.ListChangedStacks(), RunExpected{Stdout: nljoin("b")})
+	})
+
+	t.Run("configuration takes precedence", func(t *testing.T) {
+		t.Parallel()
+		s := setup(t, "upstream", "master")
+		s.Git().SetupRemote("fork", "master", "master")
+		s.RootEntry().CreateFile("git.tm", `terramate {
+  config {
+    git {
+      default_remote = "fork"
+      default_branch = "master"
+    }
+  }
+}
+`)
+		cli := NewCLI(t, s.RootDir())
+		AssertRunResult(t, cli.ListChangedStacks(), RunExpected{Stdout: nljoin("b")})
+	})
+}
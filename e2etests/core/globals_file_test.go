@@ -0,0 +1,104 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package core_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	. "github.com/terramate-io/terramate/e2etests/internal/runner"
+	"github.com/terramate-io/terramate/test"
+	"github.com/terramate-io/terramate/test/sandbox"
+)
+
+func TestGlobalsFile(t *testing.T) {
+	t.Parallel()
+
+	layout := []string{
+		`f:globals.tm:
+		globals {
+		  env    = "dev"
+		  region = "us-east-1"
+		  name   = "${global.env}-${global.region}"
+		}`,
+		`s:stacks/a`,
+		`f:stacks/a/globals.tm:
+		globals {
+		  region = "sa-east-1"
+		}`,
+		`f:stacks/a/generate.tm:
+		generate_file "name.txt" {
+		  content = global.name
+		}`,
+		`f:vars.tmvars.hcl:
+# the CI overrides
+env    = tm_upper("prod")
+region = "eu-west-1"
+`,
+	}
+
+	t.Run("eval mixes the file and the flags, the flags win", func(t *testing.T) {
+		t.Parallel()
+		s := sandbox.NoGit(t, true)
+		s.BuildTree(layout)
+		cli := NewCLI(t, s.RootDir())
+
+		AssertRunResult(t, cli.Run("experimental", "eval", "--globals-file", "vars.tmvars.hcl", "global.name"),
+			RunExpected{Stdout: "PROD-eu-west-1\n"})
+		AssertRunResult(t, cli.Run("experimental", "eval", "--globals-file", "vars.tmvars.hcl",
+			"-g", "region=\"ap-south-1\"", "global.name"),
+			RunExpected{Stdout: "PROD-ap-south-1\n"})
+		AssertRunResult(t, cli.Run("experimental", "get-config-value", "--globals-file", "vars.tmvars.hcl",
+			"--stack", "/stacks/a", "global.region"),
+			RunExpected{Stdout: "eu-west-1\n"})
+		AssertRunResult(t, cli.Run("experimental", "partial-eval", "--globals-file", "vars.tmvars.hcl",
+			"-g", "env=\"qa\"", "\"${global.env}-${global.region}\""),
+			RunExpected{Stdout: "\"qa-eu-west-1\"\n"})
+	})
+
+	t.Run("generate applies the file to the stacks of the working directory", func(t *testing.T) {
+		t.Parallel()
+		s := sandbox.NoGit(t, true)
+		s.BuildTree(layout)
+		cli := NewCLI(t, s.RootDir())
+
+		AssertRunResult(t, cli.Run("generate", "--globals-file", "vars.tmvars.hcl"), RunExpected{IgnoreStdout: true})
+		test.AssertFileContentEquals(t, filepath.Join(s.RootDir(), "stacks/a/name.txt"), "PROD-eu-west-1")
+
+		AssertRunResult(t, cli.Run("generate"), RunExpected{IgnoreStdout: true})
+		test.AssertFileContentEquals(t, filepath.Join(s.RootDir(), "stacks/a/name.txt"), "dev-sa-east-1")
+	})
+
+	t.Run("run --eval uses the file", func(t *testing.T) {
+		t.Parallel()
+		s := sandbox.NoGit(t, true)
+		s.BuildTree(layout)
+		cli := NewCLI(t, s.RootDir())
+
+		AssertRunResult(t, cli.Run("generate"), RunExpected{IgnoreStdout: true})
+		AssertRunResult(t, cli.Run("run", "--quiet", "--eval", "--globals-file", "vars.tmvars.hcl", "--",
+			HelperPath, "echo", "${global.name}"),
+			RunExpected{Stdout: "PROD-eu-west-1\n"})
+		AssertRunResult(t, cli.Run("run", "--quiet", "--globals-file", "vars.tmvars.hcl", "--",
+			HelperPath, "echo", "${global.name}"),
+			RunExpected{Status: 1, StderrRegex: "--globals-file requires --eval"})
+	})
+
+	t.Run("duplicated globals in the file fail with their range", func(t *testing.T) {
+		t.Parallel()
+		s := sandbox.NoGit(t, true)
+		s.BuildTree(layout)
+		s.RootEntry().CreateFile("dup.hcl", "env = \"a\"\nenv = \"b\"\n")
+		cli := NewCLI(t, s.RootDir())
+
+		AssertRunResult(t, cli.Run("experimental", "eval", "--globals-file", "dup.hcl", "global.env"), RunExpected{
+			Status:      1,
+			StderrRegex: `dup.hcl:2,1-4`,
+		})
+		AssertRunResult(t, cli.Run("generate", "--globals-file", "dup.hcl"), RunExpected{
+			Status:      1,
+			StderrRegex: `The argument "env" was already set`,
+		})
+	})
+}
@@ -0,0 +1,119 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package core_test
+
+import (
+	"testing"
+
+	. "github.com/terramate-io/terramate/e2etests/internal/runner"
+	"github.com/terramate-io/terramate/test/sandbox"
+)
+
+func TestListFormat(t *testing.T) {
+	t.Parallel()
+
+	setup := func(t *testing.T) sandbox.S {
+		s := sandbox.New(t)
+		s.BuildTree([]string{
+			`s:stacks/a:id=a-id;tags=["app"]`,
+			`s:stacks/b:id=b-id`,
+			`f:stacks/c/stack.tm:stack {
+  id   = "c-id"
+  name = "stack c"
+  tags = ["app"]
+}
+`,
+		})
+		git := s.Git()
+		git.CommitAll("all")
+		git.Push("main")
+		git.CheckoutNew("change")
+		return s
+	}
+
+	t.Run("github-matrix has a job for each stack", func(t *testing.T) {
+		t.Parallel()
+		s := setup(t)
+		cli := NewCLI(t, s.RootDir())
+		AssertRunResult(t, cli.Run("list", "--format", "github-matrix"), RunExpected{
+			Stdout: `{"include":[` +
+				`{"stack":"/stacks/a","id":"a-id","name":"a","target":"default"},` +
+				`{"stack":"/stacks/b","id":"b-id","name":"b","target":"default"},` +
+				`{"stack":"/stacks/c","id":"c-id","name":"stack c","target":"default"}` +
+				"]}\n",
+		})
+	})
+
+	t.Run("github-matrix respects the filters", func(t *testing.T) {
+		t.Parallel()
+		s := setup(t)
+		s.RootEntry().CreateFile("stacks/a/main.tf", "# changed")
+		s.RootEntry().CreateFile("stacks/b/main.tf", "# changed")
+		s.Git().CommitAll("change a and b")
+
+		cli := NewCLI(t, s.RootDir())
+		AssertRunResult(t, cli.Run("list", "--changed", "--tags", "app", "--format", "github-matrix"), RunExpected{
+			Stdout: `{"include":[{"stack":"/stacks/a","id":"a-id","name":"a","target":"default"}]}` + "\n",
+		})
+		AssertRunResult(t, cli.Run("list", "--changed", "--no-tags", "app", "--format", "github-matrix"), RunExpected{
+			Stdout: `{"include":[{"stack":"/stacks/b","id":"b-id","name":"b","target":"default"}]}` + "\n",
+		})
+	})
+
+	t.Run("github-matrix of no stacks has an empty include", func(t *testing.T) {
+		t.Parallel()
+		s := setup(t)
+		cli := NewCLI(t, s.RootDir())
+		AssertRunResult(t, cli.Run("list", "--changed", "--format", "github-matrix"), RunExpected{
+			Stdout: `{"include":[]}` + "\n",
+		})
+	})
+
+	t.Run("github-matrix fails when exceeding --max", func(t *testing.T) {
+		t.Parallel()
+		s := setup(t)
+		cli := NewCLI(t, s.RootDir())
+		AssertRunResult(t, cli.Run("list", "--format", "github-matrix", "--max", "2"), RunExpected{
+			StderrRegex: "the matrix has 3 stacks but the maximum is 2",
+			Status:      1,
+		})
+		AssertRunResult(t, cli.Run("list", "--format", "github-matrix", "--max", "3", "--tags", "app"), RunExpected{
+			Stdout: `{"include":[` +
+				`{"stack":"/stacks/a","id":"a-id","name":"a","target":"default"},` +
+				`{"stack":"/stacks/c","id":"c-id","name":"stack c","target":"default"}` +
+				"]}\n",
+		})
+	})
+
+	t.Run("json lists the stacks with the reasons", func(t *testing.T) {
+		t.Parallel()
+		s := setup(t)
+		s.RootEntry().CreateFile("stacks/b/main.tf", "# changed")
+		s.Git().CommitAll("change b")
+
+		cli := NewCLI(t, s.RootDir())
+		AssertRunResult(t, cli.Run("list", "--changed", "--why", "--format", "json"), RunExpected{
+			Stdout: `[
+  {
+    "stack": "/stacks/b",
+    "id": "b-id",
+    "name": "b",
+    "target": "default",
+    "reason": "stack has unmerged changes"
+  }
+]
+`,
+		})
+	})
+
+	t.Run("json cannot be used with --group", func(t *testing.T) {
+		t.Parallel()
+		s := setup(t)
+		cli := NewCLI(t, s.RootDir())
+		AssertRunResult(t, cli.Run("list", "--run-order", "--group", "--format", "json"), RunExpected{
+			StderrRegex: "the --group flag cannot be used together with --format json",
+			Status:      1,
+		})
+	})
+}
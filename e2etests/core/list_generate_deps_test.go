@@ -0,0 +1,164 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package core_test
+
+import (
+	"testing"
+
+	. "github.com/terramate-io/terramate/e2etests/internal/runner"
+	"github.com/terramate-io/terramate/test/sandbox"
+)
+
+func TestListChangedGenerateFileDependencies(t *testing.T) {
+	t.Parallel()
+
+	setup := func(t *testing.T) sandbox.S {
+		s := sandbox.New(t)
+		s.BuildTree([]string{
+			"s:stacks/a",
+			"s:stacks/b",
+			"s:stacks/c",
+			"s:other",
+			"f:templates/config.tpl:name = ${name}\n",
+			"f:templates/not-used.tpl:anything\n",
+			"f:shared/data.json:{\"a\": 1}\n",
+			`f:stacks/generate.tm:generate_file "config.txt" {
+  stack_filter {
+    project_paths = ["/stacks/a", "/stacks/b"]
+  }
+  content = tm_templatefile("${terramate.root.path.fs.absolute}/templates/config.tpl", {
+    name = terramate.stack.name
+  })
+}
+`,
+			`f:stacks/b/generate.tm:generate_hcl "data.hcl" {
+  content {
+    data = tm_jsondecode(tm_file("../../shared/data.json"))
+  }
+}
+`,
+			`f:stacks/c/generate.tm:generate_hcl "data.hcl" {
+  condition = false
+  content {
+    data = tm_file("../../shared/data.json")
+  }
+}
+`,
+		})
+		cli := NewCLI(t, s.RootDir())
+		AssertRunResult(t, cli.Run("generate"), RunExpected{IgnoreStdout: true})
+		git := s.Git()
+		git.CommitAll("all")
+		git.Push("main")
+		git.CheckoutNew("change")
+		return s
+	}
+
+	t.Run("changing a template changes the stacks generating from it", func(t *testing.T) {
+		t.Parallel()
+		s := setup(t)
+		s.RootEntry().CreateFile("templates/config.tpl", "name = ${name} changed\n")
+		s.Git().CommitAll("change template")
+
+		cli := NewCLI(t, s.RootDir())
+		AssertRunResult(t, cli.ListChangedStacks(), RunExpected{
+			Stdout: nljoin("stacks/a", "stacks/b"),
+		})
+		AssertRunResult(t, cli.Run("list", "--changed", "--why"), RunExpected{
+			Stdout: nljoin(
+				`stacks/a - stack changed because file "/templates/config.tpl" read by its generate blocks changed`,
+				`stacks/b - stack changed because file "/templates/config.tpl" read by its generate blocks changed`,
+			),
+		})
+	})
+
+	t.Run("changing a file read with a relative path", func(t *testing.T) {
+		t.Parallel()
+		s := setup(t)
+		s.RootEntry().CreateFile("shared/data.json", "{\"a\": 2}\n")
+		s.Git().CommitAll("change data")
+
+		cli := NewCLI(t, s.RootDir())
+		AssertRunResult(t, cli.ListChangedStacks(), RunExpected{
+			Stdout: nljoin("stacks/b"),
+		})
+	})
+
+	t.Run("uncommitted template changes are detected", func(t *testing.T) {
+		t.Parallel()
+		s := setup(t)
+		s.RootEntry().CreateFile("templates/config.tpl", "name = ${name} changed\n")
+
+		cli := NewCLI(t, s.RootDir())
+		AssertRunResult(t, cli.ListChangedStacks(), RunExpected{
+			Stdout: nljoin("stacks/a", "stacks/b"),
+		})
+	})
+
+	t.Run("changing a file not read by generate blocks changes no stack", func(t *testing.T) {
+		t.Parallel()
+		s := setup(t)
+		s.RootEntry().CreateFile("templates/not-used.tpl", "changed\n")
+		s.Git().CommitAll("change not used template")
+
+		cli := NewCLI(t, s.RootDir())
+		AssertRunResult(t, cli.ListChangedStacks(), RunExpected{})
+	})
+
+	t.Run("changing the globals of a parent scope changes no stack", func(t *testing.T) {
+		t.Parallel()
+		s := setup(t)
+		s.RootEntry().CreateFile("stacks/globals.tm", "globals {\n  env = \"prd\"\n}\n")
+		s.Git().CommitAll("add globals")
+
+		cli := NewCLI(t, s.RootDir())
+		AssertRunResult(t, cli.Run("list", "--changed", "--why"), RunExpected{})
+	})
+
+	t.Run("run --changed runs the stacks generating from the template", func(t *testing.T) {
+		t.Parallel()
+		s := setup(t)
+		s.RootEntry().CreateFile("templates/config.tpl", "name = ${name} changed\n")
+		AssertRunResult(t, NewCLI(t, s.RootDir()).Run("generate"), RunExpected{IgnoreStdout: true})
+		s.Git().CommitAll("change template")
+
+		cli := NewCLI(t, s.RootDir())
+		AssertRunResult(t, cli.Run("run", "--quiet", "--changed", "--", HelperPath, "cat", "config.txt"), RunExpected{
+			Stdout: nljoin("name = a changed", "name = b changed"),
+		})
+	})
+}
+
+func TestListChangedGenerateFileDependenciesInsideStacks(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.New(t)
+	s.BuildTree([]string{
+		"s:stacks/a:id=a",
+		"s:stacks/b:id=b",
+		"f:stacks/a/shared.tpl:shared = ${name}\n",
+		`f:stacks/b/generate.tm:generate_file "shared.txt" {
+  content = tm_templatefile("../a/shared.tpl", {
+    name = terramate.stack.name
+  })
+}
+`,
+	})
+	cli := NewCLI(t, s.RootDir())
+	AssertRunResult(t, cli.Run("generate"), RunExpected{IgnoreStdout: true})
+	git := s.Git()
+	git.CommitAll("all")
+	git.Push("main")
+	git.CheckoutNew("change")
+
+	s.DirEntry("stacks/a").CreateFile("shared.tpl", "shared = ${name} changed\n")
+	git.CommitAll("change the template of stack a")
+
+	AssertRunResult(t, cli.Run("list", "--changed", "--why"), RunExpected{
+		Stdout: nljoin(
+			`stacks/a - stack has unmerged changes`,
+			`stacks/b - stack changed because file "/stacks/a/shared.tpl" read by its generate blocks changed`,
+		),
+	})
+}
@@ -338,6 +338,43 @@ source = "%s"
 	AssertRunResult(t, cli.ListChangedStacks(), RunExpected{Stdout: wantList})
 }
 
+func TestListChangedOpenTofuModules(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.New(t)
+
+	stack := s.CreateStack("stack")
+	otherStack := s.CreateStack("other-stack")
+	mod1 := s.CreateModule("mod1")
+	mod2 := s.CreateModule("mod2")
+	mod2MainTofu := mod2.CreateFile("main.tofu", "# module 2")
+
+	mod1.CreateFile("main.tofu", `
+module "mod2" {
+  source = "../mod2"
+}`)
+
+	stack.CreateFile("main.tofu", `
+module "mod1" {
+  source = "%s"
+}`, stack.ModSource(mod1))
+
+	otherStack.CreateFile("main.tofu", "# no modules")
+
+	git := s.Git()
+	git.CommitAll("first commit")
+	git.Push("main")
+	git.CheckoutNew("change-module")
+
+	mod2MainTofu.Write("# something else, changed!")
+	git.CommitAll("module changed")
+
+	cli := NewCLI(t, s.RootDir())
+
+	wantList := stack.RelPath() + "\n"
+	AssertRunResult(t, cli.ListChangedStacks(), RunExpected{Stdout: wantList})
+}
+
 func TestListChangedParsingVariablesWithOptionals(t *testing.T) {
 	t.Parallel()
 
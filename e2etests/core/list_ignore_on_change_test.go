@@ -0,0 +1,76 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package core_test
+
+import (
+	"testing"
+
+	. "github.com/terramate-io/terramate/e2etests/internal/runner"
+	"github.com/terramate-io/terramate/test/sandbox"
+)
+
+func TestListChangedIgnoreOnChange(t *testing.T) {
+	t.Parallel()
+
+	setup := func(t *testing.T) sandbox.S {
+		s := sandbox.New(t)
+		s.BuildTree([]string{
+			`s:stacks/a:ignore_on_change=["*.md", "docs/**"]`,
+			`s:stacks/b`,
+			`f:stacks/a/main.tf:# main`,
+			`f:stacks/a/README.md:# a`,
+			`f:stacks/b/README.md:# b`,
+		})
+		s.Git().CommitAll("create stacks")
+		s.Git().Push("main")
+		s.Git().CheckoutNew("change")
+		return s
+	}
+
+	t.Run("markdown only commit", func(t *testing.T) {
+		t.Parallel()
+		s := setup(t)
+		s.RootEntry().CreateFile("stacks/a/README.md", "# a changed")
+		s.RootEntry().CreateFile("stacks/a/docs/diagram.svg", "<svg/>")
+		s.RootEntry().CreateFile("stacks/b/README.md", "# b changed")
+		s.Git().CommitAll("update docs")
+
+		cli := NewCLI(t, s.RootDir())
+		AssertRunResult(t, cli.Run("list", "--changed", "--why"), RunExpected{
+			Stdout: nljoin("stacks/b - stack has unmerged changes"),
+		})
+		AssertRunResult(t, cli.Run("run", "--changed", "--quiet", "--", HelperPath, "stack-rel-path", s.RootDir()), RunExpected{
+			Stdout: nljoin("stacks/b"),
+		})
+	})
+
+	t.Run("mixed commit", func(t *testing.T) {
+		t.Parallel()
+		s := setup(t)
+		s.RootEntry().CreateFile("stacks/a/README.md", "# a changed")
+		s.RootEntry().CreateFile("stacks/a/main.tf", "# main changed")
+		s.Git().CommitAll("update code and docs")
+
+		cli := NewCLI(t, s.RootDir())
+		AssertRunResult(t, cli.Run("list", "--changed", "--why"), RunExpected{
+			Stdout: nljoin(`stacks/a - stack has unmerged changes in "/stacks/a/main.tf"`),
+		})
+	})
+
+	t.Run("invalid pattern", func(t *testing.T) {
+		t.Parallel()
+		s := sandbox.NoGit(t, true)
+		s.BuildTree([]string{
+			`f:stack/stack.tm.hcl:stack {
+			  ignore_on_change = ["docs/[a-"]
+			}`,
+		})
+
+		cli := NewCLI(t, s.RootDir())
+		AssertRunResult(t, cli.Run("list"), RunExpected{
+			Status:      1,
+			StderrRegex: "invalid pattern",
+		})
+	})
+}
@@ -4,8 +4,10 @@
 package core_test
 
 import (
+	"strings"
 	"testing"
 
+	"github.com/madlambda/spells/assert"
 	. "github.com/terramate-io/terramate/e2etests/internal/runner"
 	"github.com/terramate-io/terramate/test/sandbox"
 )
@@ -57,3 +59,193 @@ func TestListRunOrder(t *testing.T) {
 		})
 	}
 }
+
+func TestListRunOrderGroup(t *testing.T) {
+	t.Parallel()
+
+	type testcase struct {
+		name   string
+		layout []string
+		args   []string
+		want   RunExpected
+	}
+
+	for _, tcase := range []testcase{
+		{
+			name: "independent stacks in a single group",
+			layout: []string{
+				"s:stack2",
+				"s:stack1",
+			},
+			want: RunExpected{
+				Stdout: nljoin(
+					"Group 0:",
+					"  stack1",
+					"  stack2",
+				),
+			},
+		},
+		{
+			name: "tag based ordering",
+			layout: []string{
+				`s:network:tags=["infra"]`,
+				`s:dns:tags=["infra"]`,
+				`s:app:after=["tag:infra"]`,
+				`s:monitoring:after=["/app"]`,
+				"s:docs",
+			},
+			want: RunExpected{
+				Stdout: nljoin(
+					"Group 0:",
+					"  dns",
+					"  docs",
+					"  network",
+					"Group 1:",
+					"  app",
+					"Group 2:",
+					"  monitoring",
+				),
+			},
+		},
+		{
+			name: "nested stacks run after their parents",
+			layout: []string{
+				"s:parent",
+				"s:parent/child-b",
+				"s:parent/child-a",
+				"s:parent/child-a/grandchild",
+				"s:other",
+			},
+			want: RunExpected{
+				Stdout: nljoin(
+					"Group 0:",
+					"  other",
+					"  parent",
+					"Group 1:",
+					"  parent/child-a",
+					"  parent/child-b",
+					"Group 2:",
+					"  parent/child-a/grandchild",
+				),
+			},
+		},
+		{
+			name: "cycle between stack1 and stack2",
+			layout: []string{
+				`s:stack1:after=["/stack2"]`,
+				`s:stack2:after=["/stack1"]`,
+			},
+			want: RunExpected{
+				Status: 1,
+				StderrRegexes: []string{
+					"Invalid stack configuration",
+					"cycle detected",
+				},
+			},
+		},
+		{
+			name: "group requires run-order",
+			layout: []string{
+				"s:stack1",
+			},
+			args: []string{"list", "--group"},
+			want: RunExpected{
+				Status:      1,
+				StderrRegex: "the --group flag must be used together with --run-order",
+			},
+		},
+	} {
+		tc := tcase
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			s := sandbox.New(t)
+			s.BuildTree(tc.layout)
+
+			args := tc.args
+			if args == nil {
+				args = []string{"list", "--run-order", "--group"}
+			}
+			cli := NewCLI(t, s.RootDir())
+			AssertRunResult(t, cli.Run(args...), tc.want)
+		})
+	}
+}
+
+func TestListRunOrderShuffle(t *testing.T) {
+	t.Parallel()
+
+	stacks := []string{
+		"infra/dns", "infra/iam", "infra/network",
+		"apps/a", "apps/b", "apps/c",
+		"docs", "tools",
+	}
+	s := sandbox.New(t)
+	s.BuildTree([]string{
+		`s:infra/dns:tags=["infra"]`,
+		`s:infra/iam:tags=["infra"]`,
+		`s:infra/network:tags=["infra"]`,
+		`s:apps/a:after=["tag:infra"]`,
+		`s:apps/b:after=["tag:infra"]`,
+		`s:apps/c:after=["tag:infra"]`,
+		"s:docs",
+		"s:tools",
+	})
+	for _, stack := range stacks {
+		s.DirEntry(stack).CreateFile("name.txt", stack+"\n")
+	}
+	s.Git().CommitAll("all")
+
+	cli := NewCLI(t, s.RootDir())
+	orders := map[string]struct{}{}
+	for _, seed := range []string{"1", "2", "3", "4", "5"} {
+		res := cli.Run("list", "--run-order", "--shuffle", "--seed", seed)
+		AssertRunResult(t, res, RunExpected{IgnoreStdout: true})
+		orders[res.Stdout] = struct{}{}
+
+		// the same seed gives the same order, which is the order of run.
+		AssertRunResult(t, cli.Run("list", "--run-order", "--shuffle", "--seed", seed),
+			RunExpected{Stdout: res.Stdout})
+		AssertRunResult(t, cli.Run("run", "--quiet", "--shuffle", "--seed", seed,
+			"--", HelperPath, "cat", "name.txt"),
+			RunExpected{Stdout: res.Stdout})
+
+		got := strings.Split(strings.TrimSpace(res.Stdout), "\n")
+		assert.EqualInts(t, len(stacks), len(got), "seed %s: unexpected stacks: %v", seed, got)
+		position := map[string]int{}
+		for i, stack := range got {
+			position[stack] = i
+		}
+		for _, app := range []string{"apps/a", "apps/b", "apps/c"} {
+			for _, infra := range []string{"infra/dns", "infra/iam", "infra/network"} {
+				assert.IsTrue(t, position[infra] < position[app],
+					"seed %s: %s ordered before %s: %v", seed, app, infra, got)
+			}
+		}
+	}
+	assert.IsTrue(t, len(orders) > 1, "different seeds must give different orders")
+
+	AssertRunResult(t, cli.Run("list", "--run-order", "--shuffle"), RunExpected{
+		IgnoreStdout: true,
+		StderrRegex:  `shuffling the order of execution with --seed=\d+`,
+	})
+	AssertRunResult(t, cli.Run("list", "--shuffle"), RunExpected{
+		Status:      1,
+		StderrRegex: "the --shuffle flag must be used together with --run-order",
+	})
+	AssertRunResult(t, cli.Run("list", "--run-order", "--seed", "1"), RunExpected{
+		Status:      1,
+		StderrRegex: "the --seed flag must be used together with --shuffle",
+	})
+	AssertRunResult(t, cli.Run("run", "--seed", "1", "--", HelperPath, "true"), RunExpected{
+		Status:      1,
+		StderrRegex: "the --seed flag must be used together with --shuffle",
+	})
+
+	// the zero seed is the same as not setting it.
+	AssertRunResult(t, cli.Run("run", "--quiet", "--seed", "0", "--", HelperPath, "true"), RunExpected{})
+	AssertRunResult(t, cli.Run("list", "--run-order", "--shuffle", "--seed", "0"), RunExpected{
+		IgnoreStdout: true,
+		StderrRegex:  `shuffling the order of execution with --seed=[1-9]\d*`,
+	})
+}
@@ -4,10 +4,14 @@
 package core_test
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 
+	"github.com/madlambda/spells/assert"
 	"github.com/terramate-io/terramate/config"
 	. "github.com/terramate-io/terramate/e2etests/internal/runner"
+	"github.com/terramate-io/terramate/fs"
 	"github.com/terramate-io/terramate/hcl"
 	"github.com/terramate-io/terramate/test"
 	. "github.com/terramate-io/terramate/test/hclwrite/hclutils"
@@ -596,3 +600,57 @@ func TestListRunOrderNotChangedStackIgnored(t *testing.T) {
 			Stdout: nljoin(stack.RelPath()),
 		})
 }
+
+func TestListChangedDiffAgainstSnapshotDir(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.NoGit(t, true)
+	s.BuildTree([]string{
+		"s:stacks/added",
+		"s:stacks/modified",
+		"s:stacks/deleted",
+		"s:stacks/unchanged",
+		"s:stacks/ignored",
+		"f:.gitignore:*.log",
+		"f:stacks/modified/main.tf:# main",
+		"f:stacks/deleted/main.tf:# main",
+		"f:stacks/unchanged/main.tf:# main",
+	})
+
+	snapshotdir := test.TempDir(t)
+	assert.NoError(t, fs.CopyAll(snapshotdir, s.RootDir()))
+
+	cli := NewCLI(t, s.RootDir())
+	AssertRunResult(t,
+		cli.ListChangedStacks("--diff-against", snapshotdir),
+		RunExpected{},
+	)
+
+	test.WriteFile(t, s.RootDir(), "stacks/added/main.tf", "# main")
+	test.WriteFile(t, s.RootDir(), "stacks/modified/main.tf", "# changed")
+	test.WriteFile(t, s.RootDir(), "stacks/ignored/terraform.log", "log")
+	assert.NoError(t, os.Remove(filepath.Join(s.RootDir(), "stacks/deleted/main.tf")))
+
+	AssertRunResult(t,
+		cli.ListChangedStacks("--diff-against", snapshotdir),
+		RunExpected{
+			Stdout: nljoin("stacks/added", "stacks/deleted", "stacks/modified"),
+		},
+	)
+}
+
+func TestListDiffAgainstRequiresChanged(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.NoGit(t, true)
+	s.BuildTree([]string{"s:stack"})
+
+	cli := NewCLI(t, s.RootDir())
+	AssertRunResult(t,
+		cli.Run("list", "--diff-against", test.TempDir(t)),
+		RunExpected{
+			Status:      1,
+			StderrRegex: "flag --diff-against requires --changed",
+		},
+	)
+}
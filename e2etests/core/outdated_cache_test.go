@@ -0,0 +1,97 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package core_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/madlambda/spells/assert"
+	"github.com/terramate-io/terramate/cmd/terramate/cli"
+	. "github.com/terramate-io/terramate/e2etests/internal/runner"
+	"github.com/terramate-io/terramate/generate"
+	"github.com/terramate-io/terramate/test/sandbox"
+)
+
+func TestOutdatedCodeCache(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.New(t)
+	s.BuildTree([]string{
+		`f:shared/globals.tm:globals {
+		  region = "us-east-1"
+		}`,
+		`f:envs/prod/import.tm:import {
+		  source = "/shared/globals.tm"
+		}`,
+		`f:generate.tm:generate_file "region.txt" {
+		  content = tm_try(global.region, "none")
+		}`,
+		`s:envs/prod/eu/zone/stack`,
+		`s:envs/dev/eu/zone/stack`,
+	})
+
+	tm := NewCLI(t, s.RootDir())
+	AssertRunResult(t, tm.Run("generate"), RunExpected{IgnoreStdout: true})
+	git := s.Git()
+	git.CommitAll("generated code")
+
+	cachedir := filepath.Join(s.RootDir(), filepath.FromSlash(generate.OutdatedCacheDir))
+
+	AssertRunResult(t, tm.Run("run", "--quiet", HelperPath, "true"), RunExpected{})
+	entries, err := os.ReadDir(cachedir)
+	assert.NoError(t, err)
+	assert.IsTrue(t, len(entries) > 1, "cache entries not created")
+
+	t.Run("cache is ignored by git", func(t *testing.T) {
+		AssertRunResult(t, tm.Run("run", "--quiet", HelperPath, "true"), RunExpected{})
+	})
+
+	t.Run("editing an imported file three levels up", func(t *testing.T) {
+		s.RootEntry().CreateFile("shared/globals.tm", `globals {
+		  region = "eu-west-1"
+		}`)
+		git.CommitAll("change region")
+
+		for _, args := range [][]string{
+			{"run", HelperPath, "true"},
+			{"run", "--no-cache", HelperPath, "true"},
+		} {
+			AssertRunResult(t, tm.Run(args...), RunExpected{
+				Status: 1,
+				StderrRegexes: []string{
+					string(cli.ErrOutdatedGenCodeDetected),
+					`envs/prod/eu/zone/stack/region.txt`,
+				},
+			})
+		}
+
+		AssertRunResult(t, tm.Run("generate"), RunExpected{IgnoreStdout: true})
+		git.CommitAll("regenerated code")
+		AssertRunResult(t, tm.Run("run", "--quiet", HelperPath, "true"), RunExpected{})
+	})
+}
+
+func TestOutdatedCodeCacheDisabled(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.NoGit(t, true)
+	s.BuildTree([]string{
+		`f:generate.tm:generate_file "file.txt" {
+		  content = "content"
+		}`,
+		`s:stack`,
+	})
+
+	tm := NewCLI(t, s.RootDir())
+	AssertRunResult(t, tm.Run("generate", "--no-cache"), RunExpected{IgnoreStdout: true})
+	AssertRunResult(t, tm.Run("run", "--quiet", "--no-cache", HelperPath, "true"), RunExpected{})
+
+	tm = NewCLI(t, s.RootDir(), "TM_ARG_NO_CACHE=true")
+	AssertRunResult(t, tm.Run("run", "--quiet", HelperPath, "true"), RunExpected{})
+
+	_, err := os.Stat(filepath.Join(s.RootDir(), ".terramate", "cache"))
+	assert.IsTrue(t, os.IsNotExist(err), "cache dir must not be created: %v", err)
+}
@@ -0,0 +1,92 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package core_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	. "github.com/terramate-io/terramate/e2etests/internal/runner"
+	"github.com/terramate-io/terramate/test"
+	"github.com/terramate-io/terramate/test/sandbox"
+)
+
+func TestOverlay(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.New(t)
+	s.BuildTree([]string{
+		`f:globals.tm:
+		globals {
+		  env = "prod"
+		}`,
+		"s:stack",
+		`f:stack/globals.tm:
+		globals {
+		  name = "${global.env}-stack"
+		}`,
+		`f:stack/extra.tm:
+		globals {
+		  extra = true
+		}`,
+		"s:other",
+	})
+	s.Git().CommitAll("create stacks")
+
+	overlay := test.TempDir(t)
+	test.WriteFile(t, overlay, "globals.tm", `globals {
+	  env = "dev"
+	}`)
+	test.WriteFile(t, filepath.Join(overlay, "stack"), ".wh.extra.tm", "")
+	test.WriteFile(t, filepath.Join(overlay, "other"), ".wh.stack.tm.hcl", "")
+
+	tmcli := NewCLI(t, s.RootDir())
+	stackcli := NewCLI(t, filepath.Join(s.RootDir(), "stack"))
+
+	t.Run("eval reflects the overlaid root global", func(t *testing.T) {
+		AssertRunResult(t, stackcli.Run("experimental", "eval", "global.name"), RunExpected{
+			Stdout: nljoin("prod-stack"),
+		})
+		AssertRunResult(t, stackcli.Run("experimental", "eval", "--overlay", overlay, "global.name"), RunExpected{
+			Stdout: nljoin("dev-stack"),
+		})
+	})
+
+	t.Run("debug show globals applies the overlay", func(t *testing.T) {
+		AssertRunResult(t, tmcli.Run("debug", "show", "globals", "--overlay", overlay), RunExpected{
+			Stdout: `
+stack "/stack":
+	env  = "dev"
+	name = "dev-stack"
+`,
+		})
+	})
+
+	t.Run("list applies the whiteout", func(t *testing.T) {
+		AssertRunResult(t, tmcli.Run("list"), RunExpected{
+			Stdout: nljoin("other", "stack"),
+		})
+		AssertRunResult(t, tmcli.Run("list", "--overlay", overlay), RunExpected{
+			Stdout: nljoin("stack"),
+		})
+	})
+
+	t.Run("run and generate refuse the flag", func(t *testing.T) {
+		AssertRunResult(t, tmcli.Run("run", "--overlay", overlay, "--", HelperPath, "true"), RunExpected{
+			Status:      1,
+			StderrRegex: "unknown flag --overlay",
+		})
+		AssertRunResult(t, tmcli.Run("generate", "--overlay", overlay), RunExpected{
+			Status:      1,
+			StderrRegex: "unknown flag --overlay",
+		})
+	})
+
+	t.Run("fails if the overlay is not a directory", func(t *testing.T) {
+		AssertRunResult(t, tmcli.Run("list", "--overlay", filepath.Join(overlay, "globals.tm")), RunExpected{
+			Status:      1,
+			StderrRegex: "invalid overlay directory",
+		})
+	})
+}
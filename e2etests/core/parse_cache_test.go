@@ -0,0 +1,133 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package core_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/madlambda/spells/assert"
+	. "github.com/terramate-io/terramate/e2etests/internal/runner"
+	"github.com/terramate-io/terramate/hcl"
+	"github.com/terramate-io/terramate/test"
+	. "github.com/terramate-io/terramate/test/hclwrite/hclutils"
+	"github.com/terramate-io/terramate/test/sandbox"
+)
+
+func TestParseCache(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.New(t)
+	s.BuildTree([]string{
+		`s:stacks/stack-a:tags=["a"]`,
+		`s:stacks/stack-b:tags=["b"]`,
+	})
+	s.DirEntry("stacks").CreateFile("globals.tm", Globals(
+		Str("env", "dev"),
+	).String())
+	s.Git().CommitAll("first commit")
+
+	cachedir := filepath.Join(s.RootDir(), filepath.FromSlash(hcl.ParseCacheDir))
+	tm := NewCLI(t, s.RootDir())
+
+	AssertRunResult(t, tm.Run("list", "--tags", "a"), RunExpected{
+		Stdout: nljoin("stacks/stack-a"),
+	})
+	entries := parseCacheEntries(t, cachedir)
+	assert.EqualInts(t, 3, len(entries), "unexpected cache entries: %v", entries)
+
+	t.Run("cached files are used", func(t *testing.T) {
+		AssertRunResult(t, tm.Run("list", "--tags", "a"), RunExpected{
+			Stdout: nljoin("stacks/stack-a"),
+		})
+		AssertRunResult(t, tm.Run("debug", "show", "globals"), RunExpected{
+			Stdout: `
+stack "/stacks/stack-a":
+	env = "dev"
+
+stack "/stacks/stack-b":
+	env = "dev"
+`,
+		})
+	})
+
+	t.Run("cache is ignored by git", func(t *testing.T) {
+		AssertRunResult(t, tm.Run("run", "--quiet", HelperPath, "true"), RunExpected{})
+	})
+
+	t.Run("edited files are parsed again", func(t *testing.T) {
+		s.DirEntry("stacks").CreateFile("globals.tm", Globals(
+			Str("env", "prd"),
+		).String())
+		s.DirEntry("stacks/stack-a").CreateFile("terramate.tm.hcl", Stack(
+			Expr("tags", `["b"]`),
+		).String())
+
+		AssertRunResult(t, tm.Run("list", "--tags", "b"), RunExpected{
+			Stdout: nljoin("stacks/stack-a", "stacks/stack-b"),
+		})
+		AssertRunResult(t, tm.Run("debug", "show", "globals"), RunExpected{
+			Stdout: `
+stack "/stacks/stack-a":
+	env = "prd"
+
+stack "/stacks/stack-b":
+	env = "prd"
+`,
+		})
+	})
+
+	t.Run("corrupted entries are ignored and rewritten", func(t *testing.T) {
+		for _, entry := range parseCacheEntries(t, cachedir) {
+			test.WriteFile(t, cachedir, filepath.Base(entry), "corrupted")
+		}
+		AssertRunResult(t, tm.Run("list", "--tags", "b"), RunExpected{
+			Stdout: nljoin("stacks/stack-a", "stacks/stack-b"),
+		})
+		for _, entry := range parseCacheEntries(t, cachedir) {
+			data, err := os.ReadFile(entry)
+			assert.NoError(t, err)
+			if string(data) == "corrupted" {
+				t.Fatalf("corrupted cache entry %s not rewritten", entry)
+			}
+		}
+	})
+}
+
+func TestParseCacheDisabled(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.NoGit(t, true)
+	s.BuildTree([]string{
+		`s:stack`,
+	})
+
+	tm := NewCLI(t, s.RootDir())
+	AssertRunResult(t, tm.Run("list", "--no-parse-cache"), RunExpected{
+		Stdout: nljoin("stack"),
+	})
+
+	tm = NewCLI(t, s.RootDir(), "TM_ARG_NO_PARSE_CACHE=true")
+	AssertRunResult(t, tm.Run("list"), RunExpected{
+		Stdout: nljoin("stack"),
+	})
+
+	_, err := os.Stat(filepath.Join(s.RootDir(), filepath.FromSlash(hcl.ParseCacheDir)))
+	assert.IsTrue(t, os.IsNotExist(err), "cache dir must not be created: %v", err)
+}
+
+func parseCacheEntries(t *testing.T, cachedir string) []string {
+	t.Helper()
+	dirEntries, err := os.ReadDir(cachedir)
+	assert.NoError(t, err)
+	var entries []string
+	for _, entry := range dirEntries {
+		if entry.Name() == ".gitignore" {
+			continue
+		}
+		entries = append(entries, filepath.Join(cachedir, entry.Name()))
+	}
+	return entries
+}
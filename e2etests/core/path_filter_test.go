@@ -0,0 +1,216 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package core_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	. "github.com/terramate-io/terramate/e2etests/internal/runner"
+	"github.com/terramate-io/terramate/test/sandbox"
+)
+
+func TestListPathFilters(t *testing.T) {
+	t.Parallel()
+
+	type testcase struct {
+		name string
+		wd   string
+		args []string
+		want RunExpected
+	}
+
+	s := sandbox.NoGit(t, true)
+	s.BuildTree([]string{
+		"s:other",
+		"s:stacks",
+		"s:stacks/dev/a",
+		"s:stacks/dev/b",
+		"s:stacks/prod/a",
+		"s:stacks/prod/legacy",
+		"s:stacks/prod/legacy/child",
+		"s:stacks/production/a",
+	})
+
+	for _, tc := range []testcase{
+		{
+			name: "include path selects the dir and its children",
+			args: []string{"--include", "/stacks/prod"},
+			want: RunExpected{
+				Stdout: nljoin("stacks/prod/a", "stacks/prod/legacy", "stacks/prod/legacy/child"),
+			},
+		},
+		{
+			name: "exclude path skips the dir and its children",
+			args: []string{"--include", "/stacks/prod", "--exclude", "/stacks/prod/legacy"},
+			want: RunExpected{
+				Stdout: nljoin("stacks/prod/a"),
+			},
+		},
+		{
+			name: "exclude only",
+			args: []string{"--exclude", "/stacks"},
+			want: RunExpected{
+				Stdout: nljoin("other"),
+			},
+		},
+		{
+			name: "multiple includes",
+			args: []string{"--include", "/other", "--include", "/stacks/dev"},
+			want: RunExpected{
+				Stdout: nljoin("other", "stacks/dev/a", "stacks/dev/b"),
+			},
+		},
+		{
+			name: "trailing double star includes the dir itself",
+			args: []string{"--include", "/stacks/**"},
+			want: RunExpected{
+				Stdout: nljoin(
+					"stacks",
+					"stacks/dev/a",
+					"stacks/dev/b",
+					"stacks/prod/a",
+					"stacks/prod/legacy",
+					"stacks/prod/legacy/child",
+					"stacks/production/a",
+				),
+			},
+		},
+		{
+			name: "trailing double star on exclude",
+			args: []string{"--include", "/stacks/**", "--exclude", "/stacks/prod/**"},
+			want: RunExpected{
+				Stdout: nljoin("stacks", "stacks/dev/a", "stacks/dev/b", "stacks/production/a"),
+			},
+		},
+		{
+			name: "single star matches a single level",
+			args: []string{"--include", "/stacks/*/a"},
+			want: RunExpected{
+				Stdout: nljoin("stacks/dev/a", "stacks/prod/a", "stacks/production/a"),
+			},
+		},
+		{
+			name: "double star in the middle",
+			args: []string{"--exclude", "/**/legacy/**"},
+			want: RunExpected{
+				Stdout: nljoin(
+					"other",
+					"stacks",
+					"stacks/dev/a",
+					"stacks/dev/b",
+					"stacks/prod/a",
+					"stacks/production/a",
+				),
+			},
+		},
+		{
+			name: "alternatives are not split as a list of patterns",
+			args: []string{"--include", "/stacks/{dev,prod}/a"},
+			want: RunExpected{
+				Stdout: nljoin("stacks/dev/a", "stacks/prod/a"),
+			},
+		},
+		{
+			name: "relative patterns are relative to the working dir",
+			wd:   "stacks",
+			args: []string{"--include", "prod", "--exclude", "./prod/legacy/*"},
+			want: RunExpected{
+				Stdout: nljoin("prod/a", "prod/legacy"),
+			},
+		},
+		{
+			name: "patterns are applied after the working dir filtering",
+			wd:   "stacks/dev",
+			args: []string{"--include", "/stacks/prod"},
+		},
+		{
+			name: "combined with tags filter",
+			args: []string{"--include", "/stacks/prod", "--tags", "unknown"},
+		},
+		{
+			name: "invalid pattern fails",
+			args: []string{"--include", "/stacks/[a"},
+			want: RunExpected{
+				Status:      1,
+				StderrRegex: "invalid path filter pattern",
+			},
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			tmcli := NewCLI(t, filepath.Join(s.RootDir(), tc.wd))
+			AssertRunResult(t, tmcli.Run(append([]string{"list"}, tc.args...)...), tc.want)
+		})
+	}
+}
+
+func TestRunPathFilters(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.New(t)
+	s.BuildTree([]string{
+		`s:stacks/prod/a:after=["/stacks/prod/legacy"]`,
+		"s:stacks/prod/b",
+		"s:stacks/prod/legacy",
+		"s:stacks/dev/a",
+	})
+	s.Git().CommitAll("create stacks")
+
+	tmcli := NewCLI(t, s.RootDir())
+	AssertRunResult(t, tmcli.Run(
+		"run", "--quiet",
+		"--include", "/stacks/prod",
+		"--exclude", "/stacks/prod/legacy",
+		"--", HelperPath, "stack-abs-path", s.RootDir(),
+	), RunExpected{
+		Stdout: nljoin("/stacks/prod/a", "/stacks/prod/b"),
+	})
+
+	AssertRunResult(t, tmcli.Run(
+		"run", "--quiet",
+		"--include", "/stacks/**",
+		"--exclude", "/stacks/dev/**",
+		"--", HelperPath, "stack-abs-path", s.RootDir(),
+	), RunExpected{
+		Stdout: nljoin("/stacks/prod/legacy", "/stacks/prod/a", "/stacks/prod/b"),
+	})
+}
+
+func TestScriptRunPathFilters(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.New(t)
+	s.BuildTree([]string{
+		`f:terramate.tm:
+		terramate {
+		  config {
+		    experiments = ["scripts"]
+		  }
+		}`,
+		`f:script.tm:
+		script "hello" {
+		  description = "say hello"
+		  job {
+		    command = ["echo", "hello from ${terramate.stack.path.absolute}"]
+		  }
+		}`,
+		"s:stacks/prod/a",
+		"s:stacks/prod/legacy",
+		"s:stacks/dev/a",
+	})
+	s.Git().CommitAll("create stacks")
+
+	tmcli := NewCLI(t, s.RootDir())
+	AssertRunResult(t, tmcli.Run(
+		"script", "run", "--quiet",
+		"--include", "/stacks/*/a",
+		"--exclude", "/stacks/dev",
+		"--", "hello",
+	), RunExpected{
+		Stdout: nljoin("hello from /stacks/prod/a"),
+	})
+}
@@ -0,0 +1,172 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package core_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/madlambda/spells/assert"
+	. "github.com/terramate-io/terramate/e2etests/internal/runner"
+	"github.com/terramate-io/terramate/progress"
+	"github.com/terramate-io/terramate/test/sandbox"
+)
+
+func TestProgressNDJSONRun(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.NoGit(t, true)
+	s.BuildTree([]string{
+		"s:a",
+		"s:b",
+		"s:c",
+		"f:a/data.txt:a",
+		"f:b/data.txt:b",
+	})
+
+	tmcli := NewCLI(t, s.RootDir())
+	res := tmcli.Run("--progress-format", "ndjson", "run", "--quiet", "--", HelperPath, "cat", "data.txt")
+	AssertRunResult(t, res, RunExpected{
+		Status:       1,
+		Stdout:       "ab",
+		IgnoreStderr: true,
+	})
+
+	events := decodeProgressEvents(t, res.Stderr)
+	assertProgressEvent(t, events, progress.Event{Type: progress.OperationStart, Operation: "run", Stacks: 3})
+	for _, stack := range []string{"/a", "/b"} {
+		assertProgressEvent(t, events, progress.Event{Type: progress.StackStart, Stack: stack})
+		ev := assertProgressEvent(t, events, progress.Event{Type: progress.StackSuccess, Stack: stack})
+		assert.EqualInts(t, 0, *ev.ExitCode)
+		assert.IsTrue(t, ev.DurationMS != nil, "stack success must have a duration")
+		assert.EqualStrings(t, "cat", ev.Cmd[1])
+	}
+
+	ev := assertProgressEvent(t, events, progress.Event{Type: progress.StackFailure, Stack: "/c"})
+	assert.EqualInts(t, 1, *ev.ExitCode)
+	assert.IsTrue(t, ev.Error != "", "stack failure must have an error")
+
+	ev = assertProgressEvent(t, events, progress.Event{Type: progress.Log, Stack: "/c"})
+	assert.IsTrue(t, strings.Contains(ev.Message, "data.txt"), "unexpected log message: %s", ev.Message)
+
+	ev = assertProgressEvent(t, events, progress.Event{Type: progress.OperationEnd, Operation: "run"})
+	assert.EqualInts(t, 1, *ev.ExitCode)
+	assert.IsTrue(t, ev.Error != "", "operation end must have an error")
+
+	last := events[len(events)-1]
+	assert.EqualStrings(t, string(progress.Log), string(last.Type), "human errors are log events")
+}
+
+func TestProgressNDJSONRunCanceledStacks(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.NoGit(t, true)
+	s.BuildTree([]string{
+		"s:a",
+		"s:b",
+	})
+
+	tmcli := NewCLI(t, s.RootDir())
+	res := tmcli.Run("--progress-format", "ndjson", "run", "--", HelperPath, "exit", "3")
+	AssertRunResult(t, res, RunExpected{
+		Status:       1,
+		IgnoreStderr: true,
+	})
+
+	events := decodeProgressEvents(t, res.Stderr)
+	ev := assertProgressEvent(t, events, progress.Event{Type: progress.StackFailure, Stack: "/a"})
+	assert.EqualInts(t, 3, *ev.ExitCode)
+	assertProgressEvent(t, events, progress.Event{Type: progress.StackCanceled, Stack: "/b"})
+	assertNoProgressEvent(t, events, progress.Event{Type: progress.StackStart, Stack: "/b"})
+	assertNoProgressEvent(t, events, progress.Event{Type: progress.Log, Message: "terramate: Entering stack in /a"})
+}
+
+func TestProgressNDJSONGenerate(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.NoGit(t, true)
+	s.BuildTree([]string{
+		"s:a",
+		"s:b",
+		`f:gen.tm:generate_file "file.txt" {
+		  content = terramate.stack.path.absolute
+		}`,
+	})
+
+	tmcli := NewCLI(t, s.RootDir())
+	res := tmcli.Run("--progress-format", "ndjson", "generate")
+	AssertRunResult(t, res, RunExpected{IgnoreStderr: true})
+
+	events := decodeProgressEvents(t, res.Stderr)
+	assertProgressEvent(t, events, progress.Event{Type: progress.OperationStart, Operation: "generate"})
+	for _, dir := range []string{"/a", "/b"} {
+		assertProgressEvent(t, events, progress.Event{Type: progress.FileCreated, Dir: dir, File: "file.txt"})
+	}
+	ev := assertProgressEvent(t, events, progress.Event{Type: progress.OperationEnd, Operation: "generate"})
+	assert.EqualInts(t, 0, *ev.ExitCode)
+	assert.EqualStrings(t, "", ev.Error)
+
+	s.RootEntry().CreateFile("gen.tm", `generate_file "file.txt" {
+	  content = "changed"
+	}`)
+	res = tmcli.Run("--progress-format", "ndjson", "generate")
+	AssertRunResult(t, res, RunExpected{IgnoreStderr: true})
+
+	events = decodeProgressEvents(t, res.Stderr)
+	for _, dir := range []string{"/a", "/b"} {
+		assertProgressEvent(t, events, progress.Event{Type: progress.FileChanged, Dir: dir, File: "file.txt"})
+	}
+}
+
+func decodeProgressEvents(t *testing.T, output string) []progress.Event {
+	t.Helper()
+	var events []progress.Event
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		var ev progress.Event
+		if err := json.Unmarshal(scanner.Bytes(), &ev); err != nil {
+			t.Fatalf("line %q is not a progress event: %v", scanner.Text(), err)
+		}
+		if ev.Type == "" || ev.Time.IsZero() {
+			t.Fatalf("line %q has no type or time", scanner.Text())
+		}
+		events = append(events, ev)
+	}
+	assert.NoError(t, scanner.Err())
+	return events
+}
+
+// assertProgressEvent asserts that an event matching the type and the non-empty
+// string fields of want exists and returns it.
+func assertProgressEvent(t *testing.T, events []progress.Event, want progress.Event) progress.Event {
+	t.Helper()
+	for _, ev := range events {
+		if matchProgressEvent(ev, want) {
+			return ev
+		}
+	}
+	t.Fatalf("event %+v not found in %+v", want, events)
+	return progress.Event{}
+}
+
+func assertNoProgressEvent(t *testing.T, events []progress.Event, unwanted progress.Event) {
+	t.Helper()
+	for _, ev := range events {
+		if matchProgressEvent(ev, unwanted) {
+			t.Fatalf("unexpected event %+v", ev)
+		}
+	}
+}
+
+func matchProgressEvent(ev, want progress.Event) bool {
+	return ev.Type == want.Type &&
+		(want.Operation == "" || ev.Operation == want.Operation) &&
+		(want.Stacks == 0 || ev.Stacks == want.Stacks) &&
+		(want.Stack == "" || ev.Stack == want.Stack) &&
+		(want.Dir == "" || ev.Dir == want.Dir) &&
+		(want.File == "" || ev.File == want.File) &&
+		(want.Message == "" || ev.Message == want.Message)
+}
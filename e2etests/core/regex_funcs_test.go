@@ -0,0 +1,89 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package core_test
+
+import (
+	"testing"
+
+	. "github.com/terramate-io/terramate/e2etests/internal/runner"
+	"github.com/terramate-io/terramate/test"
+	"github.com/terramate-io/terramate/test/sandbox"
+)
+
+func TestRegexFunctionsAvailability(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.New(t)
+	s.BuildTree([]string{
+		`f:terramate.tm:
+		terramate {
+		  config {
+		    experiments = ["scripts"]
+		  }
+		}`,
+		"s:stack",
+		`f:stack/globals.tm:
+		globals {
+		  url    = "https://terramate.io/docs"
+		  parsed = tm_regex("^(?P<scheme>[a-z]+)://(?P<host>[^/]+)", global.url)
+		  words  = tm_regexall("[a-z]+", "a1bc2def")
+		  dashed = tm_replace("a_b_c", "/_/", "-")
+		}`,
+		`f:stack/generate.tm:
+		generate_hcl "regex.hcl" {
+		  content {
+		    host   = tm_regex("^(?P<scheme>[a-z]+)://(?P<host>[^/]+)", global.url).host
+		    pairs  = tm_regexall("(?P<key>\\w+)=(?P<value>\\w+)", "a=1 b=2")
+		    swapped = tm_replace("key=value", "/(\\w+)=(\\w+)/", "$2=$1")
+		  }
+		}`,
+		`f:stack/script.tm:
+		script "regex" {
+		  description = "regex functions in lets"
+		  lets {
+		    host = tm_regex("//([^/]+)", global.url)[0]
+		    path = tm_replace(global.url, "/^.*terramate\\.io/", "")
+		  }
+		  job {
+		    command = ["echo", "${let.host}${let.path}"]
+		  }
+		}`,
+	})
+	s.Git().CommitAll("create stack")
+
+	tmcli := NewCLI(t, s.RootDir())
+	AssertRunResult(t, tmcli.Run("debug", "show", "globals"), RunExpected{
+		Stdout: `
+stack "/stack":
+	dashed = "a-b-c"
+	parsed = {
+	  host   = "terramate.io"
+	  scheme = "https"
+	}
+	url   = "https://terramate.io/docs"
+	words = ["a", "bc", "def"]
+`,
+	})
+
+	AssertRunResult(t, tmcli.Run("generate"), RunExpected{IgnoreStdout: true})
+	s.Git().CommitAll("generate code")
+
+	got := string(s.DirEntry("stack").ReadFile("regex.hcl"))
+	test.AssertGenCodeEquals(t, got, `host = "terramate.io"
+pairs = [
+  {
+    key   = "a"
+    value = "1"
+  },
+  {
+    key   = "b"
+    value = "2"
+  },
+]
+swapped = "value=key"`)
+
+	AssertRunResult(t, tmcli.Run("script", "run", "--quiet", "--", "regex"), RunExpected{
+		Stdout: nljoin("terramate.io/docs"),
+	})
+}
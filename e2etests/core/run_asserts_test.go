@@ -0,0 +1,155 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package core_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	. "github.com/terramate-io/terramate/e2etests/internal/runner"
+	. "github.com/terramate-io/terramate/test/hclwrite/hclutils"
+	"github.com/terramate-io/terramate/test/sandbox"
+)
+
+func TestDirectoryAssertsAppliesToDescendantStacks(t *testing.T) {
+	t.Parallel()
+
+	setup := func(t *testing.T, warning bool) sandbox.S {
+		s := sandbox.NoGit(t, true)
+		s.BuildTree([]string{
+			"s:stacks/s1",
+			"s:stacks/s2",
+			"s:stacks/s3",
+			"s:stacks/s4",
+			"s:stacks/s5",
+			"s:other",
+		})
+		s.RootEntry().CreateFile("globals.tm", Globals(
+			Str("env", "prod"),
+		).String())
+		s.RootEntry().CreateFile("stacks/s2/globals.tm", Globals(
+			Str("env", "dev"),
+		).String())
+		s.RootEntry().CreateFile("stacks/s4/globals.tm", Globals(
+			Str("env", "dev"),
+		).String())
+		s.RootEntry().CreateFile("other/globals.tm", Globals(
+			Str("env", "dev"),
+		).String())
+		s.RootEntry().CreateFile("stacks/asserts.tm", Assert(
+			Expr("assertion", `global.env == "prod"`),
+			Str("message", "env must be prod"),
+			Bool("warning", warning),
+		).String())
+		return s
+	}
+
+	t.Run("run fails naming each violating stack", func(t *testing.T) {
+		t.Parallel()
+		s := setup(t, false)
+		tmcli := NewCLI(t, s.RootDir())
+		AssertRunResult(t,
+			tmcli.Run("run", "--quiet", "--disable-safeguards=outdated-code", "--", HelperPath, "echo", "ok"),
+			RunExpected{
+				Status: 1,
+				StderrRegexes: []string{
+					"assertions failed in the selected stacks",
+					`stack /stacks/s2: .*env must be prod`,
+					`stack /stacks/s4: .*env must be prod`,
+				},
+				NoStderrRegexes: []string{
+					`stack /stacks/s1`,
+					`stack /stacks/s3`,
+					`stack /stacks/s5`,
+					`stack /other`,
+				},
+			},
+		)
+	})
+
+	t.Run("run only checks the selected stacks", func(t *testing.T) {
+		t.Parallel()
+		s := setup(t, false)
+		tmcli := NewCLI(t, filepath.Join(s.RootDir(), "stacks", "s1"))
+		AssertRunResult(t,
+			tmcli.Run("run", "--quiet", "--disable-safeguards=outdated-code", "--", HelperPath, "echo", "ok"),
+			RunExpected{Stdout: "ok\n"},
+		)
+	})
+
+	t.Run("generate fails naming each violating stack", func(t *testing.T) {
+		t.Parallel()
+		s := setup(t, false)
+		tmcli := NewCLI(t, s.RootDir())
+		AssertRunResult(t,
+			tmcli.Run("generate"),
+			RunExpected{
+				Status: 1,
+				StdoutRegexes: []string{
+					`- /stacks/s2 \(context=stack\)\n\terror: .*/stacks/asserts.tm:\d+,\d+-\d+: env must be prod`,
+					`- /stacks/s4 \(context=stack\)\n\terror: .*/stacks/asserts.tm:\d+,\d+-\d+: env must be prod`,
+				},
+				NoStdoutRegex: `- /stacks/s1 \(context=stack\)\n\terror`,
+			},
+		)
+	})
+
+	t.Run("generate reports warnings per stack", func(t *testing.T) {
+		t.Parallel()
+		s := setup(t, true)
+		tmcli := NewCLI(t, s.RootDir())
+		AssertRunResult(t,
+			tmcli.Run("generate"),
+			RunExpected{
+				StdoutRegexes: []string{
+					`- /stacks/s2 \(context=stack\)\n\twarning: /stacks/asserts.tm:\d+,\d+-\d+: env must be prod`,
+					`- /stacks/s4 \(context=stack\)\n\twarning: /stacks/asserts.tm:\d+,\d+-\d+: env must be prod`,
+				},
+				NoStdoutRegex: `/stacks/s1`,
+			},
+		)
+
+		// warnings are not changes.
+		AssertRunResult(t,
+			tmcli.Run("generate", "--detailed-exit-code"),
+			RunExpected{
+				StdoutRegex: `warning: /stacks/asserts.tm:\d+,\d+-\d+: env must be prod`,
+			},
+		)
+	})
+
+	t.Run("warning mode does not fail", func(t *testing.T) {
+		t.Parallel()
+		s := setup(t, true)
+		tmcli := NewCLI(t, filepath.Join(s.RootDir(), "stacks"))
+		tmcli.LogLevel = "warn"
+		AssertRunResult(t,
+			tmcli.Run("run", "--quiet", "--", HelperPath, "echo", "ok"),
+			RunExpected{
+				Stdout:      "ok\nok\nok\nok\nok\n",
+				StderrRegex: "assertion failed",
+			},
+		)
+	})
+
+	t.Run("root asserts are evaluated for all stacks", func(t *testing.T) {
+		t.Parallel()
+		s := setup(t, true)
+		s.RootEntry().CreateFile("asserts.tm", Assert(
+			Expr("assertion", `terramate.stack.path.absolute != "/other"`),
+			Str("message", "other is not allowed"),
+		).String())
+		tmcli := NewCLI(t, s.RootDir())
+		AssertRunResult(t,
+			tmcli.Run("run", "--quiet", "--disable-safeguards=outdated-code", "--", HelperPath, "echo", "ok"),
+			RunExpected{
+				Status: 1,
+				StderrRegexes: []string{
+					`stack /other: .*other is not allowed`,
+				},
+				NoStderrRegex: `stack /stacks`,
+			},
+		)
+	})
+}
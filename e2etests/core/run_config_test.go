@@ -0,0 +1,137 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package core_test
+
+import (
+	"testing"
+
+	. "github.com/terramate-io/terramate/e2etests/internal/runner"
+	. "github.com/terramate-io/terramate/test/hclwrite/hclutils"
+	"github.com/terramate-io/terramate/test/sandbox"
+)
+
+func TestDebugShowRunConfig(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.NoGit(t, true)
+	s.BuildTree([]string{
+		`f:defaults.tm:` + Terramate(
+			Config(
+				Run(
+					StackDefaults(
+						Str("timeout", "1h"),
+						Number("priority", 1),
+					),
+				),
+			),
+		).String(),
+		`f:infra/defaults.tm:` + Terramate(
+			Config(
+				Run(
+					StackDefaults(
+						Str("timeout", "30m"),
+					),
+				),
+			),
+		).String(),
+		"s:apps",
+		"s:infra/network",
+		"s:infra/db:priority=5",
+		"s:infra/db/replica:timeout=5m",
+	})
+
+	tmcli := NewCLI(t, s.RootDir())
+	AssertRunResult(t, tmcli.Run("debug", "show", "run-config"), RunExpected{
+		Stdout: `
+stack "/apps":
+	timeout  = 1h0m0s (terramate.config.run.stack_defaults at /)
+	priority = 1 (terramate.config.run.stack_defaults at /)
+
+stack "/infra/db":
+	timeout  = 30m0s (terramate.config.run.stack_defaults at /infra)
+	priority = 5 (stack block at /infra/db)
+
+stack "/infra/db/replica":
+	timeout  = 5m0s (stack block at /infra/db/replica)
+	priority = 1 (terramate.config.run.stack_defaults at /)
+
+stack "/infra/network":
+	timeout  = 30m0s (terramate.config.run.stack_defaults at /infra)
+	priority = 1 (terramate.config.run.stack_defaults at /)
+`,
+	})
+}
+
+func TestDebugShowRunConfigDefaults(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.NoGit(t, true)
+	s.BuildTree([]string{"s:stack"})
+
+	tmcli := NewCLI(t, s.RootDir())
+	AssertRunResult(t, tmcli.Run("debug", "show", "run-config"), RunExpected{
+		Stdout: `
+stack "/stack":
+	timeout  = none (default)
+	priority = 0 (default)
+`,
+	})
+}
+
+func TestRunStackTimeout(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.New(t)
+	s.BuildTree([]string{
+		`f:defaults.tm:` + Terramate(
+			Config(
+				Run(
+					StackDefaults(
+						Str("timeout", "1s"),
+					),
+				),
+			),
+		).String(),
+		"s:a-inherited",
+		"s:b-overridden:timeout=1m",
+	})
+	s.Git().CommitAll("create stacks")
+
+	tmcli := NewCLI(t, s.RootDir())
+	AssertRunResult(t, tmcli.Run("run", "--quiet", "--", HelperPath, "sleep", "100ms"), RunExpected{
+		Stdout: nljoin("ready", "ready"),
+	})
+
+	AssertRunResult(t, tmcli.Run("run", "--quiet", "--continue-on-error", "--", HelperPath, "sleep", "3s"), RunExpected{
+		Status:      1,
+		Stdout:      nljoin("ready", "ready"),
+		StderrRegex: `helper sleep 3s \(in /a-inherited\) exceeded the timeout of 1s`,
+	})
+}
+
+func TestRunOrderStackPriority(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.NoGit(t, true)
+	s.BuildTree([]string{
+		`f:defaults.tm:` + Terramate(
+			Config(
+				Run(
+					StackDefaults(
+						Number("priority", 1),
+					),
+				),
+			),
+		).String(),
+		"s:a",
+		"s:b:priority=10",
+		"s:c:priority=0",
+		"s:d:after=[\"/b\"]",
+	})
+
+	tmcli := NewCLI(t, s.RootDir())
+	AssertRunResult(t, tmcli.Run("list", "--run-order"), RunExpected{
+		Stdout: nljoin("b", "a", "d", "c"),
+	})
+}
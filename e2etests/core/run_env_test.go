@@ -397,3 +397,170 @@ func TestRunEnv(t *testing.T) {
 		})
 	}
 }
+
+func TestRunIsolateDataDir(t *testing.T) {
+	t.Parallel()
+
+	type testcase struct {
+		name   string
+		layout []string
+		flags  []string
+		want   func(rootdir string) RunExpected
+	}
+
+	datadir := func(rootdir, id string) string {
+		return filepath.Join(rootdir, ".terramate", "data", id)
+	}
+
+	for _, tc := range []testcase{
+		{
+			name: "disabled by default",
+			layout: []string{
+				"s:s1:id=s1-id",
+			},
+			want: func(string) RunExpected {
+				return RunExpected{}
+			},
+		},
+		{
+			name: "enabled with --isolate-data-dir",
+			layout: []string{
+				"s:s1:id=s1-id",
+				"s:s2:id=s2-id",
+			},
+			flags: []string{"--isolate-data-dir"},
+			want: func(rootdir string) RunExpected {
+				return RunExpected{
+					Stdout: nljoin(
+						"/s1: "+datadir(rootdir, "s1-id"),
+						"/s2: "+datadir(rootdir, "s2-id"),
+					),
+				}
+			},
+		},
+		{
+			name: "enabled with terramate.config.run.isolate_data_dir",
+			layout: []string{
+				`f:root.tm:` + Terramate(
+					Config(
+						Run(
+							Bool("isolate_data_dir", true),
+						),
+					),
+				).String(),
+				"s:s1:id=s1-id",
+			},
+			want: func(rootdir string) RunExpected {
+				return RunExpected{
+					Stdout: nljoin("/s1: " + datadir(rootdir, "s1-id")),
+				}
+			},
+		},
+		{
+			name: "TF_DATA_DIR defined in run env has precedence",
+			layout: []string{
+				"s:s1:id=s1-id",
+				"s:s2:id=s2-id",
+				`f:s2/env.tm:` + Terramate(
+					Config(
+						Run(
+							Env(
+								Str("TF_DATA_DIR", "custom"),
+							),
+						),
+					),
+				).String(),
+			},
+			flags: []string{"--isolate-data-dir"},
+			want: func(rootdir string) RunExpected {
+				return RunExpected{
+					Stdout: nljoin(
+						"/s1: "+datadir(rootdir, "s1-id"),
+						"/s2: custom",
+					),
+				}
+			},
+		},
+		{
+			name: "fails if a stack has no id",
+			layout: []string{
+				"s:s1:id=s1-id",
+				"s:s2",
+			},
+			flags: []string{"--isolate-data-dir"},
+			want: func(string) RunExpected {
+				return RunExpected{
+					Status:      1,
+					StderrRegex: "stack /s2 has no id",
+				}
+			},
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			s := sandbox.New(t)
+			s.BuildTree(tc.layout)
+			git := s.Git()
+			git.CommitAll("everything")
+
+			tmcli := NewCLI(t, s.RootDir())
+			args := append([]string{"run", "--quiet"}, tc.flags...)
+			args = append(args, "--", HelperPath, "env", s.RootDir(), "TF_DATA_DIR")
+			want := tc.want(s.RootDir())
+			AssertRunResult(t, tmcli.Run(args...), want)
+
+			// the data directories are ignored by git, then running again
+			// doesn't fail because of untracked files.
+			AssertRunResult(t, tmcli.Run(args...), want)
+		})
+	}
+}
+
+func TestRunIsolateDataDirMetadata(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.NoGit(t, true)
+	s.BuildTree([]string{
+		"s:s1:id=s1-id",
+	})
+
+	tmcli := NewCLI(t, s.RootDir())
+	AssertRunResult(t, tmcli.Run(
+		"run", "--quiet", "--eval", "--", HelperPath, "echo", "${terramate.stack.data_dir}",
+	), RunExpected{
+		Stdout: nljoin("/.terramate/data/s1-id"),
+	})
+}
+
+func TestRunEnvNamespaceInGenerate(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.NoGit(t, true)
+	s.BuildTree([]string{
+		"s:stack",
+		`f:terramate.tm:terramate {
+		  config {
+		    run {
+		      env {
+		        TF_PLUGIN_CACHE_DIR = "${terramate.root.path.fs.absolute}/.cache"
+		      }
+		    }
+		  }
+		}`,
+		`f:stack/generate.tm:generate_file "cache_dir.txt" {
+		  content = runenv.TF_PLUGIN_CACHE_DIR
+		}`,
+	})
+
+	tmcli := NewCLI(t, s.RootDir())
+	AssertRunResult(t, tmcli.Run("generate"), RunExpected{IgnoreStdout: true})
+
+	want := filepath.Join(s.RootDir(), ".cache")
+	AssertRunResult(t, tmcli.Run("run", "--quiet", "--", HelperPath, "cat", "cache_dir.txt"), RunExpected{
+		Stdout: want,
+	})
+	AssertRunResult(t, tmcli.Run("run", "--quiet", "--", HelperPath, "env", s.RootDir(), "TF_PLUGIN_CACHE_DIR"), RunExpected{
+		Stdout: nljoin("/stack: " + want),
+	})
+}
@@ -0,0 +1,137 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package core_test
+
+import (
+	"testing"
+
+	. "github.com/terramate-io/terramate/e2etests/internal/runner"
+	"github.com/terramate-io/terramate/test/sandbox"
+)
+
+func TestErrorOnEmptySelection(t *testing.T) {
+	t.Parallel()
+
+	setup := func(t *testing.T) sandbox.S {
+		s := sandbox.New(t)
+		s.BuildTree([]string{
+			`f:terramate.tm:
+			terramate {
+			  config {
+			    experiments = ["scripts"]
+			  }
+			}`,
+			`f:script.tm:
+			script "hello" {
+			  job {
+			    command = ["echo", "hello ${terramate.stack.name}"]
+			  }
+			}`,
+			`s:stacks/a:tags=["app"]`,
+			"s:stacks/b",
+		})
+		git := s.Git()
+		git.CommitAll("everything")
+		git.Push("main")
+		return s
+	}
+
+	emptyByTags := RunExpected{
+		Status: 3,
+		StderrRegexes: []string{
+			"Error: no stacks selected",
+			"working directory: /",
+			"--tags infra",
+		},
+	}
+
+	t.Run("run exits with 3 if no stacks are selected by tags", func(t *testing.T) {
+		t.Parallel()
+		s := setup(t)
+		cli := NewCLI(t, s.RootDir())
+		AssertRunResult(t, cli.Run("run", "--quiet", "--error-on-empty", "--tags", "infra", "--", HelperPath, "echo", "hi"), emptyByTags)
+	})
+
+	t.Run("run --dry-run exits with 3 if no stacks are selected", func(t *testing.T) {
+		t.Parallel()
+		s := setup(t)
+		cli := NewCLI(t, s.RootDir())
+		AssertRunResult(t, cli.Run("run", "--quiet", "--dry-run", "--error-on-empty", "--tags", "infra", "--", HelperPath, "echo", "hi"), emptyByTags)
+	})
+
+	t.Run("script run exits with 3 if no stacks are selected by tags", func(t *testing.T) {
+		t.Parallel()
+		s := setup(t)
+		cli := NewCLI(t, s.RootDir())
+		AssertRunResult(t, cli.Run("script", "run", "--quiet", "--error-on-empty", "--tags", "infra", "hello"), emptyByTags)
+	})
+
+	t.Run("list exits with 3 if no stacks are selected by tags", func(t *testing.T) {
+		t.Parallel()
+		s := setup(t)
+		cli := NewCLI(t, s.RootDir())
+		AssertRunResult(t, cli.Run("list", "--error-on-empty", "--tags", "infra"), emptyByTags)
+	})
+
+	t.Run("run exits with 3 if no stacks are changed", func(t *testing.T) {
+		t.Parallel()
+		s := setup(t)
+		git := s.Git()
+		git.CheckoutNew("change")
+		s.RootEntry().CreateFile("README.md", "# not a stack\n")
+		git.CommitAll("change outside of the stacks")
+
+		cli := NewCLI(t, s.RootDir())
+		AssertRunResult(t, cli.Run("run", "--quiet", "--changed", "--error-on-empty", "--", HelperPath, "echo", "hi"), RunExpected{
+			Status: 3,
+			StderrRegexes: []string{
+				"Error: no stacks selected",
+				"--changed against",
+			},
+		})
+		AssertRunResult(t, cli.Run("list", "--changed", "--error-on-empty"), RunExpected{
+			Status:      3,
+			StderrRegex: "--changed against",
+		})
+	})
+
+	t.Run("script run reports the script disabled in all the selected stacks", func(t *testing.T) {
+		t.Parallel()
+		s := setup(t)
+		s.DirEntry("stacks/a").CreateFile("script.tm", `script "hello" {
+  enabled = false
+}
+`)
+		s.Git().CommitAll("disable the script")
+		cli := NewCLI(t, s.RootDir())
+		AssertRunResult(t, cli.Run("script", "run", "--quiet", "--error-on-empty", "--tags", "app", "hello"), RunExpected{
+			Status:        3,
+			StderrRegex:   `Error: script "hello" is disabled in all the 1 selected stack\(s\)`,
+			NoStderrRegex: "no stacks selected",
+		})
+	})
+
+	t.Run("empty selection succeeds without the flag", func(t *testing.T) {
+		t.Parallel()
+		s := setup(t)
+		cli := NewCLI(t, s.RootDir())
+		AssertRunResult(t, cli.Run("run", "--quiet", "--tags", "infra", "--", HelperPath, "echo", "hi"), RunExpected{})
+		AssertRunResult(t, cli.Run("list", "--tags", "infra"), RunExpected{})
+	})
+
+	t.Run("non-empty selection runs the stacks", func(t *testing.T) {
+		t.Parallel()
+		s := setup(t)
+		cli := NewCLI(t, s.RootDir())
+		AssertRunResult(t, cli.Run("run", "--quiet", "--error-on-empty", "--tags", "app", "--", HelperPath, "echo", "hi"), RunExpected{
+			Stdout: nljoin("hi"),
+		})
+		AssertRunResult(t, cli.Run("script", "run", "--quiet", "--error-on-empty", "--tags", "app", "hello"), RunExpected{
+			Stdout: nljoin("hello a"),
+		})
+		AssertRunResult(t, cli.Run("list", "--error-on-empty", "--tags", "app"), RunExpected{
+			Stdout: nljoin("stacks/a"),
+		})
+	})
+}
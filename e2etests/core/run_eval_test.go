@@ -75,7 +75,7 @@ func TestRunEval(t *testing.T) {
 			layout: []string{`s:stack`},
 			eval:   true,
 			args: []string{
-				`test $${tm_upper(\"hcl\")}`,
+				`test $${tm_upper("hcl")}`,
 				`%%{ for i in tm_range(5) ~} some $${i} %%{endfor}`,
 			},
 			want: RunExpected{
@@ -83,32 +83,40 @@ func TestRunEval(t *testing.T) {
 			},
 		},
 		{
-			// WHY? When using --eval, each argument is interpreted as
-			// a raw HCL string content, which means it's the same as:
-			//   OQUOTE ARG CQUOTE
-			// Then if the user wants to include a literal quote, it must be
-			// escaped:
-			//   OQUOTE \" CQUOTE
-			name:   "malformed hcl string",
+			name:   "quotes are kept as is",
 			layout: []string{`s:stack`},
 			eval:   true,
 			args: []string{
 				`"`,
+				`{"stack":"${terramate.stack.path.absolute}"}`,
 			},
 			want: RunExpected{
-				Status:      1,
-				StderrRegex: `parsing expression`,
+				Stdout: "\" {\"stack\":\"/stack\"}\n",
 			},
 		},
 		{
-			name:   "escaped quote return as is",
+			name:   "backslashes are kept as is",
 			layout: []string{`s:stack`},
 			eval:   true,
 			args: []string{
 				`\"`,
+				`C:\Users\${terramate.stack.name}\plan.tfplan`,
+			},
+			want: RunExpected{
+				Stdout: `\" C:\Users\stack\plan.tfplan` + "\n",
+			},
+		},
+		{
+			name:   "malformed interpolation fails with the argument index",
+			layout: []string{`s:stack`},
+			eval:   true,
+			args: []string{
+				`ok`,
+				`${terramate.stack.name`,
 			},
 			want: RunExpected{
-				Stdout: "\"\n",
+				Status:      1,
+				StderrRegex: `stack /stack: parsing argument 3`,
 			},
 		},
 		{
@@ -138,24 +146,9 @@ func TestRunEval(t *testing.T) {
 				cmd = append(cmd, `--eval`)
 			}
 
-			// WHY?
 			// we are executing: terramate run --eval -- <helper test binary> echo <arg1, ..., argN>
-			// the problem is that --eval requires each argument to be a valid
-			// HCL string but then on Windows we cannot just paste the test binary
-			// path here because it's not a valid HCL string. Example:
-			//   terramate run --eval -- C:\Users\i4k\test.exe arg1 arg2
-			// This will construct the HCL expression below:
-			//   "C:\Users\i4k\test.exe"
-			// and then the HCL parser fails because it's gonna interpret \U as an
-			// invalid unicode sequence.
-			//
-			// The user will have to properly escape it like:
-			//   terramate run --eval -- C:\\Users\\i4k\\test.exe arg1 arg2
-			//
-			// To avoid escaping everything, we are prepending the helper
-			// binary directory to the PATH environment and then invoking
-			// just the basename.
-			//   PATH=$HELPER_DIR:$PATH terramate run --eval -- test.exe echo arg1 arg2
+			// The helper binary directory is prepended to the PATH environment
+			// and only its basename is used.
 			testHelperDir := filepath.Dir(HelperPath)
 			testHelperName := filepath.Base(HelperPath)
 			tmCli.PrependToPath(testHelperDir)
@@ -0,0 +1,135 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+//go:build !darwin
+
+package core_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/madlambda/spells/assert"
+	. "github.com/terramate-io/terramate/e2etests/internal/runner"
+	"github.com/terramate-io/terramate/test"
+	. "github.com/terramate-io/terramate/test/hclwrite/hclutils"
+	"github.com/terramate-io/terramate/test/sandbox"
+)
+
+func TestRunLockingPreventsConcurrentRuns(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.New(t)
+	s.BuildTree([]string{
+		"s:stacks/a:id=a",
+		"s:stacks/b:id=b",
+		`f:root.tm:` + Terramate(
+			Config(
+				Run(
+					Bool("locking", true),
+				),
+			),
+		).String(),
+	})
+	git := s.Git()
+	git.CommitAll("first commit")
+
+	lockfile := filepath.Join(s.RootDir(), ".terramate", "locks", "a.lock")
+
+	tmA := NewCLI(t, filepath.Join(s.RootDir(), "stacks", "a"))
+	tmB := NewCLI(t, filepath.Join(s.RootDir(), "stacks", "b"))
+
+	// the first run holds the lock of stacks/a while the others are executed.
+	cmd := tmA.NewCmd("run", "--quiet", HelperPath, "sleep", "10s")
+	cmd.Start()
+
+	errs := make(chan error)
+	go func() {
+		errs <- cmd.Wait()
+		close(errs)
+	}()
+
+	assert.NoError(t,
+		PollBufferForMsgs(cmd.Stdout, errs, "ready"),
+		"failed to start: %s", cmd.Stderr.String(),
+	)
+	test.IsFile(t, filepath.Dir(lockfile), filepath.Base(lockfile))
+
+	AssertRunResult(t,
+		tmA.Run("run", "--quiet", HelperPath, "echo", "concurrent"),
+		RunExpected{
+			Status:      1,
+			StderrRegex: "stack /stacks/a is locked by pid",
+		},
+	)
+
+	AssertRunResult(t,
+		tmA.Run("run", "--dry-run", "--quiet", HelperPath, "echo", "dry-run"),
+		RunExpected{},
+	)
+
+	AssertRunResult(t,
+		tmB.Run("run", "--quiet", HelperPath, "echo", "other stack"),
+		RunExpected{Stdout: "other stack\n"},
+	)
+
+	assert.NoError(t, <-errs, "first run failed: %s", cmd.Stderr.String())
+
+	_, err := os.Stat(lockfile)
+	assert.IsTrue(t, os.IsNotExist(err), "lock file must be removed: %v", err)
+
+	AssertRunResult(t,
+		tmA.Run("run", "--quiet", HelperPath, "echo", "after"),
+		RunExpected{Stdout: "after\n"},
+	)
+}
+
+func TestRunLockingStealsStaleLock(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.New(t)
+	s.BuildTree([]string{
+		"s:stack:id=stack",
+		`f:root.tm:` + Terramate(
+			Config(
+				Run(
+					Bool("locking", true),
+					Str("lock_ttl", "1m"),
+				),
+			),
+		).String(),
+	})
+	git := s.Git()
+	git.CommitAll("first commit")
+
+	tm := NewCLI(t, filepath.Join(s.RootDir(), "stack"))
+
+	// a previous run creates the lock directory and its .gitignore.
+	AssertRunResult(t,
+		tm.Run("run", "--quiet", HelperPath, "true"),
+		RunExpected{},
+	)
+
+	lockdir := filepath.Join(s.RootDir(), ".terramate", "locks")
+	staleLock, err := json.Marshal(map[string]any{
+		"pid":        1,
+		"hostname":   "other-host",
+		"created_at": time.Now().UTC().Add(-time.Hour),
+	})
+	assert.NoError(t, err)
+	test.WriteFile(t, lockdir, "stack.lock", string(staleLock))
+
+	AssertRunResult(t,
+		tm.Run("run", "--quiet", HelperPath, "echo", "hello"),
+		RunExpected{
+			Stdout:      "hello\n",
+			StderrRegex: "stole the stale lock of pid 1 on host other-host",
+		},
+	)
+
+	_, err = os.Stat(filepath.Join(lockdir, "stack.lock"))
+	assert.IsTrue(t, os.IsNotExist(err), "lock file must be removed: %v", err)
+}
@@ -82,6 +82,30 @@ func TestParallelBug1828Regression(t *testing.T) {
 	)
 }
 
+func TestParallelDryRunShowsStartOrder(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.NoGit(t, true)
+	s.BuildTree([]string{
+		`s:a`,
+		`s:b`,
+		`s:big:priority=10;after=["/a"]`,
+		`s:c:priority=1`,
+	})
+	tmcli := NewCLI(t, s.RootDir())
+	AssertRunResult(t,
+		tmcli.Run("run", "--parallel=2", "--dry-run", "--", HelperPath, "true"),
+		RunExpected{
+			StderrRegex: regexp.QuoteMeta(`terramate: (dry-run) Start order with --parallel=2:
+terramate: (dry-run)   1. /c (priority 1)
+terramate: (dry-run)   2. /a (priority 0)
+terramate: (dry-run)   3. /big (priority 10)
+terramate: (dry-run)   4. /b (priority 0)
+`),
+		},
+	)
+}
+
 func TestParalleCmdNotFoundContinueOnError(t *testing.T) {
 	s := sandbox.NoGit(t, true)
 	layout := []string{}
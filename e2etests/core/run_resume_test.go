@@ -0,0 +1,250 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package core_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/madlambda/spells/assert"
+	. "github.com/terramate-io/terramate/e2etests/internal/runner"
+	"github.com/terramate-io/terramate/run/state"
+	"github.com/terramate-io/terramate/test/sandbox"
+)
+
+var resumeHintRegex = regexp.MustCompile("terramate run --resume ([0-9a-f-]{36})")
+
+func TestRunResumeFailedStacks(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.New(t)
+	s.BuildTree([]string{
+		`f:globals.tm:globals {
+		  cmd = "echo"
+		}`,
+		`s:a`,
+		`s:b:after=["/a"]`,
+		`s:c`,
+		`s:d`,
+		`s:e`,
+		`f:b/globals.tm:globals {
+		  cmd = "false"
+		}`,
+		`f:c/globals.tm:globals {
+		  cmd = "false"
+		}`,
+	})
+	git := s.Git()
+	git.CommitAll("first commit")
+
+	tmcli := NewCLI(t, s.RootDir())
+	res := tmcli.Run("run", "--continue-on-error", "--exclude=/e", "--eval", "--",
+		HelperPathAsHCL, "${global.cmd}", "${terramate.stack.path.absolute}")
+	AssertRunResult(t, res, RunExpected{
+		Status:      1,
+		Stdout:      "/a\n/d\n",
+		StderrRegex: resumeHintRegex.String(),
+	})
+	runID := resumeHintRegex.FindStringSubmatch(res.Stderr)[1]
+
+	assertRunState(t, s, runID, []state.Stack{
+		{Path: "/a", Status: state.Success},
+		{Path: "/b", Status: state.Failed},
+		{Path: "/c", Status: state.Failed},
+		{Path: "/d", Status: state.Success},
+	})
+
+	// /b is fixed but /c still fails.
+	s.DirEntry("b").RemoveFile("globals.tm")
+	git.CommitAll("fix b")
+
+	res = tmcli.Run("run", "--quiet", "--resume", runID, "--",
+		HelperPathAsHCL, "${global.cmd}", "${terramate.stack.path.absolute}")
+	AssertRunResult(t, res, RunExpected{
+		Status:      1,
+		Stdout:      "/b\n",
+		StderrRegex: "one or more commands failed",
+	})
+	assertRunState(t, s, runID, []state.Stack{
+		{Path: "/a", Status: state.Success},
+		{Path: "/b", Status: state.Success},
+		{Path: "/c", Status: state.Failed},
+		{Path: "/d", Status: state.Success},
+	})
+
+	s.DirEntry("c").RemoveFile("globals.tm")
+	git.CommitAll("fix c")
+
+	// without the ID, the last run is resumed.
+	AssertRunResult(t, tmcli.Run("run", "--quiet", "--resume", "--",
+		HelperPathAsHCL, "${global.cmd}", "${terramate.stack.path.absolute}"), RunExpected{
+		Stdout: "/c\n",
+	})
+
+	// the state is removed when all the stacks succeed.
+	_, err := os.Stat(runStatePath(s, runID))
+	assert.IsTrue(t, os.IsNotExist(err), "run state must be removed: %v", err)
+}
+
+func TestRunResumeKeepsOrder(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.New(t)
+	s.BuildTree([]string{
+		`f:globals.tm:globals {
+		  cmd = "echo"
+		}`,
+		`s:a`,
+		`s:b:after=["/a"]`,
+		`s:c:after=["/b"]`,
+		`s:d:before=["/a"]`,
+		`f:b/globals.tm:globals {
+		  cmd = "false"
+		}`,
+	})
+	git := s.Git()
+	git.CommitAll("first commit")
+
+	tmcli := NewCLI(t, s.RootDir())
+	res := tmcli.Run("run", "--reverse", "--eval", "--",
+		HelperPathAsHCL, "${global.cmd}", "${terramate.stack.path.absolute}")
+	AssertRunResult(t, res, RunExpected{
+		Status:      1,
+		Stdout:      "/c\n",
+		StderrRegex: resumeHintRegex.String(),
+	})
+	runID := resumeHintRegex.FindStringSubmatch(res.Stderr)[1]
+
+	s.DirEntry("b").RemoveFile("globals.tm")
+	git.CommitAll("fix b")
+
+	// the stored --reverse is honored.
+	AssertRunResult(t, tmcli.Run("run", "--quiet", "--resume", runID, "--",
+		HelperPathAsHCL, "${global.cmd}", "${terramate.stack.path.absolute}"), RunExpected{
+		Stdout: "/b\n/a\n/d\n",
+	})
+}
+
+func TestRunResumeDryRunDoesNotSaveState(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.New(t)
+	s.BuildTree([]string{
+		`s:a`,
+		`s:b`,
+	})
+	s.Git().CommitAll("first commit")
+
+	tmcli := NewCLI(t, s.RootDir())
+	AssertRunResult(t, tmcli.Run("run", "--quiet", "--dry-run", "--", HelperPath, "false"), RunExpected{})
+	_, err := os.Stat(filepath.Join(s.RootDir(), filepath.FromSlash(state.Dir)))
+	assert.IsTrue(t, os.IsNotExist(err), "dry-run must not save the run state: %v", err)
+
+	res := tmcli.Run("run", "--", HelperPath, "false")
+	AssertRunResult(t, res, RunExpected{
+		Status:      1,
+		StderrRegex: resumeHintRegex.String(),
+	})
+	runID := resumeHintRegex.FindStringSubmatch(res.Stderr)[1]
+
+	before, err := os.ReadFile(runStatePath(s, runID))
+	assert.NoError(t, err)
+
+	AssertRunResult(t, tmcli.Run("run", "--resume", "--dry-run", runID, "--", HelperPath, "false"), RunExpected{
+		IgnoreStderr: true,
+	})
+
+	after, err := os.ReadFile(runStatePath(s, runID))
+	assert.NoError(t, err)
+	assert.EqualStrings(t, string(before), string(after), "dry-run must not change the run state")
+}
+
+func TestRunResumeErrors(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.New(t)
+	s.BuildTree([]string{
+		`s:a`,
+	})
+	s.Git().CommitAll("first commit")
+
+	tmcli := NewCLI(t, s.RootDir())
+	AssertRunResult(t, tmcli.Run("run", "--resume", "--", HelperPath, "true"), RunExpected{
+		Status:      1,
+		StderrRegex: "no runs found",
+	})
+	AssertRunResult(t, tmcli.Run("run", "--resume", "b5e0e3c5-ea7e-4a5e-a1a4-d2c8d1a9b93d", "--", HelperPath, "true"), RunExpected{
+		Status:      1,
+		StderrRegex: string(state.ErrNotFound),
+	})
+	AssertRunResult(t, tmcli.Run("run", "--resume"), RunExpected{
+		Status:      1,
+		StderrRegex: "--resume expects the command to run",
+	})
+	AssertRunResult(t, tmcli.Run("run", "--resume", "b5e0e3c5-ea7e-4a5e-a1a4-d2c8d1a9b93d"), RunExpected{
+		Status:      1,
+		StderrRegex: "--resume expects the command to run",
+	})
+	AssertRunResult(t, tmcli.Run("run"), RunExpected{
+		Status:      1,
+		StderrRegex: "no command specified",
+	})
+}
+
+func TestRunResumeDoesNotSaveCommand(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.New(t)
+	s.BuildTree([]string{
+		`s:a`,
+	})
+	s.Git().CommitAll("first commit")
+
+	tmcli := NewCLI(t, s.RootDir())
+	res := tmcli.Run("run", "--reverse", "--", HelperPath, "false", "-var", "password=hunter2")
+	AssertRunResult(t, res, RunExpected{
+		Status:      1,
+		StderrRegex: resumeHintRegex.String(),
+	})
+	runID := resumeHintRegex.FindStringSubmatch(res.Stderr)[1]
+
+	data, err := os.ReadFile(runStatePath(s, runID))
+	assert.NoError(t, err)
+	assert.IsTrue(t, !strings.Contains(string(data), "hunter2"), "run state must not have the command: %s", data)
+
+	var got state.Run
+	assert.NoError(t, json.Unmarshal(data, &got))
+	var args struct {
+		Reverse bool
+		Command []string
+	}
+	assert.NoError(t, json.Unmarshal(got.Args, &args))
+	assert.IsTrue(t, args.Reverse, "run state must have the flags: %s", got.Args)
+	assert.EqualInts(t, 0, len(args.Command), "run state must not have the command: %v", args.Command)
+}
+
+func runStatePath(s sandbox.S, runID string) string {
+	return filepath.Join(s.RootDir(), filepath.FromSlash(state.Dir), runID+".json")
+}
+
+func assertRunState(t *testing.T, s sandbox.S, runID string, want []state.Stack) {
+	t.Helper()
+
+	data, err := os.ReadFile(runStatePath(s, runID))
+	assert.NoError(t, err)
+
+	var got state.Run
+	assert.NoError(t, json.Unmarshal(data, &got))
+	assert.EqualStrings(t, runID, got.ID)
+	assert.IsTrue(t, got.SelectionComplete, "selection must be complete")
+	assert.EqualInts(t, len(want), len(got.Stacks), "stacks: %v", got.Stacks)
+	for i, st := range want {
+		assert.EqualStrings(t, st.Path, got.Stacks[i].Path)
+		assert.EqualStrings(t, string(st.Status), string(got.Stacks[i].Status), "stack %s", st.Path)
+	}
+}
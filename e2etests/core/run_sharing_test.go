@@ -886,3 +886,110 @@ func TestRunOutputDependencies(t *testing.T) {
 		}
 	})
 }
+
+func TestChangedPropagationThroughOutputDependencies(t *testing.T) {
+	t.Parallel()
+
+	// /a <- /b <- /c (input dependencies), /d is unrelated.
+	setupSandbox := func(t *testing.T, propagate bool) sandbox.S {
+		s := sandbox.New(t)
+		cfg := Config(Experiments(hcl.SharingIsCaringExperimentName))
+		if propagate {
+			cfg = Config(
+				Experiments(hcl.SharingIsCaringExperimentName),
+				Block("change_detection", Bool("propagate_outputs", true)),
+			)
+		}
+		s.BuildTree([]string{
+			`f:terramate.tm:` + Terramate(cfg).String(),
+			`f:sharing.tm:` + Block("sharing_backend",
+				Labels("default"),
+				Expr("type", "terraform"),
+				Command("terraform", "output", "-json"),
+				Str("filename", "_sharing.tf"),
+			).String(),
+			"s:a:id=a",
+			"s:b:id=b",
+			"s:c:id=c",
+			"s:d:id=d",
+		})
+		for _, dep := range []struct{ stack, from string }{
+			{"b", "a"},
+			{"c", "b"},
+		} {
+			s.RootEntry().CreateFile(dep.from+"/outputs.tm", Block("output",
+				Labels("output1"),
+				Str("backend", "default"),
+				Expr("value", "some.value"),
+			).String())
+			s.RootEntry().CreateFile(dep.stack+"/inputs.tm", Block("input",
+				Labels("input1"),
+				Str("backend", "default"),
+				Expr("value", "outputs.output1.value"),
+				Str("from_stack_id", dep.from),
+			).String())
+		}
+		s.Generate()
+		s.Git().CommitAll("initial commit")
+		s.Git().Push("main")
+		s.Git().CheckoutNew("change")
+		return s
+	}
+
+	t.Run("changes propagate transitively to input dependents", func(t *testing.T) {
+		t.Parallel()
+		s := setupSandbox(t, true)
+		s.DirEntry("a").CreateFile("main.tf", "# changed file")
+		s.Git().CommitAll("change a")
+
+		cli := NewCLI(t, s.RootDir())
+		AssertRunResult(t, cli.Run("list", "--changed", "--why"), RunExpected{
+			Stdout: nljoin(
+				"a - stack has unmerged changes",
+				"b - input dependency changed: stack /a",
+				"c - input dependency changed: stack /b",
+			),
+		})
+	})
+
+	t.Run("changes propagate only to the dependents of the changed stack", func(t *testing.T) {
+		t.Parallel()
+		s := setupSandbox(t, true)
+		s.DirEntry("b").CreateFile("main.tf", "# changed file")
+		s.Git().CommitAll("change b")
+
+		cli := NewCLI(t, s.RootDir())
+		AssertRunResult(t, cli.Run("list", "--changed"), RunExpected{
+			Stdout: nljoin("b", "c"),
+		})
+	})
+
+	t.Run("changes do not propagate by default", func(t *testing.T) {
+		t.Parallel()
+		s := setupSandbox(t, false)
+		s.DirEntry("a").CreateFile("main.tf", "# changed file")
+		s.Git().CommitAll("change a")
+
+		cli := NewCLI(t, s.RootDir())
+		AssertRunResult(t, cli.Run("list", "--changed"), RunExpected{
+			Stdout: nljoin("a"),
+		})
+	})
+
+	t.Run("propagated stacks pull their output dependencies", func(t *testing.T) {
+		t.Parallel()
+		s := setupSandbox(t, true)
+		s.DirEntry("a").CreateFile("main.tf", "# changed file")
+		s.Git().CommitAll("change a")
+
+		cli := NewCLI(t, s.RootDir())
+		AssertRunResult(t, cli.Run("run", "--quiet", "--changed", "--include-output-dependencies",
+			"--", HelperPath, "stack-abs-path", s.RootDir()), RunExpected{
+			Stdout: nljoin("/a", "/b", "/c"),
+		})
+		AssertRunResult(t, cli.Run("run", "--quiet", "--changed", "--only-output-dependencies",
+			"--", HelperPath, "stack-abs-path", s.RootDir()), RunExpected{
+			Stdout: nljoin("/a", "/b"),
+		})
+	})
+}
@@ -14,6 +14,7 @@ import (
 
 	"github.com/madlambda/spells/assert"
 	. "github.com/terramate-io/terramate/e2etests/internal/runner"
+	"github.com/terramate-io/terramate/run/state"
 	"github.com/terramate-io/terramate/test/sandbox"
 )
 
@@ -221,3 +222,63 @@ func testSIGINTx1(t *testing.T, continueOnError bool) {
 	t.Logf("terramate stdout:\n%s\n", cmd.Stdout.String())
 	t.Logf("terramate stderr:\n%s\n", cmd.Stderr.String())
 }
+
+func TestRunResumeAfterKill(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.New(t)
+	s.BuildTree([]string{
+		`f:globals.tm:globals {
+		  cmd = "echo"
+		}`,
+		`s:s1`,
+		`s:s2`,
+		`s:s3`,
+		`s:s4`,
+		`s:s5`,
+		`f:s3/globals.tm:globals {
+		  cmd = "hang"
+		}`,
+	})
+	git := s.Git()
+	git.CommitAll("first commit")
+
+	tm := NewCLI(t, s.RootDir())
+	cmd := tm.NewCmd("run", "--eval", HelperPathAsHCL, "${global.cmd}", "${terramate.stack.path.absolute}")
+	cmd.Setpgid()
+	cmd.Start()
+
+	errs := make(chan error)
+	go func() {
+		errs <- cmd.Wait()
+		close(errs)
+	}()
+
+	assert.NoError(t,
+		PollBufferForMsgs(cmd.Stdout, errs, "/s1", "/s2", "ready"),
+		"failed to start: %s", cmd.Stderr.String(),
+	)
+
+	// the whole process group is killed, so terramate has no chance to
+	// save anything after the stack started.
+	cmd.SignalGroup(os.Kill)
+	assert.Error(t, <-errs)
+
+	f, err := state.Latest(s.RootDir())
+	assert.NoError(t, err)
+	assertRunState(t, s, f.Run().ID, []state.Stack{
+		{Path: "/s1", Status: state.Success},
+		{Path: "/s2", Status: state.Success},
+		{Path: "/s3", Status: state.Running},
+		{Path: "/s4", Status: state.Pending},
+		{Path: "/s5", Status: state.Pending},
+	})
+
+	s.DirEntry("s3").RemoveFile("globals.tm")
+	git.CommitAll("fix s3")
+
+	AssertRunResult(t, tm.Run("run", "--quiet", "--resume", "--",
+		HelperPathAsHCL, "${global.cmd}", "${terramate.stack.path.absolute}"), RunExpected{
+		Stdout: "/s3\n/s4\n/s5\n",
+	})
+}
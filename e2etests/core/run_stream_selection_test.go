@@ -0,0 +1,160 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package core_test
+
+import (
+	"slices"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/madlambda/spells/assert"
+	. "github.com/terramate-io/terramate/e2etests/internal/runner"
+	"github.com/terramate-io/terramate/test/sandbox"
+)
+
+func TestRunStreamSelectionMatchesFullSelection(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.New(t)
+	s.BuildTree([]string{
+		`f:modules/net/main.tf:# net module`,
+		`s:net`,
+		`f:net/main.tf:module "net" {
+		  source = "../modules/net"
+		}`,
+		`s:db:after=["/net"]`,
+		`s:app:after=["/db"]`,
+		`s:app/api`,
+		`s:cache:before=["/app"]`,
+		`s:other`,
+		`s:web:after=["/other"]`,
+		`s:mon:watch=["/config/mon.yaml"]`,
+		`f:config/mon.yaml:level: info`,
+		`f:db/main.tf:# db`,
+		`f:app/main.tf:# app`,
+		`f:app/api/main.tf:# api`,
+		`f:cache/main.tf:# cache`,
+		`f:other/main.tf:# other`,
+		`f:web/main.tf:# web`,
+	})
+
+	git := s.Git()
+	git.CommitAll("first commit")
+	git.Push("main")
+	git.CheckoutNew("change-stacks")
+
+	for _, file := range []string{
+		"modules/net/main.tf",
+		"db/main.tf",
+		"app/main.tf",
+		"app/api/main.tf",
+		"cache/main.tf",
+		"web/main.tf",
+		"config/mon.yaml",
+	} {
+		s.RootEntry().CreateFile(file, "# changed")
+	}
+	git.CommitAll("stacks changed")
+
+	wantSet := []string{"/app", "/app/api", "/cache", "/db", "/mon", "/net", "/web"}
+
+	// pairs of stacks which must run in this order.
+	constraints := [][2]string{
+		{"/net", "/db"},
+		{"/db", "/app"},
+		{"/cache", "/app"},
+		{"/app", "/app/api"},
+	}
+
+	tmcli := NewCLI(t, s.RootDir())
+
+	for _, flags := range [][]string{
+		nil,
+		{"--reverse"},
+		{"--parallel=4"},
+		{"--parallel=4", "--reverse"},
+	} {
+		run := func(extra ...string) []string {
+			args := append([]string{"run", "--quiet", "--changed"}, flags...)
+			args = append(args, extra...)
+			args = append(args, "--", HelperPath, "stack-abs-path", s.RootDir())
+			res := tmcli.Run(args...)
+			AssertRunResult(t, res, RunExpected{IgnoreStdout: true})
+			return strings.Split(strings.TrimSpace(res.Stdout), "\n")
+		}
+
+		full := run()
+		streamed := run("--stream-selection")
+
+		for _, order := range [][]string{full, streamed} {
+			got := slices.Clone(order)
+			sort.Strings(got)
+			assert.EqualStrings(t, strings.Join(wantSet, ","), strings.Join(got, ","),
+				"flags %v: executed stacks", flags)
+
+			for _, c := range constraints {
+				first, second := c[0], c[1]
+				if slices.Contains(flags, "--reverse") {
+					first, second = second, first
+				}
+				assert.IsTrue(t, slices.Index(order, first) < slices.Index(order, second),
+					"flags %v: %s must run before %s, order: %v", flags, first, second, order)
+			}
+		}
+
+		if !slices.Contains(flags, "--parallel=4") {
+			assert.EqualStrings(t, strings.Join(full, "\n"), strings.Join(streamed, "\n"),
+				"flags %v: sequential order", flags)
+		}
+	}
+}
+
+func TestRunStreamSelectionFallback(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.New(t)
+	s.BuildTree([]string{
+		`s:stack`,
+	})
+	s.Git().CommitAll("first commit")
+
+	tmcli := NewCLI(t, s.RootDir())
+	AssertRunResult(t, tmcli.Run("run", "--quiet", "--stream-selection", "--", HelperPath, "true"), RunExpected{
+		StderrRegex: `--stream-selection is ignored because it requires --changed`,
+	})
+}
+
+func TestRunStreamSelectionStopsOnFailedAssertion(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.New(t)
+	s.BuildTree([]string{
+		`s:a`,
+		`s:b:after=["/a"]`,
+		`f:a/main.tf:# a`,
+		`f:b/main.tf:# b`,
+		`f:b/assert.tm:assert {
+		  assertion = false
+		  message   = "b is not ready"
+		}`,
+	})
+
+	git := s.Git()
+	git.CommitAll("first commit")
+	git.Push("main")
+	git.CheckoutNew("change-stacks")
+	s.RootEntry().CreateFile("a/main.tf", "# changed")
+	s.RootEntry().CreateFile("b/main.tf", "# changed")
+	git.CommitAll("stacks changed")
+
+	tmcli := NewCLI(t, s.RootDir())
+	AssertRunResult(t, tmcli.Run("run", "--quiet", "--changed", "--stream-selection",
+		"--", HelperPath, "stack-abs-path", s.RootDir()), RunExpected{
+		Status: 1,
+		// /a may be started before /b is resolved, but /b never runs.
+		StdoutRegex: `^(/a\n)?$`,
+		StderrRegex: `b is not ready`,
+	})
+}
@@ -0,0 +1,185 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package core_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/madlambda/spells/assert"
+	. "github.com/terramate-io/terramate/e2etests/internal/runner"
+	"github.com/terramate-io/terramate/test/sandbox"
+)
+
+type runSummary struct {
+	DryRun     bool  `json:"dry_run"`
+	Total      int   `json:"total"`
+	Succeeded  int   `json:"succeeded"`
+	Failed     int   `json:"failed"`
+	Skipped    int   `json:"skipped"`
+	Canceled   int   `json:"canceled"`
+	DurationMS int64 `json:"duration_ms"`
+	Stacks     []struct {
+		Stack      string `json:"stack"`
+		Status     string `json:"status"`
+		ExitCode   *int   `json:"exit_code"`
+		DurationMS int64  `json:"duration_ms"`
+	} `json:"stacks"`
+}
+
+func TestRunSummary(t *testing.T) {
+	t.Parallel()
+
+	setup := func(t *testing.T) sandbox.S {
+		s := sandbox.NoGit(t, true)
+		s.BuildTree([]string{
+			`f:terramate.tm:
+			terramate {
+			  config {
+			    experiments = ["scripts"]
+			  }
+			}`,
+			`f:script.tm:
+			script "deploy" {
+			  job {
+			    command = ["` + HelperPath + `", "sleep", tm_ternary(terramate.stack.name == "slow", "200ms", "1ms")]
+			  }
+			}`,
+			"s:fast",
+			"s:slow",
+		})
+		return s
+	}
+
+	// the helper exits with the name of the stack as exit code.
+	evalExit := []string{"--eval", "--", HelperPathAsHCL, "exit", "${terramate.stack.name}"}
+
+	t.Run("text summary is printed to stderr with the slowest stacks", func(t *testing.T) {
+		t.Parallel()
+		s := setup(t)
+		cli := NewCLI(t, s.RootDir())
+		AssertRunResult(t, cli.Run("script", "run", "--quiet", "--summary", "text", "deploy"), RunExpected{
+			Stdout: nljoin("ready", "ready"),
+			StderrRegexes: []string{
+				`terramate: run summary: 2 stack\(s\), 2 succeeded, 0 failed, 0 skipped, 0 canceled in \S+`,
+				`Slowest stacks:\nSTACK\s+DURATION\s+EXIT CODE\n/slow\s+\S+\s+0\n/fast\s+\S+\s+0\n`,
+			},
+		})
+	})
+
+	t.Run("json summary is written to the summary file", func(t *testing.T) {
+		t.Parallel()
+		s := setup(t)
+		cli := NewCLI(t, s.RootDir())
+		summaryFile := filepath.Join(t.TempDir(), "summary.json")
+		AssertRunResult(t, cli.Run("script", "run", "--quiet", "--summary", "json", "--summary-file", summaryFile, "deploy"), RunExpected{
+			Stdout: nljoin("ready", "ready"),
+		})
+
+		data, err := os.ReadFile(summaryFile)
+		assert.NoError(t, err)
+
+		var got runSummary
+		assert.NoError(t, json.Unmarshal(data, &got))
+		assert.EqualInts(t, 2, got.Total)
+		assert.EqualInts(t, 2, got.Succeeded)
+		assert.EqualInts(t, 2, len(got.Stacks))
+		assert.EqualStrings(t, "/fast", got.Stacks[0].Stack)
+		assert.EqualStrings(t, "/slow", got.Stacks[1].Stack)
+		for _, st := range got.Stacks {
+			assert.EqualStrings(t, "success", st.Status)
+			assert.IsTrue(t, st.ExitCode != nil && *st.ExitCode == 0, "unexpected exit code of %s", st.Stack)
+		}
+		assert.IsTrue(t, got.Stacks[1].DurationMS >= 200, "slow stack took %dms", got.Stacks[1].DurationMS)
+		assert.IsTrue(t, got.DurationMS >= got.Stacks[1].DurationMS, "run took %dms", got.DurationMS)
+	})
+
+	t.Run("json summary is written to stdout with the failed stacks", func(t *testing.T) {
+		t.Parallel()
+		s := sandbox.NoGit(t, true)
+		s.BuildTree([]string{"s:0", "s:3", "s:4"})
+		cli := NewCLI(t, s.RootDir())
+		res := cli.Run(append([]string{"run", "--quiet", "--continue-on-error", "--summary", "json"}, evalExit...)...)
+		AssertRunResult(t, res, RunExpected{
+			IgnoreStdout: true,
+			StderrRegex:  "one or more commands failed",
+			Status:       1,
+		})
+
+		var got runSummary
+		assert.NoError(t, json.Unmarshal([]byte(res.Stdout), &got))
+		assert.EqualInts(t, 3, got.Total)
+		assert.EqualInts(t, 1, got.Succeeded)
+		assert.EqualInts(t, 2, got.Failed)
+		assert.EqualInts(t, 3, len(got.Stacks))
+		for i, want := range []struct {
+			stack    string
+			status   string
+			exitCode int
+		}{
+			{"/0", "success", 0},
+			{"/3", "failed", 3},
+			{"/4", "failed", 4},
+		} {
+			st := got.Stacks[i]
+			assert.EqualStrings(t, want.stack, st.Stack)
+			assert.EqualStrings(t, want.status, st.Status)
+			assert.IsTrue(t, st.ExitCode != nil && *st.ExitCode == want.exitCode, "unexpected exit code of %s", st.Stack)
+		}
+	})
+
+	t.Run("canceled stacks are reported without exit code", func(t *testing.T) {
+		t.Parallel()
+		s := sandbox.NoGit(t, true)
+		s.BuildTree([]string{"s:3", `s:4:after=["/3"]`})
+		cli := NewCLI(t, s.RootDir())
+		res := cli.Run(append([]string{"run", "--quiet", "--summary", "json"}, evalExit...)...)
+		AssertRunResult(t, res, RunExpected{
+			IgnoreStdout: true,
+			StderrRegex:  "one or more commands failed",
+			Status:       1,
+		})
+
+		var got runSummary
+		assert.NoError(t, json.Unmarshal([]byte(res.Stdout), &got))
+		assert.EqualInts(t, 2, got.Total)
+		assert.EqualInts(t, 1, got.Failed)
+		assert.EqualInts(t, 1, got.Canceled)
+		assert.EqualStrings(t, "canceled", got.Stacks[1].Status)
+		assert.IsTrue(t, got.Stacks[1].ExitCode == nil, "canceled stack must have no exit code")
+	})
+
+	t.Run("dry-run prints a selection summary", func(t *testing.T) {
+		t.Parallel()
+		s := setup(t)
+		cli := NewCLI(t, s.RootDir())
+		AssertRunResult(t, cli.Run("run", "--quiet", "--dry-run", "--summary", "text", "--", HelperPath, "true"), RunExpected{
+			Stderr: "terramate: run summary (dry-run): 2 stack(s) selected\n",
+		})
+
+		res := cli.Run("script", "run", "--quiet", "--dry-run", "--summary", "json", "deploy")
+		AssertRunResult(t, res, RunExpected{IgnoreStdout: true})
+
+		var got runSummary
+		assert.NoError(t, json.Unmarshal([]byte(res.Stdout), &got))
+		assert.IsTrue(t, got.DryRun, "dry_run must be set")
+		assert.EqualInts(t, 2, got.Total)
+		for _, st := range got.Stacks {
+			assert.EqualStrings(t, "selected", st.Status)
+			assert.IsTrue(t, st.ExitCode == nil, "dry-run must have no exit code")
+		}
+	})
+
+	t.Run("summary file requires json summary", func(t *testing.T) {
+		t.Parallel()
+		s := setup(t)
+		cli := NewCLI(t, s.RootDir())
+		AssertRunResult(t, cli.Run("run", "--summary", "text", "--summary-file", "out.json", "--", HelperPath, "true"), RunExpected{
+			StderrRegex: "--summary-file requires --summary json",
+			Status:      1,
+		})
+	})
+}
@@ -0,0 +1,132 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package core_test
+
+import (
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	. "github.com/terramate-io/terramate/e2etests/internal/runner"
+	"github.com/terramate-io/terramate/test/sandbox"
+)
+
+func TestRunTerraformChdir(t *testing.T) {
+	t.Parallel()
+
+	type testcase struct {
+		name    string
+		flags   []string
+		runArgs []string
+		want    RunExpected
+	}
+
+	for _, tc := range []testcase{
+		{
+			name:    "command without -chdir",
+			runArgs: []string{"terraform", "plan"},
+			want: RunExpected{
+				StderrRegex:   regexp.QuoteMeta("terramate: (dry-run) Entering stack in /stack"),
+				NoStderrRegex: "Detected -chdir",
+			},
+		},
+		{
+			name:    "-chdir after the subcommand is not detected",
+			runArgs: []string{"terraform", "plan", "-chdir=envs/prod"},
+			want: RunExpected{
+				StderrRegex:   regexp.QuoteMeta("terramate: (dry-run) Entering stack in /stack"),
+				NoStderrRegex: "Detected -chdir",
+			},
+		},
+		{
+			name:    "notice shows the effective directory",
+			runArgs: []string{"terraform", "-chdir=envs/prod", "plan"},
+			want: RunExpected{
+				StderrRegexes: []string{
+					regexp.QuoteMeta("terramate: (dry-run) Entering stack in /stack"),
+					regexp.QuoteMeta("terramate: (dry-run) Detected -chdir, terraform runs in /stack/envs/prod"),
+				},
+			},
+		},
+		{
+			name:    "missing directory fails before execution",
+			runArgs: []string{"terraform", "-chdir=envs/dev", "plan"},
+			want: RunExpected{
+				Status:        1,
+				StderrRegex:   regexp.QuoteMeta("stack /stack: -chdir=envs/dev directory /stack/envs/dev does not exist"),
+				NoStderrRegex: "Entering stack",
+			},
+		},
+		{
+			name:    "directory outside the project fails before execution",
+			runArgs: []string{"terraform", "-chdir=../../..", "plan"},
+			want: RunExpected{
+				Status:        1,
+				StderrRegex:   regexp.QuoteMeta("stack /stack: -chdir=../../.. is outside the project"),
+				NoStderrRegex: "Entering stack",
+			},
+		},
+		{
+			name:  "terraform_dir metadata reflects -chdir",
+			flags: []string{"--eval"},
+			runArgs: []string{
+				"terraform", "-chdir=envs/${global.env}", "plan",
+				"-var", "dir=${terramate.stack.terraform_dir}",
+			},
+			want: RunExpected{
+				StderrRegex: regexp.QuoteMeta("-var dir=/stack/envs/prod"),
+			},
+		},
+		{
+			name:  "terraform_dir metadata defaults to the stack directory",
+			flags: []string{"--eval"},
+			runArgs: []string{
+				"terraform", "plan",
+				"-var", "dir=${terramate.stack.terraform_dir}",
+			},
+			want: RunExpected{
+				StderrRegex: regexp.QuoteMeta("-var dir=/stack"),
+			},
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			s := sandbox.NoGit(t, true)
+			s.BuildTree([]string{
+				"s:stack",
+				"d:stack/envs/prod",
+				`f:globals.tm:globals {
+					env = "prod"
+				}`,
+			})
+
+			cli := NewCLI(t, s.RootDir())
+			cli.PrependToPath(filepath.Dir(TerraformTestPath))
+			args := append([]string{"run", "--dry-run"}, tc.flags...)
+			args = append(args, "--")
+			args = append(args, tc.runArgs...)
+			AssertRunResult(t, cli.Run(args...), tc.want)
+		})
+	}
+}
+
+func TestTerraformDirMetadataIsRunOnly(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.NoGit(t, true)
+	s.BuildTree([]string{
+		"s:stack",
+		`f:stack/gen.tm:generate_file "dir.txt" {
+			content = terramate.stack.terraform_dir
+		}`,
+	})
+
+	cli := NewCLI(t, s.RootDir())
+	AssertRunResult(t, cli.Run("generate"), RunExpected{
+		Status:      1,
+		StdoutRegex: "terraform_dir",
+	})
+}
@@ -0,0 +1,179 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package core_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/madlambda/spells/assert"
+	. "github.com/terramate-io/terramate/e2etests/internal/runner"
+	"github.com/terramate-io/terramate/test"
+	"github.com/terramate-io/terramate/test/sandbox"
+)
+
+func TestRunCheckTerraformVersion(t *testing.T) {
+	t.Parallel()
+
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake terraform binary is a shell script")
+	}
+
+	const checkVersionConfig = `f:terramate.tm:
+	terramate {
+	  config {
+	    run {
+	      check_terraform_version = true
+	    }
+	  }
+	}`
+
+	t.Run("stacks with satisfied constraints run", func(t *testing.T) {
+		t.Parallel()
+
+		s := sandbox.New(t)
+		s.BuildTree([]string{
+			checkVersionConfig,
+			"s:stacks/a",
+			"s:stacks/b",
+			"s:stacks/c",
+			`f:stacks/a/main.tf:terraform {
+			  required_version = "~> 1.5.0"
+			}`,
+			`f:stacks/b/main.tofu:terraform {
+			  required_version = ">= 1.0, < 2.0"
+			}`,
+		})
+		s.Git().CommitAll("create stacks")
+
+		bindir, calls := fakeTerraform(t, "terraform", "1.5.0")
+		tmcli := NewCLI(t, s.RootDir())
+		tmcli.PrependToPath(bindir)
+		AssertRunResult(t, tmcli.Run("run", "--quiet", "--", "terraform", "plan"), RunExpected{
+			Stdout: nljoin("plan in a", "plan in b", "plan in c"),
+		})
+		assert.EqualInts(t, 1, versionCalls(t, calls), "terraform version must be detected once")
+	})
+
+	t.Run("stacks with unsatisfied constraints fail", func(t *testing.T) {
+		t.Parallel()
+
+		s := sandbox.New(t)
+		s.BuildTree([]string{
+			checkVersionConfig,
+			"s:stacks/a",
+			"s:stacks/b",
+			`f:stacks/a/main.tf:terraform {
+			  required_version = ">= 1.6"
+			}`,
+		})
+		s.Git().CommitAll("create stacks")
+
+		bindir, _ := fakeTerraform(t, "terraform", "1.5.0")
+		tmcli := NewCLI(t, s.RootDir())
+		tmcli.PrependToPath(bindir)
+		AssertRunResult(t, tmcli.Run("run", "--quiet", "--continue-on-error", "--", "terraform", "plan"), RunExpected{
+			Stdout:      nljoin("plan in b"),
+			StderrRegex: `stack /stacks/a requires terraform version ">= 1.6" but .* is version 1.5.0`,
+			Status:      1,
+		})
+	})
+
+	t.Run("tofu binary is checked", func(t *testing.T) {
+		t.Parallel()
+
+		s := sandbox.New(t)
+		s.BuildTree([]string{
+			checkVersionConfig,
+			"s:stack",
+			`f:stack/main.tofu:terraform {
+			  required_version = "~> 1.7.0"
+			}`,
+		})
+		s.Git().CommitAll("create stack")
+
+		bindir, _ := fakeTerraform(t, "tofu", "1.6.2")
+		tmcli := NewCLI(t, s.RootDir())
+		tmcli.PrependToPath(bindir)
+		AssertRunResult(t, tmcli.Run("run", "--quiet", "--", "tofu", "plan"), RunExpected{
+			StderrRegex: `stack /stack requires tofu version "~> 1.7.0" but .* is version 1.6.2`,
+			Status:      1,
+		})
+	})
+
+	t.Run("other commands are not checked", func(t *testing.T) {
+		t.Parallel()
+
+		s := sandbox.New(t)
+		s.BuildTree([]string{
+			checkVersionConfig,
+			"s:stack",
+			`f:stack/main.tf:terraform {
+			  required_version = ">= 99.0"
+			}`,
+		})
+		s.Git().CommitAll("create stack")
+
+		tmcli := NewCLI(t, s.RootDir())
+		AssertRunResult(t, tmcli.Run("run", "--quiet", "--", HelperPath, "echo", "hello"), RunExpected{
+			Stdout: nljoin("hello"),
+		})
+	})
+
+	t.Run("check is disabled by default", func(t *testing.T) {
+		t.Parallel()
+
+		s := sandbox.New(t)
+		s.BuildTree([]string{
+			"s:stack",
+			`f:stack/main.tf:terraform {
+			  required_version = ">= 99.0"
+			}`,
+		})
+		s.Git().CommitAll("create stack")
+
+		bindir, calls := fakeTerraform(t, "terraform", "1.5.0")
+		tmcli := NewCLI(t, s.RootDir())
+		tmcli.PrependToPath(bindir)
+		AssertRunResult(t, tmcli.Run("run", "--quiet", "--", "terraform", "plan"), RunExpected{
+			Stdout: nljoin("plan in stack"),
+		})
+		assert.EqualInts(t, 0, versionCalls(t, calls), "terraform version must not be detected")
+	})
+}
+
+// fakeTerraform creates a fake terraform (or tofu) binary reporting the given
+// version. It returns the bin directory and the file recording the version
+// command calls.
+func fakeTerraform(t *testing.T, name, version string) (bindir, calls string) {
+	t.Helper()
+
+	bindir = test.TempDir(t)
+	calls = filepath.Join(bindir, "version-calls")
+	script := test.WriteFile(t, bindir, name, fmt.Sprintf(`#!/bin/sh
+if [ "$1" = "version" ]; then
+  echo called >> %q
+  echo '{"terraform_version": "%s", "platform": "linux_amd64"}'
+  exit 0
+fi
+echo "$1 in $(basename "$PWD")"
+`, calls, version))
+	assert.NoError(t, os.Chmod(script, 0755))
+	return bindir, calls
+}
+
+func versionCalls(t *testing.T, calls string) int {
+	t.Helper()
+
+	data, err := os.ReadFile(calls)
+	if os.IsNotExist(err) {
+		return 0
+	}
+	assert.NoError(t, err)
+	return strings.Count(string(data), "called")
+}
@@ -95,6 +95,61 @@ func TestCLIRunOrder(t *testing.T) {
 				StderrRegex: `Warning: Stack /stack references an invalid path \(.*?\) in the 'after' attribute`,
 			},
 		},
+		{
+			name: "after directory without stacks with check_ordering = error",
+			layout: []string{
+				`s:stack:after=["/platform"]`,
+				"f:platform/README.md:no stacks here",
+				`f:ordering.tm:terramate {
+				  config {
+				    run {
+				      check_ordering = "error"
+				    }
+				  }
+				}`,
+			},
+			want: RunExpected{
+				Status:      1,
+				StderrRegex: `Stack /stack references a directory \(/platform\) with no stacks in the 'after' attribute`,
+			},
+		},
+		{
+			name: "after non-existent path with check_ordering = error",
+			layout: []string{
+				fmt.Sprintf("s:stack:after=[%q]", test.NonExistingDir(t)),
+				`f:ordering.tm:terramate {
+				  config {
+				    run {
+				      check_ordering = "error"
+				    }
+				  }
+				}`,
+			},
+			want: RunExpected{
+				Status:      1,
+				StderrRegex: `Stack /stack references an invalid path \(.*?\) in the 'after' attribute`,
+			},
+		},
+		{
+			name: "after directory with stacks with check_ordering = error",
+			layout: []string{
+				`s:stack:after=["/platform"]`,
+				"s:platform/network",
+				`f:ordering.tm:terramate {
+				  config {
+				    run {
+				      check_ordering = "error"
+				    }
+				  }
+				}`,
+			},
+			want: RunExpected{
+				Stdout: nljoin(
+					`/platform/network`,
+					`/stack`,
+				),
+			},
+		},
 		{
 			name: "independent stacks, consistent ordering (lexicographic)",
 			layout: []string{
@@ -730,7 +785,7 @@ func TestCLIRunOrder(t *testing.T) {
 			},
 		},
 		{
-			name: "before directory containing no stacks does nothing",
+			name: "before directory containing no stacks warns",
 			layout: []string{
 				`d:dir`,
 				`d:dir/dir2`,
@@ -742,6 +797,7 @@ func TestCLIRunOrder(t *testing.T) {
 					"/stack",
 					"/stack2",
 				),
+				StderrRegex: `Warning: Stack /stack references a directory \(/dir\) with no stacks in the 'before' attribute`,
 			},
 		},
 		{
@@ -756,7 +812,7 @@ func TestCLIRunOrder(t *testing.T) {
 			},
 		},
 		{
-			name: "after directory containing no stacks does nothing",
+			name: "after directory containing no stacks warns",
 			layout: []string{
 				`d:dir`,
 				`d:dir/dir2`,
@@ -768,6 +824,7 @@ func TestCLIRunOrder(t *testing.T) {
 					"/stack",
 					"/stack2",
 				),
+				StderrRegex: `Warning: Stack /stack references a directory \(/dir\) with no stacks in the 'after' attribute`,
 			},
 		},
 		{
@@ -2694,7 +2751,7 @@ func TestRunOutput(t *testing.T) {
 			runArgs: []string{"--eval", HelperPath, "echo", "${terramate.stack.abcabc}"},
 			want: RunExpected{
 				Stderr: "Error: unable to evaluate command" + "\n" +
-					`> <cmd arg>:1,19-26: eval expression: eval "${terramate.stack.abcabc}": This object does not have an attribute named "abcabc"` + ".\n",
+					`> <cmd arg>:1,18-25: evaluating command argument: stack /stack: evaluating argument 2 "${terramate.stack.abcabc}": eval expression: This object does not have an attribute named "abcabc"` + ".\n",
 				Stdout: "",
 				Status: 1,
 			},
@@ -3315,10 +3372,10 @@ func TestRunWitCustomizedEnv(t *testing.T) {
 	t.Run("ExperimentalRunEnv", func(t *testing.T) {
 		want := fmt.Sprintf(`
 stack "/stack":
-	FROM_ENV=%s
-	FROM_GLOBAL=%s
-	FROM_META=%s
-	TERRAMATE_OVERRIDDEN=%s
+	FROM_ENV=%s  (host)
+	FROM_GLOBAL=%s  (from /env.tm:6, inherited)
+	FROM_META=%s  (from /env.tm:5, inherited)
+	TERRAMATE_OVERRIDDEN=%s  (from /env.tm:8, inherited)
 `, exportedTerramateTest, stackGlobal, stackName, newTerramateOverriden)
 
 		AssertRunResult(t, tm.Run("debug", "show", "runtime-env"), RunExpected{
@@ -4,12 +4,18 @@
 package core_test
 
 import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
 	"strings"
 	"testing"
 
+	"github.com/madlambda/spells/assert"
 	"github.com/terramate-io/terramate/cmd/terramate/cli"
 	"github.com/terramate-io/terramate/cmd/terramate/cli/clitest"
 	. "github.com/terramate-io/terramate/e2etests/internal/runner"
+	"github.com/terramate-io/terramate/test"
 	"github.com/terramate-io/terramate/test/sandbox"
 	"go.lsp.dev/uri"
 )
@@ -637,3 +643,78 @@ func TestSafeguardCheckRemoteDisjointBranchesAreUnreachable(t *testing.T) {
 		StderrRegex: string(cli.ErrCurrentHeadIsOutOfDate),
 	})
 }
+
+func TestGitRemoteIsOnlyQueriedWhenNeeded(t *testing.T) {
+	t.Parallel()
+
+	setup := func(t *testing.T) (sandbox.S, CLI, sandbox.FileEntry, string) {
+		s := sandbox.New(t)
+		stack := s.CreateStack("stack")
+		stackFile := stack.CreateFile("main.tf", "body")
+		s.Git().CommitAll("first commit")
+
+		bindir, calls := fakeGit(t)
+		tmcli := NewCLI(t, s.RootDir())
+		tmcli.PrependToPath(bindir)
+		return s, tmcli, stackFile, calls
+	}
+
+	t.Run("list and run without --changed do not query an unreachable remote", func(t *testing.T) {
+		t.Parallel()
+
+		s, tmcli, stackFile, calls := setup(t)
+		s.Git().SetRemoteURL("origin", "http://non-existent/terramate.git")
+
+		AssertRunResult(t, tmcli.Run("list"), RunExpected{Stdout: "stack\n"})
+		AssertRunResult(t, tmcli.Run(
+			"run",
+			"--quiet",
+			"--disable-safeguards=git-out-of-sync",
+			HelperPath,
+			"cat",
+			stackFile.HostPath(),
+		), RunExpected{Stdout: "body"})
+		assert.EqualInts(t, 0, remoteQueries(t, calls), "remote must not be queried")
+	})
+
+	t.Run("run --changed queries the remote once", func(t *testing.T) {
+		t.Parallel()
+
+		_, tmcli, stackFile, calls := setup(t)
+		AssertRunResult(t, tmcli.Run(
+			"run",
+			"--quiet",
+			"--changed",
+			HelperPath,
+			"cat",
+			stackFile.HostPath(),
+		), RunExpected{Stdout: "body"})
+		assert.EqualInts(t, 1, remoteQueries(t, calls), "remote must be queried once")
+	})
+}
+
+// fakeGit creates a git wrapper which records the git commands in the calls
+// file and then executes the real git.
+func fakeGit(t *testing.T) (bindir, calls string) {
+	t.Helper()
+
+	gitPath, err := exec.LookPath("git")
+	assert.NoError(t, err)
+
+	bindir = test.TempDir(t)
+	calls = filepath.Join(bindir, "git-calls")
+	script := test.WriteFile(t, bindir, "git", fmt.Sprintf(`#!/bin/sh
+echo "$@" >> %q
+exec %q "$@"
+`, calls, gitPath))
+	assert.NoError(t, os.Chmod(script, 0755))
+	return bindir, calls
+}
+
+func remoteQueries(t *testing.T, calls string) int {
+	t.Helper()
+
+	data, err := os.ReadFile(calls)
+	assert.NoError(t, err)
+	return strings.Count(string(data), "ls-remote")
+}
@@ -0,0 +1,135 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package core_test
+
+import (
+	"testing"
+
+	. "github.com/terramate-io/terramate/e2etests/internal/runner"
+	"github.com/terramate-io/terramate/test/sandbox"
+)
+
+func TestScriptEnabled(t *testing.T) {
+	t.Parallel()
+
+	setup := func(t *testing.T) sandbox.S {
+		s := sandbox.New(t)
+		s.BuildTree([]string{
+			`f:terramate.tm:
+			terramate {
+			  config {
+			    experiments = ["scripts"]
+			  }
+			}`,
+			`f:script.tm:
+			script "deploy" {
+			  description = "deploy the stack"
+			  enabled     = global.deploy
+			  job {
+			    command = ["echo", "deploy ${terramate.stack.name}"]
+			  }
+			}
+
+			script "lint" {
+			  job {
+			    command = ["echo", "lint ${terramate.stack.name}"]
+			  }
+			}
+
+			globals {
+			  deploy = true
+			}`,
+			"s:stacks/a",
+			"s:stacks/b",
+			`f:stacks/b/globals.tm:
+			globals {
+			  deploy = false
+			}`,
+			"s:legacy",
+			`f:legacy/script.tm:
+			script "lint" {
+			  enabled = false
+			}`,
+		})
+		git := s.Git()
+		git.CommitAll("everything")
+		return s
+	}
+
+	t.Run("script run skips stacks where the script is disabled", func(t *testing.T) {
+		t.Parallel()
+		s := setup(t)
+		cli := NewCLI(t, s.RootDir())
+		AssertRunResult(t, cli.RunScript("deploy"), RunExpected{
+			Stdout: nljoin("deploy legacy", "deploy a"),
+			StderrRegexes: []string{
+				"Script 0 is disabled in stack /stacks/b, skipping",
+				`/stacks/a \(script:0 job:0.0\)> echo deploy a`,
+			},
+		})
+	})
+
+	t.Run("script run skips scripts disabled by a child directory", func(t *testing.T) {
+		t.Parallel()
+		s := setup(t)
+		cli := NewCLI(t, s.RootDir())
+		AssertRunResult(t, cli.RunScript("lint"), RunExpected{
+			Stdout: nljoin("lint a", "lint b"),
+			StderrRegexes: []string{
+				`Script 1 at /legacy/script.tm:.* having 0 job\(s\)`,
+				"Script 1 is disabled in stack /legacy, skipping",
+			},
+		})
+	})
+
+	t.Run("script list --stack shows the effective scripts of the stack", func(t *testing.T) {
+		t.Parallel()
+		s := setup(t)
+		cli := NewCLI(t, s.RootDir())
+		AssertRunResult(t, cli.Run("script", "list", "--stack", "stacks/b"), RunExpected{
+			Stdout: nljoin(
+				"deploy (disabled)",
+				`  Description: "deploy the stack"`,
+				"  Defined at /script.tm:2",
+				"",
+				"lint",
+				"  Defined at /script.tm:10",
+				"",
+			),
+		})
+		AssertRunResult(t, cli.Run("script", "list", "--stack", "legacy"), RunExpected{
+			Stdout: nljoin(
+				"deploy",
+				`  Description: "deploy the stack"`,
+				"  Defined at /script.tm:2",
+				"",
+				"lint (disabled)",
+				"  Defined at /legacy/script.tm:2",
+				"",
+			),
+		})
+	})
+
+	t.Run("script list --stack fails if the path is not a stack", func(t *testing.T) {
+		t.Parallel()
+		s := setup(t)
+		cli := NewCLI(t, s.RootDir())
+		AssertRunResult(t, cli.Run("script", "list", "--stack", "stacks"), RunExpected{
+			StderrRegex: "/stacks is not a stack",
+			Status:      1,
+		})
+	})
+
+	t.Run("script tree shows the enabled expression", func(t *testing.T) {
+		t.Parallel()
+		s := setup(t)
+		cli := NewCLI(t, s.RootDir())
+		AssertRunResult(t, cli.Run("script", "tree"), RunExpected{
+			StdoutRegexes: []string{
+				"Enabled: global.deploy",
+				"Enabled: false",
+			},
+		})
+	})
+}
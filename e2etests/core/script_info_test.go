@@ -9,6 +9,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/madlambda/spells/assert"
 	. "github.com/terramate-io/terramate/e2etests/internal/runner"
 
 	"github.com/terramate-io/terramate/test/sandbox"
@@ -203,3 +204,47 @@ Jobs:
 
 	}
 }
+
+func TestScriptInfoArgs(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.New(t)
+	s.BuildTree([]string{
+		"s:stack",
+		`f:terramate.tm:terramate {
+		  config {
+		    experiments = ["scripts"]
+		  }
+		}`,
+		`f:script.tm:script "deploy" {
+		  lets {
+		    env = script.args[0]
+		  }
+		  job {
+		    command = ["echo", let.env, script.args[1]]
+		  }
+		}
+		script "all" {
+		  job {
+		    command = tm_concat(["echo"], script.args)
+		  }
+		}
+		script "none" {
+		  job {
+		    command = ["echo"]
+		  }
+		}`,
+	})
+	s.Git().CommitAll("everything")
+
+	cli := NewCLI(t, s.RootDir())
+	AssertRunResult(t, cli.Run("script", "info", "deploy", "--", "ignored"), RunExpected{
+		StdoutRegex: "Arguments: consumed via script.args \\(at least 2 required\\)",
+	})
+	AssertRunResult(t, cli.Run("script", "info", "all"), RunExpected{
+		StdoutRegex: "Arguments: consumed via script.args\n",
+	})
+	res := cli.Run("script", "info", "none")
+	AssertRunResult(t, res, RunExpected{IgnoreStdout: true})
+	assert.IsTrue(t, !strings.Contains(res.Stdout, "Arguments:"), "stdout: %s", res.Stdout)
+}
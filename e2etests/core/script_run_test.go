@@ -4,10 +4,13 @@
 package core_test
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/terramate-io/terramate/config"
 	. "github.com/terramate-io/terramate/e2etests/internal/runner"
 
 	"github.com/terramate-io/terramate/test/sandbox"
@@ -118,6 +121,46 @@ func TestScriptRun(t *testing.T) {
 				),
 			},
 		},
+		{
+			name: "script with lets map evaluated per stack",
+			layout: []string{
+				terramateConfig,
+				"s:stack-a",
+				"s:stack-b",
+				`f:stack-a/globals.tm:
+				globals {
+				  regions = ["us-east-1", "eu-west-1"]
+				}`,
+				`f:stack-b/globals.tm:
+				globals {
+				  regions = ["sa-east-1"]
+				}`,
+				`f:script.tm:
+				script "somescript" {
+				  description = "some description"
+				  lets {
+					map "zones" {
+					  for_each = global.regions
+					  key      = element.new
+					  value {
+						zone = "${element.new}a"
+					  }
+					}
+				  }
+				  job {
+					command = ["echo", tm_join(",", [for k, v in let.zones : v.zone])]
+				  }
+				}`,
+			},
+			runScript: []string{"somescript"},
+			want: RunExpected{
+				IgnoreStderr: true,
+				Stdout: nljoin(
+					"eu-west-1a,us-east-1a",
+					"sa-east-1a",
+				),
+			},
+		},
 		{
 			name: "script with --reverse",
 			layout: []string{
@@ -252,6 +295,151 @@ func TestScriptRun(t *testing.T) {
 					"hello1" + "\n",
 			},
 		},
+		{
+			name: "job capturing output for the next job",
+			layout: []string{
+				terramateConfig,
+				"s:stack-a",
+				"s:stack-b",
+				`f:script.tm:
+				script "somescript" {
+				  description = "some description"
+				  job {
+					capture = "name"
+					command = ["` + HelperPath + `", "stack-rel-path", "${terramate.root.path.fs.absolute}"]
+				  }
+				  job {
+					command = ["echo", "name is ${job_output.name}"]
+				  }
+				}`,
+			},
+			runScript: []string{"somescript"},
+			want: RunExpected{
+				StderrRegexes: []string{
+					"/stack-a \\(script:0 job:1.0\\)> echo name is stack-a",
+					"/stack-b \\(script:0 job:1.0\\)> echo name is stack-b",
+				},
+				Stdout: nljoin(
+					"stack-a",
+					"name is stack-a",
+					"stack-b",
+					"name is stack-b",
+				),
+			},
+		},
+		{
+			name: "job capturing output larger than the limit is truncated",
+			layout: []string{
+				terramateConfig,
+				"s:stack-a",
+				"f:stack-a/data.txt:" + strings.Repeat("a", config.MaxScriptJobOutputBytes+100),
+				`f:stack-a/script.tm:
+				script "somescript" {
+				  description = "some description"
+				  job {
+					capture = "data"
+					command = ["` + HelperPath + `", "cat", "data.txt"]
+				  }
+				  job {
+					command = ["echo", "length=${tm_length(job_output.data)}"]
+				  }
+				}`,
+			},
+			runScript: []string{"somescript"},
+			want: RunExpected{
+				StderrRegexes: []string{
+					fmt.Sprintf("output captured in job_output.data truncated to %d bytes", config.MaxScriptJobOutputBytes),
+				},
+				StdoutRegex: fmt.Sprintf("alength=%d\n$", config.MaxScriptJobOutputBytes),
+			},
+		},
+		{
+			name: "job capturing output of a failed command stops the stack",
+			layout: []string{
+				terramateConfig,
+				"s:stack-a",
+				`f:stack-a/script.tm:
+				script "somescript" {
+				  description = "some description"
+				  job {
+					capture = "out"
+					command = ["` + HelperPath + `", "false"]
+				  }
+				  job {
+					command = ["echo", "${job_output.out}"]
+				  }
+				}`,
+			},
+			runScript: []string{"somescript"},
+			want: RunExpected{
+				Status: 1,
+				Stderr: "Script 0 at /stack-a/script.tm:2,5-11,6 having 2 job(s)\n" +
+					"/stack-a (script:0 job:0.0)> " + HelperPath + " false\n" +
+					"Error: one or more commands failed\n" +
+					"> execution failed: running " + HelperPath + " false (in /stack-a): exit status 1\n",
+			},
+		},
+		{
+			name: "job referencing output not captured by a previous job fails",
+			layout: []string{
+				terramateConfig,
+				"s:stack-a",
+				`f:stack-a/script.tm:
+				script "somescript" {
+				  description = "some description"
+				  job {
+					command = ["echo", "${job_output.out}"]
+				  }
+				  job {
+					capture = "out"
+					command = ["echo", "hello"]
+				  }
+				}`,
+			},
+			runScript: []string{"somescript"},
+			want: RunExpected{
+				Status:      1,
+				StderrRegex: "job_output.out is not captured by a previous job of the script",
+			},
+		},
+		{
+			name: "job capturing output with sensitive global values is masked",
+			layout: []string{
+				`f:terramate.tm:
+				terramate {
+				  config {
+					experiments       = ["scripts"]
+					sensitive_globals = ["token"]
+				  }
+				}`,
+				"s:stack-a",
+				`f:stack-a/globals.tm:
+				globals {
+				  token = "s3cr3t"
+				}`,
+				`f:stack-a/script.tm:
+				script "somescript" {
+				  description = "some description"
+				  job {
+					capture = "token"
+					command = ["echo", "s3cr3t"]
+				  }
+				  job {
+					command = ["echo", "token is ${job_output.token}"]
+				  }
+				}`,
+			},
+			runScript: []string{"somescript"},
+			want: RunExpected{
+				StderrRegexes: []string{
+					"/stack-a \\(script:0 job:1.0\\)> echo token is \\(sensitive\\)",
+				},
+				Stdout: nljoin(
+					"s3cr3t",
+					"token is s3cr3t",
+				),
+			},
+		},
 		{
 			name: "unknown script should return exit code",
 			layout: []string{
@@ -419,6 +607,199 @@ func TestScriptRun(t *testing.T) {
 					"/stack-a (script:0 job:0.0)> echo some message\n",
 			},
 		},
+		{
+			name: "arguments after -- are available as script.args",
+			layout: []string{
+				terramateConfig,
+				"s:stack-a",
+				`f:script.tm:
+				script "deploy" {
+				  lets {
+				    env = script.args[0]
+				  }
+				  job {
+				    command = ["echo", "${let.env}", "n=${tm_length(script.args)}"]
+				  }
+				  job {
+				    command = tm_concat(["echo"], script.args)
+				  }
+				}`,
+			},
+			runScript: []string{"--quiet", "deploy", "--", "prod", "--verbose"},
+			want: RunExpected{
+				Stdout: "prod n=2\nprod --verbose\n",
+			},
+		},
+		{
+			name: "script.args is empty without arguments",
+			layout: []string{
+				terramateConfig,
+				"s:stack-a",
+				`f:script.tm:
+				script "deploy" {
+				  job {
+				    command = ["echo", "n=${tm_length(script.args)}"]
+				  }
+				}`,
+			},
+			runScript: []string{"--quiet", "deploy"},
+			want: RunExpected{
+				Stdout: "n=0\n",
+			},
+		},
+		{
+			name: "missing argument fails with the script and job",
+			layout: []string{
+				terramateConfig,
+				"s:stack-a",
+				`f:script.tm:
+				script "deploy" "all" {
+				  job {
+				    command = ["echo", script.args[0]]
+				  }
+				  job {
+				    command = ["echo", script.args[1]]
+				  }
+				}`,
+			},
+			runScript: []string{"deploy", "all", "--", "prod"},
+			want: RunExpected{
+				StderrRegex: `job 1 of script "deploy all" references script.args\[1\] but 1 argument\(s\) were given`,
+				Status:      1,
+			},
+		},
+		{
+			name: "missing argument in lets fails with the script",
+			layout: []string{
+				terramateConfig,
+				"s:stack-a",
+				`f:script.tm:
+				script "deploy" {
+				  lets {
+				    env = script.args[0]
+				  }
+				  job {
+				    command = ["echo", let.env]
+				  }
+				}`,
+			},
+			runScript: []string{"deploy"},
+			want: RunExpected{
+				StderrRegex: `lets of script "deploy" reference script.args\[0\] but 0 argument\(s\) were given`,
+				Status:      1,
+			},
+		},
+		{
+			name: "job_ordering job runs each job in all stacks before the next job",
+			layout: []string{
+				terramateConfig,
+				`s:stack-a:after=["/stack-b"]`,
+				"s:stack-b",
+				`f:script.tm:
+				script "deploy" {
+				  job_ordering = "job"
+				  job {
+					capture = "name"
+					command = ["echo", "${terramate.stack.name}"]
+				  }
+				  job {
+					command = ["echo", "job1 ${job_output.name}"]
+				  }
+				}`,
+			},
+			runScript: []string{"deploy"},
+			want: RunExpected{
+				Stdout: nljoin(
+					"stack-b",
+					"stack-a",
+					"job1 stack-b",
+					"job1 stack-a",
+				),
+				Stderr: "Script 0 at /script.tm:2,5-11,6 having 2 job(s)\n" +
+					"/stack-b (script:0 job:0.0)> echo stack-b\n" +
+					"/stack-a (script:0 job:0.0)> echo stack-a\n" +
+					"/stack-b (script:0 job:1.0)> echo job1 stack-b\n" +
+					"/stack-a (script:0 job:1.0)> echo job1 stack-a\n",
+			},
+		},
+		{
+			name: "job_ordering job skips the next jobs of failed stacks",
+			layout: []string{
+				terramateConfig,
+				"s:stack-a",
+				"s:stack-b",
+				`f:script.tm:
+				script "deploy" {
+				  job_ordering = "job"
+				  job {
+					command = ["` + HelperPath + `", tm_ternary(terramate.stack.name == "stack-a", "false", "true")]
+				  }
+				  job {
+					command = ["echo", "job1 ${terramate.stack.name}"]
+				  }
+				}`,
+			},
+			args:      []string{"--continue-on-error"},
+			runScript: []string{"deploy"},
+			want: RunExpected{
+				Stdout: nljoin("job1 stack-b"),
+				StderrRegexes: []string{
+					`/stack-a \(script:0 job:1\)> skipped because a previous job failed`,
+					`/stack-b \(script:0 job:1.0\)> echo job1 stack-b`,
+					"one or more commands failed",
+				},
+				Status: 1,
+			},
+		},
+		{
+			name: "job_ordering job aborts the next jobs on failure",
+			layout: []string{
+				terramateConfig,
+				"s:stack-a",
+				"s:stack-b:after=[\"/stack-a\"]",
+				`f:script.tm:
+				script "deploy" {
+				  job_ordering = "job"
+				  job {
+					command = ["` + HelperPath + `", tm_ternary(terramate.stack.name == "stack-a", "false", "true")]
+				  }
+				  job {
+					command = ["echo", "job1 ${terramate.stack.name}"]
+				  }
+				}`,
+			},
+			runScript: []string{"deploy"},
+			want: RunExpected{
+				StderrRegex: "one or more commands failed",
+				Status:      1,
+			},
+		},
+		{
+			name: "scripts with different job_ordering fail",
+			layout: []string{
+				terramateConfig,
+				"s:stack-a",
+				"s:stack-b",
+				`f:script.tm:
+				script "deploy" {
+				  job_ordering = "job"
+				  job {
+					command = ["echo", "hello"]
+				  }
+				}`,
+				`f:stack-b/script.tm:
+				script "deploy" {
+				  job {
+					command = ["echo", "hello"]
+				  }
+				}`,
+			},
+			runScript: []string{"deploy"},
+			want: RunExpected{
+				StderrRegex: "all the scripts run together must have the same job_ordering",
+				Status:      1,
+			},
+		},
 		{
 			name: "complex before/after keeps script commands in order",
 			layout: []string{
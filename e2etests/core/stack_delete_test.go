@@ -0,0 +1,128 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package core_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/madlambda/spells/assert"
+	. "github.com/terramate-io/terramate/e2etests/internal/runner"
+	"github.com/terramate-io/terramate/stack/trigger"
+	"github.com/terramate-io/terramate/test/sandbox"
+)
+
+func TestStackDelete(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.New(t)
+	s.BuildTree([]string{
+		"s:stacks/a",
+		"f:stacks/a/main.tf:# main",
+		`f:stacks/b/stack.tm:stack {
+		  after = ["/stacks/a"]
+		}`,
+		`f:stacks/c/stack.tm:stack {
+		  wants = ["../a"]
+		}`,
+	})
+	s.Git().CommitAll("create stacks")
+
+	tmcli := NewCLI(t, s.RootDir())
+	AssertRunResult(t, tmcli.TriggerStack(trigger.Changed, "/stacks/a"), RunExpected{IgnoreStdout: true})
+
+	AssertRunResult(t, tmcli.RunWithStdin("n\n", "stack", "delete", "stacks/a"), RunExpected{
+		Status:       1,
+		IgnoreStdout: true,
+		StderrRegex:  "stack deletion aborted",
+	})
+	_, err := os.Stat(filepath.Join(s.RootDir(), "stacks/a"))
+	assert.NoError(t, err)
+
+	AssertRunResult(t, tmcli.Run("stack", "delete", "--yes", "stacks/a"), RunExpected{
+		StdoutRegex: `Files to be removed:\n\t/stacks/a/main.tf\n\t/stacks/a/stack.tm.hcl\n` +
+			`Triggers to be removed:\n\t/.tmtriggers/stacks/a/changed-.*\.tm\.hcl\n` +
+			`Stack /stacks/a deleted\n`,
+		StderrRegex: `stack /stacks/b references /stacks/a in the "after" attribute at /stacks/b/stack.tm:2,14-25(.|\n)*` +
+			`stack /stacks/c references ../a in the "wants" attribute at /stacks/c/stack.tm:2,14-20`,
+	})
+
+	_, err = os.Stat(filepath.Join(s.RootDir(), "stacks/a"))
+	assert.IsTrue(t, os.IsNotExist(err), "stack dir must be removed")
+	_, err = os.Stat(filepath.Join(trigger.Dir(s.RootDir()), "stacks/a"))
+	assert.IsTrue(t, os.IsNotExist(err), "stack triggers must be removed")
+
+	AssertRunResult(t, tmcli.Run("list"), RunExpected{
+		Stdout: nljoin("stacks/b", "stacks/c"),
+	})
+}
+
+func TestStackDeleteKeepDir(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.New(t)
+	s.BuildTree([]string{
+		`f:stack/stack.tm:stack {
+		  name = "stack"
+		}
+
+		generate_hcl "gen.hcl" {
+		  content {
+		    a = 1
+		  }
+		}`,
+		"f:stack/main.tf:# main",
+		"f:stack/README.md:# readme",
+	})
+	s.Git().CommitAll("create stack")
+
+	tmcli := NewCLI(t, s.RootDir())
+	AssertRunResult(t, tmcli.Run("generate"), RunExpected{IgnoreStdout: true})
+	s.Git().CommitAll("generate code")
+
+	AssertRunResult(t, tmcli.Run("stack", "delete", "--yes", "--keep-dir", "stack"), RunExpected{
+		Stdout: nljoin(
+			"The stack block will be removed from /stack/stack.tm",
+			"Files to be removed:",
+			"\t/stack/gen.hcl",
+			"Stack /stack removed, the directory was kept",
+		),
+	})
+
+	for _, fname := range []string{"main.tf", "README.md", "stack.tm"} {
+		_, err := os.Stat(filepath.Join(s.RootDir(), "stack", fname))
+		assert.NoError(t, err, "file %s must be kept", fname)
+	}
+	_, err := os.Stat(filepath.Join(s.RootDir(), "stack/gen.hcl"))
+	assert.IsTrue(t, os.IsNotExist(err), "generated file must be removed")
+
+	AssertRunResult(t, tmcli.Run("list"), RunExpected{})
+}
+
+func TestStackDeleteFailures(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.New(t)
+	s.BuildTree([]string{
+		"d:dir",
+		"s:parent",
+		"s:parent/child",
+	})
+	s.Git().CommitAll("create stacks")
+
+	tmcli := NewCLI(t, s.RootDir())
+	AssertRunResult(t, tmcli.Run("stack", "delete", "--yes", "dir"), RunExpected{
+		Status:      1,
+		StderrRegex: "is not a stack",
+	})
+	AssertRunResult(t, tmcli.Run("stack", "delete", "--yes", "parent"), RunExpected{
+		Status:      1,
+		StderrRegex: "has child stacks",
+	})
+	AssertRunResult(t, tmcli.Run("stack", "delete", "--yes", ".."), RunExpected{
+		Status:      1,
+		StderrRegex: "outside the project",
+	})
+}
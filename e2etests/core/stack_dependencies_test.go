@@ -0,0 +1,94 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package core_test
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/madlambda/spells/assert"
+	. "github.com/terramate-io/terramate/e2etests/internal/runner"
+	"github.com/terramate-io/terramate/stack"
+	"github.com/terramate-io/terramate/test/sandbox"
+)
+
+func TestStackDependencies(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.NoGit(t, true)
+	s.BuildTree([]string{
+		"s:infra",
+		`f:infra/network/stack.tm:stack {
+		  after  = ["tag:db"]
+		  before = ["/app"]
+		  watch  = ["/config.json"]
+		}`,
+		`f:infra/network/main.tf:module "vpc" {
+		  source = "../../modules/vpc"
+		}`,
+		`f:infra/db/stack.tm:stack {
+		  tags = ["db"]
+		}`,
+		`f:app/stack.tm:stack {
+		  wants = ["/infra/network"]
+		}`,
+		"f:modules/vpc/main.tf:# vpc",
+		"f:config.json:{}",
+	})
+
+	tmcli := NewCLI(t, s.RootDir())
+	AssertRunResult(t, tmcli.Run("experimental", "dependencies", "infra/network"), RunExpected{
+		Stdout: nljoin(
+			"Stack /infra/network",
+			"Parent stacks:",
+			"\t/infra",
+			"After:",
+			"\ttag:db: /infra/db",
+			"Before:",
+			"\t/app: /app",
+			"Wanted by:",
+			"\t/app",
+			"Watch:",
+			"\t/config.json",
+			"Terraform modules:",
+			`	/modules/vpc (source "../../modules/vpc" called by /infra/network)`,
+		),
+	})
+
+	tmcli = NewCLI(t, filepath.Join(s.RootDir(), "app"))
+	AssertRunResult(t, tmcli.Run("experimental", "dependencies"), RunExpected{
+		Stdout: nljoin(
+			"Stack /app",
+			"Wants:",
+			"\t/infra/network",
+		),
+	})
+
+	res := tmcli.Run("experimental", "dependencies", "--json", "../infra/network")
+	AssertRunResult(t, res, RunExpected{IgnoreStdout: true})
+
+	var deps stack.Dependencies
+	assert.NoError(t, json.Unmarshal([]byte(res.Stdout), &deps))
+	assert.EqualStrings(t, "/infra/network", deps.Stack.String())
+	assert.EqualInts(t, 1, len(deps.After))
+	assert.EqualStrings(t, "/infra/db", deps.After[0].Stacks[0].String())
+	assert.EqualInts(t, 1, len(deps.WantedBy))
+	assert.EqualStrings(t, "/app", deps.WantedBy[0].String())
+	assert.EqualInts(t, 1, len(deps.TerraformModules))
+	assert.EqualStrings(t, "/modules/vpc", deps.TerraformModules[0].Path.String())
+}
+
+func TestStackDependenciesNotAStack(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.NoGit(t, true)
+	s.BuildTree([]string{"d:dir"})
+
+	tmcli := NewCLI(t, s.RootDir())
+	AssertRunResult(t, tmcli.Run("experimental", "dependencies", "dir"), RunExpected{
+		Status:      1,
+		StderrRegex: "is not a stack",
+	})
+}
@@ -0,0 +1,175 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package core_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/madlambda/spells/assert"
+	. "github.com/terramate-io/terramate/e2etests/internal/runner"
+	"github.com/terramate-io/terramate/test/sandbox"
+)
+
+func TestStackIDFilter(t *testing.T) {
+	t.Parallel()
+
+	type testcase struct {
+		name string
+		args []string
+		want RunExpected
+	}
+
+	for _, tc := range []testcase{
+		{
+			name: "single id",
+			args: []string{"list", "--stack-id", "stack-b"},
+			want: RunExpected{Stdout: nljoin("stacks/b")},
+		},
+		{
+			name: "repeated and case insensitive ids",
+			args: []string{"list", "--stack-id", "STACK-C", "--stack-id", "Stack-A"},
+			want: RunExpected{Stdout: nljoin("stacks/a", "stacks/c")},
+		},
+		{
+			name: "composable with tags",
+			args: []string{"list", "--stack-id", "stack-a", "--stack-id", "stack-b", "--tags", "app"},
+			want: RunExpected{Stdout: nljoin("stacks/a")},
+		},
+		{
+			name: "composable with include",
+			args: []string{"list", "--stack-id", "stack-a", "--stack-id", "stack-c", "--include", "stacks/c"},
+			want: RunExpected{Stdout: nljoin("stacks/c")},
+		},
+		{
+			name: "missing id fails",
+			args: []string{"list", "--stack-id", "stack-a", "--stack-id", "not-found"},
+			want: RunExpected{
+				Status:      1,
+				StderrRegex: "--stack-id not-found does not match any stack of the project",
+			},
+		},
+		{
+			name: "missing id is ignored with --ignore-missing-ids",
+			args: []string{"list", "--stack-id", "stack-a", "--stack-id", "not-found", "--ignore-missing-ids"},
+			want: RunExpected{Stdout: nljoin("stacks/a")},
+		},
+		{
+			name: "--ignore-missing-ids requires --stack-id",
+			args: []string{"list", "--ignore-missing-ids"},
+			want: RunExpected{
+				Status:      1,
+				StderrRegex: "--ignore-missing-ids requires --stack-id",
+			},
+		},
+		{
+			name: "run",
+			args: []string{"run", "--quiet", "--eval", "--stack-id", "stack-c", "--", HelperPathAsHCL, "stack-rel-path", "${terramate.root.path.fs.absolute}"},
+			want: RunExpected{Stdout: nljoin("stacks/c")},
+		},
+		{
+			name: "script run",
+			args: []string{"script", "run", "--quiet", "--stack-id", "stack-b", "--", "relpath"},
+			want: RunExpected{Stdout: nljoin("stacks/b")},
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			s := sandbox.New(t)
+			s.BuildTree([]string{
+				`s:stacks/a:id=stack-a;tags=["app"]`,
+				`s:stacks/b:id=stack-b;tags=["db"]`,
+				`s:stacks/c:id=stack-c`,
+				`s:stacks/no-id`,
+				`f:terramate.tm:terramate {
+				  config {
+				    experiments = ["scripts"]
+				  }
+				}`,
+				`f:script.tm:script "relpath" {
+				  description = "print the stack path"
+				  job {
+				    command = ["` + HelperPathAsHCL + `", "stack-rel-path", "${terramate.root.path.fs.absolute}"]
+				  }
+				}`,
+			})
+			s.Git().CommitAll("all stacks")
+
+			cli := NewCLI(t, s.RootDir())
+			AssertRunResult(t, cli.Run(tc.args...), tc.want)
+		})
+	}
+}
+
+func TestStackIDFilterAfterMovingStacks(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.New(t)
+	s.BuildTree([]string{
+		`s:stacks/a:id=stack-a`,
+		`s:stacks/b:id=stack-b`,
+	})
+	s.Git().CommitAll("all stacks")
+
+	cli := NewCLI(t, s.RootDir())
+	AssertRunResult(t, cli.Run("list", "--stack-id", "stack-a"), RunExpected{
+		Stdout: nljoin("stacks/a"),
+	})
+
+	assert.NoError(t, os.MkdirAll(filepath.Join(s.RootDir(), "moved"), 0o755))
+	assert.NoError(t, os.Rename(
+		filepath.Join(s.RootDir(), "stacks", "a"),
+		filepath.Join(s.RootDir(), "moved", "app"),
+	))
+	s.Git().CommitAll("move stack-a")
+
+	AssertRunResult(t, cli.Run("list", "--stack-id", "stack-a"), RunExpected{
+		Stdout: nljoin("moved/app"),
+	})
+	AssertRunResult(t, cli.Run(
+		"run", "--quiet", "--stack-id", "stack-a", "--", HelperPath, "stack-rel-path", s.RootDir(),
+	), RunExpected{
+		Stdout: nljoin("moved/app"),
+	})
+}
+
+func TestTriggerByStackID(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.New(t)
+	s.BuildTree([]string{
+		`s:stacks/a:id=stack-a`,
+		`s:stacks/b:id=stack-b`,
+		`s:stacks/c:id=stack-c`,
+	})
+	git := s.Git()
+	git.CommitAll("all stacks")
+	git.Push("main")
+	git.CheckoutNew("trigger-by-id")
+
+	cli := NewCLI(t, s.RootDir())
+	AssertRunResult(t, cli.Run("trigger", "--stack-id", "STACK-A", "--stack-id", "stack-c"), RunExpected{
+		StdoutRegexes: []string{
+			`Created change trigger for stack "/stacks/a"`,
+			`Created change trigger for stack "/stacks/c"`,
+		},
+	})
+	git.CommitAll("commit the trigger files")
+
+	AssertRunResult(t, cli.Run("list", "--changed"), RunExpected{
+		Stdout: nljoin("stacks/a", "stacks/c"),
+	})
+
+	AssertRunResult(t, cli.Run("trigger", "--stack-id", "not-found"), RunExpected{
+		Status:      1,
+		StderrRegex: "--stack-id not-found does not match any stack of the project",
+	})
+	AssertRunResult(t, cli.Run("trigger", "stacks/b", "--stack-id", "stack-b"), RunExpected{
+		Status:      1,
+		StderrRegex: "--stack-id conflicts with the stack path argument",
+	})
+}
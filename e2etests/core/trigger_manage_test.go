@@ -0,0 +1,128 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package core_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/madlambda/spells/assert"
+	. "github.com/terramate-io/terramate/e2etests/internal/runner"
+	"github.com/terramate-io/terramate/test/sandbox"
+)
+
+func TestTriggerListAndClear(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.New(t)
+	s.BuildTree([]string{
+		"s:stacks/a",
+		"s:stacks/b",
+		"s:stacks/b/child",
+	})
+	git := s.Git()
+	git.CommitAll("first commit")
+	git.Push("main")
+	git.CheckoutNew("triggers")
+
+	cli := NewCLI(t, s.RootDir())
+	AssertRunResult(t, cli.Run("trigger", "--list"), RunExpected{})
+
+	AssertRunResult(t, cli.Run("trigger", "--change", "--reason", "redeploy a", "/stacks/a"), RunExpected{
+		Stdout: nljoin(`Created change trigger for stack "/stacks/a"`),
+	})
+	AssertRunResult(t, cli.Run("trigger", "--change", "--reason", "redeploy child", "/stacks/b/child"), RunExpected{
+		Stdout: nljoin(`Created change trigger for stack "/stacks/b/child"`),
+	})
+	git.CommitAll("add triggers")
+
+	AssertRunResult(t, cli.ListChangedStacks(), RunExpected{
+		Stdout: nljoin("stacks/a", "stacks/b/child"),
+	})
+
+	AssertRunResult(t, cli.Run("trigger", "--list"), RunExpected{
+		StdoutRegexes: []string{
+			`(?m)^/stacks/a\n  Kind: changed\n  Reason: redeploy a\n  Created: \S+\n  Author: .+\n  File: /\.tmtriggers/stacks/a/changed-\S+\.tm\.hcl$`,
+			`(?m)^/stacks/b/child\n  Kind: changed\n  Reason: redeploy child\n`,
+		},
+	})
+
+	t.Run("list as JSON", func(t *testing.T) {
+		res := cli.Run("trigger", "--list", "--json", "stacks/b", "--recursive")
+		AssertRunResult(t, res, RunExpected{IgnoreStdout: true})
+
+		var triggers []struct {
+			Stack     string `json:"stack"`
+			File      string `json:"file"`
+			Kind      string `json:"kind"`
+			Reason    string `json:"reason"`
+			CreatedAt string `json:"created_at"`
+			Author    string `json:"author"`
+		}
+		assert.NoError(t, json.Unmarshal([]byte(res.Stdout), &triggers))
+		assert.EqualInts(t, 1, len(triggers), "unexpected triggers: %v", triggers)
+		assert.EqualStrings(t, "/stacks/b/child", triggers[0].Stack)
+		assert.EqualStrings(t, "changed", triggers[0].Kind)
+		assert.EqualStrings(t, "redeploy child", triggers[0].Reason)
+		assert.IsTrue(t, triggers[0].CreatedAt != "", "created_at is not set")
+		assert.IsTrue(t, triggers[0].Author != "", "author is not set")
+	})
+
+	t.Run("list of a stack without triggers", func(t *testing.T) {
+		AssertRunResult(t, cli.Run("trigger", "--list", "stacks/b"), RunExpected{})
+	})
+
+	t.Run("invalid flags", func(t *testing.T) {
+		AssertRunResult(t, cli.Run("trigger", "--clear"), RunExpected{
+			StderrRegex: "--clear expects a stack path or --all",
+			Status:      1,
+		})
+		AssertRunResult(t, cli.Run("trigger", "--clear", "--all", "stacks/a"), RunExpected{
+			StderrRegex: "--clear expects either a stack path or --all",
+			Status:      1,
+		})
+		AssertRunResult(t, cli.Run("trigger", "--list", "--clear"), RunExpected{
+			StderrRegex: "flags --list and --clear are conflicting",
+			Status:      1,
+		})
+		AssertRunResult(t, cli.Run("trigger", "--json"), RunExpected{
+			StderrRegex: "--json must be used together with --list",
+			Status:      1,
+		})
+		AssertRunResult(t, cli.Run("trigger", "--list", "--stack-id", "some-id"), RunExpected{
+			StderrRegex: "--stack-id is incompatible with --list and --clear",
+			Status:      1,
+		})
+		AssertRunResult(t, cli.Run("trigger", "--clear", "--all", "--stack-id", "some-id"), RunExpected{
+			StderrRegex: "--stack-id is incompatible with --list and --clear",
+			Status:      1,
+		})
+	})
+
+	AssertRunResult(t, cli.Run("trigger", "--clear", "stacks/a"), RunExpected{
+		Stdout: nljoin(
+			`Removed change trigger of stack "/stacks/a"`,
+			"Removed 1 trigger(s)",
+		),
+	})
+	git.CommitAll("clear trigger of stack a")
+
+	AssertRunResult(t, cli.ListChangedStacks(), RunExpected{
+		Stdout: nljoin("stacks/b/child"),
+	})
+
+	AssertRunResult(t, cli.Run("trigger", "--clear", "--all"), RunExpected{
+		Stdout: nljoin(
+			`Removed change trigger of stack "/stacks/b/child"`,
+			"Removed 1 trigger(s)",
+		),
+	})
+	git.CommitAll("clear all triggers")
+
+	AssertRunResult(t, cli.ListChangedStacks(), RunExpected{})
+	AssertRunResult(t, cli.Run("trigger", "--list"), RunExpected{})
+	AssertRunResult(t, cli.Run("trigger", "--clear", "--all"), RunExpected{
+		Stdout: nljoin("Removed 0 trigger(s)"),
+	})
+}
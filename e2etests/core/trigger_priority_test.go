@@ -0,0 +1,145 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package core_test
+
+import (
+	"testing"
+
+	. "github.com/terramate-io/terramate/e2etests/internal/runner"
+	"github.com/terramate-io/terramate/test/sandbox"
+)
+
+func TestTriggerPriorityRunOrder(t *testing.T) {
+	t.Parallel()
+
+	type trigger struct {
+		stack    string
+		priority string
+	}
+
+	type testcase struct {
+		name     string
+		layout   []string
+		triggers []trigger
+		want     []string
+	}
+
+	for _, tc := range []testcase{
+		{
+			name: "default order is unchanged without priorities",
+			layout: []string{
+				"s:stack-a",
+				"s:stack-b",
+			},
+			triggers: []trigger{
+				{stack: "/stack-a"},
+				{stack: "/stack-b"},
+			},
+			want: []string{"stack-a", "stack-b"},
+		},
+		{
+			name: "independent stacks are ordered by priority",
+			layout: []string{
+				"s:stack-a",
+				"s:stack-b",
+			},
+			triggers: []trigger{
+				{stack: "/stack-a"},
+				{stack: "/stack-b", priority: "10"},
+			},
+			want: []string{"stack-b", "stack-a"},
+		},
+		{
+			name: "priority loses to an after constraint",
+			layout: []string{
+				"s:stack-a",
+				`s:stack-b:after=["/stack-a"]`,
+			},
+			triggers: []trigger{
+				{stack: "/stack-a"},
+				{stack: "/stack-b", priority: "10"},
+			},
+			want: []string{"stack-a", "stack-b"},
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			s := sandbox.New(t)
+			s.BuildTree(tc.layout)
+			for _, stack := range []string{"stack-a", "stack-b"} {
+				s.DirEntry(stack).CreateFile("name.txt", stack+"\n")
+			}
+
+			git := s.Git()
+			git.CommitAll("all")
+			git.Push("main")
+			git.CheckoutNew("trigger-the-stacks")
+
+			cli := NewCLI(t, s.RootDir())
+			for _, tr := range tc.triggers {
+				args := []string{"trigger", tr.stack}
+				if tr.priority != "" {
+					args = append(args, "--priority", tr.priority)
+				}
+				AssertRunResult(t, cli.Run(args...), RunExpected{
+					StdoutRegex: "Created change trigger",
+				})
+			}
+			git.CommitAll("commit the trigger files")
+
+			AssertRunResult(t, cli.Run("list", "--changed", "--run-order"), RunExpected{
+				Stdout: nljoin(tc.want...),
+			})
+			AssertRunResult(t, cli.Run(
+				"run",
+				"--quiet",
+				"--changed",
+				HelperPath,
+				"cat",
+				"name.txt",
+			), RunExpected{Stdout: nljoin(tc.want...)})
+		})
+	}
+}
+
+func TestTriggerPriorityIgnoresStaleTriggers(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.New(t)
+	s.BuildTree([]string{
+		"s:stack-a",
+		"s:stack-b",
+	})
+	for _, stack := range []string{"stack-a", "stack-b"} {
+		s.DirEntry(stack).CreateFile("name.txt", stack+"\n")
+	}
+
+	cli := NewCLI(t, s.RootDir())
+	AssertRunResult(t, cli.Run("trigger", "/stack-b", "--priority", "10"), RunExpected{
+		StdoutRegex: "Created change trigger",
+	})
+
+	git := s.Git()
+	git.CommitAll("all")
+	git.Push("main")
+	git.CheckoutNew("change-the-stacks")
+
+	for _, stack := range []string{"stack-a", "stack-b"} {
+		s.DirEntry(stack).CreateFile("name.txt", stack+"\nchanged\n")
+	}
+	git.CommitAll("change the stacks")
+
+	AssertRunResult(t, cli.Run("list", "--changed", "--run-order"), RunExpected{
+		Stdout: nljoin("stack-a", "stack-b"),
+	})
+	AssertRunResult(t, cli.Run(
+		"run",
+		"--quiet",
+		HelperPath,
+		"cat",
+		"name.txt",
+	), RunExpected{Stdout: nljoin("stack-a", "changed", "stack-b", "changed")})
+}
@@ -0,0 +1,97 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package core_test
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/madlambda/spells/assert"
+	"github.com/terramate-io/terramate/config/validate"
+	. "github.com/terramate-io/terramate/e2etests/internal/runner"
+	"github.com/terramate-io/terramate/test/sandbox"
+)
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.New(t)
+	s.BuildTree([]string{
+		`s:a`,
+		`s:b`,
+		`f:globals.tm:globals {
+		  value = "ok"
+		}`,
+	})
+
+	tmcli := NewCLI(t, s.RootDir())
+	AssertRunResult(t, tmcli.Run("validate"), RunExpected{})
+
+	s.RootEntry().CreateFile("a/invalid.tm", "stack {")
+	s.RootEntry().CreateFile("b/unknown.tm", "unknown {}")
+
+	// no git commit is required and all the errors are reported.
+	AssertRunResult(t, tmcli.Run("validate"), RunExpected{
+		Status: 1,
+		Stdout: "a/invalid.tm:1:7: error: HCL syntax error: There is no closing brace for this block " +
+			"before the end of the file. This may be caused by incorrect brace nesting elsewhere in this file.\n" +
+			`b/unknown.tm:1:1: error: terramate schema error: unrecognized block "unknown"` + "\n",
+	})
+}
+
+func TestValidateWithoutGit(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.NoGit(t, true)
+	s.BuildTree([]string{
+		`s:stacks/a`,
+		`f:stacks/a/globals.tm:globals {
+		  a = 1
+		}`,
+		`f:stacks/a/globals2.tm:globals {
+		  a = 2
+		}`,
+		`f:stacks/config.tm:terramate {
+		  config {
+		    git {
+		      default_branch = "main"
+		    }
+		  }
+		}`,
+	})
+
+	// validate runs from any directory of the project.
+	tmcli := NewCLI(t, filepath.Join(s.RootDir(), "stacks", "a"))
+	res := tmcli.Run("validate", "--json")
+	AssertRunResult(t, res, RunExpected{
+		Status:       1,
+		IgnoreStdout: true,
+	})
+
+	var got struct {
+		Valid       bool                 `json:"valid"`
+		Diagnostics validate.Diagnostics `json:"diagnostics"`
+	}
+	assert.NoError(t, json.Unmarshal([]byte(res.Stdout), &got), "stdout: %s", res.Stdout)
+	assert.IsTrue(t, !got.Valid, "configuration must be invalid")
+	assert.EqualInts(t, 2, len(got.Diagnostics), "diagnostics: %v", got.Diagnostics)
+
+	redeclared := got.Diagnostics[0]
+	assert.EqualStrings(t, string(validate.SeverityError), string(redeclared.Severity))
+	assert.IsTrue(t, redeclared.Range != nil, "redeclared global must have a range")
+	assert.EqualStrings(t, "stacks/a/globals2.tm", redeclared.Range.Filename)
+	assert.EqualInts(t, 2, redeclared.Range.Start.Line)
+
+	misplaced := got.Diagnostics[1]
+	assert.EqualStrings(t, string(validate.SeverityWarning), string(misplaced.Severity))
+	assert.IsTrue(t, misplaced.Range != nil, "misplaced config must have a range")
+	assert.EqualStrings(t, "stacks/config.tm", misplaced.Range.Filename)
+
+	// warnings alone do not fail.
+	s.DirEntry("stacks/a").RemoveFile("globals2.tm")
+	AssertRunResult(t, tmcli.Run("validate", "--json"), RunExpected{
+		StdoutRegex: `"valid": true`,
+	})
+}
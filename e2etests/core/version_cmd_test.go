@@ -0,0 +1,189 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package core_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/madlambda/spells/assert"
+	tm "github.com/terramate-io/terramate"
+	. "github.com/terramate-io/terramate/e2etests/internal/runner"
+	"github.com/terramate-io/terramate/test"
+	. "github.com/terramate-io/terramate/test/hclwrite/hclutils"
+)
+
+func TestVersionCommandJSON(t *testing.T) {
+	t.Parallel()
+
+	wantUnknown := fmt.Sprintf(`{
+  "version": %q,
+  "status": "unknown",
+  "latest_version": null,
+  "release_date": null,
+  "download_url": null,
+  "alerts": null
+}
+`, tm.Version())
+
+	t.Run("checkpoint disabled by flag", func(t *testing.T) {
+		t.Parallel()
+
+		tmcli := NewCLI(t, test.TempDir(t))
+		AssertRunResult(t, tmcli.Run("--disable-checkpoint", "version", "--json"), RunExpected{
+			Stdout: wantUnknown,
+		})
+	})
+
+	t.Run("checkpoint disabled by env", func(t *testing.T) {
+		t.Parallel()
+
+		tmcli := NewCLI(t, test.TempDir(t))
+		AssertRunResult(t, tmcli.Run("version", "--json"), RunExpected{
+			Stdout: wantUnknown,
+		})
+	})
+
+	t.Run("checkpoint disabled by project config", func(t *testing.T) {
+		t.Parallel()
+
+		tmcli := newCheckpointCachedCLI(t, `{
+			"product": "terramate",
+			"current_version": "999.0.0",
+			"outdated": true
+		}`)
+		test.WriteFile(t, tmcli.Chdir, "terramate.tm", Terramate(
+			Str("required_version", ">= 0.0.0"),
+			Config(
+				Block("checkpoint",
+					Bool("enabled", false),
+				),
+			),
+		).String())
+		AssertRunResult(t, tmcli.Run("version", "--json"), RunExpected{
+			Stdout: wantUnknown,
+		})
+		AssertRunResult(t, tmcli.Run("version", "--check"), RunExpected{})
+	})
+
+	t.Run("outdated version", func(t *testing.T) {
+		t.Parallel()
+
+		tmcli := newCheckpointCachedCLI(t, `{
+			"product": "terramate",
+			"current_version": "999.0.0",
+			"current_release_date": 1700000000,
+			"current_download_url": "https://example.com/terramate",
+			"outdated": true,
+			"alerts": [{"id": 1, "level": "warning", "message": "security fix", "url": "https://example.com/alert"}]
+		}`)
+		AssertRunResult(t, tmcli.Run("version", "--json"), RunExpected{
+			Stdout: fmt.Sprintf(`{
+  "version": %q,
+  "status": "outdated",
+  "latest_version": "999.0.0",
+  "release_date": "2023-11-14T22:13:20Z",
+  "download_url": "https://example.com/terramate",
+  "alerts": [
+    {
+      "level": "warning",
+      "message": "security fix",
+      "url": "https://example.com/alert"
+    }
+  ]
+}
+`, tm.Version()),
+		})
+	})
+
+	t.Run("up-to-date version", func(t *testing.T) {
+		t.Parallel()
+
+		tmcli := newCheckpointCachedCLI(t, fmt.Sprintf(`{
+			"product": "terramate",
+			"current_version": %q,
+			"current_release_date": 1700000000,
+			"current_download_url": "https://example.com/terramate",
+			"outdated": false
+		}`, tm.Version()))
+		AssertRunResult(t, tmcli.Run("version", "--json"), RunExpected{
+			Stdout: fmt.Sprintf(`{
+  "version": %[1]q,
+  "status": "up-to-date",
+  "latest_version": %[1]q,
+  "release_date": "2023-11-14T22:13:20Z",
+  "download_url": "https://example.com/terramate",
+  "alerts": []
+}
+`, tm.Version()),
+		})
+	})
+}
+
+func TestVersionCommandCheck(t *testing.T) {
+	t.Parallel()
+
+	t.Run("checkpoint disabled", func(t *testing.T) {
+		t.Parallel()
+
+		tmcli := NewCLI(t, test.TempDir(t))
+		AssertRunResult(t, tmcli.Run("--disable-checkpoint", "version", "--check"), RunExpected{})
+		AssertRunResult(t, tmcli.Run("version", "--check"), RunExpected{})
+	})
+
+	t.Run("outdated version", func(t *testing.T) {
+		t.Parallel()
+
+		tmcli := newCheckpointCachedCLI(t, `{
+			"product": "terramate",
+			"current_version": "999.0.0",
+			"outdated": true
+		}`)
+		AssertRunResult(t, tmcli.Run("version", "--check"), RunExpected{
+			Status: 1,
+		})
+	})
+
+	t.Run("up-to-date version", func(t *testing.T) {
+		t.Parallel()
+
+		tmcli := newCheckpointCachedCLI(t, fmt.Sprintf(`{
+			"product": "terramate",
+			"current_version": %q,
+			"outdated": false
+		}`, tm.Version()))
+		AssertRunResult(t, tmcli.Run("version", "--check"), RunExpected{})
+	})
+}
+
+// newCheckpointCachedCLI creates a CLI with checkpoint enabled and the given
+// checkpoint response cached, so no request to the checkpoint API is made.
+func newCheckpointCachedCLI(t *testing.T, response string) CLI {
+	t.Helper()
+
+	userDir := test.TempDir(t)
+
+	// cache format: magic bytes, version length, version and the JSON response.
+	var cache bytes.Buffer
+	cache.Write([]byte{0x35, 0x77, 0x69, 0xFB})
+	assert.NoError(t, binary.Write(&cache, binary.LittleEndian, uint32(len(tm.Version()))))
+	cache.WriteString(tm.Version())
+	cache.WriteString(response)
+	test.WriteFile(t, userDir, "checkpoint_cache", cache.String())
+
+	rcfile := test.WriteFile(t, userDir, "terramate.rc", fmt.Sprintf(
+		"user_terramate_dir = \"%s\"\n", strings.ReplaceAll(userDir, "\\", "\\\\"),
+	))
+
+	tmcli := NewCLI(t, test.TempDir(t))
+	tmcli.AppendEnv = append(tmcli.AppendEnv,
+		"CHECKPOINT_DISABLE=",
+		"TM_CLI_CONFIG_FILE="+filepath.Clean(rcfile),
+	)
+	return tmcli
+}
@@ -178,6 +178,41 @@ type Cmd struct {
 	Stderr *buffer
 }
 
+// UserDir returns the user Terramate directory of the CLI.
+func (tm CLI) UserDir() string {
+	return tm.userDir
+}
+
+// WriteCredential writes a fake Terramate Cloud credential of the user with the
+// given email for the given profile. An empty profile is the default one.
+func (tm CLI) WriteCredential(profile, email string) {
+	t := tm.t
+	t.Helper()
+
+	type MyCustomClaims struct {
+		Email string `json:"email"`
+		jwt.StandardClaims
+	}
+
+	claims := MyCustomClaims{
+		email,
+		jwt.StandardClaims{
+			ExpiresAt: time.Now().Add(1 * time.Hour).Unix(),
+			Issuer:    "terramate-tests",
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	fakeJwt, err := token.SignedString([]byte("test"))
+	assert.NoError(t, err)
+
+	filename := "credentials.tmrc.json"
+	if profile != "" {
+		filename = "credentials." + profile + ".tmrc.json"
+	}
+	test.WriteFile(t, tm.userDir, filename, fmt.Sprintf(`{"id_token": "%s", "refresh_token": "abcd", "provider": "Google"}`, fakeJwt))
+}
+
 // Run the command.
 func (tc *Cmd) Run() error {
 	return tc.cmd.Run()
@@ -216,23 +251,7 @@ func (tm CLI) NewCmd(args ...string) *Cmd {
 	env := append(tm.environ, tm.AppendEnv...)
 
 	// fake credentials
-	type MyCustomClaims struct {
-		Email string `json:"email"`
-		jwt.StandardClaims
-	}
-
-	claims := MyCustomClaims{
-		"batman@terramate.io",
-		jwt.StandardClaims{
-			ExpiresAt: time.Now().Add(1 * time.Hour).Unix(),
-			Issuer:    "terramate-tests",
-		},
-	}
-
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	fakeJwt, err := token.SignedString([]byte("test"))
-	assert.NoError(t, err)
-	test.WriteFile(t, tm.userDir, "credentials.tmrc.json", fmt.Sprintf(`{"id_token": "%s", "refresh_token": "abcd", "provider": "Google"}`, fakeJwt))
+	tm.WriteCredential("", "batman@terramate.io")
 
 	cmd := exec.Command(tm.terramatePath(), allargs...)
 	cmd.Stdout = stdout
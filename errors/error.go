@@ -37,6 +37,10 @@ type Error struct {
 	// FileRange holds the error source.
 	FileRange hcl.Range
 
+	// Related holds other file ranges that help to understand the error.
+	// They're not part of the error message.
+	Related RelatedRanges
+
 	// Err holds the underlying error.
 	Err error
 }
@@ -69,6 +73,9 @@ const separator = ": "
 //   - [errors.StackMeta]
 //     The stack that originated the error.
 //
+//   - [errors.RelatedRanges]
+//     Other file ranges related to the error, appended to the ones already set.
+//
 //   - [hcl.Diagnostics]
 //     The underlying hcl error that triggered this one.
 //     Only the first hcl.Diagnostic will be used.
@@ -161,6 +168,8 @@ func E(args ...interface{}) *Error {
 					Byte:   end.Byte(),
 				},
 			}
+		case RelatedRanges:
+			e.Related = append(e.Related, arg...)
 		case hcl.Diagnostics:
 			errs.Append(arg)
 		case hcl.Diagnostic:
@@ -275,7 +284,7 @@ func E(args ...interface{}) *Error {
 // isEmpty tells if all fields of this error are empty.
 // Note that e.Err is the underlying error hence not checked.
 func (e *Error) isEmpty() bool {
-	return e.FileRange == hcl.Range{} && e.Kind == "" && e.Description == ""
+	return e.FileRange == hcl.Range{} && e.Kind == "" && e.Description == "" && len(e.Related) == 0
 }
 
 func (e *Error) error(fields []interface{}, verbose bool) string {
@@ -504,3 +504,40 @@ func TestDetailedRepresentation(t *testing.T) {
 func fmt(format string, args ...interface{}) string {
 	return stdfmt.Sprintf(format, args...)
 }
+
+func TestErrorRelatedRanges(t *testing.T) {
+	t.Parallel()
+
+	defined := errors.RelatedRange{
+		Range: hcl.Range{
+			Filename: "/test/globals.tm",
+			Start:    hcl.Pos{Line: 2, Column: 3, Byte: 12},
+			End:      hcl.Pos{Line: 2, Column: 8, Byte: 17},
+		},
+		Description: "global.a defined",
+	}
+	missing := errors.RelatedRange{Description: "no definition of global.b found"}
+
+	inner := E(syntaxError, "inner", errors.RelatedRanges{missing})
+	err := E(tmSchemaError, inner, "outer", errors.RelatedRanges{defined})
+
+	assert.EqualStrings(t, "terramate schema error: outer: syntax error: inner", err.Error(),
+		"related ranges are not part of the message")
+
+	related := errors.Related(err)
+	assert.EqualInts(t, 2, len(related))
+	assert.EqualStrings(t, "/test/globals.tm:2,3-8: global.a defined", related[0].String())
+	assert.EqualStrings(t, "no definition of global.b found", related[1].String())
+
+	// the elements of wrapped lists get the related ranges of the wrapper.
+	list := E(errors.L(E("first"), E("second")), errors.RelatedRanges{defined})
+	for _, item := range errors.L(list).Errors() {
+		related := errors.Related(item)
+		assert.EqualInts(t, 1, len(related), "item: %v", item)
+		assert.EqualStrings(t, defined.String(), related[0].String())
+	}
+
+	// errors only carrying related ranges are kept when wrapped.
+	err = E(tmSchemaError, E(tmSchemaError, stderrors.New("cause"), errors.RelatedRanges{missing}))
+	assert.EqualInts(t, 1, len(errors.Related(err)))
+}
@@ -0,0 +1,47 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package errors
+
+import (
+	"errors"
+
+	"github.com/terramate-io/hcl/v2"
+)
+
+// RelatedRange is a file range related to an error, e.g. a definition
+// considered while evaluating the expression which failed.
+type RelatedRange struct {
+	// Range is the related range. It can be empty if the description refers
+	// to something which is not defined anywhere.
+	Range hcl.Range
+
+	// Description tells how the range is related to the error.
+	Description string
+}
+
+// RelatedRanges is a list of related ranges.
+type RelatedRanges []RelatedRange
+
+// String returns the related range as <range>: <description>.
+func (r RelatedRange) String() string {
+	if r.Range.Empty() {
+		return r.Description
+	}
+	rng := r.Range
+	rng.Filename = fixupFilename(rng.Filename)
+	return rng.String() + separator + r.Description
+}
+
+// Related returns the related ranges of err and of all the errors wrapped by it,
+// from the outermost to the innermost error.
+func Related(err error) RelatedRanges {
+	var related RelatedRanges
+	for err != nil {
+		if e, ok := err.(*Error); ok {
+			related = append(related, e.Related...)
+		}
+		err = errors.Unwrap(err)
+	}
+	return related
+}
@@ -17,6 +17,19 @@ type (
 		Source tf.Source
 		// VendorDir is where the source is supposed to be vendored.
 		VendorDir project.Path
+		// Constraint is the version constraint the Source ref was resolved
+		// from, if any.
+		Constraint string
+		// Error is the error resolving the version constraint, if any.
+		// The Source has no ref in this case and must not be vendored.
+		Error error
+	}
+
+	// FileRead represents an event indicating that a file was read by a
+	// filesystem function.
+	FileRead struct {
+		// Path is the absolute host path of the file.
+		Path string
 	}
 
 	// VendorProgress represents a vendor progress event.
@@ -47,7 +47,7 @@ func ListTerramateFiles(dir string) (ListResult, error) {
 		}
 		if entry.IsDir() {
 			res.Dirs = append(res.Dirs, fname)
-		} else if isTerramateFile(fname) {
+		} else if IsTerramateFile(fname) {
 			res.TmFiles = append(res.TmFiles, fname)
 		} else if strings.HasSuffix(fname, tmgenExt) && len(fname) > len(tmgenExt) {
 			res.TmGenFiles = append(res.TmGenFiles, fname)
@@ -62,7 +62,8 @@ func ListTerramateFiles(dir string) (ListResult, error) {
 	return res, nil
 }
 
-func isTerramateFile(filename string) bool {
+// IsTerramateFile tells if the filename is a Terramate configuration file.
+func IsTerramateFile(filename string) bool {
 	if len(filename) <= 3 || filename[0] == '.' {
 		return false
 	}
@@ -0,0 +1,211 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+// Package watch provides the detection of file changes inside a directory.
+package watch
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/terramate-io/terramate/errors"
+)
+
+// SkipFunc tells if the file or directory with the given host path must not be
+// watched. Skipping a directory skips all of its contents.
+type SkipFunc func(path string, entry fs.DirEntry) bool
+
+// Poller detects the files created, changed or removed inside a directory by
+// comparing snapshots of their modification time and size.
+type Poller struct {
+	dir   string
+	skip  SkipFunc
+	files map[string]fileState
+}
+
+type fileState struct {
+	modTime time.Time
+	size    int64
+	isDir   bool
+}
+
+// NewPoller creates a poller for the given directory, taking the snapshot the
+// first [Poller.Poll] is compared against.
+// If skip is nil, then the entries starting with a dot are skipped.
+func NewPoller(dir string, skip SkipFunc) (*Poller, error) {
+	if skip == nil {
+		skip = SkipDotEntries
+	}
+	p := &Poller{
+		dir:  dir,
+		skip: skip,
+	}
+	files, err := p.snapshot()
+	if err != nil {
+		return nil, err
+	}
+	p.files = files
+	return p, nil
+}
+
+// SkipDotEntries skips the files and directories starting with a dot, like
+// the Terramate configuration loading does.
+func SkipDotEntries(_ string, entry fs.DirEntry) bool {
+	return entry.Name()[0] == '.'
+}
+
+// Poll returns the sorted host paths of the files and directories created,
+// changed or removed since the previous poll.
+func (p *Poller) Poll() ([]string, error) {
+	files, err := p.snapshot()
+	if err != nil {
+		return nil, err
+	}
+	var changed []string
+	for path, state := range files {
+		old, ok := p.files[path]
+		if !ok || (!state.isDir && (old.modTime != state.modTime || old.size != state.size)) {
+			changed = append(changed, path)
+		}
+	}
+	for path := range p.files {
+		if _, ok := files[path]; !ok {
+			changed = append(changed, path)
+		}
+	}
+	p.files = files
+	sort.Strings(changed)
+	return changed, nil
+}
+
+func (p *Poller) snapshot() (map[string]fileState, error) {
+	files := map[string]fileState{}
+	err := filepath.WalkDir(p.dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) && path != p.dir {
+				// removed while walking.
+				return nil
+			}
+			return err
+		}
+		if path == p.dir {
+			return nil
+		}
+		if p.skip(path, entry) {
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		files[path] = fileState{
+			modTime: info.ModTime(),
+			size:    info.Size(),
+			isDir:   entry.IsDir(),
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.E(err, "watching %s", p.dir)
+	}
+	return files, nil
+}
+
+// Events polls the poller at every interval and sends the changed paths to the
+// returned channel, which is closed when the context is done or polling fails.
+// The polling error, if any, is sent to the errs channel, which must be able
+// to buffer it.
+func Events(ctx context.Context, p *Poller, interval time.Duration, errs chan<- error) <-chan string {
+	events := make(chan string)
+	go func() {
+		defer close(events)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			changed, err := p.Poll()
+			if err != nil {
+				errs <- err
+				return
+			}
+			for _, path := range changed {
+				select {
+				case events <- path:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return events
+}
+
+// Debounce groups the events received in quick succession, sending them as a
+// single batch of unique and sorted paths when no new event is received for
+// the given delay. The returned channel is closed after the events channel is
+// closed, sending the pending batch first, or when the context is done.
+func Debounce(ctx context.Context, events <-chan string, delay time.Duration) <-chan []string {
+	batches := make(chan []string)
+	go func() {
+		defer close(batches)
+
+		pending := map[string]struct{}{}
+		var timeout <-chan time.Time
+
+		flush := func() bool {
+			if len(pending) == 0 {
+				return true
+			}
+			batch := make([]string, 0, len(pending))
+			for path := range pending {
+				batch = append(batch, path)
+			}
+			sort.Strings(batch)
+			pending = map[string]struct{}{}
+
+			select {
+			case batches <- batch:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case path, ok := <-events:
+				if !ok {
+					flush()
+					return
+				}
+				pending[path] = struct{}{}
+				timeout = time.After(delay)
+			case <-timeout:
+				timeout = nil
+				if !flush() {
+					return
+				}
+			}
+		}
+	}()
+	return batches
+}
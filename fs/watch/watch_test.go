@@ -0,0 +1,90 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package watch_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/madlambda/spells/assert"
+	"github.com/terramate-io/terramate/fs/watch"
+	"github.com/terramate-io/terramate/test"
+)
+
+func TestPollerDetectsChanges(t *testing.T) {
+	t.Parallel()
+
+	dir := test.TempDir(t)
+	test.WriteFile(t, dir, "changed.tm", "a")
+	test.WriteFile(t, dir, "removed.tm", "a")
+	test.WriteFile(t, dir, "unchanged.tm", "a")
+	test.WriteFile(t, dir, ".hidden/file.tm", "a")
+
+	poller, err := watch.NewPoller(dir, nil)
+	assert.NoError(t, err)
+
+	changed, err := poller.Poll()
+	assert.NoError(t, err)
+	assert.EqualInts(t, 0, len(changed), "unexpected changes: %v", changed)
+
+	test.WriteFile(t, dir, "changed.tm", "changed")
+	test.WriteFile(t, dir, "created/file.tm", "a")
+	test.WriteFile(t, dir, ".hidden/file.tm", "changed")
+	assert.NoError(t, os.Remove(filepath.Join(dir, "removed.tm")))
+
+	changed, err = poller.Poll()
+	assert.NoError(t, err)
+	test.AssertDiff(t, changed, []string{
+		filepath.Join(dir, "changed.tm"),
+		filepath.Join(dir, "created"),
+		filepath.Join(dir, "created/file.tm"),
+		filepath.Join(dir, "removed.tm"),
+	})
+
+	changed, err = poller.Poll()
+	assert.NoError(t, err)
+	assert.EqualInts(t, 0, len(changed), "changes reported twice: %v", changed)
+}
+
+func TestDebounceGroupsEvents(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events := make(chan string)
+	batches := watch.Debounce(ctx, events, 50*time.Millisecond)
+
+	events <- "/b"
+	events <- "/a"
+	events <- "/b"
+	test.AssertDiff(t, <-batches, []string{"/a", "/b"})
+
+	events <- "/c"
+	test.AssertDiff(t, <-batches, []string{"/c"})
+
+	events <- "/d"
+	close(events)
+	test.AssertDiff(t, <-batches, []string{"/d"})
+
+	_, ok := <-batches
+	assert.IsTrue(t, !ok, "batches channel must be closed")
+}
+
+func TestDebounceStopsWhenContextIsDone(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events := make(chan string)
+	batches := watch.Debounce(ctx, events, time.Hour)
+
+	events <- "/a"
+	cancel()
+
+	_, ok := <-batches
+	assert.IsTrue(t, !ok, "batches channel must be closed")
+}
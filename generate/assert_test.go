@@ -264,12 +264,14 @@ func TestGenerateAssert(t *testing.T) {
 			wantReport: generate.Report{
 				Successes: []generate.Result{
 					{
-						Dir:     project.NewPath("/stacks/stack-1"),
-						Created: []string{"test.hcl", "test.txt"},
+						Dir:      project.NewPath("/stacks/stack-1"),
+						Created:  []string{"test.hcl", "test.txt"},
+						Warnings: []string{"/stacks/terramate.tm.hcl:3,15-20: msg"},
 					},
 					{
-						Dir:     project.NewPath("/stacks/stack-2"),
-						Created: []string{"test.hcl", "test.txt"},
+						Dir:      project.NewPath("/stacks/stack-2"),
+						Created:  []string{"test.hcl", "test.txt"},
+						Warnings: []string{"/stacks/terramate.tm.hcl:3,15-20: msg"},
 					},
 				},
 			},
@@ -527,6 +529,10 @@ func TestGenerateAssertInsideGenerateBlocks(t *testing.T) {
 					{
 						Dir:     project.NewPath("/stack"),
 						Created: []string{"test.hcl", "test.txt"},
+						Warnings: []string{
+							"/stack/terramate.tm.hcl:7,17-22: msg",
+							"/stack/terramate.tm.hcl:15,17-22: msg",
+						},
 					},
 				},
 			},
@@ -0,0 +1,121 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package generate
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/rs/zerolog/log"
+	"github.com/terramate-io/terramate/config"
+	"github.com/terramate-io/terramate/errors"
+	"github.com/terramate-io/terramate/globals"
+	"github.com/terramate-io/terramate/hcl"
+	"github.com/terramate-io/terramate/project"
+	"github.com/terramate-io/terramate/stack"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// ErrCheck indicates that a check block failed after the code generation
+// of a stack.
+const ErrCheck errors.Kind = "check failed"
+
+// loadChecks returns the check blocks that apply to the stack, which are the
+// ones defined in the stack directory and in any of its parent directories.
+// A check shadows the checks with the same name defined in parent directories.
+func loadChecks(root *config.Root, st *config.Stack) []hcl.CheckConfig {
+	curdir := st.Dir
+	seen := map[string]struct{}{}
+	checks := []hcl.CheckConfig{}
+
+	for {
+		cfg, ok := root.Lookup(curdir)
+		if ok {
+			for _, check := range cfg.Node.Checks {
+				if _, shadowed := seen[check.Name]; shadowed {
+					continue
+				}
+				seen[check.Name] = struct{}{}
+				checks = append(checks, check)
+			}
+		}
+
+		if p := curdir.Dir(); p != curdir {
+			curdir = p
+		} else {
+			break
+		}
+	}
+
+	sort.Slice(checks, func(i, j int) bool {
+		return checks[i].Name < checks[j].Name
+	})
+	return checks
+}
+
+// checkStack evaluates the checks that apply to the stack after its code is
+// generated. Besides the globals and metadata of the stack, the checks have
+// access to the generated namespace, where generated.files lists the files
+// generated for the stack.
+//
+// The failed checks with error severity are returned as an error list and the
+// ones with warn severity as warnings, both including the origin of the check.
+func checkStack(root *config.Root, st *config.Stack, generated []GenFile) ([]string, error) {
+	checkCfgs := loadChecks(root, st)
+	if len(checkCfgs) == 0 {
+		return nil, nil
+	}
+
+	report := globals.ForStack(root, st)
+	if err := report.AsError(); err != nil {
+		return nil, err
+	}
+	evalctx := stack.NewEvalCtx(root, st, report.Globals)
+
+	files := []cty.Value{}
+	for _, file := range generated {
+		if file.Condition() {
+			files = append(files, cty.StringVal(file.Label()))
+		}
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].AsString() < files[j].AsString()
+	})
+	filesVal := cty.ListValEmpty(cty.String)
+	if len(files) > 0 {
+		filesVal = cty.ListVal(files)
+	}
+	evalctx.SetNamespace("generated", map[string]cty.Value{
+		"files": filesVal,
+	})
+
+	var warnings []string
+	errs := errors.L()
+	for _, checkCfg := range checkCfgs {
+		check, err := config.EvalCheck(evalctx.Context, checkCfg)
+		if err != nil {
+			errs.Append(errors.E(err, "evaluating check %q", checkCfg.Name))
+			continue
+		}
+		if check.Assertion {
+			continue
+		}
+
+		checkRange := check.Range
+		checkRange.Filename = project.PrjAbsPath(root.HostDir(), check.Range.Filename).String()
+		msg := fmt.Sprintf("%s: check %q: %s", checkRange, check.Name, check.Message)
+		if check.Warning() {
+			warnings = append(warnings, msg)
+			log.Warn().
+				Stringer("origin", checkRange).
+				Str("check", check.Name).
+				Str("msg", check.Message).
+				Stringer("stack", st.Dir).
+				Msg("check failed")
+			continue
+		}
+		errs.Append(errors.E(ErrCheck, msg))
+	}
+	return warnings, errs.AsError()
+}
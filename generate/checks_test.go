@@ -0,0 +1,204 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package generate_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/terramate-io/terramate/errors"
+	"github.com/terramate-io/terramate/generate"
+	"github.com/terramate-io/terramate/hcl/eval"
+	"github.com/terramate-io/terramate/project"
+	. "github.com/terramate-io/terramate/test/hclwrite/hclutils"
+)
+
+func TestGenerateChecks(t *testing.T) {
+	t.Parallel()
+
+	testCodeGeneration(t, []testcase{
+		{
+			name: "checks access the files generated for the stack",
+			layout: []string{
+				"s:stacks/stack-1",
+				"s:stacks/stack-2",
+			},
+			configs: []hclconfig{
+				{
+					path: "/stacks",
+					add: GenerateFile(
+						Labels("backend.tf"),
+						Expr("condition", `terramate.stack.name == "stack-1"`),
+						Str("content", "backend"),
+					),
+				},
+				{
+					path:     "/",
+					filename: "checks.tm",
+					add: Check(
+						Labels("backend"),
+						Expr("assertion", `tm_contains(generated.files, "backend.tf")`),
+						Str("message", "backend.tf is required"),
+					),
+				},
+			},
+			want: []generatedFile{
+				{
+					dir: "/stacks/stack-1",
+					files: map[string]fmt.Stringer{
+						"backend.tf": stringer("backend"),
+					},
+				},
+			},
+			wantReport: generate.Report{
+				Successes: []generate.Result{
+					{
+						Dir:     project.NewPath("/stacks/stack-1"),
+						Created: []string{"backend.tf"},
+					},
+				},
+				Failures: []generate.FailureResult{
+					{
+						Result: generate.Result{
+							Dir: project.NewPath("/stacks/stack-2"),
+						},
+						Error: errors.E(generate.ErrCheck),
+					},
+				},
+			},
+		},
+		{
+			name: "checks access globals and metadata",
+			layout: []string{
+				"s:stacks/stack-1",
+				"s:stacks/stack-2",
+			},
+			configs: []hclconfig{
+				{
+					path: "/stacks/stack-1",
+					add: Globals(
+						Str("env", "prod"),
+					),
+				},
+				{
+					path: "/",
+					add: Check(
+						Labels("env"),
+						Expr("assertion", `tm_try(global.env, null) == "prod"`),
+						Expr("message", `"${terramate.stack.name} must be on prod"`),
+						Str("severity", "warn"),
+					),
+				},
+			},
+			wantReport: generate.Report{
+				Successes: []generate.Result{
+					{
+						Dir: project.NewPath("/stacks/stack-2"),
+						Warnings: []string{
+							`/terramate.tm.hcl:3,15-49: check "env": stack-2 must be on prod`,
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "inherited checks are overridden deeper in the tree",
+			layout: []string{
+				"s:stacks/stack-1",
+				"s:stacks/stack-2",
+				"s:stacks/stack-3",
+			},
+			configs: []hclconfig{
+				{
+					path: "/",
+					add: Doc(
+						Check(
+							Labels("backend"),
+							Bool("assertion", false),
+							Str("message", "backend.tf is required"),
+						),
+						Check(
+							Labels("tags"),
+							Bool("assertion", false),
+							Str("message", "tags are required"),
+							Str("severity", "warn"),
+						),
+					),
+				},
+				{
+					path: "/stacks/stack-2",
+					add: Check(
+						Labels("backend"),
+						Bool("assertion", false),
+						Str("message", "backend.tf is recommended"),
+						Str("severity", "warn"),
+					),
+				},
+				{
+					path: "/stacks/stack-3",
+					add: Doc(
+						Check(
+							Labels("backend"),
+							Bool("assertion", true),
+							Str("message", "not used"),
+						),
+						Check(
+							Labels("tags"),
+							Bool("assertion", true),
+							Str("message", "not used"),
+						),
+					),
+				},
+			},
+			wantReport: generate.Report{
+				Successes: []generate.Result{
+					{
+						Dir: project.NewPath("/stacks/stack-2"),
+						Warnings: []string{
+							`/stacks/stack-2/terramate.tm.hcl:3,15-20: check "backend": backend.tf is recommended`,
+							`/terramate.tm.hcl:7,15-20: check "tags": tags are required`,
+						},
+					},
+				},
+				Failures: []generate.FailureResult{
+					{
+						Result: generate.Result{
+							Dir: project.NewPath("/stacks/stack-1"),
+							Warnings: []string{
+								`/terramate.tm.hcl:7,15-20: check "tags": tags are required`,
+							},
+						},
+						Error: errors.E(generate.ErrCheck),
+					},
+				},
+			},
+		},
+		{
+			name: "checks with eval failures",
+			layout: []string{
+				"s:stacks/stack-1",
+			},
+			configs: []hclconfig{
+				{
+					path: "/stacks",
+					add: Check(
+						Labels("backend"),
+						Expr("assertion", "unknown.ref"),
+						Str("message", "msg"),
+					),
+				},
+			},
+			wantReport: generate.Report{
+				Failures: []generate.FailureResult{
+					{
+						Result: generate.Result{
+							Dir: project.NewPath("/stacks/stack-1"),
+						},
+						Error: errors.E(eval.ErrEval),
+					},
+				},
+			},
+		},
+	})
+}
@@ -0,0 +1,144 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package generate
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+
+	"github.com/rs/zerolog/log"
+	"github.com/terramate-io/terramate/config"
+	"github.com/terramate-io/terramate/errors"
+	"github.com/terramate-io/terramate/hcl"
+)
+
+// ObjectsDir is the directory, relative to the project root, where the
+// content of the generated files is stored when the hardlink dedup mode is
+// enabled. Each object is named after the SHA-256 of its content.
+//
+// The directory is ignored by git through a .gitignore created inside it:
+// only the generated files inside the stacks are tracked, and git stores them
+// as regular files, so cloning the repository never depends on hardlinks.
+const ObjectsDir = ".terramate/objects"
+
+const objectsGitignore = "# Created by Terramate. Do not commit the objects.\n*\n"
+
+// linkFile creates a hardlink. It's a variable so tests can simulate
+// filesystems without hardlink support.
+var linkFile = os.Link
+
+// dedupMode returns the configured terramate.config.generate.dedup mode.
+func dedupMode(root *config.Root) string {
+	tmcfg := root.Tree().Node.Terramate
+	if tmcfg == nil ||
+		tmcfg.Config == nil ||
+		tmcfg.Config.Generate == nil ||
+		tmcfg.Config.Generate.Dedup == nil {
+		return hcl.GenerateDedupCopy
+	}
+	return *tmcfg.Config.Generate.Dedup
+}
+
+// writeFile writes the generated body into target. In the hardlink dedup
+// mode, the target is hardlinked to the object holding the same content,
+// falling back to a copy if linking is not possible. Files with a mode are
+// always copied, as the links share the mode of the object.
+//
+// In the copy mode an existing target is written in place, keeping its
+// permissions, ACLs and extended attributes, unless it's a hardlink.
+func writeFile(root *config.Root, target string, body []byte, mode os.FileMode) error {
+	hardlinkMode := dedupMode(root) == hcl.GenerateDedupHardlink
+
+	// WHY: the target may be a hardlink shared with other stacks, so writing
+	// in place would change all of them. Removing it first breaks the link.
+	if hardlinkMode || isHardlinked(target) {
+		if err := os.Remove(target); err != nil && !os.IsNotExist(err) {
+			return errors.E(err, "removing old file")
+		}
+	}
+
+	if mode != 0 {
+		if err := os.WriteFile(target, body, mode); err != nil {
+			return err
+		}
+		// WHY: the mode given to os.WriteFile is masked by the umask.
+		return os.Chmod(target, mode)
+	}
+
+	if hardlinkMode {
+		object, err := writeObject(root.HostDir(), body)
+		if err == nil {
+			err = linkFile(object, target)
+		}
+		if err == nil {
+			return nil
+		}
+		log.Debug().
+			Err(err).
+			Str("file", target).
+			Msg("unable to hardlink generated file, copying it instead")
+	}
+
+	return os.WriteFile(target, body, 0666)
+}
+
+// writeObject stores the body in the objects dir, if not present yet, and
+// returns the path of the object.
+func writeObject(rootdir string, body []byte) (string, error) {
+	sum := sha256.Sum256(body)
+	objectsDir := filepath.Join(rootdir, ObjectsDir)
+	object := filepath.Join(objectsDir, hex.EncodeToString(sum[:]))
+
+	// WHY: an object can be changed through any of its links, so its content
+	// is checked instead of trusting its name.
+	current, err := os.ReadFile(object)
+	if err == nil && bytes.Equal(current, body) {
+		return object, nil
+	}
+
+	if err := createObjectsDir(objectsDir); err != nil {
+		return "", err
+	}
+
+	// The object is replaced atomically, so files already linked to a
+	// modified object keep their content and are detected as outdated.
+	tmp, err := os.CreateTemp(objectsDir, ".tmp-")
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	_, err = tmp.Write(body)
+	if err == nil {
+		err = tmp.Chmod(0644)
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmp.Name(), object); err != nil {
+		return "", err
+	}
+	return object, nil
+}
+
+func createObjectsDir(objectsDir string) error {
+	if err := os.MkdirAll(objectsDir, 0755); err != nil {
+		return err
+	}
+	// The objects live inside the project, so make sure git ignores them.
+	gitignore := filepath.Join(objectsDir, ".gitignore")
+	if _, err := os.Stat(gitignore); err == nil {
+		return nil
+	}
+	if err := os.WriteFile(gitignore, []byte(objectsGitignore), 0644); err != nil {
+		return errors.E(err, "creating objects .gitignore")
+	}
+	return nil
+}
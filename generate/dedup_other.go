@@ -0,0 +1,15 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+//go:build !unix
+
+package generate
+
+import "os"
+
+// isHardlinked tells if the file may have other hardlinks. The link count is
+// not available on this platform, so any existing file is assumed to be linked.
+func isHardlinked(file string) bool {
+	_, err := os.Lstat(file)
+	return err == nil
+}
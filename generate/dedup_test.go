@@ -0,0 +1,248 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package generate_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/madlambda/spells/assert"
+	"github.com/terramate-io/terramate/generate"
+	"github.com/terramate-io/terramate/project"
+	"github.com/terramate-io/terramate/test"
+	"github.com/terramate-io/terramate/test/sandbox"
+)
+
+const dedupConfig = `
+terramate {
+  config {
+    generate {
+      dedup = "hardlink"
+    }
+  }
+}
+
+generate_file "file.txt" {
+  content = "content: ${global.content}"
+}
+
+globals {
+  content = "shared"
+}
+`
+
+func TestGenerateDedupHardlink(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.NoGit(t, true)
+	s.BuildTree([]string{
+		"f:terramate.tm:" + dedupConfig,
+		"s:stacks/a",
+		"s:stacks/b",
+		"s:stacks/c",
+	})
+
+	s.Generate()
+
+	a := statFile(t, s.RootDir(), "stacks/a/file.txt")
+	b := statFile(t, s.RootDir(), "stacks/b/file.txt")
+	c := statFile(t, s.RootDir(), "stacks/c/file.txt")
+	assert.IsTrue(t, os.SameFile(a, b), "stacks a and b must share the file")
+	assert.IsTrue(t, os.SameFile(a, c), "stacks a and c must share the file")
+	assertObjectsCount(t, s.RootDir(), 1)
+	assertNoOutdated(t, s)
+
+	// stack c diverges and the link must be broken only for it.
+	test.WriteFile(t, filepath.Join(s.RootDir(), "stacks/c"), "globals.tm",
+		`globals {
+		  content = "diverged"
+		}`)
+	s.ReloadConfig()
+	s.Generate()
+
+	assertFileContent(t, s.RootDir(), "stacks/a/file.txt", "content: shared")
+	assertFileContent(t, s.RootDir(), "stacks/b/file.txt", "content: shared")
+	assertFileContent(t, s.RootDir(), "stacks/c/file.txt", "content: diverged")
+
+	a = statFile(t, s.RootDir(), "stacks/a/file.txt")
+	b = statFile(t, s.RootDir(), "stacks/b/file.txt")
+	c = statFile(t, s.RootDir(), "stacks/c/file.txt")
+	assert.IsTrue(t, os.SameFile(a, b), "stacks a and b must still share the file")
+	assert.IsTrue(t, !os.SameFile(a, c), "stack c must not share the file anymore")
+	assertObjectsCount(t, s.RootDir(), 2)
+	assertNoOutdated(t, s)
+
+	// a file modified in place through a link is outdated by its content.
+	test.WriteFile(t, filepath.Join(s.RootDir(), "stacks/a"), "file.txt", "changed")
+	assertFileContent(t, s.RootDir(), "stacks/b/file.txt", "changed")
+
+	got, err := generate.DetectOutdated(s.Config(), s.Config().Tree(), project.NewPath("/modules"))
+	assert.NoError(t, err)
+	test.AssertDiff(t, got, []string{"stacks/a/file.txt", "stacks/b/file.txt"})
+
+	s.Generate()
+
+	assertFileContent(t, s.RootDir(), "stacks/a/file.txt", "content: shared")
+	assertFileContent(t, s.RootDir(), "stacks/b/file.txt", "content: shared")
+	assertNoOutdated(t, s)
+}
+
+func TestGenerateDedupObjectsAreIgnoredByGit(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.New(t)
+	s.BuildTree([]string{
+		"f:terramate.tm:" + dedupConfig,
+		"s:stacks/a",
+		"s:stacks/b",
+	})
+	s.Generate()
+	s.Git().CommitAll("generated files")
+	assertObjectsCount(t, s.RootDir(), 1)
+
+	status, err := s.Git().Unwrap().Exec("status", "--porcelain", "--untracked-files=all")
+	assert.NoError(t, err)
+	assert.EqualStrings(t, "", status, "generate must not leave untracked files")
+
+	tracked, err := s.Git().Unwrap().Exec("ls-files", generate.ObjectsDir)
+	assert.NoError(t, err)
+	assert.EqualStrings(t, "", tracked, "objects must not be committed")
+}
+
+func TestGenerateDedupCopyIsDefault(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.NoGit(t, true)
+	s.BuildTree([]string{
+		`f:terramate.tm:generate_file "file.txt" {
+		  content = "shared"
+		}`,
+		"s:stacks/a",
+		"s:stacks/b",
+	})
+
+	s.Generate()
+
+	a := statFile(t, s.RootDir(), "stacks/a/file.txt")
+	b := statFile(t, s.RootDir(), "stacks/b/file.txt")
+	assert.IsTrue(t, !os.SameFile(a, b), "files must not be shared")
+
+	_, err := os.Stat(filepath.Join(s.RootDir(), generate.ObjectsDir))
+	assert.IsTrue(t, os.IsNotExist(err), "objects dir must not be created")
+}
+
+func TestGenerateDedupCopyWritesInPlace(t *testing.T) {
+	t.Parallel()
+
+	if runtime.GOOS == "windows" {
+		t.Skip("the link count of files is not available on windows")
+	}
+
+	s := sandbox.NoGit(t, true)
+	s.BuildTree([]string{
+		`f:terramate.tm:generate_file "file.txt" {
+		  content = "content: ${global.content}"
+		}
+
+		globals {
+		  content = "v1"
+		}`,
+		"s:stacks/a",
+		"s:stacks/b",
+	})
+
+	s.Generate()
+
+	afile := filepath.Join(s.RootDir(), "stacks/a/file.txt")
+	assert.NoError(t, os.Chmod(afile, 0750))
+	before := statFile(t, s.RootDir(), "stacks/a/file.txt")
+
+	// stack b is linked to an external file, which must not be changed.
+	external := filepath.Join(t.TempDir(), "external.txt")
+	assert.NoError(t, os.Link(filepath.Join(s.RootDir(), "stacks/b/file.txt"), external))
+
+	test.WriteFile(t, s.RootDir(), "globals.tm", `globals {
+	  content = "v2"
+	}`)
+	test.WriteFile(t, s.RootDir(), "terramate.tm", `generate_file "file.txt" {
+	  content = "content: ${global.content}"
+	}`)
+	s.ReloadConfig()
+	s.Generate()
+
+	assertFileContent(t, s.RootDir(), "stacks/a/file.txt", "content: v2")
+	after := statFile(t, s.RootDir(), "stacks/a/file.txt")
+	assert.IsTrue(t, os.SameFile(before, after), "file must be written in place")
+	assert.IsTrue(t, after.Mode().Perm() == 0750, "file mode must be kept, got %s", after.Mode())
+
+	assertFileContent(t, s.RootDir(), "stacks/b/file.txt", "content: v2")
+	got, err := os.ReadFile(external)
+	assert.NoError(t, err)
+	assert.EqualStrings(t, "content: v1", string(got))
+}
+
+// Not parallel: it replaces the package hardlink function.
+func TestGenerateDedupFallbackToCopy(t *testing.T) {
+	restore := generate.SetLinkFile(func(string, string) error {
+		return errors.New("hardlinks not supported")
+	})
+	defer restore()
+
+	s := sandbox.NoGit(t, true)
+	s.BuildTree([]string{
+		"f:terramate.tm:" + dedupConfig,
+		"s:stacks/a",
+		"s:stacks/b",
+		"s:stacks/c",
+	})
+
+	s.Generate()
+
+	a := statFile(t, s.RootDir(), "stacks/a/file.txt")
+	b := statFile(t, s.RootDir(), "stacks/b/file.txt")
+	c := statFile(t, s.RootDir(), "stacks/c/file.txt")
+	assert.IsTrue(t, !os.SameFile(a, b) && !os.SameFile(a, c), "files must be copied")
+	assertFileContent(t, s.RootDir(), "stacks/a/file.txt", "content: shared")
+	assertFileContent(t, s.RootDir(), "stacks/b/file.txt", "content: shared")
+	assertFileContent(t, s.RootDir(), "stacks/c/file.txt", "content: shared")
+	assertNoOutdated(t, s)
+}
+
+func statFile(t *testing.T, rootdir, relpath string) os.FileInfo {
+	t.Helper()
+	st, err := os.Stat(filepath.Join(rootdir, relpath))
+	assert.NoError(t, err)
+	return st
+}
+
+func assertFileContent(t *testing.T, rootdir, relpath, want string) {
+	t.Helper()
+	got, err := os.ReadFile(filepath.Join(rootdir, relpath))
+	assert.NoError(t, err)
+	assert.EqualStrings(t, want, string(got))
+}
+
+func assertObjectsCount(t *testing.T, rootdir string, want int) {
+	t.Helper()
+	entries, err := os.ReadDir(filepath.Join(rootdir, generate.ObjectsDir))
+	assert.NoError(t, err)
+	got := 0
+	for _, entry := range entries {
+		if !strings.HasPrefix(entry.Name(), ".") {
+			got++
+		}
+	}
+	assert.EqualInts(t, want, got, "unexpected number of objects")
+}
+
+func assertNoOutdated(t *testing.T, s sandbox.S) {
+	t.Helper()
+	got, err := generate.DetectOutdated(s.Config(), s.Config().Tree(), project.NewPath("/modules"))
+	assert.NoError(t, err)
+	assert.EqualInts(t, 0, len(got), "unexpected outdated files: %v", got)
+}
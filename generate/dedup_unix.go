@@ -0,0 +1,21 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+//go:build unix
+
+package generate
+
+import (
+	"os"
+	"syscall"
+)
+
+// isHardlinked tells if the file has other hardlinks.
+func isHardlinked(file string) bool {
+	st, err := os.Lstat(file)
+	if err != nil {
+		return false
+	}
+	sys, ok := st.Sys().(*syscall.Stat_t)
+	return ok && sys.Nlink > 1
+}
@@ -0,0 +1,124 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package generate
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/terramate-io/terramate/errors"
+)
+
+// dirNames caches the entry names of the directories looked up while
+// generating code, so each directory is listed only once instead of once per
+// file. The files written and removed by the generation are recorded with
+// add and remove. A nil *dirNames lists the directory on every lookup.
+// It's safe for concurrent use.
+type dirNames struct {
+	mu   sync.Mutex
+	dirs map[string]map[string]struct{}
+}
+
+func newDirNames() *dirNames {
+	return &dirNames{
+		dirs: map[string]map[string]struct{}{},
+	}
+}
+
+// nameCase looks up the name of the file at path in its directory.
+// It returns if an entry with the exact name exists and the name of another
+// entry differing from it only by case, if any.
+func (d *dirNames) nameCase(path string) (exists bool, other string, err error) {
+	dir := filepath.Dir(path)
+	name := filepath.Base(path)
+
+	var names map[string]struct{}
+	if d == nil {
+		names, err = listNames(dir)
+		if err != nil {
+			return false, "", err
+		}
+	} else {
+		if err := d.load(dir); err != nil {
+			return false, "", err
+		}
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		names = d.dirs[dir]
+	}
+
+	for entry := range names {
+		switch {
+		case entry == name:
+			exists = true
+		case strings.EqualFold(entry, name):
+			other = entry
+		}
+	}
+	return exists, other, nil
+}
+
+// add records the file at path as created.
+func (d *dirNames) add(path string) {
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if names, ok := d.dirs[filepath.Dir(path)]; ok {
+		names[filepath.Base(path)] = struct{}{}
+	}
+}
+
+// remove records the file at path as removed.
+func (d *dirNames) remove(path string) {
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if names, ok := d.dirs[filepath.Dir(path)]; ok {
+		delete(names, filepath.Base(path))
+	}
+}
+
+// load lists dir if it's not cached yet.
+func (d *dirNames) load(dir string) error {
+	d.mu.Lock()
+	_, ok := d.dirs[dir]
+	d.mu.Unlock()
+	if ok {
+		return nil
+	}
+
+	// WHY: the directory is listed without holding the lock, so stacks
+	// generated in parallel don't wait for each other's listing.
+	names, err := listNames(dir)
+	if err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if _, ok := d.dirs[dir]; !ok {
+		d.dirs[dir] = names
+	}
+	return nil
+}
+
+// listNames returns the entry names of dir. A missing dir has no entries.
+func listNames(dir string) (map[string]struct{}, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	names := make(map[string]struct{}, len(entries))
+	for _, entry := range entries {
+		names[entry.Name()] = struct{}{}
+	}
+	return names, nil
+}
@@ -0,0 +1,23 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package generate
+
+import (
+	"github.com/terramate-io/terramate/config"
+	"github.com/terramate-io/terramate/project"
+)
+
+// SetLinkFile replaces the function used to create hardlinks and returns a
+// function that restores the original one.
+func SetLinkFile(fn func(oldname, newname string) error) (restore func()) {
+	old := linkFile
+	linkFile = fn
+	return func() { linkFile = old }
+}
+
+// DetectOutdatedCached is like [DetectOutdated] but always using the on-disk
+// cache of the outdated code detection with the given version.
+func DetectOutdatedCached(root *config.Root, target *config.Tree, vendorDir project.Path, version string) ([]string, error) {
+	return detectOutdated(root, target, nil, vendorDir, openOutdatedCache(root, vendorDir, version))
+}
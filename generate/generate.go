@@ -24,6 +24,7 @@ import (
 	"github.com/terramate-io/terramate/event"
 	"github.com/terramate-io/terramate/generate/genfile"
 	"github.com/terramate-io/terramate/generate/genhcl"
+	"github.com/terramate-io/terramate/generate/outdatedcache"
 	"github.com/terramate-io/terramate/generate/sharing"
 	"github.com/terramate-io/terramate/globals"
 	"github.com/terramate-io/terramate/hcl"
@@ -74,6 +75,8 @@ type GenFile interface {
 	Condition() bool
 	// Asserts is the origin generate block assert blocks.
 	Asserts() []config.Assert
+	// Mode is the permission mode of the generated file, zero for the default.
+	Mode() os.FileMode
 }
 
 // LoadResult represents all generated files of a specific directory.
@@ -113,7 +116,7 @@ func Load(root *config.Root, vendorDir project.Path) ([]LoadResult, error) {
 			continue
 		}
 		cfg, _ := root.Lookup(st.Dir())
-		generated, err := loadStackCodeCfgs(root, cfg, vendorDir, nil)
+		generated, _, err := loadStackCodeCfgs(root, cfg, vendorDir, nil)
 		if err != nil {
 			res.Err = errors.E(err, "while loading configs of stack %s", st.Dir())
 			results[i] = res
@@ -136,7 +139,7 @@ func Load(root *config.Root, vendorDir project.Path) ([]LoadResult, error) {
 				continue
 			}
 
-			file, skip, err := genfile.Eval(block, dircfg, evalctx)
+			file, skip, err := evalRootGenFile(root, block, dircfg, evalctx, vendorDir, nil)
 			if err != nil {
 				res.Err = errors.L(res.Err, err).AsError()
 				results = append(results, res)
@@ -157,6 +160,74 @@ func Load(root *config.Root, vendorDir project.Path) ([]LoadResult, error) {
 	return results, nil
 }
 
+// LoadVendorRequests loads the generated code of all stacks and of the
+// context=root blocks, like [Load], and returns all the tm_vendor requests
+// made while evaluating it. Each request is
+// returned once, in the order they were first made.
+//
+// Failures loading the generated code of any stack are returned as an error
+// list since the vendor requests of that stack would be missing.
+func LoadVendorRequests(root *config.Root, vendorDir project.Path) ([]event.VendorRequest, error) {
+	stacks, err := config.LoadAllStacks(root, root.Tree())
+	if err != nil {
+		return nil, err
+	}
+
+	vendorRequests := make(chan event.VendorRequest)
+	collected := make(chan []event.VendorRequest)
+
+	go func() {
+		var requests []event.VendorRequest
+		seen := map[string]struct{}{}
+		for req := range vendorRequests {
+			if req.Error != nil {
+				// the evaluation failure is reported by loadStackCodeCfgs.
+				continue
+			}
+			key := req.VendorDir.String() + "|" + req.Source.Raw
+			if _, ok := seen[key]; ok {
+				continue
+			}
+			seen[key] = struct{}{}
+			requests = append(requests, req)
+		}
+		collected <- requests
+	}()
+
+	errs := errors.L()
+	for _, st := range stacks {
+		cfg, _ := root.Lookup(st.Dir())
+		_, _, err := loadStackCodeCfgs(root, cfg, vendorDir, vendorRequests)
+		if err != nil {
+			errs.Append(errors.E(err, "while loading configs of stack %s", st.Dir()))
+		}
+	}
+
+	for _, cfg := range root.Tree().AsList() {
+		if cfg.IsEmptyConfig() {
+			continue
+		}
+		_, err := loadRootCodeCfgs(root, cfg, vendorDir, vendorRequests)
+		if err != nil {
+			errs.Append(errors.E(err, "while loading root configs of dir %s", cfg.Dir()))
+		}
+	}
+
+	close(vendorRequests)
+	requests := <-collected
+	return requests, errs.AsError()
+}
+
+// Contexts of the generate blocks selectable on [Do].
+const (
+	// ContextAll selects the blocks of all contexts.
+	ContextAll = "all"
+	// ContextStack selects only the blocks with context=stack.
+	ContextStack = genfile.StackContext
+	// ContextRoot selects only the blocks with context=root.
+	ContextRoot = genfile.RootContext
+)
+
 // Do will generate code for the entire configuration.
 //
 // There generation mechanism depend on the generate_* block context attribute:
@@ -180,6 +251,15 @@ func Load(root *config.Root, vendorDir project.Path) ([]LoadResult, error) {
 // calls to communicate each vendor request. If the caller is not interested on
 // [event.VendorRequest] events just pass a nil channel.
 //
+// The context selects which generate blocks are generated, it must be one of
+// [ContextAll], [ContextStack] or [ContextRoot]. The orphaned generated files
+// outside of stacks are only cleaned up when the stack context is selected.
+//
+// Generated files not generated anymore (eg.: the block was removed or its
+// condition is false) are only deleted if allowDelete is true or if the
+// terramate.config.generate.allow_deletion is set, otherwise they are reported
+// as pending deletion.
+//
 // It will return a report including details of which directories succeed and
 // failed on code generation, any failure found is added to the report but does
 // not abort the overall code generation process, so partial results can be
@@ -190,9 +270,48 @@ func Do(
 	parallel int,
 	vendorDir project.Path,
 	vendorRequests chan<- event.VendorRequest,
+	allowDelete bool,
+	context string,
+) *Report {
+	return doGenerate(root, targetDir, nil, parallel, vendorDir, vendorRequests, allowDelete, context)
+}
+
+// DoStacks is like [Do] but only the given stacks inside the targetDir are
+// generated. The generate_file blocks with context=root and the cleanup of
+// orphaned files are not affected by the selection.
+func DoStacks(
+	root *config.Root,
+	targetDir project.Path,
+	stacks project.Paths,
+	parallel int,
+	vendorDir project.Path,
+	vendorRequests chan<- event.VendorRequest,
+	allowDelete bool,
+	context string,
+) *Report {
+	selected := map[project.Path]bool{}
+	for _, dir := range stacks {
+		selected[dir] = true
+	}
+	return doGenerate(root, targetDir, selected, parallel, vendorDir, vendorRequests, allowDelete, context)
+}
+
+// doGenerate implements [Do] and [DoStacks]. If selected is nil then all the
+// stacks inside targetDir are generated.
+func doGenerate(
+	root *config.Root,
+	targetDir project.Path,
+	selected map[project.Path]bool,
+	parallel int,
+	vendorDir project.Path,
+	vendorRequests chan<- event.VendorRequest,
+	allowDelete bool,
+	context string,
 ) *Report {
 	logger := log.With().
 		Stringer("target_dir", targetDir).
+		Str("context", context).
+		Bool("selected_stacks", selected != nil).
 		Logger()
 
 	startTime := time.Now()
@@ -205,6 +324,14 @@ func Do(
 			Msg("generate finished")
 	}()
 
+	switch context {
+	case ContextAll, ContextStack, ContextRoot:
+	default:
+		return &Report{
+			BootstrapErr: errors.E("invalid generate context %q", context),
+		}
+	}
+
 	tree, ok := root.Lookup(targetDir)
 	if !ok {
 		return &Report{
@@ -215,17 +342,74 @@ func Do(
 		parallel = runtime.NumCPU()
 	}
 
-	logger = logger.With().Int("parallel", parallel).Logger()
+	if !allowDelete {
+		allowDelete = deletionAllowed(root)
+	}
 
-	workchan := make(chan *config.Tree)
-	reportchan := make(chan *Report)
+	logger = logger.With().
+		Int("parallel", parallel).
+		Bool("allow_delete", allowDelete).
+		Logger()
+
+	// The code generation of all the stacks and of the generate_file blocks
+	// with context=root is planned before writing any file, so files
+	// generated into the same host path by different scopes are detected
+	// and nothing is written.
+	var stacks config.List[*config.Tree]
+	if context != ContextRoot {
+		for _, st := range tree.Stacks() {
+			if selected == nil || selected[st.Dir()] {
+				stacks = append(stacks, st)
+			}
+		}
+	}
+	stackPlans := make([]*stackGenPlan, len(stacks))
+	rootPlan := &rootGenPlan{
+		timers: map[string]*phaseTimer{},
+		report: &Report{},
+	}
+
+	workchan := make(chan int)
 	var wg sync.WaitGroup
 	for i := 0; i < parallel; i++ {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			for cfg := range workchan {
-				reportchan <- stackGenerate(root, cfg, vendorDir, vendorRequests)
+			for i := range workchan {
+				stackPlans[i] = planStackGenerate(root, stacks[i], vendorDir, vendorRequests)
+			}
+		}()
+	}
+
+	if context != ContextStack {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rootPlan = planRootGenerate(root, targetDir, vendorDir, vendorRequests)
+		}()
+	}
+
+	for i := range stacks {
+		workchan <- i
+	}
+
+	close(workchan)
+	wg.Wait()
+
+	if conflicts := checkHostFileConflicts(root, stackPlans, rootPlan); conflicts.HasFailures() {
+		conflicts.sort()
+		conflicts.Metrics = *newMetrics(time.Since(startTime), len(stacks), nil)
+		return conflicts
+	}
+
+	names := newDirNames()
+	workchan = make(chan int)
+	for i := 0; i < parallel; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range workchan {
+				stackGenerate(root, stackPlans[i], names, allowDelete)
 			}
 		}()
 	}
@@ -233,62 +417,82 @@ func Do(
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		reportchan <- rootGenerate(root, targetDir)
-	}()
-
-	var report *Report
-	mergedReports := make(chan struct{})
-	go func() {
-		report = mergeReports(reportchan)
-		mergedReports <- struct{}{}
+		if !rootPlan.report.HasFailures() {
+			generateRootFiles(root, rootPlan.files, rootPlan.timers, rootPlan.report, names, allowDelete)
+		}
+		rootPlan.report.scopes = append(rootPlan.report.scopes, rootPlan.scopes()...)
 	}()
 
-	for _, cfg := range tree.Stacks() {
-		workchan <- cfg
+	for i := range stacks {
+		workchan <- i
 	}
 
 	close(workchan)
 	wg.Wait()
+
+	reportchan := make(chan *Report, len(stacks)+1)
+	for _, plan := range stackPlans {
+		reportchan <- plan.report
+	}
+	reportchan <- rootPlan.report
 	close(reportchan)
 
-	<-mergedReports
+	report := mergeReports(reportchan)
+	report.addRootContextDirs(rootPlan.report)
 
-	return cleanupOrphaned(root, tree, report)
+	cleanupStart := time.Now()
+	if context != ContextRoot {
+		report = cleanupOrphaned(root, tree, report, allowDelete)
+	} else {
+		report.sort()
+	}
+
+	report.Metrics = *newMetrics(time.Since(startTime), len(stacks), report.scopes)
+	report.Metrics.Phases.Write += time.Since(cleanupStart)
+	return report
 }
 
-// stackGenerate assumes cfg is a stack.
-func stackGenerate(
+// stackGenPlan is the planned code generation of a stack. The files are only
+// written after all the plans are checked for conflicts.
+type stackGenPlan struct {
+	cfg       *config.Tree
+	generated []GenFile
+	// warnings are the failed assertions on warning mode and the failed
+	// checks with warn severity.
+	warnings []string
+	timer    *phaseTimer
+	report   *Report
+}
+
+// planStackGenerate loads and validates the files generated by the stack,
+// without writing them. It assumes cfg is a stack.
+func planStackGenerate(
 	root *config.Root,
 	cfg *config.Tree,
 	vendorDir project.Path,
 	vendorRequests chan<- event.VendorRequest,
-) *Report {
-	logger := log.With().
-		Str("action", "stackGenerate()").
-		Stringer("stack", cfg.Dir()).
-		Logger()
-
-	startTime := time.Now()
-	defer func() {
-		endTime := time.Now()
-		logger.Debug().
-			Time("started_at", startTime).
-			Time("finished_at", endTime).
-			Dur("elapsed_time_ms", endTime.Sub(startTime)).
-			Msg("stack generation finished")
-	}()
-	report := &Report{}
+) *stackGenPlan {
+	plan := &stackGenPlan{
+		cfg:    cfg,
+		timer:  newPhaseTimer(),
+		report: &Report{},
+	}
+	report := plan.report
+	timer := plan.timer
 
 	_, err := cfg.Stack()
+	timer.lap(&timer.phases.Load)
 	if err != nil {
 		report.BootstrapErr = err
-		return report
+		return plan
 	}
 
-	generated, err := loadStackCodeCfgs(root, cfg, vendorDir, vendorRequests)
+	generated, warnings, err := loadStackCodeCfgs(root, cfg, vendorDir, vendorRequests)
+	timer.lap(&timer.phases.Eval)
+	plan.warnings = warnings
 	if err != nil {
 		report.addFailure(cfg.Dir(), err)
-		return report
+		return plan
 	}
 
 	errsmap := checkFileConflict(generated)
@@ -298,25 +502,90 @@ func stackGenerate(
 			errs.Append(err)
 		}
 		report.addFailure(cfg.Dir(), errs.AsError())
-		return report
+		return plan
 	}
 
 	err = validateStackGeneratedFiles(root, cfg.HostDir(), generated)
+	timer.lap(&timer.phases.Render)
 	if err != nil {
 		report.addFailure(cfg.Dir(), err)
-		return report
+		return plan
+	}
+
+	plan.generated = generated
+	return plan
+}
+
+// stackGenerate writes the files planned for the stack, adding the results
+// to the plan report.
+func stackGenerate(root *config.Root, plan *stackGenPlan, names *dirNames, allowDelete bool) {
+	cfg := plan.cfg
+	report := plan.report
+	timer := plan.timer
+
+	logger := log.With().
+		Str("action", "stackGenerate()").
+		Stringer("stack", cfg.Dir()).
+		Logger()
+
+	startTime := time.Now()
+	defer func() {
+		endTime := time.Now()
+		logger.Debug().
+			Time("started_at", startTime).
+			Time("finished_at", endTime).
+			Dur("elapsed_time_ms", endTime.Sub(startTime)).
+			Msg("stack generation finished")
+	}()
+
+	defer func() {
+		report.addWarnings(cfg.Dir(), plan.warnings)
+		report.scopes = append(report.scopes, timer.scope(cfg.Dir(), ""))
+	}()
+
+	if report.HasFailures() {
+		return
 	}
 
-	allFiles, err := allStackGeneratedFiles(root, cfg.HostDir(), generated)
+	timer.restart()
+	generated := plan.generated
+
+	allFiles, err := allStackGeneratedFiles(root, names, cfg.HostDir(), generated)
+	timer.lap(&timer.phases.Load)
 	if err != nil {
 		report.addFailure(cfg.Dir(), errors.E(err, "listing all generated files"))
-		return report
+		return
 	}
 
 	logger.Trace().Msg("saving generated files")
 
 	stackReport := dirReport{}
 
+	// WHY: files renamed only by case must be removed before the new ones are
+	// written because both names are the same file in case-insensitive
+	// filesystems. Without deletion allowed, the write fails on the conflict.
+	if allowDelete {
+		for _, file := range generated {
+			if !file.Condition() {
+				continue
+			}
+			for filename := range allFiles {
+				if filename == file.Label() || !strings.EqualFold(filename, file.Label()) {
+					continue
+				}
+				path := filepath.Join(cfg.HostDir(), filename)
+				err := os.Remove(path)
+				if err != nil {
+					report.addFailure(cfg.Dir(), errors.E(err, "removing file %s", filename))
+					return
+				}
+				names.remove(path)
+				stackReport.addDeletedFile(filename)
+				delete(allFiles, filename)
+			}
+		}
+	}
+
 	for _, file := range generated {
 		filename := file.Label()
 		path := filepath.Join(cfg.HostDir(), filename)
@@ -333,9 +602,16 @@ func stackGenerate(
 
 		// Change detection + remove entries that got re-generated
 		oldFileBody, oldExists := allFiles[filename]
+		modeChanged, err := modeOutdated(path, effectiveMode(path, file))
+		if err != nil {
+			report.addFailure(cfg.Dir(), errors.E(err, "checking mode of file %q", filename))
+			continue
+		}
+		timer.lap(&timer.phases.Render)
 
-		if !oldExists || oldFileBody != body {
-			err := writeGeneratedCode(root, path, file)
+		if !oldExists || oldFileBody != body || modeChanged {
+			err := writeGeneratedCode(root, names, path, file)
+			timer.lap(&timer.phases.Write)
 			if err != nil {
 				report.addFailure(cfg.Dir(), errors.E(err, "saving file %q", filename))
 				continue
@@ -351,7 +627,7 @@ func stackGenerate(
 			stackReport.addCreatedFile(filename)
 		} else {
 			delete(allFiles, filename)
-			if body != oldFileBody {
+			if body != oldFileBody || modeChanged {
 				log.Info().
 					Stringer("stack", cfg.Dir()).
 					Str("file", filename).
@@ -363,6 +639,16 @@ func stackGenerate(
 	}
 
 	for filename := range allFiles {
+		if !allowDelete {
+			log.Info().
+				Stringer("stack", cfg.Dir()).
+				Str("file", filename).
+				Msg("file pending deletion")
+
+			stackReport.addPendingDeletion(filename)
+			continue
+		}
+
 		log.Info().
 			Stringer("stack", cfg.Dir()).
 			Str("file", filename).
@@ -376,17 +662,58 @@ func stackGenerate(
 			report.addFailure(cfg.Dir(), errors.E("removing file %s", filename))
 			continue
 		}
+		names.remove(path)
 
 		delete(allFiles, filename)
 	}
 
+	timer.lap(&timer.phases.Write)
+
+	// the checks are only evaluated after all the files of the stack are
+	// successfully generated.
+	if !report.HasFailures() {
+		st, err := cfg.Stack()
+		if err == nil {
+			var warnings []string
+			warnings, err = checkStack(root, st, generated)
+			plan.warnings = append(plan.warnings, warnings...)
+		}
+		timer.lap(&timer.phases.Eval)
+		stackReport.err = err
+	}
+
 	report.addDirReport(cfg.Dir(), stackReport)
-	return report
 }
 
-func rootGenerate(root *config.Root, target project.Path) *Report {
+// rootGenPlan is the planned code generation of the generate_file blocks with
+// context=root. The files are only written after all the plans are checked
+// for conflicts.
+type rootGenPlan struct {
+	files []GenFile
+	// timers are indexed by the generated file label.
+	timers map[string]*phaseTimer
+	report *Report
+}
+
+func (p *rootGenPlan) scopes() []ScopeMetrics {
+	var scopes []ScopeMetrics
+	for label, timer := range p.timers {
+		targetDir := project.NewPath(path.Clean("/" + path.Dir(label)))
+		scopes = append(scopes, timer.scope(targetDir, label))
+	}
+	return scopes
+}
+
+// planRootGenerate evaluates and validates the generate_file blocks with
+// context=root inside the target directory, without writing any file.
+func planRootGenerate(
+	root *config.Root,
+	target project.Path,
+	vendorDir project.Path,
+	vendorRequests chan<- event.VendorRequest,
+) *rootGenPlan {
 	logger := log.With().
-		Str("action", "rootGenerate()").
+		Str("action", "planRootGenerate()").
 		Stringer("target_dir", target).
 		Logger()
 
@@ -397,14 +724,19 @@ func rootGenerate(root *config.Root, target project.Path) *Report {
 			Time("started_at", startTime).
 			Time("finished_at", endTime).
 			Dur("elapsed_time_ms", endTime.Sub(startTime)).
-			Msg("root generation finished")
+			Msg("root generation planned")
 	}()
 
-	report := &Report{}
+	plan := &rootGenPlan{
+		timers: map[string]*phaseTimer{},
+		report: &Report{},
+	}
+	report := plan.report
 	evalctx := eval.NewContext(stdlib.Functions(root.HostDir(), root.Tree().Node.Experiments()))
 	evalctx.SetNamespace("terramate", root.Runtime())
 
 	var files []GenFile
+	timers := plan.timers
 
 	for _, cfg := range root.Tree().AsList() {
 		logger := logger.With().
@@ -433,7 +765,7 @@ func rootGenerate(root *config.Root, target project.Path) *Report {
 			err := validateRootGenerateBlock(root, block)
 			if err != nil {
 				report.addFailure(targetDir, err)
-				return report
+				return plan
 			}
 
 			logger.Trace().Msg("block validated successfully")
@@ -443,10 +775,18 @@ func rootGenerate(root *config.Root, target project.Path) *Report {
 				continue
 			}
 
-			file, skip, err := genfile.Eval(block, cfg, evalctx)
+			timer := timers[block.Label]
+			if timer == nil {
+				timer = newPhaseTimer()
+				timers[block.Label] = timer
+			}
+			timer.restart()
+
+			file, skip, err := evalRootGenFile(root, block, cfg, evalctx, vendorDir, vendorRequests)
+			timer.lap(&timer.phases.Eval)
 			if err != nil {
 				report.addFailure(targetDir, err)
-				return report
+				return plan
 			}
 
 			if skip {
@@ -468,35 +808,70 @@ func rootGenerate(root *config.Root, target project.Path) *Report {
 				targetDir := path.Dir(file)
 				report.addFailure(project.NewPath(targetDir), err)
 			}
-			return report
+			return plan
 		}
 	}
 
 	logger.Trace().Msg("no conflicts found")
 
-	generateRootFiles(root, files, report)
-	return report
+	plan.files = files
+	return plan
 }
 
-func handleAsserts(rootdir string, dir string, asserts []config.Assert) error {
+// CheckAsserts evaluates the assert blocks that apply to each of the given
+// stacks, which includes the ones defined on any of its parent directories,
+// using the evaluation context of the stack.
+//
+// The failures of all stacks are aggregated into a single error list, where
+// each error names the violating stack. Assertions on warning mode are only
+// logged and never produce an error.
+func CheckAsserts(root *config.Root, stacks config.List[*config.SortableStack]) error {
+	errs := errors.L()
+	for _, st := range stacks {
+		if err := checkStackAsserts(root, st.Stack); err != nil {
+			errs.Append(errors.E(err, "stack %s", st.Dir()))
+		}
+	}
+	return errs.AsError()
+}
+
+func checkStackAsserts(root *config.Root, st *config.Stack) error {
+	report := globals.ForStack(root, st)
+	if err := report.AsError(); err != nil {
+		return err
+	}
+	evalctx := stack.NewEvalCtx(root, st, report.Globals)
+	asserts, err := loadAsserts(root, st, evalctx.Context)
+	if err != nil {
+		return err
+	}
+	_, err = handleAsserts(root.HostDir(), st.HostDir(root), asserts)
+	return err
+}
+
+// handleAsserts returns the failed assertions of the given asserts as an error
+// list. The failures of assertions on warning mode are returned as warnings
+// instead, formatted as the errors.
+func handleAsserts(rootdir string, dir string, asserts []config.Assert) ([]string, error) {
 	logger := log.With().
 		Str("action", "generate.handleAsserts()").
 		Str("dir", dir).
 		Logger()
+	var warnings []string
 	errs := errors.L()
 	for _, assert := range asserts {
 		if !assert.Assertion {
 			assertRange := assert.Range
 			assertRange.Filename = project.PrjAbsPath(rootdir, assert.Range.Filename).String()
+			msg := fmt.Sprintf("%s: %s", assertRange, assert.Message)
 			if assert.Warning {
+				warnings = append(warnings, msg)
 				log.Warn().
 					Stringer("origin", assertRange).
 					Str("msg", assert.Message).
 					Str("dir", dir).
 					Msg("assertion failed")
 			} else {
-				msg := fmt.Sprintf("%s: %s", assertRange, assert.Message)
-
 				logger.Debug().Msgf("assertion failure detected: %s", msg)
 
 				err := errors.E(ErrAssertion, msg)
@@ -504,7 +879,7 @@ func handleAsserts(rootdir string, dir string, asserts []config.Assert) error {
 			}
 		}
 	}
-	return errs.AsError()
+	return warnings, errs.AsError()
 }
 
 // ListStackGenFiles will list the path of all generated code inside the given dir
@@ -592,6 +967,28 @@ processSubdirs:
 // DetectOutdated will verify if the given config has outdated code in the target tree
 // and return a list of filenames that are outdated, ordered lexicographically.
 func DetectOutdated(root *config.Root, target *config.Tree, vendorDir project.Path) ([]string, error) {
+	return detectOutdated(root, target, nil, vendorDir, newOutdatedCache(root, vendorDir))
+}
+
+// DetectOutdatedStacks is like [DetectOutdated] but only the generated code of
+// the given stacks is checked, so stacks not generated by [DoStacks] are not
+// reported. The code generated with context=root and the orphaned files are
+// always checked.
+func DetectOutdatedStacks(root *config.Root, target *config.Tree, stacks project.Paths, vendorDir project.Path) ([]string, error) {
+	selected := map[project.Path]bool{}
+	for _, dir := range stacks {
+		selected[dir] = true
+	}
+	return detectOutdated(root, target, selected, vendorDir, newOutdatedCache(root, vendorDir))
+}
+
+func detectOutdated(
+	root *config.Root,
+	target *config.Tree,
+	selected map[project.Path]bool,
+	vendorDir project.Path,
+	cache *outdatedCache,
+) ([]string, error) {
 	logger := log.With().
 		Str("action", "generate.DetectOutdated()").
 		Stringer("dir", target.Dir()).
@@ -599,11 +996,15 @@ func DetectOutdated(root *config.Root, target *config.Tree, vendorDir project.Pa
 
 	outdatedFiles := newStringSet()
 	errs := errors.L()
+	names := newDirNames()
 
 	logger.Debug().Msg("checking outdated code inside stacks")
 
 	for _, cfg := range target.Stacks() {
-		outdated, err := stackContextOutdated(root, cfg, vendorDir)
+		if selected != nil && !selected[cfg.Dir()] {
+			continue
+		}
+		outdated, err := stackContextOutdated(root, cfg, vendorDir, cache, names)
 		if err != nil {
 			errs.Append(err)
 			continue
@@ -617,7 +1018,7 @@ func DetectOutdated(root *config.Root, target *config.Tree, vendorDir project.Pa
 	}
 
 	for _, cfg := range target.AsList() {
-		outdated, err := rootContextOutdated(root, cfg)
+		outdated, err := rootContextOutdated(root, cfg, vendorDir, cache, names)
 		if err != nil {
 			errs.Append(err)
 			continue
@@ -668,7 +1069,7 @@ func DetectOutdated(root *config.Root, target *config.Tree, vendorDir project.Pa
 
 // stackContextOutdated will verify if a given directory has outdated code
 // for blocks with context=stack and return a list of filenames that are outdated.
-func stackContextOutdated(root *config.Root, cfg *config.Tree, vendorDir project.Path) ([]string, error) {
+func stackContextOutdated(root *config.Root, cfg *config.Tree, vendorDir project.Path, cache *outdatedCache, names *dirNames) ([]string, error) {
 	logger := log.With().
 		Str("action", "generate.stackOutdated").
 		Stringer("stack", cfg.Dir()).
@@ -676,11 +1077,17 @@ func stackContextOutdated(root *config.Root, cfg *config.Tree, vendorDir project
 
 	cfgpath := cfg.HostDir()
 
-	generated, err := loadStackCodeCfgs(root, cfg, vendorDir, nil)
-	if err != nil {
-		return nil, err
-	}
-	err = validateStackGeneratedFiles(root, cfgpath, generated)
+	digests, err := cache.digests("stack:"+cfg.Dir().String(), cfg, func() ([]outdatedcache.File, error) {
+		generated, _, err := loadStackCodeCfgs(root, cfg, vendorDir, nil)
+		if err != nil {
+			return nil, err
+		}
+		err = validateStackGeneratedFiles(root, cfgpath, generated)
+		if err != nil {
+			return nil, err
+		}
+		return digestGenFiles(cfgpath, generated), nil
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -695,7 +1102,7 @@ func stackContextOutdated(root *config.Root, cfg *config.Tree, vendorDir project
 	// We start with the assumption that all gen files on the stack
 	// are outdated and then update the outdated files set as we go.
 	outdatedFiles := newStringSet(genfilesOnFs...)
-	err = updateOutdatedFiles(root, cfgpath, generated, outdatedFiles)
+	err = updateOutdatedFiles(cfgpath, digests, outdatedFiles, names)
 	if err != nil {
 		return nil, errors.E(err, "handling detected files")
 	}
@@ -704,10 +1111,14 @@ func stackContextOutdated(root *config.Root, cfg *config.Tree, vendorDir project
 
 // rootContextOutdated will verify if the given directory has outdated code for context=root blocks
 // and return the list of outdated files.
-func rootContextOutdated(root *config.Root, cfg *config.Tree) ([]string, error) {
-	cfgpath := cfg.HostDir()
-
-	generated, err := loadRootCodeCfgs(root, cfg)
+func rootContextOutdated(root *config.Root, cfg *config.Tree, vendorDir project.Path, cache *outdatedCache, names *dirNames) ([]string, error) {
+	digests, err := cache.digests("root:"+cfg.Dir().String(), cfg, func() ([]outdatedcache.File, error) {
+		generated, err := loadRootCodeCfgs(root, cfg, vendorDir, nil)
+		if err != nil {
+			return nil, err
+		}
+		return digestGenFiles(root.HostDir(), generated), nil
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -715,50 +1126,65 @@ func rootContextOutdated(root *config.Root, cfg *config.Tree) ([]string, error)
 	// We start with the assumption that all gen files on the stack
 	// are outdated and then update the outdated files set as we go.
 	outdatedFiles := newStringSet()
-	err = updateOutdatedFiles(root, cfgpath, generated, outdatedFiles)
+	err = updateOutdatedFiles(root.HostDir(), digests, outdatedFiles, names)
 	if err != nil {
 		return nil, errors.E(err, "handling detected files")
 	}
 	return outdatedFiles.slice(), nil
 }
 
-func updateOutdatedFiles(root *config.Root, cfgpath string, generated []GenFile, outdatedFiles *stringSet) error {
+// digestGenFiles returns the digests of the generated files, which are
+// generated relative to basedir.
+func digestGenFiles(basedir string, generated []GenFile) []outdatedcache.File {
+	digests := make([]outdatedcache.File, 0, len(generated))
+	for _, genfile := range generated {
+		filename := genfile.Label()
+		if genfile.Context() == "root" {
+			filename = filename[1:]
+		}
+		digest := outdatedcache.File{
+			Filename:  filename,
+			Condition: genfile.Condition(),
+			Mode:      effectiveMode(filepath.Join(basedir, filename), genfile),
+		}
+		if genfile.Condition() {
+			digest.Digest = outdatedcache.Digest(genfile.Header() + genfile.Body())
+		}
+		digests = append(digests, digest)
+	}
+	return digests
+}
+
+func updateOutdatedFiles(basedir string, generated []outdatedcache.File, outdatedFiles *stringSet, names *dirNames) error {
 	logger := log.With().
 		Str("action", "generate.updateOutdatedFiles").
-		Str("stack", cfgpath).
+		Str("dir", basedir).
 		Logger()
 
 	// So we can properly check blocks with condition false/true in any order
 	blocksCondTrue := map[string]struct{}{}
 
 	for _, genfile := range generated {
-		logger = logger.With().
-			Str("label", genfile.Label()).
-			Logger()
+		filename := genfile.Filename
+		targetpath := filepath.Join(basedir, filename)
 
-		var targetpath string
-		var filename string
-		if genfile.Context() == "root" {
-			filename = genfile.Label()[1:]
-			targetpath = filepath.Join(root.HostDir(), filename)
-		} else {
-			filename = genfile.Label()
-			targetpath = filepath.Join(cfgpath, filename)
-		}
+		logger := logger.With().
+			Str("filename", filename).
+			Logger()
 
-		currentCode, codeFound, err := readFile(targetpath)
+		currentCode, codeFound, err := readFile(names, targetpath)
 		if err != nil {
 			return err
 		}
 
-		if genfile.Condition() {
+		if genfile.Condition {
 			blocksCondTrue[filename] = struct{}{}
 		}
 
 		_, prevBlockCondTrue := blocksCondTrue[filename]
 
 		if !codeFound {
-			if !genfile.Condition() && !prevBlockCondTrue {
+			if !genfile.Condition && !prevBlockCondTrue {
 				logger.Debug().Msg("not outdated: condition = false")
 
 				outdatedFiles.remove(filename)
@@ -771,7 +1197,7 @@ func updateOutdatedFiles(root *config.Root, cfgpath string, generated []GenFile,
 			continue
 		}
 
-		if !genfile.Condition() {
+		if !genfile.Condition {
 			if prevBlockCondTrue {
 				logger.Debug().Msg("condition = false but other block was true, ignoring")
 				continue
@@ -782,10 +1208,17 @@ func updateOutdatedFiles(root *config.Root, cfgpath string, generated []GenFile,
 			continue
 		}
 
-		generatedCode := genfile.Header() + genfile.Body()
-		if generatedCode != currentCode {
+		modeChanged, err := modeOutdated(targetpath, genfile.Mode)
+		if err != nil {
+			return err
+		}
+		if outdatedcache.Digest(currentCode) != genfile.Digest {
 			logger.Debug().Msg("outdated: code on fs differs from generated from config")
 
+			outdatedFiles.add(filename)
+		} else if modeChanged {
+			logger.Debug().Msg("outdated: mode of the file on fs differs from the config")
+
 			outdatedFiles.add(filename)
 		} else {
 			logger.Debug().Msg("not outdated: code on fs and generated from config equals")
@@ -797,27 +1230,41 @@ func updateOutdatedFiles(root *config.Root, cfgpath string, generated []GenFile,
 	return nil
 }
 
-func writeGeneratedCode(root *config.Root, target string, genfile GenFile) error {
+func writeGeneratedCode(root *config.Root, names *dirNames, target string, genfile GenFile) error {
 	body := genfile.Header() + genfile.Body()
 
 	if genfile.Header() != "" {
 		// WHY: some file generation strategies don't provide
 		// headers, like generate_file, so we can't detect
 		// if we are overwriting a Terramate generated file.
-		if err := checkFileCanBeOverwritten(root, target); err != nil {
+		if err := checkFileCanBeOverwritten(root, names, target); err != nil {
 			return err
 		}
 	}
 
+	_, other, err := names.nameCase(target)
+	if err != nil {
+		return err
+	}
+	if other != "" {
+		return errors.E(config.ErrPathCaseConflict,
+			"file %q differs only by case from the existing file %q",
+			filepath.Base(target), other)
+	}
+
 	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
 		return err
 	}
 
-	return os.WriteFile(target, []byte(body), 0666)
+	if err := writeFile(root, target, []byte(body), effectiveMode(target, genfile)); err != nil {
+		return err
+	}
+	names.add(target)
+	return nil
 }
 
-func checkFileCanBeOverwritten(root *config.Root, path string) error {
-	_, _, err := readGeneratedFile(root, path)
+func checkFileCanBeOverwritten(root *config.Root, names *dirNames, path string) error {
+	_, _, err := readGeneratedFile(root, names, path)
 	return err
 }
 
@@ -828,8 +1275,8 @@ func checkFileCanBeOverwritten(root *config.Root, path string) error {
 // The returned boolean indicates if the file exists, so the contents of
 // the file + true is returned if a file is found, but if no file is found
 // it will return an empty string and false indicating that the file doesn't exist.
-func readGeneratedFile(root *config.Root, path string) (string, bool, error) {
-	data, found, err := readFile(path)
+func readGeneratedFile(root *config.Root, names *dirNames, path string) (string, bool, error) {
+	data, found, err := readFile(names, path)
 	if err != nil {
 		return "", false, err
 	}
@@ -851,14 +1298,16 @@ func readGeneratedFile(root *config.Root, path string) (string, bool, error) {
 // The returned boolean indicates if the file exists, so the contents of
 // the file + true is returned if a file is found, but if no file is found
 // it will return an empty string and false indicating that the file doesn't exist.
-func readFile(path string) (string, bool, error) {
-	_, err := os.Stat(path)
+// A file whose name differs only by case is not found, even in case-insensitive
+// filesystems.
+func readFile(names *dirNames, path string) (string, bool, error) {
+	exists, _, err := names.nameCase(path)
 	if err != nil {
-		if errors.Is(err, fs.ErrNotExist) {
-			return "", false, nil
-		}
 		return "", false, err
 	}
+	if !exists {
+		return "", false, nil
+	}
 
 	data, err := os.ReadFile(path)
 	if err != nil {
@@ -870,6 +1319,7 @@ func readFile(path string) (string, bool, error) {
 
 func allStackGeneratedFiles(
 	root *config.Root,
+	names *dirNames,
 	dir string,
 	genfiles []GenFile,
 ) (map[string]string, error) {
@@ -892,27 +1342,35 @@ func allStackGeneratedFiles(
 
 	for _, filename := range files {
 		path := filepath.Join(dir, filename)
-		body, err := os.ReadFile(path)
+		body, found, err := readFile(names, path)
 		if err != nil {
-			if os.IsNotExist(err) {
-				continue
-			}
 			return nil, errors.E(err, "reading generated file")
 		}
+		if !found {
+			continue
+		}
 
-		allFiles[filename] = string(body)
+		allFiles[filename] = body
 	}
 
 	return allFiles, nil
 }
 
-func generateRootFiles(root *config.Root, genfiles []GenFile, report *Report) {
+func generateRootFiles(
+	root *config.Root,
+	genfiles []GenFile,
+	timers map[string]*phaseTimer,
+	report *Report,
+	names *dirNames,
+	allowDelete bool,
+) {
 	logger := log.With().
 		Str("action", "generate.generateRootFiles()").
 		Logger()
 
 	diskFiles := map[string]string{}         // files already on disk
 	mustExistFiles := map[string]GenFile{}   // files that must be present on disk
+	mustExistFolded := map[string]struct{}{} // must-exist files ignoring the case
 	mustDeleteFiles := map[string]struct{}{} // files to be deleted
 
 	// this computes the files that must be present on disk after generate
@@ -924,12 +1382,15 @@ func generateRootFiles(root *config.Root, genfiles []GenFile, report *Report) {
 			logger.Debug().Msg("file must be generated (if needed)")
 
 			mustExistFiles[file.Label()] = file
+			mustExistFolded[strings.ToLower(file.Label())] = struct{}{}
 		}
 	}
 
-	// this computes the files that must be deleted if they are present on disk
+	// this computes the files that must be deleted if they are present on disk.
+	// Files differing only by case from a must-exist file are kept as they
+	// are the same file in case-insensitive filesystems.
 	for _, file := range genfiles {
-		if _, ok := mustExistFiles[file.Label()]; !ok {
+		if _, ok := mustExistFolded[strings.ToLower(file.Label())]; !ok {
 			logger := genFileLogger(logger, file)
 			logger.Debug().Msg("file must be deleted (if needed)")
 
@@ -943,33 +1404,49 @@ func generateRootFiles(root *config.Root, genfiles []GenFile, report *Report) {
 
 		logger.Debug().Msg("reading the content of the file on disk")
 
+		timer := timers[label]
+		timer.restart()
+
 		abspath := filepath.Join(root.HostDir(), label)
 		dir := path.Dir(label)
-		body, err := os.ReadFile(abspath)
+		body, found, err := readFile(names, abspath)
+		timer.lap(&timer.phases.Load)
 		if err != nil {
-			if os.IsNotExist(err) {
-				logger.Debug().Msg("file do not exists")
-
-				continue
-			}
 			dirReport := dirReport{}
 			dirReport.err = errors.E(err, "reading generated file")
 			report.addDirReport(project.NewPath(dir), dirReport)
 			return
 		}
+		if !found {
+			logger.Debug().Msg("file do not exists")
+
+			continue
+		}
 
 		logger.Debug().Msg("file content read successfully")
 
-		diskFiles[label] = string(body)
+		diskFiles[label] = body
 	}
 
 	// this deletes the files that exist but have condition=false.
 	for label := range mustDeleteFiles {
 		logger := logger.With().Str("file", label).Logger()
 
+		timer := timers[label]
+		timer.restart()
+
 		abspath := filepath.Join(root.HostDir(), label)
-		_, err := os.Lstat(abspath)
-		if err == nil {
+		exists, _, err := names.nameCase(abspath)
+		if err == nil && !exists {
+			err = fs.ErrNotExist
+		}
+		if err == nil && !allowDelete {
+			logger.Debug().Msg("file pending deletion")
+
+			dirReport := dirReport{}
+			dirReport.addPendingDeletion(path.Base(label))
+			report.addDirReport(project.NewPath(path.Dir(label)), dirReport)
+		} else if err == nil {
 			logger.Debug().Msg("deleting file")
 
 			dirReport := dirReport{}
@@ -979,12 +1456,14 @@ func generateRootFiles(root *config.Root, genfiles []GenFile, report *Report) {
 			if err != nil {
 				dirReport.err = errors.E(err, "deleting file")
 			} else {
+				names.remove(abspath)
 				dirReport.addDeletedFile(path.Base(label))
 			}
 			report.addDirReport(project.NewPath(dir), dirReport)
 
 			logger.Debug().Msg("deleted successfully")
 		}
+		timer.lap(&timer.phases.Write)
 	}
 
 	// this writes the files that must exist (if needed).
@@ -993,6 +1472,9 @@ func generateRootFiles(root *config.Root, genfiles []GenFile, report *Report) {
 
 		logger.Debug().Msg("generating file (if needed)")
 
+		timer := timers[label]
+		timer.restart()
+
 		abspath := filepath.Join(root.HostDir(), label)
 		filename := path.Base(label)
 		dir := project.NewPath(path.Dir(label))
@@ -1000,13 +1482,22 @@ func generateRootFiles(root *config.Root, genfiles []GenFile, report *Report) {
 
 		dirReport := dirReport{}
 		diskContent, existOnDisk := diskFiles[label]
-		if !existOnDisk || body != diskContent {
+		modeChanged, err := modeOutdated(abspath, effectiveMode(abspath, genfile))
+		if err != nil {
+			dirReport.err = errors.E(err, "checking mode of file %s", label)
+			report.addDirReport(dir, dirReport)
+			continue
+		}
+		timer.lap(&timer.phases.Render)
+		if !existOnDisk || body != diskContent || modeChanged {
 			logger.Debug().
 				Bool("existOnDisk", existOnDisk).
 				Bool("fileChanged", body != diskContent).
+				Bool("modeChanged", modeChanged).
 				Msg("writing file")
 
-			err := writeGeneratedCode(root, abspath, genfile)
+			err := writeGeneratedCode(root, names, abspath, genfile)
+			timer.lap(&timer.phases.Write)
 			if err != nil {
 				dirReport.err = errors.E(err, "saving file %s", label)
 				report.addDirReport(dir, dirReport)
@@ -1018,7 +1509,7 @@ func generateRootFiles(root *config.Root, genfiles []GenFile, report *Report) {
 
 		if !existOnDisk {
 			dirReport.addCreatedFile(filename)
-		} else if body != diskContent {
+		} else if body != diskContent || modeChanged {
 			dirReport.addChangedFile(label)
 		} else {
 			logger.Debug().Msg("nothing to do, file on disk is up to date.")
@@ -1220,11 +1711,26 @@ func (ss *stringSet) slice() []string {
 	return res
 }
 
+// checkFileConflict checks the generated files for conflicting labels.
+// Labels are compared ignoring the case because they name the same file in
+// case-insensitive filesystems.
 func checkFileConflict(generated []GenFile) map[string]error {
 	genset := map[string]GenFile{}
 	errsmap := map[string]error{}
 	for _, file := range generated {
-		if other, ok := genset[file.Label()]; ok && file.Condition() {
+		key := strings.ToLower(file.Label())
+		if other, ok := genset[key]; ok && file.Condition() {
+			if other.Label() != file.Label() {
+				errsmap[file.Label()] = errors.E(ErrConflictingConfig,
+					"configs from %q and %q generate files %q and %q differing only by case "+
+						"and have `condition = true`",
+					file.Range().Path(),
+					other.Range().Path(),
+					file.Label(),
+					other.Label(),
+				)
+				continue
+			}
 			errsmap[file.Label()] = errors.E(ErrConflictingConfig,
 				"configs from %q and %q generate a file with same name %q have "+
 					"`condition = true`",
@@ -1237,11 +1743,123 @@ func checkFileConflict(generated []GenFile) map[string]error {
 		if !file.Condition() {
 			continue
 		}
-		genset[file.Label()] = file
+		genset[key] = file
 	}
 	return errsmap
 }
 
+// plannedFile is a file planned to be generated into a host path.
+type plannedFile struct {
+	// dir is the stack directory or, for generate_file blocks with
+	// context=root, the directory of the generated file.
+	dir      project.Path
+	stack    bool
+	hostpath string
+	file     GenFile
+}
+
+func (f plannedFile) String() string {
+	scope := "root"
+	if f.stack {
+		scope = "stack " + f.dir.String()
+	}
+	return fmt.Sprintf("%s: block %q at %s", scope, f.file.Label(), f.file.Range())
+}
+
+// checkHostFileConflicts checks that the files planned by different stacks,
+// or by a stack and the generate_file blocks with context=root, are not
+// generated into the same host path, which would make the result depend on
+// the order the files are written. Host paths are compared ignoring the case,
+// like in checkFileConflict. Conflicts inside the same scope are already
+// detected when planning it.
+// The returned report has a failure for each directory involved in a conflict.
+func checkHostFileConflicts(root *config.Root, stackPlans []*stackGenPlan, rootPlan *rootGenPlan) *Report {
+	planned := map[string][]plannedFile{}
+	add := func(f plannedFile) {
+		key := strings.ToLower(f.hostpath)
+		planned[key] = append(planned[key], f)
+	}
+
+	for _, plan := range stackPlans {
+		if plan.report.HasFailures() {
+			continue
+		}
+		for _, file := range plan.generated {
+			if !file.Condition() {
+				continue
+			}
+			add(plannedFile{
+				dir:      plan.cfg.Dir(),
+				stack:    true,
+				hostpath: filepath.Join(plan.cfg.HostDir(), filepath.FromSlash(file.Label())),
+				file:     file,
+			})
+		}
+	}
+
+	if !rootPlan.report.HasFailures() {
+		for _, file := range rootPlan.files {
+			if !file.Condition() {
+				continue
+			}
+			add(plannedFile{
+				dir:      project.NewPath(path.Dir(file.Label())),
+				hostpath: filepath.Join(root.HostDir(), filepath.FromSlash(file.Label())),
+				file:     file,
+			})
+		}
+	}
+
+	keys := make([]string, 0, len(planned))
+	for key, files := range planned {
+		if len(files) > 1 {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	failures := map[project.Path]*errors.List{}
+	var dirs []project.Path
+	for _, key := range keys {
+		files := planned[key]
+		sort.Slice(files, func(i, j int) bool {
+			if files[i].dir != files[j].dir {
+				return files[i].dir.String() < files[j].dir.String()
+			}
+			return files[i].file.Range().String() < files[j].file.Range().String()
+		})
+
+		origins := make([]string, len(files))
+		for i, f := range files {
+			origins[i] = f.String()
+		}
+		err := errors.E(ErrConflictingConfig,
+			"file %s is generated by multiple configs with `condition = true`: %s",
+			project.PrjAbsPath(root.HostDir(), files[0].hostpath),
+			strings.Join(origins, ", "),
+		)
+
+		seen := map[project.Path]bool{}
+		for _, f := range files {
+			if seen[f.dir] {
+				continue
+			}
+			seen[f.dir] = true
+			if failures[f.dir] == nil {
+				failures[f.dir] = errors.L()
+				dirs = append(dirs, f.dir)
+			}
+			failures[f.dir].Append(err)
+		}
+	}
+
+	report := &Report{}
+	for _, dir := range dirs {
+		report.addFailure(dir, failures[dir].AsError())
+	}
+	return report
+}
+
 func loadAsserts(root *config.Root, st *config.Stack, evalctx *eval.Context) ([]config.Assert, error) {
 	logger := log.With().
 		Str("action", "generate.loadAsserts").
@@ -1284,7 +1902,12 @@ func loadAsserts(root *config.Root, st *config.Stack, evalctx *eval.Context) ([]
 	return asserts, nil
 }
 
-func loadRootCodeCfgs(root *config.Root, cfg *config.Tree) ([]GenFile, error) {
+func loadRootCodeCfgs(
+	root *config.Root,
+	cfg *config.Tree,
+	vendorDir project.Path,
+	vendorRequests chan<- event.VendorRequest,
+) ([]GenFile, error) {
 	blocks := cfg.Node.Generate.Files
 
 	genfiles := []GenFile{}
@@ -1300,7 +1923,7 @@ func loadRootCodeCfgs(root *config.Root, cfg *config.Tree) ([]GenFile, error) {
 		evalctx := eval.NewContext(stdlib.Functions(cfg.RootDir(), root.Tree().Node.Experiments()))
 		evalctx.SetNamespace("terramate", root.Runtime())
 
-		file, skip, err := genfile.Eval(block, cfg, evalctx)
+		file, skip, err := evalRootGenFile(root, block, cfg, evalctx, vendorDir, vendorRequests)
 		if err != nil {
 			return nil, err
 		}
@@ -1312,40 +1935,80 @@ func loadRootCodeCfgs(root *config.Root, cfg *config.Tree) ([]GenFile, error) {
 	return genfiles, nil
 }
 
+// redactSensitiveGlobals redacts the values of the sensitive globals of the
+// stack from err, so they are not shown in the generate report.
+func redactSensitiveGlobals(root *config.Root, globalVals *eval.Object, err error) error {
+	if err == nil || globalVals == nil {
+		return err
+	}
+	sensitive, serr := globals.LoadSensitive(root)
+	if serr != nil {
+		return errors.E(serr, "loading terramate.config.sensitive_globals")
+	}
+	if sensitive.IsEmpty() {
+		return err
+	}
+	return sensitive.RedactError(globalVals.AsValueMap(), err)
+}
+
+// evalRootGenFile evaluates a generate_file block with context=root. The
+// tm_vendor function is relative to the directory of the generated file, as
+// done for the blocks of the stacks.
+func evalRootGenFile(
+	root *config.Root,
+	block hcl.GenFileBlock,
+	cfg *config.Tree,
+	parentctx *eval.Context,
+	vendorDir project.Path,
+	vendorRequests chan<- event.VendorRequest,
+) (genfile.File, bool, error) {
+	vendorTargetDir := project.NewPath(path.Clean("/" + path.Dir(block.Label)))
+	evalctx := parentctx.Copy()
+	evalctx.SetFunction(stdlib.Name("vendor"), stdlib.VendorFunc(root.HostDir(), vendorTargetDir, vendorDir, vendorRequests))
+	return genfile.Eval(block, cfg, evalctx)
+}
+
 func loadStackCodeCfgs(
 	root *config.Root,
 	cfg *config.Tree,
 	vendorDir project.Path,
 	vendorRequests chan<- event.VendorRequest,
-) ([]GenFile, error) {
+) (_ []GenFile, _ []string, err error) {
 	st, err := cfg.Stack()
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	globals := globals.ForStack(root, st)
+	defer func() {
+		err = redactSensitiveGlobals(root, globals.Globals, err)
+	}()
 	if err := globals.AsError(); err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	evalctx := stack.NewEvalCtx(root, st, globals.Globals)
 	asserts, err := loadAsserts(root, st, evalctx.Context)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	tel.DefaultRecord.Set(
 		tel.BoolFlag("asserts", len(asserts) > 0),
 	)
 
+	if err := setRunEnv(root, cfg, st, evalctx.Context); err != nil {
+		return nil, nil, err
+	}
+
 	var genfilesConfigs []GenFile
 
 	genfiles, err := genfile.Load(root, st, evalctx.Context, vendorDir, vendorRequests)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	genhcls, err := genhcl.Load(root, st, evalctx.Context, vendorDir, vendorRequests)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	for _, f := range genfiles {
@@ -1364,9 +2027,9 @@ func loadStackCodeCfgs(
 		asserts = append(asserts, gen.Asserts()...)
 	}
 
-	err = handleAsserts(root.HostDir(), st.HostDir(root), asserts)
+	warnings, err := handleAsserts(root.HostDir(), st.HostDir(root), asserts)
 	if err != nil {
-		return nil, err
+		return nil, warnings, err
 	}
 
 	type backendFile struct {
@@ -1378,7 +2041,7 @@ func loadStackCodeCfgs(
 	for _, outputBlock := range cfg.Node.Outputs {
 		output, err := config.EvalOutput(evalctx.Context, outputBlock)
 		if err != nil {
-			return nil, err
+			return nil, warnings, err
 		}
 		v, ok := backendMap[output.Backend]
 		if !ok {
@@ -1390,7 +2053,7 @@ func loadStackCodeCfgs(
 	for _, inputBlock := range cfg.Node.Inputs {
 		input, err := config.EvalInput(evalctx.Context, inputBlock)
 		if err != nil {
-			return nil, err
+			return nil, warnings, err
 		}
 		v, ok := backendMap[input.Backend]
 		if !ok {
@@ -1402,18 +2065,19 @@ func loadStackCodeCfgs(
 	for backendName, file := range backendMap {
 		backend, ok := cfg.SharingBackend(backendName)
 		if !ok {
-			return nil, errors.E("backend %s not found", backendName)
+			return nil, warnings, errors.E("backend %s not found", backendName)
 		}
 		sharingFile, err := sharing.PrepareFile(root, backend.Filename, file.inputs, file.outputs)
 		if err != nil {
-			return nil, err
+			return nil, warnings, err
 		}
 		genfilesConfigs = append(genfilesConfigs, sharingFile)
 	}
-	return genfilesConfigs, nil
+	genfilesConfigs, err = injectHeader(root, evalctx.Context, genfilesConfigs)
+	return genfilesConfigs, warnings, err
 }
 
-func cleanupOrphaned(root *config.Root, target *config.Tree, report *Report) *Report {
+func cleanupOrphaned(root *config.Root, target *config.Tree, report *Report, allowDelete bool) *Report {
 	logger := log.With().
 		Str("action", "generate.cleanupOrphaned()").
 		Stringer("dir", target.Dir()).
@@ -1436,11 +2100,21 @@ func cleanupOrphaned(root *config.Root, target *config.Tree, report *Report) *Re
 	}
 
 	deletedFiles := map[project.Path][]string{}
+	pendingFiles := map[project.Path][]string{}
 	deleteFailures := map[project.Path]*errors.List{}
 
 	for _, genfile := range orphanedGenFiles {
 		genfileAbspath := filepath.Join(target.HostDir(), genfile)
 		dir := project.PrjAbsPath(root.HostDir(), filepath.Dir(genfileAbspath))
+		if !allowDelete {
+			log.Info().
+				Stringer("dir", dir).
+				Str("file", filepath.Base(genfile)).
+				Msg("orphaned file pending deletion")
+
+			pendingFiles[dir] = append(pendingFiles[dir], filepath.Base(genfile))
+			continue
+		}
 		if err := os.Remove(genfileAbspath); err != nil {
 			if deleteFailures[dir] == nil {
 				deleteFailures[dir] = errors.L()
@@ -1478,5 +2152,21 @@ func cleanupOrphaned(root *config.Root, target *config.Tree, report *Report) *Re
 			Deleted: deletedFiles,
 		})
 	}
+
+	for dir, pendingFiles := range pendingFiles {
+		report.Successes = append(report.Successes, Result{
+			Dir:             dir,
+			PendingDeletion: pendingFiles,
+		})
+	}
 	return report
 }
+
+// deletionAllowed tells if terramate.config.generate.allow_deletion is set.
+func deletionAllowed(root *config.Root) bool {
+	tmcfg := root.Tree().Node.Terramate
+	return tmcfg != nil &&
+		tmcfg.Config != nil &&
+		tmcfg.Config.Generate != nil &&
+		tmcfg.Config.Generate.AllowDeletion
+}
@@ -0,0 +1,165 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package generate_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/madlambda/spells/assert"
+	"github.com/terramate-io/terramate/generate"
+	"github.com/terramate-io/terramate/project"
+	"github.com/terramate-io/terramate/test"
+	"github.com/terramate-io/terramate/test/sandbox"
+)
+
+func TestGenerateDeletionSafeguard(t *testing.T) {
+	t.Parallel()
+
+	type testcase struct {
+		name        string
+		layout      []string
+		files       []string
+		wantPending generate.Report
+		wantDeleted generate.Report
+	}
+
+	for _, tc := range []testcase{
+		{
+			name: "stack files not generated anymore",
+			layout: []string{
+				"s:stack",
+				genfile("stack/old.hcl"),
+				genfile("stack/dir/old.hcl"),
+			},
+			files: []string{"stack/old.hcl", "stack/dir/old.hcl"},
+			wantPending: generate.Report{
+				Successes: []generate.Result{
+					{
+						Dir:             project.NewPath("/stack"),
+						PendingDeletion: []string{"dir/old.hcl", "old.hcl"},
+					},
+				},
+			},
+			wantDeleted: generate.Report{
+				Successes: []generate.Result{
+					{
+						Dir:     project.NewPath("/stack"),
+						Deleted: []string{"dir/old.hcl", "old.hcl"},
+					},
+				},
+			},
+		},
+		{
+			name: "orphaned files outside stacks",
+			layout: []string{
+				"s:stack",
+				genfile("dir/orphan.hcl"),
+			},
+			files: []string{"dir/orphan.hcl"},
+			wantPending: generate.Report{
+				Successes: []generate.Result{
+					{
+						Dir:             project.NewPath("/dir"),
+						PendingDeletion: []string{"orphan.hcl"},
+					},
+				},
+			},
+			wantDeleted: generate.Report{
+				Successes: []generate.Result{
+					{
+						Dir:     project.NewPath("/dir"),
+						Deleted: []string{"orphan.hcl"},
+					},
+				},
+			},
+		},
+		{
+			name: "root files with condition false",
+			layout: []string{
+				`f:generate.tm:generate_file "/dir/root.txt" {
+				  context   = root
+				  condition = false
+				  content   = "root"
+				}`,
+				"f:dir/root.txt:root",
+			},
+			files: []string{"dir/root.txt"},
+			wantPending: generate.Report{
+				Successes: []generate.Result{
+					{
+						Dir:             project.NewPath("/dir"),
+						PendingDeletion: []string{"root.txt"},
+					},
+				},
+			},
+			wantDeleted: generate.Report{
+				Successes: []generate.Result{
+					{
+						Dir:     project.NewPath("/dir"),
+						Deleted: []string{"root.txt"},
+					},
+				},
+			},
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			s := sandbox.NoGit(t, true)
+			s.BuildTree(tc.layout)
+
+			vendorDir := project.NewPath("/modules")
+			report := generate.Do(s.Config(), project.NewPath("/"), 0, vendorDir, nil, false, generate.ContextAll)
+			assertEqualReports(t, report, tc.wantPending)
+			for _, file := range tc.files {
+				_, err := os.Stat(filepath.Join(s.RootDir(), file))
+				assert.NoError(t, err, "file pending deletion must not be deleted")
+			}
+
+			// pending deletions are outdated code.
+			outdated, err := generate.DetectOutdated(s.Config(), s.Config().Tree(), vendorDir)
+			assert.NoError(t, err)
+			assert.EqualInts(t, len(tc.files), len(outdated), "outdated files: %v", outdated)
+
+			report = generate.Do(s.Config(), project.NewPath("/"), 0, vendorDir, nil, true, generate.ContextAll)
+			assertEqualReports(t, report, tc.wantDeleted)
+			for _, file := range tc.files {
+				_, err := os.Stat(filepath.Join(s.RootDir(), file))
+				assert.IsTrue(t, os.IsNotExist(err), "file %s must be deleted", file)
+			}
+		})
+	}
+}
+
+func TestGenerateDeletionAllowedByConfig(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.NoGit(t, true)
+	s.BuildTree([]string{
+		"s:stack",
+		genfile("stack/old.hcl"),
+	})
+	test.WriteFile(t, s.RootDir(), "terramate.tm", `
+		terramate {
+		  config {
+		    generate {
+		      allow_deletion = true
+		    }
+		  }
+		}
+	`)
+
+	report := generate.Do(s.Config(), project.NewPath("/"), 0, project.NewPath("/modules"), nil, false, generate.ContextAll)
+	assertEqualReports(t, report, generate.Report{
+		Successes: []generate.Result{
+			{
+				Dir:     project.NewPath("/stack"),
+				Deleted: []string{"old.hcl"},
+			},
+		},
+	})
+}
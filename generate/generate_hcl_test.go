@@ -1296,6 +1296,51 @@ EOT
 				},
 			},
 		},
+		{
+			name: "flush HEREDOC with strip markers inside content",
+			layout: []string{
+				"s:stack",
+			},
+			configs: []hclconfig{
+				{
+					path: "/stack",
+					add: Doc(
+						Globals(
+							Expr("msg", `<<-EOT
+    # Items
+    %{ for item in ["a", "b"] ~}
+    - ${item}
+    %{ endfor ~}
+  EOT`),
+						),
+						GenerateHCL(
+							Labels("test"),
+							Content(
+								Expr("msg", "global.msg"),
+							),
+						),
+					),
+				},
+			},
+			want: []generatedFile{
+				{
+					dir: "/stack",
+					files: map[string]fmt.Stringer{
+						"test": Doc(
+							Expr("msg", `"# Items\n- a\n- b\n"`),
+						),
+					},
+				},
+			},
+			wantReport: generate.Report{
+				Successes: []generate.Result{
+					{
+						Dir:     project.NewPath("/stack"),
+						Created: []string{"test"},
+					},
+				},
+			},
+		},
 		{
 			name: "stack with block with same label as parent but multiple true conditions",
 			layout: []string{
@@ -2108,7 +2153,7 @@ func TestWontOverwriteManuallyDefinedTerraform(t *testing.T) {
 		fmt.Sprintf("f:stack/%s:%s", genFilename, manualTfCode),
 	})
 
-	report := generate.Do(s.Config(), project.NewPath("/"), 0, project.NewPath("/modules"), nil)
+	report := generate.Do(s.Config(), project.NewPath("/"), 0, project.NewPath("/modules"), nil, true, generate.ContextAll)
 	assert.EqualInts(t, 0, len(report.Successes), "want no success")
 	assert.EqualInts(t, 1, len(report.Failures), "want single failure")
 	assertReportHasError(t, report, errors.E(generate.ErrManualCodeExists))
@@ -2671,6 +2716,132 @@ func TestGenerateHCLStackFiltersSC11177(t *testing.T) {
 	})
 }
 
+func TestGenerateHCLStackFiltersStructure(t *testing.T) {
+	t.Parallel()
+
+	rootGenHCL := func(label string, filters ...*hclwrite.Block) hclconfig {
+		builders := []hclwrite.BlockBuilder{Labels(label)}
+		for _, filter := range filters {
+			builders = append(builders, filter)
+		}
+		builders = append(builders, Content())
+		return hclconfig{
+			path: "/",
+			add:  GenerateHCL(builders...),
+		}
+	}
+
+	testCodeGeneration(t, []testcase{
+		{
+			name: "depth and is_leaf over nested stacks",
+			layout: []string{
+				"s:infra",
+				"s:infra/apps",
+				"s:infra/network",
+				"s:infra/network/regions/eu",
+				"s:legacy",
+				"d:legacy/modules/db",
+			},
+			configs: []hclconfig{
+				rootGenHCL("leaf.tf", StackFilter(
+					Bool("is_leaf", true),
+				)),
+				rootGenHCL("parent.tf", StackFilter(
+					Bool("is_leaf", false),
+				)),
+				rootGenHCL("deep.tf", StackFilter(
+					Str("depth", ">= 3"),
+				)),
+				rootGenHCL("exact.tf", StackFilter(
+					Number("depth", 2),
+				)),
+				rootGenHCL("nested.tf", StackFilter(
+					Str("depth", "!= 1"),
+				)),
+				rootGenHCL("and.tf", StackFilter(
+					ProjectPaths("/infra/**"),
+					Bool("is_leaf", true),
+					Str("depth", "< 4"),
+				)),
+				rootGenHCL("or.tf",
+					StackFilter(
+						Str("depth", "== 1"),
+						Bool("is_leaf", true),
+					),
+					StackFilter(
+						Str("depth", ">3"),
+					),
+				),
+			},
+			want: []generatedFile{
+				{
+					dir: "/infra",
+					files: map[string]fmt.Stringer{
+						"parent.tf": Doc(),
+					},
+				},
+				{
+					dir: "/infra/apps",
+					files: map[string]fmt.Stringer{
+						"and.tf":    Doc(),
+						"exact.tf":  Doc(),
+						"leaf.tf":   Doc(),
+						"nested.tf": Doc(),
+					},
+				},
+				{
+					dir: "/infra/network",
+					files: map[string]fmt.Stringer{
+						"exact.tf":  Doc(),
+						"nested.tf": Doc(),
+						"parent.tf": Doc(),
+					},
+				},
+				{
+					dir: "/infra/network/regions/eu",
+					files: map[string]fmt.Stringer{
+						"deep.tf":   Doc(),
+						"leaf.tf":   Doc(),
+						"nested.tf": Doc(),
+						"or.tf":     Doc(),
+					},
+				},
+				{
+					dir: "/legacy",
+					files: map[string]fmt.Stringer{
+						"leaf.tf": Doc(),
+						"or.tf":   Doc(),
+					},
+				},
+			},
+			wantReport: generate.Report{
+				Successes: []generate.Result{
+					{
+						Dir:     project.NewPath("/infra"),
+						Created: []string{"parent.tf"},
+					},
+					{
+						Dir:     project.NewPath("/infra/apps"),
+						Created: []string{"and.tf", "exact.tf", "leaf.tf", "nested.tf"},
+					},
+					{
+						Dir:     project.NewPath("/infra/network"),
+						Created: []string{"exact.tf", "nested.tf", "parent.tf"},
+					},
+					{
+						Dir:     project.NewPath("/infra/network/regions/eu"),
+						Created: []string{"deep.tf", "leaf.tf", "nested.tf", "or.tf"},
+					},
+					{
+						Dir:     project.NewPath("/legacy"),
+						Created: []string{"leaf.tf", "or.tf"},
+					},
+				},
+			},
+		},
+	})
+}
+
 func TestGenerateHCLOverwriting(t *testing.T) {
 	t.Parallel()
 
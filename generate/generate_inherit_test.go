@@ -0,0 +1,152 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package generate_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/terramate-io/terramate/generate"
+	"github.com/terramate-io/terramate/project"
+	"github.com/terramate-io/terramate/test/hclwrite"
+	. "github.com/terramate-io/terramate/test/hclwrite/hclutils"
+)
+
+func TestGenerateInheritWithStackFilters(t *testing.T) {
+	t.Parallel()
+
+	type blockType struct {
+		name    string
+		block   func(builders ...hclwrite.BlockBuilder) *hclwrite.Block
+		content hclwrite.BlockBuilder
+		want    fmt.Stringer
+	}
+
+	blockTypes := []blockType{
+		{
+			name:    "generate_hcl",
+			block:   GenerateHCL,
+			content: Content(Str("hello", "world")),
+			want:    Doc(Str("hello", "world")),
+		},
+		{
+			name:    "generate_file",
+			block:   GenerateFile,
+			content: Str("content", "hello world"),
+			want:    stringer("hello world"),
+		},
+	}
+
+	filterAttrs := []struct {
+		name string
+		attr func(paths ...string) hclwrite.BlockBuilder
+	}{
+		{"project_paths", ProjectPaths},
+		{"repository_paths", RepositoryPaths},
+	}
+
+	// the block is always defined in the /parent stack, and the filter is
+	// always matched against the stack the code is generated for.
+	filters := []struct {
+		pattern string
+		matches []string
+	}{
+		{"parent", []string{"/parent"}},
+		{"child", []string{"/parent/child"}},
+		{"/parent/**", []string{"/parent/child"}},
+		{"/**", []string{"/parent", "/parent/child"}},
+	}
+
+	var tcases []testcase
+	for _, bt := range blockTypes {
+		for _, inherit := range []bool{true, false} {
+			for _, fattr := range filterAttrs {
+				for _, filter := range filters {
+					tc := testcase{
+						name: fmt.Sprintf("%s with inherit=%t and %s=%q",
+							bt.name, inherit, fattr.name, filter.pattern),
+						layout: []string{
+							"s:parent",
+							"s:parent/child",
+							"s:other",
+						},
+						configs: []hclconfig{
+							{
+								path: "/parent",
+								add: bt.block(
+									Labels("file.txt"),
+									Bool("inherit", inherit),
+									StackFilter(fattr.attr(filter.pattern)),
+									bt.content,
+								),
+							},
+						},
+					}
+					for _, dir := range filter.matches {
+						if dir != "/parent" && !inherit {
+							continue
+						}
+						tc.want = append(tc.want, generatedFile{
+							dir: dir,
+							files: map[string]fmt.Stringer{
+								"file.txt": bt.want,
+							},
+						})
+						tc.wantReport.Successes = append(tc.wantReport.Successes, generate.Result{
+							Dir:     project.NewPath(dir),
+							Created: []string{"file.txt"},
+						})
+					}
+					tcases = append(tcases, tc)
+				}
+			}
+		}
+
+		// regression: a non-inheritable block from a parent stack must not
+		// touch a child stack not matching its filter.
+		tcases = append(tcases, testcase{
+			name: fmt.Sprintf("%s with inherit=false keeps file of unmatched child stack", bt.name),
+			layout: []string{
+				"s:parent",
+				"s:parent/child",
+				"f:parent/child/file.txt:manual",
+			},
+			configs: []hclconfig{
+				{
+					path: "/parent",
+					add: bt.block(
+						Labels("file.txt"),
+						Bool("inherit", false),
+						StackFilter(ProjectPaths("/parent")),
+						bt.content,
+					),
+				},
+			},
+			want: []generatedFile{
+				{
+					dir: "/parent",
+					files: map[string]fmt.Stringer{
+						"file.txt": bt.want,
+					},
+				},
+				{
+					dir: "/parent/child",
+					files: map[string]fmt.Stringer{
+						"file.txt": stringer("manual"),
+					},
+				},
+			},
+			wantReport: generate.Report{
+				Successes: []generate.Result{
+					{
+						Dir:     project.NewPath("/parent"),
+						Created: []string{"file.txt"},
+					},
+				},
+			},
+		})
+	}
+
+	testCodeGeneration(t, tcases)
+}
@@ -7,9 +7,12 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/madlambda/spells/assert"
 	"github.com/terramate-io/terramate/errors"
+	"github.com/terramate-io/terramate/hcl/eval"
 	"github.com/terramate-io/terramate/project"
 	. "github.com/terramate-io/terramate/test/hclwrite/hclutils"
 	"github.com/terramate-io/terramate/test/sandbox"
@@ -97,6 +100,54 @@ func TestGenerateRootContext(t *testing.T) {
 				},
 			},
 		},
+		{
+			// Example of a markdown inventory of all stacks using template
+			// directives with strip markers inside a flush heredoc.
+			name: "generate.context=root markdown inventory of stacks",
+			layout: []string{
+				"s:stacks/stack-1",
+				"s:stacks/stack-2",
+			},
+			configs: []hclconfig{
+				{
+					path: "/source",
+					add: Doc(
+						GenerateFile(
+							Labels("/INVENTORY.md"),
+							Expr("context", "root"),
+							Expr("content", `<<-EOT
+    # Stacks
+
+    %{ for stack in terramate.stacks.list ~}
+    - [${stack}](.${stack})
+    %{ endfor ~}
+
+    Total: ${tm_length(terramate.stacks.list)}
+  EOT`),
+						),
+					),
+				},
+			},
+			want: []generatedFile{
+				{
+					dir: "/",
+					files: map[string]fmt.Stringer{
+						"INVENTORY.md": stringer("# Stacks\n\n" +
+							"- [/stacks/stack-1](./stacks/stack-1)\n" +
+							"- [/stacks/stack-2](./stacks/stack-2)\n" +
+							"\nTotal: 2"),
+					},
+				},
+			},
+			wantReport: generate.Report{
+				Successes: []generate.Result{
+					{
+						Dir:     project.NewPath("/"),
+						Created: []string{"INVENTORY.md"},
+					},
+				},
+			},
+		},
 		{
 			name: "generate.context=root fails when generating outside rootdir",
 			configs: []hclconfig{
@@ -688,6 +739,75 @@ func TestGenerateRootContext(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "generate.context=root with lets in condition and content",
+			configs: []hclconfig{
+				{
+					path: "/source",
+					add: GenerateFile(
+						Labels("/target/file.txt"),
+						Expr("context", "root"),
+						Lets(
+							Str("name", "file"),
+						),
+						Lets(
+							Expr("upper", "tm_upper(let.name)"),
+						),
+						Expr("condition", `let.name == "file"`),
+						Expr("content", `"${let.upper}"`),
+					),
+				},
+			},
+			want: []generatedFile{
+				{
+					dir: "/target",
+					files: map[string]fmt.Stringer{
+						"file.txt": stringer("FILE"),
+					},
+				},
+			},
+			wantReport: generate.Report{
+				Successes: []generate.Result{
+					{
+						Dir:     project.NewPath("/target"),
+						Created: []string{"file.txt"},
+					},
+				},
+			},
+		},
+		{
+			name: "generate.context=root lets are not visible to other blocks",
+			configs: []hclconfig{
+				{
+					path: "/source",
+					add: Doc(
+						GenerateFile(
+							Labels("/target/a.txt"),
+							Expr("context", "root"),
+							Lets(
+								Str("name", "a"),
+							),
+							Expr("content", `let.name`),
+						),
+						GenerateFile(
+							Labels("/target/b.txt"),
+							Expr("context", "root"),
+							Expr("content", `let.name`),
+						),
+					),
+				},
+			},
+			wantReport: generate.Report{
+				Failures: []generate.FailureResult{
+					{
+						Result: generate.Result{
+							Dir: project.NewPath("/target"),
+						},
+						Error: errors.E(eval.ErrEval),
+					},
+				},
+			},
+		},
 		{
 			name: "child and parent directories with same label and same condition - fails",
 			configs: []hclconfig{
@@ -792,3 +912,139 @@ func TestGenerateFileWithRootContextRemoveFilesWhenConditionIsFalse(t *testing.T
 	})
 	assertFileDontExist(filename)
 }
+
+func TestGenerateSelectedContext(t *testing.T) {
+	t.Parallel()
+
+	setup := func(t *testing.T) sandbox.S {
+		s := sandbox.NoGit(t, true)
+		s.BuildTree([]string{"s:stack"})
+		s.RootEntry().CreateConfig(
+			Doc(
+				GenerateFile(
+					Labels("/root.txt"),
+					Expr("context", "root"),
+					Str("content", "root"),
+				),
+				GenerateFile(
+					Labels("stack.txt"),
+					Str("content", "stack"),
+				),
+			).String(),
+		)
+		return s
+	}
+
+	generateContext := func(s sandbox.S, context string) *generate.Report {
+		return generate.Do(s.Config(), project.NewPath("/"), 0, project.NewPath("/modules"), nil, true, context)
+	}
+
+	t.Run("root context only generates blocks with context=root", func(t *testing.T) {
+		t.Parallel()
+
+		s := setup(t)
+		report := generateContext(s, generate.ContextRoot)
+		assertEqualReports(t, report, generate.Report{
+			Successes: []generate.Result{
+				{
+					Dir:     project.NewPath("/"),
+					Created: []string{"root.txt"},
+				},
+			},
+		})
+		assert.IsTrue(t, strings.Contains(report.Full(), "- / (context=root)"), report.Full())
+
+		report = generateContext(s, generate.ContextAll)
+		assertEqualReports(t, report, generate.Report{
+			Successes: []generate.Result{
+				{
+					Dir:     project.NewPath("/stack"),
+					Created: []string{"stack.txt"},
+				},
+			},
+		})
+	})
+
+	t.Run("stack context only generates blocks with context=stack", func(t *testing.T) {
+		t.Parallel()
+
+		s := setup(t)
+		report := generateContext(s, generate.ContextStack)
+		assertEqualReports(t, report, generate.Report{
+			Successes: []generate.Result{
+				{
+					Dir:     project.NewPath("/stack"),
+					Created: []string{"stack.txt"},
+				},
+			},
+		})
+		assert.IsTrue(t, strings.Contains(report.Full(), "- /stack (context=stack)"), report.Full())
+
+		report = generateContext(s, generate.ContextAll)
+		assertEqualReports(t, report, generate.Report{
+			Successes: []generate.Result{
+				{
+					Dir:     project.NewPath("/"),
+					Created: []string{"root.txt"},
+				},
+			},
+		})
+	})
+
+	t.Run("invalid context fails", func(t *testing.T) {
+		t.Parallel()
+
+		s := setup(t)
+		report := generateContext(s, "invalid")
+		assert.IsTrue(t, report.BootstrapErr != nil, "want bootstrap error")
+	})
+}
+
+func TestGenerateSelectedStacks(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.NoGit(t, true)
+	s.BuildTree([]string{"s:stack-a", "s:stack-b"})
+	s.RootEntry().CreateConfig(
+		Doc(
+			GenerateFile(
+				Labels("/root.txt"),
+				Expr("context", "root"),
+				Str("content", "root"),
+			),
+			GenerateFile(
+				Labels("stack.txt"),
+				Expr("content", "terramate.stack.name"),
+			),
+		).String(),
+	)
+
+	selected := project.Paths{project.NewPath("/stack-b")}
+	report := generate.DoStacks(s.Config(), project.NewPath("/"), selected, 0,
+		project.NewPath("/modules"), nil, true, generate.ContextAll)
+	assertEqualReports(t, report, generate.Report{
+		Successes: []generate.Result{
+			{
+				Dir:     project.NewPath("/"),
+				Created: []string{"root.txt"},
+			},
+			{
+				Dir:     project.NewPath("/stack-b"),
+				Created: []string{"stack.txt"},
+			},
+		},
+	})
+
+	root := s.Config()
+	outdated, err := generate.DetectOutdatedStacks(root, root.Tree(), selected, project.NewPath("/modules"))
+	assert.NoError(t, err)
+	assertEqualStringList(t, outdated, []string{})
+
+	outdated, err = generate.DetectOutdated(root, root.Tree(), project.NewPath("/modules"))
+	assert.NoError(t, err)
+	assertEqualStringList(t, outdated, []string{"stack-a/stack.txt"})
+
+	report = generate.DoStacks(s.Config(), project.NewPath("/"), project.Paths{}, 0,
+		project.NewPath("/modules"), nil, true, generate.ContextAll)
+	assertEqualReports(t, report, generate.Report{})
+}
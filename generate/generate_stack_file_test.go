@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"runtime"
 	"testing"
 
 	"github.com/madlambda/spells/assert"
@@ -783,6 +784,72 @@ func TestGenerateFileRemoveFilesWhenConditionIsFalse(t *testing.T) {
 	assertFileDontExist(filename)
 }
 
+func TestGenerateFileMode(t *testing.T) {
+	t.Parallel()
+
+	if runtime.GOOS == "windows" {
+		t.Skip("file modes are not supported on windows")
+	}
+
+	const filename = "apply.sh"
+
+	s := sandbox.NoGit(t, true)
+	stackEntry := s.CreateStack("stack")
+	stackEntry.CreateConfig(
+		GenerateFile(
+			Labels(filename),
+			Str("content", "terraform apply"),
+			Str("mode", "0755"),
+		).String(),
+	)
+	path := filepath.Join(stackEntry.Path(), filename)
+
+	assertMode := func(want os.FileMode) {
+		t.Helper()
+
+		st, err := os.Stat(path)
+		assert.NoError(t, err)
+		if got := st.Mode().Perm(); got != want {
+			t.Fatalf("want mode %s, got %s", want, got)
+		}
+	}
+
+	report := s.Generate()
+	assertEqualReports(t, report, generate.Report{
+		Successes: []generate.Result{
+			{
+				Dir:     project.NewPath("/stack"),
+				Created: []string{filename},
+			},
+		},
+	})
+	assertMode(0755)
+
+	outdated, err := generate.DetectOutdated(s.Config(), s.Config().Tree(), project.NewPath("/modules"))
+	assert.NoError(t, err)
+	assertEqualStringList(t, outdated, []string{})
+
+	assert.NoError(t, os.Chmod(path, 0644))
+
+	outdated, err = generate.DetectOutdated(s.Config(), s.Config().Tree(), project.NewPath("/modules"))
+	assert.NoError(t, err)
+	assertEqualStringList(t, outdated, []string{"stack/" + filename})
+
+	report = s.Generate()
+	assertEqualReports(t, report, generate.Report{
+		Successes: []generate.Result{
+			{
+				Dir:     project.NewPath("/stack"),
+				Changed: []string{filename},
+			},
+		},
+	})
+	assertMode(0755)
+
+	report = s.Generate()
+	assertEqualReports(t, report, generate.Report{})
+}
+
 func TestGenerateFileTerramateRootMetadata(t *testing.T) {
 	t.Parallel()
 
@@ -1240,5 +1307,43 @@ func TestGenerateFileStackFilters(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "is_leaf and depth",
+			layout: []string{
+				"s:stacks",
+				"s:stacks/dev",
+				"s:stacks/prod",
+				"s:stacks/prod/eu",
+			},
+			configs: []hclconfig{
+				{
+					path: "/",
+					add: GenerateFile(
+						Labels("leaf"),
+						StackFilter(
+							Bool("is_leaf", true),
+							Str("depth", "<= 2"),
+						),
+						Str("content", "content"),
+					),
+				},
+			},
+			want: []generatedFile{
+				{
+					dir: "/stacks/dev",
+					files: map[string]fmt.Stringer{
+						"leaf": stringer("content"),
+					},
+				},
+			},
+			wantReport: generate.Report{
+				Successes: []generate.Result{
+					{
+						Dir:     project.NewPath("/stacks/dev"),
+						Created: []string{"leaf"},
+					},
+				},
+			},
+		},
 	})
 }
@@ -1072,6 +1072,74 @@ func TestGenerateConflictsBetweenGenerateTypes(t *testing.T) {
 	})
 }
 
+func TestGenerateConflictsBetweenStacks(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.NoGit(t, true)
+	s.BuildTree([]string{
+		"s:a",
+		"s:a/b",
+		"s:c",
+	})
+	// Paths differing only by case are the same file in case-insensitive
+	// filesystems, so /a/B/x.tf and /a/b/x.tf are the same file and so are
+	// /A/y.tf and /a/y.tf.
+	s.RootEntry().CreateFile("a/gen.tm", `
+generate_file "B/x.tf" {
+  content = "from a"
+}
+
+generate_file "y.tf" {
+  content = "from a"
+}
+`)
+	s.RootEntry().CreateFile("a/b/gen.tm", `
+generate_file "x.tf" {
+  content = "from a/b"
+}
+`)
+	s.RootEntry().CreateFile("c/gen.tm", `
+generate_file "ok.tf" {
+  content = "from c"
+}
+`)
+	s.RootEntry().CreateFile("root.tm", `
+generate_file "/A/y.tf" {
+  context = root
+  content = "from root"
+}
+`)
+
+	var first string
+	for i := 0; i < 20; i++ {
+		report := generate.Do(s.Config(), project.NewPath("/"), 4, project.NewPath("/modules"), nil, false, generate.ContextAll)
+		assert.EqualInts(t, 0, len(report.Successes), "want no successes: %s", report.Full())
+		assert.EqualInts(t, 3, len(report.Failures), "want 3 failures: %s", report.Full())
+
+		wantDirs := []string{"/A", "/a", "/a/b"}
+		for j, failure := range report.Failures {
+			assert.EqualStrings(t, wantDirs[j], failure.Dir.String())
+			assert.IsTrue(t, errors.IsKind(failure.Error, generate.ErrConflictingConfig),
+				"want %s, got %v", generate.ErrConflictingConfig, failure.Error)
+		}
+
+		got := report.Minimal()
+		if i == 0 {
+			first = got
+			assert.IsTrue(t, strings.Contains(got, "stack /a: block \"B/x.tf\" at /a/gen.tm"), got)
+			assert.IsTrue(t, strings.Contains(got, "stack /a/b: block \"x.tf\" at /a/b/gen.tm"), got)
+			assert.IsTrue(t, strings.Contains(got, "root: block \"/A/y.tf\" at /root.tm"), got)
+			continue
+		}
+		assert.EqualStrings(t, first, got, "conflict report is not deterministic")
+	}
+
+	for _, file := range []string{"a/B/x.tf", "a/b/x.tf", "a/y.tf", "A/y.tf", "c/ok.tf"} {
+		_, err := os.Stat(filepath.Join(s.RootDir(), file))
+		assert.IsTrue(t, errors.Is(err, fs.ErrNotExist), "file %s must not be written", file)
+	}
+}
+
 func TestTmGenDeletesFileWhenHidden(t *testing.T) {
 	t.Parallel()
 
@@ -1192,7 +1260,7 @@ func testCodeGeneration(t *testing.T, tcases []testcase) {
 			if fromdir == "" {
 				fromdir = "/"
 			}
-			report := generate.Do(s.Config(), project.NewPath(fromdir), 0, vendorDir, nil)
+			report := generate.Do(s.Config(), project.NewPath(fromdir), 0, vendorDir, nil, true, generate.ContextAll)
 			assertEqualReports(t, report, tcase.wantReport)
 
 			assertGeneratedFiles(t)
@@ -1200,11 +1268,21 @@ func testCodeGeneration(t *testing.T, tcases []testcase) {
 			// piggyback on the tests to validate that regeneration doesn't
 			// delete files or fail and has identical results.
 			t.Run("regenerate", func(t *testing.T) {
-				report := generate.Do(s.Config(), project.NewPath(fromdir), 0, vendorDir, nil)
+				report := generate.Do(s.Config(), project.NewPath(fromdir), 0, vendorDir, nil, true, generate.ContextAll)
 				// since we just generated everything, report should only contain
-				// the same failures as previous code generation.
+				// the same failures and warnings as previous code generation.
+				var warnings []generate.Result
+				for _, success := range tcase.wantReport.Successes {
+					if len(success.Warnings) > 0 {
+						warnings = append(warnings, generate.Result{
+							Dir:      success.Dir,
+							Warnings: success.Warnings,
+						})
+					}
+				}
 				assertEqualReports(t, report, generate.Report{
-					Failures: tcase.wantReport.Failures,
+					Successes: warnings,
+					Failures:  tcase.wantReport.Failures,
 				})
 				assertGeneratedFiles(t)
 			})
@@ -6,10 +6,10 @@ package genfile
 
 import (
 	"fmt"
+	"os"
 	"path"
 	"sort"
 
-	"github.com/gobwas/glob"
 	tel "github.com/terramate-io/terramate/cmd/terramate/cli/telemetry"
 	"github.com/terramate-io/terramate/config"
 	"github.com/terramate-io/terramate/errors"
@@ -19,7 +19,6 @@ import (
 	"github.com/terramate-io/terramate/hcl/info"
 	"github.com/terramate-io/terramate/stdlib"
 
-	"github.com/rs/zerolog/log"
 	"github.com/terramate-io/terramate/lets"
 	"github.com/terramate-io/terramate/project"
 	"github.com/zclconf/go-cty/cty"
@@ -67,6 +66,7 @@ type File struct {
 	body      string
 	condition bool
 	asserts   []config.Assert
+	mode      os.FileMode
 }
 
 // Builtin returns false for generate_file blocks.
@@ -105,6 +105,12 @@ func (f File) Asserts() []config.Assert {
 	return f.asserts
 }
 
+// Mode returns the permission mode of the file set by the mode attribute,
+// or zero if the file has the default permissions.
+func (f File) Mode() os.FileMode {
+	return f.mode
+}
+
 // Header returns the header of this file.
 func (f File) Header() string {
 	// For now we don't support headers for arbitrary files
@@ -154,40 +160,13 @@ func Load(
 
 		name := genFileBlock.Label
 
-		matchedAnyStackFilter := len(genFileBlock.StackFilters) == 0
-		for _, cond := range genFileBlock.StackFilters {
-			matched := true
-
-			for n, globs := range map[string][]glob.Glob{
-				"project path":    cond.ProjectPaths,
-				"repository path": cond.RepositoryPaths,
-			} {
-				if globs != nil && !hcl.MatchAnyGlob(globs, st.Dir.String()) {
-					log.Logger.Trace().Msgf("Skipping %q, %s doesn't match any filter in %v", st.Dir, n, globs)
-					matched = false
-					break
-				}
-			}
-
-			matchedAnyStackFilter = matchedAnyStackFilter || matched
-		}
-
-		if !matchedAnyStackFilter {
-			files = append(files, File{
-				label:     name,
-				origin:    genFileBlock.Range,
-				condition: false,
-			})
-			continue
-		}
-
 		vendorTargetDir := project.NewPath(path.Join(
 			st.Dir.String(),
 			path.Dir(name)))
 
 		evalctx := parentctx.Copy()
 
-		evalctx.SetFunction(stdlib.Name("vendor"), stdlib.VendorFunc(vendorTargetDir, vendorDir, vendorRequests))
+		evalctx.SetFunction(stdlib.Name("vendor"), stdlib.VendorFunc(root.HostDir(), vendorTargetDir, vendorDir, vendorRequests))
 
 		dircfg, _ := root.Lookup(st.Dir)
 		file, skip, err := Eval(genFileBlock, dircfg, evalctx)
@@ -212,59 +191,87 @@ func Load(
 }
 
 // Eval the generate_file block.
+// The cfg is the directory the code is generated for, which is the stack
+// directory for blocks with stack context.
+// The lets of the block are loaded in a copy of evalctx, so they are only
+// visible to the inherit, condition, assert and content of this block.
 func Eval(block hcl.GenFileBlock, cfg *config.Tree, evalctx *eval.Context) (file File, skip bool, err error) {
 	name := block.Label
+
+	// A non-inheritable block from a parent directory is skipped even if its
+	// stack_filter doesn't match, but the inherit attribute is only evaluated
+	// when needed, so stacks not matching the filter don't load its lets.
+	matched := hcl.MatchStackFilters(block.StackFilters, cfg.Dir(), cfg.IsLeafStack())
+	if !matched && (block.Inherit == nil || block.Dir == cfg.Dir()) {
+		return File{
+			label:     name,
+			origin:    block.Range,
+			condition: false,
+			context:   block.Context,
+		}, false, nil
+	}
+
+	evalctx = evalctx.Copy()
 	err = lets.Load(block.Lets, evalctx)
 	if err != nil {
 		return File{}, false, err
 	}
 
-	condition := true
-	if block.Condition != nil {
-		value, err := evalctx.Eval(block.Condition.Expr)
+	inherit := true
+	if block.Inherit != nil {
+		value, err := evalctx.Eval(block.Inherit.Expr)
 		if err != nil {
-			return File{}, false, errors.E(ErrConditionEval, err)
+			return File{}, false, errors.E(ErrInheritEval, err)
 		}
+
 		if value.Type() != cty.Bool {
 			return File{}, false, errors.E(
-				ErrInvalidConditionType,
-				"condition has type %s but must be boolean",
+				ErrInvalidInheritType,
+				`"inherit" has type %s but must be boolean`,
 				value.Type().FriendlyName(),
 			)
 		}
-		condition = value.True()
+
+		inherit = value.True()
 	}
 
-	if !condition {
+	if !inherit && block.Dir != cfg.Dir() {
+		// ignore non-inheritable block
+		return File{}, true, nil
+	}
+
+	if !matched {
 		return File{
 			label:     name,
 			origin:    block.Range,
-			condition: condition,
+			condition: false,
 			context:   block.Context,
 		}, false, nil
 	}
 
-	inherit := true
-	if block.Inherit != nil {
-		value, err := evalctx.Eval(block.Inherit.Expr)
+	condition := true
+	if block.Condition != nil {
+		value, err := evalctx.Eval(block.Condition.Expr)
 		if err != nil {
-			return File{}, false, errors.E(ErrInheritEval, err)
+			return File{}, false, errors.E(ErrConditionEval, err)
 		}
-
 		if value.Type() != cty.Bool {
 			return File{}, false, errors.E(
-				ErrInvalidInheritType,
-				`"inherit" has type %s but must be boolean`,
+				ErrInvalidConditionType,
+				"condition has type %s but must be boolean",
 				value.Type().FriendlyName(),
 			)
 		}
-
-		inherit = value.True()
+		condition = value.True()
 	}
 
-	if !inherit && block.Dir != cfg.Dir() {
-		// ignore non-inheritable block
-		return File{}, true, nil
+	if !condition {
+		return File{
+			label:     name,
+			origin:    block.Range,
+			condition: condition,
+			context:   block.Context,
+		}, false, nil
 	}
 
 	asserts := make([]config.Assert, len(block.Asserts))
@@ -317,6 +324,7 @@ func Eval(block hcl.GenFileBlock, cfg *config.Tree, evalctx *eval.Context) (file
 		condition: condition,
 		context:   block.Context,
 		asserts:   asserts,
+		mode:      block.Mode,
 	}, false, nil
 }
 
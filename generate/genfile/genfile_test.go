@@ -14,6 +14,7 @@ import (
 	"github.com/terramate-io/terramate/generate/genfile"
 	"github.com/terramate-io/terramate/hcl"
 	"github.com/terramate-io/terramate/hcl/info"
+	"github.com/terramate-io/terramate/lets"
 	"github.com/terramate-io/terramate/project"
 	"github.com/terramate-io/terramate/stack"
 	"github.com/terramate-io/terramate/test"
@@ -923,6 +924,96 @@ stack_id=stack-id
 			},
 			wantErr: errors.E(genfile.ErrContentEval),
 		},
+		{
+			name:  "lets are available to condition",
+			stack: "/stack",
+			configs: []hclconfig{
+				{
+					path: "/stack/test.tm",
+					add: GenerateFile(
+						Labels("test"),
+						Lets(
+							Expr("enabled", `terramate.stack.path.absolute != "/stack"`),
+						),
+						Expr("condition", `let.enabled`),
+						Expr("content", `global.this.will.explode`),
+					),
+				},
+			},
+			want: []result{
+				{
+					name: "test",
+					file: genFile{
+						condition: false,
+					},
+				},
+			},
+		},
+		{
+			name:  "lets referencing lets of other lets blocks",
+			stack: "/stack",
+			configs: []hclconfig{
+				{
+					path: "/stack/test.tm",
+					add: GenerateFile(
+						Labels("test"),
+						Lets(
+							Expr("greeting", `"${let.hello} ${let.name}"`),
+						),
+						Lets(
+							Str("hello", "hello"),
+							Expr("name", `terramate.stack.name`),
+						),
+						Expr("content", `let.greeting`),
+					),
+				},
+			},
+			want: []result{
+				{
+					name: "test",
+					file: genFile{
+						condition: true,
+						body:      "hello stack",
+					},
+				},
+			},
+		},
+		{
+			name:  "lets with cyclic references fail",
+			stack: "/stack",
+			configs: []hclconfig{
+				{
+					path: "/stack/test.tm",
+					add: GenerateFile(
+						Labels("test"),
+						Lets(
+							Expr("a", `let.b`),
+							Expr("b", `let.a`),
+						),
+						Expr("content", `let.a`),
+					),
+				},
+			},
+			wantErr: errors.E(lets.ErrEval),
+		},
+		{
+			name:  "lets failing to evaluate fail the block",
+			stack: "/stack",
+			configs: []hclconfig{
+				{
+					path: "/stack/test.tm",
+					add: GenerateFile(
+						Labels("test"),
+						Lets(
+							Expr("a", `tm_upper(global.undefined)`),
+						),
+						Bool("condition", false),
+						Expr("content", `let.a`),
+					),
+				},
+			},
+			wantErr: errors.E(lets.ErrEval),
+		},
 	}
 
 	for _, tcase := range tcases {
@@ -0,0 +1,208 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package genfile_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/madlambda/spells/assert"
+	"github.com/terramate-io/terramate/config"
+	"github.com/terramate-io/terramate/errors"
+	"github.com/terramate-io/terramate/generate/genfile"
+	"github.com/terramate-io/terramate/project"
+	"github.com/terramate-io/terramate/stack"
+	"github.com/terramate-io/terramate/test"
+	errtest "github.com/terramate-io/terramate/test/errors"
+	. "github.com/terramate-io/terramate/test/hclutils"
+	. "github.com/terramate-io/terramate/test/hclwrite/hclutils"
+	"github.com/terramate-io/terramate/test/sandbox"
+)
+
+func TestGenerateFileHeredocTemplates(t *testing.T) {
+	t.Parallel()
+
+	tcases := []testcase{
+		{
+			name:  "for directive with strip markers keeps indentation removed",
+			stack: "/stack",
+			configs: []hclconfig{
+				{
+					path: "/stack/generate.tm",
+					add: GenerateFile(
+						Labels("list.md"),
+						Expr("content", `<<-EOT
+    # Items
+    %{ for item in ["a", "b"] ~}
+    - ${item}
+    %{ endfor ~}
+    done
+  EOT`),
+					),
+				},
+			},
+			want: []result{
+				{
+					name: "list.md",
+					file: genFile{
+						condition: true,
+						body:      "# Items\n- a\n- b\ndone\n",
+					},
+				},
+			},
+		},
+		{
+			name:  "nested for directives over objects are sorted by key",
+			stack: "/stack",
+			configs: []hclconfig{
+				{
+					path: "/stack/generate.tm",
+					add: GenerateFile(
+						Labels("nested.md"),
+						Lets(
+							Expr("objs", `{
+								z = { k2 = 1, k1 = 2 }
+								a = { b = 3 }
+							}`),
+						),
+						Expr("content", `<<-EOT
+    %{ for name, attrs in let.objs ~}
+    ${name}:
+    %{ for k, v in attrs ~}
+      ${k}=${v}
+    %{ endfor ~}
+    %{ endfor ~}
+  EOT`),
+					),
+				},
+			},
+			want: []result{
+				{
+					name: "nested.md",
+					file: genFile{
+						condition: true,
+						body:      "a:\n  b=3\nz:\n  k1=2\n  k2=1\n",
+					},
+				},
+			},
+		},
+		{
+			name:  "if directive with strip markers",
+			stack: "/stack",
+			configs: []hclconfig{
+				{
+					path: "/stack/generate.tm",
+					add: GenerateFile(
+						Labels("cond.md"),
+						Expr("content", `<<-EOT
+    %{ for n in [1, 5] ~}
+    %{ if n > 2 ~}
+    ${n} is big
+    %{ else ~}
+    ${n} is small
+    %{ endif ~}
+    %{ endfor ~}
+  EOT`),
+					),
+				},
+			},
+			want: []result{
+				{
+					name: "cond.md",
+					file: genFile{
+						condition: true,
+						body:      "1 is small\n5 is big\n",
+					},
+				},
+			},
+		},
+		{
+			name:  "left strip marker trims the previous literal",
+			stack: "/stack",
+			configs: []hclconfig{
+				{
+					path: "/stack/generate.tm",
+					add: GenerateFile(
+						Labels("inline.txt"),
+						Expr("content", `<<-EOT
+    items:
+    %{~ for item in ["a", "b"] } ${item}%{ endfor }
+    end
+  EOT`),
+					),
+				},
+			},
+			want: []result{
+				{
+					name: "inline.txt",
+					file: genFile{
+						condition: true,
+						body:      "items: a b\nend\n",
+					},
+				},
+			},
+		},
+		{
+			name:  "escaped sequences and blank lines are preserved",
+			stack: "/stack",
+			configs: []hclconfig{
+				{
+					path: "/stack/generate.tm",
+					add: GenerateFile(
+						Labels("escaped.txt"),
+						Expr("content", `<<-EOT
+    %{ for item in ["a"] ~}
+    $${literal} %%{ directive } ${item}
+
+      indented
+    %{ endfor ~}
+  EOT`),
+					),
+				},
+			},
+			want: []result{
+				{
+					name: "escaped.txt",
+					file: genFile{
+						condition: true,
+						body:      "${literal} %{ directive } a\n\n  indented\n",
+					},
+				},
+			},
+		},
+	}
+
+	for _, tcase := range tcases {
+		testGenfile(t, tcase)
+	}
+}
+
+func TestGenerateFileHeredocTemplateErrorRange(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.NoGit(t, true)
+	s.BuildTree([]string{"s:/stack"})
+	st := s.LoadStacks()[0].Stack
+
+	test.AppendFile(t, s.RootDir(), "/stack/generate.tm", GenerateFile(
+		Labels("list.md"),
+		Expr("content", `<<-EOT
+    %{ for item in ["a", "b"] ~}
+    - ${item.name}
+    %{ endfor ~}
+  EOT`),
+	).String())
+
+	root, err := config.LoadRoot(s.RootDir())
+	assert.NoError(t, err)
+
+	globals := s.LoadStackGlobals(root, st)
+	evalctx := stack.NewEvalCtx(root, st, globals)
+	_, err = genfile.Load(root, st, evalctx.Context, project.NewPath("/modules"), nil)
+	errtest.Assert(t, err, errors.E(genfile.ErrContentEval, Mkrange(
+		filepath.Join(s.RootDir(), "stack/generate.tm"),
+		Start(5, 13, 89),
+		End(5, 18, 94),
+	)))
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/terramate-io/terramate/errors"
 	"github.com/terramate-io/terramate/generate/genhcl"
 	"github.com/terramate-io/terramate/hcl/eval"
+	"github.com/terramate-io/terramate/lets"
 	. "github.com/terramate-io/terramate/test/hclwrite/hclutils"
 )
 
@@ -1861,6 +1862,85 @@ func TestGenerateHCLDynamic(t *testing.T) {
 			},
 			wantErr: errors.E(genhcl.ErrDynamicAttrsConflict),
 		},
+		{
+			name:  "tm_dynamic using lets",
+			stack: "/stack",
+			configs: []hclconfig{
+				{
+					path: "/stack",
+					add: Doc(
+						Globals(
+							Str("env", "prd"),
+						),
+						GenerateHCL(
+							Labels("tm_dynamic_test.tf"),
+							Lets(
+								Expr("names", `["a", "b"]`),
+								Expr("prefix", `"${global.env}-${let.suffix}"`),
+							),
+							Lets(
+								Str("suffix", "x"),
+								Expr("enabled", `tm_length(let.names) > 0`),
+							),
+							Content(
+								TmDynamic(
+									Labels("my_block"),
+									Expr("for_each", `let.names`),
+									Expr("labels", `[let.prefix]`),
+									Expr("condition", `let.enabled`),
+									Content(
+										Expr("value", `"${let.prefix}-${my_block.value}"`),
+									),
+								),
+							),
+						),
+					),
+				},
+			},
+			want: []result{
+				{
+					name: "tm_dynamic_test.tf",
+					hcl: genHCL{
+						condition: true,
+						body: Doc(
+							Block("my_block",
+								Labels("prd-x"),
+								Str("value", "prd-x-a"),
+							),
+							Block("my_block",
+								Labels("prd-x"),
+								Str("value", "prd-x-b"),
+							),
+						),
+					},
+				},
+			},
+		},
+		{
+			name:  "tm_dynamic using lets fails if the lets fail to evaluate",
+			stack: "/stack",
+			configs: []hclconfig{
+				{
+					path: "/stack",
+					add: GenerateHCL(
+						Labels("tm_dynamic_test.tf"),
+						Lets(
+							Expr("names", `tm_concat(["a"], unknown.names)`),
+						),
+						Content(
+							TmDynamic(
+								Labels("my_block"),
+								Expr("for_each", `let.names`),
+								Content(
+									Expr("value", `my_block.value`),
+								),
+							),
+						),
+					),
+				},
+			},
+			wantErr: errors.E(lets.ErrEval),
+		},
 	}
 
 	for _, tcase := range tcases {
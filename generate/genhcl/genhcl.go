@@ -6,11 +6,11 @@ package genhcl
 
 import (
 	stdfmt "fmt"
+	"os"
 	"path"
 	"sort"
+	"strings"
 
-	"github.com/gobwas/glob"
-	"github.com/rs/zerolog/log"
 	hhcl "github.com/terramate-io/hcl/v2"
 	"github.com/terramate-io/hcl/v2/hclsyntax"
 	"github.com/terramate-io/hcl/v2/hclwrite"
@@ -18,6 +18,7 @@ import (
 	"github.com/terramate-io/terramate/config"
 	"github.com/terramate-io/terramate/errors"
 	"github.com/terramate-io/terramate/event"
+	"github.com/terramate-io/terramate/globals"
 	"github.com/terramate-io/terramate/hcl"
 	"github.com/terramate-io/terramate/hcl/ast"
 	"github.com/terramate-io/terramate/hcl/fmt"
@@ -114,6 +115,9 @@ func (h HCL) Asserts() []config.Assert {
 	return h.asserts
 }
 
+// Mode returns zero as generate_hcl files have the default permissions.
+func (h HCL) Mode() os.FileMode { return 0 }
+
 // Header returns the header of the generated HCL file.
 func (h HCL) Header() string {
 	return Header(h.magicCommentStyle)
@@ -209,29 +213,21 @@ func Load(
 
 	commentStyle := CommentStyleFromConfig(root.Tree())
 
+	isLeaf := false
+	if tree, ok := root.Lookup(st.Dir); ok {
+		isLeaf = tree.IsLeafStack()
+	}
+
 	var hcls []HCL
 	for _, hclBlock := range hclBlocks {
 		name := hclBlock.Label
 
-		matchedAnyStackFilter := len(hclBlock.StackFilters) == 0
-		for _, cond := range hclBlock.StackFilters {
-			matched := true
-
-			for n, globs := range map[string][]glob.Glob{
-				"project path":    cond.ProjectPaths,
-				"repository path": cond.RepositoryPaths,
-			} {
-				if globs != nil && !hcl.MatchAnyGlob(globs, st.Dir.String()) {
-					log.Logger.Trace().Msgf("Skipping %q, %s doesn't match any filter in %v", st.Dir, n, globs)
-					matched = false
-					break
-				}
-			}
-
-			matchedAnyStackFilter = matchedAnyStackFilter || matched
-		}
-
-		if !matchedAnyStackFilter {
+		// A non-inheritable block from a parent directory is skipped even if
+		// its stack_filter doesn't match, but the inherit attribute is only
+		// evaluated when needed, so stacks not matching the filter don't load
+		// its lets.
+		matched := hcl.MatchStackFilters(hclBlock.StackFilters, st.Dir, isLeaf)
+		if !matched && (hclBlock.Inherit == nil || hclBlock.Dir == st.Dir) {
 			hcls = append(hcls, HCL{
 				magicCommentStyle: commentStyle,
 				label:             name,
@@ -249,7 +245,7 @@ func Load(
 
 		evalctx.SetFunction(
 			stdlib.Name("vendor"),
-			stdlib.VendorFunc(vendorTargetDir, vendorDir, vendorRequests),
+			stdlib.VendorFunc(root.HostDir(), vendorTargetDir, vendorDir, vendorRequests),
 		)
 
 		err := lets.Load(hclBlock.Lets, evalctx)
@@ -257,52 +253,62 @@ func Load(
 			return nil, err
 		}
 
-		condition := true
-		if hclBlock.Condition != nil {
-			value, err := evalctx.Eval(hclBlock.Condition.Expr)
+		inherit := true
+		if hclBlock.Inherit != nil {
+			value, err := evalctx.Eval(hclBlock.Inherit.Expr)
 			if err != nil {
-				return nil, errors.E(ErrConditionEval, err)
+				return nil, errors.E(ErrInheritEval, err)
 			}
+
 			if value.Type() != cty.Bool {
 				return nil, errors.E(
-					ErrInvalidConditionType,
-					"condition has type %s but must be boolean",
+					ErrInvalidInheritType,
+					`"inherit" has type %s but must be boolean`,
 					value.Type().FriendlyName(),
 				)
 			}
-			condition = value.True()
+
+			inherit = value.True()
 		}
 
-		if !condition {
+		if !inherit && hclBlock.Dir != st.Dir {
+			// ignore non-inheritable block
+			continue
+		}
+
+		if !matched {
 			hcls = append(hcls, HCL{
 				magicCommentStyle: commentStyle,
 				label:             name,
 				origin:            hclBlock.Range,
-				condition:         condition,
+				condition:         false,
 			})
 			continue
 		}
 
-		inherit := true
-		if hclBlock.Inherit != nil {
-			value, err := evalctx.Eval(hclBlock.Inherit.Expr)
+		condition := true
+		if hclBlock.Condition != nil {
+			value, err := evalctx.Eval(hclBlock.Condition.Expr)
 			if err != nil {
-				return nil, errors.E(ErrInheritEval, err)
+				return nil, errors.E(ErrConditionEval, err)
 			}
-
 			if value.Type() != cty.Bool {
 				return nil, errors.E(
-					ErrInvalidInheritType,
-					`"inherit" has type %s but must be boolean`,
+					ErrInvalidConditionType,
+					"condition has type %s but must be boolean",
 					value.Type().FriendlyName(),
 				)
 			}
-
-			inherit = value.True()
+			condition = value.True()
 		}
 
-		if !inherit && hclBlock.Dir != st.Dir {
-			// ignore non-inheritable block
+		if !condition {
+			hcls = append(hcls, HCL{
+				magicCommentStyle: commentStyle,
+				label:             name,
+				origin:            hclBlock.Range,
+				condition:         condition,
+			})
 			continue
 		}
 
@@ -345,7 +351,11 @@ func Load(
 			panic(errors.E(errors.ErrInternal, "unexpected block body type"))
 		}
 		if err := copyBody(gen.Body(), blockBody, evalctx); err != nil {
-			return nil, evalErr(root.Tree().RootDir(), ErrContentEval, hclBlock, err)
+			err = evalErr(root.Tree().RootDir(), ErrContentEval, hclBlock, err)
+			if trace := undefinedGlobalsTrace(root, st, evalctx, blockBody, err); len(trace) > 0 {
+				err = errors.E(err, trace)
+			}
+			return nil, err
 		}
 
 		formatted, err := fmt.FormatMultiline(string(gen.Bytes()), hclBlock.Range.HostPath())
@@ -378,6 +388,133 @@ func evalErr(rootdir string, kind errors.Kind, block hcl.GenHCLBlock, err error)
 	return errors.E(kind, err, "generate_hcl %q", block.Label)
 }
 
+// undefinedGlobalsTrace returns the definitions considered for the globals
+// referenced by the content which are undefined for the stack, including the
+// ones unsetting them and the ones from imported files. Only the references
+// inside the range of the error are considered.
+func undefinedGlobalsTrace(
+	root *config.Root,
+	st *config.Stack,
+	evalctx *eval.Context,
+	content *hclsyntax.Body,
+	err error,
+) errors.RelatedRanges {
+	globalsVal, ok := evalctx.GetNamespace("global")
+	if !ok {
+		return nil
+	}
+
+	var errRange hhcl.Range
+	var e *errors.Error
+	if errors.As(err, &e) {
+		errRange = e.FileRange
+	}
+
+	var undefined []eval.ObjectPath
+	seen := map[string]bool{}
+	_ = hclsyntax.VisitAll(content, func(node hclsyntax.Node) hhcl.Diagnostics {
+		expr, ok := node.(*hclsyntax.ScopeTraversalExpr)
+		if !ok || expr.Traversal.RootName() != "global" {
+			return nil
+		}
+		if !errRange.Empty() && !containsRange(errRange, expr.SrcRange) {
+			return nil
+		}
+		globalPath, ok := undefinedGlobalPath(globalsVal, expr.Traversal)
+		name := strings.Join(globalPath, ".")
+		if ok && !seen[name] {
+			seen[name] = true
+			undefined = append(undefined, globalPath)
+		}
+		return nil
+	})
+	if len(undefined) == 0 {
+		return nil
+	}
+
+	tree, ok := root.Lookup(st.Dir)
+	if !ok {
+		return nil
+	}
+	exprs, loadErr := globals.LoadExprs(tree)
+	if loadErr != nil {
+		return nil
+	}
+
+	var trace errors.RelatedRanges
+	for _, globalPath := range undefined {
+		defs := exprs.Trace(globalPath)
+		if len(defs) == 0 {
+			trace = append(trace, errors.RelatedRange{
+				Description: stdfmt.Sprintf("global.%s is not defined in %s or its parent directories",
+					strings.Join(globalPath, "."), st.Dir),
+			})
+			continue
+		}
+		for _, def := range defs {
+			desc := def.Name() + " is defined"
+			if def.Unset {
+				desc = def.Name() + " is unset"
+			}
+			if def.Imported() {
+				desc += stdfmt.Sprintf(", imported by %s", def.ConfigDir)
+			}
+			trace = append(trace, errors.RelatedRange{
+				Range:       def.Origin.ToHCLRange(),
+				Description: desc,
+			})
+		}
+	}
+	return trace
+}
+
+// undefinedGlobalPath returns the path of the first undefined global accessed
+// by the traversal, if any.
+func undefinedGlobalPath(globalsVal cty.Value, traversal hhcl.Traversal) (eval.ObjectPath, bool) {
+	var globalPath eval.ObjectPath
+	val := globalsVal
+	for _, step := range traversal[1:] {
+		var name string
+		switch step := step.(type) {
+		case hhcl.TraverseAttr:
+			name = step.Name
+		case hhcl.TraverseIndex:
+			if !step.Key.IsKnown() || step.Key.Type() != cty.String {
+				return nil, false
+			}
+			name = step.Key.AsString()
+		default:
+			return nil, false
+		}
+		if val.IsNull() || !val.IsKnown() {
+			return nil, false
+		}
+		globalPath = append(globalPath, name)
+		switch {
+		case val.Type().IsObjectType():
+			if !val.Type().HasAttribute(name) {
+				return globalPath, true
+			}
+			val = val.GetAttr(name)
+		case val.Type().IsMapType():
+			key := cty.StringVal(name)
+			if !val.HasIndex(key).True() {
+				return globalPath, true
+			}
+			val = val.Index(key)
+		default:
+			return nil, false
+		}
+	}
+	return nil, false
+}
+
+func containsRange(outer, inner hhcl.Range) bool {
+	return outer.Filename == inner.Filename &&
+		outer.Start.Byte <= inner.Start.Byte &&
+		inner.End.Byte <= outer.End.Byte
+}
+
 type dynBlockAttributes struct {
 	attributes *hclsyntax.Attribute
 	iterator   *hclsyntax.Attribute
@@ -19,6 +19,7 @@ import (
 	"github.com/terramate-io/terramate/hcl"
 	"github.com/terramate-io/terramate/hcl/eval"
 	"github.com/terramate-io/terramate/hcl/info"
+	"github.com/terramate-io/terramate/lets"
 	"github.com/terramate-io/terramate/project"
 	"github.com/terramate-io/terramate/stack"
 	"github.com/terramate-io/terramate/test"
@@ -1681,6 +1682,58 @@ func TestGenerateHCL(t *testing.T) {
 			},
 			wantErr: errors.E(eval.ErrPartial),
 		},
+		{
+			name:  "lets are available to condition",
+			stack: "/stack",
+			configs: []hclconfig{
+				{
+					path: "/stack",
+					add: GenerateHCL(
+						Labels("test"),
+						Lets(
+							Expr("enabled", `terramate.stack.path.absolute != "/stack"`),
+						),
+						Expr("condition", `let.enabled`),
+						Content(
+							Block("testblock",
+								Expr("bool", "global.this.will.explode"),
+							),
+						),
+					),
+				},
+			},
+			want: []result{
+				{
+					name: "test",
+					hcl: genHCL{
+						condition: false,
+						body:      Doc(),
+					},
+				},
+			},
+		},
+		{
+			name:  "lets with cyclic references fail",
+			stack: "/stack",
+			configs: []hclconfig{
+				{
+					path: "/stack",
+					add: GenerateHCL(
+						Labels("test"),
+						Lets(
+							Expr("a", `let.b`),
+							Expr("b", `let.a`),
+						),
+						Content(
+							Block("testblock",
+								Expr("value", "let.a"),
+							),
+						),
+					),
+				},
+			},
+			wantErr: errors.E(lets.ErrEval),
+		},
 	}
 
 	for _, tcase := range tcases {
@@ -0,0 +1,196 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package generate
+
+import (
+	"path"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"github.com/terramate-io/terramate/config"
+	"github.com/terramate-io/terramate/errors"
+	"github.com/terramate-io/terramate/generate/genhcl"
+	"github.com/terramate-io/terramate/hcl"
+	"github.com/terramate-io/terramate/hcl/eval"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// ErrHeaderEval indicates the failure to evaluate the
+// terramate.config.generate.header.license attribute.
+const ErrHeaderEval errors.Kind = "evaluating terramate.config.generate.header.license"
+
+// headerFile is a generated file with the terramate.config.generate.header
+// injected into its header or body.
+type headerFile struct {
+	GenFile
+	header string
+	body   string
+}
+
+func (f headerFile) Header() string { return f.header }
+func (f headerFile) Body() string   { return f.body }
+
+// commentStyle is how a comment is written in a type of file.
+// Line comments have only the line prefix set.
+type commentStyle struct {
+	line  string
+	start string
+	end   string
+}
+
+var (
+	hashComments  = commentStyle{line: "#"}
+	slashComments = commentStyle{line: "//"}
+	blockComments = commentStyle{start: "/*", end: "*/"}
+)
+
+// hclExtensions are the extensions of HCL files, which are commented with the
+// terramate.config.generate.hcl_magic_header_comment_style.
+var hclExtensions = map[string]struct{}{
+	".hcl":    {},
+	".tf":     {},
+	".tfvars": {},
+	".tm":     {},
+	".tofu":   {},
+}
+
+var extensionComments = map[string]commentStyle{
+	".bash":   hashComments,
+	".conf":   hashComments,
+	".ps1":    hashComments,
+	".py":     hashComments,
+	".rb":     hashComments,
+	".sh":     hashComments,
+	".toml":   hashComments,
+	".yaml":   hashComments,
+	".yml":    hashComments,
+	".zsh":    hashComments,
+	".c":      slashComments,
+	".cpp":    slashComments,
+	".cs":     slashComments,
+	".go":     slashComments,
+	".groovy": slashComments,
+	".h":      slashComments,
+	".java":   slashComments,
+	".js":     slashComments,
+	".kt":     slashComments,
+	".proto":  slashComments,
+	".rs":     slashComments,
+	".scala":  slashComments,
+	".swift":  slashComments,
+	".ts":     slashComments,
+	".css":    blockComments,
+	".less":   blockComments,
+	".scss":   blockComments,
+}
+
+var filenameComments = map[string]commentStyle{
+	"Dockerfile": hashComments,
+	"Makefile":   hashComments,
+}
+
+// fileCommentStyle returns the comment style of the file with the given
+// label, which is false if the type of file has no (known) comments.
+func fileCommentStyle(label string, hclStyle genhcl.CommentStyle) (commentStyle, bool) {
+	name := path.Base(label)
+	if style, ok := filenameComments[name]; ok {
+		return style, true
+	}
+	ext := path.Ext(name)
+	if _, ok := hclExtensions[ext]; ok {
+		return commentStyle{line: hclStyle.String()}, true
+	}
+	style, ok := extensionComments[ext]
+	return style, ok
+}
+
+// comment returns the text commented with the style, followed by an empty line.
+func (c commentStyle) comment(text string) string {
+	var b strings.Builder
+	if c.line == "" {
+		b.WriteString(c.start + "\n" + text + "\n" + c.end + "\n")
+	} else {
+		for _, line := range strings.Split(text, "\n") {
+			b.WriteString(strings.TrimRight(c.line+" "+line, " ") + "\n")
+		}
+	}
+	b.WriteString("\n")
+	return b.String()
+}
+
+// injectHeader returns the files with the terramate.config.generate.header
+// evaluated in the given context injected.
+//
+// Files with the Terramate header, like the ones from generate_hcl, have the
+// configured header placed right after it, so they are still detected as
+// generated. Other files have it placed at the top or after the shebang line,
+// depending on the configured position. Files of unknown type or without
+// comments (eg.: JSON) are kept unchanged.
+func injectHeader(root *config.Root, evalctx *eval.Context, files []GenFile) ([]GenFile, error) {
+	tmcfg := root.Tree().Node.Terramate
+	if tmcfg == nil ||
+		tmcfg.Config == nil ||
+		tmcfg.Config.Generate == nil ||
+		tmcfg.Config.Generate.Header == nil ||
+		tmcfg.Config.Generate.Header.License == nil {
+		return files, nil
+	}
+
+	headerCfg := tmcfg.Config.Generate.Header
+	val, err := evalctx.Eval(headerCfg.License.Expr)
+	if err != nil {
+		return nil, errors.E(ErrHeaderEval, err)
+	}
+	if val.Type() != cty.String {
+		return nil, errors.E(ErrHeaderEval, headerCfg.License.Range,
+			"must be a string but %q was given", val.Type().FriendlyName())
+	}
+	license := strings.TrimRight(val.AsString(), "\n")
+	if license == "" {
+		return files, nil
+	}
+
+	hclStyle := genhcl.CommentStyleFromConfig(root.Tree())
+	result := make([]GenFile, 0, len(files))
+	for _, file := range files {
+		if !file.Condition() {
+			result = append(result, file)
+			continue
+		}
+
+		if file.Header() != "" {
+			result = append(result, headerFile{
+				GenFile: file,
+				header:  file.Header() + commentStyle{line: hclStyle.String()}.comment(license),
+				body:    file.Body(),
+			})
+			continue
+		}
+
+		style, ok := fileCommentStyle(file.Label(), hclStyle)
+		if !ok {
+			log.Warn().
+				Str("file", file.Label()).
+				Stringer("origin", file.Range()).
+				Msg("file type doesn't support comments, the configured header is not injected")
+
+			result = append(result, file)
+			continue
+		}
+
+		body := file.Body()
+		header := style.comment(license)
+		if headerCfg.Position == hcl.GenerateHeaderPositionAfterShebang && strings.HasPrefix(body, "#!") {
+			shebang, rest, _ := strings.Cut(body, "\n")
+			body = shebang + "\n" + header + rest
+		} else {
+			body = header + body
+		}
+		result = append(result, headerFile{
+			GenFile: file,
+			body:    body,
+		})
+	}
+	return result, nil
+}
@@ -0,0 +1,165 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package generate_test
+
+import (
+	"testing"
+
+	"github.com/madlambda/spells/assert"
+	"github.com/terramate-io/terramate/errors"
+	"github.com/terramate-io/terramate/generate"
+	"github.com/terramate-io/terramate/project"
+	errtest "github.com/terramate-io/terramate/test/errors"
+	"github.com/terramate-io/terramate/test/sandbox"
+)
+
+const headerConfig = `
+terramate {
+  config {
+    generate {
+      header {
+        license  = tm_file("${terramate.root.path.fs.absolute}/LICENSE_HEADER")
+        position = "after-shebang"
+      }
+    }
+  }
+}
+
+generate_hcl "main.tf" {
+  content {
+    locals {
+      stack = terramate.stack.name
+    }
+  }
+}
+
+generate_file "run.sh" {
+  content = <<-EOF
+    #!/bin/sh
+    echo ${terramate.stack.name}
+  EOF
+}
+
+generate_file "config.json" {
+  content = tm_jsonencode({ stack = terramate.stack.name })
+}
+
+generate_file "style.css" {
+  content = "body {}\n"
+}
+`
+
+const licenseHeader = `Copyright 2024 Acme Inc.
+SPDX-License-Identifier: Apache-2.0
+`
+
+func TestGenerateHeaderInjection(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.NoGit(t, true)
+	s.BuildTree([]string{
+		"f:terramate.tm:" + headerConfig,
+		"f:LICENSE_HEADER:" + licenseHeader,
+		"s:stack",
+	})
+
+	report := s.Generate()
+	assert.IsTrue(t, !report.HasFailures(), "unexpected failures: %s", report.Full())
+
+	assertFileContent(t, s.RootDir(), "stack/main.tf",
+		`// TERRAMATE: GENERATED AUTOMATICALLY DO NOT EDIT
+
+// Copyright 2024 Acme Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+locals {
+  stack = "stack"
+}
+`)
+	assertFileContent(t, s.RootDir(), "stack/run.sh",
+		`#!/bin/sh
+# Copyright 2024 Acme Inc.
+# SPDX-License-Identifier: Apache-2.0
+
+echo stack
+`)
+	assertFileContent(t, s.RootDir(), "stack/config.json", `{"stack":"stack"}`)
+	assertFileContent(t, s.RootDir(), "stack/style.css",
+		`/*
+Copyright 2024 Acme Inc.
+SPDX-License-Identifier: Apache-2.0
+*/
+
+body {}
+`)
+	assertNoOutdated(t, s)
+
+	// regenerating must be stable.
+	report = s.Generate()
+	assert.IsTrue(t, !report.HasFailures(), "unexpected failures: %s", report.Full())
+	assert.EqualInts(t, 0, len(report.Successes), "regeneration must not change files")
+	assertNoOutdated(t, s)
+}
+
+func TestGenerateHeaderPositionTop(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.NoGit(t, true)
+	s.BuildTree([]string{
+		`f:terramate.tm:
+		terramate {
+		  config {
+		    generate {
+		      hcl_magic_header_comment_style = "#"
+		      header {
+		        license = "Copyright ${terramate.stack.name}"
+		      }
+		    }
+		  }
+		}
+
+		generate_file "run.sh" {
+		  content = "#!/bin/sh\n"
+		}
+
+		generate_file "vars.tfvars" {
+		  content = "a = 1\n"
+		}`,
+		"s:stack",
+	})
+
+	report := s.Generate()
+	assert.IsTrue(t, !report.HasFailures(), "unexpected failures: %s", report.Full())
+
+	assertFileContent(t, s.RootDir(), "stack/run.sh", "# Copyright stack\n\n#!/bin/sh\n")
+	assertFileContent(t, s.RootDir(), "stack/vars.tfvars", "# Copyright stack\n\na = 1\n")
+	assertNoOutdated(t, s)
+}
+
+func TestGenerateHeaderLicenseMustBeString(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.NoGit(t, true)
+	s.BuildTree([]string{
+		`f:terramate.tm:
+		terramate {
+		  config {
+		    generate {
+		      header {
+		        license = 1
+		      }
+		    }
+		  }
+		}
+
+		generate_file "file.txt" {
+		  content = "test"
+		}`,
+		"s:stack",
+	})
+
+	report := generate.Do(s.Config(), project.NewPath("/"), 0, project.NewPath("/modules"), nil, false, generate.ContextAll)
+	assert.EqualInts(t, 1, len(report.Failures))
+	errtest.Assert(t, report.Failures[0].Error, errors.E(generate.ErrHeaderEval))
+}
@@ -0,0 +1,163 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package generate
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/terramate-io/terramate/project"
+)
+
+// MaxSlowestScopes is the maximum number of scopes kept in [Metrics.Slowest].
+const MaxSlowestScopes = 10
+
+// Metrics has the timing metrics of the code generation.
+type Metrics struct {
+	// Total is the wall time of the whole code generation.
+	Total time.Duration `json:"total"`
+
+	// Phases is the time spent on each phase summed over all scopes.
+	// Since scopes are generated in parallel it can be bigger than Total.
+	Phases PhaseMetrics `json:"phases"`
+
+	// Stacks is the number of stacks processed.
+	Stacks int `json:"stacks"`
+
+	// Slowest are the slowest scopes, slowest first.
+	Slowest []ScopeMetrics `json:"slowest"`
+}
+
+// PhaseMetrics has the time spent on each code generation phase.
+type PhaseMetrics struct {
+	// Load is the time spent loading the stack, its globals and the
+	// previously generated files.
+	Load time.Duration `json:"load"`
+	// Eval is the time spent evaluating the generate blocks.
+	Eval time.Duration `json:"eval"`
+	// Render is the time spent rendering and comparing the generated code.
+	Render time.Duration `json:"render"`
+	// Write is the time spent writing and deleting files.
+	Write time.Duration `json:"write"`
+}
+
+// ScopeMetrics has the timing metrics of a single code generation scope,
+// which is either a stack or a generate_file block with context=root.
+type ScopeMetrics struct {
+	// Dir is the stack directory or the directory of the root generated file.
+	Dir project.Path `json:"dir"`
+	// Block is the label of the generate block, empty for stacks.
+	Block string `json:"block,omitempty"`
+	// Total is the total time spent on the scope.
+	Total time.Duration `json:"total"`
+	// Phases is the time spent on each phase of the scope.
+	Phases PhaseMetrics `json:"phases"`
+}
+
+// Sum returns the sum of the time spent on all phases.
+func (p PhaseMetrics) Sum() time.Duration {
+	return p.Load + p.Eval + p.Render + p.Write
+}
+
+func (p *PhaseMetrics) add(other PhaseMetrics) {
+	p.Load += other.Load
+	p.Eval += other.Eval
+	p.Render += other.Render
+	p.Write += other.Write
+}
+
+func (p PhaseMetrics) String() string {
+	return fmt.Sprintf("load %s, eval %s, render %s, write %s",
+		p.Load, p.Eval, p.Render, p.Write)
+}
+
+func (s ScopeMetrics) String() string {
+	name := s.Dir.String()
+	if s.Block != "" {
+		name += fmt.Sprintf(" (generate_file %q)", s.Block)
+	}
+	return fmt.Sprintf("%s: %s (%s)", name, s.Total, s.Phases)
+}
+
+// String provides a human readable summary of the metrics.
+func (m Metrics) String() string {
+	report := []string{"Code generation metrics", ""}
+	addLine := func(msg string, args ...interface{}) {
+		report = append(report, fmt.Sprintf(msg, args...))
+	}
+
+	addLine("Total time: %s", m.Total)
+	addLine("Stacks: %d", m.Stacks)
+	addLine("Phases:")
+	addLine("\tload:   %s", m.Phases.Load)
+	addLine("\teval:   %s", m.Phases.Eval)
+	addLine("\trender: %s", m.Phases.Render)
+	addLine("\twrite:  %s", m.Phases.Write)
+
+	if len(m.Slowest) > 0 {
+		addLine("Slowest:")
+		for _, scope := range m.Slowest {
+			addLine("\t- %s", scope)
+		}
+	}
+	return strings.Join(report, "\n")
+}
+
+func newMetrics(total time.Duration, stacks int, scopes []ScopeMetrics) *Metrics {
+	m := &Metrics{
+		Total:  total,
+		Stacks: stacks,
+	}
+	for _, scope := range scopes {
+		m.Phases.add(scope.Phases)
+	}
+
+	slowest := make([]ScopeMetrics, len(scopes))
+	copy(slowest, scopes)
+	sort.SliceStable(slowest, func(i, j int) bool {
+		if slowest[i].Total != slowest[j].Total {
+			return slowest[i].Total > slowest[j].Total
+		}
+		return slowest[i].Dir.String() < slowest[j].Dir.String()
+	})
+	if len(slowest) > MaxSlowestScopes {
+		slowest = slowest[:MaxSlowestScopes]
+	}
+	m.Slowest = slowest
+	return m
+}
+
+// phaseTimer measures the time spent on each phase of a scope.
+type phaseTimer struct {
+	last   time.Time
+	phases PhaseMetrics
+}
+
+func newPhaseTimer() *phaseTimer {
+	return &phaseTimer{last: time.Now()}
+}
+
+// restart discards the time elapsed since the last lap. It is used when the
+// work of multiple scopes is interleaved.
+func (t *phaseTimer) restart() {
+	t.last = time.Now()
+}
+
+// lap adds the time elapsed since the last lap to the given phase.
+func (t *phaseTimer) lap(phase *time.Duration) {
+	now := time.Now()
+	*phase += now.Sub(t.last)
+	t.last = now
+}
+
+func (t *phaseTimer) scope(dir project.Path, block string) ScopeMetrics {
+	return ScopeMetrics{
+		Dir:    dir,
+		Block:  block,
+		Total:  t.phases.Sum(),
+		Phases: t.phases,
+	}
+}
@@ -0,0 +1,127 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package generate_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/madlambda/spells/assert"
+	"github.com/terramate-io/terramate/generate"
+	"github.com/terramate-io/terramate/project"
+	. "github.com/terramate-io/terramate/test/hclwrite/hclutils"
+	"github.com/terramate-io/terramate/test/sandbox"
+)
+
+func TestGenerateMetrics(t *testing.T) {
+	t.Parallel()
+
+	const nstacks = 12
+
+	s := sandbox.NoGit(t, true)
+	layout := []string{}
+	for i := 0; i < nstacks; i++ {
+		layout = append(layout, fmt.Sprintf("s:stacks/stack-%02d", i))
+	}
+	s.BuildTree(layout)
+
+	s.RootEntry().CreateConfig(
+		Doc(
+			GenerateHCL(
+				Labels("file.hcl"),
+				Content(
+					Str("stack", "${terramate.stack.path.absolute}"),
+				),
+			),
+			GenerateFile(
+				Labels("/root.txt"),
+				Expr("context", "root"),
+				Str("content", "root file"),
+			),
+		).String(),
+	)
+
+	report := s.Generate()
+	metrics := report.Metrics
+
+	assert.EqualInts(t, nstacks, metrics.Stacks)
+	assert.EqualInts(t, generate.MaxSlowestScopes, len(metrics.Slowest))
+	assertValidMetrics(t, metrics)
+
+	var scopesTotal time.Duration
+	for _, scope := range metrics.Slowest {
+		scopesTotal += scope.Total
+	}
+	assert.IsTrue(t, scopesTotal <= metrics.Phases.Sum(),
+		"slowest scopes total %s must not exceed the phases sum %s",
+		scopesTotal, metrics.Phases.Sum())
+
+	metricsReport := metrics.String()
+	for _, want := range []string{
+		"Total time: ",
+		fmt.Sprintf("Stacks: %d", nstacks),
+		"Slowest:",
+	} {
+		assert.IsTrue(t, strings.Contains(metricsReport, want),
+			"metrics report %q must contain %q", metricsReport, want)
+	}
+}
+
+func TestGenerateMetricsRootContextScopes(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.NoGit(t, true)
+	s.RootEntry().CreateConfig(
+		GenerateFile(
+			Labels("/dir/root.txt"),
+			Expr("context", "root"),
+			Str("content", "root file"),
+		).String(),
+	)
+
+	report := s.Generate()
+	metrics := report.Metrics
+
+	assert.EqualInts(t, 0, metrics.Stacks)
+	assert.EqualInts(t, 1, len(metrics.Slowest))
+	assertValidMetrics(t, metrics)
+
+	scope := metrics.Slowest[0]
+	assert.EqualStrings(t, project.NewPath("/dir").String(), scope.Dir.String())
+	assert.EqualStrings(t, "/dir/root.txt", scope.Block)
+}
+
+func assertValidMetrics(t *testing.T, metrics generate.Metrics) {
+	t.Helper()
+
+	assertNonNegativePhases(t, metrics.Phases)
+	assert.IsTrue(t, metrics.Total > 0, "total time must be positive")
+
+	for i, scope := range metrics.Slowest {
+		assertNonNegativePhases(t, scope.Phases)
+		assert.IsTrue(t, scope.Total == scope.Phases.Sum(),
+			"scope %s total %s must be the sum of its phases %s",
+			scope.Dir, scope.Total, scope.Phases.Sum())
+
+		if i > 0 {
+			assert.IsTrue(t, metrics.Slowest[i-1].Total >= scope.Total,
+				"slowest scopes must be sorted by total time")
+		}
+	}
+}
+
+func assertNonNegativePhases(t *testing.T, phases generate.PhaseMetrics) {
+	t.Helper()
+
+	for name, d := range map[string]time.Duration{
+		"load":   phases.Load,
+		"eval":   phases.Eval,
+		"render": phases.Render,
+		"write":  phases.Write,
+	} {
+		assert.IsTrue(t, d >= 0, "phase %s has negative duration %s", name, d)
+	}
+}
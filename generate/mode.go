@@ -0,0 +1,42 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package generate
+
+import (
+	"os"
+	"runtime"
+
+	"github.com/rs/zerolog/log"
+)
+
+// effectiveMode returns the permission mode to be set on the generated file at
+// path. Zero means the file has the default permissions. File modes are not
+// supported on Windows, so the mode attribute is ignored there.
+func effectiveMode(path string, genfile GenFile) os.FileMode {
+	mode := genfile.Mode()
+	if mode != 0 && runtime.GOOS == "windows" {
+		log.Debug().
+			Str("file", path).
+			Stringer("mode", mode).
+			Msg("ignoring generate_file.mode on windows")
+		return 0
+	}
+	return mode
+}
+
+// modeOutdated tells if the file at path exists with a permission mode
+// other than the given one. A zero mode is never outdated.
+func modeOutdated(path string, mode os.FileMode) (bool, error) {
+	if mode == 0 {
+		return false, nil
+	}
+	st, err := os.Stat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return st.Mode().Perm() != mode, nil
+}
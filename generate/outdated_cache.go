@@ -0,0 +1,233 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package generate
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+	"github.com/terramate-io/terramate/config"
+	"github.com/terramate-io/terramate/fs"
+	"github.com/terramate-io/terramate/generate/outdatedcache"
+	"github.com/terramate-io/terramate/project"
+)
+
+// OutdatedCacheDir is the directory, relative to the project root, where the
+// digests of the generated code are cached for the outdated code detection.
+const OutdatedCacheDir = ".terramate/cache/outdated"
+
+var outdatedCacheCfg struct {
+	sync.RWMutex
+	enabled bool
+	version string
+}
+
+// uncacheableRefs are the functions and namespaces whose values depend on
+// something other than the configuration files, like files read from the
+// project, the current time or the host environment (read by the run.env
+// definitions exposed in the runenv namespace). The directories using them are
+// always evaluated.
+var uncacheableRefs = [][]byte{
+	[]byte("tm_file"),
+	[]byte("tm_templatefile"),
+	[]byte("tm_timestamp"),
+	[]byte("tm_plantimestamp"),
+	[]byte("tm_uuid"),
+	[]byte("tm_bcrypt"),
+	[]byte(RunEnvNamespace),
+}
+
+// EnableOutdatedCache enables the on-disk cache of the outdated code detection
+// ([DetectOutdated] and [DetectOutdatedStacks]). The cache is stored in the
+// [OutdatedCacheDir] of the project root and its entries are invalidated when
+// the version changes.
+func EnableOutdatedCache(version string) {
+	outdatedCacheCfg.Lock()
+	defer outdatedCacheCfg.Unlock()
+	outdatedCacheCfg.enabled = true
+	outdatedCacheCfg.version = version
+}
+
+// DisableOutdatedCache disables the on-disk cache of the outdated code
+// detection.
+func DisableOutdatedCache() {
+	outdatedCacheCfg.Lock()
+	defer outdatedCacheCfg.Unlock()
+	outdatedCacheCfg.enabled = false
+	outdatedCacheCfg.version = ""
+}
+
+// outdatedCache caches the digests of the code generated for each directory,
+// keyed by the fingerprint of the inputs of the code generation of the
+// directory: the Terramate files of the directory and its parents, the files
+// imported by them, the global overrides and the list of stacks.
+// A nil *outdatedCache is valid and never caches anything.
+type outdatedCache struct {
+	cache     *outdatedcache.Cache
+	root      *config.Root
+	vendorDir project.Path
+
+	stacks string
+	dirs   map[project.Path]inputsHash
+	files  map[string]inputsHash
+}
+
+type inputsHash struct {
+	hash      string
+	cacheable bool
+}
+
+func newOutdatedCache(root *config.Root, vendorDir project.Path) *outdatedCache {
+	outdatedCacheCfg.RLock()
+	defer outdatedCacheCfg.RUnlock()
+	if !outdatedCacheCfg.enabled {
+		return nil
+	}
+	return openOutdatedCache(root, vendorDir, outdatedCacheCfg.version)
+}
+
+func openOutdatedCache(root *config.Root, vendorDir project.Path, version string) *outdatedCache {
+	dir := filepath.Join(root.HostDir(), filepath.FromSlash(OutdatedCacheDir))
+	return &outdatedCache{
+		cache:     outdatedcache.New(dir, version),
+		root:      root,
+		vendorDir: vendorDir,
+		stacks:    strings.Join(root.Stacks().Strings(), ","),
+		dirs:      map[project.Path]inputsHash{},
+		files:     map[string]inputsHash{},
+	}
+}
+
+// digests returns the digests of the code generated for the key in the
+// directory configured by cfg. The code is only evaluated by the gen function
+// if the inputs of the directory changed since the digests were cached.
+func (c *outdatedCache) digests(
+	key string,
+	cfg *config.Tree,
+	gen func() ([]outdatedcache.File, error),
+) ([]outdatedcache.File, error) {
+	if c == nil {
+		return gen()
+	}
+
+	logger := log.With().
+		Str("action", "generate.outdatedCache.digests()").
+		Str("key", key).
+		Logger()
+
+	fingerprint, ok := c.fingerprint(cfg)
+	if !ok {
+		logger.Debug().Msg("inputs are not cacheable")
+		return gen()
+	}
+
+	if files, ok := c.cache.Load(key, fingerprint); ok {
+		logger.Debug().Msg("inputs not changed, using cached digests")
+		return files, nil
+	}
+
+	files, err := gen()
+	if err != nil {
+		return nil, err
+	}
+	if err := c.cache.Store(key, fingerprint, files); err != nil {
+		logger.Debug().Err(err).Msg("caching digests")
+	}
+	return files, nil
+}
+
+// fingerprint returns the fingerprint of the inputs of the code generation of
+// the directory configured by cfg. It returns false if the inputs cannot be
+// fingerprinted.
+func (c *outdatedCache) fingerprint(cfg *config.Tree) (string, bool) {
+	h := sha256.New()
+	fmt.Fprintf(h, "root=%s\nvendor=%s\nstacks=%s\n", c.root.HostDir(), c.vendorDir, c.stacks)
+
+	for tree := cfg; tree != nil; tree = tree.Parent {
+		dir := c.dirHash(tree)
+		if !dir.cacheable {
+			return "", false
+		}
+		fmt.Fprintf(h, "dir=%s %s\n", tree.Dir(), dir.hash)
+	}
+
+	for _, override := range c.root.GlobalOverrides(cfg.Dir()) {
+		file := c.fileHash(override.Origin.HostPath())
+		if !file.cacheable {
+			return "", false
+		}
+		fmt.Fprintf(h, "override=%s %s %s %s\n", override.Scope,
+			strings.Join(override.Labels, "."), override.Name, file.hash)
+	}
+	return hex.EncodeToString(h.Sum(nil)), true
+}
+
+// dirHash returns the hash of the Terramate files of the directory and the
+// files imported by them.
+func (c *outdatedCache) dirHash(tree *config.Tree) inputsHash {
+	if res, ok := c.dirs[tree.Dir()]; ok {
+		return res
+	}
+
+	res := inputsHash{}
+	defer func() { c.dirs[tree.Dir()] = res }()
+
+	list, err := fs.ListTerramateFiles(tree.HostDir())
+	if err != nil {
+		return res
+	}
+
+	var files []string
+	for _, fname := range append(list.TmFiles, list.TmGenFiles...) {
+		files = append(files, filepath.Join(tree.HostDir(), fname))
+	}
+	files = append(files, tree.ImportedFiles...)
+
+	h := sha256.New()
+	for _, file := range files {
+		fileHash := c.fileHash(file)
+		if !fileHash.cacheable {
+			return res
+		}
+		fmt.Fprintf(h, "%s %s\n", file, fileHash.hash)
+	}
+	res = inputsHash{hash: hex.EncodeToString(h.Sum(nil)), cacheable: true}
+	return res
+}
+
+func (c *outdatedCache) fileHash(file string) inputsHash {
+	if res, ok := c.files[file]; ok {
+		return res
+	}
+
+	res := inputsHash{}
+	content, err := os.ReadFile(file)
+	if err == nil {
+		sum := sha256.Sum256(content)
+		res = inputsHash{
+			hash:      hex.EncodeToString(sum[:]),
+			cacheable: isCacheable(content),
+		}
+	} else {
+		log.Debug().Err(err).Str("file", file).Msg("hashing input file")
+	}
+	c.files[file] = res
+	return res
+}
+
+func isCacheable(content []byte) bool {
+	for _, fn := range uncacheableRefs {
+		if bytes.Contains(content, fn) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,144 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package generate_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/madlambda/spells/assert"
+	"github.com/terramate-io/terramate/generate"
+	"github.com/terramate-io/terramate/project"
+	"github.com/terramate-io/terramate/test/sandbox"
+)
+
+func TestOutdatedDetectionCacheImportedGlobals(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.NoGit(t, true)
+	s.BuildTree([]string{
+		`f:shared/globals.tm:
+globals {
+  region = "us-east-1"
+}
+`,
+		`f:envs/prod/import.tm:
+import {
+  source = "/shared/globals.tm"
+}
+`,
+		`f:generate.tm:
+generate_file "region.txt" {
+  content = tm_try(global.region, "none")
+}
+`,
+		`s:envs/prod/eu/zone/stack`,
+		`s:envs/prod/other`,
+		`s:envs/dev/eu/zone/stack`,
+	})
+	s.Generate()
+
+	vendorDir := project.NewPath("/modules")
+	detect := func() []string {
+		t.Helper()
+		got, err := generate.DetectOutdatedCached(s.Config(), s.Config().Tree(), vendorDir, "1.0.0")
+		assert.NoError(t, err)
+		return got
+	}
+
+	assertEqualStringList(t, detect(), []string{})
+	assertEqualStringList(t, detect(), []string{})
+
+	entries, err := os.ReadDir(filepath.Join(s.RootDir(), filepath.FromSlash(generate.OutdatedCacheDir)))
+	assert.NoError(t, err)
+	assert.IsTrue(t, len(entries) > 1, "cache entries not created")
+
+	// the file is imported three levels up of the stack.
+	s.RootEntry().CreateFile("shared/globals.tm", `globals {
+  region = "eu-west-1"
+}
+`)
+	s.ReloadConfig()
+
+	assertEqualStringList(t, detect(), []string{
+		"envs/prod/eu/zone/stack/region.txt",
+		"envs/prod/other/region.txt",
+	})
+
+	s.Generate()
+	assertEqualStringList(t, detect(), []string{})
+}
+
+func TestOutdatedDetectionCacheReadFiles(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.NoGit(t, true)
+	s.BuildTree([]string{
+		`f:stack/generate.tm:
+generate_file "data.txt" {
+  content = tm_file("input.txt")
+}
+`,
+		`f:stack/input.txt:one`,
+		`s:stack`,
+	})
+	s.Generate()
+
+	vendorDir := project.NewPath("/modules")
+	detect := func() []string {
+		t.Helper()
+		got, err := generate.DetectOutdatedCached(s.Config(), s.Config().Tree(), vendorDir, "1.0.0")
+		assert.NoError(t, err)
+		return got
+	}
+
+	assertEqualStringList(t, detect(), []string{})
+
+	// the files read by the configuration are not fingerprinted, so the
+	// stack is always evaluated.
+	s.RootEntry().CreateFile("stack/input.txt", "two")
+	assertEqualStringList(t, detect(), []string{"stack/data.txt"})
+}
+
+func TestOutdatedDetectionCacheRunEnv(t *testing.T) {
+	t.Setenv("TM_TEST_RUNENV_CACHE_DIR", "/tmp/cache-1")
+
+	s := sandbox.NoGit(t, true)
+	s.BuildTree([]string{
+		`f:terramate.tm:
+terramate {
+  config {
+    run {
+      env {
+        TF_PLUGIN_CACHE_DIR = env.TM_TEST_RUNENV_CACHE_DIR
+      }
+    }
+  }
+}
+`,
+		`f:stack/generate.tm:
+generate_file "env.sh" {
+  content = "export TF_PLUGIN_CACHE_DIR=${runenv.TF_PLUGIN_CACHE_DIR}"
+}
+`,
+		`s:stack`,
+	})
+	s.Generate()
+
+	vendorDir := project.NewPath("/modules")
+	detect := func() []string {
+		t.Helper()
+		got, err := generate.DetectOutdatedCached(s.Config(), s.Config().Tree(), vendorDir, "1.0.0")
+		assert.NoError(t, err)
+		return got
+	}
+
+	assertEqualStringList(t, detect(), []string{})
+
+	// the run environment depends on the host environment, so the stack is
+	// always evaluated.
+	t.Setenv("TM_TEST_RUNENV_CACHE_DIR", "/tmp/cache-2")
+	assertEqualStringList(t, detect(), []string{"stack/env.sh"})
+}
@@ -1568,6 +1568,15 @@ func TestOutdatedDetection(t *testing.T) {
 
 				assertEqualStringList(t, got, step.want)
 
+				t.Log("checking that the cached detection gives the same result")
+
+				// twice, so the second time the cached digests are used.
+				for i := 0; i < 2; i++ {
+					got, err = generate.DetectOutdatedCached(s.Config(), target, vendorDir, terramate.Version())
+					assert.NoError(t, err)
+					assertEqualStringList(t, got, step.want)
+				}
+
 				t.Log("checking that after generate outdated detection should always return empty")
 
 				s.GenerateWith(s.Config(), vendorDir)
@@ -1575,6 +1584,11 @@ func TestOutdatedDetection(t *testing.T) {
 				assert.NoError(t, err)
 
 				assertEqualStringList(t, got, []string{})
+
+				got, err = generate.DetectOutdatedCached(s.Config(), s.Config().Tree(), vendorDir, terramate.Version())
+				assert.NoError(t, err)
+
+				assertEqualStringList(t, got, []string{})
 			}
 		})
 	}
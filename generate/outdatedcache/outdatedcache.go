@@ -0,0 +1,174 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+// Package outdatedcache implements an on-disk cache of the code generated for
+// each directory, used to detect outdated code without evaluating the
+// directories whose configuration did not change.
+//
+// Each cached entry stores the digests of the files generated for a single key
+// (eg.: a stack) together with the fingerprint of the inputs of the code
+// generation and the version of Terramate which produced it. An entry is only
+// used if both match, otherwise the code must be evaluated again and the entry
+// is rewritten. Any failure reading or decoding an entry is handled as a cache
+// miss.
+package outdatedcache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"github.com/rs/zerolog/log"
+	"github.com/terramate-io/terramate/errors"
+)
+
+// formatVersion is the version of the cache entry encoding. It must be bumped
+// whenever the encoding changes in an incompatible way.
+const formatVersion = 1
+
+const gitignoreContent = "# Created by Terramate. Do not commit the cache.\n*\n"
+
+// File is the digest of a generated file.
+type File struct {
+	// Filename is the name of the generated file, relative to the directory
+	// where it's generated.
+	Filename string `json:"filename"`
+
+	// Condition is the evaluated condition of the generate block.
+	Condition bool `json:"condition"`
+
+	// Digest is the hash of the generated content, see [Digest].
+	Digest string `json:"digest,omitempty"`
+
+	// Mode is the permission mode of the file, zero if not set.
+	Mode os.FileMode `json:"mode,omitempty"`
+}
+
+type entry struct {
+	Format      int    `json:"format"`
+	Version     string `json:"version"`
+	Key         string `json:"key"`
+	Fingerprint string `json:"fingerprint"`
+	Files       []File `json:"files"`
+}
+
+// Cache is an on-disk cache of generated file digests.
+type Cache struct {
+	dir     string
+	version string
+}
+
+// New creates a new cache which stores its entries inside dir. The version is
+// the version of the tool creating the entries, entries created by a different
+// version are never used.
+func New(dir string, version string) *Cache {
+	return &Cache{
+		dir:     dir,
+		version: version,
+	}
+}
+
+// Dir returns the directory where the cache entries are stored.
+func (c *Cache) Dir() string { return c.dir }
+
+// Load the files cached for the key. It returns false if the key is not
+// cached, or if the cached entry has a different fingerprint or is corrupted.
+func (c *Cache) Load(key, fingerprint string) ([]File, bool) {
+	logger := log.With().
+		Str("action", "outdatedcache.Load()").
+		Str("key", key).
+		Logger()
+
+	content, err := os.ReadFile(c.entryPath(key))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			logger.Debug().Err(err).Msg("reading cache entry")
+		}
+		return nil, false
+	}
+
+	var e entry
+	if err := json.Unmarshal(content, &e); err != nil {
+		logger.Debug().Err(err).Msg("ignoring corrupted cache entry")
+		return nil, false
+	}
+
+	if e.Format != formatVersion || e.Version != c.version || e.Key != key {
+		logger.Trace().Msg("ignoring cache entry from different version")
+		return nil, false
+	}
+
+	if e.Fingerprint != fingerprint {
+		logger.Trace().Msg("ignoring outdated cache entry")
+		return nil, false
+	}
+
+	logger.Trace().Msg("cache hit")
+	return e.Files, true
+}
+
+// Store the files generated for the key with the given fingerprint.
+func (c *Cache) Store(key, fingerprint string, files []File) error {
+	content, err := json.Marshal(entry{
+		Format:      formatVersion,
+		Version:     c.version,
+		Key:         key,
+		Fingerprint: fingerprint,
+		Files:       files,
+	})
+	if err != nil {
+		return errors.E(err, "encoding cache entry for %s", key)
+	}
+
+	if err := c.init(); err != nil {
+		return err
+	}
+
+	// The entry is written to a temporary file first and then renamed, so
+	// concurrent readers never observe a partially written entry.
+	tmpfile, err := os.CreateTemp(c.dir, ".entry-*")
+	if err != nil {
+		return errors.E(err, "creating cache entry")
+	}
+	_, err = tmpfile.Write(content)
+	closeErr := tmpfile.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmpfile.Name(), c.entryPath(key))
+	}
+	if err != nil {
+		_ = os.Remove(tmpfile.Name())
+		return errors.E(err, "writing cache entry")
+	}
+	return nil
+}
+
+// Digest returns the digest of the generated content.
+func Digest(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+func (c *Cache) init() error {
+	if err := os.MkdirAll(c.dir, 0755); err != nil {
+		return errors.E(err, "creating cache directory")
+	}
+	// The cache lives inside the project, so make sure git ignores it.
+	gitignore := filepath.Join(c.dir, ".gitignore")
+	if _, err := os.Stat(gitignore); err == nil {
+		return nil
+	}
+	if err := os.WriteFile(gitignore, []byte(gitignoreContent), 0644); err != nil {
+		return errors.E(err, "creating cache .gitignore")
+	}
+	return nil
+}
+
+func (c *Cache) entryPath(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:]))
+}
@@ -0,0 +1,111 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package outdatedcache_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/madlambda/spells/assert"
+	"github.com/terramate-io/terramate/generate/outdatedcache"
+	"github.com/terramate-io/terramate/test"
+)
+
+var sampleFiles = []outdatedcache.File{
+	{
+		Filename:  "main.tf",
+		Condition: true,
+		Digest:    outdatedcache.Digest("content"),
+	},
+	{
+		Filename:  "run.sh",
+		Condition: true,
+		Digest:    outdatedcache.Digest("#!/bin/sh"),
+		Mode:      0755,
+	},
+	{
+		Filename: "disabled.tf",
+	},
+}
+
+func TestCacheRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	cache := outdatedcache.New(filepath.Join(test.TempDir(t), "cache"), "1.0.0")
+	_, ok := cache.Load("stack:/a", "fingerprint")
+	assert.IsTrue(t, !ok, "empty cache must miss")
+
+	assert.NoError(t, cache.Store("stack:/a", "fingerprint", sampleFiles))
+
+	got, ok := cache.Load("stack:/a", "fingerprint")
+	assert.IsTrue(t, ok, "cache must hit")
+	test.AssertDiff(t, got, sampleFiles)
+
+	_, ok = cache.Load("stack:/b", "fingerprint")
+	assert.IsTrue(t, !ok, "other keys must miss")
+}
+
+func TestCacheInvalidation(t *testing.T) {
+	t.Parallel()
+
+	cachedir := filepath.Join(test.TempDir(t), "cache")
+	cache := outdatedcache.New(cachedir, "1.0.0")
+	assert.NoError(t, cache.Store("stack:/a", "fingerprint", sampleFiles))
+
+	_, ok := cache.Load("stack:/a", "changed")
+	assert.IsTrue(t, !ok, "changed fingerprint must miss")
+
+	_, ok = outdatedcache.New(cachedir, "1.1.0").Load("stack:/a", "fingerprint")
+	assert.IsTrue(t, !ok, "other version must miss")
+
+	// storing again updates the entry.
+	assert.NoError(t, cache.Store("stack:/a", "changed", sampleFiles[:1]))
+	got, ok := cache.Load("stack:/a", "changed")
+	assert.IsTrue(t, ok, "updated entry must hit")
+	test.AssertDiff(t, got, sampleFiles[:1])
+}
+
+func TestCacheIgnoresCorruptedEntries(t *testing.T) {
+	t.Parallel()
+
+	cachedir := filepath.Join(test.TempDir(t), "cache")
+	cache := outdatedcache.New(cachedir, "1.0.0")
+	assert.NoError(t, cache.Store("stack:/a", "fingerprint", sampleFiles))
+
+	entries := cacheEntries(t, cachedir)
+	assert.EqualInts(t, 1, len(entries))
+
+	for _, corrupted := range []string{"", "garbage", `{"format": 1`, "[]"} {
+		test.WriteFile(t, cachedir, filepath.Base(entries[0]), corrupted)
+		_, ok := cache.Load("stack:/a", "fingerprint")
+		assert.IsTrue(t, !ok, "corrupted entry %q must miss", corrupted)
+	}
+}
+
+func TestCacheDirIsIgnoredByGit(t *testing.T) {
+	t.Parallel()
+
+	cachedir := filepath.Join(test.TempDir(t), "cache")
+	cache := outdatedcache.New(cachedir, "1.0.0")
+	assert.NoError(t, cache.Store("stack:/a", "fingerprint", sampleFiles))
+
+	gitignore, err := os.ReadFile(filepath.Join(cachedir, ".gitignore"))
+	assert.NoError(t, err)
+	assert.EqualStrings(t, "# Created by Terramate. Do not commit the cache.\n*\n", string(gitignore))
+}
+
+func cacheEntries(t *testing.T, dir string) []string {
+	t.Helper()
+	dirEntries, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	var entries []string
+	for _, entry := range dirEntries {
+		if entry.Name() == ".gitignore" {
+			continue
+		}
+		entries = append(entries, filepath.Join(dir, entry.Name()))
+	}
+	return entries
+}
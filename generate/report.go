@@ -22,6 +22,12 @@ type Result struct {
 	Changed []string
 	// Deleted contains filenames of all deleted files inside the stack
 	Deleted []string
+	// PendingDeletion contains filenames of all files that are not generated
+	// anymore but were not deleted because deletion is not allowed.
+	PendingDeletion []string
+	// Warnings contains the failed assertions on warning mode and the failed
+	// checks with warn severity that apply to the stack, including their origin.
+	Warnings []string
 }
 
 // FailureResult represents a failure on code generation.
@@ -45,6 +51,15 @@ type Report struct {
 	// CleanupErr is an error that happened after code generation
 	// was done while trying to cleanup files outside stacks.
 	CleanupErr error
+
+	// Metrics has the timing metrics of the code generation.
+	Metrics Metrics
+
+	scopes []ScopeMetrics
+
+	// rootContextDirs are the directories of the results of the
+	// generate_file blocks with context=root.
+	rootContextDirs map[project.Path]struct{}
 }
 
 // HasFailures returns true if this report includes any failures.
@@ -52,6 +67,18 @@ func (r Report) HasFailures() bool {
 	return r.BootstrapErr != nil || len(r.Failures) > 0
 }
 
+// HasChanges returns true if this report includes any created, changed or
+// deleted files.
+func (r Report) HasChanges() bool {
+	for _, res := range r.Successes {
+		if len(res.Created) > 0 || len(res.Changed) > 0 || len(res.Deleted) > 0 ||
+			len(res.PendingDeletion) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
 // Full provides a full report of the generated code, including information per stack.
 func (r Report) Full() string {
 	if r.empty() {
@@ -74,7 +101,12 @@ func (r Report) Full() string {
 		addLine("")
 	}
 	addStack := func(stack project.Path) {
-		addLine("- %s", stack)
+		addLine("- %s (context=%s)", stack, r.dirContext(stack))
+	}
+	addRelated := func(err error, indent string) {
+		for _, related := range errors.Related(err) {
+			addLine("%s%s", indent, related)
+		}
 	}
 	addResultChangeset := func(res Result) {
 		for _, created := range res.Created {
@@ -86,16 +118,27 @@ func (r Report) Full() string {
 		for _, deleted := range res.Deleted {
 			addLine("\t[-] %s", deleted)
 		}
+		for _, pending := range res.PendingDeletion {
+			addLine("\t[!] %s (pending deletion)", pending)
+		}
+	}
+	addWarnings := func(res Result) {
+		for _, warning := range res.Warnings {
+			addLine("\twarning: %s", warning)
+		}
 	}
 	needsHint := false
+	needsPendingHint := false
 
 	if len(r.Successes) > 0 {
 		addLine("Successes:")
 		newline()
 		for _, success := range r.Successes {
 			addStack(success.Dir)
+			addWarnings(success)
 			addResultChangeset(success)
 			newline()
+			needsPendingHint = needsPendingHint || len(success.PendingDeletion) > 0
 		}
 		needsHint = true
 	}
@@ -108,12 +151,16 @@ func (r Report) Full() string {
 			if list, ok := failure.Error.(*errors.List); ok {
 				for _, err := range list.Errors() {
 					addLine("\terror: %s", err)
+					addRelated(err, "\t\t")
 				}
 			} else {
 				addLine("\terror: %s", failure.Error)
+				addRelated(failure.Error, "\t\t")
 			}
+			addWarnings(failure.Result)
 			addResultChangeset(failure.Result)
 			newline()
+			needsPendingHint = needsPendingHint || len(failure.PendingDeletion) > 0
 		}
 		needsHint = true
 	}
@@ -127,6 +174,11 @@ func (r Report) Full() string {
 		addLine("Hint: '+', '~' and '-' mean the file was created, changed and deleted, respectively.")
 	}
 
+	if needsPendingHint {
+		addLine("Hint: '!' means the file is not generated anymore but it was not deleted.\n" +
+			"Run with --allow-delete or set terramate.config.generate.allow_deletion = true to delete it.")
+	}
+
 	return strings.Join(report, "\n")
 }
 
@@ -146,7 +198,15 @@ func (r Report) Minimal() string {
 	addLine := func(msg string, args ...interface{}) {
 		report = append(report, fmt.Sprintf(msg, args...))
 	}
+	addRelated := func(err error) {
+		for _, related := range errors.Related(err) {
+			addLine("\t%s", related)
+		}
+	}
 	addResult := func(res Result) {
+		for _, w := range res.Warnings {
+			addLine("Warning on %s: %s", res.Dir, w)
+		}
 		for _, c := range res.Created {
 			addLine("Created file %s/%s", res.Dir, c)
 		}
@@ -156,6 +216,9 @@ func (r Report) Minimal() string {
 		for _, c := range res.Deleted {
 			addLine("Deleted file %s/%s", res.Dir, c)
 		}
+		for _, c := range res.PendingDeletion {
+			addLine("Pending deletion of file %s/%s", res.Dir, c)
+		}
 	}
 
 	for _, success := range r.Successes {
@@ -166,9 +229,11 @@ func (r Report) Minimal() string {
 		if list, ok := failure.Error.(*errors.List); ok {
 			for _, err := range list.Errors() {
 				addLine("Error on %s: %v", failure.Dir, err)
+				addRelated(err)
 			}
 		} else {
 			addLine("Error on %s: %v", failure.Dir, failure.Error)
+			addRelated(failure.Error)
 		}
 		addResult(failure.Result)
 	}
@@ -181,6 +246,27 @@ func (r Report) Minimal() string {
 	return strings.Join(report, "\n")
 }
 
+// dirContext returns the context of the generate blocks which produced the
+// results of the given directory.
+func (r Report) dirContext(dir project.Path) string {
+	if _, ok := r.rootContextDirs[dir]; ok {
+		return ContextRoot
+	}
+	return ContextStack
+}
+
+func (r *Report) addRootContextDirs(rootReport *Report) {
+	if r.rootContextDirs == nil {
+		r.rootContextDirs = map[project.Path]struct{}{}
+	}
+	for _, success := range rootReport.Successes {
+		r.rootContextDirs[success.Dir] = struct{}{}
+	}
+	for _, failure := range rootReport.Failures {
+		r.rootContextDirs[failure.Dir] = struct{}{}
+	}
+}
+
 func (r Report) empty() bool {
 	return r.BootstrapErr == nil &&
 		len(r.Failures) == 0 &&
@@ -219,6 +305,30 @@ func (r *Report) addFailure(dir project.Path, err error) {
 	})
 }
 
+// addWarnings adds the warnings to the result of the given dir. A success
+// result is added if there is no result for the dir yet.
+func (r *Report) addWarnings(dir project.Path, warnings []string) {
+	if len(warnings) == 0 {
+		return
+	}
+	for i, failure := range r.Failures {
+		if failure.Dir == dir {
+			r.Failures[i].Warnings = append(r.Failures[i].Warnings, warnings...)
+			return
+		}
+	}
+	for i, success := range r.Successes {
+		if success.Dir == dir {
+			r.Successes[i].Warnings = append(r.Successes[i].Warnings, warnings...)
+			return
+		}
+	}
+	r.Successes = append(r.Successes, Result{
+		Dir:      dir,
+		Warnings: warnings,
+	})
+}
+
 func (r *Report) addDirReport(path project.Path, sr dirReport) {
 	if sr.empty() {
 		return
@@ -232,15 +342,17 @@ func (r *Report) addDirReport(path project.Path, sr dirReport) {
 				other.Created = append(other.Created, sr.created...)
 				other.Changed = append(other.Changed, sr.changed...)
 				other.Deleted = append(other.Deleted, sr.deleted...)
+				other.PendingDeletion = append(other.PendingDeletion, sr.pendingDeletion...)
 				r.Successes[i] = other
 				return
 			}
 		}
 		r.Successes = append(r.Successes, Result{
-			Dir:     path,
-			Created: sr.created,
-			Changed: sr.changed,
-			Deleted: sr.deleted,
+			Dir:             path,
+			Created:         sr.created,
+			Changed:         sr.changed,
+			Deleted:         sr.deleted,
+			PendingDeletion: sr.pendingDeletion,
 		})
 		return
 	}
@@ -250,16 +362,18 @@ func (r *Report) addDirReport(path project.Path, sr dirReport) {
 			other.Created = append(other.Created, sr.created...)
 			other.Changed = append(other.Changed, sr.changed...)
 			other.Deleted = append(other.Deleted, sr.deleted...)
+			other.PendingDeletion = append(other.PendingDeletion, sr.pendingDeletion...)
 			r.Failures[i] = other
 			return
 		}
 	}
 	r.Failures = append(r.Failures, FailureResult{
 		Result: Result{
-			Dir:     path,
-			Created: sr.created,
-			Changed: sr.changed,
-			Deleted: sr.deleted,
+			Dir:             path,
+			Created:         sr.created,
+			Changed:         sr.changed,
+			Deleted:         sr.deleted,
+			PendingDeletion: sr.pendingDeletion,
 		},
 		Error: sr.err,
 	})
@@ -269,13 +383,15 @@ func (r *Result) sortFilenames() {
 	sort.Strings(r.Created)
 	sort.Strings(r.Changed)
 	sort.Strings(r.Deleted)
+	sort.Strings(r.PendingDeletion)
 }
 
 type dirReport struct {
-	created []string
-	changed []string
-	deleted []string
-	err     error
+	created         []string
+	changed         []string
+	deleted         []string
+	pendingDeletion []string
+	err             error
 }
 
 func (s *dirReport) addCreatedFile(filename string) {
@@ -290,6 +406,10 @@ func (s *dirReport) addChangedFile(filename string) {
 	s.changed = append(s.changed, filename)
 }
 
+func (s *dirReport) addPendingDeletion(filename string) {
+	s.pendingDeletion = append(s.pendingDeletion, filename)
+}
+
 func (s dirReport) isSuccess() bool {
 	return s.err == nil
 }
@@ -298,6 +418,7 @@ func (s dirReport) empty() bool {
 	return len(s.created) == 0 &&
 		len(s.changed) == 0 &&
 		len(s.deleted) == 0 &&
+		len(s.pendingDeletion) == 0 &&
 		s.err == nil
 }
 
@@ -318,6 +439,7 @@ func mergeReports(reportChan chan *Report) *Report {
 
 		merged.Successes = joinResults(merged.Successes, r.Successes)
 		merged.Failures = joinResults(merged.Failures, r.Failures)
+		merged.scopes = append(merged.scopes, r.scopes...)
 	}
 	return merged
 }
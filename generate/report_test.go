@@ -90,16 +90,16 @@ Error details: ignore`,
 
 Successes:
 
-- /test
+- /test (context=stack)
 	[+] test
 
-- /test2
+- /test2 (context=stack)
 	[~] test
 
-- /test3
+- /test3 (context=stack)
 	[-] test
 
-- /test4
+- /test4 (context=stack)
 	[+] created1.tf
 	[+] created2.tf
 	[~] changed.tf
@@ -117,6 +117,41 @@ Changed file /test4/changed.tf
 Changed file /test4/changed2.tf
 Deleted file /test4/removed1.tf
 Deleted file /test4/removed2.tf`,
+		},
+		{
+			name: "pending deletion results",
+			report: generate.Report{
+				Successes: []generate.Result{
+					{
+						Dir:             project.NewPath("/test"),
+						Created:         []string{"created.tf"},
+						PendingDeletion: []string{"pending1.tf", "pending2.tf"},
+					},
+					{
+						Dir:             project.NewPath("/test2"),
+						PendingDeletion: []string{"pending.tf"},
+					},
+				},
+			},
+			wantFull: `Code generation report
+
+Successes:
+
+- /test (context=stack)
+	[+] created.tf
+	[!] pending1.tf (pending deletion)
+	[!] pending2.tf (pending deletion)
+
+- /test2 (context=stack)
+	[!] pending.tf (pending deletion)
+
+Hint: '+', '~' and '-' mean the file was created, changed and deleted, respectively.
+Hint: '!' means the file is not generated anymore but it was not deleted.
+Run with --allow-delete or set terramate.config.generate.allow_deletion = true to delete it.`,
+			wantMinimal: `Created file /test/created.tf
+Pending deletion of file /test/pending1.tf
+Pending deletion of file /test/pending2.tf
+Pending deletion of file /test2/pending.tf`,
 		},
 		{
 			name: "failure results",
@@ -143,10 +178,10 @@ Deleted file /test4/removed2.tf`,
 
 Failures:
 
-- /test
+- /test (context=stack)
 	error: full error
 
-- /test2
+- /test2 (context=stack)
 	error: partial error
 	[+] created1.tf
 	[+] created2.tf
@@ -201,22 +236,22 @@ Deleted file /test2/removed2.tf`,
 
 Successes:
 
-- /success
+- /success (context=stack)
 	[+] created.tf
 	[~] changed.tf
 	[-] removed.tf
 
-- /success2
+- /success2 (context=stack)
 	[+] created.tf
 	[~] changed.tf
 	[-] removed.tf
 
 Failures:
 
-- /failed
+- /failed (context=stack)
 	error: error
 
-- /failed2
+- /failed2 (context=stack)
 	error: error
 
 Hint: '+', '~' and '-' mean the file was created, changed and deleted, respectively.`,
@@ -260,12 +295,12 @@ Error on /failed2: error`,
 
 Failures:
 
-- /empty
+- /empty (context=stack)
 
-- /failed
+- /failed (context=stack)
 	error: error
 
-- /failed2
+- /failed2 (context=stack)
 	error: error1
 	error: error2
 
@@ -273,6 +308,54 @@ Hint: '+', '~' and '-' mean the file was created, changed and deleted, respectiv
 			wantMinimal: `Error on /failed: error
 Error on /failed2: error1
 Error on /failed2: error2`,
+		},
+		{
+			name: "warning results",
+			report: generate.Report{
+				Successes: []generate.Result{
+					{
+						Dir:      project.NewPath("/success"),
+						Created:  []string{"created.tf"},
+						Warnings: []string{"/terramate.tm:2,15-20: env is deprecated"},
+					},
+					{
+						Dir:      project.NewPath("/uptodate"),
+						Warnings: []string{"/terramate.tm:2,15-20: env is deprecated"},
+					},
+				},
+				Failures: []generate.FailureResult{
+					{
+						Result: generate.Result{
+							Dir:      project.NewPath("/failed"),
+							Warnings: []string{"/failed/stack.tm:5,15-20: warning"},
+						},
+						Error: errors.E("error"),
+					},
+				},
+			},
+			wantFull: `Code generation report
+
+Successes:
+
+- /success (context=stack)
+	warning: /terramate.tm:2,15-20: env is deprecated
+	[+] created.tf
+
+- /uptodate (context=stack)
+	warning: /terramate.tm:2,15-20: env is deprecated
+
+Failures:
+
+- /failed (context=stack)
+	error: error
+	warning: /failed/stack.tm:5,15-20: warning
+
+Hint: '+', '~' and '-' mean the file was created, changed and deleted, respectively.`,
+			wantMinimal: `Warning on /success: /terramate.tm:2,15-20: env is deprecated
+Created file /success/created.tf
+Warning on /uptodate: /terramate.tm:2,15-20: env is deprecated
+Error on /failed: error
+Warning on /failed: /failed/stack.tm:5,15-20: warning`,
 		},
 		{
 			name: "cleanup error result",
@@ -291,7 +374,7 @@ Error on /failed2: error2`,
 
 Successes:
 
-- /success
+- /success (context=stack)
 	[+] created.tf
 	[~] changed.tf
 	[-] removed.tf
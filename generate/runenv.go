@@ -0,0 +1,125 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package generate
+
+import (
+	hhcl "github.com/terramate-io/hcl/v2"
+	"github.com/terramate-io/hcl/v2/hclsyntax"
+	"github.com/terramate-io/terramate/config"
+	"github.com/terramate-io/terramate/errors"
+	"github.com/terramate-io/terramate/hcl"
+	"github.com/terramate-io/terramate/hcl/ast"
+	"github.com/terramate-io/terramate/hcl/eval"
+	"github.com/terramate-io/terramate/run"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// ErrRunEnv indicates that the terramate.config.run.env configuration of the
+// stack cannot be evaluated for the runenv namespace.
+const ErrRunEnv errors.Kind = "evaluating terramate.config.run.env for the runenv namespace"
+
+// RunEnvNamespace is the namespace, available in the generate_hcl and
+// generate_file blocks of stacks, containing the environment variables
+// defined by terramate.config.run.env for the stack.
+const RunEnvNamespace = "runenv"
+
+// setRunEnv sets the runenv namespace in the evaluation context of the stack.
+//
+// The run environment is evaluated with the stack globals and metadata (and
+// the host environment), exactly as done by the run command, so it never
+// depends on generated code. This way, the generate blocks are evaluated after
+// the run environment and there are no evaluation cycles.
+//
+// The run environment is only evaluated if any of the generate blocks of the
+// stack references the namespace, so failing run.env definitions (like
+// required host environment variables not set) only affect the generation of
+// the stacks which need them. The isolated data directory (TF_DATA_DIR) is not
+// part of the namespace.
+func setRunEnv(root *config.Root, cfg *config.Tree, st *config.Stack, evalctx *eval.Context) error {
+	if !referencesRunEnv(cfg) {
+		return nil
+	}
+	vars, err := run.LoadEnvWithOrigins(root, st, false)
+	if err != nil {
+		return errors.E(ErrRunEnv, err)
+	}
+	runenv := make(map[string]cty.Value, len(vars))
+	for _, v := range vars {
+		runenv[v.Name] = cty.StringVal(v.Value)
+	}
+	evalctx.SetNamespace(RunEnvNamespace, runenv)
+	return nil
+}
+
+// referencesRunEnv tells if any of the generate blocks of the given directory
+// or of its parent directories reference the runenv namespace.
+func referencesRunEnv(cfg *config.Tree) bool {
+	for tree := cfg; tree != nil; tree = tree.Parent {
+		for _, block := range tree.Node.Generate.Files {
+			if block.Context == "root" {
+				continue
+			}
+			if exprReferencesRunEnv(block.Content.Expr) ||
+				blockReferencesRunEnv(block.Lets, block.Condition, block.Inherit, block.Asserts) {
+				return true
+			}
+		}
+		for _, block := range tree.Node.Generate.HCLs {
+			if body, ok := block.Content.Body.(*hclsyntax.Body); ok && bodyReferencesRunEnv(body) {
+				return true
+			}
+			if blockReferencesRunEnv(block.Lets, block.Condition, block.Inherit, block.Asserts) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func blockReferencesRunEnv(
+	lets *ast.MergedBlock,
+	condition, inherit *hclsyntax.Attribute,
+	asserts []hcl.AssertConfig,
+) bool {
+	if lets != nil {
+		for _, raw := range lets.RawOrigins {
+			if bodyReferencesRunEnv(raw.Body) {
+				return true
+			}
+		}
+	}
+	for _, attr := range []*hclsyntax.Attribute{condition, inherit} {
+		if attr != nil && exprReferencesRunEnv(attr.Expr) {
+			return true
+		}
+	}
+	for _, assert := range asserts {
+		for _, expr := range []hhcl.Expression{assert.Assertion, assert.Message, assert.Warning} {
+			if expr != nil && exprReferencesRunEnv(expr) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func bodyReferencesRunEnv(body *hclsyntax.Body) bool {
+	found := false
+	_ = hclsyntax.VisitAll(body, func(node hclsyntax.Node) hhcl.Diagnostics {
+		if expr, ok := node.(hclsyntax.Expression); ok && !found {
+			found = exprReferencesRunEnv(expr)
+		}
+		return nil
+	})
+	return found
+}
+
+func exprReferencesRunEnv(expr hhcl.Expression) bool {
+	for _, traversal := range expr.Variables() {
+		if traversal.RootName() == RunEnvNamespace {
+			return true
+		}
+	}
+	return false
+}
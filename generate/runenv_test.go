@@ -0,0 +1,201 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package generate_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/madlambda/spells/assert"
+	"github.com/terramate-io/terramate/errors"
+	"github.com/terramate-io/terramate/generate"
+	"github.com/terramate-io/terramate/project"
+	"github.com/terramate-io/terramate/test/hclwrite"
+	. "github.com/terramate-io/terramate/test/hclwrite/hclutils"
+	"github.com/terramate-io/terramate/test/sandbox"
+)
+
+func TestGenerateRunEnv(t *testing.T) {
+	t.Parallel()
+
+	runEnv := func(builders ...hclwrite.BlockBuilder) *hclwrite.Block {
+		return Terramate(Config(Run(Env(builders...))))
+	}
+
+	testCodeGeneration(t, []testcase{
+		{
+			name: "run.env of the stack hierarchy",
+			layout: []string{
+				"s:stacks/stack-1",
+				"s:stacks/stack-2",
+			},
+			configs: []hclconfig{
+				{
+					path: "/",
+					add: Doc(
+						Globals(
+							Str("cache_dir", "/var/cache/terraform"),
+						),
+						runEnv(
+							Expr("TF_PLUGIN_CACHE_DIR", "global.cache_dir"),
+							Expr("STACK", "terramate.stack.name"),
+						),
+					),
+				},
+				{
+					path: "/stacks/stack-2",
+					add: runEnv(
+						Str("TF_PLUGIN_CACHE_DIR", "/tmp/cache"),
+					),
+				},
+				{
+					path: "/stacks",
+					add: Doc(
+						GenerateFile(
+							Labels("env.sh"),
+							Expr("content", `"export TF_PLUGIN_CACHE_DIR=${runenv.TF_PLUGIN_CACHE_DIR} STACK=${runenv.STACK}"`),
+						),
+						GenerateHCL(
+							Labels("env.hcl"),
+							Content(
+								Expr("env", "runenv"),
+							),
+						),
+					),
+				},
+			},
+			want: []generatedFile{
+				{
+					dir: "/stacks/stack-1",
+					files: map[string]fmt.Stringer{
+						"env.sh": stringer("export TF_PLUGIN_CACHE_DIR=/var/cache/terraform STACK=stack-1"),
+						"env.hcl": Doc(
+							Expr("env", `{
+								STACK               = "stack-1"
+								TF_PLUGIN_CACHE_DIR = "/var/cache/terraform"
+							}`),
+						),
+					},
+				},
+				{
+					dir: "/stacks/stack-2",
+					files: map[string]fmt.Stringer{
+						"env.sh": stringer("export TF_PLUGIN_CACHE_DIR=/tmp/cache STACK=stack-2"),
+						"env.hcl": Doc(
+							Expr("env", `{
+								STACK               = "stack-2"
+								TF_PLUGIN_CACHE_DIR = "/tmp/cache"
+							}`),
+						),
+					},
+				},
+			},
+			wantReport: generate.Report{
+				Successes: []generate.Result{
+					{
+						Dir:     project.NewPath("/stacks/stack-1"),
+						Created: []string{"env.hcl", "env.sh"},
+					},
+					{
+						Dir:     project.NewPath("/stacks/stack-2"),
+						Created: []string{"env.hcl", "env.sh"},
+					},
+				},
+			},
+		},
+		{
+			name: "run.env is only evaluated for the stacks referencing runenv",
+			layout: []string{
+				"s:stacks/stack-1",
+				"s:other/stack-2",
+			},
+			configs: []hclconfig{
+				{
+					path: "/",
+					add: runEnv(
+						Expr("TOKEN", "env.TM_TEST_RUNENV_UNDEFINED_VAR"),
+					),
+				},
+				{
+					path: "/stacks",
+					add: GenerateFile(
+						Labels("token.txt"),
+						Expr("content", "runenv.TOKEN"),
+					),
+				},
+				{
+					path: "/other",
+					add: GenerateFile(
+						Labels("file.txt"),
+						Str("content", "content"),
+					),
+				},
+			},
+			want: []generatedFile{
+				{
+					dir: "/other/stack-2",
+					files: map[string]fmt.Stringer{
+						"file.txt": stringer("content"),
+					},
+				},
+			},
+			wantReport: generate.Report{
+				Successes: []generate.Result{
+					{
+						Dir:     project.NewPath("/other/stack-2"),
+						Created: []string{"file.txt"},
+					},
+				},
+				Failures: []generate.FailureResult{
+					{
+						Result: generate.Result{
+							Dir: project.NewPath("/stacks/stack-1"),
+						},
+						Error: errors.E(generate.ErrRunEnv),
+					},
+				},
+			},
+		},
+	})
+}
+
+func TestGenerateRunEnvUnknownKey(t *testing.T) {
+	t.Parallel()
+
+	for _, genblock := range []string{
+		`generate_hcl "file.hcl" {
+		  content {
+		    cache_dir = runenv.TF_PLUGIN_CACHE_DIR
+		  }
+		}`,
+		`generate_file "file.txt" {
+		  content = "cache_dir=${runenv.TF_PLUGIN_CACHE_DIR}"
+		}`,
+	} {
+		s := sandbox.NoGit(t, true)
+		s.BuildTree([]string{
+			"s:stack",
+			`f:terramate.tm:terramate {
+			  config {
+			    run {
+			      env {
+			        TF_DATA_DIR = "/tmp/data"
+			      }
+			    }
+			  }
+			}`,
+			"f:stack/generate.tm:" + genblock,
+		})
+
+		report := generate.Do(s.Config(), project.NewPath("/"), 0, project.NewPath("/modules"), nil, true, generate.ContextAll)
+		assert.EqualInts(t, 1, len(report.Failures))
+		errmsg := report.Failures[0].Error.Error()
+		for _, want := range []string{"stack/generate.tm:", "TF_PLUGIN_CACHE_DIR"} {
+			if !strings.Contains(errmsg, want) {
+				t.Errorf("error %q does not contain %q", errmsg, want)
+			}
+		}
+	}
+}
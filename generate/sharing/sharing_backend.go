@@ -6,6 +6,7 @@ package sharing
 
 import (
 	stdfmt "fmt"
+	"os"
 
 	"github.com/terramate-io/hcl/v2/hclsyntax"
 	"github.com/terramate-io/hcl/v2/hclwrite"
@@ -83,6 +84,9 @@ func (f File) Asserts() []config.Assert {
 	return nil
 }
 
+// Mode returns zero as sharing_backend files have the default permissions.
+func (f File) Mode() os.FileMode { return 0 }
+
 // Header returns the header of the generated HCL file.
 func (f File) Header() string {
 	return genhcl.Header(f.magicCommentStyle)
@@ -5,8 +5,11 @@ package generate_test
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 	"testing"
 
+	"github.com/madlambda/spells/assert"
 	"github.com/terramate-io/terramate/event"
 	"github.com/terramate-io/terramate/generate"
 	"github.com/terramate-io/terramate/project"
@@ -190,6 +193,41 @@ func TestGenerateVendor(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "tm_vendor path is relative to the label of context=root blocks",
+			layout: []string{
+				"s:stack",
+			},
+			vendorDir: "/vendor",
+			configs: []hclconfig{
+				{
+					path: "/source",
+					add: Doc(
+						GenerateFile(
+							Labels("/docs/modules.txt"),
+							Expr("context", "root"),
+							Expr("content", `tm_vendor("github.com/terramate-io/terramate?ref=v1")`),
+						),
+					),
+				},
+			},
+			want: []generatedFile{
+				{
+					dir: "/docs",
+					files: map[string]fmt.Stringer{
+						"modules.txt": stringer("../vendor/github.com/terramate-io/terramate/v1"),
+					},
+				},
+			},
+			wantReport: generate.Report{
+				Successes: []generate.Result{
+					{
+						Dir:     project.NewPath("/docs"),
+						Created: []string{"modules.txt"},
+					},
+				},
+			},
+		},
 		{
 			name: "tm_vendor inside lets block",
 			layout: []string{
@@ -293,7 +331,7 @@ func TestGenerateVendorRequestEvents(t *testing.T) {
 
 	t.Log("generating code")
 
-	report := generate.Do(s.Config(), project.NewPath("/"), 0, vendorDir, events)
+	report := generate.Do(s.Config(), project.NewPath("/"), 0, vendorDir, events, true, generate.ContextAll)
 
 	t.Logf("generation report: %s", report.Full())
 
@@ -326,3 +364,54 @@ func TestGenerateVendorRequestEvents(t *testing.T) {
 
 	test.AssertEqualSets(t, gotEvents, wantEvents)
 }
+
+func TestLoadVendorRequests(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.NoGit(t, true)
+	s.BuildTree([]string{
+		"s:stacks/stack-1",
+		"s:stacks/stack-2",
+	})
+
+	s.RootEntry().CreateFile("config.tm", Doc(
+		GenerateHCL(
+			Labels("file.hcl"),
+			Content(
+				Expr("vendor", `tm_vendor("github.com/terramate-io/terramate?ref=v1")`),
+			),
+		),
+		GenerateFile(
+			Labels("file.txt"),
+			Expr("content", `tm_vendor("github.com/terramate-io/terramate?ref=v2")`),
+		),
+		GenerateFile(
+			Labels("/docs/modules.txt"),
+			Expr("context", "root"),
+			Expr("content", `tm_vendor("github.com/terramate-io/terramate?ref=v3")`),
+		),
+	).String())
+
+	vendorDir := project.NewPath("/vendor")
+	got, err := generate.LoadVendorRequests(s.Config(), vendorDir)
+	assert.NoError(t, err)
+
+	test.AssertDiff(t, got, []event.VendorRequest{
+		{
+			Source:    test.ParseSource(t, "github.com/terramate-io/terramate?ref=v2"),
+			VendorDir: vendorDir,
+		},
+		{
+			Source:    test.ParseSource(t, "github.com/terramate-io/terramate?ref=v1"),
+			VendorDir: vendorDir,
+		},
+		{
+			Source:    test.ParseSource(t, "github.com/terramate-io/terramate?ref=v3"),
+			VendorDir: vendorDir,
+		},
+	})
+
+	// loading vendor requests never generates code.
+	_, err = os.Stat(filepath.Join(s.RootDir(), "stacks", "stack-1", "file.hcl"))
+	assert.IsTrue(t, os.IsNotExist(err))
+}
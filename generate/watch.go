@@ -0,0 +1,165 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package generate
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/terramate-io/terramate/config"
+	"github.com/terramate-io/terramate/errors"
+	"github.com/terramate-io/terramate/fs"
+	"github.com/terramate-io/terramate/project"
+)
+
+// Watcher maps the files changed in the project to the stacks which need their
+// code generated again, reloading the affected configuration.
+type Watcher struct {
+	root      *config.Root
+	generated map[project.Path]struct{}
+
+	// reloaded and files are the reloaded directories and the changed files
+	// not yet consumed by a successful [Watcher.Affected].
+	reloaded project.Paths
+	files    project.Paths
+}
+
+// NewWatcher creates a watcher for the given configuration, which is
+// reloaded by [Watcher.Affected] when Terramate files change.
+func NewWatcher(root *config.Root) *Watcher {
+	w := &Watcher{
+		root:      root,
+		generated: map[project.Path]struct{}{},
+	}
+	root.OnReload(func(dirs project.Paths) {
+		w.reloaded = append(w.reloaded, dirs...)
+	})
+	return w
+}
+
+// Track records the files created, changed and deleted by the code generation
+// of the given report, so changes on them are ignored and do not trigger the
+// code generation again.
+func (w *Watcher) Track(report *Report) {
+	for _, res := range report.Successes {
+		for _, files := range [][]string{res.Created, res.Changed, res.Deleted} {
+			for _, file := range files {
+				w.generated[res.Dir.Join(file)] = struct{}{}
+			}
+		}
+	}
+}
+
+// Affected reloads the configuration affected by the changed host paths and
+// returns the stacks whose code must be generated again:
+//
+//   - A changed Terramate file, or a created or removed directory, reloads the
+//     configuration of its directory and of the directories importing it. All
+//     the stacks inside the reloaded directories are affected, since their
+//     globals and generate blocks may have changed.
+//   - Any other file affects the stack containing it and the stacks watching
+//     it with stack.watch.
+//
+// The boolean result tells if any change is relevant, even if no stack is
+// affected (eg.: a change on the root directory without stacks). The files
+// tracked with [Watcher.Track] and the paths outside the project are ignored.
+// If reloading the configuration of any path fails, then the error is returned
+// and the directories reloaded successfully and the changed files are kept, so
+// their stacks are also affected by the next call succeeding.
+func (w *Watcher) Affected(changed []string) (project.Paths, bool, error) {
+	rootdir := w.root.HostDir()
+
+	relevant := len(w.reloaded) > 0 || len(w.files) > 0
+	errs := errors.L()
+	for _, hostpath := range changed {
+		if hostpath != rootdir && !strings.HasPrefix(hostpath, rootdir+string(filepath.Separator)) {
+			continue
+		}
+		prjpath := project.PrjAbsPath(rootdir, hostpath)
+		if _, ok := w.generated[prjpath]; ok {
+			continue
+		}
+		relevant = true
+		if !isConfigPath(w.root, hostpath, prjpath) {
+			w.files = append(w.files, prjpath)
+			continue
+		}
+		errs.Append(w.root.ReloadPath(prjpath))
+	}
+
+	if err := errs.AsError(); err != nil {
+		return nil, relevant, err
+	}
+
+	files := w.files
+	selected := map[project.Path]struct{}{}
+	for _, file := range files {
+		if dir, ok := w.closestStack(file); ok {
+			selected[dir] = struct{}{}
+		}
+	}
+	for _, stack := range w.root.Tree().Stacks() {
+		if config.ScopeReloaded(w.reloaded, stack.Dir()) {
+			selected[stack.Dir()] = struct{}{}
+			continue
+		}
+		st, err := stack.Stack()
+		if err != nil {
+			return nil, relevant, err
+		}
+		if watchesAny(st.Watch, files) {
+			selected[stack.Dir()] = struct{}{}
+		}
+	}
+
+	affected := make(project.Paths, 0, len(selected))
+	for dir := range selected {
+		affected = append(affected, dir)
+	}
+	affected.Sort()
+	w.reloaded = nil
+	w.files = nil
+	return affected, relevant, nil
+}
+
+// closestStack returns the directory of the stack containing the file, if any.
+func (w *Watcher) closestStack(file project.Path) (project.Path, bool) {
+	dir := file.Dir()
+	for {
+		if node, ok := w.root.Lookup(dir); ok && node.IsStack() {
+			return dir, true
+		}
+		if dir.String() == "/" {
+			return project.Path{}, false
+		}
+		dir = dir.Dir()
+	}
+}
+
+func watchesAny(watch project.Paths, files project.Paths) bool {
+	for _, w := range watch {
+		for _, file := range files {
+			if w == file {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isConfigPath tells if the changed path is a Terramate configuration file or
+// a directory, existing or removed, of the configuration.
+func isConfigPath(root *config.Root, hostpath string, prjpath project.Path) bool {
+	name := path.Base(prjpath.String())
+	if fs.IsTerramateFile(name) || strings.HasSuffix(name, ".tmgen") {
+		return true
+	}
+	if _, found := root.Lookup(prjpath); found {
+		return true
+	}
+	st, err := os.Stat(hostpath)
+	return err == nil && st.IsDir()
+}
@@ -0,0 +1,195 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package generate_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/madlambda/spells/assert"
+	"github.com/terramate-io/terramate/config"
+	"github.com/terramate-io/terramate/generate"
+	"github.com/terramate-io/terramate/project"
+	"github.com/terramate-io/terramate/test"
+	"github.com/terramate-io/terramate/test/sandbox"
+)
+
+func TestWatcherAffectedStacks(t *testing.T) {
+	t.Parallel()
+
+	type testcase struct {
+		name     string
+		change   func(s sandbox.S)
+		changed  []string
+		want     []string
+		relevant bool
+		wantErr  bool
+	}
+
+	for _, tc := range []testcase{
+		{
+			name: "root globals affect all stacks",
+			change: func(s sandbox.S) {
+				s.RootEntry().CreateFile("globals.tm", `globals {
+  a = "changed"
+}`)
+			},
+			changed:  []string{"globals.tm"},
+			want:     []string{"/stacks/a", "/stacks/a/child", "/stacks/b"},
+			relevant: true,
+		},
+		{
+			name: "stack file affects the stack and its child stacks",
+			change: func(s sandbox.S) {
+				s.DirEntry("stacks/a").CreateFile("globals.tm", `globals {
+  a = "changed"
+}`)
+			},
+			changed:  []string{"stacks/a/globals.tm"},
+			want:     []string{"/stacks/a", "/stacks/a/child"},
+			relevant: true,
+		},
+		{
+			name: "imported file affects the importing stacks",
+			change: func(s sandbox.S) {
+				s.DirEntry("modules").CreateFile("globals.tm", `globals {
+  imported = "changed"
+}`)
+			},
+			changed:  []string{"modules/globals.tm"},
+			want:     []string{"/stacks/b"},
+			relevant: true,
+		},
+		{
+			name: "other file affects the closest stack",
+			change: func(s sandbox.S) {
+				s.DirEntry("stacks/a/child").CreateFile("main.tf", "# changed")
+			},
+			changed:  []string{"stacks/a/child/main.tf"},
+			want:     []string{"/stacks/a/child"},
+			relevant: true,
+		},
+		{
+			name: "watched file affects the watching stacks",
+			change: func(s sandbox.S) {
+				s.DirEntry("modules").CreateFile("data.txt", "changed")
+			},
+			changed:  []string{"modules/data.txt"},
+			want:     []string{"/stacks/a"},
+			relevant: true,
+		},
+		{
+			name: "file outside stacks affects no stack",
+			change: func(s sandbox.S) {
+				s.RootEntry().CreateFile("README.md", "changed")
+			},
+			changed:  []string{"README.md"},
+			want:     []string{},
+			relevant: true,
+		},
+		{
+			name: "generated files are ignored",
+			change: func(s sandbox.S) {
+				s.DirEntry("stacks/b").CreateFile("file.txt", "changed")
+			},
+			changed: []string{"stacks/b/file.txt"},
+		},
+		{
+			name: "invalid configuration fails",
+			change: func(s sandbox.S) {
+				s.DirEntry("stacks/b").CreateFile("globals.tm", "globals {")
+			},
+			changed:  []string{"stacks/b/globals.tm"},
+			relevant: true,
+			wantErr:  true,
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			s := sandbox.NoGit(t, true)
+			s.BuildTree([]string{
+				`s:stacks/a:watch=["/modules/data.txt"]`,
+				"s:stacks/a/child",
+				"s:stacks/b",
+				"f:modules/data.txt:data",
+				"f:modules/globals.tm:globals {\n  imported = true\n}\n",
+				"f:stacks/b/import.tm:import {\n  source = \"/modules/globals.tm\"\n}\n",
+				"f:stacks/b/gen.tm:generate_file \"file.txt\" {\n  content = \"data\"\n}\n",
+			})
+
+			root, err := config.LoadRoot(s.RootDir())
+			assert.NoError(t, err)
+
+			watcher := generate.NewWatcher(root)
+			report := generate.Do(root, project.NewPath("/"), 0, project.NewPath("/modules"), nil, false, generate.ContextAll)
+			assert.IsTrue(t, !report.HasFailures(), "unexpected failures: %s", report.Full())
+			watcher.Track(report)
+
+			tc.change(s)
+			changed := make([]string, len(tc.changed))
+			for i, path := range tc.changed {
+				changed[i] = filepath.Join(s.RootDir(), filepath.FromSlash(path))
+			}
+
+			got, relevant, err := watcher.Affected(changed)
+			assert.IsTrue(t, relevant == tc.relevant, "relevant: got %t but want %t", relevant, tc.relevant)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+
+			want := []string{}
+			if tc.want != nil {
+				want = tc.want
+			}
+			test.AssertDiff(t, got.Strings(), want)
+		})
+	}
+}
+
+func TestWatcherKeepsReloadedStacksOnPartialFailure(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.NoGit(t, true)
+	s.BuildTree([]string{
+		"s:stacks/a",
+		"s:stacks/b",
+		"s:stacks/c",
+	})
+
+	root, err := config.LoadRoot(s.RootDir())
+	assert.NoError(t, err)
+	watcher := generate.NewWatcher(root)
+
+	hostpath := func(path string) string {
+		return filepath.Join(s.RootDir(), filepath.FromSlash(path))
+	}
+
+	s.DirEntry("stacks/a").CreateFile("globals.tm", "globals {\n  a = 1\n}\n")
+	s.DirEntry("stacks/b").CreateFile("globals.tm", "globals {")
+	s.DirEntry("stacks/c").CreateFile("main.tf", "# changed")
+	_, relevant, err := watcher.Affected([]string{
+		hostpath("stacks/a/globals.tm"),
+		hostpath("stacks/b/globals.tm"),
+		hostpath("stacks/c/main.tf"),
+	})
+	assert.IsTrue(t, relevant, "change must be relevant")
+	assert.Error(t, err)
+
+	// the stacks reloaded and changed before the failure are still affected
+	// once the configuration is fixed.
+	s.DirEntry("stacks/b").CreateFile("globals.tm", "globals {\n  b = 1\n}\n")
+	got, relevant, err := watcher.Affected([]string{hostpath("stacks/b/globals.tm")})
+	assert.NoError(t, err)
+	assert.IsTrue(t, relevant, "change must be relevant")
+	test.AssertDiff(t, got.Strings(), []string{"/stacks/a", "/stacks/b", "/stacks/c"})
+
+	got, relevant, err = watcher.Affected(nil)
+	assert.NoError(t, err)
+	assert.IsTrue(t, !relevant, "no pending changes must be left")
+	test.AssertDiff(t, got.Strings(), []string{})
+}
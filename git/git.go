@@ -309,6 +309,58 @@ func (git *Git) Remotes() ([]Remote, error) {
 	return remotes, nil
 }
 
+// RemoteNames returns the names of the configured remotes, ordered
+// lexicographically. Unlike Remotes, it also returns the remotes which were
+// never fetched.
+func (git *Git) RemoteNames() ([]string, error) {
+	res, err := git.exec("remote")
+	if err != nil {
+		return nil, err
+	}
+	if res == "" {
+		return nil, nil
+	}
+	names := strings.Split(res, "\n")
+	sort.Strings(names)
+	return names, nil
+}
+
+// RemoteHEAD returns the branch the HEAD of the remote points to, as recorded
+// by `git clone` or `git remote set-head` in refs/remotes/<remote>/HEAD.
+func (git *Git) RemoteHEAD(remote string) (string, error) {
+	ref, err := git.exec("symbolic-ref", "--short", "refs/remotes/"+remote+"/HEAD")
+	if err != nil {
+		return "", err
+	}
+	branch := strings.TrimPrefix(ref, remote+"/")
+	if branch == ref || branch == "" {
+		return "", fmt.Errorf("unexpected HEAD reference %q of remote %q", ref, remote)
+	}
+	return branch, nil
+}
+
+// Upstream returns the remote and the branch tracked by the current branch.
+// An error is returned if HEAD is detached or the current branch has no
+// upstream in a remote repository.
+func (git *Git) Upstream() (remote string, branch string, err error) {
+	current, err := git.CurrentBranch()
+	if err != nil {
+		return "", "", err
+	}
+	remote, err = git.GetConfigValue("branch." + current + ".remote")
+	if err != nil {
+		return "", "", err
+	}
+	if remote == "." {
+		return "", "", fmt.Errorf("branch %q tracks a local branch", current)
+	}
+	merge, err := git.GetConfigValue("branch." + current + ".merge")
+	if err != nil {
+		return "", "", err
+	}
+	return remote, strings.TrimPrefix(merge, "refs/heads/"), nil
+}
+
 // LogSummary returns a list of commit log summary in reverse chronological
 // order from the revs set operation. It expects the same revision list as the
 // `git rev-list` command.
@@ -446,6 +498,34 @@ func (git *Git) FetchRemoteRev(remote, ref string) (Ref, error) {
 	}, nil
 }
 
+// ListRemoteTags lists the names of the tags of the remote repository.
+func (git *Git) ListRemoteTags(remote string) ([]string, error) {
+	output, err := git.exec("ls-remote", "--tags", "--refs", remote)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"Git.ListRemoteTags: git ls-remote --tags %q: %w",
+			remote,
+			err,
+		)
+	}
+	if output == "" {
+		return nil, nil
+	}
+	var tags []string
+	for _, line := range strings.Split(output, "\n") {
+		parsed := strings.Split(line, "\t")
+		if len(parsed) != 2 {
+			return nil, fmt.Errorf(
+				"Git.ListRemoteTags: git ls-remote --tags %q can't parse: %v",
+				remote,
+				line,
+			)
+		}
+		tags = append(tags, strings.TrimPrefix(parsed[1], "refs/tags/"))
+	}
+	return tags, nil
+}
+
 // MergeBase finds the common commit ancestor of commit1 and commit2.
 func (git *Git) MergeBase(commit1, commit2 string) (string, error) {
 	return git.exec("merge-base", commit1, commit2)
@@ -697,6 +777,12 @@ func (git *Git) ShowCommitMetadata(objectName string) (*CommitMetadata, error) {
 	}, nil
 }
 
+// FileCreationCommit returns the SHA of the commit which added the given
+// file. It returns an empty string if the file is not committed.
+func (git *Git) FileCreationCommit(path string) (string, error) {
+	return git.exec("log", "--diff-filter=A", "--format=%H", "-1", "--", path)
+}
+
 // Root returns the git root directory.
 func (git *Git) Root() (string, error) {
 	return git.exec("rev-parse", "--show-toplevel")
@@ -220,6 +220,36 @@ func TestFetchRemoteRevErrorHandling(t *testing.T) {
 	assert.Error(t, err, "unexpected result: %v", remoteRef)
 }
 
+func TestListRemoteTags(t *testing.T) {
+	t.Parallel()
+	repodir := mkOneCommitRepo(t)
+	git := test.NewGitWrapper(t, repodir, []string{})
+
+	remote, _ := addDefaultRemoteRev(t, git)
+
+	tags, err := git.ListRemoteTags(remote)
+	assert.NoError(t, err)
+	assert.EqualInts(t, 0, len(tags), "unexpected tags: %v", tags)
+
+	for _, tag := range []string{"v1.0.0", "v1.1.0"} {
+		_, err := git.Exec("tag", tag)
+		assert.NoError(t, err)
+	}
+	_, err = git.Exec("tag", "-a", "v2.0.0", "-m", "annotated tag")
+	assert.NoError(t, err)
+	_, err = git.Exec("push", remote, "--tags")
+	assert.NoError(t, err)
+
+	tags, err = git.ListRemoteTags(remote)
+	assert.NoError(t, err)
+	if diff := cmp.Diff(tags, []string{"v1.0.0", "v1.1.0", "v2.0.0"}); diff != "" {
+		t.Fatalf("unexpected tags (-got +want):\n%s", diff)
+	}
+
+	_, err = git.ListRemoteTags(filepath.Join(repodir, "non-existent"))
+	assert.Error(t, err)
+}
+
 func TestListingAvailableRemotes(t *testing.T) {
 	t.Parallel()
 	type testcase struct {
@@ -352,6 +382,67 @@ func TestListRemoteWithMultipleBranches(t *testing.T) {
 	assertEqualRemotes(t, got, want)
 }
 
+func TestRemoteNames(t *testing.T) {
+	t.Parallel()
+
+	repodir := mkOneCommitRepo(t)
+	g := test.NewGitWrapper(t, repodir, []string{})
+
+	got, err := g.RemoteNames()
+	assert.NoError(t, err)
+	assert.EqualInts(t, 0, len(got), "want no remotes: %v", got)
+
+	// remotes are listed even if they were never fetched.
+	assert.NoError(t, g.RemoteAdd("upstream", test.EmptyRepo(t, true)))
+	assert.NoError(t, g.RemoteAdd("fork", test.EmptyRepo(t, true)))
+
+	got, err = g.RemoteNames()
+	assert.NoError(t, err)
+	if diff := cmp.Diff(got, []string{"fork", "upstream"}); diff != "" {
+		t.Fatalf("unexpected remotes (-got +want):\n%s", diff)
+	}
+}
+
+func TestRemoteHEAD(t *testing.T) {
+	t.Parallel()
+
+	repodir := mkOneCommitRepo(t)
+	g := test.NewGitWrapper(t, repodir, []string{})
+	remote, _ := addDefaultRemoteRev(t, g)
+
+	_, err := g.RemoteHEAD(remote)
+	assert.Error(t, err, "remote HEAD is only set by clone or set-head")
+
+	assert.NoError(t, g.Checkout("master", true))
+	assert.NoError(t, g.Push(remote, "master"))
+	_, err = g.Exec("remote", "set-head", remote, "master")
+	assert.NoError(t, err)
+
+	branch, err := g.RemoteHEAD(remote)
+	assert.NoError(t, err)
+	assert.EqualStrings(t, "master", branch)
+}
+
+func TestUpstream(t *testing.T) {
+	t.Parallel()
+
+	repodir := mkOneCommitRepo(t)
+	g := test.NewGitWrapper(t, repodir, []string{})
+	assert.NoError(t, g.RemoteAdd("upstream", test.EmptyRepo(t, true)))
+	assert.NoError(t, g.Push("upstream", defaultBranch))
+
+	_, _, err := g.Upstream()
+	assert.Error(t, err, "branch has no upstream")
+
+	_, err = g.Exec("branch", "--set-upstream-to=upstream/"+defaultBranch)
+	assert.NoError(t, err)
+
+	remote, branch, err := g.Upstream()
+	assert.NoError(t, err)
+	assert.EqualStrings(t, "upstream", remote)
+	assert.EqualStrings(t, defaultBranch, branch)
+}
+
 func TestShowMetadata(t *testing.T) {
 	type testcase struct {
 		name        string
@@ -430,6 +521,33 @@ func TestShowMetadata(t *testing.T) {
 	}
 }
 
+func TestFileCreationCommit(t *testing.T) {
+	t.Parallel()
+
+	repodir := test.EmptyRepo(t, false)
+	gw := test.NewGitWrapper(t, repodir, []string{})
+
+	filename := test.WriteFile(t, repodir, "file.txt", "created")
+	assert.NoError(t, gw.Add(filename))
+	assert.NoError(t, gw.Commit("add file"))
+
+	created, err := gw.RevParse("HEAD")
+	assert.NoError(t, err)
+
+	test.WriteFile(t, repodir, "file.txt", "changed")
+	assert.NoError(t, gw.Add(filename))
+	assert.NoError(t, gw.Commit("change file"))
+
+	got, err := gw.FileCreationCommit("file.txt")
+	assert.NoError(t, err)
+	assert.EqualStrings(t, created, got)
+
+	test.WriteFile(t, repodir, "uncommitted.txt", "uncommitted")
+	got, err = gw.FileCreationCommit("uncommitted.txt")
+	assert.NoError(t, err)
+	assert.EqualStrings(t, "", got)
+}
+
 func TestGetConfigValue(t *testing.T) {
 	repodir := mkOneCommitRepo(t)
 
@@ -141,11 +141,29 @@ func ParseURL(rawURL string) (u *url.URL, err error) {
 }
 
 // RepoInfoFromURL returns the host, owner and repo name from a given URL.
+// The port is not part of the returned host.
+// Provider specific path components are removed, so the SSH and HTTPS remote
+// URLs of the same repository give the same information:
+//   - Bitbucket Server: https://host/scm/PROJ/repo.git -> PROJ, repo
+//   - Azure DevOps: git@ssh.dev.azure.com:v3/org/project/repo and
+//     https://dev.azure.com/org/project/_git/repo -> dev.azure.com, org, project/repo
 func RepoInfoFromURL(u *url.URL) (host string, owner string, name string, err error) {
 	if u.Hostname() == "" {
 		return "", "", "", errors.E("no hostname detected")
 	}
-	parts := strings.SplitN(strings.Trim(u.Path, "/"), "/", 2)
+	host = normalizeHostname(u)
+	path := strings.Trim(u.Path, "/")
+	switch {
+	case host == azureSSHHost:
+		host = azureHost
+		path = strings.TrimPrefix(path, "v3/")
+	case host == azureHost:
+		path = strings.Replace(path, "/_git/", "/", 1)
+	case strings.HasPrefix(path, "scm/") && strings.Count(path, "/") >= 2:
+		// Bitbucket Server HTTP(S) clone URLs have the /scm prefix.
+		path = strings.TrimPrefix(path, "scm/")
+	}
+	parts := strings.SplitN(path, "/", 2)
 	if len(parts) == 2 {
 		owner = parts[0]
 		name = parts[1]
@@ -153,13 +171,14 @@ func RepoInfoFromURL(u *url.URL) (host string, owner string, name string, err er
 		name = parts[0]
 	}
 	name = strings.TrimSuffix(name, ".git")
-	return normalizeHostname(u), owner, name, nil
+	return host, owner, name, nil
 }
 
+const (
+	azureHost    = "dev.azure.com"
+	azureSSHHost = "ssh.dev.azure.com"
+)
+
 func normalizeHostname(u *url.URL) string {
-	host := u.Hostname()
-	if p := u.Port(); p != "" && p != "80" && p != "443" {
-		host += ":" + p
-	}
-	return strings.ToLower(strings.TrimPrefix(host, "www."))
+	return strings.ToLower(strings.TrimPrefix(u.Hostname(), "www."))
 }
@@ -209,8 +209,8 @@ func TestNormalizeGitURL(t *testing.T) {
 			raw:  "git@github.com:8888/terramate-io/terramate.git",
 			normalized: git.Repository{
 				RawURL: "git@github.com:8888/terramate-io/terramate.git",
-				Repo:   "github.com:8888/terramate-io/terramate",
-				Host:   "github.com:8888",
+				Repo:   "github.com/terramate-io/terramate",
+				Host:   "github.com",
 				Owner:  "terramate-io",
 				Name:   "terramate",
 			},
@@ -237,6 +237,94 @@ func TestNormalizeGitURL(t *testing.T) {
 				Name:   "terramate",
 			},
 		},
+		{
+			name: "bitbucket server ssh url with port",
+			raw:  "ssh://git@bitbucket.company.com:7999/PROJ/repo.git",
+			normalized: git.Repository{
+				RawURL: "ssh://git@bitbucket.company.com:7999/PROJ/repo.git",
+				Repo:   "bitbucket.company.com/PROJ/repo",
+				Host:   "bitbucket.company.com",
+				Owner:  "PROJ",
+				Name:   "repo",
+			},
+		},
+		{
+			name: "bitbucket server ssh url without .git suffix",
+			raw:  "ssh://git@bitbucket.company.com:7999/PROJ/repo",
+			normalized: git.Repository{
+				RawURL: "ssh://git@bitbucket.company.com:7999/PROJ/repo",
+				Repo:   "bitbucket.company.com/PROJ/repo",
+				Host:   "bitbucket.company.com",
+				Owner:  "PROJ",
+				Name:   "repo",
+			},
+		},
+		{
+			name: "bitbucket server https url",
+			raw:  "https://bitbucket.company.com/scm/PROJ/repo.git",
+			normalized: git.Repository{
+				RawURL: "https://bitbucket.company.com/scm/PROJ/repo.git",
+				Repo:   "bitbucket.company.com/PROJ/repo",
+				Host:   "bitbucket.company.com",
+				Owner:  "PROJ",
+				Name:   "repo",
+			},
+		},
+		{
+			name: "bitbucket server https url with port and user",
+			raw:  "https://user@bitbucket.company.com:7990/scm/PROJ/repo.git",
+			normalized: git.Repository{
+				RawURL: "https://user@bitbucket.company.com:7990/scm/PROJ/repo.git",
+				Repo:   "bitbucket.company.com/PROJ/repo",
+				Host:   "bitbucket.company.com",
+				Owner:  "PROJ",
+				Name:   "repo",
+			},
+		},
+		{
+			name: "azure devops ssh url",
+			raw:  "git@ssh.dev.azure.com:v3/org/project/repo",
+			normalized: git.Repository{
+				RawURL: "git@ssh.dev.azure.com:v3/org/project/repo",
+				Repo:   "dev.azure.com/org/project/repo",
+				Host:   "dev.azure.com",
+				Owner:  "org",
+				Name:   "project/repo",
+			},
+		},
+		{
+			name: "azure devops ssh url with ssh:// prefix",
+			raw:  "ssh://git@ssh.dev.azure.com/v3/org/project/repo",
+			normalized: git.Repository{
+				RawURL: "ssh://git@ssh.dev.azure.com/v3/org/project/repo",
+				Repo:   "dev.azure.com/org/project/repo",
+				Host:   "dev.azure.com",
+				Owner:  "org",
+				Name:   "project/repo",
+			},
+		},
+		{
+			name: "azure devops https url",
+			raw:  "https://dev.azure.com/org/project/_git/repo",
+			normalized: git.Repository{
+				RawURL: "https://dev.azure.com/org/project/_git/repo",
+				Repo:   "dev.azure.com/org/project/repo",
+				Host:   "dev.azure.com",
+				Owner:  "org",
+				Name:   "project/repo",
+			},
+		},
+		{
+			name: "azure devops https url with user",
+			raw:  "https://org@dev.azure.com/org/project/_git/repo",
+			normalized: git.Repository{
+				RawURL: "https://org@dev.azure.com/org/project/_git/repo",
+				Repo:   "dev.azure.com/org/project/repo",
+				Host:   "dev.azure.com",
+				Owner:  "org",
+				Name:   "project/repo",
+			},
+		},
 	} {
 		tc := tc
 		t.Run(tc.name, func(t *testing.T) {
@@ -72,7 +72,8 @@ func (a GlobalPathKey) name() string {
 // loading globals and merging them appropriately.
 //
 // More specific globals (closer or at the current dir) have precedence over
-// less specific globals (closer or at the root dir).
+// less specific globals (closer or at the root dir), and the global overrides
+// of the root (see [config.Root.SetGlobalOverrides]) have precedence over all.
 func ForDir(root *config.Root, cfgdir project.Path, ctx *eval.Context) EvalReport {
 	tree, ok := root.Lookup(cfgdir)
 	if !ok {
@@ -86,6 +87,7 @@ func ForDir(root *config.Root, cfgdir project.Path, ctx *eval.Context) EvalRepor
 		return report
 	}
 
+	exprs.SetOverrides(cfgdir, root.GlobalOverrides(cfgdir))
 	return exprs.Eval(ctx)
 }
 
@@ -316,8 +318,7 @@ func (dirExprs HierarchicalExprs) Eval(ctx *eval.Context) EvalReport {
 					Strs("global", accessor.Path()).
 					Logger()
 
-				traversal, diags := hhcl.AbsTraversalForExpr(expr.Expression)
-				if !diags.HasErrors() && len(traversal) == 1 && traversal.RootName() == "unset" {
+				if isUnset(expr.Expression) {
 					if _, ok := globals.GetKeyPath(accessor.Path()); ok {
 						err := globals.DeleteAt(accessor.Path())
 						if err != nil {
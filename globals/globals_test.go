@@ -329,6 +329,59 @@ func TestLoadGlobals(t *testing.T) {
 				),
 			},
 		},
+		{
+			name: "stacks referencing parent and children metadata",
+			layout: []string{
+				"s:infra:id=infra",
+				"s:infra/b",
+				"s:infra/a",
+				"s:infra/a/nested",
+				"s:infra/dir/c",
+				"s:other",
+			},
+			configs: []hclconfig{
+				{
+					path: "/",
+					add: Globals(
+						Expr("parent_path", `terramate.stack.parent.path == null ? "top-level" : terramate.stack.parent.path`),
+						Expr("parent_id", `tm_try(terramate.stack.parent.id, "no-parent")`),
+						Expr("children", "terramate.stack.children"),
+					),
+				},
+			},
+			want: map[string]*hclwrite.Block{
+				"/infra": Globals(
+					Str("parent_path", "top-level"),
+					Str("parent_id", "no-parent"),
+					EvalExpr(t, "children", `tolist(["/infra/a", "/infra/b", "/infra/dir/c"])`),
+				),
+				"/infra/a": Globals(
+					Str("parent_path", "/infra"),
+					Str("parent_id", "infra"),
+					EvalExpr(t, "children", `tolist(["/infra/a/nested"])`),
+				),
+				"/infra/a/nested": Globals(
+					Str("parent_path", "/infra/a"),
+					Str("parent_id", ""),
+					EvalExpr(t, "children", "tolist([])"),
+				),
+				"/infra/b": Globals(
+					Str("parent_path", "/infra"),
+					Str("parent_id", "infra"),
+					EvalExpr(t, "children", "tolist([])"),
+				),
+				"/infra/dir/c": Globals(
+					Str("parent_path", "/infra"),
+					Str("parent_id", "infra"),
+					EvalExpr(t, "children", "tolist([])"),
+				),
+				"/other": Globals(
+					Str("parent_path", "top-level"),
+					Str("parent_id", "no-parent"),
+					EvalExpr(t, "children", "tolist([])"),
+				),
+			},
+		},
 		{
 			name: "stacks using functions and metadata",
 			layout: []string{
@@ -3931,6 +3984,29 @@ func TestLoadGlobals(t *testing.T) {
 				),
 			},
 		},
+		{
+			name:   "flush heredoc with strip markers",
+			layout: []string{"s:stack"},
+			configs: []hclconfig{
+				{
+					path: "/stack",
+					add: Globals(
+						Expr("list", `<<-EOT
+    # Items
+    %{ for item in ["a", "b"] ~}
+    - ${item}
+    %{ endfor ~}
+    done
+  EOT`),
+					),
+				},
+			},
+			want: map[string]*hclwrite.Block{
+				"/stack": Globals(
+					Str("list", "# Items\n- a\n- b\ndone\n"),
+				),
+			},
+		},
 	}
 
 	for _, tcase := range tcases {
@@ -0,0 +1,81 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package globals
+
+import (
+	"os"
+	"sort"
+
+	hhcl "github.com/terramate-io/hcl/v2"
+	"github.com/terramate-io/hcl/v2/hclsyntax"
+	"github.com/terramate-io/terramate/config"
+	"github.com/terramate-io/terramate/errors"
+	"github.com/terramate-io/terramate/hcl"
+	"github.com/terramate-io/terramate/hcl/info"
+	"github.com/terramate-io/terramate/project"
+)
+
+// ErrOverridesFile indicates that the globals overrides file is invalid.
+const ErrOverridesFile errors.Kind = "invalid globals file"
+
+// LoadOverridesFile loads the global overrides from the file at path, which
+// only has attribute assignments, like:
+//
+//	region = "eu-west-1"
+//	name   = "${global.prefix}-app"
+//
+// The expressions are evaluated as globals, so they can use functions and
+// reference other globals. The overrides apply to the scope directory and its
+// subdirectories.
+func LoadOverridesFile(rootdir string, scope project.Path, path string) ([]config.GlobalOverride, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.E(ErrOverridesFile, err, "reading %s", path)
+	}
+
+	file, diags := hclsyntax.ParseConfig(data, path, hhcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, errors.E(ErrOverridesFile, diags)
+	}
+	hcl.FixFlushHeredocs(file, data)
+
+	body := file.Body.(*hclsyntax.Body)
+	errs := errors.L()
+	for _, block := range body.Blocks {
+		errs.Append(errors.E(ErrOverridesFile, block.DefRange(),
+			"unexpected block %s, the globals file only supports attributes", block.Type))
+	}
+
+	// the parser already reports duplicated attributes.
+	attrs := make([]*hclsyntax.Attribute, 0, len(body.Attributes))
+	for _, attr := range body.Attributes {
+		attrs = append(attrs, attr)
+	}
+	sort.Slice(attrs, func(i, j int) bool {
+		return attrs[i].SrcRange.Start.Byte < attrs[j].SrcRange.Start.Byte
+	})
+
+	overrides := make([]config.GlobalOverride, 0, len(attrs))
+	for _, attr := range attrs {
+		overrides = append(overrides, config.GlobalOverride{
+			Scope:  scope,
+			Name:   attr.Name,
+			Expr:   attr.Expr,
+			Origin: info.NewRange(rootdir, attr.SrcRange),
+		})
+	}
+
+	if err := errs.AsError(); err != nil {
+		return nil, err
+	}
+	return overrides, nil
+}
+
+// SetOverrides sets the given overrides at the specified directory, in order,
+// so a later override of the same global wins. See [HierarchicalExprs.SetOverride].
+func (dirExprs HierarchicalExprs) SetOverrides(dir project.Path, overrides []config.GlobalOverride) {
+	for _, o := range overrides {
+		dirExprs.SetOverride(dir, NewGlobalAttrPath(o.Labels, o.Name), o.Expr, o.Origin)
+	}
+}
@@ -0,0 +1,151 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package globals_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/madlambda/spells/assert"
+	hhcl "github.com/terramate-io/hcl/v2"
+	"github.com/terramate-io/terramate/config"
+	"github.com/terramate-io/terramate/errors"
+	"github.com/terramate-io/terramate/globals"
+	"github.com/terramate-io/terramate/project"
+	errtest "github.com/terramate-io/terramate/test/errors"
+	. "github.com/terramate-io/terramate/test/hclutils"
+	"github.com/terramate-io/terramate/test/sandbox"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestLoadOverridesFile(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.NoGit(t, true)
+	s.BuildTree([]string{
+		`f:vars.tmvars.hcl:
+# overrides for CI
+region = "eu-west-1"
+name   = "${global.prefix}-${tm_upper(global.region)}"
+`,
+	})
+
+	path := filepath.Join(s.RootDir(), "vars.tmvars.hcl")
+	overrides, err := globals.LoadOverridesFile(s.RootDir(), project.NewPath("/stacks"), path)
+	assert.NoError(t, err)
+	assert.EqualInts(t, 2, len(overrides))
+
+	// the overrides keep the order of the file.
+	assert.EqualStrings(t, "region", overrides[0].Name)
+	assert.EqualStrings(t, "name", overrides[1].Name)
+	for _, o := range overrides {
+		assert.EqualStrings(t, "/stacks", o.Scope.String())
+		assert.EqualStrings(t, "/vars.tmvars.hcl", o.Origin.Path().String())
+	}
+}
+
+func TestLoadOverridesFileErrors(t *testing.T) {
+	t.Parallel()
+
+	type testcase struct {
+		name       string
+		content    string
+		start, end hhcl.Pos // the range of the error, if any
+	}
+
+	for _, tc := range []testcase{
+		{
+			name: "duplicated names",
+			content: `a = 1
+b = 2
+a = 3
+`,
+			start: Start(3, 1, 12),
+			end:   End(3, 2, 13),
+		},
+		{
+			name:    "blocks are not supported",
+			content: "globals {\n  a = 1\n}\n",
+			start:   Start(1, 1, 0),
+			end:     End(1, 8, 7),
+		},
+		{
+			name:    "invalid syntax",
+			content: "a = \n",
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			s := sandbox.NoGit(t, true)
+			s.RootEntry().CreateFile("vars.hcl", tc.content)
+
+			path := filepath.Join(s.RootDir(), "vars.hcl")
+			_, err := globals.LoadOverridesFile(s.RootDir(), project.NewPath("/"), path)
+			want := errors.E(globals.ErrOverridesFile)
+			if tc.start.Line != 0 {
+				want = errors.E(globals.ErrOverridesFile, Mkrange(path, tc.start, tc.end))
+			}
+			errtest.Assert(t, err, want)
+		})
+	}
+
+	t.Run("missing file", func(t *testing.T) {
+		t.Parallel()
+		rootdir := t.TempDir()
+		_, err := globals.LoadOverridesFile(rootdir, project.NewPath("/"), filepath.Join(rootdir, "missing.hcl"))
+		errtest.Assert(t, err, errors.E(globals.ErrOverridesFile))
+	})
+}
+
+func TestForStackWithRootOverrides(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.NoGit(t, true)
+	s.BuildTree([]string{
+		`f:globals.tm:
+globals {
+  prefix = "tm"
+  region = "us-east-1"
+  name   = "${global.prefix}-${global.region}"
+}
+`,
+		`s:stacks/a`,
+		`f:stacks/a/globals.tm:
+globals {
+  region = "sa-east-1"
+}
+`,
+		`s:other`,
+		`f:vars.hcl:
+region = "eu-west-1"
+prefix = tm_upper("ci")
+`,
+	})
+
+	root, err := config.LoadRoot(s.RootDir())
+	assert.NoError(t, err)
+
+	overrides, err := globals.LoadOverridesFile(s.RootDir(), project.NewPath("/stacks"),
+		filepath.Join(s.RootDir(), "vars.hcl"))
+	assert.NoError(t, err)
+	root.SetGlobalOverrides(overrides)
+
+	evalGlobal := func(stackdir, name string) cty.Value {
+		st, err := config.LoadStack(root, project.NewPath(stackdir))
+		assert.NoError(t, err)
+		report := globals.ForStack(root, st)
+		assert.NoError(t, report.AsError())
+		return report.Globals.AsValueMap()[name]
+	}
+
+	// the overrides have precedence over the globals of the stack.
+	assert.EqualStrings(t, "eu-west-1", evalGlobal("/stacks/a", "region").AsString())
+	assert.EqualStrings(t, "CI-eu-west-1", evalGlobal("/stacks/a", "name").AsString())
+
+	// the stacks outside of the scope are not affected.
+	assert.EqualStrings(t, "us-east-1", evalGlobal("/other", "region").AsString())
+	assert.EqualStrings(t, "tm-us-east-1", evalGlobal("/other", "name").AsString())
+}
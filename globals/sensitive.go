@@ -0,0 +1,230 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package globals
+
+import (
+	stderrors "errors"
+	"sort"
+	"strings"
+
+	"github.com/gobwas/glob"
+	hhcl "github.com/terramate-io/hcl/v2"
+	"github.com/terramate-io/terramate/config"
+	"github.com/terramate-io/terramate/errors"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// SensitiveValue is the placeholder printed in place of sensitive values.
+const SensitiveValue = "(sensitive)"
+
+// Sensitive matches global accessor paths against the patterns configured in
+// the `terramate.config.sensitive_globals` attribute. The patterns are globs
+// where the "*" wildcard does not cross the "." separator. A pattern matching
+// a global object makes all its nested values sensitive.
+type Sensitive struct {
+	patterns []glob.Glob
+}
+
+// NewSensitive creates a new sensitive globals matcher from the given patterns.
+func NewSensitive(patterns []string) (Sensitive, error) {
+	s := Sensitive{}
+	for _, pattern := range patterns {
+		g, err := glob.Compile(pattern, '.')
+		if err != nil {
+			return Sensitive{}, errors.E(err, "compiling sensitive global pattern %q", pattern)
+		}
+		s.patterns = append(s.patterns, g)
+	}
+	return s, nil
+}
+
+// LoadSensitive creates the sensitive globals matcher from the patterns of
+// the `terramate.config.sensitive_globals` attribute of the project.
+func LoadSensitive(root *config.Root) (Sensitive, error) {
+	cfg := root.Tree().Node
+	if cfg.Terramate == nil || cfg.Terramate.Config == nil {
+		return Sensitive{}, nil
+	}
+	return NewSensitive(cfg.Terramate.Config.SensitiveGlobals)
+}
+
+// IsEmpty tells if there are no sensitive globals patterns.
+func (s Sensitive) IsEmpty() bool { return len(s.patterns) == 0 }
+
+// Match tells if the global at the given accessor path is sensitive.
+func (s Sensitive) Match(path []string) bool {
+	for size := 1; size <= len(path); size++ {
+		name := strings.Join(path[:size], ".")
+		for _, g := range s.patterns {
+			if g.Match(name) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// MatchExpr tells if the expression references a sensitive global or a value
+// nested inside one.
+func (s Sensitive) MatchExpr(expr hhcl.Expression) bool {
+	if s.IsEmpty() {
+		return false
+	}
+	for _, traversal := range expr.Variables() {
+		if traversal.RootName() != "global" {
+			continue
+		}
+		var path []string
+		for _, step := range traversal[1:] {
+			switch attr := step.(type) {
+			case hhcl.TraverseAttr:
+				path = append(path, attr.Name)
+			case hhcl.TraverseIndex:
+				if attr.Key.Type() == cty.String {
+					path = append(path, attr.Key.AsString())
+				}
+			}
+		}
+		if s.Match(path) {
+			return true
+		}
+	}
+	return false
+}
+
+// Redact returns a copy of the globals values where all sensitive values are
+// replaced by [SensitiveValue].
+func (s Sensitive) Redact(globals map[string]cty.Value) map[string]cty.Value {
+	if s.IsEmpty() {
+		return globals
+	}
+	return s.redact(nil, globals)
+}
+
+func (s Sensitive) redact(basepath []string, values map[string]cty.Value) map[string]cty.Value {
+	res := make(map[string]cty.Value, len(values))
+	for name, val := range values {
+		path := append(append([]string{}, basepath...), name)
+		switch {
+		case s.Match(path):
+			res[name] = cty.StringVal(SensitiveValue)
+		case val.Type().IsObjectType() && val.IsKnown() && !val.IsNull():
+			res[name] = cty.ObjectVal(s.redact(path, val.AsValueMap()))
+		default:
+			res[name] = val
+		}
+	}
+	return res
+}
+
+// RedactString replaces all occurrences of sensitive global string values in
+// str by [SensitiveValue].
+func (s Sensitive) RedactString(globals map[string]cty.Value, str string) string {
+	secrets := s.secrets(nil, globals)
+	if len(secrets) == 0 {
+		return str
+	}
+	// replace longer secrets first, so a secret containing another is fully
+	// redacted.
+	sort.Slice(secrets, func(i, j int) bool {
+		return len(secrets[i]) > len(secrets[j])
+	})
+	oldnew := make([]string, 0, len(secrets)*2)
+	for _, secret := range secrets {
+		oldnew = append(oldnew, secret, SensitiveValue)
+	}
+	return strings.NewReplacer(oldnew...).Replace(str)
+}
+
+// RedactError returns a copy of err with all sensitive global string values
+// replaced by [SensitiveValue]. The kinds and ranges of the Terramate and HCL
+// errors are kept. Other errors having sensitive values in their message are
+// replaced by a plain error with the redacted message.
+func (s Sensitive) RedactError(globals map[string]cty.Value, err error) error {
+	if err == nil || s.IsEmpty() {
+		return err
+	}
+	return s.redactError(globals, err)
+}
+
+func (s Sensitive) redactError(globals map[string]cty.Value, err error) error {
+	switch e := err.(type) {
+	case nil:
+		return nil
+	case *errors.Error:
+		redacted := *e
+		redacted.Description = s.RedactString(globals, e.Description)
+		redacted.Err = s.redactError(globals, e.Err)
+		return &redacted
+	case *errors.List:
+		redacted := errors.L()
+		for _, err := range e.Errors() {
+			redacted.Append(s.redactError(globals, err))
+		}
+		return redacted
+	case hhcl.Diagnostics:
+		redacted := make(hhcl.Diagnostics, len(e))
+		for i, diag := range e {
+			redacted[i] = s.redactDiagnostic(globals, diag)
+		}
+		return redacted
+	case *hhcl.Diagnostic:
+		return s.redactDiagnostic(globals, e)
+	}
+	msg := err.Error()
+	if redacted := s.RedactString(globals, msg); redacted != msg {
+		return stderrors.New(redacted)
+	}
+	return err
+}
+
+func (s Sensitive) redactDiagnostic(globals map[string]cty.Value, diag *hhcl.Diagnostic) *hhcl.Diagnostic {
+	redacted := *diag
+	redacted.Summary = s.RedactString(globals, diag.Summary)
+	redacted.Detail = s.RedactString(globals, diag.Detail)
+	// the expression and its evaluation context may be used to describe the
+	// values involved in the error.
+	redacted.Expression = nil
+	redacted.EvalContext = nil
+	return &redacted
+}
+
+func (s Sensitive) secrets(basepath []string, values map[string]cty.Value) []string {
+	var res []string
+	for name, val := range values {
+		path := append(append([]string{}, basepath...), name)
+		if s.Match(path) {
+			res = append(res, stringsOf(val)...)
+			continue
+		}
+		if val.Type().IsObjectType() && val.IsKnown() && !val.IsNull() {
+			res = append(res, s.secrets(path, val.AsValueMap())...)
+		}
+	}
+	return res
+}
+
+// stringsOf returns all non-empty string values found inside val.
+func stringsOf(val cty.Value) []string {
+	val, _ = val.UnmarkDeep()
+	if !val.IsKnown() || val.IsNull() {
+		return nil
+	}
+	if val.Type() == cty.String {
+		if str := val.AsString(); str != "" {
+			return []string{str}
+		}
+		return nil
+	}
+	if !val.CanIterateElements() {
+		return nil
+	}
+	var res []string
+	it := val.ElementIterator()
+	for it.Next() {
+		_, elem := it.Element()
+		res = append(res, stringsOf(elem)...)
+	}
+	return res
+}
@@ -0,0 +1,61 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package globals_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/madlambda/spells/assert"
+	hhcl "github.com/terramate-io/hcl/v2"
+	"github.com/terramate-io/terramate/errors"
+	"github.com/terramate-io/terramate/globals"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestSensitiveRedactErrorKeepsKindAndRange(t *testing.T) {
+	t.Parallel()
+
+	sensitive, err := globals.NewSensitive([]string{"db_password"})
+	assert.NoError(t, err)
+	values := map[string]cty.Value{
+		"db_password": cty.StringVal("hunter2"),
+		"db_user":     cty.StringVal("admin"),
+	}
+
+	const kind errors.Kind = "some kind"
+	rng := hhcl.Range{
+		Filename: "globals.tm",
+		Start:    hhcl.Pos{Line: 1, Column: 1, Byte: 0},
+		End:      hhcl.Pos{Line: 1, Column: 10, Byte: 9},
+	}
+	diag := &hhcl.Diagnostic{
+		Severity: hhcl.DiagError,
+		Summary:  "Invalid function argument",
+		Detail:   `cannot convert "hunter2" to number`,
+		Subject:  &rng,
+	}
+	original := errors.L(
+		errors.E(kind, diag),
+		errors.E(kind, "user admin has password hunter2"),
+	)
+
+	redacted := sensitive.RedactError(values, original)
+	assert.IsTrue(t, errors.IsKind(redacted, kind), "kind is lost: %v", redacted)
+
+	errs := errors.L(redacted).Errors()
+	assert.EqualInts(t, 2, len(errs), "unexpected errors: %v", errs)
+	for _, err := range errs {
+		assert.IsTrue(t, !strings.Contains(err.Error(), "hunter2"), "secret leaked: %v", err)
+		assert.IsTrue(t, strings.Contains(err.Error(), globals.SensitiveValue), "not redacted: %v", err)
+		assert.IsTrue(t, errors.IsKind(err, kind), "kind is lost: %v", err)
+	}
+	var e *errors.Error
+	assert.IsTrue(t, errors.As(errs[0], &e))
+	assert.EqualStrings(t, rng.String(), e.FileRange.String())
+	assert.IsTrue(t, strings.Contains(errs[1].Error(), "admin"), "non-sensitive value redacted: %v", errs[1])
+
+	// the original error is not changed.
+	assert.IsTrue(t, strings.Contains(original.Detailed(), "hunter2"))
+}
@@ -0,0 +1,90 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package globals
+
+import (
+	"sort"
+	"strings"
+
+	hhcl "github.com/terramate-io/hcl/v2"
+	"github.com/terramate-io/terramate/hcl/eval"
+	"github.com/terramate-io/terramate/hcl/info"
+	"github.com/terramate-io/terramate/project"
+)
+
+// Definition is a global definition considered by the evaluation of the globals
+// of a directory.
+type Definition struct {
+	// Path of the defined global, e.g. ["a", "b"] for global.a.b.
+	Path eval.ObjectPath
+
+	// Origin is the range of the definition.
+	Origin info.Range
+
+	// ConfigDir is the directory which loaded the definition. It's not the
+	// directory of the origin file if the file is imported.
+	ConfigDir project.Path
+
+	// Unset tells if the definition unsets the global.
+	Unset bool
+}
+
+// Name returns the name of the global, e.g. global.a.b.
+func (d Definition) Name() string {
+	return "global." + strings.Join(d.Path, ".")
+}
+
+// Imported tells if the definition comes from a file imported by ConfigDir.
+func (d Definition) Imported() bool {
+	return d.Origin.Path().Dir() != d.ConfigDir
+}
+
+// Trace returns the definitions affecting the global at path: the ones of the
+// global itself, of the objects containing it and of the globals nested inside
+// it, including the ones unsetting them. The definitions are sorted from the
+// least to the most specific directory, which takes precedence.
+func (dirExprs HierarchicalExprs) Trace(path eval.ObjectPath) []Definition {
+	var defs []Definition
+	for _, exprSet := range dirExprs.sort() {
+		var dirDefs []Definition
+		for key, expr := range exprSet.expressions {
+			keyPath := key.Path()
+			if !isPrefixPath(keyPath, path) && !isPrefixPath(path, keyPath) {
+				continue
+			}
+			dirDefs = append(dirDefs, Definition{
+				Path:      keyPath,
+				Origin:    expr.Origin,
+				ConfigDir: exprSet.origin,
+				Unset:     isUnset(expr.Expression),
+			})
+		}
+		sort.Slice(dirDefs, func(i, j int) bool {
+			if len(dirDefs[i].Path) != len(dirDefs[j].Path) {
+				return len(dirDefs[i].Path) < len(dirDefs[j].Path)
+			}
+			return dirDefs[i].Origin.String() < dirDefs[j].Origin.String()
+		})
+		defs = append(defs, dirDefs...)
+	}
+	return defs
+}
+
+// isUnset tells if the expression is the unset keyword.
+func isUnset(expr hhcl.Expression) bool {
+	traversal, diags := hhcl.AbsTraversalForExpr(expr)
+	return !diags.HasErrors() && len(traversal) == 1 && traversal.RootName() == "unset"
+}
+
+func isPrefixPath(prefix, path eval.ObjectPath) bool {
+	if len(prefix) > len(path) {
+		return false
+	}
+	for i := range prefix {
+		if prefix[i] != path[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,94 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package ast
+
+import (
+	"os"
+
+	"github.com/terramate-io/hcl/v2"
+	"github.com/terramate-io/hcl/v2/hclwrite"
+	"github.com/terramate-io/terramate/errors"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// EditableFile is a HCL file edited in place. Only the edited attributes are
+// rewritten, all the other tokens of the file, including comments and blank
+// lines, are kept byte for byte.
+type EditableFile struct {
+	path string
+	mode os.FileMode
+	file *hclwrite.File
+}
+
+// ParseEditableFile parses the file at path for editing.
+func ParseEditableFile(path string) (*EditableFile, error) {
+	st, err := os.Lstat(path)
+	if err != nil {
+		return nil, errors.E(err, "stating %s", path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.E(err, "reading %s", path)
+	}
+	file, diags := hclwrite.ParseConfig(data, path, hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, errors.E(diags, "parsing %s", path)
+	}
+	return &EditableFile{
+		path: path,
+		mode: st.Mode(),
+		file: file,
+	}, nil
+}
+
+// HasBlock tells if the file has a top level block of the given type.
+func (f *EditableFile) HasBlock(blockType string) bool {
+	return f.file.Body().FirstMatchingBlock(blockType, nil) != nil
+}
+
+// SetBlockAttribute sets the attribute of the first top level block of the
+// given type. An existing attribute keeps its position and comments and only
+// its expression is replaced. A new attribute is added at the end of the block.
+func (f *EditableFile) SetBlockAttribute(blockType, name string, val cty.Value) error {
+	block := f.file.Body().FirstMatchingBlock(blockType, nil)
+	if block == nil {
+		return errors.E("%s block not found in %s", blockType, f.path)
+	}
+	block.Body().SetAttributeValue(name, val)
+	return nil
+}
+
+// AppendBlocks appends the top level blocks of the given HCL source at the end
+// of the file, separated from the existing content by a blank line.
+func (f *EditableFile) AppendBlocks(src []byte) error {
+	srcFile, diags := hclwrite.ParseConfig(src, f.path, hcl.InitialPos)
+	if diags.HasErrors() {
+		return errors.E(diags, "parsing blocks appended to %s", f.path)
+	}
+	body := f.file.Body()
+	content := f.file.Bytes()
+	if len(content) > 0 && content[len(content)-1] != '\n' {
+		body.AppendNewline()
+	}
+	for _, block := range srcFile.Body().Blocks() {
+		if len(f.file.Bytes()) > 0 {
+			body.AppendNewline()
+		}
+		body.AppendBlock(block)
+	}
+	return nil
+}
+
+// Bytes returns the content of the edited file.
+func (f *EditableFile) Bytes() []byte {
+	return f.file.Bytes()
+}
+
+// Save writes the edited file, keeping its permissions.
+func (f *EditableFile) Save() error {
+	if err := os.WriteFile(f.path, f.file.Bytes(), f.mode); err != nil {
+		return errors.E(err, "writing %s", f.path)
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package ast_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/madlambda/spells/assert"
+	"github.com/terramate-io/terramate/errors"
+	"github.com/terramate-io/terramate/hcl/ast"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestEditableFileSetBlockAttribute(t *testing.T) {
+	t.Parallel()
+
+	// each testcase edits testdata/edit/<name>.tm and compares the result
+	// with testdata/edit/<name>.golden.
+	for _, name := range []string{
+		"commented_with_id",
+		"commented_without_id",
+		"other_blocks_first",
+	} {
+		name := name
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			input := readTestdata(t, name+".tm")
+			want := readTestdata(t, name+".golden")
+
+			path := filepath.Join(t.TempDir(), "stack.tm")
+			assert.NoError(t, os.WriteFile(path, input, 0640))
+
+			file, err := ast.ParseEditableFile(path)
+			assert.NoError(t, err)
+			assert.IsTrue(t, file.HasBlock("stack"))
+			assert.NoError(t, file.SetBlockAttribute("stack", "id", cty.StringVal("new-id")))
+			assert.EqualStrings(t, string(want), string(file.Bytes()))
+
+			assert.NoError(t, file.Save())
+			got, err := os.ReadFile(path)
+			assert.NoError(t, err)
+			assert.EqualStrings(t, string(want), string(got))
+
+			st, err := os.Stat(path)
+			assert.NoError(t, err)
+			assert.EqualInts(t, 0640, int(st.Mode().Perm()))
+		})
+	}
+}
+
+func TestEditableFileMissingBlock(t *testing.T) {
+	t.Parallel()
+
+	const content = "# no stack here\nterramate {}\n"
+
+	path := filepath.Join(t.TempDir(), "file.tm")
+	assert.NoError(t, os.WriteFile(path, []byte(content), 0644))
+
+	file, err := ast.ParseEditableFile(path)
+	assert.NoError(t, err)
+	assert.IsTrue(t, !file.HasBlock("stack"))
+
+	err = file.SetBlockAttribute("stack", "id", cty.StringVal("id"))
+	assert.IsError(t, err, errors.E("stack block not found in %s", path))
+	assert.EqualStrings(t, content, string(file.Bytes()))
+}
+
+func TestEditableFileAppendBlocks(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{
+			name:    "empty file",
+			content: "",
+			want:    "stack {\n  name = \"stack\"\n}\n\nimport {\n  source = \"/file.tm\"\n}\n",
+		},
+		{
+			name:    "keeps comments",
+			content: "# config of the stack\nterramate {\n  # required version\n  required_version = \"> 0.1\"\n}\n",
+			want: "# config of the stack\nterramate {\n  # required version\n  required_version = \"> 0.1\"\n}\n" +
+				"\nstack {\n  name = \"stack\"\n}\n\nimport {\n  source = \"/file.tm\"\n}\n",
+		},
+		{
+			name:    "no trailing newline",
+			content: "globals {\n  a = 1\n}",
+			want: "globals {\n  a = 1\n}\n" +
+				"\nstack {\n  name = \"stack\"\n}\n\nimport {\n  source = \"/file.tm\"\n}\n",
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			path := filepath.Join(t.TempDir(), "terramate.tm.hcl")
+			assert.NoError(t, os.WriteFile(path, []byte(tc.content), 0644))
+
+			file, err := ast.ParseEditableFile(path)
+			assert.NoError(t, err)
+			assert.NoError(t, file.AppendBlocks([]byte("stack {\n  name = \"stack\"\n}\n\nimport {\n  source = \"/file.tm\"\n}\n")))
+			assert.EqualStrings(t, tc.want, string(file.Bytes()))
+		})
+	}
+}
+
+func TestEditableFileInvalidSyntax(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "file.tm")
+	assert.NoError(t, os.WriteFile(path, []byte("stack {"), 0644))
+
+	_, err := ast.ParseEditableFile(path)
+	assert.Error(t, err)
+}
+
+func readTestdata(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", "edit", name))
+	assert.NoError(t, err)
+	return data
+}
@@ -22,6 +22,16 @@ func ParseExpression(str string, filename string) (hcl.Expression, error) {
 	return expr, nil
 }
 
+// ParseTemplate parses the string as the content of an HCL template, like the
+// content of a heredoc, where quotes and backslashes are literal characters.
+func ParseTemplate(str string, filename string) (hcl.Expression, error) {
+	expr, diags := hclsyntax.ParseTemplate([]byte(str), filename, hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, errors.E(diags, "parsing template from bytes")
+	}
+	return expr, nil
+}
+
 // TokensForExpression generates valid tokens for the given expression.
 func TokensForExpression(expr hcl.Expression) hclwrite.Tokens {
 	tokens := tokensForExpression(expr)
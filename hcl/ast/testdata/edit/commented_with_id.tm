@@ -0,0 +1,27 @@
+// Copyright notice that must be kept.
+// SPDX-License-Identifier: MPL-2.0
+
+/*
+ * The stack of the networking layer.
+ */
+stack {
+  # human readable name
+  name = "network" // trailing comment
+
+  // the old id, replaced by the clone.
+  id = "old-id" # must be replaced
+
+  /* tags used by the CI */
+  tags = [
+    "network", # the layer
+    "prod",    // the environment
+  ]
+
+  # last comment of the block
+}
+
+# a comment between blocks
+
+globals {
+  region = "eu-west-1" // default region
+}
@@ -0,0 +1,9 @@
+# The stack has no id yet.
+stack {
+  // the name of the stack
+  name        = "app"
+  description = "the application" # short
+
+  # after the attributes
+}
+// trailing comment of the file
@@ -0,0 +1,21 @@
+// generated code comes first
+generate_hcl "main.tf" {
+  content {
+    # not a stack id
+    id = "generated"
+  }
+}
+
+terramate {
+  // required version
+  required_version = ">= 0.1.0"
+}
+
+stack {
+  id = "old-id" // the stack id
+}
+
+stack {
+  # only the first stack block is edited
+  id = "second"
+}
@@ -9,13 +9,16 @@ import (
 	"os"
 	"path/filepath"
 	"sort"
+	"strings"
 
+	"github.com/gobwas/glob"
 	"github.com/rs/zerolog/log"
 	"github.com/terramate-io/hcl/v2"
 	"github.com/terramate-io/hcl/v2/hclsyntax"
 	"github.com/terramate-io/hcl/v2/hclwrite"
 	"github.com/terramate-io/terramate/errors"
 	"github.com/terramate-io/terramate/fs"
+	"github.com/terramate-io/terramate/project"
 )
 
 // ErrHCLSyntax is the error kind for syntax errors.
@@ -24,9 +27,13 @@ const ErrHCLSyntax errors.Kind = "HCL syntax error"
 // ErrReadFile is the error kind for any error related to reading the file content.
 const ErrReadFile errors.Kind = "failed to read file"
 
+// ErrInvalidExclude is the error kind for invalid exclude patterns.
+const ErrInvalidExclude errors.Kind = "invalid exclude pattern"
+
 // FormatResult represents the result of a formatting operation.
 type FormatResult struct {
 	path      string
+	prjpath   project.Path
 	formatted string
 }
 
@@ -55,24 +62,62 @@ func Format(src, filename string) (string, error) {
 }
 
 // FormatTree will format all Terramate configuration files
-// in the given tree starting at the given dir. It will recursively
-// navigate on sub directories. Directories starting with "." are ignored.
+// in the given tree starting at the given dir of the project rooted at rootdir.
+// It will recursively navigate on sub directories. Directories starting with "."
+// are ignored.
+//
+// The excludes are glob patterns (eg.: "modules/**") matched against the
+// slash separated path of each directory and file relative to dir. Matching
+// directories are not visited and matching files are not formatted.
 //
 // Only Terramate configuration files will be formatted.
 //
 // Files that are already formatted are ignored. If all files are formatted
-// this function returns an empty result.
+// this function returns an empty result. The results are sorted by their
+// project path.
 //
 // All files will be left untouched. To save the formatted result on disk you
 // can use FormatResult.Save for each FormatResult.
-func FormatTree(dir string) ([]FormatResult, error) {
+func FormatTree(rootdir string, dir project.Path, excludes ...string) ([]FormatResult, error) {
+	patterns, err := compileExcludes(excludes)
+	if err != nil {
+		return nil, err
+	}
+	return formatTree(rootdir, dir, dir, patterns, true)
+}
+
+// FormatDir is like FormatTree but only formats the Terramate configuration
+// files directly inside dir, its sub directories are not visited.
+func FormatDir(rootdir string, dir project.Path, excludes ...string) ([]FormatResult, error) {
+	patterns, err := compileExcludes(excludes)
+	if err != nil {
+		return nil, err
+	}
+	return formatTree(rootdir, dir, dir, patterns, false)
+}
+
+func compileExcludes(excludes []string) ([]glob.Glob, error) {
+	patterns := make([]glob.Glob, 0, len(excludes))
+	for _, exclude := range excludes {
+		g, err := glob.Compile(exclude, '/')
+		if err != nil {
+			return nil, errors.E(ErrInvalidExclude, err, "pattern %q", exclude)
+		}
+		patterns = append(patterns, g)
+	}
+	return patterns, nil
+}
+
+func formatTree(rootdir string, basedir, dir project.Path, excludes []glob.Glob, recursive bool) ([]FormatResult, error) {
 	logger := log.With().
 		Str("action", "FormatTree").
-		Str("dir", dir).
+		Stringer("dir", dir).
 		Logger()
 
+	hostdir := dir.HostPath(rootdir)
+
 	// TODO(i4k): use files from the config tree.
-	res, err := fs.ListTerramateFiles(dir)
+	res, err := fs.ListTerramateFiles(hostdir)
 	if err != nil {
 		return nil, errors.E(errFormatTree, err)
 	}
@@ -83,34 +128,64 @@ func FormatTree(dir string) ([]FormatResult, error) {
 		}
 	}
 
-	files := append([]string{}, res.TmFiles...)
-	files = append(files, res.TmGenFiles...)
+	files := []string{}
+	for _, fname := range append(append([]string{}, res.TmFiles...), res.TmGenFiles...) {
+		if isExcluded(basedir, dir.Join(fname), excludes) {
+			logger.Debug().Str("file", fname).Msg("file excluded")
+			continue
+		}
+		files = append(files, fname)
+	}
 
 	sort.Strings(files)
 
 	errs := errors.L()
-	results, err := FormatFiles(dir, files)
+	results, err := FormatFiles(hostdir, files)
 
 	errs.Append(err)
 
-	for _, d := range res.Dirs {
-		subres, err := FormatTree(filepath.Join(dir, d))
-		if err != nil {
-			errs.Append(err)
-			continue
+	for i := range results {
+		results[i].prjpath = project.PrjAbsPath(rootdir, results[i].path)
+	}
+
+	if recursive {
+		for _, d := range res.Dirs {
+			subdir := dir.Join(d)
+			if isExcluded(basedir, subdir, excludes) {
+				logger.Debug().Str("subdir", d).Msg("directory excluded")
+				continue
+			}
+			subres, err := formatTree(rootdir, basedir, subdir, excludes, true)
+			if err != nil {
+				errs.Append(err)
+				continue
+			}
+			results = append(results, subres...)
 		}
-		results = append(results, subres...)
 	}
 
 	if err := errs.AsError(); err != nil {
 		return nil, err
 	}
 	sort.Slice(results, func(i, j int) bool {
-		return results[i].path < results[j].path
+		return results[i].prjpath.String() < results[j].prjpath.String()
 	})
 	return results, nil
 }
 
+func isExcluded(basedir, path project.Path, excludes []glob.Glob) bool {
+	if len(excludes) == 0 {
+		return false
+	}
+	relpath := strings.TrimPrefix(strings.TrimPrefix(path.String(), basedir.String()), "/")
+	for _, g := range excludes {
+		if g.Match(relpath) {
+			return true
+		}
+	}
+	return false
+}
+
 // FormatFiles will format all the provided Terramate paths.
 // Only Terramate configuration files can be reliably formatted with this function.
 // If HCL files for a different tool is provided, the result is unpredictable.
@@ -170,6 +245,12 @@ func (f FormatResult) Path() string {
 	return f.path
 }
 
+// PrjPath is the project path of the original file.
+// It is only set for the results of FormatTree and FormatDir.
+func (f FormatResult) PrjPath() project.Path {
+	return f.prjpath
+}
+
 // Formatted is the contents of the original file after formatting.
 func (f FormatResult) Formatted() string {
 	return f.formatted
@@ -454,7 +535,7 @@ func fmtExpr(tokens hclwrite.Tokens) (hclwrite.Tokens, int) {
 		case hclsyntax.TokenOParen:
 			addToken(token)
 			openParens++
-		case hclsyntax.TokenTemplateInterp:
+		case hclsyntax.TokenTemplateInterp, hclsyntax.TokenTemplateControl:
 			addToken(token)
 			openStrTemplate++
 		case hclsyntax.TokenTemplateSeqEnd:
@@ -14,6 +14,7 @@ import (
 	"github.com/terramate-io/terramate/errors"
 	"github.com/terramate-io/terramate/hcl"
 	"github.com/terramate-io/terramate/hcl/fmt"
+	"github.com/terramate-io/terramate/project"
 	"github.com/terramate-io/terramate/test"
 
 	. "github.com/terramate-io/terramate/test/hclutils"
@@ -306,6 +307,23 @@ seems like a list [1,2,3]
 EOT
   ,
 ]
+`,
+		},
+		{
+			name: "heredoc with template directives iterating objects",
+			input: `
+var = <<-EOT
+  %{ for k, v in { a = 1, b = 2 } ~}
+  ${k}=${v}
+  %{ endfor ~}
+EOT
+`,
+			want: `
+var = <<-EOT
+  %{for k, v in { a = 1, b = 2 } ~}
+  ${k}=${v}
+  %{endfor~}
+EOT
 `,
 		},
 		{
@@ -1392,7 +1410,7 @@ d = []
 				assertFileContains(t, r.Path(), r.Formatted())
 			}
 
-			got, err := fmt.FormatTree(rootdir)
+			got, err := fmt.FormatTree(rootdir, project.NewPath("/"))
 			assert.NoError(t, err)
 
 			if len(got) > 0 {
@@ -1419,7 +1437,7 @@ d = []
 		// for hcl.FormatTree behavior.
 		t.Run("Tree/"+tcase.name, func(t *testing.T) {
 			rootdir, files := sandbox(t)
-			got, err := fmt.FormatTree(rootdir)
+			got, err := fmt.FormatTree(rootdir, project.NewPath("/"))
 			checkResults(t, got, files, tcase, err)
 			if err == nil {
 				saveFiles(t, rootdir, got)
@@ -1441,7 +1459,7 @@ d = []
 
 func TestFormatTreeReturnsEmptyResultsForEmptyDir(t *testing.T) {
 	tmpdir := test.TempDir(t)
-	got, err := fmt.FormatTree(tmpdir)
+	got, err := fmt.FormatTree(tmpdir, project.NewPath("/"))
 	assert.NoError(t, err)
 	assert.EqualInts(t, 0, len(got), "want no results, got: %v", got)
 }
@@ -1454,7 +1472,7 @@ func TestFormatTreeFailsOnNonAccessibleSubdir(t *testing.T) {
 	test.AssertChmod(t, filepath.Join(tmpdir, subdir), 0)
 	defer test.AssertChmod(t, filepath.Join(tmpdir, subdir), 0755)
 
-	_, err := fmt.FormatTree(tmpdir)
+	_, err := fmt.FormatTree(tmpdir, project.NewPath("/"))
 	assert.Error(t, err)
 }
 
@@ -1470,13 +1488,13 @@ func TestFormatTreeFailsOnNonAccessibleFile(t *testing.T) {
 	test.AssertChmod(t, filepath.Join(tmpdir, filename), 0)
 	defer test.AssertChmod(t, filepath.Join(tmpdir, filename), 0755)
 
-	_, err := fmt.FormatTree(tmpdir)
+	_, err := fmt.FormatTree(tmpdir, project.NewPath("/"))
 	assert.Error(t, err)
 }
 
 func TestFormatTreeFailsOnNonExistentDir(t *testing.T) {
 	tmpdir := test.TempDir(t)
-	_, err := fmt.FormatTree(filepath.Join(tmpdir, "non-existent"))
+	_, err := fmt.FormatTree(tmpdir, project.NewPath("/non-existent"))
 	assert.Error(t, err)
 }
 
@@ -1502,7 +1520,7 @@ a = 1
 	test.WriteFile(t, subdir, "file.tm", unformattedCode)
 	test.WriteFile(t, subdir, "file.tm.hcl", unformattedCode)
 
-	got, err := fmt.FormatTree(tmpdir)
+	got, err := fmt.FormatTree(tmpdir, project.NewPath("/"))
 	assert.NoError(t, err)
 	assert.EqualInts(t, 0, len(got), "want no results, got: %v", got)
 }
@@ -1528,7 +1546,7 @@ a = 1
 		test.WriteFile(t, subdir, "file.tm", unformattedCode)
 		test.WriteFile(t, subdir, "file.tm.hcl", unformattedCode)
 
-		got, err := fmt.FormatTree(tmpdir)
+		got, err := fmt.FormatTree(tmpdir, project.NewPath("/"))
 		assert.NoError(t, err)
 		assert.EqualInts(t, 0, len(got), "want no results, got: %v", got)
 	}
@@ -1547,3 +1565,127 @@ func assertFileContains(t *testing.T, filepath, got string) {
 	want := string(data)
 	assert.EqualStrings(t, want, got, "file %q contents don't match", filepath)
 }
+
+func TestFormatTreeExcludes(t *testing.T) {
+	t.Parallel()
+
+	const unformattedCode = `
+a = 1
+ b = "la"
+`
+	tmpdir := test.TempDir(t)
+	test.WriteFile(t, tmpdir, "file.tm", unformattedCode)
+	test.WriteFile(t, filepath.Join(tmpdir, "modules"), "file.tm", unformattedCode)
+	test.WriteFile(t, filepath.Join(tmpdir, "modules", "nested"), "file.tm", unformattedCode)
+	test.WriteFile(t, filepath.Join(tmpdir, "stacks", "vendor"), "file.tm", unformattedCode)
+	test.WriteFile(t, filepath.Join(tmpdir, "stacks"), "skip.tm", unformattedCode)
+	test.WriteFile(t, filepath.Join(tmpdir, "stacks"), "file.tm", unformattedCode)
+
+	got, err := fmt.FormatTree(tmpdir, project.NewPath("/"), "modules", "**/vendor", "stacks/skip.tm")
+	assert.NoError(t, err)
+
+	var gotPaths []string
+	for _, res := range got {
+		relpath, err := filepath.Rel(tmpdir, res.Path())
+		assert.NoError(t, err)
+		gotPaths = append(gotPaths, filepath.ToSlash(relpath))
+	}
+	assert.EqualInts(t, 2, len(gotPaths), "unexpected results: %v", gotPaths)
+	assert.EqualStrings(t, "file.tm", gotPaths[0])
+	assert.EqualStrings(t, "stacks/file.tm", gotPaths[1])
+}
+
+func TestFormatTreeFailsOnInvalidExclude(t *testing.T) {
+	t.Parallel()
+
+	tmpdir := test.TempDir(t)
+	_, err := fmt.FormatTree(tmpdir, project.NewPath("/"), "[invalid")
+	errtest.Assert(t, err, errors.E(fmt.ErrInvalidExclude))
+}
+
+func TestFormatTreeNestedProjectPaths(t *testing.T) {
+	t.Parallel()
+
+	const unformattedCode = `
+a = 1
+ b = "la"
+`
+	tmpdir := test.TempDir(t)
+	test.WriteFile(t, tmpdir, "file.tm", unformattedCode)
+	test.WriteFile(t, filepath.Join(tmpdir, "stacks"), "file.tm", unformattedCode)
+	test.WriteFile(t, filepath.Join(tmpdir, "stacks", "a"), "file.tm.hcl", unformattedCode)
+	test.WriteFile(t, filepath.Join(tmpdir, "stacks", "a", "b"), "file.tm", unformattedCode)
+	test.WriteFile(t, filepath.Join(tmpdir, "stacks", "a", "vendor"), "file.tm", unformattedCode)
+
+	type testcase struct {
+		name     string
+		dir      string
+		excludes []string
+		dirOnly  bool
+		want     []string
+	}
+
+	for _, tc := range []testcase{
+		{
+			name: "tree from root",
+			dir:  "/",
+			want: []string{
+				"/file.tm",
+				"/stacks/a/b/file.tm",
+				"/stacks/a/file.tm.hcl",
+				"/stacks/a/vendor/file.tm",
+				"/stacks/file.tm",
+			},
+		},
+		{
+			name:     "tree from subdir with excludes",
+			dir:      "/stacks/a",
+			excludes: []string{"vendor"},
+			want: []string{
+				"/stacks/a/b/file.tm",
+				"/stacks/a/file.tm.hcl",
+			},
+		},
+		{
+			name:    "dir from root",
+			dir:     "/",
+			dirOnly: true,
+			want:    []string{"/file.tm"},
+		},
+		{
+			name:    "dir from subdir",
+			dir:     "/stacks/a",
+			dirOnly: true,
+			want:    []string{"/stacks/a/file.tm.hcl"},
+		},
+		{
+			name:     "dir from subdir with excluded file",
+			dir:      "/stacks/a",
+			excludes: []string{"*.tm.hcl"},
+			dirOnly:  true,
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			format := fmt.FormatTree
+			if tc.dirOnly {
+				format = fmt.FormatDir
+			}
+			got, err := format(tmpdir, project.NewPath(tc.dir), tc.excludes...)
+			assert.NoError(t, err)
+
+			gotPaths := []string{}
+			for _, res := range got {
+				assert.EqualStrings(t, project.AbsPath(tmpdir, res.PrjPath().String()), res.Path())
+				gotPaths = append(gotPaths, res.PrjPath().String())
+			}
+			if tc.want == nil {
+				tc.want = []string{}
+			}
+			if diff := cmp.Diff(tc.want, gotPaths); diff != "" {
+				t.Fatalf("unexpected results: %s", diff)
+			}
+		})
+	}
+}
@@ -4,8 +4,11 @@
 package hcl_test
 
 import (
+	"fmt"
+	"strings"
 	"testing"
 
+	"github.com/terramate-io/terramate/errors"
 	"github.com/terramate-io/terramate/hcl"
 
 	. "github.com/terramate-io/terramate/test/hclutils"
@@ -111,3 +114,101 @@ func TestHCLParserGenerateBlocksRange(t *testing.T) {
 		testParser(t, tcase)
 	}
 }
+
+func TestHCLParserGenerateFileMode(t *testing.T) {
+	t.Parallel()
+
+	genfile := func(mode string) string {
+		return fmt.Sprintf("generate_file \"run.sh\" {\n  content = \"echo\"\n  mode    = %s\n}\n", mode)
+	}
+
+	tcases := []testcase{
+		{
+			name: "octal string with leading zero",
+			input: []cfgfile{
+				{filename: "gen.tm", body: genfile(`"0755"`)},
+			},
+			want: want{
+				config: hcl.Config{
+					Generate: hcl.GenerateConfig{
+						Files: []hcl.GenFileBlock{
+							{
+								Label: "run.sh",
+								Range: Range("gen.tm", Start(1, 1, 0), End(4, 2, 64)),
+								Mode:  0755,
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "octal string without leading zero",
+			input: []cfgfile{
+				{filename: "gen.tm", body: genfile(`"750"`)},
+			},
+			want: want{
+				config: hcl.Config{
+					Generate: hcl.GenerateConfig{
+						Files: []hcl.GenFileBlock{
+							{
+								Label: "run.sh",
+								Range: Range("gen.tm", Start(1, 1, 0), End(4, 2, 63)),
+								Mode:  0750,
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	for _, invalid := range []string{`"0999"`, `"rwxr-xr-x"`, `"01777"`, `"0000"`, `"7"`, `755`, `global.mode`} {
+		tcases = append(tcases, testcase{
+			name: "invalid mode " + invalid,
+			input: []cfgfile{
+				{filename: "gen.tm", body: genfile(invalid)},
+			},
+			want: want{
+				errs: []error{
+					errors.E(hcl.ErrTerramateSchema,
+						Mkrange("gen.tm", Start(1, 1, 0), End(4, 2, len(genfile(invalid))-1))),
+				},
+			},
+		})
+	}
+
+	for _, tcase := range tcases {
+		testParser(t, tcase)
+	}
+}
+
+func TestHCLParserGenerateLetsRedeclared(t *testing.T) {
+	t.Parallel()
+
+	for _, block := range []string{
+		"generate_file \"file.txt\" {\n" +
+			"  lets {\n    a = 1\n  }\n" +
+			"  lets {\n    a = 2\n  }\n" +
+			"  content = \"x\"\n}\n",
+		"generate_hcl \"file.hcl\" {\n" +
+			"  lets {\n    a = 1\n  }\n" +
+			"  lets {\n    a = 2\n  }\n" +
+			"  content {}\n}\n",
+	} {
+		// the second declaration of the let is at line 6 of both blocks.
+		offset := strings.Index(block, "    a = 2") + 4
+		testParser(t, testcase{
+			name: "redeclared let in " + block[:strings.Index(block, " ")],
+			input: []cfgfile{
+				{filename: "gen.tm", body: block},
+			},
+			want: want{
+				errs: []error{
+					errors.E(hcl.ErrTerramateSchema,
+						Mkrange("gen.tm", Start(6, 5, offset), End(6, 6, offset+1))),
+				},
+			},
+		})
+	}
+}
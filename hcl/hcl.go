@@ -5,11 +5,15 @@ package hcl
 
 import (
 	"fmt"
+	"math/big"
 	"os"
 	"path"
 	"path/filepath"
+	"regexp"
 	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gobwas/glob"
 	"github.com/rs/zerolog/log"
@@ -21,6 +25,7 @@ import (
 	"github.com/terramate-io/terramate/hcl/ast"
 	"github.com/terramate-io/terramate/hcl/eval"
 	"github.com/terramate-io/terramate/hcl/info"
+	"github.com/terramate-io/terramate/hcl/parsecache"
 	"github.com/terramate-io/terramate/project"
 	"github.com/terramate-io/terramate/safeguard"
 	"github.com/terramate-io/terramate/stdlib"
@@ -76,6 +81,7 @@ type Config struct {
 	Globals         ast.MergedLabelBlocks
 	Vendor          *VendorConfig
 	Asserts         []AssertConfig
+	Checks          []CheckConfig
 	Generate        GenerateConfig
 	Scripts         []*Script
 	SharingBackends SharingBackends
@@ -107,6 +113,17 @@ type AssertConfig struct {
 type StackFilterConfig struct {
 	ProjectPaths    []glob.Glob
 	RepositoryPaths []glob.Glob
+	Depth           *StackFilterDepth
+	IsLeaf          *bool
+}
+
+// StackFilterDepth represents the depth attribute of a stack_filter block,
+// which constrains the number of path segments of the stack path. For example,
+// the stack /stacks/prod has depth 2.
+type StackFilterDepth struct {
+	// Op is one of "=", "!=", ">", ">=", "<" or "<=".
+	Op    string
+	Value int
 }
 
 // SharingBackendType is the type of the sharing backend.
@@ -173,13 +190,125 @@ func MatchAnyGlob(globs []glob.Glob, s string) bool {
 	return false
 }
 
+// MatchStackFilters tells if the stack at the given path matches any of the
+// stack_filter blocks. All attributes of a block must match, and it always
+// matches when there are no stack_filter blocks. The isLeaf tells if the stack
+// has no child stacks, which is matched by the is_leaf attribute.
+//
+// The filters are always matched against the stack which the code is generated
+// for, independent of the directory where the block is defined.
+func MatchStackFilters(filters []StackFilterConfig, stackdir project.Path, isLeaf bool) bool {
+	if len(filters) == 0 {
+		return true
+	}
+	for _, cond := range filters {
+		if cond.match(stackdir, isLeaf) {
+			return true
+		}
+	}
+	return false
+}
+
+func (cond StackFilterConfig) match(stackdir project.Path, isLeaf bool) bool {
+	for n, globs := range map[string][]glob.Glob{
+		"project path":    cond.ProjectPaths,
+		"repository path": cond.RepositoryPaths,
+	} {
+		if globs != nil && !MatchAnyGlob(globs, stackdir.String()) {
+			log.Logger.Trace().Msgf("Skipping %q, %s doesn't match any filter in %v", stackdir, n, globs)
+			return false
+		}
+	}
+	if cond.Depth != nil && !cond.Depth.Match(pathDepth(stackdir)) {
+		log.Logger.Trace().Msgf("Skipping %q, depth doesn't match %s", stackdir, cond.Depth)
+		return false
+	}
+	if cond.IsLeaf != nil && *cond.IsLeaf != isLeaf {
+		log.Logger.Trace().Msgf("Skipping %q, is_leaf is not %t", stackdir, *cond.IsLeaf)
+		return false
+	}
+	return true
+}
+
+// Match tells if the given depth satisfies the constraint.
+func (d StackFilterDepth) Match(depth int) bool {
+	switch d.Op {
+	case "!=":
+		return depth != d.Value
+	case ">":
+		return depth > d.Value
+	case ">=":
+		return depth >= d.Value
+	case "<":
+		return depth < d.Value
+	case "<=":
+		return depth <= d.Value
+	default:
+		return depth == d.Value
+	}
+}
+
+// String returns the constraint as written in the depth attribute.
+func (d StackFilterDepth) String() string {
+	return fmt.Sprintf("%s %d", d.Op, d.Value)
+}
+
+// pathDepth returns the number of segments of the path, which is 0 for the
+// project root.
+func pathDepth(p project.Path) int {
+	if p.String() == "/" {
+		return 0
+	}
+	return strings.Count(p.String(), "/")
+}
+
 // RunConfig represents Terramate run configuration.
 type RunConfig struct {
 	// CheckGenCode enables generated code is up-to-date check on run.
 	CheckGenCode bool
 
+	// CheckTerraformVersion enables the check of the terraform (or tofu)
+	// version against the required_version of the stacks on run.
+	CheckTerraformVersion bool
+
+	// IsolateDataDir enables a per-stack TF_DATA_DIR on run.
+	IsolateDataDir bool
+
+	// CheckOrdering tells how invalid stack ordering entries are reported.
+	// It's one of the RunCheckOrdering* values or empty for the default.
+	CheckOrdering string
+
+	// Locking enables the per-stack advisory lock on run.
+	Locking bool
+
+	// LockTTL is the age after which a stack lock is considered stale.
+	// It's zero if not set, meaning the default TTL.
+	LockTTL time.Duration
+
 	// Env contains environment definitions for run.
 	Env *RunEnv
+
+	// StackDefaults contains the run defaults of the stacks in the directory
+	// and its subdirectories.
+	StackDefaults *RunStackDefaults
+}
+
+// Supported values for the `terramate.config.run.check_ordering` attribute.
+const (
+	// RunCheckOrderingWarn reports invalid stack ordering entries as warnings (default).
+	RunCheckOrderingWarn = "warn"
+	// RunCheckOrderingError reports invalid stack ordering entries as errors.
+	RunCheckOrderingError = "error"
+)
+
+// RunStackDefaults represents the terramate.config.run.stack_defaults block.
+// The attributes not set are nil.
+type RunStackDefaults struct {
+	// Timeout is the default maximum duration of commands run in the stacks.
+	Timeout *time.Duration
+
+	// Priority is the default run order priority of the stacks.
+	Priority *int
 }
 
 // RunEnv represents Terramate run environment.
@@ -204,12 +333,20 @@ type GitConfig struct {
 
 	// CheckRemote enables checking if local default branch is updated with remote.
 	CheckRemote OptionalCheck
+
+	// BranchProtection enables checking if deployments are synchronized to
+	// Terramate Cloud only from the default branch.
+	BranchProtection bool
 }
 
 // ChangeDetectionConfig is the `terramate.config.change_detection` config.
 type ChangeDetectionConfig struct {
 	Terragrunt *TerragruntChangeDetectionConfig
 	Git        *GitChangeDetectionConfig
+
+	// PropagateOutputs tells if the stacks with inputs from a changed stack
+	// must also be considered changed. It requires the outputs-sharing experiment.
+	PropagateOutputs bool
 }
 
 // GitChangeDetectionConfig is the `terramate.config.change_detection.git` config.
@@ -236,16 +373,90 @@ const (
 // GenerateRootConfig represents the AST node for the `terramate.config.generate` block.
 type GenerateRootConfig struct {
 	HCLMagicHeaderCommentStyle *string
+	Dedup                      *string
+
+	// AllowDeletion enables the deletion of generated files whose blocks
+	// were removed or have condition = false.
+	AllowDeletion bool
+
+	// Header is the extra header injected into the generated files.
+	Header *GenerateHeaderConfig
 }
 
+// GenerateHeaderConfig represents the `terramate.config.generate.header` block.
+type GenerateHeaderConfig struct {
+	// License is the expression of the license header. It's evaluated in the
+	// context of each stack and must be a string.
+	License *ast.Attribute
+
+	// Position is where the header is placed in generated files. It's one of
+	// the GenerateHeaderPosition* values.
+	Position string
+}
+
+// Supported values for the `terramate.config.generate.header.position` attribute.
+const (
+	// GenerateHeaderPositionTop places the header at the top of the file (default).
+	GenerateHeaderPositionTop = "top"
+	// GenerateHeaderPositionAfterShebang places the header after the shebang
+	// line, if the file starts with one.
+	GenerateHeaderPositionAfterShebang = "after-shebang"
+)
+
+// Supported values for the `terramate.config.generate.dedup` attribute.
+const (
+	// GenerateDedupCopy writes the generated files in each stack (default).
+	GenerateDedupCopy = "copy"
+	// GenerateDedupHardlink stores identical generated files once and
+	// hardlinks them into each stack.
+	GenerateDedupHardlink = "hardlink"
+)
+
 // CloudConfig represents Terramate cloud configuration.
 type CloudConfig struct {
 	// Organization is the name of the cloud organization
 	Organization string
 
+	// Retries is the number of times an idempotent request to the cloud API
+	// is retried after a transient failure. If nil, the client default is used.
+	Retries *int
+
 	Targets *TargetsConfig
+
+	// Metadata is the custom metadata synchronized with the stacks.
+	Metadata *CloudMetadata
+
+	// Logs configures the synchronization of command logs.
+	Logs *CloudLogsConfig
 }
 
+// CloudLogsConfig represents the `terramate.config.cloud.logs` block.
+type CloudLogsConfig struct {
+	// Sync tells which stacks have their (bounded) command output uploaded
+	// after the execution of a deployment. It's one of the CloudLogsSync*
+	// constants or empty if not set.
+	Sync string
+
+	// Redact is the list of regular expressions matching the lines of the
+	// command output which must not be synchronized.
+	Redact []string
+}
+
+// CloudMetadata represents the `terramate.config.cloud.metadata` block.
+type CloudMetadata struct {
+	// Attributes is the collection of attribute definitions within the metadata block.
+	Attributes ast.Attributes
+}
+
+// Valid values for terramate.config.cloud.logs.sync.
+const (
+	// CloudLogsSyncFailed uploads the logs of failed stacks only.
+	CloudLogsSyncFailed = "failed"
+
+	// CloudLogsSyncAll uploads the logs of all stacks.
+	CloudLogsSyncAll = "all"
+)
+
 // TargetsConfig represents Terramate targets configuration.
 type TargetsConfig struct {
 	Enabled bool
@@ -256,6 +467,18 @@ type TelemetryConfig struct {
 	Enabled *bool
 }
 
+// CheckpointConfig represents Terramate checkpoint configuration, which
+// controls the check for new versions.
+type CheckpointConfig struct {
+	Enabled *bool
+}
+
+// StackRootConfig represents the `terramate.config.stack` block.
+type StackRootConfig struct {
+	// Filename is the name of the file where new stack blocks are written.
+	Filename string
+}
+
 // RootConfig represents the root config block of a Terramate configuration.
 type RootConfig struct {
 	Git               *GitConfig
@@ -266,6 +489,16 @@ type RootConfig struct {
 	Experiments       []string
 	DisableSafeguards safeguard.Keywords
 	Telemetry         *TelemetryConfig
+	Checkpoint        *CheckpointConfig
+	Stack             *StackRootConfig
+
+	// SensitiveGlobals is a list of glob patterns matching global paths
+	// (eg.: "db_password", "api.*") whose values must be redacted in output.
+	SensitiveGlobals []string
+
+	// NormalizePathCase tells if stack references differing only by case
+	// from a project directory are normalized, instead of being an error.
+	NormalizePathCase bool
 }
 
 // ManifestDesc represents a parsed manifest description.
@@ -335,6 +568,16 @@ type Stack struct {
 
 	// Watch is a list of files to be watched for changes.
 	Watch []string
+
+	// IgnoreOnChange is a list of glob patterns, relative to the stack
+	// directory, of the files whose changes do not mark the stack as changed.
+	IgnoreOnChange []string
+
+	// Timeout is the maximum duration of commands run in the stack, if set.
+	Timeout *time.Duration
+
+	// Priority is the run order priority of the stack, if set.
+	Priority *int
 }
 
 // GenHCLBlock represents a parsed generate_hcl block.
@@ -389,6 +632,9 @@ type GenFileBlock struct {
 
 	// Inherit tells if the block is inherited in child directories.
 	Inherit *hclsyntax.Attribute
+
+	// Mode is the permission mode of the generated file, if set.
+	Mode os.FileMode
 }
 
 // Evaluator represents a Terramate evaluator
@@ -424,13 +670,22 @@ type TerramateParser struct {
 	files     map[string][]byte // path=content
 	hclparser *hclparse.Parser
 	evalctx   *eval.Context
+	cache     *parsecache.Cache
 
 	// parsedFiles stores a map of all parsed files
 	parsedFiles map[string]parsedFile
 
+	// importedFiles are the files imported by the parsed configuration,
+	// including the ones imported by other imported files.
+	importedFiles []string
+
 	strict bool
 	// if true, calling Parse() or MinimalParse() will fail.
 	parsed bool
+
+	// if true, the warnings are kept instead of logged.
+	collectWarnings bool
+	warnings        []error
 }
 
 // NewGitConfig creates a git configuration with proper default values.
@@ -492,6 +747,7 @@ func NewTerramateParser(rootdir string, dir string, experiments ...string) (*Ter
 		hclparser:   hclparse.NewParser(),
 		parsedFiles: make(map[string]parsedFile),
 		evalctx:     eval.NewContext(stdlib.Functions(dir, experiments)),
+		cache:       newParseCache(rootdir),
 	}, nil
 }
 
@@ -506,6 +762,19 @@ func NewStrictTerramateParser(rootdir string, dir string, experiments ...string)
 	return parser, nil
 }
 
+// CollectWarnings makes the parser keep the warnings about harmless
+// configuration mistakes, so they can be retrieved with Warnings, instead of
+// logging them.
+func (p *TerramateParser) CollectWarnings() {
+	p.collectWarnings = true
+}
+
+// Warnings returns the warnings found when parsing the configuration, if the
+// parser collects them.
+func (p *TerramateParser) Warnings() []error {
+	return p.warnings
+}
+
 func (p *TerramateParser) addParsedFile(origin string, kind parsedKind, files ...string) {
 	for _, file := range files {
 		p.parsedFiles[file] = parsedFile{
@@ -656,11 +925,24 @@ func (p *TerramateParser) parseSyntax() error {
 	errs := errors.L()
 	for _, name := range p.sortedFilenames() {
 		data := p.files[name]
-		_, diags := p.hclparser.ParseHCL(data, name)
+		if p.cache != nil {
+			if file, ok := p.cache.Load(name, data); ok {
+				p.hclparser.AddFile(name, file)
+				p.addParsedFile(p.dir, internal, name)
+				continue
+			}
+		}
+		file, diags := p.hclparser.ParseHCL(data, name)
 		if diags.HasErrors() {
 			errs.Append(errors.E(ErrHCLSyntax, diags))
 			continue
 		}
+		FixFlushHeredocs(file, data)
+		if p.cache != nil {
+			if err := p.cache.Store(name, data, file); err != nil {
+				log.Debug().Err(err).Str("file", name).Msg("failed to cache parsed file")
+			}
+		}
 		p.addParsedFile(p.dir, internal, name)
 	}
 	return errs.AsError()
@@ -778,10 +1060,20 @@ func (p *TerramateParser) handleImport(importBlock *ast.Block) error {
 		}
 
 		p.addParsedFile(p.dir, external, file)
+		p.importedFiles = append(p.importedFiles, file)
+		p.importedFiles = append(p.importedFiles, importParser.importedFiles...)
 	}
 	return nil
 }
 
+// ImportedFiles returns the host path of the files imported by the parsed
+// configuration, directly or by other imported files, sorted.
+func (p *TerramateParser) ImportedFiles() []string {
+	files := append([]string{}, p.importedFiles...)
+	sort.Strings(files)
+	return files
+}
+
 func (p *TerramateParser) sortedFilenames() []string {
 	filenames := []string{}
 	for fname := range p.files {
@@ -886,6 +1178,29 @@ func (p *TerramateParser) parseStack(stackblock *ast.Block) (*Stack, error) {
 		case "watch":
 			errs.Append(assignSet(attr, &stack.Watch, attrVal))
 
+		case "ignore_on_change":
+			if err := assignSet(attr, &stack.IgnoreOnChange, attrVal); err != nil {
+				errs.Append(err)
+				continue
+			}
+			errs.Append(validateIgnoreOnChange(attr, stack.IgnoreOnChange))
+
+		case "timeout":
+			timeout, err := parseRunTimeout("stack.timeout", attr.Expr.Range(), attrVal)
+			if err != nil {
+				errs.Append(err)
+				continue
+			}
+			stack.Timeout = &timeout
+
+		case "priority":
+			priority, err := parseRunPriority("stack.priority", attr.Expr.Range(), attrVal)
+			if err != nil {
+				errs.Append(err)
+				continue
+			}
+			stack.Priority = &priority
+
 		default:
 			errs.Append(errors.E(
 				attr.NameRange, "unrecognized attribute stack.%q", attr.Name,
@@ -930,6 +1245,23 @@ func (c Config) HasRunEnv() bool {
 		c.Terramate.Config.Run.Env != nil
 }
 
+// HasRunStackDefaults returns true if the config has a
+// terramate.config.run.stack_defaults block defined.
+func (c Config) HasRunStackDefaults() bool {
+	return c.Terramate != nil &&
+		c.Terramate.Config != nil &&
+		c.Terramate.Config.Run != nil &&
+		c.Terramate.Config.Run.StackDefaults != nil
+}
+
+// HasCloudMetadata returns true if the config has a terramate.config.cloud.metadata block defined
+func (c Config) HasCloudMetadata() bool {
+	return c.Terramate != nil &&
+		c.Terramate.Config != nil &&
+		c.Terramate.Config.Cloud != nil &&
+		c.Terramate.Config.Cloud.Metadata != nil
+}
+
 // Experiments returns the config enabled experiments, if any.
 func (c Config) Experiments() []string {
 	if c.Terramate != nil &&
@@ -946,7 +1278,7 @@ func (c Config) AbsDir() string { return c.absdir }
 func (c Config) IsEmpty() bool {
 	return c.Stack == nil && c.Terramate == nil &&
 		c.Vendor == nil && len(c.Asserts) == 0 &&
-		len(c.Globals) == 0 &&
+		len(c.Checks) == 0 && len(c.Globals) == 0 &&
 		len(c.Generate.Files) == 0 && len(c.Generate.HCLs) == 0
 }
 
@@ -998,6 +1330,8 @@ func IsRootConfig(rootdir string) (bool, error) {
 	if err != nil {
 		return false, err
 	}
+	// rootdir is just a candidate, so nothing must be cached inside it.
+	p.cache = nil
 	err = p.AddDir(rootdir)
 	if err != nil {
 		return false, errors.E(err, "adding files to parser")
@@ -1178,6 +1512,15 @@ func parseGenerateFileBlock(cfgdir project.Path, block *ast.Block) (GenFileBlock
 		))
 	}
 
+	var mode os.FileMode
+	if modeAttr, ok := block.Body.Attributes["mode"]; ok {
+		var err error
+		mode, err = parseFileMode(modeAttr)
+		if err != nil {
+			errs.Append(errors.E(ErrTerramateSchema, block.Range, err))
+		}
+	}
+
 	if err := errs.AsError(); err != nil {
 		return GenFileBlock{}, err
 	}
@@ -1198,9 +1541,28 @@ func parseGenerateFileBlock(cfgdir project.Path, block *ast.Block) (GenFileBlock
 		Condition:    block.Body.Attributes["condition"],
 		Inherit:      inherit,
 		Context:      context,
+		Mode:         mode,
 	}, nil
 }
 
+// parseFileMode parses the generate_file.mode attribute, which must be a
+// string literal with the octal permission bits of the file, like "0755".
+func parseFileMode(attr *hclsyntax.Attribute) (os.FileMode, error) {
+	val, diags := attr.Expr.Value(nil)
+	if diags.HasErrors() || val.Type() != cty.String || val.IsNull() {
+		return 0, errors.E(`generate_file.mode must be an octal string literal like "0755"`)
+	}
+	str := val.AsString()
+	if len(str) < 3 || len(str) > 4 {
+		return 0, errors.E(`generate_file.mode must be an octal string like "0755" but given %q`, str)
+	}
+	mode, err := strconv.ParseUint(str, 8, 32)
+	if err != nil || mode == 0 || mode > 0777 {
+		return 0, errors.E(`generate_file.mode must be an octal string like "0755" but given %q`, str)
+	}
+	return os.FileMode(mode), nil
+}
+
 func validateImportBlock(block *ast.Block) error {
 	errs := errors.L()
 	if len(block.Labels) != 0 {
@@ -1330,6 +1692,10 @@ func validateGenerateFileBlock(block *ast.Block) error {
 				Name:     "context",
 				Required: false,
 			},
+			{
+				Name:     "mode",
+				Required: false,
+			},
 		},
 		Blocks: []hcl.BlockHeaderSchema{
 			{
@@ -1358,6 +1724,25 @@ func validateGenerateFileBlock(block *ast.Block) error {
 	return errs.AsError()
 }
 
+// validateIgnoreOnChange validates the glob patterns of the
+// stack.ignore_on_change attribute.
+func validateIgnoreOnChange(attr *hcl.Attribute, patterns []string) error {
+	errs := errors.L()
+	for _, pattern := range patterns {
+		if pattern == "" || path.IsAbs(pattern) {
+			errs.Append(errors.E(ErrTerramateSchema, attr.Expr.Range(),
+				"field stack.ignore_on_change must have patterns relative to the stack directory but found %q",
+				pattern))
+			continue
+		}
+		if _, err := glob.Compile(pattern, '/'); err != nil {
+			errs.Append(errors.E(ErrTerramateSchema, err, attr.Expr.Range(),
+				"invalid pattern %q in field stack.ignore_on_change", pattern))
+		}
+	}
+	return errs.AsError()
+}
+
 func assignSet(attr *hcl.Attribute, target *[]string, val cty.Value) error {
 	if val.IsNull() {
 		return nil
@@ -1577,6 +1962,28 @@ func parseStackFilterConfig(block *ast.Block) (StackFilterConfig, error) {
 			cfg.RepositoryPaths, err = parseStackFilterAttr(attr)
 			errs.Append(err)
 
+		case "depth":
+			depth, err := parseStackFilterDepth(attr)
+			if err != nil {
+				errs.Append(err)
+				continue
+			}
+			cfg.Depth = &depth
+
+		case "is_leaf":
+			attrVal, hclerr := attr.Expr.Value(nil)
+			if hclerr != nil {
+				errs.Append(errors.E(ErrTerramateSchema, hclerr, attr.NameRange, "evaluating %s", attr.Name))
+				continue
+			}
+			if attrVal.Type() != cty.Bool || attrVal.IsNull() {
+				errs.Append(errors.E(ErrTerramateSchema, attr.NameRange,
+					"%s.%s must be a boolean, got %s", block.Type, attr.Name, attrVal.Type().FriendlyName()))
+				continue
+			}
+			isLeaf := attrVal.True()
+			cfg.IsLeaf = &isLeaf
+
 		default:
 			errs.Append(errors.E(ErrTerramateSchema, attr.NameRange,
 				"unrecognized attribute %s.%s", block.Type, attr.Name,
@@ -1631,6 +2038,58 @@ func parseStackFilterAttr(attr ast.Attribute) ([]glob.Glob, error) {
 
 }
 
+// parseStackFilterDepth parses the depth attribute, which is either a number
+// matching the exact depth or a string with a comparison operator and a
+// number, like ">= 3".
+func parseStackFilterDepth(attr ast.Attribute) (StackFilterDepth, error) {
+	attrVal, hclerr := attr.Expr.Value(nil)
+	if hclerr != nil {
+		return StackFilterDepth{}, errors.E(ErrTerramateSchema, hclerr, attr.NameRange, "evaluating %s", attr.Name)
+	}
+
+	invalid := func() error {
+		return errors.E(ErrTerramateSchema, attr.Expr.Range(),
+			`%s must be a non-negative number or a string like ">= 3", using one of the operators =, !=, >, >=, < and <=`,
+			attr.Name)
+	}
+
+	if attrVal.IsNull() {
+		return StackFilterDepth{}, invalid()
+	}
+
+	switch attrVal.Type() {
+	case cty.Number:
+		bf := attrVal.AsBigFloat()
+		value, accuracy := bf.Int64()
+		if !bf.IsInt() || accuracy != 0 || value < 0 {
+			return StackFilterDepth{}, invalid()
+		}
+		return StackFilterDepth{Op: "=", Value: int(value)}, nil
+
+	case cty.String:
+		str := strings.TrimSpace(attrVal.AsString())
+		op := "="
+		for _, candidate := range []string{"==", "!=", ">=", "<=", "=", ">", "<"} {
+			if strings.HasPrefix(str, candidate) {
+				op = candidate
+				str = strings.TrimSpace(str[len(candidate):])
+				break
+			}
+		}
+		if op == "==" {
+			op = "="
+		}
+		value, err := strconv.Atoi(str)
+		if err != nil || value < 0 {
+			return StackFilterDepth{}, invalid()
+		}
+		return StackFilterDepth{Op: op, Value: value}, nil
+
+	default:
+		return StackFilterDepth{}, invalid()
+	}
+}
+
 func parseVendorConfig(cfg *VendorConfig, vendor *ast.Block) error {
 	errs := errors.L()
 
@@ -1756,10 +2215,43 @@ func (p *TerramateParser) parseRootConfig(cfg *RootConfig, block *ast.MergedBloc
 			if err != nil {
 				errs.Append(errors.E(ErrTerramateSchema, attr.Expr.Range(), err))
 			}
+		case "normalize_path_case":
+			val, diags := attr.Expr.Value(nil)
+			if diags.HasErrors() {
+				errs.Append(errors.E(diags, attr.Expr.Range(),
+					"evaluating terramate.config.normalize_path_case attribute"))
+				continue
+			}
+			if val.Type() != cty.Bool {
+				errs.Append(attrErr(attr,
+					"terramate.config.normalize_path_case is not a boolean but %q",
+					val.Type().FriendlyName(),
+				))
+				continue
+			}
+			cfg.NormalizePathCase = val.True()
+		case "sensitive_globals":
+			val, diags := attr.Expr.Value(nil)
+			if diags.HasErrors() {
+				errs.Append(errors.E(diags, attr.Expr.Range(),
+					"evaluating terramate.config.sensitive_globals attribute"))
+				continue
+			}
+
+			if err := assignSet(attr.Attribute, &cfg.SensitiveGlobals, val); err != nil {
+				errs.Append(err)
+				continue
+			}
+			for _, pattern := range cfg.SensitiveGlobals {
+				if _, err := glob.Compile(pattern, '.'); err != nil {
+					errs.Append(errors.E(ErrTerramateSchema, attr.Expr.Range(), err,
+						"invalid terramate.config.sensitive_globals pattern %q", pattern))
+				}
+			}
 		}
 	}
 
-	errs.AppendWrap(ErrTerramateSchema, block.ValidateSubBlocks("git", "generate", "change_detection", "run", "cloud", "targets", "telemetry"))
+	errs.AppendWrap(ErrTerramateSchema, block.ValidateSubBlocks("git", "generate", "change_detection", "run", "cloud", "targets", "telemetry", "checkpoint", "stack"))
 
 	gitBlock, ok := block.Blocks[ast.NewEmptyLabelBlockType("git")]
 	if ok {
@@ -1797,6 +2289,18 @@ func (p *TerramateParser) parseRootConfig(cfg *RootConfig, block *ast.MergedBloc
 		errs.Append(parseTelemetryConfigBlock(cfg.Telemetry, telemetryBlock))
 	}
 
+	checkpointBlock, ok := block.Blocks[ast.NewEmptyLabelBlockType("checkpoint")]
+	if ok {
+		cfg.Checkpoint = &CheckpointConfig{}
+		errs.Append(parseCheckpointConfigBlock(cfg.Checkpoint, checkpointBlock))
+	}
+
+	stackBlock, ok := block.Blocks[ast.NewEmptyLabelBlockType("stack")]
+	if ok {
+		cfg.Stack = &StackRootConfig{}
+		errs.Append(parseStackRootConfigBlock(cfg.Stack, stackBlock))
+	}
+
 	return errs.AsError()
 }
 
@@ -1833,13 +2337,71 @@ func parseRunConfig(cfg *RootConfig, runBlock *ast.MergedBlock) error {
 				continue
 			}
 			runCfg.CheckGenCode = value.True()
+		case "check_terraform_version":
+			if value.Type() != cty.Bool {
+				errs.Append(attrErr(attr,
+					"terramate.config.run.check_terraform_version is not a bool but %q",
+					value.Type().FriendlyName(),
+				))
+
+				continue
+			}
+			runCfg.CheckTerraformVersion = value.True()
+		case "isolate_data_dir":
+			if value.Type() != cty.Bool {
+				errs.Append(attrErr(attr,
+					"terramate.config.run.isolate_data_dir is not a bool but %q",
+					value.Type().FriendlyName(),
+				))
+
+				continue
+			}
+			runCfg.IsolateDataDir = value.True()
+		case "check_ordering":
+			if value.Type() != cty.String {
+				errs.Append(attrErr(attr,
+					"terramate.config.run.check_ordering is not a string but %q",
+					value.Type().FriendlyName(),
+				))
+
+				continue
+			}
+			str := value.AsString()
+			if str != RunCheckOrderingWarn && str != RunCheckOrderingError {
+				errs.Append(attrErr(attr,
+					"terramate.config.run.check_ordering must be either %q or %q but %q was given",
+					RunCheckOrderingWarn, RunCheckOrderingError, str,
+				))
+
+				continue
+			}
+			runCfg.CheckOrdering = str
+		case "locking":
+			if value.Type() != cty.Bool {
+				errs.Append(attrErr(attr,
+					"terramate.config.run.locking is not a bool but %q",
+					value.Type().FriendlyName(),
+				))
+
+				continue
+			}
+			runCfg.Locking = value.True()
+		case "lock_ttl":
+			ttl, err := parseRunTimeout(
+				"terramate.config.run.lock_ttl", attr.Expr.Range(), value,
+			)
+			if err != nil {
+				errs.Append(err)
+				continue
+			}
+			runCfg.LockTTL = ttl
 		default:
 			errs.Append(errors.E("unrecognized attribute terramate.config.run.env.%s",
 				attr.Name))
 		}
 	}
 
-	errs.AppendWrap(ErrTerramateSchema, runBlock.ValidateSubBlocks("env"))
+	errs.AppendWrap(ErrTerramateSchema, runBlock.ValidateSubBlocks("env", "stack_defaults"))
 
 	block, ok := runBlock.Blocks[ast.NewEmptyLabelBlockType("env")]
 	if ok {
@@ -1847,13 +2409,101 @@ func parseRunConfig(cfg *RootConfig, runBlock *ast.MergedBlock) error {
 		errs.Append(parseRunEnv(runCfg.Env, block))
 	}
 
+	block, ok = runBlock.Blocks[ast.NewEmptyLabelBlockType("stack_defaults")]
+	if ok {
+		runCfg.StackDefaults = &RunStackDefaults{}
+		errs.Append(parseRunStackDefaults(runCfg.StackDefaults, block))
+	}
+
+	return errs.AsError()
+}
+
+func parseRunStackDefaults(defaults *RunStackDefaults, block *ast.MergedBlock) error {
+	errs := errors.L()
+
+	errs.AppendWrap(ErrTerramateSchema, block.ValidateSubBlocks())
+
+	for _, attr := range block.Attributes.SortedList() {
+		value, diags := attr.Expr.Value(nil)
+		if diags.HasErrors() {
+			errs.Append(errors.E(diags,
+				"failed to evaluate terramate.config.run.stack_defaults.%s attribute", attr.Name,
+			))
+			continue
+		}
+
+		switch attr.Name {
+		case "timeout":
+			timeout, err := parseRunTimeout(
+				"terramate.config.run.stack_defaults.timeout", attr.Expr.Range(), value,
+			)
+			if err != nil {
+				errs.Append(err)
+				continue
+			}
+			defaults.Timeout = &timeout
+		case "priority":
+			priority, err := parseRunPriority(
+				"terramate.config.run.stack_defaults.priority", attr.Expr.Range(), value,
+			)
+			if err != nil {
+				errs.Append(err)
+				continue
+			}
+			defaults.Priority = &priority
+		default:
+			errs.Append(errors.E(ErrTerramateSchema, attr.NameRange,
+				"unrecognized attribute terramate.config.run.stack_defaults.%s", attr.Name))
+		}
+	}
+
 	return errs.AsError()
 }
 
+// parseRunTimeout parses a timeout attribute. The timeout must be a positive
+// duration string as accepted by [time.ParseDuration], eg.: "30m".
+func parseRunTimeout(name string, rng hcl.Range, value cty.Value) (time.Duration, error) {
+	if value.Type() != cty.String {
+		return 0, errors.E(ErrTerramateSchema, rng,
+			"%s must be a string but given %q", name, value.Type().FriendlyName())
+	}
+	timeout, err := time.ParseDuration(value.AsString())
+	if err != nil {
+		return 0, errors.E(ErrTerramateSchema, rng,
+			"%s has an invalid duration %q", name, value.AsString())
+	}
+	if timeout <= 0 {
+		return 0, errors.E(ErrTerramateSchema, rng,
+			"%s must be a positive duration but given %q", name, value.AsString())
+	}
+	return timeout, nil
+}
+
+// parseRunPriority parses a priority attribute, which must be an integer.
+func parseRunPriority(name string, rng hcl.Range, value cty.Value) (int, error) {
+	if value.Type() != cty.Number {
+		return 0, errors.E(ErrTerramateSchema, rng,
+			"%s must be a number but given %q", name, value.Type().FriendlyName())
+	}
+	priority, accuracy := value.AsBigFloat().Int64()
+	if accuracy != big.Exact {
+		return 0, errors.E(ErrTerramateSchema, rng, "%s must be an integer", name)
+	}
+	return int(priority), nil
+}
+
 func parseGenerateRootConfig(cfg *GenerateRootConfig, generateBlock *ast.MergedBlock) error {
 	errs := errors.L()
 
-	errs.AppendWrap(ErrTerramateSchema, generateBlock.ValidateSubBlocks())
+	errs.AppendWrap(ErrTerramateSchema, generateBlock.ValidateSubBlocks("header"))
+
+	headerBlock, ok := generateBlock.Blocks[ast.NewEmptyLabelBlockType("header")]
+	if ok {
+		cfg.Header = &GenerateHeaderConfig{
+			Position: GenerateHeaderPositionTop,
+		}
+		errs.Append(parseGenerateHeaderConfig(cfg.Header, headerBlock))
+	}
 
 	for _, attr := range generateBlock.Attributes.SortedList() {
 		value, diags := attr.Expr.Value(nil)
@@ -1886,6 +2536,39 @@ func parseGenerateRootConfig(cfg *GenerateRootConfig, generateBlock *ast.MergedB
 
 			cfg.HCLMagicHeaderCommentStyle = &str
 
+		case "dedup":
+			if value.Type() != cty.String {
+				errs.Append(attrErr(attr,
+					"terramate.config.generate.dedup is not a string but %q",
+					value.Type().FriendlyName(),
+				))
+
+				continue
+			}
+
+			str := value.AsString()
+			if str != GenerateDedupCopy && str != GenerateDedupHardlink {
+				errs.Append(attrErr(attr,
+					"terramate.config.generate.dedup must be either %q or %q but %q was given",
+					GenerateDedupCopy, GenerateDedupHardlink, str,
+				))
+				continue
+			}
+
+			cfg.Dedup = &str
+
+		case "allow_deletion":
+			if value.Type() != cty.Bool {
+				errs.Append(attrErr(attr,
+					"terramate.config.generate.allow_deletion is not a bool but %q",
+					value.Type().FriendlyName(),
+				))
+
+				continue
+			}
+
+			cfg.AllowDeletion = value.True()
+
 		default:
 			errs.Append(errors.E(
 				attr.NameRange,
@@ -1897,11 +2580,85 @@ func parseGenerateRootConfig(cfg *GenerateRootConfig, generateBlock *ast.MergedB
 	return errs.AsError()
 }
 
+func parseGenerateHeaderConfig(cfg *GenerateHeaderConfig, headerBlock *ast.MergedBlock) error {
+	errs := errors.L()
+
+	errs.AppendWrap(ErrTerramateSchema, headerBlock.ValidateSubBlocks())
+
+	for _, attr := range headerBlock.Attributes.SortedList() {
+		switch attr.Name {
+		case "license":
+			license := attr
+			cfg.License = &license
+
+		case "position":
+			value, diags := attr.Expr.Value(nil)
+			if diags.HasErrors() {
+				errs.Append(errors.E(diags,
+					"failed to evaluate terramate.config.generate.header.%s attribute", attr.Name,
+				))
+				continue
+			}
+			if value.Type() != cty.String {
+				errs.Append(attrErr(attr,
+					"terramate.config.generate.header.position is not a string but %q",
+					value.Type().FriendlyName(),
+				))
+				continue
+			}
+
+			str := value.AsString()
+			if str != GenerateHeaderPositionTop && str != GenerateHeaderPositionAfterShebang {
+				errs.Append(attrErr(attr,
+					"terramate.config.generate.header.position must be either %q or %q but %q was given",
+					GenerateHeaderPositionTop, GenerateHeaderPositionAfterShebang, str,
+				))
+				continue
+			}
+
+			cfg.Position = str
+
+		default:
+			errs.Append(errors.E(
+				attr.NameRange,
+				"unrecognized attribute terramate.config.generate.header.%s",
+				attr.Name,
+			))
+		}
+	}
+	return errs.AsError()
+}
+
 func parseChangeDetectionConfig(cfg *ChangeDetectionConfig, changeDetectionBlock *ast.MergedBlock) error {
 	err := changeDetectionBlock.ValidateSubBlocks("terragrunt", "git")
 	if err != nil {
 		return err
 	}
+
+	for _, attr := range changeDetectionBlock.Attributes.SortedList() {
+		switch attr.Name {
+		case "propagate_outputs":
+			value, diags := attr.Expr.Value(nil)
+			if diags.HasErrors() {
+				return errors.E(diags,
+					"failed to evaluate terramate.config.change_detection.%s attribute", attr.Name,
+				)
+			}
+			if value.Type() != cty.Bool {
+				return attrErr(attr,
+					"terramate.config.change_detection.propagate_outputs is not a boolean but %q",
+					value.Type().FriendlyName(),
+				)
+			}
+			cfg.PropagateOutputs = value.True()
+		default:
+			return errors.E(
+				attr.NameRange,
+				"unrecognized attribute terramate.config.change_detection.%s",
+				attr.Name,
+			)
+		}
+	}
 	terragruntBlock, ok := changeDetectionBlock.Blocks[ast.NewEmptyLabelBlockType("terragrunt")]
 	if ok {
 		cfg.Terragrunt = &TerragruntChangeDetectionConfig{}
@@ -1972,6 +2729,94 @@ func parseTerragruntChangeDetectionConfig(cfg *TerragruntChangeDetectionConfig,
 	return nil
 }
 
+func parseCheckpointConfigBlock(cfg *CheckpointConfig, checkpointBlock *ast.MergedBlock) error {
+	errs := errors.L()
+
+	errs.AppendWrap(ErrTerramateSchema, checkpointBlock.ValidateSubBlocks())
+
+	for _, attr := range checkpointBlock.Attributes {
+		switch attr.Name {
+		case "enabled":
+			value, diags := attr.Expr.Value(nil)
+			if diags.HasErrors() {
+				errs.Append(errors.E(diags,
+					"failed to evaluate terramate.config.checkpoint.%s attribute", attr.Name,
+				))
+				continue
+			}
+			if value.Type() != cty.Bool {
+				errs.Append(errors.E(ErrTerramateSchema, attr.Expr.Range(),
+					"terramate.config.checkpoint.%s must be a bool but has type %s",
+					attr.Name, value.Type().FriendlyName(),
+				))
+				continue
+			}
+			v := value.True()
+			cfg.Enabled = &v
+
+		default:
+			errs.Append(errors.E(
+				ErrTerramateSchema,
+				attr.NameRange,
+				"unrecognized attribute terramate.config.checkpoint.%s",
+				attr.Name,
+			))
+		}
+	}
+	return errs.AsError()
+}
+
+func parseStackRootConfigBlock(cfg *StackRootConfig, stackBlock *ast.MergedBlock) error {
+	errs := errors.L()
+
+	errs.AppendWrap(ErrTerramateSchema, stackBlock.ValidateSubBlocks())
+
+	for _, attr := range stackBlock.Attributes {
+		switch attr.Name {
+		case "filename":
+			value, diags := attr.Expr.Value(nil)
+			if diags.HasErrors() {
+				errs.Append(errors.E(diags,
+					"failed to evaluate terramate.config.stack.%s attribute", attr.Name,
+				))
+				continue
+			}
+			if value.Type() != cty.String {
+				errs.Append(errors.E(ErrTerramateSchema, attr.Expr.Range(),
+					"terramate.config.stack.%s must be a string but has type %s",
+					attr.Name, value.Type().FriendlyName(),
+				))
+				continue
+			}
+			filename := value.AsString()
+			if filename == "" || strings.HasPrefix(filename, ".") || strings.ContainsAny(filename, `/\`) {
+				errs.Append(errors.E(ErrTerramateSchema, attr.Expr.Range(),
+					"terramate.config.stack.%s must be a file name without directories but got %q",
+					attr.Name, filename,
+				))
+				continue
+			}
+			if !strings.HasSuffix(filename, ".tm") && !strings.HasSuffix(filename, ".tm.hcl") {
+				errs.Append(errors.E(ErrTerramateSchema, attr.Expr.Range(),
+					"terramate.config.stack.%s must have the .tm or .tm.hcl extension but got %q",
+					attr.Name, filename,
+				))
+				continue
+			}
+			cfg.Filename = filename
+
+		default:
+			errs.Append(errors.E(
+				ErrTerramateSchema,
+				attr.NameRange,
+				"unrecognized attribute terramate.config.stack.%s",
+				attr.Name,
+			))
+		}
+	}
+	return errs.AsError()
+}
+
 func parseTelemetryConfigBlock(cfg *TelemetryConfig, telemetryBlock *ast.MergedBlock) error {
 	errs := errors.L()
 
@@ -2157,6 +3002,15 @@ func parseGitConfig(cfg *RootConfig, gitBlock *ast.MergedBlock) error {
 				continue
 			}
 			git.CheckRemote = ToOptionalCheck(value.True())
+		case "branch_protection":
+			if value.Type() != cty.Bool {
+				errs.Append(attrErr(attr,
+					"terramate.config.git.branch_protection is not a boolean but %q",
+					value.Type().FriendlyName(),
+				))
+				continue
+			}
+			git.BranchProtection = value.True()
 
 		default:
 			errs.Append(errors.E(
@@ -2204,6 +3058,14 @@ func parseCloudConfig(cloud *CloudConfig, cloudBlock *ast.MergedBlock) error {
 
 			cloud.Organization = value.AsString()
 
+		case "retries":
+			retries, err := parseCloudRetries(attr.NameRange, value)
+			if err != nil {
+				errs.Append(err)
+				continue
+			}
+			cloud.Retries = &retries
+
 		default:
 			errs.Append(errors.E(
 				attr.NameRange,
@@ -2213,7 +3075,7 @@ func parseCloudConfig(cloud *CloudConfig, cloudBlock *ast.MergedBlock) error {
 		}
 	}
 
-	errs.AppendWrap(ErrTerramateSchema, cloudBlock.ValidateSubBlocks("targets"))
+	errs.AppendWrap(ErrTerramateSchema, cloudBlock.ValidateSubBlocks("targets", "metadata", "logs"))
 
 	targetsBlock, ok := cloudBlock.Blocks[ast.NewEmptyLabelBlockType("targets")]
 	if ok {
@@ -2222,6 +3084,110 @@ func parseCloudConfig(cloud *CloudConfig, cloudBlock *ast.MergedBlock) error {
 		errs.Append(parseTargetsConfig(cloud.Targets, targetsBlock))
 	}
 
+	metadataBlock, ok := cloudBlock.Blocks[ast.NewEmptyLabelBlockType("metadata")]
+	if ok {
+		cloud.Metadata = &CloudMetadata{}
+
+		errs.Append(parseCloudMetadata(cloud.Metadata, metadataBlock))
+	}
+
+	logsBlock, ok := cloudBlock.Blocks[ast.NewEmptyLabelBlockType("logs")]
+	if ok {
+		cloud.Logs = &CloudLogsConfig{}
+
+		errs.Append(parseCloudLogsConfig(cloud.Logs, logsBlock))
+	}
+
+	return errs.AsError()
+}
+
+func parseCloudLogsConfig(logs *CloudLogsConfig, logsBlock *ast.MergedBlock) error {
+	errs := errors.L()
+
+	errs.AppendWrap(ErrTerramateSchema, logsBlock.ValidateSubBlocks())
+
+	for _, attr := range logsBlock.Attributes.SortedList() {
+		value, diags := attr.Expr.Value(nil)
+		if diags.HasErrors() {
+			errs.Append(errors.E(diags,
+				"failed to evaluate terramate.config.cloud.logs.%s attribute", attr.Name,
+			))
+			continue
+		}
+
+		switch attr.Name {
+		case "sync":
+			if value.Type() != cty.String {
+				errs.Append(attrErr(attr,
+					"terramate.config.cloud.logs.sync is not a string but %q",
+					value.Type().FriendlyName(),
+				))
+
+				continue
+			}
+
+			sync := value.AsString()
+			if sync != CloudLogsSyncFailed && sync != CloudLogsSyncAll {
+				errs.Append(attrErr(attr,
+					"terramate.config.cloud.logs.sync must be %q or %q but given %q",
+					CloudLogsSyncFailed, CloudLogsSyncAll, sync,
+				))
+
+				continue
+			}
+
+			logs.Sync = sync
+
+		case "redact":
+			var patterns []string
+			if err := assignSet(attr.Attribute, &patterns, value); err != nil {
+				errs.Append(err)
+				continue
+			}
+			for _, pattern := range patterns {
+				if _, err := regexp.Compile(pattern); err != nil {
+					errs.Append(attrErr(attr,
+						"terramate.config.cloud.logs.redact has invalid regex %q: %v",
+						pattern, err,
+					))
+				}
+			}
+			logs.Redact = patterns
+
+		default:
+			errs.Append(errors.E(
+				attr.NameRange,
+				"unrecognized attribute terramate.config.cloud.logs.%s",
+				attr.Name,
+			))
+		}
+	}
+	return errs.AsError()
+}
+
+// parseCloudRetries parses terramate.config.cloud.retries, which must be a
+// non-negative integer.
+func parseCloudRetries(rng hcl.Range, value cty.Value) (int, error) {
+	const name = "terramate.config.cloud.retries"
+	if value.Type() != cty.Number {
+		return 0, errors.E(ErrTerramateSchema, rng,
+			"%s must be a number but given %q", name, value.Type().FriendlyName())
+	}
+	retries, accuracy := value.AsBigFloat().Int64()
+	if accuracy != big.Exact || retries < 0 {
+		return 0, errors.E(ErrTerramateSchema, rng,
+			"%s must be a non-negative integer", name)
+	}
+	return int(retries), nil
+}
+
+func parseCloudMetadata(metadata *CloudMetadata, metadataBlock *ast.MergedBlock) error {
+	if len(metadataBlock.Attributes) > 0 {
+		metadata.Attributes = metadataBlock.Attributes
+	}
+
+	errs := errors.L()
+	errs.AppendWrap(ErrTerramateSchema, metadataBlock.ValidateSubBlocks())
 	return errs.AsError()
 }
 
@@ -2319,6 +3285,19 @@ func (p *TerramateParser) parseTerramateSchema() (Config, error) {
 			}
 			config.Asserts = append(config.Asserts, assertCfg)
 
+		case "check":
+			checkCfg, err := parseCheckConfig(block)
+			if err != nil {
+				errs.Append(err)
+				continue
+			}
+			if other, found := findCheck(config.Checks, checkCfg.Name); found {
+				errs.Append(errors.E(ErrCheckRedeclared, block.DefRange(),
+					"check %q defined at %q", checkCfg.Name, other.Range.String()))
+				continue
+			}
+			config.Checks = append(config.Checks, checkCfg)
+
 		case "vendor":
 			if foundVendor {
 				errs.Append(errors.E(errKind, block.DefRange(),
@@ -2461,6 +3440,10 @@ func (p *TerramateParser) checkConfigSanity(_ Config) error {
 	if p.strict {
 		return errs.AsError()
 	}
+	if p.collectWarnings {
+		p.warnings = append(p.warnings, errs.Errors()...)
+		return nil
+	}
 	for _, err := range errs.Errors() {
 		logger.Warn().Err(err).Send()
 	}
@@ -2700,6 +3683,8 @@ func (r *RootConfig) HasSafeguardDisabled(keyword safeguard.Keyword) bool {
 		return !git.CheckUncommitted || r.DisableSafeguards.Has(keyword, safeguard.Git)
 	case safeguard.GitOutOfSync:
 		return !git.CheckRemote.ValueOr(true) || r.DisableSafeguards.Has(keyword, safeguard.Git)
+	case safeguard.GitBranchProtection:
+		return !git.BranchProtection || r.DisableSafeguards.Has(keyword, safeguard.Git)
 	case safeguard.Outdated:
 		return !run.CheckGenCode || r.DisableSafeguards.Has(keyword)
 	default:
@@ -0,0 +1,377 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package hcl_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/madlambda/spells/assert"
+	"github.com/terramate-io/hcl/v2/hclparse"
+	"github.com/terramate-io/hcl/v2/hclsyntax"
+	"github.com/terramate-io/terramate/errors"
+	"github.com/terramate-io/terramate/hcl"
+	"github.com/terramate-io/terramate/hcl/ast"
+	"github.com/terramate-io/terramate/test"
+)
+
+func TestHCLParserConfigCloudMetadata(t *testing.T) {
+	// See TestHCLParserConfigRun for why the attributes are built this way.
+	cloudMetadataCfg := func(rawattributes string) hcl.Config {
+		rootdir := test.TempDir(t)
+		filepath := filepath.Join(rootdir, "test_file.hcl")
+		assert.NoError(t, os.WriteFile(filepath, []byte(rawattributes), 0700))
+
+		parser := hclparse.NewParser()
+		res, diags := parser.ParseHCLFile(filepath)
+		if diags.HasErrors() {
+			t.Fatalf("test case provided invalid hcl, error: %v hcl:\n%s", diags, rawattributes)
+		}
+
+		body := res.Body.(*hclsyntax.Body)
+		attrs := make(ast.Attributes)
+
+		for name, attr := range body.Attributes {
+			attrs[name] = ast.NewAttribute(rootdir, attr.AsHCLAttribute())
+		}
+
+		return hcl.Config{
+			Terramate: &hcl.Terramate{
+				Config: &hcl.RootConfig{
+					Cloud: &hcl.CloudConfig{
+						Organization: "my-org",
+						Metadata: &hcl.CloudMetadata{
+							Attributes: attrs,
+						},
+					},
+				},
+			},
+		}
+	}
+
+	for _, tc := range []testcase{
+		{
+			name: "empty metadata",
+			input: []cfgfile{
+				{
+					filename: "cfg.tm",
+					body: `
+						terramate {
+						  config {
+						    cloud {
+						      organization = "my-org"
+						      metadata {
+						      }
+						    }
+						  }
+						}
+					`,
+				},
+			},
+			want: want{
+				config: hcl.Config{
+					Terramate: &hcl.Terramate{
+						Config: &hcl.RootConfig{
+							Cloud: &hcl.CloudConfig{
+								Organization: "my-org",
+								Metadata:     &hcl.CloudMetadata{},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "attrs on cloud.metadata",
+			input: []cfgfile{
+				{
+					filename: "cfg.tm",
+					body: `
+						terramate {
+						  config {
+						    cloud {
+						      organization = "my-org"
+						      metadata {
+						        owner        = "platform"
+						        service_tier = global.tier
+						        interp       = "${global.team}-team"
+						      }
+						    }
+						  }
+						}
+					`,
+				},
+			},
+			want: want{
+				config: cloudMetadataCfg(`
+						owner        = "platform"
+						service_tier = global.tier
+						interp       = "${global.team}-team"
+				`),
+			},
+		},
+		{
+			name: "metadata defined on multiple files are merged",
+			input: []cfgfile{
+				{
+					filename: "cfg1.tm",
+					body: `
+						terramate {
+						  config {
+						    cloud {
+						      organization = "my-org"
+						      metadata {
+						        owner = "platform"
+						      }
+						    }
+						  }
+						}
+					`,
+				},
+				{
+					filename: "cfg2.tm",
+					body: `
+						terramate {
+						  config {
+						    cloud {
+						      metadata {
+						        service_tier = global.tier
+						      }
+						    }
+						  }
+						}
+					`,
+				},
+			},
+			want: want{
+				config: cloudMetadataCfg(`
+						owner        = "platform"
+						service_tier = global.tier
+				`),
+			},
+		},
+		{
+			name: "unrecognized block on cloud.metadata",
+			input: []cfgfile{
+				{
+					filename: "cfg.tm",
+					body: `
+						terramate {
+						  config {
+						    cloud {
+						      metadata {
+						        something {
+						        }
+						      }
+						    }
+						  }
+						}
+					`,
+				},
+			},
+			want: want{
+				errs: []error{
+					errors.E(hcl.ErrTerramateSchema),
+				},
+			},
+		},
+		{
+			name: "unrecognized label on cloud.metadata",
+			input: []cfgfile{
+				{
+					filename: "cfg.tm",
+					body: `
+						terramate {
+						  config {
+						    cloud {
+						      metadata "label" {
+						      }
+						    }
+						  }
+						}
+					`,
+				},
+			},
+			want: want{
+				errs: []error{
+					errors.E(hcl.ErrTerramateSchema),
+				},
+			},
+		},
+	} {
+		testParser(t, tc)
+	}
+}
+
+func TestHCLParserConfigCloudRetries(t *testing.T) {
+	retries := func(n int) *int { return &n }
+	cloudCfg := func(body string) []cfgfile {
+		return []cfgfile{
+			{
+				filename: "cfg.tm",
+				body: `
+					terramate {
+					  config {
+					    cloud {
+					      ` + body + `
+					    }
+					  }
+					}
+				`,
+			},
+		}
+	}
+
+	for _, tc := range []testcase{
+		{
+			name:  "retries is set",
+			input: cloudCfg(`retries = 5`),
+			want: want{
+				config: hcl.Config{
+					Terramate: &hcl.Terramate{
+						Config: &hcl.RootConfig{
+							Cloud: &hcl.CloudConfig{
+								Retries: retries(5),
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name:  "zero retries disables retrying",
+			input: cloudCfg(`retries = 0`),
+			want: want{
+				config: hcl.Config{
+					Terramate: &hcl.Terramate{
+						Config: &hcl.RootConfig{
+							Cloud: &hcl.CloudConfig{
+								Retries: retries(0),
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name:  "negative retries fails",
+			input: cloudCfg(`retries = -1`),
+			want: want{
+				errs: []error{
+					errors.E(hcl.ErrTerramateSchema),
+				},
+			},
+		},
+		{
+			name:  "fractional retries fails",
+			input: cloudCfg(`retries = 1.5`),
+			want: want{
+				errs: []error{
+					errors.E(hcl.ErrTerramateSchema),
+				},
+			},
+		},
+		{
+			name:  "retries is not a number fails",
+			input: cloudCfg(`retries = "3"`),
+			want: want{
+				errs: []error{
+					errors.E(hcl.ErrTerramateSchema),
+				},
+			},
+		},
+	} {
+		testParser(t, tc)
+	}
+}
+
+func TestHCLParserConfigCloudLogs(t *testing.T) {
+	cloudCfg := func(body string) []cfgfile {
+		return []cfgfile{
+			{
+				filename: "cfg.tm",
+				body: `
+					terramate {
+					  config {
+					    cloud {
+					      logs {
+					        ` + body + `
+					      }
+					    }
+					  }
+					}
+				`,
+			},
+		}
+	}
+
+	for _, tc := range []testcase{
+		{
+			name:  "empty logs block",
+			input: cloudCfg(``),
+			want: want{
+				config: hcl.Config{
+					Terramate: &hcl.Terramate{
+						Config: &hcl.RootConfig{
+							Cloud: &hcl.CloudConfig{
+								Logs: &hcl.CloudLogsConfig{},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "sync and redact are set",
+			input: cloudCfg(`
+				sync   = "all"
+				redact = ["(?i)password", "^token="]
+			`),
+			want: want{
+				config: hcl.Config{
+					Terramate: &hcl.Terramate{
+						Config: &hcl.RootConfig{
+							Cloud: &hcl.CloudConfig{
+								Logs: &hcl.CloudLogsConfig{
+									Sync:   hcl.CloudLogsSyncAll,
+									Redact: []string{"(?i)password", "^token="},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name:  "invalid sync value fails",
+			input: cloudCfg(`sync = "always"`),
+			want: want{
+				errs: []error{
+					errors.E(hcl.ErrTerramateSchema),
+				},
+			},
+		},
+		{
+			name:  "invalid redact regex fails",
+			input: cloudCfg(`redact = ["secret("]`),
+			want: want{
+				errs: []error{
+					errors.E(hcl.ErrTerramateSchema),
+				},
+			},
+		},
+		{
+			name:  "unrecognized attribute fails",
+			input: cloudCfg(`enabled = true`),
+			want: want{
+				errs: []error{
+					errors.E(hcl.ErrTerramateSchema),
+				},
+			},
+		},
+	} {
+		testParser(t, tc)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/madlambda/spells/assert"
 	"github.com/terramate-io/hcl/v2/hclparse"
@@ -290,6 +291,64 @@ func TestHCLParserConfigRun(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "run.check_terraform_version defined",
+			input: []cfgfile{
+				{
+					filename: "cfg.tm",
+					body: `
+						terramate {
+						  config {
+						    run {
+							check_terraform_version = true
+						    }
+						  }
+						}
+					`,
+				},
+			},
+			want: want{
+				config: hcl.Config{
+					Terramate: &hcl.Terramate{
+						Config: &hcl.RootConfig{
+							Run: &hcl.RunConfig{
+								CheckGenCode:          true,
+								CheckTerraformVersion: true,
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "run.isolate_data_dir defined",
+			input: []cfgfile{
+				{
+					filename: "cfg.tm",
+					body: `
+						terramate {
+						  config {
+						    run {
+							isolate_data_dir = true
+						    }
+						  }
+						}
+					`,
+				},
+			},
+			want: want{
+				config: hcl.Config{
+					Terramate: &hcl.Terramate{
+						Config: &hcl.RootConfig{
+							Run: &hcl.RunConfig{
+								CheckGenCode:   true,
+								IsolateDataDir: true,
+							},
+						},
+					},
+				},
+			},
+		},
 		{
 			name: "attrs on run.env in single block/file",
 			input: []cfgfile{
@@ -657,7 +716,361 @@ func TestHCLParserConfigRun(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "run.check_terraform_version attribute must be a boolean",
+			input: []cfgfile{
+				{
+					filename: "cfg.tm",
+					body: `
+						terramate {
+						  config {
+						    run {
+						      check_terraform_version = "yes"
+						    }
+						  }
+						}
+					`,
+				},
+			},
+			want: want{
+				errs: []error{
+					errors.E(hcl.ErrTerramateSchema,
+						Mkrange("cfg.tm", Start(5, 39, 90), End(5, 44, 95)),
+					),
+				},
+			},
+		},
+		{
+			name: "run.isolate_data_dir attribute must be a boolean",
+			input: []cfgfile{
+				{
+					filename: "cfg.tm",
+					body: `
+						terramate {
+						  config {
+						    run {
+						      isolate_data_dir = "yes"
+						    }
+						  }
+						}
+					`,
+				},
+			},
+			want: want{
+				errs: []error{
+					errors.E(hcl.ErrTerramateSchema,
+						Mkrange("cfg.tm", Start(5, 32, 83), End(5, 37, 88)),
+					),
+				},
+			},
+		},
+		{
+			name: "run.check_ordering defined",
+			input: []cfgfile{
+				{
+					filename: "cfg.tm",
+					body: `
+						terramate {
+						  config {
+						    run {
+						      check_ordering = "error"
+						    }
+						  }
+						}
+					`,
+				},
+			},
+			want: want{
+				config: hcl.Config{
+					Terramate: &hcl.Terramate{
+						Config: &hcl.RootConfig{
+							Run: &hcl.RunConfig{
+								CheckGenCode:  true,
+								CheckOrdering: hcl.RunCheckOrderingError,
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "run.locking and run.lock_ttl defined",
+			input: []cfgfile{
+				{
+					filename: "cfg.tm",
+					body: `
+						terramate {
+						  config {
+						    run {
+						      locking  = true
+						      lock_ttl = "30m"
+						    }
+						  }
+						}
+					`,
+				},
+			},
+			want: want{
+				config: hcl.Config{
+					Terramate: &hcl.Terramate{
+						Config: &hcl.RootConfig{
+							Run: &hcl.RunConfig{
+								CheckGenCode: true,
+								Locking:      true,
+								LockTTL:      30 * time.Minute,
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "run.locking attribute must be a boolean",
+			input: []cfgfile{
+				{
+					filename: "cfg.tm",
+					body: `
+						terramate {
+						  config {
+						    run {
+						      locking = "yes"
+						    }
+						  }
+						}
+					`,
+				},
+			},
+			want: want{
+				errs: []error{
+					errors.E(hcl.ErrTerramateSchema,
+						Mkrange("cfg.tm", Start(5, 23, 74), End(5, 28, 79)),
+					),
+				},
+			},
+		},
+		{
+			name: "run.lock_ttl attribute must be a valid duration",
+			input: []cfgfile{
+				{
+					filename: "cfg.tm",
+					body: `
+						terramate {
+						  config {
+						    run {
+						      lock_ttl = "1x"
+						    }
+						  }
+						}
+					`,
+				},
+			},
+			want: want{
+				errs: []error{
+					errors.E(hcl.ErrTerramateSchema,
+						Mkrange("cfg.tm", Start(5, 24, 75), End(5, 28, 79)),
+					),
+				},
+			},
+		},
+		{
+			name: "run.check_ordering attribute must be a string",
+			input: []cfgfile{
+				{
+					filename: "cfg.tm",
+					body: `
+						terramate {
+						  config {
+						    run {
+						      check_ordering = 1
+						    }
+						  }
+						}
+					`,
+				},
+			},
+			want: want{
+				errs: []error{
+					errors.E(hcl.ErrTerramateSchema,
+						Mkrange("cfg.tm", Start(5, 30, 81), End(5, 31, 82)),
+					),
+				},
+			},
+		},
+		{
+			name: "run.check_ordering attribute must be warn or error",
+			input: []cfgfile{
+				{
+					filename: "cfg.tm",
+					body: `
+						terramate {
+						  config {
+						    run {
+						      check_ordering = "strict"
+						    }
+						  }
+						}
+					`,
+				},
+			},
+			want: want{
+				errs: []error{
+					errors.E(hcl.ErrTerramateSchema,
+						Mkrange("cfg.tm", Start(5, 30, 81), End(5, 38, 89)),
+					),
+				},
+			},
+		},
+		{
+			name: "run.stack_defaults with timeout and priority",
+			input: []cfgfile{
+				{
+					filename: "cfg.tm",
+					body: `
+						terramate {
+						  config {
+						    run {
+						      stack_defaults {
+						        timeout  = "30m"
+						        priority = 10
+						      }
+						    }
+						  }
+						}
+					`,
+				},
+			},
+			want: want{
+				config: hcl.Config{
+					Terramate: &hcl.Terramate{
+						Config: &hcl.RootConfig{
+							Run: &hcl.RunConfig{
+								CheckGenCode: true,
+								StackDefaults: &hcl.RunStackDefaults{
+									Timeout:  durationPtr(30 * time.Minute),
+									Priority: intPtr(10),
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "empty run.stack_defaults",
+			input: []cfgfile{
+				{
+					filename: "cfg.tm",
+					body: `
+						terramate {
+						  config {
+						    run {
+						      stack_defaults {
+						      }
+						    }
+						  }
+						}
+					`,
+				},
+			},
+			want: want{
+				config: hcl.Config{
+					Terramate: &hcl.Terramate{
+						Config: &hcl.RootConfig{
+							Run: &hcl.RunConfig{
+								CheckGenCode:  true,
+								StackDefaults: &hcl.RunStackDefaults{},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "run.stack_defaults with invalid attributes",
+			input: []cfgfile{
+				{
+					filename: "cfg.tm",
+					body: `
+						terramate {
+						  config {
+						    run {
+						      stack_defaults {
+						        timeout  = "forever"
+						        priority = 1.5
+						        unknown  = true
+						      }
+						    }
+						  }
+						}
+					`,
+				},
+			},
+			want: want{
+				errs: []error{
+					errors.E(hcl.ErrTerramateSchema),
+					errors.E(hcl.ErrTerramateSchema),
+					errors.E(hcl.ErrTerramateSchema),
+				},
+			},
+		},
+		{
+			name: "run.stack_defaults.timeout must be positive",
+			input: []cfgfile{
+				{
+					filename: "cfg.tm",
+					body: `
+						terramate {
+						  config {
+						    run {
+						      stack_defaults {
+						        timeout = "-1m"
+						      }
+						    }
+						  }
+						}
+					`,
+				},
+			},
+			want: want{
+				errs: []error{
+					errors.E(hcl.ErrTerramateSchema),
+				},
+			},
+		},
+		{
+			name: "run.stack_defaults does not allow blocks",
+			input: []cfgfile{
+				{
+					filename: "cfg.tm",
+					body: `
+						terramate {
+						  config {
+						    run {
+						      stack_defaults {
+						        env {
+						        }
+						      }
+						    }
+						  }
+						}
+					`,
+				},
+			},
+			want: want{
+				errs: []error{
+					errors.E(hcl.ErrTerramateSchema),
+				},
+			},
+		},
 	} {
 		testParser(t, tc)
 	}
 }
+
+func durationPtr(d time.Duration) *time.Duration {
+	return &d
+}
+
+func intPtr(i int) *int {
+	return &i
+}
@@ -0,0 +1,83 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package hcl
+
+import (
+	"github.com/terramate-io/hcl/v2"
+	"github.com/terramate-io/terramate/errors"
+	"github.com/terramate-io/terramate/hcl/ast"
+	"github.com/terramate-io/terramate/hcl/info"
+)
+
+// ErrCheckRedeclared indicates that multiple check blocks with the same name
+// are defined in the same directory.
+const ErrCheckRedeclared errors.Kind = "terramate schema error: (check): multiple check blocks with same name in the same directory"
+
+// CheckConfig represents a Terramate check block. The checks are evaluated
+// for each stack after its code is generated and they apply to the stacks of
+// the directory where they are defined and of its child directories. A check
+// shadows the check with the same name defined in any parent directory.
+type CheckConfig struct {
+	Range     info.Range
+	Name      string
+	Assertion hcl.Expression
+	Message   hcl.Expression
+	Severity  hcl.Expression
+}
+
+func parseCheckConfig(check *ast.Block) (CheckConfig, error) {
+	cfg := CheckConfig{}
+	errs := errors.L()
+
+	cfg.Range = check.Range
+
+	if len(check.Labels) != 1 {
+		errs.Append(errors.E(ErrTerramateSchema, check.DefRange(),
+			"check must have a single label but %d given", len(check.Labels)))
+	} else {
+		cfg.Name = check.Labels[0]
+	}
+
+	errs.Append(checkHasSubBlocks(check))
+
+	for _, attr := range check.Attributes {
+		switch attr.Name {
+		case "assertion":
+			cfg.Assertion = attr.Expr
+		case "message":
+			cfg.Message = attr.Expr
+		case "severity":
+			cfg.Severity = attr.Expr
+		default:
+			errs.Append(errors.E(ErrTerramateSchema, attr.NameRange,
+				"unrecognized attribute %s.%s", check.Type, attr.Name,
+			))
+		}
+	}
+
+	if cfg.Assertion == nil {
+		errs.Append(errors.E(ErrTerramateSchema, check.Range,
+			"check.assertion is required"))
+	}
+
+	if cfg.Message == nil {
+		errs.Append(errors.E(ErrTerramateSchema, check.Range,
+			"check.message is required"))
+	}
+
+	if err := errs.AsError(); err != nil {
+		return CheckConfig{}, err
+	}
+
+	return cfg, nil
+}
+
+func findCheck(checks []CheckConfig, name string) (CheckConfig, bool) {
+	for _, check := range checks {
+		if check.Name == name {
+			return check, true
+		}
+	}
+	return CheckConfig{}, false
+}
@@ -0,0 +1,189 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package hcl_test
+
+import (
+	"testing"
+
+	"github.com/terramate-io/terramate/errors"
+	"github.com/terramate-io/terramate/hcl"
+	"github.com/terramate-io/terramate/test"
+	. "github.com/terramate-io/terramate/test/hclutils"
+	. "github.com/terramate-io/terramate/test/hclutils/info"
+	. "github.com/terramate-io/terramate/test/hclwrite/hclutils"
+)
+
+func TestHCLParserCheck(t *testing.T) {
+	expr := test.NewExpr
+	tcases := []testcase{
+		{
+			name: "single check",
+			input: []cfgfile{
+				{
+					filename: "check.tm",
+					body: Check(
+						Labels("backend"),
+						Expr("assertion", `tm_contains(generated.files, "backend.tf")`),
+						Str("message", "backend.tf is required"),
+					).String(),
+				},
+			},
+			want: want{
+				config: hcl.Config{
+					Checks: []hcl.CheckConfig{
+						{
+							Name:      "backend",
+							Assertion: expr(t, `tm_contains(generated.files, "backend.tf")`),
+							Message:   expr(t, `"backend.tf is required"`),
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "checks with severity on multiple files",
+			input: []cfgfile{
+				{
+					filename: "check.tm",
+					body: Check(
+						Labels("backend"),
+						Expr("assertion", "true"),
+						Str("message", "msg"),
+						Str("severity", "error"),
+					).String(),
+				},
+				{
+					filename: "check2.tm",
+					body: Check(
+						Labels("tags"),
+						Expr("assertion", "false"),
+						Expr("message", "global.message"),
+						Expr("severity", "global.severity"),
+					).String(),
+				},
+			},
+			want: want{
+				config: hcl.Config{
+					Checks: []hcl.CheckConfig{
+						{
+							Range: Range(
+								"check.tm",
+								Start(1, 1, 0),
+								End(5, 2, 80),
+							),
+							Name:      "backend",
+							Assertion: expr(t, "true"),
+							Message:   expr(t, `"msg"`),
+							Severity:  expr(t, `"error"`),
+						},
+						{
+							Range: Range(
+								"check2.tm",
+								Start(1, 1, 0),
+								End(5, 2, 95),
+							),
+							Name:      "tags",
+							Assertion: expr(t, "false"),
+							Message:   expr(t, "global.message"),
+							Severity:  expr(t, "global.severity"),
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "label is obligatory",
+			input: []cfgfile{
+				{
+					filename: "check.tm",
+					body: Check(
+						Expr("assertion", "true"),
+						Str("message", "msg"),
+					).String(),
+				},
+			},
+			want: want{
+				errs: []error{
+					errors.E(hcl.ErrTerramateSchema,
+						Mkrange("check.tm", Start(1, 1, 0), End(1, 6, 5)),
+					),
+				},
+			},
+		},
+		{
+			name: "assertion and message are obligatory",
+			input: []cfgfile{
+				{
+					filename: "check.tm",
+					body: Check(
+						Labels("backend"),
+					).String(),
+				},
+			},
+			want: want{
+				errs: []error{
+					errors.E(hcl.ErrTerramateSchema,
+						Mkrange("check.tm", Start(1, 1, 0), End(2, 2, 19)),
+					),
+					errors.E(hcl.ErrTerramateSchema,
+						Mkrange("check.tm", Start(1, 1, 0), End(2, 2, 19)),
+					),
+				},
+			},
+		},
+		{
+			name: "unrecognized attribute fails",
+			input: []cfgfile{
+				{
+					filename: "check.tm",
+					body: Check(
+						Labels("backend"),
+						Expr("assertion", "true"),
+						Str("message", "msg"),
+						Expr("warning", "true"),
+					).String(),
+				},
+			},
+			want: want{
+				errs: []error{
+					errors.E(hcl.ErrTerramateSchema,
+						Mkrange("check.tm", Start(4, 3, 59), End(4, 10, 66)),
+					),
+				},
+			},
+		},
+		{
+			name: "same name in the same directory fails",
+			input: []cfgfile{
+				{
+					filename: "check.tm",
+					body: Check(
+						Labels("backend"),
+						Expr("assertion", "true"),
+						Str("message", "msg"),
+					).String(),
+				},
+				{
+					filename: "check2.tm",
+					body: Check(
+						Labels("backend"),
+						Expr("assertion", "false"),
+						Str("message", "other"),
+					).String(),
+				},
+			},
+			want: want{
+				errs: []error{
+					errors.E(hcl.ErrCheckRedeclared,
+						Mkrange("check2.tm", Start(1, 1, 0), End(1, 16, 15)),
+					),
+				},
+			},
+		},
+	}
+
+	for _, tc := range tcases {
+		testParser(t, tc)
+	}
+}
@@ -34,8 +34,9 @@ type Commands ast.Attribute
 type ScriptJob struct {
 	Name        *ast.Attribute
 	Description *ast.Attribute
-	Command     *Command  // Command is a single executable command
-	Commands    *Commands // Commands is a list of executable commands
+	Command     *Command       // Command is a single executable command
+	Commands    *Commands      // Commands is a list of executable commands
+	Capture     *ast.Attribute // Capture is the job_output name storing the job stdout
 }
 
 // Script represents a parsed script block
@@ -44,6 +45,8 @@ type Script struct {
 	Labels      []string         // Labels of the script block used for grouping scripts
 	Name        *ast.Attribute   // Name of the script
 	Description *ast.Attribute   // Description is a human readable description of a script
+	Enabled     *ast.Attribute   // Enabled tells if the script is enabled for the stack, defaults to true
+	JobOrdering *ast.Attribute   // JobOrdering tells if the jobs run stack by stack or job by job across the stacks
 	Jobs        []*ScriptJob     // Job represents the command(s) part of this script
 	Lets        *ast.MergedBlock // Lets are script local variables.
 }
@@ -93,6 +96,10 @@ func (p *TerramateParser) parseScriptBlock(block *ast.Block) (*Script, error) {
 			parsedScript.Name = &attr
 		case "description":
 			parsedScript.Description = &attr
+		case "enabled":
+			parsedScript.Enabled = &attr
+		case "job_ordering":
+			parsedScript.JobOrdering = &attr
 		default:
 			errs.Append(errors.E(ErrScriptUnrecognizedAttr, attr.NameRange))
 		}
@@ -122,7 +129,9 @@ func (p *TerramateParser) parseScriptBlock(block *ast.Block) (*Script, error) {
 		errs.Append(errors.E(ErrScriptNoLabels, block.TypeRange))
 	}
 
-	if len(parsedScript.Jobs) == 0 {
+	// a script without jobs is only allowed for disabling an inherited
+	// script in a subtree.
+	if len(parsedScript.Jobs) == 0 && parsedScript.Enabled == nil {
 		errs.Append(errors.E(ErrScriptMissingOrInvalidJob, block.Range))
 	}
 
@@ -131,7 +140,7 @@ func (p *TerramateParser) parseScriptBlock(block *ast.Block) (*Script, error) {
 		if labelType.Type == "lets" {
 			mergedLets[labelType] = mergedBlock
 
-			errs.Append(validateLets(mergedBlock))
+			errs.AppendWrap(ErrTerramateSchema, validateLets(mergedBlock))
 		}
 	}
 
@@ -171,6 +180,8 @@ func parseScriptJobBlock(block *ast.Block) (*ScriptJob, error) {
 			parsedScriptJob.Command = NewScriptCommand(attr)
 		case "commands":
 			parsedScriptJob.Commands = NewScriptCommands(attr)
+		case "capture":
+			parsedScriptJob.Capture = &attr
 		default:
 			errs.Append(errors.E(ErrScriptJobUnrecognizedAttr, attr.NameRange, attr.Name))
 		}
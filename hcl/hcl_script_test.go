@@ -550,6 +550,99 @@ func TestHCLScript(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "script with lets map block",
+			input: []cfgfile{
+				{
+					filename: "cfg.tm",
+					body: `
+					  terramate {
+						  config {
+							  experiments = ["scripts"]
+						  }
+					  }
+					`,
+				},
+				{
+					filename: "script.tm",
+					body: `
+					  script "group1" "script1" {
+						description = "some description"
+						lets {
+							map "zones" {
+								for_each = ["a", "b"]
+								key      = element.new
+								value {
+									map "nested" {
+										for_each = [element.new]
+										iterator = nested
+										key      = nested.new
+										value    = true
+									}
+								}
+							}
+						}
+						job {
+						  command = ["echo", let.zones]
+						}
+					  }
+					`,
+				},
+			},
+			want: want{
+				errs: []error{},
+				config: hcl.Config{
+					Scripts: []*hcl.Script{
+						{
+							Labels:      []string{"group1", "script1"},
+							Description: makeAttribute(t, "description", `"some description"`),
+							Jobs: []*hcl.ScriptJob{
+								{
+									Command: makeCommand(t, `["echo", let.zones]`),
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "script with invalid lets map block -- fails",
+			input: []cfgfile{
+				{
+					filename: "cfg.tm",
+					body: `
+					  terramate {
+						  config {
+							  experiments = ["scripts"]
+						  }
+					  }
+					`,
+				},
+				{
+					filename: "script.tm",
+					body: `
+					  script "group1" "script1" {
+						description = "some description"
+						lets {
+							map "zones" {
+								key   = element.new
+								value = true
+							}
+						}
+						job {
+						  command = ["ls", "-l"]
+						}
+					  }
+					`,
+				},
+			},
+			want: want{
+				errs: []error{
+					errors.E(hcl.ErrTerramateSchema),
+				},
+			},
+		},
 		{
 			name: "script with multiple jobs",
 			input: []cfgfile{
@@ -747,6 +840,188 @@ func TestHCLScript(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "job with capture",
+			input: []cfgfile{
+				{
+					filename: "cfg.tm",
+					body: `
+					  terramate {
+						  config {
+							  experiments = ["scripts"]
+						  }
+					  }
+					`,
+				},
+				{
+					filename: "script.tm",
+					body: `
+					  script "deploy" {
+						job {
+						  command = ["echo", "arn"]
+						  capture = "bootstrap"
+						}
+						job {
+						  command = ["echo", job_output.bootstrap]
+						}
+					  }
+					`,
+				},
+			},
+			want: want{
+				config: hcl.Config{
+					Terramate: &hcl.Terramate{
+						Config: &hcl.RootConfig{
+							Experiments: []string{"scripts"},
+						},
+					},
+					Scripts: []*hcl.Script{
+						{
+							Labels: []string{"deploy"},
+							Jobs: []*hcl.ScriptJob{
+								{
+									Command: makeCommand(t, `["echo", "arn"]`),
+									Capture: makeAttribute(t, "capture", `"bootstrap"`),
+								},
+								{
+									Command: makeCommand(t, `["echo", job_output.bootstrap]`),
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "script with enabled attr",
+			input: []cfgfile{
+				{
+					filename: "cfg.tm",
+					body: `
+					  terramate {
+						  config {
+							  experiments = ["scripts"]
+						  }
+					  }
+					`,
+				},
+				{
+					filename: "script.tm",
+					body: `
+					  script "deploy" {
+						enabled = global.deploy_enabled
+						job {
+						  command = ["echo", "hello"]
+						}
+					  }
+					`,
+				},
+			},
+			want: want{
+				config: hcl.Config{
+					Terramate: &hcl.Terramate{
+						Config: &hcl.RootConfig{
+							Experiments: []string{"scripts"},
+						},
+					},
+					Scripts: []*hcl.Script{
+						{
+							Labels:  []string{"deploy"},
+							Enabled: makeAttribute(t, "enabled", `global.deploy_enabled`),
+							Jobs: []*hcl.ScriptJob{
+								{
+									Command: makeCommand(t, `["echo", "hello"]`),
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "script with enabled attr and no jobs disables the script",
+			input: []cfgfile{
+				{
+					filename: "cfg.tm",
+					body: `
+					  terramate {
+						  config {
+							  experiments = ["scripts"]
+						  }
+					  }
+					`,
+				},
+				{
+					filename: "script.tm",
+					body: `
+					  script "deploy" {
+						enabled = false
+					  }
+					`,
+				},
+			},
+			want: want{
+				config: hcl.Config{
+					Terramate: &hcl.Terramate{
+						Config: &hcl.RootConfig{
+							Experiments: []string{"scripts"},
+						},
+					},
+					Scripts: []*hcl.Script{
+						{
+							Labels:  []string{"deploy"},
+							Enabled: makeAttribute(t, "enabled", `false`),
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "script with job_ordering attr",
+			input: []cfgfile{
+				{
+					filename: "cfg.tm",
+					body: `
+					  terramate {
+						  config {
+							  experiments = ["scripts"]
+						  }
+					  }
+					`,
+				},
+				{
+					filename: "script.tm",
+					body: `
+					  script "deploy" {
+						job_ordering = "job"
+						job {
+						  command = ["echo", "hello"]
+						}
+					  }
+					`,
+				},
+			},
+			want: want{
+				config: hcl.Config{
+					Terramate: &hcl.Terramate{
+						Config: &hcl.RootConfig{
+							Experiments: []string{"scripts"},
+						},
+					},
+					Scripts: []*hcl.Script{
+						{
+							Labels:      []string{"deploy"},
+							JobOrdering: makeAttribute(t, "job_ordering", `"job"`),
+							Jobs: []*hcl.ScriptJob{
+								{
+									Command: makeCommand(t, `["echo", "hello"]`),
+								},
+							},
+						},
+					},
+				},
+			},
+		},
 	} {
 		testParser(t, tc)
 	}
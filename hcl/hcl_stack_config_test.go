@@ -0,0 +1,120 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package hcl_test
+
+import (
+	"testing"
+
+	"github.com/terramate-io/terramate/errors"
+	"github.com/terramate-io/terramate/hcl"
+)
+
+func TestHCLParserConfigStack(t *testing.T) {
+	stackCfg := func(body string) []cfgfile {
+		return []cfgfile{
+			{
+				filename: "cfg.tm",
+				body: `
+					terramate {
+					  config {
+					    stack {
+					      ` + body + `
+					    }
+					  }
+					}
+				`,
+			},
+		}
+	}
+
+	for _, tc := range []testcase{
+		{
+			name:  "empty stack config",
+			input: stackCfg(``),
+			want: want{
+				config: hcl.Config{
+					Terramate: &hcl.Terramate{
+						Config: &hcl.RootConfig{
+							Stack: &hcl.StackRootConfig{},
+						},
+					},
+				},
+			},
+		},
+		{
+			name:  "filename",
+			input: stackCfg(`filename = "terramate.tm.hcl"`),
+			want: want{
+				config: hcl.Config{
+					Terramate: &hcl.Terramate{
+						Config: &hcl.RootConfig{
+							Stack: &hcl.StackRootConfig{
+								Filename: "terramate.tm.hcl",
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name:  "filename with .tm extension",
+			input: stackCfg(`filename = "main.tm"`),
+			want: want{
+				config: hcl.Config{
+					Terramate: &hcl.Terramate{
+						Config: &hcl.RootConfig{
+							Stack: &hcl.StackRootConfig{
+								Filename: "main.tm",
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name:  "filename is not a string",
+			input: stackCfg(`filename = true`),
+			want: want{
+				errs: []error{errors.E(hcl.ErrTerramateSchema)},
+			},
+		},
+		{
+			name:  "filename with directories",
+			input: stackCfg(`filename = "dir/stack.tm.hcl"`),
+			want: want{
+				errs: []error{errors.E(hcl.ErrTerramateSchema)},
+			},
+		},
+		{
+			name:  "dot filename",
+			input: stackCfg(`filename = ".stack.tm.hcl"`),
+			want: want{
+				errs: []error{errors.E(hcl.ErrTerramateSchema)},
+			},
+		},
+		{
+			name:  "filename without Terramate extension",
+			input: stackCfg(`filename = "stack.hcl"`),
+			want: want{
+				errs: []error{errors.E(hcl.ErrTerramateSchema)},
+			},
+		},
+		{
+			name:  "unrecognized attribute",
+			input: stackCfg(`name = "stack.tm.hcl"`),
+			want: want{
+				errs: []error{errors.E(hcl.ErrTerramateSchema)},
+			},
+		},
+		{
+			name:  "sub blocks are not allowed",
+			input: stackCfg(`files {}`),
+			want: want{
+				errs: []error{errors.E(hcl.ErrTerramateSchema)},
+			},
+		},
+	} {
+		testParser(t, tc)
+	}
+}
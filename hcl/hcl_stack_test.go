@@ -5,6 +5,7 @@ package hcl_test
 
 import (
 	"testing"
+	"time"
 
 	"github.com/terramate-io/terramate/errors"
 	"github.com/terramate-io/terramate/hcl"
@@ -581,6 +582,158 @@ func TestHCLParserStack(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "stack with timeout and priority",
+			input: []cfgfile{
+				{
+					filename: "stack.tm",
+					body: `
+						stack {
+							timeout  = "1h30m"
+							priority = -5
+						}
+					`,
+				},
+			},
+			want: want{
+				config: hcl.Config{
+					Stack: &hcl.Stack{
+						Timeout:  durationPtr(90 * time.Minute),
+						Priority: intPtr(-5),
+					},
+				},
+			},
+		},
+		{
+			name: "stack timeout is not a string - fails",
+			input: []cfgfile{
+				{
+					filename: "stack.tm",
+					body: `
+						stack {
+							timeout = 30
+						}
+					`,
+				},
+			},
+			want: want{
+				errs: []error{
+					errors.E(hcl.ErrTerramateSchema,
+						Mkrange("stack.tm", Start(3, 18, 32), End(3, 20, 34))),
+				},
+			},
+		},
+		{
+			name: "stack timeout with invalid duration - fails",
+			input: []cfgfile{
+				{
+					filename: "stack.tm",
+					body: `
+						stack {
+							timeout = "30 minutes"
+						}
+					`,
+				},
+			},
+			want: want{
+				errs: []error{
+					errors.E(hcl.ErrTerramateSchema),
+				},
+			},
+		},
+		{
+			name: "stack priority is not an integer - fails",
+			input: []cfgfile{
+				{
+					filename: "stack.tm",
+					body: `
+						stack {
+							priority = "high"
+						}
+					`,
+				},
+			},
+			want: want{
+				errs: []error{
+					errors.E(hcl.ErrTerramateSchema),
+				},
+			},
+		},
+		{
+			name: "stack with ignore_on_change",
+			input: []cfgfile{
+				{
+					filename: "stack.tm",
+					body: `
+						stack {
+							ignore_on_change = ["*.md", "docs/**"]
+						}
+					`,
+				},
+			},
+			want: want{
+				config: hcl.Config{
+					Stack: &hcl.Stack{
+						IgnoreOnChange: []string{"*.md", "docs/**"},
+					},
+				},
+			},
+		},
+		{
+			name: "stack ignore_on_change with invalid pattern - fails",
+			input: []cfgfile{
+				{
+					filename: "stack.tm",
+					body: `
+						stack {
+							ignore_on_change = ["docs/[a-"]
+						}
+					`,
+				},
+			},
+			want: want{
+				errs: []error{
+					errors.E(hcl.ErrTerramateSchema,
+						Mkrange("stack.tm", Start(3, 27, 41), End(3, 39, 53))),
+				},
+			},
+		},
+		{
+			name: "stack ignore_on_change with absolute pattern - fails",
+			input: []cfgfile{
+				{
+					filename: "stack.tm",
+					body: `
+						stack {
+							ignore_on_change = ["/docs/**"]
+						}
+					`,
+				},
+			},
+			want: want{
+				errs: []error{
+					errors.E(hcl.ErrTerramateSchema),
+				},
+			},
+		},
+		{
+			name: "stack ignore_on_change is not a set of strings - fails",
+			input: []cfgfile{
+				{
+					filename: "stack.tm",
+					body: `
+						stack {
+							ignore_on_change = "*.md"
+						}
+					`,
+				},
+			},
+			want: want{
+				errs: []error{
+					errors.E(hcl.ErrTerramateSchema),
+				},
+			},
+		},
 	} {
 		testParser(t, tc)
 	}
@@ -594,6 +594,117 @@ func TestHCLParserTerramateBlock(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "terramate.config.sensitive_globals with correct values",
+			input: []cfgfile{
+				{
+					filename: "cfg.tm",
+					body: `
+						terramate {
+						    config {
+								sensitive_globals = ["db_password", "api.*"]
+							}
+						}
+					`,
+				},
+			},
+			want: want{
+				config: hcl.Config{
+					Terramate: &hcl.Terramate{
+						Config: &hcl.RootConfig{
+							SensitiveGlobals: []string{"db_password", "api.*"},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "terramate.config.sensitive_globals with wrong type",
+			input: []cfgfile{
+				{
+					filename: "cfg.tm",
+					body: `
+						terramate {
+						    config {
+								sensitive_globals = "db_password"
+							}
+						}
+					`,
+				},
+			},
+			want: want{
+				errs: []error{
+					errors.E(hcl.ErrTerramateSchema,
+						Mkrange("cfg.tm", Start(4, 29, 66), End(4, 42, 79))),
+				},
+			},
+		},
+		{
+			name: "terramate.config.sensitive_globals with invalid pattern",
+			input: []cfgfile{
+				{
+					filename: "cfg.tm",
+					body: `
+						terramate {
+						    config {
+								sensitive_globals = ["api.[*"]
+							}
+						}
+					`,
+				},
+			},
+			want: want{
+				errs: []error{
+					errors.E(hcl.ErrTerramateSchema,
+						Mkrange("cfg.tm", Start(4, 29, 66), End(4, 39, 76))),
+				},
+			},
+		},
+		{
+			name: "terramate.config.normalize_path_case enabled",
+			input: []cfgfile{
+				{
+					filename: "cfg.tm",
+					body: `
+						terramate {
+						    config {
+								normalize_path_case = true
+							}
+						}
+					`,
+				},
+			},
+			want: want{
+				config: hcl.Config{
+					Terramate: &hcl.Terramate{
+						Config: &hcl.RootConfig{
+							NormalizePathCase: true,
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "terramate.config.normalize_path_case with wrong type",
+			input: []cfgfile{
+				{
+					filename: "cfg.tm",
+					body: `
+						terramate {
+						    config {
+								normalize_path_case = "yes"
+							}
+						}
+					`,
+				},
+			},
+			want: want{
+				errs: []error{
+					errors.E(hcl.ErrTerramateSchema,
+						Mkrange("cfg.tm", Start(4, 31, 68), End(4, 36, 73))),
+				},
+			},
+		},
 	} {
 		testParser(t, tc)
 	}
@@ -775,6 +886,7 @@ func TestHCLParserRootConfig(t *testing.T) {
 									check_untracked         = false
 									check_uncommitted       = false
 									check_remote            = false
+									branch_protection       = true
 								}
 							}
 						}
@@ -791,6 +903,7 @@ func TestHCLParserRootConfig(t *testing.T) {
 								CheckUntracked:   false,
 								CheckUncommitted: false,
 								CheckRemote:      hcl.CheckIsFalse,
+								BranchProtection: true,
 							},
 						},
 					},
@@ -850,19 +963,309 @@ func TestHCLParserRootConfig(t *testing.T) {
 					errors.E(hcl.ErrTerramateSchema,
 						Mkrange("cfg.tm", Start(6, 30, 112), End(6, 33, 115))),
 					errors.E(hcl.ErrTerramateSchema,
-						Mkrange("cfg.tm", Start(7, 30, 145), End(7, 32, 147))),
+						Mkrange("cfg.tm", Start(7, 30, 145), End(7, 32, 147))),
+				},
+			},
+		},
+		{
+			name: "git.branch_protection must be boolean",
+			input: []cfgfile{
+				{
+					filename: "cfg.tm",
+					body: `
+						terramate {
+							config {
+								git {
+									branch_protection = "main"
+								}
+							}
+						}
+					`,
+				},
+			},
+			want: want{
+				errs: []error{
+					errors.E(hcl.ErrTerramateSchema,
+						Mkrange("cfg.tm", Start(5, 30, 78), End(5, 36, 84))),
+				},
+			},
+		},
+		{
+			name: "empty config.cloud block",
+			input: []cfgfile{
+				{
+					filename: "cfg.tm",
+					body: `
+						terramate {
+							config {
+								cloud {}
+							}
+						}
+					`,
+				},
+			},
+			want: want{
+				config: hcl.Config{
+					Terramate: &hcl.Terramate{
+						Config: &hcl.RootConfig{
+							Cloud: &hcl.CloudConfig{
+								Organization: "",
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "basic config.cloud block",
+			input: []cfgfile{
+				{
+					filename: "cfg.tm",
+					body: `
+						terramate {
+							config {
+								cloud {
+									organization = "my-org"
+								}
+							}
+						}
+					`,
+				},
+			},
+			want: want{
+				config: hcl.Config{
+					Terramate: &hcl.Terramate{
+						Config: &hcl.RootConfig{
+							Cloud: &hcl.CloudConfig{
+								Organization: "my-org",
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "terramate.config.generate.hcl_magic_header_comment_style = //",
+			input: []cfgfile{
+				{
+					filename: "cfg.tm",
+					body: `
+						terramate {
+							config {
+								generate {
+									hcl_magic_header_comment_style = "//"
+								}
+							}
+						}
+					`,
+				},
+			},
+			want: want{
+				config: hcl.Config{
+					Terramate: &hcl.Terramate{
+						Config: &hcl.RootConfig{
+							Generate: &hcl.GenerateRootConfig{
+								HCLMagicHeaderCommentStyle: ptr("//"),
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "terramate.config.generate.hcl_magic_header_comment_style = #",
+			input: []cfgfile{
+				{
+					filename: "cfg.tm",
+					body: `
+						terramate {
+							config {
+								generate {
+									hcl_magic_header_comment_style = "#"
+								}
+							}
+						}
+					`,
+				},
+			},
+			want: want{
+				config: hcl.Config{
+					Terramate: &hcl.Terramate{
+						Config: &hcl.RootConfig{
+							Generate: &hcl.GenerateRootConfig{
+								HCLMagicHeaderCommentStyle: ptr("#"),
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "terramate.config.generate.dedup = copy",
+			input: []cfgfile{
+				{
+					filename: "cfg.tm",
+					body: `
+						terramate {
+							config {
+								generate {
+									dedup = "copy"
+								}
+							}
+						}
+					`,
+				},
+			},
+			want: want{
+				config: hcl.Config{
+					Terramate: &hcl.Terramate{
+						Config: &hcl.RootConfig{
+							Generate: &hcl.GenerateRootConfig{
+								Dedup: ptr("copy"),
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "terramate.config.generate.dedup = hardlink",
+			input: []cfgfile{
+				{
+					filename: "cfg.tm",
+					body: `
+						terramate {
+							config {
+								generate {
+									dedup = "hardlink"
+								}
+							}
+						}
+					`,
+				},
+			},
+			want: want{
+				config: hcl.Config{
+					Terramate: &hcl.Terramate{
+						Config: &hcl.RootConfig{
+							Generate: &hcl.GenerateRootConfig{
+								Dedup: ptr("hardlink"),
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "terramate.config.generate.dedup with invalid value",
+			input: []cfgfile{
+				{
+					filename: "cfg.tm",
+					body: `
+						terramate {
+							config {
+								generate {
+									dedup = "symlink"
+								}
+							}
+						}
+					`,
+				},
+			},
+			want: want{
+				errs: []error{
+					errors.E(hcl.ErrTerramateSchema,
+						Mkrange("cfg.tm", Start(5, 18, 71), End(5, 27, 80))),
+				},
+			},
+		},
+		{
+			name: "terramate.config.generate.dedup with wrong type",
+			input: []cfgfile{
+				{
+					filename: "cfg.tm",
+					body: `
+						terramate {
+							config {
+								generate {
+									dedup = true
+								}
+							}
+						}
+					`,
+				},
+			},
+			want: want{
+				errs: []error{
+					errors.E(hcl.ErrTerramateSchema,
+						Mkrange("cfg.tm", Start(5, 18, 71), End(5, 22, 75))),
+				},
+			},
+		},
+		{
+			name: "terramate.config.generate.allow_deletion = true",
+			input: []cfgfile{
+				{
+					filename: "cfg.tm",
+					body: `
+						terramate {
+							config {
+								generate {
+									allow_deletion = true
+								}
+							}
+						}
+					`,
+				},
+			},
+			want: want{
+				config: hcl.Config{
+					Terramate: &hcl.Terramate{
+						Config: &hcl.RootConfig{
+							Generate: &hcl.GenerateRootConfig{
+								AllowDeletion: true,
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "terramate.config.generate.allow_deletion with wrong type",
+			input: []cfgfile{
+				{
+					filename: "cfg.tm",
+					body: `
+						terramate {
+							config {
+								generate {
+									allow_deletion = "yes"
+								}
+							}
+						}
+					`,
+				},
+			},
+			want: want{
+				errs: []error{
+					errors.E(hcl.ErrTerramateSchema,
+						Mkrange("cfg.tm", Start(5, 27, 80), End(5, 32, 85))),
 				},
 			},
 		},
 		{
-			name: "empty config.cloud block",
+			name: "terramate.config.generate.header with defaults",
 			input: []cfgfile{
 				{
 					filename: "cfg.tm",
 					body: `
 						terramate {
 							config {
-								cloud {}
+								generate {
+									header {
+										license = tm_file("LICENSE_HEADER")
+									}
+								}
 							}
 						}
 					`,
@@ -872,8 +1275,10 @@ func TestHCLParserRootConfig(t *testing.T) {
 				config: hcl.Config{
 					Terramate: &hcl.Terramate{
 						Config: &hcl.RootConfig{
-							Cloud: &hcl.CloudConfig{
-								Organization: "",
+							Generate: &hcl.GenerateRootConfig{
+								Header: &hcl.GenerateHeaderConfig{
+									Position: hcl.GenerateHeaderPositionTop,
+								},
 							},
 						},
 					},
@@ -881,15 +1286,18 @@ func TestHCLParserRootConfig(t *testing.T) {
 			},
 		},
 		{
-			name: "basic config.cloud block",
+			name: "terramate.config.generate.header.position = after-shebang",
 			input: []cfgfile{
 				{
 					filename: "cfg.tm",
 					body: `
 						terramate {
 							config {
-								cloud {
-									organization = "my-org"
+								generate {
+									header {
+										license  = "Copyright Acme"
+										position = "after-shebang"
+									}
 								}
 							}
 						}
@@ -900,8 +1308,10 @@ func TestHCLParserRootConfig(t *testing.T) {
 				config: hcl.Config{
 					Terramate: &hcl.Terramate{
 						Config: &hcl.RootConfig{
-							Cloud: &hcl.CloudConfig{
-								Organization: "my-org",
+							Generate: &hcl.GenerateRootConfig{
+								Header: &hcl.GenerateHeaderConfig{
+									Position: hcl.GenerateHeaderPositionAfterShebang,
+								},
 							},
 						},
 					},
@@ -909,7 +1319,7 @@ func TestHCLParserRootConfig(t *testing.T) {
 			},
 		},
 		{
-			name: "terramate.config.generate.hcl_magic_header_comment_style = //",
+			name: "terramate.config.generate.header.position with invalid value",
 			input: []cfgfile{
 				{
 					filename: "cfg.tm",
@@ -917,7 +1327,9 @@ func TestHCLParserRootConfig(t *testing.T) {
 						terramate {
 							config {
 								generate {
-									hcl_magic_header_comment_style = "//"
+									header {
+										position = "bottom"
+									}
 								}
 							}
 						}
@@ -925,19 +1337,14 @@ func TestHCLParserRootConfig(t *testing.T) {
 				},
 			},
 			want: want{
-				config: hcl.Config{
-					Terramate: &hcl.Terramate{
-						Config: &hcl.RootConfig{
-							Generate: &hcl.GenerateRootConfig{
-								HCLMagicHeaderCommentStyle: ptr("//"),
-							},
-						},
-					},
+				errs: []error{
+					errors.E(hcl.ErrTerramateSchema,
+						Mkrange("cfg.tm", Start(6, 22, 93), End(6, 30, 101))),
 				},
 			},
 		},
 		{
-			name: "terramate.config.generate.hcl_magic_header_comment_style = #",
+			name: "terramate.config.generate.header with unrecognized attribute",
 			input: []cfgfile{
 				{
 					filename: "cfg.tm",
@@ -945,7 +1352,9 @@ func TestHCLParserRootConfig(t *testing.T) {
 						terramate {
 							config {
 								generate {
-									hcl_magic_header_comment_style = "#"
+									header {
+										style = "#"
+									}
 								}
 							}
 						}
@@ -953,14 +1362,8 @@ func TestHCLParserRootConfig(t *testing.T) {
 				},
 			},
 			want: want{
-				config: hcl.Config{
-					Terramate: &hcl.Terramate{
-						Config: &hcl.RootConfig{
-							Generate: &hcl.GenerateRootConfig{
-								HCLMagicHeaderCommentStyle: ptr("#"),
-							},
-						},
-					},
+				errs: []error{
+					errors.E(Mkrange("cfg.tm", Start(6, 11, 82), End(6, 16, 87))),
 				},
 			},
 		},
@@ -1128,6 +1531,78 @@ func TestHCLParserRootConfig(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "enabling terramate.config.change_detection.propagate_outputs",
+			input: []cfgfile{
+				{
+					filename: "cfg.tm",
+					body: `
+						terramate {
+						  config {
+						    change_detection {
+							  propagate_outputs = true
+							}
+						  }
+						}
+					`,
+				},
+			},
+			want: want{
+				config: hcl.Config{
+					Terramate: &hcl.Terramate{
+						Config: &hcl.RootConfig{
+							ChangeDetection: &hcl.ChangeDetectionConfig{
+								PropagateOutputs: true,
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "terramate.config.change_detection.propagate_outputs is not a boolean",
+			input: []cfgfile{
+				{
+					filename: "cfg.tm",
+					body: `
+						terramate {
+						  config {
+						    change_detection {
+							  propagate_outputs = "true"
+							}
+						  }
+						}
+					`,
+				},
+			},
+			want: want{
+				errs: []error{
+					errors.E(hcl.ErrTerramateSchema),
+				},
+			},
+		},
+		{
+			name: "unrecognized attribute in terramate.config.change_detection",
+			input: []cfgfile{
+				{
+					filename: "cfg.tm",
+					body: `
+						terramate {
+						  config {
+						    change_detection {
+							  something = true
+							}
+						  }
+						}
+					`,
+				},
+			},
+			want: want{
+				errs: []error{
+					errors.E(hcl.ErrTerramateSchema),
+				},
+			},
+		},
 		{
 			name: "disabling terramate.config.telemetry with string",
 			input: []cfgfile{
@@ -1184,6 +1659,78 @@ func TestHCLParserRootConfig(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "disabling terramate.config.checkpoint",
+			input: []cfgfile{
+				{
+					filename: "cfg.tm",
+					body: `
+						terramate {
+						  config {
+						    checkpoint {
+							  enabled = false
+							}
+						  }
+						}
+					`,
+				},
+			},
+			want: want{
+				config: hcl.Config{
+					Terramate: &hcl.Terramate{
+						Config: &hcl.RootConfig{
+							Checkpoint: &hcl.CheckpointConfig{
+								Enabled: &off,
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "terramate.config.checkpoint.enabled with string fails",
+			input: []cfgfile{
+				{
+					filename: "cfg.tm",
+					body: `
+						terramate {
+						  config {
+						    checkpoint {
+							  enabled = "off"
+							}
+						  }
+						}
+					`,
+				},
+			},
+			want: want{
+				errs: []error{
+					errors.E(hcl.ErrTerramateSchema),
+				},
+			},
+		},
+		{
+			name: "unrecognized attribute in terramate.config.checkpoint fails",
+			input: []cfgfile{
+				{
+					filename: "cfg.tm",
+					body: `
+						terramate {
+						  config {
+						    checkpoint {
+							  signature = false
+							}
+						  }
+						}
+					`,
+				},
+			},
+			want: want{
+				errs: []error{
+					errors.E(hcl.ErrTerramateSchema),
+				},
+			},
+		},
 	} {
 		testParser(t, tc)
 	}
@@ -1506,6 +2053,101 @@ func TestHCLParserGenerateStackFilters(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "generate_hcl - depth with unknown operator",
+			input: []cfgfile{
+				{
+					filename: "gen.tm",
+					body: `
+						generate_hcl "test.tf" {
+							stack_filter { depth = "=> 3" }
+							content { foo = "bar" }
+						}
+					`,
+				},
+			},
+			want: want{
+				errs: []error{
+					errors.E(hcl.ErrTerramateSchema),
+				},
+			},
+		},
+		{
+			name: "generate_hcl - negative depth",
+			input: []cfgfile{
+				{
+					filename: "gen.tm",
+					body: `
+						generate_hcl "test.tf" {
+							stack_filter { depth = -1 }
+							content { foo = "bar" }
+						}
+					`,
+				},
+			},
+			want: want{
+				errs: []error{
+					errors.E(hcl.ErrTerramateSchema),
+				},
+			},
+		},
+		{
+			name: "generate_hcl - fractional depth",
+			input: []cfgfile{
+				{
+					filename: "gen.tm",
+					body: `
+						generate_hcl "test.tf" {
+							stack_filter { depth = 1.5 }
+							content { foo = "bar" }
+						}
+					`,
+				},
+			},
+			want: want{
+				errs: []error{
+					errors.E(hcl.ErrTerramateSchema),
+				},
+			},
+		},
+		{
+			name: "generate_hcl - depth is not a number nor string",
+			input: []cfgfile{
+				{
+					filename: "gen.tm",
+					body: `
+						generate_hcl "test.tf" {
+							stack_filter { depth = [3] }
+							content { foo = "bar" }
+						}
+					`,
+				},
+			},
+			want: want{
+				errs: []error{
+					errors.E(hcl.ErrTerramateSchema),
+				},
+			},
+		},
+		{
+			name: "generate_hcl - is_leaf is not boolean",
+			input: []cfgfile{
+				{
+					filename: "gen.tm",
+					body: `
+						generate_hcl "test.tf" {
+							stack_filter { is_leaf = "true" }
+							content { foo = "bar" }
+						}
+					`,
+				},
+			},
+			want: want{
+				errs: []error{
+					errors.E(hcl.ErrTerramateSchema),
+				},
+			},
+		},
 		{
 			name: "generate_file - invalid context",
 			input: []cfgfile{
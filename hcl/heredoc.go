@@ -0,0 +1,176 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package hcl
+
+import (
+	"bytes"
+	"strings"
+	"unicode"
+
+	"github.com/terramate-io/hcl/v2"
+	"github.com/terramate-io/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// FixFlushHeredocs fixes the flush heredocs of all the expressions of the
+// given file, parsed from src. The Terramate configuration files are already
+// fixed by the [TerramateParser].
+func FixFlushHeredocs(file *hcl.File, src []byte) {
+	body, ok := file.Body.(*hclsyntax.Body)
+	if !ok {
+		return
+	}
+	fixFlushHeredocs(body, src)
+}
+
+// fixFlushHeredocs fixes the indentation of the flush heredocs (<<-EOT)
+// found in the given node and its children.
+//
+// The HCL parser applies the strip markers of the template directives (eg.:
+// %{ for x in list ~}) before removing the indentation of the heredoc lines,
+// then a line following a strip marker is not recognized as the start of a
+// line and keeps its indentation. Here the literals of the template are
+// rebuilt from the source, removing the indentation of every line.
+func fixFlushHeredocs(node hclsyntax.Node, src []byte) {
+	_ = hclsyntax.VisitAll(node, func(node hclsyntax.Node) hcl.Diagnostics {
+		tmpl, ok := node.(*hclsyntax.TemplateExpr)
+		if !ok {
+			return nil
+		}
+		rng := tmpl.SrcRange
+		if rng.End.Byte > len(src) || !bytes.HasPrefix(src[rng.Start.Byte:], []byte("<<-")) {
+			return nil
+		}
+		body := src[rng.Start.Byte:rng.End.Byte]
+		start := bytes.IndexByte(body, '\n')
+		end := bytes.LastIndexByte(body, '\n')
+		if start == -1 || start == end {
+			return nil
+		}
+		indent := heredocIndentation(string(body[start+1 : end+1]))
+		if indent == 0 {
+			return nil
+		}
+		fixTemplateLiterals(tmpl, src, indent)
+		return nil
+	})
+}
+
+// fixTemplateLiterals rebuilds the literals of the template, including the
+// ones of nested template directives, removing indent characters from the
+// start of each line.
+func fixTemplateLiterals(tmpl *hclsyntax.TemplateExpr, src []byte, indent int) {
+	for _, part := range tmpl.Parts {
+		switch p := part.(type) {
+		case *hclsyntax.LiteralValueExpr:
+			fixTemplateLiteral(p, src, indent)
+		case *hclsyntax.TemplateJoinExpr:
+			// %{ for ... } directive
+			forExpr, ok := p.Tuple.(*hclsyntax.ForExpr)
+			if !ok || !isTemplateDirective(p, src) {
+				continue
+			}
+			if body, ok := forExpr.ValExpr.(*hclsyntax.TemplateExpr); ok {
+				fixTemplateLiterals(body, src, indent)
+			}
+		case *hclsyntax.ConditionalExpr:
+			// %{ if ... } directive, conditionals inside ${} are ignored.
+			if !isTemplateDirective(p, src) {
+				continue
+			}
+			for _, result := range []hclsyntax.Expression{p.TrueResult, p.FalseResult} {
+				if body, ok := result.(*hclsyntax.TemplateExpr); ok {
+					fixTemplateLiterals(body, src, indent)
+				}
+			}
+		}
+	}
+}
+
+// fixTemplateLiteral rebuilds the literal value from its source. A strip
+// marker on the left (~}) removes the whitespace up to the end of the line,
+// including the newline, and a strip marker on the right (%{~ or ${~) removes
+// all the trailing whitespace. The remaining lines have the heredoc
+// indentation removed.
+func fixTemplateLiteral(lit *hclsyntax.LiteralValueExpr, src []byte, indent int) {
+	if !lit.Val.Type().Equals(cty.String) || lit.Val.IsNull() {
+		return
+	}
+	start, end := lit.SrcRange.Start.Byte, lit.SrcRange.End.Byte
+	if start > end || end > len(src) {
+		return
+	}
+
+	// The heredoc lines are scanned separately, then the whitespace before a
+	// strip marker may belong to the next line.
+	ltrim := bytes.HasSuffix(src[:start], []byte("~}"))
+	next := bytes.TrimLeft(src[end:], " \t\r\n")
+	rtrim := bytes.HasPrefix(next, []byte("%{~")) || bytes.HasPrefix(next, []byte("${~"))
+
+	// the parser already removed the indentation of the literals starting a
+	// line, then it's included again to be handled like the other lines.
+	lineStart := bytes.LastIndexByte(src[:start], '\n') + 1
+	if len(bytes.TrimLeft(src[lineStart:start], " \t")) == 0 {
+		start = lineStart
+	}
+	atLineStart := start == lineStart
+
+	lines := strings.SplitAfter(string(src[start:end]), "\n")
+	for i, line := range lines {
+		if i == 0 && ltrim {
+			lines[i] = strings.TrimLeftFunc(line, unicode.IsSpace)
+			continue
+		}
+		if i == 0 && !atLineStart {
+			continue
+		}
+		if strings.HasSuffix(line, "\n") && strings.TrimSpace(line) == "" {
+			// blank lines are kept as is, like the parser does.
+			continue
+		}
+		lines[i] = removeIndentation(line, indent)
+	}
+	val := strings.Join(lines, "")
+	if rtrim {
+		val = strings.TrimRightFunc(val, unicode.IsSpace)
+	}
+	val = strings.ReplaceAll(val, "$${", "${")
+	val = strings.ReplaceAll(val, "%%{", "%{")
+	lit.Val = cty.StringVal(val)
+}
+
+// heredocIndentation returns the smallest number of whitespace characters
+// prefixing the non-blank lines of the heredoc body.
+func heredocIndentation(body string) int {
+	indent := -1
+	for _, line := range strings.Split(body, "\n") {
+		trimmed := strings.TrimLeftFunc(line, unicode.IsSpace)
+		if trimmed == "" {
+			continue
+		}
+		spaces := len([]rune(line[:len(line)-len(trimmed)]))
+		if indent == -1 || spaces < indent {
+			indent = spaces
+		}
+	}
+	if indent == -1 {
+		return 0
+	}
+	return indent
+}
+
+func removeIndentation(line string, indent int) string {
+	for i, r := range line {
+		if indent == 0 || r == '\n' || !unicode.IsSpace(r) {
+			return line[i:]
+		}
+		indent--
+	}
+	return ""
+}
+
+func isTemplateDirective(expr hclsyntax.Expression, src []byte) bool {
+	start := expr.Range().Start.Byte
+	return start < len(src) && bytes.HasPrefix(src[start:], []byte("%{"))
+}
@@ -0,0 +1,57 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package hcl
+
+import (
+	"path/filepath"
+	"sync"
+
+	"github.com/terramate-io/terramate/hcl/parsecache"
+)
+
+// ParseCacheDir is the directory, relative to the project root, where the
+// parsed files are cached.
+const ParseCacheDir = ".terramate/cache/parse"
+
+// parserRevision identifies the changes applied by the parser to the syntax
+// trees, like the heredoc fixes. It must be bumped whenever they change, so
+// the cache entries of the same Terramate version produced before the change
+// are not used.
+const parserRevision = "1"
+
+var parseCacheCfg struct {
+	sync.RWMutex
+	enabled bool
+	version string
+}
+
+// EnableParseCache enables the on-disk cache of parsed files for all parsers
+// created afterwards. The cache is stored in the [ParseCacheDir] of the project
+// root and its entries are invalidated when the version changes.
+func EnableParseCache(version string) {
+	parseCacheCfg.Lock()
+	defer parseCacheCfg.Unlock()
+	parseCacheCfg.enabled = true
+	parseCacheCfg.version = version
+}
+
+// DisableParseCache disables the on-disk cache of parsed files.
+func DisableParseCache() {
+	parseCacheCfg.Lock()
+	defer parseCacheCfg.Unlock()
+	parseCacheCfg.enabled = false
+	parseCacheCfg.version = ""
+}
+
+func newParseCache(rootdir string) *parsecache.Cache {
+	parseCacheCfg.RLock()
+	defer parseCacheCfg.RUnlock()
+	if !parseCacheCfg.enabled {
+		return nil
+	}
+	return parsecache.New(
+		filepath.Join(rootdir, filepath.FromSlash(ParseCacheDir)),
+		parseCacheCfg.version+"+parser."+parserRevision,
+	)
+}
@@ -0,0 +1,760 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package parsecache
+
+import (
+	"encoding/binary"
+	"math/big"
+	"sort"
+
+	"github.com/terramate-io/hcl/v2"
+	"github.com/terramate-io/hcl/v2/hclsyntax"
+	"github.com/terramate-io/terramate/errors"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// The syntax tree is encoded in a compact binary format. All ranges of a file
+// share the same filename, so the filename is stored only once per entry and
+// restored when decoding.
+
+type exprKind byte
+
+const (
+	// nilExpr is used for optional expressions (eg.: the condition of a for).
+	nilExpr exprKind = iota
+	literalExpr
+	scopeTraversalExpr
+	relativeTraversalExpr
+	funcallExpr
+	conditionalExpr
+	indexExpr
+	tupleExpr
+	objectExpr
+	objectKeyExpr
+	forExpr
+	splatExpr
+	anonSymbolExpr
+	binaryOpExpr
+	unaryOpExpr
+	templateExpr
+	templateJoinExpr
+	templateWrapExpr
+	parenthesesExpr
+)
+
+type valueKind byte
+
+const (
+	nullValue valueKind = iota
+	stringValue
+	numberValue
+	boolValue
+)
+
+type traverserKind byte
+
+const (
+	traverseRoot traverserKind = iota
+	traverseAttr
+	traverseIndex
+)
+
+var operations = []*hclsyntax.Operation{
+	hclsyntax.OpLogicalOr,
+	hclsyntax.OpLogicalAnd,
+	hclsyntax.OpLogicalNot,
+	hclsyntax.OpEqual,
+	hclsyntax.OpNotEqual,
+	hclsyntax.OpGreaterThan,
+	hclsyntax.OpGreaterThanOrEqual,
+	hclsyntax.OpLessThan,
+	hclsyntax.OpLessThanOrEqual,
+	hclsyntax.OpAdd,
+	hclsyntax.OpSubtract,
+	hclsyntax.OpMultiply,
+	hclsyntax.OpDivide,
+	hclsyntax.OpModulo,
+	hclsyntax.OpNegate,
+}
+
+type encoder struct {
+	buf      []byte
+	filename string
+	anons    map[*hclsyntax.AnonSymbolExpr]uint64
+}
+
+func (e *encoder) uint(v uint64) {
+	e.buf = binary.AppendUvarint(e.buf, v)
+}
+
+func (e *encoder) int(v int) {
+	e.buf = binary.AppendVarint(e.buf, int64(v))
+}
+
+func (e *encoder) byte(b byte) {
+	e.buf = append(e.buf, b)
+}
+
+func (e *encoder) bool(b bool) {
+	if b {
+		e.byte(1)
+	} else {
+		e.byte(0)
+	}
+}
+
+func (e *encoder) bytes(data []byte) {
+	e.uint(uint64(len(data)))
+	e.buf = append(e.buf, data...)
+}
+
+func (e *encoder) string(s string) {
+	e.uint(uint64(len(s)))
+	e.buf = append(e.buf, s...)
+}
+
+func (e *encoder) rng(r hcl.Range) error {
+	if r.Filename != e.filename {
+		return errors.E("range from file %q found while encoding %q", r.Filename, e.filename)
+	}
+	e.int(r.Start.Line)
+	e.int(r.Start.Column)
+	e.int(r.Start.Byte)
+	e.int(r.End.Line)
+	e.int(r.End.Column)
+	e.int(r.End.Byte)
+	return nil
+}
+
+func (e *encoder) rngs(rs ...hcl.Range) error {
+	for _, r := range rs {
+		if err := e.rng(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *encoder) body(b *hclsyntax.Body) error {
+	if err := e.rngs(b.SrcRange, b.EndRange); err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(b.Attributes))
+	for name := range b.Attributes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	e.uint(uint64(len(names)))
+	for _, name := range names {
+		attr := b.Attributes[name]
+		e.string(attr.Name)
+		if err := e.expr(attr.Expr); err != nil {
+			return err
+		}
+		if err := e.rngs(attr.SrcRange, attr.NameRange, attr.EqualsRange); err != nil {
+			return err
+		}
+	}
+
+	e.uint(uint64(len(b.Blocks)))
+	for _, blk := range b.Blocks {
+		e.string(blk.Type)
+		e.uint(uint64(len(blk.Labels)))
+		for _, label := range blk.Labels {
+			e.string(label)
+		}
+		e.uint(uint64(len(blk.LabelRanges)))
+		if err := e.rngs(blk.LabelRanges...); err != nil {
+			return err
+		}
+		if err := e.rngs(blk.TypeRange, blk.OpenBraceRange, blk.CloseBraceRange); err != nil {
+			return err
+		}
+		if err := e.body(blk.Body); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *encoder) exprs(exprs ...hclsyntax.Expression) error {
+	for _, ex := range exprs {
+		if err := e.expr(ex); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *encoder) exprList(exprs []hclsyntax.Expression) error {
+	e.uint(uint64(len(exprs)))
+	return e.exprs(exprs...)
+}
+
+func (e *encoder) expr(ex hclsyntax.Expression) error {
+	if ex == nil {
+		e.byte(byte(nilExpr))
+		return nil
+	}
+
+	switch ex := ex.(type) {
+	case *hclsyntax.LiteralValueExpr:
+		e.byte(byte(literalExpr))
+		if err := e.value(ex.Val); err != nil {
+			return err
+		}
+		return e.rng(ex.SrcRange)
+	case *hclsyntax.ScopeTraversalExpr:
+		e.byte(byte(scopeTraversalExpr))
+		if err := e.traversal(ex.Traversal); err != nil {
+			return err
+		}
+		return e.rng(ex.SrcRange)
+	case *hclsyntax.RelativeTraversalExpr:
+		e.byte(byte(relativeTraversalExpr))
+		if err := e.traversal(ex.Traversal); err != nil {
+			return err
+		}
+		if err := e.expr(ex.Source); err != nil {
+			return err
+		}
+		return e.rng(ex.SrcRange)
+	case *hclsyntax.FunctionCallExpr:
+		e.byte(byte(funcallExpr))
+		e.string(ex.Name)
+		e.bool(ex.ExpandFinal)
+		if err := e.exprList(ex.Args); err != nil {
+			return err
+		}
+		return e.rngs(ex.NameRange, ex.OpenParenRange, ex.CloseParenRange)
+	case *hclsyntax.ConditionalExpr:
+		e.byte(byte(conditionalExpr))
+		if err := e.exprs(ex.Condition, ex.TrueResult, ex.FalseResult); err != nil {
+			return err
+		}
+		return e.rng(ex.SrcRange)
+	case *hclsyntax.IndexExpr:
+		e.byte(byte(indexExpr))
+		if err := e.exprs(ex.Collection, ex.Key); err != nil {
+			return err
+		}
+		return e.rngs(ex.SrcRange, ex.OpenRange, ex.BracketRange)
+	case *hclsyntax.TupleConsExpr:
+		e.byte(byte(tupleExpr))
+		if err := e.exprList(ex.Exprs); err != nil {
+			return err
+		}
+		return e.rngs(ex.SrcRange, ex.OpenRange)
+	case *hclsyntax.ObjectConsExpr:
+		e.byte(byte(objectExpr))
+		e.uint(uint64(len(ex.Items)))
+		for _, item := range ex.Items {
+			if err := e.exprs(item.KeyExpr, item.ValueExpr); err != nil {
+				return err
+			}
+		}
+		return e.rngs(ex.SrcRange, ex.OpenRange)
+	case *hclsyntax.ObjectConsKeyExpr:
+		e.byte(byte(objectKeyExpr))
+		e.bool(ex.ForceNonLiteral)
+		return e.expr(ex.Wrapped)
+	case *hclsyntax.ForExpr:
+		e.byte(byte(forExpr))
+		e.string(ex.KeyVar)
+		e.string(ex.ValVar)
+		e.bool(ex.Group)
+		if err := e.exprs(ex.CollExpr, ex.KeyExpr, ex.ValExpr, ex.CondExpr); err != nil {
+			return err
+		}
+		return e.rngs(ex.SrcRange, ex.OpenRange, ex.CloseRange)
+	case *hclsyntax.SplatExpr:
+		e.byte(byte(splatExpr))
+		var item hclsyntax.Expression
+		if ex.Item != nil {
+			item = ex.Item
+		}
+		if err := e.exprs(ex.Source, ex.Each, item); err != nil {
+			return err
+		}
+		return e.rngs(ex.SrcRange, ex.MarkerRange)
+	case *hclsyntax.AnonSymbolExpr:
+		// the same symbol is referenced by the splat expression and its
+		// "Each" expression, so identity must be preserved.
+		e.byte(byte(anonSymbolExpr))
+		id, ok := e.anons[ex]
+		if !ok {
+			id = uint64(len(e.anons))
+			e.anons[ex] = id
+		}
+		e.uint(id)
+		return e.rng(ex.SrcRange)
+	case *hclsyntax.BinaryOpExpr:
+		e.byte(byte(binaryOpExpr))
+		if err := e.operation(ex.Op); err != nil {
+			return err
+		}
+		if err := e.exprs(ex.LHS, ex.RHS); err != nil {
+			return err
+		}
+		return e.rng(ex.SrcRange)
+	case *hclsyntax.UnaryOpExpr:
+		e.byte(byte(unaryOpExpr))
+		if err := e.operation(ex.Op); err != nil {
+			return err
+		}
+		if err := e.expr(ex.Val); err != nil {
+			return err
+		}
+		return e.rngs(ex.SrcRange, ex.SymbolRange)
+	case *hclsyntax.TemplateExpr:
+		e.byte(byte(templateExpr))
+		if err := e.exprList(ex.Parts); err != nil {
+			return err
+		}
+		return e.rng(ex.SrcRange)
+	case *hclsyntax.TemplateJoinExpr:
+		e.byte(byte(templateJoinExpr))
+		return e.expr(ex.Tuple)
+	case *hclsyntax.TemplateWrapExpr:
+		e.byte(byte(templateWrapExpr))
+		if err := e.expr(ex.Wrapped); err != nil {
+			return err
+		}
+		return e.rng(ex.SrcRange)
+	case *hclsyntax.ParenthesesExpr:
+		e.byte(byte(parenthesesExpr))
+		if err := e.expr(ex.Expression); err != nil {
+			return err
+		}
+		return e.rng(ex.SrcRange)
+	}
+	return errors.E("unsupported expression type %T", ex)
+}
+
+func (e *encoder) traversal(traversal hcl.Traversal) error {
+	e.uint(uint64(len(traversal)))
+	for _, step := range traversal {
+		switch step := step.(type) {
+		case hcl.TraverseRoot:
+			e.byte(byte(traverseRoot))
+			e.string(step.Name)
+			if err := e.rng(step.SrcRange); err != nil {
+				return err
+			}
+		case hcl.TraverseAttr:
+			e.byte(byte(traverseAttr))
+			e.string(step.Name)
+			if err := e.rng(step.SrcRange); err != nil {
+				return err
+			}
+		case hcl.TraverseIndex:
+			e.byte(byte(traverseIndex))
+			if err := e.value(step.Key); err != nil {
+				return err
+			}
+			if err := e.rng(step.SrcRange); err != nil {
+				return err
+			}
+		default:
+			return errors.E("unsupported traverser type %T", step)
+		}
+	}
+	return nil
+}
+
+func (e *encoder) value(val cty.Value) error {
+	if val.IsMarked() || !val.IsKnown() {
+		return errors.E("unsupported literal value %#v", val)
+	}
+	if val.IsNull() {
+		if val.Type() != cty.DynamicPseudoType {
+			return errors.E("unsupported null literal of type %s", val.Type().FriendlyName())
+		}
+		e.byte(byte(nullValue))
+		return nil
+	}
+	switch val.Type() {
+	case cty.String:
+		e.byte(byte(stringValue))
+		e.string(val.AsString())
+		return nil
+	case cty.Bool:
+		e.byte(byte(boolValue))
+		e.bool(val.True())
+		return nil
+	case cty.Number:
+		data, err := val.AsBigFloat().GobEncode()
+		if err != nil {
+			return errors.E(err, "encoding number")
+		}
+		e.byte(byte(numberValue))
+		e.bytes(data)
+		return nil
+	}
+	return errors.E("unsupported literal of type %s", val.Type().FriendlyName())
+}
+
+func (e *encoder) operation(op *hclsyntax.Operation) error {
+	for i, candidate := range operations {
+		if candidate == op {
+			e.uint(uint64(i))
+			return nil
+		}
+	}
+	return errors.E("unsupported operation")
+}
+
+// decoder decodes the binary format produced by the encoder.
+// The first decoding error is sticky, all subsequent reads return zero values.
+type decoder struct {
+	data     []byte
+	err      error
+	filename string
+	anons    map[uint64]*hclsyntax.AnonSymbolExpr
+}
+
+var errCorrupted = errors.E("corrupted cache entry")
+
+func (d *decoder) fail() {
+	if d.err == nil {
+		d.err = errCorrupted
+	}
+	d.data = nil
+}
+
+func (d *decoder) uint() uint64 {
+	v, n := binary.Uvarint(d.data)
+	if n <= 0 {
+		d.fail()
+		return 0
+	}
+	d.data = d.data[n:]
+	return v
+}
+
+func (d *decoder) int() int {
+	v, n := binary.Varint(d.data)
+	if n <= 0 {
+		d.fail()
+		return 0
+	}
+	d.data = d.data[n:]
+	return int(v)
+}
+
+// len reads a length prefix, validating that at least min bytes per element
+// are still available so corrupted entries cannot trigger huge allocations.
+func (d *decoder) len(min int) int {
+	n := d.uint()
+	if n > uint64(len(d.data)/min) {
+		d.fail()
+		return 0
+	}
+	return int(n)
+}
+
+func (d *decoder) byte() byte {
+	if len(d.data) == 0 {
+		d.fail()
+		return 0
+	}
+	b := d.data[0]
+	d.data = d.data[1:]
+	return b
+}
+
+func (d *decoder) bool() bool {
+	switch d.byte() {
+	case 0:
+		return false
+	case 1:
+		return true
+	}
+	d.fail()
+	return false
+}
+
+func (d *decoder) bytes() []byte {
+	n := d.len(1)
+	if d.err != nil {
+		return nil
+	}
+	res := d.data[:n:n]
+	d.data = d.data[n:]
+	return res
+}
+
+func (d *decoder) string() string {
+	return string(d.bytes())
+}
+
+func (d *decoder) rng() hcl.Range {
+	return hcl.Range{
+		Filename: d.filename,
+		Start:    hcl.Pos{Line: d.int(), Column: d.int(), Byte: d.int()},
+		End:      hcl.Pos{Line: d.int(), Column: d.int(), Byte: d.int()},
+	}
+}
+
+func (d *decoder) body() *hclsyntax.Body {
+	res := &hclsyntax.Body{
+		SrcRange: d.rng(),
+		EndRange: d.rng(),
+	}
+
+	nattrs := d.len(1)
+	res.Attributes = make(hclsyntax.Attributes, nattrs)
+	for i := 0; i < nattrs && d.err == nil; i++ {
+		attr := &hclsyntax.Attribute{
+			Name: d.string(),
+			Expr: d.expr(),
+		}
+		attr.SrcRange = d.rng()
+		attr.NameRange = d.rng()
+		attr.EqualsRange = d.rng()
+		if attr.Expr == nil {
+			d.fail()
+		}
+		res.Attributes[attr.Name] = attr
+	}
+
+	nblocks := d.len(1)
+	res.Blocks = make(hclsyntax.Blocks, 0, nblocks)
+	for i := 0; i < nblocks && d.err == nil; i++ {
+		blk := &hclsyntax.Block{
+			Type: d.string(),
+		}
+		nlabels := d.len(1)
+		for j := 0; j < nlabels && d.err == nil; j++ {
+			blk.Labels = append(blk.Labels, d.string())
+		}
+		nlabelRanges := d.len(6)
+		for j := 0; j < nlabelRanges && d.err == nil; j++ {
+			blk.LabelRanges = append(blk.LabelRanges, d.rng())
+		}
+		blk.TypeRange = d.rng()
+		blk.OpenBraceRange = d.rng()
+		blk.CloseBraceRange = d.rng()
+		blk.Body = d.body()
+		res.Blocks = append(res.Blocks, blk)
+	}
+	return res
+}
+
+func (d *decoder) exprList() []hclsyntax.Expression {
+	n := d.len(1)
+	// the parser leaves empty lists of expressions as nil.
+	var res []hclsyntax.Expression
+	for i := 0; i < n && d.err == nil; i++ {
+		res = append(res, d.expr())
+	}
+	return res
+}
+
+func (d *decoder) expr() hclsyntax.Expression {
+	if d.err != nil {
+		return nil
+	}
+	switch exprKind(d.byte()) {
+	case nilExpr:
+		return nil
+	case literalExpr:
+		return &hclsyntax.LiteralValueExpr{
+			Val:      d.value(),
+			SrcRange: d.rng(),
+		}
+	case scopeTraversalExpr:
+		return &hclsyntax.ScopeTraversalExpr{
+			Traversal: d.traversal(),
+			SrcRange:  d.rng(),
+		}
+	case relativeTraversalExpr:
+		return &hclsyntax.RelativeTraversalExpr{
+			Traversal: d.traversal(),
+			Source:    d.expr(),
+			SrcRange:  d.rng(),
+		}
+	case funcallExpr:
+		return &hclsyntax.FunctionCallExpr{
+			Name:            d.string(),
+			ExpandFinal:     d.bool(),
+			Args:            d.exprList(),
+			NameRange:       d.rng(),
+			OpenParenRange:  d.rng(),
+			CloseParenRange: d.rng(),
+		}
+	case conditionalExpr:
+		return &hclsyntax.ConditionalExpr{
+			Condition:   d.expr(),
+			TrueResult:  d.expr(),
+			FalseResult: d.expr(),
+			SrcRange:    d.rng(),
+		}
+	case indexExpr:
+		return &hclsyntax.IndexExpr{
+			Collection:   d.expr(),
+			Key:          d.expr(),
+			SrcRange:     d.rng(),
+			OpenRange:    d.rng(),
+			BracketRange: d.rng(),
+		}
+	case tupleExpr:
+		return &hclsyntax.TupleConsExpr{
+			Exprs:     d.exprList(),
+			SrcRange:  d.rng(),
+			OpenRange: d.rng(),
+		}
+	case objectExpr:
+		nitems := d.len(2)
+		var items []hclsyntax.ObjectConsItem
+		for i := 0; i < nitems && d.err == nil; i++ {
+			items = append(items, hclsyntax.ObjectConsItem{
+				KeyExpr:   d.expr(),
+				ValueExpr: d.expr(),
+			})
+		}
+		return &hclsyntax.ObjectConsExpr{
+			Items:     items,
+			SrcRange:  d.rng(),
+			OpenRange: d.rng(),
+		}
+	case objectKeyExpr:
+		return &hclsyntax.ObjectConsKeyExpr{
+			ForceNonLiteral: d.bool(),
+			Wrapped:         d.expr(),
+		}
+	case forExpr:
+		return &hclsyntax.ForExpr{
+			KeyVar:     d.string(),
+			ValVar:     d.string(),
+			Group:      d.bool(),
+			CollExpr:   d.expr(),
+			KeyExpr:    d.expr(),
+			ValExpr:    d.expr(),
+			CondExpr:   d.expr(),
+			SrcRange:   d.rng(),
+			OpenRange:  d.rng(),
+			CloseRange: d.rng(),
+		}
+	case splatExpr:
+		res := &hclsyntax.SplatExpr{
+			Source: d.expr(),
+			Each:   d.expr(),
+		}
+		if item := d.expr(); item != nil {
+			anon, ok := item.(*hclsyntax.AnonSymbolExpr)
+			if !ok {
+				d.fail()
+				return nil
+			}
+			res.Item = anon
+		}
+		res.SrcRange = d.rng()
+		res.MarkerRange = d.rng()
+		return res
+	case anonSymbolExpr:
+		id := d.uint()
+		srcRange := d.rng()
+		if anon, ok := d.anons[id]; ok {
+			return anon
+		}
+		anon := &hclsyntax.AnonSymbolExpr{SrcRange: srcRange}
+		d.anons[id] = anon
+		return anon
+	case binaryOpExpr:
+		return &hclsyntax.BinaryOpExpr{
+			Op:       d.operation(),
+			LHS:      d.expr(),
+			RHS:      d.expr(),
+			SrcRange: d.rng(),
+		}
+	case unaryOpExpr:
+		return &hclsyntax.UnaryOpExpr{
+			Op:          d.operation(),
+			Val:         d.expr(),
+			SrcRange:    d.rng(),
+			SymbolRange: d.rng(),
+		}
+	case templateExpr:
+		return &hclsyntax.TemplateExpr{
+			Parts:    d.exprList(),
+			SrcRange: d.rng(),
+		}
+	case templateJoinExpr:
+		return &hclsyntax.TemplateJoinExpr{
+			Tuple: d.expr(),
+		}
+	case templateWrapExpr:
+		return &hclsyntax.TemplateWrapExpr{
+			Wrapped:  d.expr(),
+			SrcRange: d.rng(),
+		}
+	case parenthesesExpr:
+		return &hclsyntax.ParenthesesExpr{
+			Expression: d.expr(),
+			SrcRange:   d.rng(),
+		}
+	}
+	d.fail()
+	return nil
+}
+
+func (d *decoder) traversal() hcl.Traversal {
+	n := d.len(1)
+	res := make(hcl.Traversal, 0, n)
+	for i := 0; i < n && d.err == nil; i++ {
+		switch traverserKind(d.byte()) {
+		case traverseRoot:
+			res = append(res, hcl.TraverseRoot{
+				Name:     d.string(),
+				SrcRange: d.rng(),
+			})
+		case traverseAttr:
+			res = append(res, hcl.TraverseAttr{
+				Name:     d.string(),
+				SrcRange: d.rng(),
+			})
+		case traverseIndex:
+			res = append(res, hcl.TraverseIndex{
+				Key:      d.value(),
+				SrcRange: d.rng(),
+			})
+		default:
+			d.fail()
+		}
+	}
+	return res
+}
+
+func (d *decoder) value() cty.Value {
+	switch valueKind(d.byte()) {
+	case nullValue:
+		return cty.NullVal(cty.DynamicPseudoType)
+	case stringValue:
+		return cty.StringVal(d.string())
+	case boolValue:
+		return cty.BoolVal(d.bool())
+	case numberValue:
+		data := d.bytes()
+		f := new(big.Float)
+		if d.err != nil || f.GobDecode(data) != nil {
+			d.fail()
+			return cty.NilVal
+		}
+		return cty.NumberVal(f)
+	}
+	d.fail()
+	return cty.NilVal
+}
+
+func (d *decoder) operation() *hclsyntax.Operation {
+	index := d.uint()
+	if index >= uint64(len(operations)) {
+		d.fail()
+		return nil
+	}
+	return operations[index]
+}
@@ -0,0 +1,10 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package parsecache_test
+
+import "github.com/rs/zerolog"
+
+func init() {
+	zerolog.SetGlobalLevel(zerolog.Disabled)
+}
@@ -0,0 +1,198 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+// Package parsecache implements an on-disk cache of parsed HCL files.
+//
+// Each cached entry stores the syntax tree of a single file together with the
+// hash of the file content and the version of Terramate which produced it.
+// An entry is only used if both match, otherwise the file must be parsed again
+// and the entry is rewritten. Any failure reading or decoding an entry is
+// handled as a cache miss.
+package parsecache
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+
+	"github.com/rs/zerolog/log"
+	"github.com/terramate-io/hcl/v2"
+	"github.com/terramate-io/hcl/v2/hclsyntax"
+	"github.com/terramate-io/terramate/errors"
+)
+
+// formatVersion is the version of the cache entry encoding. It must be bumped
+// whenever the encoding changes in an incompatible way.
+const formatVersion = 1
+
+// magic identifies the cache entry files.
+const magic = "TMPC"
+
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+const gitignoreContent = "# Created by Terramate. Do not commit the cache.\n*\n"
+
+// Cache is an on-disk cache of parsed HCL files.
+type Cache struct {
+	dir     string
+	version string
+}
+
+// New creates a new cache which stores its entries inside dir. The version is
+// the version of the tool creating the entries, entries created by a different
+// version are never used.
+func New(dir string, version string) *Cache {
+	return &Cache{
+		dir:     dir,
+		version: version,
+	}
+}
+
+// Dir returns the directory where the cache entries are stored.
+func (c *Cache) Dir() string { return c.dir }
+
+// Load the parsed file from the cache. It returns false if the file is not
+// cached, or if the cached entry is stale or corrupted.
+func (c *Cache) Load(filename string, data []byte) (*hcl.File, bool) {
+	logger := log.With().
+		Str("action", "parsecache.Load()").
+		Str("file", filename).
+		Logger()
+
+	content, err := os.ReadFile(c.entryPath(filename))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			logger.Debug().Err(err).Msg("reading cache entry")
+		}
+		return nil, false
+	}
+
+	// entries end with a checksum of all its previous content.
+	if len(content) < len(magic)+crc32.Size || !bytes.HasPrefix(content, []byte(magic)) {
+		logger.Debug().Msg("ignoring corrupted cache entry")
+		return nil, false
+	}
+	content, checksum := content[:len(content)-crc32.Size], content[len(content)-crc32.Size:]
+	if crc32.Checksum(content, crcTable) != binary.BigEndian.Uint32(checksum) {
+		logger.Debug().Msg("ignoring corrupted cache entry")
+		return nil, false
+	}
+
+	d := decoder{
+		data:     content[len(magic):],
+		filename: filename,
+		anons:    map[uint64]*hclsyntax.AnonSymbolExpr{},
+	}
+
+	format := d.uint()
+	version := d.string()
+	entryFilename := d.string()
+	hash := d.bytes()
+	if d.err != nil {
+		logger.Debug().Err(d.err).Msg("ignoring corrupted cache entry")
+		return nil, false
+	}
+
+	if format != formatVersion || version != c.version || entryFilename != filename {
+		logger.Trace().Msg("ignoring cache entry from different version")
+		return nil, false
+	}
+
+	wantHash := contentHash(data)
+	if !bytes.Equal(hash, wantHash[:]) {
+		logger.Trace().Msg("ignoring outdated cache entry")
+		return nil, false
+	}
+
+	body := d.body()
+	if d.err == nil && len(d.data) > 0 {
+		d.fail()
+	}
+	if d.err != nil {
+		logger.Debug().Err(d.err).Msg("ignoring corrupted cache entry")
+		return nil, false
+	}
+
+	logger.Trace().Msg("cache hit")
+
+	return &hcl.File{
+		Body:  body,
+		Bytes: data,
+	}, true
+}
+
+// Store the parsed file in the cache. The file must be the result of parsing
+// data.
+func (c *Cache) Store(filename string, data []byte, file *hcl.File) error {
+	body, ok := file.Body.(*hclsyntax.Body)
+	if !ok {
+		return errors.E("unsupported body type %T", file.Body)
+	}
+
+	hash := contentHash(data)
+	e := encoder{
+		buf:      append(make([]byte, 0, len(data)*2), magic...),
+		filename: filename,
+		anons:    map[*hclsyntax.AnonSymbolExpr]uint64{},
+	}
+	e.uint(formatVersion)
+	e.string(c.version)
+	e.string(filename)
+	e.bytes(hash[:])
+	if err := e.body(body); err != nil {
+		return errors.E(err, "encoding %s", filename)
+	}
+	e.buf = binary.BigEndian.AppendUint32(e.buf, crc32.Checksum(e.buf, crcTable))
+
+	if err := c.init(); err != nil {
+		return err
+	}
+
+	// The entry is written to a temporary file first and then renamed, so
+	// concurrent readers never observe a partially written entry.
+	tmpfile, err := os.CreateTemp(c.dir, ".entry-*")
+	if err != nil {
+		return errors.E(err, "creating cache entry")
+	}
+	_, err = tmpfile.Write(e.buf)
+	closeErr := tmpfile.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmpfile.Name(), c.entryPath(filename))
+	}
+	if err != nil {
+		_ = os.Remove(tmpfile.Name())
+		return errors.E(err, "writing cache entry")
+	}
+	return nil
+}
+
+func (c *Cache) init() error {
+	if err := os.MkdirAll(c.dir, 0755); err != nil {
+		return errors.E(err, "creating cache directory")
+	}
+	// The cache lives inside the project, so make sure git ignores it.
+	gitignore := filepath.Join(c.dir, ".gitignore")
+	if _, err := os.Stat(gitignore); err == nil {
+		return nil
+	}
+	if err := os.WriteFile(gitignore, []byte(gitignoreContent), 0644); err != nil {
+		return errors.E(err, "creating cache .gitignore")
+	}
+	return nil
+}
+
+func (c *Cache) entryPath(filename string) string {
+	sum := sha256.Sum256([]byte(filename))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:]))
+}
+
+func contentHash(data []byte) [sha256.Size]byte {
+	return sha256.Sum256(data)
+}
@@ -0,0 +1,215 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package parsecache_test
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/madlambda/spells/assert"
+	"github.com/terramate-io/hcl/v2"
+	"github.com/terramate-io/hcl/v2/hclparse"
+	"github.com/terramate-io/terramate/hcl/parsecache"
+	"github.com/terramate-io/terramate/test"
+)
+
+const sampleConfig = `
+terramate {
+  config {
+    experiments = ["scripts", "outputs-sharing"]
+  }
+}
+
+globals "nested" "labels" {
+  str      = "string"
+  num      = 1.5
+  big      = 123456789012345678901234567890
+  bool     = true
+  null     = null
+  neg      = -1
+  not      = !false
+  list     = [1, "two", [3]]
+  obj      = { a = 1, "b" = 2, (global.key) = 3 }
+  tmpl     = "prefix-${global.str}-${tm_upper("x")}"
+  heredoc  = <<-EOT
+    line ${global.num}
+    %{for v in global.list~}
+    item ${v}
+    %{endfor~}
+    %{if global.bool}yes%{else}no%{endif}
+  EOT
+  cond     = global.bool ? "a" : "b"
+  ops      = (1 + 2) * 3 / 4 % 5 - 6 > 7 || 1 >= 2 && 1 < 2 || 1 <= 2 || 1 == 2 || 1 != 2
+  index    = global.list[0]
+  strindex = global.obj["a"]
+  attr     = global.obj.a
+  relative = tm_concat(global.list, [])[0].name
+  splat    = global.list[*].name
+  fullsplat = global.objs.*.name
+  forlist  = [for i, v in global.list : v if i > 0]
+  forobj   = { for k, v in global.obj : k => v... }
+  expand   = tm_max(global.list...)
+  empty    = ""
+  emptyobj = {}
+  emptylist = []
+  noargs   = tm_timestamp()
+}
+
+stack {
+  name = "stack"
+}
+
+generate_hcl "file.hcl" {
+  content {
+    block {
+      a = global.str
+    }
+  }
+}
+`
+
+func TestCacheRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	dir := test.TempDir(t)
+	filename := filepath.Join(dir, "config.tm")
+	data := []byte(sampleConfig)
+	want := parse(t, filename, data)
+
+	cache := parsecache.New(filepath.Join(dir, "cache"), "1.0.0")
+	_, ok := cache.Load(filename, data)
+	assert.IsTrue(t, !ok, "empty cache must not have entries")
+
+	assert.NoError(t, cache.Store(filename, data, want))
+
+	got, ok := cache.Load(filename, data)
+	assert.IsTrue(t, ok, "stored file must be cached")
+	assert.EqualStrings(t, string(data), string(got.Bytes))
+
+	if !reflect.DeepEqual(want.Body, got.Body) {
+		t.Fatalf("cached body differs from parsed body")
+	}
+}
+
+func TestCacheInvalidation(t *testing.T) {
+	t.Parallel()
+
+	dir := test.TempDir(t)
+	cachedir := filepath.Join(dir, "cache")
+	filename := filepath.Join(dir, "config.tm")
+	data := []byte(sampleConfig)
+
+	cache := parsecache.New(cachedir, "1.0.0")
+	assert.NoError(t, cache.Store(filename, data, parse(t, filename, data)))
+
+	t.Run("on edit", func(t *testing.T) {
+		edited := []byte(sampleConfig + "\n# edited\n")
+		_, ok := cache.Load(filename, edited)
+		assert.IsTrue(t, !ok, "edited file must not be cached")
+	})
+
+	t.Run("on different filename", func(t *testing.T) {
+		_, ok := cache.Load(filepath.Join(dir, "other.tm"), data)
+		assert.IsTrue(t, !ok, "other file must not be cached")
+	})
+
+	t.Run("on version bump", func(t *testing.T) {
+		bumped := parsecache.New(cachedir, "1.0.1")
+		_, ok := bumped.Load(filename, data)
+		assert.IsTrue(t, !ok, "entry from old version must not be used")
+
+		assert.NoError(t, bumped.Store(filename, data, parse(t, filename, data)))
+		_, ok = bumped.Load(filename, data)
+		assert.IsTrue(t, ok, "entry must be rewritten for the new version")
+
+		_, ok = cache.Load(filename, data)
+		assert.IsTrue(t, !ok, "entry from new version must not be used by old version")
+	})
+}
+
+func TestCacheIgnoresCorruptedEntries(t *testing.T) {
+	t.Parallel()
+
+	dir := test.TempDir(t)
+	cachedir := filepath.Join(dir, "cache")
+	filename := filepath.Join(dir, "config.tm")
+	data := []byte(sampleConfig)
+
+	cache := parsecache.New(cachedir, "1.0.0")
+	assert.NoError(t, cache.Store(filename, data, parse(t, filename, data)))
+
+	entries := cacheEntries(t, cachedir)
+	assert.EqualInts(t, 1, len(entries))
+
+	valid := readFile(t, entries[0])
+	corruptedEntries := [][]byte{
+		nil,
+		[]byte("garbage"),
+	}
+	for size := 0; size < len(valid); size += 13 {
+		corruptedEntries = append(corruptedEntries, valid[:size])
+	}
+	for pos := 0; pos < len(valid); pos += 17 {
+		flipped := append([]byte{}, valid...)
+		flipped[pos] ^= 0x1
+		corruptedEntries = append(corruptedEntries, flipped)
+	}
+
+	for _, corrupted := range corruptedEntries {
+		test.WriteFile(t, cachedir, filepath.Base(entries[0]), string(corrupted))
+		_, ok := cache.Load(filename, data)
+		assert.IsTrue(t, !ok, "corrupted entry must be ignored")
+
+		assert.NoError(t, cache.Store(filename, data, parse(t, filename, data)))
+		_, ok = cache.Load(filename, data)
+		assert.IsTrue(t, ok, "corrupted entry must be rewritten")
+	}
+}
+
+func TestCacheDirIsIgnoredByGit(t *testing.T) {
+	t.Parallel()
+
+	dir := test.TempDir(t)
+	cachedir := filepath.Join(dir, "cache")
+	filename := filepath.Join(dir, "config.tm")
+	data := []byte(sampleConfig)
+
+	cache := parsecache.New(cachedir, "1.0.0")
+	assert.NoError(t, cache.Store(filename, data, parse(t, filename, data)))
+
+	gitignore := readFile(t, filepath.Join(cachedir, ".gitignore"))
+	assert.EqualStrings(t, "# Created by Terramate. Do not commit the cache.\n*\n", string(gitignore))
+}
+
+func parse(t *testing.T, filename string, data []byte) *hcl.File {
+	t.Helper()
+	file, diags := hclparse.NewParser().ParseHCL(data, filename)
+	if diags.HasErrors() {
+		t.Fatal(diags.Error())
+	}
+	return file
+}
+
+func cacheEntries(t *testing.T, dir string) []string {
+	t.Helper()
+	dirEntries, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	var entries []string
+	for _, entry := range dirEntries {
+		if entry.Name() == ".gitignore" {
+			continue
+		}
+		entries = append(entries, filepath.Join(dir, entry.Name()))
+	}
+	return entries
+}
+
+func readFile(t *testing.T, path string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	return data
+}
@@ -85,6 +85,18 @@ func PrintConfig(w io.Writer, cfg Config) error {
 			stackBody.SetAttributeValue("watch", cty.SetVal(listToValue(stack.Watch)))
 		}
 
+		if len(stack.IgnoreOnChange) > 0 {
+			stackBody.SetAttributeValue("ignore_on_change", cty.SetVal(listToValue(stack.IgnoreOnChange)))
+		}
+
+		if stack.Timeout != nil {
+			stackBody.SetAttributeValue("timeout", cty.StringVal(stack.Timeout.String()))
+		}
+
+		if stack.Priority != nil {
+			stackBody.SetAttributeValue("priority", cty.NumberIntVal(int64(*stack.Priority)))
+		}
+
 		if stack.ID != "" {
 			stackBody.SetAttributeValue("id", cty.StringVal(stack.ID))
 		}
@@ -46,6 +46,7 @@ func NewTopLevelRawConfig() RawConfig {
 		"generate_file":   (*RawConfig).addBlock,
 		"generate_hcl":    (*RawConfig).addBlock,
 		"assert":          (*RawConfig).addBlock,
+		"check":           (*RawConfig).addBlock,
 		"import":          func(_ *RawConfig, _ *ast.Block) error { return nil },
 		"sharing_backend": (*RawConfig).addBlock,
 		"input":           (*RawConfig).addBlock,
@@ -192,6 +192,7 @@ func loadExprs(letblock *ast.MergedBlock) (Exprs, error) {
 		if _, ok := letblock.Attributes[varName]; ok {
 			return nil, errors.E(
 				ErrRedefined,
+				mapBlock.RawOrigins[0].LabelRanges(),
 				"map label %s conflicts with let.%s attribute", varName, varName)
 		}
 		mapExpr, err := mapexpr.NewMapExpr(mapBlock)
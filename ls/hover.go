@@ -0,0 +1,459 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package tmls
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/rs/zerolog"
+	hhcl "github.com/terramate-io/hcl/v2"
+	"github.com/terramate-io/hcl/v2/hclsyntax"
+	"github.com/terramate-io/hcl/v2/hclwrite"
+	"github.com/terramate-io/terramate/config"
+	"github.com/terramate-io/terramate/errors"
+	"github.com/terramate-io/terramate/globals"
+	"github.com/terramate-io/terramate/hcl/ast"
+	"github.com/terramate-io/terramate/hcl/eval"
+	"github.com/terramate-io/terramate/project"
+	"github.com/terramate-io/terramate/stdlib"
+	"github.com/zclconf/go-cty/cty"
+	"go.lsp.dev/jsonrpc2"
+	lsp "go.lsp.dev/protocol"
+	"go.lsp.dev/uri"
+)
+
+// dirEval is the evaluation of the globals and metadata of a directory.
+type dirEval struct {
+	ctx       *eval.Context
+	exprs     globals.HierarchicalExprs
+	report    globals.EvalReport
+	sensitive globals.Sensitive
+	inStack   bool
+}
+
+func (s *Server) handleHover(
+	ctx context.Context,
+	reply jsonrpc2.Replier,
+	r jsonrpc2.Request,
+	log zerolog.Logger,
+) error {
+	var params lsp.HoverParams
+	if err := json.Unmarshal(r.Params(), &params); err != nil {
+		log.Error().Err(err).Msg("failed to unmarshal params")
+		return jsonrpc2.ErrParse
+	}
+
+	fname := params.TextDocument.URI.Filename()
+	traversal, rng, ok := s.traversalAt(fname, params.Position)
+	if !ok {
+		return reply(ctx, nil, nil)
+	}
+
+	dirEval, err := s.evalDir(filepath.Dir(fname))
+	if err != nil {
+		log.Debug().Err(err).Msg("unable to evaluate the directory")
+		return reply(ctx, nil, nil)
+	}
+
+	value, ok := dirEval.describe(traversal)
+	if !ok {
+		return reply(ctx, nil, nil)
+	}
+
+	hoverRange := toLSPRange(rng)
+	return reply(ctx, lsp.Hover{
+		Contents: lsp.MarkupContent{
+			Kind:  lsp.Markdown,
+			Value: value,
+		},
+		Range: &hoverRange,
+	}, nil)
+}
+
+func (s *Server) handleDefinition(
+	ctx context.Context,
+	reply jsonrpc2.Replier,
+	r jsonrpc2.Request,
+	log zerolog.Logger,
+) error {
+	var params lsp.DefinitionParams
+	if err := json.Unmarshal(r.Params(), &params); err != nil {
+		log.Error().Err(err).Msg("failed to unmarshal params")
+		return jsonrpc2.ErrParse
+	}
+
+	fname := params.TextDocument.URI.Filename()
+	traversal, _, ok := s.traversalAt(fname, params.Position)
+	if !ok || traversal.RootName() != "global" {
+		return reply(ctx, nil, nil)
+	}
+
+	dirEval, err := s.evalDir(filepath.Dir(fname))
+	if err != nil {
+		log.Debug().Err(err).Msg("unable to evaluate the directory")
+		return reply(ctx, nil, nil)
+	}
+
+	def, ok := dirEval.definition(traversalPath(traversal))
+	if !ok {
+		return reply(ctx, nil, nil)
+	}
+
+	return reply(ctx, []lsp.Location{
+		{
+			URI:   lsp.URI(uri.File(def.Origin.HostPath())),
+			Range: toLSPRange(def.Origin.ToHCLRange()),
+		},
+	}, nil)
+}
+
+// setDocument keeps the content of an open document and reloads the
+// configuration affected by the file, which invalidates the cached evaluations
+// of the reloaded directories, their child directories and the directories
+// importing the file.
+func (s *Server) setDocument(fname string, content string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.documents == nil {
+		s.documents = map[string]string{}
+	}
+	s.documents[fname] = content
+	s.reloadConfig(fname)
+}
+
+// loadRoot returns the configuration of the project of dir, loading it only
+// if not loaded yet. It must be called with s.mu held.
+func (s *Server) loadRoot(dir string) (*config.Root, error) {
+	if s.root != nil && isInsideDir(s.root.HostDir(), dir) {
+		return s.root, nil
+	}
+
+	root, _, found, err := config.TryLoadConfig(dir)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, errors.E("no Terramate project found for %s", dir)
+	}
+
+	rootdir := root.HostDir()
+	root.OnReload(func(dirs project.Paths) {
+		for evaldir := range s.evalCache {
+			if config.ScopeReloaded(dirs, project.PrjAbsPath(rootdir, evaldir)) {
+				delete(s.evalCache, evaldir)
+			}
+		}
+	})
+	s.root = root
+	s.evalCache = nil
+	return root, nil
+}
+
+// reloadConfig reloads the configuration affected by the change of the file.
+// If the configuration cannot be reloaded, it's dropped and loaded again when
+// needed. It must be called with s.mu held.
+func (s *Server) reloadConfig(fname string) {
+	if s.root == nil || !isInsideDir(s.root.HostDir(), fname) {
+		return
+	}
+	rootdir := s.root.HostDir()
+	if err := s.root.ReloadPath(project.PrjAbsPath(rootdir, fname)); err != nil {
+		s.log.Debug().Err(err).Str("file", fname).Msg("unable to reload the configuration")
+		s.root = nil
+		s.evalCache = nil
+	}
+}
+
+func isInsideDir(dir, path string) bool {
+	return path == dir || strings.HasPrefix(path, dir+string(filepath.Separator))
+}
+
+func (s *Server) document(fname string) (string, bool) {
+	s.mu.Lock()
+	content, ok := s.documents[fname]
+	s.mu.Unlock()
+	if ok {
+		return content, true
+	}
+	data, err := os.ReadFile(fname)
+	if err != nil {
+		return "", false
+	}
+	return string(data), true
+}
+
+// traversalAt returns the global or terramate variable traversal at the given
+// position of the document.
+func (s *Server) traversalAt(fname string, pos lsp.Position) (hhcl.Traversal, hhcl.Range, bool) {
+	content, ok := s.document(fname)
+	if !ok {
+		return nil, hhcl.Range{}, false
+	}
+
+	offset, ok := byteOffset(content, pos)
+	if !ok {
+		return nil, hhcl.Range{}, false
+	}
+
+	file, diags := hclsyntax.ParseConfig([]byte(content), fname, hhcl.InitialPos)
+	if file == nil || file.Body == nil {
+		log := s.log.With().Str("file", fname).Logger()
+		log.Debug().Err(diags).Msg("unable to parse the document")
+		return nil, hhcl.Range{}, false
+	}
+
+	var found *hclsyntax.ScopeTraversalExpr
+	_ = hclsyntax.VisitAll(file.Body.(*hclsyntax.Body), func(node hclsyntax.Node) hhcl.Diagnostics {
+		expr, ok := node.(*hclsyntax.ScopeTraversalExpr)
+		if !ok || !expr.SrcRange.ContainsOffset(offset) {
+			return nil
+		}
+		switch expr.Traversal.RootName() {
+		case "global", "terramate":
+		default:
+			return nil
+		}
+		found = expr
+		return nil
+	})
+	if found == nil {
+		return nil, hhcl.Range{}, false
+	}
+	return found.Traversal, found.SrcRange, true
+}
+
+// evalDir evaluates the globals and metadata of the given directory, using the
+// same scope resolution of the globals of the directory used by Terramate.
+// The evaluation is cached per directory until the configuration affecting it
+// is reloaded.
+func (s *Server) evalDir(dir string) (*dirEval, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if cached, ok := s.evalCache[dir]; ok {
+		return cached, nil
+	}
+
+	root, err := s.loadRoot(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	tree, ok := root.Lookup(project.PrjAbsPath(root.HostDir(), dir))
+	if !ok {
+		return nil, errors.E("configuration at %s not found", dir)
+	}
+
+	exprs, err := globals.LoadExprs(tree)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &dirEval{
+		exprs: exprs,
+	}
+
+	runtime := root.Runtime()
+	if tree.IsStack() {
+		st, err := tree.Stack()
+		if err != nil {
+			return nil, err
+		}
+		runtime.Merge(st.RuntimeValues(root))
+		res.inStack = true
+	}
+
+	res.ctx = eval.NewContext(stdlib.Functions(dir, root.Tree().Node.Experiments()))
+	res.ctx.SetNamespace("terramate", runtime)
+	res.report = exprs.Eval(res.ctx)
+	res.ctx.SetNamespace("global", res.report.Globals.AsValueMap())
+
+	if cfg := root.Tree().Node; cfg.Terramate != nil && cfg.Terramate.Config != nil {
+		res.sensitive, _ = globals.NewSensitive(cfg.Terramate.Config.SensitiveGlobals)
+	}
+
+	if s.evalCache == nil {
+		s.evalCache = map[string]*dirEval{}
+	}
+	s.evalCache[dir] = res
+	return res, nil
+}
+
+// describe returns the markdown description of the value of the traversal.
+func (e *dirEval) describe(traversal hhcl.Traversal) (string, bool) {
+	name := traversalName(traversal)
+	expr := &hclsyntax.ScopeTraversalExpr{Traversal: traversal}
+
+	switch traversal.RootName() {
+	case "terramate":
+		path := traversalPath(traversal)
+		if len(path) > 0 && path[0] == "stack" && !e.inStack {
+			return "", false
+		}
+		val, err := e.ctx.Eval(expr)
+		if err != nil {
+			return "", false
+		}
+		return describeValue(name, val, ""), true
+
+	case "global":
+		path := traversalPath(traversal)
+		if e.sensitive.Match(path) {
+			return describeValue(name, cty.StringVal(globals.SensitiveValue), e.definedAt(path)), true
+		}
+		val, err := e.ctx.Eval(expr)
+		if err == nil {
+			return describeValue(name, e.redact(path, cty.UnknownAsNull(val)), e.definedAt(path)), true
+		}
+
+		// the global failed to evaluate, then its expression is shown
+		// partially evaluated together with the error.
+		def, ok := e.definition(path)
+		if !ok {
+			return "", false
+		}
+		evalErr, ok := e.evalError(def.Path)
+		if !ok {
+			return "", false
+		}
+		partial := ast.TokensForExpression(evalErr.Expr.Expression)
+		if newexpr, _, err := e.ctx.PartialEval(evalErr.Expr.Expression); err == nil {
+			partial = ast.TokensForExpression(newexpr)
+		}
+		desc := fmt.Sprintf("```hcl\n%s = %s\n```\n\n(not evaluated) %s",
+			name, strings.TrimSpace(string(hclwrite.Format(partial.Bytes()))), evalErr.Err)
+		if at := e.definedAt(path); at != "" {
+			desc += "\n\n" + at
+		}
+		return desc, true
+	}
+	return "", false
+}
+
+// definition returns the definition which sets the global at path.
+func (e *dirEval) definition(path eval.ObjectPath) (globals.Definition, bool) {
+	var res globals.Definition
+	found := false
+	for _, def := range e.exprs.Trace(path) {
+		if len(def.Path) > len(path) || !isPrefixPath(def.Path, path) {
+			continue
+		}
+		// the definitions are sorted by precedence.
+		res = def
+		found = true
+	}
+	return res, found
+}
+
+// redact redacts the sensitive globals nested inside the value of the global
+// at path.
+func (e *dirEval) redact(path eval.ObjectPath, val cty.Value) cty.Value {
+	if e.sensitive.IsEmpty() || len(path) == 0 {
+		return val
+	}
+	nested := map[string]cty.Value{path[len(path)-1]: val}
+	for i := len(path) - 2; i >= 0; i-- {
+		nested = map[string]cty.Value{path[i]: cty.ObjectVal(nested)}
+	}
+	redacted := cty.ObjectVal(e.sensitive.Redact(nested))
+	for _, name := range path {
+		redacted = redacted.GetAttr(name)
+	}
+	return redacted
+}
+
+func (e *dirEval) definedAt(path eval.ObjectPath) string {
+	def, ok := e.definition(path)
+	if !ok {
+		return ""
+	}
+	return fmt.Sprintf("Defined at `%s:%d`", def.Origin.Path(), def.Origin.Start().Line())
+}
+
+func (e *dirEval) evalError(path eval.ObjectPath) (globals.EvalError, bool) {
+	for key, evalErr := range e.report.Errors {
+		if isPrefixPath(key.Path(), path) || isPrefixPath(path, key.Path()) {
+			return evalErr, true
+		}
+	}
+	return globals.EvalError{}, false
+}
+
+func describeValue(name string, val cty.Value, definedAt string) string {
+	tokens := ast.TokensForValue(val)
+	desc := fmt.Sprintf("```hcl\n%s = %s\n```", name,
+		strings.TrimSpace(string(hclwrite.Format(tokens.Bytes()))))
+	if definedAt != "" {
+		desc += "\n\n" + definedAt
+	}
+	return desc
+}
+
+// traversalPath returns the accessor path of the traversal, without the root
+// name, e.g. ["a", "b"] for global.a.b or global["a"].b.
+func traversalPath(traversal hhcl.Traversal) eval.ObjectPath {
+	var path eval.ObjectPath
+	for _, step := range traversal[1:] {
+		switch s := step.(type) {
+		case hhcl.TraverseAttr:
+			path = append(path, s.Name)
+		case hhcl.TraverseIndex:
+			if s.Key.Type() != cty.String {
+				return path
+			}
+			path = append(path, s.Key.AsString())
+		default:
+			return path
+		}
+	}
+	return path
+}
+
+func traversalName(traversal hhcl.Traversal) string {
+	return strings.Join(append([]string{traversal.RootName()}, traversalPath(traversal)...), ".")
+}
+
+func isPrefixPath(prefix, path eval.ObjectPath) bool {
+	if len(prefix) > len(path) {
+		return false
+	}
+	for i := range prefix {
+		if prefix[i] != path[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// byteOffset converts the LSP position into the byte offset of the content.
+func byteOffset(content string, pos lsp.Position) (int, bool) {
+	offset := 0
+	for line := uint32(0); line < pos.Line; line++ {
+		next := strings.IndexByte(content[offset:], '\n')
+		if next == -1 {
+			return 0, false
+		}
+		offset += next + 1
+	}
+	offset += int(pos.Character)
+	if offset > len(content) {
+		return 0, false
+	}
+	return offset, true
+}
+
+func toLSPRange(rng hhcl.Range) lsp.Range {
+	res := lsp.Range{}
+	res.Start.Line = uint32(rng.Start.Line) - 1
+	res.Start.Character = uint32(rng.Start.Column) - 1
+	res.End.Line = uint32(rng.End.Line) - 1
+	res.End.Character = uint32(rng.End.Column) - 1
+	return res
+}
@@ -0,0 +1,213 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package tmls_test
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/madlambda/spells/assert"
+	lstest "github.com/terramate-io/terramate/test/ls"
+	lsp "go.lsp.dev/protocol"
+)
+
+const hoverTestMain = `generate_hcl "test.hcl" {
+  content {
+    a        = global.a
+    imported = global.obj.imported
+    name     = terramate.stack.name
+    bad      = global.bad
+  }
+}
+`
+
+func hoverTestLayout() []string {
+	return []string{
+		"f:globals.tm:globals {\n  a = \"root\"\n}\n",
+		"f:imports/globals.tm:globals \"obj\" {\n  imported = \"from import\"\n}\n",
+		"s:stack",
+		"f:stack/globals.tm:import {\n  source = \"/imports/globals.tm\"\n}\n\nglobals {\n  a   = \"stack\"\n  bad = global.a + global.undefined\n}\n",
+		"f:stack/main.tm:" + hoverTestMain,
+		"f:dir/main.tm:" + hoverTestMain,
+	}
+}
+
+func TestHoverGlobals(t *testing.T) {
+	t.Parallel()
+	f := lstest.Setup(t, hoverTestLayout()...)
+	f.Editor.CheckInitialize(f.Sandbox.RootDir())
+	f.Editor.Open("stack/main.tm")
+	drainNotifications(t, f, 3)
+
+	hover := f.Editor.Hover("stack/main.tm", 2, 17)
+	assert.IsTrue(t, hover != nil, "expected hover for global.a")
+	assert.EqualStrings(t, string(lsp.Markdown), string(hover.Contents.Kind))
+	assert.EqualStrings(t,
+		"```hcl\nglobal.a = \"stack\"\n```\n\nDefined at `/stack/globals.tm:6`",
+		hover.Contents.Value)
+	assert.IsTrue(t, hover.Range != nil, "expected hover range")
+	assert.EqualInts(t, 2, int(hover.Range.Start.Line))
+	assert.EqualInts(t, 15, int(hover.Range.Start.Character))
+	assert.EqualInts(t, 23, int(hover.Range.End.Character))
+
+	hover = f.Editor.Hover("stack/main.tm", 3, 25)
+	assert.IsTrue(t, hover != nil, "expected hover for global.obj.imported")
+	assert.EqualStrings(t,
+		"```hcl\nglobal.obj.imported = \"from import\"\n```\n\nDefined at `/imports/globals.tm:2`",
+		hover.Contents.Value)
+
+	hover = f.Editor.Hover("stack/main.tm", 4, 20)
+	assert.IsTrue(t, hover != nil, "expected hover for terramate.stack.name")
+	assert.EqualStrings(t,
+		"```hcl\nterramate.stack.name = \"stack\"\n```",
+		hover.Contents.Value)
+
+	hover = f.Editor.Hover("stack/main.tm", 5, 20)
+	assert.IsTrue(t, hover != nil, "expected hover for global.bad")
+	assert.IsTrue(t, strings.HasPrefix(hover.Contents.Value,
+		"```hcl\nglobal.bad = global.a + global.undefined\n```\n\n(not evaluated)"),
+		"unexpected hover: %s", hover.Contents.Value)
+
+	// the attribute name is not a reference.
+	hover = f.Editor.Hover("stack/main.tm", 2, 5)
+	assert.IsTrue(t, hover == nil, "unexpected hover: %v", hover)
+}
+
+func TestHoverOutsideStack(t *testing.T) {
+	t.Parallel()
+	f := lstest.Setup(t, hoverTestLayout()...)
+	f.Editor.CheckInitialize(f.Sandbox.RootDir())
+	f.Editor.Open("dir/main.tm")
+	drainNotifications(t, f, 1)
+
+	hover := f.Editor.Hover("dir/main.tm", 2, 17)
+	assert.IsTrue(t, hover != nil, "expected hover for global.a")
+	assert.EqualStrings(t,
+		"```hcl\nglobal.a = \"root\"\n```\n\nDefined at `/globals.tm:2`",
+		hover.Contents.Value)
+
+	hover = f.Editor.Hover("dir/main.tm", 4, 20)
+	assert.IsTrue(t, hover == nil, "unexpected stack metadata hover: %v", hover)
+}
+
+func TestHoverInvalidatedOnChange(t *testing.T) {
+	t.Parallel()
+	f := lstest.Setup(t, hoverTestLayout()...)
+	f.Editor.CheckInitialize(f.Sandbox.RootDir())
+	f.Editor.Open("dir/main.tm")
+	drainNotifications(t, f, 1)
+
+	hover := f.Editor.Hover("dir/main.tm", 2, 17)
+	assert.IsTrue(t, hover != nil, "expected hover for global.a")
+	assert.EqualStrings(t,
+		"```hcl\nglobal.a = \"root\"\n```\n\nDefined at `/globals.tm:2`",
+		hover.Contents.Value)
+
+	changed := "globals {\n  a = \"changed\"\n}\n"
+	f.Sandbox.RootEntry().CreateFile("globals.tm", changed)
+	f.Editor.Change("globals.tm", changed)
+	// root.config.tm and globals.tm
+	drainNotifications(t, f, 2)
+
+	hover = f.Editor.Hover("dir/main.tm", 2, 17)
+	assert.IsTrue(t, hover != nil, "expected hover for global.a")
+	assert.EqualStrings(t,
+		"```hcl\nglobal.a = \"changed\"\n```\n\nDefined at `/globals.tm:2`",
+		hover.Contents.Value)
+}
+
+func TestHoverInvalidatedOnImportedFileChange(t *testing.T) {
+	t.Parallel()
+	f := lstest.Setup(t, hoverTestLayout()...)
+	f.Editor.CheckInitialize(f.Sandbox.RootDir())
+	f.Editor.Open("stack/main.tm")
+	drainNotifications(t, f, 3)
+
+	hover := f.Editor.Hover("stack/main.tm", 3, 25)
+	assert.IsTrue(t, hover != nil, "expected hover for global.obj.imported")
+	assert.EqualStrings(t,
+		"```hcl\nglobal.obj.imported = \"from import\"\n```\n\nDefined at `/imports/globals.tm:2`",
+		hover.Contents.Value)
+
+	changed := "globals \"obj\" {\n  imported = \"changed\"\n}\n"
+	f.Sandbox.DirEntry("imports").CreateFile("globals.tm", changed)
+	f.Editor.Change("imports/globals.tm", changed)
+	drainNotifications(t, f, 1)
+
+	hover = f.Editor.Hover("stack/main.tm", 3, 25)
+	assert.IsTrue(t, hover != nil, "expected hover for global.obj.imported")
+	assert.EqualStrings(t,
+		"```hcl\nglobal.obj.imported = \"changed\"\n```\n\nDefined at `/imports/globals.tm:2`",
+		hover.Contents.Value)
+}
+
+func TestDefinitionGlobals(t *testing.T) {
+	t.Parallel()
+	f := lstest.Setup(t, hoverTestLayout()...)
+	f.Editor.CheckInitialize(f.Sandbox.RootDir())
+	f.Editor.Open("stack/main.tm")
+	drainNotifications(t, f, 3)
+
+	type want struct {
+		file      string
+		line      uint32
+		character uint32
+	}
+
+	for _, tc := range []struct {
+		name      string
+		line      uint32
+		character uint32
+		want      *want
+	}{
+		{
+			name:      "global in the stack",
+			line:      2,
+			character: 17,
+			want:      &want{file: "stack/globals.tm", line: 5, character: 2},
+		},
+		{
+			name:      "global in imported file",
+			line:      3,
+			character: 25,
+			want:      &want{file: "imports/globals.tm", line: 1, character: 2},
+		},
+		{
+			name:      "stack metadata has no definition",
+			line:      4,
+			character: 20,
+		},
+		{
+			name:      "not a reference",
+			line:      0,
+			character: 3,
+		},
+	} {
+		locations := f.Editor.Definition("stack/main.tm", tc.line, tc.character)
+		if tc.want == nil {
+			assert.EqualInts(t, 0, len(locations), "%s: unexpected locations: %v", tc.name, locations)
+			continue
+		}
+		assert.EqualInts(t, 1, len(locations), "%s: unexpected locations: %v", tc.name, locations)
+		got := locations[0]
+		assert.EqualStrings(t, filepath.Join(f.Sandbox.RootDir(), tc.want.file), got.URI.Filename(), tc.name)
+		assert.EqualInts(t, int(tc.want.line), int(got.Range.Start.Line), tc.name)
+		assert.EqualInts(t, int(tc.want.character), int(got.Range.Start.Character), tc.name)
+	}
+}
+
+func drainNotifications(t *testing.T, f lstest.Fixture, n int) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		select {
+		case r := <-f.Editor.Requests:
+			assert.EqualStrings(t, lsp.MethodTextDocumentPublishDiagnostics, r.Method(),
+				"unexpected notification request")
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timeout waiting for notification %d of %d", i+1, n)
+		}
+	}
+}
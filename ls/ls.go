@@ -12,6 +12,7 @@ import (
 	"path/filepath"
 	"sort"
 	"strings"
+	"sync"
 
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
@@ -32,6 +33,13 @@ type Server struct {
 	workspace string
 	handlers  handlers
 
+	// mu guards the documents, the loaded configuration and the evaluation
+	// cache.
+	mu        sync.Mutex
+	documents map[string]string
+	root      *config.Root
+	evalCache map[string]*dirEval
+
 	log zerolog.Logger
 }
 
@@ -68,6 +76,8 @@ func (s *Server) buildHandlers() {
 		lsp.MethodTextDocumentDidChange:  s.handleDocumentChange,
 		lsp.MethodTextDocumentDidSave:    s.handleDocumentSaved,
 		lsp.MethodTextDocumentCompletion: s.handleCompletion,
+		lsp.MethodTextDocumentHover:      s.handleHover,
+		lsp.MethodTextDocumentDefinition: s.handleDefinition,
 
 		// commands
 		MethodExecuteCommand: s.handleExecuteCommand,
@@ -119,10 +129,10 @@ func (s *Server) handleInitialize(
 			CompletionProvider: &lsp.CompletionOptions{},
 
 			// if we support `goto` definition.
-			DefinitionProvider: false,
+			DefinitionProvider: true,
 
 			// If we support `hover` info.
-			HoverProvider: false,
+			HoverProvider: true,
 
 			TextDocumentSync: lsp.TextDocumentSyncOptions{
 				// Send all file content on every change (can be optimized later).
@@ -180,6 +190,7 @@ func (s *Server) handleDocumentOpen(
 
 	fname := params.TextDocument.URI.Filename()
 	content := params.TextDocument.Text
+	s.setDocument(fname, content)
 
 	return s.checkAndReply(ctx, reply, fname, content)
 }
@@ -204,6 +215,7 @@ func (s *Server) handleDocumentChange(
 
 	content := params.ContentChanges[0].Text
 	fname := params.TextDocument.URI.Filename()
+	s.setDocument(fname, content)
 
 	return s.checkAndReply(ctx, reply, fname, content)
 }
@@ -226,6 +238,7 @@ func (s *Server) handleDocumentSaved(
 		log.Error().Err(err).Msg("reading saved file.")
 		return nil
 	}
+	s.setDocument(fname, string(content))
 
 	return s.checkAndReply(ctx, reply, fname, string(content))
 }
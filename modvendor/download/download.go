@@ -112,7 +112,7 @@ func HandleVendorRequests(
 
 			logger.Trace().Msgf("handling vendor request")
 
-			report := Vendor(rootdir, vendorRequest.VendorDir, vendorRequest.Source, progressEvents)
+			report := handleVendorRequest(rootdir, vendorRequest, progressEvents)
 
 			logger.Trace().Msgf("handled vendor request, sending report")
 
@@ -128,6 +128,38 @@ func HandleVendorRequests(
 	return reportsStream
 }
 
+// handleVendorRequest vendors the module of the request. A request with a
+// version constraint that failed to be resolved is reported as ignored, and
+// the version resolved for a vendored module is recorded in the vendor
+// manifest.
+func handleVendorRequest(
+	rootdir string,
+	req event.VendorRequest,
+	progressEvents ProgressEventStream,
+) Report {
+	if req.Error != nil {
+		report := NewReport(req.VendorDir)
+		report.addIgnored(req.Source.Raw, req.Error)
+		return report
+	}
+
+	report := Vendor(rootdir, req.VendorDir, req.Source, progressEvents)
+	if req.Constraint == "" {
+		return report
+	}
+
+	moddir := modvendor.AbsVendorDir(rootdir, req.VendorDir, req.Source)
+	if _, err := os.Stat(moddir); err != nil {
+		return report
+	}
+
+	if err := modvendor.RecordVersion(rootdir, req.VendorDir, req.Source, req.Constraint); err != nil {
+		report.Error = errors.L(report.Error, errors.E(err,
+			"recording version %s of %s in the vendor manifest", req.Source.Ref, req.Source.URL)).AsError()
+	}
+	return report
+}
+
 // MergeVendorReports will read all reports from the given reports channel, merge them
 // and send the merged result on the returned channel and then close it.
 // The returned channel always produce a single final report after the given
@@ -267,7 +299,7 @@ func vendorAll(
 			return filepath.SkipDir
 		}
 
-		if !d.Type().IsRegular() || !strings.HasSuffix(path, ".tf") {
+		if !d.Type().IsRegular() || !tf.IsTerraformFile(path) {
 			return nil
 		}
 
@@ -314,6 +346,9 @@ func vendorAll(
 			info.vendoredAt = modvendor.TargetDir(vendorDir, modsrc)
 			info.subdir = v.Source.Subdir
 
+		} else if st, err := os.Stat(targetVendorDir); err == nil && st.IsDir() {
+			// vendored concurrently by another vendoring process.
+			info.vendoredAt = modvendor.TargetDir(vendorDir, modsrc)
 		}
 	}
 
@@ -377,19 +412,6 @@ func downloadVendor(
 		}
 	}()
 
-	// Same strategy used on the Go toolchain:
-	// - https://github.com/golang/go/blob/2ebe77a2fda1ee9ff6fd9a3e08933ad1ebaea039/src/cmd/go/internal/get/get.go#L129
-
-	env := append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
-	g, err := git.WithConfig(git.Config{
-		WorkingDir:     clonedRepoDir,
-		AllowPorcelain: true,
-		Env:            env,
-	})
-	if err != nil {
-		return "", err
-	}
-
 	event := event.VendorProgress{
 		Message:   "downloading",
 		TargetDir: modvendor.TargetDir(vendorDir, modsrc),
@@ -403,20 +425,14 @@ func downloadVendor(
 			Msg("dropped progress event, event handler is not fast enough or absent")
 	}
 
-	if err := g.Clone(modsrc.URL, clonedRepoDir); err != nil {
+	if modsrc.PathScheme == tf.OCIScheme {
+		if err := pullOCIArtifact(modsrc, clonedRepoDir); err != nil {
+			return "", err
+		}
+	} else if err := cloneGitRepo(modsrc, clonedRepoDir); err != nil {
 		return "", err
 	}
 
-	const create = false
-
-	if err := g.Checkout(modsrc.Ref, create); err != nil {
-		return "", errors.E(err, "checking ref %s", modsrc.Ref)
-	}
-
-	if err := os.RemoveAll(filepath.Join(clonedRepoDir, ".git")); err != nil {
-		return "", errors.E(err, "removing .git dir from cloned repo")
-	}
-
 	matcher, err := manifest.LoadFileMatcher(clonedRepoDir)
 	if err != nil {
 		return "", err
@@ -442,6 +458,10 @@ func downloadVendor(
 	}
 
 	if err := os.Rename(tmTempDir, modVendorDir); err != nil {
+		if _, statErr := os.Stat(modVendorDir); statErr == nil {
+			// the same module was vendored concurrently.
+			return "", errors.E(ErrAlreadyVendored, "dir %q exists", modVendorDir)
+		}
 		// Assuming that the whole Terramate project is inside the
 		// same fs/mount/dev.
 		return "", errors.E(err, "moving module from tmp dir to vendor")
@@ -449,6 +469,38 @@ func downloadVendor(
 	return modVendorDir, nil
 }
 
+// cloneGitRepo clones the git repository of the given module source into dir,
+// checking out its reference. The .git dir is removed after the checkout.
+func cloneGitRepo(modsrc tf.Source, dir string) error {
+	// Same strategy used on the Go toolchain:
+	// - https://github.com/golang/go/blob/2ebe77a2fda1ee9ff6fd9a3e08933ad1ebaea039/src/cmd/go/internal/get/get.go#L129
+
+	env := append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	g, err := git.WithConfig(git.Config{
+		WorkingDir:     dir,
+		AllowPorcelain: true,
+		Env:            env,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := g.Clone(modsrc.URL, dir); err != nil {
+		return err
+	}
+
+	const create = false
+
+	if err := g.Checkout(modsrc.Ref, create); err != nil {
+		return errors.E(err, "checking ref %s", modsrc.Ref)
+	}
+
+	if err := os.RemoveAll(filepath.Join(dir, ".git")); err != nil {
+		return errors.E(err, "removing .git dir from cloned repo")
+	}
+	return nil
+}
+
 func patchFiles(rootdir string, files []string, sources *sourcesInfo) error {
 	errs := errors.L()
 	for _, fname := range files {
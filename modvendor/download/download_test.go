@@ -1155,6 +1155,77 @@ func TestModVendorNoRefFails(t *testing.T) {
 	}, report)
 }
 
+func TestHandleVendorRequestsWithVersionConstraint(t *testing.T) {
+	t.Parallel()
+
+	const constraint = "~> 1.0"
+
+	repoSandbox := sandbox.New(t)
+	repoSandbox.RootEntry().CreateFile("main.tf", "# module")
+	repogit := repoSandbox.Git()
+	repogit.CommitAll("add module")
+	_, err := repogit.Unwrap().Exec("tag", "v1.0.0")
+	assert.NoError(t, err)
+
+	gitURI := uri.File(repoSandbox.RootDir())
+	rootdir := test.TempDir(t)
+	vendordir := project.NewPath("/vendor")
+
+	unresolved := test.ParseSource(t, fmt.Sprintf("git::%s", gitURI))
+	resolved := test.ParseSource(t, fmt.Sprintf("git::%s?ref=v1.0.0", gitURI))
+	resolveErr := errors.E(modvendor.ErrResolveVersion, "no matching tag")
+
+	events := make(chan event.VendorRequest)
+	reports := download.HandleVendorRequests(rootdir, events, nil)
+	go func() {
+		events <- event.VendorRequest{
+			VendorDir:  vendordir,
+			Source:     resolved,
+			Constraint: constraint,
+		}
+		events <- event.VendorRequest{
+			VendorDir:  vendordir,
+			Source:     unresolved,
+			Constraint: "~> 2.0",
+			Error:      resolveErr,
+		}
+		close(events)
+	}()
+
+	got := download.Report{
+		Vendored: map[project.Path]download.Vendored{},
+	}
+	for report := range reports {
+		for k, v := range report.Vendored {
+			got.Vendored[k] = v
+		}
+		got.Ignored = append(got.Ignored, report.Ignored...)
+		got.Error = errors.L(got.Error, report.Error).AsError()
+	}
+
+	assertVendorReport(t, download.Report{
+		Vendored: map[project.Path]download.Vendored{
+			modvendor.TargetDir(vendordir, resolved): {
+				Source: resolved,
+				Dir:    modvendor.TargetDir(vendordir, resolved),
+			},
+		},
+		Ignored: []download.IgnoredVendor{
+			{
+				RawSource: unresolved.Raw,
+				Reason:    errors.E(modvendor.ErrResolveVersion),
+			},
+		},
+	}, got)
+
+	manifest, err := modvendor.LoadManifest(rootdir, vendordir)
+	assert.NoError(t, err)
+	version, ok := manifest.Lookup(unresolved.Raw, constraint)
+	assert.IsTrue(t, ok, "resolved version not recorded: %+v", manifest)
+	assert.EqualStrings(t, "v1.0.0", version)
+	assert.EqualInts(t, 1, len(manifest.Modules), "unexpected manifest: %+v", manifest)
+}
+
 func assertNoGitDir(t *testing.T, dir string) {
 	t.Helper()
 
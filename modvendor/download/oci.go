@@ -0,0 +1,486 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package download
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"github.com/terramate-io/terramate/errors"
+	"github.com/terramate-io/terramate/tf"
+)
+
+const (
+	// ErrOCIAuth indicates that the OCI registry denied access to the artifact.
+	ErrOCIAuth errors.Kind = "OCI registry authentication failed"
+
+	// ErrOCIArtifact indicates that the OCI artifact is invalid.
+	ErrOCIArtifact errors.Kind = "invalid OCI artifact"
+)
+
+const (
+	ociManifestMediaType       = "application/vnd.oci.image.manifest.v1+json"
+	dockerManifestMediaType    = "application/vnd.docker.distribution.manifest.v2+json"
+	ociTitleAnnotation         = "org.opencontainers.image.title"
+	maxOCIManifestSize         = 4 * 1024 * 1024
+	dockerConfigEnv            = "DOCKER_CONFIG"
+	dockerConfigFilename       = "config.json"
+	dockerDefaultConfigDirname = ".docker"
+)
+
+type (
+	ociDescriptor struct {
+		MediaType   string            `json:"mediaType"`
+		Digest      string            `json:"digest"`
+		Size        int64             `json:"size"`
+		Annotations map[string]string `json:"annotations"`
+	}
+
+	ociManifest struct {
+		MediaType string          `json:"mediaType"`
+		Layers    []ociDescriptor `json:"layers"`
+	}
+
+	ociClient struct {
+		scheme     string
+		registry   string
+		repository string
+		username   string
+		password   string
+		token      string
+		client     *http.Client
+	}
+)
+
+// pullOCIArtifact pulls the OCI artifact of the given module source and
+// unpacks its tar layers into the given directory.
+//
+// The registry credentials are read from the Docker config file, so any
+// registry authenticated with "docker login" or "oras login" can be used.
+// If the module reference is a digest then the artifact manifest is verified
+// against it, and every layer is always verified against its digest.
+func pullOCIArtifact(modsrc tf.Source, dir string) error {
+	registry, repository, _ := strings.Cut(strings.TrimPrefix(modsrc.URL, tf.OCIScheme+"://"), "/")
+
+	logger := log.With().
+		Str("action", "download.pullOCIArtifact()").
+		Str("registry", registry).
+		Str("repository", repository).
+		Str("ref", modsrc.Ref).
+		Logger()
+
+	c := &ociClient{
+		scheme:     "https",
+		registry:   registry,
+		repository: repository,
+		client:     &http.Client{},
+	}
+	if isLoopbackRegistry(registry) {
+		// same as docker, registries on the loopback interface are insecure.
+		c.scheme = "http"
+	}
+
+	username, password, err := dockerCredentials(registry)
+	if err != nil {
+		return err
+	}
+	c.username, c.password = username, password
+
+	logger.Debug().Msg("fetching manifest")
+
+	manifest, err := c.manifest(modsrc.Ref)
+	if err != nil {
+		return err
+	}
+
+	unpacked := 0
+	for _, layer := range manifest.Layers {
+		if !strings.Contains(layer.MediaType, "tar") {
+			logger.Debug().
+				Str("mediaType", layer.MediaType).
+				Msg("ignoring layer that is not a tar archive")
+			continue
+		}
+
+		logger.Debug().Str("digest", layer.Digest).Msg("unpacking layer")
+
+		if err := c.unpackLayer(layer, dir); err != nil {
+			return errors.E(err, "unpacking layer %s", layer.Digest)
+		}
+		unpacked++
+	}
+	if unpacked == 0 {
+		return errors.E(ErrOCIArtifact, "artifact %s has no tar layers", modsrc.Raw)
+	}
+	return nil
+}
+
+func (c *ociClient) manifest(ref string) (ociManifest, error) {
+	resp, err := c.get("manifests/"+ref, ociManifestMediaType+", "+dockerManifestMediaType)
+	if err != nil {
+		return ociManifest{}, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxOCIManifestSize))
+	if err != nil {
+		return ociManifest{}, errors.E(err, "reading manifest")
+	}
+
+	if strings.HasPrefix(ref, "sha256:") {
+		sum := sha256.Sum256(data)
+		if got := "sha256:" + hex.EncodeToString(sum[:]); got != ref {
+			return ociManifest{}, errors.E(ErrOCIArtifact,
+				"manifest digest mismatch: want %s but got %s", ref, got)
+		}
+	}
+
+	var manifest ociManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return ociManifest{}, errors.E(ErrOCIArtifact, err, "parsing manifest")
+	}
+	return manifest, nil
+}
+
+func (c *ociClient) unpackLayer(layer ociDescriptor, dir string) error {
+	algorithm, wantSum, _ := strings.Cut(layer.Digest, ":")
+	if algorithm != "sha256" {
+		return errors.E(ErrOCIArtifact, "unsupported layer digest %q", layer.Digest)
+	}
+
+	resp, err := c.get("blobs/"+layer.Digest, "")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	digester := sha256.New()
+	blob := io.TeeReader(resp.Body, digester)
+	r := blob
+	if strings.Contains(layer.MediaType, "gzip") {
+		gzr, err := gzip.NewReader(r)
+		if err != nil {
+			return errors.E(ErrOCIArtifact, err, "decompressing layer")
+		}
+		defer func() { _ = gzr.Close() }()
+		r = gzr
+	}
+
+	if err := untar(r, dir, layer.Annotations[ociTitleAnnotation]); err != nil {
+		return err
+	}
+	// consumes any trailing data so the digest covers the whole blob.
+	if _, err := io.Copy(io.Discard, blob); err != nil {
+		return errors.E(err, "reading layer")
+	}
+	return checkDigest(digester, wantSum)
+}
+
+func checkDigest(digester hash.Hash, want string) error {
+	if got := hex.EncodeToString(digester.Sum(nil)); got != want {
+		return errors.E(ErrOCIArtifact, "layer digest mismatch: want sha256:%s but got sha256:%s", want, got)
+	}
+	return nil
+}
+
+// get requests the given path of the repository API, authenticating with the
+// registry if requested by it.
+func (c *ociClient) get(apipath string, accept string) (*http.Response, error) {
+	u := url.URL{
+		Scheme: c.scheme,
+		Host:   c.registry,
+		Path:   path.Join("/v2", c.repository, apipath),
+	}
+
+	do := func() (*http.Response, error) {
+		req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+		if err != nil {
+			return nil, errors.E(err, "creating request")
+		}
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		if c.token != "" {
+			req.Header.Set("Authorization", "Bearer "+c.token)
+		} else if c.username != "" {
+			req.SetBasicAuth(c.username, c.password)
+		}
+		resp, err := c.client.Do(req)
+		if err != nil {
+			return nil, errors.E(err, "requesting %s", u.String())
+		}
+		return resp, nil
+	}
+
+	resp, err := do()
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusUnauthorized && c.token == "" {
+		challenge := resp.Header.Get("WWW-Authenticate")
+		_ = resp.Body.Close()
+
+		if err := c.authenticate(challenge); err != nil {
+			return nil, err
+		}
+		resp, err = do()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return resp, nil
+	case http.StatusUnauthorized, http.StatusForbidden:
+		_ = resp.Body.Close()
+		return nil, c.authError(resp.Status)
+	default:
+		_ = resp.Body.Close()
+		return nil, errors.E("requesting %s: unexpected status %s", u.String(), resp.Status)
+	}
+}
+
+// authenticate handles the authentication challenge of the registry. Basic
+// challenges are answered with the configured credentials and Bearer
+// challenges with a token obtained from the registry token server.
+func (c *ociClient) authenticate(challenge string) error {
+	scheme, params := parseAuthChallenge(challenge)
+	switch strings.ToLower(scheme) {
+	case "basic":
+		if c.username == "" {
+			return c.authError("no credentials configured")
+		}
+		// the credentials were already sent, the retry reports the failure.
+		return nil
+	case "bearer":
+		return c.fetchToken(params)
+	default:
+		return c.authError(fmt.Sprintf("unsupported authentication challenge %q", challenge))
+	}
+}
+
+func (c *ociClient) fetchToken(params map[string]string) error {
+	realm, err := url.Parse(params["realm"])
+	if err != nil || params["realm"] == "" {
+		return errors.E(err, "invalid token realm %q in authentication challenge", params["realm"])
+	}
+
+	query := realm.Query()
+	if service := params["service"]; service != "" {
+		query.Set("service", service)
+	}
+	scope := params["scope"]
+	if scope == "" {
+		scope = "repository:" + c.repository + ":pull"
+	}
+	query.Set("scope", scope)
+	realm.RawQuery = query.Encode()
+
+	req, err := http.NewRequest(http.MethodGet, realm.String(), nil)
+	if err != nil {
+		return errors.E(err, "creating token request")
+	}
+	if c.username != "" {
+		req.SetBasicAuth(c.username, c.password)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return errors.E(err, "requesting registry token")
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return c.authError("token request failed with status " + resp.Status)
+	}
+
+	var token struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return errors.E(err, "parsing registry token")
+	}
+	c.token = token.Token
+	if c.token == "" {
+		c.token = token.AccessToken
+	}
+	if c.token == "" {
+		return c.authError("registry returned an empty token")
+	}
+	return nil
+}
+
+func (c *ociClient) authError(reason string) error {
+	hint := "check the credentials with \"docker login " + c.registry + "\""
+	if c.username == "" {
+		hint = "no credentials found for the registry, authenticate with \"docker login " + c.registry + "\""
+	}
+	return errors.E(ErrOCIAuth, "pulling %s/%s: %s: %s", c.registry, c.repository, reason, hint)
+}
+
+// parseAuthChallenge parses a WWW-Authenticate header value like:
+//
+//	Bearer realm="https://auth.example.com/token",service="registry.example.com"
+func parseAuthChallenge(challenge string) (string, map[string]string) {
+	scheme, rest, _ := strings.Cut(strings.TrimSpace(challenge), " ")
+	params := map[string]string{}
+	for rest != "" {
+		var key, value string
+		key, rest, _ = strings.Cut(rest, "=")
+		key = strings.TrimSpace(strings.TrimLeft(key, ", "))
+		if strings.HasPrefix(rest, `"`) {
+			value, rest, _ = strings.Cut(rest[1:], `"`)
+		} else {
+			value, rest, _ = strings.Cut(rest, ",")
+		}
+		rest = strings.TrimLeft(rest, ", ")
+		if key != "" {
+			params[strings.ToLower(key)] = value
+		}
+	}
+	return scheme, params
+}
+
+// dockerCredentials returns the credentials of the given registry configured
+// in the Docker config file, if any. Credential helpers are not supported.
+func dockerCredentials(registry string) (string, string, error) {
+	configDir := os.Getenv(dockerConfigEnv)
+	if configDir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", "", nil
+		}
+		configDir = filepath.Join(home, dockerDefaultConfigDirname)
+	}
+
+	data, err := os.ReadFile(filepath.Join(configDir, dockerConfigFilename))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", "", nil
+		}
+		return "", "", errors.E(err, "reading docker config")
+	}
+
+	var config struct {
+		Auths map[string]struct {
+			Auth     string `json:"auth"`
+			Username string `json:"username"`
+			Password string `json:"password"`
+		} `json:"auths"`
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return "", "", errors.E(err, "parsing docker config")
+	}
+
+	for key, auth := range config.Auths {
+		host := key
+		if u, err := url.Parse(key); err == nil && u.Host != "" {
+			host = u.Host
+		}
+		if host != registry {
+			continue
+		}
+		if auth.Auth == "" {
+			return auth.Username, auth.Password, nil
+		}
+		decoded, err := base64.StdEncoding.DecodeString(auth.Auth)
+		if err != nil {
+			return "", "", errors.E(err, "decoding docker config credentials of %s", registry)
+		}
+		username, password, _ := strings.Cut(string(decoded), ":")
+		return username, password, nil
+	}
+	return "", "", nil
+}
+
+func isLoopbackRegistry(registry string) bool {
+	host := registry
+	if h, _, err := net.SplitHostPort(registry); err == nil {
+		host = h
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// untar extracts the tar archive into dir. When the archive was created from a
+// directory, as done by oras, the entries are inside a directory named after
+// the layer title, which is stripped.
+func untar(r io.Reader, dir string, title string) error {
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return errors.E(ErrOCIArtifact, err, "reading tar layer")
+		}
+
+		name := path.Clean(strings.TrimPrefix(hdr.Name, "./"))
+		if title != "" {
+			if name == title {
+				continue
+			}
+			name = strings.TrimPrefix(name, title+"/")
+		}
+		if name == "." {
+			continue
+		}
+		if path.IsAbs(name) || name == ".." || strings.HasPrefix(name, "../") {
+			return errors.E(ErrOCIArtifact, "tar entry %q is outside the module directory", hdr.Name)
+		}
+
+		target := filepath.Join(dir, filepath.FromSlash(name))
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0775); err != nil {
+				return errors.E(err, "creating directory %s", name)
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0775); err != nil {
+				return errors.E(err, "creating directory of %s", name)
+			}
+			if err := writeTarFile(tr, target, hdr.FileInfo().Mode().Perm()); err != nil {
+				return errors.E(err, "writing file %s", name)
+			}
+		default:
+			log.Debug().
+				Str("entry", hdr.Name).
+				Msg("ignoring tar entry that is not a regular file or directory")
+		}
+	}
+}
+
+func writeTarFile(r io.Reader, target string, mode os.FileMode) error {
+	f, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode|0600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
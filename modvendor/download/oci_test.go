@@ -0,0 +1,260 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package download_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/madlambda/spells/assert"
+	"github.com/terramate-io/terramate/errors"
+	"github.com/terramate-io/terramate/modvendor"
+	"github.com/terramate-io/terramate/modvendor/download"
+	"github.com/terramate-io/terramate/project"
+	"github.com/terramate-io/terramate/test"
+)
+
+const (
+	ociTestUser     = "user"
+	ociTestPassword = "secret"
+)
+
+// ociRegistry is a minimal OCI distribution registry serving artifacts from
+// memory, optionally requiring basic authentication.
+type ociRegistry struct {
+	requireAuth bool
+	tags        map[string]string // repository:tag -> manifest digest
+	manifests   map[string][]byte // digest -> manifest
+	blobs       map[string][]byte // digest -> blob
+}
+
+func newOCIRegistry(t *testing.T, requireAuth bool) (*ociRegistry, string) {
+	t.Helper()
+
+	reg := &ociRegistry{
+		requireAuth: requireAuth,
+		tags:        map[string]string{},
+		manifests:   map[string][]byte{},
+		blobs:       map[string][]byte{},
+	}
+	srv := httptest.NewServer(reg)
+	t.Cleanup(srv.Close)
+	return reg, strings.TrimPrefix(srv.URL, "http://")
+}
+
+func (reg *ociRegistry) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if reg.requireAuth {
+		user, pass, ok := r.BasicAuth()
+		if !ok || user != ociTestUser || pass != ociTestPassword {
+			w.Header().Set("WWW-Authenticate", `Basic realm="test registry"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+	}
+
+	apipath := strings.TrimPrefix(r.URL.Path, "/v2/")
+	if i := strings.LastIndex(apipath, "/manifests/"); i != -1 {
+		repo, ref := apipath[:i], apipath[i+len("/manifests/"):]
+		digest := ref
+		if !strings.HasPrefix(ref, "sha256:") {
+			digest = reg.tags[repo+":"+ref]
+		}
+		manifest, ok := reg.manifests[digest]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/vnd.oci.image.manifest.v1+json")
+		_, _ = w.Write(manifest)
+		return
+	}
+	if i := strings.LastIndex(apipath, "/blobs/"); i != -1 {
+		blob, ok := reg.blobs[apipath[i+len("/blobs/"):]]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write(blob)
+		return
+	}
+	w.WriteHeader(http.StatusNotFound)
+}
+
+// push stores an artifact with a single gzipped tar layer holding the given
+// files inside a directory named after title, as done by oras, and returns
+// the manifest digest.
+func (reg *ociRegistry) push(t *testing.T, repo, tag, title string, files map[string]string) string {
+	t.Helper()
+
+	var buf bytes.Buffer
+	gzw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gzw)
+	assert.NoError(t, tw.WriteHeader(&tar.Header{
+		Name:     title + "/",
+		Typeflag: tar.TypeDir,
+		Mode:     0755,
+	}))
+	for name, content := range files {
+		assert.NoError(t, tw.WriteHeader(&tar.Header{
+			Name:     title + "/" + name,
+			Typeflag: tar.TypeReg,
+			Mode:     0644,
+			Size:     int64(len(content)),
+		}))
+		_, err := tw.Write([]byte(content))
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, tw.Close())
+	assert.NoError(t, gzw.Close())
+
+	layerDigest := ociDigest(buf.Bytes())
+	reg.blobs[layerDigest] = buf.Bytes()
+
+	manifest, err := json.Marshal(map[string]interface{}{
+		"schemaVersion": 2,
+		"mediaType":     "application/vnd.oci.image.manifest.v1+json",
+		"layers": []map[string]interface{}{
+			{
+				"mediaType": "application/vnd.oci.image.layer.v1.tar+gzip",
+				"digest":    layerDigest,
+				"size":      buf.Len(),
+				"annotations": map[string]string{
+					"org.opencontainers.image.title":  title,
+					"io.deis.oras.content.unpack":     "true",
+					"io.deis.oras.content.digest":     layerDigest,
+					"org.opencontainers.image.source": "test",
+				},
+			},
+		},
+	})
+	assert.NoError(t, err)
+
+	manifestDigest := ociDigest(manifest)
+	reg.manifests[manifestDigest] = manifest
+	reg.tags[repo+":"+tag] = manifestDigest
+	return manifestDigest
+}
+
+func ociDigest(data []byte) string {
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+func TestModVendorOCIPullByTag(t *testing.T) {
+	t.Setenv("DOCKER_CONFIG", test.TempDir(t))
+
+	reg, host := newOCIRegistry(t, false)
+	reg.push(t, "modules/vpc", "1.2.3", "vpc", map[string]string{
+		"main.tf":         "# vpc module",
+		"modules/a/a.tf":  "# submodule",
+		"docs/README.txt": "docs",
+	})
+
+	source := test.ParseSource(t, "oci://"+host+"/modules/vpc:1.2.3")
+	rootdir := test.TempDir(t)
+	vendordir := project.NewPath("/vendor")
+
+	report := download.Vendor(rootdir, vendordir, source, nil)
+	assertVendorReportOK(t, report)
+
+	clonedir := modvendor.AbsVendorDir(rootdir, vendordir, source)
+	assert.EqualStrings(t, "# vpc module", string(test.ReadFile(t, clonedir, "main.tf")))
+	assert.EqualStrings(t, "# submodule", string(test.ReadFile(t, clonedir, "modules/a/a.tf")))
+	assert.EqualStrings(t, "docs", string(test.ReadFile(t, clonedir, "docs/README.txt")))
+}
+
+func TestModVendorOCIPullByDigest(t *testing.T) {
+	t.Setenv("DOCKER_CONFIG", test.TempDir(t))
+
+	reg, host := newOCIRegistry(t, false)
+	digest := reg.push(t, "modules/vpc", "1.2.3", "vpc", map[string]string{
+		"main.tf": "# pinned",
+	})
+	// the tag is moved but the digest still references the pinned artifact.
+	reg.push(t, "modules/vpc", "1.2.3", "vpc", map[string]string{
+		"main.tf": "# moved tag",
+	})
+
+	source := test.ParseSource(t, "oci://"+host+"/modules/vpc@"+digest)
+	rootdir := test.TempDir(t)
+	vendordir := project.NewPath("/vendor")
+
+	report := download.Vendor(rootdir, vendordir, source, nil)
+	assertVendorReportOK(t, report)
+
+	clonedir := modvendor.AbsVendorDir(rootdir, vendordir, source)
+	assert.EqualStrings(t, "# pinned", string(test.ReadFile(t, clonedir, "main.tf")))
+
+	unknown := test.ParseSource(t, "oci://"+host+"/modules/vpc@"+ociDigest([]byte("unknown")))
+	report = download.Vendor(rootdir, vendordir, unknown, nil)
+	assert.EqualInts(t, 1, len(report.Ignored))
+	assert.IsTrue(t, errors.IsKind(report.Ignored[0].Reason, download.ErrDownloadMod))
+}
+
+func TestModVendorOCIAuth(t *testing.T) {
+	configdir := test.TempDir(t)
+	t.Setenv("DOCKER_CONFIG", configdir)
+
+	reg, host := newOCIRegistry(t, true)
+	reg.push(t, "modules/vpc", "1.2.3", "vpc", map[string]string{
+		"main.tf": "# private",
+	})
+
+	source := test.ParseSource(t, "oci://"+host+"/modules/vpc:1.2.3")
+	rootdir := test.TempDir(t)
+	vendordir := project.NewPath("/vendor")
+
+	report := download.Vendor(rootdir, vendordir, source, nil)
+	assert.EqualInts(t, 1, len(report.Ignored))
+	reason := report.Ignored[0].Reason
+	assert.IsTrue(t, errors.IsKind(reason, download.ErrOCIAuth), "got error: %v", reason)
+	assert.IsTrue(t, strings.Contains(reason.Error(), "docker login "+host),
+		"error %q must hint about docker login", reason)
+
+	writeDockerConfig(t, configdir, host, ociTestUser, "wrong")
+
+	report = download.Vendor(rootdir, vendordir, source, nil)
+	assert.EqualInts(t, 1, len(report.Ignored))
+	assert.IsTrue(t, errors.IsKind(report.Ignored[0].Reason, download.ErrOCIAuth))
+
+	writeDockerConfig(t, configdir, host, ociTestUser, ociTestPassword)
+
+	report = download.Vendor(rootdir, vendordir, source, nil)
+	assertVendorReportOK(t, report)
+
+	clonedir := modvendor.AbsVendorDir(rootdir, vendordir, source)
+	assert.EqualStrings(t, "# private", string(test.ReadFile(t, clonedir, "main.tf")))
+}
+
+func writeDockerConfig(t *testing.T, dir, host, user, password string) {
+	t.Helper()
+
+	auth := base64.StdEncoding.EncodeToString([]byte(user + ":" + password))
+	config, err := json.Marshal(map[string]interface{}{
+		"auths": map[string]interface{}{
+			host: map[string]string{"auth": auth},
+		},
+	})
+	assert.NoError(t, err)
+	test.WriteFile(t, dir, "config.json", string(config))
+}
+
+func assertVendorReportOK(t *testing.T, report download.Report) {
+	t.Helper()
+
+	assert.NoError(t, report.Error)
+	for _, ignored := range report.Ignored {
+		t.Errorf("unexpected ignored module %s: %v", ignored.RawSource, ignored.Reason)
+	}
+	assert.EqualInts(t, 1, len(report.Vendored))
+}
@@ -0,0 +1,429 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package download
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	iofs "io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+	"github.com/terramate-io/terramate/errors"
+	"github.com/terramate-io/terramate/modvendor"
+	"github.com/terramate-io/terramate/project"
+	"github.com/terramate-io/terramate/tf"
+)
+
+// UpdateStatus is the outcome of updating a vendored module.
+type UpdateStatus int
+
+const (
+	// UpdateFailed indicates that the module could not be vendored again.
+	UpdateFailed UpdateStatus = iota
+	// Updated indicates that the module was vendored for the first time or
+	// that its content changed.
+	Updated
+	// UpToDate indicates that the module was vendored again but its content
+	// did not change.
+	UpToDate
+)
+
+// UpdateResult is the result of updating a single vendored module.
+type UpdateResult struct {
+	Source tf.Source
+	Dir    project.Path
+	Status UpdateStatus
+	Error  error
+}
+
+// UpdateReport is the result of updating a set of vendored modules.
+type UpdateReport struct {
+	Results []UpdateResult
+	Pruned  []project.Path
+}
+
+// Update vendors the given module again at its pinned reference.
+//
+// The previously vendored directory, if any, is kept aside while the module is
+// downloaded and is restored if vendoring fails, so a failed update never
+// leaves the vendor dir without the module. The module dependencies that are
+// not vendored yet are vendored as done by [Vendor].
+func Update(
+	rootdir string,
+	vendorDir project.Path,
+	modsrc tf.Source,
+	events ProgressEventStream,
+) UpdateResult {
+	res := UpdateResult{
+		Source: modsrc,
+		Dir:    modvendor.TargetDir(vendorDir, modsrc),
+		Status: UpdateFailed,
+	}
+
+	logger := log.With().
+		Str("action", "download.Update()").
+		Str("modsrc", modsrc.Raw).
+		Stringer("dir", res.Dir).
+		Logger()
+
+	moddir := modvendor.AbsVendorDir(rootdir, vendorDir, modsrc)
+
+	var backupdir string
+	if _, err := os.Stat(moddir); err == nil {
+		tmpdir, err := os.MkdirTemp(rootdir, ".tmvendor")
+		if err != nil {
+			res.Error = errors.E(err, "creating tmp dir inside project")
+			return res
+		}
+		defer func() {
+			if err := os.RemoveAll(tmpdir); err != nil {
+				logger.Warn().Err(err).Msg("deleting temp dir inside terramate project")
+			}
+		}()
+
+		backupdir = filepath.Join(tmpdir, "module")
+		if err := os.Rename(moddir, backupdir); err != nil {
+			res.Error = errors.E(err, "moving vendored module to tmp dir")
+			return res
+		}
+	}
+
+	report := Vendor(rootdir, vendorDir, modsrc, events)
+	if err := updateError(report, modsrc); err != nil {
+		res.Error = err
+		if backupdir != "" {
+			logger.Debug().Msg("restoring previously vendored module")
+
+			if err := os.RemoveAll(moddir); err != nil {
+				res.Error = errors.L(res.Error, err).AsError()
+				return res
+			}
+			if err := os.Rename(backupdir, moddir); err != nil {
+				res.Error = errors.L(res.Error,
+					errors.E(err, "restoring vendored module")).AsError()
+			}
+		}
+		return res
+	}
+
+	res.Status = Updated
+	if backupdir == "" {
+		return res
+	}
+
+	same, err := sameDirContent(backupdir, moddir)
+	if err != nil {
+		res.Status = UpdateFailed
+		res.Error = errors.E(err, "comparing updated module")
+		return res
+	}
+	if same {
+		res.Status = UpToDate
+	}
+	return res
+}
+
+// UpdateAll calls [Update] for all the given module sources, using at most
+// parallel concurrent downloads. Sources vendored into the same directory are
+// updated only once. The results are sorted by the vendored directory.
+func UpdateAll(
+	rootdir string,
+	vendorDir project.Path,
+	sources []tf.Source,
+	parallel int,
+	events ProgressEventStream,
+) UpdateReport {
+	if parallel <= 0 {
+		parallel = 1
+	}
+
+	var unique []tf.Source
+	seen := map[project.Path]struct{}{}
+	for _, modsrc := range sources {
+		dir := modvendor.TargetDir(vendorDir, modsrc)
+		if _, ok := seen[dir]; ok {
+			continue
+		}
+		seen[dir] = struct{}{}
+		unique = append(unique, modsrc)
+	}
+
+	results := make([]UpdateResult, len(unique))
+	jobs := make(chan int)
+
+	var wg sync.WaitGroup
+	for i := 0; i < parallel; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] = Update(rootdir, vendorDir, unique[i], events)
+			}
+		}()
+	}
+
+	for i := range unique {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Dir.String() < results[j].Dir.String()
+	})
+	return UpdateReport{Results: results}
+}
+
+// Prune removes all directories inside the vendor dir that are not used by the
+// given vendored modules or by any of their vendored dependencies. It returns
+// the removed directories, sorted.
+//
+// Directories holding files are considered part of a vendored module and are
+// never partially removed.
+func Prune(rootdir string, vendorDir project.Path, modules []project.Path) ([]project.Path, error) {
+	absVendorDir := filepath.Join(rootdir, filepath.FromSlash(vendorDir.String()))
+	if _, err := os.Stat(absVendorDir); err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, errors.E(err, "checking vendor dir")
+	}
+
+	keep, err := vendoredDependencies(rootdir, absVendorDir, modules)
+	if err != nil {
+		return nil, err
+	}
+
+	var pruned []project.Path
+	var prune func(dir string, isVendorDir bool) error
+	prune = func(dir string, isVendorDir bool) error {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return errors.E(err, "reading vendor dir")
+		}
+		if !isVendorDir {
+			for _, entry := range entries {
+				if !entry.IsDir() {
+					return nil
+				}
+			}
+		}
+		for _, entry := range entries {
+			if !entry.IsDir() {
+				continue
+			}
+			path := filepath.Join(dir, entry.Name())
+			switch keepStatus(keep, path) {
+			case keepDir:
+				continue
+			case keepParent:
+				if err := prune(path, false); err != nil {
+					return err
+				}
+			default:
+				if err := os.RemoveAll(path); err != nil {
+					return errors.E(err, "removing unused vendored dir")
+				}
+				pruned = append(pruned, project.PrjAbsPath(rootdir, path))
+			}
+		}
+		return nil
+	}
+
+	if err := prune(absVendorDir, true); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(pruned, func(i, j int) bool {
+		return pruned[i].String() < pruned[j].String()
+	})
+	return pruned, nil
+}
+
+const (
+	keepNone = iota
+	keepDir
+	keepParent
+)
+
+func keepStatus(keep map[string]struct{}, dir string) int {
+	status := keepNone
+	for k := range keep {
+		if k == dir || strings.HasPrefix(dir, k+string(filepath.Separator)) {
+			return keepDir
+		}
+		if strings.HasPrefix(k, dir+string(filepath.Separator)) {
+			status = keepParent
+		}
+	}
+	return status
+}
+
+// vendoredDependencies returns the host dirs of the given modules and all the
+// local modules inside the vendor dir referenced by them, recursively.
+func vendoredDependencies(
+	rootdir string,
+	absVendorDir string,
+	modules []project.Path,
+) (map[string]struct{}, error) {
+	keep := map[string]struct{}{}
+	var pending []string
+	for _, mod := range modules {
+		pending = append(pending, filepath.Join(rootdir, filepath.FromSlash(mod.String())))
+	}
+
+	for len(pending) > 0 {
+		moddir := pending[0]
+		pending = pending[1:]
+
+		if _, ok := keep[moddir]; ok {
+			continue
+		}
+		keep[moddir] = struct{}{}
+
+		err := filepath.WalkDir(moddir, func(path string, d iofs.DirEntry, err error) error {
+			if err != nil {
+				if os.IsNotExist(err) {
+					return nil
+				}
+				return err
+			}
+			if !d.Type().IsRegular() || !tf.IsTerraformFile(path) {
+				return nil
+			}
+			modules, err := tf.ParseModules(path)
+			if err != nil {
+				// unparseable files don't reference anything.
+				return nil
+			}
+			for _, mod := range modules {
+				if !mod.IsLocal() {
+					continue
+				}
+				dep := filepath.Clean(filepath.Join(filepath.Dir(path), filepath.FromSlash(mod.Source)))
+				if strings.HasPrefix(dep, absVendorDir+string(filepath.Separator)) {
+					pending = append(pending, dep)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, errors.E(err, "listing dependencies of vendored module")
+		}
+	}
+	return keep, nil
+}
+
+// String returns a human readable report of the updated modules.
+func (r UpdateReport) String() string {
+	report := []string{
+		"Vendor update report:",
+		"",
+	}
+
+	addLine := func(msg string, args ...interface{}) {
+		report = append(report, fmt.Sprintf(msg, args...))
+	}
+
+	for _, res := range r.Results {
+		switch res.Status {
+		case Updated:
+			addLine("[+] %s", res.Source.URL)
+		case UpToDate:
+			addLine("[=] %s", res.Source.URL)
+		default:
+			addLine("[!] %s", res.Source.URL)
+		}
+		addLine("    ref: %s", res.Source.Ref)
+		addLine("    dir: %s", res.Dir)
+		if res.Error != nil {
+			addLine("    reason: %s", res.Error)
+		}
+	}
+	for _, dir := range r.Pruned {
+		addLine("[-] %s", dir)
+	}
+
+	return strings.Join(report, "\n")
+}
+
+// Count returns the number of results with the given status.
+func (r UpdateReport) Count(status UpdateStatus) int {
+	count := 0
+	for _, res := range r.Results {
+		if res.Status == status {
+			count++
+		}
+	}
+	return count
+}
+
+// HasFailures returns true if any module failed to be updated.
+func (r UpdateReport) HasFailures() bool {
+	return r.Count(UpdateFailed) > 0
+}
+
+// updateError returns the error that prevented modsrc from being vendored,
+// if any. The module being vendored concurrently as a dependency of another
+// module is not an error.
+func updateError(report Report, modsrc tf.Source) error {
+	for _, ignored := range report.Ignored {
+		if ignored.RawSource == modsrc.Raw &&
+			!errors.IsKind(ignored.Reason, ErrAlreadyVendored) {
+			return ignored.Reason
+		}
+	}
+	return report.Error
+}
+
+func sameDirContent(dir1, dir2 string) (bool, error) {
+	digest1, err := dirDigest(dir1)
+	if err != nil {
+		return false, err
+	}
+	digest2, err := dirDigest(dir2)
+	if err != nil {
+		return false, err
+	}
+	if len(digest1) != len(digest2) {
+		return false, nil
+	}
+	for path, sum := range digest1 {
+		if other, ok := digest2[path]; !ok || !bytes.Equal(sum, other) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func dirDigest(dir string) (map[string][]byte, error) {
+	digest := map[string][]byte{}
+	err := filepath.WalkDir(dir, func(path string, d iofs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		relpath, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			digest[relpath] = nil
+			return nil
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		sum := sha256.Sum256(content)
+		digest[relpath] = sum[:]
+		return nil
+	})
+	return digest, err
+}
@@ -0,0 +1,166 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package download_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/madlambda/spells/assert"
+	"github.com/terramate-io/terramate/modvendor"
+	"github.com/terramate-io/terramate/modvendor/download"
+	"github.com/terramate-io/terramate/project"
+	"github.com/terramate-io/terramate/test"
+	"github.com/terramate-io/terramate/test/sandbox"
+	"github.com/terramate-io/terramate/tf"
+	"go.lsp.dev/uri"
+)
+
+func TestModVendorUpdate(t *testing.T) {
+	t.Parallel()
+
+	const filename = "file.txt"
+
+	repoSandbox := sandbox.New(t)
+	repoSandbox.RootEntry().CreateFile(filename, "v1")
+	repogit := repoSandbox.Git()
+	repogit.CommitAll("add file")
+
+	source := newSource(t, uri.File(repoSandbox.RootDir()), "main")
+	rootdir := test.TempDir(t)
+	vendordir := project.NewPath("/vendor")
+	clonedir := modvendor.AbsVendorDir(rootdir, vendordir, source)
+
+	got := download.Update(rootdir, vendordir, source, nil)
+	assert.NoError(t, got.Error)
+	assertUpdateStatus(t, download.Updated, got.Status)
+	assert.EqualStrings(t, modvendor.TargetDir(vendordir, source).String(), got.Dir.String())
+	assert.EqualStrings(t, "v1", string(test.ReadFile(t, clonedir, filename)))
+
+	got = download.Update(rootdir, vendordir, source, nil)
+	assert.NoError(t, got.Error)
+	assertUpdateStatus(t, download.UpToDate, got.Status)
+
+	repoSandbox.RootEntry().CreateFile(filename, "v2")
+	repogit.CommitAll("update file")
+
+	got = download.Update(rootdir, vendordir, source, nil)
+	assert.NoError(t, got.Error)
+	assertUpdateStatus(t, download.Updated, got.Status)
+	assert.EqualStrings(t, "v2", string(test.ReadFile(t, clonedir, filename)))
+	assertNoGitDir(t, clonedir)
+}
+
+func TestModVendorUpdateFailureKeepsVendoredModule(t *testing.T) {
+	t.Parallel()
+
+	const filename = "file.txt"
+
+	repoSandbox := sandbox.New(t)
+	repoSandbox.RootEntry().CreateFile(filename, "data")
+	repoSandbox.Git().CommitAll("add file")
+
+	source := newSource(t, uri.File(repoSandbox.RootDir()), "main")
+	rootdir := test.TempDir(t)
+	vendordir := project.NewPath("/vendor")
+
+	got := download.Update(rootdir, vendordir, source, nil)
+	assert.NoError(t, got.Error)
+
+	assert.NoError(t, os.RemoveAll(repoSandbox.RootDir()))
+
+	got = download.Update(rootdir, vendordir, source, nil)
+	assertUpdateStatus(t, download.UpdateFailed, got.Status)
+	assert.Error(t, got.Error)
+
+	clonedir := modvendor.AbsVendorDir(rootdir, vendordir, source)
+	assert.EqualStrings(t, "data", string(test.ReadFile(t, clonedir, filename)))
+}
+
+func TestModVendorUpdateAll(t *testing.T) {
+	t.Parallel()
+
+	var sources []tf.Source
+	for _, name := range []string{"mod-a", "mod-b", "mod-c"} {
+		repoSandbox := sandbox.New(t)
+		repoSandbox.RootEntry().CreateFile("name.txt", name)
+		repoSandbox.Git().CommitAll("add file")
+		sources = append(sources, newSource(t, uri.File(repoSandbox.RootDir()), "main"))
+	}
+	// duplicated sources are updated once.
+	sources = append(sources, sources[0])
+
+	rootdir := test.TempDir(t)
+	vendordir := project.NewPath("/vendor")
+
+	report := download.UpdateAll(rootdir, vendordir, sources, 2, nil)
+	assert.EqualInts(t, 3, len(report.Results))
+	assert.EqualInts(t, 3, report.Count(download.Updated))
+	assert.IsTrue(t, !report.HasFailures())
+
+	for i := 1; i < len(report.Results); i++ {
+		assert.IsTrue(t, report.Results[i-1].Dir.String() < report.Results[i].Dir.String(),
+			"results must be sorted by dir")
+	}
+
+	report = download.UpdateAll(rootdir, vendordir, sources, 2, nil)
+	assert.EqualInts(t, 3, report.Count(download.UpToDate))
+}
+
+func TestModVendorPrune(t *testing.T) {
+	t.Parallel()
+
+	rootdir := test.TempDir(t)
+	vendordir := project.NewPath("/vendor")
+
+	for _, file := range []string{
+		"vendor/README.md",
+		"vendor/github.com/org/used/v1/main.tf",
+		"vendor/github.com/org/used/v1/sub/other.txt",
+		"vendor/github.com/org/used/v0/main.tf",
+		"vendor/github.com/org/dep/v1/modules/a/main.tf",
+		"vendor/github.com/org/dep/v1/README.md",
+		"vendor/github.com/org/unused/v1/main.tf",
+		"vendor/gitlab.com/org/unused/v1/main.tf",
+	} {
+		path := filepath.Join(rootdir, filepath.FromSlash(file))
+		test.WriteFile(t, filepath.Dir(path), filepath.Base(path), "")
+	}
+
+	test.WriteFile(t, filepath.Join(rootdir, "vendor/github.com/org/used/v1"), "main.tf", `
+		module "dep" {
+		  source = "../../dep/v1/modules/a"
+		}
+	`)
+
+	pruned, err := download.Prune(rootdir, vendordir, []project.Path{
+		project.NewPath("/vendor/github.com/org/used/v1"),
+	})
+	assert.NoError(t, err)
+
+	test.AssertDiff(t, pruned, []project.Path{
+		project.NewPath("/vendor/github.com/org/unused"),
+		project.NewPath("/vendor/github.com/org/used/v0"),
+		project.NewPath("/vendor/gitlab.com"),
+	})
+
+	for _, file := range []string{
+		"vendor/README.md",
+		"vendor/github.com/org/used/v1/sub/other.txt",
+		"vendor/github.com/org/dep/v1/modules/a/main.tf",
+		"vendor/github.com/org/dep/v1/README.md",
+	} {
+		_, err := os.Stat(filepath.Join(rootdir, filepath.FromSlash(file)))
+		assert.NoError(t, err, "file %s must not be pruned", file)
+	}
+}
+
+func assertUpdateStatus(t *testing.T, want, got download.UpdateStatus) {
+	t.Helper()
+
+	if want != got {
+		t.Fatalf("want update status %d, got %d", want, got)
+	}
+}
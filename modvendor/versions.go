@@ -0,0 +1,276 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package modvendor
+
+import (
+	"encoding/json"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/go-version"
+	"github.com/terramate-io/terramate/errors"
+	"github.com/terramate-io/terramate/git"
+	"github.com/terramate-io/terramate/project"
+	"github.com/terramate-io/terramate/tf"
+)
+
+// ErrResolveVersion indicates that the version constraint of a module source
+// can't be resolved.
+const ErrResolveVersion errors.Kind = "resolving module version"
+
+// ManifestFilename is the name of the vendor manifest file, at the root of
+// the vendor dir. It records the versions resolved for the version
+// constraints of the vendored modules.
+const ManifestFilename = "vendor.manifest.json"
+
+// Manifest is the vendor manifest.
+type Manifest struct {
+	Modules []ResolvedModule `json:"modules"`
+}
+
+// ResolvedModule is the version resolved for a module source and version
+// constraint.
+type ResolvedModule struct {
+	// Source is the module source, with no ref.
+	Source string `json:"source"`
+	// Constraint is the version constraint.
+	Constraint string `json:"constraint"`
+	// Version is the git tag resolved for the constraint.
+	Version string `json:"version"`
+}
+
+// remoteTags memoizes the tags of the remote repositories, so each
+// repository is listed at most once per process.
+var remoteTags = struct {
+	sync.Mutex
+	results map[string]tagsResult
+}{
+	results: map[string]tagsResult{},
+}
+
+type tagsResult struct {
+	tags []string
+	err  error
+}
+
+// ResolveVersion resolves the version constraint of the given module source,
+// which must have no ref, and returns the source pinned at the resolved
+// version. The version recorded in the vendor manifest for the same source
+// and constraint is used, if any. Otherwise the tags of the module git
+// repository are listed and the highest semantic version tag matching the
+// constraint is selected.
+func ResolveVersion(rootdir string, vendorDir project.Path, modsrc tf.Source, constraint string) (tf.Source, error) {
+	if modsrc.Ref != "" {
+		return tf.Source{}, errors.E(ErrResolveVersion,
+			"source %q has a ref and a version constraint", modsrc.Raw)
+	}
+	if modsrc.PathScheme == tf.OCIScheme {
+		return tf.Source{}, errors.E(ErrResolveVersion,
+			"version constraints are not supported for OCI source %q", modsrc.Raw)
+	}
+	constraints, err := version.NewConstraint(constraint)
+	if err != nil {
+		return tf.Source{}, errors.E(ErrResolveVersion, err,
+			"invalid version constraint %q", constraint)
+	}
+
+	manifest, err := LoadManifest(rootdir, vendorDir)
+	if err != nil {
+		return tf.Source{}, errors.E(ErrResolveVersion, err)
+	}
+
+	ref, ok := manifest.Lookup(modsrc.Raw, constraint)
+	if !ok {
+		tags, err := listRemoteTags(rootdir, modsrc.URL)
+		if err != nil {
+			return tf.Source{}, errors.E(ErrResolveVersion, err,
+				"listing tags of %q", modsrc.URL)
+		}
+		ref, ok = highestMatchingTag(tags, constraints)
+		if !ok {
+			return tf.Source{}, errors.E(ErrResolveVersion,
+				"no tag of %q matches the version constraint %q", modsrc.URL, constraint)
+		}
+	}
+
+	resolved, err := tf.ParseSource(withRef(modsrc.Raw, ref))
+	if err != nil {
+		return tf.Source{}, errors.E(ErrResolveVersion, err)
+	}
+	return resolved, nil
+}
+
+// RecordVersion records in the vendor manifest the version of the module
+// source resolved by [ResolveVersion] for the version constraint.
+func RecordVersion(rootdir string, vendorDir project.Path, resolved tf.Source, constraint string) error {
+	manifest, err := LoadManifest(rootdir, vendorDir)
+	if err != nil {
+		return err
+	}
+	source := withoutRef(resolved.Raw, resolved.Ref)
+	if ref, ok := manifest.Lookup(source, constraint); ok && ref == resolved.Ref {
+		return nil
+	}
+	manifest.set(source, constraint, resolved.Ref)
+	return manifest.save(rootdir, vendorDir)
+}
+
+// withRef adds the ref query parameter to the raw module source.
+func withRef(raw, ref string) string {
+	sep := "?"
+	if strings.Contains(raw, "?") {
+		sep = "&"
+	}
+	return raw + sep + "ref=" + url.QueryEscape(ref)
+}
+
+// withoutRef removes the ref query parameter added by [withRef].
+func withoutRef(raw, ref string) string {
+	raw = strings.TrimSuffix(raw, "ref="+url.QueryEscape(ref))
+	return strings.TrimSuffix(strings.TrimSuffix(raw, "?"), "&")
+}
+
+// LoadManifest loads the vendor manifest of the vendor dir. An empty manifest
+// is returned if the manifest file doesn't exist.
+func LoadManifest(rootdir string, vendorDir project.Path) (Manifest, error) {
+	var manifest Manifest
+	data, err := os.ReadFile(manifestPath(rootdir, vendorDir))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return manifest, nil
+		}
+		return manifest, errors.E(err, "reading vendor manifest")
+	}
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return manifest, errors.E(err, "parsing vendor manifest")
+	}
+	return manifest, nil
+}
+
+// Lookup returns the version recorded for the module source and version
+// constraint.
+func (m Manifest) Lookup(source, constraint string) (string, bool) {
+	for _, mod := range m.Modules {
+		if mod.Source == source && mod.Constraint == constraint {
+			return mod.Version, true
+		}
+	}
+	return "", false
+}
+
+// Sources returns the module sources recorded in the manifest, pinned at
+// their resolved versions.
+func (m Manifest) Sources() ([]tf.Source, error) {
+	sources := make([]tf.Source, 0, len(m.Modules))
+	for _, mod := range m.Modules {
+		modsrc, err := tf.ParseSource(withRef(mod.Source, mod.Version))
+		if err != nil {
+			return nil, errors.E(err, "invalid module source %q in vendor manifest", mod.Source)
+		}
+		sources = append(sources, modsrc)
+	}
+	return sources, nil
+}
+
+func (m *Manifest) set(source, constraint, version string) {
+	for i, mod := range m.Modules {
+		if mod.Source == source && mod.Constraint == constraint {
+			m.Modules[i].Version = version
+			return
+		}
+	}
+	m.Modules = append(m.Modules, ResolvedModule{
+		Source:     source,
+		Constraint: constraint,
+		Version:    version,
+	})
+	sort.Slice(m.Modules, func(i, j int) bool {
+		if m.Modules[i].Source != m.Modules[j].Source {
+			return m.Modules[i].Source < m.Modules[j].Source
+		}
+		return m.Modules[i].Constraint < m.Modules[j].Constraint
+	})
+}
+
+// save writes the manifest to a temporary file which is then renamed, so the
+// manifest is never read partially written.
+func (m Manifest) save(rootdir string, vendorDir project.Path) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return errors.E(err, "encoding vendor manifest")
+	}
+	path := manifestPath(rootdir, vendorDir)
+	if err := os.MkdirAll(filepath.Dir(path), 0775); err != nil {
+		return errors.E(err, "creating vendor dir")
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmvendor-manifest")
+	if err != nil {
+		return errors.E(err, "creating vendor manifest")
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	_, err = tmp.Write(append(data, '\n'))
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return errors.E(err, "writing vendor manifest")
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return errors.E(err, "writing vendor manifest")
+	}
+	return nil
+}
+
+func manifestPath(rootdir string, vendorDir project.Path) string {
+	return filepath.Join(rootdir, filepath.FromSlash(vendorDir.String()), ManifestFilename)
+}
+
+func listRemoteTags(rootdir, remote string) ([]string, error) {
+	remoteTags.Lock()
+	defer remoteTags.Unlock()
+
+	if res, ok := remoteTags.results[remote]; ok {
+		return res.tags, res.err
+	}
+
+	var res tagsResult
+	g, err := git.WithConfig(git.Config{
+		WorkingDir: rootdir,
+		Env:        append(os.Environ(), "GIT_TERMINAL_PROMPT=0"),
+	})
+	if err != nil {
+		res.err = err
+	} else {
+		res.tags, res.err = g.ListRemoteTags(remote)
+	}
+	remoteTags.results[remote] = res
+	return res.tags, res.err
+}
+
+// highestMatchingTag returns the tag with the highest semantic version
+// matching the constraints. Tags which are not semantic versions are ignored.
+func highestMatchingTag(tags []string, constraints version.Constraints) (string, bool) {
+	var (
+		best    *version.Version
+		bestTag string
+	)
+	for _, tag := range tags {
+		v, err := version.NewVersion(tag)
+		if err != nil {
+			continue
+		}
+		if !constraints.Check(v) {
+			continue
+		}
+		if best == nil || v.GreaterThan(best) {
+			best, bestTag = v, tag
+		}
+	}
+	return bestTag, best != nil
+}
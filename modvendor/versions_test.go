@@ -0,0 +1,178 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package modvendor_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/madlambda/spells/assert"
+	"github.com/terramate-io/terramate/errors"
+	"github.com/terramate-io/terramate/modvendor"
+	"github.com/terramate-io/terramate/project"
+	"github.com/terramate-io/terramate/test"
+	errtest "github.com/terramate-io/terramate/test/errors"
+	"github.com/terramate-io/terramate/test/sandbox"
+	"github.com/terramate-io/terramate/tf"
+	"go.lsp.dev/uri"
+)
+
+func TestResolveVersion(t *testing.T) {
+	t.Parallel()
+
+	type testcase struct {
+		name       string
+		tags       []string
+		constraint string
+		want       string
+		wantErr    error
+	}
+
+	for _, tc := range []testcase{
+		{
+			name:       "highest matching tag",
+			tags:       []string{"v1.0.0", "v1.2.0", "v1.10.1", "v2.0.0"},
+			constraint: "~> 1.0",
+			want:       "v1.10.1",
+		},
+		{
+			name:       "tags without v prefix",
+			tags:       []string{"1.0.0", "1.1.0", "2.0.0"},
+			constraint: ">= 1.0, < 2.0",
+			want:       "1.1.0",
+		},
+		{
+			name:       "non semantic version tags are ignored",
+			tags:       []string{"latest", "release-2", "v1.0.0"},
+			constraint: ">= 1.0",
+			want:       "v1.0.0",
+		},
+		{
+			name:       "no matching tag",
+			tags:       []string{"v1.0.0", "v1.1.0"},
+			constraint: "~> 2.0",
+			wantErr:    errors.E(modvendor.ErrResolveVersion),
+		},
+		{
+			name:       "no tags",
+			constraint: "~> 1.0",
+			wantErr:    errors.E(modvendor.ErrResolveVersion),
+		},
+		{
+			name:       "invalid constraint",
+			tags:       []string{"v1.0.0"},
+			constraint: "not a constraint",
+			wantErr:    errors.E(modvendor.ErrResolveVersion),
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			source := newTaggedSource(t, tc.tags...)
+			rootdir := test.TempDir(t)
+
+			got, err := modvendor.ResolveVersion(rootdir, project.NewPath("/vendor"), source, tc.constraint)
+			errtest.Assert(t, err, tc.wantErr)
+			if tc.wantErr != nil {
+				return
+			}
+			assert.EqualStrings(t, tc.want, got.Ref)
+			assert.EqualStrings(t, source.URL, got.URL)
+			assert.EqualStrings(t, source.Raw+"?ref="+tc.want, got.Raw)
+		})
+	}
+}
+
+func TestResolveVersionFailsIfSourceHasRef(t *testing.T) {
+	t.Parallel()
+
+	source, err := tf.ParseSource("github.com/terramate-io/terramate?ref=v1.0.0")
+	assert.NoError(t, err)
+
+	_, err = modvendor.ResolveVersion(test.TempDir(t), project.NewPath("/vendor"), source, "~> 1.0")
+	errtest.Assert(t, err, errors.E(modvendor.ErrResolveVersion))
+}
+
+func TestResolveVersionUsesManifest(t *testing.T) {
+	t.Parallel()
+
+	const constraint = "~> 1.0"
+
+	source := newTaggedSource(t, "v1.0.0", "v1.1.0")
+	rootdir := test.TempDir(t)
+	vendordir := project.NewPath("/vendor")
+
+	resolved, err := modvendor.ResolveVersion(rootdir, vendordir, source, constraint)
+	assert.NoError(t, err)
+	assert.EqualStrings(t, "v1.1.0", resolved.Ref)
+
+	manifest, err := modvendor.LoadManifest(rootdir, vendordir)
+	assert.NoError(t, err)
+	assert.EqualInts(t, 0, len(manifest.Modules), "resolving must not record versions")
+
+	// an older version recorded in the manifest takes precedence over the
+	// tags of the repository.
+	older, err := tf.ParseSource(source.Raw + "?ref=v1.0.0")
+	assert.NoError(t, err)
+	assert.NoError(t, modvendor.RecordVersion(rootdir, vendordir, older, constraint))
+
+	test.IsFile(t, project.AbsPath(rootdir, vendordir.String()), modvendor.ManifestFilename)
+
+	manifest, err = modvendor.LoadManifest(rootdir, vendordir)
+	assert.NoError(t, err)
+	version, ok := manifest.Lookup(source.Raw, constraint)
+	assert.IsTrue(t, ok, "version not recorded: %+v", manifest)
+	assert.EqualStrings(t, "v1.0.0", version)
+
+	resolved, err = modvendor.ResolveVersion(rootdir, vendordir, source, constraint)
+	assert.NoError(t, err)
+	assert.EqualStrings(t, "v1.0.0", resolved.Ref)
+
+	// other constraints of the same source are resolved independently.
+	resolved, err = modvendor.ResolveVersion(rootdir, vendordir, source, ">= 1.1")
+	assert.NoError(t, err)
+	assert.EqualStrings(t, "v1.1.0", resolved.Ref)
+}
+
+func TestManifestSources(t *testing.T) {
+	t.Parallel()
+
+	source := newTaggedSource(t, "v1.0.0", "v2.0.0")
+	rootdir := test.TempDir(t)
+	vendordir := project.NewPath("/vendor")
+
+	for _, ref := range []string{"v1.0.0", "v2.0.0"} {
+		resolved, err := tf.ParseSource(source.Raw + "?ref=" + ref)
+		assert.NoError(t, err)
+		assert.NoError(t, modvendor.RecordVersion(rootdir, vendordir, resolved, "= "+ref[1:]))
+	}
+
+	manifest, err := modvendor.LoadManifest(rootdir, vendordir)
+	assert.NoError(t, err)
+	sources, err := manifest.Sources()
+	assert.NoError(t, err)
+	assert.EqualInts(t, 2, len(sources), "unexpected sources: %v", sources)
+	assert.EqualStrings(t, source.URL, sources[0].URL)
+	assert.EqualStrings(t, "v1.0.0", sources[0].Ref)
+	assert.EqualStrings(t, source.URL, sources[1].URL)
+	assert.EqualStrings(t, "v2.0.0", sources[1].Ref)
+}
+
+func newTaggedSource(t *testing.T, tags ...string) tf.Source {
+	t.Helper()
+
+	repo := sandbox.New(t)
+	repo.RootEntry().CreateFile("main.tf", "# module")
+	repogit := repo.Git()
+	repogit.CommitAll("add module")
+	for _, tag := range tags {
+		_, err := repogit.Unwrap().Exec("tag", tag)
+		assert.NoError(t, err)
+	}
+
+	source, err := tf.ParseSource(fmt.Sprintf("git::%s", uri.File(repo.RootDir())))
+	assert.NoError(t, err)
+	return source
+}
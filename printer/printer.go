@@ -130,12 +130,16 @@ func (p *Printer) Successf(format string, a ...any) {
 }
 
 // toStrings converts an error into a list of strings where each string
-// represents an individual error.
+// represents an individual error, followed by its related ranges.
 func toStrings(err error) []string {
 	errs := errors.L(err).Errors()
 	list := make([]string, 0, len(errs))
 	for _, errItem := range errs {
-		list = append(list, errItem.Error())
+		msg := errItem.Error()
+		for _, related := range errors.Related(errItem) {
+			msg += "\n    " + related.String()
+		}
+		list = append(list, msg)
 	}
 
 	return list
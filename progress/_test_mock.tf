@@ -0,0 +1,22 @@
+// TERRAMATE: GENERATED AUTOMATICALLY DO NOT EDIT
+
+resource "local_file" "progress" {
+  content = <<-EOT
+package progress // import "github.com/terramate-io/terramate/progress"
+
+Package progress defines the events of the machine-readable progress protocol
+of Terramate, enabled with the --progress-format=ndjson flag. The events are
+encoded as one JSON object per line (NDJSON), so external tools can decode them
+with any JSON decoder.
+
+const FormatNDJSON = "ndjson"
+type Event struct{ ... }
+type LogWriter struct{ ... }
+type Type string
+    const OperationStart Type = "operation_start" ...
+type Writer struct{ ... }
+    func NewWriter(w io.Writer) *Writer
+EOT
+
+  filename = "${path.module}/mock-progress.ignore"
+}
@@ -0,0 +1,201 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+// Package progress defines the events of the machine-readable progress
+// protocol of Terramate, enabled with the --progress-format=ndjson flag.
+// The events are encoded as one JSON object per line (NDJSON), so external
+// tools can decode them with any JSON decoder.
+package progress
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+// FormatNDJSON is the progress format which emits one JSON event per line.
+const FormatNDJSON = "ndjson"
+
+// Type is the type of a progress event.
+type Type string
+
+// Event types.
+const (
+	// OperationStart is emitted when a long-running operation starts.
+	OperationStart Type = "operation_start"
+	// OperationEnd is emitted when a long-running operation finishes.
+	// The Error field is set if the operation failed.
+	OperationEnd Type = "operation_end"
+
+	// StackStart is emitted before the commands of a stack are executed.
+	StackStart Type = "stack_start"
+	// StackSuccess is emitted when all commands of a stack succeeded.
+	StackSuccess Type = "stack_success"
+	// StackFailure is emitted when a command of a stack failed.
+	StackFailure Type = "stack_failure"
+	// StackCanceled is emitted when the execution of a stack was canceled
+	// before any command was executed.
+	StackCanceled Type = "stack_canceled"
+	// StackSkipped is emitted when the remaining script jobs of a stack are
+	// skipped because a previous job of the stack failed.
+	StackSkipped Type = "stack_skipped"
+
+	// FileCreated is emitted when a generated file is created.
+	FileCreated Type = "file_created"
+	// FileChanged is emitted when a generated file is changed.
+	FileChanged Type = "file_changed"
+	// FileDeleted is emitted when a generated file is deleted.
+	FileDeleted Type = "file_deleted"
+	// GenerateFailure is emitted when the code generation of a directory failed.
+	GenerateFailure Type = "generate_failure"
+	// GenerateWarning is emitted for each failed assertion on warning mode
+	// of a directory.
+	GenerateWarning Type = "generate_warning"
+
+	// VendorProgress is emitted when vendoring a module makes progress.
+	VendorProgress Type = "vendor_progress"
+
+	// Log is emitted for each line of human-readable output, like warnings
+	// and errors, which would otherwise be written to stderr.
+	Log Type = "log"
+)
+
+// Event is a progress event. Only the fields relevant for the event type are set.
+type Event struct {
+	Type Type      `json:"type"`
+	Time time.Time `json:"time"`
+
+	// Operation is the name of the operation, e.g. "run" or "generate".
+	Operation string `json:"operation,omitempty"`
+
+	// Stacks is the number of stacks selected by the operation.
+	Stacks int `json:"stacks,omitempty"`
+
+	// Stack is the project path of the stack.
+	Stack string `json:"stack,omitempty"`
+
+	// Cmd is the last command executed in the stack.
+	Cmd []string `json:"cmd,omitempty"`
+
+	// ExitCode is the exit code of the last command executed in the stack
+	// or of the operation.
+	ExitCode *int `json:"exit_code,omitempty"`
+
+	// DurationMS is the duration of the stack execution or of the operation
+	// in milliseconds.
+	DurationMS *int64 `json:"duration_ms,omitempty"`
+
+	// Dir is the project path of the directory with generated files or
+	// vendored modules.
+	Dir string `json:"dir,omitempty"`
+
+	// File is the name of the generated file.
+	File string `json:"file,omitempty"`
+
+	// Module is the source of the vendored module.
+	Module string `json:"module,omitempty"`
+
+	// Message is a human-readable message.
+	Message string `json:"message,omitempty"`
+
+	// Error is the error message of failure events.
+	Error string `json:"error,omitempty"`
+}
+
+// WithExitCode returns a copy of the event with the given exit code.
+func (ev Event) WithExitCode(code int) Event {
+	ev.ExitCode = &code
+	return ev
+}
+
+// WithDuration returns a copy of the event with the given duration.
+func (ev Event) WithDuration(d time.Duration) Event {
+	ms := d.Milliseconds()
+	ev.DurationMS = &ms
+	return ev
+}
+
+// Writer writes progress events as NDJSON. It is safe for concurrent use.
+type Writer struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewWriter creates a new Writer which writes the events to w.
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: w}
+}
+
+// Emit writes the event as a single line. The Time field is set to the
+// current time if it's zero.
+func (w *Writer) Emit(ev Event) error {
+	if ev.Time.IsZero() {
+		ev.Time = time.Now().UTC()
+	}
+	data, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	_, err = w.w.Write(append(data, '\n'))
+	return err
+}
+
+// LogWriter returns an io.Writer which emits a Log event for each line written
+// to it. If stack is not empty, it's set in the emitted events.
+func (w *Writer) LogWriter(stack string) *LogWriter {
+	return &LogWriter{w: w, stack: stack}
+}
+
+// LogWriter is an io.Writer which emits each written line as a Log event.
+type LogWriter struct {
+	w     *Writer
+	stack string
+
+	mu  sync.Mutex
+	buf []byte
+}
+
+// Write buffers p and emits a Log event for each complete line.
+func (lw *LogWriter) Write(p []byte) (int, error) {
+	lw.mu.Lock()
+	defer lw.mu.Unlock()
+
+	lw.buf = append(lw.buf, p...)
+	for {
+		i := bytes.IndexByte(lw.buf, '\n')
+		if i < 0 {
+			break
+		}
+		line := string(bytes.TrimSuffix(lw.buf[:i], []byte("\r")))
+		lw.buf = lw.buf[i+1:]
+		if err := lw.emit(line); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// Flush emits the buffered incomplete line, if any.
+func (lw *LogWriter) Flush() error {
+	lw.mu.Lock()
+	defer lw.mu.Unlock()
+
+	if len(lw.buf) == 0 {
+		return nil
+	}
+	line := string(lw.buf)
+	lw.buf = nil
+	return lw.emit(line)
+}
+
+func (lw *LogWriter) emit(line string) error {
+	return lw.w.Emit(Event{
+		Type:    Log,
+		Stack:   lw.stack,
+		Message: line,
+	})
+}
@@ -0,0 +1,89 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package progress_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/madlambda/spells/assert"
+	"github.com/terramate-io/terramate/progress"
+)
+
+func TestWriterEmit(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	w := progress.NewWriter(&buf)
+
+	assert.NoError(t, w.Emit(progress.Event{
+		Type:      progress.OperationStart,
+		Operation: "run",
+		Stacks:    2,
+	}))
+	assert.NoError(t, w.Emit(progress.Event{
+		Type:  progress.StackFailure,
+		Stack: "/stack",
+		Cmd:   []string{"terraform", "plan"},
+		Error: "failed",
+	}.WithExitCode(0).WithDuration(1500*time.Millisecond)))
+
+	events := decodeEvents(t, buf.Bytes())
+	assert.EqualInts(t, 2, len(events))
+
+	assert.EqualStrings(t, string(progress.OperationStart), string(events[0].Type))
+	assert.EqualStrings(t, "run", events[0].Operation)
+	assert.EqualInts(t, 2, events[0].Stacks)
+	assert.IsTrue(t, !events[0].Time.IsZero(), "time must be set")
+	assert.IsTrue(t, events[0].ExitCode == nil, "exit code must be omitted")
+	assert.IsTrue(t, events[0].DurationMS == nil, "duration must be omitted")
+
+	assert.EqualStrings(t, string(progress.StackFailure), string(events[1].Type))
+	assert.EqualStrings(t, "/stack", events[1].Stack)
+	assert.EqualInts(t, 2, len(events[1].Cmd))
+	assert.IsTrue(t, events[1].ExitCode != nil, "exit code must be present")
+	assert.EqualInts(t, 0, *events[1].ExitCode)
+	assert.IsTrue(t, events[1].DurationMS != nil, "duration must be present")
+	assert.EqualInts(t, 1500, int(*events[1].DurationMS))
+	assert.EqualStrings(t, "failed", events[1].Error)
+}
+
+func TestLogWriter(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	lw := progress.NewWriter(&buf).LogWriter("/stack")
+
+	_, err := lw.Write([]byte("first line\nsecond "))
+	assert.NoError(t, err)
+	_, err = lw.Write([]byte("line\r\nincomplete"))
+	assert.NoError(t, err)
+	assert.NoError(t, lw.Flush())
+	assert.NoError(t, lw.Flush())
+
+	events := decodeEvents(t, buf.Bytes())
+	want := []string{"first line", "second line", "incomplete"}
+	assert.EqualInts(t, len(want), len(events))
+	for i, msg := range want {
+		assert.EqualStrings(t, string(progress.Log), string(events[i].Type))
+		assert.EqualStrings(t, "/stack", events[i].Stack)
+		assert.EqualStrings(t, msg, events[i].Message)
+	}
+}
+
+func decodeEvents(t *testing.T, data []byte) []progress.Event {
+	t.Helper()
+	var events []progress.Event
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		var ev progress.Event
+		assert.NoError(t, json.Unmarshal(scanner.Bytes(), &ev), "line: %s", scanner.Text())
+		events = append(events, ev)
+	}
+	assert.NoError(t, scanner.Err())
+	return events
+}
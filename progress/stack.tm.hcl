@@ -0,0 +1,9 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+stack {
+  name        = "package progress // import \"github.com/terramate-io/terramate/progress\""
+  description = "package progress // import \"github.com/terramate-io/terramate/progress\"\n\nPackage progress defines the events of the machine-readable progress protocol\nof Terramate, enabled with the --progress-format=ndjson flag. The events are\nencoded as one JSON object per line (NDJSON), so external tools can decode them\nwith any JSON decoder.\n\nconst FormatNDJSON = \"ndjson\"\ntype Event struct{ ... }\ntype LogWriter struct{ ... }\ntype Type string\n    const OperationStart Type = \"operation_start\" ...\ntype Writer struct{ ... }\n    func NewWriter(w io.Writer) *Writer"
+  tags        = ["golang", "progress"]
+  id          = "9e432579-e96e-4d73-a197-f81347570441"
+}
@@ -0,0 +1,83 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package run
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/terramate-io/terramate/config"
+	"github.com/terramate-io/terramate/errors"
+	"github.com/terramate-io/terramate/project"
+)
+
+// ErrTerraformChdir indicates that the directory set with the
+// `terraform -chdir=<dir>` option is invalid.
+const ErrTerraformChdir errors.Kind = "invalid terraform -chdir directory"
+
+// TerraformChdir returns the directory set with the -chdir global option of a
+// terraform or tofu command. It returns false if the command is not terraform
+// or tofu or if the option is not set.
+func TerraformChdir(args []string) (string, bool) {
+	if _, ok := terraformProgram(args); !ok {
+		return "", false
+	}
+	// global options must come before the subcommand.
+	for _, arg := range args[1:] {
+		if !strings.HasPrefix(arg, "-") {
+			break
+		}
+		for _, prefix := range []string{"-chdir=", "--chdir="} {
+			if strings.HasPrefix(arg, prefix) {
+				return strings.TrimPrefix(arg, prefix), true
+			}
+		}
+	}
+	return "", false
+}
+
+// terraformProgram returns the name of the program (terraform or tofu) if
+// args is a terraform or tofu command.
+func terraformProgram(args []string) (string, bool) {
+	if len(args) == 0 {
+		return "", false
+	}
+	program := strings.TrimSuffix(filepath.Base(args[0]), ".exe")
+	if program != "terraform" && program != "tofu" {
+		return "", false
+	}
+	return program, true
+}
+
+// TerraformDir returns the project directory where the terraform or tofu
+// command given by args runs when executed inside the stack. It returns false
+// if the command does not set the -chdir option, and an error if the directory
+// does not exist or is outside the project.
+func TerraformDir(root *config.Root, st *config.Stack, args []string) (project.Path, bool, error) {
+	chdir, ok := TerraformChdir(args)
+	if !ok {
+		return project.Path{}, false, nil
+	}
+
+	hostdir := filepath.FromSlash(chdir)
+	if !filepath.IsAbs(hostdir) {
+		hostdir = filepath.Join(st.HostDir(root), hostdir)
+	}
+	hostdir = filepath.Clean(hostdir)
+
+	rootdir := root.HostDir()
+	if hostdir != rootdir && !strings.HasPrefix(hostdir, rootdir+string(filepath.Separator)) {
+		return project.Path{}, true, errors.E(ErrTerraformChdir,
+			"stack %s: -chdir=%s is outside the project", st.Dir, chdir)
+	}
+
+	info, err := os.Stat(hostdir)
+	if err != nil || !info.IsDir() {
+		return project.Path{}, true, errors.E(ErrTerraformChdir,
+			"stack %s: -chdir=%s directory %s does not exist", st.Dir, chdir,
+			project.PrjAbsPath(rootdir, hostdir))
+	}
+	return project.PrjAbsPath(rootdir, hostdir), true, nil
+}
@@ -0,0 +1,40 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package run_test
+
+import (
+	"testing"
+
+	"github.com/madlambda/spells/assert"
+	"github.com/terramate-io/terramate/run"
+)
+
+func TestTerraformChdir(t *testing.T) {
+	t.Parallel()
+
+	type testcase struct {
+		args  []string
+		want  string
+		found bool
+	}
+
+	for _, tc := range []testcase{
+		{args: nil},
+		{args: []string{"terraform", "plan"}},
+		{args: []string{"terraform", "-chdir=dir", "plan"}, want: "dir", found: true},
+		{args: []string{"terraform", "--chdir=dir", "plan"}, want: "dir", found: true},
+		{args: []string{"terraform", "-no-color", "-chdir=a/b", "plan"}, want: "a/b", found: true},
+		{args: []string{"/usr/bin/terraform", "-chdir=dir", "plan"}, want: "dir", found: true},
+		{args: []string{"terraform.exe", "-chdir=dir", "plan"}, want: "dir", found: true},
+		{args: []string{"tofu", "-chdir=dir", "plan"}, want: "dir", found: true},
+		{args: []string{"terraform", "plan", "-chdir=dir"}},
+		{args: []string{"terraform", "-chdir", "plan"}},
+		{args: []string{"echo", "-chdir=dir"}},
+		{args: []string{"terragrunt", "-chdir=dir", "plan"}},
+	} {
+		got, found := run.TerraformChdir(tc.args)
+		assert.IsTrue(t, found == tc.found, "args %v: want found=%t, got %t", tc.args, tc.found, found)
+		assert.EqualStrings(t, tc.want, got, "args %v", tc.args)
+	}
+}
@@ -0,0 +1,139 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package run
+
+import (
+	"cmp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/terramate-io/terramate/config"
+	"github.com/terramate-io/terramate/errors"
+	"github.com/terramate-io/terramate/run/dag"
+)
+
+// ErrInvalidChunk indicates that a chunk spec is invalid.
+const ErrInvalidChunk errors.Kind = "invalid chunk"
+
+// ParseChunk parses a chunk spec in the form <index>/<total>, where the index
+// starts at 0 and must be less than the total.
+func ParseChunk(spec string) (index int, total int, err error) {
+	indexStr, totalStr, ok := strings.Cut(spec, "/")
+	if !ok {
+		return 0, 0, errors.E(ErrInvalidChunk, "%q: expected <index>/<total>", spec)
+	}
+	index, err = strconv.Atoi(strings.TrimSpace(indexStr))
+	if err != nil {
+		return 0, 0, errors.E(ErrInvalidChunk, err, "%q: the index is not a number", spec)
+	}
+	total, err = strconv.Atoi(strings.TrimSpace(totalStr))
+	if err != nil {
+		return 0, 0, errors.E(ErrInvalidChunk, err, "%q: the total is not a number", spec)
+	}
+	if total <= 0 {
+		return 0, 0, errors.E(ErrInvalidChunk, "%q: the total must be greater than 0", spec)
+	}
+	if index < 0 || index >= total {
+		return 0, 0, errors.E(ErrInvalidChunk, "%q: the index must be between 0 and %d", spec, total-1)
+	}
+	return index, total, nil
+}
+
+// Chunks partitions the given list of stacks into the given number of chunks,
+// so each chunk can be executed by a different job.
+// The stacks connected by ordering constraints, directly or through other
+// stacks, are always in the same chunk, so the order of execution is honored
+// without dependencies between the chunks. The groups of connected stacks are
+// distributed from the biggest to the smallest to the chunk with the fewest
+// stacks, which gives the same chunks for the same selection of stacks.
+// The stacks of each chunk keep the order of the given list.
+func Chunks[S ~[]E, E any](root *config.Root, items S, getStack func(E) *config.Stack, total int) ([]S, string, error) {
+	if total <= 0 {
+		return nil, "", errors.E(ErrInvalidChunk, "the total must be greater than 0")
+	}
+
+	// the DAG building sorts the items, so the order of the given list is kept.
+	d, reason, err := BuildDAGFromStacks(root, slices.Clone(items), getStack)
+	if err != nil {
+		return nil, reason, err
+	}
+
+	components := connectedComponents(d)
+	slices.SortStableFunc(components, func(a, b []dag.ID) int {
+		if c := cmp.Compare(len(b), len(a)); c != 0 {
+			return c
+		}
+		return cmp.Compare(a[0], b[0])
+	})
+
+	sizes := make([]int, total)
+	chunkOf := map[string]int{}
+	for _, component := range components {
+		chunk := 0
+		for i := range sizes {
+			if sizes[i] < sizes[chunk] {
+				chunk = i
+			}
+		}
+		sizes[chunk] += len(component)
+		for _, id := range component {
+			chunkOf[string(id)] = chunk
+		}
+	}
+
+	chunks := make([]S, total)
+	for _, item := range items {
+		chunk := chunkOf[getStack(item).Dir.String()]
+		chunks[chunk] = append(chunks[chunk], item)
+	}
+	return chunks, "", nil
+}
+
+// connectedComponents returns the groups of nodes connected by edges in any
+// direction. The ids of each group are lexicographic sorted.
+func connectedComponents[V any](d *dag.DAG[V]) [][]dag.ID {
+	parent := map[dag.ID]dag.ID{}
+	var find func(id dag.ID) dag.ID
+	find = func(id dag.ID) dag.ID {
+		if p, ok := parent[id]; ok && p != id {
+			root := find(p)
+			parent[id] = root
+			return root
+		}
+		return id
+	}
+	union := func(a, b dag.ID) {
+		ra, rb := find(a), find(b)
+		if ra == rb {
+			return
+		}
+		// the smallest id is the representative, so the result is stable.
+		if rb < ra {
+			ra, rb = rb, ra
+		}
+		parent[rb] = ra
+	}
+
+	ids := d.IDs()
+	for _, id := range ids {
+		for _, ancestor := range d.AncestorsOf(id) {
+			union(id, ancestor)
+		}
+	}
+
+	var components [][]dag.ID
+	index := map[dag.ID]int{}
+	for _, id := range ids {
+		root := find(id)
+		i, ok := index[root]
+		if !ok {
+			i = len(components)
+			index[root] = i
+			components = append(components, nil)
+		}
+		components[i] = append(components[i], id)
+	}
+	return components
+}
@@ -0,0 +1,281 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package run_test
+
+import (
+	"fmt"
+	"math/rand"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/madlambda/spells/assert"
+	"github.com/terramate-io/terramate/config"
+	"github.com/terramate-io/terramate/errors"
+	"github.com/terramate-io/terramate/run"
+	"github.com/terramate-io/terramate/run/dag"
+	"github.com/terramate-io/terramate/test"
+	errtest "github.com/terramate-io/terramate/test/errors"
+	"github.com/terramate-io/terramate/test/sandbox"
+)
+
+func TestParseChunk(t *testing.T) {
+	t.Parallel()
+
+	type testcase struct {
+		spec      string
+		wantIndex int
+		wantTotal int
+		wantErr   bool
+	}
+
+	for _, tc := range []testcase{
+		{spec: "0/1", wantIndex: 0, wantTotal: 1},
+		{spec: "2/3", wantIndex: 2, wantTotal: 3},
+		{spec: "3/3", wantErr: true},
+		{spec: "-1/3", wantErr: true},
+		{spec: "0/0", wantErr: true},
+		{spec: "0/-2", wantErr: true},
+		{spec: "1", wantErr: true},
+		{spec: "a/2", wantErr: true},
+		{spec: "1/b", wantErr: true},
+		{spec: "", wantErr: true},
+	} {
+		tc := tc
+		t.Run(tc.spec, func(t *testing.T) {
+			t.Parallel()
+			index, total, err := run.ParseChunk(tc.spec)
+			if tc.wantErr {
+				assert.IsError(t, err, errors.E(run.ErrInvalidChunk))
+				return
+			}
+			assert.NoError(t, err)
+			assert.EqualInts(t, tc.wantIndex, index)
+			assert.EqualInts(t, tc.wantTotal, total)
+		})
+	}
+}
+
+func TestChunks(t *testing.T) {
+	t.Parallel()
+
+	type testcase struct {
+		name   string
+		layout []string
+		total  int
+		want   [][]string
+	}
+
+	for _, tc := range []testcase{
+		{
+			name: "independent stacks are balanced",
+			layout: []string{
+				"s:a",
+				"s:b",
+				"s:c",
+				"s:d",
+				"s:e",
+			},
+			total: 2,
+			want: [][]string{
+				{"/a", "/c", "/e"},
+				{"/b", "/d"},
+			},
+		},
+		{
+			name: "more chunks than stacks",
+			layout: []string{
+				"s:a",
+				"s:b",
+			},
+			total: 3,
+			want: [][]string{
+				{"/a"},
+				{"/b"},
+				nil,
+			},
+		},
+		{
+			name: "ordered stacks are in the same chunk",
+			layout: []string{
+				`s:network:tags=["infra"]`,
+				`s:dns:tags=["infra"]`,
+				`s:app:after=["tag:infra"]`,
+				`s:docs`,
+				`s:tools`,
+			},
+			total: 2,
+			want: [][]string{
+				{"/app", "/dns", "/network"},
+				{"/docs", "/tools"},
+			},
+		},
+		{
+			name: "nested stacks are in the chunk of their parents",
+			layout: []string{
+				"s:parent",
+				"s:parent/child-a",
+				"s:parent/child-b",
+				"s:x",
+				"s:y",
+				"s:z",
+			},
+			total: 2,
+			want: [][]string{
+				{"/parent", "/parent/child-a", "/parent/child-b"},
+				{"/x", "/y", "/z"},
+			},
+		},
+		{
+			name: "stacks connected through a common dependency",
+			layout: []string{
+				`s:a:before=["/c"]`,
+				`s:b:before=["/c"]`,
+				`s:c`,
+				`s:d`,
+			},
+			total: 3,
+			want: [][]string{
+				{"/a", "/b", "/c"},
+				{"/d"},
+				nil,
+			},
+		},
+		{
+			name: "single chunk has all the stacks",
+			layout: []string{
+				`s:a:after=["/b"]`,
+				"s:b",
+				"s:c",
+			},
+			total: 1,
+			want: [][]string{
+				{"/a", "/b", "/c"},
+			},
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			s := sandbox.NoGit(t, true)
+			s.BuildTree(tc.layout)
+
+			root, err := config.LoadRoot(s.RootDir())
+			assert.NoError(t, err)
+
+			stacks, err := config.LoadAllStacks(root, root.Tree())
+			assert.NoError(t, err)
+
+			chunks, _, err := run.Chunks(root, stacks,
+				func(s *config.SortableStack) *config.Stack { return s.Stack }, tc.total)
+			assert.NoError(t, err)
+
+			var got [][]string
+			for _, chunk := range chunks {
+				var dirs []string
+				for _, st := range chunk {
+					dirs = append(dirs, st.Dir().String())
+				}
+				got = append(got, dirs)
+			}
+			test.AssertDiff(t, got, tc.want)
+		})
+	}
+}
+
+func TestChunksPartitionTheSelection(t *testing.T) {
+	t.Parallel()
+
+	for seed := int64(0); seed < 20; seed++ {
+		seed := seed
+		t.Run(fmt.Sprintf("seed %d", seed), func(t *testing.T) {
+			t.Parallel()
+
+			rng := rand.New(rand.NewSource(seed))
+			n := 5 + rng.Intn(15)
+			var layout []string
+			for i := 0; i < n; i++ {
+				// edges only go from lower to higher indexes to avoid cycles.
+				var after []string
+				for j := 0; j < i; j++ {
+					if rng.Intn(n) == 0 {
+						after = append(after, fmt.Sprintf(`"/s%02d"`, j))
+					}
+				}
+				spec := fmt.Sprintf("s:s%02d", i)
+				if len(after) > 0 {
+					spec += fmt.Sprintf(":after=[%s]", strings.Join(after, ","))
+				}
+				layout = append(layout, spec)
+			}
+
+			s := sandbox.NoGit(t, true)
+			s.BuildTree(layout)
+
+			root, err := config.LoadRoot(s.RootDir())
+			assert.NoError(t, err)
+
+			stacks, err := config.LoadAllStacks(root, root.Tree())
+			assert.NoError(t, err)
+			rng.Shuffle(len(stacks), func(i, j int) {
+				stacks[i], stacks[j] = stacks[j], stacks[i]
+			})
+
+			getStack := func(s *config.SortableStack) *config.Stack { return s.Stack }
+			total := 1 + rng.Intn(4)
+			chunks, _, err := run.Chunks(root, stacks, getStack, total)
+			assert.NoError(t, err)
+			assert.EqualInts(t, total, len(chunks))
+
+			// the chunks must be the same regardless of the given order.
+			reversed := slices.Clone(stacks)
+			slices.Reverse(reversed)
+			reversedChunks, _, err := run.Chunks(root, reversed, getStack, total)
+			assert.NoError(t, err)
+
+			chunkOf := map[string]int{}
+			for i, chunk := range chunks {
+				for _, st := range chunk {
+					dir := st.Dir().String()
+					_, dup := chunkOf[dir]
+					assert.IsTrue(t, !dup, "stack %s is in many chunks", dir)
+					chunkOf[dir] = i
+				}
+				assert.EqualInts(t, len(chunk), len(reversedChunks[i]))
+				for _, st := range reversedChunks[i] {
+					assert.EqualInts(t, i, chunkOf[st.Dir().String()])
+				}
+			}
+			assert.EqualInts(t, len(stacks), len(chunkOf))
+
+			for _, st := range stacks {
+				for _, after := range st.After {
+					assert.EqualInts(t, chunkOf[st.Dir().String()], chunkOf[after],
+						"stack %s and %s are ordered but in different chunks", st.Dir(), after)
+				}
+			}
+		})
+	}
+}
+
+func TestChunksFailsOnCycles(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.NoGit(t, true)
+	s.BuildTree([]string{
+		`s:a:after=["/b"]`,
+		`s:b:after=["/a"]`,
+	})
+
+	root, err := config.LoadRoot(s.RootDir())
+	assert.NoError(t, err)
+
+	stacks, err := config.LoadAllStacks(root, root.Tree())
+	assert.NoError(t, err)
+
+	_, _, err = run.Chunks(root, stacks,
+		func(s *config.SortableStack) *config.Stack { return s.Stack }, 2)
+	errtest.Assert(t, err, errors.E(dag.ErrCycleDetected))
+}
@@ -0,0 +1,85 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package run
+
+import (
+	"github.com/terramate-io/terramate/config"
+	"github.com/terramate-io/terramate/errors"
+	"github.com/zclconf/go-cty/cty"
+)
+
+const (
+	// ErrEvalCloudMetadata indicates that an error happened while evaluating
+	// one of the terramate.config.cloud.metadata attributes.
+	ErrEvalCloudMetadata errors.Kind = "evaluating terramate.config.cloud.metadata attribute"
+
+	// ErrInvalidCloudMetadataType indicates the cloud metadata attribute
+	// has an invalid type.
+	ErrInvalidCloudMetadataType errors.Kind = "invalid cloud metadata type"
+)
+
+// LoadCloudMetadata evaluates the custom metadata to be synchronized with
+// Terramate Cloud for the given stack.
+// All defined `terramate.config.cloud.metadata` definitions from the provided
+// stack dir up to the root of the project are collected, and definitions closer
+// to the stack have precedence over parent definitions. Attributes evaluating
+// to null are not synchronized.
+func LoadCloudMetadata(root *config.Root, st *config.Stack) (map[string]string, error) {
+	tree, _ := root.Lookup(st.Dir)
+	hasMetadata := false
+	for node := tree; node != nil; node = node.Parent {
+		if node.Node.HasCloudMetadata() {
+			hasMetadata = true
+			break
+		}
+	}
+	if !hasMetadata {
+		return nil, nil
+	}
+
+	evalctx, err := stackEvalContext(root, st)
+	if err != nil {
+		return nil, err
+	}
+
+	metadata := map[string]string{}
+	seen := map[string]struct{}{}
+
+	for ; tree != nil; tree = tree.Parent {
+		if !tree.Node.HasCloudMetadata() {
+			continue
+		}
+
+		attrs := tree.Node.Terramate.Config.Cloud.Metadata.Attributes.SortedList()
+		for _, attr := range attrs {
+			if _, ok := seen[attr.Name]; ok {
+				continue
+			}
+			seen[attr.Name] = struct{}{}
+
+			val, err := evalctx.Eval(attr.Expr)
+			if err != nil {
+				return nil, errors.E(ErrEvalCloudMetadata, err, "stack %s", st.Dir)
+			}
+
+			if val.IsNull() {
+				continue
+			}
+
+			if val.Type() != cty.String {
+				return nil, errors.E(
+					ErrInvalidCloudMetadataType,
+					attr.Range,
+					"stack %s: metadata key %q has type %s but must be string",
+					st.Dir,
+					attr.Name,
+					val.Type().FriendlyName(),
+				)
+			}
+
+			metadata[attr.Name] = val.AsString()
+		}
+	}
+	return metadata, nil
+}
@@ -0,0 +1,199 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package run_test
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/madlambda/spells/assert"
+	"github.com/terramate-io/terramate/config"
+	"github.com/terramate-io/terramate/errors"
+	"github.com/terramate-io/terramate/run"
+	"github.com/terramate-io/terramate/test"
+	errorstest "github.com/terramate-io/terramate/test/errors"
+	"github.com/terramate-io/terramate/test/hclwrite"
+	. "github.com/terramate-io/terramate/test/hclwrite/hclutils"
+	"github.com/terramate-io/terramate/test/sandbox"
+)
+
+func TestLoadCloudMetadata(t *testing.T) {
+	t.Parallel()
+
+	type (
+		hclconfig struct {
+			path string
+			add  fmt.Stringer
+		}
+		result struct {
+			metadata map[string]string
+			err      error
+		}
+		testcase struct {
+			name    string
+			layout  []string
+			configs []hclconfig
+			want    map[string]result
+		}
+	)
+
+	cloudMetadataCfg := func(builders ...hclwrite.BlockBuilder) *hclwrite.Block {
+		return Terramate(Config(Block("cloud", Block("metadata", builders...))))
+	}
+
+	for _, tc := range []testcase{
+		{
+			name: "no metadata config",
+			layout: []string{
+				"s:stack",
+			},
+		},
+		{
+			name: "metadata evaluated from globals and stack metadata",
+			layout: []string{
+				"s:stacks/stack-1",
+				"s:stacks/stack-2",
+			},
+			configs: []hclconfig{
+				{
+					path: "/",
+					add: cloudMetadataCfg(
+						Expr("service_tier", "global.tier"),
+						Expr("owner", `"team-${terramate.stack.name}"`),
+					),
+				},
+				{
+					path: "/",
+					add: Globals(
+						Str("tier", "gold"),
+					),
+				},
+				{
+					path: "/stacks/stack-2",
+					add: Globals(
+						Str("tier", "silver"),
+					),
+				},
+			},
+			want: map[string]result{
+				"stacks/stack-1": {
+					metadata: map[string]string{
+						"owner":        "team-stack-1",
+						"service_tier": "gold",
+					},
+				},
+				"stacks/stack-2": {
+					metadata: map[string]string{
+						"owner":        "team-stack-2",
+						"service_tier": "silver",
+					},
+				},
+			},
+		},
+		{
+			name: "closer definitions override parent ones and null unsets",
+			layout: []string{
+				"s:stacks/stack-1",
+				"s:stacks/stack-2",
+			},
+			configs: []hclconfig{
+				{
+					path: "/",
+					add: cloudMetadataCfg(
+						Str("owner", "root"),
+						Str("service_tier", "gold"),
+					),
+				},
+				{
+					path: "/stacks/stack-1",
+					add: cloudMetadataCfg(
+						Str("owner", "stack-1"),
+						Expr("service_tier", "null"),
+					),
+				},
+			},
+			want: map[string]result{
+				"stacks/stack-1": {
+					metadata: map[string]string{
+						"owner": "stack-1",
+					},
+				},
+				"stacks/stack-2": {
+					metadata: map[string]string{
+						"owner":        "root",
+						"service_tier": "gold",
+					},
+				},
+			},
+		},
+		{
+			name: "non-string values fail",
+			layout: []string{
+				"s:stack",
+			},
+			configs: []hclconfig{
+				{
+					path: "/",
+					add: cloudMetadataCfg(
+						Number("owner", 1),
+					),
+				},
+			},
+			want: map[string]result{
+				"stack": {
+					err: errors.E(run.ErrInvalidCloudMetadataType),
+				},
+			},
+		},
+		{
+			name: "undefined globals fail",
+			layout: []string{
+				"s:stack",
+			},
+			configs: []hclconfig{
+				{
+					path: "/",
+					add: cloudMetadataCfg(
+						Expr("owner", "global.undefined"),
+					),
+				},
+			},
+			want: map[string]result{
+				"stack": {
+					err: errors.E(run.ErrEvalCloudMetadata),
+				},
+			},
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			s := sandbox.NoGit(t, true)
+			s.BuildTree(tc.layout)
+			for _, cfg := range tc.configs {
+				path := filepath.Join(s.RootDir(), cfg.path)
+				test.AppendFile(t, path, "cloud_metadata_test_cfg.tm", cfg.add.String())
+			}
+
+			root, err := config.LoadRoot(s.RootDir())
+			assert.NoError(t, err)
+
+			for _, stackPath := range root.Stacks() {
+				st, err := config.LoadStack(root, stackPath)
+				assert.NoError(t, err)
+
+				want := tc.want[stackPath.String()[1:]]
+
+				got, err := run.LoadCloudMetadata(root, st)
+				errorstest.Assert(t, err, want.err)
+				if err != nil {
+					continue
+				}
+				test.AssertDiff(t, got, want.metadata)
+			}
+		})
+	}
+}
@@ -8,7 +8,9 @@
 package dag
 
 import (
+	"encoding/binary"
 	"fmt"
+	"hash/fnv"
 	"sort"
 
 	"github.com/terramate-io/terramate/errors"
@@ -26,6 +28,14 @@ type (
 		cycles map[ID]bool
 
 		validated bool
+
+		// priorities of the nodes used when ordering the DAG.
+		priorities map[ID]int
+
+		// shuffled tells if the tie-break order of the nodes is permuted by
+		// the shuffleSeed instead of being lexicographic.
+		shuffled    bool
+		shuffleSeed int64
 	}
 
 	// Visited in a map of visited dag nodes by id.
@@ -225,12 +235,154 @@ func (d *DAG[V]) HasCycle(id ID) bool {
 	return d.cycles[id]
 }
 
-// Order returns the topological order of the DAG. The node ids are
-// lexicographic sorted whenever possible to give a consistent output.
+// Cycles returns the cycles of the DAG, which are the shortest cycles going
+// through each node that depends on itself. Each cycle is a chain of node ids
+// where each node has the next one as ancestor, starting and ending at its
+// lexicographic smallest node. The cycles are sorted lexicographically.
+func (d *DAG[V]) Cycles() [][]ID {
+	// Tarjan's algorithm for the strongly connected components.
+	var (
+		index   = map[ID]int{}
+		lowlink = map[ID]int{}
+		onStack = map[ID]bool{}
+		stack   []ID
+		groups  [][]ID
+	)
+	var strongconnect func(id ID)
+	strongconnect = func(id ID) {
+		index[id] = len(index)
+		lowlink[id] = index[id]
+		stack = append(stack, id)
+		onStack[id] = true
+
+		for _, ancestor := range sortedIDs(d.dag[id]) {
+			if _, visited := index[ancestor]; !visited {
+				strongconnect(ancestor)
+				lowlink[id] = min(lowlink[id], lowlink[ancestor])
+			} else if onStack[ancestor] {
+				lowlink[id] = min(lowlink[id], index[ancestor])
+			}
+		}
+
+		if lowlink[id] != index[id] {
+			return
+		}
+		var group []ID
+		for {
+			last := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[last] = false
+			group = append(group, last)
+			if last == id {
+				break
+			}
+		}
+		groups = append(groups, group)
+	}
+
+	for _, id := range d.IDs() {
+		if _, visited := index[id]; !visited {
+			strongconnect(id)
+		}
+	}
+
+	var cycles [][]ID
+	seen := map[string]bool{}
+	for _, group := range groups {
+		if len(group) == 1 && !idList(d.dag[group[0]]).contains(group[0]) {
+			continue
+		}
+		for _, start := range sortedIDs(group) {
+			cycle := rotateCycle(d.shortestCycle(start, group))
+			key := fmt.Sprint(cycle)
+			if !seen[key] {
+				seen[key] = true
+				cycles = append(cycles, cycle)
+			}
+		}
+	}
+	sort.Slice(cycles, func(i, j int) bool {
+		a, b := cycles[i], cycles[j]
+		for k := 0; k < len(a) && k < len(b); k++ {
+			if a[k] != b[k] {
+				return a[k] < b[k]
+			}
+		}
+		return len(a) < len(b)
+	})
+	return cycles
+}
+
+// rotateCycle rotates the cycle to start and end at its smallest node.
+func rotateCycle(cycle []ID) []ID {
+	nodes := cycle[:len(cycle)-1]
+	first := 0
+	for i, id := range nodes {
+		if id < nodes[first] {
+			first = i
+		}
+	}
+	rotated := append(append([]ID{}, nodes[first:]...), nodes[:first]...)
+	return append(rotated, rotated[0])
+}
+
+// shortestCycle returns the shortest chain from start back to itself going
+// only through the nodes of the given group.
+func (d *DAG[V]) shortestCycle(start ID, group idList) []ID {
+	prev := map[ID]ID{}
+	queue := []ID{start}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		for _, ancestor := range sortedIDs(d.dag[id]) {
+			if ancestor == start {
+				chain := []ID{start}
+				for cur := id; cur != start; cur = prev[cur] {
+					chain = append(chain, cur)
+				}
+				// the chain was built backwards, from the end to the start.
+				for i, j := 1, len(chain)-1; i < j; i, j = i+1, j-1 {
+					chain[i], chain[j] = chain[j], chain[i]
+				}
+				return append(chain, start)
+			}
+			if _, seen := prev[ancestor]; seen || !group.contains(ancestor) {
+				continue
+			}
+			prev[ancestor] = id
+			queue = append(queue, ancestor)
+		}
+	}
+	panic(errors.E(errors.ErrInternal, "no cycle found from node %s", start))
+}
+
+// SetPriorities sets the priorities of the nodes of the DAG. Whenever the DAG
+// constraints allow, nodes with higher priority come first in the [DAG.Order].
+// Nodes not present in the given map have priority 0.
+func (d *DAG[V]) SetPriorities(priorities map[ID]int) {
+	d.priorities = priorities
+}
+
+// Shuffle pseudo-randomly permutes the tie-break order of the nodes, which is
+// lexicographic by default. The DAG constraints and the node priorities are
+// still honored and the same seed always gives the same order.
+func (d *DAG[V]) Shuffle(seed int64) {
+	d.shuffled = true
+	d.shuffleSeed = seed
+}
+
+// Priority returns the priority of the given node.
+func (d *DAG[V]) Priority(id ID) int {
+	return d.priorities[id]
+}
+
+// Order returns the topological order of the DAG. The node ids are sorted by
+// priority, higher first, and then lexicographic sorted whenever possible to
+// give a consistent output.
 func (d *DAG[V]) Order() []ID {
 	order := []ID{}
 	visited := Visited{}
-	for _, id := range d.IDs() {
+	for _, id := range d.prioritizedIDs(d.IDs()) {
 		if _, ok := visited[id]; ok {
 			continue
 		}
@@ -247,15 +399,83 @@ func (d *DAG[V]) Order() []ID {
 	return order
 }
 
+// Levels returns the node ids grouped by their level in the DAG. The nodes
+// without ancestors are at level 0 and every other node is one level after its
+// deepest ancestor, so the nodes of the same level don't depend on each other.
+// The node ids of each level are lexicographic sorted.
+func (d *DAG[V]) Levels() [][]ID {
+	levelOf := map[ID]int{}
+	var computeLevel func(id ID) int
+	computeLevel = func(id ID) int {
+		if level, ok := levelOf[id]; ok {
+			return level
+		}
+		level := 0
+		for _, ancestor := range d.dag[id] {
+			if ancestorLevel := computeLevel(ancestor) + 1; ancestorLevel > level {
+				level = ancestorLevel
+			}
+		}
+		levelOf[id] = level
+		return level
+	}
+
+	var levels [][]ID
+	for _, id := range d.IDs() {
+		level := computeLevel(id)
+		for len(levels) <= level {
+			levels = append(levels, []ID{})
+		}
+		levels[level] = append(levels[level], id)
+	}
+	return levels
+}
+
 func (d *DAG[V]) walkFrom(id ID, do func(id ID)) {
 	children := d.dag[id]
-	for _, tid := range sortedIDs(children) {
+	for _, tid := range d.prioritizedIDs(sortedIDs(children)) {
 		d.walkFrom(tid, do)
 	}
 
 	do(id)
 }
 
+// SortIDs returns a copy of the given ids sorted by priority, higher first,
+// and then lexicographic (or shuffled, see [DAG.Shuffle]). It's the tie-break
+// order of nodes which are equally ready to be processed.
+func (d *DAG[V]) SortIDs(ids []ID) []ID {
+	return d.prioritizedIDs(sortedIDs(ids))
+}
+
+// prioritizedIDs stable sorts the given ids by their priority, higher first.
+// The ids are shuffled beforehand if the DAG is shuffled.
+func (d *DAG[V]) prioritizedIDs(ids idList) idList {
+	if d.shuffled {
+		sort.SliceStable(ids, func(i, j int) bool {
+			return d.shuffleRank(ids[i]) < d.shuffleRank(ids[j])
+		})
+	}
+	if len(d.priorities) == 0 {
+		return ids
+	}
+	sort.SliceStable(ids, func(i, j int) bool {
+		return d.priorities[ids[i]] > d.priorities[ids[j]]
+	})
+	return ids
+}
+
+// shuffleRank returns the position of the node in the shuffled order. It only
+// depends on the seed and the node id, so the relative order of two nodes is
+// the same in any DAG shuffled with the same seed.
+func (d *DAG[V]) shuffleRank(id ID) uint64 {
+	h := fnv.New64a()
+	var seed [8]byte
+	binary.LittleEndian.PutUint64(seed[:], uint64(d.shuffleSeed))
+	_, _ = h.Write(seed[:])
+	_, _ = h.Write([]byte(id))
+	return h.Sum64()
+}
+
 func sortedIDs(ids []ID) idList {
 	idlist := make(idList, 0, len(ids))
 	for _, id := range ids {
@@ -290,9 +510,19 @@ func Transform[D, S any](from *DAG[S], f func(id ID, v S) (D, error)) (*DAG[D],
 		values:    make(map[ID]D, len(from.values)),
 		cycles:    from.cycles,
 		validated: from.validated,
+
+		priorities: from.priorities,
+
+		shuffled:    from.shuffled,
+		shuffleSeed: from.shuffleSeed,
 	}
 
-	for id, v := range from.values {
+	// the ids are sorted, so the same error is returned for the same DAG.
+	for _, id := range from.IDs() {
+		v, ok := from.values[id]
+		if !ok {
+			continue
+		}
 		if fv, err := f(id, v); err == nil {
 			to.values[id] = fv
 		} else {
@@ -305,6 +535,7 @@ func Transform[D, S any](from *DAG[S], f func(id ID, v S) (D, error)) (*DAG[D],
 	from.values = nil
 	from.cycles = nil
 	from.validated = false
+	from.priorities = nil
 
 	return to, nil
 }
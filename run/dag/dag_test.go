@@ -4,6 +4,8 @@
 package dag_test
 
 import (
+	"fmt"
+	"math/rand"
 	"strconv"
 	"testing"
 
@@ -300,6 +302,244 @@ func TestValidatedDAG(t *testing.T) {
 	}
 }
 
+func TestDAGOrderWithPriorities(t *testing.T) {
+	t.Parallel()
+
+	type testcase struct {
+		name       string
+		nodes      map[string]node
+		priorities map[dag.ID]int
+		order      []dag.ID
+	}
+
+	for _, tc := range []testcase{
+		{
+			name: "no priorities keeps lexicographic order",
+			nodes: map[string]node{
+				"A": {},
+				"B": {},
+				"C": {},
+			},
+			order: []dag.ID{"A", "B", "C"},
+		},
+		{
+			name: "independent nodes ordered by priority",
+			nodes: map[string]node{
+				"A": {},
+				"B": {},
+				"C": {},
+			},
+			priorities: map[dag.ID]int{
+				"C": 10,
+				"B": 5,
+			},
+			order: []dag.ID{"C", "B", "A"},
+		},
+		{
+			name: "negative priorities run last",
+			nodes: map[string]node{
+				"A": {},
+				"B": {},
+				"C": {},
+			},
+			priorities: map[dag.ID]int{
+				"A": -1,
+			},
+			order: []dag.ID{"B", "C", "A"},
+		},
+		{
+			name: "priority never overrides ancestors",
+			nodes: map[string]node{
+				"A": {},
+				"B": {},
+				"C": {
+					ancestors: []dag.ID{"B"},
+				},
+			},
+			priorities: map[dag.ID]int{
+				"C": 10,
+			},
+			order: []dag.ID{"B", "C", "A"},
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			d := dag.New[any]()
+			for id, v := range tc.nodes {
+				assert.NoError(t, d.AddNode(dag.ID(id), nil, v.descendants, v.ancestors))
+			}
+			_, err := d.Validate()
+			assert.NoError(t, err)
+
+			d.SetPriorities(tc.priorities)
+			assertOrder(t, tc.order, d.Order())
+		})
+	}
+}
+
+func TestDAGLevels(t *testing.T) {
+	t.Parallel()
+
+	type testcase struct {
+		name   string
+		nodes  map[string]node
+		levels [][]dag.ID
+	}
+
+	for _, tc := range []testcase{
+		{
+			name: "independent nodes are in the first level",
+			nodes: map[string]node{
+				"C": {},
+				"A": {},
+				"B": {},
+			},
+			levels: [][]dag.ID{{"A", "B", "C"}},
+		},
+		{
+			name: "chain of nodes",
+			nodes: map[string]node{
+				"A": {},
+				"B": {
+					ancestors: []dag.ID{"A"},
+				},
+				"C": {
+					ancestors: []dag.ID{"B"},
+				},
+			},
+			levels: [][]dag.ID{{"A"}, {"B"}, {"C"}},
+		},
+		{
+			name: "node is after its deepest ancestor",
+			nodes: map[string]node{
+				"A": {},
+				"B": {
+					ancestors: []dag.ID{"A"},
+				},
+				"C": {
+					ancestors: []dag.ID{"A", "B"},
+				},
+				"D": {
+					descendants: []dag.ID{"B"},
+				},
+				"E": {},
+			},
+			levels: [][]dag.ID{{"A", "D", "E"}, {"B"}, {"C"}},
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			d := dag.New[any]()
+			for id, v := range tc.nodes {
+				assert.NoError(t, d.AddNode(dag.ID(id), nil, v.descendants, v.ancestors))
+			}
+			_, err := d.Validate()
+			assert.NoError(t, err)
+
+			levels := d.Levels()
+			assert.EqualInts(t, len(tc.levels), len(levels), "levels: %v", levels)
+			for i, want := range tc.levels {
+				assertOrder(t, want, levels[i])
+			}
+		})
+	}
+}
+
+func TestDAGCycles(t *testing.T) {
+	t.Parallel()
+
+	type testcase struct {
+		name   string
+		nodes  map[string]node
+		cycles [][]dag.ID
+	}
+
+	for _, tc := range []testcase{
+		{
+			name: "no cycles",
+			nodes: map[string]node{
+				"A": {},
+				"B": {
+					ancestors: []dag.ID{"A"},
+				},
+			},
+		},
+		{
+			name: "node after itself",
+			nodes: map[string]node{
+				"A": {
+					ancestors: []dag.ID{"A"},
+				},
+			},
+			cycles: [][]dag.ID{{"A", "A"}},
+		},
+		{
+			name: "shortest cycle of each node",
+			nodes: map[string]node{
+				"A": {
+					ancestors: []dag.ID{"B", "C"},
+				},
+				"B": {
+					ancestors: []dag.ID{"C"},
+				},
+				"C": {
+					ancestors: []dag.ID{"A"},
+				},
+				"D": {},
+			},
+			cycles: [][]dag.ID{
+				{"A", "B", "C", "A"},
+				{"A", "C", "A"},
+			},
+		},
+		{
+			name: "independent cycles",
+			nodes: map[string]node{
+				"X": {
+					ancestors: []dag.ID{"Y"},
+				},
+				"Y": {
+					ancestors: []dag.ID{"Z"},
+				},
+				"Z": {
+					ancestors: []dag.ID{"X"},
+				},
+				"B": {
+					ancestors: []dag.ID{"A"},
+				},
+				"A": {
+					descendants: []dag.ID{"X"},
+					ancestors:   []dag.ID{"B"},
+				},
+			},
+			cycles: [][]dag.ID{
+				{"A", "B", "A"},
+				{"X", "Y", "Z", "X"},
+			},
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			d := dag.New[any]()
+			for id, v := range tc.nodes {
+				assert.NoError(t, d.AddNode(dag.ID(id), nil, v.descendants, v.ancestors))
+			}
+
+			cycles := d.Cycles()
+			assert.EqualInts(t, len(tc.cycles), len(cycles), "cycles: %v", cycles)
+			for i, want := range tc.cycles {
+				assertOrder(t, want, cycles[i])
+			}
+		})
+	}
+}
+
 func TestReduceDAG(t *testing.T) {
 	type node struct {
 		value     int
@@ -419,6 +659,136 @@ func TestTransformDAG(t *testing.T) {
 	}
 }
 
+func TestDAGOrderIsDeterministic(t *testing.T) {
+	t.Parallel()
+
+	// Each layout is built many times with the nodes and the edges added in
+	// a different order and the computed order must never change.
+	for seed := int64(0); seed < 100; seed++ {
+		rng := rand.New(rand.NewSource(seed))
+		nnodes := 2 + rng.Intn(20)
+		ids := make([]dag.ID, nnodes)
+		for i := range ids {
+			ids[i] = dag.ID(fmt.Sprintf("/stack-%d/%d", rng.Intn(5), i))
+		}
+
+		// edges only point to lower indexes, so the DAG is acyclic.
+		edges := map[int][]int{}
+		for i := 1; i < nnodes; i++ {
+			for j := 0; j < i; j++ {
+				if rng.Intn(4) == 0 {
+					edges[i] = append(edges[i], j)
+				}
+			}
+		}
+		priorities := map[dag.ID]int{}
+		for _, id := range ids {
+			if rng.Intn(3) == 0 {
+				priorities[id] = rng.Intn(5) - 2
+			}
+		}
+
+		build := func() *dag.DAG[int] {
+			nodes := map[int]node{}
+			for i, ancestors := range edges {
+				for _, j := range ancestors {
+					// the same edge is either an ancestor of the node or
+					// a descendant of the other node.
+					if rng.Intn(2) == 0 {
+						n := nodes[i]
+						n.ancestors = append(n.ancestors, ids[j])
+						nodes[i] = n
+					} else {
+						n := nodes[j]
+						n.descendants = append(n.descendants, ids[i])
+						nodes[j] = n
+					}
+				}
+			}
+			d := dag.New[int]()
+			for _, i := range rng.Perm(nnodes) {
+				n := nodes[i]
+				rng.Shuffle(len(n.ancestors), func(a, b int) {
+					n.ancestors[a], n.ancestors[b] = n.ancestors[b], n.ancestors[a]
+				})
+				rng.Shuffle(len(n.descendants), func(a, b int) {
+					n.descendants[a], n.descendants[b] = n.descendants[b], n.descendants[a]
+				})
+				assert.NoError(t, d.AddNode(ids[i], i, n.descendants, n.ancestors))
+			}
+			_, err := d.Validate()
+			assert.NoError(t, err)
+			d.SetPriorities(priorities)
+			return d
+		}
+
+		want := build()
+		wantOrder := want.Order()
+		wantLevels := want.Levels()
+		for run := 0; run < 5; run++ {
+			got := build()
+			assert.EqualStrings(t, fmt.Sprint(wantOrder), fmt.Sprint(got.Order()),
+				"seed %d: order changed", seed)
+			assert.EqualStrings(t, fmt.Sprint(wantLevels), fmt.Sprint(got.Levels()),
+				"seed %d: levels changed", seed)
+		}
+	}
+}
+
+func TestDAGShuffledOrder(t *testing.T) {
+	t.Parallel()
+
+	const nnodes = 30
+
+	build := func(shuffleSeed int64) *dag.DAG[int] {
+		// every node depends on the node with half of its index, then the
+		// DAG is a tree with many independent siblings.
+		d := dag.New[int]()
+		for i := 0; i < nnodes; i++ {
+			var ancestors []dag.ID
+			if i > 0 {
+				ancestors = append(ancestors, dag.ID(fmt.Sprintf("/stack-%02d", i/2)))
+			}
+			assert.NoError(t, d.AddNode(dag.ID(fmt.Sprintf("/stack-%02d", i)), i, nil, ancestors))
+		}
+		_, err := d.Validate()
+		assert.NoError(t, err)
+		d.SetPriorities(map[dag.ID]int{"/stack-29": 10})
+		d.Shuffle(shuffleSeed)
+		return d
+	}
+
+	distinctOrders := map[string]struct{}{}
+	for seed := int64(1); seed <= 20; seed++ {
+		d := build(seed)
+		order := d.Order()
+		assert.EqualInts(t, nnodes, len(order), "seed %d: order length mismatch", seed)
+
+		// the same seed must always give the same order.
+		assert.EqualStrings(t, fmt.Sprint(order), fmt.Sprint(build(seed).Order()),
+			"seed %d: order is not reproducible", seed)
+
+		// the ancestors of every node must come before it.
+		position := map[dag.ID]int{}
+		for i, id := range order {
+			position[id] = i
+		}
+		for _, id := range order {
+			for _, ancestor := range d.AncestorsOf(id) {
+				assert.IsTrue(t, position[ancestor] < position[id],
+					"seed %d: %s ordered before its ancestor %s", seed, id, ancestor)
+			}
+		}
+
+		// the priority is still honored: /stack-29 comes right after its
+		// ancestors chain (/stack-00, /stack-01, /stack-03, /stack-07, /stack-14).
+		assert.EqualStrings(t, "/stack-29", string(order[5]), "seed %d: priority not honored", seed)
+
+		distinctOrders[fmt.Sprint(order)] = struct{}{}
+	}
+	assert.IsTrue(t, len(distinctOrders) > 10, "different seeds must give different orders")
+}
+
 func assertOrder(t *testing.T, want, got []dag.ID) {
 	t.Helper()
 	assert.EqualInts(t, len(want), len(got), "length mismatch")
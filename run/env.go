@@ -4,7 +4,9 @@
 package run
 
 import (
+	"fmt"
 	"os"
+	"path/filepath"
 	"sort"
 	"strings"
 
@@ -13,6 +15,8 @@ import (
 	"github.com/terramate-io/terramate/errors"
 	"github.com/terramate-io/terramate/globals"
 	"github.com/terramate-io/terramate/hcl/eval"
+	"github.com/terramate-io/terramate/hcl/info"
+	"github.com/terramate-io/terramate/project"
 	"github.com/terramate-io/terramate/stdlib"
 	"golang.org/x/exp/maps"
 
@@ -30,34 +34,93 @@ const (
 	// ErrInvalidEnvVarType indicates the env var attribute
 	// has an invalid type.
 	ErrInvalidEnvVarType errors.Kind = "invalid environment variable type"
+
+	// ErrDataDir indicates that the isolated data directory of the stack
+	// cannot be used.
+	ErrDataDir errors.Kind = "isolating stack data directory"
 )
 
+// dataDirsGitignore is written to the directory of the isolated data
+// directories, so they are never committed.
+const dataDirsGitignore = "# Created by Terramate. Do not commit the data directories.\n*\n"
+
 // EnvVars represents a set of environment variables to be used
 // when running commands. Each string follows the same format used
 // on os.Environ and can be used to set env on exec.Cmd.
 type EnvVars []string
 
+// EnvOriginKind is the kind of definition an environment variable of the
+// stack run environment comes from.
+type EnvOriginKind string
+
+const (
+	// EnvOriginConfig indicates the variable is defined in a
+	// terramate.config.run.env block.
+	EnvOriginConfig EnvOriginKind = "config"
+
+	// EnvOriginHost indicates the variable is defined in a
+	// terramate.config.run.env block as a pass-through of a host environment
+	// variable, like `FOO = env.FOO`.
+	EnvOriginHost EnvOriginKind = "host"
+
+	// EnvOriginDataDir indicates the variable is the isolated data directory
+	// of the stack.
+	EnvOriginDataDir EnvOriginKind = "data_dir"
+)
+
+// EnvOrigin tells where an environment variable of the stack run environment
+// is defined.
+type EnvOrigin struct {
+	// Kind of the definition.
+	Kind EnvOriginKind
+
+	// Range is the range of the defining attribute. It is empty for the
+	// isolated data directory.
+	Range info.Range
+
+	// Inherited tells if the attribute is defined in a parent directory of
+	// the stack.
+	Inherited bool
+}
+
+// EnvVar is an environment variable of the stack run environment.
+type EnvVar struct {
+	Name   string
+	Value  string
+	Origin EnvOrigin
+}
+
 // LoadEnv will load environment variables to be exported when running any command
 // inside the given stack. The order of the env vars is guaranteed to be the same
 // and is ordered lexicographically.
 // All defined `terramate.config.run.env` definitions from the provided stack dir
 // up to the root of the project are collected, and env definitions closer to the
 // stack have precedence over parent definitions.
-func LoadEnv(root *config.Root, st *config.Stack) (EnvVars, error) {
-	globalsReport := globals.ForStack(root, st)
-	if err := globalsReport.AsError(); err != nil {
-		return nil, errors.E(ErrLoadingGlobals, err)
+// If isolateDataDir is true, TF_DATA_DIR is set to the data directory of the
+// stack, which is created if needed, unless it's defined in
+// `terramate.config.run.env`.
+func LoadEnv(root *config.Root, st *config.Stack, isolateDataDir bool) (EnvVars, error) {
+	vars, err := LoadEnvWithOrigins(root, st, isolateDataDir)
+	if err != nil {
+		return nil, err
+	}
+	var envVars EnvVars
+	for _, v := range vars {
+		envVars = append(envVars, v.Name+"="+v.Value)
 	}
+	return envVars, nil
+}
 
-	evalctx := eval.NewContext(stdlib.Functions(st.HostDir(root), root.Tree().Node.Experiments()))
-	runtime := root.Runtime()
-	runtime.Merge(st.RuntimeValues(root))
-	evalctx.SetNamespace("terramate", runtime)
-	evalctx.SetNamespace("global", globalsReport.Globals.AsValueMap())
-	evalctx.SetEnv(os.Environ())
+// LoadEnvWithOrigins is like [LoadEnv] but also returns where each
+// environment variable is defined.
+func LoadEnvWithOrigins(root *config.Root, st *config.Stack, isolateDataDir bool) ([]EnvVar, error) {
+	evalctx, err := stackEvalContext(root, st)
+	if err != nil {
+		return nil, err
+	}
 
 	tree, _ := root.Lookup(st.Dir)
-	envMap := map[string]string{}
+	envMap := map[string]EnvVar{}
 	skipMap := map[string]struct{}{}
 
 	for {
@@ -94,7 +157,19 @@ func LoadEnv(root *config.Root, st *config.Stack) (EnvVars, error) {
 				}
 
 				if _, ok := envMap[attr.Name]; !ok {
-					envMap[attr.Name] = val.AsString()
+					kind := EnvOriginConfig
+					if !diags.HasErrors() && len(traversal) == 2 && traversal.RootName() == "env" {
+						kind = EnvOriginHost
+					}
+					envMap[attr.Name] = EnvVar{
+						Name:  attr.Name,
+						Value: val.AsString(),
+						Origin: EnvOrigin{
+							Kind:      kind,
+							Range:     attr.Range,
+							Inherited: tree.Dir() != st.Dir,
+						},
+					}
 				}
 			}
 		}
@@ -105,13 +180,81 @@ func LoadEnv(root *config.Root, st *config.Stack) (EnvVars, error) {
 		}
 	}
 
-	var envVars EnvVars
+	_, isset := envMap["TF_DATA_DIR"]
+	_, isunset := skipMap["TF_DATA_DIR"]
+	if isolateDataDir && !isset && !isunset {
+		datadir, err := createDataDir(root, st)
+		if err != nil {
+			return nil, err
+		}
+		envMap["TF_DATA_DIR"] = EnvVar{
+			Name:   "TF_DATA_DIR",
+			Value:  datadir,
+			Origin: EnvOrigin{Kind: EnvOriginDataDir},
+		}
+	}
+
 	keys := maps.Keys(envMap)
 	sort.Strings(keys)
+	vars := make([]EnvVar, 0, len(keys))
 	for _, k := range keys {
-		envVars = append(envVars, k+"="+envMap[k])
+		vars = append(vars, envMap[k])
 	}
-	return envVars, nil
+	return vars, nil
+}
+
+// String returns a human readable description of the origin.
+func (o EnvOrigin) String() string {
+	switch o.Kind {
+	case EnvOriginHost:
+		return "host"
+	case EnvOriginDataDir:
+		return "isolated data dir"
+	default:
+		level := "stack"
+		if o.Inherited {
+			level = "inherited"
+		}
+		return fmt.Sprintf("from %s:%d, %s", o.Range.Path(), o.Range.Start().Line(), level)
+	}
+}
+
+// createDataDir creates the isolated data directory of the stack and returns
+// its host path.
+func createDataDir(root *config.Root, st *config.Stack) (string, error) {
+	datadir, ok := st.DataDir()
+	if !ok {
+		return "", errors.E(ErrDataDir, "stack %s has no id", st.Dir)
+	}
+	hostdir := project.AbsPath(root.HostDir(), datadir.String())
+	if err := os.MkdirAll(hostdir, 0755); err != nil {
+		return "", errors.E(ErrDataDir, err)
+	}
+	gitignore := filepath.Join(root.HostDir(), filepath.FromSlash(config.StackDataDirs), ".gitignore")
+	if _, err := os.Stat(gitignore); err == nil {
+		return hostdir, nil
+	}
+	if err := os.WriteFile(gitignore, []byte(dataDirsGitignore), 0644); err != nil {
+		return "", errors.E(ErrDataDir, err, "creating .gitignore")
+	}
+	return hostdir, nil
+}
+
+// stackEvalContext creates an evaluation context for the given stack with the
+// terramate metadata, globals and env namespaces available.
+func stackEvalContext(root *config.Root, st *config.Stack) (*eval.Context, error) {
+	globalsReport := globals.ForStack(root, st)
+	if err := globalsReport.AsError(); err != nil {
+		return nil, errors.E(ErrLoadingGlobals, err)
+	}
+
+	evalctx := eval.NewContext(stdlib.Functions(st.HostDir(root), root.Tree().Node.Experiments()))
+	runtime := root.Runtime()
+	runtime.Merge(st.RuntimeValues(root))
+	evalctx.SetNamespace("terramate", runtime)
+	evalctx.SetNamespace("global", globalsReport.Globals.AsValueMap())
+	evalctx.SetEnv(os.Environ())
+	return evalctx, nil
 }
 
 func getEnv(key string, environ []string) (string, bool) {
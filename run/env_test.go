@@ -5,13 +5,16 @@ package run_test
 
 import (
 	"fmt"
+	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/madlambda/spells/assert"
 	"github.com/rs/zerolog"
 	"github.com/terramate-io/terramate/config"
 	"github.com/terramate-io/terramate/errors"
+	"github.com/terramate-io/terramate/project"
 	"github.com/terramate-io/terramate/run"
 	"github.com/terramate-io/terramate/test"
 	errorstest "github.com/terramate-io/terramate/test/errors"
@@ -594,7 +597,7 @@ func TestLoadRunEnv(t *testing.T) {
 
 				wantres := tcase.want[stackPath.String()[1:]]
 
-				gotvars, err := run.LoadEnv(root, stack)
+				gotvars, err := run.LoadEnv(root, stack, false)
 				errorstest.Assert(t, err, wantres.enverr)
 				if err != nil {
 					continue
@@ -606,6 +609,163 @@ func TestLoadRunEnv(t *testing.T) {
 	}
 }
 
+func TestLoadRunEnvIsolateDataDir(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.NoGit(t, true)
+	s.BuildTree([]string{
+		"s:stack-1:id=stack-1-id",
+		"s:stack-2:id=stack-2-id",
+		"s:stack-3",
+	})
+	test.AppendFile(t, filepath.Join(s.RootDir(), "stack-2"), "run_env_test_cfg.tm",
+		Terramate(Config(Run(Env(Str("TF_DATA_DIR", "custom"))))).String())
+
+	root, err := config.LoadRoot(s.RootDir())
+	assert.NoError(t, err)
+
+	loadEnv := func(stackdir string, isolate bool) (run.EnvVars, error) {
+		t.Helper()
+		stack, err := config.LoadStack(root, project.NewPath(stackdir))
+		assert.NoError(t, err)
+		return run.LoadEnv(root, stack, isolate)
+	}
+
+	datadir := filepath.Join(s.RootDir(), ".terramate", "data", "stack-1-id")
+
+	env, err := loadEnv("/stack-1", false)
+	assert.NoError(t, err)
+	test.AssertDiff(t, env, run.EnvVars(nil))
+	_, err = os.Stat(datadir)
+	assert.IsTrue(t, os.IsNotExist(err), "data dir must not be created: %v", err)
+
+	env, err = loadEnv("/stack-1", true)
+	assert.NoError(t, err)
+	test.AssertDiff(t, env, run.EnvVars{"TF_DATA_DIR=" + datadir})
+	test.IsDir(t, datadir, "")
+
+	gitignore := test.ReadFile(t, filepath.Join(s.RootDir(), ".terramate", "data"), ".gitignore")
+	assert.IsTrue(t, strings.Contains(string(gitignore), "*\n"), "unexpected .gitignore: %s", gitignore)
+
+	env, err = loadEnv("/stack-2", true)
+	assert.NoError(t, err)
+	test.AssertDiff(t, env, run.EnvVars{"TF_DATA_DIR=custom"})
+
+	_, err = loadEnv("/stack-3", true)
+	errorstest.Assert(t, err, errors.E(run.ErrDataDir))
+}
+
+func TestLoadRunEnvOrigins(t *testing.T) {
+	t.Setenv("TESTING_RUN_ENV_ORIGIN", "from host")
+
+	s := sandbox.NoGit(t, true)
+	s.BuildTree([]string{
+		"s:stacks/stack:id=stack-id",
+		`f:env.tm:terramate {
+  config {
+    run {
+      env {
+        ROOT       = "root"
+        HOST       = env.TESTING_RUN_ENV_ORIGIN
+        OVERRIDDEN = "root"
+      }
+    }
+  }
+}`,
+		`f:stacks/env.tm:terramate {
+  config {
+    run {
+      env {
+        PARENT     = "${terramate.stack.name}"
+        OVERRIDDEN = "parent"
+      }
+    }
+  }
+}`,
+		`f:stacks/stack/env.tm:terramate {
+  config {
+    run {
+      env {
+        OVERRIDDEN = "stack"
+      }
+    }
+  }
+}`,
+	})
+
+	root, err := config.LoadRoot(s.RootDir())
+	assert.NoError(t, err)
+	stack, err := config.LoadStack(root, project.NewPath("/stacks/stack"))
+	assert.NoError(t, err)
+
+	vars, err := run.LoadEnvWithOrigins(root, stack, true)
+	assert.NoError(t, err)
+
+	type want struct {
+		name, value string
+		kind        run.EnvOriginKind
+		inherited   bool
+		origin      string
+	}
+
+	datadir := filepath.Join(s.RootDir(), ".terramate", "data", "stack-id")
+	wants := []want{
+		{
+			name:      "HOST",
+			value:     "from host",
+			kind:      run.EnvOriginHost,
+			inherited: true,
+			origin:    "host",
+		},
+		{
+			name:   "OVERRIDDEN",
+			value:  "stack",
+			kind:   run.EnvOriginConfig,
+			origin: "from /stacks/stack/env.tm:5, stack",
+		},
+		{
+			name:      "PARENT",
+			value:     "stack",
+			kind:      run.EnvOriginConfig,
+			inherited: true,
+			origin:    "from /stacks/env.tm:5, inherited",
+		},
+		{
+			name:      "ROOT",
+			value:     "root",
+			kind:      run.EnvOriginConfig,
+			inherited: true,
+			origin:    "from /env.tm:5, inherited",
+		},
+		{
+			name:   "TF_DATA_DIR",
+			value:  datadir,
+			kind:   run.EnvOriginDataDir,
+			origin: "isolated data dir",
+		},
+	}
+
+	assert.EqualInts(t, len(wants), len(vars), "unexpected env: %v", vars)
+	for i, w := range wants {
+		got := vars[i]
+		assert.EqualStrings(t, w.name, got.Name)
+		assert.EqualStrings(t, w.value, got.Value, "env %s", w.name)
+		assert.EqualStrings(t, string(w.kind), string(got.Origin.Kind), "env %s", w.name)
+		assert.IsTrue(t, w.inherited == got.Origin.Inherited, "env %s inherited mismatch", w.name)
+		assert.EqualStrings(t, w.origin, got.Origin.String(), "env %s", w.name)
+	}
+
+	env, err := run.LoadEnv(root, stack, true)
+	assert.NoError(t, err)
+	test.AssertDiff(t, env, run.EnvVars{
+		"HOST=from host",
+		"OVERRIDDEN=stack",
+		"PARENT=stack",
+		"ROOT=root",
+		"TF_DATA_DIR=" + datadir,
+	})
+}
+
 func init() {
 	zerolog.SetGlobalLevel(zerolog.Disabled)
 }
@@ -0,0 +1,73 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package run
+
+import (
+	"strings"
+
+	"github.com/terramate-io/terramate/config"
+	"github.com/terramate-io/terramate/errors"
+	"github.com/terramate-io/terramate/hcl/ast"
+	"github.com/terramate-io/terramate/hcl/eval"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+)
+
+// ErrEvalArg indicates that an argument of the command failed to evaluate.
+const ErrEvalArg errors.Kind = "evaluating command argument"
+
+// EvalArgs evaluates the arguments of the command run in the given stack.
+//
+// The arguments containing interpolation (${) or directive (%{) sequences are
+// evaluated as HCL templates, like the content of a heredoc, then quotes and
+// backslashes have no special meaning. The escaped sequences $${ and %%{ are
+// replaced by the literal ${ and %{ sequences. The other arguments are kept
+// as is.
+//
+// The arguments must evaluate to primitive values, which are converted to
+// strings. The errors have the stack directory and the index of the argument,
+// where the index 0 is the program.
+func EvalArgs(ctx *eval.Context, st *config.Stack, args []string) ([]string, error) {
+	newargs := make([]string, 0, len(args))
+	for i, arg := range args {
+		if !hasTemplateSequence(arg) {
+			newargs = append(newargs, arg)
+			continue
+		}
+		expr, err := ast.ParseTemplate(arg, "<cmd arg>")
+		if err != nil {
+			return nil, errors.E(ErrEvalArg, err, "stack %s: parsing argument %d %q", st.Dir, i, arg)
+		}
+		val, err := ctx.Eval(expr)
+		if err != nil {
+			return nil, errors.E(ErrEvalArg, err, "stack %s: evaluating argument %d %q", st.Dir, i, arg)
+		}
+		if val.IsNull() || !val.IsWhollyKnown() || !val.Type().IsPrimitiveType() {
+			return nil, errors.E(ErrEvalArg,
+				"stack %s: argument %d %q evaluates to %s but only strings, numbers and bools are permitted",
+				st.Dir, i, arg, describeArgValue(val))
+		}
+		strval, err := convert.Convert(val, cty.String)
+		if err != nil {
+			return nil, errors.E(ErrEvalArg, err, "stack %s: converting argument %d %q to string", st.Dir, i, arg)
+		}
+		newargs = append(newargs, strval.AsString())
+	}
+	return newargs, nil
+}
+
+func hasTemplateSequence(arg string) bool {
+	return strings.Contains(arg, "${") || strings.Contains(arg, "%{")
+}
+
+func describeArgValue(val cty.Value) string {
+	switch {
+	case val.IsNull():
+		return "null"
+	case !val.IsWhollyKnown():
+		return "an unknown value"
+	default:
+		return val.Type().FriendlyName()
+	}
+}
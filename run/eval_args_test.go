@@ -0,0 +1,159 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package run_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/madlambda/spells/assert"
+	"github.com/terramate-io/terramate/config"
+	"github.com/terramate-io/terramate/errors"
+	"github.com/terramate-io/terramate/hcl/eval"
+	"github.com/terramate-io/terramate/project"
+	"github.com/terramate-io/terramate/run"
+	"github.com/terramate-io/terramate/stdlib"
+	errtest "github.com/terramate-io/terramate/test/errors"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestEvalArgs(t *testing.T) {
+	t.Parallel()
+
+	type testcase struct {
+		name    string
+		args    []string
+		want    []string
+		wantErr string
+	}
+
+	for _, tc := range []testcase{
+		{
+			name: "args without interpolation are kept as is",
+			args: []string{"terraform", "plan", "-out=plan.tfplan", "terramate.stack.name"},
+			want: []string{"terraform", "plan", "-out=plan.tfplan", "terramate.stack.name"},
+		},
+		{
+			name: "interpolation of metadata and globals",
+			args: []string{"echo", "${terramate.stack.name}", "-var=region=${global.region}"},
+			want: []string{"echo", "stack", "-var=region=eu-west-1"},
+		},
+		{
+			name: "function calls with quoted strings",
+			args: []string{`${tm_upper("hcl")}`},
+			want: []string{"HCL"},
+		},
+		{
+			name: "directives",
+			args: []string{`%{ for i in tm_range(3) ~}${i}%{ endfor }`},
+			want: []string{"012"},
+		},
+		{
+			name: "JSON literal without interpolation",
+			args: []string{`-var=tags={"env":"prod","team":"infra"}`},
+			want: []string{`-var=tags={"env":"prod","team":"infra"}`},
+		},
+		{
+			name: "JSON literal with interpolation",
+			args: []string{`-var=tags={"env":"${global.env}","name":"${terramate.stack.name}"}`},
+			want: []string{`-var=tags={"env":"prod","name":"stack"}`},
+		},
+		{
+			name: "JSON encoded by a function",
+			args: []string{`-var=tags=${tm_jsonencode({ env = global.env })}`},
+			want: []string{`-var=tags={"env":"prod"}`},
+		},
+		{
+			name: "lone quotes",
+			args: []string{`"`, `"${global.env}`, `${global.env}"`},
+			want: []string{`"`, `"prod`, `prod"`},
+		},
+		{
+			name: "backslashes are literal",
+			args: []string{`\"`, `\n`, `${global.env}\t\"`},
+			want: []string{`\"`, `\n`, `prod\t\"`},
+		},
+		{
+			name: "regular expressions",
+			args: []string{`^\d+\.\d+$`, `^${global.env}-[a-z]{3}\s*$`},
+			want: []string{`^\d+\.\d+$`, `^prod-[a-z]{3}\s*$`},
+		},
+		{
+			name: "windows paths",
+			args: []string{`C:\Users\terramate\bin\tool.exe`, `C:\Users\${global.env}\plan.tfplan`},
+			want: []string{`C:\Users\terramate\bin\tool.exe`, `C:\Users\prod\plan.tfplan`},
+		},
+		{
+			name: "escaped interpolation and directives are literal",
+			args: []string{`$${global.env}`, `%%{ if true }`, `$${literal} ${global.env}`},
+			want: []string{`${global.env}`, `%{ if true }`, `${literal} prod`},
+		},
+		{
+			name: "dollar and percent without braces are literal",
+			args: []string{`$HOME`, `100%`, `$$ ${global.env} %%`},
+			want: []string{`$HOME`, `100%`, `$$ prod %%`},
+		},
+		{
+			name: "numbers and bools are converted to strings",
+			args: []string{`${global.count}`, `${global.enabled}`},
+			want: []string{"3", "true"},
+		},
+		{
+			name:    "unterminated interpolation fails with argument index",
+			args:    []string{"echo", "ok", "${global.env"},
+			wantErr: `stack /stack: parsing argument 2 "${global.env"`,
+		},
+		{
+			name:    "undefined variable fails with argument index",
+			args:    []string{"echo", "${global.undefined}"},
+			wantErr: `stack /stack: evaluating argument 1 "${global.undefined}"`,
+		},
+		{
+			name:    "list value fails",
+			args:    []string{"${global.list}"},
+			wantErr: `stack /stack: argument 0 "${global.list}" evaluates to list of string`,
+		},
+		{
+			name:    "null value fails",
+			args:    []string{"echo", "${null}"},
+			wantErr: `stack /stack: argument 1 "${null}" evaluates to null`,
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			st := &config.Stack{Dir: project.NewPath("/stack"), Name: "stack"}
+			got, err := run.EvalArgs(newArgsEvalCtx(t), st, tc.args)
+			if tc.wantErr != "" {
+				errtest.Assert(t, err, errors.E(run.ErrEvalArg))
+				assert.IsTrue(t, strings.Contains(err.Error(), tc.wantErr),
+					"error %q does not contain %q", err.Error(), tc.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.EqualInts(t, len(tc.want), len(got), "got %q", got)
+			for i, want := range tc.want {
+				assert.EqualStrings(t, want, got[i], "argument %d", i)
+			}
+		})
+	}
+}
+
+func newArgsEvalCtx(t *testing.T) *eval.Context {
+	ctx := eval.NewContext(stdlib.NoFS(t.TempDir(), nil))
+	ctx.SetNamespace("terramate", map[string]cty.Value{
+		"stack": cty.ObjectVal(map[string]cty.Value{
+			"name": cty.StringVal("stack"),
+		}),
+	})
+	ctx.SetNamespace("global", map[string]cty.Value{
+		"region":  cty.StringVal("eu-west-1"),
+		"env":     cty.StringVal("prod"),
+		"count":   cty.NumberIntVal(3),
+		"enabled": cty.True,
+		"list":    cty.ListVal([]cty.Value{cty.StringVal("a")}),
+	})
+	return ctx
+}
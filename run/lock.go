@@ -0,0 +1,213 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package run
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/terramate-io/terramate/config"
+	"github.com/terramate-io/terramate/errors"
+	"github.com/terramate-io/terramate/project"
+)
+
+const (
+	// ErrLocked indicates that the stack is locked by another run.
+	ErrLocked errors.Kind = "stack is locked by another run"
+
+	// ErrLock indicates that the run lock of the stack cannot be acquired
+	// or released.
+	ErrLock errors.Kind = "locking stack"
+)
+
+// DefaultLockTTL is the age after which a stack lock is considered stale when
+// `terramate.config.run.lock_ttl` is not set.
+const DefaultLockTTL = time.Hour
+
+// lockDirsGitignore is written to the directory of the lock files, so they
+// are never committed nor reported as untracked files.
+const lockDirsGitignore = "# Created by Terramate. Do not commit the lock files.\n*\n"
+
+// LockInfo is the content of a stack lock file.
+type LockInfo struct {
+	PID       int       `json:"pid"`
+	Hostname  string    `json:"hostname"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Lock is an acquired run lock of a stack.
+type Lock struct {
+	path string
+	data []byte
+
+	// Stolen is the stale lock replaced when acquiring the lock, if any.
+	Stolen *LockInfo
+}
+
+// AcquireLock acquires the run lock of the stack, which is a file at
+// .terramate/locks/<stack-id>.lock, see [config.Stack.LockFile], with the pid,
+// hostname and creation time of the current process. A lock of another run fails with [ErrLocked],
+// unless it's stale: older than the ttl or created by a process of the same
+// host which is no longer running. Stale locks are replaced and reported in
+// [Lock.Stolen].
+func AcquireLock(root *config.Root, st *config.Stack, ttl time.Duration) (*Lock, error) {
+	lockfile := st.LockFile()
+	if ttl <= 0 {
+		ttl = DefaultLockTTL
+	}
+
+	if err := createLockDir(root); err != nil {
+		return nil, err
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil {
+		return nil, errors.E(ErrLock, err, "getting hostname")
+	}
+
+	data, err := json.Marshal(LockInfo{
+		PID:       os.Getpid(),
+		Hostname:  hostname,
+		CreatedAt: time.Now().UTC(),
+	})
+	if err != nil {
+		return nil, errors.E(ErrLock, err)
+	}
+
+	path := project.AbsPath(root.HostDir(), lockfile.String())
+
+	var stolen *LockInfo
+	// the lock is retried once after a stale lock is removed.
+	for attempt := 0; attempt < 2; attempt++ {
+		err := createLockFile(path, data)
+		if err == nil {
+			return &Lock{path: path, data: data, Stolen: stolen}, nil
+		}
+		if !errors.Is(err, fs.ErrExist) {
+			return nil, errors.E(ErrLock, err, "creating lock file of stack %s", st.Dir)
+		}
+
+		current, err := readLockFile(path)
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				// released in the meantime.
+				continue
+			}
+			return nil, errors.E(ErrLock, err, "reading lock file of stack %s", st.Dir)
+		}
+
+		if !current.isStale(ttl, hostname) {
+			return nil, errors.E(ErrLocked,
+				"stack %s is locked by pid %d on host %s since %s (lock file: %s)",
+				st.Dir, current.PID, current.Hostname,
+				current.CreatedAt.Format(time.RFC3339), lockfile,
+			)
+		}
+
+		removed, err := removeStaleLockFile(path, current)
+		if err != nil {
+			return nil, errors.E(ErrLock, err, "removing stale lock file of stack %s", st.Dir)
+		}
+		if removed {
+			stolen = &current
+		}
+	}
+
+	return nil, errors.E(ErrLocked, "stack %s is locked by another run (lock file: %s)", st.Dir, lockfile)
+}
+
+// Release releases the lock. The lock file is kept if it was stolen by
+// another run in the meantime.
+func (l *Lock) Release() error {
+	data, err := os.ReadFile(l.path)
+	if err != nil {
+		return errors.E(ErrLock, err, "reading lock file")
+	}
+	if !bytes.Equal(data, l.data) {
+		return errors.E(ErrLock, "lock file %s was replaced by another run", l.path)
+	}
+	if err := os.Remove(l.path); err != nil {
+		return errors.E(ErrLock, err, "removing lock file")
+	}
+	return nil
+}
+
+func (info LockInfo) isStale(ttl time.Duration, hostname string) bool {
+	if time.Since(info.CreatedAt) > ttl {
+		return true
+	}
+	return info.Hostname == hostname && !processExists(info.PID)
+}
+
+func createLockDir(root *config.Root) error {
+	lockdir := filepath.Join(root.HostDir(), filepath.FromSlash(config.StackLockDirs))
+	if err := os.MkdirAll(lockdir, 0755); err != nil {
+		return errors.E(ErrLock, err)
+	}
+	gitignore := filepath.Join(lockdir, ".gitignore")
+	if _, err := os.Stat(gitignore); err == nil {
+		return nil
+	}
+	if err := os.WriteFile(gitignore, []byte(lockDirsGitignore), 0644); err != nil {
+		return errors.E(ErrLock, err, "creating .gitignore")
+	}
+	return nil
+}
+
+// createLockFile atomically creates the lock file with the given content.
+// The content is written to a temporary file which is then linked as the lock
+// file, so the lock file is never observed partially written. It fails with
+// [fs.ErrExist] if the lock file already exists.
+func createLockFile(path string, data []byte) error {
+	tmp := path + "." + strconv.Itoa(os.Getpid()) + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(tmp) }()
+	return os.Link(tmp, path)
+}
+
+func readLockFile(path string) (LockInfo, error) {
+	var info LockInfo
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return info, err
+	}
+	if err := json.Unmarshal(data, &info); err != nil {
+		return info, errors.E(err, "invalid lock file %s", path)
+	}
+	return info, nil
+}
+
+// removeStaleLockFile removes the lock file if it's still the given stale
+// lock. The lock file is moved aside before checking its content, so a lock
+// created by another run in the meantime is restored instead of removed.
+func removeStaleLockFile(path string, stale LockInfo) (bool, error) {
+	moved := path + "." + strconv.Itoa(os.Getpid()) + ".stale"
+	if err := os.Rename(path, moved); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return false, nil
+		}
+		return false, err
+	}
+	defer func() { _ = os.Remove(moved) }()
+
+	info, err := readLockFile(moved)
+	if err != nil {
+		return false, err
+	}
+	if info == stale {
+		return true, nil
+	}
+	// not the stale lock: put it back unless yet another lock was created.
+	if err := os.Link(moved, path); err != nil && !errors.Is(err, fs.ErrExist) {
+		return false, err
+	}
+	return false, nil
+}
@@ -0,0 +1,230 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package run_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/madlambda/spells/assert"
+	"github.com/terramate-io/terramate/config"
+	"github.com/terramate-io/terramate/errors"
+	"github.com/terramate-io/terramate/project"
+	"github.com/terramate-io/terramate/run"
+	"github.com/terramate-io/terramate/test"
+	errorstest "github.com/terramate-io/terramate/test/errors"
+	"github.com/terramate-io/terramate/test/sandbox"
+)
+
+func TestRunLock(t *testing.T) {
+	t.Parallel()
+
+	root, st := setupLockStack(t)
+	lockfile := filepath.Join(root.HostDir(), ".terramate", "locks", "stack-id.lock")
+
+	lock, err := run.AcquireLock(root, st, 0)
+	assert.NoError(t, err)
+	assert.IsTrue(t, lock.Stolen == nil, "unexpected stolen lock: %+v", lock.Stolen)
+
+	info := readLockInfo(t, lockfile)
+	hostname, err := os.Hostname()
+	assert.NoError(t, err)
+	assert.EqualInts(t, os.Getpid(), info.PID)
+	assert.EqualStrings(t, hostname, info.Hostname)
+	assert.IsTrue(t, time.Since(info.CreatedAt) < time.Minute, "unexpected lock time: %s", info.CreatedAt)
+
+	gitignore := test.ReadFile(t, filepath.Join(root.HostDir(), ".terramate", "locks"), ".gitignore")
+	assert.EqualStrings(t, "# Created by Terramate. Do not commit the lock files.\n*\n", string(gitignore))
+
+	_, err = run.AcquireLock(root, st, 0)
+	errorstest.Assert(t, err, errors.E(run.ErrLocked))
+
+	assert.NoError(t, lock.Release())
+	_, err = os.Stat(lockfile)
+	assert.IsTrue(t, os.IsNotExist(err), "lock file must be removed: %v", err)
+
+	lock, err = run.AcquireLock(root, st, 0)
+	assert.NoError(t, err)
+	assert.NoError(t, lock.Release())
+}
+
+func TestRunLockStealsStaleLocks(t *testing.T) {
+	t.Parallel()
+
+	hostname, err := os.Hostname()
+	assert.NoError(t, err)
+
+	type testcase struct {
+		name   string
+		lock   run.LockInfo
+		ttl    time.Duration
+		stolen bool
+	}
+
+	const deadPID = 1 << 30
+
+	for _, tc := range []testcase{
+		{
+			name: "fresh lock of running process",
+			lock: run.LockInfo{
+				PID:       os.Getpid(),
+				Hostname:  hostname,
+				CreatedAt: time.Now().UTC(),
+			},
+		},
+		{
+			name: "fresh lock of dead process on other host",
+			lock: run.LockInfo{
+				PID:       deadPID,
+				Hostname:  "other-" + hostname,
+				CreatedAt: time.Now().UTC(),
+			},
+		},
+		{
+			name: "lock of dead process on same host",
+			lock: run.LockInfo{
+				PID:       deadPID,
+				Hostname:  hostname,
+				CreatedAt: time.Now().UTC(),
+			},
+			stolen: true,
+		},
+		{
+			name: "lock older than default TTL",
+			lock: run.LockInfo{
+				PID:       os.Getpid(),
+				Hostname:  "other-" + hostname,
+				CreatedAt: time.Now().UTC().Add(-2 * time.Hour),
+			},
+			stolen: true,
+		},
+		{
+			name: "lock older than configured TTL",
+			lock: run.LockInfo{
+				PID:       os.Getpid(),
+				Hostname:  "other-" + hostname,
+				CreatedAt: time.Now().UTC().Add(-2 * time.Minute),
+			},
+			ttl:    time.Minute,
+			stolen: true,
+		},
+		{
+			name: "lock younger than configured TTL",
+			lock: run.LockInfo{
+				PID:       os.Getpid(),
+				Hostname:  "other-" + hostname,
+				CreatedAt: time.Now().UTC().Add(-2 * time.Hour),
+			},
+			ttl: 3 * time.Hour,
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			root, st := setupLockStack(t)
+			lockdir := filepath.Join(root.HostDir(), ".terramate", "locks")
+			data, err := json.Marshal(tc.lock)
+			assert.NoError(t, err)
+			test.WriteFile(t, lockdir, "stack-id.lock", string(data))
+
+			lock, err := run.AcquireLock(root, st, tc.ttl)
+			if !tc.stolen {
+				errorstest.Assert(t, err, errors.E(run.ErrLocked))
+				got := readLockInfo(t, filepath.Join(lockdir, "stack-id.lock"))
+				assertLockInfo(t, got, tc.lock)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.IsTrue(t, lock.Stolen != nil, "want stolen lock")
+			assertLockInfo(t, *lock.Stolen, tc.lock)
+			got := readLockInfo(t, filepath.Join(lockdir, "stack-id.lock"))
+			assert.EqualInts(t, os.Getpid(), got.PID)
+			assert.NoError(t, lock.Release())
+		})
+	}
+}
+
+func TestRunLockReleaseKeepsReplacedLock(t *testing.T) {
+	t.Parallel()
+
+	root, st := setupLockStack(t)
+	lockdir := filepath.Join(root.HostDir(), ".terramate", "locks")
+
+	lock, err := run.AcquireLock(root, st, 0)
+	assert.NoError(t, err)
+
+	test.WriteFile(t, lockdir, "stack-id.lock", `{"pid":1,"hostname":"other","created_at":"2024-01-01T00:00:00Z"}`)
+
+	errorstest.Assert(t, lock.Release(), errors.E(run.ErrLock))
+	test.IsFile(t, lockdir, "stack-id.lock")
+}
+
+func TestRunLockStackWithoutID(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.NoGit(t, true)
+	s.BuildTree([]string{"s:stacks/a", "s:stacks/b"})
+	root, err := config.LoadRoot(s.RootDir())
+	assert.NoError(t, err)
+	a, err := config.LoadStack(root, project.NewPath("/stacks/a"))
+	assert.NoError(t, err)
+	b, err := config.LoadStack(root, project.NewPath("/stacks/b"))
+	assert.NoError(t, err)
+
+	sum := sha256.Sum256([]byte("/stacks/a"))
+	lockfile := filepath.Join(root.HostDir(), ".terramate", "locks",
+		"path-"+hex.EncodeToString(sum[:])+".lock")
+
+	lock, err := run.AcquireLock(root, a, 0)
+	assert.NoError(t, err)
+	test.IsFile(t, filepath.Dir(lockfile), filepath.Base(lockfile))
+
+	_, err = run.AcquireLock(root, a, 0)
+	errorstest.Assert(t, err, errors.E(run.ErrLocked))
+
+	// other stacks without ID have their own lock.
+	lockb, err := run.AcquireLock(root, b, 0)
+	assert.NoError(t, err)
+
+	assert.NoError(t, lock.Release())
+	assert.NoError(t, lockb.Release())
+}
+
+func setupLockStack(t *testing.T) (*config.Root, *config.Stack) {
+	t.Helper()
+
+	s := sandbox.NoGit(t, true)
+	s.BuildTree([]string{"s:stack:id=stack-id"})
+	root, err := config.LoadRoot(s.RootDir())
+	assert.NoError(t, err)
+	st, err := config.LoadStack(root, project.NewPath("/stack"))
+	assert.NoError(t, err)
+	return root, st
+}
+
+func readLockInfo(t *testing.T, path string) run.LockInfo {
+	t.Helper()
+
+	var info run.LockInfo
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.NoError(t, json.Unmarshal(data, &info))
+	return info
+}
+
+func assertLockInfo(t *testing.T, got, want run.LockInfo) {
+	t.Helper()
+
+	assert.EqualInts(t, want.PID, got.PID, "lock pid mismatch")
+	assert.EqualStrings(t, want.Hostname, got.Hostname, "lock hostname mismatch")
+	assert.IsTrue(t, want.CreatedAt.Equal(got.CreatedAt),
+		"lock created_at %s != %s", got.CreatedAt, want.CreatedAt)
+}
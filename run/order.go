@@ -19,8 +19,13 @@ import (
 	"golang.org/x/exp/slices"
 )
 
+// ErrInvalidOrdering indicates that a stack ordering entry is invalid and
+// `terramate.config.run.check_ordering` is set to "error".
+const ErrInvalidOrdering errors.Kind = "invalid stack ordering"
+
 // Sort computes the final execution order for the given list of stacks.
-// In the case of multiple possible orders, it returns the lexicographic sorted path.
+// In the case of multiple possible orders, stacks triggered with a higher
+// priority come first and then it returns the lexicographic sorted path.
 func Sort[S ~[]E, E any](root *config.Root, items S, getStack func(E) *config.Stack) (string, error) {
 	d, reason, err := buildValidStackDAG(root, items, getStack)
 	if err != nil {
@@ -48,6 +53,57 @@ func Sort[S ~[]E, E any](root *config.Root, items S, getStack func(E) *config.St
 	return "", nil
 }
 
+// Shuffle computes a pseudo-random execution order for the given list of
+// stacks, which still honors the ordering constraints and priorities of the
+// stacks. The same seed always gives the same order, which is the order used
+// by the sequential scheduler when the DAG is shuffled with the same seed.
+func Shuffle[S ~[]E, E any](root *config.Root, items S, getStack func(E) *config.Stack, seed int64) (string, error) {
+	d, reason, err := BuildDAGFromStacks(root, items, getStack)
+	if err != nil {
+		return reason, err
+	}
+
+	d.Shuffle(seed)
+
+	order := d.Order()
+	orderLookup := make(map[string]int, len(order))
+	for idx, id := range order {
+		orderLookup[string(id)] = idx
+	}
+
+	slices.SortStableFunc(items, func(a, b E) int {
+		return cmp.Compare(orderLookup[getStack(a).Dir.String()], orderLookup[getStack(b).Dir.String()])
+	})
+
+	return "", nil
+}
+
+// Levels groups the given list of stacks by their level in the execution
+// order. The stacks of the first group have no dependencies in the list and
+// the stacks of each next group only depend on stacks of the previous groups,
+// so the stacks of the same group can be executed concurrently.
+// The stacks of each group are lexicographic sorted.
+func Levels[S ~[]E, E any](root *config.Root, items S, getStack func(E) *config.Stack) ([]S, string, error) {
+	d, reason, err := BuildDAGFromStacks(root, items, getStack)
+	if err != nil {
+		return nil, reason, err
+	}
+
+	var levels []S
+	for _, ids := range d.Levels() {
+		level := make(S, 0, len(ids))
+		for _, id := range ids {
+			item, err := d.Node(id)
+			if err != nil {
+				return nil, "", fmt.Errorf("calculating run-order levels: %w", err)
+			}
+			level = append(level, item)
+		}
+		levels = append(levels, level)
+	}
+	return levels, "", nil
+}
+
 // BuildDAGFromStacks computes the final, reduced dag for the given list of stacks.
 func BuildDAGFromStacks[S ~[]E, E any](root *config.Root, items S, getStack func(E) *config.Stack) (*dag.DAG[E], string, error) {
 	d, reason, err := buildValidStackDAG(root, items, getStack)
@@ -163,6 +219,24 @@ func buildValidStackDAG[S ~[]E, E any](
 		return nil, reason, err
 	}
 
+	// the stack configured priorities are overridden by the ones of the change
+	// triggers in the current change set.
+	priorities := map[dag.ID]int{}
+	for _, id := range d.IDs() {
+		st, err := d.Node(id)
+		if err != nil {
+			return nil, "", errors.E(err, "getting stack of node %s", id)
+		}
+		if st.TriggerPriority != 0 {
+			priorities[id] = st.TriggerPriority
+		} else if cfg := LoadStackRunConfig(root, st); cfg.Priority != 0 {
+			priorities[id] = cfg.Priority
+		}
+	}
+	if len(priorities) > 0 {
+		d.SetPriorities(priorities)
+	}
+
 	return d, "", nil
 }
 
@@ -189,6 +263,19 @@ func BuildDAG(
 	visited[dag.ID(s.Dir.String())] = struct{}{}
 
 	computePaths := func(fieldname string, paths []string) ([]string, error) {
+		isOrdering := fieldname == "before" || fieldname == "after"
+		strict := isOrdering && root.IsOrderingCheckStrict()
+
+		errs := errors.L()
+		report := func(format string, args ...any) {
+			msg := fmt.Sprintf(format, args...)
+			if strict {
+				errs.Append(errors.E(ErrInvalidOrdering, msg))
+				return
+			}
+			printer.Stderr.Warn(msg)
+		}
+
 		uniqPaths := map[string]struct{}{}
 		for _, pathstr := range paths {
 			if strings.HasPrefix(pathstr, "tag:") {
@@ -217,25 +304,31 @@ func BuildDAG(
 			st, err := os.Stat(abspath)
 			if err != nil {
 				logger.Debug().Str("path", pathstr).Err(err).Msgf("Invalid stack.%s path", fieldname)
-				printer.Stderr.Warn(
-					fmt.Sprintf("Stack %s references an invalid path (%s) in the '%s' attribute", s.Dir, pathstr, fieldname),
-				)
+				report("Stack %s references an invalid path (%s) in the '%s' attribute", s.Dir, pathstr, fieldname)
 			} else if !st.IsDir() {
-				printer.Stderr.Warn(
-					fmt.Sprintf("Stack %s references a path (%s) that is not a directory in the '%s' attribute",
-						s.Dir,
-						pathstr, fieldname,
-					),
+				report("Stack %s references a path (%s) that is not a directory in the '%s' attribute",
+					s.Dir,
+					pathstr, fieldname,
+				)
+			} else if isOrdering && len(root.StacksByPaths(s.Dir, pathstr)) == 0 {
+				report("Stack %s references a directory (%s) with no stacks in the '%s' attribute",
+					s.Dir, pathstr, fieldname,
 				)
 			} else {
 				uniqPaths[pathstr] = struct{}{}
 			}
 		}
 
-		var cleanpaths []string
+		if err := errs.AsError(); err != nil {
+			return nil, err
+		}
+
+		cleanpaths := make([]string, 0, len(uniqPaths))
 		for path := range uniqPaths {
 			cleanpaths = append(cleanpaths, path)
 		}
+		// sorted, so the stacks are loaded and visited in a stable order.
+		slices.Sort(cleanpaths)
 		return cleanpaths, nil
 	}
 
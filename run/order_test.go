@@ -4,14 +4,21 @@
 package run_test
 
 import (
+	"fmt"
+	"math/rand"
 	"path"
 	"slices"
+	"strings"
 	"testing"
 
 	"github.com/madlambda/spells/assert"
 	"github.com/terramate-io/terramate/config"
+	"github.com/terramate-io/terramate/errors"
 	"github.com/terramate-io/terramate/project"
 	"github.com/terramate-io/terramate/run"
+	"github.com/terramate-io/terramate/run/dag"
+	"github.com/terramate-io/terramate/test"
+	errtest "github.com/terramate-io/terramate/test/errors"
 	"github.com/terramate-io/terramate/test/sandbox"
 )
 
@@ -65,3 +72,292 @@ func TestStableSortOrder(t *testing.T) {
 		}
 	}
 }
+
+func TestSortOrderIsDeterministic(t *testing.T) {
+	t.Parallel()
+
+	// The order of each randomized layout is computed many times, with the
+	// stacks given in a different order, and it must never change.
+	for seed := int64(0); seed < 20; seed++ {
+		seed := seed
+		t.Run(fmt.Sprintf("seed %d", seed), func(t *testing.T) {
+			t.Parallel()
+
+			rng := rand.New(rand.NewSource(seed))
+			var paths []string
+			for i := 0; i < 4+rng.Intn(8); i++ {
+				dir := fmt.Sprintf("/stacks/s%d", rng.Intn(20))
+				if rng.Intn(3) == 0 {
+					dir = fmt.Sprintf("/apps/s%d", rng.Intn(5))
+				}
+				if !slices.Contains(paths, dir) {
+					paths = append(paths, dir)
+				}
+				if rng.Intn(3) == 0 && !slices.Contains(paths, dir+"/child") {
+					paths = append(paths, dir+"/child")
+				}
+			}
+			// parents come before their children, so the before/after edges
+			// below are only added from lower to higher indexes to avoid cycles.
+			// The after edges only target stacks without child stacks, as
+			// a directory also refers to all stacks inside it.
+			slices.Sort(paths)
+			hasChild := func(p string) bool {
+				return slices.ContainsFunc(paths, func(other string) bool {
+					return strings.HasPrefix(other, p+"/")
+				})
+			}
+
+			var layout []string
+			for i, p := range paths {
+				var after, before []string
+				for j := range paths {
+					if j < i && !hasChild(paths[j]) && rng.Intn(4) == 0 {
+						after = append(after, `"`+paths[j]+`"`)
+					}
+					if j > i && rng.Intn(6) == 0 {
+						before = append(before, `"`+paths[j]+`"`)
+					}
+				}
+				layout = append(layout, fmt.Sprintf("s:%s:after=[%s];before=[%s]",
+					p[1:], strings.Join(after, ","), strings.Join(before, ",")))
+			}
+
+			s := sandbox.NoGit(t, true)
+			s.BuildTree(layout)
+			root, err := config.LoadRoot(s.RootDir())
+			assert.NoError(t, err)
+
+			getStack := func(s *config.SortableStack) *config.Stack { return s.Stack }
+
+			var want []string
+			for i := 0; i < 20; i++ {
+				stacks, err := config.LoadAllStacks(root, root.Tree())
+				assert.NoError(t, err)
+				rng.Shuffle(len(stacks), func(i, j int) {
+					stacks[i], stacks[j] = stacks[j], stacks[i]
+				})
+
+				_, err = run.Sort(root, stacks, getStack)
+				assert.NoError(t, err)
+
+				var got []string
+				for _, st := range stacks {
+					got = append(got, st.Dir().String())
+				}
+				if want == nil {
+					want = got
+					continue
+				}
+				assert.EqualStrings(t, strings.Join(want, " "), strings.Join(got, " "),
+					"layout %v", layout)
+			}
+		})
+	}
+}
+
+func TestSortOrderOfUnorderedSiblingsIsDeterministic(t *testing.T) {
+	t.Parallel()
+
+	// regression: sibling stacks without any ordering between them, which
+	// depend on the same tag, must not swap places between runs.
+	s := sandbox.NoGit(t, true)
+	s.BuildTree([]string{
+		`s:infra/network:tags=["infra"]`,
+		`s:infra/dns:tags=["infra"]`,
+		`s:infra/iam:tags=["infra"]`,
+		`s:apps/b:after=["tag:infra"]`,
+		`s:apps/a:after=["tag:infra"]`,
+		`s:apps/c:after=["tag:infra"]`,
+		`s:docs`,
+	})
+
+	root, err := config.LoadRoot(s.RootDir())
+	assert.NoError(t, err)
+
+	want := []string{
+		"/infra/dns", "/infra/iam", "/infra/network",
+		"/apps/a", "/apps/b", "/apps/c",
+		"/docs",
+	}
+	getStack := func(s *config.SortableStack) *config.Stack { return s.Stack }
+	for i := 0; i < 100; i++ {
+		stacks, err := config.LoadAllStacks(root, root.Tree())
+		assert.NoError(t, err)
+		rand.Shuffle(len(stacks), func(i, j int) {
+			stacks[i], stacks[j] = stacks[j], stacks[i]
+		})
+
+		_, err = run.Sort(root, stacks, getStack)
+		assert.NoError(t, err)
+
+		var got []string
+		for _, st := range stacks {
+			got = append(got, st.Dir().String())
+		}
+		assert.EqualStrings(t, strings.Join(want, " "), strings.Join(got, " "))
+	}
+}
+
+func TestShuffleOrder(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.NoGit(t, true)
+	s.BuildTree([]string{
+		`s:infra/network:tags=["infra"]`,
+		`s:infra/dns:tags=["infra"]`,
+		`s:infra/iam:tags=["infra"]`,
+		`s:apps/b:after=["tag:infra"]`,
+		`s:apps/a:after=["tag:infra"]`,
+		`s:apps/c:after=["tag:infra"]`,
+		`s:docs`,
+		`s:docs/child`,
+		`s:tools`,
+	})
+
+	root, err := config.LoadRoot(s.RootDir())
+	assert.NoError(t, err)
+
+	getStack := func(s *config.SortableStack) *config.Stack { return s.Stack }
+	shuffle := func(seed int64) []string {
+		stacks, err := config.LoadAllStacks(root, root.Tree())
+		assert.NoError(t, err)
+		rand.Shuffle(len(stacks), func(i, j int) {
+			stacks[i], stacks[j] = stacks[j], stacks[i]
+		})
+
+		_, err = run.Shuffle(root, stacks, getStack, seed)
+		assert.NoError(t, err)
+
+		var got []string
+		for _, st := range stacks {
+			got = append(got, st.Dir().String())
+		}
+		return got
+	}
+
+	distinctOrders := map[string]struct{}{}
+	for seed := int64(1); seed <= 20; seed++ {
+		want := shuffle(seed)
+		for i := 0; i < 5; i++ {
+			assert.EqualStrings(t, strings.Join(want, " "), strings.Join(shuffle(seed), " "),
+				"seed %d: order is not reproducible", seed)
+		}
+
+		position := map[string]int{}
+		for i, dir := range want {
+			position[dir] = i
+		}
+		for _, app := range []string{"/apps/a", "/apps/b", "/apps/c"} {
+			for _, infra := range []string{"/infra/dns", "/infra/iam", "/infra/network"} {
+				assert.IsTrue(t, position[infra] < position[app],
+					"seed %d: %s ordered before %s: %v", seed, app, infra, want)
+			}
+		}
+		assert.IsTrue(t, position["/docs"] < position["/docs/child"],
+			"seed %d: child stack ordered before its parent: %v", seed, want)
+
+		distinctOrders[strings.Join(want, " ")] = struct{}{}
+	}
+	assert.IsTrue(t, len(distinctOrders) > 1, "different seeds must give different orders")
+}
+
+func TestLevels(t *testing.T) {
+	t.Parallel()
+
+	type testcase struct {
+		name   string
+		layout []string
+		want   [][]string
+	}
+
+	for _, tc := range []testcase{
+		{
+			name: "independent stacks are in the same level",
+			layout: []string{
+				"s:c",
+				"s:a",
+				"s:b",
+			},
+			want: [][]string{{"/a", "/b", "/c"}},
+		},
+		{
+			name: "tag based ordering",
+			layout: []string{
+				`s:network:tags=["infra"]`,
+				`s:dns:tags=["infra"]`,
+				`s:app:after=["tag:infra"]`,
+				`s:monitoring:after=["/app"]`,
+				`s:docs`,
+			},
+			want: [][]string{
+				{"/dns", "/docs", "/network"},
+				{"/app"},
+				{"/monitoring"},
+			},
+		},
+		{
+			name: "nested stacks run after their parents",
+			layout: []string{
+				"s:parent",
+				"s:parent/child-b",
+				"s:parent/child-a",
+				"s:parent/child-a/grandchild",
+				"s:other",
+			},
+			want: [][]string{
+				{"/other", "/parent"},
+				{"/parent/child-a", "/parent/child-b"},
+				{"/parent/child-a/grandchild"},
+			},
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			s := sandbox.NoGit(t, true)
+			s.BuildTree(tc.layout)
+
+			root, err := config.LoadRoot(s.RootDir())
+			assert.NoError(t, err)
+
+			stacks, err := config.LoadAllStacks(root, root.Tree())
+			assert.NoError(t, err)
+
+			levels, _, err := run.Levels(root, stacks,
+				func(s *config.SortableStack) *config.Stack { return s.Stack })
+			assert.NoError(t, err)
+
+			var got [][]string
+			for _, level := range levels {
+				var dirs []string
+				for _, st := range level {
+					dirs = append(dirs, st.Dir().String())
+				}
+				got = append(got, dirs)
+			}
+			test.AssertDiff(t, got, tc.want)
+		})
+	}
+}
+
+func TestLevelsFailsOnCycles(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.NoGit(t, true)
+	s.BuildTree([]string{
+		`s:a:after=["/b"]`,
+		`s:b:after=["/a"]`,
+	})
+
+	root, err := config.LoadRoot(s.RootDir())
+	assert.NoError(t, err)
+
+	stacks, err := config.LoadAllStacks(root, root.Tree())
+	assert.NoError(t, err)
+
+	_, _, err = run.Levels(root, stacks,
+		func(s *config.SortableStack) *config.Stack { return s.Stack })
+	errtest.Assert(t, err, errors.E(dag.ErrCycleDetected))
+}
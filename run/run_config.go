@@ -0,0 +1,112 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package run
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/terramate-io/terramate/config"
+	"github.com/terramate-io/terramate/project"
+)
+
+// ConfigOriginKind is the kind of definition an effective stack run
+// configuration value comes from.
+type ConfigOriginKind string
+
+const (
+	// OriginStack indicates the value is defined in the stack block.
+	OriginStack ConfigOriginKind = "stack"
+
+	// OriginStackDefaults indicates the value is defined in a
+	// terramate.config.run.stack_defaults block.
+	OriginStackDefaults ConfigOriginKind = "stack_defaults"
+
+	// OriginDefault indicates the value is the built-in default.
+	OriginDefault ConfigOriginKind = "default"
+)
+
+// ConfigOrigin tells where an effective stack run configuration value
+// is defined.
+type ConfigOrigin struct {
+	// Kind of the definition.
+	Kind ConfigOriginKind
+
+	// Dir is the directory of the defining block. It is empty for
+	// built-in defaults.
+	Dir project.Path
+}
+
+// StackRunConfig is the effective run configuration of a stack.
+type StackRunConfig struct {
+	// Timeout is the maximum duration of each command run in the stack.
+	// Zero means no timeout.
+	Timeout       time.Duration
+	TimeoutOrigin ConfigOrigin
+
+	// Priority is the run order priority of the stack. Whenever the order
+	// constraints allow, stacks with higher priority run first.
+	Priority       int
+	PriorityOrigin ConfigOrigin
+}
+
+// LoadStackRunConfig resolves the effective run configuration of the given
+// stack. Attributes defined in the stack block have precedence over the
+// `terramate.config.run.stack_defaults` definitions, which are collected from
+// the stack dir up to the root of the project, with definitions closer to the
+// stack having precedence over parent definitions.
+func LoadStackRunConfig(root *config.Root, st *config.Stack) StackRunConfig {
+	cfg := StackRunConfig{
+		TimeoutOrigin:  ConfigOrigin{Kind: OriginDefault},
+		PriorityOrigin: ConfigOrigin{Kind: OriginDefault},
+	}
+
+	tree, ok := root.Lookup(st.Dir)
+	if !ok {
+		return cfg
+	}
+
+	hasTimeout, hasPriority := false, false
+	if stackblock := tree.Node.Stack; stackblock != nil {
+		origin := ConfigOrigin{Kind: OriginStack, Dir: tree.Dir()}
+		if stackblock.Timeout != nil {
+			cfg.Timeout, cfg.TimeoutOrigin = *stackblock.Timeout, origin
+			hasTimeout = true
+		}
+		if stackblock.Priority != nil {
+			cfg.Priority, cfg.PriorityOrigin = *stackblock.Priority, origin
+			hasPriority = true
+		}
+	}
+
+	for ; tree != nil && !(hasTimeout && hasPriority); tree = tree.Parent {
+		if !tree.Node.HasRunStackDefaults() {
+			continue
+		}
+
+		defaults := tree.Node.Terramate.Config.Run.StackDefaults
+		origin := ConfigOrigin{Kind: OriginStackDefaults, Dir: tree.Dir()}
+		if !hasTimeout && defaults.Timeout != nil {
+			cfg.Timeout, cfg.TimeoutOrigin = *defaults.Timeout, origin
+			hasTimeout = true
+		}
+		if !hasPriority && defaults.Priority != nil {
+			cfg.Priority, cfg.PriorityOrigin = *defaults.Priority, origin
+			hasPriority = true
+		}
+	}
+	return cfg
+}
+
+// String returns a human readable description of the origin.
+func (o ConfigOrigin) String() string {
+	switch o.Kind {
+	case OriginStack:
+		return fmt.Sprintf("stack block at %s", o.Dir)
+	case OriginStackDefaults:
+		return fmt.Sprintf("terramate.config.run.stack_defaults at %s", o.Dir)
+	default:
+		return "default"
+	}
+}
@@ -0,0 +1,129 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package run_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/terramate-io/terramate/config"
+	"github.com/terramate-io/terramate/project"
+	"github.com/terramate-io/terramate/run"
+	"github.com/terramate-io/terramate/test"
+	"github.com/terramate-io/terramate/test/sandbox"
+)
+
+func TestLoadStackRunConfig(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.NoGit(t, true)
+	s.BuildTree([]string{
+		`f:defaults.tm:terramate {
+		  config {
+		    run {
+		      stack_defaults {
+		        timeout  = "1h"
+		        priority = 1
+		      }
+		    }
+		  }
+		}`,
+		`f:infra/defaults.tm:terramate {
+		  config {
+		    run {
+		      stack_defaults {
+		        timeout = "30m"
+		      }
+		    }
+		  }
+		}`,
+		`f:infra/prod/defaults.tm:terramate {
+		  config {
+		    run {
+		      stack_defaults {
+		        timeout  = "10m"
+		        priority = 10
+		      }
+		    }
+		  }
+		}`,
+		"s:apps",
+		"s:infra/network",
+		"s:infra/prod/db",
+		"s:infra/prod/cache:timeout=2m;priority=20",
+		"s:infra/prod/queue:priority=-1",
+	})
+
+	root := s.Config()
+	stackDefaults := func(dir string) run.ConfigOrigin {
+		return run.ConfigOrigin{Kind: run.OriginStackDefaults, Dir: project.NewPath(dir)}
+	}
+	stackBlock := func(dir string) run.ConfigOrigin {
+		return run.ConfigOrigin{Kind: run.OriginStack, Dir: project.NewPath(dir)}
+	}
+
+	for stackdir, want := range map[string]run.StackRunConfig{
+		"/apps": {
+			Timeout:        time.Hour,
+			TimeoutOrigin:  stackDefaults("/"),
+			Priority:       1,
+			PriorityOrigin: stackDefaults("/"),
+		},
+		"/infra/network": {
+			Timeout:        30 * time.Minute,
+			TimeoutOrigin:  stackDefaults("/infra"),
+			Priority:       1,
+			PriorityOrigin: stackDefaults("/"),
+		},
+		"/infra/prod/db": {
+			Timeout:        10 * time.Minute,
+			TimeoutOrigin:  stackDefaults("/infra/prod"),
+			Priority:       10,
+			PriorityOrigin: stackDefaults("/infra/prod"),
+		},
+		"/infra/prod/cache": {
+			Timeout:        2 * time.Minute,
+			TimeoutOrigin:  stackBlock("/infra/prod/cache"),
+			Priority:       20,
+			PriorityOrigin: stackBlock("/infra/prod/cache"),
+		},
+		"/infra/prod/queue": {
+			Timeout:        10 * time.Minute,
+			TimeoutOrigin:  stackDefaults("/infra/prod"),
+			Priority:       -1,
+			PriorityOrigin: stackBlock("/infra/prod/queue"),
+		},
+	} {
+		got := run.LoadStackRunConfig(root, loadStack(t, root, stackdir))
+		test.AssertDiff(t, got, want, "stack %s", stackdir)
+	}
+}
+
+func TestLoadStackRunConfigDefaults(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.NoGit(t, true)
+	s.BuildTree([]string{"s:stack"})
+
+	root := s.Config()
+	got := run.LoadStackRunConfig(root, loadStack(t, root, "/stack"))
+	test.AssertDiff(t, got, run.StackRunConfig{
+		TimeoutOrigin:  run.ConfigOrigin{Kind: run.OriginDefault},
+		PriorityOrigin: run.ConfigOrigin{Kind: run.OriginDefault},
+	})
+}
+
+func loadStack(t *testing.T, root *config.Root, dir string) *config.Stack {
+	t.Helper()
+
+	tree, ok := root.Lookup(project.NewPath(dir))
+	if !ok {
+		t.Fatalf("stack %s not found", dir)
+	}
+	st, err := tree.Stack()
+	if err != nil {
+		t.Fatalf("loading stack %s: %v", dir, err)
+	}
+	return st
+}
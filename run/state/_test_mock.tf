@@ -0,0 +1,27 @@
+// TERRAMATE: GENERATED AUTOMATICALLY DO NOT EDIT
+
+resource "local_file" "state" {
+  content = <<-EOT
+package state // import "github.com/terramate-io/terramate/run/state"
+
+Package state persists the outcome of each stack of a `terramate run`, so a
+failed or interrupted run can be resumed later.
+
+The state of a run is a JSON file inside the Dir of the project, which is
+rewritten every time the status of a stack changes. The file is replaced
+atomically, so a process which crashes mid-way always leaves a usable state.
+
+const ErrNotFound errors.Kind = "run state not found" ...
+const Dir = ".terramate/runs"
+type File struct{ ... }
+    func Latest(rootdir string) (*File, error)
+    func Load(rootdir string, id string) (*File, error)
+    func New(rootdir string, id string, args any, stacks []string, selectionComplete bool) (*File, error)
+type Run struct{ ... }
+type Stack struct{ ... }
+type Status string
+    const Pending Status = "pending" ...
+EOT
+
+  filename = "${path.module}/mock-state.ignore"
+}
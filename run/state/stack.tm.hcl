@@ -0,0 +1,9 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+stack {
+  name        = "package state // import \"github.com/terramate-io/terramate/run/state\""
+  description = "package state // import \"github.com/terramate-io/terramate/run/state\"\n\nPackage state persists the outcome of each stack of a `terramate run`, so a\nfailed or interrupted run can be resumed later.\n\nThe state of a run is a JSON file inside the Dir of the project, which is\nrewritten every time the status of a stack changes. The file is replaced\natomically, so a process which crashes mid-way always leaves a usable state.\n\nconst ErrNotFound errors.Kind = \"run state not found\" ...\nconst Dir = \".terramate/runs\"\ntype File struct{ ... }\n    func Latest(rootdir string) (*File, error)\n    func Load(rootdir string, id string) (*File, error)\n    func New(rootdir string, id string, args any, stacks []string, selectionComplete bool) (*File, error)\ntype Run struct{ ... }\ntype Stack struct{ ... }\ntype Status string\n    const Pending Status = \"pending\" ..."
+  tags        = ["golang", "run", "state"]
+  id          = "2b123021-d31b-4fb0-aa10-2093a5fdaed2"
+}
@@ -0,0 +1,285 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+// Package state persists the outcome of each stack of a `terramate run`, so
+// a failed or interrupted run can be resumed later.
+//
+// The state of a run is a JSON file inside the [Dir] of the project, which is
+// rewritten every time the status of a stack changes. The file is replaced
+// atomically, so a process which crashes mid-way always leaves a usable state.
+package state
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/terramate-io/terramate/errors"
+)
+
+// Dir is the directory, relative to the project root, where the state of the
+// runs is stored.
+const Dir = ".terramate/runs"
+
+const (
+	// ErrNotFound indicates that the state of a run was not found.
+	ErrNotFound errors.Kind = "run state not found"
+
+	// ErrInvalid indicates that the state of a run cannot be read.
+	ErrInvalid errors.Kind = "invalid run state"
+)
+
+const gitignoreContent = "# Created by Terramate. Do not commit the state of the runs.\n*\n"
+
+// Status is the status of a stack in a run.
+type Status string
+
+// Available statuses.
+const (
+	Pending  Status = "pending"
+	Running  Status = "running"
+	Success  Status = "success"
+	Failed   Status = "failed"
+	Canceled Status = "canceled"
+)
+
+// Stack is the state of a stack in a run.
+type Stack struct {
+	Path   string `json:"path"`
+	Status Status `json:"status"`
+}
+
+// Run is the persisted state of a run.
+type Run struct {
+	ID        string    `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// Args are the flags of the run, used to run the command with the same
+	// options when it is resumed. They never include the command.
+	Args json.RawMessage `json:"args"`
+
+	// SelectionComplete tells if all the stacks of the run are known. It's
+	// false while the stacks are selected during the execution.
+	SelectionComplete bool `json:"selection_complete"`
+
+	// Stacks are the selected stacks, in the order they were selected.
+	Stacks []Stack `json:"stacks"`
+}
+
+// File is the state of a run stored on disk. It's safe for concurrent use.
+type File struct {
+	path string
+
+	mu    sync.Mutex
+	run   Run
+	index map[string]int
+}
+
+// New creates the state of a new run with the given stacks, all pending,
+// and writes it inside the rootdir. The args are stored as JSON.
+func New(rootdir string, id string, args any, stacks []string, selectionComplete bool) (*File, error) {
+	rawArgs, err := json.Marshal(args)
+	if err != nil {
+		return nil, errors.E(err, "encoding run arguments")
+	}
+
+	now := time.Now().UTC()
+	f := &File{
+		path: filePath(rootdir, id),
+		run: Run{
+			ID:                id,
+			CreatedAt:         now,
+			UpdatedAt:         now,
+			Args:              rawArgs,
+			SelectionComplete: selectionComplete,
+		},
+		index: map[string]int{},
+	}
+	for _, path := range stacks {
+		f.add(path)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.write(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Load the state of the run with the given ID.
+func Load(rootdir string, id string) (*File, error) {
+	path := filePath(rootdir, id)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, errors.E(ErrNotFound, "run %s", id)
+		}
+		return nil, errors.E(err, "reading the state of run %s", id)
+	}
+
+	f := &File{
+		path:  path,
+		index: map[string]int{},
+	}
+	if err := json.Unmarshal(data, &f.run); err != nil {
+		return nil, errors.E(ErrInvalid, err, "run %s", id)
+	}
+	if f.run.ID != id {
+		return nil, errors.E(ErrInvalid, "file %s has the state of run %s", path, f.run.ID)
+	}
+	for i, st := range f.run.Stacks {
+		f.index[st.Path] = i
+	}
+	return f, nil
+}
+
+// Latest loads the state of the most recently updated run.
+func Latest(rootdir string) (*File, error) {
+	entries, err := os.ReadDir(filepath.Join(rootdir, filepath.FromSlash(Dir)))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, errors.E(ErrNotFound, "no runs found")
+		}
+		return nil, errors.E(err, "listing the runs")
+	}
+
+	var runs []*File
+	for _, entry := range entries {
+		id, ok := strings.CutSuffix(entry.Name(), ".json")
+		if !ok || entry.IsDir() {
+			continue
+		}
+		f, err := Load(rootdir, id)
+		if err != nil {
+			return nil, err
+		}
+		runs = append(runs, f)
+	}
+	if len(runs) == 0 {
+		return nil, errors.E(ErrNotFound, "no runs found")
+	}
+
+	sort.Slice(runs, func(i, j int) bool {
+		return runs[i].run.UpdatedAt.After(runs[j].run.UpdatedAt)
+	})
+	return runs[0], nil
+}
+
+// Run returns a copy of the state of the run.
+func (f *File) Run() Run {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	run := f.run
+	run.Stacks = append([]Stack(nil), f.run.Stacks...)
+	return run
+}
+
+// Path returns the path of the state file.
+func (f *File) Path() string { return f.path }
+
+// Unfinished returns the stacks which failed or were not completed, in the
+// order they were selected.
+func (f *File) Unfinished() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var stacks []string
+	for _, st := range f.run.Stacks {
+		if st.Status != Success {
+			stacks = append(stacks, st.Path)
+		}
+	}
+	return stacks
+}
+
+// Set the status of the stack, adding it to the run if needed, and writes
+// the state. Setting the status of a nil File is a no-op.
+func (f *File) Set(path string, status Status) error {
+	if f == nil {
+		return nil
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	i, ok := f.index[path]
+	if !ok {
+		i = f.add(path)
+	}
+	f.run.Stacks[i].Status = status
+	return f.write()
+}
+
+// CompleteSelection marks the selection of the stacks as complete and writes
+// the state. Calling it on a nil File is a no-op.
+func (f *File) CompleteSelection() error {
+	if f == nil {
+		return nil
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.run.SelectionComplete = true
+	return f.write()
+}
+
+// Remove the state file.
+func (f *File) Remove() error {
+	if err := os.Remove(f.path); err != nil && !os.IsNotExist(err) {
+		return errors.E(err, "removing the state of run %s", f.run.ID)
+	}
+	return nil
+}
+
+func (f *File) add(path string) int {
+	f.run.Stacks = append(f.run.Stacks, Stack{Path: path, Status: Pending})
+	f.index[path] = len(f.run.Stacks) - 1
+	return len(f.run.Stacks) - 1
+}
+
+// write the state to the file. The caller must hold the lock.
+func (f *File) write() error {
+	f.run.UpdatedAt = time.Now().UTC()
+	data, err := json.MarshalIndent(f.run, "", "  ")
+	if err != nil {
+		return errors.E(err, "encoding the state of run %s", f.run.ID)
+	}
+
+	dir := filepath.Dir(f.path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return errors.E(err, "creating the runs directory")
+	}
+	// The runs live inside the project, so make sure git ignores them.
+	gitignore := filepath.Join(dir, ".gitignore")
+	if _, err := os.Stat(gitignore); err != nil {
+		if err := os.WriteFile(gitignore, []byte(gitignoreContent), 0644); err != nil {
+			return errors.E(err, "creating the runs .gitignore")
+		}
+	}
+
+	// The state is written to a temporary file first and then renamed, so
+	// the file is never left partially written.
+	tmpfile, err := os.CreateTemp(dir, ".run-*")
+	if err != nil {
+		return errors.E(err, "creating the state of run %s", f.run.ID)
+	}
+	_, err = tmpfile.Write(data)
+	closeErr := tmpfile.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmpfile.Name(), f.path)
+	}
+	if err != nil {
+		_ = os.Remove(tmpfile.Name())
+		return errors.E(err, "writing the state of run %s", f.run.ID)
+	}
+	return nil
+}
+
+func filePath(rootdir string, id string) string {
+	return filepath.Join(rootdir, filepath.FromSlash(Dir), id+".json")
+}
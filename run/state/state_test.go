@@ -0,0 +1,179 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package state_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/madlambda/spells/assert"
+	"github.com/terramate-io/terramate/errors"
+	"github.com/terramate-io/terramate/run/state"
+	"github.com/terramate-io/terramate/test"
+)
+
+type args struct {
+	Command []string `json:"command"`
+	Reverse bool     `json:"reverse"`
+}
+
+func TestStateNewLoad(t *testing.T) {
+	t.Parallel()
+
+	rootdir := test.TempDir(t)
+	f, err := state.New(rootdir, "run1", args{Command: []string{"echo"}, Reverse: true},
+		[]string{"/a", "/b", "/c"}, true)
+	assert.NoError(t, err)
+
+	assert.NoError(t, f.Set("/a", state.Success))
+	assert.NoError(t, f.Set("/b", state.Failed))
+	assert.NoError(t, f.Set("/d", state.Running))
+
+	got, err := state.Load(rootdir, "run1")
+	assert.NoError(t, err)
+	assert.EqualStrings(t, f.Path(), got.Path())
+
+	run := got.Run()
+	assert.EqualStrings(t, "run1", run.ID)
+	assert.IsTrue(t, run.SelectionComplete)
+	var gotArgs args
+	assert.NoError(t, json.Unmarshal(run.Args, &gotArgs))
+	assertStrings(t, []string{"echo"}, gotArgs.Command)
+	assert.IsTrue(t, gotArgs.Reverse, "args must be preserved")
+	assertStacks(t, run.Stacks, []state.Stack{
+		{Path: "/a", Status: state.Success},
+		{Path: "/b", Status: state.Failed},
+		{Path: "/c", Status: state.Pending},
+		{Path: "/d", Status: state.Running},
+	})
+	assertStrings(t, []string{"/b", "/c", "/d"}, got.Unfinished())
+
+	gitignore, err := os.ReadFile(filepath.Join(rootdir, filepath.FromSlash(state.Dir), ".gitignore"))
+	assert.NoError(t, err)
+	assert.IsTrue(t, len(gitignore) > 0, "runs .gitignore must not be empty")
+
+	assert.NoError(t, got.Remove())
+	_, err = state.Load(rootdir, "run1")
+	assert.IsTrue(t, errors.IsKind(err, state.ErrNotFound), "got %v", err)
+}
+
+func TestStateCompleteSelection(t *testing.T) {
+	t.Parallel()
+
+	rootdir := test.TempDir(t)
+	f, err := state.New(rootdir, "run", args{}, nil, false)
+	assert.NoError(t, err)
+	assert.NoError(t, f.Set("/a", state.Pending))
+
+	got, err := state.Load(rootdir, "run")
+	assert.NoError(t, err)
+	assert.IsTrue(t, !got.Run().SelectionComplete, "selection must be incomplete")
+
+	assert.NoError(t, f.CompleteSelection())
+	got, err = state.Load(rootdir, "run")
+	assert.NoError(t, err)
+	assert.IsTrue(t, got.Run().SelectionComplete, "selection must be complete")
+	assertStrings(t, []string{"/a"}, got.Unfinished())
+}
+
+func TestStateNilFile(t *testing.T) {
+	t.Parallel()
+
+	var f *state.File
+	assert.NoError(t, f.Set("/a", state.Success))
+	assert.NoError(t, f.CompleteSelection())
+}
+
+func TestStateLatest(t *testing.T) {
+	t.Parallel()
+
+	rootdir := test.TempDir(t)
+	_, err := state.Latest(rootdir)
+	assert.IsTrue(t, errors.IsKind(err, state.ErrNotFound), "got %v", err)
+
+	first, err := state.New(rootdir, "first", args{}, []string{"/a"}, true)
+	assert.NoError(t, err)
+	time.Sleep(10 * time.Millisecond)
+	_, err = state.New(rootdir, "second", args{}, []string{"/a"}, true)
+	assert.NoError(t, err)
+
+	latest, err := state.Latest(rootdir)
+	assert.NoError(t, err)
+	assert.EqualStrings(t, "second", latest.Run().ID)
+
+	// updating a run makes it the latest one.
+	time.Sleep(10 * time.Millisecond)
+	assert.NoError(t, first.Set("/a", state.Failed))
+
+	latest, err = state.Latest(rootdir)
+	assert.NoError(t, err)
+	assert.EqualStrings(t, "first", latest.Run().ID)
+}
+
+func TestStateInvalid(t *testing.T) {
+	t.Parallel()
+
+	rootdir := test.TempDir(t)
+	dir := filepath.Join(rootdir, filepath.FromSlash(state.Dir))
+	test.WriteFile(t, dir, "broken.json", "{")
+	test.WriteFile(t, dir, "other.json", `{"id": "another"}`)
+
+	_, err := state.Load(rootdir, "broken")
+	assert.IsTrue(t, errors.IsKind(err, state.ErrInvalid), "got %v", err)
+
+	_, err = state.Load(rootdir, "other")
+	assert.IsTrue(t, errors.IsKind(err, state.ErrInvalid), "got %v", err)
+}
+
+func TestStateConcurrentSet(t *testing.T) {
+	t.Parallel()
+
+	const nstacks = 50
+
+	rootdir := test.TempDir(t)
+	var stacks []string
+	for i := 0; i < nstacks; i++ {
+		stacks = append(stacks, "/s"+strconv.Itoa(i))
+	}
+	f, err := state.New(rootdir, "run", args{}, stacks, true)
+	assert.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for _, path := range stacks {
+		wg.Add(1)
+		go func(path string) {
+			defer wg.Done()
+			assert.NoError(t, f.Set(path, state.Running))
+			assert.NoError(t, f.Set(path, state.Success))
+		}(path)
+	}
+	wg.Wait()
+
+	got, err := state.Load(rootdir, "run")
+	assert.NoError(t, err)
+	assertStrings(t, nil, got.Unfinished())
+	assert.EqualInts(t, nstacks, len(got.Run().Stacks))
+}
+
+func assertStacks(t *testing.T, got, want []state.Stack) {
+	t.Helper()
+	assert.EqualInts(t, len(want), len(got), "stacks: %v", got)
+	for i := range want {
+		assert.EqualStrings(t, want[i].Path, got[i].Path)
+		assert.EqualStrings(t, string(want[i].Status), string(got[i].Status), "stack %s", want[i].Path)
+	}
+}
+
+func assertStrings(t *testing.T, want, got []string) {
+	t.Helper()
+	assert.EqualInts(t, len(want), len(got), "got %v", got)
+	for i := range want {
+		assert.EqualStrings(t, want[i], got[i])
+	}
+}
@@ -0,0 +1,93 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package run
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"github.com/terramate-io/terramate/errors"
+	"github.com/terramate-io/tfjson"
+)
+
+// ErrTerraformTarget indicates that the resources changed by a plan cannot be
+// targeted in a command.
+const ErrTerraformTarget errors.Kind = "cannot target changed resources"
+
+// TerraformSubcommand returns the subcommand of a terraform or tofu command and
+// its index in args, skipping the global options. It returns false if the
+// command is not terraform or tofu or if it has no subcommand.
+func TerraformSubcommand(args []string) (string, int, bool) {
+	if _, ok := terraformProgram(args); !ok {
+		return "", 0, false
+	}
+	for i, arg := range args[1:] {
+		if !strings.HasPrefix(arg, "-") {
+			return arg, i + 1, true
+		}
+	}
+	return "", 0, false
+}
+
+// WithTargets returns a copy of the terraform or tofu command given by args
+// with a -target=<address> option for each of the given addresses, placed right
+// after the subcommand. Only the plan and apply subcommands are supported.
+func WithTargets(args []string, addresses []string) ([]string, error) {
+	if err := CheckTargetable(args); err != nil {
+		return nil, err
+	}
+	_, idx, _ := TerraformSubcommand(args)
+
+	newargs := make([]string, 0, len(args)+len(addresses))
+	newargs = append(newargs, args[:idx+1]...)
+	for _, addr := range addresses {
+		newargs = append(newargs, "-target="+addr)
+	}
+	newargs = append(newargs, args[idx+1:]...)
+	return newargs, nil
+}
+
+// CheckTargetable checks that args is a terraform or tofu plan or apply
+// command, which supports the -target option.
+func CheckTargetable(args []string) error {
+	subcmd, _, ok := TerraformSubcommand(args)
+	if !ok {
+		return errors.E(ErrTerraformTarget,
+			"command %q is not a terraform or tofu command", strings.Join(args, " "))
+	}
+	if subcmd != "plan" && subcmd != "apply" {
+		return errors.E(ErrTerraformTarget,
+			"command %q must be a plan or apply, got %s", strings.Join(args, " "), subcmd)
+	}
+	return nil
+}
+
+// ChangedResources returns the sorted addresses of the managed resources
+// changed by the given terraform or tofu JSON plan. Resources without planned
+// actions are ignored.
+func ChangedResources(planJSON string) ([]string, error) {
+	var plan tfjson.Plan
+	if err := json.Unmarshal([]byte(planJSON), &plan); err != nil {
+		return nil, errors.E(err, "unmarshaling JSON plan")
+	}
+
+	seen := map[string]struct{}{}
+	var addresses []string
+	for _, rc := range plan.ResourceChanges {
+		if rc == nil || rc.Address == "" || rc.Mode != tfjson.ManagedResourceMode {
+			continue
+		}
+		if rc.Change == nil || rc.Change.Actions.NoOp() || rc.Change.Actions.Read() {
+			continue
+		}
+		if _, ok := seen[rc.Address]; ok {
+			continue
+		}
+		seen[rc.Address] = struct{}{}
+		addresses = append(addresses, rc.Address)
+	}
+	sort.Strings(addresses)
+	return addresses, nil
+}
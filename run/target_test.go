@@ -0,0 +1,93 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package run_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/madlambda/spells/assert"
+	"github.com/terramate-io/terramate/errors"
+	"github.com/terramate-io/terramate/run"
+	errtest "github.com/terramate-io/terramate/test/errors"
+)
+
+func TestWithTargets(t *testing.T) {
+	t.Parallel()
+
+	type testcase struct {
+		args    []string
+		want    []string
+		wantErr error
+	}
+
+	targets := []string{"a.b", "module.m.c.d[0]"}
+	for _, tc := range []testcase{
+		{
+			args: []string{"terraform", "plan"},
+			want: []string{"terraform", "plan", "-target=a.b", "-target=module.m.c.d[0]"},
+		},
+		{
+			args: []string{"tofu", "apply", "-auto-approve"},
+			want: []string{"tofu", "apply", "-target=a.b", "-target=module.m.c.d[0]", "-auto-approve"},
+		},
+		{
+			args: []string{"terraform", "-chdir=dir", "plan", "-out=plan.tfplan"},
+			want: []string{"terraform", "-chdir=dir", "plan", "-target=a.b", "-target=module.m.c.d[0]", "-out=plan.tfplan"},
+		},
+		{
+			args: []string{"/usr/bin/terraform", "plan"},
+			want: []string{"/usr/bin/terraform", "plan", "-target=a.b", "-target=module.m.c.d[0]"},
+		},
+		{
+			args:    []string{"terraform", "init"},
+			wantErr: errors.E(run.ErrTerraformTarget),
+		},
+		{
+			args:    []string{"terraform", "-chdir=dir"},
+			wantErr: errors.E(run.ErrTerraformTarget),
+		},
+		{
+			args:    []string{"terragrunt", "plan"},
+			wantErr: errors.E(run.ErrTerraformTarget),
+		},
+		{
+			args:    nil,
+			wantErr: errors.E(run.ErrTerraformTarget),
+		},
+	} {
+		got, err := run.WithTargets(tc.args, targets)
+		errtest.Assert(t, err, tc.wantErr, "args %v", tc.args)
+		if diff := cmp.Diff(tc.want, got); diff != "" {
+			t.Errorf("args %v: -(want) +(got):\n%s", tc.args, diff)
+		}
+	}
+}
+
+func TestChangedResources(t *testing.T) {
+	t.Parallel()
+
+	got, err := run.ChangedResources(`{
+	  "format_version": "1.2",
+	  "resource_changes": [
+	    {"address": "b.b", "mode": "managed", "change": {"actions": ["create"]}},
+	    {"address": "a.a", "mode": "managed", "change": {"actions": ["delete", "create"]}},
+	    {"address": "c.c", "mode": "managed", "change": {"actions": ["no-op"]}},
+	    {"address": "data.d.d", "mode": "data", "change": {"actions": ["read"]}},
+	    {"address": "module.m.e.e", "mode": "managed", "change": {"actions": ["update"]}},
+	    {"address": "b.b", "mode": "managed", "change": {"actions": ["create"]}}
+	  ]
+	}`)
+	assert.NoError(t, err)
+	if diff := cmp.Diff([]string{"a.a", "b.b", "module.m.e.e"}, got); diff != "" {
+		t.Fatalf("-(want) +(got):\n%s", diff)
+	}
+
+	got, err = run.ChangedResources(`{"format_version": "1.2"}`)
+	assert.NoError(t, err)
+	assert.EqualInts(t, 0, len(got))
+
+	_, err = run.ChangedResources(`not json`)
+	assert.Error(t, err)
+}
@@ -0,0 +1,135 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package run
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/terramate-io/terramate/config"
+	"github.com/terramate-io/terramate/errors"
+	"github.com/terramate-io/terramate/tf"
+	"github.com/terramate-io/terramate/versions"
+)
+
+// ErrTerraformVersion indicates that the terraform (or tofu) binary does not
+// satisfy the required_version constraint of a stack.
+const ErrTerraformVersion errors.Kind = "terraform version not supported by stack"
+
+// TerraformVersionChecker checks the version of the terraform and tofu
+// binaries against the required_version constraint of the stacks.
+// The version of each binary is detected only once.
+type TerraformVersionChecker struct {
+	versions *OnceMap[string, string]
+}
+
+// NewTerraformVersionChecker creates a new TerraformVersionChecker.
+func NewTerraformVersionChecker() *TerraformVersionChecker {
+	return &TerraformVersionChecker{
+		versions: NewOnceMap[string, string](),
+	}
+}
+
+// Check checks if the binary at cmdPath satisfies the required_version of the
+// stack. The args are the command being run in the stack and the check is
+// skipped if it is not a terraform or tofu command or if the stack has no
+// required_version constraint.
+func (c *TerraformVersionChecker) Check(
+	root *config.Root,
+	st *config.Stack,
+	args []string,
+	cmdPath string,
+	environ []string,
+) error {
+	program, ok := terraformProgram(args)
+	if !ok {
+		return nil
+	}
+
+	dir, ok, err := TerraformDir(root, st, args)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		dir = st.Dir
+	}
+
+	constraint, err := requiredVersion(dir.HostPath(root.HostDir()))
+	if err != nil {
+		return errors.E(err, "stack %s: reading terraform required_version", st.Dir)
+	}
+	if constraint == "" {
+		return nil
+	}
+
+	version, err := c.versions.GetOrInit(cmdPath, func() (string, error) {
+		return detectTerraformVersion(cmdPath, environ)
+	})
+	if err != nil {
+		return errors.E(err, "stack %s: detecting %s version", st.Dir, program)
+	}
+
+	match, err := versions.Match(version, constraint, false)
+	if err != nil {
+		return errors.E(ErrTerraformVersion, err,
+			"stack %s: checking %s version %s", st.Dir, program, version)
+	}
+	if !match {
+		return errors.E(ErrTerraformVersion,
+			"stack %s requires %s version %q but %s is version %s",
+			st.Dir, program, constraint, cmdPath, version)
+	}
+	return nil
+}
+
+// requiredVersion returns the required_version constraints of the Terraform
+// and OpenTofu files in the directory, combined into a single constraint.
+func requiredVersion(hostdir string) (string, error) {
+	entries, err := os.ReadDir(hostdir)
+	if err != nil {
+		return "", errors.E(err, "listing files of %s", hostdir)
+	}
+
+	var constraints []string
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() || !tf.IsTerraformFile(entry.Name()) {
+			continue
+		}
+		found, err := tf.ParseRequiredVersions(filepath.Join(hostdir, entry.Name()))
+		if err != nil {
+			return "", err
+		}
+		constraints = append(constraints, found...)
+	}
+	return strings.Join(constraints, ", "), nil
+}
+
+// detectTerraformVersion runs `<cmdPath> version -json` and returns the
+// reported version. Old versions not supporting -json are handled by parsing
+// the first line of the output (eg.: "Terraform v1.5.0" or "OpenTofu v1.6.0").
+func detectTerraformVersion(cmdPath string, environ []string) (string, error) {
+	cmd := exec.Command(cmdPath, "version", "-json")
+	cmd.Env = environ
+	out, err := cmd.Output()
+	if err != nil {
+		return "", errors.E(err, "running %s version -json", cmdPath)
+	}
+
+	var info struct {
+		Version string `json:"terraform_version"`
+	}
+	if err := json.Unmarshal(out, &info); err == nil && info.Version != "" {
+		return info.Version, nil
+	}
+
+	line, _, _ := strings.Cut(string(out), "\n")
+	fields := strings.Fields(line)
+	if len(fields) == 2 && strings.HasPrefix(fields[1], "v") {
+		return strings.TrimPrefix(fields[1], "v"), nil
+	}
+	return "", errors.E("unexpected output of %s version -json: %s", cmdPath, out)
+}
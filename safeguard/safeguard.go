@@ -13,13 +13,14 @@ type Keywords []Keyword
 
 // Available keywords.
 const (
-	All            Keyword = "all"
-	None           Keyword = "none"
-	Git            Keyword = "git"
-	GitUntracked   Keyword = "git-untracked"
-	GitUncommitted Keyword = "git-uncommitted"
-	GitOutOfSync   Keyword = "git-out-of-sync"
-	Outdated       Keyword = "outdated-code"
+	All                 Keyword = "all"
+	None                Keyword = "none"
+	Git                 Keyword = "git"
+	GitUntracked        Keyword = "git-untracked"
+	GitUncommitted      Keyword = "git-uncommitted"
+	GitOutOfSync        Keyword = "git-out-of-sync"
+	GitBranchProtection Keyword = "git-branch-protection"
+	Outdated            Keyword = "outdated-code"
 )
 
 // FromStrings constructs a Keywords list out of a list of strings.
@@ -60,13 +61,14 @@ func (ks Keywords) Has(target ...Keyword) bool {
 // IsValid checks if k is valid.
 func (k Keyword) IsValid() bool {
 	valid := map[Keyword]bool{
-		All:            true,
-		None:           true,
-		Git:            true,
-		GitUntracked:   true,
-		GitUncommitted: true,
-		GitOutOfSync:   true,
-		Outdated:       true,
+		All:                 true,
+		None:                true,
+		Git:                 true,
+		GitUntracked:        true,
+		GitUncommitted:      true,
+		GitOutOfSync:        true,
+		GitBranchProtection: true,
+		Outdated:            true,
 	}
 	return valid[k]
 }
@@ -0,0 +1,12 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package scheduler
+
+import "github.com/terramate-io/terramate/run/dag"
+
+// SetOnNodeStart sets a function called when each node is started, in the
+// start order of the scheduler.
+func SetOnNodeStart[V any](s *Parallel[V], f func(id dag.ID)) {
+	s.onNodeStart = f
+}
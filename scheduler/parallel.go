@@ -4,8 +4,8 @@
 package scheduler
 
 import (
+	"sort"
 	"sync"
-	"sync/atomic"
 
 	"github.com/terramate-io/terramate/errors"
 	"github.com/terramate-io/terramate/run/dag"
@@ -17,6 +17,18 @@ type Parallel[V any] struct {
 	d  *dag.DAG[V]
 
 	state map[dag.ID]*parallelNodeState
+	nodes []*parallelNodeState
+
+	// limit is the maximum number of nodes running at the same time.
+	limit int
+
+	// mtx guards the scheduling state below. The ready nodes are kept in the
+	// DAG tie-break order, so whenever a node can be started the first ready
+	// one is picked.
+	mtx         sync.Mutex
+	ready       readyQueue
+	running     int
+	onNodeStart func(id dag.ID)
 
 	errsMtx sync.Mutex
 	errs    errors.List
@@ -30,25 +42,29 @@ func NewParallel[V any](d *dag.DAG[V], reverse bool) *Parallel[V] {
 		state: map[dag.ID]*parallelNodeState{},
 	}
 
-	ids := d.IDs()
+	// The nodes are kept in the tie-break order of the DAG, so the successors
+	// and the root nodes are visited in a stable order.
+	ids := d.SortIDs(d.IDs())
 
 	// Pass 1 - Create node state
-	for _, id := range ids {
-		s.state[id] = &parallelNodeState{id: id}
+	for i, id := range ids {
+		st := &parallelNodeState{id: id, order: i}
+		s.state[id] = st
+		s.nodes = append(s.nodes, st)
 	}
 
 	// Pass 2 - Add forward edges
 	if !reverse {
-		for id, st := range s.state {
-			for _, pid := range d.AncestorsOf(id) {
+		for _, st := range s.nodes {
+			for _, pid := range d.AncestorsOf(st.id) {
 				pst := s.state[pid]
 				pst.successors = append(pst.successors, st)
 				st.nRequiredPredecessors++
 			}
 		}
 	} else {
-		for id, st := range s.state {
-			for _, pid := range d.AncestorsOf(id) {
+		for _, st := range s.nodes {
+			for _, pid := range d.SortIDs(d.AncestorsOf(st.id)) {
 				pst := s.state[pid]
 				st.successors = append(st.successors, pst)
 				pst.nRequiredPredecessors++
@@ -59,49 +75,121 @@ func NewParallel[V any](d *dag.DAG[V], reverse bool) *Parallel[V] {
 	return s
 }
 
+// SetLimit sets the maximum number of nodes running at the same time.
+// When the limit is reached, the ready nodes wait and the first of them in the
+// tie-break order of the DAG is started as soon as a running node is done.
+// A limit lower than 1 means no limit, which is the default.
+func (s *Parallel[V]) SetLimit(limit int) {
+	s.limit = limit
+}
+
 // Run executes the given function on each node of the DAG.
 // Nodes are run in parallel, but no node is visted until all its precessors are done.
+// Nodes which are ready at the same time are started in the tie-break order of
+// the DAG, see [dag.DAG.SortIDs].
 func (s *Parallel[V]) Run(f Func[V]) error {
-	for _, st := range s.state {
+	s.mtx.Lock()
+	for _, st := range s.nodes {
 		// Start at root nodes (nodes without any predecessors).
 		if st.nRequiredPredecessors == 0 {
-			s.visitNode(st, f)
+			s.ready.push(st)
 		}
 	}
+	s.dispatch(f)
+	s.mtx.Unlock()
 
 	s.wg.Wait()
 	return s.errs.AsError()
 }
 
+// Order returns the order in which the nodes are started when each node is
+// done before the next one is started. It's the preference order used by
+// [Parallel.Run] whenever more nodes are ready than can be started.
+func (s *Parallel[V]) Order() []dag.ID {
+	nReady := map[dag.ID]int64{}
+	var ready readyQueue
+	for _, st := range s.nodes {
+		if st.nRequiredPredecessors == 0 {
+			ready.push(st)
+		}
+	}
+
+	order := make([]dag.ID, 0, len(s.nodes))
+	for len(ready) > 0 {
+		st := ready.pop()
+		order = append(order, st.id)
+		for _, succ := range st.successors {
+			nReady[succ.id]++
+			if nReady[succ.id] >= succ.nRequiredPredecessors {
+				ready.push(succ)
+			}
+		}
+	}
+	return order
+}
+
 type parallelNodeState struct {
 	id                    dag.ID
+	order                 int
 	successors            []*parallelNodeState
-	nReadyPredecessors    atomic.Int64
+	nReadyPredecessors    int64
 	nRequiredPredecessors int64
 }
 
-func (s *Parallel[V]) visitNode(st *parallelNodeState, f Func[V]) {
-	s.forkTask(func() {
-		v, _ := s.d.Node(st.id)
-
-		if err := f(v); err != nil {
-			// We collect the errors, but continue. It's up to the caller to cancel the context and skip
-			// the function body.
-			s.errsMtx.Lock()
-			defer s.errsMtx.Unlock()
-			s.errs.Append(err)
+// readyQueue holds the nodes ready to be started in the tie-break order.
+type readyQueue []*parallelNodeState
+
+func (q *readyQueue) push(st *parallelNodeState) {
+	i := sort.Search(len(*q), func(i int) bool { return (*q)[i].order > st.order })
+	*q = append(*q, nil)
+	copy((*q)[i+1:], (*q)[i:])
+	(*q)[i] = st
+}
+
+func (q *readyQueue) pop() *parallelNodeState {
+	st := (*q)[0]
+	*q = (*q)[1:]
+	return st
+}
+
+// dispatch starts the ready nodes while the limit allows.
+// Must be called with s.mtx held.
+func (s *Parallel[V]) dispatch(f Func[V]) {
+	for len(s.ready) > 0 && (s.limit < 1 || s.running < s.limit) {
+		st := s.ready.pop()
+		s.running++
+		if s.onNodeStart != nil {
+			s.onNodeStart(st.id)
 		}
+		s.forkTask(func() {
+			s.visitNode(st, f)
+		})
+	}
+}
 
-		st.nReadyPredecessors.Store(0)
+func (s *Parallel[V]) visitNode(st *parallelNodeState, f Func[V]) {
+	v, _ := s.d.Node(st.id)
+
+	if err := f(v); err != nil {
+		// We collect the errors, but continue. It's up to the caller to cancel the context and skip
+		// the function body.
+		s.errsMtx.Lock()
+		s.errs.Append(err)
+		s.errsMtx.Unlock()
+	}
 
-		for _, succ := range st.successors {
-			// Join paths
-			c := succ.nReadyPredecessors.Add(1)
-			if c >= succ.nRequiredPredecessors {
-				s.visitNode(succ, f)
-			}
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	s.running--
+	for _, succ := range st.successors {
+		// Join paths
+		succ.nReadyPredecessors++
+		if succ.nReadyPredecessors >= succ.nRequiredPredecessors {
+			s.ready.push(succ)
 		}
-	})
+	}
+	s.dispatch(f)
 }
 
 func (s *Parallel[V]) forkTask(body func()) {
@@ -6,7 +6,11 @@ package scheduler_test
 import (
 	"context"
 	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/madlambda/spells/assert"
 	"github.com/terramate-io/terramate/run/dag"
@@ -46,6 +50,191 @@ func TestSimpleParallel(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestParallelStartOrderIsDeterministic(t *testing.T) {
+	t.Parallel()
+
+	type testcase struct {
+		name       string
+		nodes      map[string][]dag.ID
+		priorities map[dag.ID]int
+		reverse    bool
+		want       []dag.ID
+	}
+
+	for _, tc := range []testcase{
+		{
+			name: "independent nodes start lexicographic",
+			nodes: map[string][]dag.ID{
+				"c": nil, "a/z": nil, "b": nil, "a": nil, "a/b": nil, "d": nil, "e": nil,
+			},
+			want: []dag.ID{"a", "a/b", "a/z", "b", "c", "d", "e"},
+		},
+		{
+			name: "independent nodes start by priority",
+			nodes: map[string][]dag.ID{
+				"c": nil, "b": nil, "a": nil, "d": nil,
+			},
+			priorities: map[dag.ID]int{"d": 2, "c": 1},
+			want:       []dag.ID{"d", "c", "a", "b"},
+		},
+		{
+			name: "successors ready together start lexicographic",
+			nodes: map[string][]dag.ID{
+				"root": nil,
+				"x":    {"root"},
+				"c":    {"root"},
+				"a":    {"root"},
+				"m":    {"root"},
+				"b":    {"root"},
+			},
+			want: []dag.ID{"root", "a", "b", "c", "m", "x"},
+		},
+		{
+			name: "reverse successors ready together start lexicographic",
+			nodes: map[string][]dag.ID{
+				"z": {"x", "c", "a", "m", "b"},
+				"x": nil, "c": nil, "a": nil, "m": nil, "b": nil,
+			},
+			reverse: true,
+			want:    []dag.ID{"z", "a", "b", "c", "m", "x"},
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			for i := 0; i < 200; i++ {
+				d := dag.New[string]()
+				for id, ancestors := range tc.nodes {
+					assert.NoError(t, d.AddNode(dag.ID(id), id, nil, ancestors))
+				}
+				d.SetPriorities(tc.priorities)
+
+				var (
+					mu      sync.Mutex
+					started []dag.ID
+				)
+				g := scheduler.NewParallel(d, tc.reverse)
+				scheduler.SetOnNodeStart(g, func(id dag.ID) {
+					mu.Lock()
+					defer mu.Unlock()
+					started = append(started, id)
+				})
+				assert.NoError(t, g.Run(func(string) error { return nil }))
+				assert.EqualInts(t, len(tc.want), len(started))
+				for j := range tc.want {
+					assert.EqualStrings(t, string(tc.want[j]), string(started[j]),
+						"run %d: start order %v", i, started)
+				}
+			}
+		})
+	}
+}
+
+func TestParallelLimitStartsHigherPriorityReadyNodesFirst(t *testing.T) {
+	t.Parallel()
+
+	type testcase struct {
+		name       string
+		nodes      map[string][]dag.ID
+		priorities map[dag.ID]int
+		reverse    bool
+		want       []dag.ID
+	}
+
+	for _, tc := range []testcase{
+		{
+			name: "equal priorities start lexicographic",
+			nodes: map[string][]dag.ID{
+				"c": nil, "b": {"a"}, "a": nil, "d": {"b"},
+			},
+			want: []dag.ID{"a", "b", "c", "d"},
+		},
+		{
+			name: "node ready later starts before waiting lower priority nodes",
+			nodes: map[string][]dag.ID{
+				"a": nil, "b": nil, "c": nil, "x": {"a"},
+			},
+			priorities: map[dag.ID]int{"x": 5},
+			want:       []dag.ID{"a", "x", "b", "c"},
+		},
+		{
+			name: "priority never violates the order",
+			nodes: map[string][]dag.ID{
+				"a": nil, "b": {"a"}, "big": {"b"}, "c": nil,
+			},
+			priorities: map[dag.ID]int{"big": 10, "c": 1},
+			want:       []dag.ID{"c", "a", "b", "big"},
+		},
+		{
+			name: "reverse order with priorities",
+			nodes: map[string][]dag.ID{
+				"z": {"a", "b"}, "a": nil, "b": nil, "y": nil,
+			},
+			priorities: map[dag.ID]int{"b": 3},
+			reverse:    true,
+			want:       []dag.ID{"y", "z", "b", "a"},
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			for i := 0; i < 100; i++ {
+				d := dag.New[string]()
+				for id, ancestors := range tc.nodes {
+					assert.NoError(t, d.AddNode(dag.ID(id), id, nil, ancestors))
+				}
+				d.SetPriorities(tc.priorities)
+
+				var started []dag.ID
+				g := scheduler.NewParallel(d, tc.reverse)
+				g.SetLimit(1)
+				scheduler.SetOnNodeStart(g, func(id dag.ID) {
+					started = append(started, id)
+				})
+
+				order := g.Order()
+				assert.NoError(t, g.Run(func(string) error { return nil }))
+				assert.EqualInts(t, len(tc.want), len(started))
+				assert.EqualInts(t, len(tc.want), len(order))
+				for j := range tc.want {
+					assert.EqualStrings(t, string(tc.want[j]), string(started[j]),
+						"run %d: start order %v", i, started)
+					assert.EqualStrings(t, string(tc.want[j]), string(order[j]),
+						"order %v", order)
+				}
+			}
+		})
+	}
+}
+
+func TestParallelLimitIsRespected(t *testing.T) {
+	t.Parallel()
+
+	const limit = 3
+
+	g := scheduler.NewParallel(makeGridDAG(), false)
+	g.SetLimit(limit)
+
+	var running, maxRunning atomic.Int64
+	err := g.Run(func(gridNode) error {
+		n := running.Add(1)
+		defer running.Add(-1)
+		for {
+			cur := maxRunning.Load()
+			if n <= cur || maxRunning.CompareAndSwap(cur, n) {
+				break
+			}
+		}
+		time.Sleep(time.Millisecond)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.IsTrue(t, maxRunning.Load() <= limit, "ran %d nodes at once", maxRunning.Load())
+	assert.EqualInts(t, limit, int(maxRunning.Load()))
+}
+
 func makeDAG() *dag.DAG[string] {
 	d := dag.New[string]()
 
@@ -165,3 +354,213 @@ func TestParallelGrid(t *testing.T) {
 	assert.NoError(t, err)
 	assert.EqualInts(t, 272271, ndarr[99], "invalid result")
 }
+
+func TestShuffledGrid(t *testing.T) {
+	t.Parallel()
+
+	compute := func(ndarr []int) func(nd gridNode) error {
+		var mu sync.Mutex
+		return func(nd gridNode) error {
+			mu.Lock()
+			defer mu.Unlock()
+
+			v := 1
+			if nd.aIdx != -1 {
+				v += ndarr[nd.aIdx]
+			}
+			if nd.bIdx != -1 {
+				v += ndarr[nd.bIdx]
+			}
+			ndarr[nd.idx] = v
+			return nil
+		}
+	}
+
+	for seed := int64(1); seed <= 5; seed++ {
+		d := makeGridDAG()
+		d.Shuffle(seed)
+
+		var visited []int
+		ndarr := make([]int, 10*10)
+		f := compute(ndarr)
+		err := scheduler.NewSequential(d, false).Run(func(nd gridNode) error {
+			visited = append(visited, nd.idx)
+			return f(nd)
+		})
+		assert.NoError(t, err)
+		assert.EqualInts(t, 272271, ndarr[99], "seed %d: invalid sequential result", seed)
+
+		// the sequential scheduler follows the shuffled order of the DAG,
+		// which is reproducible with the same seed.
+		other := makeGridDAG()
+		other.Shuffle(seed)
+		order := other.Order()
+		assert.EqualInts(t, len(order), len(visited), "seed %d: visited length mismatch", seed)
+		for i, id := range order {
+			nd, _ := other.Node(id)
+			assert.EqualInts(t, nd.idx, visited[i], "seed %d: node %d mismatch", seed, i)
+		}
+
+		ndarr = make([]int, 10*10)
+		parallel := scheduler.NewParallel(d, false)
+		parallel.SetLimit(3)
+		assert.NoError(t, parallel.Run(compute(ndarr)))
+		assert.EqualInts(t, 272271, ndarr[99], "seed %d: invalid parallel result", seed)
+	}
+}
+
+func TestStreamingGrid(t *testing.T) {
+	t.Parallel()
+
+	for _, parallel := range []bool{false, true} {
+		d := makeGridDAG()
+		ndarr := make([]int, 10*10)
+
+		g := scheduler.NewStreaming(d, false, parallel)
+		go func() {
+			for _, id := range g.Order() {
+				g.Select(id)
+			}
+		}()
+
+		err := g.Run(func(nd gridNode) error {
+			v := 1
+
+			if nd.aIdx != -1 {
+				v += ndarr[nd.aIdx]
+			}
+
+			if nd.bIdx != -1 {
+				v += ndarr[nd.bIdx]
+			}
+
+			ndarr[nd.idx] = v
+			return nil
+		})
+
+		assert.NoError(t, err)
+		assert.EqualInts(t, 272271, ndarr[99], "invalid result (parallel=%t)", parallel)
+	}
+}
+
+func TestStreamingSkippedNodesKeepOrder(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		parallel bool
+		reverse  bool
+	}{
+		{false, false},
+		{false, true},
+		{true, false},
+		{true, true},
+	} {
+		for i := 0; i < 50; i++ {
+			// a -> b -> c, where b is not selected, so c must still run after a.
+			d := dag.New[string]()
+			assert.NoError(t, d.AddNode("a", "a", nil, nil))
+			assert.NoError(t, d.AddNode("b", "b", nil, []dag.ID{"a"}))
+			assert.NoError(t, d.AddNode("c", "c", nil, []dag.ID{"b"}))
+			assert.NoError(t, d.AddNode("x", "x", nil, nil))
+
+			g := scheduler.NewStreaming(d, tc.reverse, tc.parallel)
+
+			var (
+				mu      sync.Mutex
+				visited []string
+			)
+			done := make(chan error)
+			go func() {
+				done <- g.Run(func(s string) error {
+					mu.Lock()
+					defer mu.Unlock()
+					visited = append(visited, s)
+					return nil
+				})
+			}()
+
+			// resolve out of order, with c selected before a is resolved.
+			g.Select("c")
+			g.Skip("b")
+			g.Select("a")
+			g.Close()
+
+			assert.NoError(t, <-done)
+			assert.EqualInts(t, 2, len(visited), "visited: %v", visited)
+
+			want := []string{"a", "c"}
+			if tc.reverse {
+				want = []string{"c", "a"}
+			}
+			assert.EqualStrings(t, strings.Join(want, ","), strings.Join(visited, ","),
+				"parallel=%t reverse=%t", tc.parallel, tc.reverse)
+		}
+	}
+}
+
+// BenchmarkStreamingTimeToFirstNode compares the time to visit the first node
+// when all the nodes are resolved before scheduling, like `terramate run
+// --changed`, and when the nodes are resolved while scheduling, like
+// `terramate run --changed --stream-selection`. The resolution of each node
+// simulates the change detection of a stack.
+func BenchmarkStreamingTimeToFirstNode(b *testing.B) {
+	const (
+		nodes       = 200
+		resolveCost = 20 * time.Microsecond
+	)
+
+	d := dag.New[string]()
+	for i := 0; i < nodes; i++ {
+		var ancestors []dag.ID
+		if i%10 != 0 {
+			ancestors = []dag.ID{dag.ID(fmt.Sprintf("%03d", i-1))}
+		}
+		id := fmt.Sprintf("%03d", i)
+		_ = d.AddNode(dag.ID(id), id, nil, ancestors)
+	}
+
+	resolve := func() {
+		time.Sleep(resolveCost)
+	}
+
+	runFirst := func(b *testing.B, sched scheduler.S[string], start time.Time) time.Duration {
+		var once sync.Once
+		var first time.Duration
+		err := sched.Run(func(string) error {
+			once.Do(func() { first = time.Since(start) })
+			return nil
+		})
+		if err != nil {
+			b.Fatal(err)
+		}
+		return first
+	}
+
+	b.Run("full", func(b *testing.B) {
+		var total time.Duration
+		for i := 0; i < b.N; i++ {
+			start := time.Now()
+			for range d.IDs() {
+				resolve()
+			}
+			total += runFirst(b, scheduler.NewParallel(d, false), start)
+		}
+		b.ReportMetric(float64(total.Nanoseconds())/float64(b.N), "ns-to-first-node/op")
+	})
+
+	b.Run("streamed", func(b *testing.B) {
+		var total time.Duration
+		for i := 0; i < b.N; i++ {
+			start := time.Now()
+			g := scheduler.NewStreaming(d, false, true)
+			go func() {
+				for _, id := range g.Order() {
+					resolve()
+					g.Select(id)
+				}
+			}()
+			total += runFirst(b, g, start)
+		}
+		b.ReportMetric(float64(total.Nanoseconds())/float64(b.N), "ns-to-first-node/op")
+	})
+}
@@ -0,0 +1,221 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package scheduler
+
+import (
+	"slices"
+	"sync"
+
+	"github.com/terramate-io/terramate/errors"
+	"github.com/terramate-io/terramate/run/dag"
+)
+
+// Streaming is a scheduler implementing the scheduler.S interface for DAGs
+// whose nodes are selected for execution while the scheduler is running.
+// Every node of the DAG must be resolved, either by [Streaming.Select],
+// [Streaming.Skip] or [Streaming.Close]. A node is only visited when it's
+// selected and all its predecessors are resolved and done, so the order of the
+// selected nodes is the same as if the unselected nodes were removed from the
+// DAG beforehand.
+type Streaming[V any] struct {
+	d        *dag.DAG[V]
+	parallel bool
+
+	// order is the order in which the nodes are visited by the sequential
+	// strategy and the order in which the nodes should be resolved to start
+	// them as soon as possible.
+	order []dag.ID
+
+	mu        sync.Mutex
+	state     map[dag.ID]*streamingNodeState
+	f         Func[V]
+	remaining int
+	done      chan struct{}
+
+	wg      sync.WaitGroup
+	errsMtx sync.Mutex
+	errs    errors.List
+}
+
+type streamingNodeState struct {
+	id         dag.ID
+	successors []*streamingNodeState
+	resolvedc  chan struct{}
+	resolved   bool
+	selected   bool
+
+	// nPendingPredecessors is the number of predecessors not yet done.
+	nPendingPredecessors int
+}
+
+// NewStreaming creates a new streaming scheduler for the given DAG. If
+// parallel is true, nodes are visited in parallel as soon as they are ready,
+// otherwise they are visited sequentially in the topological order of the DAG.
+func NewStreaming[V any](d *dag.DAG[V], reverse, parallel bool) *Streaming[V] {
+	s := &Streaming[V]{
+		d:        d,
+		parallel: parallel,
+		order:    d.Order(),
+		state:    map[dag.ID]*streamingNodeState{},
+		done:     make(chan struct{}),
+	}
+	if reverse {
+		slices.Reverse(s.order)
+	}
+
+	ids := d.SortIDs(d.IDs())
+	for _, id := range ids {
+		s.state[id] = &streamingNodeState{
+			id:        id,
+			resolvedc: make(chan struct{}),
+		}
+	}
+	s.remaining = len(ids)
+
+	for _, id := range ids {
+		st := s.state[id]
+		for _, pid := range d.SortIDs(d.AncestorsOf(id)) {
+			pst := s.state[pid]
+			if !reverse {
+				pst.successors = append(pst.successors, st)
+				st.nPendingPredecessors++
+			} else {
+				st.successors = append(st.successors, pst)
+				pst.nPendingPredecessors++
+			}
+		}
+	}
+	return s
+}
+
+// Order returns the order in which the nodes should be resolved, so the first
+// nodes to be visited are resolved first.
+func (s *Streaming[V]) Order() []dag.ID {
+	return slices.Clone(s.order)
+}
+
+// Select resolves the node as selected, so it's visited when ready.
+func (s *Streaming[V]) Select(id dag.ID) {
+	s.resolve(id, true)
+}
+
+// Skip resolves the node as not selected, so it's never visited.
+func (s *Streaming[V]) Skip(id dag.ID) {
+	s.resolve(id, false)
+}
+
+// Close resolves all the pending nodes as not selected.
+func (s *Streaming[V]) Close() {
+	for _, id := range s.order {
+		s.resolve(id, false)
+	}
+}
+
+// Run executes the given function on each selected node of the DAG. It returns
+// when all the nodes are resolved and all the selected ones are visited.
+func (s *Streaming[V]) Run(f Func[V]) error {
+	if !s.parallel {
+		return s.runSequential(f)
+	}
+
+	s.mu.Lock()
+	s.f = f
+	var ready []*streamingNodeState
+	for _, id := range s.d.SortIDs(s.d.IDs()) {
+		st := s.state[id]
+		if st.resolved && st.nPendingPredecessors == 0 {
+			ready = append(ready, st)
+		}
+	}
+	empty := s.remaining == 0
+	s.mu.Unlock()
+
+	s.start(ready)
+	if !empty {
+		<-s.done
+	}
+	s.wg.Wait()
+	return s.errs.AsError()
+}
+
+func (s *Streaming[V]) runSequential(f Func[V]) error {
+	errs := errors.L()
+	for _, id := range s.order {
+		st := s.state[id]
+		<-st.resolvedc
+		if st.selected {
+			v, _ := s.d.Node(id)
+			errs.Append(f(v))
+		}
+	}
+	return errs.AsError()
+}
+
+func (s *Streaming[V]) resolve(id dag.ID, selected bool) {
+	s.mu.Lock()
+	st, ok := s.state[id]
+	if !ok || st.resolved {
+		s.mu.Unlock()
+		return
+	}
+	st.resolved = true
+	st.selected = selected
+	close(st.resolvedc)
+
+	var ready []*streamingNodeState
+	if s.f != nil && st.nPendingPredecessors == 0 {
+		ready = append(ready, st)
+	}
+	s.mu.Unlock()
+
+	s.start(ready)
+}
+
+// start visits the selected nodes which are ready and completes the ready
+// nodes which are not selected, which may in turn make their successors ready.
+func (s *Streaming[V]) start(ready []*streamingNodeState) {
+	for len(ready) > 0 {
+		st := ready[0]
+		ready = ready[1:]
+		if st.selected {
+			s.visitNode(st)
+			continue
+		}
+		ready = append(ready, s.complete(st)...)
+	}
+}
+
+func (s *Streaming[V]) visitNode(st *streamingNodeState) {
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+
+		v, _ := s.d.Node(st.id)
+		if err := s.f(v); err != nil {
+			s.errsMtx.Lock()
+			s.errs.Append(err)
+			s.errsMtx.Unlock()
+		}
+		s.start(s.complete(st))
+	}()
+}
+
+// complete marks the node as done and returns its successors which are ready.
+func (s *Streaming[V]) complete(st *streamingNodeState) []*streamingNodeState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var ready []*streamingNodeState
+	for _, succ := range st.successors {
+		succ.nPendingPredecessors--
+		if succ.nPendingPredecessors == 0 && succ.resolved {
+			ready = append(ready, succ)
+		}
+	}
+	s.remaining--
+	if s.remaining == 0 {
+		close(s.done)
+	}
+	return ready
+}
@@ -10,12 +10,11 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/rs/zerolog/log"
-	hhcl "github.com/terramate-io/hcl/v2"
-	"github.com/terramate-io/hcl/v2/hclwrite"
 	"github.com/terramate-io/terramate/config"
 	"github.com/terramate-io/terramate/errors"
 	"github.com/terramate-io/terramate/fs"
 	"github.com/terramate-io/terramate/hcl"
+	"github.com/terramate-io/terramate/hcl/ast"
 	"github.com/terramate-io/terramate/project"
 	"github.com/zclconf/go-cty/cty"
 )
@@ -147,7 +146,8 @@ func Clone(root *config.Root, destdir, srcdir string, skipChildStacks bool) (int
 
 // UpdateStackID updates the stack.id of the given stack directory.
 // The functions updates just the file which defines the stack block.
-// The updated file will lose all comments.
+// Only the id attribute is rewritten, the comments and formatting of the file
+// are kept.
 func UpdateStackID(root *config.Root, stackdir string) (string, error) {
 	parser, err := hcl.NewTerramateParser(root.HostDir(), stackdir)
 	if err != nil {
@@ -167,52 +167,24 @@ func UpdateStackID(root *config.Root, stackdir string) (string, error) {
 		return "", errors.E("stack does not have a stack block")
 	}
 
-	st, err := os.Lstat(stackFilePath)
+	file, err := ast.ParseEditableFile(stackFilePath)
 	if err != nil {
-		return "", errors.E(err, "stating the stack file")
+		return "", errors.E(err, "parsing stack definition file")
 	}
 
-	originalFileMode := st.Mode()
-
-	// Parsing HCL always delivers an AST that
-	// has no comments on it, so building a new HCL file from the parsed
-	// AST will lose all comments from the original code.
-
-	stackContents, err := os.ReadFile(stackFilePath)
+	uuid, err := uuid.NewRandom()
 	if err != nil {
-		return "", errors.E(err, "reading stack definition file")
+		return "", errors.E(err, "creating new ID for stack")
 	}
 
-	parsed, diags := hclwrite.ParseConfig([]byte(stackContents), stackFilePath, hhcl.InitialPos)
-	if diags.HasErrors() {
-		return "", errors.E(diags, "parsing stack configuration")
+	id := uuid.String()
+	if err := file.SetBlockAttribute(hcl.StackBlockType, "id", cty.StringVal(id)); err != nil {
+		return "", err
 	}
-
-	blocks := parsed.Body().Blocks()
-
-	for _, block := range blocks {
-		if block.Type() != hcl.StackBlockType {
-			continue
-		}
-
-		uuid, err := uuid.NewRandom()
-		if err != nil {
-			return "", errors.E(err, "creating new ID for stack")
-		}
-
-		id := uuid.String()
-
-		body := block.Body()
-		body.SetAttributeValue("id", cty.StringVal(id))
-
-		err = os.WriteFile(stackFilePath, parsed.Bytes(), originalFileMode)
-		if err != nil {
-			return "", err
-		}
-		return id, nil
+	if err := file.Save(); err != nil {
+		return "", err
 	}
-
-	return "", errors.E("stack block not found")
+	return id, nil
 }
 
 func getStackFilepath(parser *hcl.TerramateParser) string {
@@ -321,6 +321,52 @@ stack {
 	assert.EqualStrings(t, want, got, "want:\n%s\ngot:\n%s\n", want, got)
 }
 
+func TestUpdateStackIDKeepsComments(t *testing.T) {
+	t.Parallel()
+	const (
+		stackCfgFilename = "stack.tm.hcl"
+		stackCfg         = `// Copyright header
+stack {
+  // Commenting stack name
+  name = "stack" // More comments !!
+
+  # Last comment
+}
+
+/* block comment after the stack */
+globals {
+  a = 1 // comment
+}
+`
+		wantTemplate = `// Copyright header
+stack {
+  // Commenting stack name
+  name = "stack" // More comments !!
+
+  # Last comment
+  id = %q
+}
+
+/* block comment after the stack */
+globals {
+  a = 1 // comment
+}
+`
+	)
+	s := sandbox.NoGit(t, true)
+	s.BuildTree([]string{"d:stack"})
+
+	stackEntry := s.DirEntry("stack")
+	stackEntry.CreateFile(stackCfgFilename, stackCfg)
+
+	id, err := stack.UpdateStackID(s.Config(), filepath.Join(s.RootDir(), "stack"))
+	assert.NoError(t, err)
+
+	want := fmt.Sprintf(wantTemplate, id)
+	got := string(stackEntry.ReadFile(stackCfgFilename))
+	assert.EqualStrings(t, want, got, "want:\n%s\ngot:\n%s\n", want, got)
+}
+
 func entriesNames(entries []os.DirEntry) []string {
 	names := make([]string, len(entries))
 	for i, v := range entries {
@@ -4,7 +4,9 @@
 package stack
 
 import (
+	"bytes"
 	"fmt"
+	"io"
 	"os"
 	"path"
 	"path/filepath"
@@ -13,6 +15,7 @@ import (
 	"github.com/terramate-io/terramate/config"
 	"github.com/terramate-io/terramate/errors"
 	"github.com/terramate-io/terramate/hcl"
+	"github.com/terramate-io/terramate/hcl/ast"
 )
 
 const (
@@ -30,6 +33,16 @@ const (
 // DefaultFilename is the default file name for created stacks.
 const DefaultFilename = "stack.tm.hcl"
 
+// Filename returns the name of the file where the stack blocks are created,
+// which is configured by `terramate.config.stack.filename` and defaults to
+// [DefaultFilename].
+func Filename(root *config.Root) string {
+	if filename := root.StackFilename(); filename != "" {
+		return filename
+	}
+	return DefaultFilename
+}
+
 const (
 	createDirMode = 0755
 )
@@ -39,6 +52,10 @@ const (
 //
 // If the stack already exists it will return an error and no changes will be
 // made to the stack.
+//
+// When `terramate.config.stack.filename` is set and the file already exists
+// in the stack directory, the stack block is appended to it, keeping its
+// content and comments.
 func Create(root *config.Root, stack config.Stack, imports ...string) (err error) {
 	err = stack.Validate()
 	if err != nil {
@@ -59,8 +76,11 @@ func Create(root *config.Root, stack config.Stack, imports ...string) (err error
 		return errors.E(err, "failed to create new stack directories")
 	}
 
-	_, err = os.Stat(filepath.Join(hostpath, DefaultFilename))
-	if err == nil {
+	filename := Filename(root)
+	stackFilePath := filepath.Join(hostpath, filename)
+	_, err = os.Stat(stackFilePath)
+	appendToFile := err == nil
+	if appendToFile && root.StackFilename() == "" {
 		// Even if there is no stack block inside the file, we can't overwrite
 		// the user file anyway.
 		return errors.E(ErrStackDefaultCfgFound)
@@ -76,6 +96,8 @@ func Create(root *config.Root, stack config.Stack, imports ...string) (err error
 		WantedBy:    stack.WantedBy,
 		Watch:       stack.Watch.Strings(),
 		Tags:        stack.Tags,
+
+		IgnoreOnChange: stack.IgnoreOnChange,
 	}
 
 	tmCfg, err := hcl.NewConfig(hostpath)
@@ -85,7 +107,11 @@ func Create(root *config.Root, stack config.Stack, imports ...string) (err error
 
 	tmCfg.Stack = &stackCfg
 
-	stackFile, err := os.Create(filepath.Join(hostpath, DefaultFilename))
+	if appendToFile {
+		return appendStackConfig(stackFilePath, tmCfg, imports)
+	}
+
+	stackFile, err := os.Create(stackFilePath)
 	if err != nil {
 		return errors.E(err, "creating/truncating stack file")
 	}
@@ -97,20 +123,44 @@ func Create(root *config.Root, stack config.Stack, imports ...string) (err error
 		}
 	}()
 
-	if err := hcl.PrintConfig(stackFile, tmCfg); err != nil {
+	return printStackConfig(stackFile, tmCfg, imports)
+}
+
+// appendStackConfig appends the stack block and imports to the existing file,
+// failing if it already has a stack block.
+func appendStackConfig(path string, tmCfg hcl.Config, imports []string) error {
+	file, err := ast.ParseEditableFile(path)
+	if err != nil {
+		return err
+	}
+	if file.HasBlock(hcl.StackBlockType) {
+		return errors.E(ErrStackAlreadyExists, "stack block found in %s", path)
+	}
+
+	var buf bytes.Buffer
+	if err := printStackConfig(&buf, tmCfg, imports); err != nil {
+		return err
+	}
+	if err := file.AppendBlocks(buf.Bytes()); err != nil {
+		return err
+	}
+	return file.Save()
+}
+
+func printStackConfig(w io.Writer, tmCfg hcl.Config, imports []string) error {
+	if err := hcl.PrintConfig(w, tmCfg); err != nil {
 		return errors.E(err, "writing stack config to stack file")
 	}
 
 	if len(imports) > 0 {
-		_, err = fmt.Fprint(stackFile, "\n")
+		_, err := fmt.Fprint(w, "\n")
 		if err != nil {
 			return errors.E(err, "writing stack config")
 		}
 	}
 
-	if err := hcl.PrintImports(stackFile, imports); err != nil {
+	if err := hcl.PrintImports(w, imports); err != nil {
 		return errors.E(err, "writing stack imports to stack file")
 	}
-
 	return nil
 }
@@ -199,6 +199,116 @@ func TestStackCreation(t *testing.T) {
 	}
 }
 
+func TestStackCreationWithConfiguredFilename(t *testing.T) {
+	t.Parallel()
+
+	const rootConfig = `f:config.tm:terramate {
+	  config {
+	    stack {
+	      filename = "terramate.tm.hcl"
+	    }
+	  }
+	}`
+
+	type want struct {
+		err  error
+		file string
+	}
+	type testcase struct {
+		name    string
+		layout  []string
+		stack   config.Stack
+		imports []string
+		want    want
+	}
+
+	for _, tc := range []testcase{
+		{
+			name:  "creates the configured file",
+			stack: config.Stack{Dir: project.NewPath("/stack"), ID: "stack-id", Name: "stack", Description: "stack"},
+			want: want{
+				file: "stack {\n" +
+					"  name        = \"stack\"\n" +
+					"  description = \"stack\"\n" +
+					"  id          = \"stack-id\"\n" +
+					"}\n",
+			},
+		},
+		{
+			name: "appends to the existing file",
+			layout: []string{
+				"f:stack/terramate.tm.hcl:# stack globals\nglobals {\n  # the env\n  env = \"prod\"\n}\n",
+			},
+			stack:   config.Stack{Dir: project.NewPath("/stack"), Name: "stack", Description: "stack"},
+			imports: []string{"/common/something.tm.hcl"},
+			want: want{
+				file: "# stack globals\nglobals {\n  # the env\n  env = \"prod\"\n}\n" +
+					"\nstack {\n" +
+					"  name        = \"stack\"\n" +
+					"  description = \"stack\"\n" +
+					"}\n" +
+					"\nimport {\n" +
+					"  source = \"/common/something.tm.hcl\"\n" +
+					"}\n",
+			},
+		},
+		{
+			name: "fails if the configured file has a stack block",
+			layout: []string{
+				"f:stack/terramate.tm.hcl:stack {\n}\n",
+			},
+			stack: config.Stack{Dir: project.NewPath("/stack")},
+			want:  want{err: errors.E(stack.ErrStackAlreadyExists)},
+		},
+		{
+			name: "fails if another file of the dir has a stack block",
+			layout: []string{
+				"f:stack/terramate.tm.hcl:globals {\n}\n",
+				"f:stack/stack.tm:stack {\n}\n",
+			},
+			stack: config.Stack{Dir: project.NewPath("/stack")},
+			want:  want{err: errors.E(stack.ErrStackAlreadyExists)},
+		},
+		{
+			name:   "stack.tm.hcl is not used when another file is configured",
+			layout: []string{"f:stack/stack.tm.hcl:globals {\n}\n"},
+			stack:  config.Stack{Dir: project.NewPath("/stack"), Name: "stack", Description: "stack"},
+			want: want{
+				file: "stack {\n" +
+					"  name        = \"stack\"\n" +
+					"  description = \"stack\"\n" +
+					"}\n",
+			},
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			s := sandbox.NoGit(t, true)
+			s.BuildTree(append([]string{rootConfig}, tc.layout...))
+			buildImportedFiles(t, s.RootDir(), tc.imports)
+
+			root, err := config.LoadRoot(s.RootDir())
+			assert.NoError(t, err)
+			assert.EqualStrings(t, "terramate.tm.hcl", stack.Filename(root))
+
+			err = stack.Create(root, tc.stack, tc.imports...)
+			assert.IsError(t, err, tc.want.err)
+			if tc.want.err != nil {
+				return
+			}
+
+			stackdir := tc.stack.Dir.HostPath(s.RootDir())
+			got := test.ReadFile(t, stackdir, "terramate.tm.hcl")
+			assert.EqualStrings(t, tc.want.file, string(got))
+
+			st := s.LoadStack(tc.stack.Dir)
+			assert.EqualStrings(t, tc.stack.ID, st.ID)
+			test.AssertStackImports(t, s.RootDir(), st.HostDir(root), tc.imports)
+		})
+	}
+}
+
 func buildImportedFiles(t *testing.T, rootdir string, imports []string) {
 	t.Helper()
 
@@ -0,0 +1,312 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package stack
+
+import (
+	"bytes"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	hhcl "github.com/terramate-io/hcl/v2"
+	"github.com/terramate-io/hcl/v2/hclparse"
+	"github.com/terramate-io/hcl/v2/hclsyntax"
+	"github.com/terramate-io/hcl/v2/hclwrite"
+	"github.com/terramate-io/terramate/config"
+	"github.com/terramate-io/terramate/errors"
+	"github.com/terramate-io/terramate/hcl"
+	"github.com/terramate-io/terramate/hcl/info"
+	"github.com/terramate-io/terramate/project"
+	"github.com/terramate-io/terramate/stack/trigger"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// ErrStackHasChildStacks indicates that the stack cannot be deleted because
+// it has child stacks.
+const ErrStackHasChildStacks errors.Kind = "stack has child stacks"
+
+// Reference is a reference to a stack from the ordering or wants attributes
+// of another stack.
+type Reference struct {
+	// Stack is the stack defining the reference.
+	Stack project.Path
+
+	// Attribute is the stack attribute defining the reference (eg.: after).
+	Attribute string
+
+	// Path is the referenced path, as written in the attribute.
+	Path string
+
+	// Range is the range of the reference in the configuration.
+	Range info.Range
+}
+
+// DeletePlan describes the changes made by [Delete].
+type DeletePlan struct {
+	// Dir is the directory of the stack being deleted.
+	Dir project.Path
+
+	// KeepDir tells if only the stack block is removed, turning the stack
+	// directory into a plain directory.
+	KeepDir bool
+
+	// Files are the files being removed.
+	// In the keep-dir mode, it contains only the file defining the stack block
+	// when the block is its only content and the GeneratedFiles.
+	Files project.Paths
+
+	// StackFile is the file defining the stack block.
+	StackFile project.Path
+
+	// GeneratedFiles are the files generated for the stack, which are removed
+	// in the keep-dir mode. They must be set by the caller.
+	GeneratedFiles project.Paths
+
+	// Triggers are the trigger files of the stack.
+	Triggers project.Paths
+
+	// References are the references to the stack from other stacks, which
+	// become invalid after the stack is deleted.
+	References []Reference
+}
+
+// PlanDelete computes the changes needed to delete the stack at dir.
+// A stack with child stacks can only be deleted in the keep-dir mode.
+func PlanDelete(root *config.Root, dir project.Path, keepDir bool) (DeletePlan, error) {
+	tree, ok := root.Lookup(dir)
+	if !ok || !tree.IsStack() {
+		return DeletePlan{}, errors.E(ErrInvalidStackDir, "%s is not a stack", dir)
+	}
+
+	plan := DeletePlan{
+		Dir:     dir,
+		KeepDir: keepDir,
+	}
+
+	stackFile, onlyStackBlock, err := findStackBlockFile(tree)
+	if err != nil {
+		return DeletePlan{}, err
+	}
+	plan.StackFile = project.PrjAbsPath(root.HostDir(), stackFile)
+
+	if keepDir {
+		if onlyStackBlock {
+			plan.Files = append(plan.Files, plan.StackFile)
+		}
+	} else {
+		if len(tree.Stacks()) > 1 {
+			return DeletePlan{}, errors.E(ErrStackHasChildStacks,
+				"stack %s has child stacks, delete them first or use the keep-dir mode", dir)
+		}
+		plan.Files, err = listFiles(root.HostDir(), tree.HostDir())
+		if err != nil {
+			return DeletePlan{}, err
+		}
+	}
+
+	plan.Triggers, err = trigger.StackTriggers(root, dir)
+	if err != nil {
+		return DeletePlan{}, err
+	}
+
+	plan.References, err = stackReferences(root, dir, keepDir)
+	if err != nil {
+		return DeletePlan{}, err
+	}
+	return plan, nil
+}
+
+// Delete applies the given plan, see [PlanDelete].
+func Delete(root *config.Root, plan DeletePlan) error {
+	rootdir := root.HostDir()
+	if plan.KeepDir {
+		if err := removeStackBlock(plan.StackFile.HostPath(rootdir)); err != nil {
+			return err
+		}
+		for _, file := range plan.GeneratedFiles {
+			if err := os.Remove(file.HostPath(rootdir)); err != nil && !os.IsNotExist(err) {
+				return errors.E(err, "removing generated file %s", file)
+			}
+		}
+	} else {
+		if err := os.RemoveAll(plan.Dir.HostPath(rootdir)); err != nil {
+			return errors.E(err, "removing stack directory %s", plan.Dir)
+		}
+	}
+
+	for _, file := range plan.Triggers {
+		if err := os.Remove(file.HostPath(rootdir)); err != nil && !os.IsNotExist(err) {
+			return errors.E(err, "removing trigger file %s", file)
+		}
+	}
+	if len(plan.Triggers) > 0 {
+		// the triggers dir is only removed if empty.
+		_ = os.Remove(filepath.Join(trigger.Dir(rootdir), plan.Dir.String()))
+	}
+	return nil
+}
+
+// findStackBlockFile returns the file defining the stack block and if the
+// stack block is the only content of the file.
+func findStackBlockFile(tree *config.Tree) (string, bool, error) {
+	for _, fname := range tree.TerramateFiles {
+		file, err := parseWritableFile(filepath.Join(tree.HostDir(), fname))
+		if err != nil {
+			return "", false, err
+		}
+		body := file.Body()
+		if body.FirstMatchingBlock(hcl.StackBlockType, nil) == nil {
+			continue
+		}
+		only := len(body.Blocks()) == 1 && len(body.Attributes()) == 0
+		return filepath.Join(tree.HostDir(), fname), only, nil
+	}
+	return "", false, errors.E(errors.ErrInternal, "stack block not found in %s", tree.Dir())
+}
+
+// removeStackBlock removes the stack block from the file, or the file itself
+// if the stack block is its only content.
+func removeStackBlock(fname string) error {
+	file, err := parseWritableFile(fname)
+	if err != nil {
+		return err
+	}
+	body := file.Body()
+	block := body.FirstMatchingBlock(hcl.StackBlockType, nil)
+	if block == nil {
+		return errors.E("stack block not found in %s", fname)
+	}
+	body.RemoveBlock(block)
+	if len(body.Blocks()) == 0 && len(body.Attributes()) == 0 {
+		return os.Remove(fname)
+	}
+
+	st, err := os.Lstat(fname)
+	if err != nil {
+		return errors.E(err, "stating the stack file")
+	}
+	// the blank lines around the removed block are left behind.
+	data := bytes.TrimLeft(hclwrite.Format(file.Bytes()), "\n")
+	return os.WriteFile(fname, data, st.Mode())
+}
+
+func parseWritableFile(fname string) (*hclwrite.File, error) {
+	data, err := os.ReadFile(fname)
+	if err != nil {
+		return nil, errors.E(err, "reading %s", fname)
+	}
+	file, diags := hclwrite.ParseConfig(data, fname, hhcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, errors.E(diags, "parsing %s", fname)
+	}
+	return file, nil
+}
+
+func listFiles(rootdir string, dir string) (project.Paths, error) {
+	var files project.Paths
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			files = append(files, project.PrjAbsPath(rootdir, path))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.E(err, "listing files of %s", dir)
+	}
+	return files, nil
+}
+
+// stackReferences returns the references to dir, or its subdirectories, from
+// the after, before, wants and wanted_by attributes of the other stacks.
+// The stacks being deleted are ignored, which are only the stack at dir in the
+// keep-dir mode. References using tag queries are also ignored because they
+// don't become invalid.
+func stackReferences(root *config.Root, dir project.Path, keepDir bool) ([]Reference, error) {
+	var refs []Reference
+	for _, tree := range root.Tree().Stacks() {
+		if tree.Dir() == dir || (!keepDir && tree.Dir().HasDirPrefix(dir.String())) {
+			continue
+		}
+		st := tree.Node.Stack
+		for _, attr := range []struct {
+			name  string
+			paths []string
+		}{
+			{"after", st.After},
+			{"before", st.Before},
+			{"wants", st.Wants},
+			{"wanted_by", st.WantedBy},
+		} {
+			for _, pathstr := range attr.paths {
+				if strings.HasPrefix(pathstr, "tag:") {
+					continue
+				}
+				target := pathstr
+				if !path.IsAbs(target) {
+					target = path.Join(tree.Dir().String(), target)
+				}
+				if !project.NewPath(target).HasDirPrefix(dir.String()) {
+					continue
+				}
+				rng, err := referenceRange(tree, attr.name, pathstr)
+				if err != nil {
+					return nil, err
+				}
+				refs = append(refs, Reference{
+					Stack:     tree.Dir(),
+					Attribute: attr.name,
+					Path:      pathstr,
+					Range:     rng,
+				})
+			}
+		}
+	}
+	sort.SliceStable(refs, func(i, j int) bool {
+		return refs[i].Stack.String() < refs[j].Stack.String()
+	})
+	return refs, nil
+}
+
+// referenceRange returns the range of the pathstr element of the attribute
+// of the stack block. It falls back to the range of the whole attribute value
+// if the element cannot be located.
+func referenceRange(tree *config.Tree, attrName, pathstr string) (info.Range, error) {
+	rootdir := tree.RootDir()
+	parser := hclparse.NewParser()
+	for _, fname := range tree.TerramateFiles {
+		file, diags := parser.ParseHCLFile(filepath.Join(tree.HostDir(), fname))
+		if diags.HasErrors() {
+			return info.Range{}, errors.E(diags)
+		}
+		body, ok := file.Body.(*hclsyntax.Body)
+		if !ok {
+			continue
+		}
+		for _, block := range body.Blocks {
+			if block.Type != hcl.StackBlockType {
+				continue
+			}
+			attr, ok := block.Body.Attributes[attrName]
+			if !ok {
+				continue
+			}
+			if tuple, ok := attr.Expr.(*hclsyntax.TupleConsExpr); ok {
+				for _, elem := range tuple.Exprs {
+					val, diags := elem.Value(nil)
+					if !diags.HasErrors() && val.Type() == cty.String && val.AsString() == pathstr {
+						return info.NewRange(rootdir, elem.Range()), nil
+					}
+				}
+			}
+			return info.NewRange(rootdir, attr.Expr.Range()), nil
+		}
+	}
+	return info.Range{}, errors.E(errors.ErrInternal, "stack.%s not found in %s", attrName, tree.Dir())
+}
@@ -0,0 +1,176 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package stack_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/madlambda/spells/assert"
+	"github.com/terramate-io/terramate/errors"
+	"github.com/terramate-io/terramate/project"
+	"github.com/terramate-io/terramate/stack"
+	"github.com/terramate-io/terramate/stack/trigger"
+	errtest "github.com/terramate-io/terramate/test/errors"
+	"github.com/terramate-io/terramate/test/sandbox"
+)
+
+func TestStackDeletePlanReferences(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.NoGit(t, true)
+	s.BuildTree([]string{
+		"s:stacks/a",
+		"f:stacks/a/main.tf:# main",
+		`f:stacks/b/stack.tm:stack {
+		  after = ["/stacks/a", "tag:a"]
+		}`,
+		`f:stacks/c/stack.tm:stack {
+		  wants  = ["../a"]
+		  before = ["/stacks/b"]
+		}`,
+	})
+
+	plan, err := stack.PlanDelete(s.Config(), project.NewPath("/stacks/a"), false)
+	assert.NoError(t, err)
+
+	assertPaths(t, plan.Files, "/stacks/a/main.tf", "/stacks/a/stack.tm.hcl")
+	assert.EqualInts(t, 2, len(plan.References))
+
+	ref := plan.References[0]
+	assert.EqualStrings(t, "/stacks/b", ref.Stack.String())
+	assert.EqualStrings(t, "after", ref.Attribute)
+	assert.EqualStrings(t, "/stacks/a", ref.Path)
+	assert.EqualStrings(t, "/stacks/b/stack.tm:2,14-25", ref.Range.String())
+
+	ref = plan.References[1]
+	assert.EqualStrings(t, "/stacks/c", ref.Stack.String())
+	assert.EqualStrings(t, "wants", ref.Attribute)
+	assert.EqualStrings(t, "../a", ref.Path)
+	assert.EqualStrings(t, "/stacks/c/stack.tm:2,15-21", ref.Range.String())
+}
+
+func TestStackDelete(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.NoGit(t, true)
+	s.BuildTree([]string{
+		"s:stacks/a",
+		"f:stacks/a/main.tf:# main",
+		"f:stacks/a/modules/mod.tf:# mod",
+		"s:stacks/b",
+	})
+	root := s.Config()
+	dir := project.NewPath("/stacks/a")
+	assert.NoError(t, trigger.Create(root, dir, trigger.Changed, "test", 0))
+
+	plan, err := stack.PlanDelete(root, dir, false)
+	assert.NoError(t, err)
+	assertPaths(t, plan.Files,
+		"/stacks/a/main.tf",
+		"/stacks/a/modules/mod.tf",
+		"/stacks/a/stack.tm.hcl",
+	)
+	assert.EqualInts(t, 1, len(plan.Triggers))
+
+	assert.NoError(t, stack.Delete(root, plan))
+
+	_, err = os.Stat(filepath.Join(s.RootDir(), "stacks/a"))
+	assert.IsTrue(t, os.IsNotExist(err), "stack dir must be removed")
+	_, err = os.Stat(filepath.Join(trigger.Dir(s.RootDir()), "stacks/a"))
+	assert.IsTrue(t, os.IsNotExist(err), "stack triggers must be removed")
+	_, err = os.Stat(filepath.Join(s.RootDir(), "stacks/b/stack.tm.hcl"))
+	assert.NoError(t, err)
+}
+
+func TestStackDeleteKeepDir(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.NoGit(t, true)
+	s.BuildTree([]string{
+		`f:stacks/a/config.tm:stack {
+		  name = "a"
+		}
+
+		globals {
+		  a = 1
+		}`,
+		"f:stacks/a/main.tf:# main",
+		"s:stacks/a/child",
+		"s:stacks/b",
+	})
+	root := s.Config()
+	dir := project.NewPath("/stacks/a")
+
+	plan, err := stack.PlanDelete(root, dir, true)
+	assert.NoError(t, err)
+	assert.EqualStrings(t, "/stacks/a/config.tm", plan.StackFile.String())
+	assert.EqualInts(t, 0, len(plan.Files))
+
+	assert.NoError(t, stack.Delete(root, plan))
+
+	root = s.ReloadConfig()
+	tree, ok := root.Lookup(dir)
+	assert.IsTrue(t, ok)
+	assert.IsTrue(t, !tree.IsStack(), "dir must not be a stack anymore")
+	assert.IsTrue(t, tree.Node.HasGlobals(), "other blocks must be kept")
+
+	_, err = os.Stat(filepath.Join(s.RootDir(), "stacks/a/main.tf"))
+	assert.NoError(t, err)
+
+	child, ok := root.Lookup(project.NewPath("/stacks/a/child"))
+	assert.IsTrue(t, ok)
+	assert.IsTrue(t, child.IsStack(), "child stack must be kept")
+}
+
+func TestStackDeleteKeepDirRemovesStackFile(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.NoGit(t, true)
+	s.BuildTree([]string{
+		"s:stack",
+		"f:stack/main.tf:# main",
+	})
+	root := s.Config()
+
+	plan, err := stack.PlanDelete(root, project.NewPath("/stack"), true)
+	assert.NoError(t, err)
+	assertPaths(t, plan.Files, "/stack/stack.tm.hcl")
+	assert.NoError(t, stack.Delete(root, plan))
+
+	_, err = os.Stat(filepath.Join(s.RootDir(), "stack/stack.tm.hcl"))
+	assert.IsTrue(t, os.IsNotExist(err), "stack file must be removed")
+	_, err = os.Stat(filepath.Join(s.RootDir(), "stack/main.tf"))
+	assert.NoError(t, err)
+}
+
+func TestStackDeleteFailures(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.NoGit(t, true)
+	s.BuildTree([]string{
+		"d:dir",
+		"s:parent",
+		"s:parent/child",
+	})
+	root := s.Config()
+
+	_, err := stack.PlanDelete(root, project.NewPath("/dir"), false)
+	errtest.Assert(t, err, errors.E(stack.ErrInvalidStackDir))
+
+	_, err = stack.PlanDelete(root, project.NewPath("/not-found"), false)
+	errtest.Assert(t, err, errors.E(stack.ErrInvalidStackDir))
+
+	_, err = stack.PlanDelete(root, project.NewPath("/parent"), false)
+	errtest.Assert(t, err, errors.E(stack.ErrStackHasChildStacks))
+}
+
+func assertPaths(t *testing.T, got project.Paths, want ...string) {
+	t.Helper()
+	assert.EqualInts(t, len(want), len(got), "paths: %v", got)
+	for i, p := range want {
+		assert.EqualStrings(t, p, got[i].String())
+	}
+}
@@ -0,0 +1,285 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package stack
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/terramate-io/terramate/config"
+	"github.com/terramate-io/terramate/errors"
+	"github.com/terramate-io/terramate/project"
+	"github.com/terramate-io/terramate/run"
+	"github.com/terramate-io/terramate/run/dag"
+	"github.com/terramate-io/terramate/tf"
+	"github.com/terramate-io/terramate/tg"
+)
+
+type (
+	// Dependencies is the resolved dependency information of a stack.
+	Dependencies struct {
+		// Stack is the directory of the stack.
+		Stack project.Path `json:"stack"`
+
+		// Parents are the parent stacks, which implicitly run before the stack.
+		Parents project.Paths `json:"parents"`
+
+		// After are the stack.after entries resolved to stacks.
+		After []OrderDependency `json:"after"`
+
+		// Before are the stack.before entries resolved to stacks.
+		Before []OrderDependency `json:"before"`
+
+		// Wants are all stacks selected together with the stack, from its
+		// stack.wants and from the stack.wanted_by of other stacks.
+		Wants project.Paths `json:"wants"`
+
+		// WantedBy are all stacks that select the stack when selected.
+		WantedBy project.Paths `json:"wanted_by"`
+
+		// Watch are the files watched by change detection.
+		Watch project.Paths `json:"watch"`
+
+		// TerraformModules are the local Terraform modules used by the stack,
+		// including the modules called by them.
+		TerraformModules []TerraformModule `json:"terraform_modules"`
+
+		// Terragrunt is the Terragrunt module of the stack, which is only set
+		// when Terragrunt change detection is enabled.
+		Terragrunt *tg.Module `json:"terragrunt,omitempty"`
+	}
+
+	// OrderDependency is an entry of the stack.after or stack.before attribute.
+	OrderDependency struct {
+		// Entry is the entry as written in the attribute.
+		Entry string `json:"entry"`
+
+		// Stacks are the stacks the entry resolves to.
+		Stacks project.Paths `json:"stacks"`
+	}
+
+	// TerraformModule is a local Terraform module used by a stack.
+	TerraformModule struct {
+		// Source is the module source as written in the module block.
+		Source string `json:"source"`
+
+		// Path is the directory of the module.
+		Path project.Path `json:"path"`
+
+		// CalledBy is the directory of the stack or module calling it.
+		CalledBy project.Path `json:"called_by"`
+	}
+)
+
+// Dependencies returns the resolved dependencies of the stack at dir.
+func (m *Manager) Dependencies(dir project.Path) (*Dependencies, error) {
+	tree, ok := m.root.Lookup(dir)
+	if !ok || !tree.IsStack() {
+		return nil, errors.E(ErrInvalidStackDir, "%s is not a stack", dir)
+	}
+	st, err := tree.Stack()
+	if err != nil {
+		return nil, err
+	}
+
+	deps := &Dependencies{
+		Stack: dir,
+		Watch: st.Watch,
+	}
+
+	for parent := dir; parent.String() != "/"; {
+		parent = parent.Dir()
+		ptree, ok := m.root.Lookup(parent)
+		if ok && ptree.IsStack() {
+			deps.Parents = append(project.Paths{parent}, deps.Parents...)
+		}
+	}
+
+	deps.After, err = m.orderDependencies(st, st.After)
+	if err != nil {
+		return nil, errors.E(err, "resolving stack.after")
+	}
+	deps.Before, err = m.orderDependencies(st, st.Before)
+	if err != nil {
+		return nil, errors.E(err, "resolving stack.before")
+	}
+
+	deps.Wants, deps.WantedBy, err = m.wantsClosure(st)
+	if err != nil {
+		return nil, err
+	}
+
+	visitedMods := map[project.Path]bool{}
+	err = m.filesApply(dir, func(fname string) error {
+		if !tf.IsTerraformFile(fname) {
+			return nil
+		}
+		modules, err := tf.ParseModules(filepath.Join(st.HostDir(m.root), fname))
+		if err != nil {
+			return errors.E(err, "parsing modules")
+		}
+		for _, mod := range modules {
+			err := m.tfModuleDeps(mod, dir, &deps.TerraformModules, visitedMods)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.E(err, "listing Terraform modules")
+	}
+
+	if m.root.IsTerragruntChangeDetectionEnabled() {
+		tgModules, err := tg.ScanModules(m.root.HostDir(), dir, false)
+		if err != nil {
+			return nil, errors.E(err, "scanning terragrunt modules")
+		}
+		for _, mod := range tgModules {
+			if mod.Path == dir {
+				deps.Terragrunt = mod
+				break
+			}
+		}
+	}
+	return deps, nil
+}
+
+// orderDependencies resolves the stack.after or stack.before entries in the
+// same way as the run order does. Paths include their child stacks and
+// entries not found in the project resolve to no stacks.
+func (m *Manager) orderDependencies(st *config.Stack, entries []string) ([]OrderDependency, error) {
+	var deps []OrderDependency
+	for _, entry := range entries {
+		dep := OrderDependency{Entry: entry}
+		if strings.HasPrefix(entry, "tag:") {
+			paths, err := m.root.StacksByTagsFilters([]string{strings.TrimPrefix(entry, "tag:")})
+			if err != nil {
+				return nil, errors.E(err, "invalid order entry %q", entry)
+			}
+			dep.Stacks = paths
+			sort.Slice(dep.Stacks, func(i, j int) bool {
+				return dep.Stacks[i].String() < dep.Stacks[j].String()
+			})
+		} else {
+			for _, tree := range m.root.StacksByPaths(st.Dir, entry) {
+				dep.Stacks = append(dep.Stacks, tree.Dir())
+			}
+		}
+		deps = append(deps, dep)
+	}
+	return deps, nil
+}
+
+// wantsClosure returns the stacks transitively wanted by st and the stacks
+// transitively wanting it, using the same DAG of the stack selection.
+func (m *Manager) wantsClosure(st *config.Stack) (wants, wantedBy project.Paths, err error) {
+	allstacks, err := config.LoadAllStacks(m.root, m.root.Tree())
+	if err != nil {
+		return nil, nil, errors.E(err, "loading all stacks")
+	}
+
+	wantsDag := dag.New[*config.Stack]()
+	visited := dag.Visited{}
+	sort.Sort(allstacks)
+	for _, elem := range allstacks {
+		err := run.BuildDAG(
+			wantsDag,
+			m.root,
+			elem.Stack,
+			"wanted_by",
+			func(s config.Stack) []string { return s.WantedBy },
+			"wants",
+			func(s config.Stack) []string { return s.Wants },
+			visited,
+		)
+		if err != nil {
+			return nil, nil, errors.E(err, "building wants DAG")
+		}
+	}
+
+	wantedOf := map[dag.ID][]dag.ID{}
+	for _, id := range wantsDag.IDs() {
+		for _, ancestor := range wantsDag.AncestorsOf(id) {
+			wantedOf[ancestor] = append(wantedOf[ancestor], id)
+		}
+	}
+
+	id := dag.ID(st.Dir.String())
+	wants = closure(id, wantsDag.AncestorsOf)
+	wantedBy = closure(id, func(id dag.ID) []dag.ID { return wantedOf[id] })
+	return wants, wantedBy, nil
+}
+
+// closure returns the sorted paths reachable from id, excluding id itself.
+func closure(id dag.ID, next func(dag.ID) []dag.ID) project.Paths {
+	visited := dag.Visited{id: struct{}{}}
+	var paths project.Paths
+	pending := next(id)
+	for len(pending) > 0 {
+		cur := pending[0]
+		pending = pending[1:]
+		if _, ok := visited[cur]; ok {
+			continue
+		}
+		visited[cur] = struct{}{}
+		paths = append(paths, project.NewPath(string(cur)))
+		pending = append(pending, next(cur)...)
+	}
+	sort.Slice(paths, func(i, j int) bool {
+		return paths[i].String() < paths[j].String()
+	})
+	return paths
+}
+
+// tfModuleDeps appends the local module mod, called from the calledBy
+// directory, and the modules it calls to the mods list. Remote modules and
+// modules outside of the project are ignored as they are by change detection.
+func (m *Manager) tfModuleDeps(
+	mod tf.Module, calledBy project.Path, mods *[]TerraformModule, visited map[project.Path]bool,
+) error {
+	if !mod.IsLocal() {
+		return nil
+	}
+
+	rootdir := m.root.HostDir()
+	modAbsPath := filepath.Join(calledBy.HostPath(rootdir), mod.Source)
+	if modAbsPath != rootdir && !strings.HasPrefix(modAbsPath, rootdir+string(filepath.Separator)) {
+		return nil
+	}
+	modPath := project.PrjAbsPath(rootdir, modAbsPath)
+	if visited[modPath] {
+		return nil
+	}
+	visited[modPath] = true
+
+	st, err := os.Stat(modAbsPath)
+	if err != nil || !st.IsDir() {
+		return errors.E("\"source\" path %q is not a directory", modAbsPath)
+	}
+
+	*mods = append(*mods, TerraformModule{
+		Source:   mod.Source,
+		Path:     modPath,
+		CalledBy: calledBy,
+	})
+
+	return m.filesApply(modPath, func(fname string) error {
+		if !tf.IsTerraformFile(fname) {
+			return nil
+		}
+		modules, err := tf.ParseModules(filepath.Join(modAbsPath, fname))
+		if err != nil {
+			return errors.E(err, "parsing module %q", mod.Source)
+		}
+		for _, mod2 := range modules {
+			if err := m.tfModuleDeps(mod2, modPath, mods, visited); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
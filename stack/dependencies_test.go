@@ -0,0 +1,145 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package stack_test
+
+import (
+	"testing"
+
+	"github.com/madlambda/spells/assert"
+	"github.com/terramate-io/terramate/errors"
+	"github.com/terramate-io/terramate/project"
+	"github.com/terramate-io/terramate/stack"
+	errtest "github.com/terramate-io/terramate/test/errors"
+	"github.com/terramate-io/terramate/test/sandbox"
+)
+
+func TestStackDependencies(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.NoGit(t, true)
+	s.BuildTree([]string{
+		`f:terramate.tm:terramate {
+		  config {
+		    change_detection {
+		      terragrunt {
+		        enabled = "force"
+		      }
+		    }
+		  }
+		}`,
+		"s:infra",
+		`f:infra/network/stack.tm:stack {
+		  after    = ["/infra/db", "tag:queue"]
+		  before   = ["../app", "/not-found"]
+		  wants    = ["/infra/db"]
+		  watch    = ["/config.json"]
+		}`,
+		`f:infra/network/main.tf:module "vpc" {
+		  source = "../../modules/vpc"
+		}
+
+		module "remote" {
+		  source = "github.com/terramate-io/example"
+		}`,
+		`f:infra/network/terragrunt.hcl:terraform {
+		  source = "../../modules/vpc"
+		}
+
+		locals {
+		  cfg = file("../../config.json")
+		}`,
+		`f:infra/db/stack.tm:stack {
+		  wants = ["/infra/cache"]
+		}`,
+		"s:infra/cache",
+		`f:infra/queue1/stack.tm:stack {
+		  tags = ["queue"]
+		}`,
+		`f:infra/queue2/stack.tm:stack {
+		  tags = ["queue"]
+		}`,
+		"s:infra/app",
+		"s:infra/app/child",
+		`f:monitoring/stack.tm:stack {
+		  wanted_by = ["/infra/network"]
+		}`,
+		`f:frontend/stack.tm:stack {
+		  wants = ["/infra/network"]
+		}`,
+		`f:modules/vpc/main.tf:module "subnet" {
+		  source = "../subnet"
+		}`,
+		"f:modules/subnet/main.tf:# subnet",
+		"f:config.json:{}",
+	})
+
+	deps, err := stack.NewManager(s.Config()).Dependencies(project.NewPath("/infra/network"))
+	assert.NoError(t, err)
+
+	assert.EqualStrings(t, "/infra/network", deps.Stack.String())
+	assertPaths(t, deps.Parents, "/infra")
+
+	assert.EqualInts(t, 2, len(deps.After))
+	assert.EqualStrings(t, "/infra/db", deps.After[0].Entry)
+	assertPaths(t, deps.After[0].Stacks, "/infra/db")
+	assert.EqualStrings(t, "tag:queue", deps.After[1].Entry)
+	assertPaths(t, deps.After[1].Stacks, "/infra/queue1", "/infra/queue2")
+
+	assert.EqualInts(t, 2, len(deps.Before))
+	assert.EqualStrings(t, "../app", deps.Before[0].Entry)
+	assertPaths(t, deps.Before[0].Stacks, "/infra/app", "/infra/app/child")
+	assert.EqualStrings(t, "/not-found", deps.Before[1].Entry)
+	assertPaths(t, deps.Before[1].Stacks)
+
+	assertPaths(t, deps.Wants, "/infra/cache", "/infra/db", "/monitoring")
+	assertPaths(t, deps.WantedBy, "/frontend")
+	assertPaths(t, deps.Watch, "/config.json")
+
+	assert.EqualInts(t, 2, len(deps.TerraformModules))
+	vpc := deps.TerraformModules[0]
+	assert.EqualStrings(t, "../../modules/vpc", vpc.Source)
+	assert.EqualStrings(t, "/modules/vpc", vpc.Path.String())
+	assert.EqualStrings(t, "/infra/network", vpc.CalledBy.String())
+	subnet := deps.TerraformModules[1]
+	assert.EqualStrings(t, "../subnet", subnet.Source)
+	assert.EqualStrings(t, "/modules/subnet", subnet.Path.String())
+	assert.EqualStrings(t, "/modules/vpc", subnet.CalledBy.String())
+
+	assert.IsTrue(t, deps.Terragrunt != nil, "terragrunt module must be detected")
+	assert.EqualStrings(t, "/infra/network/terragrunt.hcl", deps.Terragrunt.ConfigFile.String())
+	found := false
+	for _, dep := range deps.Terragrunt.DependsOn {
+		if dep.String() == "/config.json" {
+			found = true
+		}
+	}
+	assert.IsTrue(t, found, "terragrunt dependencies must include /config.json: %v", deps.Terragrunt.DependsOn)
+}
+
+func TestStackDependenciesNoDependencies(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.NoGit(t, true)
+	s.BuildTree([]string{"s:stack"})
+
+	deps, err := stack.NewManager(s.Config()).Dependencies(project.NewPath("/stack"))
+	assert.NoError(t, err)
+	assertPaths(t, deps.Parents)
+	assert.EqualInts(t, 0, len(deps.After))
+	assert.EqualInts(t, 0, len(deps.Before))
+	assertPaths(t, deps.Wants)
+	assertPaths(t, deps.WantedBy)
+	assert.EqualInts(t, 0, len(deps.TerraformModules))
+	assert.IsTrue(t, deps.Terragrunt == nil, "terragrunt change detection is disabled")
+}
+
+func TestStackDependenciesNotAStack(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.NoGit(t, true)
+	s.BuildTree([]string{"d:dir"})
+
+	_, err := stack.NewManager(s.Config()).Dependencies(project.NewPath("/dir"))
+	errtest.Assert(t, err, errors.E(stack.ErrInvalidStackDir))
+}
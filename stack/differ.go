@@ -0,0 +1,239 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package stack
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing/format/gitignore"
+	"github.com/terramate-io/terramate/errors"
+	"github.com/terramate-io/terramate/git"
+	"github.com/terramate-io/terramate/project"
+)
+
+// Differ computes the files changed in the project. The changed files are
+// mapped into changed stacks by the change detection of the [Manager].
+type Differ interface {
+	// ChangedFiles returns the files changed compared to the base reference.
+	// The meaning of the base reference depends on the differ, and it
+	// may be ignored.
+	ChangedFiles(baseRef string) (project.Paths, error)
+}
+
+// gitDiffer computes the changed files from the commits between the git base
+// reference and HEAD.
+type gitDiffer struct {
+	rootdir string
+	git     *git.Git
+}
+
+// DirDiffer computes the changed files by comparing the content of the
+// project files with the files of a snapshot directory. The files matching
+// the patterns of the .gitignore files of the project are not compared.
+type DirDiffer struct {
+	rootdir     string
+	snapshotdir string
+}
+
+// NewDirDiffer creates a differ comparing the project at rootdir with the
+// snapshot directory.
+func NewDirDiffer(rootdir, snapshotdir string) *DirDiffer {
+	return &DirDiffer{
+		rootdir:     rootdir,
+		snapshotdir: snapshotdir,
+	}
+}
+
+// ChangedFiles returns the project files which were added, modified or
+// deleted compared to the snapshot directory. The baseRef is ignored.
+func (d *DirDiffer) ChangedFiles(_ string) (project.Paths, error) {
+	st, err := os.Stat(d.snapshotdir)
+	if err != nil {
+		return nil, errors.E(err, "stat failed on snapshot directory %q", d.snapshotdir)
+	}
+	if !st.IsDir() {
+		return nil, errors.E("snapshot %q is not a directory", d.snapshotdir)
+	}
+
+	ignore := &ignorePatterns{readFiles: true}
+	current, err := hashDirFiles(d.rootdir, ignore)
+	if err != nil {
+		return nil, errors.E(err, "reading project files")
+	}
+
+	ignore.readFiles = false
+	snapshot, err := hashDirFiles(d.snapshotdir, ignore)
+	if err != nil {
+		return nil, errors.E(err, "reading snapshot files")
+	}
+
+	var changed project.Paths
+	for relpath, hash := range current {
+		if snapshotHash, ok := snapshot[relpath]; !ok || snapshotHash != hash {
+			changed = append(changed, project.NewPath("/"+relpath))
+		}
+	}
+	for relpath := range snapshot {
+		if _, ok := current[relpath]; !ok {
+			changed = append(changed, project.NewPath("/"+relpath))
+		}
+	}
+	sort.Slice(changed, func(i, j int) bool {
+		return changed[i].String() < changed[j].String()
+	})
+	return changed, nil
+}
+
+// ignorePatterns are the .gitignore patterns used when walking a directory.
+// If readFiles is set, the .gitignore files found are added to the patterns.
+type ignorePatterns struct {
+	patterns  []gitignore.Pattern
+	readFiles bool
+}
+
+func (ig *ignorePatterns) match(relpath string, isDir bool) bool {
+	return gitignore.NewMatcher(ig.patterns).Match(strings.Split(relpath, "/"), isDir)
+}
+
+// readGitIgnore adds the patterns of the .gitignore file of dir, if any.
+// The domain is the slash separated path of dir relative to the walked
+// directory.
+func (ig *ignorePatterns) readGitIgnore(dir string, domain []string) error {
+	if !ig.readFiles {
+		return nil
+	}
+	f, err := os.Open(filepath.Join(dir, ".gitignore"))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	defer func() { _ = f.Close() }()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "#") || strings.TrimSpace(line) == "" {
+			continue
+		}
+		ig.patterns = append(ig.patterns, gitignore.ParsePattern(line, domain))
+	}
+	return scanner.Err()
+}
+
+// hashDirFiles returns the hash of the content of the files inside dir,
+// indexed by their slash separated path relative to dir.
+func hashDirFiles(dir string, ignore *ignorePatterns) (map[string]string, error) {
+	files := map[string]string{}
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		relpath, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		relpath = filepath.ToSlash(relpath)
+		if relpath == "." {
+			return ignore.readGitIgnore(path, nil)
+		}
+		if d.IsDir() && d.Name() == ".git" {
+			return filepath.SkipDir
+		}
+		if ignore.match(relpath, d.IsDir()) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			return ignore.readGitIgnore(path, strings.Split(relpath, "/"))
+		}
+		hash, err := hashFile(path, d)
+		if err != nil {
+			return err
+		}
+		if hash != "" {
+			files[relpath] = hash
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// hashFile returns the hash of the file content, or of the link target if the
+// file is a symbolic link. Other kinds of files have an empty hash.
+func hashFile(path string, d fs.DirEntry) (string, error) {
+	h := sha256.New()
+	switch {
+	case d.Type()&fs.ModeSymlink != 0:
+		target, err := os.Readlink(path)
+		if err != nil {
+			return "", err
+		}
+		_, _ = h.Write([]byte("symlink:" + target))
+	case d.Type().IsRegular():
+		f, err := os.Open(path)
+		if err != nil {
+			return "", err
+		}
+		defer func() { _ = f.Close() }()
+		if _, err := io.Copy(h, f); err != nil {
+			return "", err
+		}
+	default:
+		return "", nil
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// ChangedFiles lists the files changed between the gitBaseRef and HEAD.
+func (d *gitDiffer) ChangedFiles(gitBaseRef string) (project.Paths, error) {
+	st, err := os.Stat(d.rootdir)
+	if err != nil {
+		return nil, errors.E(err, "stat failed on %q", d.rootdir)
+	}
+
+	if !st.IsDir() {
+		return nil, errors.E("is not a directory")
+	}
+
+	dirWrapper := d.git.With().WorkingDir(d.rootdir).Wrapper()
+
+	baseRef, err := dirWrapper.RevParse(gitBaseRef)
+	if err != nil {
+		return nil, errors.E(err, "getting revision %q", gitBaseRef)
+	}
+
+	headRef, err := dirWrapper.RevParse("HEAD")
+	if err != nil {
+		return nil, errors.E(err, "getting HEAD revision")
+	}
+
+	if baseRef == headRef {
+		return project.Paths{}, nil
+	}
+
+	relpaths, err := dirWrapper.DiffNames(baseRef, headRef)
+	if err != nil {
+		return project.Paths{}, errors.E(err, "git diff-tree failed")
+	}
+	var paths project.Paths
+	for _, relpath := range relpaths {
+		paths = append(paths, project.PrjAbsPath(d.rootdir, filepath.Join(d.rootdir, relpath)))
+	}
+	return paths, nil
+}
@@ -0,0 +1,183 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package stack_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/madlambda/spells/assert"
+	"github.com/terramate-io/terramate/fs"
+	"github.com/terramate-io/terramate/project"
+	"github.com/terramate-io/terramate/stack"
+	"github.com/terramate-io/terramate/test"
+	"github.com/terramate-io/terramate/test/sandbox"
+)
+
+func TestDirDifferChangedFiles(t *testing.T) {
+	t.Parallel()
+
+	type testcase struct {
+		name    string
+		project map[string]string
+		change  func(t *testing.T, rootdir string)
+		want    []string
+	}
+
+	for _, tc := range []testcase{
+		{
+			name: "no changes",
+			project: map[string]string{
+				"a/file.tf": "a",
+				"b/file.tf": "b",
+			},
+		},
+		{
+			name: "added file",
+			project: map[string]string{
+				"a/file.tf": "a",
+			},
+			change: func(t *testing.T, rootdir string) {
+				test.WriteFile(t, rootdir, "b/file.tf", "b")
+			},
+			want: []string{"/b/file.tf"},
+		},
+		{
+			name: "modified file",
+			project: map[string]string{
+				"a/file.tf": "a",
+				"b/file.tf": "b",
+			},
+			change: func(t *testing.T, rootdir string) {
+				test.WriteFile(t, rootdir, "b/file.tf", "changed")
+			},
+			want: []string{"/b/file.tf"},
+		},
+		{
+			name: "deleted file",
+			project: map[string]string{
+				"a/file.tf": "a",
+				"b/file.tf": "b",
+			},
+			change: func(t *testing.T, rootdir string) {
+				assert.NoError(t, os.Remove(filepath.Join(rootdir, "a/file.tf")))
+			},
+			want: []string{"/a/file.tf"},
+		},
+		{
+			name: "added, modified and deleted files",
+			project: map[string]string{
+				"a/file.tf": "a",
+				"b/file.tf": "b",
+			},
+			change: func(t *testing.T, rootdir string) {
+				assert.NoError(t, os.Remove(filepath.Join(rootdir, "a/file.tf")))
+				test.WriteFile(t, rootdir, "b/file.tf", "changed")
+				test.WriteFile(t, rootdir, "c/file.tf", "c")
+			},
+			want: []string{"/a/file.tf", "/b/file.tf", "/c/file.tf"},
+		},
+		{
+			name: "changes of ignored files are not detected",
+			project: map[string]string{
+				".gitignore":       "*.log\n/tmp\n",
+				"a/.gitignore":     "# comment\n\ncache/\n",
+				"a/file.tf":        "a",
+				"a/cache/file.tf":  "cache",
+				"b/cache/file.tf":  "cache",
+				"tmp/file.tf":      "tmp",
+				"b/terraform.log":  "log",
+				"b/not-ignored.tf": "b",
+			},
+			change: func(t *testing.T, rootdir string) {
+				test.WriteFile(t, rootdir, "a/cache/file.tf", "changed")
+				test.WriteFile(t, rootdir, "b/cache/file.tf", "changed")
+				test.WriteFile(t, rootdir, "tmp/file.tf", "changed")
+				test.WriteFile(t, rootdir, "b/terraform.log", "changed")
+				test.WriteFile(t, rootdir, "b/other.log", "new")
+			},
+			want: []string{"/b/cache/file.tf"},
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			rootdir := test.TempDir(t)
+			for path, content := range tc.project {
+				test.WriteFile(t, rootdir, path, content)
+			}
+
+			snapshotdir := test.TempDir(t)
+			assert.NoError(t, fs.CopyAll(snapshotdir, rootdir))
+
+			if tc.change != nil {
+				tc.change(t, rootdir)
+			}
+
+			changed, err := stack.NewDirDiffer(rootdir, snapshotdir).ChangedFiles("")
+			assert.NoError(t, err)
+
+			assertPaths(t, changed, tc.want...)
+		})
+	}
+}
+
+func TestDirDifferFailsIfSnapshotIsNotADirectory(t *testing.T) {
+	t.Parallel()
+
+	rootdir := test.TempDir(t)
+	file := test.WriteFile(t, rootdir, "file.txt", "")
+
+	_, err := stack.NewDirDiffer(rootdir, filepath.Join(rootdir, "non-existent")).ChangedFiles("")
+	assert.Error(t, err)
+
+	_, err = stack.NewDirDiffer(rootdir, file).ChangedFiles("")
+	assert.Error(t, err)
+}
+
+func TestListChangedWithDirDiffer(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.NoGit(t, true)
+	s.BuildTree([]string{
+		`s:stacks/a:watch=["/watched.txt"]`,
+		"s:stacks/b",
+		"s:stacks/c",
+		"s:stacks/d",
+		"f:watched.txt:watched",
+		"f:modules/mod/main.tf:# module",
+		`f:stacks/b/main.tf:module "mod" {
+  source = "../../modules/mod"
+}`,
+		"f:stacks/c/main.tf:# stack c",
+		"f:stacks/d/main.tf:# stack d",
+	})
+
+	snapshotdir := test.TempDir(t)
+	assert.NoError(t, fs.CopyAll(snapshotdir, s.RootDir()))
+
+	newDirDiffManager := func(t *testing.T) *stack.Manager {
+		t.Helper()
+		return stack.NewDiffAwareManager(s.Config(), nil, stack.NewDirDiffer(s.RootDir(), snapshotdir))
+	}
+
+	report, err := newDirDiffManager(t).ListChanged(stack.ChangeConfig{})
+	assert.NoError(t, err)
+	assert.EqualInts(t, 0, len(report.Stacks), "want no changed stacks")
+
+	test.WriteFile(t, s.RootDir(), "watched.txt", "changed")
+	test.WriteFile(t, s.RootDir(), "modules/mod/main.tf", "# changed module")
+	assert.NoError(t, os.Remove(filepath.Join(s.RootDir(), "stacks/c/main.tf")))
+
+	report, err = newDirDiffManager(t).ListChanged(stack.ChangeConfig{})
+	assert.NoError(t, err)
+
+	var got project.Paths
+	for _, entry := range report.Stacks {
+		got = append(got, entry.Stack.Dir)
+	}
+	assertPaths(t, got, "/stacks/a", "/stacks/b", "/stacks/c")
+}
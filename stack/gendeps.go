@@ -0,0 +1,162 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package stack
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"github.com/terramate-io/terramate/config"
+	"github.com/terramate-io/terramate/event"
+	"github.com/terramate-io/terramate/generate/genfile"
+	"github.com/terramate-io/terramate/generate/genhcl"
+	"github.com/terramate-io/terramate/globals"
+	"github.com/terramate-io/terramate/project"
+	"github.com/terramate-io/terramate/stdlib"
+)
+
+// generateDeps returns the project files read by the filesystem functions
+// (eg.: tm_file and tm_templatefile) when evaluating the generate blocks of the
+// stack. These files are implicit watch files of the stack, as changing them
+// changes its generated code.
+//
+// The dependencies are computed on every new manager, so they are always
+// up to date with the files in the working tree.
+func (m *Manager) generateDeps(st *config.Stack) project.Paths {
+	if deps, ok := m.cache.generateDeps[st.Dir]; ok {
+		return deps
+	}
+
+	deps := m.loadGenerateDeps(st)
+	if m.cache.generateDeps == nil {
+		m.cache.generateDeps = map[project.Path]project.Paths{}
+	}
+	m.cache.generateDeps[st.Dir] = deps
+	return deps
+}
+
+func (m *Manager) loadGenerateDeps(st *config.Stack) project.Paths {
+	logger := log.With().
+		Str("action", "stack.Manager.loadGenerateDeps()").
+		Stringer("stack", st.Dir).
+		Logger()
+
+	if !m.hasGenerateBlocks(st.Dir) {
+		return nil
+	}
+
+	report := globals.ForStack(m.root, st)
+	if err := report.AsError(); err != nil {
+		logger.Debug().Err(err).Msg("ignoring stack with globals evaluation errors")
+		return nil
+	}
+
+	rootdir := m.root.HostDir()
+	reads := make(chan event.FileRead)
+	collected := make(chan project.Paths)
+
+	go func() {
+		var deps project.Paths
+		seen := map[project.Path]struct{}{}
+		for read := range reads {
+			relpath, err := filepath.Rel(rootdir, read.Path)
+			if err != nil || relpath == ".." || strings.HasPrefix(relpath, ".."+string(filepath.Separator)) {
+				// files outside of the project are not tracked by git.
+				continue
+			}
+			dep := project.PrjAbsPath(rootdir, read.Path)
+			if _, ok := seen[dep]; ok {
+				continue
+			}
+			seen[dep] = struct{}{}
+			deps = append(deps, dep)
+		}
+		collected <- deps
+	}()
+
+	evalctx := NewEvalCtx(m.root, st, report.Globals)
+	funcs := stdlib.FileReadFuncs(st.HostDir(m.root), m.root.Tree().Node.Experiments(), reads)
+	for name, fn := range funcs {
+		evalctx.SetFunction(name, fn)
+	}
+
+	// the vendor dir only changes the values returned by tm_vendor and the
+	// generated code is discarded, so any vendor dir works here.
+	vendorDir := project.NewPath("/")
+	if _, err := genfile.Load(m.root, st, evalctx.Context, vendorDir, nil); err != nil {
+		logger.Debug().Err(err).Msg("evaluating generate_file blocks")
+	}
+	if _, err := genhcl.Load(m.root, st, evalctx.Context, vendorDir, nil); err != nil {
+		logger.Debug().Err(err).Msg("evaluating generate_hcl blocks")
+	}
+
+	close(reads)
+	deps := <-collected
+	deps.Sort()
+	return deps
+}
+
+// generateConfigFiles returns the configuration files defining the globals and
+// the generate blocks inherited by the stack from its parent directories,
+// including the imported files. Changing these files changes the generated
+// code of the stack, as the stack is generated from the configuration of all
+// of its parent scopes.
+func (m *Manager) generateConfigFiles(st *config.Stack) project.Paths {
+	if !m.hasGenerateBlocks(st.Dir) {
+		return nil
+	}
+
+	var files project.Paths
+	seen := map[project.Path]struct{}{}
+	add := func(file project.Path) {
+		if _, ok := seen[file]; ok {
+			return
+		}
+		seen[file] = struct{}{}
+		files = append(files, file)
+	}
+
+	// the files of the stack directory are already detected as changes of the
+	// stack itself.
+	for dir := st.Dir.Dir(); ; dir = dir.Dir() {
+		cfg, ok := m.root.Lookup(dir)
+		if ok && !cfg.IsEmptyConfig() {
+			for _, globals := range cfg.Node.Globals {
+				for _, origin := range globals.RawOrigins {
+					add(origin.Range.Path())
+				}
+			}
+			for _, block := range cfg.Node.Generate.Files {
+				add(block.Range.Path())
+			}
+			for _, block := range cfg.Node.Generate.HCLs {
+				add(block.Range.Path())
+			}
+		}
+		if dir.String() == "/" {
+			break
+		}
+	}
+	files.Sort()
+	return files
+}
+
+// hasGenerateBlocks tells if the directory or any of its parents have generate
+// blocks, which are inherited by the stacks.
+func (m *Manager) hasGenerateBlocks(dir project.Path) bool {
+	for {
+		cfg, ok := m.root.Lookup(dir)
+		if ok && !cfg.IsEmptyConfig() {
+			if len(cfg.Node.Generate.Files) > 0 || len(cfg.Node.Generate.HCLs) > 0 {
+				return true
+			}
+		}
+		parent := dir.Dir()
+		if parent == dir {
+			return false
+		}
+		dir = parent
+	}
+}
@@ -12,10 +12,14 @@ import (
 	"sort"
 	"strings"
 
+	"github.com/gobwas/glob"
 	"github.com/rs/zerolog/log"
 	"github.com/terramate-io/terramate/config"
 	"github.com/terramate-io/terramate/errors"
+	tmfs "github.com/terramate-io/terramate/fs"
 	"github.com/terramate-io/terramate/git"
+	"github.com/terramate-io/terramate/globals"
+	"github.com/terramate-io/terramate/hcl"
 	"github.com/terramate-io/terramate/printer"
 	"github.com/terramate-io/terramate/project"
 	"github.com/terramate-io/terramate/run"
@@ -28,21 +32,49 @@ import (
 type (
 	// Manager is the terramate stacks manager.
 	Manager struct {
-		root *config.Root // whole config
-		git  *git.Git
+		root   *config.Root // whole config
+		git    *git.Git
+		differ Differ
 
 		cache struct {
 			stacks       []Entry
 			stacksMap    map[string]Entry
-			changedFiles map[string]project.Paths // gitBaseRef -> changed files
+			changedFiles map[string]project.Paths // baseRef -> changed files
+			generateDeps map[project.Path]project.Paths
 		}
 	}
 
+	// ChangeDetector detects the changed stacks one stack at a time, so the
+	// stacks can be processed while the change detection is in progress.
+	ChangeDetector struct {
+		m            *Manager
+		cfg          ChangeConfig
+		checks       RepoChecks
+		changedFiles project.Paths
+
+		// generateDepFiles are the changed files which are not Terramate
+		// configuration files, indexed by the directory of the stack
+		// containing them ("/" outside of stacks). Only these are checked
+		// against the files read by the generate blocks of the other stacks,
+		// as the configuration files already change the configuration.
+		generateDepFiles map[project.Path]project.Paths
+
+		// stackSet has the stacks changed by files in their directories or
+		// by trigger files.
+		stackSet  map[project.Path]Entry
+		ignoreSet map[project.Path]struct{}
+	}
+
 	// ChangeConfig is the configuration for the ListChanged method.
 	ChangeConfig struct {
 		BaseRef            string
 		UncommittedChanges *bool
 		UntrackedChanges   *bool
+
+		// GenerateConfig enables the detection of stacks changed by the
+		// files defining the globals and generate blocks inherited from
+		// their parent directories, which change their generated code.
+		GenerateConfig bool
 	}
 
 	// Report is the report of project's stacks and the result of its default checks.
@@ -84,10 +116,23 @@ func NewManager(root *config.Root) *Manager {
 }
 
 // NewGitAwareManager returns a stack manager that supports change detection.
+// The changed files are the ones changed between the git base reference and
+// HEAD, plus the untracked and uncommitted files if enabled.
 func NewGitAwareManager(root *config.Root, git *git.Git) *Manager {
+	return NewDiffAwareManager(root, git, &gitDiffer{
+		rootdir: root.HostDir(),
+		git:     git,
+	})
+}
+
+// NewDiffAwareManager returns a stack manager that supports change detection
+// with the changed files computed by the given differ. The git is optional
+// and only used for the repository checks.
+func NewDiffAwareManager(root *config.Root, git *git.Git, differ Differ) *Manager {
 	m := &Manager{
-		root: root,
-		git:  git,
+		root:   root,
+		git:    git,
+		differ: differ,
 	}
 	m.cache.changedFiles = make(map[string]project.Paths)
 	return m
@@ -130,17 +175,152 @@ func (m *Manager) ListChanged(cfg ChangeConfig) (*Report, error) {
 		Str("action", "ListChanged()").
 		Logger()
 
-	if !m.git.IsRepository() {
-		return nil, errors.E(
-			ErrListChanged,
-			"the path \"%s\" is not a git repository",
-			m.root.HostDir(),
-		)
+	detector, err := m.NewChangeDetector(cfg)
+	if err != nil {
+		return nil, err
 	}
 
-	checks, err := checkRepoIsClean(m.git)
+	if len(detector.changedFiles) == 0 {
+		return &Report{
+			Checks: detector.checks,
+		}, nil
+	}
+
+	stackSet := detector.stackSet
+	ignoreSet := detector.ignoreSet
+
+	allstacks, err := m.allStacks()
 	if err != nil {
-		return nil, errors.E(ErrListChanged, err)
+		return nil, err
+	}
+
+	tgModulesMap := make(map[project.Path]*tg.Module)
+	var tgModules tg.Modules
+
+	if m.root.IsTerragruntChangeDetectionEnabled() {
+		// discover Terragrunt modules
+		tgModules, err = tg.ScanModules(m.root.HostDir(), project.NewPath("/"), false)
+		if err != nil {
+			return nil, errors.E(ErrListChanged, err, "scanning terragrunt modules")
+		}
+
+		for _, mod := range tgModules {
+			tgModulesMap[mod.Path] = mod
+		}
+	}
+
+rangeStacks:
+	for _, stackEntry := range allstacks {
+		stack := stackEntry.Stack
+		if _, ok := stackSet[stack.Dir]; ok {
+			continue
+		}
+
+		if entry, ok := detector.watchedFilesChanged(stack); ok {
+			stackSet[stack.Dir] = entry
+			continue rangeStacks
+		}
+
+		if entry, ok := detector.generateDepsChanged(stack); ok {
+			stackSet[stack.Dir] = entry
+			continue rangeStacks
+		}
+
+		if entry, ok := detector.generateConfigChanged(stack); ok {
+			stackSet[stack.Dir] = entry
+			continue rangeStacks
+		}
+
+		entry, changed, err := detector.tfModulesChanged(stack)
+		if err != nil {
+			return nil, err
+		}
+		if changed {
+			stackSet[stack.Dir] = entry
+		}
+
+		// tgModulesMap is only populated if Terragrunt is enabled.
+		tgMod, ok := tgModulesMap[stack.Dir]
+		if !ok {
+			continue
+		}
+
+		changed, why, err := m.tgModuleChanged(stack, tgMod, cfg.BaseRef, stackSet, tgModulesMap)
+		if err != nil {
+			return nil, errors.E(ErrListChanged, err, "checking if Terragrunt module changes")
+		}
+
+		if changed {
+			logger.Debug().
+				Stringer("stack", stack).
+				Str("changed", tgMod.Source).
+				Msg("Terragrunt module changed.")
+
+			stack.IsChanged = true
+			stackSet[stack.Dir] = Entry{
+				Stack:  stack,
+				Reason: fmt.Sprintf("stack changed because module %q changed because %s", tgMod.Path, why),
+			}
+			continue rangeStacks
+		}
+	}
+
+	for ignored := range ignoreSet {
+		delete(stackSet, ignored)
+	}
+
+	if m.root.IsOutputsPropagationEnabled() {
+		err := m.addInputDependents(allstacks, stackSet, ignoreSet)
+		if err != nil {
+			return nil, errors.E(ErrListChanged, err, "propagating changes to input dependents")
+		}
+	}
+
+	changedStacks := make(config.List[Entry], 0, len(stackSet))
+	for _, stack := range stackSet {
+		changedStacks = append(changedStacks, stack)
+	}
+
+	sort.Sort(changedStacks)
+
+	return &Report{
+		Checks: detector.checks,
+		Stacks: changedStacks,
+	}, nil
+}
+
+// NewChangeDetector creates a change detector for the given configuration.
+// The changed files and the trigger files are computed at this point, but the
+// Terraform modules of the stacks are only checked by [ChangeDetector.Changed].
+// When the changes are detected by git, it's an error to call this method in
+// a directory that's not inside a repository or a repository with no commits
+// in it.
+func (m *Manager) NewChangeDetector(cfg ChangeConfig) (*ChangeDetector, error) {
+	_, isGitDiffer := m.differ.(*gitDiffer)
+	if m.differ == nil || isGitDiffer {
+		if m.git == nil || !m.git.IsRepository() {
+			return nil, errors.E(
+				ErrListChanged,
+				"the path \"%s\" is not a git repository",
+				m.root.HostDir(),
+			)
+		}
+	}
+
+	var (
+		checks RepoChecks
+		err    error
+	)
+	if m.git != nil && m.git.IsRepository() {
+		checks, err = checkRepoIsClean(m.git)
+		if err != nil {
+			return nil, errors.E(ErrListChanged, err)
+		}
+	}
+
+	if !isGitDiffer {
+		// the dirty files are already part of the changes of other differs.
+		return m.newChangeDetector(cfg, checks)
 	}
 
 	var dirtyFiles project.Paths
@@ -172,19 +352,36 @@ func (m *Manager) ListChanged(cfg ChangeConfig) (*Report, error) {
 		dirtyFiles = append(dirtyFiles, checks.UntrackedFiles...)
 	}
 
+	return m.newChangeDetector(cfg, checks, dirtyFiles...)
+}
+
+func (m *Manager) newChangeDetector(cfg ChangeConfig, checks RepoChecks, dirtyFiles ...project.Path) (*ChangeDetector, error) {
+	logger := log.With().
+		Str("action", "NewChangeDetector()").
+		Logger()
+
 	changedFiles, err := m.changedFiles(cfg.BaseRef, dirtyFiles...)
 	if err != nil {
 		return nil, errors.E(ErrListChanged, err)
 	}
 
-	if len(changedFiles) == 0 {
-		return &Report{
-			Checks: checks,
-		}, nil
+	detector := &ChangeDetector{
+		m:            m,
+		cfg:          cfg,
+		checks:       checks,
+		changedFiles: changedFiles,
+		stackSet:     map[project.Path]Entry{},
+		ignoreSet:    map[project.Path]struct{}{},
+
+		generateDepFiles: map[project.Path]project.Paths{},
 	}
 
-	stackSet := map[project.Path]Entry{}
-	ignoreSet := map[project.Path]struct{}{}
+	stackSet := detector.stackSet
+	ignoreSet := detector.ignoreSet
+
+	// the changed files attributed to each stack, in the order they are found.
+	stackFiles := map[project.Path]*stackChangedFiles{}
+	var stackOrder []project.Path
 
 	for _, projpath := range changedFiles {
 		logger = logger.With().
@@ -233,6 +430,10 @@ func (m *Manager) ListChanged(cfg ChangeConfig) (*Report, error) {
 				return nil, errors.E(ErrListChanged, err)
 			}
 
+			s.TriggerPriority = triggerInfo.Priority
+			if prev, ok := stackSet[s.Dir]; ok && prev.Stack.TriggerPriority > s.TriggerPriority {
+				s.TriggerPriority = prev.Stack.TriggerPriority
+			}
 			stackSet[s.Dir] = Entry{
 				Stack:  s,
 				Reason: "stack has been triggered by: " + projpath.String(),
@@ -242,10 +443,6 @@ func (m *Manager) ListChanged(cfg ChangeConfig) (*Report, error) {
 
 		dirname := filepath.Dir(abspath)
 
-		if _, ok := stackSet[project.PrjAbsPath(m.root.HostDir(), dirname)]; ok {
-			continue
-		}
-
 		cfgpath := project.PrjAbsPath(m.root.HostDir(), dirname)
 		stackTree, found := m.root.Lookup(cfgpath)
 		if !found || !stackTree.IsStack() {
@@ -259,149 +456,342 @@ func (m *Manager) ListChanged(cfg ChangeConfig) (*Report, error) {
 				}
 			}
 			if !found || !stackTree.IsStack() {
+				detector.addGenerateDepFile(project.NewPath("/"), projpath)
 				continue
 			}
 		}
+		detector.addGenerateDepFile(stackTree.Dir(), projpath)
+
+		files, ok := stackFiles[stackTree.Dir()]
+		if !ok {
+			s, err := config.NewStackFromHCL(m.root.HostDir(), stackTree.Node)
+			if err != nil {
+				return nil, errors.E(ErrListChanged, err)
+			}
+			files = &stackChangedFiles{stack: s}
+			stackFiles[s.Dir] = files
+			stackOrder = append(stackOrder, s.Dir)
+		}
+		files.add(projpath)
+	}
 
-		s, err := config.NewStackFromHCL(m.root.HostDir(), stackTree.Node)
+	for _, dir := range stackOrder {
+		if _, ok := stackSet[dir]; ok {
+			// triggered stacks.
+			continue
+		}
+		files := stackFiles[dir]
+		entry, changed, err := files.entry()
 		if err != nil {
 			return nil, errors.E(ErrListChanged, err)
 		}
-
-		stackSet[s.Dir] = Entry{
-			Stack:  s,
-			Reason: "stack has unmerged changes",
+		if !changed {
+			logger.Debug().
+				Stringer("stack", dir).
+				Msg("all changed files of the stack are ignored by stack.ignore_on_change")
+			continue
 		}
+		stackSet[dir] = entry
 	}
+	return detector, nil
+}
 
-	allstacks, err := m.allStacks()
-	if err != nil {
-		return nil, err
-	}
+// stackChangedFiles are the changed files attributed to a stack, which are the
+// files inside the stack directory or inside its non-stack sub directories.
+type stackChangedFiles struct {
+	stack *config.Stack
+	files project.Paths
+}
 
-	tgModulesMap := make(map[project.Path]*tg.Module)
-	var tgModules tg.Modules
+func (c *stackChangedFiles) add(file project.Path) {
+	c.files = append(c.files, file)
+}
 
-	if m.root.IsTerragruntChangeDetectionEnabled() {
-		// discover Terragrunt modules
-		tgModules, err = tg.ScanModules(m.root.HostDir(), project.NewPath("/"), false)
+// entry returns the change entry of the stack, if any of its changed files is
+// not ignored by the stack.ignore_on_change patterns. The reason of the change
+// only mentions the files which are not ignored.
+func (c *stackChangedFiles) entry() (Entry, bool, error) {
+	if len(c.stack.IgnoreOnChange) == 0 {
+		return Entry{
+			Stack:  c.stack,
+			Reason: "stack has unmerged changes",
+		}, true, nil
+	}
+
+	patterns := make([]glob.Glob, 0, len(c.stack.IgnoreOnChange))
+	for _, pattern := range c.stack.IgnoreOnChange {
+		g, err := glob.Compile(pattern, '/')
 		if err != nil {
-			return nil, errors.E(ErrListChanged, err, "scanning terragrunt modules")
+			return Entry{}, false, errors.E(err, "stack %s: compiling stack.ignore_on_change pattern %q", c.stack.Dir, pattern)
 		}
+		patterns = append(patterns, g)
+	}
 
-		for _, mod := range tgModules {
-			tgModulesMap[mod.Path] = mod
+	var changed project.Paths
+	for _, file := range c.files {
+		relpath := strings.TrimPrefix(file.String(), c.stack.Dir.String())
+		relpath = strings.TrimPrefix(relpath, "/")
+		if !hcl.MatchAnyGlob(patterns, relpath) {
+			changed = append(changed, file)
 		}
 	}
 
-rangeStacks:
-	for _, stackEntry := range allstacks {
-		stack := stackEntry.Stack
-		if _, ok := stackSet[stack.Dir]; ok {
-			continue
-		}
+	switch len(changed) {
+	case 0:
+		return Entry{}, false, nil
+	case len(c.files):
+		return Entry{
+			Stack:  c.stack,
+			Reason: "stack has unmerged changes",
+		}, true, nil
+	}
 
-		if changed, ok := hasChangedWatchedFiles(stack, changedFiles); ok {
-			logger.Debug().
-				Stringer("stack", stack).
-				Stringer("watchfile", changed).
-				Msg("changed.")
+	reason := fmt.Sprintf("stack has unmerged changes in %q", changed[0])
+	if len(changed) > 1 {
+		reason += fmt.Sprintf(" and %d other files", len(changed)-1)
+	}
+	return Entry{
+		Stack:  c.stack,
+		Reason: reason,
+	}, true, nil
+}
 
-			stack.IsChanged = true
-			stackSet[stack.Dir] = Entry{
-				Stack: stack,
-				Reason: fmt.Sprintf(
-					"stack changed because watched file %q changed",
-					changed,
-				),
-			}
-			continue rangeStacks
-		}
+// Checks returns the result of the default checks of the repository.
+func (d *ChangeDetector) Checks() RepoChecks {
+	return d.checks
+}
 
-		// Terraform module change detection
-		err := m.filesApply(stack.Dir, func(fname string) error {
-			if path.Ext(fname) != ".tf" {
-				return nil
-			}
+// IsIncremental tells if the change of each stack can be detected
+// independently of the other stacks. This is not the case when the Terragrunt
+// change detection or the propagation of changes to output dependents is
+// enabled, then only [Manager.ListChanged] gives the correct result.
+func (d *ChangeDetector) IsIncremental() bool {
+	return !d.m.root.IsTerragruntChangeDetectionEnabled() && !d.m.root.IsOutputsPropagationEnabled()
+}
 
-			tfpath := filepath.Join(stack.HostDir(m.root), fname)
+// Changed tells if the given stack has changed, returning its entry with the
+// reason of the change. It gives the same result as [Manager.ListChanged] if
+// the change detection is incremental, see [ChangeDetector.IsIncremental].
+// It's not safe for concurrent use.
+func (d *ChangeDetector) Changed(stack *config.Stack) (Entry, bool, error) {
+	if len(d.changedFiles) == 0 {
+		return Entry{}, false, nil
+	}
+	if _, ok := d.ignoreSet[stack.Dir]; ok {
+		return Entry{}, false, nil
+	}
+	if entry, ok := d.stackSet[stack.Dir]; ok {
+		return entry, true, nil
+	}
+	if entry, ok := d.watchedFilesChanged(stack); ok {
+		return entry, true, nil
+	}
+	if entry, ok := d.generateDepsChanged(stack); ok {
+		return entry, true, nil
+	}
+	if entry, ok := d.generateConfigChanged(stack); ok {
+		return entry, true, nil
+	}
+	return d.tfModulesChanged(stack)
+}
 
-			modules, err := tf.ParseModules(tfpath)
-			if err != nil {
-				return errors.E(ErrListChanged, "parsing modules", err)
-			}
+func (d *ChangeDetector) watchedFilesChanged(stack *config.Stack) (Entry, bool) {
+	changed, ok := hasChangedWatchedFiles(stack, d.changedFiles)
+	if !ok {
+		return Entry{}, false
+	}
 
-			for _, mod := range modules {
-				changed, why, err := m.tfModuleChanged(mod, stack.HostDir(m.root), cfg.BaseRef, make(map[string]bool))
-				if err != nil {
-					return errors.E(ErrListChanged, err, "checking module %q", mod.Source)
-				}
+	log.Debug().
+		Stringer("stack", stack).
+		Stringer("watchfile", changed).
+		Msg("changed.")
+
+	stack.IsChanged = true
+	return Entry{
+		Stack: stack,
+		Reason: fmt.Sprintf(
+			"stack changed because watched file %q changed",
+			changed,
+		),
+	}, true
+}
 
-				if changed {
-					logger.Debug().
-						Stringer("stack", stack).
-						Str("configFile", tfpath).
-						Msg("Module changed.")
-
-					stack.IsChanged = true
-					stackSet[stack.Dir] = Entry{
-						Stack: stack,
-						Reason: fmt.Sprintf(
-							"stack changed because %q changed because %s",
-							mod.Source, why,
-						),
-					}
-					return nil
-				}
-			}
-			return nil
-		})
+// addGenerateDepFile adds the changed file, contained by the stack at dir, to
+// the files checked against the files read by generate blocks.
+func (d *ChangeDetector) addGenerateDepFile(dir project.Path, file project.Path) {
+	if tmfs.IsTerramateFile(path.Base(file.String())) {
+		return
+	}
+	d.generateDepFiles[dir] = append(d.generateDepFiles[dir], file)
+}
 
-		if err != nil {
-			return nil, errors.E(ErrListChanged, "checking if Terraform module changes", err)
+// generateDepsChanged checks if any of the files read by the generate blocks
+// of the stack has changed, wherever the files are, including inside other
+// stacks. The changed files of the stack itself are not considered, as they
+// already change the stack unless ignored by stack.ignore_on_change. The
+// generate blocks are only evaluated if there are changed files which can be
+// read by them.
+func (d *ChangeDetector) generateDepsChanged(stack *config.Stack) (Entry, bool) {
+	var files project.Paths
+	for dir, dirFiles := range d.generateDepFiles {
+		if dir != stack.Dir {
+			files = append(files, dirFiles...)
 		}
+	}
+	if len(files) == 0 {
+		return Entry{}, false
+	}
+	changed, ok := hasChangedFiles(d.m.generateDeps(stack), files)
+	if !ok {
+		return Entry{}, false
+	}
 
-		// tgModulesMap is only populated if Terragrunt is enabled.
-		tgMod, ok := tgModulesMap[stack.Dir]
-		if !ok {
-			continue
+	log.Debug().
+		Stringer("stack", stack).
+		Stringer("generate_dependency", changed).
+		Msg("changed.")
+
+	stack.IsChanged = true
+	return Entry{
+		Stack: stack,
+		Reason: fmt.Sprintf(
+			"stack changed because file %q read by its generate blocks changed",
+			changed,
+		),
+	}, true
+}
+
+// generateConfigChanged checks if any of the configuration files defining the
+// globals or the generate blocks inherited by the stack has changed. It's only
+// checked if enabled by [ChangeConfig.GenerateConfig].
+func (d *ChangeDetector) generateConfigChanged(stack *config.Stack) (Entry, bool) {
+	if !d.cfg.GenerateConfig {
+		return Entry{}, false
+	}
+	changed, ok := hasChangedFiles(d.m.generateConfigFiles(stack), d.changedFiles)
+	if !ok {
+		return Entry{}, false
+	}
+
+	log.Debug().
+		Stringer("stack", stack).
+		Stringer("generate_config", changed).
+		Msg("changed.")
+
+	stack.IsChanged = true
+	return Entry{
+		Stack: stack,
+		Reason: fmt.Sprintf(
+			"stack changed because the configuration file %q of its generated code changed",
+			changed,
+		),
+	}, true
+}
+
+// tfModulesChanged checks if any of the Terraform modules used by the stack
+// has changed.
+func (d *ChangeDetector) tfModulesChanged(stack *config.Stack) (entry Entry, changed bool, err error) {
+	m := d.m
+	err = m.filesApply(stack.Dir, func(fname string) error {
+		if !tf.IsTerraformFile(fname) {
+			return nil
 		}
 
-		changed, why, err := m.tgModuleChanged(stack, tgMod, cfg.BaseRef, stackSet, tgModulesMap)
+		tfpath := filepath.Join(stack.HostDir(m.root), fname)
+
+		modules, err := tf.ParseModules(tfpath)
 		if err != nil {
-			return nil, errors.E(ErrListChanged, err, "checking if Terragrunt module changes")
+			return errors.E(ErrListChanged, "parsing modules", err)
 		}
 
-		if changed {
-			logger.Debug().
-				Stringer("stack", stack).
-				Str("changed", tgMod.Source).
-				Msg("Terragrunt module changed.")
+		for _, mod := range modules {
+			modChanged, why, err := m.tfModuleChanged(mod, stack.HostDir(m.root), d.cfg.BaseRef, make(map[string]bool))
+			if err != nil {
+				return errors.E(ErrListChanged, err, "checking module %q", mod.Source)
+			}
 
-			stack.IsChanged = true
-			stackSet[stack.Dir] = Entry{
-				Stack:  stack,
-				Reason: fmt.Sprintf("stack changed because module %q changed because %s", tgMod.Path, why),
+			if modChanged {
+				log.Debug().
+					Stringer("stack", stack).
+					Str("configFile", tfpath).
+					Msg("Module changed.")
+
+				stack.IsChanged = true
+				changed = true
+				entry = Entry{
+					Stack: stack,
+					Reason: fmt.Sprintf(
+						"stack changed because %q changed because %s",
+						mod.Source, why,
+					),
+				}
+				return nil
 			}
-			continue rangeStacks
 		}
-	}
+		return nil
+	})
 
-	for ignored := range ignoreSet {
-		delete(stackSet, ignored)
+	if err != nil {
+		return Entry{}, false, errors.E(ErrListChanged, "checking if Terraform module changes", err)
 	}
+	return entry, changed, nil
+}
 
-	changedStacks := make(config.List[Entry], 0, len(stackSet))
-	for _, stack := range stackSet {
-		changedStacks = append(changedStacks, stack)
+// addInputDependents adds to the changed stackSet the stacks having inputs from
+// changed stacks, transitively. Stacks with ignored changes are never added.
+func (m *Manager) addInputDependents(allstacks []Entry, stackSet map[project.Path]Entry, ignoreSet map[project.Path]struct{}) error {
+	// stack.id -> stacks with inputs from the stack.
+	dependents := map[string][]*config.Stack{}
+	for _, entry := range allstacks {
+		st := entry.Stack
+		cfg, found := m.root.Lookup(st.Dir)
+		if !found || len(cfg.Node.Inputs) == 0 {
+			continue
+		}
+		report := globals.ForStack(m.root, st)
+		if err := report.AsError(); err != nil {
+			return errors.E(err, "evaluating globals of stack %s", st.Dir)
+		}
+		evalctx := NewEvalCtx(m.root, st, report.Globals)
+		for _, input := range cfg.Node.Inputs {
+			fromStackID, err := config.EvalInputFromStackID(evalctx.Context, input)
+			if err != nil {
+				return errors.E(err, "evaluating input.%s.from_stack_id of stack %s", input.Name, st.Dir)
+			}
+			dependents[fromStackID] = append(dependents[fromStackID], st)
+		}
 	}
 
-	sort.Sort(changedStacks)
+	changed := make([]*config.Stack, 0, len(stackSet))
+	for _, entry := range stackSet {
+		changed = append(changed, entry.Stack)
+	}
+	sort.Slice(changed, func(i, j int) bool {
+		return changed[i].Dir.String() < changed[j].Dir.String()
+	})
 
-	return &Report{
-		Checks: checks,
-		Stacks: changedStacks,
-	}, nil
+	for len(changed) > 0 {
+		st := changed[0]
+		changed = changed[1:]
+		if st.ID == "" {
+			continue
+		}
+		for _, dependent := range dependents[st.ID] {
+			if _, ok := stackSet[dependent.Dir]; ok {
+				continue
+			}
+			if _, ok := ignoreSet[dependent.Dir]; ok {
+				continue
+			}
+			dependent.IsChanged = true
+			stackSet[dependent.Dir] = Entry{
+				Stack:  dependent,
+				Reason: fmt.Sprintf("input dependency changed: stack %s", st.Dir),
+			}
+			changed = append(changed, dependent)
+		}
+	}
+	return nil
 }
 
 func (m *Manager) allStacks() ([]Entry, error) {
@@ -483,14 +873,26 @@ func (m *Manager) AddWantedOf(scopeStacks config.List[*config.SortableStack]) (c
 		}
 	}
 
+	// the scope stacks are kept as is because they carry the change detection
+	// state (eg.: IsChanged and TriggerPriority).
+	scopeLookup := map[dag.ID]*config.SortableStack{}
+	for _, s := range scopeStacks {
+		scopeLookup[dag.ID(s.Dir().String())] = s
+	}
+
 	var selectedStacks config.List[*config.SortableStack]
 	visited = dag.Visited{}
 	addStack := func(s *config.Stack) {
-		if _, ok := visited[dag.ID(s.Dir.String())]; ok {
+		id := dag.ID(s.Dir.String())
+		if _, ok := visited[id]; ok {
 			return
 		}
 
-		visited[dag.ID(s.Dir.String())] = struct{}{}
+		visited[id] = struct{}{}
+		if scoped, ok := scopeLookup[id]; ok {
+			selectedStacks = append(selectedStacks, scoped)
+			return
+		}
 		selectedStacks = append(selectedStacks, s.Sortable())
 	}
 
@@ -615,7 +1017,7 @@ func (m *Manager) tfModuleChanged(
 		if changed {
 			return nil
 		}
-		if path.Ext(fname) != ".tf" {
+		if !tf.IsTerraformFile(fname) {
 			return nil
 		}
 
@@ -651,9 +1053,13 @@ func (m *Manager) tfModuleChanged(
 func (m *Manager) changedFiles(gitBaseRef string, dirtyFiles ...project.Path) (project.Paths, error) {
 	_, ok := m.cache.changedFiles[gitBaseRef]
 	if !ok {
+		if m.differ == nil {
+			return nil, errors.E(ErrListChanged, "change detection is not supported")
+		}
+
 		var err error
 
-		m.cache.changedFiles[gitBaseRef], err = m.listChangedFiles(m.root.HostDir(), gitBaseRef)
+		m.cache.changedFiles[gitBaseRef], err = m.differ.ChangedFiles(gitBaseRef)
 		if err != nil {
 			return nil, errors.E(ErrListChanged, err)
 		}
@@ -733,46 +1139,12 @@ func (m *Manager) tgModuleChanged(
 	return false, "", nil
 }
 
-// listChangedFiles lists all changed files in the dir directory.
-func (m *Manager) listChangedFiles(dir string, gitBaseRef string) (project.Paths, error) {
-	st, err := os.Stat(dir)
-	if err != nil {
-		return nil, errors.E(err, "stat failed on %q", dir)
-	}
-
-	if !st.IsDir() {
-		return nil, errors.E("is not a directory")
-	}
-
-	dirWrapper := m.git.With().WorkingDir(dir).Wrapper()
-
-	baseRef, err := dirWrapper.RevParse(gitBaseRef)
-	if err != nil {
-		return nil, errors.E(err, "getting revision %q", gitBaseRef)
-	}
-
-	headRef, err := dirWrapper.RevParse("HEAD")
-	if err != nil {
-		return nil, errors.E(err, "getting HEAD revision")
-	}
-
-	if baseRef == headRef {
-		return project.Paths{}, nil
-	}
-
-	relpaths, err := dirWrapper.DiffNames(baseRef, headRef)
-	if err != nil {
-		return project.Paths{}, errors.E(err, "git diff-tree failed")
-	}
-	var paths project.Paths
-	for _, relpath := range relpaths {
-		paths = append(paths, project.PrjAbsPath(dir, filepath.Join(dir, relpath)))
-	}
-	return paths, nil
+func hasChangedWatchedFiles(stack *config.Stack, changedFiles project.Paths) (project.Path, bool) {
+	return hasChangedFiles(stack.Watch, changedFiles)
 }
 
-func hasChangedWatchedFiles(stack *config.Stack, changedFiles project.Paths) (project.Path, bool) {
-	for _, watchFile := range stack.Watch {
+func hasChangedFiles(files project.Paths, changedFiles project.Paths) (project.Path, bool) {
+	for _, watchFile := range files {
 		for _, file := range changedFiles {
 			if file.String() == watchFile.String() {
 				return watchFile, true
@@ -15,6 +15,7 @@ import (
 	"github.com/terramate-io/terramate/stack"
 	"github.com/terramate-io/terramate/test"
 	. "github.com/terramate-io/terramate/test/hclwrite/hclutils"
+	"github.com/terramate-io/terramate/test/sandbox"
 )
 
 type repository struct {
@@ -334,6 +335,81 @@ func TestListChangedStackReason(t *testing.T) {
 	}
 }
 
+func TestListChangedIgnoreOnChange(t *testing.T) {
+	t.Parallel()
+
+	type testcase struct {
+		name    string
+		changes []string
+		want    map[string]string
+	}
+
+	for _, tc := range []testcase{
+		{
+			name:    "only ignored files changed",
+			changes: []string{"stack/README.md", "stack/docs/diagram.png", "stack/docs/arch/overview.svg"},
+			want: map[string]string{
+				"/other": "stack has unmerged changes",
+			},
+		},
+		{
+			name:    "ignored and not ignored files changed",
+			changes: []string{"stack/README.md", "stack/main.tf", "stack/docs/diagram.png"},
+			want: map[string]string{
+				"/other": "stack has unmerged changes",
+				"/stack": `stack has unmerged changes in "/stack/main.tf"`,
+			},
+		},
+		{
+			name:    "multiple not ignored files changed",
+			changes: []string{"stack/README.md", "stack/main.tf", "stack/modules/README.txt"},
+			want: map[string]string{
+				"/other": "stack has unmerged changes",
+				"/stack": `stack has unmerged changes in "/stack/main.tf" and 1 other files`,
+			},
+		},
+		{
+			name:    "patterns are relative to the stack directory",
+			changes: []string{"stack/sub/README.md"},
+			want: map[string]string{
+				"/other": "stack has unmerged changes",
+				"/stack": "stack has unmerged changes",
+			},
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			s := sandbox.New(t)
+			s.BuildTree([]string{
+				`s:stack:ignore_on_change=["*.md","docs/**"]`,
+				"s:other",
+			})
+			s.Git().CommitAll("create stacks")
+			s.Git().Push("main")
+			s.Git().CheckoutNew("change")
+
+			// changes of the other stack are never ignored.
+			s.RootEntry().CreateFile("other/README.md", "# other")
+			for _, file := range tc.changes {
+				s.RootEntry().CreateFile(file, "changed")
+			}
+			s.Git().CommitAll("change files")
+
+			m := newManager(t, s.RootDir())
+			report, err := m.ListChanged(stack.ChangeConfig{BaseRef: defaultBranch})
+			assert.NoError(t, err)
+
+			got := map[string]string{}
+			for _, entry := range report.Stacks {
+				got[entry.Stack.Dir.String()] = entry.Reason
+			}
+			test.AssertDiff(t, got, tc.want)
+		})
+	}
+}
+
 func assertStacks(
 	t *testing.T, want []string, got []stack.Entry, wantReason bool,
 ) {
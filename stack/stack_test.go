@@ -4,12 +4,15 @@
 package stack_test
 
 import (
+	"strings"
 	"testing"
 
+	"github.com/google/go-cmp/cmp"
 	"github.com/madlambda/spells/assert"
 	"github.com/rs/zerolog"
 	"github.com/terramate-io/terramate/config"
 	"github.com/terramate-io/terramate/errors"
+	"github.com/terramate-io/terramate/project"
 	"github.com/terramate-io/terramate/test/sandbox"
 )
 
@@ -26,6 +29,60 @@ func TestLoadAllFailsIfStacksIDIsNotUnique(t *testing.T) {
 	assert.IsError(t, err, errors.E(config.ErrStackDuplicatedID))
 }
 
+func TestLoadAllListsAllDuplicatedStackIDs(t *testing.T) {
+	t.Parallel()
+	s := sandbox.NoGit(t, true)
+	s.BuildTree([]string{
+		"s:stacks/c:id=first",
+		"s:stacks/a:id=FIRST",
+		"s:stacks/b:id=second",
+		"s:stacks/d:id=second",
+		"s:stacks/e:id=first",
+		"s:stacks/f:id=unique",
+		"s:stacks/g",
+	})
+	root, err := config.LoadRoot(s.RootDir())
+	assert.NoError(t, err)
+
+	_, err = config.LoadAllStacks(root, root.Tree())
+	assert.IsError(t, err, errors.E(config.ErrStackDuplicatedID))
+	assert.IsTrue(t,
+		strings.Contains(err.Error(), `stack ID "FIRST" is defined by the stacks /stacks/a, /stacks/c, /stacks/e; `+
+			`stack ID "second" is defined by the stacks /stacks/b, /stacks/d`),
+		"unexpected error: %v", err)
+
+	dups, err := config.FindDuplicatedStackIDs(root.Tree())
+	assert.NoError(t, err)
+	want := []config.DuplicatedStackID{
+		{
+			ID: "FIRST",
+			Stacks: []project.Path{
+				project.NewPath("/stacks/a"),
+				project.NewPath("/stacks/c"),
+				project.NewPath("/stacks/e"),
+			},
+		},
+		{
+			ID: "second",
+			Stacks: []project.Path{
+				project.NewPath("/stacks/b"),
+				project.NewPath("/stacks/d"),
+			},
+		},
+	}
+	if diff := cmp.Diff(want, dups, cmp.AllowUnexported(project.Path{})); diff != "" {
+		t.Fatalf("unexpected duplicated IDs (-want +got):\n%s", diff)
+	}
+
+	_, _, err = root.StackByID("second")
+	assert.IsError(t, err, errors.E(config.ErrStackDuplicatedID))
+
+	st, found, err := root.StackByID("unique")
+	assert.NoError(t, err)
+	assert.IsTrue(t, found)
+	assert.EqualStrings(t, "/stacks/f", st.Dir.String())
+}
+
 func init() {
 	zerolog.SetGlobalLevel(zerolog.Disabled)
 }
@@ -6,9 +6,12 @@ package trigger
 
 import (
 	"fmt"
+	"io/fs"
+	"math/big"
 	"os"
 	"path"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
@@ -53,6 +56,8 @@ type Info struct {
 	Context string
 	// StackPath is the path of the triggered stack.
 	StackPath project.Path
+	// Priority is the priority of the triggered stack in the run order.
+	Priority int
 }
 
 const (
@@ -136,6 +141,10 @@ func ParseFile(path string) (Info, error) {
 				Name:     "context",
 				Required: false,
 			},
+			{
+				Name:     "priority",
+				Required: false,
+			},
 		},
 	})
 
@@ -196,6 +205,17 @@ func ParseFile(path string) (Info, error) {
 				continue
 			}
 			info.Reason = val.AsString()
+		case "priority":
+			if val.Type() != cty.Number {
+				errs.Append(errors.E(ErrParsing, "trigger: %s must be a number", attribute.Name))
+				continue
+			}
+			v, accuracy := val.AsBigFloat().Int64()
+			if accuracy != big.Exact {
+				errs.Append(errors.E(ErrParsing, "trigger: %s must be an integer", attribute.Name))
+				continue
+			}
+			info.Priority = int(v)
 		default:
 			errs.Append(errors.E(ErrParsing, "trigger: has unknown attribute %q", attribute.Name))
 		}
@@ -232,8 +252,9 @@ func triggerFilename(kind Kind) (string, error) {
 }
 
 // Create creates a trigger for a stack with the given path and the given reason
-// inside the project rootdir.
-func Create(root *config.Root, path project.Path, kind Kind, reason string) error {
+// inside the project rootdir. A non-zero priority is stored in the trigger and
+// used to order the triggered stacks when the trigger is part of the change set.
+func Create(root *config.Root, path project.Path, kind Kind, reason string, priority int) error {
 	tree, ok := root.Lookup(path)
 	if !ok || !tree.IsStack() {
 		return errors.E(ErrTrigger, "path %s is not a stack directory", path)
@@ -255,6 +276,9 @@ func Create(root *config.Root, path project.Path, kind Kind, reason string) erro
 	triggerBody.SetAttributeValue("reason", cty.StringVal(reason))
 	triggerBody.SetAttributeRaw("type", hclwrite.TokensForIdentifier(string(kind)))
 	triggerBody.SetAttributeRaw("context", hclwrite.TokensForIdentifier(DefaultContext))
+	if priority != 0 {
+		triggerBody.SetAttributeValue("priority", cty.NumberIntVal(int64(priority)))
+	}
 
 	triggerPath := filepath.Join(triggerDir, filename)
 
@@ -270,3 +294,154 @@ func Create(root *config.Root, path project.Path, kind Kind, reason string) erro
 
 	return nil
 }
+
+// StackTriggers returns the trigger files of the stack with the given path,
+// sorted by name. Triggers of child stacks are not included.
+func StackTriggers(root *config.Root, path project.Path) (project.Paths, error) {
+	entries, err := os.ReadDir(filepath.Join(Dir(root.HostDir()), path.String()))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, errors.E(ErrTrigger, err, "listing triggers of stack %s", path)
+	}
+	var triggers project.Paths
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() {
+			continue
+		}
+		triggers = append(triggers, project.NewPath("/"+triggersDir).Join(path.String()).Join(entry.Name()))
+	}
+	return triggers, nil
+}
+
+// Entry is a trigger file of the project.
+type Entry struct {
+	// Path is the project path of the trigger file.
+	Path project.Path
+	// Info is the parsed trigger. Its StackPath is always set, even if the
+	// trigger file cannot be parsed.
+	Info Info
+}
+
+// List returns the triggers inside the triggers directory of the project,
+// sorted by stack path and creation time.
+func List(root *config.Root) ([]Entry, error) {
+	files, err := listFiles(root, project.NewPath("/"), true)
+	if err != nil {
+		return nil, err
+	}
+
+	errs := errors.L()
+	entries := make([]Entry, 0, len(files))
+	for _, file := range files {
+		stackpath, _ := StackPath(file)
+		info, err := ParseFile(file.HostPath(root.HostDir()))
+		if err != nil {
+			errs.Append(errors.E(err, "parsing trigger file %s", file))
+			continue
+		}
+		info.StackPath = stackpath
+		entries = append(entries, Entry{Path: file, Info: info})
+	}
+	if err := errs.AsError(); err != nil {
+		return nil, err
+	}
+	sortEntries(entries)
+	return entries, nil
+}
+
+// Clear removes the triggers of the stack with the given path. If recursive
+// is true, the triggers of the stacks inside the path are also removed, so
+// clearing the project root recursively removes all the triggers.
+// Trigger files that cannot be parsed are removed as well.
+// The removed triggers are returned sorted by stack path and creation time.
+func Clear(root *config.Root, path project.Path, recursive bool) ([]Entry, error) {
+	files, err := listFiles(root, path, recursive)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []Entry
+	for _, file := range files {
+		stackpath, _ := StackPath(file)
+		hostpath := file.HostPath(root.HostDir())
+		info, err := ParseFile(hostpath)
+		if err != nil {
+			info = Info{}
+		}
+		info.StackPath = stackpath
+		if err := os.Remove(hostpath); err != nil {
+			return entries, errors.E(ErrTrigger, err, "removing trigger file %s", file)
+		}
+		entries = append(entries, Entry{Path: file, Info: info})
+	}
+
+	removeEmptyDirs(root.HostDir(), path)
+	sortEntries(entries)
+	return entries, nil
+}
+
+// listFiles returns the trigger files of the stack with the given path and,
+// if recursive is true, of the stacks inside it.
+func listFiles(root *config.Root, path project.Path, recursive bool) (project.Paths, error) {
+	if !recursive {
+		return StackTriggers(root, path)
+	}
+	dir := filepath.Join(Dir(root.HostDir()), path.String())
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		return nil, nil
+	}
+	var files project.Paths
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.Type().IsRegular() {
+			files = append(files, project.PrjAbsPath(root.HostDir(), path))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.E(ErrTrigger, err, "listing triggers of %s", path)
+	}
+	return files, nil
+}
+
+// removeEmptyDirs removes the empty directories of the triggers directory
+// inside and above the given path.
+func removeEmptyDirs(rootdir string, path project.Path) {
+	triggersHostDir := Dir(rootdir)
+	dir := filepath.Join(triggersHostDir, path.String())
+
+	var dirs []string
+	_ = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err == nil && d.IsDir() {
+			dirs = append(dirs, path)
+		}
+		return nil
+	})
+	// children first.
+	for i := len(dirs) - 1; i >= 0; i-- {
+		_ = os.Remove(dirs[i])
+	}
+	for dir != triggersHostDir {
+		dir = filepath.Dir(dir)
+		if os.Remove(dir) != nil {
+			return
+		}
+	}
+}
+
+func sortEntries(entries []Entry) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if a.Info.StackPath != b.Info.StackPath {
+			return a.Info.StackPath.String() < b.Info.StackPath.String()
+		}
+		if a.Info.Ctime != b.Info.Ctime {
+			return a.Info.Ctime < b.Info.Ctime
+		}
+		return a.Path.String() < b.Path.String()
+	})
+}
@@ -6,6 +6,7 @@ package trigger_test
 import (
 	"fmt"
 	"math"
+	"os"
 	"path/filepath"
 	"strings"
 	"testing"
@@ -29,12 +30,13 @@ type want struct {
 }
 
 type testcase struct {
-	name   string
-	layout []string
-	path   string
-	kind   trigger.Kind
-	reason string
-	want   want
+	name     string
+	layout   []string
+	path     string
+	kind     trigger.Kind
+	reason   string
+	priority int
+	want     want
 }
 
 func TestTriggerStacks(t *testing.T) {
@@ -80,6 +82,17 @@ func TestTriggerStacks(t *testing.T) {
 			reason: "root is stack",
 			want:   want{kind: trigger.Changed},
 		},
+		{
+			name: "trigger-changed with priority",
+			layout: []string{
+				"s:stack",
+			},
+			path:     "/stack",
+			kind:     trigger.Changed,
+			reason:   "critical stack",
+			priority: 10,
+			want:     want{kind: trigger.Changed},
+		},
 		{
 			name:   "stack doesnt exist",
 			path:   "/non-existent-stack",
@@ -113,7 +126,7 @@ func testTrigger(t *testing.T, tc testcase) {
 	s.BuildTree(tc.layout)
 	root, err := config.LoadRoot(s.RootDir())
 	assert.NoError(t, err)
-	err = trigger.Create(root, project.NewPath(tc.path), tc.kind, tc.reason)
+	err = trigger.Create(root, project.NewPath(tc.path), tc.kind, tc.reason, tc.priority)
 	errtest.Assert(t, err, tc.want.err)
 
 	if err != nil {
@@ -141,6 +154,7 @@ func testTrigger(t *testing.T, tc testcase) {
 	assert.IsTrue(t, triggerInfo.Ctime < math.MaxInt64)
 	assert.EqualStrings(t, trigger.DefaultContext, triggerInfo.Context)
 	assert.EqualStrings(t, string(tc.want.kind), string(triggerInfo.Type))
+	assert.EqualInts(t, tc.priority, triggerInfo.Priority)
 
 	gotPath, ok := trigger.StackPath(project.PrjAbsPath(root.HostDir(), triggerFile))
 	assert.IsTrue(t, ok)
@@ -213,6 +227,41 @@ func TestTriggerParser(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "valid file with priority",
+			body: Trigger(
+				Number("ctime", 1000000),
+				Str("reason", "something"),
+				Expr("type", "changed"),
+				Expr("context", "stack"),
+				Number("priority", 5),
+			),
+			want: want{
+				info: trigger.Info{
+					Type:     trigger.Changed,
+					Context:  trigger.DefaultContext,
+					Priority: 5,
+				},
+			},
+		},
+		{
+			name: "priority not number",
+			body: Trigger(
+				Number("ctime", 1000000),
+				Str("reason", "something"),
+				Str("priority", "5"),
+			),
+			want: want{err: errors.E(trigger.ErrParsing)},
+		},
+		{
+			name: "priority not integer",
+			body: Trigger(
+				Number("ctime", 1000000),
+				Str("reason", "something"),
+				Expr("priority", "1.5"),
+			),
+			want: want{err: errors.E(trigger.ErrParsing)},
+		},
 		{
 			name: "multiple trigger blocks - fails",
 			body: Doc(
@@ -314,10 +363,92 @@ func TestTriggerParser(t *testing.T) {
 			}
 			assert.EqualStrings(t, string(info.Type), string(tc.want.info.Type))
 			assert.EqualStrings(t, info.Context, tc.want.info.Context)
+			assert.EqualInts(t, info.Priority, tc.want.info.Priority)
 		})
 	}
 }
 
+func TestTriggerListAndClear(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.NoGit(t, true)
+	s.BuildTree([]string{
+		"s:stack-a",
+		"s:stack-a/child",
+		"s:stack-b",
+	})
+	root, err := config.LoadRoot(s.RootDir())
+	assert.NoError(t, err)
+
+	entries, err := trigger.List(root)
+	assert.NoError(t, err)
+	assert.EqualInts(t, 0, len(entries), "unexpected triggers: %v", entries)
+
+	for _, tr := range []struct {
+		path   string
+		kind   trigger.Kind
+		reason string
+	}{
+		{path: "/stack-b", kind: trigger.Ignored, reason: "ignore b"},
+		{path: "/stack-a/child", kind: trigger.Changed, reason: "change child"},
+		{path: "/stack-a", kind: trigger.Changed, reason: "change a"},
+	} {
+		err := trigger.Create(root, project.NewPath(tr.path), tr.kind, tr.reason, 0)
+		assert.NoError(t, err)
+	}
+
+	type got struct {
+		Stack  string
+		Kind   trigger.Kind
+		Reason string
+	}
+	summarize := func(entries []trigger.Entry) []got {
+		var res []got
+		for _, e := range entries {
+			assert.IsTrue(t, e.Path.HasPrefix("/.tmtriggers"+e.Info.StackPath.String()),
+				"trigger file %s is not inside the stack trigger dir", e.Path)
+			res = append(res, got{Stack: e.Info.StackPath.String(), Kind: e.Info.Type, Reason: e.Info.Reason})
+		}
+		return res
+	}
+
+	entries, err = trigger.List(root)
+	assert.NoError(t, err)
+	test.AssertDiff(t, summarize(entries), []got{
+		{Stack: "/stack-a", Kind: trigger.Changed, Reason: "change a"},
+		{Stack: "/stack-a/child", Kind: trigger.Changed, Reason: "change child"},
+		{Stack: "/stack-b", Kind: trigger.Ignored, Reason: "ignore b"},
+	})
+
+	removed, err := trigger.Clear(root, project.NewPath("/stack-a"), false)
+	assert.NoError(t, err)
+	test.AssertDiff(t, summarize(removed), []got{
+		{Stack: "/stack-a", Kind: trigger.Changed, Reason: "change a"},
+	})
+
+	entries, err = trigger.List(root)
+	assert.NoError(t, err)
+	assert.EqualInts(t, 2, len(entries), "unexpected triggers: %v", entries)
+
+	removed, err = trigger.Clear(root, project.NewPath("/"), true)
+	assert.NoError(t, err)
+	test.AssertDiff(t, summarize(removed), []got{
+		{Stack: "/stack-a/child", Kind: trigger.Changed, Reason: "change child"},
+		{Stack: "/stack-b", Kind: trigger.Ignored, Reason: "ignore b"},
+	})
+
+	entries, err = trigger.List(root)
+	assert.NoError(t, err)
+	assert.EqualInts(t, 0, len(entries), "unexpected triggers: %v", entries)
+
+	_, err = os.Stat(trigger.Dir(s.RootDir()))
+	assert.IsTrue(t, os.IsNotExist(err), "empty triggers dir must be removed")
+
+	removed, err = trigger.Clear(root, project.NewPath("/stack-a"), false)
+	assert.NoError(t, err)
+	assert.EqualInts(t, 0, len(removed), "unexpected removed triggers: %v", removed)
+}
+
 func init() {
 	zerolog.SetGlobalLevel(zerolog.Disabled)
 }
@@ -99,6 +99,7 @@ func Functions(basedir string, experiments []string) map[string]function.Functio
 
 	tmfuncs["tm_hclencode"] = HCLEncode()
 	tmfuncs["tm_hcldecode"] = HCLDecode()
+	tmfuncs["tm_yamlencode_all"] = YAMLEncodeAll()
 	return tmfuncs
 }
 
@@ -126,6 +127,54 @@ func NoFS(basedir string, experiments []string) map[string]function.Function {
 	return funcs
 }
 
+// FileReadFuncs returns the filesystem functions which read a file given by
+// its first argument (eg.: tm_file and tm_templatefile) but reporting the
+// absolute path of each file read on the given stream. Relative paths are
+// resolved from basedir, the same as [Functions].
+//
+// The files read by the templates themselves are not reported.
+func FileReadFuncs(basedir string, experiments []string, stream chan<- event.FileRead) map[string]function.Function {
+	funcs := Functions(basedir, experiments)
+	fileFuncNames := []string{
+		"tm_file",
+		"tm_fileexists",
+		"tm_filebase64",
+		"tm_filebase64sha256",
+		"tm_filebase64sha512",
+		"tm_filemd5",
+		"tm_filesha1",
+		"tm_filesha256",
+		"tm_filesha512",
+		"tm_templatefile",
+	}
+	res := make(map[string]function.Function, len(fileFuncNames))
+	for _, name := range fileFuncNames {
+		res[name] = fileReadFunc(basedir, funcs[name], stream)
+	}
+	return res
+}
+
+func fileReadFunc(basedir string, fn function.Function, stream chan<- event.FileRead) function.Function {
+	return function.New(&function.Spec{
+		Params:   fn.Params(),
+		VarParam: fn.VarParam(),
+		Type: func(args []cty.Value) (cty.Type, error) {
+			return fn.ReturnTypeForValues(args)
+		},
+		Impl: func(args []cty.Value, _ cty.Type) (cty.Value, error) {
+			path, _ := args[0].Unmark()
+			if path.IsKnown() && !path.IsNull() && path.Type() == cty.String {
+				abspath := path.AsString()
+				if !filepath.IsAbs(abspath) {
+					abspath = filepath.Join(basedir, abspath)
+				}
+				stream <- event.FileRead{Path: filepath.Clean(abspath)}
+			}
+			return fn.Call(args)
+		},
+	})
+}
+
 // Regex is a copy of Terraform [stdlib.RegexFunc] but with cached compiled
 // patterns.
 func Regex() function.Function {
@@ -299,12 +348,19 @@ func AbspathFunc(basedir string) function.Function {
 }
 
 // VendorFunc returns the `tm_vendor` function.
+// The rootdir is the host path of the project root, used to read the vendor
+// manifest when resolving version constraints.
 // The basedir defines what tm_vendor will use to define the relative paths
 // of vendored dependencies.
 // The vendordir defines where modules are vendored inside the project.
 // The stream defines the event stream for tm_vendor, one event is produced
-// per successful function call.
-func VendorFunc(basedir, vendordir project.Path, stream chan<- event.VendorRequest) function.Function {
+// per successful function call and per version constraint that fails to be
+// resolved.
+//
+// The optional version parameter is a version constraint, like "~> 1.2", which
+// is resolved to the highest matching semantic version tag of the module
+// repository. See [modvendor.ResolveVersion].
+func VendorFunc(rootdir string, basedir, vendordir project.Path, stream chan<- event.VendorRequest) function.Function {
 	return function.New(&function.Spec{
 		Params: []function.Parameter{
 			{
@@ -312,6 +368,10 @@ func VendorFunc(basedir, vendordir project.Path, stream chan<- event.VendorReque
 				Type: cty.String,
 			},
 		},
+		VarParam: &function.Parameter{
+			Name: "version",
+			Type: cty.String,
+		},
 		Type: function.StaticReturnType(cty.String),
 		Impl: func(args []cty.Value, _ cty.Type) (cty.Value, error) {
 			// Param spec already enforce modsrc to be string.
@@ -320,6 +380,46 @@ func VendorFunc(basedir, vendordir project.Path, stream chan<- event.VendorReque
 			if err != nil {
 				return cty.NilVal, errors.E(err, "tm_vendor: invalid module source")
 			}
+
+			var constraint string
+			switch len(args) {
+			case 1:
+			case 2:
+				constraint = args[1].AsString()
+				if modsrc.Ref != "" {
+					return cty.NilVal, errors.E(
+						"tm_vendor: source %q has a ref and a version constraint", source)
+				}
+			default:
+				return cty.NilVal, errors.E(
+					"tm_vendor: expects at most 2 arguments but %d were given", len(args))
+			}
+
+			logger := log.With().
+				Str("action", "tm_vendor").
+				Str("source", source).
+				Str("constraint", constraint).
+				Logger()
+
+			if len(args) == 2 {
+				resolved, err := modvendor.ResolveVersion(rootdir, vendordir, modsrc, constraint)
+				if err != nil {
+					if stream != nil {
+						logger.Debug().Err(err).Msg("failed to resolve version, sending event")
+
+						stream <- event.VendorRequest{
+							Source:     modsrc,
+							VendorDir:  vendordir,
+							Constraint: constraint,
+							Error:      err,
+						}
+					}
+					// the error is not wrapped since it is shared with the event.
+					return cty.NilVal, err
+				}
+				modsrc = resolved
+			}
+
 			targetPath := modvendor.TargetDir(vendordir, modsrc)
 			result, err := filepath.Rel(basedir.String(), targetPath.String())
 			if err != nil {
@@ -331,16 +431,12 @@ func VendorFunc(basedir, vendordir project.Path, stream chan<- event.VendorReque
 			result = filepath.ToSlash(result)
 
 			if stream != nil {
-				logger := log.With().
-					Str("action", "tm_vendor").
-					Str("source", source).
-					Logger()
-
 				logger.Debug().Msg("calculated path with success, sending event")
 
 				stream <- event.VendorRequest{
-					Source:    modsrc,
-					VendorDir: vendordir,
+					Source:     modsrc,
+					VendorDir:  vendordir,
+					Constraint: constraint,
 				}
 
 				log.Debug().Msg("event sent")
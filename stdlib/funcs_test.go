@@ -12,11 +12,14 @@ import (
 	"github.com/rs/zerolog"
 	"github.com/terramate-io/terramate/event"
 	"github.com/terramate-io/terramate/hcl/eval"
+	"github.com/terramate-io/terramate/modvendor"
 	"github.com/terramate-io/terramate/project"
 	"github.com/terramate-io/terramate/stdlib"
 	"github.com/terramate-io/terramate/test"
 	"github.com/terramate-io/terramate/test/errors"
+	"github.com/terramate-io/terramate/test/sandbox"
 	"github.com/terramate-io/terramate/tf"
+	"go.lsp.dev/uri"
 )
 
 func TestTmVendor(t *testing.T) {
@@ -91,6 +94,17 @@ func TestTmVendor(t *testing.T) {
 				VendorDir: project.NewPath("/"),
 			},
 		},
+		{
+			name:      "oci module source",
+			vendorDir: "/vendor",
+			targetDir: "/dir",
+			expr:      `tm_vendor("oci://registry.example.com/modules/vpc:1.2.3")`,
+			want:      "../vendor/registry.example.com/modules/vpc/1.2.3",
+			wantEvent: event.VendorRequest{
+				Source:    src("oci://registry.example.com/modules/vpc:1.2.3"),
+				VendorDir: project.NewPath("/vendor"),
+			},
+		},
 		{
 			name:      "fails on invalid module src",
 			vendorDir: "/modules",
@@ -112,11 +126,18 @@ func TestTmVendor(t *testing.T) {
 			expr:      `tm_vendor([])`,
 			wantErr:   true,
 		},
+		{
+			name:      "fails on source with ref and version constraint",
+			vendorDir: "/modules",
+			targetDir: "/dir",
+			expr:      `tm_vendor("github.com/terramate-io/terramate?ref=main", "~> 1.0")`,
+			wantErr:   true,
+		},
 		{
 			name:      "fails on extra parameter",
 			vendorDir: "/modules",
 			targetDir: "/dir",
-			expr:      `tm_vendor("github.com/terramate-io/terramate?ref=main", "")`,
+			expr:      `tm_vendor("github.com/terramate-io/terramate", "~> 1.0", "")`,
 			wantErr:   true,
 		},
 	}
@@ -131,7 +152,7 @@ func TestTmVendor(t *testing.T) {
 			targetdir := project.NewPath(tcase.targetDir)
 
 			funcs := stdlib.Functions(rootdir, []string{})
-			funcs[stdlib.Name("vendor")] = stdlib.VendorFunc(targetdir, vendordir, events)
+			funcs[stdlib.Name("vendor")] = stdlib.VendorFunc(rootdir, targetdir, vendordir, events)
 			ctx := eval.NewContext(funcs)
 
 			gotEvents := []event.VendorRequest{}
@@ -164,7 +185,7 @@ func TestTmVendor(t *testing.T) {
 			// it also works with a nil channel (no interest on events).
 			t.Run("works with nil events channel", func(t *testing.T) {
 				funcs := stdlib.Functions(rootdir, []string{})
-				funcs["tm_vendor"] = stdlib.VendorFunc(targetdir, vendordir, nil)
+				funcs["tm_vendor"] = stdlib.VendorFunc(rootdir, targetdir, vendordir, nil)
 				ctx := eval.NewContext(funcs)
 
 				val, err := ctx.Eval(test.NewExpr(t, tcase.expr))
@@ -175,6 +196,149 @@ func TestTmVendor(t *testing.T) {
 	}
 }
 
+func TestTmVendorWithVersionConstraint(t *testing.T) {
+	t.Parallel()
+
+	repo := sandbox.New(t)
+	repo.RootEntry().CreateFile("main.tf", "# module")
+	repogit := repo.Git()
+	repogit.CommitAll("add module")
+	for _, tag := range []string{"v1.0.0", "v1.1.0", "v2.0.0"} {
+		_, err := repogit.Unwrap().Exec("tag", tag)
+		assert.NoError(t, err)
+	}
+
+	source := "git::" + string(uri.File(repo.RootDir()))
+	rootdir := test.TempDir(t)
+	vendordir := project.NewPath("/vendor")
+	resolved := test.ParseSource(t, source+"?ref=v1.1.0")
+
+	events := make(chan event.VendorRequest)
+	funcs := stdlib.Functions(rootdir, []string{})
+	funcs[stdlib.Name("vendor")] = stdlib.VendorFunc(rootdir, project.NewPath("/"), vendordir, events)
+	ctx := eval.NewContext(funcs)
+
+	gotEvents := []event.VendorRequest{}
+	done := make(chan struct{})
+	go func() {
+		for event := range events {
+			gotEvents = append(gotEvents, event)
+		}
+		close(done)
+	}()
+
+	val, err := ctx.Eval(test.NewExpr(t, `tm_vendor("`+source+`", "~> 1.0")`))
+	assert.NoError(t, err)
+	want := strings.TrimPrefix(modvendor.TargetDir(vendordir, resolved).String(), "/")
+	assert.EqualStrings(t, want, val.AsString())
+
+	_, err = ctx.Eval(test.NewExpr(t, `tm_vendor("`+source+`", "~> 3.0")`))
+	assert.Error(t, err)
+
+	close(events)
+	<-done
+
+	assert.EqualInts(t, 2, len(gotEvents), "unexpected events: %v", gotEvents)
+	test.AssertDiff(t, gotEvents[0], event.VendorRequest{
+		Source:     resolved,
+		VendorDir:  vendordir,
+		Constraint: "~> 1.0",
+	})
+	assert.EqualStrings(t, "~> 3.0", gotEvents[1].Constraint)
+	assert.EqualStrings(t, test.ParseSource(t, source).Raw, gotEvents[1].Source.Raw)
+	errors.AssertIsKind(t, gotEvents[1].Error, modvendor.ErrResolveVersion)
+}
+
+func TestTmFileReadFuncs(t *testing.T) {
+	t.Parallel()
+	type testcase struct {
+		name     string
+		expr     string
+		want     string
+		wantRead string
+	}
+
+	for _, tcase := range []testcase{
+		{
+			name:     "tm_file with relative path",
+			expr:     `tm_file("file.txt")`,
+			want:     "content",
+			wantRead: "dir/file.txt",
+		},
+		{
+			name:     "tm_file with relative path to parent dir",
+			expr:     `tm_file("../other.txt")`,
+			want:     "other",
+			wantRead: "other.txt",
+		},
+		{
+			name:     "tm_templatefile",
+			expr:     `tm_templatefile("tpl.txt", {name = "test"})`,
+			want:     "hello test",
+			wantRead: "dir/tpl.txt",
+		},
+		{
+			name:     "tm_filemd5",
+			expr:     `tm_filemd5("file.txt")`,
+			want:     "9a0364b9e99bb480dd25e1f0284c8555",
+			wantRead: "dir/file.txt",
+		},
+		{
+			name:     "tm_fileexists of non-existent file",
+			expr:     `tm_tostring(tm_fileexists("missing.txt"))`,
+			want:     "false",
+			wantRead: "dir/missing.txt",
+		},
+		{
+			name: "non filesystem function",
+			expr: `tm_upper("a")`,
+			want: "A",
+		},
+	} {
+		tcase := tcase
+		t.Run(tcase.name, func(t *testing.T) {
+			t.Parallel()
+			rootdir := test.TempDir(t)
+			basedir := filepath.Join(rootdir, "dir")
+			test.WriteFile(t, basedir, "file.txt", "content")
+			test.WriteFile(t, basedir, "tpl.txt", "hello ${name}")
+			test.WriteFile(t, rootdir, "other.txt", "other")
+
+			reads := make(chan event.FileRead)
+			ctx := eval.NewContext(stdlib.Functions(basedir, []string{}))
+			for name, fn := range stdlib.FileReadFuncs(basedir, []string{}, reads) {
+				ctx.SetFunction(name, fn)
+			}
+
+			gotReads := []string{}
+			done := make(chan struct{})
+			go func() {
+				for read := range reads {
+					gotReads = append(gotReads, read.Path)
+				}
+				close(done)
+			}()
+
+			val, err := ctx.Eval(test.NewExpr(t, tcase.expr))
+
+			close(reads)
+			<-done
+
+			assert.NoError(t, err)
+			assert.EqualStrings(t, tcase.want, val.AsString())
+
+			if tcase.wantRead == "" {
+				assert.EqualInts(t, 0, len(gotReads), "unexpected reads: %v", gotReads)
+				return
+			}
+			assert.IsTrue(t, len(gotReads) > 0, "expected file reads")
+			for _, got := range gotReads {
+				assert.EqualStrings(t, filepath.Join(rootdir, filepath.FromSlash(tcase.wantRead)), got)
+			}
+		})
+	}
+}
+
 func TestStdlibTmVersionMatch(t *testing.T) {
 	t.Parallel()
 
@@ -0,0 +1,164 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package stdlib_test
+
+import (
+	"fmt"
+	"testing"
+
+	lang "github.com/terramate-io/opentofulib/lang"
+	"github.com/terramate-io/terramate/hcl/eval"
+	"github.com/terramate-io/terramate/stdlib"
+	"github.com/terramate-io/terramate/test"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// TestStdlibRegexConformance checks that the regex functions behave exactly
+// like the Terraform ones. The %s in the expressions is replaced by the
+// function prefix: "tm_" for Terramate and "" for Terraform.
+func TestStdlibRegexConformance(t *testing.T) {
+	t.Parallel()
+
+	type testcase struct {
+		expr    string
+		want    cty.Value
+		wantErr bool
+	}
+
+	for _, tc := range []testcase{
+		{
+			expr: `%sregex("[a-z]+", "53453453.345345aaabbbccc23454")`,
+			want: cty.StringVal("aaabbbccc"),
+		},
+		{
+			expr: `%sregex("(\\d\\d\\d\\d)-(\\d\\d)-(\\d\\d)", "2019-02-01")`,
+			want: cty.TupleVal([]cty.Value{
+				cty.StringVal("2019"),
+				cty.StringVal("02"),
+				cty.StringVal("01"),
+			}),
+		},
+		{
+			expr: `%sregex("^(?:(?P<scheme>[^:/?#]+):)?(?://(?P<authority>[^/?#]*))?", "https://terramate.io/docs/")`,
+			want: cty.ObjectVal(map[string]cty.Value{
+				"scheme":    cty.StringVal("https"),
+				"authority": cty.StringVal("terramate.io"),
+			}),
+		},
+		{
+			expr: `%sregex("^(?P<name>[a-z]+)(?:-(?P<env>[a-z]+))?$", "stack")`,
+			want: cty.ObjectVal(map[string]cty.Value{
+				"name": cty.StringVal("stack"),
+				"env":  cty.NullVal(cty.String),
+			}),
+		},
+		{
+			expr: `%sregex("(a)(b)?", "a")`,
+			want: cty.TupleVal([]cty.Value{
+				cty.StringVal("a"),
+				cty.NullVal(cty.String),
+			}),
+		},
+		{
+			expr:    `%sregex("[0-9]+", "abc")`,
+			wantErr: true,
+		},
+		{
+			expr:    `%sregex("[a-z", "abc")`,
+			wantErr: true,
+		},
+		{
+			expr:    `%sregex("(?P<name>a)(b)", "ab")`,
+			wantErr: true,
+		},
+		{
+			expr: `%sregexall("[a-z]+", "1234abcd5678efgh9")`,
+			want: cty.ListVal([]cty.Value{
+				cty.StringVal("abcd"),
+				cty.StringVal("efgh"),
+			}),
+		},
+		{
+			expr: `%sregexall("[a-z]+", "1234")`,
+			want: cty.ListValEmpty(cty.String),
+		},
+		{
+			expr: `%sregexall("(\\w+)=(\\w+)", "a=1 b=2")`,
+			want: cty.ListVal([]cty.Value{
+				cty.TupleVal([]cty.Value{cty.StringVal("a"), cty.StringVal("1")}),
+				cty.TupleVal([]cty.Value{cty.StringVal("b"), cty.StringVal("2")}),
+			}),
+		},
+		{
+			expr: `%sregexall("(?P<key>\\w+)=(?P<value>\\w+)", "a=1 b=2")`,
+			want: cty.ListVal([]cty.Value{
+				cty.ObjectVal(map[string]cty.Value{
+					"key":   cty.StringVal("a"),
+					"value": cty.StringVal("1"),
+				}),
+				cty.ObjectVal(map[string]cty.Value{
+					"key":   cty.StringVal("b"),
+					"value": cty.StringVal("2"),
+				}),
+			}),
+		},
+		{
+			expr:    `%sregexall("[a-z", "abc")`,
+			wantErr: true,
+		},
+		{
+			expr: `%sreplace("1 + 2 + 3", "+", "-")`,
+			want: cty.StringVal("1 - 2 - 3"),
+		},
+		{
+			expr: `%sreplace("hello world", "/w.*d/", "everybody")`,
+			want: cty.StringVal("hello everybody"),
+		},
+		{
+			expr: `%sreplace("key=value", "/(\\w+)=(\\w+)/", "$2=$1")`,
+			want: cty.StringVal("value=key"),
+		},
+		{
+			expr: `%sreplace("key=value", "/(?P<k>\\w+)=(?P<v>\\w+)/", "$${v}:$${k}")`,
+			want: cty.StringVal("value:key"),
+		},
+		{
+			expr: `%sreplace("a/b/c", "/", "-")`,
+			want: cty.StringVal("a-b-c"),
+		},
+		{
+			expr:    `%sreplace("abc", "/[a-z/", "x")`,
+			wantErr: true,
+		},
+	} {
+		tc := tc
+		t.Run(tc.expr, func(t *testing.T) {
+			t.Parallel()
+
+			rootdir := test.TempDir(t)
+			tmctx := eval.NewContext(stdlib.Functions(rootdir, []string{}))
+			tmval, tmerr := tmctx.Eval(test.NewExpr(t, fmt.Sprintf(tc.expr, "tm_")))
+
+			scope := &lang.Scope{BaseDir: rootdir}
+			tfctx := eval.NewContext(scope.Functions())
+			tfval, tferr := tfctx.Eval(test.NewExpr(t, fmt.Sprintf(tc.expr, "")))
+
+			if tc.wantErr {
+				if tmerr == nil || tferr == nil {
+					t.Fatalf("want error: got terramate error %v and terraform error %v", tmerr, tferr)
+				}
+				return
+			}
+			if tmerr != nil || tferr != nil {
+				t.Fatalf("unexpected error: terramate %v and terraform %v", tmerr, tferr)
+			}
+			if !tfval.RawEquals(tc.want) {
+				t.Fatalf("terraform returned %#v but want %#v", tfval, tc.want)
+			}
+			if !tmval.RawEquals(tfval) {
+				t.Fatalf("terramate returned %#v but terraform returned %#v", tmval, tfval)
+			}
+		})
+	}
+}
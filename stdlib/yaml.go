@@ -0,0 +1,63 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package stdlib
+
+import (
+	"strings"
+
+	"github.com/terramate-io/terramate/errors"
+	ctyyaml "github.com/zclconf/go-cty-yaml"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+)
+
+const errYAMLEncode errors.Kind = "failed to YAML encode the value"
+
+// YAMLEncodeAll implements the `tm_yamlencode_all()` function.
+// It encodes each element of the given list or tuple as a YAML document, the
+// documents separated by `---` lines.
+//
+// The keys of objects and maps are emitted in lexicographical order at every
+// nesting level, the same as `tm_yamlencode()`, so the output is stable.
+func YAMLEncodeAll() function.Function {
+	return function.New(&function.Spec{
+		Params: []function.Parameter{
+			{
+				Name: "docs",
+				Type: cty.DynamicPseudoType,
+			},
+		},
+		Type: function.StaticReturnType(cty.String),
+		Impl: func(args []cty.Value, _ cty.Type) (cty.Value, error) {
+			return yamlEncodeAll(args[0])
+		},
+	})
+}
+
+func yamlEncodeAll(docs cty.Value) (cty.Value, error) {
+	ty := docs.Type()
+	if !ty.IsListType() && !ty.IsTupleType() {
+		return cty.NilVal, errors.E(errYAMLEncode, "only list/tuple can be encoded as documents but got %s", ty.FriendlyName())
+	}
+	if docs.IsNull() {
+		return cty.NilVal, errors.E(errYAMLEncode, "documents cannot be null")
+	}
+	if !docs.IsWhollyKnown() {
+		return cty.UnknownVal(cty.String), nil
+	}
+
+	var out strings.Builder
+	for it := docs.ElementIterator(); it.Next(); {
+		_, doc := it.Element()
+		data, err := ctyyaml.Standard.Marshal(doc)
+		if err != nil {
+			return cty.NilVal, errors.E(errYAMLEncode, err)
+		}
+		if out.Len() > 0 {
+			out.WriteString("---\n")
+		}
+		out.Write(data)
+	}
+	return cty.StringVal(out.String()), nil
+}
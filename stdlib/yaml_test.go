@@ -0,0 +1,152 @@
+// Copyright 2024 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package stdlib_test
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/madlambda/spells/assert"
+	"github.com/terramate-io/terramate/errors"
+	"github.com/terramate-io/terramate/hcl/eval"
+	"github.com/terramate-io/terramate/stdlib"
+	"github.com/terramate-io/terramate/test"
+	errtest "github.com/terramate-io/terramate/test/errors"
+)
+
+func TestStdlibYAMLEncodeAll(t *testing.T) {
+	t.Parallel()
+	type want struct {
+		res string
+		err error
+	}
+	type testcase struct {
+		name string
+		expr string
+		want want
+	}
+
+	for _, tc := range []testcase{
+		{
+			name: "only list/tuple can be encoded",
+			expr: `tm_yamlencode_all({a = 1})`,
+			want: want{
+				err: errors.E(eval.ErrEval),
+			},
+		},
+		{
+			name: "empty list generates an empty string",
+			expr: `tm_yamlencode_all([])`,
+			want: want{
+				res: "",
+			},
+		},
+		{
+			name: "single document",
+			expr: `tm_yamlencode_all([{b = 1, a = "x"}])`,
+			want: want{
+				res: "\"a\": \"x\"\n\"b\": 1\n",
+			},
+		},
+		{
+			name: "documents are separated by ---",
+			expr: `tm_yamlencode_all([
+				{kind = "Namespace", metadata = {name = "app"}},
+				{kind = "Service", metadata = {namespace = "app", name = "web"}},
+			])`,
+			want: want{
+				res: "\"kind\": \"Namespace\"\n" +
+					"\"metadata\":\n" +
+					"  \"name\": \"app\"\n" +
+					"---\n" +
+					"\"kind\": \"Service\"\n" +
+					"\"metadata\":\n" +
+					"  \"name\": \"web\"\n" +
+					"  \"namespace\": \"app\"\n",
+			},
+		},
+		{
+			name: "documents of different types",
+			expr: `tm_yamlencode_all(["a", ["b"], {c = true}])`,
+			want: want{
+				res: "\"a\"\n" +
+					"---\n" +
+					"- \"b\"\n" +
+					"---\n" +
+					"\"c\": true\n",
+			},
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			rootdir := test.TempDir(t)
+			ctx := eval.NewContext(stdlib.Functions(rootdir, []string{}))
+			got, err := ctx.Eval(test.NewExpr(t, tc.expr))
+			errtest.Assert(t, err, tc.want.err)
+			if tc.want.err != nil {
+				return
+			}
+			assert.EqualStrings(t, tc.want.res, got.AsString())
+		})
+	}
+}
+
+// TestStdlibEncodeIsStable is a regression test for the generated YAML and
+// JSON changing between runs when the keys of the input objects are given
+// in a different order.
+func TestStdlibEncodeIsStable(t *testing.T) {
+	t.Parallel()
+
+	keys := []string{"metadata", "spec", "apiVersion", "kind", "zeta", "alpha", "beta", "gamma"}
+
+	// shuffledObject returns an HCL object expression with the keys, and the
+	// keys of the nested objects, declared in a random order.
+	var shuffledObject func(rnd *rand.Rand, depth int) string
+	shuffledObject = func(rnd *rand.Rand, depth int) string {
+		var attrs []string
+		for _, i := range rnd.Perm(len(keys)) {
+			val := fmt.Sprintf("%q", keys[i])
+			if depth > 0 {
+				val = shuffledObject(rnd, depth-1)
+			}
+			attrs = append(attrs, fmt.Sprintf("%s = %s", keys[i], val))
+		}
+		return "{" + strings.Join(attrs, ", ") + "}"
+	}
+
+	shuffledMerge := func(rnd *rand.Rand) string {
+		return fmt.Sprintf("tm_merge(%s, %s, {spec = %s})",
+			shuffledObject(rnd, 2),
+			shuffledObject(rnd, 1),
+			shuffledObject(rnd, 2))
+	}
+
+	rootdir := test.TempDir(t)
+	for _, fn := range []string{"tm_yamlencode", "tm_jsonencode"} {
+		for _, seed := range []int64{1, 2, 3} {
+			var got []string
+			for _, rnd := range []*rand.Rand{rand.New(rand.NewSource(seed)), rand.New(rand.NewSource(seed * 100))} {
+				ctx := eval.NewContext(stdlib.Functions(rootdir, []string{}))
+				val, err := ctx.Eval(test.NewExpr(t, fmt.Sprintf("%s(%s)", fn, shuffledMerge(rnd))))
+				assert.NoError(t, err)
+				got = append(got, val.AsString())
+			}
+			assert.EqualStrings(t, got[0], got[1], "%s output changed with the keys order", fn)
+		}
+	}
+
+	var got []string
+	for _, seed := range []int64{10, 20} {
+		rnd := rand.New(rand.NewSource(seed))
+		ctx := eval.NewContext(stdlib.Functions(rootdir, []string{}))
+		val, err := ctx.Eval(test.NewExpr(t, fmt.Sprintf("tm_yamlencode_all([%s, %s])",
+			shuffledMerge(rnd), shuffledObject(rnd, 3))))
+		assert.NoError(t, err)
+		got = append(got, val.AsString())
+	}
+	assert.EqualStrings(t, got[0], got[1], "tm_yamlencode_all output changed with the keys order")
+}
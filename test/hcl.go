@@ -84,9 +84,11 @@ func AssertTerramateConfig(t *testing.T, got, want hcl.Config) {
 		// this contains the Raw HCL constructs and it was never tested here.
 		cmpopts.IgnoreFields(hcl.Config{}, "Imported"),
 
-		// Globals/Asserts/Scripts are mostly Attribute and Expr, which cannot be easily compared with cmp.Diff.
-		cmpopts.IgnoreFields(hcl.Config{}, "Globals", "Asserts", "Scripts", "Inputs", "Outputs"),
+		// Globals/Asserts/Checks/Scripts are mostly Attribute and Expr, which cannot be easily compared with cmp.Diff.
+		cmpopts.IgnoreFields(hcl.Config{}, "Globals", "Asserts", "Checks", "Scripts", "Inputs", "Outputs"),
 		cmpopts.IgnoreFields(hcl.RunEnv{}, "Attributes"), // because Expr and Range
+		cmpopts.IgnoreFields(hcl.CloudMetadata{}, "Attributes"),
+		cmpopts.IgnoreFields(hcl.GenerateHeaderConfig{}, "License"),
 		cmpopts.IgnoreFields(hcl.Config{}, "Generate"),
 	); diff != "" {
 		t.Logf("want: %+v", want)
@@ -96,6 +98,7 @@ func AssertTerramateConfig(t *testing.T, got, want hcl.Config) {
 
 	assertTerramateBlock(t, got.Terramate, want.Terramate)
 	assertAssertsBlock(t, got.Asserts, want.Asserts, "terramate asserts")
+	assertChecksBlock(t, got.Checks, want.Checks)
 	assertGenHCLBlocks(t, got.Generate.HCLs, want.Generate.HCLs)
 	assertGenFileBlocks(t, got.Generate.Files, want.Generate.Files)
 	assertScriptBlocks(t, got.Scripts, want.Scripts)
@@ -211,6 +214,30 @@ func assertAssertsBlock(t *testing.T, got, want []hcl.AssertConfig, ctx string)
 	}
 }
 
+func assertChecksBlock(t *testing.T, got, want []hcl.CheckConfig) {
+	t.Helper()
+
+	if len(got) != len(want) {
+		t.Fatalf("got %d check blocks, want %d", len(got), len(want))
+	}
+
+	for i, g := range got {
+		w := want[i]
+		ctx := fmt.Sprintf("check %d", i)
+		AssertEqualRanges(t, g.Range, w.Range, "%s: range mismatch", ctx)
+		assert.EqualStrings(t, w.Name, g.Name, "%s: name mismatch", ctx)
+		assert.EqualStrings(t,
+			exprAsStr(t, w.Assertion), exprAsStr(t, g.Assertion),
+			"%s: assertion expr mismatch", ctx)
+		assert.EqualStrings(t,
+			exprAsStr(t, w.Message), exprAsStr(t, g.Message),
+			"%s: message expr mismatch", ctx)
+		assert.EqualStrings(t,
+			exprAsStr(t, w.Severity), exprAsStr(t, g.Severity),
+			"%s: severity expr mismatch", ctx)
+	}
+}
+
 func exprAsStr(t *testing.T, expr hhcl.Expression) string {
 	t.Helper()
 
@@ -274,6 +301,15 @@ func assertTerramateConfigBlock(t *testing.T, got, want *hcl.RootConfig) {
 		t.Fatalf("want.Experiments[%+v] != got.Experiments[%+v]", want.Experiments, got.Experiments)
 	}
 
+	if !slices.Equal(want.SensitiveGlobals, got.SensitiveGlobals) {
+		t.Fatalf("want.SensitiveGlobals[%+v] != got.SensitiveGlobals[%+v]", want.SensitiveGlobals, got.SensitiveGlobals)
+	}
+
+	if (want.Stack == nil) != (got.Stack == nil) ||
+		(want.Stack != nil && *want.Stack != *got.Stack) {
+		t.Fatalf("want.Stack[%+v] != got.Stack[%+v]", want.Stack, got.Stack)
+	}
+
 	assertTerramateRunBlock(t, got.Run, want.Run)
 	assertTerramateCloudBlock(t, got.Cloud, want.Cloud)
 }
@@ -302,6 +338,8 @@ func assertGenFileBlocks(t *testing.T, got, want []hcl.GenFileBlock) {
 		wantBlock := want[i]
 		AssertEqualRanges(t, gotBlock.Range, wantBlock.Range, "genfile range differs")
 		assert.EqualStrings(t, wantBlock.Label, gotBlock.Label, "genfile label differs")
+		assert.IsTrue(t, wantBlock.Mode == gotBlock.Mode,
+			"genfile mode differs: want %s got %s", wantBlock.Mode, gotBlock.Mode)
 		assertAssertsBlock(t, gotBlock.Asserts, wantBlock.Asserts, "genfile asserts")
 	}
 }
@@ -370,6 +408,15 @@ func assertScriptBlocks(t *testing.T, got, want []*hcl.Script) {
 					"commands mismatch")
 			}
 
+			if wantJob.Capture != nil {
+				assert.EqualStrings(t,
+					exprAsStr(t, wantJob.Capture.Expr),
+					exprAsStr(t, gotJob.Capture.Expr),
+					"capture mismatch")
+			} else if gotJob.Capture != nil {
+				t.Fatalf("got job.capture[%s] but expected nil", exprAsStr(t, gotJob.Capture.Expr))
+			}
+
 		}
 	}
 
@@ -389,6 +436,20 @@ func assertTerramateRunBlock(t *testing.T, got, want *hcl.RunConfig) {
 	assert.IsTrue(t, want.CheckGenCode == got.CheckGenCode,
 		"want.Run.CheckGenCode %v != got.Run.CheckGenCode %v",
 		want.CheckGenCode, got.CheckGenCode)
+	assert.IsTrue(t, want.CheckTerraformVersion == got.CheckTerraformVersion,
+		"want.Run.CheckTerraformVersion %v != got.Run.CheckTerraformVersion %v",
+		want.CheckTerraformVersion, got.CheckTerraformVersion)
+	assert.IsTrue(t, want.IsolateDataDir == got.IsolateDataDir,
+		"want.Run.IsolateDataDir %v != got.Run.IsolateDataDir %v",
+		want.IsolateDataDir, got.IsolateDataDir)
+	assert.IsTrue(t, want.Locking == got.Locking,
+		"want.Run.Locking %v != got.Run.Locking %v",
+		want.Locking, got.Locking)
+	assert.IsTrue(t, want.LockTTL == got.LockTTL,
+		"want.Run.LockTTL %v != got.Run.LockTTL %v",
+		want.LockTTL, got.LockTTL)
+
+	AssertDiff(t, got.StackDefaults, want.StackDefaults, "run.stack_defaults mismatch")
 
 	if (want.Env == nil) != (got.Env == nil) {
 		t.Fatalf(
@@ -424,9 +485,37 @@ func assertTerramateCloudBlock(t *testing.T, got, want *hcl.CloudConfig) {
 		return
 	}
 
-	if *want != *got {
-		t.Fatalf("want.Cloud[%+v] != got.Cloud[%+v]", want, got)
+	if want.Organization != got.Organization {
+		t.Fatalf("want.Cloud.Organization[%s] != got.Cloud.Organization[%s]",
+			want.Organization, got.Organization)
+	}
+
+	if (want.Retries == nil) != (got.Retries == nil) ||
+		(want.Retries != nil && *want.Retries != *got.Retries) {
+		t.Fatalf("want.Cloud.Retries[%v] != got.Cloud.Retries[%v]", want.Retries, got.Retries)
+	}
+
+	if (want.Targets == nil) != (got.Targets == nil) ||
+		(want.Targets != nil && *want.Targets != *got.Targets) {
+		t.Fatalf("want.Cloud.Targets[%+v] != got.Cloud.Targets[%+v]", want.Targets, got.Targets)
+	}
+
+	if diff := cmp.Diff(want.Logs, got.Logs); diff != "" {
+		t.Fatalf("want.Cloud.Logs != got.Cloud.Logs: %s", diff)
+	}
+
+	if (want.Metadata == nil) != (got.Metadata == nil) {
+		t.Fatalf("want.Cloud.Metadata[%+v] != got.Cloud.Metadata[%+v]", want.Metadata, got.Metadata)
 	}
+
+	if want.Metadata == nil {
+		return
+	}
+
+	gotHCL := hclFromAttributes(t, got.Metadata.Attributes)
+	wantHCL := hclFromAttributes(t, want.Metadata.Attributes)
+
+	AssertDiff(t, gotHCL, wantHCL)
 }
 
 // hclFromAttributes ensures that we always build the same HCL document
@@ -37,6 +37,9 @@ func FixRangesOnConfig(dir string, cfg hcl.Config) {
 	for i := range cfg.Asserts {
 		cfg.Asserts[i].Range = FixRange(dir, cfg.Asserts[i].Range)
 	}
+	for i := range cfg.Checks {
+		cfg.Checks[i].Range = FixRange(dir, cfg.Checks[i].Range)
+	}
 	for i := range cfg.Generate.Files {
 		cfg.Generate.Files[i].Range = FixRange(dir,
 			cfg.Generate.Files[i].Range)
@@ -88,6 +88,11 @@ func Env(builders ...hclwrite.BlockBuilder) *hclwrite.Block {
 	return Block("env", builders...)
 }
 
+// StackDefaults is a helper for a "stack_defaults" block.
+func StackDefaults(builders ...hclwrite.BlockBuilder) *hclwrite.Block {
+	return Block("stack_defaults", builders...)
+}
+
 // GenerateHCL is a helper for a "generate_hcl" block.
 func GenerateHCL(builders ...hclwrite.BlockBuilder) *hclwrite.Block {
 	return Block("generate_hcl", builders...)
@@ -221,6 +226,11 @@ func Assert(builders ...hclwrite.BlockBuilder) *hclwrite.Block {
 	return Block("assert", builders...)
 }
 
+// Check is a helper for a "check" block.
+func Check(builders ...hclwrite.BlockBuilder) *hclwrite.Block {
+	return Block("check", builders...)
+}
+
 // Trigger is a helper for a "trigger" block.
 func Trigger(builders ...hclwrite.BlockBuilder) *hclwrite.Block {
 	return Block("trigger", builders...)
@@ -137,14 +137,52 @@ func (e *Editor) Command(cmd lsp.ExecuteCommandParams) (interface{}, error) {
 	return cmdResult, err
 }
 
+// Hover sends a hover request to the language server for the given position
+// of the file and returns its result.
+func (e *Editor) Hover(path string, line, character uint32) *lsp.Hover {
+	t := e.t
+	t.Helper()
+	var hoverResult *lsp.Hover
+	_, err := e.call(lsp.MethodTextDocumentHover, lsp.HoverParams{
+		TextDocumentPositionParams: e.positionParams(path, line, character),
+	}, &hoverResult)
+	assert.NoError(t, err, "calling %q", lsp.MethodTextDocumentHover)
+	return hoverResult
+}
+
+// Definition sends a definition request to the language server for the given
+// position of the file and returns the found locations.
+func (e *Editor) Definition(path string, line, character uint32) []lsp.Location {
+	t := e.t
+	t.Helper()
+	var locations []lsp.Location
+	_, err := e.call(lsp.MethodTextDocumentDefinition, lsp.DefinitionParams{
+		TextDocumentPositionParams: e.positionParams(path, line, character),
+	}, &locations)
+	assert.NoError(t, err, "calling %q", lsp.MethodTextDocumentDefinition)
+	return locations
+}
+
+func (e *Editor) positionParams(path string, line, character uint32) lsp.TextDocumentPositionParams {
+	return lsp.TextDocumentPositionParams{
+		TextDocument: lsp.TextDocumentIdentifier{
+			URI: uri.File(filepath.Join(e.sandbox.RootDir(), path)),
+		},
+		Position: lsp.Position{
+			Line:      line,
+			Character: character,
+		},
+	}
+}
+
 // DefaultInitializeResult is the default server response for the initialization
 // request.
 func DefaultInitializeResult() lsp.InitializeResult {
 	return lsp.InitializeResult{
 		Capabilities: lsp.ServerCapabilities{
 			CompletionProvider: &lsp.CompletionOptions{},
-			DefinitionProvider: false,
-			HoverProvider:      false,
+			DefinitionProvider: true,
+			HoverProvider:      true,
 			TextDocumentSync: map[string]interface{}{
 				"change":    float64(1),
 				"openClose": true,
@@ -23,6 +23,7 @@ import (
 	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/madlambda/spells/assert"
@@ -254,11 +255,12 @@ func (s S) Generate() *generate.Report {
 }
 
 // GenerateWith generates code for all stacks inside the provided path.
+// Generated files not generated anymore are deleted.
 func (s S) GenerateWith(root *config.Root, vendorDir project.Path) *generate.Report {
 	t := s.t
 	t.Helper()
 
-	report := generate.Do(root, project.NewPath("/"), 0, vendorDir, nil)
+	report := generate.Do(root, project.NewPath("/"), 0, vendorDir, nil, true, generate.ContextAll)
 	for _, failure := range report.Failures {
 		t.Errorf("Generate unexpected failure: %v", failure)
 	}
@@ -691,10 +693,20 @@ func buildTree(t testing.TB, root *config.Root, environ []string, layout []strin
 				cfg.Stack.WantedBy = parseListSpec(t, name, value)
 			case "watch":
 				cfg.Stack.Watch = parseListSpec(t, name, value)
+			case "ignore_on_change":
+				cfg.Stack.IgnoreOnChange = parseListSpec(t, name, value)
 			case "description":
 				cfg.Stack.Description = value
 			case "tags":
 				cfg.Stack.Tags = parseListSpec(t, name, value)
+			case "timeout":
+				timeout, err := time.ParseDuration(value)
+				assert.NoError(t, err, "parsing stack timeout")
+				cfg.Stack.Timeout = &timeout
+			case "priority":
+				priority, err := strconv.Atoi(value)
+				assert.NoError(t, err, "parsing stack priority")
+				cfg.Stack.Priority = &priority
 			default:
 				t.Fatal("attribute " + parts[0] + " not supported.")
 			}
@@ -42,14 +42,22 @@ const (
 	ErrInvalidModSrc errors.Kind = "invalid module source"
 )
 
+// OCIScheme is the scheme of module sources stored as OCI artifacts.
+const OCIScheme = "oci"
+
 // ParseSource parses the given modsource string.
 // The modsource must be a valid Terraform Git/Github source reference as documented in:
 //
 // - https://www.terraform.io/language/modules/sources
 //
-// Source references that are not Git/Github are not supported.
+// It also supports modules stored as artifacts in an OCI registry, with the
+// oci://<registry>/<repository>:<tag> and oci://<registry>/<repository>@<digest>
+// syntax. Other source references are not supported.
 func ParseSource(modsource string) (Source, error) {
 	switch {
+	case strings.HasPrefix(modsource, OCIScheme+"://"):
+		return parseOCISource(modsource)
+
 	// Github: https://developer.hashicorp.com/terraform/language/modules/sources#github
 	// Bitbucket: https://developer.hashicorp.com/terraform/language/modules/sources#bitbucket
 	// Note: mercurial is deprecated in Bitbucket so we are not supporting it in modules.
@@ -167,3 +175,43 @@ func parseURLSubdir(u *url.URL) string {
 	u.Path = path
 	return subdir
 }
+
+func parseOCISource(modsource string) (Source, error) {
+	ref := strings.TrimPrefix(modsource, OCIScheme+"://")
+
+	var tag, digest string
+	if i := strings.Index(ref, "@"); i != -1 {
+		ref, digest = ref[:i], ref[i+1:]
+		if !strings.HasPrefix(digest, "sha256:") || len(digest) == len("sha256:") {
+			return Source{}, errors.E(ErrInvalidModSrc,
+				"source %q has an invalid digest, only sha256 digests are supported",
+				modsource)
+		}
+	} else if i := strings.LastIndex(ref, ":"); i > strings.LastIndex(ref, "/") {
+		ref, tag = ref[:i], ref[i+1:]
+		if tag == "" {
+			return Source{}, errors.E(ErrInvalidModSrc, "source %q has an empty tag", modsource)
+		}
+	}
+
+	ref, subdir := parseSubdir(ref)
+	host, repository, ok := strings.Cut(ref, "/")
+	if !ok || host == "" || repository == "" {
+		return Source{}, errors.E(ErrInvalidModSrc,
+			"source %q must have the oci://<registry>/<repository>:<tag> format",
+			modsource)
+	}
+
+	version := tag
+	if digest != "" {
+		version = digest
+	}
+	return Source{
+		Raw:        modsource,
+		URL:        OCIScheme + "://" + host + "/" + repository,
+		Path:       path.Join(strings.Replace(host, ":", "-", -1), repository),
+		PathScheme: OCIScheme,
+		Subdir:     subdir,
+		Ref:        version,
+	}, nil
+}
@@ -432,6 +432,75 @@ func TestParseGitSources(t *testing.T) {
 				err: errors.E(tf.ErrUnsupportedModSrc),
 			},
 		},
+		{
+			name:   "oci source with tag",
+			source: "oci://registry.example.com/modules/vpc:1.2.3",
+			want: want{
+				parsed: tf.Source{
+					URL:        "oci://registry.example.com/modules/vpc",
+					Path:       "registry.example.com/modules/vpc",
+					PathScheme: "oci",
+					Ref:        "1.2.3",
+				},
+			},
+		},
+		{
+			name:   "oci source with digest",
+			source: "oci://registry.example.com/modules/vpc@sha256:4d5e6f",
+			want: want{
+				parsed: tf.Source{
+					URL:        "oci://registry.example.com/modules/vpc",
+					Path:       "registry.example.com/modules/vpc",
+					PathScheme: "oci",
+					Ref:        "sha256:4d5e6f",
+				},
+			},
+		},
+		{
+			name:   "oci source with registry port and subdir",
+			source: "oci://localhost:5000/modules/network//vpc:v1",
+			want: want{
+				parsed: tf.Source{
+					URL:        "oci://localhost:5000/modules/network",
+					Path:       "localhost-5000/modules/network",
+					PathScheme: "oci",
+					Subdir:     "/vpc",
+					Ref:        "v1",
+				},
+			},
+		},
+		{
+			name:   "oci source without reference",
+			source: "oci://localhost:5000/modules/vpc",
+			want: want{
+				parsed: tf.Source{
+					URL:        "oci://localhost:5000/modules/vpc",
+					Path:       "localhost-5000/modules/vpc",
+					PathScheme: "oci",
+				},
+			},
+		},
+		{
+			name:   "oci source without repository",
+			source: "oci://registry.example.com:1.2.3",
+			want: want{
+				err: errors.E(tf.ErrInvalidModSrc),
+			},
+		},
+		{
+			name:   "oci source with empty tag",
+			source: "oci://registry.example.com/modules/vpc:",
+			want: want{
+				err: errors.E(tf.ErrInvalidModSrc),
+			},
+		},
+		{
+			name:   "oci source with unsupported digest",
+			source: "oci://registry.example.com/modules/vpc@md5:abc",
+			want: want{
+				err: errors.E(tf.ErrInvalidModSrc),
+			},
+		},
 		{
 			name:   "https is not supported",
 			source: "https://example.com/vpc-module.zip",
@@ -5,6 +5,7 @@ package tf
 
 import (
 	"os"
+	"path/filepath"
 
 	"github.com/rs/zerolog/log"
 	"github.com/terramate-io/hcl/v2/hclparse"
@@ -23,6 +24,19 @@ type Module struct {
 // ErrHCLSyntax represents a HCL syntax error
 const ErrHCLSyntax errors.Kind = "HCL syntax error"
 
+// Extensions of the Terraform and OpenTofu configuration files.
+const (
+	TerraformExt = ".tf"
+	OpenTofuExt  = ".tofu"
+)
+
+// IsTerraformFile tells if the given filename is a Terraform or OpenTofu
+// configuration file, ie. it has a ".tf" or ".tofu" extension.
+func IsTerraformFile(filename string) bool {
+	ext := filepath.Ext(filename)
+	return ext == TerraformExt || ext == OpenTofuExt
+}
+
 // IsLocal tells if module source is a local directory.
 func (m Module) IsLocal() bool {
 	// As specified here: https://www.terraform.io/docs/language/modules/sources.html#local-paths
@@ -127,6 +141,35 @@ func IsStack(path string) (bool, error) {
 	return false, nil
 }
 
+// ParseRequiredVersions parses the required_version attribute of the terraform
+// blocks of the given file. It returns all the constraints found, which must
+// be all satisfied.
+func ParseRequiredVersions(path string) ([]string, error) {
+	p := hclparse.NewParser()
+
+	f, diags := p.ParseHCLFile(path)
+	if diags.HasErrors() {
+		return nil, errors.E(ErrHCLSyntax, diags)
+	}
+
+	body := f.Body.(*hclsyntax.Body)
+
+	var constraints []string
+	for _, block := range body.Blocks {
+		if block.Type != "terraform" {
+			continue
+		}
+		constraint, ok, err := findStringAttr(block, "required_version")
+		if err != nil {
+			return nil, errors.E(err, "parsing terraform.required_version")
+		}
+		if ok {
+			constraints = append(constraints, constraint)
+		}
+	}
+	return constraints, nil
+}
+
 func findStringAttr(block *hclsyntax.Block, attrName string) (string, bool, error) {
 	attrs := ast.AsHCLAttributes(block.Body.Attributes)
 
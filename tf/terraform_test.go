@@ -405,3 +405,87 @@ var end = start
 func init() {
 	zerolog.SetGlobalLevel(zerolog.Disabled)
 }
+
+func TestIsTerraformFile(t *testing.T) {
+	t.Parallel()
+
+	for filename, want := range map[string]bool{
+		"main.tf":             true,
+		"main.tofu":           true,
+		"/path/to/main.tf":    true,
+		"/path/to/main.tofu":  true,
+		"main.tf.json":        false,
+		"main.tofu.tmgen":     false,
+		"terraform.tfvars":    false,
+		"main.tm":             false,
+		"tf":                  false,
+		"/path/to.tf/main.tm": false,
+	} {
+		assert.IsTrue(t, tf.IsTerraformFile(filename) == want,
+			"IsTerraformFile(%q) must be %t", filename, want)
+	}
+}
+
+func TestParseRequiredVersions(t *testing.T) {
+	t.Parallel()
+
+	type testcase struct {
+		name    string
+		content string
+		want    []string
+		wantErr error
+	}
+
+	for _, tc := range []testcase{
+		{
+			name:    "no terraform block",
+			content: `provider "aws" {}`,
+		},
+		{
+			name:    "terraform block without required_version",
+			content: `terraform {}`,
+		},
+		{
+			name: "single constraint",
+			content: `terraform {
+				required_version = "~> 1.5"
+			}`,
+			want: []string{"~> 1.5"},
+		},
+		{
+			name: "multiple terraform blocks",
+			content: `terraform {
+				required_version = ">= 1.0"
+			}
+			terraform {
+				required_version = "< 2.0"
+			}`,
+			want: []string{">= 1.0", "< 2.0"},
+		},
+		{
+			name: "required_version is not a string",
+			content: `terraform {
+				required_version = 1
+			}`,
+			wantErr: errors.E("attribute \"required_version\" is not a string"),
+		},
+		{
+			name:    "invalid syntax",
+			content: `terraform {`,
+			wantErr: errors.E(tf.ErrHCLSyntax),
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			path := test.WriteFile(t, t.TempDir(), "main.tf", tc.content)
+			got, err := tf.ParseRequiredVersions(path)
+			errtest.Assert(t, err, tc.wantErr)
+			if err != nil {
+				return
+			}
+			test.AssertDiff(t, got, tc.want)
+		})
+	}
+}
@@ -157,6 +157,8 @@ func tgReadTerragruntConfigFuncImpl(ctx *tgconfig.ParsingContext, rootdir string
 // Check the original version here: https://github.com/gruntwork-io/terragrunt/blob/b47b57ae0cd2c8644ca5625fceed0a2258b1a763/config/config_helpers.go#L578-L612
 // The important changes are:
 //   - The read file is added to the `mod.DependsOn` slice.
+//   - The overridden functions are bound to the read file, so nested calls resolve
+//     relative paths from it and track the files they read.
 func readTerragruntConfigImpl(ctx *tgconfig.ParsingContext, configPath string, defaultVal *cty.Value, rootdir string, mod *Module) (cty.Value, error) {
 	targetConfig := getCleanedTargetConfigPath(configPath, ctx.TerragruntOptions.TerragruntConfigPath)
 	targetConfigFileExists := util.FileExists(targetConfig)
@@ -170,6 +172,7 @@ func readTerragruntConfigImpl(ctx *tgconfig.ParsingContext, configPath string, d
 
 	// We update the ctx of terragruntOptions to the config being read in.
 	ctx = ctx.WithTerragruntOptions(ctx.TerragruntOptions.Clone(targetConfig))
+	ctx.PredefinedFunctions = predefinedFunctions(ctx, rootdir, mod)
 	cfg, err := tgconfig.ParseConfigFile(ctx.TerragruntOptions, ctx, targetConfig, nil)
 	if err != nil {
 		return cty.NilVal, err
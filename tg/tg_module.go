@@ -99,13 +99,7 @@ func ScanModules(rootdir string, dir project.Path, trackDependencies bool) (Modu
 		}
 
 		// Override the predefined functions to intercept the function calls that process paths.
-		pctx.PredefinedFunctions = make(map[string]function.Function)
-		pctx.PredefinedFunctions[config.FuncNameFindInParentFolders] = tgFindInParentFoldersFuncImpl(pctx, rootdir, mod)
-		pctx.PredefinedFunctions[config.FuncNameReadTerragruntConfig] = tgReadTerragruntConfigFuncImpl(pctx, rootdir, mod)
-		pctx.PredefinedFunctions[config.FuncNameReadTfvarsFile] = wrapStringSliceToStringAsFuncImpl(pctx, rootdir, mod, tgReadTFVarsFileFuncImpl)
-
-		// override Terraform function
-		pctx.PredefinedFunctions["file"] = tgFileFuncImpl(pctx, rootdir, mod)
+		pctx.PredefinedFunctions = predefinedFunctions(pctx, rootdir, mod)
 
 		// Here we parse the Terragrunt file which calls into our overrided functions.
 		// After this returns, the module's DependsOn will be populated.
@@ -296,6 +290,20 @@ func newTerragruntOptions(dir string) *options.TerragruntOptions {
 	return opts
 }
 
+// predefinedFunctions returns the functions overriding the Terragrunt (and Terraform)
+// functions that process paths, so the files they read are tracked in mod.DependsOn.
+// The functions resolve relative paths from the config file of the given pctx.
+func predefinedFunctions(pctx *config.ParsingContext, rootdir string, mod *Module) map[string]function.Function {
+	return map[string]function.Function{
+		config.FuncNameFindInParentFolders:  tgFindInParentFoldersFuncImpl(pctx, rootdir, mod),
+		config.FuncNameReadTerragruntConfig: tgReadTerragruntConfigFuncImpl(pctx, rootdir, mod),
+		config.FuncNameReadTfvarsFile:       wrapStringSliceToStringAsFuncImpl(pctx, rootdir, mod, tgReadTFVarsFileFuncImpl),
+
+		// override Terraform function
+		"file": tgFileFuncImpl(pctx, rootdir, mod),
+	}
+}
+
 func warnDependencyOutsideProject(mod *Module, dep string, field string) {
 	printer.Stderr.WarnWithDetails(fmt.Sprintf("Dependency outside of Terramate project detected in `%s` configuration. Ignoring.", field),
 		errors.E("The Terragrunt module %s depends on the module at %s, which is located outside of the your current"+
@@ -424,6 +424,80 @@ func TestTerragruntScanModules(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "module reading config file that reads other config files",
+			layout: []string{
+				`f:some/dir/terragrunt.hcl:` + Doc(
+					Block("terraform",
+						Str("source", "https://some.etc/prj"),
+					),
+					Block("locals",
+						Expr("common", `read_terragrunt_config(find_in_parent_folders("common.hcl"))`),
+					),
+				).String(),
+				`f:some/common.hcl:` + Block("locals",
+					Expr("account", `read_terragrunt_config("account.hcl")`),
+					Expr("env", `read_terragrunt_config(find_in_parent_folders("env.hcl"))`),
+				).String(),
+				`f:some/account.hcl:`,
+				`f:env.hcl:`,
+			},
+			want: want{
+				modules: tg.Modules{
+					{
+						Path:       project.NewPath("/some/dir"),
+						Source:     "https://some.etc/prj",
+						ConfigFile: project.NewPath("/some/dir/terragrunt.hcl"),
+						DependsOn: project.Paths{
+							project.NewPath("/env.hcl"),
+							project.NewPath("/some/account.hcl"),
+							project.NewPath("/some/common.hcl"),
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "module with remote source exposed from included envcommon file",
+			layout: []string{
+				`f:live/qa/mysql/terragrunt.hcl:` + Doc(
+					Block("include", Labels("root"),
+						Expr("path", `find_in_parent_folders()`),
+					),
+					Block("include", Labels("envcommon"),
+						Expr("path", `"${dirname(find_in_parent_folders())}/_envcommon/mysql.hcl"`),
+						Bool("expose", true),
+					),
+					Block("terraform",
+						Expr("source", `"${include.envcommon.locals.base_source_url}?ref=v0.8.0"`),
+					),
+				).String(),
+				`f:live/qa/env.hcl:` + Block("locals",
+					Str("environment", "qa"),
+				).String(),
+				`f:terragrunt.hcl:` + Block("locals",
+					Expr("env_vars", `read_terragrunt_config(find_in_parent_folders("env.hcl"))`),
+				).String(),
+				`f:_envcommon/mysql.hcl:` + Block("locals",
+					Expr("env_vars", `read_terragrunt_config(find_in_parent_folders("env.hcl"))`),
+					Str("base_source_url", "git::git@github.com:acme/modules.git//mysql"),
+				).String(),
+			},
+			want: want{
+				modules: tg.Modules{
+					{
+						Path:       project.NewPath("/live/qa/mysql"),
+						Source:     "git::git@github.com:acme/modules.git//mysql?ref=v0.8.0",
+						ConfigFile: project.NewPath("/live/qa/mysql/terragrunt.hcl"),
+						DependsOn: project.Paths{
+							project.NewPath("/_envcommon/mysql.hcl"),
+							project.NewPath("/live/qa/env.hcl"),
+							project.NewPath("/terragrunt.hcl"),
+						},
+					},
+				},
+			},
+		},
 		{
 			name: "module reading tfvars",
 			layout: []string{